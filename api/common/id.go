@@ -254,6 +254,11 @@ var ContextNodeRolesKey = contextNodeRolesKey{}
 
 type contextNodeRolesKey struct{}
 
+// ContextDynamicFlagsKey is a context key to store the flags which can be changed at runtime.
+var ContextDynamicFlagsKey = contextDynamicFlagsKey{}
+
+type contextDynamicFlagsKey struct{}
+
 // ContextNodeSelectorKey is a context key to store the node selector.
 var ContextNodeSelectorKey = contextNodeSelectorKey{}
 
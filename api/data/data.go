@@ -21,6 +21,7 @@ package data
 import (
 	"google.golang.org/protobuf/proto"
 
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
 	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
 	propertyv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/property/v1"
 	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
@@ -30,15 +31,20 @@ import (
 var (
 	// TopicMap is the map of topic name to topic.
 	TopicMap = map[string]bus.Topic{
-		TopicStreamWrite.String():    TopicStreamWrite,
-		TopicStreamQuery.String():    TopicStreamQuery,
-		TopicMeasureWrite.String():   TopicMeasureWrite,
-		TopicMeasureQuery.String():   TopicMeasureQuery,
-		TopicTopNQuery.String():      TopicTopNQuery,
-		TopicPropertyDelete.String(): TopicPropertyDelete,
-		TopicPropertyQuery.String():  TopicPropertyQuery,
-		TopicPropertyUpdate.String(): TopicPropertyUpdate,
-		TopicPropertyRepair.String(): TopicPropertyRepair,
+		TopicStreamWrite.String():         TopicStreamWrite,
+		TopicStreamQuery.String():         TopicStreamQuery,
+		TopicMeasureWrite.String():        TopicMeasureWrite,
+		TopicMeasureQuery.String():        TopicMeasureQuery,
+		TopicTopNQuery.String():           TopicTopNQuery,
+		TopicPropertyDelete.String():      TopicPropertyDelete,
+		TopicPropertyQuery.String():       TopicPropertyQuery,
+		TopicPropertyUpdate.String():      TopicPropertyUpdate,
+		TopicPropertyRepair.String():      TopicPropertyRepair,
+		TopicStreamSeriesLookup.String():  TopicStreamSeriesLookup,
+		TopicMeasureSeriesLookup.String(): TopicMeasureSeriesLookup,
+		TopicMeasureDeleteSeries.String(): TopicMeasureDeleteSeries,
+		TopicStreamDeleteRange.String():   TopicStreamDeleteRange,
+		TopicMeasureDeleteRange.String():  TopicMeasureDeleteRange,
 	}
 
 	// TopicRequestMap is the map of topic name to request message.
@@ -71,6 +77,21 @@ var (
 		TopicPropertyRepair: func() proto.Message {
 			return &propertyv1.InternalRepairRequest{}
 		},
+		TopicStreamSeriesLookup: func() proto.Message {
+			return &databasev1.SeriesServiceLookupRequest{}
+		},
+		TopicMeasureSeriesLookup: func() proto.Message {
+			return &databasev1.SeriesServiceLookupRequest{}
+		},
+		TopicMeasureDeleteSeries: func() proto.Message {
+			return &measurev1.InternalDeleteSeriesRequest{}
+		},
+		TopicStreamDeleteRange: func() proto.Message {
+			return &databasev1.InternalDeleteRangeRequest{}
+		},
+		TopicMeasureDeleteRange: func() proto.Message {
+			return &databasev1.InternalDeleteRangeRequest{}
+		},
 	}
 
 	// TopicResponseMap is the map of topic name to response message.
@@ -97,6 +118,21 @@ var (
 		TopicPropertyRepair: func() proto.Message {
 			return &propertyv1.InternalRepairResponse{}
 		},
+		TopicStreamSeriesLookup: func() proto.Message {
+			return &databasev1.SeriesServiceLookupResponse{}
+		},
+		TopicMeasureSeriesLookup: func() proto.Message {
+			return &databasev1.SeriesServiceLookupResponse{}
+		},
+		TopicMeasureDeleteSeries: func() proto.Message {
+			return &measurev1.DeleteSeriesResponse{}
+		},
+		TopicStreamDeleteRange: func() proto.Message {
+			return &databasev1.DeletionServiceDeleteRangeResponse{}
+		},
+		TopicMeasureDeleteRange: func() proto.Message {
+			return &databasev1.DeletionServiceDeleteRangeResponse{}
+		},
 	}
 
 	// TopicCommon is the common topic for data transmission.
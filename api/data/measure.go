@@ -57,3 +57,30 @@ var MeasureDeleteExpiredSegmentsKindVersion = common.KindVersion{
 
 // TopicMeasureDeleteExpiredSegments is the measure delete topic.
 var TopicMeasureDeleteExpiredSegments = bus.BiTopic(MeasureDeleteExpiredSegmentsKindVersion.String())
+
+// MeasureSeriesLookupKindVersion is the version tag of measure series lookup kind.
+var MeasureSeriesLookupKindVersion = common.KindVersion{
+	Version: "v1",
+	Kind:    "measure-series-lookup",
+}
+
+// TopicMeasureSeriesLookup is the topic to look up the series of the measures.
+var TopicMeasureSeriesLookup = bus.BiTopic(MeasureSeriesLookupKindVersion.String())
+
+// MeasureDeleteSeriesKindVersion is the version tag of measure series deletion kind.
+var MeasureDeleteSeriesKindVersion = common.KindVersion{
+	Version: "v1",
+	Kind:    "measure-delete-series",
+}
+
+// TopicMeasureDeleteSeries is the topic to tombstone the series of the measures.
+var TopicMeasureDeleteSeries = bus.BiTopic(MeasureDeleteSeriesKindVersion.String())
+
+// MeasureDeleteRangeKindVersion is the version tag of measure range deletion kind.
+var MeasureDeleteRangeKindVersion = common.KindVersion{
+	Version: "v1",
+	Kind:    "measure-delete-range",
+}
+
+// TopicMeasureDeleteRange is the topic to tombstone a time range of the measure groups.
+var TopicMeasureDeleteRange = bus.BiTopic(MeasureDeleteRangeKindVersion.String())
@@ -0,0 +1,59 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package data
+
+import (
+	"github.com/apache/skywalking-banyandb/api/common"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+)
+
+// ReplicaDirKindVersion is the version tag of replica directory kind.
+var ReplicaDirKindVersion = common.KindVersion{
+	Version: "v1",
+	Kind:    "replica-dir",
+}
+
+// TopicReplicaDir is the topic to find the staging directory of a replicated part.
+var TopicReplicaDir = bus.BiTopic(ReplicaDirKindVersion.String())
+
+// ReplicaStatusKindVersion is the version tag of replica status kind.
+var ReplicaStatusKindVersion = common.KindVersion{
+	Version: "v1",
+	Kind:    "replica-status",
+}
+
+// TopicReplicaStatus is the topic to report the applied parts and the staged files of a replicated shard.
+var TopicReplicaStatus = bus.BiTopic(ReplicaStatusKindVersion.String())
+
+// ReplicaSeriesKindVersion is the version tag of replica series kind.
+var ReplicaSeriesKindVersion = common.KindVersion{
+	Version: "v1",
+	Kind:    "replica-series",
+}
+
+// TopicReplicaSeries is the topic to insert the replicated series into the series index.
+var TopicReplicaSeries = bus.BiTopic(ReplicaSeriesKindVersion.String())
+
+// ReplicaCommitKindVersion is the version tag of replica commit kind.
+var ReplicaCommitKindVersion = common.KindVersion{
+	Version: "v1",
+	Kind:    "replica-commit",
+}
+
+// TopicReplicaCommit is the topic to install the staged parts into a replicated shard.
+var TopicReplicaCommit = bus.BiTopic(ReplicaCommitKindVersion.String())
@@ -0,0 +1,41 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package data
+
+import (
+	"github.com/apache/skywalking-banyandb/api/common"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+)
+
+// RetentionPreviewKindVersion is the version tag of retention preview kind.
+var RetentionPreviewKindVersion = common.KindVersion{
+	Version: "v1",
+	Kind:    "retention-preview",
+}
+
+// TopicRetentionPreview is the topic to report the segments which the next retention pass deletes.
+var TopicRetentionPreview = bus.BiTopic(RetentionPreviewKindVersion.String())
+
+// RetentionTriggerKindVersion is the version tag of retention trigger kind.
+var RetentionTriggerKindVersion = common.KindVersion{
+	Version: "v1",
+	Kind:    "retention-trigger",
+}
+
+// TopicRetentionTrigger is the topic to run a retention pass immediately.
+var TopicRetentionTrigger = bus.BiTopic(RetentionTriggerKindVersion.String())
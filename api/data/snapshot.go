@@ -30,3 +30,12 @@ var SnapshotKindVersion = common.KindVersion{
 
 // TopicSnapshot is the snapshot topic.
 var TopicSnapshot = bus.BiTopic(SnapshotKindVersion.String())
+
+// SnapshotDirKindVersion is the version tag of snapshot directory kind.
+var SnapshotDirKindVersion = common.KindVersion{
+	Version: "v1",
+	Kind:    "snapshot-dir",
+}
+
+// TopicSnapshotDir is the topic to find the directory of a snapshot.
+var TopicSnapshotDir = bus.BiTopic(SnapshotDirKindVersion.String())
@@ -18,7 +18,11 @@
 package data
 
 import (
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+
 	"github.com/apache/skywalking-banyandb/api/common"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
 	"github.com/apache/skywalking-banyandb/pkg/bus"
 )
 
@@ -48,3 +52,46 @@ var StreamDeleteExpiredSegmentsKindVersion = common.KindVersion{
 
 // TopicDeleteExpiredStreamSegments is the delete stream segments topic.
 var TopicDeleteExpiredStreamSegments = bus.BiTopic(StreamDeleteExpiredSegmentsKindVersion.String())
+
+// StreamSeriesLookupKindVersion is the version tag of stream series lookup kind.
+var StreamSeriesLookupKindVersion = common.KindVersion{
+	Version: "v1",
+	Kind:    "stream-series-lookup",
+}
+
+// TopicStreamSeriesLookup is the topic to look up the series of the streams.
+var TopicStreamSeriesLookup = bus.BiTopic(StreamSeriesLookupKindVersion.String())
+
+// StreamDeleteRangeKindVersion is the version tag of stream range deletion kind.
+var StreamDeleteRangeKindVersion = common.KindVersion{
+	Version: "v1",
+	Kind:    "stream-delete-range",
+}
+
+// TopicStreamDeleteRange is the topic to tombstone a time range of the stream groups.
+var TopicStreamDeleteRange = bus.BiTopic(StreamDeleteRangeKindVersion.String())
+
+// downgradeStreamWrite splits the batches into the elements for the nodes older than the batches.
+// The chunks can't be reassembled here, so they are rejected by the nodes older than the chunks.
+func downgradeStreamWrite(req proto.Message, version uint32) ([]proto.Message, error) {
+	iwr, ok := req.(*streamv1.InternalWriteRequest)
+	if !ok {
+		return nil, errors.Errorf("invalid stream write request %T", req)
+	}
+	if batch := iwr.GetBatch(); batch != nil && version < ProtocolVersionStreamWriteBatch {
+		reqs := make([]proto.Message, 0, len(batch.GetRequests()))
+		for _, r := range batch.GetRequests() {
+			rr, err := downgradeStreamWrite(r, version)
+			if err != nil {
+				return nil, err
+			}
+			reqs = append(reqs, rr...)
+		}
+		return reqs, nil
+	}
+	if iwr.GetChunk() != nil && version < ProtocolVersionStreamElementChunk {
+		return nil, errors.Wrapf(ErrUnsupportedVersion, "the element chunks require version %d, the node supports %d",
+			ProtocolVersionStreamElementChunk, version)
+	}
+	return []proto.Message{req}, nil
+}
@@ -0,0 +1,60 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package data
+
+import (
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+)
+
+// The protocol versions of the internal messages. A node supports the messages of its version and the older ones.
+// A new version is added once a message gains a variant the older nodes can't handle,
+// e.g. they would unmarshal it without an error but drop it.
+const (
+	// ProtocolVersionBase is the version of the nodes which don't negotiate the version.
+	ProtocolVersionBase uint32 = 1
+	// ProtocolVersionStreamElementChunk adds the chunks of the oversized stream elements.
+	ProtocolVersionStreamElementChunk uint32 = 2
+	// ProtocolVersionStreamWriteBatch adds the stream elements grouped by the shards.
+	ProtocolVersionStreamWriteBatch uint32 = 3
+	// ProtocolVersion is the latest version supported by the node.
+	ProtocolVersion = ProtocolVersionStreamWriteBatch
+)
+
+// ErrUnsupportedVersion indicates the message can't be encoded in the protocol version of the peer.
+var ErrUnsupportedVersion = errors.New("unsupported protocol version")
+
+// TopicDowngradeMap is the map of topic to the function converting a request into
+// the ones of an older protocol version. The topics absent from the map don't have any newer variants.
+var TopicDowngradeMap = map[bus.Topic]func(req proto.Message, version uint32) ([]proto.Message, error){
+	TopicStreamWrite: downgradeStreamWrite,
+}
+
+// NegotiateVersion returns the protocol version supported by both the node and the peer.
+// The peer's version is 0 if it's older than the versioning.
+func NegotiateVersion(peer uint32) uint32 {
+	if peer < ProtocolVersionBase {
+		return ProtocolVersionBase
+	}
+	if peer > ProtocolVersion {
+		return ProtocolVersion
+	}
+	return peer
+}
@@ -20,9 +20,12 @@ package validate
 
 import (
 	"errors"
+	"fmt"
 
 	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
 	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
 )
 
 // Group validates the provided Group object.
@@ -36,6 +39,10 @@ func Group(group *commonv1.Group) error {
 	if group.Catalog == commonv1.Catalog_CATALOG_UNSPECIFIED {
 		return errors.New("catalog is unspecified")
 	}
+	if group.Catalog != commonv1.Catalog_CATALOG_STREAM &&
+		group.GetResourceOpts().GetElementIdSource() == commonv1.ElementIDSource_ELEMENT_ID_SOURCE_SERVER {
+		return errors.New("server-generated element IDs are only available for the stream groups")
+	}
 	if group.Catalog == commonv1.Catalog_CATALOG_PROPERTY {
 		if group.ResourceOpts == nil {
 			return errors.New("resourceOpts is nil")
@@ -93,6 +100,23 @@ func GroupForStreamOrMeasure(group *commonv1.Group) error {
 	if group.ResourceOpts.Ttl.Unit == commonv1.IntervalRule_UNIT_UNSPECIFIED {
 		return errors.New("group ttl unit is unspecified")
 	}
+	return shardSplits(group.ResourceOpts)
+}
+
+func shardSplits(opts *commonv1.ResourceOpts) error {
+	split := make(map[uint32]struct{}, len(opts.GetShardSplits()))
+	for _, ss := range opts.GetShardSplits() {
+		if ss.GetShardId() >= opts.GetShardNum() {
+			return fmt.Errorf("split shard %d is out of the %d shards", ss.GetShardId(), opts.GetShardNum())
+		}
+		if ss.GetNode() == "" {
+			return fmt.Errorf("the node of split shard %d is empty", ss.GetShardId())
+		}
+		if _, ok := split[ss.GetShardId()]; ok {
+			return fmt.Errorf("shard %d is split more than once", ss.GetShardId())
+		}
+		split[ss.GetShardId()] = struct{}{}
+	}
 	return nil
 }
 
@@ -120,9 +144,33 @@ func Stream(stream *databasev1.Stream) error {
 	if len(stream.Entity.TagNames) == 0 {
 		return errors.New("stream entity tag names is empty")
 	}
+	if err := shardingKey(stream.ShardingKey, stream.TagFamilies); err != nil {
+		return err
+	}
 	return tagFamily(stream.TagFamilies)
 }
 
+func shardingKey(key *databasev1.ShardingKey, families []*databasev1.TagFamilySpec) error {
+	for _, name := range key.GetTagNames() {
+		var found bool
+		for _, f := range families {
+			for _, t := range f.GetTags() {
+				if t.GetName() != name {
+					continue
+				}
+				if t.GetType() != databasev1.TagType_TAG_TYPE_STRING && t.GetType() != databasev1.TagType_TAG_TYPE_INT {
+					return errors.New("the tags of the sharding key should be string or int")
+				}
+				found = true
+			}
+		}
+		if !found {
+			return errors.New("the tag of the sharding key is not found: " + name)
+		}
+	}
+	return nil
+}
+
 // Measure validates the provided Measure object.
 // It checks for nil values, empty strings, and unspecified enum values.
 func Measure(measure *databasev1.Measure) error {
@@ -164,6 +212,11 @@ func Measure(measure *databasev1.Measure) error {
 	if measure.IndexMode && len(measure.Fields) > 0 {
 		return errors.New("index mode is enabled, but fields are not empty")
 	}
+	for i := range measure.TagFamilies {
+		if measure.TagFamilies[i].Layout == databasev1.TagFamilyLayout_TAG_FAMILY_LAYOUT_PAYLOAD {
+			return errors.New("the payload layout of tag families is only supported by streams")
+		}
+	}
 	return tagFamily(measure.TagFamilies)
 }
 
@@ -179,11 +232,64 @@ func tagFamily(tagFamilies []*databasev1.TagFamilySpec) error {
 			if tagFamilies[i].Tags[j].Type == databasev1.TagType_TAG_TYPE_UNSPECIFIED {
 				return errors.New("tag type is unspecified")
 			}
+			if err := tagDefault(tagFamilies[i].Tags[j]); err != nil {
+				return fmt.Errorf("tag %s: %w", tagFamilies[i].Tags[j].Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func tagDefault(tag *databasev1.TagSpec) error {
+	d := tag.GetDefaultValue()
+	if d == nil {
+		return nil
+	}
+	switch d.GetSource() {
+	case databasev1.TagDefault_SOURCE_UNSPECIFIED:
+		if d.GetValue() == nil || d.GetValue().GetValue() == nil {
+			return errors.New("default value is empty")
 		}
+		if !tagValueMatches(tag.Type, d.GetValue()) {
+			return fmt.Errorf("default value doesn't match the tag type %s", tag.Type)
+		}
+		return nil
+	case databasev1.TagDefault_SOURCE_NODE_ID, databasev1.TagDefault_SOURCE_PEER_ADDRESS:
+		if tag.Type != databasev1.TagType_TAG_TYPE_STRING {
+			return fmt.Errorf("default source %s only applies to the string tags", d.GetSource())
+		}
+	case databasev1.TagDefault_SOURCE_RECEIVE_TIME:
+		if tag.Type != databasev1.TagType_TAG_TYPE_INT && tag.Type != databasev1.TagType_TAG_TYPE_TIMESTAMP {
+			return fmt.Errorf("default source %s only applies to the int or timestamp tags", d.GetSource())
+		}
+	default:
+		return fmt.Errorf("default source %s is unknown", d.GetSource())
+	}
+	if d.GetValue() != nil {
+		return errors.New("default value and default source are mutually exclusive")
 	}
 	return nil
 }
 
+func tagValueMatches(t databasev1.TagType, v *modelv1.TagValue) bool {
+	switch v.GetValue().(type) {
+	case *modelv1.TagValue_Str:
+		return t == databasev1.TagType_TAG_TYPE_STRING
+	case *modelv1.TagValue_Int:
+		return t == databasev1.TagType_TAG_TYPE_INT
+	case *modelv1.TagValue_StrArray:
+		return t == databasev1.TagType_TAG_TYPE_STRING_ARRAY
+	case *modelv1.TagValue_IntArray:
+		return t == databasev1.TagType_TAG_TYPE_INT_ARRAY
+	case *modelv1.TagValue_BinaryData:
+		return t == databasev1.TagType_TAG_TYPE_DATA_BINARY
+	case *modelv1.TagValue_Timestamp:
+		return t == databasev1.TagType_TAG_TYPE_TIMESTAMP
+	default:
+		return false
+	}
+}
+
 // IndexRule validates the provided IndexRule object.
 // It checks for nil values, empty strings, and unspecified enum values.
 func IndexRule(indexRule *databasev1.IndexRule) error {
@@ -271,5 +377,52 @@ func TopNAggregation(topNAggregation *databasev1.TopNAggregation) error {
 	if topNAggregation.FieldName == "" {
 		return errors.New("topNAggregation fieldName is empty")
 	}
+	if err := nonNegativeDuration(topNAggregation.AllowedLateness); err != nil {
+		return errors.New("topNAggregation allowedLateness is invalid")
+	}
+	if err := nonNegativeDuration(topNAggregation.WindowOffset); err != nil {
+		return errors.New("topNAggregation windowOffset is invalid")
+	}
+	if topNAggregation.FieldExpression != nil {
+		return fieldExpression(topNAggregation.FieldExpression)
+	}
+	return nil
+}
+
+func nonNegativeDuration(s string) error {
+	if s == "" {
+		return nil
+	}
+	d, err := timestamp.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	if d < 0 {
+		return errors.New("negative duration")
+	}
+	return nil
+}
+
+func fieldExpression(expr *databasev1.FieldExpression) error {
+	if expr == nil {
+		return errors.New("the operand of the field expression is nil")
+	}
+	switch e := expr.GetExpr().(type) {
+	case *databasev1.FieldExpression_FieldName:
+		if e.FieldName == "" {
+			return errors.New("the field name of the field expression is empty")
+		}
+	case *databasev1.FieldExpression_Constant:
+	case *databasev1.FieldExpression_Binary:
+		if e.Binary.GetOp() == databasev1.FieldExpression_OP_UNSPECIFIED {
+			return errors.New("the op of the field expression is unspecified")
+		}
+		if err := fieldExpression(e.Binary.GetLeft()); err != nil {
+			return err
+		}
+		return fieldExpression(e.Binary.GetRight())
+	default:
+		return errors.New("the field expression is empty")
+	}
 	return nil
 }
@@ -27,6 +27,7 @@ import (
 	"os/signal"
 	"path"
 	"path/filepath"
+	"sync"
 	"syscall"
 	"time"
 
@@ -55,6 +56,7 @@ type backupOptions struct {
 	measureRoot  string
 	propertyRoot string
 	dest         string
+	concurrency  int
 	enableTLS    bool
 	insecure     bool
 }
@@ -126,6 +128,12 @@ func NewBackupCommand() *cobra.Command {
 	cmd.Flags().StringVar(&backupOpts.fsConfig.S3ProfileName, "s3-profile", "", "S3 profile name")
 	cmd.Flags().StringVar(&backupOpts.fsConfig.S3ChecksumAlgorithm, "s3-checksum-algorithm", "", "S3 checksum algorithm")
 	cmd.Flags().StringVar(&backupOpts.fsConfig.S3StorageClass, "s3-storage-class", "", "S3 upload storage class")
+	cmd.Flags().IntVar(&backupOpts.concurrency, "upload-concurrency", 4, "the number of the files uploaded in parallel")
+	cmd.Flags().Int64Var(&backupOpts.fsConfig.UploadRateLimit, "upload-rate-limit", 0, "the max bytes uploaded per second, 0 means no limit")
+	cmd.Flags().Int64Var(&backupOpts.fsConfig.S3PartSize, "s3-part-size", 0,
+		"the size in bytes of the parts of the S3 multipart uploads, which the larger files are uploaded in. 0 means 64MiB")
+	cmd.Flags().IntVar(&backupOpts.fsConfig.S3PartConcurrency, "s3-part-concurrency", 0,
+		"the number of the parts of a file uploaded to S3 in parallel. 0 means 4")
 	return cmd
 }
 
@@ -133,6 +141,9 @@ func backupAction(options backupOptions) error {
 	if options.dest == "" {
 		return errors.New("dest is required")
 	}
+	if options.concurrency < 1 {
+		return errors.New("upload-concurrency must be greater than 0")
+	}
 	fs, err := newFS(options.dest, &options.fsConfig)
 	if err != nil {
 		return err
@@ -153,7 +164,7 @@ func backupAction(options backupOptions) error {
 			logger.Warningf("Failed to get snapshot directory for %s: %v", snp.Name, err)
 			continue
 		}
-		multierr.AppendInto(&err, backupSnapshot(fs, snapshotDir, snapshot.CatalogName(snp.Catalog), timeDir))
+		multierr.AppendInto(&err, backupSnapshot(fs, snapshotDir, snapshot.CatalogName(snp.Catalog), timeDir, options.concurrency))
 	}
 	return err
 }
@@ -184,7 +195,8 @@ func getTimeDir(style string) string {
 	}
 }
 
-func backupSnapshot(fs remote.FS, snapshotDir, catalog, timeDir string) error {
+// backupSnapshot uploads the files of the snapshot missing in the remote storage, up to concurrency of which are uploaded in parallel.
+func backupSnapshot(fs remote.FS, snapshotDir, catalog, timeDir string, concurrency int) error {
 	localFiles, err := getAllFiles(snapshotDir)
 	if err != nil {
 		return err
@@ -197,13 +209,38 @@ func backupSnapshot(fs remote.FS, snapshotDir, catalog, timeDir string) error {
 	if err != nil {
 		return err
 	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
 	for _, relPath := range localFiles {
 		remotePath := path.Join(timeDir, catalog, relPath)
-		if !contains(remoteFiles, remotePath) {
-			if err := uploadFile(ctx, fs, snapshotDir, relPath, remotePath); err != nil {
-				return err
-			}
+		if contains(remoteFiles, remotePath) {
+			continue
+		}
+		sem <- struct{}{}
+		if ctx.Err() != nil {
+			<-sem
+			break
 		}
+		wg.Add(1)
+		go func(relPath, remotePath string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if errUpload := uploadFile(ctx, fs, snapshotDir, relPath, remotePath); errUpload != nil {
+				mu.Lock()
+				err = multierr.Append(err, fmt.Errorf("upload %s: %w", relPath, errUpload))
+				mu.Unlock()
+				cancel()
+			}
+		}(relPath, remotePath)
+	}
+	wg.Wait()
+	if err != nil {
+		return err
 	}
 
 	deleteOrphanedFiles(ctx, fs, localFiles, remoteFiles, timeDir, catalog)
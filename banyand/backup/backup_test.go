@@ -19,10 +19,14 @@ package backup
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"os"
 	"path"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
 	"testing"
 	"time"
 
@@ -137,6 +141,7 @@ func TestGetAllFiles(t *testing.T) {
 type mockFS struct {
 	uploaded []string
 	deleted  []string
+	mu       sync.Mutex
 }
 
 func (m *mockFS) List(_ context.Context, prefix string) ([]string, error) {
@@ -144,6 +149,8 @@ func (m *mockFS) List(_ context.Context, prefix string) ([]string, error) {
 }
 
 func (m *mockFS) Upload(_ context.Context, p string, _ io.Reader) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.uploaded = append(m.uploaded, p)
 	return nil
 }
@@ -162,7 +169,7 @@ func TestBackupSnapshot(t *testing.T) {
 	os.WriteFile(filepath.Join(tmpDir, "newfile.txt"), nil, 0o600)
 
 	m := &mockFS{}
-	err := backupSnapshot(m, tmpDir, "test-snapshot", "daily")
+	err := backupSnapshot(m, tmpDir, "test-snapshot", "daily", 1)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -178,6 +185,25 @@ func TestBackupSnapshot(t *testing.T) {
 	}
 }
 
+func TestBackupSnapshotConcurrently(t *testing.T) {
+	tmpDir := t.TempDir()
+	var want []string
+	for i := 0; i < 10; i++ {
+		name := fmt.Sprintf("file%d.txt", i)
+		os.WriteFile(filepath.Join(tmpDir, name), nil, 0o600)
+		want = append(want, "daily/test-snapshot/"+name)
+	}
+
+	m := &mockFS{}
+	if err := backupSnapshot(m, tmpDir, "test-snapshot", "daily", 3); err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(m.uploaded)
+	if !reflect.DeepEqual(m.uploaded, want) {
+		t.Errorf("uploaded = %v, want %v", m.uploaded, want)
+	}
+}
+
 func TestContains(t *testing.T) {
 	tests := []struct {
 		s     string
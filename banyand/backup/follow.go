@@ -0,0 +1,530 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/spf13/cobra"
+	"go.uber.org/multierr"
+	"google.golang.org/grpc"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/banyand/backup/snapshot"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+)
+
+const followingSuffix = ".following"
+
+var errPromoted = errors.New("the standby is promoted")
+
+type followOptions struct {
+	primaryAddr   string
+	cert          string
+	streamRoot    string
+	measureRoot   string
+	httpAddr      string
+	streamGroups  []string
+	measureGroups []string
+	interval      time.Duration
+	enableTLS     bool
+	insecure      bool
+}
+
+func newFollowCommand() *cobra.Command {
+	var opts followOptions
+	cmd := &cobra.Command{
+		Use:   "follow",
+		Short: "Follow a primary data node by pulling its newly sealed parts continuously",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if opts.primaryAddr == "" {
+				return errors.New("primary-addr is required")
+			}
+			if len(opts.streamGroups) == 0 && len(opts.measureGroups) == 0 {
+				return errors.New("at least one of stream-groups or measure-groups is required")
+			}
+			f := newFollower(opts)
+			return f.run()
+		},
+	}
+	cmd.Flags().StringVar(&opts.primaryAddr, "primary-addr", "", "gRPC address of the primary data node")
+	cmd.Flags().BoolVar(&opts.enableTLS, "enable-tls", false, "Enable TLS for gRPC connection")
+	cmd.Flags().BoolVar(&opts.insecure, "insecure", false, "Skip server certificate verification")
+	cmd.Flags().StringVar(&opts.cert, "cert", "", "Path to the gRPC server certificate")
+	cmd.Flags().StringSliceVar(&opts.streamGroups, "stream-groups", nil, "Stream groups to follow")
+	cmd.Flags().StringSliceVar(&opts.measureGroups, "measure-groups", nil, "Measure groups to follow")
+	cmd.Flags().StringVar(&opts.streamRoot, "stream-root-path", "/tmp", "Root directory for stream catalog")
+	cmd.Flags().StringVar(&opts.measureRoot, "measure-root-path", "/tmp", "Root directory for measure catalog")
+	cmd.Flags().DurationVar(&opts.interval, "interval", 30*time.Second, "Interval of pulling the snapshots from the primary")
+	cmd.Flags().StringVar(&opts.httpAddr, "http-addr", ":17915", "HTTP address serving the metrics, status and promote API")
+	return cmd
+}
+
+type catalogStatus struct {
+	SyncedAt   time.Time `json:"synced_at"`
+	Snapshot   string    `json:"snapshot"`
+	Error      string    `json:"error,omitempty"`
+	LagSeconds float64   `json:"lag_seconds"`
+}
+
+type followStatus struct {
+	Catalogs map[string]*catalogStatus `json:"catalogs"`
+	Primary  string                    `json:"primary"`
+	Promoted bool                      `json:"promoted"`
+}
+
+type followMetrics struct {
+	pulledFiles  *prometheus.CounterVec
+	pulledBytes  *prometheus.CounterVec
+	removedFiles *prometheus.CounterVec
+	failures     *prometheus.CounterVec
+}
+
+type follower struct {
+	start      time.Time
+	registry   *prometheus.Registry
+	metrics    *followMetrics
+	l          *logger.Logger
+	status     map[commonv1.Catalog]*catalogStatus
+	stopCh     chan struct{}
+	stoppedCh  chan struct{}
+	promotedCh chan struct{}
+	opts       followOptions
+	mu         sync.Mutex
+	promoted   bool
+}
+
+func newFollower(opts followOptions) *follower {
+	f := &follower{
+		opts:       opts,
+		start:      time.Now(),
+		l:          logger.GetLogger().Named("follower"),
+		status:     make(map[commonv1.Catalog]*catalogStatus),
+		stopCh:     make(chan struct{}),
+		stoppedCh:  make(chan struct{}),
+		promotedCh: make(chan struct{}),
+		registry:   prometheus.NewRegistry(),
+	}
+	f.metrics = &followMetrics{
+		pulledFiles: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "banyandb_follow_pulled_files_total",
+			Help: "The number of the files pulled from the primary",
+		}, []string{"catalog"}),
+		pulledBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "banyandb_follow_pulled_bytes_total",
+			Help: "The bytes of the files pulled from the primary",
+		}, []string{"catalog"}),
+		removedFiles: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "banyandb_follow_removed_files_total",
+			Help: "The number of the local files removed because the primary dropped them",
+		}, []string{"catalog"}),
+		failures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "banyandb_follow_failures_total",
+			Help: "The number of the failed catch-up rounds",
+		}, []string{"catalog"}),
+	}
+	f.registry.MustRegister(f.metrics.pulledFiles, f.metrics.pulledBytes, f.metrics.removedFiles, f.metrics.failures)
+	for _, c := range f.catalogs() {
+		catalog := c
+		f.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name:        "banyandb_follow_lag_seconds",
+			Help:        "The age of the latest snapshot applied to the standby",
+			ConstLabels: prometheus.Labels{"catalog": snapshot.CatalogName(catalog)},
+		}, func() float64 {
+			return f.lag(catalog).Seconds()
+		}))
+	}
+	return f
+}
+
+func (f *follower) run() error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(f.registry, promhttp.HandlerOpts{}))
+	mux.HandleFunc("/status", f.handleStatus)
+	mux.HandleFunc("/promote", f.handlePromote)
+	srv := &http.Server{Addr: f.opts.httpAddr, Handler: mux, ReadHeaderTimeout: 3 * time.Second}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			f.l.Error().Err(err).Msg("failed to serve the follower API")
+		}
+	}()
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(ctx)
+	}()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	f.l.Info().Str("primary", f.opts.primaryAddr).Dur("interval", f.opts.interval).Msg("start following the primary")
+	go f.follow()
+	select {
+	case <-sigChan:
+		f.l.Info().Msg("shutting down the follower...")
+		f.stop()
+	case <-f.promotedCh:
+		f.l.Info().Msg("the standby is promoted, stop following the primary")
+	}
+	return nil
+}
+
+func (f *follower) follow() {
+	defer close(f.stoppedCh)
+	ticker := time.NewTicker(f.opts.interval)
+	defer ticker.Stop()
+	for {
+		if err := f.catchUp(); err != nil {
+			f.l.Error().Err(err).Msg("failed to catch up with the primary")
+		}
+		select {
+		case <-f.stopCh:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (f *follower) stop() {
+	select {
+	case <-f.stopCh:
+	default:
+		close(f.stopCh)
+	}
+	<-f.stoppedCh
+}
+
+// promote stops following the primary after the last catch-up.
+// The data node could serve the reads of the local data afterward.
+// The promotion succeeds even if the last catch-up fails, and the error is returned.
+func (f *follower) promote() error {
+	f.mu.Lock()
+	if f.promoted {
+		f.mu.Unlock()
+		return errPromoted
+	}
+	f.promoted = true
+	f.mu.Unlock()
+	select {
+	case <-f.stopCh:
+	default:
+		close(f.stopCh)
+	}
+	<-f.stoppedCh
+	defer close(f.promotedCh)
+	return f.catchUp()
+}
+
+func (f *follower) catchUp() error {
+	var groups []*databasev1.SnapshotRequest_Group
+	for _, g := range f.opts.streamGroups {
+		groups = append(groups, &databasev1.SnapshotRequest_Group{Catalog: commonv1.Catalog_CATALOG_STREAM, Group: g})
+	}
+	for _, g := range f.opts.measureGroups {
+		groups = append(groups, &databasev1.SnapshotRequest_Group{Catalog: commonv1.Catalog_CATALOG_MEASURE, Group: g})
+	}
+	snapshots, err := snapshot.Get(f.opts.primaryAddr, f.opts.enableTLS, f.opts.insecure, f.opts.cert, groups...)
+	if err != nil {
+		for _, c := range f.catalogs() {
+			f.metrics.failures.WithLabelValues(snapshot.CatalogName(c)).Inc()
+		}
+		return err
+	}
+	_, err = snapshot.Conn(f.opts.primaryAddr, f.opts.enableTLS, f.opts.insecure, f.opts.cert, func(conn *grpc.ClientConn) (struct{}, error) {
+		client := databasev1.NewSnapshotServiceClient(conn)
+		var errs error
+		for _, snp := range snapshots {
+			if _, ok := f.groups(snp.Catalog); !ok {
+				continue
+			}
+			catalogName := snapshot.CatalogName(snp.Catalog)
+			if snp.Error != "" {
+				f.metrics.failures.WithLabelValues(catalogName).Inc()
+				f.setStatus(snp, fmt.Errorf("primary failed to take the snapshot: %s", snp.Error))
+				errs = multierr.Append(errs, fmt.Errorf("%s snapshot %s failed: %s", catalogName, snp.Name, snp.Error))
+				continue
+			}
+			if errPull := f.pull(client, snp); errPull != nil {
+				f.metrics.failures.WithLabelValues(catalogName).Inc()
+				f.setStatus(snp, errPull)
+				errs = multierr.Append(errs, fmt.Errorf("failed to pull %s snapshot %s: %w", catalogName, snp.Name, errPull))
+				continue
+			}
+			f.setStatus(snp, nil)
+		}
+		return struct{}{}, errs
+	})
+	return err
+}
+
+func (f *follower) pull(client databasev1.SnapshotServiceClient, snp *databasev1.Snapshot) error {
+	groups, _ := f.groups(snp.Catalog)
+	localDir := filepath.Join(snapshot.LocalDir(f.root(snp.Catalog), snp.Catalog), storage.DataDir)
+	if err := os.MkdirAll(localDir, storage.DirPerm); err != nil {
+		return fmt.Errorf("failed to create local directory %s: %w", localDir, err)
+	}
+	localFiles, err := followedFiles(localDir, groups)
+	if err != nil {
+		return fmt.Errorf("failed to list local files: %w", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := client.PullSnapshot(ctx, &databasev1.PullSnapshotRequest{
+		Catalog:       snp.Catalog,
+		Name:          snp.Name,
+		ExistingFiles: localFiles,
+	})
+	if err != nil {
+		return err
+	}
+	return applySnapshot(stream.Recv, localDir, localFiles, f.metrics, snapshot.CatalogName(snp.Catalog))
+}
+
+// applySnapshot writes the pulled files into the local directory, and removes the local files which
+// are absent in the snapshot of the primary. Only the files of the followed groups are touched.
+func applySnapshot(recv func() (*databasev1.PullSnapshotResponse, error), localDir string, localFiles []string,
+	metrics *followMetrics, catalog string,
+) (err error) {
+	var file *os.File
+	var filePath string
+	defer func() {
+		if file != nil {
+			_ = file.Close()
+			_ = os.Remove(file.Name())
+		}
+	}()
+	remoteFiles := make(map[string]struct{})
+	manifestReceived := false
+	for {
+		resp, errRecv := recv()
+		if errors.Is(errRecv, io.EOF) {
+			break
+		}
+		if errRecv != nil {
+			return errRecv
+		}
+		if !manifestReceived {
+			for _, rf := range resp.GetFiles() {
+				remoteFiles[rf.GetPath()] = struct{}{}
+			}
+			manifestReceived = true
+			continue
+		}
+		if file == nil || filePath != resp.GetPath() {
+			if file != nil {
+				return fmt.Errorf("file %s is incomplete", filePath)
+			}
+			filePath = resp.GetPath()
+			if !filepath.IsLocal(filepath.FromSlash(filePath)) {
+				return fmt.Errorf("invalid file path %s", filePath)
+			}
+			localPath := filepath.Join(localDir, filepath.FromSlash(filePath))
+			if err = os.MkdirAll(filepath.Dir(localPath), storage.DirPerm); err != nil {
+				return err
+			}
+			if file, err = os.Create(localPath + followingSuffix); err != nil {
+				return err
+			}
+		}
+		if _, err = file.Write(resp.GetChunk()); err != nil {
+			return err
+		}
+		metrics.pulledBytes.WithLabelValues(catalog).Add(float64(len(resp.GetChunk())))
+		if !resp.GetLast() {
+			continue
+		}
+		if err = file.Close(); err != nil {
+			return err
+		}
+		tmpPath := file.Name()
+		file = nil
+		if err = os.Rename(tmpPath, strings.TrimSuffix(tmpPath, followingSuffix)); err != nil {
+			return err
+		}
+		metrics.pulledFiles.WithLabelValues(catalog).Inc()
+	}
+	if !manifestReceived {
+		return errors.New("no file list is received")
+	}
+	if file != nil {
+		return fmt.Errorf("file %s is incomplete", filePath)
+	}
+	for _, lf := range localFiles {
+		if _, ok := remoteFiles[lf]; ok {
+			continue
+		}
+		localPath := filepath.Join(localDir, filepath.FromSlash(lf))
+		if errRemove := os.Remove(localPath); errRemove != nil && !errors.Is(errRemove, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove local file %s: %w", localPath, errRemove)
+		}
+		cleanEmptyDirs(filepath.Dir(localPath), localDir)
+		metrics.removedFiles.WithLabelValues(catalog).Inc()
+	}
+	return nil
+}
+
+// followedFiles lists the local files of the followed groups.
+func followedFiles(localDir string, groups map[string]struct{}) ([]string, error) {
+	files, err := getAllFiles(localDir)
+	if err != nil {
+		return nil, err
+	}
+	result := files[:0]
+	for _, f := range files {
+		group, _, _ := strings.Cut(f, "/")
+		if _, ok := groups[group]; ok {
+			result = append(result, f)
+		}
+	}
+	return result, nil
+}
+
+func (f *follower) catalogs() []commonv1.Catalog {
+	var cc []commonv1.Catalog
+	if len(f.opts.streamGroups) > 0 {
+		cc = append(cc, commonv1.Catalog_CATALOG_STREAM)
+	}
+	if len(f.opts.measureGroups) > 0 {
+		cc = append(cc, commonv1.Catalog_CATALOG_MEASURE)
+	}
+	return cc
+}
+
+func (f *follower) groups(catalog commonv1.Catalog) (map[string]struct{}, bool) {
+	var gg []string
+	switch catalog {
+	case commonv1.Catalog_CATALOG_STREAM:
+		gg = f.opts.streamGroups
+	case commonv1.Catalog_CATALOG_MEASURE:
+		gg = f.opts.measureGroups
+	default:
+	}
+	if len(gg) == 0 {
+		return nil, false
+	}
+	result := make(map[string]struct{}, len(gg))
+	for _, g := range gg {
+		result[g] = struct{}{}
+	}
+	return result, true
+}
+
+func (f *follower) root(catalog commonv1.Catalog) string {
+	if catalog == commonv1.Catalog_CATALOG_STREAM {
+		return f.opts.streamRoot
+	}
+	return f.opts.measureRoot
+}
+
+func (f *follower) setStatus(snp *databasev1.Snapshot, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	st, ok := f.status[snp.Catalog]
+	if !ok {
+		st = &catalogStatus{}
+		f.status[snp.Catalog] = st
+	}
+	if err != nil {
+		st.Error = err.Error()
+		return
+	}
+	st.Error = ""
+	st.Snapshot = snp.Name
+	st.SyncedAt = snapshotTime(snp.Name)
+}
+
+// lag returns the age of the latest snapshot applied to the standby.
+// It's the time since the follower starts if no snapshot is applied.
+func (f *follower) lag(catalog commonv1.Catalog) time.Duration {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if st, ok := f.status[catalog]; ok && !st.SyncedAt.IsZero() {
+		return time.Since(st.SyncedAt)
+	}
+	return time.Since(f.start)
+}
+
+// snapshotTime parses the time when the snapshot is taken from its name, for example, 20250101120000-00000001.
+func snapshotTime(name string) time.Time {
+	ts, _, _ := strings.Cut(name, "-")
+	t, err := time.ParseInLocation("20060102150405", ts, time.UTC)
+	if err != nil {
+		return time.Now()
+	}
+	return t
+}
+
+func (f *follower) handleStatus(w http.ResponseWriter, _ *http.Request) {
+	writeFollowStatus(w, f.followStatus())
+}
+
+func (f *follower) followStatus() followStatus {
+	st := followStatus{
+		Primary:  f.opts.primaryAddr,
+		Catalogs: make(map[string]*catalogStatus),
+	}
+	for _, c := range f.catalogs() {
+		lag := f.lag(c)
+		f.mu.Lock()
+		cs := catalogStatus{}
+		if s, ok := f.status[c]; ok {
+			cs = *s
+		}
+		st.Promoted = f.promoted
+		f.mu.Unlock()
+		cs.LagSeconds = lag.Seconds()
+		st.Catalogs[snapshot.CatalogName(c)] = &cs
+	}
+	return st
+}
+
+func writeFollowStatus(w http.ResponseWriter, st followStatus) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(st)
+}
+
+func (f *follower) handlePromote(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if err := f.promote(); err != nil {
+		if errors.Is(err, errPromoted) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		// the errors are reported in the status of the catalogs.
+		f.l.Error().Err(err).Msg("the last catch-up before the promotion failed")
+	}
+	writeFollowStatus(w, f.followStatus())
+}
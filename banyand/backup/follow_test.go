@@ -0,0 +1,127 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package backup
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+)
+
+func responses(rr ...*databasev1.PullSnapshotResponse) func() (*databasev1.PullSnapshotResponse, error) {
+	return func() (*databasev1.PullSnapshotResponse, error) {
+		if len(rr) == 0 {
+			return nil, io.EOF
+		}
+		r := rr[0]
+		rr = rr[1:]
+		return r, nil
+	}
+}
+
+func TestApplySnapshot(t *testing.T) {
+	localDir := t.TempDir()
+	keptPath := filepath.Join(localDir, "g1", "seg-1", "shard-0", "0000000000000001", "meta.bin")
+	stalePath := filepath.Join(localDir, "g1", "seg-1", "shard-0", "0000000000000002", "meta.bin")
+	otherPath := filepath.Join(localDir, "g2", "seg-1", "shard-0", "0000000000000001", "meta.bin")
+	for _, p := range []string{keptPath, stalePath, otherPath} {
+		if err := os.MkdirAll(filepath.Dir(p), storage.DirPerm); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(p, []byte("old"), 0o600); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+	localFiles, err := followedFiles(localDir, map[string]struct{}{"g1": {}})
+	if err != nil {
+		t.Fatalf("failed to list followed files: %v", err)
+	}
+	if len(localFiles) != 2 {
+		t.Fatalf("expected 2 followed files, got %v", localFiles)
+	}
+
+	newFile := "g1/seg-1/shard-0/0000000000000003/primary.bin"
+	err = applySnapshot(responses(
+		&databasev1.PullSnapshotResponse{Files: []*databasev1.SnapshotFile{
+			{Path: "g1/seg-1/shard-0/0000000000000001/meta.bin", Size: 3},
+			{Path: newFile, Size: 11},
+		}},
+		&databasev1.PullSnapshotResponse{Path: newFile, Chunk: []byte("hello ")},
+		&databasev1.PullSnapshotResponse{Path: newFile, Chunk: []byte("world"), Last: true},
+	), localDir, localFiles, newFollower(followOptions{}).metrics, "stream")
+	if err != nil {
+		t.Fatalf("applySnapshot failed: %v", err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(localDir, filepath.FromSlash(newFile)))
+	if err != nil {
+		t.Fatalf("failed to read the pulled file: %v", err)
+	}
+	if string(got) != "hello world" {
+		t.Fatalf("expected the pulled content %q, got %q", "hello world", string(got))
+	}
+	if _, err = os.Stat(keptPath); err != nil {
+		t.Fatalf("expected %s to be kept: %v", keptPath, err)
+	}
+	if _, err = os.Stat(filepath.Dir(stalePath)); !os.IsNotExist(err) {
+		t.Fatalf("expected the stale part %s to be removed", filepath.Dir(stalePath))
+	}
+	if _, err = os.Stat(otherPath); err != nil {
+		t.Fatalf("expected the file of the unfollowed group %s to be kept: %v", otherPath, err)
+	}
+}
+
+func TestApplySnapshotIncomplete(t *testing.T) {
+	localDir := t.TempDir()
+	path := "g1/seg-1/shard-0/0000000000000001/primary.bin"
+	err := applySnapshot(responses(
+		&databasev1.PullSnapshotResponse{Files: []*databasev1.SnapshotFile{{Path: path, Size: 11}}},
+		&databasev1.PullSnapshotResponse{Path: path, Chunk: []byte("hello ")},
+	), localDir, nil, newFollower(followOptions{}).metrics, "stream")
+	if err == nil {
+		t.Fatal("expected the incomplete file to fail the catch-up")
+	}
+	files, err := getAllFiles(localDir)
+	if err != nil {
+		t.Fatalf("failed to list local files: %v", err)
+	}
+	if len(files) != 0 {
+		t.Fatalf("expected no file is left, got %v", files)
+	}
+
+	err = applySnapshot(responses(
+		&databasev1.PullSnapshotResponse{},
+		&databasev1.PullSnapshotResponse{Path: "../escaped", Chunk: []byte("x"), Last: true},
+	), localDir, nil, newFollower(followOptions{}).metrics, "stream")
+	if err == nil {
+		t.Fatal("expected the path out of the local directory to be rejected")
+	}
+}
+
+func TestSnapshotTime(t *testing.T) {
+	got := snapshotTime("20250102030405-0000000A")
+	want := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	if !got.Equal(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,220 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/banyand/measure"
+	"github.com/apache/skywalking-banyandb/banyand/stream"
+)
+
+const (
+	importFormatJSON    = "json"
+	importFormatParquet = "parquet"
+)
+
+type importOptions struct {
+	streamRoot     string
+	measureRoot    string
+	groupFile      string
+	streamFile     string
+	measureFile    string
+	input          string
+	format         string
+	indexRuleFiles []string
+	batchSize      int
+}
+
+func newImportCommand() *cobra.Command {
+	var opts importOptions
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "Import the history of a stream or a measure by building sealed parts directly, the data node must be stopped",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if opts.groupFile == "" {
+				return errors.New("group-file is required")
+			}
+			if (opts.streamFile == "") == (opts.measureFile == "") {
+				return errors.New("either stream-file or measure-file is required")
+			}
+			if opts.input == "" {
+				return errors.New("input is required")
+			}
+			if opts.format == "" {
+				opts.format = importFormatJSON
+				if strings.EqualFold(filepath.Ext(opts.input), ".parquet") {
+					opts.format = importFormatParquet
+				}
+			}
+			switch opts.format {
+			case importFormatJSON:
+			case importFormatParquet:
+				if opts.input == "-" {
+					return errors.New("the parquet input can't be read from the standard input")
+				}
+			default:
+				return fmt.Errorf("unsupported format %q", opts.format)
+			}
+			return runImport(cmd.Context(), opts, cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().StringVar(&opts.streamRoot, "stream-root-path", "/tmp", "Root directory for stream catalog")
+	cmd.Flags().StringVar(&opts.measureRoot, "measure-root-path", "/tmp", "Root directory for measure catalog")
+	cmd.Flags().StringVar(&opts.groupFile, "group-file", "", "Path to the JSON-encoded group of the stream or the measure")
+	cmd.Flags().StringVar(&opts.streamFile, "stream-file", "", "Path to the JSON-encoded stream")
+	cmd.Flags().StringVar(&opts.measureFile, "measure-file", "", "Path to the JSON-encoded measure")
+	cmd.Flags().StringSliceVar(&opts.indexRuleFiles, "index-rule-files", nil, "Paths to the JSON-encoded index rules bound to the stream or the measure")
+	cmd.Flags().StringVar(&opts.input, "input", "", "Path to the elements or the data points to import, or \"-\" for the standard input")
+	cmd.Flags().StringVar(&opts.format, "format", "",
+		"Format of the input: \"json\" for one JSON-encoded element or data point per line, or \"parquet\". It's \"parquet\" if the input ends with .parquet")
+	cmd.Flags().IntVar(&opts.batchSize, "batch-size", 100_000, "Number of elements or data points built into the parts at once")
+	return cmd
+}
+
+func runImport(ctx context.Context, opts importOptions, out io.Writer) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	group := &commonv1.Group{}
+	if err := readProtoFile(opts.groupFile, group); err != nil {
+		return err
+	}
+	rules := make([]*databasev1.IndexRule, 0, len(opts.indexRuleFiles))
+	for _, f := range opts.indexRuleFiles {
+		r := &databasev1.IndexRule{}
+		if err := readProtoFile(f, r); err != nil {
+			return err
+		}
+		rules = append(rules, r)
+	}
+	if opts.measureFile != "" {
+		return importMeasure(ctx, opts, group, rules, out)
+	}
+	return importStream(ctx, opts, group, rules, out)
+}
+
+func importStream(ctx context.Context, opts importOptions, group *commonv1.Group, rules []*databasev1.IndexRule, out io.Writer) error {
+	if group.GetCatalog() != commonv1.Catalog_CATALOG_STREAM {
+		return fmt.Errorf("group %s isn't a stream group", group.GetMetadata().GetName())
+	}
+	s := &databasev1.Stream{}
+	if err := readProtoFile(opts.streamFile, s); err != nil {
+		return err
+	}
+	var r stream.ElementReader
+	if opts.format == importFormatParquet {
+		pr, err := newParquetElementReader(opts.input, s)
+		if err != nil {
+			return err
+		}
+		defer pr.Close()
+		r = pr
+	} else {
+		in, closeFn, err := openImportInput(opts.input)
+		if err != nil {
+			return err
+		}
+		defer closeFn()
+		r = stream.NewJSONElementReader(in)
+	}
+	stats, err := stream.Import(ctx, stream.ImportOptions{
+		Group:      group,
+		Stream:     s,
+		IndexRules: rules,
+		Root:       opts.streamRoot,
+		BatchSize:  opts.batchSize,
+	}, r)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(out, "imported %d elements into %d parts, skipped %d elements\n", stats.Elements, stats.Parts, stats.Skipped)
+	return err
+}
+
+func importMeasure(ctx context.Context, opts importOptions, group *commonv1.Group, rules []*databasev1.IndexRule, out io.Writer) error {
+	if group.GetCatalog() != commonv1.Catalog_CATALOG_MEASURE {
+		return fmt.Errorf("group %s isn't a measure group", group.GetMetadata().GetName())
+	}
+	m := &databasev1.Measure{}
+	if err := readProtoFile(opts.measureFile, m); err != nil {
+		return err
+	}
+	var r measure.DataPointReader
+	if opts.format == importFormatParquet {
+		pr, err := newParquetDataPointReader(opts.input, m)
+		if err != nil {
+			return err
+		}
+		defer pr.Close()
+		r = pr
+	} else {
+		in, closeFn, err := openImportInput(opts.input)
+		if err != nil {
+			return err
+		}
+		defer closeFn()
+		r = measure.NewJSONDataPointReader(in)
+	}
+	stats, err := measure.Import(ctx, measure.ImportOptions{
+		Group:      group,
+		Measure:    m,
+		IndexRules: rules,
+		Root:       opts.measureRoot,
+		BatchSize:  opts.batchSize,
+	}, r)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(out, "imported %d data points into %d parts, skipped %d data points\n", stats.DataPoints, stats.Parts, stats.Skipped)
+	return err
+}
+
+func openImportInput(input string) (io.Reader, func(), error) {
+	if input == "-" {
+		return os.Stdin, func() {}, nil
+	}
+	f, err := os.Open(input)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot open %s: %w", input, err)
+	}
+	return f, func() { _ = f.Close() }, nil
+}
+
+func readProtoFile(path string, m proto.Message) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("cannot read %s: %w", path, err)
+	}
+	if err = protojson.Unmarshal(data, m); err != nil {
+		return fmt.Errorf("cannot parse %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package backup
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+func TestImportCommand(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "root")
+	files := map[string]string{
+		"group.json": `{"metadata":{"name":"default"},"catalog":"CATALOG_STREAM",` +
+			`"resourceOpts":{"shardNum":1,"segmentInterval":{"unit":"UNIT_DAY","num":1},"ttl":{"unit":"UNIT_DAY","num":7}}}`,
+		"stream.json": `{"metadata":{"name":"sw","group":"default"},"entity":{"tagNames":["service"]},` +
+			`"tagFamilies":[{"name":"searchable","tags":[{"name":"service","type":"TAG_TYPE_STRING"},{"name":"endpoint","type":"TAG_TYPE_STRING"}]}]}`,
+		"rule.json": `{"metadata":{"name":"endpoint","group":"default","id":1},"tags":["endpoint"],"type":"TYPE_INVERTED"}`,
+		"elements.json": `{"elementId":"1","timestamp":"2024-01-01T00:00:00Z","tagFamilies":[{"tags":[{"str":{"value":"svc"}},{"str":{"value":"/a"}}]}]}
+{"elementId":"2","timestamp":"2024-01-02T00:00:00Z","tagFamilies":[{"tags":[{"str":{"value":"svc"}},{"str":{"value":"/b"}}]}]}
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	cmd := NewRestoreCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{
+		"import",
+		"--stream-root-path", root,
+		"--group-file", filepath.Join(dir, "group.json"),
+		"--stream-file", filepath.Join(dir, "stream.json"),
+		"--index-rule-files", filepath.Join(dir, "rule.json"),
+		"--input", filepath.Join(dir, "elements.json"),
+	})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("failed to import: %v", err)
+	}
+	if !strings.Contains(out.String(), "imported 2 elements") {
+		t.Fatalf("unexpected output: %s", out.String())
+	}
+	segments, err := filepath.Glob(filepath.Join(root, "stream", "data", "default", "seg-*"))
+	if err != nil {
+		t.Fatalf("failed to list segments: %v", err)
+	}
+	if len(segments) != 2 {
+		t.Fatalf("expected 2 segments, got %v", segments)
+	}
+}
+
+func TestImportCommandMeasureParquet(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "root")
+	files := map[string]string{
+		"group.json": `{"metadata":{"name":"default"},"catalog":"CATALOG_MEASURE",` +
+			`"resourceOpts":{"shardNum":1,"segmentInterval":{"unit":"UNIT_DAY","num":1},"ttl":{"unit":"UNIT_DAY","num":7}}}`,
+		"measure.json": `{"metadata":{"name":"service_cpm","group":"default"},"entity":{"tagNames":["service"]},` +
+			`"tagFamilies":[{"name":"default","tags":[{"name":"service","type":"TAG_TYPE_STRING"}]}],` +
+			`"fields":[{"name":"total","fieldType":"FIELD_TYPE_INT","encodingMethod":"ENCODING_METHOD_GORILLA","compressionMethod":"COMPRESSION_METHOD_ZSTD"}]}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	if err := parquet.WriteFile(filepath.Join(dir, "data_points.parquet"), []testParquetDataPoint{
+		{Timestamp: "2024-01-01T00:00:00Z", Service: "svc", Total: 1, Version: 1},
+		{Timestamp: "2024-01-02T00:00:00Z", Service: "svc", Total: 2, Version: 1},
+	}); err != nil {
+		t.Fatalf("failed to write the data points: %v", err)
+	}
+	cmd := NewRestoreCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{
+		"import",
+		"--measure-root-path", root,
+		"--group-file", filepath.Join(dir, "group.json"),
+		"--measure-file", filepath.Join(dir, "measure.json"),
+		"--input", filepath.Join(dir, "data_points.parquet"),
+	})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("failed to import: %v", err)
+	}
+	if !strings.Contains(out.String(), "imported 2 data points") {
+		t.Fatalf("unexpected output: %s", out.String())
+	}
+	segments, err := filepath.Glob(filepath.Join(root, "measure", "data", "default", "seg-*"))
+	if err != nil {
+		t.Fatalf("failed to list segments: %v", err)
+	}
+	if len(segments) != 2 {
+		t.Fatalf("expected 2 segments, got %v", segments)
+	}
+}
@@ -0,0 +1,392 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/apache/skywalking-banyandb/api/data"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/banyand/backup/snapshot"
+	"github.com/apache/skywalking-banyandb/banyand/measure"
+	"github.com/apache/skywalking-banyandb/banyand/metadata"
+	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
+	"github.com/apache/skywalking-banyandb/banyand/queue/pub"
+	"github.com/apache/skywalking-banyandb/banyand/stream"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+const archiveGroupSuffix = "-archive"
+
+// archive moves the segments of the local data node past the archive age to the archive groups,
+// then deletes them from the data node.
+func (l *lifecycleService) archive(ctx context.Context) error {
+	gg, err := l.metadata.GroupRegistry().ListGroup(ctx)
+	if err != nil {
+		return err
+	}
+	progress := LoadProgress(l.archiveProgressFilePath, l.l)
+	progress.ClearErrors()
+	var groups []*commonv1.Group
+	for _, g := range gg {
+		ro := g.GetResourceOpts()
+		if ro.GetArchive() == nil || ro.GetArchived() || progress.IsGroupCompleted(g.Metadata.Name) {
+			continue
+		}
+		if g.Catalog != commonv1.Catalog_CATALOG_STREAM && g.Catalog != commonv1.Catalog_CATALOG_MEASURE {
+			l.l.Info().Msgf("group catalog: %s doesn't support archiving", g.Catalog)
+			continue
+		}
+		groups = append(groups, g)
+	}
+	if len(groups) == 0 {
+		progress.Remove(l.archiveProgressFilePath, l.l)
+		return nil
+	}
+	l.l.Info().Msgf("starting archiving for %d groups: %v", len(groups), getGroupNames(groups))
+
+	streamDir, measureDir, err := l.getSnapshots(groups, progress)
+	if err != nil {
+		return err
+	}
+	progress.Save(l.archiveProgressFilePath, l.l)
+	streamSVC, measureSVC, err := l.setupQuerySvc(ctx, streamDir, measureDir)
+	if streamSVC != nil {
+		defer streamSVC.GracefulStop()
+	}
+	if measureSVC != nil {
+		defer measureSVC.GracefulStop()
+	}
+	if err != nil {
+		return err
+	}
+	nodes, err := l.archiveNodes(ctx)
+	if err != nil {
+		return err
+	}
+
+	var failed int
+	for _, g := range groups {
+		var ok bool
+		switch {
+		case g.Catalog == commonv1.Catalog_CATALOG_STREAM && streamSVC != nil:
+			ok = l.archiveStreamGroup(ctx, g, streamSVC, nodes, progress)
+		case g.Catalog == commonv1.Catalog_CATALOG_MEASURE && measureSVC != nil:
+			ok = l.archiveMeasureGroup(ctx, g, measureSVC, nodes, progress)
+		default:
+			l.l.Error().Msgf("the %s service is not available, skipping group: %s", g.Catalog, g.Metadata.Name)
+		}
+		if ok {
+			progress.MarkGroupCompleted(g.Metadata.Name)
+		} else {
+			failed++
+		}
+		progress.Save(l.archiveProgressFilePath, l.l)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d groups are not fully archived, progress file retained", failed)
+	}
+	progress.Remove(l.archiveProgressFilePath, l.l)
+	l.l.Info().Msg("archiving completed successfully")
+	return nil
+}
+
+// archiveNodes returns the data nodes storing the archive groups.
+func (l *lifecycleService) archiveNodes(ctx context.Context) ([]*databasev1.Node, error) {
+	nodes, err := l.metadata.NodeRegistry().ListNode(ctx, databasev1.Role_ROLE_DATA)
+	if err != nil {
+		return nil, err
+	}
+	selector, err := pub.ParseLabelSelector(l.archiveNodeSelector)
+	if err != nil {
+		return nil, err
+	}
+	targets := make([]*databasev1.Node, 0, len(nodes))
+	for _, n := range nodes {
+		if selector.Matches(n.Labels) {
+			targets = append(targets, n)
+		}
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no data nodes matched the archive node selector %q", l.archiveNodeSelector)
+	}
+	return targets, nil
+}
+
+func (l *lifecycleService) archiveStreamGroup(ctx context.Context, g *commonv1.Group, streamSVC stream.Service,
+	nodes []*databasev1.Node, progress *Progress,
+) bool {
+	name := g.Metadata.Name
+	tr, found := archiveTimeRange(streamSVC.GetSegmentsTimeRanges(name), archiveDeadline(g, time.Now()))
+	if !found {
+		l.l.Info().Msgf("no segments to archive in group %s", name)
+		return true
+	}
+	ag, err := ensureArchiveSchemas(ctx, l.metadata, g)
+	if err != nil {
+		l.l.Error().Err(err).Msgf("failed to create the archive group of %s", name)
+		return false
+	}
+	selector, client, err := newClusterClient(l.metadata, data.TopicStreamWrite, nodes)
+	if err != nil {
+		l.l.Error().Err(err).Msgf("failed to connect the data nodes for group %s", ag.Metadata.Name)
+		return false
+	}
+	defer client.GracefulStop()
+	ss, err := l.metadata.StreamRegistry().ListStream(ctx, schema.ListOpt{Group: name})
+	if err != nil {
+		l.l.Error().Err(err).Msgf("failed to list streams in group %s", name)
+		return false
+	}
+	ok := true
+	for _, s := range ss {
+		if progress.IsStreamCompleted(name, s.Metadata.Name) {
+			continue
+		}
+		target, errTarget := l.metadata.StreamRegistry().GetStream(ctx, &commonv1.Metadata{Group: ag.Metadata.Name, Name: s.Metadata.Name})
+		if errTarget != nil {
+			progress.MarkStreamError(name, s.Metadata.Name, errTarget.Error())
+			ok = false
+			continue
+		}
+		result, errQuery := queryStreamTimeRange(ctx, s, streamSVC, &tr, l.l)
+		if errQuery != nil {
+			progress.MarkStreamError(name, s.Metadata.Name, errQuery.Error())
+			ok = false
+			continue
+		}
+		sum := migrateStream(ctx, target, result, ag.ResourceOpts.ShardNum, ag.ResourceOpts.Replicas, selector, client, l.l)
+		l.l.Info().Msgf("archived %d elements in stream %s to group %s", sum, s.Metadata.Name, ag.Metadata.Name)
+		progress.MarkStreamCompleted(name, s.Metadata.Name, sum)
+		progress.Save(l.archiveProgressFilePath, l.l)
+	}
+	if !ok {
+		l.l.Info().Msgf("skipping delete archived stream segments for group %s: some streams not fully archived", name)
+		return false
+	}
+	if progress.IsStreamGroupDeleted(name) {
+		return true
+	}
+	resp, err := snapshot.Conn(l.gRPCAddr, l.enableTLS, l.insecure, l.cert, func(conn *grpc.ClientConn) (*streamv1.DeleteExpiredSegmentsResponse, error) {
+		return streamv1.NewStreamServiceClient(conn).DeleteExpiredSegments(ctx, &streamv1.DeleteExpiredSegmentsRequest{
+			Group:     name,
+			TimeRange: archivedTimeRange(tr),
+			Archived:  true,
+		})
+	})
+	if err != nil {
+		l.l.Error().Err(err).Msgf("failed to delete archived segments in group %s", name)
+		return false
+	}
+	l.l.Info().Msgf("deleted %d archived segments in group %s", resp.Deleted, name)
+	progress.MarkStreamGroupDeleted(name)
+	return true
+}
+
+func (l *lifecycleService) archiveMeasureGroup(ctx context.Context, g *commonv1.Group, measureSVC measure.Service,
+	nodes []*databasev1.Node, progress *Progress,
+) bool {
+	name := g.Metadata.Name
+	tr, found := archiveTimeRange(measureSVC.GetSegmentsTimeRanges(name), archiveDeadline(g, time.Now()))
+	if !found {
+		l.l.Info().Msgf("no segments to archive in group %s", name)
+		return true
+	}
+	ag, err := ensureArchiveSchemas(ctx, l.metadata, g)
+	if err != nil {
+		l.l.Error().Err(err).Msgf("failed to create the archive group of %s", name)
+		return false
+	}
+	selector, client, err := newClusterClient(l.metadata, data.TopicMeasureWrite, nodes)
+	if err != nil {
+		l.l.Error().Err(err).Msgf("failed to connect the data nodes for group %s", ag.Metadata.Name)
+		return false
+	}
+	defer client.GracefulStop()
+	mm, err := l.metadata.MeasureRegistry().ListMeasure(ctx, schema.ListOpt{Group: name})
+	if err != nil {
+		l.l.Error().Err(err).Msgf("failed to list measures in group %s", name)
+		return false
+	}
+	ok := true
+	for _, m := range mm {
+		if progress.IsMeasureCompleted(name, m.Metadata.Name) {
+			continue
+		}
+		target, errTarget := l.metadata.MeasureRegistry().GetMeasure(ctx, &commonv1.Metadata{Group: ag.Metadata.Name, Name: m.Metadata.Name})
+		if errTarget != nil {
+			progress.MarkMeasureError(name, m.Metadata.Name, errTarget.Error())
+			ok = false
+			continue
+		}
+		result, errQuery := queryMeasureTimeRange(ctx, m, measureSVC, &tr, l.l)
+		if errQuery != nil {
+			progress.MarkMeasureError(name, m.Metadata.Name, errQuery.Error())
+			ok = false
+			continue
+		}
+		sum := migrateMeasure(ctx, target, result, ag.ResourceOpts.ShardNum, ag.ResourceOpts.Replicas, selector, client, l.l)
+		l.l.Info().Msgf("archived %d data points in measure %s to group %s", sum, m.Metadata.Name, ag.Metadata.Name)
+		progress.MarkMeasureCompleted(name, m.Metadata.Name, sum)
+		progress.Save(l.archiveProgressFilePath, l.l)
+	}
+	if !ok {
+		l.l.Info().Msgf("skipping delete archived measure segments for group %s: some measures not fully archived", name)
+		return false
+	}
+	if progress.IsMeasureGroupDeleted(name) {
+		return true
+	}
+	resp, err := snapshot.Conn(l.gRPCAddr, l.enableTLS, l.insecure, l.cert, func(conn *grpc.ClientConn) (*measurev1.DeleteExpiredSegmentsResponse, error) {
+		return measurev1.NewMeasureServiceClient(conn).DeleteExpiredSegments(ctx, &measurev1.DeleteExpiredSegmentsRequest{
+			Group:     name,
+			TimeRange: archivedTimeRange(tr),
+			Archived:  true,
+		})
+	})
+	if err != nil {
+		l.l.Error().Err(err).Msgf("failed to delete archived segments in group %s", name)
+		return false
+	}
+	l.l.Info().Msgf("deleted %d archived segments in group %s", resp.Deleted, name)
+	progress.MarkMeasureGroupDeleted(name)
+	return true
+}
+
+// archiveGroupName returns the name of the archive group of g.
+func archiveGroupName(g *commonv1.Group) string {
+	if name := g.GetResourceOpts().GetArchive().GetGroup(); name != "" {
+		return name
+	}
+	return g.GetMetadata().GetName() + archiveGroupSuffix
+}
+
+// newArchiveGroup returns the archive group of g, which inherits the shards, the replicas and the segment interval of g.
+func newArchiveGroup(g *commonv1.Group) *commonv1.Group {
+	ro := g.GetResourceOpts()
+	ttl := ro.GetArchive().GetTtl()
+	if ttl == nil {
+		ttl = ro.GetTtl()
+	}
+	return &commonv1.Group{
+		Metadata: &commonv1.Metadata{Name: archiveGroupName(g)},
+		Catalog:  g.Catalog,
+		ResourceOpts: &commonv1.ResourceOpts{
+			ShardNum:        ro.GetShardNum(),
+			SegmentInterval: ro.GetSegmentInterval(),
+			Ttl:             ttl,
+			Replicas:        ro.GetReplicas(),
+			ElementIdSource: ro.GetElementIdSource(),
+			Archived:        true,
+		},
+	}
+}
+
+// ensureArchiveSchemas creates the archive group of g, and the streams or the measures of g in it.
+// The index rules aren't copied, so only the series are indexed in the archive group.
+// The existing schemas are kept, so that the archiving can be resumed.
+func ensureArchiveSchemas(ctx context.Context, registry metadata.Repo, g *commonv1.Group) (*commonv1.Group, error) {
+	name := archiveGroupName(g)
+	if name == g.Metadata.Name {
+		return nil, fmt.Errorf("group %s can't be archived to itself", name)
+	}
+	if err := skipExisting(registry.GroupRegistry().CreateGroup(ctx, newArchiveGroup(g))); err != nil {
+		return nil, err
+	}
+	ag, err := registry.GroupRegistry().GetGroup(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if !ag.GetResourceOpts().GetArchived() {
+		return nil, fmt.Errorf("group %s exists, but it isn't an archive group", name)
+	}
+	if ag.Catalog != g.Catalog {
+		return nil, fmt.Errorf("the archive group %s is in catalog %s, but group %s is in %s", name, ag.Catalog, g.Metadata.Name, g.Catalog)
+	}
+	opt := schema.ListOpt{Group: g.Metadata.Name}
+	switch g.Catalog {
+	case commonv1.Catalog_CATALOG_STREAM:
+		ss, err := registry.StreamRegistry().ListStream(ctx, opt)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range ss {
+			s = resetRevision(s)
+			s.Metadata.Group = name
+			if _, err = registry.StreamRegistry().CreateStream(ctx, s); skipExisting(err) != nil {
+				return nil, fmt.Errorf("failed to create stream %s: %w", s.Metadata.Name, err)
+			}
+		}
+	case commonv1.Catalog_CATALOG_MEASURE:
+		mm, err := registry.MeasureRegistry().ListMeasure(ctx, opt)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range mm {
+			m = resetRevision(m)
+			m.Metadata.Group = name
+			if _, err = registry.MeasureRegistry().CreateMeasure(ctx, m); skipExisting(err) != nil {
+				return nil, fmt.Errorf("failed to create measure %s: %w", m.Metadata.Name, err)
+			}
+		}
+	}
+	return ag, nil
+}
+
+// archiveDeadline returns the time before which the segments of g are archived.
+func archiveDeadline(g *commonv1.Group, now time.Time) time.Time {
+	after := g.GetResourceOpts().GetArchive().GetAfter()
+	d := time.Duration(after.GetNum()) * time.Hour
+	if after.GetUnit() == commonv1.IntervalRule_UNIT_DAY {
+		d *= 24
+	}
+	return now.Add(-d)
+}
+
+// archiveTimeRange returns the time range spanning the segments ending before the deadline, and false if there is none.
+func archiveTimeRange(segments []timestamp.TimeRange, deadline time.Time) (timestamp.TimeRange, bool) {
+	var start, end time.Time
+	for _, s := range segments {
+		if s.End.After(deadline) {
+			continue
+		}
+		if start.IsZero() || s.Start.Before(start) {
+			start = s.Start
+		}
+		if s.End.After(end) {
+			end = s.End
+		}
+	}
+	if end.IsZero() {
+		return timestamp.TimeRange{}, false
+	}
+	return timestamp.NewSectionTimeRange(start, end), true
+}
+
+func archivedTimeRange(tr timestamp.TimeRange) *modelv1.TimeRange {
+	return &modelv1.TimeRange{Begin: timestamppb.New(tr.Start), End: timestamppb.New(tr.End)}
+}
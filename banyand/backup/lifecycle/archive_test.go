@@ -0,0 +1,128 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package lifecycle
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+func archivedGroup(archive *commonv1.ArchiveOpts) *commonv1.Group {
+	return &commonv1.Group{
+		Metadata: &commonv1.Metadata{Name: "sw"},
+		Catalog:  commonv1.Catalog_CATALOG_STREAM,
+		ResourceOpts: &commonv1.ResourceOpts{
+			ShardNum:        2,
+			SegmentInterval: &commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_DAY, Num: 1},
+			Ttl:             &commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_DAY, Num: 30},
+			Archive:         archive,
+		},
+	}
+}
+
+func TestNewArchiveGroup(t *testing.T) {
+	g := archivedGroup(&commonv1.ArchiveOpts{After: &commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_DAY, Num: 7}})
+	ag := newArchiveGroup(g)
+	assert.Equal(t, "sw-archive", ag.Metadata.Name)
+	assert.True(t, ag.ResourceOpts.Archived)
+	assert.Nil(t, ag.ResourceOpts.Archive)
+	assert.Equal(t, uint32(2), ag.ResourceOpts.ShardNum)
+	assert.Equal(t, uint32(30), ag.ResourceOpts.Ttl.Num)
+
+	g = archivedGroup(&commonv1.ArchiveOpts{
+		After: &commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_DAY, Num: 7},
+		Group: "sw-history",
+		Ttl:   &commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_DAY, Num: 365},
+	})
+	ag = newArchiveGroup(g)
+	assert.Equal(t, "sw-history", ag.Metadata.Name)
+	assert.Equal(t, uint32(365), ag.ResourceOpts.Ttl.Num)
+}
+
+func TestArchiveTimeRange(t *testing.T) {
+	day := func(d int) time.Time {
+		return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC)
+	}
+	segments := []timestamp.TimeRange{
+		timestamp.NewSectionTimeRange(day(3), day(4)),
+		timestamp.NewSectionTimeRange(day(1), day(2)),
+		timestamp.NewSectionTimeRange(day(2), day(3)),
+	}
+	g := archivedGroup(&commonv1.ArchiveOpts{After: &commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_HOUR, Num: 36}})
+
+	// the segment of day 3 isn't past the age yet.
+	tr, ok := archiveTimeRange(segments, archiveDeadline(g, day(4).Add(12*time.Hour)))
+	require.True(t, ok)
+	assert.Equal(t, day(1), tr.Start)
+	assert.Equal(t, day(3), tr.End)
+
+	_, ok = archiveTimeRange(segments, archiveDeadline(g, day(3)))
+	assert.False(t, ok)
+}
+
+func TestEnsureArchiveSchemas(t *testing.T) {
+	req := require.New(t)
+	ctx := context.Background()
+	registry := newTestRegistry(t)
+	g := archivedGroup(&commonv1.ArchiveOpts{After: &commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_DAY, Num: 7}})
+	req.NoError(registry.GroupRegistry().CreateGroup(ctx, g))
+	_, err := registry.StreamRegistry().CreateStream(ctx, &databasev1.Stream{
+		Metadata: &commonv1.Metadata{Name: "log", Group: "sw"},
+		TagFamilies: []*databasev1.TagFamilySpec{{
+			Name: "default",
+			Tags: []*databasev1.TagSpec{{Name: "svc", Type: databasev1.TagType_TAG_TYPE_STRING}},
+		}},
+		Entity: &databasev1.Entity{TagNames: []string{"svc"}},
+	})
+	req.NoError(err)
+
+	ag, err := ensureArchiveSchemas(ctx, registry, g)
+	req.NoError(err)
+	req.Equal("sw-archive", ag.Metadata.Name)
+	s, err := registry.StreamRegistry().GetStream(ctx, &commonv1.Metadata{Name: "log", Group: "sw-archive"})
+	req.NoError(err)
+	req.Equal([]string{"svc"}, s.GetEntity().GetTagNames())
+	bb, err := registry.IndexRuleBindingRegistry().ListIndexRuleBinding(ctx, schema.ListOpt{Group: "sw-archive"})
+	req.NoError(err)
+	req.Empty(bb)
+
+	// the archiving is resumed on the existing schemas.
+	_, err = ensureArchiveSchemas(ctx, registry, g)
+	req.NoError(err)
+
+	// a regular group isn't taken as the archive group.
+	g.ResourceOpts.Archive.Group = "sw-regular"
+	regular := archivedGroup(nil)
+	regular.Metadata.Name = "sw-regular"
+	req.NoError(registry.GroupRegistry().CreateGroup(ctx, regular))
+	_, err = ensureArchiveSchemas(ctx, registry, g)
+	req.ErrorContains(err, "isn't an archive group")
+
+	g.ResourceOpts.Archive.Group = "sw"
+	_, err = ensureArchiveSchemas(ctx, registry, g)
+	req.Error(err)
+}
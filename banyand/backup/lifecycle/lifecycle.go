@@ -69,5 +69,6 @@ func NewCommand() *cobra.Command {
 	cmd.Flags().AddFlagSet(group.RegisterFlags().FlagSet)
 	cmd.Flags().StringVar(&logging.Env, "logging-env", "prod", "the logging")
 	cmd.Flags().StringVar(&logging.Level, "logging-level", "info", "the root level of logging")
+	cmd.AddCommand(newMigrateCommand())
 	return cmd
 }
@@ -0,0 +1,487 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	"github.com/apache/skywalking-banyandb/api/data"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/banyand/liaison/grpc"
+	"github.com/apache/skywalking-banyandb/banyand/measure"
+	"github.com/apache/skywalking-banyandb/banyand/metadata"
+	"github.com/apache/skywalking-banyandb/banyand/metadata/embeddedetcd"
+	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
+	"github.com/apache/skywalking-banyandb/banyand/observability"
+	"github.com/apache/skywalking-banyandb/banyand/protector"
+	"github.com/apache/skywalking-banyandb/banyand/queue"
+	"github.com/apache/skywalking-banyandb/banyand/queue/pub"
+	"github.com/apache/skywalking-banyandb/banyand/stream"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+	"github.com/apache/skywalking-banyandb/pkg/config"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/node"
+	"github.com/apache/skywalking-banyandb/pkg/run"
+	"github.com/apache/skywalking-banyandb/pkg/signal"
+	"github.com/apache/skywalking-banyandb/pkg/version"
+)
+
+// newMigrateCommand creates the command migrating a standalone server to a cluster.
+func newMigrateCommand() *cobra.Command {
+	logging := logger.Logging{}
+	metaSvc, err := metadata.NewClient(false, false)
+	if err != nil {
+		logger.GetLogger().Err(err).Msg("failed to initiate metadata service")
+	}
+	svc := newMigrationService(metaSvc)
+	group := run.NewGroup("migrate")
+	group.Register(new(signal.Handler), metaSvc, svc)
+	cmd := &cobra.Command{
+		Use:               "migrate",
+		Short:             "Migrate the metadata and the data of a stopped standalone server to a cluster",
+		DisableAutoGenTag: true,
+		Version:           version.Build(),
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if err = config.Load("logging", cmd.Flags()); err != nil {
+				return err
+			}
+			if err = logger.Init(logging); err != nil {
+				return err
+			}
+			defer func() {
+				if err := recover(); err != nil {
+					logger.GetLogger().Error().Msgf("panic occurred: %v", err)
+					os.Exit(-1)
+				}
+			}()
+			if err := group.Run(context.Background()); err != nil {
+				logger.GetLogger().Error().Err(err).Stack().Str("name", group.Name()).Msg("Exit")
+				os.Exit(-1)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().AddFlagSet(group.RegisterFlags().FlagSet)
+	cmd.Flags().StringVar(&logging.Env, "logging-env", "prod", "the logging")
+	cmd.Flags().StringVar(&logging.Level, "logging-level", "info", "the root level of logging")
+	return cmd
+}
+
+// migrationService copies the schemas of a standalone server to the metadata registry of a cluster,
+// then reads the data of the standalone server and writes them to the data nodes of the cluster.
+// The data are re-sharded by the shard number of the groups in the cluster.
+type migrationService struct {
+	metadata         metadata.Repo
+	omr              observability.MetricsRegistry
+	pm               protector.Memory
+	l                *logger.Logger
+	metadataRoot     string
+	streamRoot       string
+	measureRoot      string
+	nodeSelector     string
+	progressFilePath string
+	etcdClientURL    string
+	etcdPeerURL      string
+	shardNum         uint32
+}
+
+func newMigrationService(meta metadata.Repo) *migrationService {
+	ms := &migrationService{
+		metadata: meta,
+		omr:      observability.BypassRegistry,
+	}
+	ms.pm = protector.NewMemory(ms.omr)
+	return ms
+}
+
+func (m *migrationService) Name() string {
+	return "migration"
+}
+
+func (m *migrationService) FlagSet() *run.FlagSet {
+	flagS := run.NewFlagSet(m.Name())
+	flagS.StringVar(&m.metadataRoot, "standalone-metadata-root-path", "", "the metadata root path of the standalone server")
+	flagS.StringVar(&m.streamRoot, "stream-root-path", "/tmp", "the stream root path of the standalone server")
+	flagS.StringVar(&m.measureRoot, "measure-root-path", "/tmp", "the measure root path of the standalone server")
+	flagS.StringVar(&m.etcdClientURL, "standalone-etcd-listen-client-url", "http://localhost:23790",
+		"the URL on which the metadata of the standalone server is served during the migration")
+	flagS.StringVar(&m.etcdPeerURL, "standalone-etcd-listen-peer-url", "http://localhost:23800",
+		"the peer URL of the metadata of the standalone server during the migration")
+	flagS.Uint32Var(&m.shardNum, "shard-num", 0, "the shard number of the migrated groups in the cluster, 0 keeps the shard number of the standalone server")
+	flagS.StringVar(&m.nodeSelector, "data-node-selector", "", "the label selector of the data nodes receiving the data, empty means all the data nodes")
+	flagS.StringVar(&m.progressFilePath, "progress-file", "/tmp/migration-progress.json", "Path to store progress for crash recovery")
+	return flagS
+}
+
+func (m *migrationService) Validate() error {
+	if m.metadataRoot == "" {
+		return errors.New("standalone-metadata-root-path is required")
+	}
+	if _, err := pub.ParseLabelSelector(m.nodeSelector); err != nil {
+		return fmt.Errorf("invalid data-node-selector %s: %w", m.nodeSelector, err)
+	}
+	return nil
+}
+
+func (m *migrationService) GracefulStop() {}
+
+func (m *migrationService) Serve() run.StopNotify {
+	m.l = logger.GetLogger("migration")
+	done := make(chan struct{})
+	defer close(done)
+	if err := m.migrate(context.Background()); err != nil {
+		logger.Panicf("failed to migrate the standalone server: %v", err)
+	}
+	return done
+}
+
+func (m *migrationService) migrate(ctx context.Context) error {
+	source, closeSource, err := m.openStandaloneMetadata(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeSource()
+
+	groups, err := copySchemas(ctx, source, m.metadata, m.shardNum, m.l)
+	if err != nil {
+		return err
+	}
+	progress := LoadProgress(m.progressFilePath, m.l)
+	progress.ClearErrors()
+
+	streamSVC, measureSVC, err := m.setupQuerySvc(ctx, source)
+	if streamSVC != nil {
+		defer streamSVC.GracefulStop()
+	}
+	if measureSVC != nil {
+		defer measureSVC.GracefulStop()
+	}
+	if err != nil {
+		return err
+	}
+	nodes, err := m.metadata.NodeRegistry().ListNode(ctx, databasev1.Role_ROLE_DATA)
+	if err != nil {
+		return err
+	}
+	selector, _ := pub.ParseLabelSelector(m.nodeSelector)
+	targets := make([]*databasev1.Node, 0, len(nodes))
+	for _, n := range nodes {
+		if selector.Matches(n.Labels) {
+			targets = append(targets, n)
+		}
+	}
+	if len(targets) == 0 {
+		return errors.New("no data nodes matched")
+	}
+
+	var failed int
+	for _, g := range groups {
+		if progress.IsGroupCompleted(g.Metadata.Name) {
+			m.l.Info().Msgf("skipping already migrated group: %s", g.Metadata.Name)
+			continue
+		}
+		var ok bool
+		switch g.Catalog {
+		case commonv1.Catalog_CATALOG_STREAM:
+			ok = m.migrateStreamGroup(ctx, g, source, streamSVC, targets, progress)
+		case commonv1.Catalog_CATALOG_MEASURE:
+			ok = m.migrateMeasureGroup(ctx, g, source, measureSVC, targets, progress)
+		default:
+			m.l.Info().Msgf("the data of group %s in catalog %s aren't migrated", g.Metadata.Name, g.Catalog)
+			ok = true
+		}
+		if ok {
+			progress.MarkGroupCompleted(g.Metadata.Name)
+		} else {
+			failed++
+		}
+		progress.Save(m.progressFilePath, m.l)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d groups are not fully migrated, progress file retained", failed)
+	}
+	progress.Remove(m.progressFilePath, m.l)
+	m.l.Info().Msg("the standalone server is migrated successfully")
+	return nil
+}
+
+// openStandaloneMetadata serves the metadata of the standalone server by an embedded etcd on its metadata root path.
+func (m *migrationService) openStandaloneMetadata(ctx context.Context) (metadata.Service, func(), error) {
+	if _, err := os.Stat(filepath.Join(m.metadataRoot, "metadata")); err != nil {
+		return nil, nil, fmt.Errorf("no metadata of the standalone server in %s: %w", m.metadataRoot, err)
+	}
+	server, err := embeddedetcd.NewServer(embeddedetcd.RootDir(m.metadataRoot),
+		embeddedetcd.ConfigureListener([]string{m.etcdClientURL}, []string{m.etcdPeerURL}))
+	if err != nil {
+		return nil, nil, err
+	}
+	<-server.ReadyNotify()
+	closeServer := func() {
+		server.Close()
+		<-server.StopNotify()
+	}
+	source, err := metadata.NewClient(false, false)
+	if err == nil {
+		err = source.FlagSet().Parse([]string{"--" + metadata.FlagEtcdEndpointsName, m.etcdClientURL})
+	}
+	if err == nil {
+		err = source.PreRun(ctx)
+	}
+	if err != nil {
+		closeServer()
+		return nil, nil, err
+	}
+	return source, func() {
+		source.GracefulStop()
+		closeServer()
+	}, nil
+}
+
+func (m *migrationService) setupQuerySvc(ctx context.Context, source metadata.Repo) (streamSVC stream.Service, measureSVC measure.Service, err error) {
+	ctx = context.WithValue(ctx, common.ContextNodeKey, common.Node{})
+	streamSVC, err = stream.NewReadonlyService(source, m.omr, m.pm)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = streamSVC.FlagSet().Parse([]string{"--stream-root-path", m.streamRoot}); err != nil {
+		return nil, nil, err
+	}
+	if err = streamSVC.PreRun(ctx); err != nil {
+		return streamSVC, nil, err
+	}
+	measureSVC, err = measure.NewReadonlyService(source, m.omr, m.pm)
+	if err != nil {
+		return streamSVC, nil, err
+	}
+	if err = measureSVC.FlagSet().Parse([]string{"--measure-root-path", m.measureRoot}); err != nil {
+		return streamSVC, nil, err
+	}
+	if err = measureSVC.PreRun(ctx); err != nil {
+		return streamSVC, measureSVC, err
+	}
+	return streamSVC, measureSVC, nil
+}
+
+func (m *migrationService) migrateStreamGroup(ctx context.Context, g *commonv1.Group, source metadata.Repo,
+	streamSVC stream.Service, nodes []*databasev1.Node, progress *Progress,
+) bool {
+	selector, client, err := newClusterClient(m.metadata, data.TopicStreamWrite, nodes)
+	if err != nil {
+		m.l.Error().Err(err).Msgf("failed to connect the data nodes for group %s", g.Metadata.Name)
+		return false
+	}
+	defer client.GracefulStop()
+	ss, err := source.StreamRegistry().ListStream(ctx, schema.ListOpt{Group: g.Metadata.Name})
+	if err != nil {
+		m.l.Error().Err(err).Msgf("failed to list streams in group %s", g.Metadata.Name)
+		return false
+	}
+	ok := true
+	for _, s := range ss {
+		if progress.IsStreamCompleted(g.Metadata.Name, s.Metadata.Name) {
+			continue
+		}
+		var sum int
+		for _, tr := range streamSVC.GetSegmentsTimeRanges(g.Metadata.Name) {
+			n, err := migrateStreamTimeRange(ctx, s, streamSVC, &tr, g.ResourceOpts.ShardNum, g.ResourceOpts.Replicas, selector, client, m.l)
+			if err != nil {
+				progress.MarkStreamError(g.Metadata.Name, s.Metadata.Name, err.Error())
+				ok = false
+				break
+			}
+			sum += n
+		}
+		if ok {
+			m.l.Info().Msgf("migrated %d elements in stream %s", sum, s.Metadata.Name)
+			progress.MarkStreamCompleted(g.Metadata.Name, s.Metadata.Name, sum)
+		}
+		progress.Save(m.progressFilePath, m.l)
+	}
+	return ok
+}
+
+func (m *migrationService) migrateMeasureGroup(ctx context.Context, g *commonv1.Group, source metadata.Repo,
+	measureSVC measure.Service, nodes []*databasev1.Node, progress *Progress,
+) bool {
+	selector, client, err := newClusterClient(m.metadata, data.TopicMeasureWrite, nodes)
+	if err != nil {
+		m.l.Error().Err(err).Msgf("failed to connect the data nodes for group %s", g.Metadata.Name)
+		return false
+	}
+	defer client.GracefulStop()
+	mm, err := source.MeasureRegistry().ListMeasure(ctx, schema.ListOpt{Group: g.Metadata.Name})
+	if err != nil {
+		m.l.Error().Err(err).Msgf("failed to list measures in group %s", g.Metadata.Name)
+		return false
+	}
+	ok := true
+	for _, ms := range mm {
+		if progress.IsMeasureCompleted(g.Metadata.Name, ms.Metadata.Name) {
+			continue
+		}
+		var sum int
+		for _, tr := range measureSVC.GetSegmentsTimeRanges(g.Metadata.Name) {
+			n, err := migrateMeasureTimeRange(ctx, ms, measureSVC, &tr, g.ResourceOpts.ShardNum, g.ResourceOpts.Replicas, selector, client, m.l)
+			if err != nil {
+				progress.MarkMeasureError(g.Metadata.Name, ms.Metadata.Name, err.Error())
+				ok = false
+				break
+			}
+			sum += n
+		}
+		if ok {
+			m.l.Info().Msgf("migrated %d data points in measure %s", sum, ms.Metadata.Name)
+			progress.MarkMeasureCompleted(g.Metadata.Name, ms.Metadata.Name, sum)
+		}
+		progress.Save(m.progressFilePath, m.l)
+	}
+	return ok
+}
+
+// newClusterClient returns the client writing to the nodes, which are picked by the shards of the groups in the registry.
+func newClusterClient(registry metadata.Repo, topic bus.Topic, nodes []*databasev1.Node) (node.Selector, queue.Client, error) {
+	nodeSel := node.NewRoundRobinSelector("", registry)
+	if ok, _ := nodeSel.OnInit([]schema.Kind{schema.KindGroup}); !ok {
+		return nil, nil, errors.New("failed to initialize the node selector")
+	}
+	client := pub.NewWithoutMetadata()
+	_ = grpc.NewClusterNodeRegistry(topic, client, nodeSel)
+	for _, n := range nodes {
+		client.OnAddOrUpdate(schema.Metadata{
+			TypeMeta: schema.TypeMeta{
+				Kind: schema.KindNode,
+			},
+			Spec: n,
+		})
+	}
+	return nodeSel, client, nil
+}
+
+// copySchemas creates the schemas of the source registry in the target one, and returns the groups in the target registry.
+// The existing schemas in the target registry are kept, so that the migration can be resumed.
+func copySchemas(ctx context.Context, source, target metadata.Repo, shardNum uint32, l *logger.Logger) ([]*commonv1.Group, error) {
+	gg, err := source.GroupRegistry().ListGroup(ctx)
+	if err != nil {
+		return nil, err
+	}
+	groups := make([]*commonv1.Group, 0, len(gg))
+	for _, g := range gg {
+		g = resetRevision(g)
+		if shardNum > 0 && g.ResourceOpts != nil {
+			g.ResourceOpts.ShardNum = shardNum
+		}
+		if err = skipExisting(target.GroupRegistry().CreateGroup(ctx, g)); err != nil {
+			return nil, fmt.Errorf("failed to create group %s: %w", g.Metadata.Name, err)
+		}
+		// the group might exist in the cluster with a different shard number.
+		if g, err = target.GroupRegistry().GetGroup(ctx, g.Metadata.Name); err != nil {
+			return nil, err
+		}
+		groups = append(groups, g)
+	}
+	for _, g := range groups {
+		opt := schema.ListOpt{Group: g.Metadata.Name}
+		irr, err := source.IndexRuleRegistry().ListIndexRule(ctx, opt)
+		if err != nil {
+			return nil, err
+		}
+		for _, ir := range irr {
+			if err = skipExisting(target.IndexRuleRegistry().CreateIndexRule(ctx, resetRevision(ir))); err != nil {
+				return nil, fmt.Errorf("failed to create index rule %s: %w", ir.Metadata.Name, err)
+			}
+		}
+		ss, err := source.StreamRegistry().ListStream(ctx, opt)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range ss {
+			if _, err = target.StreamRegistry().CreateStream(ctx, resetRevision(s)); skipExisting(err) != nil {
+				return nil, fmt.Errorf("failed to create stream %s: %w", s.Metadata.Name, err)
+			}
+		}
+		mm, err := source.MeasureRegistry().ListMeasure(ctx, opt)
+		if err != nil {
+			return nil, err
+		}
+		for _, ms := range mm {
+			if _, err = target.MeasureRegistry().CreateMeasure(ctx, resetRevision(ms)); skipExisting(err) != nil {
+				return nil, fmt.Errorf("failed to create measure %s: %w", ms.Metadata.Name, err)
+			}
+		}
+		bb, err := source.IndexRuleBindingRegistry().ListIndexRuleBinding(ctx, opt)
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range bb {
+			if err = skipExisting(target.IndexRuleBindingRegistry().CreateIndexRuleBinding(ctx, resetRevision(b))); err != nil {
+				return nil, fmt.Errorf("failed to create index rule binding %s: %w", b.Metadata.Name, err)
+			}
+		}
+		tt, err := source.TopNAggregationRegistry().ListTopNAggregation(ctx, opt)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range tt {
+			if err = skipExisting(target.TopNAggregationRegistry().CreateTopNAggregation(ctx, resetRevision(t))); err != nil {
+				return nil, fmt.Errorf("failed to create topN aggregation %s: %w", t.Metadata.Name, err)
+			}
+		}
+		pp, err := source.PropertyRegistry().ListProperty(ctx, opt)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range pp {
+			if err = skipExisting(target.PropertyRegistry().CreateProperty(ctx, resetRevision(p))); err != nil {
+				return nil, fmt.Errorf("failed to create property %s: %w", p.Metadata.Name, err)
+			}
+		}
+		l.Info().Msgf("copied the schemas of group %s", g.Metadata.Name)
+	}
+	return groups, nil
+}
+
+func skipExisting(err error) error {
+	if errors.Is(err, schema.ErrGRPCAlreadyExists) {
+		return nil
+	}
+	return err
+}
+
+type withMetadata interface {
+	proto.Message
+	GetMetadata() *commonv1.Metadata
+}
+
+// resetRevision clones the schema without the revisions of the source registry.
+func resetRevision[T withMetadata](m T) T {
+	c := proto.Clone(m).(T)
+	if md := c.GetMetadata(); md != nil {
+		md.CreateRevision = 0
+		md.ModRevision = 0
+	}
+	return c
+}
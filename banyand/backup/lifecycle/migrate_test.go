@@ -0,0 +1,100 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/banyand/metadata"
+	"github.com/apache/skywalking-banyandb/banyand/metadata/embeddedetcd"
+	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/test"
+)
+
+func newTestRegistry(t *testing.T) metadata.Service {
+	req := require.New(t)
+	path, deferFn := test.Space(req)
+	ports, err := test.AllocateFreePorts(2)
+	req.NoError(err)
+	endpoint := fmt.Sprintf("http://127.0.0.1:%d", ports[0])
+	server, err := embeddedetcd.NewServer(embeddedetcd.RootDir(path),
+		embeddedetcd.ConfigureListener([]string{endpoint}, []string{fmt.Sprintf("http://127.0.0.1:%d", ports[1])}))
+	req.NoError(err)
+	<-server.ReadyNotify()
+	svc, err := metadata.NewClient(false, false)
+	req.NoError(err)
+	req.NoError(svc.FlagSet().Parse([]string{"--" + metadata.FlagEtcdEndpointsName, endpoint}))
+	req.NoError(svc.PreRun(context.Background()))
+	t.Cleanup(func() {
+		svc.GracefulStop()
+		server.Close()
+		<-server.StopNotify()
+		deferFn()
+	})
+	return svc
+}
+
+func TestCopySchemas(t *testing.T) {
+	req := require.New(t)
+	ctx := context.Background()
+	source := newTestRegistry(t)
+	target := newTestRegistry(t)
+
+	req.NoError(source.GroupRegistry().CreateGroup(ctx, &commonv1.Group{
+		Metadata: &commonv1.Metadata{Name: "sw"},
+		Catalog:  commonv1.Catalog_CATALOG_STREAM,
+		ResourceOpts: &commonv1.ResourceOpts{
+			ShardNum:        1,
+			SegmentInterval: &commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_DAY, Num: 1},
+			Ttl:             &commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_DAY, Num: 7},
+		},
+	}))
+	_, err := source.StreamRegistry().CreateStream(ctx, &databasev1.Stream{
+		Metadata: &commonv1.Metadata{Name: "log", Group: "sw"},
+		TagFamilies: []*databasev1.TagFamilySpec{{
+			Name: "default",
+			Tags: []*databasev1.TagSpec{{Name: "svc", Type: databasev1.TagType_TAG_TYPE_STRING}},
+		}},
+		Entity: &databasev1.Entity{TagNames: []string{"svc"}},
+	})
+	req.NoError(err)
+
+	groups, err := copySchemas(ctx, source, target, 4, logger.GetLogger("test"))
+	req.NoError(err)
+	req.Len(groups, 1)
+	// the group is re-sharded in the cluster
+	req.Equal(uint32(4), groups[0].GetResourceOpts().GetShardNum())
+	s, err := target.StreamRegistry().GetStream(ctx, &commonv1.Metadata{Name: "log", Group: "sw"})
+	req.NoError(err)
+	req.Equal([]string{"svc"}, s.GetEntity().GetTagNames())
+
+	// the existing schemas are kept when the migration is resumed
+	groups, err = copySchemas(ctx, source, target, 0, logger.GetLogger("test"))
+	req.NoError(err)
+	req.Equal(uint32(4), groups[0].GetResourceOpts().GetShardNum())
+	ss, err := target.StreamRegistry().ListStream(ctx, schema.ListOpt{Group: "sw"})
+	req.NoError(err)
+	req.Len(ss, 1)
+}
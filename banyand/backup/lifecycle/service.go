@@ -29,6 +29,7 @@ import (
 
 	"github.com/benbjohnson/clock"
 	"github.com/robfig/cron/v3"
+	"go.uber.org/multierr"
 	"google.golang.org/grpc"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
@@ -45,6 +46,7 @@ import (
 	"github.com/apache/skywalking-banyandb/banyand/observability"
 	"github.com/apache/skywalking-banyandb/banyand/protector"
 	"github.com/apache/skywalking-banyandb/banyand/queue"
+	"github.com/apache/skywalking-banyandb/banyand/queue/pub"
 	"github.com/apache/skywalking-banyandb/banyand/stream"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
 	"github.com/apache/skywalking-banyandb/pkg/node"
@@ -62,21 +64,23 @@ type service interface {
 var _ service = (*lifecycleService)(nil)
 
 type lifecycleService struct {
-	metadata          metadata.Repo
-	omr               observability.MetricsRegistry
-	pm                protector.Memory
-	l                 *logger.Logger
-	sch               *timestamp.Scheduler
-	measureRoot       string
-	streamRoot        string
-	progressFilePath  string
-	reportDir         string
-	schedule          string
-	cert              string
-	gRPCAddr          string
-	maxExecutionTimes int
-	enableTLS         bool
-	insecure          bool
+	metadata                metadata.Repo
+	omr                     observability.MetricsRegistry
+	pm                      protector.Memory
+	l                       *logger.Logger
+	sch                     *timestamp.Scheduler
+	measureRoot             string
+	streamRoot              string
+	progressFilePath        string
+	archiveProgressFilePath string
+	archiveNodeSelector     string
+	reportDir               string
+	schedule                string
+	cert                    string
+	gRPCAddr                string
+	maxExecutionTimes       int
+	enableTLS               bool
+	insecure                bool
 }
 
 // NewService creates a new lifecycle service.
@@ -99,6 +103,10 @@ func (l *lifecycleService) FlagSet() *run.FlagSet {
 	flagS.StringVar(&l.streamRoot, "stream-root-path", "/tmp", "Root directory for stream catalog")
 	flagS.StringVar(&l.measureRoot, "measure-root-path", "/tmp", "Root directory for measure catalog")
 	flagS.StringVar(&l.progressFilePath, "progress-file", "/tmp/lifecycle-progress.json", "Path to store progress for crash recovery")
+	flagS.StringVar(&l.archiveProgressFilePath, "archive-progress-file", "/tmp/lifecycle-archive-progress.json",
+		"Path to store the archive progress for crash recovery")
+	flagS.StringVar(&l.archiveNodeSelector, "archive-node-selector", "",
+		"the label selector of the data nodes storing the archive groups, empty means all the data nodes")
 	flagS.StringVar(&l.reportDir, "report-dir", "/tmp/lifecycle-reports", "Directory to store migration reports")
 	flagS.StringVar(
 		&l.schedule,
@@ -111,6 +119,9 @@ func (l *lifecycleService) FlagSet() *run.FlagSet {
 }
 
 func (l *lifecycleService) Validate() error {
+	if _, err := pub.ParseLabelSelector(l.archiveNodeSelector); err != nil {
+		return fmt.Errorf("invalid archive-node-selector %s: %w", l.archiveNodeSelector, err)
+	}
 	return nil
 }
 
@@ -130,7 +141,7 @@ func (l *lifecycleService) Serve() run.StopNotify {
 	if l.schedule == "" {
 		defer close(done)
 		l.l.Info().Msg("starting lifecycle migration without schedule")
-		if err := l.action(); err != nil {
+		if err := l.run(); err != nil {
 			logger.Panicf("failed to run lifecycle migration: %v", err)
 		}
 		return done
@@ -141,7 +152,7 @@ func (l *lifecycleService) Serve() run.StopNotify {
 	var executionCount int
 	err := l.sch.Register("lifecycle", cron.Descriptor, l.schedule, func(triggerTime time.Time, _ *logger.Logger) bool {
 		l.l.Info().Msgf("lifecycle migration triggered at %s", triggerTime)
-		if err := l.action(); err != nil {
+		if err := l.run(); err != nil {
 			l.l.Error().Err(err).Msg("failed to run lifecycle migration action")
 		}
 		executionCount++
@@ -159,6 +170,15 @@ func (l *lifecycleService) Serve() run.StopNotify {
 	return done
 }
 
+// run archives the groups, then migrates the groups to their next stages.
+func (l *lifecycleService) run() error {
+	errArchive := l.archive(context.Background())
+	if errArchive != nil {
+		l.l.Error().Err(errArchive).Msg("failed to archive groups")
+	}
+	return multierr.Append(l.action(), errArchive)
+}
+
 func (l *lifecycleService) action() error {
 	ctx := context.Background()
 	progress := LoadProgress(l.progressFilePath, l.l)
@@ -444,11 +464,29 @@ func (l *lifecycleService) processStreams(
 func (l *lifecycleService) processSingleStream(ctx context.Context, s *databasev1.Stream,
 	streamSVC stream.Service, tr *timestamp.TimeRange, shardNum uint32, replicas uint32, selector node.Selector, client queue.Client,
 ) (int, error) {
-	q, err := streamSVC.Stream(s.Metadata)
+	return migrateStreamTimeRange(ctx, s, streamSVC, tr, shardNum, replicas, selector, client, l.l)
+}
+
+// migrateStreamTimeRange queries the elements of the stream in the time range, and writes them to the nodes picked by the selector.
+func migrateStreamTimeRange(ctx context.Context, s *databasev1.Stream,
+	streamSVC stream.Query, tr *timestamp.TimeRange, shardNum uint32, replicas uint32, selector node.Selector, client queue.Client, l *logger.Logger,
+) (int, error) {
+	result, err := queryStreamTimeRange(ctx, s, streamSVC, tr, l)
 	if err != nil {
-		l.l.Error().Err(err).Msgf("failed to get stream %s", s.Metadata.Name)
 		return 0, err
 	}
+	return migrateStream(ctx, s, result, shardNum, replicas, selector, client, l), nil
+}
+
+// queryStreamTimeRange queries all the elements of the stream in the time range.
+func queryStreamTimeRange(ctx context.Context, s *databasev1.Stream, streamSVC stream.Query, tr *timestamp.TimeRange,
+	l *logger.Logger,
+) (model.StreamQueryResult, error) {
+	q, err := streamSVC.Stream(s.Metadata)
+	if err != nil {
+		l.Error().Err(err).Msgf("failed to get stream %s", s.Metadata.Name)
+		return nil, err
+	}
 
 	tagProjection := make([]model.TagProjection, len(s.TagFamilies))
 	entity := make([]*modelv1.TagValue, len(s.Entity.TagNames))
@@ -473,10 +511,10 @@ func (l *lifecycleService) processSingleStream(ctx context.Context, s *databasev
 		MaxElementSize: math.MaxInt,
 	})
 	if err != nil {
-		l.l.Error().Err(err).Msgf("failed to query stream %s", s.Metadata.Name)
-		return 0, err
+		l.Error().Err(err).Msgf("failed to query stream %s", s.Metadata.Name)
+		return nil, err
 	}
-	return migrateStream(ctx, s, result, shardNum, replicas, selector, client, l.l), nil
+	return result, nil
 }
 
 func (l *lifecycleService) deleteExpiredStreamSegments(ctx context.Context, g *commonv1.Group, tr *timestamp.TimeRange, progress *Progress) {
@@ -583,11 +621,29 @@ func (l *lifecycleService) processMeasures(
 func (l *lifecycleService) processSingleMeasure(ctx context.Context, m *databasev1.Measure,
 	measureSVC measure.Service, tr *timestamp.TimeRange, shardNum uint32, replicas uint32, selector node.Selector, client queue.Client,
 ) (int, error) {
-	q, err := measureSVC.Measure(m.Metadata)
+	return migrateMeasureTimeRange(ctx, m, measureSVC, tr, shardNum, replicas, selector, client, l.l)
+}
+
+// migrateMeasureTimeRange queries the data points of the measure in the time range, and writes them to the nodes picked by the selector.
+func migrateMeasureTimeRange(ctx context.Context, m *databasev1.Measure,
+	measureSVC measure.Query, tr *timestamp.TimeRange, shardNum uint32, replicas uint32, selector node.Selector, client queue.Client, l *logger.Logger,
+) (int, error) {
+	result, err := queryMeasureTimeRange(ctx, m, measureSVC, tr, l)
 	if err != nil {
-		l.l.Error().Err(err).Msgf("failed to get measure %s", m.Metadata.Name)
 		return 0, err
 	}
+	return migrateMeasure(ctx, m, result, shardNum, replicas, selector, client, l), nil
+}
+
+// queryMeasureTimeRange queries all the data points of the measure in the time range.
+func queryMeasureTimeRange(ctx context.Context, m *databasev1.Measure, measureSVC measure.Query, tr *timestamp.TimeRange,
+	l *logger.Logger,
+) (model.MeasureQueryResult, error) {
+	q, err := measureSVC.Measure(m.Metadata)
+	if err != nil {
+		l.Error().Err(err).Msgf("failed to get measure %s", m.Metadata.Name)
+		return nil, err
+	}
 
 	tagProjection := make([]model.TagProjection, len(m.TagFamilies))
 	for i, tf := range m.TagFamilies {
@@ -616,11 +672,10 @@ func (l *lifecycleService) processSingleMeasure(ctx context.Context, m *database
 		TimeRange:       tr,
 	})
 	if err != nil {
-		l.l.Error().Err(err).Msgf("failed to query measure %s", m.Metadata.Name)
-		return 0, err
+		l.Error().Err(err).Msgf("failed to query measure %s", m.Metadata.Name)
+		return nil, err
 	}
-
-	return migrateMeasure(ctx, m, result, shardNum, replicas, selector, client, l.l), nil
+	return result, nil
 }
 
 func (l *lifecycleService) deleteExpiredMeasureSegments(ctx context.Context, g *commonv1.Group, tr *timestamp.TimeRange, progress *Progress) {
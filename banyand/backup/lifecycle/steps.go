@@ -190,6 +190,11 @@ func migrateStream(ctx context.Context, s *databasev1.Stream, result model.Strea
 	copies := replicas + 1
 
 	entityLocator := partition.NewEntityLocator(s.TagFamilies, s.Entity, 0)
+	var shardingKeyLocator *partition.Locator
+	if shardingKey := s.GetShardingKey(); len(shardingKey.GetTagNames()) > 0 {
+		sl := partition.NewShardingKeyLocator(s.TagFamilies, shardingKey)
+		shardingKeyLocator = &sl
+	}
 
 	batch := client.NewBatchPublisher(30 * time.Second)
 	defer batch.Close()
@@ -214,6 +219,12 @@ func migrateStream(ctx context.Context, s *databasev1.Stream, result model.Strea
 				l.Error().Err(err).Msg("failed to locate entity")
 				continue
 			}
+			if shardingKeyLocator != nil {
+				if _, shardID, err = shardingKeyLocator.Locate(s.Metadata.Name, ev.TagFamilies, shardNum); err != nil {
+					l.Error().Err(err).Msg("failed to locate the shard by the sharding key")
+					continue
+				}
+			}
 
 			// Write to multiple replicas
 			for replicaID := uint32(0); replicaID < copies; replicaID++ {
@@ -226,6 +237,8 @@ func migrateStream(ctx context.Context, s *databasev1.Stream, result model.Strea
 					Request:      writeEntity,
 					ShardId:      uint32(shardID),
 					EntityValues: tagValues[1:].Encode(),
+					// keep the element ID in the next stage.
+					ElementId: sr.ElementIDs[i],
 				}
 				message := bus.NewBatchMessageWithNode(bus.MessageID(time.Now().UnixNano()), nodeID, iwr)
 				_, err = batch.Publish(ctx, data.TopicStreamWrite, message)
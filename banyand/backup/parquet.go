@@ -0,0 +1,401 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package backup
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/banyand/measure"
+	"github.com/apache/skywalking-banyandb/banyand/stream"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+)
+
+const (
+	parquetTimestampColumn = "timestamp"
+	parquetElementIDColumn = "element_id"
+	parquetVersionColumn   = "version"
+
+	parquetReadBatchSize = 256
+)
+
+type parquetColumn struct {
+	node  parquet.Node
+	index int
+}
+
+// parquetRows reads the rows of a Parquet file, whose top-level columns are mapped to the tags and the fields by their names.
+// A list column could be either a repeated primitive or the standard LIST.
+type parquetRows struct {
+	file    *os.File
+	reader  *parquet.Reader
+	columns map[string]parquetColumn
+	values  [][]parquet.Value
+	rows    []parquet.Row
+	pos     int
+	n       int
+}
+
+func openParquetRows(path string) (*parquetRows, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open %s: %w", path, err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("cannot stat %s: %w", path, err)
+	}
+	pf, err := parquet.OpenFile(f, info.Size())
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("cannot open the parquet file %s: %w", path, err)
+	}
+	schema := pf.Schema()
+	pr := &parquetRows{
+		file:    f,
+		reader:  parquet.NewReader(pf),
+		columns: make(map[string]parquetColumn),
+		rows:    make([]parquet.Row, parquetReadBatchSize),
+	}
+	for _, p := range schema.Columns() {
+		if len(p) != 1 && (len(p) != 3 || p[1] != "list" || (p[2] != "element" && p[2] != "item")) {
+			continue
+		}
+		leaf, ok := schema.Lookup(p...)
+		if !ok {
+			continue
+		}
+		pr.columns[p[0]] = parquetColumn{node: leaf.Node, index: leaf.ColumnIndex}
+	}
+	pr.values = make([][]parquet.Value, len(schema.Columns()))
+	if _, ok := pr.columns[parquetTimestampColumn]; !ok {
+		_ = pr.Close()
+		return nil, fmt.Errorf("the column %q is absent in %s", parquetTimestampColumn, path)
+	}
+	return pr, nil
+}
+
+func (pr *parquetRows) Close() error {
+	return errors.Join(pr.reader.Close(), pr.file.Close())
+}
+
+// next loads the values of the next row, or returns io.EOF at the end of the file.
+func (pr *parquetRows) next() error {
+	if pr.pos >= pr.n {
+		n, err := pr.reader.ReadRows(pr.rows)
+		if n == 0 {
+			if err == nil || errors.Is(err, io.EOF) {
+				return io.EOF
+			}
+			return fmt.Errorf("cannot read the parquet rows: %w", err)
+		}
+		pr.pos, pr.n = 0, n
+	}
+	for i := range pr.values {
+		pr.values[i] = pr.values[i][:0]
+	}
+	for _, v := range pr.rows[pr.pos] {
+		if !v.IsNull() {
+			pr.values[v.Column()] = append(pr.values[v.Column()], v)
+		}
+	}
+	pr.pos++
+	return nil
+}
+
+// column returns the non-null values of the named column in the current row.
+func (pr *parquetRows) column(name string) (parquet.Node, []parquet.Value) {
+	c, ok := pr.columns[name]
+	if !ok {
+		return nil, nil
+	}
+	return c.node, pr.values[c.index]
+}
+
+func (pr *parquetRows) timestamp() (*timestamppb.Timestamp, error) {
+	node, vv := pr.column(parquetTimestampColumn)
+	if len(vv) == 0 {
+		return nil, errors.New("the timestamp is absent")
+	}
+	t, err := parquetTime(node, vv[0])
+	if err != nil {
+		return nil, err
+	}
+	return timestamppb.New(t), nil
+}
+
+func (pr *parquetRows) tagFamilies(specs []*databasev1.TagFamilySpec) ([]*modelv1.TagFamilyForWrite, error) {
+	tagFamilies := make([]*modelv1.TagFamilyForWrite, len(specs))
+	for i, tfs := range specs {
+		tf := &modelv1.TagFamilyForWrite{Tags: make([]*modelv1.TagValue, len(tfs.GetTags()))}
+		for j, ts := range tfs.GetTags() {
+			node, vv := pr.column(ts.GetName())
+			tv, err := parquetTagValue(ts.GetType(), node, vv)
+			if err != nil {
+				return nil, fmt.Errorf("tag %s: %w", ts.GetName(), err)
+			}
+			tf.Tags[j] = tv
+		}
+		tagFamilies[i] = tf
+	}
+	return tagFamilies, nil
+}
+
+func (pr *parquetRows) fields(specs []*databasev1.FieldSpec) ([]*modelv1.FieldValue, error) {
+	fields := make([]*modelv1.FieldValue, len(specs))
+	for i, fs := range specs {
+		_, vv := pr.column(fs.GetName())
+		fv, err := parquetFieldValue(fs.GetFieldType(), vv)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", fs.GetName(), err)
+		}
+		fields[i] = fv
+	}
+	return fields, nil
+}
+
+type parquetElementReader struct {
+	*parquetRows
+	schema *databasev1.Stream
+}
+
+func newParquetElementReader(path string, schema *databasev1.Stream) (*parquetElementReader, error) {
+	pr, err := openParquetRows(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := pr.columns[parquetElementIDColumn]; !ok {
+		_ = pr.Close()
+		return nil, fmt.Errorf("the column %q is absent in %s", parquetElementIDColumn, path)
+	}
+	return &parquetElementReader{parquetRows: pr, schema: schema}, nil
+}
+
+func (r *parquetElementReader) Next() (*streamv1.ElementValue, error) {
+	if err := r.next(); err != nil {
+		return nil, err
+	}
+	ev := &streamv1.ElementValue{}
+	var err error
+	if ev.Timestamp, err = r.timestamp(); err != nil {
+		return nil, fmt.Errorf("%w: %w", stream.ErrInvalidElement, err)
+	}
+	_, vv := r.column(parquetElementIDColumn)
+	if len(vv) == 0 {
+		return nil, fmt.Errorf("%w: the element id is absent", stream.ErrInvalidElement)
+	}
+	switch vv[0].Kind() {
+	case parquet.ByteArray, parquet.FixedLenByteArray:
+		ev.ElementId = string(vv[0].ByteArray())
+	case parquet.Int32, parquet.Int64:
+		id, _ := parquetInt(vv[0])
+		ev.ElementId = strconv.FormatInt(id, 10)
+	default:
+		return nil, fmt.Errorf("%w: unsupported element id type %s", stream.ErrInvalidElement, vv[0].Kind())
+	}
+	if ev.TagFamilies, err = r.tagFamilies(r.schema.GetTagFamilies()); err != nil {
+		return nil, fmt.Errorf("%w: %w", stream.ErrInvalidElement, err)
+	}
+	return ev, nil
+}
+
+type parquetDataPointReader struct {
+	*parquetRows
+	schema *databasev1.Measure
+}
+
+func newParquetDataPointReader(path string, schema *databasev1.Measure) (*parquetDataPointReader, error) {
+	pr, err := openParquetRows(path)
+	if err != nil {
+		return nil, err
+	}
+	return &parquetDataPointReader{parquetRows: pr, schema: schema}, nil
+}
+
+func (r *parquetDataPointReader) Next() (*measurev1.DataPointValue, error) {
+	if err := r.next(); err != nil {
+		return nil, err
+	}
+	dp := &measurev1.DataPointValue{}
+	var err error
+	if dp.Timestamp, err = r.timestamp(); err != nil {
+		return nil, fmt.Errorf("%w: %w", measure.ErrInvalidDataPoint, err)
+	}
+	if _, vv := r.column(parquetVersionColumn); len(vv) > 0 {
+		if dp.Version, err = parquetInt(vv[0]); err != nil {
+			return nil, fmt.Errorf("%w: version: %w", measure.ErrInvalidDataPoint, err)
+		}
+	}
+	if dp.TagFamilies, err = r.tagFamilies(r.schema.GetTagFamilies()); err != nil {
+		return nil, fmt.Errorf("%w: %w", measure.ErrInvalidDataPoint, err)
+	}
+	if dp.Fields, err = r.fields(r.schema.GetFields()); err != nil {
+		return nil, fmt.Errorf("%w: %w", measure.ErrInvalidDataPoint, err)
+	}
+	return dp, nil
+}
+
+// parquetTime converts a timestamp value, which is either an int64 in milliseconds unless the column says another unit,
+// or an RFC3339 string.
+func parquetTime(node parquet.Node, v parquet.Value) (time.Time, error) {
+	switch v.Kind() {
+	case parquet.Int64:
+		if lt := node.Type().LogicalType(); lt != nil && lt.Timestamp != nil {
+			switch {
+			case lt.Timestamp.Unit.Micros != nil:
+				return time.UnixMicro(v.Int64()), nil
+			case lt.Timestamp.Unit.Nanos != nil:
+				return time.Unix(0, v.Int64()), nil
+			}
+		}
+		return time.UnixMilli(v.Int64()), nil
+	case parquet.ByteArray:
+		return time.Parse(time.RFC3339Nano, string(v.ByteArray()))
+	default:
+		return time.Time{}, fmt.Errorf("unsupported timestamp type %s", v.Kind())
+	}
+}
+
+func parquetInt(v parquet.Value) (int64, error) {
+	switch v.Kind() {
+	case parquet.Int32:
+		return int64(v.Int32()), nil
+	case parquet.Int64:
+		return v.Int64(), nil
+	default:
+		return 0, fmt.Errorf("expect an integer, but got %s", v.Kind())
+	}
+}
+
+func parquetBytes(v parquet.Value) ([]byte, error) {
+	switch v.Kind() {
+	case parquet.ByteArray, parquet.FixedLenByteArray:
+		return bytes.Clone(v.ByteArray()), nil
+	default:
+		return nil, fmt.Errorf("expect a byte array, but got %s", v.Kind())
+	}
+}
+
+func parquetTagValue(tagType databasev1.TagType, node parquet.Node, vv []parquet.Value) (*modelv1.TagValue, error) {
+	if len(vv) == 0 {
+		return pbv1.NullTagValue, nil
+	}
+	switch tagType {
+	case databasev1.TagType_TAG_TYPE_STRING:
+		b, err := parquetBytes(vv[0])
+		if err != nil {
+			return nil, err
+		}
+		return &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: string(b)}}}, nil
+	case databasev1.TagType_TAG_TYPE_INT:
+		i, err := parquetInt(vv[0])
+		if err != nil {
+			return nil, err
+		}
+		return &modelv1.TagValue{Value: &modelv1.TagValue_Int{Int: &modelv1.Int{Value: i}}}, nil
+	case databasev1.TagType_TAG_TYPE_STRING_ARRAY:
+		arr := make([]string, len(vv))
+		for i := range vv {
+			b, err := parquetBytes(vv[i])
+			if err != nil {
+				return nil, err
+			}
+			arr[i] = string(b)
+		}
+		return &modelv1.TagValue{Value: &modelv1.TagValue_StrArray{StrArray: &modelv1.StrArray{Value: arr}}}, nil
+	case databasev1.TagType_TAG_TYPE_INT_ARRAY:
+		arr := make([]int64, len(vv))
+		for i := range vv {
+			n, err := parquetInt(vv[i])
+			if err != nil {
+				return nil, err
+			}
+			arr[i] = n
+		}
+		return &modelv1.TagValue{Value: &modelv1.TagValue_IntArray{IntArray: &modelv1.IntArray{Value: arr}}}, nil
+	case databasev1.TagType_TAG_TYPE_DATA_BINARY:
+		b, err := parquetBytes(vv[0])
+		if err != nil {
+			return nil, err
+		}
+		return &modelv1.TagValue{Value: &modelv1.TagValue_BinaryData{BinaryData: b}}, nil
+	case databasev1.TagType_TAG_TYPE_TIMESTAMP:
+		t, err := parquetTime(node, vv[0])
+		if err != nil {
+			return nil, err
+		}
+		return &modelv1.TagValue{Value: &modelv1.TagValue_Timestamp{Timestamp: timestamppb.New(t)}}, nil
+	default:
+		return nil, fmt.Errorf("unsupported tag type %s", tagType)
+	}
+}
+
+func parquetFieldValue(fieldType databasev1.FieldType, vv []parquet.Value) (*modelv1.FieldValue, error) {
+	if len(vv) == 0 {
+		return pbv1.NullFieldValue, nil
+	}
+	switch fieldType {
+	case databasev1.FieldType_FIELD_TYPE_INT:
+		i, err := parquetInt(vv[0])
+		if err != nil {
+			return nil, err
+		}
+		return &modelv1.FieldValue{Value: &modelv1.FieldValue_Int{Int: &modelv1.Int{Value: i}}}, nil
+	case databasev1.FieldType_FIELD_TYPE_FLOAT:
+		var f float64
+		switch vv[0].Kind() {
+		case parquet.Float:
+			f = float64(vv[0].Float())
+		case parquet.Double:
+			f = vv[0].Double()
+		case parquet.Int32, parquet.Int64:
+			i, _ := parquetInt(vv[0])
+			f = float64(i)
+		default:
+			return nil, fmt.Errorf("expect a float, but got %s", vv[0].Kind())
+		}
+		return &modelv1.FieldValue{Value: &modelv1.FieldValue_Float{Float: &modelv1.Float{Value: f}}}, nil
+	case databasev1.FieldType_FIELD_TYPE_STRING:
+		b, err := parquetBytes(vv[0])
+		if err != nil {
+			return nil, err
+		}
+		return &modelv1.FieldValue{Value: &modelv1.FieldValue_Str{Str: &modelv1.Str{Value: string(b)}}}, nil
+	case databasev1.FieldType_FIELD_TYPE_DATA_BINARY:
+		b, err := parquetBytes(vv[0])
+		if err != nil {
+			return nil, err
+		}
+		return &modelv1.FieldValue{Value: &modelv1.FieldValue_BinaryData{BinaryData: b}}, nil
+	default:
+		return nil, fmt.Errorf("unsupported field type %s", fieldType)
+	}
+}
@@ -0,0 +1,147 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package backup
+
+import (
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/banyand/measure"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+)
+
+type testParquetElement struct {
+	Endpoint  *string  `parquet:"endpoint,optional"`
+	ElementID string   `parquet:"element_id"`
+	Service   string   `parquet:"service"`
+	Labels    []string `parquet:"labels,list"`
+	Timestamp int64    `parquet:"timestamp,timestamp(millisecond)"`
+	Duration  int32    `parquet:"duration"`
+}
+
+type testParquetDataPoint struct {
+	Timestamp string  `parquet:"timestamp"`
+	Service   string  `parquet:"service"`
+	Total     int64   `parquet:"total"`
+	Version   int64   `parquet:"version"`
+	Value     float64 `parquet:"value"`
+}
+
+func TestParquetElementReader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "elements.parquet")
+	endpoint := "/a"
+	begin := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, parquet.WriteFile(path, []testParquetElement{
+		{ElementID: "1", Timestamp: begin.UnixMilli(), Service: "svc", Endpoint: &endpoint, Labels: []string{"x", "y"}, Duration: -10},
+		{ElementID: "2", Timestamp: begin.Add(time.Hour).UnixMilli(), Service: "svc"},
+	}))
+	s := &databasev1.Stream{
+		Metadata: &commonv1.Metadata{Name: "sw", Group: "default"},
+		TagFamilies: []*databasev1.TagFamilySpec{{
+			Name: "searchable",
+			Tags: []*databasev1.TagSpec{
+				{Name: "service", Type: databasev1.TagType_TAG_TYPE_STRING},
+				{Name: "endpoint", Type: databasev1.TagType_TAG_TYPE_STRING},
+				{Name: "labels", Type: databasev1.TagType_TAG_TYPE_STRING_ARRAY},
+				{Name: "duration", Type: databasev1.TagType_TAG_TYPE_INT},
+				{Name: "absent", Type: databasev1.TagType_TAG_TYPE_INT},
+			},
+		}},
+	}
+	r, err := newParquetElementReader(path, s)
+	require.NoError(t, err)
+	defer r.Close()
+
+	ev, err := r.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "1", ev.GetElementId())
+	assert.True(t, ev.GetTimestamp().AsTime().Equal(begin))
+	tags := ev.GetTagFamilies()[0].GetTags()
+	require.Len(t, tags, 5)
+	assert.Equal(t, "svc", tags[0].GetStr().GetValue())
+	assert.Equal(t, "/a", tags[1].GetStr().GetValue())
+	assert.Equal(t, []string{"x", "y"}, tags[2].GetStrArray().GetValue())
+	assert.Equal(t, int64(-10), tags[3].GetInt().GetValue())
+	assert.Equal(t, pbv1.NullTagValue, tags[4])
+
+	ev, err = r.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "2", ev.GetElementId())
+	tags = ev.GetTagFamilies()[0].GetTags()
+	assert.Equal(t, pbv1.NullTagValue, tags[1])
+	assert.Equal(t, pbv1.NullTagValue, tags[2])
+
+	_, err = r.Next()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestParquetDataPointReader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data_points.parquet")
+	require.NoError(t, parquet.WriteFile(path, []testParquetDataPoint{
+		{Timestamp: "2024-01-01T00:00:00Z", Service: "svc", Total: 10, Version: 2, Value: 0.5},
+		{Timestamp: "yesterday", Service: "svc"},
+	}))
+	m := &databasev1.Measure{
+		Metadata: &commonv1.Metadata{Name: "service_cpm", Group: "default"},
+		TagFamilies: []*databasev1.TagFamilySpec{{
+			Name: "default",
+			Tags: []*databasev1.TagSpec{{Name: "service", Type: databasev1.TagType_TAG_TYPE_STRING}},
+		}},
+		Fields: []*databasev1.FieldSpec{
+			{Name: "total", FieldType: databasev1.FieldType_FIELD_TYPE_INT},
+			{Name: "value", FieldType: databasev1.FieldType_FIELD_TYPE_FLOAT},
+			{Name: "absent", FieldType: databasev1.FieldType_FIELD_TYPE_STRING},
+		},
+	}
+	r, err := newParquetDataPointReader(path, m)
+	require.NoError(t, err)
+	defer r.Close()
+
+	dp, err := r.Next()
+	require.NoError(t, err)
+	assert.True(t, dp.GetTimestamp().AsTime().Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, int64(2), dp.GetVersion())
+	assert.Equal(t, "svc", dp.GetTagFamilies()[0].GetTags()[0].GetStr().GetValue())
+	require.Len(t, dp.GetFields(), 3)
+	assert.Equal(t, int64(10), dp.GetFields()[0].GetInt().GetValue())
+	assert.InDelta(t, 0.5, dp.GetFields()[1].GetFloat().GetValue(), 1e-9)
+	assert.Equal(t, pbv1.NullFieldValue, dp.GetFields()[2])
+
+	_, err = r.Next()
+	assert.ErrorIs(t, err, measure.ErrInvalidDataPoint)
+
+	_, err = r.Next()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestParquetReaderWithoutTimestamp(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "elements.parquet")
+	require.NoError(t, parquet.WriteFile(path, []struct {
+		ElementID string `parquet:"element_id"`
+	}{{ElementID: "1"}}))
+	_, err := newParquetElementReader(path, &databasev1.Stream{})
+	assert.ErrorContains(t, err, `"timestamp" is absent`)
+}
@@ -0,0 +1,630 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/spf13/cobra"
+	"go.uber.org/multierr"
+	"google.golang.org/grpc"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/banyand/backup/snapshot"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	"github.com/apache/skywalking-banyandb/pkg/convert"
+	"github.com/apache/skywalking-banyandb/pkg/index/inverted"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+)
+
+const (
+	replicateChunkSize   = 1024 * 1024
+	replicateSeriesBatch = 1000
+	partMetadataFilename = "metadata.json"
+	seriesIndexDirname   = "sidx"
+)
+
+type replicateOptions struct {
+	remoteAddr     string
+	remoteCert     string
+	follow         followOptions
+	remoteTLS      bool
+	remoteInsecure bool
+}
+
+func newReplicateCommand() *cobra.Command {
+	var opts replicateOptions
+	cmd := &cobra.Command{
+		Use:   "replicate",
+		Short: "Replicate the newly sealed parts of a primary data node to a data node of a remote cluster",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if opts.follow.primaryAddr == "" {
+				return errors.New("primary-addr is required")
+			}
+			if opts.remoteAddr == "" {
+				return errors.New("remote-addr is required")
+			}
+			if len(opts.follow.streamGroups) == 0 && len(opts.follow.measureGroups) == 0 {
+				return errors.New("at least one of stream-groups or measure-groups is required")
+			}
+			return newReplicator(opts).run()
+		},
+	}
+	cmd.Flags().StringVar(&opts.follow.primaryAddr, "primary-addr", "", "gRPC address of the primary data node")
+	cmd.Flags().BoolVar(&opts.follow.enableTLS, "enable-tls", false, "Enable TLS for the gRPC connection to the primary")
+	cmd.Flags().BoolVar(&opts.follow.insecure, "insecure", false, "Skip the primary certificate verification")
+	cmd.Flags().StringVar(&opts.follow.cert, "cert", "", "Path to the gRPC certificate of the primary")
+	cmd.Flags().StringVar(&opts.remoteAddr, "remote-addr", "", "gRPC address of the remote data node")
+	cmd.Flags().BoolVar(&opts.remoteTLS, "remote-enable-tls", false, "Enable TLS for the gRPC connection to the remote")
+	cmd.Flags().BoolVar(&opts.remoteInsecure, "remote-insecure", false, "Skip the remote certificate verification")
+	cmd.Flags().StringVar(&opts.remoteCert, "remote-cert", "", "Path to the gRPC certificate of the remote")
+	cmd.Flags().StringSliceVar(&opts.follow.streamGroups, "stream-groups", nil, "Stream groups to replicate")
+	cmd.Flags().StringSliceVar(&opts.follow.measureGroups, "measure-groups", nil, "Measure groups to replicate")
+	cmd.Flags().StringVar(&opts.follow.streamRoot, "stream-root-path", "/tmp", "Staging directory of the stream parts pulled from the primary")
+	cmd.Flags().StringVar(&opts.follow.measureRoot, "measure-root-path", "/tmp", "Staging directory of the measure parts pulled from the primary")
+	cmd.Flags().DurationVar(&opts.follow.interval, "interval", 30*time.Second, "Interval of replicating the parts")
+	cmd.Flags().StringVar(&opts.follow.httpAddr, "http-addr", ":17916", "HTTP address serving the metrics and status API")
+	return cmd
+}
+
+type replicaCatalogStatus struct {
+	ReplicatedAt time.Time `json:"replicated_at"`
+	Error        string    `json:"error,omitempty"`
+	LagSeconds   float64   `json:"lag_seconds"`
+}
+
+type replicateStatus struct {
+	Catalogs map[string]*replicaCatalogStatus `json:"catalogs"`
+	Primary  string                           `json:"primary"`
+	Remote   string                           `json:"remote"`
+}
+
+type replicateMetrics struct {
+	shippedParts *prometheus.CounterVec
+	shippedBytes *prometheus.CounterVec
+	removedParts *prometheus.CounterVec
+	failures     *prometheus.CounterVec
+}
+
+type replicator struct {
+	start    time.Time
+	registry *prometheus.Registry
+	metrics  *replicateMetrics
+	follower *follower
+	l        *logger.Logger
+	status   map[commonv1.Catalog]*replicaCatalogStatus
+	// sentSeries holds the hashes of the series sent to the remote, keyed by the catalog, group and segment.
+	sentSeries map[string]map[uint64]struct{}
+	stopCh     chan struct{}
+	stoppedCh  chan struct{}
+	opts       replicateOptions
+	mu         sync.Mutex
+}
+
+func newReplicator(opts replicateOptions) *replicator {
+	r := &replicator{
+		opts:       opts,
+		start:      time.Now(),
+		follower:   newFollower(opts.follow),
+		l:          logger.GetLogger().Named("replicator"),
+		status:     make(map[commonv1.Catalog]*replicaCatalogStatus),
+		sentSeries: make(map[string]map[uint64]struct{}),
+		stopCh:     make(chan struct{}),
+		stoppedCh:  make(chan struct{}),
+		registry:   prometheus.NewRegistry(),
+	}
+	r.metrics = &replicateMetrics{
+		shippedParts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "banyandb_replicate_shipped_parts_total",
+			Help: "The number of the parts shipped to the remote",
+		}, []string{"catalog"}),
+		shippedBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "banyandb_replicate_shipped_bytes_total",
+			Help: "The bytes of the parts shipped to the remote",
+		}, []string{"catalog"}),
+		removedParts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "banyandb_replicate_removed_parts_total",
+			Help: "The number of the replicated parts removed because the primary dropped them",
+		}, []string{"catalog"}),
+		failures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "banyandb_replicate_failures_total",
+			Help: "The number of the failed replication rounds",
+		}, []string{"catalog"}),
+	}
+	r.registry.MustRegister(r.metrics.shippedParts, r.metrics.shippedBytes, r.metrics.removedParts, r.metrics.failures)
+	for _, c := range r.follower.catalogs() {
+		catalog := c
+		r.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name:        "banyandb_replicate_lag_seconds",
+			Help:        "The time since the remote caught up with the primary",
+			ConstLabels: prometheus.Labels{"catalog": snapshot.CatalogName(catalog)},
+		}, func() float64 {
+			return r.lag(catalog).Seconds()
+		}))
+	}
+	return r
+}
+
+func (r *replicator) run() error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(prometheus.Gatherers{r.registry, r.follower.registry}, promhttp.HandlerOpts{}))
+	mux.HandleFunc("/status", r.handleStatus)
+	srv := &http.Server{Addr: r.opts.follow.httpAddr, Handler: mux, ReadHeaderTimeout: 3 * time.Second}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			r.l.Error().Err(err).Msg("failed to serve the replicator API")
+		}
+	}()
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(ctx)
+	}()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	r.l.Info().Str("primary", r.opts.follow.primaryAddr).Str("remote", r.opts.remoteAddr).
+		Dur("interval", r.opts.follow.interval).Msg("start replicating the primary")
+	go r.replicate()
+	<-sigChan
+	r.l.Info().Msg("shutting down the replicator...")
+	close(r.stopCh)
+	<-r.stoppedCh
+	return nil
+}
+
+func (r *replicator) replicate() {
+	defer close(r.stoppedCh)
+	ticker := time.NewTicker(r.opts.follow.interval)
+	defer ticker.Stop()
+	for {
+		if err := r.replicateOnce(); err != nil {
+			r.l.Error().Err(err).Msg("failed to replicate the primary")
+		}
+		select {
+		case <-r.stopCh:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// replicateOnce pulls the latest snapshots of the primary into the staging directories,
+// and pushes the parts which the remote doesn't hold yet.
+// A catalog failing to be pulled isn't pushed, so that the remote never drops the parts by a partial snapshot.
+func (r *replicator) replicateOnce() error {
+	errs := r.follower.catchUp()
+	_, err := snapshot.Conn(r.opts.remoteAddr, r.opts.remoteTLS, r.opts.remoteInsecure, r.opts.remoteCert,
+		func(conn *grpc.ClientConn) (struct{}, error) {
+			client := databasev1.NewReplicationServiceClient(conn)
+			var pushErrs error
+			for _, c := range r.follower.catalogs() {
+				if r.pullFailed(c) {
+					continue
+				}
+				if errPush := r.push(client, c); errPush != nil {
+					r.metrics.failures.WithLabelValues(snapshot.CatalogName(c)).Inc()
+					r.setStatus(c, errPush)
+					pushErrs = multierr.Append(pushErrs, fmt.Errorf("failed to push %s: %w", snapshot.CatalogName(c), errPush))
+					continue
+				}
+				r.setStatus(c, nil)
+			}
+			return struct{}{}, pushErrs
+		})
+	return multierr.Append(errs, err)
+}
+
+func (r *replicator) pullFailed(catalog commonv1.Catalog) bool {
+	r.follower.mu.Lock()
+	defer r.follower.mu.Unlock()
+	st, ok := r.follower.status[catalog]
+	return !ok || st.Error != ""
+}
+
+func (r *replicator) push(client databasev1.ReplicationServiceClient, catalog commonv1.Catalog) error {
+	groups, _ := r.follower.groups(catalog)
+	localDir := filepath.Join(snapshot.LocalDir(r.follower.root(catalog), catalog), storage.DataDir)
+	shards, err := stagedShards(localDir, groups)
+	if err != nil {
+		return err
+	}
+	pushed := make(map[string]struct{})
+	var errs error
+	for _, sh := range shards {
+		sh.part.Catalog = catalog
+		pushed[seriesKey(sh.part)] = struct{}{}
+		if errShard := r.pushShard(client, sh); errShard != nil {
+			errs = multierr.Append(errs, fmt.Errorf("shard %s/%s/shard-%d: %w", sh.part.Group, sh.part.Segment, sh.part.ShardId, errShard))
+		}
+	}
+	prefix := snapshot.CatalogName(catalog) + "/"
+	for k := range r.sentSeries {
+		if _, ok := pushed[k]; !ok && strings.HasPrefix(k, prefix) {
+			delete(r.sentSeries, k)
+		}
+	}
+	return errs
+}
+
+// pushShard ships the parts of a shard which the remote doesn't hold, and commits the live parts
+// to make the remote shard mirror the primary one.
+func (r *replicator) pushShard(client databasev1.ReplicationServiceClient, sh stagedShard) error {
+	ctx := context.Background()
+	st, err := client.ReplicaStatus(ctx, &databasev1.ReplicaStatusRequest{Shard: sh.part})
+	if err != nil {
+		return err
+	}
+	applied := make(map[uint64]struct{}, len(st.GetApplied()))
+	for _, id := range st.GetApplied() {
+		applied[id] = struct{}{}
+	}
+	staged := make(map[string]uint64, len(st.GetStaged()))
+	for _, f := range st.GetStaged() {
+		staged[f.GetPath()] = f.GetSize()
+	}
+	catalog := snapshot.CatalogName(sh.part.Catalog)
+	shipped := 0
+	for _, id := range sh.parts {
+		if _, ok := applied[id]; ok {
+			continue
+		}
+		part := &databasev1.ReplicaPart{
+			Catalog:   sh.part.Catalog,
+			Group:     sh.part.Group,
+			Segment:   sh.part.Segment,
+			ShardId:   sh.part.ShardId,
+			PartId:    id,
+			Timestamp: sh.part.Timestamp,
+		}
+		n, errShip := shipPart(client, part, filepath.Join(sh.dir, partDirName(id)), staged)
+		r.metrics.shippedBytes.WithLabelValues(catalog).Add(float64(n))
+		if errShip != nil {
+			return fmt.Errorf("failed to ship part %s: %w", partDirName(id), errShip)
+		}
+		shipped++
+	}
+	obsolete := len(applied)
+	for _, id := range sh.parts {
+		if _, ok := applied[id]; ok {
+			obsolete--
+		}
+	}
+	if shipped == 0 && obsolete == 0 {
+		return nil
+	}
+	if shipped > 0 {
+		if err = r.pushSeries(client, sh); err != nil {
+			return err
+		}
+	}
+	resp, err := client.CommitReplica(ctx, &databasev1.CommitReplicaRequest{Shard: sh.part, Live: sh.parts})
+	if err != nil {
+		return err
+	}
+	r.metrics.shippedParts.WithLabelValues(catalog).Add(float64(resp.GetInstalled()))
+	r.metrics.removedParts.WithLabelValues(catalog).Add(float64(resp.GetRemoved()))
+	return nil
+}
+
+// pushSeries sends the series of the segment which aren't sent yet, so that the remote could look up the shipped parts.
+func (r *replicator) pushSeries(client databasev1.ReplicationServiceClient, sh stagedShard) error {
+	key := seriesKey(sh.part)
+	sent, ok := r.sentSeries[key]
+	if !ok {
+		sent = make(map[uint64]struct{})
+		r.sentSeries[key] = sent
+	}
+	var batch [][]byte
+	var hashes []uint64
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if _, err := client.ReplicateSeries(context.Background(), &databasev1.ReplicateSeriesRequest{Shard: sh.part, Series: batch}); err != nil {
+			return err
+		}
+		for _, h := range hashes {
+			sent[h] = struct{}{}
+		}
+		batch, hashes = batch[:0], hashes[:0]
+		return nil
+	}
+	seriesDir := filepath.Join(filepath.Dir(sh.dir), seriesIndexDirname)
+	if _, err := os.Stat(seriesDir); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	err := inverted.IterateSeries(context.Background(), seriesDir, func(entityValues []byte) error {
+		h := convert.Hash(entityValues)
+		if _, ok := sent[h]; ok {
+			return nil
+		}
+		batch = append(batch, append([]byte(nil), entityValues...))
+		hashes = append(hashes, h)
+		if len(batch) < replicateSeriesBatch {
+			return nil
+		}
+		return flush()
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send the series of %s: %w", seriesDir, err)
+	}
+	return flush()
+}
+
+// shipPart sends the files of a part. The files staged on the remote are resumed from their sizes.
+func shipPart(client databasev1.ReplicationServiceClient, part *databasev1.ReplicaPart, dir string, staged map[string]uint64) (shipped uint64, err error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := client.ReplicatePart(ctx)
+	if err != nil {
+		return 0, err
+	}
+	first := true
+	send := func(req *databasev1.ReplicatePartRequest) error {
+		if first {
+			req.Part = part
+			first = false
+		}
+		return stream.Send(req)
+	}
+	buf := make([]byte, replicateChunkSize)
+	for _, name := range names {
+		info, errStat := os.Stat(filepath.Join(dir, name))
+		if errStat != nil {
+			return shipped, errStat
+		}
+		offset := staged[partDirName(part.PartId)+"/"+name]
+		if offset > uint64(info.Size()) {
+			offset = 0
+		}
+		n, errSend := sendFile(send, filepath.Join(dir, name), name, offset, uint64(info.Size()), buf)
+		shipped += n
+		if errSend != nil {
+			return shipped, errSend
+		}
+	}
+	if first {
+		return shipped, fmt.Errorf("part %s is empty", dir)
+	}
+	if _, err = stream.CloseAndRecv(); err != nil && !errors.Is(err, io.EOF) {
+		return shipped, err
+	}
+	return shipped, nil
+}
+
+func sendFile(send func(*databasev1.ReplicatePartRequest) error, path, name string, offset, size uint64, buf []byte) (sent uint64, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		err = multierr.Append(err, f.Close())
+	}()
+	if _, err = f.Seek(int64(offset), io.SeekStart); err != nil {
+		return 0, err
+	}
+	for {
+		n, errRead := io.ReadFull(f, buf)
+		if errRead != nil && !errors.Is(errRead, io.EOF) && !errors.Is(errRead, io.ErrUnexpectedEOF) {
+			return sent, errRead
+		}
+		last := offset+sent+uint64(n) >= size
+		if err = send(&databasev1.ReplicatePartRequest{
+			Path:   name,
+			Offset: offset + sent,
+			Chunk:  buf[:n],
+			Last:   last,
+		}); err != nil {
+			return sent, err
+		}
+		sent += uint64(n)
+		if last {
+			return sent, nil
+		}
+		if n == 0 {
+			return sent, fmt.Errorf("file %s is truncated", path)
+		}
+	}
+}
+
+type stagedShard struct {
+	part  *databasev1.ReplicaPart
+	dir   string
+	parts []uint64
+}
+
+// stagedShards lists the shards of the followed groups in the staging directory, together with their parts.
+func stagedShards(localDir string, groups map[string]struct{}) ([]stagedShard, error) {
+	var result []stagedShard
+	for group := range groups {
+		segments, err := os.ReadDir(filepath.Join(localDir, group))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, seg := range segments {
+			if !seg.IsDir() || !strings.HasPrefix(seg.Name(), "seg-") {
+				continue
+			}
+			shardEntries, errRead := os.ReadDir(filepath.Join(localDir, group, seg.Name()))
+			if errRead != nil {
+				return nil, errRead
+			}
+			for _, se := range shardEntries {
+				id, ok := strings.CutPrefix(se.Name(), "shard-")
+				if !se.IsDir() || !ok {
+					continue
+				}
+				shardID, errParse := strconv.ParseUint(id, 10, 32)
+				if errParse != nil {
+					continue
+				}
+				dir := filepath.Join(localDir, group, seg.Name(), se.Name())
+				sh, errShard := readStagedShard(dir)
+				if errShard != nil {
+					return nil, errShard
+				}
+				if len(sh.parts) == 0 {
+					continue
+				}
+				sh.part.Group = group
+				sh.part.Segment = seg.Name()
+				sh.part.ShardId = uint32(shardID)
+				result = append(result, sh)
+			}
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i].part, result[j].part
+		if a.Group != b.Group {
+			return a.Group < b.Group
+		}
+		if a.Segment != b.Segment {
+			return a.Segment < b.Segment
+		}
+		return a.ShardId < b.ShardId
+	})
+	return result, nil
+}
+
+// readStagedShard lists the parts of a shard. The timestamp of the shard is the minimum one of its parts,
+// which locates the segment on the remote.
+func readStagedShard(dir string) (stagedShard, error) {
+	sh := stagedShard{dir: dir, part: &databasev1.ReplicaPart{}}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return sh, err
+	}
+	for _, e := range entries {
+		if !e.IsDir() || len(e.Name()) != 16 {
+			continue
+		}
+		id, errParse := strconv.ParseUint(e.Name(), 16, 64)
+		if errParse != nil {
+			continue
+		}
+		data, errRead := os.ReadFile(filepath.Join(dir, e.Name(), partMetadataFilename))
+		if errRead != nil {
+			// the part is being pulled.
+			continue
+		}
+		var pm struct {
+			MinTimestamp int64 `json:"minTimestamp"`
+		}
+		if err = json.Unmarshal(data, &pm); err != nil {
+			return sh, fmt.Errorf("failed to parse the metadata of part %s: %w", filepath.Join(dir, e.Name()), err)
+		}
+		if len(sh.parts) == 0 || pm.MinTimestamp < sh.part.Timestamp {
+			sh.part.Timestamp = pm.MinTimestamp
+		}
+		sh.parts = append(sh.parts, id)
+	}
+	sort.Slice(sh.parts, func(i, j int) bool { return sh.parts[i] < sh.parts[j] })
+	return sh, nil
+}
+
+func partDirName(id uint64) string {
+	return fmt.Sprintf("%016x", id)
+}
+
+func seriesKey(part *databasev1.ReplicaPart) string {
+	return snapshot.CatalogName(part.Catalog) + "/" + part.Group + "/" + part.Segment
+}
+
+func (r *replicator) setStatus(catalog commonv1.Catalog, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	st, ok := r.status[catalog]
+	if !ok {
+		st = &replicaCatalogStatus{}
+		r.status[catalog] = st
+	}
+	if err != nil {
+		st.Error = err.Error()
+		return
+	}
+	st.Error = ""
+	r.follower.mu.Lock()
+	if fs, ok := r.follower.status[catalog]; ok {
+		st.ReplicatedAt = fs.SyncedAt
+	}
+	r.follower.mu.Unlock()
+}
+
+// lag returns the age of the primary snapshot the remote caught up with.
+// It's the time since the replicator starts if the remote never catches up.
+func (r *replicator) lag(catalog commonv1.Catalog) time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if st, ok := r.status[catalog]; ok && !st.ReplicatedAt.IsZero() {
+		return time.Since(st.ReplicatedAt)
+	}
+	return time.Since(r.start)
+}
+
+func (r *replicator) handleStatus(w http.ResponseWriter, _ *http.Request) {
+	st := replicateStatus{
+		Primary:  r.opts.follow.primaryAddr,
+		Remote:   r.opts.remoteAddr,
+		Catalogs: make(map[string]*replicaCatalogStatus),
+	}
+	for _, c := range r.follower.catalogs() {
+		lag := r.lag(c)
+		r.mu.Lock()
+		cs := replicaCatalogStatus{}
+		if s, ok := r.status[c]; ok {
+			cs = *s
+		}
+		r.mu.Unlock()
+		cs.LagSeconds = lag.Seconds()
+		st.Catalogs[snapshot.CatalogName(c)] = &cs
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(st)
+}
@@ -0,0 +1,127 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package backup
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+)
+
+func TestStagedShards(t *testing.T) {
+	localDir := t.TempDir()
+	write := func(path, content string) {
+		p := filepath.Join(localDir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(p), storage.DirPerm); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o600); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+	write("g1/seg-20240102/shard-1/0000000000000002/metadata.json", `{"minTimestamp":200}`)
+	write("g1/seg-20240102/shard-1/0000000000000001/metadata.json", `{"minTimestamp":100}`)
+	// the part being pulled has no metadata yet.
+	write("g1/seg-20240102/shard-1/0000000000000003/primary.bin", "")
+	write("g1/seg-20240101/shard-0/000000000000000a/metadata.json", `{"minTimestamp":50}`)
+	write("g1/seg-20240101/sidx/seg.zap", "")
+	write("g1/seg-20240101/shard-2/not-a-part/metadata.json", `{}`)
+	write("g2/seg-20240101/shard-0/0000000000000001/metadata.json", `{"minTimestamp":1}`)
+
+	shards, err := stagedShards(localDir, map[string]struct{}{"g1": {}})
+	if err != nil {
+		t.Fatalf("failed to list the staged shards: %v", err)
+	}
+	if len(shards) != 2 {
+		t.Fatalf("expected 2 shards, got %d", len(shards))
+	}
+	want := []struct {
+		segment string
+		parts   []uint64
+		shardID uint32
+		ts      int64
+	}{
+		{segment: "seg-20240101", shardID: 0, ts: 50, parts: []uint64{10}},
+		{segment: "seg-20240102", shardID: 1, ts: 100, parts: []uint64{1, 2}},
+	}
+	for i, w := range want {
+		got := shards[i]
+		if got.part.Group != "g1" || got.part.Segment != w.segment || got.part.ShardId != w.shardID || got.part.Timestamp != w.ts {
+			t.Errorf("unexpected shard %d: %v", i, got.part)
+		}
+		if len(got.parts) != len(w.parts) {
+			t.Fatalf("expected parts %v, got %v", w.parts, got.parts)
+		}
+		for j := range w.parts {
+			if got.parts[j] != w.parts[j] {
+				t.Errorf("expected parts %v, got %v", w.parts, got.parts)
+			}
+		}
+	}
+}
+
+func TestSendFileResume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "primary.bin")
+	content := []byte("0123456789abcdef")
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	var received bytes.Buffer
+	var requests []*databasev1.ReplicatePartRequest
+	send := func(req *databasev1.ReplicatePartRequest) error {
+		if req.Offset != uint64(6+received.Len()) {
+			t.Errorf("unexpected offset %d", req.Offset)
+		}
+		received.Write(req.Chunk)
+		requests = append(requests, req)
+		return nil
+	}
+	// the remote staged the first 6 bytes before the transfer broke.
+	sent, err := sendFile(send, path, "primary.bin", 6, uint64(len(content)), make([]byte, 4))
+	if err != nil {
+		t.Fatalf("failed to send the file: %v", err)
+	}
+	if sent != 10 || received.String() != "6789abcdef" {
+		t.Fatalf("unexpected content %q sent %d", received.String(), sent)
+	}
+	if len(requests) != 3 || !requests[2].Last || requests[1].Last {
+		t.Fatalf("unexpected requests %v", requests)
+	}
+
+	// an empty file is sent as an empty last chunk.
+	emptyPath := filepath.Join(filepath.Dir(path), "empty.bin")
+	if err = os.WriteFile(emptyPath, nil, 0o600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	requests = nil
+	received.Reset()
+	sent, err = sendFile(func(req *databasev1.ReplicatePartRequest) error {
+		requests = append(requests, req)
+		return nil
+	}, emptyPath, "empty.bin", 0, 0, make([]byte, 4))
+	if err != nil {
+		t.Fatalf("failed to send the empty file: %v", err)
+	}
+	if sent != 0 || len(requests) != 1 || !requests[0].Last {
+		t.Fatalf("unexpected requests %v", requests)
+	}
+}
@@ -57,6 +57,9 @@ func NewRestoreCommand() *cobra.Command {
 	rootCmd.Flags().StringVar(&logging.Level, "logging-level", "info", "the root level of logging")
 	rootCmd.AddCommand(newRunCommand())
 	rootCmd.AddCommand(NewTimeDirCommand())
+	rootCmd.AddCommand(newFollowCommand())
+	rootCmd.AddCommand(newReplicateCommand())
+	rootCmd.AddCommand(newImportCommand())
 	return rootCmd
 }
 
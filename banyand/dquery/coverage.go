@@ -0,0 +1,86 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package dquery
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+)
+
+// timeouts bounds the distributed queries and the data nodes answering them.
+type timeouts struct {
+	query time.Duration
+	node  time.Duration
+}
+
+// of returns the timeout of the query and the one of the data nodes.
+// The data nodes leave a fifth of the query timeout for the liaison to receive and merge their partial results.
+func (t timeouts) of(timeout time.Duration) (query, node time.Duration) {
+	query = timeout
+	if query <= 0 {
+		query = t.query
+	}
+	node = query - query/5
+	if t.node > 0 && t.node < node {
+		node = t.node
+	}
+	return query, node
+}
+
+// coverage collects the results of the data nodes answering a distributed query.
+type coverage struct {
+	incomplete []*commonv1.NodeStatus
+	mu         sync.Mutex
+	total      uint32
+}
+
+func (c *coverage) report(status *commonv1.NodeStatus) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.total++
+	if status.Truncated || status.Reason != "" {
+		c.incomplete = append(c.incomplete, status)
+	}
+}
+
+// result returns nil if no data node is queried.
+func (c *coverage) result() *commonv1.QueryCoverage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.total == 0 {
+		return nil
+	}
+	return &commonv1.QueryCoverage{
+		TotalNodes:      c.total,
+		CompleteNodes:   c.total - uint32(len(c.incomplete)),
+		IncompleteNodes: c.incomplete,
+	}
+}
+
+// truncatedReason returns why the results are partial, or false if all the data nodes answer completely.
+func (c *coverage) truncatedReason() (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.incomplete) == 0 {
+		return "", false
+	}
+	return fmt.Sprintf("%d of %d data nodes are slow or fail to answer", len(c.incomplete), c.total), true
+}
@@ -0,0 +1,75 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package dquery
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+)
+
+func TestTimeoutsOf(t *testing.T) {
+	tests := []struct {
+		name      string
+		timeouts  timeouts
+		timeout   time.Duration
+		wantQuery time.Duration
+		wantNode  time.Duration
+	}{
+		{name: "default", timeouts: timeouts{query: 30 * time.Second}, wantQuery: 30 * time.Second, wantNode: 24 * time.Second},
+		{name: "request", timeouts: timeouts{query: 30 * time.Second}, timeout: 10 * time.Second, wantQuery: 10 * time.Second, wantNode: 8 * time.Second},
+		{
+			name: "node", timeouts: timeouts{query: 30 * time.Second, node: 5 * time.Second},
+			timeout: 10 * time.Second, wantQuery: 10 * time.Second, wantNode: 5 * time.Second,
+		},
+		{
+			name: "node exceeding the query", timeouts: timeouts{query: 30 * time.Second, node: 20 * time.Second},
+			timeout: 10 * time.Second, wantQuery: 10 * time.Second, wantNode: 8 * time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, node := tt.timeouts.of(tt.timeout)
+			assert.Equal(t, tt.wantQuery, query)
+			assert.Equal(t, tt.wantNode, node)
+		})
+	}
+}
+
+func TestCoverage(t *testing.T) {
+	c := &coverage{}
+	assert.Nil(t, c.result())
+	_, truncated := c.truncatedReason()
+	assert.False(t, truncated)
+
+	c.report(&commonv1.NodeStatus{Name: "data-0"})
+	c.report(&commonv1.NodeStatus{Name: "data-1", Truncated: true, Reason: "node data-1 reaches the deadline of the query"})
+	c.report(&commonv1.NodeStatus{Name: "data-2", Reason: "context deadline exceeded"})
+	result := c.result()
+	assert.Equal(t, uint32(3), result.TotalNodes)
+	assert.Equal(t, uint32(1), result.CompleteNodes)
+	assert.Len(t, result.IncompleteNodes, 2)
+	assert.Equal(t, "data-1", result.IncompleteNodes[0].Name)
+	assert.Equal(t, "data-2", result.IncompleteNodes[1].Name)
+	reason, truncated := c.truncatedReason()
+	assert.True(t, truncated)
+	assert.Equal(t, "2 of 3 data nodes are slow or fail to answer", reason)
+}
@@ -21,6 +21,7 @@ package dquery
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 
@@ -39,6 +40,8 @@ import (
 	"github.com/apache/skywalking-banyandb/banyand/stream"
 	"github.com/apache/skywalking-banyandb/pkg/bus"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/node"
+	pkgquery "github.com/apache/skywalking-banyandb/pkg/query"
 	"github.com/apache/skywalking-banyandb/pkg/query/executor"
 	"github.com/apache/skywalking-banyandb/pkg/run"
 	"github.com/apache/skywalking-banyandb/pkg/schema"
@@ -68,24 +71,33 @@ type queryService struct {
 	metaService          metadata.Repo
 	pipeline             queue.Server
 	omr                  observability.MetricsRegistry
+	fed                  *federation
+	nodeSel              node.Selector
 	log                  *logger.Logger
 	sqp                  *streamQueryProcessor
 	mqp                  *measureQueryProcessor
 	tqp                  *topNQueryProcessor
 	closer               *run.Closer
+	slowQueries          *pkgquery.SlowQueryRecorder
 	nodeID               string
 	hotStageNodeSelector string
-	slowQuery            time.Duration
+	timeouts             timeouts
+	slowQuery            run.DynamicDuration
+	slowRecords          int
 }
 
 // NewService return a new query service.
+// The stream queries pinning the sharding key are sent to the node picked by the nodeSel, which could be nil to broadcast them.
 func NewService(metaService metadata.Repo, pipeline queue.Server, broadcaster bus.Broadcaster, qClient queue.Client, omr observability.MetricsRegistry,
+	nodeSel node.Selector,
 ) (Service, error) {
 	svc := &queryService{
 		metaService: metaService,
 		closer:      run.NewCloser(1),
 		pipeline:    pipeline,
 		omr:         omr,
+		fed:         newFederation(),
+		nodeSel:     nodeSel,
 	}
 	svc.sqp = &streamQueryProcessor{
 		queryService: svc,
@@ -109,11 +121,26 @@ func (q *queryService) Name() string {
 
 func (q *queryService) FlagSet() *run.FlagSet {
 	fs := run.NewFlagSet("distributed-query")
-	fs.DurationVar(&q.slowQuery, "dst-slow-query", 5*time.Second, "distributed slow query threshold, 0 means no slow query log")
+	fs.DynamicDurationVar(&q.slowQuery, "dst-slow-query", 5*time.Second, "distributed slow query threshold, 0 means no slow query log")
+	fs.IntVar(&q.slowRecords, "dst-slow-query-records", 100, "the number of the last distributed slow queries kept for the web console, 0 means none")
+	fs.DurationVar(&q.fed.timeout, "federation-query-timeout", 10*time.Second, "timeout for querying the remote clusters federated with the groups")
+	fs.DurationVar(&q.timeouts.query, "dst-query-timeout", 30*time.Second, "timeout of the distributed queries which don't set their own timeout")
+	fs.DurationVar(&q.timeouts.node, "dst-node-timeout", 0,
+		"timeout of the data nodes answering a distributed query, which is at most four fifths of the query timeout and 0 means the maximum. "+
+			"The slower nodes return partial results")
 	return fs
 }
 
 func (q *queryService) Validate() error {
+	if q.timeouts.query <= 0 {
+		return errors.New("dst-query-timeout should be positive")
+	}
+	if q.timeouts.node < 0 {
+		return errors.New("dst-node-timeout should not be negative")
+	}
+	if q.slowRecords < 0 {
+		return errors.New("dst-slow-query-records should not be negative")
+	}
 	return nil
 }
 
@@ -130,6 +157,8 @@ func (q *queryService) PreRun(ctx context.Context) error {
 	}
 
 	q.log = logger.GetLogger(moduleName)
+	q.fed.log = q.log.Named("federation")
+	q.slowQueries = pkgquery.NewSlowQueryRecorder(moduleName, q.slowRecords)
 	q.sqp.streamService = stream.NewPortableRepository(q.metaService, q.log,
 		schema.NewMetrics(q.omr.With(streamScope)))
 	q.mqp.measureService = measure.NewPortableRepository(q.metaService, q.log,
@@ -139,16 +168,28 @@ func (q *queryService) PreRun(ctx context.Context) error {
 		q.pipeline.Subscribe(data.TopicStreamQuery, q.sqp),
 		q.pipeline.Subscribe(data.TopicMeasureQuery, q.mqp),
 		q.pipeline.Subscribe(data.TopicTopNQuery, q.tqp),
+		q.pipeline.Subscribe(data.TopicStreamSeriesLookup, &seriesLookupProcessor{queryService: q, broadcaster: q.sqp.broadcaster, topic: data.TopicStreamSeriesLookup}),
+		q.pipeline.Subscribe(data.TopicMeasureSeriesLookup, &seriesLookupProcessor{queryService: q, broadcaster: q.sqp.broadcaster, topic: data.TopicMeasureSeriesLookup}),
+		q.pipeline.Subscribe(data.TopicMeasureDeleteSeries, &deleteSeriesProcessor{queryService: q, broadcaster: q.sqp.broadcaster}),
+		q.pipeline.Subscribe(data.TopicStreamDeleteRange, &deleteRangeProcessor{queryService: q, broadcaster: q.sqp.broadcaster, topic: data.TopicStreamDeleteRange}),
+		q.pipeline.Subscribe(data.TopicMeasureDeleteRange, &deleteRangeProcessor{queryService: q, broadcaster: q.sqp.broadcaster, topic: data.TopicMeasureDeleteRange}),
 	)
 }
 
 func (q *queryService) GracefulStop() {
 	q.sqp.streamService.Close()
 	q.mqp.measureService.Close()
+	q.fed.close()
+	q.slowQueries.Close()
 	q.closer.Done()
 	q.closer.CloseThenWait()
 }
 
+// ShutdownPhase implements run.PhasedService.
+func (q *queryService) ShutdownPhase() run.ShutdownPhase {
+	return run.ShutdownPhaseQuery
+}
+
 func (q *queryService) Serve() run.StopNotify {
 	return q.closer.CloseNotify()
 }
@@ -196,8 +237,12 @@ var _ executor.DistributedExecutionContext = (*distributedContext)(nil)
 
 type distributedContext struct {
 	bus.Broadcaster
+	federation    executor.Federation
 	timeRange     *modelv1.TimeRange
 	nodeSelectors map[string][]string
+	coverage      *coverage
+	queryTimeout  time.Duration
+	nodeTimeout   time.Duration
 }
 
 func (dc *distributedContext) TimeRange() *modelv1.TimeRange {
@@ -207,3 +252,25 @@ func (dc *distributedContext) TimeRange() *modelv1.TimeRange {
 func (dc *distributedContext) NodeSelectors() map[string][]string {
 	return dc.nodeSelectors
 }
+
+func (dc *distributedContext) Federation() executor.Federation {
+	return dc.federation
+}
+
+func (dc *distributedContext) Timeout() (query, node time.Duration) {
+	return dc.queryTimeout, dc.nodeTimeout
+}
+
+func (dc *distributedContext) ReportNode(status *commonv1.NodeStatus) {
+	dc.coverage.report(status)
+}
+
+// executionError returns the error of a query failing to execute, which keeps the status of the queries
+// the data nodes don't admit.
+func executionError(err error, format string, args ...any) *common.Error {
+	var ce *common.Error
+	if errors.As(err, &ce) && ce.Status() == modelv1.Status_STATUS_QUERY_NOT_ADMITTED {
+		return common.NewErrorWithStatus(ce.Status(), fmt.Sprintf(format, args...))
+	}
+	return common.NewError(format, args...)
+}
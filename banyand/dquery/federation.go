@@ -0,0 +1,208 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package dquery
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/pkg/grpchelper"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/query/executor"
+)
+
+// federation holds the connections to the remote clusters federated with the groups.
+type federation struct {
+	conns   map[string]*grpc.ClientConn
+	log     *logger.Logger
+	timeout time.Duration
+	mu      sync.Mutex
+}
+
+func newFederation() *federation {
+	return &federation{conns: make(map[string]*grpc.ClientConn)}
+}
+
+// begin returns the federated query of the groups. It returns nil if the groups aren't federated with
+// any remote cluster, or the query is fanned out by another cluster. The groups whose options load returns nil are skipped.
+func (f *federation) begin(ctx context.Context, groups []string, load func(group string) *commonv1.ResourceOpts) *federatedQuery {
+	if executor.IsFederated(ctx) {
+		return nil
+	}
+	clusters := make(map[string]*remoteCluster)
+	for _, g := range groups {
+		for _, rc := range load(g).GetRemoteClusters() {
+			c, ok := clusters[rc.GetName()]
+			if !ok {
+				c = &remoteCluster{spec: rc}
+				clusters[rc.GetName()] = c
+			}
+			c.groups = append(c.groups, g)
+		}
+	}
+	if len(clusters) == 0 {
+		return nil
+	}
+	fq := &federatedQuery{f: f}
+	for _, c := range clusters {
+		fq.clusters = append(fq.clusters, c)
+	}
+	sort.Slice(fq.clusters, func(i, j int) bool {
+		return fq.clusters[i].spec.GetName() < fq.clusters[j].spec.GetName()
+	})
+	return fq
+}
+
+func (f *federation) conn(rc *commonv1.RemoteCluster) (*grpc.ClientConn, error) {
+	key := fmt.Sprintf("%s|%s|%t|%t|%s", rc.GetName(), rc.GetAddress(), rc.GetEnableTls(), rc.GetInsecure(), rc.GetCert())
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if conn, ok := f.conns[key]; ok {
+		return conn, nil
+	}
+	opts, err := grpchelper.SecureOptions(nil, rc.GetEnableTls(), rc.GetInsecure(), rc.GetCert())
+	if err != nil {
+		return nil, err
+	}
+	conn, err := grpc.NewClient(rc.GetAddress(), opts...)
+	if err != nil {
+		return nil, err
+	}
+	f.conns[key] = conn
+	return conn, nil
+}
+
+func (f *federation) close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for k, conn := range f.conns {
+		if err := conn.Close(); err != nil {
+			f.log.Warn().Err(err).Str("cluster", k).Msg("failed to close the connection to the remote cluster")
+		}
+		delete(f.conns, k)
+	}
+}
+
+type remoteCluster struct {
+	spec   *commonv1.RemoteCluster
+	groups []string
+}
+
+var _ executor.Federation = (*federatedQuery)(nil)
+
+// federatedQuery fans out a query to the remote clusters, and records their statuses.
+type federatedQuery struct {
+	f        *federation
+	statuses map[string]string
+	clusters []*remoteCluster
+	mu       sync.Mutex
+}
+
+func (fq *federatedQuery) QueryStream(ctx context.Context, req *streamv1.QueryRequest) []*streamv1.QueryResponse {
+	return fanOut(ctx, fq, func(ctx context.Context, conn *grpc.ClientConn, groups []string) (*streamv1.QueryResponse, error) {
+		r := proto.Clone(req).(*streamv1.QueryRequest)
+		r.Groups = groups
+		return streamv1.NewStreamServiceClient(conn).Query(ctx, r)
+	})
+}
+
+func (fq *federatedQuery) QueryMeasure(ctx context.Context, req *measurev1.QueryRequest) []*measurev1.QueryResponse {
+	return fanOut(ctx, fq, func(ctx context.Context, conn *grpc.ClientConn, groups []string) (*measurev1.QueryResponse, error) {
+		r := proto.Clone(req).(*measurev1.QueryRequest)
+		r.Groups = groups
+		return measurev1.NewMeasureServiceClient(conn).Query(ctx, r)
+	})
+}
+
+// fanOut queries the remote clusters concurrently. The responses of the failed clusters are dropped.
+func fanOut[T any](ctx context.Context, fq *federatedQuery, query func(context.Context, *grpc.ClientConn, []string) (T, error)) []T {
+	results := make([]T, len(fq.clusters))
+	errs := make([]error, len(fq.clusters))
+	var wg sync.WaitGroup
+	for i, c := range fq.clusters {
+		wg.Add(1)
+		go func(i int, c *remoteCluster) {
+			defer wg.Done()
+			conn, err := fq.f.conn(c.spec)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			rpcCtx, cancel := context.WithTimeout(metadata.NewOutgoingContext(ctx, metadata.Pairs(executor.FederatedHeader, "true")), fq.f.timeout)
+			defer cancel()
+			results[i], errs[i] = query(rpcCtx, conn, c.groups)
+		}(i, c)
+	}
+	wg.Wait()
+	var succeeded []T
+	fq.mu.Lock()
+	defer fq.mu.Unlock()
+	if fq.statuses == nil {
+		fq.statuses = make(map[string]string, len(fq.clusters))
+	}
+	for i, c := range fq.clusters {
+		if errs[i] != nil {
+			fq.statuses[c.spec.GetName()] = errs[i].Error()
+			fq.f.log.Warn().Err(errs[i]).Str("cluster", c.spec.GetName()).Msg("failed to query the remote cluster")
+			continue
+		}
+		if _, ok := fq.statuses[c.spec.GetName()]; !ok {
+			fq.statuses[c.spec.GetName()] = ""
+		}
+		succeeded = append(succeeded, results[i])
+	}
+	return succeeded
+}
+
+// clusterStatuses returns the statuses of the remote clusters. It returns nil if the query isn't federated.
+func (fq *federatedQuery) clusterStatuses() []*commonv1.ClusterStatus {
+	if fq == nil {
+		return nil
+	}
+	fq.mu.Lock()
+	defer fq.mu.Unlock()
+	result := make([]*commonv1.ClusterStatus, 0, len(fq.clusters))
+	for _, c := range fq.clusters {
+		st := &commonv1.ClusterStatus{Name: c.spec.GetName()}
+		if errMsg, ok := fq.statuses[c.spec.GetName()]; ok {
+			st.Error = errMsg
+		} else {
+			st.Error = "the cluster isn't queried"
+		}
+		result = append(result, st)
+	}
+	return result
+}
+
+// executorFederation converts the federated query to the interface, which avoids a non-nil interface holding a nil pointer.
+func (fq *federatedQuery) executorFederation() executor.Federation {
+	if fq == nil {
+		return nil
+	}
+	return fq
+}
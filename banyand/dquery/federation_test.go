@@ -0,0 +1,185 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package dquery
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/banyand/measure"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/query/executor"
+)
+
+type fakeCluster struct {
+	err       error
+	groups    []string
+	federated bool
+	mu        sync.Mutex
+}
+
+func (c *fakeCluster) record(ctx context.Context, groups []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.groups = groups
+	md, _ := metadata.FromIncomingContext(ctx)
+	c.federated = len(md.Get(executor.FederatedHeader)) > 0
+}
+
+type fakeMeasureCluster struct {
+	measurev1.UnimplementedMeasureServiceServer
+	*fakeCluster
+}
+
+func (c fakeMeasureCluster) Query(ctx context.Context, req *measurev1.QueryRequest) (*measurev1.QueryResponse, error) {
+	c.record(ctx, req.Groups)
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &measurev1.QueryResponse{DataPoints: []*measurev1.DataPoint{{Sid: 1}}}, nil
+}
+
+type fakeStreamCluster struct {
+	streamv1.UnimplementedStreamServiceServer
+	*fakeCluster
+}
+
+func (c fakeStreamCluster) Query(ctx context.Context, req *streamv1.QueryRequest) (*streamv1.QueryResponse, error) {
+	c.record(ctx, req.Groups)
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &streamv1.QueryResponse{Elements: []*streamv1.Element{{ElementId: "1"}}}, nil
+}
+
+func startCluster(t *testing.T, c *fakeCluster) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := grpc.NewServer()
+	measurev1.RegisterMeasureServiceServer(srv, fakeMeasureCluster{fakeCluster: c})
+	streamv1.RegisterStreamServiceServer(srv, fakeStreamCluster{fakeCluster: c})
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+	t.Cleanup(srv.Stop)
+	return lis.Addr().String()
+}
+
+func newTestFederation(t *testing.T) *federation {
+	f := newFederation()
+	f.log = logger.GetLogger("test", "federation")
+	f.timeout = 5 * time.Second
+	t.Cleanup(f.close)
+	return f
+}
+
+func TestFederationBegin(t *testing.T) {
+	f := newTestFederation(t)
+	opts := map[string]*commonv1.ResourceOpts{
+		"sw":    {RemoteClusters: []*commonv1.RemoteCluster{{Name: "b", Address: "b:17912"}, {Name: "a", Address: "a:17912"}}},
+		"sw-2":  {RemoteClusters: []*commonv1.RemoteCluster{{Name: "a", Address: "a:17912"}}},
+		"local": {},
+	}
+	load := func(group string) *commonv1.ResourceOpts {
+		return opts[group]
+	}
+
+	assert.Nil(t, f.begin(context.Background(), []string{"local"}, load))
+	assert.Nil(t, f.begin(context.Background(), []string{"absent"}, load))
+	federated := metadata.NewIncomingContext(context.Background(), metadata.Pairs(executor.FederatedHeader, "true"))
+	assert.Nil(t, f.begin(federated, []string{"sw"}, load), "the queries fanned out by another cluster aren't fanned out again")
+
+	fq := f.begin(context.Background(), []string{"sw", "sw-2", "absent", "local"}, load)
+	require.NotNil(t, fq)
+	require.Len(t, fq.clusters, 2)
+	assert.Equal(t, "a", fq.clusters[0].spec.GetName())
+	assert.Equal(t, []string{"sw", "sw-2"}, fq.clusters[0].groups)
+	assert.Equal(t, "b", fq.clusters[1].spec.GetName())
+	assert.Equal(t, []string{"sw"}, fq.clusters[1].groups)
+	assert.Nil(t, (*federatedQuery)(nil).clusterStatuses())
+	assert.Nil(t, (*federatedQuery)(nil).executorFederation())
+}
+
+func TestFederatedQueryFanOut(t *testing.T) {
+	healthy := &fakeCluster{}
+	broken := &fakeCluster{err: errors.New("the cluster is down")}
+	opts := &commonv1.ResourceOpts{RemoteClusters: []*commonv1.RemoteCluster{
+		{Name: "healthy", Address: startCluster(t, healthy)},
+		{Name: "broken", Address: startCluster(t, broken)},
+	}}
+	f := newTestFederation(t)
+	fq := f.begin(context.Background(), []string{"sw"}, func(string) *commonv1.ResourceOpts {
+		return opts
+	})
+	require.NotNil(t, fq)
+	statuses := fq.clusterStatuses()
+	require.Len(t, statuses, 2)
+	for _, st := range statuses {
+		assert.Equal(t, "the cluster isn't queried", st.Error)
+	}
+
+	measureResps := fq.QueryMeasure(context.Background(), &measurev1.QueryRequest{Name: "service_cpm", Groups: []string{"sw", "local"}})
+	require.Len(t, measureResps, 1)
+	assert.Len(t, measureResps[0].DataPoints, 1)
+	streamResps := fq.QueryStream(context.Background(), &streamv1.QueryRequest{Name: "sw", Groups: []string{"sw", "local"}})
+	require.Len(t, streamResps, 1)
+	assert.Len(t, streamResps[0].Elements, 1)
+
+	for _, c := range []*fakeCluster{healthy, broken} {
+		assert.Equal(t, []string{"sw"}, c.groups, "a cluster is only queried for the groups federated with it")
+		assert.True(t, c.federated, "the fanned-out queries carry the federated header")
+	}
+	statuses = fq.clusterStatuses()
+	require.Len(t, statuses, 2)
+	assert.Equal(t, "broken", statuses[0].Name)
+	assert.Contains(t, statuses[0].Error, "the cluster is down")
+	assert.Equal(t, "healthy", statuses[1].Name)
+	assert.Empty(t, statuses[1].Error)
+}
+
+type absentMeasures struct {
+	measure.SchemaService
+}
+
+func (absentMeasures) Measure(md *commonv1.Metadata) (measure.Measure, error) {
+	return nil, errors.New(md.GetName() + " is absent")
+}
+
+func TestMeasureRewriteWithoutTop(t *testing.T) {
+	p := &measureQueryProcessor{measureService: absentMeasures{}, queryService: &queryService{log: logger.GetLogger("test")}}
+	req := &measurev1.QueryRequest{Name: "service_cpm", Groups: []string{"sw"}, RewriteAggTopNResult: true}
+	var resp bus.Message
+	require.NotPanics(t, func() {
+		resp = p.Rev(context.Background(), bus.NewMessage(bus.MessageID(1), req))
+	})
+	assert.Contains(t, resp.Data().(*common.Error).Error(), "service_cpm is absent", "the request without the TopN is queried as it is")
+}
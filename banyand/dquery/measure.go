@@ -23,6 +23,8 @@ import (
 	"fmt"
 	"time"
 
+	"google.golang.org/protobuf/proto"
+
 	"github.com/apache/skywalking-banyandb/api/common"
 	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
 	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
@@ -34,6 +36,7 @@ import (
 	"github.com/apache/skywalking-banyandb/pkg/query/executor"
 	"github.com/apache/skywalking-banyandb/pkg/query/logical"
 	logical_measure "github.com/apache/skywalking-banyandb/pkg/query/logical/measure"
+	"github.com/apache/skywalking-banyandb/pkg/tracecontext"
 )
 
 type measureQueryProcessor struct {
@@ -46,13 +49,49 @@ type measureQueryProcessor struct {
 
 func (p *measureQueryProcessor) Rev(ctx context.Context, message bus.Message) (resp bus.Message) {
 	queryCriteria, ok := message.Data().(*measurev1.QueryRequest)
-	n := time.Now()
-	now := n.UnixNano()
+	now := time.Now().UnixNano()
 	if !ok {
 		resp = bus.NewMessage(bus.MessageID(now), common.NewError("invalid event data type"))
 		return
 	}
-	ml := p.log.Named("measure", queryCriteria.Groups[0], queryCriteria.Name)
+	if !queryCriteria.RewriteAggTopNResult || queryCriteria.Top == nil {
+		return p.query(ctx, queryCriteria)
+	}
+	// a liaison federating the group pushes down the aggregation and TopN as it does to the data nodes,
+	// so the raw data points of the ranked groups are returned like a data node does.
+	aggCriteria := proto.Clone(queryCriteria).(*measurev1.QueryRequest)
+	aggCriteria.RewriteAggTopNResult = false
+	aggCriteria.Top.Number *= 2
+	resp = p.query(ctx, aggCriteria)
+	aggResp, ok := resp.Data().(*measurev1.QueryResponse)
+	if !ok || len(aggResp.DataPoints) == 0 {
+		return
+	}
+	rawCriteria, err := logical_measure.RewriteAggTopNRequest(queryCriteria, aggResp.DataPoints)
+	if err != nil {
+		resp = bus.NewMessage(bus.MessageID(now), common.NewError("fail to rewrite the query criteria for measure %s: %v", queryCriteria.Name, err))
+		return
+	}
+	rawCriteria.Stages, rawCriteria.Trace = queryCriteria.Stages, queryCriteria.Trace
+	resp = p.query(ctx, rawCriteria)
+	rawResp, ok := resp.Data().(*measurev1.QueryResponse)
+	if !ok {
+		return
+	}
+	if aggResp.Truncated && !rawResp.Truncated {
+		rawResp.Truncated, rawResp.TruncatedReason = true, aggResp.TruncatedReason
+	}
+	if rawResp.SeriesOverflow == nil {
+		rawResp.SeriesOverflow = aggResp.SeriesOverflow
+	}
+	return
+}
+
+func (p *measureQueryProcessor) query(ctx context.Context, queryCriteria *measurev1.QueryRequest) (resp bus.Message) {
+	ql := tracecontext.Logger(ctx, p.log)
+	n := time.Now()
+	now := n.UnixNano()
+	ml := tracecontext.Logger(ctx, p.log.Named("measure", queryCriteria.Groups[0], queryCriteria.Name))
 	if e := ml.Debug(); e.Enabled() {
 		e.RawJSON("req", logger.Proto(queryCriteria)).Msg("received a query event")
 	}
@@ -128,14 +167,29 @@ func (p *measureQueryProcessor) Rev(ctx context.Context, message bus.Message) (r
 		}()
 	}
 
+	fq := p.fed.begin(ctx, queryCriteria.Groups, func(group string) *commonv1.ResourceOpts {
+		gs, ok := p.measureService.LoadGroup(group)
+		if !ok {
+			return nil
+		}
+		return gs.GetSchema().GetResourceOpts()
+	})
+	queryTimeout, nodeTimeout := p.timeouts.of(queryCriteria.GetTimeout().AsDuration())
+	cov := &coverage{}
+	// the data nodes report the series they count, which are summed up by the query.
+	ctx = query.WithSeriesLimit(ctx, 0)
 	mIterator, err := plan.(executor.MeasureExecutable).Execute(executor.WithDistributedExecutionContext(ctx, &distributedContext{
 		Broadcaster:   p.broadcaster,
+		federation:    fq.executorFederation(),
 		timeRange:     queryCriteria.TimeRange,
 		nodeSelectors: nodeSelectors,
+		coverage:      cov,
+		queryTimeout:  queryTimeout,
+		nodeTimeout:   nodeTimeout,
 	}))
 	if err != nil {
 		ml.Error().Err(err).Dur("latency", time.Since(n)).RawJSON("req", logger.Proto(queryCriteria)).Msg("fail to query")
-		resp = bus.NewMessage(bus.MessageID(now), common.NewError("fail to execute the query plan for measure %s: %v", queryCriteria.Name, err))
+		resp = bus.NewMessage(bus.MessageID(now), executionError(err, "fail to execute the query plan for measure %s: %v", queryCriteria.Name, err))
 		return
 	}
 	defer func() {
@@ -165,15 +219,23 @@ func (p *measureQueryProcessor) Rev(ctx context.Context, message bus.Message) (r
 			}
 		}
 	}()
-	qr := &measurev1.QueryResponse{DataPoints: result}
+	qr := &measurev1.QueryResponse{
+		DataPoints: result, ClusterStatuses: fq.clusterStatuses(), Coverage: cov.result(),
+		SeriesOverflow: query.GetSeriesOverflow(ctx),
+	}
+	if reason, truncated := cov.truncatedReason(); truncated {
+		ml.Warn().Str("reason", reason).RawJSON("coverage", logger.Proto(qr.Coverage)).Msg("the measure query is truncated")
+		qr.Truncated, qr.TruncatedReason = true, reason
+	}
 	if e := ml.Debug(); e.Enabled() {
 		e.RawJSON("ret", logger.Proto(qr)).Msg("got a measure")
 	}
 	resp = bus.NewMessage(bus.MessageID(now), qr)
-	if !queryCriteria.Trace && p.slowQuery > 0 {
+	if !queryCriteria.Trace && p.slowQuery.Load() > 0 {
 		latency := time.Since(n)
-		if latency > p.slowQuery {
-			p.log.Warn().Dur("latency", latency).RawJSON("req", logger.Proto(queryCriteria)).Int("resp_count", len(result)).Msg("measure slow query")
+		if latency > p.slowQuery.Load() {
+			ql.Warn().Dur("latency", latency).RawJSON("req", logger.Proto(queryCriteria)).Int("resp_count", len(result)).Msg("measure slow query")
+			p.slowQueries.Capture("measure", latency, queryCriteria, len(result))
 		}
 	}
 	return
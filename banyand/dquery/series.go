@@ -0,0 +1,143 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package dquery
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/multierr"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	"github.com/apache/skywalking-banyandb/api/data"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+)
+
+// seriesLookupProcessor looks up the series on all the data nodes and merges them.
+type seriesLookupProcessor struct {
+	broadcaster bus.Broadcaster
+	topic       bus.Topic
+	*queryService
+	*bus.UnImplementedHealthyListener
+}
+
+func (p *seriesLookupProcessor) Rev(_ context.Context, message bus.Message) bus.Message {
+	now := bus.MessageID(time.Now().UnixNano())
+	req, ok := message.Data().(*databasev1.SeriesServiceLookupRequest)
+	if !ok {
+		return bus.NewMessage(now, common.NewError("invalid event data type %T", message.Data()))
+	}
+	_, timeout := p.timeouts.of(0)
+	ff, err := p.broadcaster.Broadcast(timeout, p.topic, bus.NewMessage(now, req))
+	if err != nil {
+		return bus.NewMessage(now, common.NewError("failed to look up the series of %s: %v", req.GetName(), err))
+	}
+	var allErr error
+	var series []*databasev1.Series
+	for _, f := range ff {
+		m, getErr := f.Get()
+		if getErr != nil {
+			allErr = multierr.Append(allErr, getErr)
+			continue
+		}
+		switch d := m.Data().(type) {
+		case *databasev1.SeriesServiceLookupResponse:
+			series = append(series, d.GetSeries()...)
+		case *common.Error:
+			allErr = multierr.Append(allErr, d)
+		}
+	}
+	if allErr != nil {
+		return bus.NewMessage(now, common.NewError("failed to look up the series of %s: %v", req.GetName(), allErr))
+	}
+	return bus.NewMessage(now, &databasev1.SeriesServiceLookupResponse{Series: storage.MergeSeries(series, req.GetLimit())})
+}
+
+// deleteSeriesProcessor tombstones the series on all the data nodes.
+type deleteSeriesProcessor struct {
+	broadcaster bus.Broadcaster
+	*queryService
+	*bus.UnImplementedHealthyListener
+}
+
+func (p *deleteSeriesProcessor) Rev(_ context.Context, message bus.Message) bus.Message {
+	now := bus.MessageID(time.Now().UnixNano())
+	req, ok := message.Data().(*measurev1.InternalDeleteSeriesRequest)
+	if !ok {
+		return bus.NewMessage(now, common.NewError("invalid event data type %T", message.Data()))
+	}
+	_, timeout := p.timeouts.of(0)
+	ff, err := p.broadcaster.Broadcast(timeout, data.TopicMeasureDeleteSeries, bus.NewMessage(now, req))
+	if err != nil {
+		return bus.NewMessage(now, common.NewError("failed to delete the series of %s: %v", req.GetGroup(), err))
+	}
+	var allErr error
+	for _, f := range ff {
+		m, getErr := f.Get()
+		if getErr != nil {
+			allErr = multierr.Append(allErr, getErr)
+			continue
+		}
+		if d, ok := m.Data().(*common.Error); ok {
+			allErr = multierr.Append(allErr, d)
+		}
+	}
+	if allErr != nil {
+		return bus.NewMessage(now, common.NewError("failed to delete the series of %s: %v", req.GetGroup(), allErr))
+	}
+	return bus.NewMessage(now, &measurev1.DeleteSeriesResponse{DeletedAt: req.GetDeletedAt()})
+}
+
+// deleteRangeProcessor tombstones a time range of a group on all the data nodes.
+type deleteRangeProcessor struct {
+	broadcaster bus.Broadcaster
+	topic       bus.Topic
+	*queryService
+	*bus.UnImplementedHealthyListener
+}
+
+func (p *deleteRangeProcessor) Rev(_ context.Context, message bus.Message) bus.Message {
+	now := bus.MessageID(time.Now().UnixNano())
+	req, ok := message.Data().(*databasev1.InternalDeleteRangeRequest)
+	if !ok {
+		return bus.NewMessage(now, common.NewError("invalid event data type %T", message.Data()))
+	}
+	_, timeout := p.timeouts.of(0)
+	ff, err := p.broadcaster.Broadcast(timeout, p.topic, bus.NewMessage(now, req))
+	if err != nil {
+		return bus.NewMessage(now, common.NewError("failed to delete the range of %s: %v", req.GetGroup(), err))
+	}
+	var allErr error
+	for _, f := range ff {
+		m, getErr := f.Get()
+		if getErr != nil {
+			allErr = multierr.Append(allErr, getErr)
+			continue
+		}
+		if d, ok := m.Data().(*common.Error); ok {
+			allErr = multierr.Append(allErr, d)
+		}
+	}
+	if allErr != nil {
+		return bus.NewMessage(now, common.NewError("failed to delete the range of %s: %v", req.GetGroup(), allErr))
+	}
+	return bus.NewMessage(now, &databasev1.DeletionServiceDeleteRangeResponse{DeletedAt: req.GetDeletedAt()})
+}
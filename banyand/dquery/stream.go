@@ -24,14 +24,17 @@ import (
 
 	"github.com/apache/skywalking-banyandb/api/common"
 	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
 	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
 	"github.com/apache/skywalking-banyandb/banyand/stream"
 	"github.com/apache/skywalking-banyandb/pkg/bus"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/partition"
 	"github.com/apache/skywalking-banyandb/pkg/query"
 	"github.com/apache/skywalking-banyandb/pkg/query/executor"
 	"github.com/apache/skywalking-banyandb/pkg/query/logical"
 	logical_stream "github.com/apache/skywalking-banyandb/pkg/query/logical/stream"
+	"github.com/apache/skywalking-banyandb/pkg/tracecontext"
 )
 
 type streamQueryProcessor struct {
@@ -42,6 +45,7 @@ type streamQueryProcessor struct {
 }
 
 func (p *streamQueryProcessor) Rev(ctx context.Context, message bus.Message) (resp bus.Message) {
+	ql := tracecontext.Logger(ctx, p.log)
 	n := time.Now()
 	now := n.UnixNano()
 	queryCriteria, ok := message.Data().(*streamv1.QueryRequest)
@@ -49,11 +53,12 @@ func (p *streamQueryProcessor) Rev(ctx context.Context, message bus.Message) (re
 		resp = bus.NewMessage(bus.MessageID(now), common.NewError("invalid event data type"))
 		return
 	}
-	if p.log.Debug().Enabled() {
-		p.log.Debug().RawJSON("criteria", logger.Proto(queryCriteria)).Msg("received a query request")
+	if ql.Debug().Enabled() {
+		ql.Debug().RawJSON("criteria", logger.Proto(queryCriteria)).Msg("received a query request")
 	}
 
 	var schemas []logical.Schema
+	var streams []*databasev1.Stream
 	for _, group := range queryCriteria.Groups {
 		meta := &commonv1.Metadata{
 			Name:  queryCriteria.Name,
@@ -70,6 +75,7 @@ func (p *streamQueryProcessor) Rev(ctx context.Context, message bus.Message) (re
 			return
 		}
 		schemas = append(schemas, s)
+		streams = append(streams, ec.GetSchema())
 	}
 
 	plan, err := logical_stream.DistributedAnalyze(queryCriteria, schemas)
@@ -78,8 +84,8 @@ func (p *streamQueryProcessor) Rev(ctx context.Context, message bus.Message) (re
 		return
 	}
 
-	if p.log.Debug().Enabled() {
-		p.log.Debug().Str("plan", plan.String()).Msg("query plan")
+	if ql.Debug().Enabled() {
+		ql.Debug().Str("plan", plan.String()).Msg("query plan")
 	}
 	nodeSelectors := make(map[string][]string)
 	for _, g := range queryCriteria.Groups {
@@ -87,18 +93,18 @@ func (p *streamQueryProcessor) Rev(ctx context.Context, message bus.Message) (re
 			if ns, exist := p.parseNodeSelector(queryCriteria.Stages, gs.GetSchema().ResourceOpts); exist {
 				nodeSelectors[g] = ns
 			} else if len(gs.GetSchema().ResourceOpts.Stages) > 0 {
-				p.log.Error().Strs("req_stages", queryCriteria.Stages).Strs("default_stages", gs.GetSchema().GetResourceOpts().GetDefaultStages()).Msg("no stage found")
+				ql.Error().Strs("req_stages", queryCriteria.Stages).Strs("default_stages", gs.GetSchema().GetResourceOpts().GetDefaultStages()).Msg("no stage found")
 				resp = bus.NewMessage(bus.MessageID(now), common.NewError("no stage found in request or default stages in resource opts"))
 				return
 			}
 		} else {
-			p.log.Error().RawJSON("req", logger.Proto(queryCriteria)).Msg("group not found")
+			ql.Error().RawJSON("req", logger.Proto(queryCriteria)).Msg("group not found")
 			resp = bus.NewMessage(bus.MessageID(now), common.NewError("group %s not found", g))
 			return
 		}
 	}
 	if len(queryCriteria.Stages) > 0 && len(nodeSelectors) == 0 {
-		p.log.Error().RawJSON("req", logger.Proto(queryCriteria)).Msg("no stage found")
+		ql.Error().RawJSON("req", logger.Proto(queryCriteria)).Msg("no stage found")
 		resp = bus.NewMessage(bus.MessageID(now), common.NewError("no stage found"))
 		return
 	}
@@ -123,25 +129,95 @@ func (p *streamQueryProcessor) Rev(ctx context.Context, message bus.Message) (re
 			span.Stop()
 		}()
 	}
+	fq := p.fed.begin(ctx, queryCriteria.Groups, func(group string) *commonv1.ResourceOpts {
+		gs, ok := p.streamService.LoadGroup(group)
+		if !ok {
+			return nil
+		}
+		return gs.GetSchema().GetResourceOpts()
+	})
+	ctx, fc := logical_stream.WithFacetCollector(ctx, queryCriteria.GetFacet())
+	broadcaster := p.broadcaster
+	if len(nodeSelectors) == 0 && len(streams) == 1 {
+		if nb, pinned := p.pinNode(queryCriteria, streams[0]); pinned {
+			broadcaster = nb
+		}
+	}
+	queryTimeout, nodeTimeout := p.timeouts.of(queryCriteria.GetTimeout().AsDuration())
+	cov := &coverage{}
 	se := plan.(executor.StreamExecutable)
 	defer se.Close()
 	entities, err := se.Execute(executor.WithDistributedExecutionContext(ctx, &distributedContext{
-		Broadcaster:   p.broadcaster,
+		Broadcaster:   broadcaster,
+		federation:    fq.executorFederation(),
 		timeRange:     queryCriteria.TimeRange,
 		nodeSelectors: nodeSelectors,
+		coverage:      cov,
+		queryTimeout:  queryTimeout,
+		nodeTimeout:   nodeTimeout,
 	}))
 	if err != nil {
-		p.log.Error().Err(err).RawJSON("req", logger.Proto(queryCriteria)).Msg("fail to execute the query plan")
+		ql.Error().Err(err).RawJSON("req", logger.Proto(queryCriteria)).Msg("fail to execute the query plan")
 		resp = bus.NewMessage(bus.MessageID(now), common.NewError("execute the query plan for stream %s: %v", queryCriteria.Name, err))
 		return
 	}
 
-	resp = bus.NewMessage(bus.MessageID(now), &streamv1.QueryResponse{Elements: entities})
-	if !queryCriteria.Trace && p.slowQuery > 0 {
+	qr := &streamv1.QueryResponse{Elements: entities, Facets: fc.Result(), ClusterStatuses: fq.clusterStatuses(), Coverage: cov.result()}
+	if reason, truncated := cov.truncatedReason(); truncated {
+		ql.Warn().Str("reason", reason).RawJSON("coverage", logger.Proto(qr.Coverage)).Msg("the stream query is truncated")
+		qr.Truncated, qr.TruncatedReason = true, reason
+	}
+	resp = bus.NewMessage(bus.MessageID(now), qr)
+	if !queryCriteria.Trace && p.slowQuery.Load() > 0 {
 		latency := time.Since(n)
-		if latency > p.slowQuery {
-			p.log.Warn().Dur("latency", latency).RawJSON("req", logger.Proto(queryCriteria)).Int("resp_count", len(entities)).Msg("stream slow query")
+		if latency > p.slowQuery.Load() {
+			ql.Warn().Dur("latency", latency).RawJSON("req", logger.Proto(queryCriteria)).Int("resp_count", len(entities)).Msg("stream slow query")
+			p.slowQueries.Capture("stream", latency, queryCriteria, len(entities))
 		}
 	}
 	return
 }
+
+// pinNode returns the broadcaster sending the query to the node holding the shard,
+// if the query pins the sharding key of the stream.
+func (p *streamQueryProcessor) pinNode(queryCriteria *streamv1.QueryRequest, stm *databasev1.Stream) (bus.Broadcaster, bool) {
+	publisher, ok := p.broadcaster.(bus.Publisher)
+	if !ok || p.nodeSel == nil {
+		return nil, false
+	}
+	gs, ok := p.streamService.LoadGroup(stm.GetMetadata().GetGroup())
+	if !ok {
+		return nil, false
+	}
+	shardID, pinned, err := partition.PinShard(stm.GetMetadata().GetName(), stm.GetShardingKey(),
+		queryCriteria.GetCriteria(), gs.GetSchema().GetResourceOpts().GetShardNum())
+	if err != nil || !pinned {
+		return nil, false
+	}
+	// the series of a split shard are spread over two nodes.
+	for _, ss := range gs.GetSchema().GetResourceOpts().GetShardSplits() {
+		if ss.GetShardId() == uint32(shardID) {
+			return nil, false
+		}
+	}
+	nodeID, err := p.nodeSel.Pick(stm.GetMetadata().GetGroup(), stm.GetMetadata().GetName(), uint32(shardID), 0)
+	if err != nil {
+		p.log.Warn().Err(err).Uint32("shard_id", uint32(shardID)).Msg("fail to pick the node holding the pinned shard, broadcast the query instead")
+		return nil, false
+	}
+	return &nodeBroadcaster{publisher: publisher, node: nodeID}, true
+}
+
+// nodeBroadcaster sends the broadcast messages to a single node.
+type nodeBroadcaster struct {
+	publisher bus.Publisher
+	node      string
+}
+
+func (nb *nodeBroadcaster) Broadcast(_ time.Duration, topic bus.Topic, message bus.Message) ([]bus.Future, error) {
+	f, err := nb.publisher.Publish(context.Background(), topic, bus.NewMessageWithNode(message.ID(), nb.node, message.Data()))
+	if err != nil {
+		return nil, err
+	}
+	return []bus.Future{f}, nil
+}
@@ -36,6 +36,7 @@ import (
 	"github.com/apache/skywalking-banyandb/pkg/logger"
 	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
 	pkgquery "github.com/apache/skywalking-banyandb/pkg/query"
+	"github.com/apache/skywalking-banyandb/pkg/tracecontext"
 )
 
 const defaultTopNQueryTimeout = 10 * time.Second
@@ -48,9 +49,10 @@ type topNQueryProcessor struct {
 }
 
 func (t *topNQueryProcessor) Rev(ctx context.Context, message bus.Message) (resp bus.Message) {
+	ql := tracecontext.Logger(ctx, t.log)
 	request, ok := message.Data().(*measurev1.TopNRequest)
 	if !ok {
-		t.log.Warn().Msg("invalid event data type")
+		ql.Warn().Msg("invalid event data type")
 		return
 	}
 	n := time.Now()
@@ -63,7 +65,7 @@ func (t *topNQueryProcessor) Rev(ctx context.Context, message bus.Message) (resp
 		resp = bus.NewMessage(now, common.NewError("unspecified requested aggregation function"))
 		return
 	}
-	if e := t.log.Debug(); e.Enabled() {
+	if e := ql.Debug(); e.Enabled() {
 		e.RawJSON("req", logger.Proto(request)).Msg("received a topN query event")
 	}
 	nodeSelectors := make(map[string][]string)
@@ -72,18 +74,18 @@ func (t *topNQueryProcessor) Rev(ctx context.Context, message bus.Message) (resp
 			if ns, exist := t.parseNodeSelector(request.Stages, gs.GetSchema().ResourceOpts); exist {
 				nodeSelectors[g] = ns
 			} else if len(gs.GetSchema().ResourceOpts.Stages) > 0 {
-				t.log.Error().Strs("req_stages", request.Stages).Strs("default_stages", gs.GetSchema().GetResourceOpts().GetDefaultStages()).Msg("no stage found")
+				ql.Error().Strs("req_stages", request.Stages).Strs("default_stages", gs.GetSchema().GetResourceOpts().GetDefaultStages()).Msg("no stage found")
 				resp = bus.NewMessage(now, common.NewError("no stage found in request or default stages in resource opts"))
 				return
 			}
 		} else {
-			t.log.Error().Str("group", g).Msg("failed to load group")
+			ql.Error().Str("group", g).Msg("failed to load group")
 			resp = bus.NewMessage(now, common.NewError("failed to load group %s", g))
 			return
 		}
 	}
 	if len(request.Stages) > 0 && len(nodeSelectors) == 0 {
-		t.log.Error().RawJSON("req", logger.Proto(request)).Msg("no stage found")
+		ql.Error().RawJSON("req", logger.Proto(request)).Msg("no stage found")
 		resp = bus.NewMessage(now, common.NewError("no stage found"))
 		return
 	}
@@ -109,12 +111,16 @@ func (t *topNQueryProcessor) Rev(ctx context.Context, message bus.Message) (resp
 	}
 	agg := request.Agg
 	request.Agg = modelv1.AggregationFunction_AGGREGATION_FUNCTION_UNSPECIFIED
-	ff, err := t.broadcaster.Broadcast(defaultTopNQueryTimeout, data.TopicTopNQuery, bus.NewMessageWithNodeSelectors(now, nodeSelectors, request.TimeRange, request))
+	tc, _ := tracecontext.FromContext(ctx)
+	ff, err := t.broadcaster.Broadcast(defaultTopNQueryTimeout, data.TopicTopNQuery,
+		bus.NewMessageWithNodeSelectors(now, nodeSelectors, request.TimeRange, request).WithTraceContext(tc))
 	if err != nil {
 		resp = bus.NewMessage(now, common.NewError("execute the query %s: %v", request.GetName(), err))
 		return
 	}
 	var allErr error
+	// the data nodes report the series they count, which are summed up by the query.
+	ctx = pkgquery.WithSeriesLimit(ctx, 0)
 	aggregator := query.CreateTopNPostAggregator(request.GetTopN(),
 		agg, request.GetFieldValueSort())
 	var tags []string
@@ -127,6 +133,7 @@ func (t *topNQueryProcessor) Rev(ctx context.Context, message bus.Message) (resp
 				continue
 			}
 			topNResp := d.(*measurev1.TopNResponse)
+			pkgquery.ReportSeriesOverflow(ctx, topNResp.SeriesOverflow)
 			for _, l := range topNResp.Lists {
 				for _, tn := range l.Items {
 					if tags == nil {
@@ -145,21 +152,23 @@ func (t *topNQueryProcessor) Rev(ctx context.Context, message bus.Message) (resp
 		}
 	}
 	if allErr != nil {
-		resp = bus.NewMessage(now, common.NewError("execute the query %s: %v", request.GetName(), allErr))
+		resp = bus.NewMessage(now, executionError(allErr, "execute the query %s: %v", request.GetName(), allErr))
 		return
 	}
 	if tags == nil {
-		resp = bus.NewMessage(now, &measurev1.TopNResponse{})
+		resp = bus.NewMessage(now, &measurev1.TopNResponse{SeriesOverflow: pkgquery.GetSeriesOverflow(ctx)})
 		return
 	}
 	lists := aggregator.Val(tags)
 	resp = bus.NewMessage(now, &measurev1.TopNResponse{
-		Lists: lists,
+		Lists:          lists,
+		SeriesOverflow: pkgquery.GetSeriesOverflow(ctx),
 	})
-	if !request.Trace && t.slowQuery > 0 {
+	if !request.Trace && t.slowQuery.Load() > 0 {
 		latency := time.Since(n)
-		if latency > t.slowQuery {
-			t.log.Warn().Dur("latency", latency).RawJSON("req", logger.Proto(request)).Int("resp_count", len(lists)).Msg("top_n slow query")
+		if latency > t.slowQuery.Load() {
+			ql.Warn().Dur("latency", latency).RawJSON("req", logger.Proto(request)).Int("resp_count", len(lists)).Msg("top_n slow query")
+			t.slowQueries.Capture("top_n", latency, request, len(lists))
 		}
 	}
 	return
@@ -0,0 +1,31 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storage
+
+import (
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+)
+
+// AckOnFlush reports whether a write is acknowledged after it's flushed to the disk.
+// The level of the group applies if the write doesn't request one.
+func AckOnFlush(requested commonv1.AckLevel, group *commonv1.Group) bool {
+	if requested == commonv1.AckLevel_ACK_LEVEL_UNSPECIFIED {
+		requested = group.GetResourceOpts().GetAckLevel()
+	}
+	return requested == commonv1.AckLevel_ACK_LEVEL_FLUSH
+}
@@ -0,0 +1,82 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storage
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.uber.org/multierr"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	"github.com/apache/skywalking-banyandb/banyand/observability"
+	"github.com/apache/skywalking-banyandb/pkg/schema"
+)
+
+const partMetadataFilename = "metadata.json"
+
+// Bundle adds the storage usage of the groups and the metadata files of their segments and parts under root to the support bundle.
+// The metadata files are only attached for the selected groups, since a node could hold thousands of parts.
+func Bundle[T TSTable, O any](catalog commonv1.Catalog, repo schema.Repository, root string, groups []string, w observability.BundleWriter) error {
+	prefix := strings.ToLower(strings.TrimPrefix(catalog.String(), "CATALOG_")) + "/"
+	err := observability.WriteBundleProtos(w, prefix+"usage.json", StorageUsage[T, O](catalog, repo, groups, time.Now()))
+	for _, g := range groups {
+		if group, ok := repo.LoadGroup(g); !ok || group.GetSchema().GetCatalog() != catalog {
+			continue
+		}
+		err = multierr.Append(err, bundleMetadataFiles(filepath.Join(root, g), prefix+g+"/", w))
+	}
+	return err
+}
+
+// bundleMetadataFiles copies the metadata of the segments, the parts and the snapshots under dir,
+// which describe the layout of the data without exposing it.
+func bundleMetadataFiles(dir, prefix string, w observability.BundleWriter) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, errWalk error) error {
+		// the merges and the retention remove the parts and the segments while walking.
+		if errors.Is(errWalk, fs.ErrNotExist) {
+			return nil
+		}
+		if errWalk != nil {
+			return errWalk
+		}
+		if d.IsDir() {
+			return nil
+		}
+		name := d.Name()
+		if name != partMetadataFilename && name != metadataFilename && name != RangeTombstonesFilename && filepath.Ext(name) != ".snp" {
+			return nil
+		}
+		data, errRead := os.ReadFile(path)
+		if errors.Is(errRead, fs.ErrNotExist) {
+			return nil
+		}
+		if errRead != nil {
+			return errRead
+		}
+		rel, errRel := filepath.Rel(dir, path)
+		if errRel != nil {
+			return errRel
+		}
+		return w.WriteFile(prefix+filepath.ToSlash(rel), data)
+	})
+}
@@ -0,0 +1,63 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mapBundleWriter map[string]string
+
+func (w mapBundleWriter) WriteFile(name string, data []byte) error {
+	w[name] = string(data)
+	return nil
+}
+
+func TestBundleMetadataFiles(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"seg-20250101/metadata":                               "1.4.0",
+		"seg-20250101/shard-0/0000000000000001.snp":           `["0000000000000002"]`,
+		"seg-20250101/shard-0/0000000000000002/metadata.json": `{"totalCount":10}`,
+		"seg-20250101/shard-0/0000000000000002/primary.bin":   "data",
+		"seg-20250101/shard-0/0000000000000002/tag.family.tf": "data",
+		"seg-20250101/sidx/000000000001.seg":                  "index",
+		RangeTombstonesFilename:                               "[]",
+	} {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		require.NoError(t, os.WriteFile(p, []byte(content), 0o600))
+	}
+	w := mapBundleWriter{}
+	require.NoError(t, bundleMetadataFiles(dir, "stream/g/", w))
+	assert.Equal(t, mapBundleWriter{
+		"stream/g/seg-20250101/metadata":                               "1.4.0",
+		"stream/g/seg-20250101/shard-0/0000000000000001.snp":           `["0000000000000002"]`,
+		"stream/g/seg-20250101/shard-0/0000000000000002/metadata.json": `{"totalCount":10}`,
+		"stream/g/" + RangeTombstonesFilename:                          "[]",
+	}, w)
+
+	w = mapBundleWriter{}
+	require.NoError(t, bundleMetadataFiles(filepath.Join(dir, "absent"), "stream/absent/", w))
+	assert.Empty(t, w)
+}
@@ -0,0 +1,132 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storage
+
+import (
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/pkg/schema"
+)
+
+// DefaultDigestBucket is the width of the time buckets counting the elements if the request doesn't set it.
+const DefaultDigestBucket = time.Hour
+
+// TableDigest accumulates the parts, the series and the elements of a TSTable.
+type TableDigest struct {
+	series  map[common.SeriesID]struct{}
+	buckets map[int64]uint64
+	parts   []*databasev1.PartDigest
+	bucket  int64
+}
+
+// NewTableDigest returns a TableDigest counting the elements in the buckets of the width.
+func NewTableDigest(bucket time.Duration) *TableDigest {
+	if bucket <= 0 {
+		bucket = DefaultDigestBucket
+	}
+	return &TableDigest{
+		series:  make(map[common.SeriesID]struct{}),
+		buckets: make(map[int64]uint64),
+		bucket:  bucket.Nanoseconds(),
+	}
+}
+
+// AddPart appends the digest of a part.
+func (td *TableDigest) AddPart(pd *databasev1.PartDigest) {
+	td.parts = append(td.parts, pd)
+}
+
+// AddBlock counts the elements of a block of the series by their timestamps.
+func (td *TableDigest) AddBlock(sid common.SeriesID, timestamps []int64) {
+	td.series[sid] = struct{}{}
+	for _, ts := range timestamps {
+		begin := ts - ts%td.bucket
+		if ts < 0 && ts%td.bucket != 0 {
+			begin -= td.bucket
+		}
+		td.buckets[begin]++
+	}
+}
+
+// Segment returns the digest of the segment, whose parts are sorted by their checksums to be compared across the replicas.
+func (td *TableDigest) Segment(name string) *databasev1.SegmentDigest {
+	sd := &databasev1.SegmentDigest{
+		Segment:     name,
+		SeriesCount: uint64(len(td.series)),
+		Parts:       td.parts,
+	}
+	sort.Slice(sd.Parts, func(i, j int) bool {
+		return sd.Parts[i].GetChecksum() < sd.Parts[j].GetChecksum()
+	})
+	for begin, count := range td.buckets {
+		sd.Buckets = append(sd.Buckets, &databasev1.BucketCount{Begin: begin, Count: count})
+	}
+	sort.Slice(sd.Buckets, func(i, j int) bool {
+		return sd.Buckets[i].GetBegin() < sd.Buckets[j].GetBegin()
+	})
+	return sd
+}
+
+// digest opens the closed segments to digest the shard, then they're closed again once they're idle.
+// The segments which don't hold the shard are skipped.
+func (sc *segmentController[T, O]) digest(shardID common.ShardID, bucket time.Duration) ([]*databasev1.SegmentDigest, error) {
+	ss, err := sc.segments(true)
+	if err != nil {
+		return nil, err
+	}
+	var result []*databasev1.SegmentDigest
+	for _, s := range ss {
+		if sLst := s.sLst.Load(); sLst != nil {
+			for _, sh := range *sLst {
+				if sh.id != shardID {
+					continue
+				}
+				td := NewTableDigest(bucket)
+				sh.table.Digest(td)
+				result = append(result, td.Segment(filepath.Base(s.location)))
+			}
+		}
+		s.DecRef()
+	}
+	return result, nil
+}
+
+// ShardDigest digests the shard of the group in the catalog.
+// It returns nil if the group isn't in the catalog or isn't loaded on this node.
+func ShardDigest[T TSTable, O any](catalog commonv1.Catalog, repo schema.Repository, req *databasev1.IntegrityServiceDigestRequest) (
+	*databasev1.IntegrityServiceDigestResponse, error,
+) {
+	g, ok := repo.LoadGroup(req.GetGroup())
+	if !ok || g.GetSchema().GetCatalog() != catalog {
+		return nil, nil
+	}
+	db, ok := g.SupplyTSDB().(TSDB[T, O])
+	if !ok || db == nil {
+		return nil, nil
+	}
+	segments, err := db.Digest(common.ShardID(req.GetShardId()), req.GetBucket().AsDuration())
+	if err != nil {
+		return nil, err
+	}
+	return &databasev1.IntegrityServiceDigestResponse{Catalog: catalog, Segments: segments}, nil
+}
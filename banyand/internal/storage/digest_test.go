@@ -0,0 +1,71 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+)
+
+func TestDigest(t *testing.T) {
+	tsdb, c, _, dfFn := setUpDB(t)
+	defer dfFn()
+	first := c.Now()
+	for i := 0; i < 3; i++ {
+		seg, err := tsdb.CreateSegmentIfNotExist(first.AddDate(0, 0, i))
+		require.NoError(t, err)
+		_, err = seg.CreateTSTableIfNotExist(common.ShardID(i % 2))
+		require.NoError(t, err)
+		seg.DecRef()
+	}
+
+	dd, err := tsdb.Digest(common.ShardID(0), 0)
+	require.NoError(t, err)
+	require.Len(t, dd, 2, "the segments without the shard are skipped")
+	for _, d := range dd {
+		assert.Equal(t, uint64(1), d.GetSeriesCount())
+		assert.Equal(t, []*databasev1.BucketCount{{Begin: 0, Count: 1}}, d.GetBuckets())
+	}
+
+	require.NoError(t, tsdb.Close())
+	_, err = tsdb.Digest(common.ShardID(0), 0)
+	require.Error(t, err)
+}
+
+func TestTableDigest(t *testing.T) {
+	td := NewTableDigest(time.Second)
+	td.AddBlock(1, []int64{int64(2 * time.Second), int64(time.Second + 1)})
+	td.AddBlock(2, []int64{-1, int64(time.Second)})
+	td.AddPart(&databasev1.PartDigest{Id: 1, Checksum: 9})
+	td.AddPart(&databasev1.PartDigest{Id: 2, Checksum: 3})
+	sd := td.Segment("seg-20240101")
+	assert.Equal(t, "seg-20240101", sd.GetSegment())
+	assert.Equal(t, uint64(2), sd.GetSeriesCount())
+	assert.Equal(t, []*databasev1.BucketCount{
+		{Begin: -int64(time.Second), Count: 1},
+		{Begin: int64(time.Second), Count: 2},
+		{Begin: int64(2 * time.Second), Count: 1},
+	}, sd.GetBuckets())
+	assert.Equal(t, uint64(3), sd.GetParts()[0].GetChecksum())
+}
@@ -49,6 +49,7 @@ type seriesIndex struct {
 	store   index.SeriesStore
 	l       *logger.Logger
 	metrics *inverted.Metrics
+	seen    seenTracker
 	p       common.Position
 }
 
@@ -76,15 +77,33 @@ func newSeriesIndex(ctx context.Context, root string, flushTimeoutSeconds int64,
 	return si, nil
 }
 
+// Insert inserts the absent series, and rewrites the existing ones whose first or last seen timestamps are widened.
 func (s *seriesIndex) Insert(docs index.Documents) error {
-	return s.store.InsertSeriesBatch(index.Batch{
-		Documents: docs,
+	s.seen.mu.Lock()
+	defer s.seen.mu.Unlock()
+	unchanged, rewrites, err := s.seen.track(s.store, docs, seenResolution)
+	if err != nil {
+		return err
+	}
+	if err = s.store.InsertSeriesBatch(index.Batch{
+		Documents: unchanged,
+	}); err != nil {
+		return err
+	}
+	return s.store.UpdateSeriesBatch(index.Batch{
+		Documents: rewrites,
 	})
 }
 
 func (s *seriesIndex) Update(docs index.Documents) error {
+	s.seen.mu.Lock()
+	defer s.seen.mu.Unlock()
+	unchanged, rewrites, err := s.seen.track(s.store, docs, 0)
+	if err != nil {
+		return err
+	}
 	return s.store.UpdateSeriesBatch(index.Batch{
-		Documents: docs,
+		Documents: append(unchanged, rewrites...),
 	})
 }
 
@@ -135,6 +154,7 @@ func (s *seriesIndex) filter(ctx context.Context, series []*pbv1.Series,
 	if err != nil {
 		return SeriesData{}, errors.WithMessagef(err, "failed to convert index series to series list, matchers: %v, matched: %d", seriesMatchers, len(ss))
 	}
+	data.Seen = seenRangesOf(ss)
 	return data, nil
 }
 
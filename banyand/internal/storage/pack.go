@@ -0,0 +1,253 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storage
+
+import (
+	"io"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/apache/skywalking-banyandb/pkg/encoding"
+	"github.com/apache/skywalking-banyandb/pkg/fs"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+)
+
+const (
+	// PackFilename is the name of the container file which packs the files of a part.
+	PackFilename = "part.pack"
+
+	packMagic      = uint64(0x6b6361702d626462) // "bdb-pack" in little endian
+	packFooterSize = 16
+)
+
+// PackEntry is a file packed into a container.
+type PackEntry struct {
+	Name string
+	Data []byte
+}
+
+// MustWritePack writes the entries into the container file in the directory, which replaces a file per entry
+// to save the inodes and the file descriptors of the small parts.
+// The container holds the data of the entries, followed by the index of their names, offsets and sizes,
+// and ends with the offset of the index and the magic number.
+func MustWritePack(fileSystem fs.FileSystem, dir string, entries []PackEntry) {
+	var size int
+	for i := range entries {
+		size += len(entries[i].Data)
+	}
+	buf := make([]byte, 0, size+len(entries)*32+packFooterSize)
+	offsets := make([]uint64, len(entries))
+	for i := range entries {
+		offsets[i] = uint64(len(buf))
+		buf = append(buf, entries[i].Data...)
+	}
+	indexOffset := uint64(len(buf))
+	buf = encoding.VarUint64ToBytes(buf, uint64(len(entries)))
+	for i := range entries {
+		buf = encoding.EncodeBytes(buf, []byte(entries[i].Name))
+		buf = encoding.VarUint64ToBytes(buf, offsets[i])
+		buf = encoding.VarUint64ToBytes(buf, uint64(len(entries[i].Data)))
+	}
+	buf = encoding.Uint64ToBytes(buf, indexOffset)
+	buf = encoding.Uint64ToBytes(buf, packMagic)
+	fs.MustFlush(fileSystem, buf, filepath.Join(dir, PackFilename), FilePerm)
+}
+
+type packEntry struct {
+	offset uint64
+	size   uint64
+}
+
+// Pack is an opened container file. The readers of its entries share the file, which is closed by the pack.
+type Pack struct {
+	f       fs.File
+	entries map[string]packEntry
+}
+
+// OpenPack opens the container file in the directory and loads its index.
+func OpenPack(fileSystem fs.FileSystem, dir string) (*Pack, error) {
+	f, err := fileSystem.OpenFile(filepath.Join(dir, PackFilename))
+	if err != nil {
+		return nil, err
+	}
+	p := &Pack{f: f}
+	if err = p.loadIndex(); err != nil {
+		_ = f.Close()
+		return nil, errors.WithMessagef(err, "cannot load the index of %s", f.Path())
+	}
+	return p, nil
+}
+
+func (p *Pack) loadIndex() error {
+	size, err := p.f.Size()
+	if err != nil {
+		return err
+	}
+	if size < packFooterSize {
+		return errors.Errorf("the size %d is less than the footer", size)
+	}
+	footer := make([]byte, packFooterSize)
+	if _, err = p.f.Read(size-packFooterSize, footer); err != nil {
+		return err
+	}
+	if encoding.BytesToUint64(footer[8:]) != packMagic {
+		return errors.New("the magic number mismatches")
+	}
+	indexOffset := encoding.BytesToUint64(footer[:8])
+	if indexOffset > uint64(size-packFooterSize) {
+		return errors.Errorf("the index offset %d is out of the file", indexOffset)
+	}
+	src := make([]byte, uint64(size-packFooterSize)-indexOffset)
+	if _, err = p.f.Read(int64(indexOffset), src); err != nil {
+		return err
+	}
+	src, n := encoding.BytesToVarUint64(src)
+	p.entries = make(map[string]packEntry, n)
+	for i := uint64(0); i < n; i++ {
+		var name []byte
+		var e packEntry
+		if src, name, err = encoding.DecodeBytes(src); err != nil {
+			return err
+		}
+		src, e.offset = encoding.BytesToVarUint64(src)
+		src, e.size = encoding.BytesToVarUint64(src)
+		if e.offset+e.size > indexOffset {
+			return errors.Errorf("the entry %s is out of the data", name)
+		}
+		p.entries[string(name)] = e
+	}
+	return nil
+}
+
+// Names returns the sorted names of the entries.
+func (p *Pack) Names() []string {
+	names := make([]string, 0, len(p.entries))
+	for name := range p.entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Reader returns the reader of the entry, which is valid until the pack is closed.
+func (p *Pack) Reader(name string) (fs.Reader, bool) {
+	e, ok := p.entries[name]
+	if !ok {
+		return nil, false
+	}
+	return &packReader{f: p.f, name: name, entry: e}, true
+}
+
+// MustReader returns the reader of the entry and panics if it's absent.
+func (p *Pack) MustReader(name string) fs.Reader {
+	r, ok := p.Reader(name)
+	if !ok {
+		logger.Panicf("%s is absent in %s", name, p.f.Path())
+	}
+	return r
+}
+
+// Read reads the whole entry.
+func (p *Pack) Read(name string) ([]byte, error) {
+	e, ok := p.entries[name]
+	if !ok {
+		return nil, errors.Errorf("%s is absent in %s", name, p.f.Path())
+	}
+	data := make([]byte, e.size)
+	if e.size == 0 {
+		return data, nil
+	}
+	if _, err := p.f.Read(int64(e.offset), data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Path returns the path of the container file.
+func (p *Pack) Path() string {
+	return p.f.Path()
+}
+
+// Close closes the container file.
+func (p *Pack) Close() error {
+	return p.f.Close()
+}
+
+type packReader struct {
+	f     fs.File
+	name  string
+	entry packEntry
+}
+
+func (r *packReader) Read(offset int64, buffer []byte) (int, error) {
+	if offset < 0 || uint64(offset) > r.entry.size {
+		return 0, errors.Errorf("the offset %d is out of %s whose size is %d", offset, r.Path(), r.entry.size)
+	}
+	remaining := r.entry.size - uint64(offset)
+	if uint64(len(buffer)) > remaining {
+		n, err := r.read(offset, buffer[:remaining])
+		if err != nil {
+			return n, err
+		}
+		return n, io.EOF
+	}
+	return r.read(offset, buffer)
+}
+
+func (r *packReader) read(offset int64, buffer []byte) (int, error) {
+	if len(buffer) == 0 {
+		return 0, nil
+	}
+	return r.f.Read(int64(r.entry.offset)+offset, buffer)
+}
+
+func (r *packReader) SequentialRead() fs.SeqReader {
+	return &packSeqReader{
+		SectionReader: io.NewSectionReader(r, 0, int64(r.entry.size)),
+		path:          r.Path(),
+	}
+}
+
+// ReadAt adapts the reader to io.ReaderAt.
+func (r *packReader) ReadAt(p []byte, off int64) (int, error) {
+	return r.Read(off, p)
+}
+
+func (r *packReader) Path() string {
+	return r.f.Path() + "#" + r.name
+}
+
+// Close does nothing since the file is owned by the pack.
+func (r *packReader) Close() error {
+	return nil
+}
+
+type packSeqReader struct {
+	*io.SectionReader
+	path string
+}
+
+func (r *packSeqReader) Path() string {
+	return r.path
+}
+
+func (r *packSeqReader) Close() error {
+	return nil
+}
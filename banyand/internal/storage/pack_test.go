@@ -0,0 +1,99 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storage
+
+import (
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/skywalking-banyandb/pkg/fs"
+	"github.com/apache/skywalking-banyandb/pkg/test"
+)
+
+func TestPack(t *testing.T) {
+	dir, defFn := test.Space(require.New(t))
+	defer defFn()
+	fileSystem := fs.NewLocalFileSystem()
+	MustWritePack(fileSystem, dir, []PackEntry{
+		{Name: "primary.bin", Data: []byte("primary")},
+		{Name: "empty.bin"},
+		{Name: "default.tf", Data: []byte("tag family")},
+	})
+
+	p, err := OpenPack(fileSystem, dir)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, p.Close())
+	}()
+	assert.Equal(t, []string{"default.tf", "empty.bin", "primary.bin"}, p.Names())
+
+	data, err := p.Read("default.tf")
+	require.NoError(t, err)
+	assert.Equal(t, "tag family", string(data))
+	data, err = p.Read("empty.bin")
+	require.NoError(t, err)
+	assert.Empty(t, data)
+	_, err = p.Read("absent.bin")
+	require.Error(t, err)
+
+	r := p.MustReader("default.tf")
+	buf := make([]byte, 6)
+	n, err := r.Read(4, buf)
+	require.NoError(t, err)
+	assert.Equal(t, "family", string(buf[:n]))
+	n, err = r.Read(8, buf)
+	assert.ErrorIs(t, err, io.EOF, "the read is bounded by the entry")
+	assert.Equal(t, "ly", string(buf[:n]))
+	seq := r.SequentialRead()
+	all, err := io.ReadAll(seq)
+	require.NoError(t, err)
+	assert.Equal(t, "tag family", string(all))
+	require.NoError(t, seq.Close())
+	require.NoError(t, r.Close(), "the readers don't close the pack")
+	data, err = p.Read("primary.bin")
+	require.NoError(t, err)
+	assert.Equal(t, "primary", string(data))
+
+	_, ok := p.Reader("absent.bin")
+	assert.False(t, ok)
+}
+
+func TestOpenPackCorrupted(t *testing.T) {
+	dir, defFn := test.Space(require.New(t))
+	defer defFn()
+	fileSystem := fs.NewLocalFileSystem()
+	_, err := OpenPack(fileSystem, dir)
+	require.Error(t, err, "the pack is absent")
+
+	fs.MustFlush(fileSystem, []byte("short"), filepath.Join(dir, PackFilename), FilePerm)
+	_, err = OpenPack(fileSystem, dir)
+	require.Error(t, err)
+
+	MustWritePack(fileSystem, dir, []PackEntry{{Name: "primary.bin", Data: []byte("primary")}})
+	data, err := fileSystem.Read(filepath.Join(dir, PackFilename))
+	require.NoError(t, err)
+	data[len(data)-1]++
+	_, err = fileSystem.Write(data, filepath.Join(dir, PackFilename), FilePerm)
+	require.NoError(t, err)
+	_, err = OpenPack(fileSystem, dir)
+	require.ErrorContains(t, err, "magic")
+}
@@ -0,0 +1,185 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storage
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+
+	"github.com/apache/skywalking-banyandb/pkg/fs"
+)
+
+// RangeTombstonesFilename is the file of the range tombstones in the root of a group.
+const RangeTombstonesFilename = "range-tombstones.json"
+
+// RangeTombstone deletes the data whose timestamps are in [Begin, End) and which were ingested at or before DeletedAt.
+type RangeTombstone struct {
+	Begin     int64 `json:"begin"`
+	End       int64 `json:"end"`
+	DeletedAt int64 `json:"deleted_at"`
+}
+
+// RangeTombstones records the deleted time ranges of a group.
+// The data in the ranges are dropped by the queries and the merges until the ranges expire.
+// The data are checked by the ingestion time of their parts, so the data written into a range
+// after its deletion, e.g. a corrected backfill, are kept. A nil RangeTombstones deletes nothing.
+type RangeTombstones struct {
+	fileSystem fs.FileSystem
+	entries    atomic.Pointer[[]RangeTombstone]
+	path       string
+	mu         sync.Mutex
+}
+
+// OpenRangeTombstones loads the range tombstones persisted in the root of a group.
+func OpenRangeTombstones(fileSystem fs.FileSystem, root string) (*RangeTombstones, error) {
+	t := &RangeTombstones{
+		fileSystem: fileSystem,
+		path:       filepath.Join(root, RangeTombstonesFilename),
+	}
+	var entries []RangeTombstone
+	data, err := fileSystem.Read(t.path)
+	if err != nil {
+		var fsErr *fs.FileSystemError
+		if !errors.As(err, &fsErr) || fsErr.Code != fs.IsNotExistError {
+			return nil, errors.WithMessagef(err, "cannot read %s", t.path)
+		}
+	} else if err = json.Unmarshal(data, &entries); err != nil {
+		return nil, errors.WithMessagef(err, "cannot parse %s", t.path)
+	}
+	t.entries.Store(&entries)
+	return t, nil
+}
+
+func (t *RangeTombstones) load() []RangeTombstone {
+	if t == nil {
+		return nil
+	}
+	entries := t.entries.Load()
+	if entries == nil {
+		return nil
+	}
+	return *entries
+}
+
+// Ranges returns the range tombstones sorted by the beginning.
+func (t *RangeTombstones) Ranges() []RangeTombstone {
+	return append([]RangeTombstone(nil), t.load()...)
+}
+
+// deletes reports whether the range deletes the data ingested at ingestedAt.
+func (r RangeTombstone) deletes(ingestedAt int64) bool {
+	return ingestedAt <= r.DeletedAt
+}
+
+// Overlaps reports whether any range deleting the data ingested at ingestedAt intersects [minTimestamp, maxTimestamp].
+func (t *RangeTombstones) Overlaps(minTimestamp, maxTimestamp, ingestedAt int64) bool {
+	for _, r := range t.load() {
+		if r.deletes(ingestedAt) && r.Begin <= maxTimestamp && minTimestamp < r.End {
+			return true
+		}
+	}
+	return false
+}
+
+// Covers reports whether a single range deleting the data ingested at ingestedAt holds the whole [minTimestamp, maxTimestamp].
+func (t *RangeTombstones) Covers(minTimestamp, maxTimestamp, ingestedAt int64) bool {
+	for _, r := range t.load() {
+		if r.deletes(ingestedAt) && r.Begin <= minTimestamp && maxTimestamp < r.End {
+			return true
+		}
+	}
+	return false
+}
+
+// Deleted reports whether the timestamp of the data ingested at ingestedAt lies in a range.
+func (t *RangeTombstones) Deleted(ts, ingestedAt int64) bool {
+	for _, r := range t.load() {
+		if r.deletes(ingestedAt) && r.Begin <= ts && ts < r.End {
+			return true
+		}
+	}
+	return false
+}
+
+// Add tombstones [begin, end) at deletedAt and persists the ranges.
+// The ranges ending before since are pruned because the segments holding their data have expired.
+func (t *RangeTombstones) Add(begin, end, deletedAt, since int64) error {
+	if begin >= end {
+		return errors.Errorf("the range [%d, %d) is empty", begin, end)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	current := t.load()
+	entries := make([]RangeTombstone, 0, len(current)+1)
+	for _, r := range current {
+		if r.End > since {
+			entries = append(entries, r)
+		}
+	}
+	entries = append(entries, RangeTombstone{Begin: begin, End: end, DeletedAt: deletedAt})
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Begin < entries[j].Begin
+	})
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return errors.WithMessage(err, "cannot marshal the range tombstones")
+	}
+	tmpPath := t.path + ".tmp"
+	if _, err = t.fileSystem.Write(data, tmpPath, FilePerm); err != nil {
+		return errors.WithMessagef(err, "cannot write %s", tmpPath)
+	}
+	if err = t.fileSystem.Rename(tmpPath, t.path); err != nil {
+		return errors.WithMessagef(err, "cannot rename %s", tmpPath)
+	}
+	t.entries.Store(&entries)
+	return nil
+}
+
+// FilterTimestamps returns the indexes of the ascending timestamps ingested at ingestedAt that are not deleted.
+// dropped is false if no timestamp is deleted, then kept is nil.
+func (t *RangeTombstones) FilterTimestamps(timestamps []int64, ingestedAt int64) (kept []int, dropped bool) {
+	entries := t.load()
+	if len(entries) == 0 || len(timestamps) == 0 {
+		return nil, false
+	}
+	if !t.Overlaps(timestamps[0], timestamps[len(timestamps)-1], ingestedAt) {
+		return nil, false
+	}
+	kept = make([]int, 0, len(timestamps))
+	for i, ts := range timestamps {
+		if t.Deleted(ts, ingestedAt) {
+			dropped = true
+			continue
+		}
+		kept = append(kept, i)
+	}
+	return kept, dropped
+}
+
+// KeepRows compacts the rows of a column to the kept indexes in place.
+func KeepRows[T any](rows []T, kept []int) []T {
+	for i, k := range kept {
+		rows[i] = rows[k]
+	}
+	return rows[:len(kept)]
+}
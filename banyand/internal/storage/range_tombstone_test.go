@@ -0,0 +1,82 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/skywalking-banyandb/pkg/fs"
+	"github.com/apache/skywalking-banyandb/pkg/test"
+)
+
+func TestRangeTombstones(t *testing.T) {
+	tmpPath, defFn := test.Space(require.New(t))
+	defer defFn()
+	fileSystem := fs.NewLocalFileSystem()
+
+	rt, err := OpenRangeTombstones(fileSystem, tmpPath)
+	require.NoError(t, err)
+	require.False(t, rt.Deleted(10, 0))
+	require.Error(t, rt.Add(20, 20, 1, 0), "an empty range is rejected")
+
+	require.NoError(t, rt.Add(20, 30, 1, 0))
+	require.NoError(t, rt.Add(10, 15, 2, 0))
+	require.Equal(t, []RangeTombstone{{Begin: 10, End: 15, DeletedAt: 2}, {Begin: 20, End: 30, DeletedAt: 1}}, rt.Ranges())
+	require.True(t, rt.Deleted(20, 0))
+	require.False(t, rt.Deleted(30, 0), "the end is excluded")
+	require.True(t, rt.Overlaps(0, 10, 0))
+	require.False(t, rt.Overlaps(15, 19, 0))
+	require.True(t, rt.Covers(21, 29, 0))
+	require.False(t, rt.Covers(14, 21, 0))
+
+	kept, dropped := rt.FilterTimestamps([]int64{5, 12, 16, 25, 30}, 0)
+	require.True(t, dropped)
+	require.Equal(t, []int{0, 2, 4}, kept)
+	require.Equal(t, []int64{5, 16, 30}, KeepRows([]int64{5, 12, 16, 25, 30}, kept))
+	kept, dropped = rt.FilterTimestamps([]int64{15, 16}, 0)
+	require.False(t, dropped)
+	require.Nil(t, kept)
+
+	// the data ingested after the deletion of a range are kept.
+	require.True(t, rt.Deleted(25, 1))
+	require.False(t, rt.Deleted(25, 2))
+	require.True(t, rt.Deleted(12, 2))
+	require.False(t, rt.Deleted(12, 3))
+	require.False(t, rt.Covers(21, 29, 2))
+	require.False(t, rt.Overlaps(0, 100, 3))
+	kept, dropped = rt.FilterTimestamps([]int64{5, 12, 16, 25, 30}, 2)
+	require.True(t, dropped)
+	require.Equal(t, []int{0, 2, 3, 4}, kept)
+	_, dropped = rt.FilterTimestamps([]int64{5, 12, 16, 25, 30}, 3)
+	require.False(t, dropped)
+
+	reopened, err := OpenRangeTombstones(fileSystem, tmpPath)
+	require.NoError(t, err)
+	require.Equal(t, rt.Ranges(), reopened.Ranges())
+	require.NoError(t, reopened.Add(40, 50, 3, 20))
+	require.Equal(t, []RangeTombstone{{Begin: 20, End: 30, DeletedAt: 1}, {Begin: 40, End: 50, DeletedAt: 3}}, reopened.Ranges(),
+		"the ranges before the oldest segment are pruned")
+
+	var nilTombstones *RangeTombstones
+	require.False(t, nilTombstones.Deleted(10, 0))
+	require.False(t, nilTombstones.Overlaps(0, 100, 0))
+	_, dropped = nilTombstones.FilterTimestamps([]int64{10}, 0)
+	require.False(t, dropped)
+}
@@ -0,0 +1,130 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/apache/skywalking-banyandb/pkg/fs"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+)
+
+// QuarantineDirname is the directory of a table which keeps the files moved aside by the startup check.
+const QuarantineDirname = "quarantine"
+
+// RepairPath is the HTTP path of the repair summary.
+const RepairPath = "/api/healthz/storage"
+
+const maxRepairEvents = 100
+
+// RepairAction is the action taken by the startup check of a table.
+type RepairAction string
+
+// The repair actions.
+const (
+	// RepairActionQuarantined moves a damaged or unknown file to the quarantine directory.
+	RepairActionQuarantined RepairAction = "quarantined"
+	// RepairActionRemoved removes a part whose data are kept by the other parts.
+	RepairActionRemoved RepairAction = "removed"
+	// RepairActionDropped drops a part absent on the disk from the manifest.
+	RepairActionDropped RepairAction = "dropped"
+	// RepairActionSkipped skips a damaged file which can't be moved aside.
+	RepairActionSkipped RepairAction = "skipped"
+)
+
+// RepairEvent records a repair.
+type RepairEvent struct {
+	Time   time.Time    `json:"time"`
+	Path   string       `json:"path"`
+	Reason string       `json:"reason"`
+	Action RepairAction `json:"action"`
+}
+
+// RepairSummary summarizes the repairs since the server started.
+// Events only keeps the latest events.
+type RepairSummary struct {
+	Counts map[RepairAction]int `json:"counts"`
+	Events []RepairEvent        `json:"events"`
+}
+
+var repairs = &repairRecorder{counts: make(map[RepairAction]int)}
+
+type repairRecorder struct {
+	counts map[RepairAction]int
+	events []RepairEvent
+	mu     sync.Mutex
+}
+
+// ReportRepair records a repair of the file or directory in path.
+func ReportRepair(path string, action RepairAction, reason error) {
+	repairs.mu.Lock()
+	defer repairs.mu.Unlock()
+	repairs.counts[action]++
+	repairs.events = append(repairs.events, RepairEvent{
+		Time:   time.Now(),
+		Path:   path,
+		Reason: reason.Error(),
+		Action: action,
+	})
+	if len(repairs.events) > maxRepairEvents {
+		repairs.events = repairs.events[len(repairs.events)-maxRepairEvents:]
+	}
+}
+
+// Repairs returns the summary of the repairs.
+func Repairs() RepairSummary {
+	repairs.mu.Lock()
+	defer repairs.mu.Unlock()
+	s := RepairSummary{
+		Counts: make(map[RepairAction]int, len(repairs.counts)),
+		Events: make([]RepairEvent, len(repairs.events)),
+	}
+	for k, v := range repairs.counts {
+		s.Counts[k] = v
+	}
+	copy(s.Events, repairs.events)
+	return s
+}
+
+// ServeRepairs writes the summary of the repairs in JSON.
+func ServeRepairs(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(Repairs())
+}
+
+// Quarantine moves the file or directory name in root to the quarantine directory of root.
+// The file is left in place if it can't be moved, and the caller should skip it.
+func Quarantine(lfs fs.FileSystem, root, name string, reason error, l *logger.Logger) {
+	src := filepath.Join(root, name)
+	dir := filepath.Join(root, QuarantineDirname)
+	lfs.MkdirIfNotExist(dir, DirPerm)
+	// the same name might be quarantined by an earlier startup.
+	dst := filepath.Join(dir, fmt.Sprintf("%s-%d", name, time.Now().UnixNano()))
+	if err := lfs.Rename(src, dst); err != nil {
+		l.Error().Err(err).Str("path", src).AnErr("reason", reason).Msg("cannot quarantine the file, skip it")
+		ReportRepair(src, RepairActionSkipped, reason)
+		return
+	}
+	l.Warn().Str("path", src).Str("quarantine", dst).AnErr("reason", reason).Msg("quarantined the file")
+	ReportRepair(src, RepairActionQuarantined, reason)
+}
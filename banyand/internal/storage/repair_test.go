@@ -0,0 +1,73 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/skywalking-banyandb/pkg/fs"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/test"
+)
+
+func TestQuarantine(t *testing.T) {
+	fileSystem := fs.NewLocalFileSystem()
+	tmpPath, deferFn := test.Space(require.New(t))
+	defer deferFn()
+	l := logger.GetLogger("test")
+	before := Repairs()
+
+	fileSystem.MkdirIfNotExist(filepath.Join(tmpPath, "part"), DirPerm)
+	reason := errors.New("damaged")
+	Quarantine(fileSystem, tmpPath, "part", reason, l)
+	// the file can't be moved if it's absent.
+	Quarantine(fileSystem, tmpPath, "absent", reason, l)
+
+	assert.NoDirExists(t, filepath.Join(tmpPath, "part"))
+	ee, err := os.ReadDir(filepath.Join(tmpPath, QuarantineDirname))
+	require.NoError(t, err)
+	require.Len(t, ee, 1)
+	assert.Regexp(t, `^part-\d+$`, ee[0].Name())
+
+	after := Repairs()
+	assert.Equal(t, before.Counts[RepairActionQuarantined]+1, after.Counts[RepairActionQuarantined])
+	assert.Equal(t, before.Counts[RepairActionSkipped]+1, after.Counts[RepairActionSkipped])
+	events := after.Events[len(after.Events)-2:]
+	assert.Equal(t, filepath.Join(tmpPath, "part"), events[0].Path)
+	assert.Equal(t, RepairActionQuarantined, events[0].Action)
+	assert.Equal(t, "damaged", events[0].Reason)
+	assert.Equal(t, filepath.Join(tmpPath, "absent"), events[1].Path)
+	assert.Equal(t, RepairActionSkipped, events[1].Action)
+
+	rec := httptest.NewRecorder()
+	ServeRepairs(rec, httptest.NewRequest(http.MethodGet, RepairPath, nil))
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var got RepairSummary
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+	assert.Equal(t, after.Counts, got.Counts)
+	assert.Len(t, got.Events, len(after.Events))
+}
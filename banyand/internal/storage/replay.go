@@ -0,0 +1,162 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storage
+
+import (
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+)
+
+type replayShardKey struct {
+	group   string
+	shardID common.ShardID
+}
+
+type replayKey struct {
+	key string
+	replayShardKey
+}
+
+// ReplayCache remembers the idempotency keys of the recent writes in every shard.
+// A write whose key is in the cache is a duplicate delivery, for example, a retry of the queue.
+type ReplayCache struct {
+	shards map[replayShardKey]*lru.Cache
+	now    func() time.Time
+	size   int
+	ttl    time.Duration
+	mu     sync.Mutex
+}
+
+// NewReplayCache returns a cache holding at most size keys for ttl in a shard.
+// It returns nil if size or ttl is not positive, which disables the replay detection.
+func NewReplayCache(size int, ttl time.Duration) *ReplayCache {
+	if size <= 0 || ttl <= 0 {
+		return nil
+	}
+	return &ReplayCache{
+		shards: make(map[replayShardKey]*lru.Cache),
+		now:    time.Now,
+		size:   size,
+		ttl:    ttl,
+	}
+}
+
+func (rc *ReplayCache) shard(k replayShardKey, create bool) *lru.Cache {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	c, ok := rc.shards[k]
+	if !ok && create {
+		// lru.New fails only if the size is not positive.
+		c, _ = lru.New(rc.size)
+		rc.shards[k] = c
+	}
+	return c
+}
+
+// dropGroup discards the keys of all the shards in the group, which is called when the group is closed.
+func (rc *ReplayCache) dropGroup(group string) {
+	if rc == nil {
+		return
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	for k := range rc.shards {
+		if k.group == group {
+			delete(rc.shards, k)
+		}
+	}
+}
+
+func (rc *ReplayCache) contains(k replayKey) bool {
+	c := rc.shard(k.replayShardKey, false)
+	if c == nil {
+		return false
+	}
+	v, ok := c.Get(k.key)
+	if !ok {
+		return false
+	}
+	if rc.now().UnixNano() < v.(int64) {
+		return true
+	}
+	c.Remove(k.key)
+	return false
+}
+
+func (rc *ReplayCache) add(k replayKey) {
+	rc.shard(k.replayShardKey, true).Add(k.key, rc.now().Add(rc.ttl).UnixNano())
+}
+
+// NewBatch returns a batch to detect the duplicate writes of a message.
+func (rc *ReplayCache) NewBatch() *ReplayBatch {
+	if rc == nil {
+		return nil
+	}
+	return &ReplayBatch{
+		cache: rc,
+		keys:  make(map[replayKey]struct{}),
+	}
+}
+
+// ReplayBatch collects the idempotency keys of a batch of writes.
+// The keys are put into the cache after the batch is written,
+// so that the retry of a failed batch is not dropped.
+type ReplayBatch struct {
+	cache *ReplayCache
+	keys  map[replayKey]struct{}
+}
+
+// Seen checks whether the key was written recently or added to the batch.
+// Otherwise, it adds the key to the batch. An empty key is never seen.
+func (rb *ReplayBatch) Seen(group string, shardID common.ShardID, key string) bool {
+	if rb == nil || key == "" {
+		return false
+	}
+	k := replayKey{key: key, replayShardKey: replayShardKey{group: group, shardID: shardID}}
+	if _, ok := rb.keys[k]; ok {
+		return true
+	}
+	if rb.cache.contains(k) {
+		return true
+	}
+	rb.keys[k] = struct{}{}
+	return false
+}
+
+// Reset discards the keys of the batch.
+func (rb *ReplayBatch) Reset() {
+	if rb == nil {
+		return
+	}
+	clear(rb.keys)
+}
+
+// Commit puts the keys of the batch into the cache.
+func (rb *ReplayBatch) Commit() {
+	if rb == nil {
+		return
+	}
+	for k := range rb.keys {
+		rb.cache.add(k)
+	}
+	clear(rb.keys)
+}
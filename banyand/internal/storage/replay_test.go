@@ -0,0 +1,101 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplayCacheDisabled(t *testing.T) {
+	assert.Nil(t, NewReplayCache(0, time.Minute))
+	assert.Nil(t, NewReplayCache(10, 0))
+	var rc *ReplayCache
+	rb := rc.NewBatch()
+	assert.False(t, rb.Seen("g", 0, "k1"))
+	assert.False(t, rb.Seen("g", 0, "k1"))
+	rb.Commit()
+}
+
+func TestReplayCacheSeen(t *testing.T) {
+	rc := NewReplayCache(2, time.Minute)
+	now := time.Now()
+	rc.now = func() time.Time { return now }
+
+	rb := rc.NewBatch()
+	assert.False(t, rb.Seen("g", 0, "k1"))
+	// duplicated in the same batch.
+	assert.True(t, rb.Seen("g", 0, "k1"))
+	// the keys are scoped by the group and shard.
+	assert.False(t, rb.Seen("g", 1, "k1"))
+	assert.False(t, rb.Seen("g1", 0, "k1"))
+	// the empty key is never seen.
+	assert.False(t, rb.Seen("g", 0, ""))
+	assert.False(t, rb.Seen("g", 0, ""))
+	rb.Commit()
+
+	rb = rc.NewBatch()
+	assert.True(t, rb.Seen("g", 0, "k1"))
+	assert.True(t, rb.Seen("g", 1, "k1"))
+
+	now = now.Add(2 * time.Minute)
+	assert.False(t, rb.Seen("g", 0, "k1"))
+}
+
+func TestReplayCacheReset(t *testing.T) {
+	rc := NewReplayCache(10, time.Minute)
+	rb := rc.NewBatch()
+	assert.False(t, rb.Seen("g", 0, "k1"))
+	rb.Reset()
+	rb.Commit()
+	// the failed write is not remembered so that its retry is accepted.
+	assert.False(t, rc.NewBatch().Seen("g", 0, "k1"))
+}
+
+func TestReplayCacheEviction(t *testing.T) {
+	rc := NewReplayCache(2, time.Minute)
+	rb := rc.NewBatch()
+	for _, k := range []string{"k1", "k2", "k3"} {
+		assert.False(t, rb.Seen("g", 0, k))
+		rb.Commit()
+	}
+	rb = rc.NewBatch()
+	assert.False(t, rb.Seen("g", 0, "k1"))
+	assert.True(t, rb.Seen("g", 0, "k3"))
+}
+
+func TestReplayCacheDropGroup(t *testing.T) {
+	rc := NewReplayCache(10, time.Minute)
+	rb := rc.NewBatch()
+	assert.False(t, rb.Seen("g", 0, "k1"))
+	assert.False(t, rb.Seen("g", 1, "k1"))
+	assert.False(t, rb.Seen("g1", 0, "k1"))
+	rb.Commit()
+
+	rc.dropGroup("g")
+	assert.Len(t, rc.shards, 1)
+	rb = rc.NewBatch()
+	assert.False(t, rb.Seen("g", 0, "k1"))
+	assert.False(t, rb.Seen("g", 1, "k1"))
+	assert.True(t, rb.Seen("g1", 0, "k1"))
+
+	var disabled *ReplayCache
+	disabled.dropGroup("g")
+}
@@ -0,0 +1,93 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storage
+
+import (
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/pkg/schema"
+)
+
+const errGroupNotFound = "group not found"
+
+// Retention previews the retention of the groups in the catalog at now, or runs it if trigger is true.
+// All the groups in the catalog are included if names is empty. The groups which aren't loaded on this node are skipped.
+func Retention[T TSTable, O any](catalog commonv1.Catalog, repo schema.Repository, names []string, trigger bool, now time.Time) []*databasev1.GroupRetention {
+	if len(names) == 0 {
+		for _, g := range repo.LoadAllGroups() {
+			names = append(names, g.GetSchema().GetMetadata().GetName())
+		}
+	}
+	var result []*databasev1.GroupRetention
+	for _, name := range names {
+		g, ok := repo.LoadGroup(name)
+		if !ok || g.GetSchema().GetCatalog() != catalog {
+			continue
+		}
+		db, ok := g.SupplyTSDB().(TSDB[T, O])
+		if !ok || db == nil {
+			continue
+		}
+		var plan RetentionPlan
+		var err error
+		if trigger {
+			plan, err = db.RunRetention(now)
+		} else {
+			plan, err = db.PreviewRetention(now)
+		}
+		gr := &databasev1.GroupRetention{Catalog: catalog, Group: name}
+		if err != nil {
+			gr.Error = err.Error()
+		}
+		if !plan.Deadline.IsZero() {
+			gr.Deadline = timestamppb.New(plan.Deadline)
+		}
+		for _, s := range plan.Segments {
+			gr.Segments = append(gr.Segments, &databasev1.RetentionSegment{
+				Name:  s.Name,
+				Begin: timestamppb.New(s.Start),
+				End:   timestamppb.New(s.End),
+				Size:  s.Size,
+			})
+		}
+		gr.Size = plan.Size()
+		result = append(result, gr)
+	}
+	return result
+}
+
+// CompleteRetention appends the retention of the requested groups which no catalog reports,
+// since they're absent from this node.
+func CompleteRetention(names []string, rr []*databasev1.GroupRetention) []*databasev1.GroupRetention {
+	reported := make(map[string]struct{}, len(rr))
+	for _, r := range rr {
+		reported[r.GetGroup()] = struct{}{}
+	}
+	for _, name := range names {
+		if _, ok := reported[name]; ok {
+			continue
+		}
+		reported[name] = struct{}{}
+		rr = append(rr, &databasev1.GroupRetention{Group: name, Error: errGroupNotFound})
+	}
+	return rr
+}
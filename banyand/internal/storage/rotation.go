@@ -18,17 +18,22 @@
 package storage
 
 import (
+	"context"
 	"time"
 
+	"github.com/pkg/errors"
 	"github.com/robfig/cron/v3"
 
 	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
 )
 
 var (
 	creationGap           = time.Hour
 	newSegmentTimeGap     = creationGap.Nanoseconds()
 	timeEventSnapDuration = (10 * time.Minute).Nanoseconds()
+	// preCreationExpr checks whether to pre-create the upcoming segment every 5 minutes.
+	preCreationExpr = "*/5 *"
 )
 
 func (d *database[T, O]) Tick(ts int64) {
@@ -46,7 +51,8 @@ func (d *database[T, O]) startRotationTask() error {
 	options := d.segmentController.getOptions()
 	var rt *retentionTask[T, O]
 	if !d.disableRetention {
-		rt = newRetentionTask(d, options.TTL)
+		rt = newRetentionTask(d)
+		d.retention = rt
 	}
 	go func(rt *retentionTask[T, O]) {
 		var idleCheckTicker *time.Ticker
@@ -103,15 +109,9 @@ func (d *database[T, O]) startRotationTask() error {
 						if gap <= 0 || gap > newSegmentTimeGap {
 							return
 						}
-						d.incTotalRotationStarted(1)
-						defer d.incTotalRotationFinished(1)
 						start := options.SegmentInterval.nextTime(t)
 						d.logger.Info().Time("segment_start", start).Time("event_time", t).Msg("create new segment")
-						_, err = d.segmentController.create(start)
-						if err != nil {
-							d.logger.Error().Err(err).Msgf("failed to create new segment.")
-							d.incTotalRotationErr(1)
-						}
+						d.createNextSegment(start, latest)
 					}()
 				}(ts)
 			case <-idleCheckC:
@@ -125,36 +125,139 @@ func (d *database[T, O]) startRotationTask() error {
 			}
 		}
 	}(rt)
+	if err := d.scheduler.Register("segment-pre-creation", cron.Minute|cron.Hour, preCreationExpr, d.preCreateSegment); err != nil {
+		return err
+	}
 	if rt == nil {
 		return nil
 	}
 	return d.scheduler.Register("retention", rt.option, rt.expr, rt.run)
 }
 
+// preCreateSegment creates the upcoming segment ahead of time if the latest one is about to end,
+// so that the first write of the next interval doesn't pay the segment creation on the hot path.
+// Unlike the ticks which are driven by the written data, it's driven by the wall clock.
+func (d *database[T, O]) preCreateSegment(now time.Time, _ *logger.Logger) bool {
+	if d.closed.Load() {
+		return false
+	}
+	ss, err := d.segmentController.segments(false)
+	if err != nil {
+		d.logger.Error().Err(err).Msg("failed to get segments")
+		return true
+	}
+	if len(ss) == 0 {
+		return true
+	}
+	defer func() {
+		for i := range ss {
+			ss[i].DecRef()
+		}
+	}()
+	latest := ss[len(ss)-1]
+	gap := latest.End.UnixNano() - now.UnixNano()
+	// gap <=0 means the latest segment has ended, no data is written to the current interval.
+	if gap <= 0 || gap > newSegmentTimeGap {
+		return true
+	}
+	d.logger.Info().Time("segment_start", latest.End).Time("now", now).Msg("pre-create new segment")
+	d.createNextSegment(latest.End, latest)
+	return true
+}
+
+// createNextSegment creates the segment starting from start, and the shards which the latest segment holds.
+func (d *database[T, O]) createNextSegment(start time.Time, latest *segment[T, O]) {
+	d.incTotalRotationStarted(1)
+	defer d.incTotalRotationFinished(1)
+	seg, err := d.segmentController.create(start)
+	if err != nil {
+		d.logger.Error().Err(err).Msgf("failed to create new segment.")
+		d.incTotalRotationErr(1)
+		return
+	}
+	if seg == latest {
+		return
+	}
+	sLst := latest.sLst.Load()
+	if sLst == nil || len(*sLst) == 0 {
+		return
+	}
+	if err = seg.incRef(context.WithValue(context.Background(), logger.ContextKey, d.logger)); err != nil {
+		d.logger.Error().Err(err).Msg("failed to open the new segment")
+		d.incTotalRotationErr(1)
+		return
+	}
+	defer seg.DecRef()
+	for _, s := range *sLst {
+		if _, err = seg.CreateTSTableIfNotExist(s.id); err != nil {
+			d.logger.Error().Err(err).Int("shard_id", int(s.id)).Msg("failed to create the shard of the new segment")
+			d.incTotalRotationErr(1)
+			return
+		}
+	}
+}
+
+var (
+	errRetentionDisabled = errors.New("the retention is disabled")
+	errRetentionRunning  = errors.New("a retention pass is running")
+)
+
+// RetentionSegment is a segment deleted by a retention pass.
+type RetentionSegment struct {
+	timestamp.TimeRange
+	Name string
+	Size int64
+}
+
+// RetentionPlan is the segments which a retention pass deletes. The segments ending before the deadline are deleted.
+type RetentionPlan struct {
+	Deadline time.Time
+	Segments []RetentionSegment
+}
+
+// Size returns the total bytes of the segments.
+func (rp RetentionPlan) Size() (size int64) {
+	for _, s := range rp.Segments {
+		size += s.Size
+	}
+	return size
+}
+
 type retentionTask[T TSTable, O any] struct {
 	database *database[T, O]
 	running  chan struct{}
 	expr     string
 	option   cron.ParseOption
-	duration time.Duration
 }
 
-func newRetentionTask[T TSTable, O any](database *database[T, O], ttl IntervalRule) *retentionTask[T, O] {
+func newRetentionTask[T TSTable, O any](database *database[T, O]) *retentionTask[T, O] {
 	return &retentionTask[T, O]{
 		database: database,
 		option:   cron.Minute | cron.Hour,
 		// Remove data which is
-		expr:     "5 0",
-		duration: ttl.estimatedDuration(),
-		running:  make(chan struct{}, 1),
+		expr:    "5 0",
+		running: make(chan struct{}, 1),
 	}
 }
 
+// deadline returns the deadline of the pass at now. It follows the updated TTL of the group.
+func (rc *retentionTask[T, O]) deadline(now time.Time) time.Time {
+	return now.Add(-rc.database.segmentController.getOptions().TTL.estimatedDuration())
+}
+
 func (rc *retentionTask[T, O]) run(now time.Time, l *logger.Logger) bool {
+	if _, err := rc.remove(now); err != nil && !errors.Is(err, errRetentionRunning) {
+		l.Error().Err(err).Msg("failed to remove the expired segments")
+	}
+	return true
+}
+
+// remove deletes the segments expired at now, and returns them.
+func (rc *retentionTask[T, O]) remove(now time.Time) (RetentionPlan, error) {
 	select {
 	case rc.running <- struct{}{}:
 	default:
-		return true
+		return RetentionPlan{}, errRetentionRunning
 	}
 	defer func() {
 		<-rc.running
@@ -162,16 +265,16 @@ func (rc *retentionTask[T, O]) run(now time.Time, l *logger.Logger) bool {
 
 	rc.database.incTotalRetentionStarted(1)
 	defer rc.database.incTotalRetentionFinished(1)
-	deadline := now.Add(-rc.duration)
+	plan := rc.database.segmentController.planRetention(rc.deadline(now))
 	start := time.Now()
-	hasData, err := rc.database.segmentController.remove(deadline)
+	hasData, err := rc.database.segmentController.remove(plan.Deadline)
 	if hasData {
 		rc.database.incTotalRetentionHasData(1)
 		rc.database.incTotalRetentionHasDataLatency(time.Since(start).Seconds())
 	}
 	if err != nil {
-		l.Error().Err(err)
 		rc.database.incTotalRetentionErr(1)
+		return plan, err
 	}
-	return true
+	return plan, nil
 }
@@ -26,6 +26,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/apache/skywalking-banyandb/api/common"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
 	"github.com/apache/skywalking-banyandb/banyand/observability"
 	"github.com/apache/skywalking-banyandb/pkg/fs"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
@@ -60,6 +61,41 @@ func TestForwardRotation(t *testing.T) {
 	})
 }
 
+func TestSegmentPreCreation(t *testing.T) {
+	t.Run("pre-create the upcoming segment with the shards ahead of time", func(t *testing.T) {
+		tsdb, c, segCtrl, dfFn := setUpDB(t)
+		defer dfFn()
+		ss, _ := segCtrl.segments(true)
+		_, err := ss[0].CreateTSTableIfNotExist(common.ShardID(0))
+		require.NoError(t, err)
+		ss[0].DecRef()
+		c.Set(c.Now().Add(23*time.Hour + 30*time.Minute))
+		// run the task directly since the scheduler only fires it once it's armed
+		require.True(t, tsdb.preCreateSegment(c.Now(), nil))
+		segments, _ := segCtrl.segments(false)
+		defer func() {
+			for i := range segments {
+				segments[i].DecRef()
+			}
+		}()
+		require.Len(t, segments, 2)
+		_, ok := segments[1].getShard(common.ShardID(0))
+		assert.True(t, ok, "expect the shard 0 to be pre-created")
+	})
+
+	t.Run("no segment pre-created when the time is not up", func(t *testing.T) {
+		tsdb, c, segCtrl, dfFn := setUpDB(t)
+		defer dfFn()
+		c.Set(c.Now().Add(22 * time.Hour))
+		require.True(t, tsdb.preCreateSegment(c.Now(), nil))
+		segments, _ := segCtrl.segments(false)
+		for i := range segments {
+			segments[i].DecRef()
+		}
+		assert.Len(t, segments, 1)
+	})
+}
+
 func TestRetention(t *testing.T) {
 	t.Run("delete the segment and index when the TTL is up", func(t *testing.T) {
 		tsdb, c, segCtrl, dfFn := setUpDB(t, 5) // Use 5-day TTL to avoid early deletion
@@ -152,6 +188,51 @@ func TestRetention(t *testing.T) {
 	})
 }
 
+func TestPreviewAndRunRetention(t *testing.T) {
+	tsdb, c, segCtrl, dfFn := setUpDB(t)
+	defer dfFn()
+	first := c.Now()
+	for i := 1; i < 3; i++ {
+		seg, err := tsdb.CreateSegmentIfNotExist(first.AddDate(0, 0, i))
+		require.NoError(t, err)
+		seg.DecRef()
+	}
+	now := first.AddDate(0, 0, 4)
+
+	plan, err := tsdb.PreviewRetention(now)
+	require.NoError(t, err)
+	assert.Equal(t, first.AddDate(0, 0, 1), plan.Deadline)
+	require.Len(t, plan.Segments, 1)
+	assert.Equal(t, "seg-"+first.Format(dayFormat), plan.Segments[0].Name)
+	assert.Equal(t, first, plan.Segments[0].Start)
+	assert.Positive(t, plan.Size())
+
+	// the preview doesn't delete the segments, and follows the updated TTL.
+	tsdb.UpdateOptions(&commonv1.ResourceOpts{
+		ShardNum:        1,
+		SegmentInterval: &commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_DAY, Num: 1},
+		Ttl:             &commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_DAY, Num: 2},
+	})
+	plan, err = tsdb.PreviewRetention(now)
+	require.NoError(t, err)
+	require.Len(t, plan.Segments, 2)
+	ss, _ := segCtrl.segments(false)
+	for _, s := range ss {
+		s.DecRef()
+	}
+	require.Len(t, ss, 3)
+
+	deleted, err := tsdb.RunRetention(now)
+	require.NoError(t, err)
+	assert.Equal(t, plan, deleted)
+	ss, _ = segCtrl.segments(false)
+	for _, s := range ss {
+		s.DecRef()
+	}
+	require.Len(t, ss, 1)
+	assert.Equal(t, first.AddDate(0, 0, 2), ss[0].Start)
+}
+
 func setUpDB(t *testing.T, ttlDays ...int) (*database[*MockTSTable, any], timestamp.MockClock, *segmentController[*MockTSTable, any], func()) {
 	dir, defFn := test.Space(require.New(t))
 
@@ -202,6 +283,18 @@ func (m *MockTSTable) TakeFileSnapshot(_ string) error {
 	return nil
 }
 
+func (m *MockTSTable) Usage(u *TableUsage) {
+	u.PartsCount++
+}
+
+func (m *MockTSTable) Digest(td *TableDigest) {
+	td.AddBlock(1, []int64{0})
+}
+
+func (m *MockTSTable) Warmup(context.Context, Cache) error {
+	return nil
+}
+
 var MockTSTableCreator = func(_ fs.FileSystem, _ string, _ common.Position,
 	_ *logger.Logger, _ timestamp.TimeRange, _, _ any,
 ) (*MockTSTable, error) {
@@ -71,6 +71,7 @@ type segment[T TSTable, O any] struct {
 	sLst     atomic.Pointer[[]*shard[T]]
 	*segmentCache
 	indexMetrics *inverted.Metrics
+	warmer       *warmer
 	lfs          banyanfs.FileSystem
 	position     common.Position
 	timestamp.TimeRange
@@ -89,13 +90,17 @@ func (sc *segmentController[T, O]) openSegment(ctx context.Context, startTime, e
 	if err != nil {
 		return nil, err
 	}
+	_, unit, err := parseSuffix(suffix)
+	if err != nil {
+		return nil, err
+	}
 	p := common.GetPosition(ctx)
 	p.Segment = suffix
 	ctx = common.SetPosition(ctx, func(_ common.Position) common.Position {
 		return p
 	})
 	options := sc.getOptions()
-	id := generateSegID(options.SegmentInterval.Unit, suffixInteger)
+	id := generateSegID(unit, suffixInteger)
 
 	s = &segment[T, O]{
 		id:           id,
@@ -108,6 +113,7 @@ func (sc *segmentController[T, O]) openSegment(ctx context.Context, startTime, e
 		tsdbOpts:     options,
 		lfs:          sc.lfs,
 		segmentCache: &segmentCache{groupCache: groupCache, segmentID: id},
+		warmer:       sc.warmer,
 	}
 	s.l = logger.Fetch(ctx, s.String())
 	s.lastAccessed.Store(time.Now().UnixNano())
@@ -144,20 +150,33 @@ func (s *segment[T, O]) Tables() (tt []T, cc []Cache) {
 	return tt, cc
 }
 
+// incRef acquires the segment. It fails with ErrExpiredData if the segment is retired,
+// which prevents a retired segment from being reopened.
 func (s *segment[T, O]) incRef(ctx context.Context) error {
 	s.lastAccessed.Store(time.Now().UnixNano())
-	if atomic.LoadInt32(&s.refCount) <= 0 {
-		return s.initialize(ctx)
+	for {
+		if atomic.LoadUint32(&s.mustBeDeleted) != 0 {
+			return ErrExpiredData
+		}
+		current := atomic.LoadInt32(&s.refCount)
+		if current <= 0 {
+			return s.initialize(ctx)
+		}
+		if atomic.CompareAndSwapInt32(&s.refCount, current, current+1) {
+			return nil
+		}
 	}
-	atomic.AddInt32(&s.refCount, 1)
-	return nil
 }
 
 func (s *segment[T, O]) initialize(ctx context.Context) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if atomic.LoadUint32(&s.mustBeDeleted) != 0 {
+		return ErrExpiredData
+	}
 	if atomic.LoadInt32(&s.refCount) > 0 {
+		atomic.AddInt32(&s.refCount, 1)
 		return nil
 	}
 
@@ -181,6 +200,9 @@ func (s *segment[T, O]) initialize(ctx context.Context) error {
 	atomic.StoreInt32(&s.refCount, 1)
 
 	s.l.Info().Stringer("seg", s).Msg("segment initialized")
+	if s.warmer != nil {
+		s.warmer.submit(s.String(), s.warmup)
+	}
 	return nil
 }
 
@@ -214,7 +236,8 @@ func (s *segment[T, O]) performCleanup() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if atomic.LoadInt32(&s.refCount) > 0 && atomic.LoadUint32(&s.mustBeDeleted) == 0 {
+	// the writes in flight keep a retired segment until they are done.
+	if atomic.LoadInt32(&s.refCount) > 0 {
 		return
 	}
 
@@ -304,6 +327,7 @@ type segmentController[T TSTable, O any] struct {
 	opts         *TSDBOpts[T, O]
 	l            *logger.Logger
 	indexMetrics *inverted.Metrics
+	warmer       *warmer
 	*groupCache
 	lfs         banyanfs.FileSystem
 	position    common.Position
@@ -323,6 +347,10 @@ func newSegmentController[T TSTable, O any](ctx context.Context, location string
 ) *segmentController[T, O] {
 	clock, _ := timestamp.GetClock(ctx)
 	p := common.GetPosition(ctx)
+	var w *warmer
+	if opts.SegmentWarmup {
+		w = newWarmer(l.Named("warmup"))
+	}
 	return &segmentController[T, O]{
 		location:     location,
 		opts:         &opts,
@@ -336,6 +364,7 @@ func newSegmentController[T TSTable, O any](ctx context.Context, location string
 		idleTimeout:  idleTimeout,
 		lfs:          lfs,
 		groupCache:   &groupCache{serviceCache, group},
+		warmer:       w,
 	}
 }
 
@@ -350,8 +379,8 @@ func (sc *segmentController[T, O]) updateOptions(resourceOpts *commonv1.Resource
 	defer sc.optsMutex.Unlock()
 	si := MustToIntervalRule(resourceOpts.SegmentInterval)
 	if sc.opts.SegmentInterval.Unit != si.Unit {
-		sc.l.Panic().Msg("segment interval unit cannot be changed")
-		return
+		// the existing segments keep their unit, and the new segments are created in the new unit.
+		sc.l.Info().Stringer("from", sc.opts.SegmentInterval.Unit).Stringer("to", si.Unit).Msg("change the unit of the segment interval")
 	}
 	sc.opts.SegmentInterval = si
 	sc.opts.TTL = MustToIntervalRule(resourceOpts.Ttl)
@@ -379,10 +408,6 @@ func (sc *segmentController[T, O]) selectSegments(timeRange timestamp.TimeRange)
 }
 
 func (sc *segmentController[T, O]) createSegment(ts time.Time) (*segment[T, O], error) {
-	// Before the first remove old segment run, any segment should be created.
-	if sc.deadline.Load() > ts.UnixNano() {
-		return nil, ErrExpiredData
-	}
 	s, err := sc.create(ts)
 	if err != nil {
 		return nil, err
@@ -435,8 +460,8 @@ func (sc *segmentController[T, O]) closeIdleSegments() int {
 	return closedCount
 }
 
-func (sc *segmentController[T, O]) format(tm time.Time) string {
-	switch sc.getOptions().SegmentInterval.Unit {
+func formatSuffix(tm time.Time, unit IntervalUnit) string {
+	switch unit {
 	case HOUR:
 		return tm.Format(hourFormat)
 	case DAY:
@@ -445,22 +470,39 @@ func (sc *segmentController[T, O]) format(tm time.Time) string {
 	panic("invalid interval unit")
 }
 
-func (sc *segmentController[T, O]) parse(value string) (time.Time, error) {
-	switch sc.getOptions().SegmentInterval.Unit {
-	case HOUR:
-		return time.ParseInLocation(hourFormat, value, time.Local)
-	case DAY:
-		return time.ParseInLocation(dayFormat, value, time.Local)
+// parseSuffix parses the start time of a segment from its suffix, whose format tells the unit of the segment.
+// The segments of different units coexist after the unit of the segment interval changes.
+func parseSuffix(value string) (time.Time, IntervalUnit, error) {
+	switch len(value) {
+	case len(hourFormat):
+		t, err := time.ParseInLocation(hourFormat, value, time.Local)
+		return t, HOUR, err
+	case len(dayFormat):
+		t, err := time.ParseInLocation(dayFormat, value, time.Local)
+		return t, DAY, err
+	}
+	return time.Time{}, HOUR, errors.Errorf("invalid segment suffix %s", value)
+}
+
+// naturalEnd returns the end of a segment which starts at start in unit, before being clamped by the next segment.
+// An hour segment created after the unit changes to day extends to the boundary of the day segments.
+// The number of the days of a day segment is unknown after the unit changes to hour, so it's assumed to be one.
+func naturalEnd(start time.Time, unit IntervalUnit, rule IntervalRule) time.Time {
+	switch {
+	case unit == rule.Unit:
+		return rule.nextTime(start)
+	case unit == HOUR:
+		return rule.nextTime(rule.Unit.standard(start))
+	default:
+		return IntervalRule{Unit: unit, Num: 1}.nextTime(start)
 	}
-	panic("invalid interval unit")
 }
 
 func (sc *segmentController[T, O]) open() error {
 	sc.Lock()
 	defer sc.Unlock()
 	emptySegments := make([]string, 0)
-	err := loadSegments(sc.location, segPathPrefix, sc, sc.getOptions().SegmentInterval, func(start, end time.Time) error {
-		suffix := sc.format(start)
+	err := loadSegments(sc.location, segPathPrefix, sc.getOptions().SegmentInterval, func(suffix string, start, end time.Time) error {
 		segmentPath := path.Join(sc.location, fmt.Sprintf(segTemplate, suffix))
 		metadataPath := path.Join(segmentPath, metadataFilename)
 		version, err := sc.lfs.Read(metadataPath)
@@ -478,7 +520,7 @@ func (sc *segmentController[T, O]) open() error {
 		if err = checkVersion(convert.BytesToString(version)); err != nil {
 			return err
 		}
-		_, err = sc.load(start, end, sc.location)
+		_, err = sc.load(suffix, start, end, sc.location)
 		return err
 	})
 	if len(emptySegments) > 0 {
@@ -493,6 +535,11 @@ func (sc *segmentController[T, O]) open() error {
 func (sc *segmentController[T, O]) create(start time.Time) (*segment[T, O], error) {
 	sc.Lock()
 	defer sc.Unlock()
+	// The deadline is checked under the lock which the retirement holds, so that a write either
+	// gets a segment before it's retired, or is rejected. Before the first retirement, any segment could be created.
+	if sc.deadline.Load() > start.UnixNano() {
+		return nil, ErrExpiredData
+	}
 	last := len(sc.lst) - 1
 	for i := range sc.lst {
 		s := sc.lst[last-i]
@@ -500,25 +547,30 @@ func (sc *segmentController[T, O]) create(start time.Time) (*segment[T, O], erro
 			return s, nil
 		}
 	}
-	options := sc.getOptions()
-	start = options.SegmentInterval.Unit.standard(start)
+	ts := start
+	rule := sc.getOptions().SegmentInterval
+	start = rule.Unit.standard(ts)
 	var next *segment[T, O]
 	for _, s := range sc.lst {
-		if s.Contains(start.UnixNano()) {
-			return s, nil
+		// the segments created before the unit changes may overlap the standard start.
+		if s.End.After(start) && !s.Start.After(ts) {
+			start = s.End
 		}
-		if next == nil && s.Start.After(start) {
+		if next == nil && s.Start.After(ts) {
 			next = s
 		}
 	}
-	stdEnd := options.SegmentInterval.nextTime(start)
-	var end time.Time
-	if next != nil && next.Start.Before(stdEnd) {
+	unit := rule.Unit
+	if !start.Equal(rule.Unit.standard(start)) {
+		// the transitional segment fills the gap until the next boundary of the new unit.
+		unit = HOUR
+	}
+	end := naturalEnd(start, unit, rule)
+	if next != nil && next.Start.Before(end) {
 		end = next.Start
-	} else {
-		end = stdEnd
 	}
-	segPath := path.Join(sc.location, fmt.Sprintf(segTemplate, sc.format(start)))
+	suffix := formatSuffix(start, unit)
+	segPath := path.Join(sc.location, fmt.Sprintf(segTemplate, suffix))
 	sc.lfs.MkdirPanicIfExist(segPath, DirPerm)
 	data := []byte(currentVersion)
 	metadataPath := filepath.Join(segPath, metadataFilename)
@@ -533,17 +585,16 @@ func (sc *segmentController[T, O]) create(start time.Time) (*segment[T, O], erro
 	if n != len(data) {
 		logger.Panicf("unexpected number of bytes written to %s; got %d; want %d", metadataPath, n, len(data))
 	}
-	return sc.load(start, end, sc.location)
+	return sc.load(suffix, start, end, sc.location)
 }
 
 func (sc *segmentController[T, O]) sortLst() {
 	sort.Slice(sc.lst, func(i, j int) bool {
-		return sc.lst[i].id < sc.lst[j].id
+		return sc.lst[i].Start.Before(sc.lst[j].Start)
 	})
 }
 
-func (sc *segmentController[T, O]) load(start, end time.Time, root string) (seg *segment[T, O], err error) {
-	suffix := sc.format(start)
+func (sc *segmentController[T, O]) load(suffix string, start, end time.Time, root string) (seg *segment[T, O], err error) {
 	segPath := path.Join(root, fmt.Sprintf(segTemplate, suffix))
 	ctx := common.SetPosition(context.WithValue(context.Background(), logger.ContextKey, sc.l), func(_ common.Position) common.Position {
 		return sc.position
@@ -562,11 +613,7 @@ func (sc *segmentController[T, O]) remove(deadline time.Time) (hasSegment bool,
 	for _, s := range ss {
 		if s.Before(deadline) {
 			hasSegment = true
-			id := s.id
-			s.delete()
-			sc.Lock()
-			sc.removeSeg(id)
-			sc.Unlock()
+			sc.retire(s)
 			sc.l.Info().Stringer("segment", s).Msg("removed a segment")
 		}
 		s.DecRef()
@@ -574,6 +621,46 @@ func (sc *segmentController[T, O]) remove(deadline time.Time) (hasSegment bool,
 	return hasSegment, err
 }
 
+// planRetention returns the segments which the retention deletes at the deadline, without deleting them.
+func (sc *segmentController[T, O]) planRetention(deadline time.Time) RetentionPlan {
+	plan := RetentionPlan{Deadline: deadline}
+	ss, _ := sc.segments(false)
+	for _, s := range ss {
+		if s.Before(deadline) {
+			size, err := DirSize(s.location)
+			if err != nil {
+				sc.l.Warn().Err(err).Stringer("segment", s).Msg("failed to get the size of the segment")
+			}
+			plan.Segments = append(plan.Segments, RetentionSegment{
+				TimeRange: s.TimeRange,
+				Name:      filepath.Base(s.location),
+				Size:      size,
+			})
+		}
+		s.DecRef()
+	}
+	return plan
+}
+
+// DirSize returns the total bytes of the files under dir.
+func DirSize(dir string) (size int64, err error) {
+	err = filepath.WalkDir(dir, func(_ string, d fs.DirEntry, errWalk error) error {
+		if errWalk != nil {
+			return errWalk
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, errInfo := d.Info()
+		if errInfo != nil {
+			return errInfo
+		}
+		size += info.Size()
+		return nil
+	})
+	return size, err
+}
+
 func (sc *segmentController[T, O]) getExpiredSegmentsTimeRange() *timestamp.TimeRange {
 	deadline := time.Now().Local().Add(-sc.opts.TTL.estimatedDuration())
 	timeRange := &timestamp.TimeRange{
@@ -593,16 +680,23 @@ func (sc *segmentController[T, O]) getExpiredSegmentsTimeRange() *timestamp.Time
 	return timeRange
 }
 
+func (sc *segmentController[T, O]) getSegmentsTimeRanges() []timestamp.TimeRange {
+	ss, _ := sc.segments(false)
+	ranges := make([]timestamp.TimeRange, 0, len(ss))
+	for _, s := range ss {
+		ranges = append(ranges, s.GetTimeRange())
+		s.DecRef()
+	}
+	return ranges
+}
+
 func (sc *segmentController[T, O]) deleteExpiredSegments(timeRange timestamp.TimeRange) int64 {
 	deadline := time.Now().Local().Add(-sc.opts.TTL.estimatedDuration())
 	var count int64
 	ss, _ := sc.segments(false)
 	for _, s := range ss {
 		if s.Before(deadline) && s.Overlapping(timeRange) {
-			s.delete()
-			sc.Lock()
-			sc.removeSeg(s.id)
-			sc.Unlock()
+			sc.retire(s)
 			count++
 		}
 		s.DecRef()
@@ -610,14 +704,41 @@ func (sc *segmentController[T, O]) deleteExpiredSegments(timeRange timestamp.Tim
 	return count
 }
 
+// deleteSegments deletes the segments included in the time range, leaving the overlapping ones alone.
+func (sc *segmentController[T, O]) deleteSegments(timeRange timestamp.TimeRange) int64 {
+	var count int64
+	ss, _ := sc.segments(false)
+	for _, s := range ss {
+		if timeRange.Include(s.TimeRange) {
+			sc.retire(s)
+			count++
+		}
+		s.DecRef()
+	}
+	return count
+}
+
+// retire takes the segment out of the admission of the writes before deleting it.
+// The writes holding the segment are done before its files are removed, while the later writes
+// to its time range are rejected with ErrExpiredData instead of reopening or recreating it.
+func (sc *segmentController[T, O]) retire(s *segment[T, O]) {
+	sc.Lock()
+	sc.removeSeg(s.id)
+	sc.Unlock()
+	s.delete()
+}
+
 func (sc *segmentController[T, O]) removeSeg(segID segmentID) {
 	for i, b := range sc.lst {
 		if b.id == segID {
 			sc.lst = append(sc.lst[:i], sc.lst[i+1:]...)
-			if len(sc.lst) < 1 {
-				sc.deadline.Store(0)
-			} else {
-				sc.deadline.Store(sc.lst[0].Start.UnixNano())
+			// the deadline never moves backward, even if all the segments are removed.
+			deadline := b.End.UnixNano()
+			if len(sc.lst) > 0 && sc.lst[0].Start.UnixNano() > deadline {
+				deadline = sc.lst[0].Start.UnixNano()
+			}
+			if deadline > sc.deadline.Load() {
+				sc.deadline.Store(deadline)
 			}
 			break
 		}
@@ -625,6 +746,9 @@ func (sc *segmentController[T, O]) removeSeg(segID segmentID) {
 }
 
 func (sc *segmentController[T, O]) close() {
+	if sc.warmer != nil {
+		sc.warmer.close()
+	}
 	sc.Lock()
 	defer sc.Unlock()
 	for _, s := range sc.lst {
@@ -636,30 +760,35 @@ func (sc *segmentController[T, O]) close() {
 	}
 }
 
-func loadSegments[T TSTable, O any](root, prefix string, parser *segmentController[T, O], intervalRule IntervalRule, loadFn func(start, end time.Time) error) error {
-	var startTimeLst []time.Time
+func loadSegments(root, prefix string, intervalRule IntervalRule, loadFn func(suffix string, start, end time.Time) error) error {
+	type segmentStart struct {
+		start  time.Time
+		suffix string
+		unit   IntervalUnit
+	}
+	var startLst []segmentStart
 	if err := walkDir(
 		root,
 		prefix,
 		func(suffix string) error {
-			startTime, err := parser.parse(suffix)
+			startTime, unit, err := parseSuffix(suffix)
 			if err != nil {
 				return err
 			}
-			startTimeLst = append(startTimeLst, startTime)
+			startLst = append(startLst, segmentStart{start: startTime, suffix: suffix, unit: unit})
 			return nil
 		}); err != nil {
 		return err
 	}
-	sort.Slice(startTimeLst, func(i, j int) bool { return i < j })
-	for i, start := range startTimeLst {
+	sort.Slice(startLst, func(i, j int) bool { return startLst[i].start.Before(startLst[j].start) })
+	for i, s := range startLst {
 		var end time.Time
-		if i < len(startTimeLst)-1 {
-			end = startTimeLst[i+1]
+		if i < len(startLst)-1 {
+			end = startLst[i+1].start
 		} else {
-			end = intervalRule.nextTime(start)
+			end = naturalEnd(s.start, s.unit, intervalRule)
 		}
-		if err := loadFn(start, end); err != nil {
+		if err := loadFn(s.suffix, s.start, end); err != nil {
 			return err
 		}
 	}
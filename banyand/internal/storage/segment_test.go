@@ -23,6 +23,7 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -30,6 +31,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/apache/skywalking-banyandb/api/common"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
 	"github.com/apache/skywalking-banyandb/pkg/fs"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
 	"github.com/apache/skywalking-banyandb/pkg/timestamp"
@@ -37,7 +39,8 @@ import (
 
 // mockTSTable is a minimal implementation of TSTable for testing.
 type mockTSTable struct {
-	ID common.ShardID
+	warmed *atomic.Int32
+	ID     common.ShardID
 }
 
 func (m mockTSTable) Close() error {
@@ -48,6 +51,17 @@ func (m mockTSTable) Collect(Metrics) {}
 
 func (m mockTSTable) TakeFileSnapshot(string) error { return nil }
 
+func (m mockTSTable) Usage(*TableUsage) {}
+
+func (m mockTSTable) Digest(*TableDigest) {}
+
+func (m mockTSTable) Warmup(context.Context, Cache) error {
+	if m.warmed != nil {
+		m.warmed.Add(1)
+	}
+	return nil
+}
+
 // mockTSTableOpener implements the necessary functions to open a TSTable.
 type mockTSTableOpener struct{}
 
@@ -642,3 +656,279 @@ func TestDeleteExpiredSegmentsWithClosedSegments(t *testing.T) {
 			"Remaining segment %d should be from the expected date", i)
 	}
 }
+
+func TestDeleteSegmentsRegardlessOfTTL(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	l := logger.GetLogger("test-delete-segments")
+	ctx = context.WithValue(ctx, logger.ContextKey, l)
+	ctx = common.SetPosition(ctx, func(_ common.Position) common.Position {
+		return common.Position{
+			Database: "test-db",
+			Stage:    "test-stage",
+		}
+	})
+
+	opts := TSDBOpts[mockTSTable, mockTSTableOpener]{
+		TSTableCreator: func(_ fs.FileSystem, _ string, _ common.Position, _ *logger.Logger,
+			_ timestamp.TimeRange, _ mockTSTableOpener, _ any,
+		) (mockTSTable, error) {
+			return mockTSTable{ID: common.ShardID(0)}, nil
+		},
+		ShardNum: 1,
+		SegmentInterval: IntervalRule{
+			Unit: DAY,
+			Num:  1,
+		},
+		TTL: IntervalRule{
+			Unit: DAY,
+			Num:  30,
+		},
+		SeriesIndexFlushTimeoutSeconds: 10,
+		SeriesIndexCacheMaxBytes:       1024 * 1024,
+	}
+	sc := newSegmentController[mockTSTable, mockTSTableOpener](
+		ctx,
+		tempDir,
+		l,
+		opts,
+		nil,
+		nil,
+		time.Minute,
+		fs.NewLocalFileSystemWithLoggerAndLimit(logger.GetLogger("storage"), opts.MemoryLimit),
+		NewServiceCache().(*serviceCache),
+		group,
+	)
+
+	now := time.Now().UTC()
+	baseDate := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	var segments []*segment[mockTSTable, mockTSTableOpener]
+	for _, date := range []time.Time{baseDate.AddDate(0, 0, -3), baseDate.AddDate(0, 0, -2), baseDate.AddDate(0, 0, -1)} {
+		segmentPath := filepath.Join(tempDir, "segment-"+date.Format(dayFormat))
+		require.NoError(t, os.MkdirAll(segmentPath, DirPerm))
+		require.NoError(t, os.WriteFile(filepath.Join(segmentPath, metadataFilename), []byte(currentVersion), FilePerm))
+		segment, err := sc.openSegment(ctx, date, date.Add(24*time.Hour), segmentPath, date.Format(dayFormat), sc.groupCache)
+		require.NoError(t, err)
+		sc.Lock()
+		sc.lst = append(sc.lst, segment)
+		sc.sortLst()
+		sc.Unlock()
+		segments = append(segments, segment)
+	}
+
+	// none of the segments are past the TTL.
+	timeRange := timestamp.NewSectionTimeRange(segments[0].Start, segments[2].Start.Add(time.Hour))
+	assert.Equal(t, int64(0), sc.deleteExpiredSegments(timeRange))
+
+	// the last segment only overlaps the time range, so it's kept.
+	assert.Equal(t, int64(2), sc.deleteSegments(timeRange))
+	require.Len(t, sc.lst, 1)
+	assert.Equal(t, segments[2].Start, sc.lst[0].Start)
+}
+
+func TestRetireSegmentWithInflightWrites(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	l := logger.GetLogger("test-retire-segment")
+	ctx = context.WithValue(ctx, logger.ContextKey, l)
+	ctx = common.SetPosition(ctx, func(_ common.Position) common.Position {
+		return common.Position{
+			Database: "test-db",
+			Stage:    "test-stage",
+		}
+	})
+
+	opts := TSDBOpts[mockTSTable, mockTSTableOpener]{
+		TSTableCreator: func(_ fs.FileSystem, _ string, _ common.Position, _ *logger.Logger,
+			_ timestamp.TimeRange, _ mockTSTableOpener, _ any,
+		) (mockTSTable, error) {
+			return mockTSTable{ID: common.ShardID(0)}, nil
+		},
+		ShardNum: 1,
+		SegmentInterval: IntervalRule{
+			Unit: DAY,
+			Num:  1,
+		},
+		TTL: IntervalRule{
+			Unit: DAY,
+			Num:  3,
+		},
+		SeriesIndexFlushTimeoutSeconds: 10,
+		SeriesIndexCacheMaxBytes:       1024 * 1024,
+	}
+
+	serviceCache := NewServiceCache().(*serviceCache)
+	sc := newSegmentController[mockTSTable, mockTSTableOpener](
+		ctx,
+		tempDir,
+		l,
+		opts,
+		nil,           // indexMetrics
+		nil,           // metrics
+		5*time.Minute, // idleTimeout
+		fs.NewLocalFileSystemWithLoggerAndLimit(logger.GetLogger("storage"), opts.MemoryLimit),
+		serviceCache,
+		group,
+	)
+	defer sc.close()
+
+	now := time.Now()
+	expiredTS := now.AddDate(0, 0, -6)
+	// the write in flight holds the expired segment.
+	inflight, err := sc.createSegment(expiredTS)
+	require.NoError(t, err)
+	live, err := sc.createSegment(now)
+	require.NoError(t, err)
+	live.DecRef()
+
+	hasSegment, err := sc.remove(now.AddDate(0, 0, -3))
+	require.NoError(t, err)
+	assert.True(t, hasSegment)
+	assert.Len(t, sc.lst, 1)
+
+	// the later writes to the retired time range are rejected instead of reopening or recreating the segment.
+	_, err = sc.createSegment(expiredTS)
+	assert.ErrorIs(t, err, ErrExpiredData)
+	assert.ErrorIs(t, inflight.incRef(ctx), ErrExpiredData)
+
+	// the files are kept until the write in flight is done.
+	assert.DirExists(t, inflight.location)
+	inflight.DecRef()
+	assert.NoDirExists(t, inflight.location)
+
+	// the retired time range stays rejected after all the segments are retired.
+	hasSegment, err = sc.remove(live.End)
+	require.NoError(t, err)
+	assert.True(t, hasSegment)
+	assert.Empty(t, sc.lst)
+	_, err = sc.createSegment(expiredTS)
+	assert.ErrorIs(t, err, ErrExpiredData)
+	next, err := sc.createSegment(now.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	next.DecRef()
+}
+
+func TestChangeSegmentIntervalUnit(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	l := logger.GetLogger("test-change-segment-interval")
+	ctx = context.WithValue(ctx, logger.ContextKey, l)
+	ctx = common.SetPosition(ctx, func(_ common.Position) common.Position {
+		return common.Position{
+			Database: "test-db",
+			Stage:    "test-stage",
+		}
+	})
+
+	newOpts := func() TSDBOpts[mockTSTable, mockTSTableOpener] {
+		return TSDBOpts[mockTSTable, mockTSTableOpener]{
+			TSTableCreator: func(_ fs.FileSystem, _ string, _ common.Position, _ *logger.Logger,
+				_ timestamp.TimeRange, _ mockTSTableOpener, _ any,
+			) (mockTSTable, error) {
+				return mockTSTable{ID: common.ShardID(0)}, nil
+			},
+			ShardNum: 1,
+			SegmentInterval: IntervalRule{
+				Unit: HOUR,
+				Num:  4,
+			},
+			TTL: IntervalRule{
+				Unit: DAY,
+				Num:  7,
+			},
+			SeriesIndexFlushTimeoutSeconds: 10,
+			SeriesIndexCacheMaxBytes:       1024 * 1024,
+		}
+	}
+	newController := func() *segmentController[mockTSTable, mockTSTableOpener] {
+		opts := newOpts()
+		return newSegmentController[mockTSTable, mockTSTableOpener](
+			ctx,
+			tempDir,
+			l,
+			opts,
+			nil,           // indexMetrics
+			nil,           // metrics
+			5*time.Minute, // idleTimeout
+			fs.NewLocalFileSystemWithLoggerAndLimit(logger.GetLogger("storage"), opts.MemoryLimit),
+			NewServiceCache().(*serviceCache),
+			group,
+		)
+	}
+	createSegment := func(sc *segmentController[mockTSTable, mockTSTableOpener], ts time.Time) *segment[mockTSTable, mockTSTableOpener] {
+		s, err := sc.createSegment(ts)
+		require.NoError(t, err)
+		s.DecRef()
+		return s
+	}
+	resourceOpts := func(unit commonv1.IntervalRule_Unit, num uint32) *commonv1.ResourceOpts {
+		return &commonv1.ResourceOpts{
+			ShardNum:        1,
+			SegmentInterval: &commonv1.IntervalRule{Unit: unit, Num: num},
+			Ttl:             &commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_DAY, Num: 7},
+		}
+	}
+	type segmentRange struct {
+		start time.Time
+		end   time.Time
+	}
+	ranges := func(sc *segmentController[mockTSTable, mockTSTableOpener]) []segmentRange {
+		var rr []segmentRange
+		for _, s := range sc.lst {
+			rr = append(rr, segmentRange{start: s.Start, end: s.End})
+		}
+		return rr
+	}
+
+	day1 := time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local)
+	day2 := day1.AddDate(0, 0, 1)
+	day3 := day2.AddDate(0, 0, 1)
+	sc := newController()
+	require.NoError(t, sc.open())
+	createSegment(sc, day1.Add(13*time.Hour))
+
+	// hour -> day: the gap until the next day is filled by a transitional segment.
+	sc.updateOptions(resourceOpts(commonv1.IntervalRule_UNIT_DAY, 1))
+	transitional := createSegment(sc, day1.Add(18*time.Hour))
+	assert.Equal(t, day1.Add(17*time.Hour), transitional.Start)
+	assert.Equal(t, day2, transitional.End)
+	assert.Equal(t, day1.Add(13*time.Hour), createSegment(sc, day1.Add(15*time.Hour)).Start)
+	daily := createSegment(sc, day2.Add(time.Hour))
+	assert.Equal(t, day2, daily.Start)
+	assert.Equal(t, day3, daily.End)
+
+	// day -> hour: the new segments start after the existing day segment.
+	sc.updateOptions(resourceOpts(commonv1.IntervalRule_UNIT_HOUR, 2))
+	assert.Equal(t, day2, createSegment(sc, day2.Add(23*time.Hour)).Start)
+	hourly := createSegment(sc, day3.Add(30*time.Minute))
+	assert.Equal(t, day3, hourly.Start)
+	assert.Equal(t, day3.Add(2*time.Hour), hourly.End)
+
+	expected := []segmentRange{
+		{start: day1.Add(13 * time.Hour), end: day1.Add(17 * time.Hour)},
+		{start: day1.Add(17 * time.Hour), end: day2},
+		{start: day2, end: day3},
+		{start: day3, end: day3.Add(2 * time.Hour)},
+	}
+	assert.Equal(t, expected, ranges(sc))
+	ss, err := sc.selectSegments(timestamp.NewInclusiveTimeRange(day1.Add(20*time.Hour), day2.Add(time.Hour)))
+	require.NoError(t, err)
+	require.Len(t, ss, 2)
+	for _, s := range ss {
+		s.DecRef()
+	}
+	sc.close()
+
+	// the segments of both units are reloaded with the same time ranges.
+	reopened := newController()
+	defer reopened.close()
+	reopened.updateOptions(resourceOpts(commonv1.IntervalRule_UNIT_HOUR, 2))
+	require.NoError(t, reopened.open())
+	assert.Equal(t, expected, ranges(reopened))
+}
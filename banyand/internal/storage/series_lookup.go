@@ -0,0 +1,199 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+	"github.com/apache/skywalking-banyandb/pkg/schema"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+type entitySchema interface {
+	GetEntity() *databasev1.Entity
+}
+
+// LookupSeries looks up the series of the resource named by req in the series indexes of the groups in the catalog.
+// The groups and the resources which aren't loaded on this node are skipped.
+func LookupSeries[T TSTable, O any](ctx context.Context, catalog commonv1.Catalog, repo schema.Repository,
+	req *databasev1.SeriesServiceLookupRequest,
+) ([]*databasev1.Series, error) {
+	tr := timestamp.NewInclusiveTimeRange(time.Unix(0, timestamp.MinNanoTime), time.Unix(0, timestamp.MaxNanoTime))
+	if req.GetTimeRange() != nil {
+		tr = timestamp.NewSectionTimeRange(req.GetTimeRange().GetBegin().AsTime(), req.GetTimeRange().GetEnd().AsTime())
+	}
+	var result []*databasev1.Series
+	for _, name := range req.GetGroups() {
+		g, ok := repo.LoadGroup(name)
+		if !ok || g.GetSchema().GetCatalog() != catalog {
+			continue
+		}
+		db, ok := g.SupplyTSDB().(TSDB[T, O])
+		if !ok || db == nil {
+			continue
+		}
+		r, ok := repo.LoadResource(&commonv1.Metadata{Group: name, Name: req.GetName()})
+		if !ok {
+			continue
+		}
+		es, ok := r.Schema().(entitySchema)
+		if !ok {
+			return nil, fmt.Errorf("%s/%s has no entity", name, req.GetName())
+		}
+		tagNames := es.GetEntity().GetTagNames()
+		entityValues, err := entityValuesOf(tagNames, req.GetEntity())
+		if err != nil {
+			return nil, err
+		}
+		ss, err := lookupSeries(ctx, db, tr, &pbv1.Series{Subject: req.GetName(), EntityValues: entityValues})
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up the series in the group %s: %w", name, err)
+		}
+		for _, s := range ss {
+			s.Group = name
+			for i, tv := range s.Entity {
+				if i < len(tagNames) {
+					tv.Key = tagNames[i]
+				}
+			}
+		}
+		result = append(result, ss...)
+	}
+	return MergeSeries(result, req.GetLimit()), nil
+}
+
+// entityValuesOf orders the values of tags by the entity tag names.
+// The absent tags match any value.
+func entityValuesOf(tagNames []string, tags []*modelv1.Tag) ([]*modelv1.TagValue, error) {
+	values := make([]*modelv1.TagValue, len(tagNames))
+	for i := range values {
+		values[i] = pbv1.AnyTagValue
+	}
+	for _, t := range tags {
+		i := indexOf(tagNames, t.GetKey())
+		if i < 0 {
+			return nil, fmt.Errorf("tag %s is not in the entity %v", t.GetKey(), tagNames)
+		}
+		values[i] = t.GetValue()
+	}
+	return values, nil
+}
+
+func indexOf(names []string, name string) int {
+	for i := range names {
+		if names[i] == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func lookupSeries[T TSTable, O any](ctx context.Context, db TSDB[T, O], tr timestamp.TimeRange, series *pbv1.Series) ([]*databasev1.Series, error) {
+	segments, err := db.SelectSegments(tr)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		for i := range segments {
+			segments[i].DecRef()
+		}
+	}()
+	found := make(map[common.SeriesID]*databasev1.Series)
+	var result []*databasev1.Series
+	for _, s := range segments {
+		sd, _, err := s.IndexDB().Search(ctx, []*pbv1.Series{series}, IndexSearchOpts{})
+		if err != nil {
+			return nil, err
+		}
+		segmentRange := s.GetTimeRange()
+		for i, ss := range sd.SeriesList {
+			if ss.Subject != series.Subject {
+				continue
+			}
+			// the series written before tracking the timestamps are seen in the whole segment.
+			first, last := segmentRange.Start, segmentRange.End
+			if sd.Seen != nil && sd.Seen[i].Last > 0 {
+				first, last = time.Unix(0, sd.Seen[i].First), time.Unix(0, sd.Seen[i].Last)
+			}
+			if f, ok := found[ss.ID]; ok {
+				seen(f, first, last)
+				continue
+			}
+			f := &databasev1.Series{
+				SeriesId:  uint64(ss.ID),
+				FirstSeen: timestamppb.New(first),
+				LastSeen:  timestamppb.New(last),
+			}
+			for _, tv := range ss.EntityValues {
+				f.Entity = append(f.Entity, &modelv1.Tag{Value: tv})
+			}
+			found[ss.ID] = f
+			result = append(result, f)
+		}
+	}
+	return result, nil
+}
+
+func seen(s *databasev1.Series, first, last time.Time) {
+	if first.Before(s.GetFirstSeen().AsTime()) {
+		s.FirstSeen = timestamppb.New(first)
+	}
+	if last.After(s.GetLastSeen().AsTime()) {
+		s.LastSeen = timestamppb.New(last)
+	}
+}
+
+// MergeSeries merges the series found by the nodes, which widens the first and last seen timestamps of the same series.
+// The result is sorted by the groups and the series IDs, and is truncated to limit if it's positive.
+func MergeSeries(ss []*databasev1.Series, limit uint32) []*databasev1.Series {
+	type key struct {
+		group string
+		id    uint64
+	}
+	found := make(map[key]*databasev1.Series, len(ss))
+	result := make([]*databasev1.Series, 0, len(ss))
+	for _, s := range ss {
+		k := key{group: s.GetGroup(), id: s.GetSeriesId()}
+		if f, ok := found[k]; ok {
+			seen(f, s.GetFirstSeen().AsTime(), s.GetLastSeen().AsTime())
+			continue
+		}
+		found[k] = s
+		result = append(result, s)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].GetGroup() != result[j].GetGroup() {
+			return result[i].GetGroup() < result[j].GetGroup()
+		}
+		return result[i].GetSeriesId() < result[j].GetSeriesId()
+	})
+	if limit > 0 && len(result) > int(limit) {
+		result = result[:limit]
+	}
+	return result
+}
@@ -0,0 +1,123 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/index"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+func strTagValue(v string) *modelv1.TagValue {
+	return &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: v}}}
+}
+
+func insertSeries(t *testing.T, seg Segment[*MockTSTable, any], subject string, values ...string) {
+	series := pbv1.Series{Subject: subject}
+	for _, v := range values {
+		series.EntityValues = append(series.EntityValues, strTagValue(v))
+	}
+	require.NoError(t, series.Marshal())
+	doc := index.Document{
+		DocID:        uint64(series.ID),
+		EntityValues: make([]byte, len(series.Buffer)),
+	}
+	copy(doc.EntityValues, series.Buffer)
+	require.NoError(t, seg.IndexDB().Insert(index.Documents{doc}))
+}
+
+func TestLookupSeries(t *testing.T) {
+	tsdb, c, _, dfFn := setUpDB(t)
+	defer dfFn()
+	first := c.Now()
+	seg, err := tsdb.CreateSegmentIfNotExist(first)
+	require.NoError(t, err)
+	insertSeries(t, seg, "service_cpm", "svc_1", "inst_1")
+	insertSeries(t, seg, "service_cpm", "svc_2", "inst_1")
+	insertSeries(t, seg, "endpoint_cpm", "svc_1", "inst_1")
+	seg.DecRef()
+	seg, err = tsdb.CreateSegmentIfNotExist(first.AddDate(0, 0, 2))
+	require.NoError(t, err)
+	insertSeries(t, seg, "service_cpm", "svc_1", "inst_2")
+	insertSeries(t, seg, "service_cpm", "svc_1", "inst_1")
+	lastEnd := seg.GetTimeRange().End
+	seg.DecRef()
+
+	all := timestamp.NewInclusiveTimeRange(first.AddDate(0, 0, -1), first.AddDate(0, 0, 3))
+	ss, err := lookupSeries[*MockTSTable, any](context.Background(), tsdb, all,
+		&pbv1.Series{Subject: "service_cpm", EntityValues: []*modelv1.TagValue{strTagValue("svc_1"), pbv1.AnyTagValue}})
+	require.NoError(t, err)
+	require.Len(t, ss, 2)
+	seen := make(map[string]*databasev1.Series)
+	for _, s := range ss {
+		require.Len(t, s.GetEntity(), 2)
+		seen[s.GetEntity()[1].GetValue().GetStr().GetValue()] = s
+	}
+	require.Contains(t, seen, "inst_1")
+	require.Contains(t, seen, "inst_2")
+	assert.Equal(t, first, seen["inst_1"].GetFirstSeen().AsTime().In(first.Location()), "inst_1 is in the first segment")
+	assert.Equal(t, lastEnd, seen["inst_1"].GetLastSeen().AsTime().In(first.Location()), "inst_1 is in the last segment")
+	assert.Equal(t, first.AddDate(0, 0, 2), seen["inst_2"].GetFirstSeen().AsTime().In(first.Location()))
+
+	ss, err = lookupSeries[*MockTSTable, any](context.Background(), tsdb, timestamp.NewInclusiveTimeRange(first, first.Add(time.Hour)),
+		&pbv1.Series{Subject: "service_cpm", EntityValues: []*modelv1.TagValue{strTagValue("svc_1"), pbv1.AnyTagValue}})
+	require.NoError(t, err)
+	require.Len(t, ss, 1, "the time range selects the first segment")
+	assert.Equal(t, "inst_1", ss[0].GetEntity()[1].GetValue().GetStr().GetValue())
+}
+
+func TestEntityValuesOf(t *testing.T) {
+	values, err := entityValuesOf([]string{"service", "instance"}, []*modelv1.Tag{{Key: "instance", Value: strTagValue("inst_1")}})
+	require.NoError(t, err)
+	require.Len(t, values, 2)
+	assert.Same(t, pbv1.AnyTagValue, values[0], "the absent tags match any value")
+	assert.Equal(t, "inst_1", values[1].GetStr().GetValue())
+
+	_, err = entityValuesOf([]string{"service"}, []*modelv1.Tag{{Key: "endpoint", Value: strTagValue("/")}})
+	require.Error(t, err)
+}
+
+func TestMergeSeries(t *testing.T) {
+	day := func(d int) *timestamppb.Timestamp {
+		return timestamppb.New(time.Date(2024, 5, d, 0, 0, 0, 0, time.UTC))
+	}
+	ss := MergeSeries([]*databasev1.Series{
+		{Group: "sw", SeriesId: 2, FirstSeen: day(2), LastSeen: day(3)},
+		{Group: "sw", SeriesId: 1, FirstSeen: day(1), LastSeen: day(2)},
+		{Group: "sw", SeriesId: 2, FirstSeen: day(1), LastSeen: day(2)},
+		{Group: "default", SeriesId: 3, FirstSeen: day(1), LastSeen: day(2)},
+	}, 0)
+	require.Len(t, ss, 3)
+	assert.Equal(t, "default", ss[0].GetGroup())
+	assert.Equal(t, uint64(1), ss[1].GetSeriesId())
+	assert.Equal(t, uint64(2), ss[2].GetSeriesId())
+	assert.Equal(t, day(1).AsTime(), ss[2].GetFirstSeen().AsTime(), "the series found by two nodes is merged")
+	assert.Equal(t, day(3).AsTime(), ss[2].GetLastSeen().AsTime())
+
+	assert.Len(t, MergeSeries(ss, 2), 2)
+}
@@ -0,0 +1,139 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storage
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/apache/skywalking-banyandb/pkg/convert"
+	"github.com/apache/skywalking-banyandb/pkg/index"
+)
+
+const (
+	// seenResolution is how far the timestamps written to a series go beyond its stored first and last seen
+	// before the inserted series are rewritten, which bounds the rewrites of the active series.
+	seenResolution = int64(time.Minute)
+	// seenLoadBatch is the maximum number of the series loaded from the index by a search.
+	seenLoadBatch = 512
+)
+
+// SeenRange is the earliest and the latest timestamps written to a series.
+type SeenRange struct {
+	First int64
+	Last  int64
+}
+
+// seenTracker caches the first and last seen timestamps stored in the series index.
+type seenTracker struct {
+	ranges map[uint64]SeenRange
+	mu     sync.Mutex
+}
+
+// track merges the first and last seen timestamps of the docs with the stored ones.
+// The docs whose timestamps go beyond the stored ones by more than resolution are returned as the rewrites,
+// and the others are returned as unchanged. Both carry the merged timestamps, and the docs without timestamps are never rewritten.
+// The caller should hold the lock until the docs are written.
+func (t *seenTracker) track(store index.SeriesStore, docs index.Documents, resolution int64) (unchanged, rewrites index.Documents, err error) {
+	if t.ranges == nil {
+		t.ranges = make(map[uint64]SeenRange)
+	}
+	if err = t.load(store, docs); err != nil {
+		return nil, nil, err
+	}
+	for i := range docs {
+		d := docs[i]
+		if d.LastSeen <= 0 {
+			unchanged = append(unchanged, d)
+			continue
+		}
+		r, ok := t.ranges[d.DocID]
+		if !ok {
+			// the new series is stored with its timestamps.
+			t.ranges[d.DocID] = SeenRange{First: d.FirstSeen, Last: d.LastSeen}
+			unchanged = append(unchanged, d)
+			continue
+		}
+		widened := r.Last <= 0 || d.FirstSeen < r.First-resolution || d.LastSeen > r.Last+resolution
+		if r.Last > 0 {
+			d.Seen(r.First)
+			d.Seen(r.Last)
+		}
+		if !widened {
+			unchanged = append(unchanged, d)
+			continue
+		}
+		t.ranges[d.DocID] = SeenRange{First: d.FirstSeen, Last: d.LastSeen}
+		rewrites = append(rewrites, d)
+	}
+	return unchanged, rewrites, nil
+}
+
+// load caches the timestamps of the series which are absent from the cache.
+// A series stored without timestamps is cached with a zero range.
+func (t *seenTracker) load(store index.SeriesStore, docs index.Documents) error {
+	var matchers []index.SeriesMatcher
+	flush := func() error {
+		if len(matchers) == 0 {
+			return nil
+		}
+		q, err := store.BuildQuery(matchers, nil, nil)
+		if err != nil {
+			return err
+		}
+		matchers = nil
+		ss, err := store.Search(context.Background(), nil, q, 0)
+		if err != nil {
+			return err
+		}
+		for _, s := range ss {
+			t.ranges[convert.Hash(s.Key.EntityValues)] = SeenRange{First: s.FirstSeen, Last: s.LastSeen}
+		}
+		return nil
+	}
+	for i := range docs {
+		if docs[i].LastSeen <= 0 {
+			continue
+		}
+		if _, ok := t.ranges[docs[i].DocID]; ok {
+			continue
+		}
+		matchers = append(matchers, index.SeriesMatcher{Type: index.SeriesMatcherTypeExact, Match: docs[i].EntityValues})
+		if len(matchers) >= seenLoadBatch {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return flush()
+}
+
+func seenRangesOf(ss []index.SeriesDocument) []SeenRange {
+	var seen []SeenRange
+	for i := range ss {
+		if ss[i].LastSeen <= 0 {
+			continue
+		}
+		if seen == nil {
+			seen = make([]SeenRange, len(ss))
+		}
+		seen[i] = SeenRange{First: ss[i].FirstSeen, Last: ss[i].LastSeen}
+	}
+	return seen
+}
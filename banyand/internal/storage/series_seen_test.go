@@ -0,0 +1,99 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/index"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+)
+
+func seenDoc(t *testing.T, svc string, first, last int64) index.Document {
+	series := pbv1.Series{Subject: "service_cpm", EntityValues: []*modelv1.TagValue{strTagValue(svc)}}
+	require.NoError(t, series.Marshal())
+	doc := index.Document{
+		DocID:        uint64(series.ID),
+		EntityValues: make([]byte, len(series.Buffer)),
+		FirstSeen:    first,
+		LastSeen:     last,
+	}
+	copy(doc.EntityValues, series.Buffer)
+	return doc
+}
+
+func searchSeen(t *testing.T, si *seriesIndex) map[string]SeenRange {
+	sd, _, err := si.Search(context.Background(),
+		[]*pbv1.Series{{Subject: "service_cpm", EntityValues: []*modelv1.TagValue{pbv1.AnyTagValue}}}, IndexSearchOpts{})
+	require.NoError(t, err)
+	result := make(map[string]SeenRange, len(sd.SeriesList))
+	for i, s := range sd.SeriesList {
+		var r SeenRange
+		if sd.Seen != nil {
+			r = sd.Seen[i]
+		}
+		result[s.EntityValues[0].GetStr().GetValue()] = r
+	}
+	return result
+}
+
+func TestSeriesIndex_Seen(t *testing.T) {
+	ctx := context.Background()
+	path, fn := setUp(require.New(t))
+	si, err := newSeriesIndex(ctx, path, 0, 0, nil)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, si.Close())
+		fn()
+	}()
+	base := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC).UnixNano()
+	second := int64(time.Second)
+	legacy := seenDoc(t, "svc_legacy", 0, 0)
+	require.NoError(t, si.Insert(index.Documents{seenDoc(t, "svc_1", base, base+second), legacy}))
+	// the timestamps within the resolution don't rewrite the series.
+	require.NoError(t, si.Insert(index.Documents{seenDoc(t, "svc_1", base, base+2*second)}))
+	assert.Equal(t, SeenRange{First: base, Last: base + second}, si.seen.ranges[seenDoc(t, "svc_1", 0, 0).DocID])
+
+	// reopen the index to load the stored timestamps.
+	require.NoError(t, si.Close())
+	si, err = newSeriesIndex(ctx, path, 0, 0, nil)
+	require.NoError(t, err)
+	seen := searchSeen(t, si)
+	require.Len(t, seen, 2)
+	assert.Equal(t, SeenRange{First: base, Last: base + second}, seen["svc_1"])
+	assert.Equal(t, SeenRange{}, seen["svc_legacy"], "the series written before the tracking have no timestamps")
+
+	later := base + 2*seenResolution
+	earlier := base - 2*seenResolution
+	require.NoError(t, si.Insert(index.Documents{seenDoc(t, "svc_1", later, later), seenDoc(t, "svc_legacy", later, later)}))
+	require.NoError(t, si.Update(index.Documents{seenDoc(t, "svc_2", base, base), seenDoc(t, "svc_1", earlier, earlier)}))
+	require.NoError(t, si.Close())
+	si, err = newSeriesIndex(ctx, path, 0, 0, nil)
+	require.NoError(t, err)
+	seen = searchSeen(t, si)
+	require.Len(t, seen, 3)
+	assert.Equal(t, SeenRange{First: earlier, Last: later}, seen["svc_1"], "the rewrites widen the stored timestamps")
+	assert.Equal(t, SeenRange{First: later, Last: later}, seen["svc_legacy"])
+	assert.Equal(t, SeenRange{First: base, Last: base}, seen["svc_2"])
+}
@@ -78,7 +78,14 @@ func (s *segment[T, O]) openShard(ctx context.Context, id common.ShardID) (*shar
 	l.Info().Int("shard_id", int(id)).Str("path", location).Msg("loading a shard")
 	p := common.GetPosition(ctx)
 	p.Shard = strconv.Itoa(int(id))
-	t, err := s.tsdbOpts.TSTableCreator(s.lfs, location, p, l, s.TimeRange, s.tsdbOpts.Option, s.metrics)
+	fileSystem := s.lfs
+	if s.tsdbOpts.FDProtector != nil {
+		fileSystem = s.tsdbOpts.FDProtector.Wrap(fileSystem, p.Database, p.Segment)
+	}
+	if s.tsdbOpts.IOScheduler != nil {
+		fileSystem = s.tsdbOpts.IOScheduler.Wrap(fileSystem)
+	}
+	t, err := s.tsdbOpts.TSTableCreator(fileSystem, location, p, l, s.TimeRange, s.tsdbOpts.Option, s.metrics)
 	if err != nil {
 		return nil, err
 	}
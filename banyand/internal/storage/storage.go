@@ -32,6 +32,7 @@ import (
 
 	"github.com/apache/skywalking-banyandb/api/common"
 	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
 	"github.com/apache/skywalking-banyandb/pkg/fs"
 	"github.com/apache/skywalking-banyandb/pkg/index"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
@@ -55,6 +56,8 @@ const (
 	SnapshotsDir = "snapshots"
 	// RepairDir is the directory for repairs.
 	RepairDir = "repairs"
+	// ReplicasDir is the directory for the parts replicated from another cluster before they are installed.
+	ReplicasDir = "replicas"
 	// DataDir is the directory for data.
 	DataDir = "data"
 	// FilePerm is the permission of the file.
@@ -93,6 +96,9 @@ type SeriesData struct {
 	Fields     FieldResultList
 	Timestamps []int64
 	Versions   []int64
+	// Seen are the first and last seen timestamps of the series in SeriesList.
+	// It's nil if none of the series tracks them, and the zero ranges mean the series written before the tracking.
+	Seen []SeenRange
 }
 
 // IndexDB is the interface of index database.
@@ -112,7 +118,16 @@ type TSDB[T TSTable, O any] interface {
 	UpdateOptions(opts *commonv1.ResourceOpts)
 	TakeFileSnapshot(dst string) error
 	GetExpiredSegmentsTimeRange() *timestamp.TimeRange
+	GetSegmentsTimeRanges() []timestamp.TimeRange
 	DeleteExpiredSegments(timeRange timestamp.TimeRange) int64
+	// DeleteSegments deletes the segments lying in the time range regardless of the TTL,
+	// e.g. the segments moved to the archive group.
+	DeleteSegments(timeRange timestamp.TimeRange) int64
+	PreviewRetention(now time.Time) (RetentionPlan, error)
+	RunRetention(now time.Time) (RetentionPlan, error)
+	Usage(now time.Time) (Usage, error)
+	// Digest reports the parts, the series count and the per-bucket element counts of every segment of the shard.
+	Digest(shardID common.ShardID, bucket time.Duration) ([]*databasev1.SegmentDigest, error)
 }
 
 // Segment is a time range of data.
@@ -130,6 +145,13 @@ type TSTable interface {
 	io.Closer
 	Collect(Metrics)
 	TakeFileSnapshot(dst string) error
+	// Usage adds the storage usage of the parts on the disk to u.
+	Usage(u *TableUsage)
+	// Digest adds the parts, the series and the elements of the table to td, including the parts in memory.
+	Digest(td *TableDigest)
+	// Warmup pre-reads the indexes and the block metadata of the parts on the disk.
+	// The block metadata could be kept in the cache c of the table.
+	Warmup(ctx context.Context, c Cache) error
 }
 
 // TSTableCreator creates a TSTable.
@@ -29,7 +29,9 @@ import (
 
 	"github.com/apache/skywalking-banyandb/api/common"
 	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
 	"github.com/apache/skywalking-banyandb/banyand/observability"
+	"github.com/apache/skywalking-banyandb/banyand/protector"
 	"github.com/apache/skywalking-banyandb/pkg/fs"
 	"github.com/apache/skywalking-banyandb/pkg/index/inverted"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
@@ -53,6 +55,9 @@ const (
 type TSDBOpts[T TSTable, O any] struct {
 	Option                         O
 	TableMetrics                   Metrics
+	FDProtector                    protector.FD
+	IOScheduler                    protector.IO
+	ReplayCache                    *ReplayCache
 	TSTableCreator                 TSTableCreator[T, O]
 	StorageMetricsFactory          *observability.Factory
 	Location                       string
@@ -62,6 +67,7 @@ type TSDBOpts[T TSTable, O any] struct {
 	SeriesIndexCacheMaxBytes       int
 	ShardNum                       uint32
 	DisableRetention               bool
+	SegmentWarmup                  bool
 	SegmentIdleTimeout             time.Duration
 	MemoryLimit                    uint64
 }
@@ -97,10 +103,13 @@ type database[T TSTable, O any] struct {
 	scheduler         *timestamp.Scheduler
 	tsEventCh         chan int64
 	segmentController *segmentController[T, O]
+	retention         *retentionTask[T, O]
+	replayCache       *ReplayCache
 	*metrics
 	lfs            fs.FileSystem
 	p              common.Position
 	location       string
+	group          string
 	latestTickTime atomic.Int64
 	sync.RWMutex
 	rotationProcessOn atomic.Bool
@@ -122,6 +131,7 @@ func (d *database[T, O]) Close() error {
 	if err := d.lfs.DeleteFile(d.lock.Path()); err != nil {
 		logger.Panicf("cannot delete lock file %s: %s", d.lock.Path(), err)
 	}
+	d.replayCache.dropGroup(d.group)
 	return nil
 }
 
@@ -161,6 +171,8 @@ func OpenTSDB[T TSTable, O any](ctx context.Context, opts TSDBOpts[T, O], cache
 		metrics:          newMetrics(opts.StorageMetricsFactory),
 		disableRetention: opts.DisableRetention,
 		lfs:              tsdbLfs,
+		replayCache:      opts.ReplayCache,
+		group:            group,
 	}
 	db.logger.Info().Str("path", opts.Location).Msg("initialized")
 	lockPath := filepath.Join(opts.Location, lockFilename)
@@ -250,10 +262,56 @@ func (d *database[T, O]) GetExpiredSegmentsTimeRange() *timestamp.TimeRange {
 	return d.segmentController.getExpiredSegmentsTimeRange()
 }
 
+func (d *database[T, O]) GetSegmentsTimeRanges() []timestamp.TimeRange {
+	return d.segmentController.getSegmentsTimeRanges()
+}
+
 func (d *database[T, O]) DeleteExpiredSegments(timeRange timestamp.TimeRange) int64 {
 	return d.segmentController.deleteExpiredSegments(timeRange)
 }
 
+func (d *database[T, O]) DeleteSegments(timeRange timestamp.TimeRange) int64 {
+	return d.segmentController.deleteSegments(timeRange)
+}
+
+// PreviewRetention returns the segments which the retention pass at now deletes, without deleting them.
+func (d *database[T, O]) PreviewRetention(now time.Time) (RetentionPlan, error) {
+	if d.closed.Load() {
+		return RetentionPlan{}, errors.New("database is closed")
+	}
+	if d.retention == nil {
+		return RetentionPlan{}, errRetentionDisabled
+	}
+	return d.segmentController.planRetention(d.retention.deadline(now)), nil
+}
+
+// RunRetention runs a retention pass at now rather than waiting for the schedule, and returns the deleted segments.
+func (d *database[T, O]) RunRetention(now time.Time) (RetentionPlan, error) {
+	if d.closed.Load() {
+		return RetentionPlan{}, errors.New("database is closed")
+	}
+	if d.retention == nil {
+		return RetentionPlan{}, errRetentionDisabled
+	}
+	return d.retention.remove(now)
+}
+
+// Usage reports the storage usage of the database at now.
+func (d *database[T, O]) Usage(now time.Time) (Usage, error) {
+	if d.closed.Load() {
+		return Usage{}, errors.New("database is closed")
+	}
+	return d.segmentController.usage(now)
+}
+
+// Digest digests the shard in every segment of the database.
+func (d *database[T, O]) Digest(shardID common.ShardID, bucket time.Duration) ([]*databasev1.SegmentDigest, error) {
+	if d.closed.Load() {
+		return nil, errors.New("database is closed")
+	}
+	return d.segmentController.digest(shardID, bucket)
+}
+
 func (d *database[T, O]) collect() {
 	if d.closed.Load() {
 		return
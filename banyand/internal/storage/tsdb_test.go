@@ -124,6 +124,33 @@ func TestOpenTSDB(t *testing.T) {
 		tsdb.Close()
 	})
 
+	t.Run("close drops the replay keys of the group", func(t *testing.T) {
+		dir, defFn := test.Space(require.New(t))
+		defer defFn()
+
+		rc := NewReplayCache(10, time.Minute)
+		opts := TSDBOpts[*MockTSTable, any]{
+			Location:        dir,
+			SegmentInterval: IntervalRule{Unit: DAY, Num: 1},
+			TTL:             IntervalRule{Unit: DAY, Num: 3},
+			ShardNum:        1,
+			TSTableCreator:  MockTSTableCreator,
+			ReplayCache:     rc,
+		}
+
+		tsdb, err := OpenTSDB(context.Background(), opts, NewServiceCache(), group)
+		require.NoError(t, err)
+		rb := rc.NewBatch()
+		require.False(t, rb.Seen(group, 0, "k1"))
+		require.False(t, rb.Seen("other", 0, "k1"))
+		rb.Commit()
+
+		require.NoError(t, tsdb.Close())
+		rb = rc.NewBatch()
+		require.False(t, rb.Seen(group, 0, "k1"))
+		require.True(t, rb.Seen("other", 0, "k1"))
+	})
+
 	t.Run("reopen existing TSDB", func(t *testing.T) {
 		dir, defFn := test.Space(require.New(t))
 		defer defFn()
@@ -0,0 +1,177 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storage
+
+import (
+	"path/filepath"
+	"time"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/pkg/fs"
+	"github.com/apache/skywalking-banyandb/pkg/schema"
+)
+
+// growthWindow is the period of the recent segments to estimate the daily growth.
+const growthWindow = 7 * 24 * time.Hour
+
+// TableUsage is the storage usage of the parts of the TSTables.
+type TableUsage struct {
+	// TagFamilySizes are the bytes of the tag families keyed by their names.
+	TagFamilySizes map[string]int64
+	PartsSize      int64
+	FieldsSize     int64
+	IndexSize      int64
+	PartsCount     int64
+}
+
+// Usage is the storage usage of a TSDB.
+type Usage struct {
+	TableUsage
+	Size            int64
+	SeriesIndexSize int64
+	SeriesCount     int64
+	SegmentsCount   int64
+	DailyGrowth     int64
+}
+
+// ReaderSize returns the size of the file behind r, or 0 if r doesn't expose it.
+func ReaderSize(r fs.Reader) int64 {
+	sr, ok := r.(interface{ Size() (int64, error) })
+	if !ok {
+		return 0
+	}
+	size, err := sr.Size()
+	if err != nil {
+		return 0
+	}
+	return size
+}
+
+// usage opens the closed segments to count their series, then they're closed again once they're idle.
+func (sc *segmentController[T, O]) usage(now time.Time) (Usage, error) {
+	ss, err := sc.segments(true)
+	if err != nil {
+		return Usage{}, err
+	}
+	u := Usage{TableUsage: TableUsage{TagFamilySizes: make(map[string]int64)}}
+	var recentSize int64
+	var recentStart time.Time
+	for _, s := range ss {
+		size, errSize := DirSize(s.location)
+		if errSize != nil {
+			sc.l.Warn().Err(errSize).Stringer("segment", s).Msg("failed to get the size of the segment")
+		}
+		u.Size += size
+		u.SegmentsCount++
+		if sidxSize, errSize := DirSize(filepath.Join(s.location, seriesIndexDirName)); errSize == nil {
+			u.SeriesIndexSize += sidxSize
+		}
+		if s.index != nil {
+			if n, errCount := s.index.store.DocCount(); errCount == nil {
+				u.SeriesCount += int64(n)
+			} else {
+				sc.l.Warn().Err(errCount).Stringer("segment", s).Msg("failed to count the series of the segment")
+			}
+		}
+		tt, _ := s.Tables()
+		for _, t := range tt {
+			t.Usage(&u.TableUsage)
+		}
+		if s.End.After(now.Add(-growthWindow)) && !s.Start.After(now) {
+			recentSize += size
+			if recentStart.IsZero() || s.Start.Before(recentStart) {
+				recentStart = s.Start
+			}
+		}
+		s.DecRef()
+	}
+	u.DailyGrowth = dailyGrowth(recentSize, recentStart, now)
+	return u, nil
+}
+
+// dailyGrowth averages size over the days since start. A period shorter than a day counts as a day,
+// which prevents a fresh segment from inflating the growth.
+func dailyGrowth(size int64, start, now time.Time) int64 {
+	if size == 0 || start.IsZero() {
+		return 0
+	}
+	days := now.Sub(start).Hours() / 24
+	if days < 1 {
+		days = 1
+	}
+	return int64(float64(size) / days)
+}
+
+// StorageUsage reports the storage usage of the groups in the catalog at now.
+// All the groups in the catalog are included if names is empty. The groups which aren't loaded on this node are skipped.
+func StorageUsage[T TSTable, O any](catalog commonv1.Catalog, repo schema.Repository, names []string, now time.Time) []*databasev1.GroupUsage {
+	if len(names) == 0 {
+		for _, g := range repo.LoadAllGroups() {
+			names = append(names, g.GetSchema().GetMetadata().GetName())
+		}
+	}
+	var result []*databasev1.GroupUsage
+	for _, name := range names {
+		g, ok := repo.LoadGroup(name)
+		if !ok || g.GetSchema().GetCatalog() != catalog {
+			continue
+		}
+		db, ok := g.SupplyTSDB().(TSDB[T, O])
+		if !ok || db == nil {
+			continue
+		}
+		u, err := db.Usage(now)
+		gu := &databasev1.GroupUsage{
+			Catalog:         catalog,
+			Group:           name,
+			Size:            u.Size,
+			PartsSize:       u.PartsSize,
+			TagFamilySizes:  u.TagFamilySizes,
+			FieldsSize:      u.FieldsSize,
+			IndexSize:       u.IndexSize,
+			SeriesIndexSize: u.SeriesIndexSize,
+			PartsCount:      u.PartsCount,
+			SeriesCount:     u.SeriesCount,
+			SegmentsCount:   u.SegmentsCount,
+			DailyGrowth:     u.DailyGrowth,
+		}
+		if err != nil {
+			gu.Error = err.Error()
+		}
+		result = append(result, gu)
+	}
+	return result
+}
+
+// CompleteUsage appends the usage of the requested groups which no catalog reports,
+// since they're absent from this node.
+func CompleteUsage(names []string, uu []*databasev1.GroupUsage) []*databasev1.GroupUsage {
+	reported := make(map[string]struct{}, len(uu))
+	for _, u := range uu {
+		reported[u.GetGroup()] = struct{}{}
+	}
+	for _, name := range names {
+		if _, ok := reported[name]; ok {
+			continue
+		}
+		reported[name] = struct{}{}
+		uu = append(uu, &databasev1.GroupUsage{Group: name, Error: errGroupNotFound})
+	}
+	return uu
+}
@@ -0,0 +1,84 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+)
+
+func TestUsage(t *testing.T) {
+	tsdb, c, _, dfFn := setUpDB(t)
+	defer dfFn()
+	first := c.Now()
+	for i := 0; i < 3; i++ {
+		seg, err := tsdb.CreateSegmentIfNotExist(first.AddDate(0, 0, i))
+		require.NoError(t, err)
+		_, err = seg.CreateTSTableIfNotExist(common.ShardID(0))
+		require.NoError(t, err)
+		seg.DecRef()
+	}
+
+	u, err := tsdb.Usage(first.AddDate(0, 0, 3))
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), u.SegmentsCount)
+	assert.Equal(t, int64(3), u.PartsCount, "every table is reported")
+	assert.Positive(t, u.Size)
+	assert.Positive(t, u.SeriesIndexSize)
+	assert.LessOrEqual(t, u.SeriesIndexSize, u.Size)
+	assert.Equal(t, u.Size/3, u.DailyGrowth)
+
+	require.NoError(t, tsdb.Close())
+	_, err = tsdb.Usage(first)
+	require.Error(t, err)
+}
+
+func TestDailyGrowth(t *testing.T) {
+	now := time.Date(2024, 5, 8, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		start time.Time
+		name  string
+		size  int64
+		want  int64
+	}{
+		{name: "no segments", start: time.Time{}, size: 0, want: 0},
+		{name: "a week", start: now.AddDate(0, 0, -7), size: 700, want: 100},
+		{name: "two days", start: now.AddDate(0, 0, -2), size: 700, want: 350},
+		{name: "less than a day", start: now.Add(-time.Hour), size: 700, want: 700},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, dailyGrowth(tt.size, tt.start, now))
+		})
+	}
+}
+
+func TestCompleteUsage(t *testing.T) {
+	uu := CompleteUsage([]string{"sw", "absent"}, []*databasev1.GroupUsage{{Group: "sw", Size: 10}})
+	require.Len(t, uu, 2)
+	assert.Equal(t, int64(10), uu[0].GetSize())
+	assert.Equal(t, "absent", uu[1].GetGroup())
+	assert.Equal(t, errGroupNotFound, uu[1].GetError())
+	assert.Empty(t, CompleteUsage(nil, nil))
+}
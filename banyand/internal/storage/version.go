@@ -33,7 +33,10 @@ const (
 	compatibleVersionsFilename = "versions.yml"
 )
 
-var errVersionIncompatible = errors.New("version not compatible")
+var (
+	errVersionIncompatible = errors.New("version not compatible")
+	errPartFormatTooNew    = errors.New("part format is newer than the supported one")
+)
 
 var compatibleVersions = readCompatibleVersions()
 
@@ -68,3 +71,13 @@ func readCompatibleVersions() []string {
 	}
 	return vv
 }
+
+// CheckPartFormatVersion returns an error if the part is written in a format newer than the current one,
+// e.g. a binary is rolled back after it writes the parts in a new format.
+// The parts in the older formats are readable, and they are rewritten in the current format during the merges.
+func CheckPartFormatVersion(version, current uint32) error {
+	if version > current {
+		return errors.WithMessagef(errPartFormatTooNew, "part format version %d, the supported version %d", version, current)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storage
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+)
+
+// warmer pre-reads the segments opened from the disk in the background, so that the first queries on them
+// don't wait for loading the term dictionaries of the indexes and the block metadata of the parts.
+// The segments are warmed one by one, which keeps the warm-up from competing with the queries for the disk.
+type warmer struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	l      *logger.Logger
+	sem    chan struct{}
+	wg     sync.WaitGroup
+	mu     sync.Mutex
+	closed bool
+}
+
+func newWarmer(l *logger.Logger) *warmer {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &warmer{
+		ctx:    ctx,
+		cancel: cancel,
+		l:      l,
+		sem:    make(chan struct{}, 1),
+	}
+}
+
+// submit warms up the target in the background. It's ignored after the warmer is closed.
+func (w *warmer) submit(name string, fn func(ctx context.Context) error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return
+	}
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		select {
+		case w.sem <- struct{}{}:
+		case <-w.ctx.Done():
+			return
+		}
+		defer func() { <-w.sem }()
+		start := time.Now()
+		if err := fn(w.ctx); err != nil {
+			if !errors.Is(err, context.Canceled) {
+				w.l.Warn().Err(err).Str("target", name).Msg("failed to warm up")
+			}
+			return
+		}
+		w.l.Info().Str("target", name).Dur("elapsed", time.Since(start)).Msg("warmed up")
+	}()
+}
+
+// close cancels the warm-ups in progress and waits for them.
+func (w *warmer) close() {
+	w.mu.Lock()
+	w.closed = true
+	w.mu.Unlock()
+	w.cancel()
+	w.wg.Wait()
+}
+
+// tryIncRef acquires the segment only if it's open, which keeps the warm-up from reopening a closed segment.
+func (s *segment[T, O]) tryIncRef() bool {
+	for {
+		if atomic.LoadUint32(&s.mustBeDeleted) != 0 {
+			return false
+		}
+		current := atomic.LoadInt32(&s.refCount)
+		if current <= 0 {
+			return false
+		}
+		if atomic.CompareAndSwapInt32(&s.refCount, current, current+1) {
+			return true
+		}
+	}
+}
+
+// warmup reads the term dictionaries of the series index and warms up the tables of the segment.
+func (s *segment[T, O]) warmup(ctx context.Context) error {
+	if !s.tryIncRef() {
+		return nil
+	}
+	defer s.DecRef()
+	terms, err := s.index.store.Warmup(ctx)
+	if err != nil {
+		return errors.WithMessage(err, "warm up the series index")
+	}
+	sLst := s.sLst.Load()
+	if sLst == nil {
+		return nil
+	}
+	for _, sh := range *sLst {
+		if err = sh.table.Warmup(ctx, sh.shardCache); err != nil {
+			return errors.WithMessagef(err, "warm up the shard %d", sh.id)
+		}
+	}
+	s.l.Debug().Int("series_terms", terms).Int("shards", len(*sLst)).Msg("warmed up the segment")
+	return nil
+}
@@ -0,0 +1,109 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	"github.com/apache/skywalking-banyandb/pkg/fs"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+func TestSegmentWarmup(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	l := logger.GetLogger("test-segment")
+	ctx := context.WithValue(context.Background(), logger.ContextKey, l)
+	warmed := &atomic.Int32{}
+	opts := TSDBOpts[mockTSTable, mockTSTableOpener]{
+		TSTableCreator: func(_ fs.FileSystem, _ string, _ common.Position, _ *logger.Logger,
+			_ timestamp.TimeRange, _ mockTSTableOpener, _ any,
+		) (mockTSTable, error) {
+			return mockTSTable{ID: common.ShardID(0), warmed: warmed}, nil
+		},
+		ShardNum:                       2,
+		SegmentInterval:                IntervalRule{Unit: DAY, Num: 1},
+		TTL:                            IntervalRule{Unit: DAY, Num: 7},
+		SeriesIndexFlushTimeoutSeconds: 10,
+		SeriesIndexCacheMaxBytes:       1024 * 1024,
+		SegmentWarmup:                  true,
+	}
+	serviceCache := NewServiceCache().(*serviceCache)
+	defer serviceCache.Close()
+	sc := newSegmentController[mockTSTable, mockTSTableOpener](ctx, tempDir, l, opts, nil, nil, 5*time.Minute,
+		fs.NewLocalFileSystemWithLoggerAndLimit(logger.GetLogger("storage"), opts.MemoryLimit), serviceCache, group)
+	defer sc.close()
+
+	now := time.Now().UTC()
+	startTime := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	suffix := startTime.Format(dayFormat)
+	segmentPath := filepath.Join(tempDir, "segment-"+suffix)
+	require.NoError(t, os.MkdirAll(segmentPath, DirPerm))
+	require.NoError(t, os.WriteFile(filepath.Join(segmentPath, metadataFilename), []byte(currentVersion), FilePerm))
+	s, err := sc.openSegment(ctx, startTime, startTime.Add(24*time.Hour), segmentPath, suffix, sc.groupCache)
+	require.NoError(t, err)
+	_, err = s.CreateTSTableIfNotExist(0)
+	require.NoError(t, err)
+
+	// the segment without any shard is warmed up when it's opened, and the shard is warmed up after reopening.
+	s.DecRef()
+	assert.Equal(t, int32(0), atomic.LoadInt32(&s.refCount))
+	require.NoError(t, s.incRef(ctx))
+	assert.Eventually(t, func() bool {
+		return warmed.Load() == 1
+	}, 10*time.Second, 10*time.Millisecond)
+
+	// a closed segment isn't reopened by the warm-up.
+	s.DecRef()
+	require.NoError(t, s.warmup(ctx))
+	assert.Equal(t, int32(0), atomic.LoadInt32(&s.refCount))
+	assert.Equal(t, int32(1), warmed.Load())
+}
+
+func TestWarmerClose(t *testing.T) {
+	w := newWarmer(logger.GetLogger("test-warmer"))
+	started := make(chan struct{})
+	var canceled atomic.Bool
+	w.submit("blocked", func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		canceled.Store(true)
+		return ctx.Err()
+	})
+	<-started
+	w.close()
+	assert.True(t, canceled.Load())
+
+	var called atomic.Bool
+	w.submit("closed", func(context.Context) error {
+		called.Store(true)
+		return nil
+	})
+	assert.False(t, called.Load())
+}
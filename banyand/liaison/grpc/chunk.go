@@ -0,0 +1,74 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+)
+
+// checkElementSize rejects the element whose encoded size exceeds maxSize. 0 means no limit.
+func checkElementSize(writeEntity *streamv1.WriteRequest, maxSize int) error {
+	if maxSize <= 0 {
+		return nil
+	}
+	if size := proto.Size(writeEntity.GetElement()); size > maxSize {
+		return fmt.Errorf("the element has %d bytes, which exceeds the max element size %d", size, maxSize)
+	}
+	return nil
+}
+
+// chunkElement splits the element of iwr into the chunks of size bytes if iwr exceeds size, which are identified by id.
+// Every chunk keeps the metadata of the request to be routed to the same nodes as the element. 0 size disables the chunking.
+func chunkElement(iwr *streamv1.InternalWriteRequest, size int, id uint64) ([]*streamv1.InternalWriteRequest, error) {
+	if size <= 0 || proto.Size(iwr) <= size {
+		return []*streamv1.InternalWriteRequest{iwr}, nil
+	}
+	element, err := proto.Marshal(iwr.GetRequest().GetElement())
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal the element: %w", err)
+	}
+	req := iwr.GetRequest()
+	header := &streamv1.WriteRequest{
+		Metadata:       req.GetMetadata(),
+		MessageId:      req.GetMessageId(),
+		IdempotencyKey: req.GetIdempotencyKey(),
+		RoutingHint:    req.GetRoutingHint(),
+	}
+	total := (len(element) + size - 1) / size
+	chunks := make([]*streamv1.InternalWriteRequest, 0, total)
+	for i := 0; i < total; i++ {
+		end := min((i+1)*size, len(element))
+		chunks = append(chunks, &streamv1.InternalWriteRequest{
+			ShardId:      iwr.GetShardId(),
+			EntityValues: iwr.GetEntityValues(),
+			Request:      header,
+			ElementId:    iwr.GetElementId(),
+			Chunk: &streamv1.ElementChunk{
+				Id:    id,
+				Index: uint32(i),
+				Total: uint32(total),
+				Data:  element[i*size : end],
+			},
+		})
+	}
+	return chunks, nil
+}
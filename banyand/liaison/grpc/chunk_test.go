@@ -0,0 +1,90 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+)
+
+func newBinaryWriteRequest(size int) *streamv1.WriteRequest {
+	return &streamv1.WriteRequest{
+		Metadata:       &commonv1.Metadata{Group: "g", Name: "s"},
+		MessageId:      1,
+		IdempotencyKey: "k",
+		Element: &streamv1.ElementValue{
+			ElementId: "e",
+			Timestamp: timestamppb.Now(),
+			TagFamilies: []*modelv1.TagFamilyForWrite{{Tags: []*modelv1.TagValue{
+				{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: "svc"}}},
+				{Value: &modelv1.TagValue_BinaryData{BinaryData: bytes.Repeat([]byte{'a'}, size)}},
+			}}},
+		},
+	}
+}
+
+func TestCheckElementSize(t *testing.T) {
+	req := newBinaryWriteRequest(1024)
+	require.NoError(t, checkElementSize(req, 0))
+	require.NoError(t, checkElementSize(req, 2048))
+	require.Error(t, checkElementSize(req, 1024))
+}
+
+func TestChunkElement(t *testing.T) {
+	iwr := &streamv1.InternalWriteRequest{
+		ShardId:      1,
+		EntityValues: []*modelv1.TagValue{{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: "svc"}}}},
+		Request:      newBinaryWriteRequest(1000),
+		ElementId:    2,
+	}
+
+	cc, err := chunkElement(iwr, 0, 3)
+	require.NoError(t, err)
+	require.Equal(t, []*streamv1.InternalWriteRequest{iwr}, cc, "the chunking is disabled")
+	cc, err = chunkElement(iwr, 4096, 3)
+	require.NoError(t, err)
+	require.Equal(t, []*streamv1.InternalWriteRequest{iwr}, cc, "the element is smaller than the chunk size")
+
+	cc, err = chunkElement(iwr, 300, 3)
+	require.NoError(t, err)
+	require.Len(t, cc, 4)
+	var element []byte
+	for i, c := range cc {
+		assert.Equal(t, uint64(3), c.GetChunk().GetId())
+		assert.Equal(t, uint32(i), c.GetChunk().GetIndex())
+		assert.Equal(t, uint32(4), c.GetChunk().GetTotal())
+		assert.Equal(t, iwr.GetShardId(), c.GetShardId())
+		assert.Equal(t, iwr.GetElementId(), c.GetElementId())
+		assert.True(t, proto.Equal(iwr.GetRequest().GetMetadata(), c.GetRequest().GetMetadata()))
+		assert.Equal(t, "k", c.GetRequest().GetIdempotencyKey())
+		assert.Nil(t, c.GetRequest().GetElement())
+		element = append(element, c.GetChunk().GetData()...)
+	}
+	got := &streamv1.ElementValue{}
+	require.NoError(t, proto.Unmarshal(element, got))
+	assert.True(t, proto.Equal(iwr.GetRequest().GetElement(), got))
+}
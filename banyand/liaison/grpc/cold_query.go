@@ -0,0 +1,149 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+)
+
+const (
+	hotStageName     = "hot"
+	archiveTierName  = "archive"
+	coldLatencyDecay = 8
+)
+
+// coldTiers returns the cold tiers read by a query against the groups, which are the lifecycle stages selected by the query
+// or the default stages of the groups except the hot one, and the archive groups. It's empty if the query only reads the hot data.
+func (s *groupRepo) coldTiers(groups, stages []string) []string {
+	s.RWMutex.RLock()
+	defer s.RWMutex.RUnlock()
+	var tiers []string
+	add := func(tier string) {
+		for _, t := range tiers {
+			if t == tier {
+				return
+			}
+		}
+		tiers = append(tiers, tier)
+	}
+	for _, g := range groups {
+		ro, ok := s.resourceOpts[g]
+		if !ok {
+			continue
+		}
+		if ro.GetArchived() {
+			add(archiveTierName)
+			continue
+		}
+		selected := stages
+		if len(selected) == 0 {
+			selected = ro.GetDefaultStages()
+		}
+		for _, sn := range selected {
+			if strings.EqualFold(sn, hotStageName) {
+				continue
+			}
+			for _, stage := range ro.GetStages() {
+				if strings.EqualFold(sn, stage.GetName()) {
+					add(stage.GetName())
+					break
+				}
+			}
+		}
+	}
+	return tiers
+}
+
+// coldQueryPool runs the queries reading the cold tiers with a low concurrency,
+// so they don't take the resources of the queries of the hot data.
+type coldQueryPool struct {
+	workers chan struct{}
+	// latency is the moving average of the latencies of the recent cold queries in nanoseconds.
+	latency atomic.Int64
+	waiting atomic.Int64
+}
+
+func newColdQueryPool(workerNum int) *coldQueryPool {
+	p := &coldQueryPool{}
+	if workerNum > 0 {
+		p.workers = make(chan struct{}, workerNum)
+	}
+	return p
+}
+
+// acquire waits for a worker of the cold queries if the tiers aren't empty. It returns the cold read indicator of the response,
+// which is nil for the hot queries, and the release function recording the latency of the query.
+func (p *coldQueryPool) acquire(ctx context.Context, tiers []string) (*commonv1.ColdRead, func(), error) {
+	if p == nil || len(tiers) == 0 {
+		return nil, func() {}, nil
+	}
+	coldRead := &commonv1.ColdRead{
+		Tiers:                  tiers,
+		EstimatedLatencyMillis: p.estimate().Milliseconds(),
+	}
+	start := time.Now()
+	if p.workers != nil {
+		p.waiting.Add(1)
+		select {
+		case p.workers <- struct{}{}:
+			p.waiting.Add(-1)
+		case <-ctx.Done():
+			p.waiting.Add(-1)
+			return nil, nil, status.Errorf(codes.ResourceExhausted, "no worker of the cold queries reading %v is available: %v", tiers, ctx.Err())
+		}
+	}
+	acquired := time.Now()
+	coldRead.QueuedMillis = acquired.Sub(start).Milliseconds()
+	return coldRead, func() {
+		p.observe(time.Since(acquired))
+		if p.workers != nil {
+			<-p.workers
+		}
+	}, nil
+}
+
+// estimate returns the latency of a new cold query, which waits for the queries ahead of it to be done.
+func (p *coldQueryPool) estimate() time.Duration {
+	latency := time.Duration(p.latency.Load())
+	if latency == 0 || p.workers == nil {
+		return latency
+	}
+	rounds := p.waiting.Load()/int64(cap(p.workers)) + 1
+	return latency * time.Duration(rounds)
+}
+
+func (p *coldQueryPool) observe(d time.Duration) {
+	for {
+		old := p.latency.Load()
+		val := int64(d)
+		if old > 0 {
+			val = old + (val-old)/coldLatencyDecay
+		}
+		if p.latency.CompareAndSwap(old, val) {
+			return
+		}
+	}
+}
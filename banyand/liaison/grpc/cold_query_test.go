@@ -0,0 +1,86 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+)
+
+func TestGroupRepo_ColdTiers(t *testing.T) {
+	stages := []*commonv1.LifecycleStage{{Name: "warm"}, {Name: "cold"}}
+	gr := &groupRepo{resourceOpts: map[string]*commonv1.ResourceOpts{
+		"plain":    {ShardNum: 1},
+		"tiered":   {ShardNum: 1, Stages: stages},
+		"defaults": {ShardNum: 1, Stages: stages, DefaultStages: []string{"hot", "warm"}},
+		"archive":  {ShardNum: 1, Archived: true},
+	}}
+
+	assert.Empty(t, gr.coldTiers([]string{"plain", "tiered"}, nil))
+	assert.Empty(t, gr.coldTiers([]string{"tiered"}, []string{"hot"}))
+	assert.Empty(t, gr.coldTiers([]string{"plain"}, []string{"cold"}))
+	assert.Equal(t, []string{"cold"}, gr.coldTiers([]string{"tiered"}, []string{"hot", "COLD", "unknown"}))
+	assert.Equal(t, []string{"warm"}, gr.coldTiers([]string{"defaults", "tiered"}, nil))
+	assert.Equal(t, []string{"warm", "archive"}, gr.coldTiers([]string{"tiered", "archive", "defaults"}, []string{"warm"}))
+}
+
+func TestColdQueryPool(t *testing.T) {
+	var nilPool *coldQueryPool
+	coldRead, release, err := nilPool.acquire(context.Background(), []string{"cold"})
+	require.NoError(t, err)
+	assert.Nil(t, coldRead)
+	release()
+
+	p := newColdQueryPool(1)
+	coldRead, release, err = p.acquire(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Nil(t, coldRead)
+	release()
+
+	coldRead, release, err = p.acquire(context.Background(), []string{"cold"})
+	require.NoError(t, err)
+	require.NotNil(t, coldRead)
+	assert.Equal(t, []string{"cold"}, coldRead.Tiers)
+	assert.Zero(t, coldRead.EstimatedLatencyMillis)
+
+	// the only worker is taken, so the next cold query waits until its deadline.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, _, err = p.acquire(ctx, []string{"cold"})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// the hot queries aren't throttled.
+	_, hotRelease, err := p.acquire(ctx, nil)
+	require.NoError(t, err)
+	hotRelease()
+
+	time.Sleep(20 * time.Millisecond)
+	release()
+	coldRead, release, err = p.acquire(context.Background(), []string{"archive"})
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, coldRead.EstimatedLatencyMillis, int64(20))
+	release()
+}
@@ -0,0 +1,336 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	grpclib "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/run"
+)
+
+// forceStopTimeout bounds the graceful stop of a server, which waits for the long-lived streams, e.g. the bidirectional writes.
+const forceStopTimeout = 10 * time.Second
+
+var errListenerClosed = errors.New("listener is closed")
+
+// connectionSettings are the keepalive and connection management options of the gRPC server.
+// A zero value falls back to the default of gRPC.
+type connectionSettings struct {
+	keepaliveTime                time.Duration
+	keepaliveTimeout             time.Duration
+	keepaliveMinTime             time.Duration
+	maxConnectionIdle            time.Duration
+	maxConnectionAge             time.Duration
+	maxConnectionAgeGrace        time.Duration
+	writeBufferSize              run.Bytes
+	connWindowSize               run.Bytes
+	maxConcurrentStreams         uint32
+	keepalivePermitWithoutStream bool
+}
+
+func (cs connectionSettings) serverOptions() []grpclib.ServerOption {
+	opts := []grpclib.ServerOption{
+		grpclib.KeepaliveParams(keepalive.ServerParameters{
+			Time:                  cs.keepaliveTime,
+			Timeout:               cs.keepaliveTimeout,
+			MaxConnectionIdle:     cs.maxConnectionIdle,
+			MaxConnectionAge:      cs.maxConnectionAge,
+			MaxConnectionAgeGrace: cs.maxConnectionAgeGrace,
+		}),
+		grpclib.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             cs.keepaliveMinTime,
+			PermitWithoutStream: cs.keepalivePermitWithoutStream,
+		}),
+	}
+	if cs.maxConcurrentStreams > 0 {
+		opts = append(opts, grpclib.MaxConcurrentStreams(cs.maxConcurrentStreams))
+	}
+	// gRPC disables the write buffer if the size is zero, so it's only set when it's specified.
+	if cs.writeBufferSize > 0 {
+		opts = append(opts, grpclib.WriteBufferSize(int(cs.writeBufferSize)))
+	}
+	if cs.connWindowSize > 0 {
+		opts = append(opts, grpclib.InitialConnWindowSize(int32(cs.connWindowSize)))
+	}
+	return opts
+}
+
+func (cs connectionSettings) validate() error {
+	for _, d := range []time.Duration{
+		cs.keepaliveTime, cs.keepaliveTimeout, cs.keepaliveMinTime,
+		cs.maxConnectionIdle, cs.maxConnectionAge, cs.maxConnectionAgeGrace,
+	} {
+		if d < 0 {
+			return errors.New("durations of connection settings must not be negative")
+		}
+	}
+	if cs.writeBufferSize < 0 || cs.connWindowSize < 0 || cs.connWindowSize > run.Bytes(1<<31-1) {
+		return errors.New("buffer sizes of connection settings must be between 0 and 2GiB")
+	}
+	return nil
+}
+
+func (cs connectionSettings) toProto() *databasev1.ConnectionSettings {
+	return &databasev1.ConnectionSettings{
+		KeepaliveTime:                durationpb.New(cs.keepaliveTime),
+		KeepaliveTimeout:             durationpb.New(cs.keepaliveTimeout),
+		KeepaliveMinTime:             durationpb.New(cs.keepaliveMinTime),
+		KeepalivePermitWithoutStream: cs.keepalivePermitWithoutStream,
+		MaxConnectionIdle:            durationpb.New(cs.maxConnectionIdle),
+		MaxConnectionAge:             durationpb.New(cs.maxConnectionAge),
+		MaxConnectionAgeGrace:        durationpb.New(cs.maxConnectionAgeGrace),
+		MaxConcurrentStreams:         cs.maxConcurrentStreams,
+		WriteBufferSize:              int64(cs.writeBufferSize),
+		ConnWindowSize:               int64(cs.connWindowSize),
+	}
+}
+
+func connectionSettingsFromProto(pb *databasev1.ConnectionSettings) connectionSettings {
+	return connectionSettings{
+		keepaliveTime:                pb.GetKeepaliveTime().AsDuration(),
+		keepaliveTimeout:             pb.GetKeepaliveTimeout().AsDuration(),
+		keepaliveMinTime:             pb.GetKeepaliveMinTime().AsDuration(),
+		keepalivePermitWithoutStream: pb.GetKeepalivePermitWithoutStream(),
+		maxConnectionIdle:            pb.GetMaxConnectionIdle().AsDuration(),
+		maxConnectionAge:             pb.GetMaxConnectionAge().AsDuration(),
+		maxConnectionAgeGrace:        pb.GetMaxConnectionAgeGrace().AsDuration(),
+		maxConcurrentStreams:         pb.GetMaxConcurrentStreams(),
+		writeBufferSize:              run.Bytes(pb.GetWriteBufferSize()),
+		connWindowSize:               run.Bytes(pb.GetConnWindowSize()),
+	}
+}
+
+// connManager dispatches the accepted connections of all listeners to the current gRPC server.
+// Since the options of a gRPC server are immutable, updating the settings replaces the server,
+// and the replaced one is gracefully stopped so that its clients reconnect to the new one.
+// The streams outliving the stop timeout are closed forcibly.
+type connManager struct {
+	newServer   func(connectionSettings) *grpclib.Server
+	l           *logger.Logger
+	ser         *grpclib.Server
+	connLis     *connListener
+	listeners   []net.Listener
+	settings    connectionSettings
+	stopTimeout time.Duration
+	mu          sync.RWMutex
+}
+
+func newConnManager(l *logger.Logger, settings connectionSettings, newServer func(connectionSettings) *grpclib.Server) *connManager {
+	return &connManager{l: l, settings: settings, newServer: newServer, stopTimeout: forceStopTimeout}
+}
+
+// serve accepts connections from the listeners until they're closed.
+// If any listener fails, all the listeners are closed and the first error is returned.
+func (m *connManager) serve(listeners ...net.Listener) error {
+	if len(listeners) == 0 {
+		return errors.New("no listener to serve")
+	}
+	m.mu.Lock()
+	m.listeners = listeners
+	m.ser, m.connLis = m.startServer(m.settings)
+	m.mu.Unlock()
+	errCh := make(chan error, len(listeners))
+	for _, lis := range listeners {
+		go func(lis net.Listener) {
+			errCh <- m.accept(lis)
+		}(lis)
+	}
+	err := <-errCh
+	m.closeListeners()
+	for i := 1; i < len(listeners); i++ {
+		<-errCh
+	}
+	return err
+}
+
+func (m *connManager) accept(lis net.Listener) error {
+	for {
+		conn, err := lis.Accept()
+		if err != nil {
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				time.Sleep(5 * time.Millisecond)
+				continue
+			}
+			return err
+		}
+		for {
+			cl := m.current()
+			// the listener might be closed by a concurrent update, then the conn goes to the new one.
+			if cl.push(conn) {
+				break
+			}
+			if cl == m.current() {
+				_ = conn.Close()
+				break
+			}
+		}
+	}
+}
+
+func (m *connManager) current() *connListener {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.connLis
+}
+
+func (m *connManager) startServer(settings connectionSettings) (*grpclib.Server, *connListener) {
+	ser := m.newServer(settings)
+	cl := newConnListener(m.listeners[0].Addr())
+	go func() {
+		if err := ser.Serve(cl); err != nil {
+			m.l.Error().Err(err).Msg("server is interrupted")
+		}
+	}()
+	return ser, cl
+}
+
+func (m *connManager) getSettings() connectionSettings {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.settings
+}
+
+func (m *connManager) update(settings connectionSettings) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.ser == nil {
+		return errors.New("server is not serving")
+	}
+	old := m.ser
+	m.settings = settings
+	m.ser, m.connLis = m.startServer(settings)
+	go m.stopReplaced(old)
+	m.l.Info().Interface("settings", settings.toProto()).Msg("connection settings are updated")
+	return nil
+}
+
+func (m *connManager) stopReplaced(ser *grpclib.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		ser.GracefulStop()
+		close(stopped)
+	}()
+	t := time.NewTimer(m.stopTimeout)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		ser.Stop()
+		m.l.Info().Msg("the replaced server is force stopped")
+	case <-stopped:
+	}
+}
+
+func (m *connManager) gracefulStop() {
+	if ser := m.closeListeners(); ser != nil {
+		ser.GracefulStop()
+	}
+}
+
+func (m *connManager) stop() {
+	if ser := m.closeListeners(); ser != nil {
+		ser.Stop()
+	}
+}
+
+func (m *connManager) closeListeners() *grpclib.Server {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, lis := range m.listeners {
+		_ = lis.Close()
+	}
+	return m.ser
+}
+
+// connListener is a net.Listener fed by the connManager.
+type connListener struct {
+	addr   net.Addr
+	connCh chan net.Conn
+	done   chan struct{}
+	once   sync.Once
+}
+
+func newConnListener(addr net.Addr) *connListener {
+	return &connListener{addr: addr, connCh: make(chan net.Conn), done: make(chan struct{})}
+}
+
+func (cl *connListener) push(conn net.Conn) bool {
+	select {
+	case cl.connCh <- conn:
+		return true
+	case <-cl.done:
+		return false
+	}
+}
+
+func (cl *connListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-cl.connCh:
+		return conn, nil
+	case <-cl.done:
+		return nil, errListenerClosed
+	}
+}
+
+func (cl *connListener) Close() error {
+	cl.once.Do(func() { close(cl.done) })
+	return nil
+}
+
+func (cl *connListener) Addr() net.Addr {
+	return cl.addr
+}
+
+type connectionSettingsServer struct {
+	databasev1.UnimplementedConnectionSettingsServiceServer
+	conns *connManager
+}
+
+func (c *connectionSettingsServer) Get(_ context.Context, _ *databasev1.ConnectionSettingsServiceGetRequest) (
+	*databasev1.ConnectionSettingsServiceGetResponse, error,
+) {
+	return &databasev1.ConnectionSettingsServiceGetResponse{Settings: c.conns.getSettings().toProto()}, nil
+}
+
+func (c *connectionSettingsServer) Update(ctx context.Context, req *databasev1.ConnectionSettingsServiceUpdateRequest) (
+	*databasev1.ConnectionSettingsServiceUpdateResponse, error,
+) {
+	if !isAdmin(ctx) {
+		return nil, status.Error(codes.PermissionDenied, "the connection settings are only updated through the admin listeners")
+	}
+	settings := connectionSettingsFromProto(req.GetSettings())
+	if err := settings.validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := c.conns.update(settings); err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	return &databasev1.ConnectionSettingsServiceUpdateResponse{Settings: settings.toProto()}, nil
+}
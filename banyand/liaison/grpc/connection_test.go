@@ -0,0 +1,154 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	grpclib "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/pkg/listener"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+)
+
+func TestConnectionSettingsValidate(t *testing.T) {
+	assert.NoError(t, connectionSettings{}.validate())
+	assert.NoError(t, connectionSettings{keepaliveTime: time.Minute, connWindowSize: 1 << 20}.validate())
+	assert.Error(t, connectionSettings{keepaliveTimeout: -time.Second}.validate())
+	assert.Error(t, connectionSettings{writeBufferSize: -1}.validate())
+	assert.Error(t, connectionSettings{connWindowSize: 1 << 31}.validate())
+}
+
+func TestConnectionSettingsProto(t *testing.T) {
+	cs := connectionSettings{
+		keepaliveTime:                time.Minute,
+		keepaliveTimeout:             10 * time.Second,
+		keepaliveMinTime:             30 * time.Second,
+		keepalivePermitWithoutStream: true,
+		maxConnectionIdle:            time.Hour,
+		maxConnectionAge:             2 * time.Hour,
+		maxConnectionAgeGrace:        time.Minute,
+		maxConcurrentStreams:         100,
+		writeBufferSize:              64 << 10,
+		connWindowSize:               1 << 20,
+	}
+	assert.Equal(t, cs, connectionSettingsFromProto(cs.toProto()))
+	assert.Equal(t, connectionSettings{}, connectionSettingsFromProto(nil))
+}
+
+func TestConnManagerUpdate(t *testing.T) {
+	var m *connManager
+	m = newConnManager(logger.GetLogger("test"), connectionSettings{}, func(settings connectionSettings) *grpclib.Server {
+		ser := grpclib.NewServer(settings.serverOptions()...)
+		grpc_health_v1.RegisterHealthServer(ser, health.NewServer())
+		databasev1.RegisterConnectionSettingsServiceServer(ser, &connectionSettingsServer{conns: m})
+		return ser
+	})
+	m.stopTimeout = 100 * time.Millisecond
+	adminLis, err := listener.Listen(listener.Config{Network: "tcp", Address: "127.0.0.1:0", Admin: true}, nil, logger.GetLogger("test"))
+	require.NoError(t, err)
+	sock := filepath.Join(t.TempDir(), "grpc.sock")
+	unixLis, err := net.Listen("unix", sock)
+	require.NoError(t, err)
+	served := make(chan error, 1)
+	go func() {
+		served <- m.serve(adminLis, unixLis)
+	}()
+
+	var conns []*grpclib.ClientConn
+	var clients []grpc_health_v1.HealthClient
+	for _, target := range []string{adminLis.Addr().String(), "unix://" + sock} {
+		conn, connErr := grpclib.NewClient(target, grpclib.WithTransportCredentials(insecure.NewCredentials()))
+		require.NoError(t, connErr)
+		defer conn.Close()
+		conns = append(conns, conn)
+		clients = append(clients, grpc_health_v1.NewHealthClient(conn))
+	}
+	check := func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		for _, client := range clients {
+			if _, checkErr := client.Check(ctx, &grpc_health_v1.HealthCheckRequest{}, grpclib.WaitForReady(true)); checkErr != nil {
+				return checkErr
+			}
+		}
+		return nil
+	}
+	require.NoError(t, check())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	adminClient := databasev1.NewConnectionSettingsServiceClient(conns[0])
+	publicClient := databasev1.NewConnectionSettingsServiceClient(conns[1])
+	updated := connectionSettings{maxConnectionIdle: time.Minute, maxConcurrentStreams: 10}
+	_, err = publicClient.Update(ctx, &databasev1.ConnectionSettingsServiceUpdateRequest{Settings: updated.toProto()})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err), "the settings are only updated through the admin listeners")
+
+	// a long-lived stream doesn't hold the replaced server beyond the stop timeout.
+	watchCtx, cancelWatch := context.WithCancel(context.Background())
+	defer cancelWatch()
+	watch, err := clients[1].Watch(watchCtx, &grpc_health_v1.HealthCheckRequest{})
+	require.NoError(t, err)
+	_, err = watch.Recv()
+	require.NoError(t, err)
+	watchClosed := make(chan error, 1)
+	go func() {
+		_, recvErr := watch.Recv()
+		watchClosed <- recvErr
+	}()
+
+	_, err = adminClient.Update(ctx, &databasev1.ConnectionSettingsServiceUpdateRequest{Settings: updated.toProto()})
+	require.NoError(t, err)
+	resp, err := publicClient.Get(ctx, &databasev1.ConnectionSettingsServiceGetRequest{}, grpclib.WaitForReady(true))
+	require.NoError(t, err)
+	assert.Equal(t, updated, connectionSettingsFromProto(resp.GetSettings()))
+	select {
+	case err = <-watchClosed:
+		assert.Error(t, err, "the stream is closed once the replaced server is force stopped")
+	case <-time.After(5 * time.Second):
+		t.Fatal("the replaced server is not stopped")
+	}
+	// the client reconnects to the new server after the old one is stopped.
+	require.NoError(t, check())
+
+	_, err = adminClient.Update(ctx, &databasev1.ConnectionSettingsServiceUpdateRequest{
+		Settings: &databasev1.ConnectionSettings{KeepaliveTime: durationpb.New(-time.Second)},
+	}, grpclib.WaitForReady(true))
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	m.gracefulStop()
+	select {
+	case err = <-served:
+		assert.ErrorIs(t, err, net.ErrClosed)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the connection manager is not stopped")
+	}
+}
@@ -0,0 +1,99 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	"github.com/apache/skywalking-banyandb/api/data"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/banyand/metadata"
+	"github.com/apache/skywalking-banyandb/banyand/queue"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+	"github.com/apache/skywalking-banyandb/pkg/grpchelper"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+type deletionServer struct {
+	databasev1.UnimplementedDeletionServiceServer
+	schemaRegistry metadata.Repo
+	groupRepo      *groupRepo
+	pipeline       queue.Client
+	l              *logger.Logger
+}
+
+// DeleteRange tombstones the time range of a group on all the data nodes.
+// The data in the range are dropped by the queries and purged by the merges.
+func (d *deletionServer) DeleteRange(ctx context.Context, req *databasev1.DeletionServiceDeleteRangeRequest) (*databasev1.DeletionServiceDeleteRangeResponse, error) {
+	if req.GetGroup() == "" {
+		return nil, status.Error(codes.InvalidArgument, "group is required")
+	}
+	tr := req.GetTimeRange()
+	if err := timestamp.CheckTimeRange(tr); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v is invalid: %s", tr, err)
+	}
+	if !tr.GetBegin().AsTime().Before(tr.GetEnd().AsTime()) {
+		return nil, status.Errorf(codes.InvalidArgument, "the begin of %v should be before the end", tr)
+	}
+	if !req.GetConfirm() {
+		return nil, status.Error(codes.FailedPrecondition, "confirm is required to delete the data")
+	}
+	g, err := d.schemaRegistry.GroupRegistry().GetGroup(ctx, req.GetGroup())
+	if err != nil {
+		return nil, grpchelper.NewError(codes.NotFound, fmt.Sprintf("group %s not found", req.GetGroup())).
+			WithResource(grpchelper.ResourceGroup, req.GetGroup())
+	}
+	if d.groupRepo.archived(req.GetGroup()) {
+		return nil, grpchelper.NewError(codes.FailedPrecondition, fmt.Sprintf("group %s is read-only", req.GetGroup())).
+			WithReason(grpchelper.ReasonGroupReadOnly).WithResource(grpchelper.ResourceGroup, req.GetGroup())
+	}
+	var topic bus.Topic
+	switch g.GetCatalog() {
+	case commonv1.Catalog_CATALOG_STREAM:
+		topic = data.TopicStreamDeleteRange
+	case commonv1.Catalog_CATALOG_MEASURE:
+		topic = data.TopicMeasureDeleteRange
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "the data of the %s groups can't be deleted by the time range", g.GetCatalog())
+	}
+	now := time.Now()
+	deletedAt := timestamppb.New(now)
+	f, err := d.pipeline.Publish(ctx, topic, bus.NewMessage(bus.MessageID(now.UnixNano()),
+		&databasev1.InternalDeleteRangeRequest{Group: req.GetGroup(), TimeRange: tr, DeletedAt: deletedAt}))
+	if err != nil {
+		return nil, err
+	}
+	msg, err := f.Get()
+	if err != nil {
+		return nil, err
+	}
+	if e, ok := msg.Data().(*common.Error); ok {
+		return nil, status.Error(codes.Internal, e.Error())
+	}
+	d.l.Info().Str("group", req.GetGroup()).Time("begin", tr.GetBegin().AsTime()).Time("end", tr.GetEnd().AsTime()).Msg("deleted the range")
+	return &databasev1.DeletionServiceDeleteRangeResponse{DeletedAt: deletedAt}, nil
+}
@@ -20,6 +20,7 @@ package grpc
 import (
 	"fmt"
 	"sync"
+	"sync/atomic"
 
 	"github.com/pkg/errors"
 
@@ -43,11 +44,22 @@ type discoveryService struct {
 	entityRepo      *entityRepo
 	shardingKeyRepo *shardingKeyRepo
 	log             *logger.Logger
+	targets         map[identity]*writeTarget
+	targetsGen      uint64
 	kind            schema.Kind
+	targetsMu       sync.RWMutex
+}
+
+// writeTarget is the resolved metadata to route the writes of a measure or a stream.
+type writeTarget struct {
+	entityLocator      partition.Locator
+	shardingKeyLocator partition.Locator
+	shardNum           uint32
+	hasShardingKey     bool
 }
 
 func newDiscoveryService(kind schema.Kind, metadataRepo metadata.Repo, nodeRegistry NodeRegistry, gr *groupRepo) *discoveryService {
-	er := &entityRepo{entitiesMap: make(map[identity]partition.Locator)}
+	er := newEntityRepo()
 	return newDiscoveryServiceWithEntityRepo(kind, metadataRepo, nodeRegistry, gr, er)
 }
 
@@ -60,14 +72,13 @@ func newDiscoveryServiceWithEntityRepo(kind schema.Kind, metadataRepo metadata.R
 		kind:            kind,
 		metadataRepo:    metadataRepo,
 		nodeRegistry:    nodeRegistry,
+		targets:         make(map[identity]*writeTarget),
 	}
 }
 
 func (ds *discoveryService) initialize() error {
 	ds.metadataRepo.RegisterHandler("liaison", ds.kind, ds.entityRepo)
-	if ds.kind == schema.KindMeasure {
-		ds.metadataRepo.RegisterHandler("liaison", ds.kind, ds.shardingKeyRepo)
-	}
+	ds.metadataRepo.RegisterHandler("liaison", ds.kind, ds.shardingKeyRepo)
 	return nil
 }
 
@@ -79,30 +90,67 @@ func (ds *discoveryService) SetLogger(log *logger.Logger) {
 }
 
 func (ds *discoveryService) navigate(metadata *commonv1.Metadata, tagFamilies []*modelv1.TagFamilyForWrite) (pbv1.EntityValues, common.ShardID, error) {
-	shardNum, existed := ds.groupRepo.shardNum(metadata.Group)
-	if !existed {
-		return nil, common.ShardID(0), errors.Wrapf(errNotExist, "finding the shard num by: %v", metadata)
-	}
-	id := getID(metadata)
-	entityLocator, existed := ds.entityRepo.getLocator(id)
-	if !existed {
-		return nil, common.ShardID(0), errors.Wrapf(errNotExist, "finding the entity locator by: %v", metadata)
+	target, err := ds.resolve(metadata)
+	if err != nil {
+		return nil, common.ShardID(0), err
 	}
-	entityValues, shardID, err := entityLocator.Locate(metadata.Name, tagFamilies, shardNum)
+	entityValues, shardID, err := target.entityLocator.Locate(metadata.Name, tagFamilies, target.shardNum)
 	if err != nil {
 		return nil, common.ShardID(0), err
 	}
-	shardingKeyLocator, existed := ds.shardingKeyRepo.getLocator(id)
-	if !existed {
+	if !target.hasShardingKey {
 		return entityValues, shardID, nil
 	}
-	_, shardID, err = shardingKeyLocator.Locate(metadata.Name, tagFamilies, shardNum)
+	_, shardID, err = target.shardingKeyLocator.Locate(metadata.Name, tagFamilies, target.shardNum)
 	if err != nil {
 		return nil, common.ShardID(0), err
 	}
 	return entityValues, shardID, nil
 }
 
+// generation changes whenever a group, a measure or a stream is updated.
+// The repositories only increase their generations, so does the sum.
+func (ds *discoveryService) generation() uint64 {
+	return ds.groupRepo.generation.Load() + ds.entityRepo.generation.Load() + ds.shardingKeyRepo.generation.Load()
+}
+
+// resolve returns the write target of the metadata. The targets are cached per (group, name)
+// instead of being looked up in the repositories for every request, and the cache is
+// dropped once the generation changes.
+func (ds *discoveryService) resolve(metadata *commonv1.Metadata) (*writeTarget, error) {
+	gen := ds.generation()
+	id := getID(metadata)
+	ds.targetsMu.RLock()
+	target, ok := ds.targets[id]
+	valid := ds.targetsGen == gen
+	ds.targetsMu.RUnlock()
+	if ok && valid {
+		return target, nil
+	}
+	shardNum, existed := ds.groupRepo.shardNum(metadata.Group)
+	if !existed {
+		return nil, errors.Wrapf(errNotExist, "finding the shard num by: %v", metadata)
+	}
+	entityLocator, existed := ds.entityRepo.getLocator(id)
+	if !existed {
+		return nil, errors.Wrapf(errNotExist, "finding the entity locator by: %v", metadata)
+	}
+	target = &writeTarget{entityLocator: entityLocator, shardNum: shardNum}
+	target.shardingKeyLocator, target.hasShardingKey = ds.shardingKeyRepo.getLocator(id)
+	ds.targetsMu.Lock()
+	defer ds.targetsMu.Unlock()
+	switch {
+	case gen > ds.targetsGen:
+		clear(ds.targets)
+		ds.targetsGen = gen
+	case gen < ds.targetsGen:
+		// a newer generation has been cached, the target might be stale.
+		return target, nil
+	}
+	ds.targets[id] = target
+	return target, nil
+}
+
 type identity struct {
 	name  string
 	group string
@@ -118,6 +166,7 @@ type groupRepo struct {
 	schema.UnimplementedOnInitHandler
 	log          *logger.Logger
 	resourceOpts map[string]*commonv1.ResourceOpts
+	generation   atomic.Uint64
 	sync.RWMutex
 }
 
@@ -135,6 +184,7 @@ func (s *groupRepo) OnAddOrUpdate(schemaMetadata schema.Metadata) {
 	s.RWMutex.Lock()
 	defer s.RWMutex.Unlock()
 	s.resourceOpts[group.Metadata.GetName()] = group.ResourceOpts
+	s.generation.Add(1)
 }
 
 func (s *groupRepo) OnDelete(schemaMetadata schema.Metadata) {
@@ -151,6 +201,7 @@ func (s *groupRepo) OnDelete(schemaMetadata schema.Metadata) {
 	s.RWMutex.Lock()
 	defer s.RWMutex.Unlock()
 	delete(s.resourceOpts, group.Metadata.GetName())
+	s.generation.Add(1)
 }
 
 func (s *groupRepo) shardNum(groupName string) (uint32, bool) {
@@ -173,6 +224,17 @@ func (s *groupRepo) copies(groupName string) (uint32, bool) {
 	return r.Replicas + 1, true
 }
 
+// archived returns whether the group is an archive group, which is read-only to the clients.
+func (s *groupRepo) archived(groupName string) bool {
+	s.RWMutex.RLock()
+	defer s.RWMutex.RUnlock()
+	r, ok := s.resourceOpts[groupName]
+	if !ok {
+		return false
+	}
+	return r.Archived
+}
+
 func getID(metadata *commonv1.Metadata) identity {
 	return identity{
 		name:  metadata.GetName(),
@@ -187,9 +249,19 @@ type entityRepo struct {
 	log         *logger.Logger
 	entitiesMap map[identity]partition.Locator
 	measureMap  map[identity]*databasev1.Measure
+	streamMap   map[identity]*databasev1.Stream
+	generation  atomic.Uint64
 	sync.RWMutex
 }
 
+func newEntityRepo() *entityRepo {
+	return &entityRepo{
+		entitiesMap: make(map[identity]partition.Locator),
+		measureMap:  make(map[identity]*databasev1.Measure),
+		streamMap:   make(map[identity]*databasev1.Stream),
+	}
+}
+
 // OnAddOrUpdate implements schema.EventHandler.
 func (e *entityRepo) OnAddOrUpdate(schemaMetadata schema.Metadata) {
 	var l partition.Locator
@@ -231,9 +303,12 @@ func (e *entityRepo) OnAddOrUpdate(schemaMetadata schema.Metadata) {
 	if schemaMetadata.Kind == schema.KindMeasure {
 		measure := schemaMetadata.Spec.(*databasev1.Measure)
 		e.measureMap[id] = measure
+		delete(e.streamMap, id)
 	} else {
 		delete(e.measureMap, id) // Ensure measure is not stored for streams
+		e.streamMap[id] = schemaMetadata.Spec.(*databasev1.Stream)
 	}
+	e.generation.Add(1)
 }
 
 // OnDelete implements schema.EventHandler.
@@ -269,6 +344,8 @@ func (e *entityRepo) OnDelete(schemaMetadata schema.Metadata) {
 	defer e.RWMutex.Unlock()
 	delete(e.entitiesMap, id)
 	delete(e.measureMap, id) // Ensure measure is not stored for streams
+	delete(e.streamMap, id)
+	e.generation.Add(1)
 }
 
 func (e *entityRepo) getLocator(id identity) (partition.Locator, bool) {
@@ -290,60 +367,78 @@ func (e *entityRepo) loadMeasure(metadata *commonv1.Metadata) (*databasev1.Measu
 	return measure, ok
 }
 
+// loadStream retrieves the stream from the entityRepo by its metadata.
+func (e *entityRepo) loadStream(metadata *commonv1.Metadata) (*databasev1.Stream, bool) {
+	id := getID(metadata)
+	e.RWMutex.RLock()
+	defer e.RWMutex.RUnlock()
+	stream, ok := e.streamMap[id]
+	return stream, ok
+}
+
 var _ schema.EventHandler = (*shardingKeyRepo)(nil)
 
 type shardingKeyRepo struct {
 	schema.UnimplementedOnInitHandler
 	log             *logger.Logger
 	shardingKeysMap map[identity]partition.Locator
+	generation      atomic.Uint64
 	sync.RWMutex
 }
 
+// shardingKeyOf returns the sharding key of a measure or a stream.
+func shardingKeyOf(schemaMetadata schema.Metadata) (kind string, md *commonv1.Metadata, families []*databasev1.TagFamilySpec, key *databasev1.ShardingKey) {
+	switch schemaMetadata.Kind {
+	case schema.KindMeasure:
+		measure := schemaMetadata.Spec.(*databasev1.Measure)
+		return "measure", measure.GetMetadata(), measure.GetTagFamilies(), measure.GetShardingKey()
+	case schema.KindStream:
+		stream := schemaMetadata.Spec.(*databasev1.Stream)
+		return "stream", stream.GetMetadata(), stream.GetTagFamilies(), stream.GetShardingKey()
+	default:
+		return "", nil, nil, nil
+	}
+}
+
 // OnAddOrUpdate implements schema.EventHandler.
 func (s *shardingKeyRepo) OnAddOrUpdate(schemaMetadata schema.Metadata) {
-	if schemaMetadata.Kind != schema.KindMeasure {
-		return
-	}
-	measure := schemaMetadata.Spec.(*databasev1.Measure)
-	shardingKey := measure.GetShardingKey()
+	kind, md, families, shardingKey := shardingKeyOf(schemaMetadata)
 	if shardingKey == nil || len(shardingKey.GetTagNames()) == 0 {
 		return
 	}
-	l := partition.NewShardingKeyLocator(measure.TagFamilies, measure.ShardingKey)
-	id := getID(measure.GetMetadata())
+	l := partition.NewShardingKeyLocator(families, shardingKey)
+	id := getID(md)
 	if le := s.log.Debug(); le.Enabled() {
 		le.
 			Str("action", "add_or_update").
 			Stringer("subject", id).
-			Str("kind", "measure").
+			Str("kind", kind).
 			Msg("sharding key added or updated")
 	}
 	s.RWMutex.Lock()
 	defer s.RWMutex.Unlock()
 	s.shardingKeysMap[id] = partition.Locator{TagLocators: l.TagLocators}
+	s.generation.Add(1)
 }
 
 // OnDelete implements schema.EventHandler.
 func (s *shardingKeyRepo) OnDelete(schemaMetadata schema.Metadata) {
-	if schemaMetadata.Kind != schema.KindMeasure {
-		return
-	}
-	measure := schemaMetadata.Spec.(*databasev1.Measure)
-	shardingKey := measure.GetShardingKey()
+	kind, md, _, shardingKey := shardingKeyOf(schemaMetadata)
 	if shardingKey == nil || len(shardingKey.GetTagNames()) == 0 {
 		return
 	}
-	id := getID(measure.GetMetadata())
+	id := getID(md)
 	if le := s.log.Debug(); le.Enabled() {
 		le.
 			Str("action", "delete").
 			Stringer("subject", id).
-			Str("kind", "measure").
+			Str("kind", kind).
 			Msg("sharding key deletedTime")
 	}
 	s.RWMutex.Lock()
 	defer s.RWMutex.Unlock()
 	delete(s.shardingKeysMap, id)
+	s.generation.Add(1)
 }
 
 func (s *shardingKeyRepo) getLocator(id identity) (partition.Locator, bool) {
@@ -0,0 +1,92 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+)
+
+func TestDiscoveryServiceResolve(t *testing.T) {
+	gr := &groupRepo{resourceOpts: make(map[string]*commonv1.ResourceOpts)}
+	ds := newDiscoveryService(schema.KindStream, nil, nil, gr)
+	ds.SetLogger(logger.GetLogger("test"))
+	group := func(shardNum uint32) schema.Metadata {
+		return schema.Metadata{TypeMeta: schema.TypeMeta{Kind: schema.KindGroup}, Spec: &commonv1.Group{
+			Metadata:     &commonv1.Metadata{Name: "g"},
+			Catalog:      commonv1.Catalog_CATALOG_STREAM,
+			ResourceOpts: &commonv1.ResourceOpts{ShardNum: shardNum},
+		}}
+	}
+	stream := schema.Metadata{TypeMeta: schema.TypeMeta{Kind: schema.KindStream}, Spec: &databasev1.Stream{
+		Metadata: &commonv1.Metadata{Group: "g", Name: "s"},
+		TagFamilies: []*databasev1.TagFamilySpec{{
+			Name: "default",
+			Tags: []*databasev1.TagSpec{{Name: "svc", Type: databasev1.TagType_TAG_TYPE_STRING}},
+		}},
+		Entity: &databasev1.Entity{TagNames: []string{"svc"}},
+	}}
+	md := &commonv1.Metadata{Group: "g", Name: "s"}
+	tagFamilies := []*modelv1.TagFamilyForWrite{{Tags: []*modelv1.TagValue{
+		{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: "svc1"}}},
+	}}}
+
+	_, err := ds.resolve(md)
+	assert.ErrorIs(t, err, errNotExist)
+	gr.OnAddOrUpdate(group(1))
+	ds.entityRepo.OnAddOrUpdate(stream)
+
+	target, err := ds.resolve(md)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), target.shardNum)
+	cached, err := ds.resolve(md)
+	require.NoError(t, err)
+	assert.Same(t, target, cached)
+	entityValues, shardID, err := ds.navigate(md, tagFamilies)
+	require.NoError(t, err)
+	assert.Equal(t, "svc1", entityValues[1].GetStr().GetValue())
+	assert.Equal(t, uint32(0), uint32(shardID))
+
+	gr.OnAddOrUpdate(group(4))
+	updated, err := ds.resolve(md)
+	require.NoError(t, err)
+	assert.NotSame(t, target, updated)
+	assert.Equal(t, uint32(4), updated.shardNum)
+
+	ds.entityRepo.OnDelete(stream)
+	_, _, err = ds.navigate(md, tagFamilies)
+	assert.ErrorIs(t, err, errNotExist)
+}
+
+func TestGroupRepoArchived(t *testing.T) {
+	gr := &groupRepo{resourceOpts: map[string]*commonv1.ResourceOpts{
+		"sw":         {ShardNum: 1},
+		"sw-archive": {ShardNum: 1, Archived: true},
+	}}
+	assert.False(t, gr.archived("sw"))
+	assert.True(t, gr.archived("sw-archive"))
+	assert.False(t, gr.archived("unknown"))
+}
@@ -0,0 +1,78 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"slices"
+	"time"
+
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+)
+
+// downsampledGroups replaces every group by its coarsest downsampled group, whose resolution divides the step,
+// whose ttl covers the beginning of the time range and which holds the queried measure.
+// The groups without such a downsampled group are queried as they are.
+func (s *groupRepo) downsampledGroups(groups []string, step time.Duration, timeRange *modelv1.TimeRange,
+	now time.Time, holds func(group string) bool,
+) []string {
+	if step <= 0 {
+		return groups
+	}
+	s.RWMutex.RLock()
+	defer s.RWMutex.RUnlock()
+	var result []string
+	for i, g := range groups {
+		selected := g
+		var coarsest time.Duration
+		for _, dg := range s.resourceOpts[g].GetDownsampledGroups() {
+			resolution := intervalDuration(dg.GetResolution())
+			if resolution <= coarsest || resolution > step || step%resolution != 0 {
+				continue
+			}
+			opts, ok := s.resourceOpts[dg.GetGroup()]
+			if !ok {
+				continue
+			}
+			if ttl := opts.GetTtl(); ttl != nil && timeRange.GetBegin().AsTime().Before(now.Add(-intervalDuration(ttl))) {
+				continue
+			}
+			if !holds(dg.GetGroup()) {
+				continue
+			}
+			selected, coarsest = dg.GetGroup(), resolution
+		}
+		if selected == g {
+			continue
+		}
+		if result == nil {
+			result = slices.Clone(groups)
+		}
+		result[i] = selected
+	}
+	if result == nil {
+		return groups
+	}
+	// several groups might share a downsampled group.
+	deduplicated := make([]string, 0, len(result))
+	for _, g := range result {
+		if !slices.Contains(deduplicated, g) {
+			deduplicated = append(deduplicated, g)
+		}
+	}
+	return deduplicated
+}
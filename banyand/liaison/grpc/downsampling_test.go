@@ -0,0 +1,62 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+)
+
+func TestGroupRepo_DownsampledGroups(t *testing.T) {
+	hour := &commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_HOUR, Num: 1}
+	day := &commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_DAY, Num: 1}
+	gr := &groupRepo{resourceOpts: map[string]*commonv1.ResourceOpts{
+		"minute": {ShardNum: 1, DownsampledGroups: []*commonv1.DownsampledGroup{
+			{Group: "hour", Resolution: hour},
+			{Group: "day", Resolution: day},
+		}},
+		"other-minute": {ShardNum: 1, DownsampledGroups: []*commonv1.DownsampledGroup{
+			{Group: "hour", Resolution: hour},
+		}},
+		"hour": {ShardNum: 1, Ttl: &commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_DAY, Num: 7}},
+		"day":  {ShardNum: 1, Ttl: &commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_DAY, Num: 30}},
+	}}
+	now := time.Now()
+	lastDays := func(n int) *modelv1.TimeRange {
+		return &modelv1.TimeRange{Begin: timestamppb.New(now.Add(-time.Duration(n) * 24 * time.Hour)), End: timestamppb.New(now)}
+	}
+	holdsAll := func(string) bool { return true }
+
+	assert.Equal(t, []string{"minute"}, gr.downsampledGroups([]string{"minute"}, 0, lastDays(1), now, holdsAll))
+	assert.Equal(t, []string{"minute"}, gr.downsampledGroups([]string{"minute"}, 5*time.Minute, lastDays(1), now, holdsAll))
+	assert.Equal(t, []string{"hour"}, gr.downsampledGroups([]string{"minute"}, 3*time.Hour, lastDays(1), now, holdsAll))
+	assert.Equal(t, []string{"minute"}, gr.downsampledGroups([]string{"minute"}, 90*time.Minute, lastDays(1), now, holdsAll))
+	assert.Equal(t, []string{"day"}, gr.downsampledGroups([]string{"minute"}, 48*time.Hour, lastDays(1), now, holdsAll))
+	// the hourly data points have expired, and the step is too fine for the daily ones.
+	assert.Equal(t, []string{"minute"}, gr.downsampledGroups([]string{"minute"}, time.Hour, lastDays(10), now, holdsAll))
+	assert.Equal(t, []string{"hour"}, gr.downsampledGroups([]string{"minute"}, 24*time.Hour, lastDays(3), now,
+		func(g string) bool { return g != "day" }))
+	assert.Equal(t, []string{"hour"}, gr.downsampledGroups([]string{"minute", "other-minute"}, time.Hour, lastDays(1), now, holdsAll))
+	assert.Equal(t, []string{"day", "hour"}, gr.downsampledGroups([]string{"minute", "other-minute"}, 24*time.Hour, lastDays(1), now, holdsAll))
+}
@@ -0,0 +1,66 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/banyand/metadata"
+)
+
+// dynamicFlagServer stores the dynamic flags in the registry, from which every node applies them.
+type dynamicFlagServer struct {
+	databasev1.UnimplementedDynamicFlagServiceServer
+	schemaRegistry metadata.Repo
+}
+
+func (d *dynamicFlagServer) Set(ctx context.Context, req *databasev1.DynamicFlagServiceSetRequest) (
+	*databasev1.DynamicFlagServiceSetResponse, error,
+) {
+	if req.GetFlag().GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "the name of the flag is empty")
+	}
+	if err := d.schemaRegistry.DynamicFlagRegistry().SetDynamicFlag(ctx, req.GetFlag()); err != nil {
+		return nil, err
+	}
+	return &databasev1.DynamicFlagServiceSetResponse{}, nil
+}
+
+func (d *dynamicFlagServer) Delete(ctx context.Context, req *databasev1.DynamicFlagServiceDeleteRequest) (
+	*databasev1.DynamicFlagServiceDeleteResponse, error,
+) {
+	ok, err := d.schemaRegistry.DynamicFlagRegistry().DeleteDynamicFlag(ctx, req.GetNode(), req.GetName())
+	if err != nil {
+		return nil, err
+	}
+	return &databasev1.DynamicFlagServiceDeleteResponse{Deleted: ok}, nil
+}
+
+func (d *dynamicFlagServer) List(ctx context.Context, _ *databasev1.DynamicFlagServiceListRequest) (
+	*databasev1.DynamicFlagServiceListResponse, error,
+) {
+	flags, err := d.schemaRegistry.DynamicFlagRegistry().ListDynamicFlag(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &databasev1.DynamicFlagServiceListResponse{Flags: flags}, nil
+}
@@ -0,0 +1,87 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"encoding/hex"
+	"sync"
+	"time"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	"github.com/apache/skywalking-banyandb/pkg/convert"
+)
+
+const (
+	elementIDWorkerBits   = 10
+	elementIDSequenceBits = 12
+	maxElementIDWorker    = 1<<elementIDWorkerBits - 1
+	maxElementIDSequence  = 1<<elementIDSequenceBits - 1
+)
+
+// elementIDEpoch is the beginning of the timestamps in the generated IDs.
+var elementIDEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// elementIDGenerator generates the snowflake-style element IDs, which are composed of
+// 41 bits of the milliseconds since elementIDEpoch, 10 bits of the worker and 12 bits of the sequence.
+// The worker should be unique among the liaison nodes.
+type elementIDGenerator struct {
+	now      func() time.Time
+	worker   uint64
+	lastMs   int64
+	sequence uint64
+	mu       sync.Mutex
+}
+
+func newElementIDGenerator(worker uint64) *elementIDGenerator {
+	return &elementIDGenerator{now: time.Now, worker: worker & maxElementIDWorker}
+}
+
+func (g *elementIDGenerator) next() uint64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	ms := g.now().Sub(elementIDEpoch).Milliseconds()
+	// the IDs keep increasing if the clock moves backwards, or the sequence is exhausted in a millisecond.
+	if ms < g.lastMs {
+		ms = g.lastMs
+	}
+	if ms == g.lastMs {
+		g.sequence = (g.sequence + 1) & maxElementIDSequence
+		if g.sequence == 0 {
+			ms++
+		}
+	} else {
+		g.sequence = 0
+	}
+	g.lastMs = ms
+	return uint64(ms)<<(elementIDWorkerBits+elementIDSequenceBits) | g.worker<<elementIDSequenceBits | g.sequence
+}
+
+// formatElementID formats the ID as the element_id in the query results.
+func formatElementID(id uint64) string {
+	return hex.EncodeToString(convert.Uint64ToBytes(id))
+}
+
+func (s *groupRepo) elementIDSource(groupName string) commonv1.ElementIDSource {
+	s.RWMutex.RLock()
+	defer s.RWMutex.RUnlock()
+	r, ok := s.resourceOpts[groupName]
+	if !ok {
+		return commonv1.ElementIDSource_ELEMENT_ID_SOURCE_UNSPECIFIED
+	}
+	return r.ElementIdSource
+}
@@ -0,0 +1,61 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestElementIDGenerator(t *testing.T) {
+	now := elementIDEpoch.Add(time.Hour)
+	g := newElementIDGenerator(5)
+	g.now = func() time.Time { return now }
+
+	first := g.next()
+	assert.Equal(t, uint64(time.Hour.Milliseconds()), first>>(elementIDWorkerBits+elementIDSequenceBits))
+	assert.Equal(t, uint64(5), first>>elementIDSequenceBits&maxElementIDWorker)
+	assert.Equal(t, uint64(0), first&maxElementIDSequence)
+
+	seen := map[uint64]struct{}{first: {}}
+	last := first
+	// the sequence is exhausted in a millisecond.
+	for i := 0; i < 2*maxElementIDSequence; i++ {
+		id := g.next()
+		assert.Greater(t, id, last)
+		last = id
+		seen[id] = struct{}{}
+	}
+	// the clock moves backwards.
+	now = now.Add(-time.Second)
+	id := g.next()
+	assert.Greater(t, id, last)
+	seen[id] = struct{}{}
+	assert.Len(t, seen, 2*maxElementIDSequence+2)
+
+	other := newElementIDGenerator(6)
+	other.now = g.now
+	assert.NotEqual(t, id, other.next())
+}
+
+func TestFormatElementID(t *testing.T) {
+	assert.Equal(t, "0000000000000001", formatElementID(1))
+	assert.Equal(t, "0102030405060708", formatElementID(0x0102030405060708))
+}
@@ -0,0 +1,262 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"context"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	propertyv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/property/v1"
+	"github.com/apache/skywalking-banyandb/banyand/metadata"
+	"github.com/apache/skywalking-banyandb/pkg/convert"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/meter"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+)
+
+const (
+	entityRegistered = "registered"
+	entityDropped    = "dropped"
+	entityFailed     = "failed"
+
+	entityRegistrationTimeout = 10 * time.Second
+)
+
+type entityRegistrationOptions struct {
+	queueSize   int
+	maxEntities int
+}
+
+func (o entityRegistrationOptions) validate() error {
+	if o.queueSize <= 0 || o.maxEntities <= 0 {
+		return errors.New("entity-registration-queue-size and entity-registration-max-entities must be positive")
+	}
+	return nil
+}
+
+func (s *groupRepo) entityRegistration(groupName string) *commonv1.EntityRegistration {
+	s.RWMutex.RLock()
+	defer s.RWMutex.RUnlock()
+	r, ok := s.resourceOpts[groupName]
+	if !ok {
+		return nil
+	}
+	return r.GetEntityRegistration()
+}
+
+type entityRegistration struct {
+	registration *commonv1.EntityRegistration
+	metadata     *commonv1.Metadata
+	entityValues pbv1.EntityValues
+	catalog      commonv1.Catalog
+	key          uint64
+}
+
+// entityRegistrar upserts the properties of the entities when their series are first observed by the liaison,
+// which saves the clients the round trips to register the entities.
+// The registrations are applied by a worker in the background, and dropped if the queue is full.
+// The entities which fail to be registered are retried by their next writes.
+type entityRegistrar struct {
+	l              *logger.Logger
+	groupRepo      *groupRepo
+	schemaRegistry metadata.Repo
+	apply          func(context.Context, *propertyv1.ApplyRequest) (*propertyv1.ApplyResponse, error)
+	registrations  meter.Counter
+	seen           map[uint64]struct{}
+	queue          chan entityRegistration
+	opts           entityRegistrationOptions
+	mu             sync.Mutex
+}
+
+func newEntityRegistrar(opts entityRegistrationOptions, l *logger.Logger, gr *groupRepo, schemaRegistry metadata.Repo,
+	apply func(context.Context, *propertyv1.ApplyRequest) (*propertyv1.ApplyResponse, error), registrations meter.Counter,
+) *entityRegistrar {
+	return &entityRegistrar{
+		l:              l,
+		groupRepo:      gr,
+		schemaRegistry: schemaRegistry,
+		apply:          apply,
+		registrations:  registrations,
+		opts:           opts,
+		seen:           make(map[uint64]struct{}),
+		queue:          make(chan entityRegistration, opts.queueSize),
+	}
+}
+
+// observe enqueues the registration of the entity if its group enables the registration and it isn't seen before.
+// The entity values include the subject at first.
+func (r *entityRegistrar) observe(catalog commonv1.Catalog, md *commonv1.Metadata, entityValues pbv1.EntityValues) {
+	if r == nil || len(entityValues) < 2 {
+		return
+	}
+	registration := r.groupRepo.entityRegistration(md.GetGroup())
+	if registration == nil {
+		return
+	}
+	series := pbv1.Series{Subject: md.GetGroup() + "/" + md.GetName(), EntityValues: entityValues[1:].Encode()}
+	if series.Marshal() != nil {
+		return
+	}
+	key := convert.Hash(series.Buffer)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.seen[key]; ok {
+		return
+	}
+	if len(r.seen) >= r.opts.maxEntities {
+		// the entities are registered again after the reset, which is harmless since the properties are merged.
+		r.seen = make(map[uint64]struct{})
+	}
+	select {
+	case r.queue <- entityRegistration{
+		registration: registration,
+		catalog:      catalog,
+		metadata:     &commonv1.Metadata{Group: md.GetGroup(), Name: md.GetName()},
+		entityValues: entityValues,
+		key:          key,
+	}:
+		r.seen[key] = struct{}{}
+	default:
+		r.registrations.Inc(1, md.GetGroup(), entityDropped)
+	}
+}
+
+func (r *entityRegistrar) start(stopCh <-chan struct{}) {
+	go func() {
+		for {
+			select {
+			case er := <-r.queue:
+				r.register(er)
+			case <-stopCh:
+				return
+			}
+		}
+	}()
+}
+
+func (r *entityRegistrar) register(er entityRegistration) {
+	ctx, cancel := context.WithTimeout(context.Background(), entityRegistrationTimeout)
+	defer cancel()
+	err := r.applyProperties(ctx, er)
+	if err == nil {
+		r.registrations.Inc(1, er.metadata.GetGroup(), entityRegistered)
+		return
+	}
+	r.l.Warn().Err(err).Str("group", er.metadata.GetGroup()).Str("name", er.metadata.GetName()).
+		Str("entity", er.entityValues.String()).Msg("failed to register the entity")
+	r.registrations.Inc(1, er.metadata.GetGroup(), entityFailed)
+	r.mu.Lock()
+	delete(r.seen, er.key)
+	r.mu.Unlock()
+}
+
+func (r *entityRegistrar) applyProperties(ctx context.Context, er entityRegistration) error {
+	tagNames, err := r.entityTagNames(ctx, er.catalog, er.metadata)
+	if err != nil {
+		return err
+	}
+	for _, name := range er.registration.GetProperties() {
+		propSchema, err := r.schemaRegistry.PropertyRegistry().GetProperty(ctx, &commonv1.Metadata{
+			Group: er.registration.GetGroup(),
+			Name:  name,
+		})
+		if err != nil {
+			return errors.WithMessagef(err, "failed to get the property schema %s", name)
+		}
+		p := entityProperty(propSchema, tagNames, er.entityValues[1:])
+		if p == nil {
+			continue
+		}
+		if _, err = r.apply(ctx, &propertyv1.ApplyRequest{Property: p, Strategy: propertyv1.ApplyRequest_STRATEGY_MERGE}); err != nil {
+			return errors.WithMessagef(err, "failed to apply the property %s", name)
+		}
+	}
+	return nil
+}
+
+func (r *entityRegistrar) entityTagNames(ctx context.Context, catalog commonv1.Catalog, md *commonv1.Metadata) ([]string, error) {
+	var entity *databasev1.Entity
+	switch catalog {
+	case commonv1.Catalog_CATALOG_STREAM:
+		s, err := r.schemaRegistry.StreamRegistry().GetStream(ctx, md)
+		if err != nil {
+			return nil, err
+		}
+		entity = s.GetEntity()
+	case commonv1.Catalog_CATALOG_MEASURE:
+		m, err := r.schemaRegistry.MeasureRegistry().GetMeasure(ctx, md)
+		if err != nil {
+			return nil, err
+		}
+		entity = m.GetEntity()
+	default:
+		return nil, errors.Errorf("catalog %s has no entity", catalog)
+	}
+	return entity.GetTagNames(), nil
+}
+
+// entityProperty fills the tags of the property schema with the entity tags of the same names.
+// The ID of the property joins the values of the tags in the order of the property schema.
+// It returns nil if the property shares no tag with the entity.
+func entityProperty(propSchema *databasev1.Property, tagNames []string, entityValues pbv1.EntityValues) *propertyv1.Property {
+	p := &propertyv1.Property{
+		Metadata: &commonv1.Metadata{Group: propSchema.GetMetadata().GetGroup(), Name: propSchema.GetMetadata().GetName()},
+	}
+	ids := make([]string, 0, len(tagNames))
+	for _, spec := range propSchema.GetTags() {
+		for i, name := range tagNames {
+			if name != spec.GetName() || i >= len(entityValues) {
+				continue
+			}
+			v := entityValues[i]
+			if _, isNull := v.GetValue().(*modelv1.TagValue_Null); v == nil || isNull {
+				break
+			}
+			p.Tags = append(p.Tags, &modelv1.Tag{Key: name, Value: v})
+			ids = append(ids, entityID(v))
+			break
+		}
+	}
+	if len(p.Tags) == 0 {
+		return nil
+	}
+	p.Id = strings.Join(ids, "/")
+	return p
+}
+
+func entityID(v *modelv1.TagValue) string {
+	switch v.GetValue().(type) {
+	case *modelv1.TagValue_Str:
+		return v.GetStr().GetValue()
+	case *modelv1.TagValue_Int:
+		return strconv.FormatInt(v.GetInt().GetValue(), 10)
+	case *modelv1.TagValue_BinaryData:
+		return hex.EncodeToString(v.GetBinaryData())
+	default:
+		return v.String()
+	}
+}
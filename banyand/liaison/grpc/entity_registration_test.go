@@ -0,0 +1,93 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+)
+
+func TestEntityProperty(t *testing.T) {
+	propSchema := &databasev1.Property{
+		Metadata: &commonv1.Metadata{Group: "sw_entity", Name: "instance"},
+		Tags: []*databasev1.TagSpec{
+			{Name: "service", Type: databasev1.TagType_TAG_TYPE_STRING},
+			{Name: "instance", Type: databasev1.TagType_TAG_TYPE_STRING},
+			{Name: "layer", Type: databasev1.TagType_TAG_TYPE_STRING},
+		},
+	}
+	p := entityProperty(propSchema, []string{"instance", "service", "endpoint"},
+		pbv1.EntityValues{pbv1.EntityStrValue("inst_1"), pbv1.EntityStrValue("svc_1"), pbv1.EntityStrValue("/")})
+	require.NotNil(t, p)
+	assert.Equal(t, "sw_entity", p.GetMetadata().GetGroup())
+	assert.Equal(t, "instance", p.GetMetadata().GetName())
+	assert.Equal(t, "svc_1/inst_1", p.GetId(), "the ID follows the order of the property schema")
+	require.Len(t, p.GetTags(), 2)
+	assert.Equal(t, "service", p.GetTags()[0].GetKey())
+	assert.Equal(t, "inst_1", p.GetTags()[1].GetValue().GetStr().GetValue())
+
+	p = entityProperty(propSchema, []string{"service", "instance"}, pbv1.EntityValues{pbv1.EntityStrValue("svc_1"), pbv1.NullTagValue})
+	require.NotNil(t, p)
+	assert.Equal(t, "svc_1", p.GetId(), "the null values are skipped")
+
+	assert.Nil(t, entityProperty(propSchema, []string{"endpoint"}, pbv1.EntityValues{pbv1.EntityStrValue("/")}))
+}
+
+func TestEntityRegistrarObserve(t *testing.T) {
+	gr := &groupRepo{resourceOpts: map[string]*commonv1.ResourceOpts{
+		"sw_metric": {EntityRegistration: &commonv1.EntityRegistration{Group: "sw_entity", Properties: []string{"service"}}},
+		"sw_record": {},
+	}}
+	counter := &anomalyCounter{}
+	r := newEntityRegistrar(entityRegistrationOptions{queueSize: 2, maxEntities: 10}, logger.GetLogger("test"), gr, nil, nil, counter)
+	md := &commonv1.Metadata{Group: "sw_metric", Name: "service_cpm"}
+	entity := func(svc string) pbv1.EntityValues {
+		return pbv1.EntityValues{pbv1.EntityStrValue(md.GetName()), pbv1.EntityStrValue(svc)}
+	}
+
+	r.observe(commonv1.Catalog_CATALOG_MEASURE, md, entity("svc_1"))
+	r.observe(commonv1.Catalog_CATALOG_MEASURE, md, entity("svc_1"))
+	r.observe(commonv1.Catalog_CATALOG_STREAM, &commonv1.Metadata{Group: "sw_record", Name: "log"}, entity("svc_1"))
+	require.Len(t, r.queue, 1, "the seen entity and the group without the registration are skipped")
+	er := <-r.queue
+	assert.Equal(t, "sw_entity", er.registration.GetGroup())
+	assert.Equal(t, "service_cpm", er.metadata.GetName())
+
+	r.observe(commonv1.Catalog_CATALOG_MEASURE, md, entity("svc_2"))
+	r.observe(commonv1.Catalog_CATALOG_MEASURE, md, entity("svc_3"))
+	r.observe(commonv1.Catalog_CATALOG_MEASURE, md, entity("svc_4"))
+	assert.Len(t, r.queue, 2)
+	assert.Equal(t, []string{"sw_metric/dropped"}, counter.events, "the registrations overflowing the queue are dropped")
+	assert.Len(t, r.seen, 3, "the dropped entity is registered by its next write")
+
+	var nilRegistrar *entityRegistrar
+	nilRegistrar.observe(commonv1.Catalog_CATALOG_MEASURE, md, entity("svc_1"))
+}
+
+func TestEntityRegistrationOptionsValidate(t *testing.T) {
+	assert.NoError(t, entityRegistrationOptions{queueSize: 1, maxEntities: 1}.validate())
+	assert.Error(t, entityRegistrationOptions{queueSize: 0, maxEntities: 1}.validate())
+	assert.Error(t, entityRegistrationOptions{queueSize: 1, maxEntities: -1}.validate())
+}
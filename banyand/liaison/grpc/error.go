@@ -0,0 +1,75 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"strings"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	"github.com/apache/skywalking-banyandb/pkg/grpchelper"
+)
+
+// serviceResources maps the services to the types of the resources they operate on.
+var serviceResources = map[string]string{
+	"StreamService":                   grpchelper.ResourceStream,
+	"StreamRegistryService":           grpchelper.ResourceStream,
+	"MeasureService":                  grpchelper.ResourceMeasure,
+	"MeasureRegistryService":          grpchelper.ResourceMeasure,
+	"TraceService":                    grpchelper.ResourceTrace,
+	"PropertyService":                 grpchelper.ResourceProperty,
+	"PropertyRegistryService":         grpchelper.ResourceProperty,
+	"GroupRegistryService":            grpchelper.ResourceGroup,
+	"IndexRuleRegistryService":        grpchelper.ResourceIndexRule,
+	"IndexRuleBindingRegistryService": grpchelper.ResourceIndexRuleBinding,
+	"TopNAggregationRegistryService":  grpchelper.ResourceTopNAggregation,
+}
+
+// errorResource locates the resource of the failed call by the metadata or the group of its request.
+// The resource name is "<group>/<name>", or the group alone for the groups.
+func errorResource(fullMethod string, req any) (resourceType, resourceName string) {
+	service := strings.TrimPrefix(fullMethod, "/")
+	if i := strings.LastIndexByte(service, '/'); i >= 0 {
+		service = service[:i]
+	}
+	if i := strings.LastIndexByte(service, '.'); i >= 0 {
+		service = service[i+1:]
+	}
+	resourceType, ok := serviceResources[service]
+	if !ok {
+		return "", ""
+	}
+	switch r := req.(type) {
+	case interface{ GetMetadata() *commonv1.Metadata }:
+		md := r.GetMetadata()
+		if md == nil {
+			return "", ""
+		}
+		if resourceType == grpchelper.ResourceGroup {
+			return resourceType, md.GetName()
+		}
+		return resourceType, md.GetGroup() + "/" + md.GetName()
+	case interface{ GetGroup() string }:
+		if resourceType == grpchelper.ResourceGroup {
+			return resourceType, r.GetGroup()
+		}
+		if named, hasName := req.(interface{ GetName() string }); hasName {
+			return resourceType, r.GetGroup() + "/" + named.GetName()
+		}
+	}
+	return "", ""
+}
@@ -0,0 +1,47 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	"github.com/apache/skywalking-banyandb/pkg/grpchelper"
+)
+
+func TestErrorResource(t *testing.T) {
+	typ, name := errorResource("/banyandb.database.v1.StreamRegistryService/Get",
+		&databasev1.StreamRegistryServiceGetRequest{Metadata: &commonv1.Metadata{Group: "sw", Name: "segment"}})
+	assert.Equal(t, grpchelper.ResourceStream, typ)
+	assert.Equal(t, "sw/segment", name)
+
+	typ, name = errorResource("/banyandb.database.v1.GroupRegistryService/Get", &databasev1.GroupRegistryServiceGetRequest{Group: "sw"})
+	assert.Equal(t, grpchelper.ResourceGroup, typ)
+	assert.Equal(t, "sw", name)
+
+	typ, name = errorResource("/banyandb.measure.v1.MeasureService/DeleteSeries", &measurev1.DeleteSeriesRequest{Group: "sw", Name: "cpm"})
+	assert.Equal(t, grpchelper.ResourceMeasure, typ)
+	assert.Equal(t, "sw/cpm", name)
+
+	typ, _ = errorResource("/banyandb.database.v1.SnapshotService/Snapshot", &databasev1.SnapshotRequest{})
+	assert.Empty(t, typ)
+}
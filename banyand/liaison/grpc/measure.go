@@ -19,17 +19,21 @@ package grpc
 
 import (
 	"context"
+	"fmt"
 	"io"
+	"slices"
 	"time"
 
 	"github.com/pkg/errors"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/apache/skywalking-banyandb/api/common"
 	"github.com/apache/skywalking-banyandb/api/data"
 	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
 	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
 	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
 	"github.com/apache/skywalking-banyandb/banyand/measure"
@@ -37,9 +41,13 @@ import (
 	"github.com/apache/skywalking-banyandb/pkg/accesslog"
 	"github.com/apache/skywalking-banyandb/pkg/bus"
 	"github.com/apache/skywalking-banyandb/pkg/convert"
+	"github.com/apache/skywalking-banyandb/pkg/grpchelper"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
 	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
 	"github.com/apache/skywalking-banyandb/pkg/query"
+	"github.com/apache/skywalking-banyandb/pkg/query/executor"
+	"github.com/apache/skywalking-banyandb/pkg/query/logical"
+	"github.com/apache/skywalking-banyandb/pkg/run"
 	"github.com/apache/skywalking-banyandb/pkg/timestamp"
 )
 
@@ -49,10 +57,19 @@ type measureService struct {
 	pipeline           queue.Client
 	broadcaster        queue.Client
 	*discoveryService
-	l               *logger.Logger
-	metrics         *metrics
-	writeTimeout    time.Duration
-	maxWaitDuration time.Duration
+	l                   *logger.Logger
+	metrics             *metrics
+	nodeID              string
+	writeRate           *writeRateDetector
+	shardLoad           *shardLoadTracker
+	entityRegistrar     *entityRegistrar
+	writeTimeout        time.Duration
+	maxWaitDuration     time.Duration
+	maxListSize         *run.DynamicInt
+	maxPatternWildcards *run.DynamicInt
+	coldQueries         *coldQueryPool
+	maxDataPointSize    run.Bytes
+	maxTagValueSize     run.Bytes
 }
 
 func (ms *measureService) setLogger(log *logger.Logger) {
@@ -95,6 +112,7 @@ func (ms *measureService) Write(measure measurev1.MeasureService_WriteServer) er
 		}
 
 		ms.metrics.totalStreamMsgReceived.Inc(1, writeRequest.Metadata.Group, "measure", "write")
+		ms.sampleMeasureWrite(writeRequest)
 
 		if status := ms.validateWriteRequest(writeRequest, measure); status != modelv1.Status_STATUS_SUCCEED {
 			continue
@@ -113,6 +131,11 @@ func (ms *measureService) validateWriteRequest(writeRequest *measurev1.WriteRequ
 		return modelv1.Status_STATUS_INVALID_TIMESTAMP
 	}
 
+	if ms.groupRepo.archived(writeRequest.GetMetadata().GetGroup()) {
+		ms.sendReply(writeRequest.GetMetadata(), modelv1.Status_STATUS_READ_ONLY, writeRequest.GetMessageId(), measure)
+		return modelv1.Status_STATUS_READ_ONLY
+	}
+
 	if writeRequest.Metadata.ModRevision > 0 {
 		measureCache, existed := ms.entityRepo.getLocator(getID(writeRequest.GetMetadata()))
 		if !existed {
@@ -127,6 +150,38 @@ func (ms *measureService) validateWriteRequest(writeRequest *measurev1.WriteRequ
 		}
 	}
 
+	ms.applyTagDefaults(measure.Context(), writeRequest)
+
+	if err := ms.checkDataPointBudget(writeRequest); err != nil {
+		ms.l.Warn().Err(err).Stringer("metadata", writeRequest.GetMetadata()).Msg("reject the oversized data point")
+		ms.sendResponse(&measurev1.WriteResponse{
+			Metadata:  writeRequest.GetMetadata(),
+			Status:    modelv1.Status_STATUS_ELEMENT_TOO_LARGE.String(),
+			MessageId: writeRequest.GetMessageId(),
+			Reason:    err.Error(),
+		}, measure)
+		return modelv1.Status_STATUS_ELEMENT_TOO_LARGE
+	}
+
+	if ms.groupRepo.strictWriteValidation(writeRequest.GetMetadata().GetGroup()) {
+		m, existed := ms.entityRepo.loadMeasure(writeRequest.GetMetadata())
+		if !existed {
+			ms.l.Error().Stringer("written", writeRequest).Msg("failed to measure schema not found")
+			ms.sendReply(writeRequest.GetMetadata(), modelv1.Status_STATUS_NOT_FOUND, writeRequest.GetMessageId(), measure)
+			return modelv1.Status_STATUS_NOT_FOUND
+		}
+		if err := validateDataPoint(m, writeRequest.GetDataPoint()); err != nil {
+			ms.l.Warn().Err(err).Stringer("metadata", writeRequest.GetMetadata()).Msg("reject the invalid data point")
+			ms.sendResponse(&measurev1.WriteResponse{
+				Metadata:  writeRequest.GetMetadata(),
+				Status:    modelv1.Status_STATUS_INVALID_DATA.String(),
+				MessageId: writeRequest.GetMessageId(),
+				Reason:    err.Error(),
+			}, measure)
+			return modelv1.Status_STATUS_INVALID_DATA
+		}
+	}
+
 	return modelv1.Status_STATUS_SUCCEED
 }
 
@@ -163,6 +218,9 @@ func (ms *measureService) processAndPublishRequest(ctx context.Context, writeReq
 		ms.sendReply(writeRequest.GetMetadata(), modelv1.Status_STATUS_INTERNAL_ERROR, writeRequest.GetMessageId(), measure)
 		return err
 	}
+	ms.writeRate.observe(writeRequest.GetMetadata().GetGroup(), tagValues)
+	ms.shardLoad.observeWrite(writeRequest.GetMetadata().GetGroup(), shardID)
+	ms.entityRegistrar.observe(commonv1.Catalog_CATALOG_MEASURE, writeRequest.GetMetadata(), tagValues)
 
 	if writeRequest.DataPoint.Version == 0 {
 		if writeRequest.MessageId == 0 {
@@ -188,11 +246,16 @@ func (ms *measureService) processAndPublishRequest(ctx context.Context, writeReq
 		return err
 	}
 
-	*succeedSent = append(*succeedSent, succeedSentMessage{
+	succeed := succeedSentMessage{
 		metadata:  writeRequest.GetMetadata(),
 		messageID: writeRequest.GetMessageId(),
 		nodes:     nodes,
-	})
+	}
+	// the clients can't write a split shard directly, whose series are spread over two nodes.
+	if writeRequest.GetRoutingHint() && !ms.groupRepo.split(writeRequest.GetMetadata().GetGroup(), uint32(shardID)) {
+		succeed.routingHint = routingHint(ms.nodeRegistry, uint32(shardID), nodes)
+	}
+	*succeedSent = append(*succeedSent, succeed)
 	return nil
 }
 
@@ -234,15 +297,29 @@ func (ms *measureService) publishToNodes(ctx context.Context, writeRequest *meas
 }
 
 func (ms *measureService) sendReply(metadata *commonv1.Metadata, status modelv1.Status, messageID uint64, measure measurev1.MeasureService_WriteServer) {
+	ms.sendReplyWithHint(metadata, status, messageID, nil, measure)
+}
+
+func (ms *measureService) sendReplyWithHint(metadata *commonv1.Metadata, status modelv1.Status, messageID uint64, hint *modelv1.RoutingHint,
+	measure measurev1.MeasureService_WriteServer,
+) {
 	if status != modelv1.Status_STATUS_SUCCEED {
-		ms.metrics.totalStreamMsgReceivedErr.Inc(1, metadata.Group, "measure", "write")
+		hint = nil
 	}
-	ms.metrics.totalStreamMsgSent.Inc(1, metadata.Group, "measure", "write")
-	if errResp := measure.Send(&measurev1.WriteResponse{Metadata: metadata, Status: status.String(), MessageId: messageID}); errResp != nil {
+	ms.sendResponse(&measurev1.WriteResponse{Metadata: metadata, Status: status.String(), MessageId: messageID, RoutingHint: hint}, measure)
+}
+
+func (ms *measureService) sendResponse(resp *measurev1.WriteResponse, measure measurev1.MeasureService_WriteServer) {
+	group := resp.GetMetadata().GetGroup()
+	if resp.GetStatus() != modelv1.Status_STATUS_SUCCEED.String() {
+		ms.metrics.totalStreamMsgReceivedErr.Inc(1, group, "measure", "write")
+	}
+	ms.metrics.totalStreamMsgSent.Inc(1, group, "measure", "write")
+	if errResp := measure.Send(resp); errResp != nil {
 		if dl := ms.l.Debug(); dl.Enabled() {
 			dl.Err(errResp).Msg("failed to send measure write response")
 		}
-		ms.metrics.totalStreamMsgSentErr.Inc(1, metadata.Group, "measure", "write")
+		ms.metrics.totalStreamMsgSentErr.Inc(1, group, "measure", "write")
 	}
 }
 
@@ -263,7 +340,7 @@ func (ms *measureService) handleWriteCleanup(publisher queue.BatchPublisher, suc
 				}
 			}
 		}
-		ms.sendReply(s.metadata, code, s.messageID, measure)
+		ms.sendReplyWithHint(s.metadata, code, s.messageID, s.routingHint, measure)
 	}
 	if err != nil {
 		ms.l.Error().Err(err).Msg("failed to close the publisher")
@@ -278,6 +355,23 @@ func (ms *measureService) handleWriteCleanup(publisher queue.BatchPublisher, suc
 var emptyMeasureQueryResponse = &measurev1.QueryResponse{DataPoints: make([]*measurev1.DataPoint, 0)}
 
 func (ms *measureService) Query(ctx context.Context, req *measurev1.QueryRequest) (resp *measurev1.QueryResponse, err error) {
+	// the rewrite is pushed down by a liaison federating the group, which is internal to the clusters
+	if req.GetRewriteAggTopNResult() && !executor.IsFederated(ctx) {
+		return nil, status.Error(codes.InvalidArgument, "rewrite_agg_top_n_result is only set by the liaisons federating the groups")
+	}
+	if !req.SkipDownsampling {
+		holds := func(group string) bool {
+			_, ok := ms.entityRepo.getLocator(identity{name: req.Name, group: group})
+			return ok
+		}
+		if groups := ms.groupRepo.downsampledGroups(req.Groups, req.GetStep().AsDuration(), req.GetTimeRange(), time.Now(), holds); !slices.Equal(groups, req.Groups) {
+			if e := ms.l.Debug(); e.Enabled() {
+				e.Strs("requested", req.Groups).Strs("downsampled", groups).Str("name", req.Name).Msg("query the downsampled groups")
+			}
+			req = proto.Clone(req).(*measurev1.QueryRequest)
+			req.Groups = groups
+		}
+	}
 	for _, g := range req.Groups {
 		ms.metrics.totalStarted.Inc(1, g, "measure", "query")
 	}
@@ -294,6 +388,41 @@ func (ms *measureService) Query(ctx context.Context, req *measurev1.QueryRequest
 	if err = timestamp.CheckTimeRange(req.GetTimeRange()); err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, "%v is invalid :%s", req.GetTimeRange(), err)
 	}
+	if err = ms.groupRepo.checkQueryLimits(req.Groups, req.GetTimeRange(), req.GetOffset(), req.GetLimit()); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	if err = logical.CheckListSize(req.GetCriteria(), ms.maxListSize.Load()); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	if err = logical.CheckPatterns(req.GetCriteria(), ms.maxPatternWildcards.Load()); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	if ms.shardLoad != nil {
+		for _, g := range req.Groups {
+			if m, ok := ms.entityRepo.loadMeasure(&commonv1.Metadata{Group: g, Name: req.Name}); ok {
+				ms.shardLoad.observeQuery(g, req.Name, m.GetEntity(), m.GetShardingKey(), req.GetCriteria())
+			}
+		}
+	}
+	coldRead, release, err := ms.coldQueries.acquire(ctx, ms.groupRepo.coldTiers(req.Groups, req.Stages))
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	if coldRead != nil {
+		for _, g := range req.Groups {
+			ms.metrics.totalColdQuery.Inc(1, g, "measure")
+		}
+		defer func() {
+			if err != nil || resp == nil {
+				return
+			}
+			if resp == emptyMeasureQueryResponse {
+				resp = &measurev1.QueryResponse{DataPoints: make([]*measurev1.DataPoint, 0)}
+			}
+			resp.ColdRead = coldRead
+		}()
+	}
 	now := time.Now()
 	if req.Trace {
 		tracer, _ := query.NewTracer(ctx, now.Format(time.RFC3339Nano))
@@ -323,9 +452,10 @@ func (ms *measureService) Query(ctx context.Context, req *measurev1.QueryRequest
 	data := msg.Data()
 	switch d := data.(type) {
 	case *measurev1.QueryResponse:
+		d.SeriesOverflow = overflowed(d.SeriesOverflow)
 		return d, nil
 	case *common.Error:
-		return nil, errors.WithMessage(errQueryMsg, d.Error())
+		return nil, measureQueryError(d)
 	}
 	return nil, nil
 }
@@ -361,21 +491,117 @@ func (ms *measureService) TopN(ctx context.Context, topNRequest *measurev1.TopNR
 	data := msg.Data()
 	switch d := data.(type) {
 	case *measurev1.TopNResponse:
+		d.SeriesOverflow = overflowed(d.SeriesOverflow)
 		return d, nil
 	case *common.Error:
-		return nil, errors.WithMessage(errQueryMsg, d.Error())
+		return nil, measureQueryError(d)
 	}
 	return nil, nil
 }
 
+// overflowed returns the series counted by the data nodes if they sample the series, or nil if they read all of them.
+func overflowed(o *measurev1.SeriesOverflow) *measurev1.SeriesOverflow {
+	if o == nil || o.Sampled >= o.Total {
+		return nil
+	}
+	return o
+}
+
+// measureQueryError converts the error answered by the query service, which tells the clients the queries
+// the data nodes don't admit.
+func measureQueryError(ce *common.Error) error {
+	if ce.Status() == modelv1.Status_STATUS_QUERY_NOT_ADMITTED {
+		return grpchelper.NewError(codes.ResourceExhausted, ce.Error()).WithReason(grpchelper.ReasonQueryNotAdmitted)
+	}
+	return errors.WithMessage(errQueryMsg, ce.Error())
+}
+
+// DeleteSeries tombstones the series of a measure on all the data nodes.
+// The data points of the series written before the deletion are dropped by the queries and the merges.
+func (ms *measureService) DeleteSeries(ctx context.Context, req *measurev1.DeleteSeriesRequest) (*measurev1.DeleteSeriesResponse, error) {
+	if req.GetGroup() == "" {
+		return nil, status.Error(codes.InvalidArgument, "group is required")
+	}
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "name is required")
+	}
+	if len(req.GetEntities()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "entities are required")
+	}
+	if ms.groupRepo.archived(req.GetGroup()) {
+		return nil, grpchelper.NewError(codes.FailedPrecondition, fmt.Sprintf("group %s is read-only", req.GetGroup())).
+			WithReason(grpchelper.ReasonGroupReadOnly).WithResource(grpchelper.ResourceGroup, req.GetGroup())
+	}
+	md := &commonv1.Metadata{Group: req.GetGroup(), Name: req.GetName()}
+	m, ok := ms.entityRepo.loadMeasure(md)
+	if !ok {
+		return nil, grpchelper.NewError(codes.NotFound, fmt.Sprintf("measure %s not found", md)).
+			WithReason(grpchelper.ReasonSchemaNotFound)
+	}
+	if m.GetIndexMode() {
+		return nil, status.Errorf(codes.InvalidArgument, "the series of the index mode measure %s can't be deleted", md)
+	}
+	seriesIDs := make([]uint64, 0, len(req.GetEntities()))
+	for _, e := range req.GetEntities() {
+		sid, err := entitySeriesID(m, e)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		seriesIDs = append(seriesIDs, sid)
+	}
+	now := time.Now()
+	deletedAt := timestamppb.New(now)
+	feat, err := ms.broadcaster.Publish(ctx, data.TopicMeasureDeleteSeries, bus.NewMessage(bus.MessageID(now.UnixNano()),
+		&measurev1.InternalDeleteSeriesRequest{Group: req.GetGroup(), SeriesIds: seriesIDs, DeletedAt: deletedAt}))
+	if err != nil {
+		return nil, err
+	}
+	msg, err := feat.Get()
+	if err != nil {
+		return nil, err
+	}
+	if e, ok := msg.Data().(*common.Error); ok {
+		return nil, status.Error(codes.Internal, e.Error())
+	}
+	ms.l.Info().Str("group", req.GetGroup()).Str("name", req.GetName()).Int("series", len(seriesIDs)).Msg("deleted the series")
+	return &measurev1.DeleteSeriesResponse{DeletedAt: deletedAt}, nil
+}
+
+// entitySeriesID computes the series ID of the entity in the order of the entity tags of the measure.
+func entitySeriesID(m *databasev1.Measure, e *measurev1.DeleteSeriesRequest_Entity) (uint64, error) {
+	tagNames := m.GetEntity().GetTagNames()
+	values := make(pbv1.EntityValues, len(tagNames))
+	for _, t := range e.GetTags() {
+		i := slices.Index(tagNames, t.GetKey())
+		if i < 0 {
+			return 0, fmt.Errorf("tag %s is not an entity tag of %s", t.GetKey(), m.GetMetadata().GetName())
+		}
+		values[i] = t.GetValue()
+	}
+	for i := range values {
+		if values[i] == nil {
+			return 0, fmt.Errorf("entity tag %s is missing", tagNames[i])
+		}
+	}
+	series := &pbv1.Series{Subject: m.GetMetadata().GetName(), EntityValues: values}
+	if err := series.Marshal(); err != nil {
+		return 0, err
+	}
+	return uint64(series.ID), nil
+}
+
 func (ms *measureService) Close() error {
 	return ms.ingestionAccessLog.Close()
 }
 
 type succeedSentMessage struct {
-	metadata  *commonv1.Metadata
-	nodes     []string
-	messageID uint64
+	metadata    *commonv1.Metadata
+	routingHint *modelv1.RoutingHint
+	elementID   string
+	reason      string
+	nodes       []string
+	batches     []*shardBatch
+	messageID   uint64
 }
 
 type measureRedirectWriteCallback struct {
@@ -453,6 +679,12 @@ func (r *measureRedirectWriteCallback) Rev(ctx context.Context, message bus.Mess
 				r.l.Error().Err(err).Str("group", group).Str("measure", measureName).Uint32("shard", shardID).Uint32("copy", copyIdx).Msg("failed to locate node")
 				continue
 			}
+			if copyIdx == 0 {
+				// the first copies of the upper half of the series of a split shard go to the split node.
+				if splitNodeID, split := r.groupRepo.splitNode(r.nodeRegistry, group, shardID); split && upperHalf(measureName, writeEvent.GetEntityValues()) {
+					nodeID = splitNodeID
+				}
+			}
 
 			msg := bus.NewBatchMessageWithNode(bus.MessageID(time.Now().UnixNano()), nodeID, writeEvent)
 			if _, err := publisher.Publish(ctx, data.TopicMeasureWrite, msg); err != nil {
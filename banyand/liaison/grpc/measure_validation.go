@@ -0,0 +1,121 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"fmt"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+)
+
+func (s *groupRepo) strictWriteValidation(groupName string) bool {
+	s.RWMutex.RLock()
+	defer s.RWMutex.RUnlock()
+	r, ok := s.resourceOpts[groupName]
+	if !ok {
+		return false
+	}
+	return r.StrictWriteValidation
+}
+
+// validateDataPoint checks the data point against the measure schema in the strict write validation.
+// The error locates the invalid tag by the indexes of its family and itself, or the invalid field by its index.
+func validateDataPoint(m *databasev1.Measure, dp *measurev1.DataPointValue) error {
+	if len(dp.GetTagFamilies()) > len(m.GetTagFamilies()) {
+		return fmt.Errorf("tag family %d is unknown, the measure has %d tag families", len(m.GetTagFamilies()), len(m.GetTagFamilies()))
+	}
+	entities := make(map[string]struct{}, len(m.GetEntity().GetTagNames()))
+	for _, name := range m.GetEntity().GetTagNames() {
+		entities[name] = struct{}{}
+	}
+	for i, spec := range m.GetTagFamilies() {
+		var tags []*modelv1.TagValue
+		if i < len(dp.GetTagFamilies()) {
+			tags = dp.GetTagFamilies()[i].GetTags()
+		}
+		if len(tags) > len(spec.GetTags()) {
+			return fmt.Errorf("tag family %d (%s) tag %d is unknown, the family has %d tags",
+				i, spec.GetName(), len(spec.GetTags()), len(spec.GetTags()))
+		}
+		for j, tagSpec := range spec.GetTags() {
+			var v *modelv1.TagValue
+			if j < len(tags) {
+				v = tags[j]
+			}
+			if _, isNull := v.GetValue().(*modelv1.TagValue_Null); isNull || v.GetValue() == nil {
+				if _, ok := entities[tagSpec.GetName()]; ok {
+					return fmt.Errorf("tag family %d (%s) tag %d (%s) is an entity tag, which is missing",
+						i, spec.GetName(), j, tagSpec.GetName())
+				}
+				continue
+			}
+			if !tagValueMatches(tagSpec.GetType(), v) {
+				return fmt.Errorf("tag family %d (%s) tag %d (%s) is %T, which doesn't match the type %s",
+					i, spec.GetName(), j, tagSpec.GetName(), v.GetValue(), tagSpec.GetType())
+			}
+		}
+	}
+	if len(dp.GetFields()) > len(m.GetFields()) {
+		return fmt.Errorf("field %d is unknown, the measure has %d fields", len(m.GetFields()), len(m.GetFields()))
+	}
+	for i, v := range dp.GetFields() {
+		if _, isNull := v.GetValue().(*modelv1.FieldValue_Null); isNull || v.GetValue() == nil {
+			continue
+		}
+		if spec := m.GetFields()[i]; !fieldValueMatches(spec.GetFieldType(), v) {
+			return fmt.Errorf("field %d (%s) is %T, which doesn't match the type %s", i, spec.GetName(), v.GetValue(), spec.GetFieldType())
+		}
+	}
+	return nil
+}
+
+func tagValueMatches(t databasev1.TagType, v *modelv1.TagValue) bool {
+	switch v.GetValue().(type) {
+	case *modelv1.TagValue_Str:
+		return t == databasev1.TagType_TAG_TYPE_STRING
+	case *modelv1.TagValue_Int:
+		return t == databasev1.TagType_TAG_TYPE_INT
+	case *modelv1.TagValue_StrArray:
+		return t == databasev1.TagType_TAG_TYPE_STRING_ARRAY
+	case *modelv1.TagValue_IntArray:
+		return t == databasev1.TagType_TAG_TYPE_INT_ARRAY
+	case *modelv1.TagValue_BinaryData:
+		return t == databasev1.TagType_TAG_TYPE_DATA_BINARY
+	case *modelv1.TagValue_Timestamp:
+		return t == databasev1.TagType_TAG_TYPE_TIMESTAMP
+	default:
+		return false
+	}
+}
+
+func fieldValueMatches(t databasev1.FieldType, v *modelv1.FieldValue) bool {
+	switch v.GetValue().(type) {
+	case *modelv1.FieldValue_Str:
+		return t == databasev1.FieldType_FIELD_TYPE_STRING
+	case *modelv1.FieldValue_Int:
+		return t == databasev1.FieldType_FIELD_TYPE_INT
+	case *modelv1.FieldValue_BinaryData:
+		return t == databasev1.FieldType_FIELD_TYPE_DATA_BINARY
+	case *modelv1.FieldValue_Float:
+		return t == databasev1.FieldType_FIELD_TYPE_FLOAT
+	default:
+		return false
+	}
+}
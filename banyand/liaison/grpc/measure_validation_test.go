@@ -0,0 +1,118 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+)
+
+func TestValidateDataPoint(t *testing.T) {
+	m := &databasev1.Measure{
+		TagFamilies: []*databasev1.TagFamilySpec{{
+			Name: "default",
+			Tags: []*databasev1.TagSpec{
+				{Name: "id", Type: databasev1.TagType_TAG_TYPE_STRING},
+				{Name: "status", Type: databasev1.TagType_TAG_TYPE_INT},
+			},
+		}},
+		Fields: []*databasev1.FieldSpec{{Name: "total", FieldType: databasev1.FieldType_FIELD_TYPE_INT}},
+		Entity: &databasev1.Entity{TagNames: []string{"id"}},
+	}
+	str := &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: "svc"}}}
+	num := &modelv1.TagValue{Value: &modelv1.TagValue_Int{Int: &modelv1.Int{Value: 200}}}
+	field := &modelv1.FieldValue{Value: &modelv1.FieldValue_Int{Int: &modelv1.Int{Value: 1}}}
+	dataPoint := func(fields []*modelv1.FieldValue, tags ...*modelv1.TagValue) *measurev1.DataPointValue {
+		return &measurev1.DataPointValue{
+			TagFamilies: []*modelv1.TagFamilyForWrite{{Tags: tags}},
+			Fields:      fields,
+		}
+	}
+
+	require.NoError(t, validateDataPoint(m, dataPoint([]*modelv1.FieldValue{field}, str, num)))
+	require.NoError(t, validateDataPoint(m, dataPoint(nil, str)), "the absent tags and fields are null")
+	require.NoError(t, validateDataPoint(m, dataPoint(nil, str, pbv1.NullTagValue)))
+
+	tests := []struct {
+		dp   *measurev1.DataPointValue
+		name string
+		want string
+	}{
+		{
+			name: "unknown tag family",
+			dp: &measurev1.DataPointValue{TagFamilies: []*modelv1.TagFamilyForWrite{
+				{Tags: []*modelv1.TagValue{str}}, {Tags: []*modelv1.TagValue{str}},
+			}},
+			want: "tag family 1 is unknown",
+		},
+		{
+			name: "unknown tag",
+			dp:   dataPoint(nil, str, num, num),
+			want: "tag family 0 (default) tag 2 is unknown",
+		},
+		{
+			name: "wrong tag type",
+			dp:   dataPoint(nil, str, str),
+			want: "tag family 0 (default) tag 1 (status)",
+		},
+		{
+			name: "missing entity tag",
+			dp:   dataPoint(nil, pbv1.NullTagValue, num),
+			want: "tag family 0 (default) tag 0 (id) is an entity tag",
+		},
+		{
+			name: "missing tag family of the entity",
+			dp:   &measurev1.DataPointValue{},
+			want: "tag family 0 (default) tag 0 (id) is an entity tag",
+		},
+		{
+			name: "unknown field",
+			dp:   dataPoint([]*modelv1.FieldValue{field, field}, str),
+			want: "field 1 is unknown",
+		},
+		{
+			name: "wrong field type",
+			dp:   dataPoint([]*modelv1.FieldValue{{Value: &modelv1.FieldValue_Float{Float: &modelv1.Float{Value: 1}}}}, str),
+			want: "field 0 (total)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateDataPoint(m, tt.dp)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestMeasureQueryRejectsRewrite(t *testing.T) {
+	// the internal rewrite of the federated queries isn't accepted from the clients, even without the TopN
+	ms := &measureService{}
+	_, err := ms.Query(context.Background(), &measurev1.QueryRequest{Groups: []string{"sw"}, Name: "service_cpm", RewriteAggTopNResult: true})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
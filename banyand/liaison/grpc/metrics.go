@@ -43,6 +43,13 @@ type metrics struct {
 	totalRegistryFinished meter.Counter
 	totalRegistryErr      meter.Counter
 	totalRegistryLatency  meter.Counter
+
+	totalWriteRateAnomaly   meter.Counter
+	totalEntityRegistration meter.Counter
+	totalHotShard           meter.Counter
+	totalOutOfOrder         meter.Counter
+	totalTagTypeMismatch    meter.Counter
+	totalColdQuery          meter.Counter
 }
 
 func newMetrics(factory *observability.Factory) *metrics {
@@ -64,5 +71,11 @@ func newMetrics(factory *observability.Factory) *metrics {
 		totalRegistryFinished:     factory.NewCounter("total_registry_finished", "group", "service", "method"),
 		totalRegistryErr:          factory.NewCounter("total_registry_err", "group", "service", "method"),
 		totalRegistryLatency:      factory.NewCounter("total_registry_latency", "group", "service", "method"),
+		totalWriteRateAnomaly:     factory.NewCounter("total_write_rate_anomaly", "group", "scope", "kind"),
+		totalEntityRegistration:   factory.NewCounter("total_entity_registration", "group", "result"),
+		totalHotShard:             factory.NewCounter("total_hot_shard", "group", "shard", "kind"),
+		totalOutOfOrder:           factory.NewCounter("total_out_of_order", "group", "policy"),
+		totalTagTypeMismatch:      factory.NewCounter("total_tag_type_mismatch", "group", "policy"),
+		totalColdQuery:            factory.NewCounter("total_cold_query", "group", "service"),
 	}
 }
@@ -19,6 +19,7 @@ package grpc
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 
 	"github.com/pkg/errors"
@@ -27,6 +28,7 @@ import (
 	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
 	"github.com/apache/skywalking-banyandb/banyand/queue"
 	"github.com/apache/skywalking-banyandb/pkg/bus"
+	"github.com/apache/skywalking-banyandb/pkg/convert"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
 	"github.com/apache/skywalking-banyandb/pkg/node"
 )
@@ -40,25 +42,35 @@ var (
 // together with the shardID calculated from the incoming data.
 type NodeRegistry interface {
 	Locate(group, name string, shardID, replicaID uint32) (string, error)
+	// Address returns the gRPC address of the node, which the clients could connect to directly.
+	Address(nodeID string) (string, bool)
+	// Nodes returns the sorted IDs of the nodes in the registry.
+	Nodes() []string
+	// Epoch identifies the nodes in the registry. It changes once a node joins, leaves or moves.
+	Epoch() uint64
 	fmt.Stringer
 }
 
 type clusterNodeService struct {
 	schema.UnimplementedOnInitHandler
-	pipeline queue.Client
-	sel      node.Selector
-	l        *logger.Logger
-	topic    bus.Topic
+	pipeline  queue.Client
+	sel       node.Selector
+	l         *logger.Logger
+	addresses map[string]string
+	topic     bus.Topic
+	epoch     uint64
 	sync.Once
+	mu sync.RWMutex
 }
 
 // NewClusterNodeRegistry creates a cluster node registry.
 func NewClusterNodeRegistry(topic bus.Topic, pipeline queue.Client, selector node.Selector) NodeRegistry {
 	nr := &clusterNodeService{
-		pipeline: pipeline,
-		sel:      selector,
-		topic:    topic,
-		l:        logger.GetLogger("cluster-node-registry-" + topic.String()),
+		pipeline:  pipeline,
+		sel:       selector,
+		topic:     topic,
+		addresses: make(map[string]string),
+		l:         logger.GetLogger("cluster-node-registry-" + topic.String()),
 	}
 	nr.Do(func() {
 		nr.pipeline.Register(nr.topic, nr)
@@ -82,6 +94,7 @@ func (n *clusterNodeService) OnAddOrUpdate(metadata schema.Metadata) {
 			return
 		}
 		n.sel.AddNode(inputNode)
+		n.setAddress(inputNode.Metadata.GetName(), inputNode.GetGrpcAddress())
 	default:
 	}
 }
@@ -94,10 +107,76 @@ func (n *clusterNodeService) OnDelete(metadata schema.Metadata) {
 			return
 		}
 		n.sel.RemoveNode(dNode)
+		n.removeAddress(dNode.Metadata.GetName())
 	default:
 	}
 }
 
+func (n *clusterNodeService) Address(nodeID string) (string, bool) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	addr, ok := n.addresses[nodeID]
+	return addr, ok && addr != ""
+}
+
+func (n *clusterNodeService) Nodes() []string {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	ids := make([]string, 0, len(n.addresses))
+	for id := range n.addresses {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (n *clusterNodeService) Epoch() uint64 {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.epoch
+}
+
+func (n *clusterNodeService) setAddress(nodeID, addr string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if old, ok := n.addresses[nodeID]; ok && old == addr {
+		return
+	}
+	n.addresses[nodeID] = addr
+	n.epoch = nodesEpoch(n.addresses)
+}
+
+func (n *clusterNodeService) removeAddress(nodeID string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if _, ok := n.addresses[nodeID]; !ok {
+		return
+	}
+	delete(n.addresses, nodeID)
+	n.epoch = nodesEpoch(n.addresses)
+}
+
+// nodesEpoch hashes the sorted nodes and their addresses.
+// The liaisons that know the same nodes get the same epoch.
+func nodesEpoch(addresses map[string]string) uint64 {
+	if len(addresses) == 0 {
+		return 0
+	}
+	ids := make([]string, 0, len(addresses))
+	for id := range addresses {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	var buf []byte
+	for _, id := range ids {
+		buf = append(buf, id...)
+		buf = append(buf, '=')
+		buf = append(buf, addresses[id]...)
+		buf = append(buf, ';')
+	}
+	return convert.Hash(buf)
+}
+
 func (n *clusterNodeService) String() string {
 	return n.sel.String()
 }
@@ -117,3 +196,18 @@ func NewLocalNodeRegistry() NodeRegistry {
 func (localNodeService) Locate(_, _ string, _, _ uint32) (string, error) {
 	return "local", nil
 }
+
+// Address of localNodeService always returns false because there is no data node to connect.
+func (localNodeService) Address(_ string) (string, bool) {
+	return "", false
+}
+
+// Nodes of localNodeService always returns nil because there is no data node.
+func (localNodeService) Nodes() []string {
+	return nil
+}
+
+// Epoch of localNodeService always returns 0.
+func (localNodeService) Epoch() uint64 {
+	return 0
+}
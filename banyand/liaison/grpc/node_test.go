@@ -59,3 +59,49 @@ func TestClusterNodeRegistry(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, fakeNodeID, nodeID)
 }
+
+func TestClusterNodeRegistryRoutingHint(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	pipeline := queue.NewMockClient(ctrl)
+
+	sel, err := node.NewPickFirstSelector()
+	assert.NoError(t, err)
+
+	pipeline.EXPECT().Register(data.TopicCommon, gomock.Any()).Return().Times(1)
+	nr := NewClusterNodeRegistry(data.TopicCommon, pipeline, sel)
+	cnr := nr.(*clusterNodeService)
+	nodeMetadata := func(name, addr string) schema.Metadata {
+		return schema.Metadata{
+			TypeMeta: schema.TypeMeta{
+				Kind: schema.KindNode,
+				Name: name,
+			},
+			Spec: &databasev1.Node{
+				Metadata:    &commonv1.Metadata{Name: name},
+				GrpcAddress: addr,
+			},
+		}
+	}
+	assert.Equal(t, uint64(0), cnr.Epoch())
+	cnr.OnAddOrUpdate(nodeMetadata("data-node-1", "10.0.0.1:17912"))
+	epoch := cnr.Epoch()
+	assert.NotEqual(t, uint64(0), epoch)
+
+	nodeID, err := cnr.Locate("metrics", "instance_traffic", 1, 0)
+	assert.NoError(t, err)
+	hint := routingHint(cnr, 1, []string{nodeID})
+	assert.NotNil(t, hint)
+	assert.Equal(t, uint32(1), hint.GetShardId())
+	assert.Equal(t, epoch, hint.GetEpoch())
+	assert.Equal(t, []string{"10.0.0.1:17912"}, hint.GetNodes())
+
+	cnr.OnAddOrUpdate(nodeMetadata("data-node-1", "10.0.0.1:17912"))
+	assert.Equal(t, epoch, cnr.Epoch())
+	cnr.OnAddOrUpdate(nodeMetadata("data-node-2", "10.0.0.2:17912"))
+	assert.NotEqual(t, epoch, cnr.Epoch())
+	cnr.OnDelete(nodeMetadata("data-node-2", "10.0.0.2:17912"))
+	assert.Equal(t, epoch, cnr.Epoch())
+
+	assert.Nil(t, routingHint(cnr, 1, []string{"unknown"}))
+	assert.Nil(t, routingHint(NewLocalNodeRegistry(), 1, []string{"local"}))
+}
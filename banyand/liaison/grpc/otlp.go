@@ -0,0 +1,259 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	coltracev1 "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	otlpcommonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	otlptracev1 "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/banyand/metadata"
+	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+)
+
+const (
+	otlpSearchableTagFamily  = "searchable"
+	otlpStorageOnlyTagFamily = "storage_only"
+	otlpServiceNameAttr      = "service.name"
+)
+
+// otlpSearchableTags are the tags of the searchable family in order.
+var otlpSearchableTags = []*databasev1.TagSpec{
+	{Name: "trace_id", Type: databasev1.TagType_TAG_TYPE_STRING},
+	{Name: "span_id", Type: databasev1.TagType_TAG_TYPE_STRING},
+	{Name: "parent_span_id", Type: databasev1.TagType_TAG_TYPE_STRING},
+	{Name: "service_name", Type: databasev1.TagType_TAG_TYPE_STRING},
+	{Name: "span_name", Type: databasev1.TagType_TAG_TYPE_STRING},
+	{Name: "span_kind", Type: databasev1.TagType_TAG_TYPE_STRING},
+	{Name: "latency", Type: databasev1.TagType_TAG_TYPE_INT},
+	{Name: "is_error", Type: databasev1.TagType_TAG_TYPE_INT},
+	{Name: "tags", Type: databasev1.TagType_TAG_TYPE_STRING_ARRAY},
+}
+
+// otlpIndexedTags are the searchable tags with an inverted index.
+var otlpIndexedTags = []string{"trace_id", "service_name", "span_name", "latency", "is_error", "tags"}
+
+// otlpTraceService receives OTLP traces and writes every span as a stream element.
+// The span attributes are flattened into searchable tags, and the whole span
+// including its resource is stored as the binary payload.
+type otlpTraceService struct {
+	coltracev1.UnimplementedTraceServiceServer
+	schemaRegistry metadata.Repo
+	streamSVC      *streamService
+	l              *logger.Logger
+	group          string
+	name           string
+	mu             sync.Mutex
+	prepared       bool
+}
+
+func (o *otlpTraceService) Export(ctx context.Context, req *coltracev1.ExportTraceServiceRequest) (*coltracev1.ExportTraceServiceResponse, error) {
+	if err := o.prepareStream(ctx); err != nil {
+		return nil, status.Errorf(codes.Unavailable, "failed to prepare the stream: %v", err)
+	}
+	md := &commonv1.Metadata{Group: o.group, Name: o.name}
+	requests, err := otlpSpansToElements(md, req.GetResourceSpans(), uint64(time.Now().UnixNano()))
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if len(requests) == 0 {
+		return &coltracev1.ExportTraceServiceResponse{}, nil
+	}
+	rejected, err := o.streamSVC.writeElements(ctx, requests)
+	if err != nil {
+		o.l.Error().Err(err).Msg("failed to write otlp spans")
+	}
+	return otlpExportResponse(len(requests), rejected, err)
+}
+
+// otlpExportResponse returns Unavailable if the spans fail to be sent, which makes the exporters retry them.
+// The spans rejected one by one are reported by the partial success instead.
+func otlpExportResponse(total, rejected int, writeErr error) (*coltracev1.ExportTraceServiceResponse, error) {
+	if writeErr != nil {
+		return nil, status.Errorf(codes.Unavailable, "failed to write the spans: %v", writeErr)
+	}
+	resp := &coltracev1.ExportTraceServiceResponse{}
+	if rejected > 0 {
+		resp.PartialSuccess = &coltracev1.ExportTracePartialSuccess{
+			RejectedSpans: int64(rejected),
+			ErrorMessage:  fmt.Sprintf("%d of %d spans are rejected", rejected, total),
+		}
+	}
+	return resp, nil
+}
+
+// prepareStream creates the stream, the index rules and the binding if they don't exist.
+func (o *otlpTraceService) prepareStream(ctx context.Context) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.prepared {
+		return nil
+	}
+	md := &commonv1.Metadata{Group: o.group, Name: o.name}
+	if _, err := o.schemaRegistry.StreamRegistry().GetStream(ctx, md); err != nil {
+		if !errors.Is(err, schema.ErrGRPCResourceNotFound) {
+			return err
+		}
+		if _, err = o.schemaRegistry.StreamRegistry().CreateStream(ctx, newOTLPStream(md)); err != nil && !errors.Is(err, schema.ErrGRPCAlreadyExists) {
+			return err
+		}
+	}
+	rules := make([]string, 0, len(otlpIndexedTags))
+	for _, t := range otlpIndexedTags {
+		rule := &databasev1.IndexRule{
+			Metadata: &commonv1.Metadata{Group: o.group, Name: o.name + "_" + t},
+			Tags:     []string{t},
+			Type:     databasev1.IndexRule_TYPE_INVERTED,
+		}
+		if err := o.schemaRegistry.IndexRuleRegistry().CreateIndexRule(ctx, rule); err != nil && !errors.Is(err, schema.ErrGRPCAlreadyExists) {
+			return err
+		}
+		rules = append(rules, rule.Metadata.Name)
+	}
+	binding := &databasev1.IndexRuleBinding{
+		Metadata: md,
+		Rules:    rules,
+		Subject:  &databasev1.Subject{Catalog: commonv1.Catalog_CATALOG_STREAM, Name: o.name},
+		BeginAt:  timestamppb.New(time.Unix(0, 0)),
+		ExpireAt: timestamppb.New(time.Date(2121, 1, 1, 0, 0, 0, 0, time.UTC)),
+	}
+	if err := o.schemaRegistry.IndexRuleBindingRegistry().CreateIndexRuleBinding(ctx, binding); err != nil && !errors.Is(err, schema.ErrGRPCAlreadyExists) {
+		return err
+	}
+	o.prepared = true
+	return nil
+}
+
+func newOTLPStream(md *commonv1.Metadata) *databasev1.Stream {
+	return &databasev1.Stream{
+		Metadata: md,
+		TagFamilies: []*databasev1.TagFamilySpec{
+			{Name: otlpSearchableTagFamily, Tags: otlpSearchableTags},
+			{Name: otlpStorageOnlyTagFamily, Tags: []*databasev1.TagSpec{{Name: "data_binary", Type: databasev1.TagType_TAG_TYPE_DATA_BINARY}}},
+		},
+		Entity: &databasev1.Entity{TagNames: []string{"service_name"}},
+	}
+}
+
+func otlpSpansToElements(md *commonv1.Metadata, resourceSpans []*otlptracev1.ResourceSpans, messageID uint64) ([]*streamv1.WriteRequest, error) {
+	var requests []*streamv1.WriteRequest
+	for _, rs := range resourceSpans {
+		serviceName := ""
+		for _, kv := range rs.GetResource().GetAttributes() {
+			if kv.GetKey() == otlpServiceNameAttr {
+				serviceName = otlpValueString(kv.GetValue())
+				break
+			}
+		}
+		for _, ss := range rs.GetScopeSpans() {
+			for _, span := range ss.GetSpans() {
+				if len(span.GetTraceId()) == 0 || len(span.GetSpanId()) == 0 {
+					return nil, errors.New("span without trace id or span id")
+				}
+				payload, err := proto.Marshal(&otlptracev1.ResourceSpans{
+					Resource:   rs.GetResource(),
+					SchemaUrl:  rs.GetSchemaUrl(),
+					ScopeSpans: []*otlptracev1.ScopeSpans{{Scope: ss.GetScope(), SchemaUrl: ss.GetSchemaUrl(), Spans: []*otlptracev1.Span{span}}},
+				})
+				if err != nil {
+					return nil, err
+				}
+				messageID++
+				requests = append(requests, &streamv1.WriteRequest{
+					Metadata:  md,
+					MessageId: messageID,
+					Element: &streamv1.ElementValue{
+						ElementId: hex.EncodeToString(span.GetTraceId()) + hex.EncodeToString(span.GetSpanId()),
+						Timestamp: timestamppb.New(time.Unix(0, int64(span.GetStartTimeUnixNano()))),
+						TagFamilies: []*modelv1.TagFamilyForWrite{
+							{Tags: otlpSearchableValues(serviceName, span)},
+							{Tags: []*modelv1.TagValue{{Value: &modelv1.TagValue_BinaryData{BinaryData: payload}}}},
+						},
+					},
+				})
+			}
+		}
+	}
+	return requests, nil
+}
+
+func otlpSearchableValues(serviceName string, span *otlptracev1.Span) []*modelv1.TagValue {
+	var latency int64
+	if span.GetEndTimeUnixNano() > span.GetStartTimeUnixNano() {
+		latency = time.Duration(span.GetEndTimeUnixNano() - span.GetStartTimeUnixNano()).Milliseconds()
+	}
+	var isError int64
+	if span.GetStatus().GetCode() == otlptracev1.Status_STATUS_CODE_ERROR {
+		isError = 1
+	}
+	tags := make([]string, 0, len(span.GetAttributes()))
+	for _, kv := range span.GetAttributes() {
+		tags = append(tags, kv.GetKey()+"="+otlpValueString(kv.GetValue()))
+	}
+	return []*modelv1.TagValue{
+		strTagValue(hex.EncodeToString(span.GetTraceId())),
+		strTagValue(hex.EncodeToString(span.GetSpanId())),
+		strTagValue(hex.EncodeToString(span.GetParentSpanId())),
+		strTagValue(serviceName),
+		strTagValue(span.GetName()),
+		strTagValue(span.GetKind().String()),
+		{Value: &modelv1.TagValue_Int{Int: &modelv1.Int{Value: latency}}},
+		{Value: &modelv1.TagValue_Int{Int: &modelv1.Int{Value: isError}}},
+		{Value: &modelv1.TagValue_StrArray{StrArray: &modelv1.StrArray{Value: tags}}},
+	}
+}
+
+func strTagValue(v string) *modelv1.TagValue {
+	return &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: v}}}
+}
+
+func otlpValueString(v *otlpcommonv1.AnyValue) string {
+	switch x := v.GetValue().(type) {
+	case *otlpcommonv1.AnyValue_StringValue:
+		return x.StringValue
+	case *otlpcommonv1.AnyValue_BoolValue:
+		return strconv.FormatBool(x.BoolValue)
+	case *otlpcommonv1.AnyValue_IntValue:
+		return strconv.FormatInt(x.IntValue, 10)
+	case *otlpcommonv1.AnyValue_DoubleValue:
+		return strconv.FormatFloat(x.DoubleValue, 'g', -1, 64)
+	case *otlpcommonv1.AnyValue_BytesValue:
+		return hex.EncodeToString(x.BytesValue)
+	case nil:
+		return ""
+	default:
+		return v.String()
+	}
+}
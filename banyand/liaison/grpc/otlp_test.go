@@ -0,0 +1,107 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	otlpcommonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	otlpresourcev1 "go.opentelemetry.io/proto/otlp/resource/v1"
+	otlptracev1 "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+)
+
+func strAttr(k, v string) *otlpcommonv1.KeyValue {
+	return &otlpcommonv1.KeyValue{Key: k, Value: &otlpcommonv1.AnyValue{Value: &otlpcommonv1.AnyValue_StringValue{StringValue: v}}}
+}
+
+func TestOTLPSpansToElements(t *testing.T) {
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	span := &otlptracev1.Span{
+		TraceId:           []byte{0x01, 0x02},
+		SpanId:            []byte{0x0a},
+		Name:              "GET /users",
+		Kind:              otlptracev1.Span_SPAN_KIND_SERVER,
+		StartTimeUnixNano: uint64(start.UnixNano()),
+		EndTimeUnixNano:   uint64(start.Add(150 * time.Millisecond).UnixNano()),
+		Attributes: []*otlpcommonv1.KeyValue{
+			strAttr("http.method", "GET"),
+			{Key: "http.status_code", Value: &otlpcommonv1.AnyValue{Value: &otlpcommonv1.AnyValue_IntValue{IntValue: 500}}},
+		},
+		Status: &otlptracev1.Status{Code: otlptracev1.Status_STATUS_CODE_ERROR},
+	}
+	rs := []*otlptracev1.ResourceSpans{{
+		Resource:   &otlpresourcev1.Resource{Attributes: []*otlpcommonv1.KeyValue{strAttr(otlpServiceNameAttr, "user-svc")}},
+		ScopeSpans: []*otlptracev1.ScopeSpans{{Spans: []*otlptracev1.Span{span}}},
+	}}
+	md := &commonv1.Metadata{Group: "otlp", Name: "otlp_span"}
+
+	requests, err := otlpSpansToElements(md, rs, 100)
+	require.NoError(t, err)
+	require.Len(t, requests, 1)
+	req := requests[0]
+	assert.Equal(t, uint64(101), req.MessageId)
+	assert.Equal(t, "01020a", req.Element.ElementId)
+	assert.True(t, start.Equal(req.Element.Timestamp.AsTime()))
+
+	searchable := req.Element.TagFamilies[0].Tags
+	require.Len(t, searchable, len(otlpSearchableTags))
+	assert.Equal(t, "0102", searchable[0].GetStr().GetValue())
+	assert.Equal(t, "0a", searchable[1].GetStr().GetValue())
+	assert.Equal(t, "", searchable[2].GetStr().GetValue())
+	assert.Equal(t, "user-svc", searchable[3].GetStr().GetValue())
+	assert.Equal(t, "GET /users", searchable[4].GetStr().GetValue())
+	assert.Equal(t, "SPAN_KIND_SERVER", searchable[5].GetStr().GetValue())
+	assert.Equal(t, int64(150), searchable[6].GetInt().GetValue())
+	assert.Equal(t, int64(1), searchable[7].GetInt().GetValue())
+	assert.Equal(t, []string{"http.method=GET", "http.status_code=500"}, searchable[8].GetStrArray().GetValue())
+
+	payload := &otlptracev1.ResourceSpans{}
+	require.NoError(t, proto.Unmarshal(req.Element.TagFamilies[1].Tags[0].GetBinaryData(), payload))
+	assert.True(t, proto.Equal(span, payload.ScopeSpans[0].Spans[0]))
+	assert.Equal(t, "user-svc", payload.Resource.Attributes[0].Value.GetStringValue())
+}
+
+func TestOTLPSpansWithoutID(t *testing.T) {
+	rs := []*otlptracev1.ResourceSpans{{
+		ScopeSpans: []*otlptracev1.ScopeSpans{{Spans: []*otlptracev1.Span{{Name: "no-id"}}}},
+	}}
+	_, err := otlpSpansToElements(&commonv1.Metadata{Group: "otlp", Name: "otlp_span"}, rs, 0)
+	assert.Error(t, err)
+}
+
+func TestOTLPExportResponse(t *testing.T) {
+	resp, err := otlpExportResponse(3, 0, nil)
+	require.NoError(t, err)
+	assert.Nil(t, resp.PartialSuccess)
+
+	resp, err = otlpExportResponse(3, 1, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), resp.PartialSuccess.RejectedSpans)
+
+	_, err = otlpExportResponse(3, 0, errors.New("the data nodes are unavailable"))
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
@@ -0,0 +1,77 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
+	"github.com/apache/skywalking-banyandb/pkg/node"
+)
+
+const placementTimeout = 5 * time.Second
+
+// shardPlacement places the shards of the groups on the data nodes by the node registries of their catalogs.
+type shardPlacement struct {
+	groups     schema.Group
+	registries map[commonv1.Catalog]NodeRegistry
+}
+
+func (sp *shardPlacement) place() []node.ShardPlacement {
+	ctx, cancel := context.WithTimeout(context.Background(), placementTimeout)
+	defer cancel()
+	gg, err := sp.groups.ListGroup(ctx)
+	if err != nil {
+		return []node.ShardPlacement{{Error: err.Error()}}
+	}
+	sort.Slice(gg, func(i, j int) bool { return gg[i].GetMetadata().GetName() < gg[j].GetMetadata().GetName() })
+	result := make([]node.ShardPlacement, 0)
+	for _, g := range gg {
+		nr, ok := sp.registries[g.GetCatalog()]
+		opts := g.GetResourceOpts()
+		if !ok || opts == nil {
+			continue
+		}
+		name := g.GetMetadata().GetName()
+		for shardID := uint32(0); shardID < opts.GetShardNum(); shardID++ {
+			p := node.ShardPlacement{
+				Group:   name,
+				Catalog: g.GetCatalog().String(),
+				ShardID: shardID,
+				Nodes:   make([]string, 0, opts.GetReplicas()+1),
+			}
+			for replicaID := uint32(0); replicaID <= opts.GetReplicas(); replicaID++ {
+				nodeID, errLocate := nr.Locate(name, "", shardID, replicaID)
+				if errLocate != nil && p.Error == "" {
+					p.Error = errLocate.Error()
+				}
+				p.Nodes = append(p.Nodes, nodeID)
+			}
+			for _, ss := range opts.GetShardSplits() {
+				if ss.GetShardId() == shardID {
+					p.SplitNode = ss.GetNode()
+				}
+			}
+			result = append(result, p)
+		}
+	}
+	return result
+}
@@ -0,0 +1,68 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
+	"github.com/apache/skywalking-banyandb/pkg/node"
+)
+
+func TestShardPlacement(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	groups := schema.NewMockGroup(ctrl)
+	sp := &shardPlacement{
+		groups: groups,
+		registries: map[commonv1.Catalog]NodeRegistry{
+			commonv1.Catalog_CATALOG_MEASURE: fakeNodeRegistry{nodes: []string{"n0", "n1", "n2"}},
+		},
+	}
+	groups.EXPECT().ListGroup(gomock.Any()).Return([]*commonv1.Group{
+		{
+			Metadata: &commonv1.Metadata{Name: "sw_metric"},
+			Catalog:  commonv1.Catalog_CATALOG_MEASURE,
+			ResourceOpts: &commonv1.ResourceOpts{
+				ShardNum: 2, Replicas: 1,
+				ShardSplits: []*commonv1.ShardSplit{{ShardId: 1, Node: "n0"}},
+			},
+		},
+		// the groups without a node registry aren't placed.
+		{
+			Metadata:     &commonv1.Metadata{Name: "sw_stream"},
+			Catalog:      commonv1.Catalog_CATALOG_STREAM,
+			ResourceOpts: &commonv1.ResourceOpts{ShardNum: 1},
+		},
+		{
+			Metadata: &commonv1.Metadata{Name: "_deleted"},
+			Catalog:  commonv1.Catalog_CATALOG_MEASURE,
+		},
+	}, nil)
+	assert.Equal(t, []node.ShardPlacement{
+		{Group: "sw_metric", Catalog: "CATALOG_MEASURE", ShardID: 0, Nodes: []string{"n0", "n1"}},
+		{Group: "sw_metric", Catalog: "CATALOG_MEASURE", ShardID: 1, Nodes: []string{"n1", "n2"}, SplitNode: "n0"},
+	}, sp.place())
+
+	groups.EXPECT().ListGroup(gomock.Any()).Return(nil, errors.New("unavailable"))
+	assert.Equal(t, []node.ShardPlacement{{Error: "unavailable"}}, sp.place())
+}
@@ -37,6 +37,7 @@ package grpc
 import (
 	"context"
 	"math"
+	"slices"
 	"sync"
 	"time"
 
@@ -394,7 +395,15 @@ func (ps *propertyServer) Query(ctx context.Context, req *propertyv1.QueryReques
 		if p.deletedTime > 0 {
 			continue
 		}
-		if len(req.TagProjection) > 0 {
+		properties = append(properties, p.Property)
+	}
+	// the properties are sorted before the projection, which might exclude the tag of the order.
+	slices.SortFunc(properties, func(a, b *propertyv1.Property) int {
+		return propertypkg.CompareProperties(a, b, req.OrderBy)
+	})
+	properties, hasMore := pageProperties(properties, req.Offset, req.Limit)
+	if len(req.TagProjection) > 0 {
+		for _, p := range properties {
 			var tags []*modelv1.Tag
 			for _, tag := range p.Tags {
 				for _, tp := range req.TagProjection {
@@ -406,12 +415,20 @@ func (ps *propertyServer) Query(ctx context.Context, req *propertyv1.QueryReques
 			}
 			p.Tags = tags
 		}
-		properties = append(properties, p.Property)
-		if len(properties) >= int(req.Limit) {
-			break
-		}
 	}
-	return &propertyv1.QueryResponse{Properties: properties, Trace: trace}, nil
+	return &propertyv1.QueryResponse{Properties: properties, Trace: trace, HasMore: hasMore}, nil
+}
+
+// pageProperties returns the sorted properties of the page starting at the offset, and whether there are more after it.
+func pageProperties(properties []*propertyv1.Property, offset, limit uint32) ([]*propertyv1.Property, bool) {
+	if int(offset) >= len(properties) {
+		return nil, false
+	}
+	properties = properties[offset:]
+	if len(properties) > int(limit) {
+		return properties[:limit], true
+	}
+	return properties, false
 }
 
 func (ps *propertyServer) repairPropertyIfNeed(entity string, p *propertyWithCount, groups map[string]*commonv1.Group) error {
@@ -0,0 +1,83 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+)
+
+// defaultTimeRange returns the shortest default time range of the groups, which ends at the current time.
+// It returns nil if none of the groups has a default time range.
+func (s *groupRepo) defaultTimeRange(groups []string) *modelv1.TimeRange {
+	s.RWMutex.RLock()
+	defer s.RWMutex.RUnlock()
+	var d time.Duration
+	for _, g := range groups {
+		ir := s.resourceOpts[g].GetQueryLimits().GetDefaultTimeRange()
+		if ir == nil {
+			continue
+		}
+		if gd := intervalDuration(ir); d == 0 || gd < d {
+			d = gd
+		}
+	}
+	if d == 0 {
+		return nil
+	}
+	end := time.Now().Truncate(time.Millisecond)
+	return &modelv1.TimeRange{
+		Begin: timestamppb.New(end.Add(-d)),
+		End:   timestamppb.New(end),
+	}
+}
+
+// checkQueryLimits rejects the query whose time range or result size exceeds the limits of any group.
+func (s *groupRepo) checkQueryLimits(groups []string, timeRange *modelv1.TimeRange, offset, limit uint32) error {
+	s.RWMutex.RLock()
+	defer s.RWMutex.RUnlock()
+	for _, g := range groups {
+		ql := s.resourceOpts[g].GetQueryLimits()
+		if ql == nil {
+			continue
+		}
+		if ir := ql.GetMaxTimeRange(); ir != nil {
+			maxRange := intervalDuration(ir)
+			if span := timeRange.GetEnd().AsTime().Sub(timeRange.GetBegin().AsTime()); span > maxRange {
+				return errors.Errorf("the time range %s exceeds the max time range %s of group %s", span, maxRange, g)
+			}
+		}
+		if ql.MaxResultSize > 0 && limit > 0 && offset+limit > ql.MaxResultSize {
+			return errors.Errorf("the offset %d plus the limit %d exceeds the max result size %d of group %s", offset, limit, ql.MaxResultSize, g)
+		}
+	}
+	return nil
+}
+
+func intervalDuration(ir *commonv1.IntervalRule) time.Duration {
+	d := time.Duration(ir.GetNum()) * time.Hour
+	if ir.GetUnit() == commonv1.IntervalRule_UNIT_DAY {
+		d *= 24
+	}
+	return d
+}
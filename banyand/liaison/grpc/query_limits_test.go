@@ -0,0 +1,62 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+)
+
+func TestGroupRepo_QueryLimits(t *testing.T) {
+	gr := &groupRepo{resourceOpts: map[string]*commonv1.ResourceOpts{
+		"unbounded": {ShardNum: 1},
+		"hourly": {ShardNum: 1, QueryLimits: &commonv1.QueryLimits{
+			DefaultTimeRange: &commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_HOUR, Num: 2},
+			MaxTimeRange:     &commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_DAY, Num: 1},
+			MaxResultSize:    100,
+		}},
+		"daily": {ShardNum: 1, QueryLimits: &commonv1.QueryLimits{
+			DefaultTimeRange: &commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_DAY, Num: 1},
+		}},
+	}}
+
+	assert.Nil(t, gr.defaultTimeRange([]string{"unbounded"}))
+	tr := gr.defaultTimeRange([]string{"unbounded", "daily", "hourly"})
+	require.NotNil(t, tr)
+	assert.Equal(t, 2*time.Hour, tr.End.AsTime().Sub(tr.Begin.AsTime()))
+	assert.WithinDuration(t, time.Now(), tr.End.AsTime(), time.Minute)
+
+	end := time.Now()
+	newTimeRange := func(d time.Duration) *modelv1.TimeRange {
+		return &modelv1.TimeRange{Begin: timestamppb.New(end.Add(-d)), End: timestamppb.New(end)}
+	}
+	assert.NoError(t, gr.checkQueryLimits([]string{"unbounded"}, newTimeRange(30*24*time.Hour), 0, 1000))
+	assert.NoError(t, gr.checkQueryLimits([]string{"unbounded", "hourly"}, newTimeRange(24*time.Hour), 20, 80))
+	assert.NoError(t, gr.checkQueryLimits([]string{"hourly"}, newTimeRange(time.Hour), 0, 0))
+	assert.ErrorContains(t, gr.checkQueryLimits([]string{"unbounded", "hourly"}, newTimeRange(25*time.Hour), 0, 10),
+		"exceeds the max time range 24h0m0s of group hourly")
+	assert.ErrorContains(t, gr.checkQueryLimits([]string{"hourly"}, newTimeRange(time.Hour), 20, 81),
+		"exceeds the max result size 100 of group hourly")
+}
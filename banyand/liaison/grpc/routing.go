@@ -0,0 +1,43 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+)
+
+// routingHint returns the shard and the addresses of the nodes which the data is published to.
+// It returns nil if any node can't be reached by the clients directly, for example, in the standalone mode.
+func routingHint(nr NodeRegistry, shardID uint32, nodes []string) *modelv1.RoutingHint {
+	if len(nodes) == 0 {
+		return nil
+	}
+	addresses := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		addr, ok := nr.Address(n)
+		if !ok {
+			return nil
+		}
+		addresses = append(addresses, addr)
+	}
+	return &modelv1.RoutingHint{
+		ShardId: shardID,
+		Epoch:   nr.Epoch(),
+		Nodes:   addresses,
+	}
+}
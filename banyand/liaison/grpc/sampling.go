@@ -0,0 +1,96 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/banyand/liaison/sampling"
+)
+
+const redactedValue = "***"
+
+// sampleStreamWrite captures the raw request if its group is sampled.
+func (s *streamService) sampleStreamWrite(writeEntity *streamv1.WriteRequest) {
+	sp := sampling.Lookup(writeEntity.GetMetadata().GetGroup())
+	if sp == nil {
+		return
+	}
+	req := proto.Clone(writeEntity).(*streamv1.WriteRequest)
+	stm, _ := s.entityRepo.loadStream(req.GetMetadata())
+	redactTags(sp, stm.GetTagFamilies(), req.GetElement().GetTagFamilies())
+	sp.Capture("stream", req)
+}
+
+// sampleMeasureWrite captures the raw request if its group is sampled.
+func (ms *measureService) sampleMeasureWrite(writeRequest *measurev1.WriteRequest) {
+	sp := sampling.Lookup(writeRequest.GetMetadata().GetGroup())
+	if sp == nil {
+		return
+	}
+	req := proto.Clone(writeRequest).(*measurev1.WriteRequest)
+	m, _ := ms.entityRepo.loadMeasure(req.GetMetadata())
+	redactTags(sp, m.GetTagFamilies(), req.GetDataPoint().GetTagFamilies())
+	sp.Capture("measure", req)
+}
+
+// redactTags masks the values of the redacted tags located by the schema.
+// All the values are masked if the schema isn't cached while some tags should be redacted.
+func redactTags(sp *sampling.Sampler, specs []*databasev1.TagFamilySpec, families []*modelv1.TagFamilyForWrite) {
+	for i, f := range families {
+		for j, v := range f.GetTags() {
+			if i < len(specs) && j < len(specs[i].GetTags()) {
+				if !sp.Redacted(specs[i].GetTags()[j].GetName()) {
+					continue
+				}
+			} else if !sp.Redacting() {
+				continue
+			}
+			f.Tags[j] = maskTagValue(v)
+		}
+	}
+}
+
+// maskTagValue replaces the value by a placeholder of the same type, so the samples still reproduce the type mismatches.
+func maskTagValue(v *modelv1.TagValue) *modelv1.TagValue {
+	switch x := v.GetValue().(type) {
+	case *modelv1.TagValue_Str:
+		return strTagValue(redactedValue)
+	case *modelv1.TagValue_Int:
+		return &modelv1.TagValue{Value: &modelv1.TagValue_Int{Int: &modelv1.Int{}}}
+	case *modelv1.TagValue_StrArray:
+		arr := make([]string, len(x.StrArray.GetValue()))
+		for i := range arr {
+			arr[i] = redactedValue
+		}
+		return &modelv1.TagValue{Value: &modelv1.TagValue_StrArray{StrArray: &modelv1.StrArray{Value: arr}}}
+	case *modelv1.TagValue_IntArray:
+		return &modelv1.TagValue{Value: &modelv1.TagValue_IntArray{IntArray: &modelv1.IntArray{Value: make([]int64, len(x.IntArray.GetValue()))}}}
+	case *modelv1.TagValue_BinaryData:
+		return &modelv1.TagValue{Value: &modelv1.TagValue_BinaryData{BinaryData: []byte{}}}
+	case *modelv1.TagValue_Timestamp:
+		return &modelv1.TagValue{Value: &modelv1.TagValue_Timestamp{Timestamp: &timestamppb.Timestamp{}}}
+	default:
+		return v
+	}
+}
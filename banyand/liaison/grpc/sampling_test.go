@@ -0,0 +1,60 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/banyand/liaison/sampling"
+)
+
+func TestRedactTags(t *testing.T) {
+	sp, err := sampling.Start(sampling.Options{Group: "redact", Rate: 1, RedactTags: []string{"user", "codes"}})
+	require.NoError(t, err)
+	defer sampling.Stop("redact")
+	specs := []*databasev1.TagFamilySpec{{
+		Name: "searchable",
+		Tags: []*databasev1.TagSpec{
+			{Name: "service", Type: databasev1.TagType_TAG_TYPE_STRING},
+			{Name: "user", Type: databasev1.TagType_TAG_TYPE_STRING},
+			{Name: "codes", Type: databasev1.TagType_TAG_TYPE_INT_ARRAY},
+		},
+	}}
+	families := func() []*modelv1.TagFamilyForWrite {
+		return []*modelv1.TagFamilyForWrite{{Tags: []*modelv1.TagValue{
+			strTagValue("svc"),
+			{Value: &modelv1.TagValue_Int{Int: &modelv1.Int{Value: 42}}},
+			{Value: &modelv1.TagValue_IntArray{IntArray: &modelv1.IntArray{Value: []int64{200, 404}}}},
+		}}}
+	}
+
+	f := families()
+	redactTags(sp, specs, f)
+	assert.Equal(t, "svc", f[0].Tags[0].GetStr().GetValue())
+	assert.Equal(t, int64(0), f[0].Tags[1].GetInt().GetValue(), "the mismatched type is kept")
+	assert.Equal(t, []int64{0, 0}, f[0].Tags[2].GetIntArray().GetValue())
+
+	f = families()
+	redactTags(sp, nil, f)
+	assert.Equal(t, redactedValue, f[0].Tags[0].GetStr().GetValue(), "all the tags are masked without the schema")
+}
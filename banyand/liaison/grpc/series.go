@@ -0,0 +1,93 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	"github.com/apache/skywalking-banyandb/api/data"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/banyand/metadata"
+	"github.com/apache/skywalking-banyandb/banyand/queue"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+	"github.com/apache/skywalking-banyandb/pkg/grpchelper"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+type seriesServer struct {
+	databasev1.UnimplementedSeriesServiceServer
+	schemaRegistry metadata.Repo
+	pipeline       queue.Client
+}
+
+func (s *seriesServer) Lookup(ctx context.Context, req *databasev1.SeriesServiceLookupRequest) (*databasev1.SeriesServiceLookupResponse, error) {
+	if len(req.GetGroups()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "groups are required")
+	}
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "name is required")
+	}
+	if req.GetTimeRange() != nil {
+		if err := timestamp.CheckTimeRange(req.GetTimeRange()); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "%v is invalid: %s", req.GetTimeRange(), err)
+		}
+	}
+	var catalog commonv1.Catalog
+	for _, gn := range req.GetGroups() {
+		g, err := s.schemaRegistry.GroupRegistry().GetGroup(ctx, gn)
+		if err != nil {
+			return nil, grpchelper.NewError(codes.NotFound, fmt.Sprintf("group %s not found", gn)).
+				WithResource(grpchelper.ResourceGroup, gn)
+		}
+		if catalog != commonv1.Catalog_CATALOG_UNSPECIFIED && g.GetCatalog() != catalog {
+			return nil, status.Error(codes.InvalidArgument, "the groups should be in the same catalog")
+		}
+		catalog = g.GetCatalog()
+	}
+	var topic bus.Topic
+	switch catalog {
+	case commonv1.Catalog_CATALOG_STREAM:
+		topic = data.TopicStreamSeriesLookup
+	case commonv1.Catalog_CATALOG_MEASURE:
+		topic = data.TopicMeasureSeriesLookup
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "the series of the %s groups can't be looked up", catalog)
+	}
+	f, err := s.pipeline.Publish(ctx, topic, bus.NewMessage(bus.MessageID(time.Now().UnixNano()), req))
+	if err != nil {
+		return nil, err
+	}
+	msg, err := f.Get()
+	if err != nil {
+		return nil, err
+	}
+	switch d := msg.Data().(type) {
+	case *databasev1.SeriesServiceLookupResponse:
+		return d, nil
+	case *common.Error:
+		return nil, status.Error(codes.Internal, d.Error())
+	}
+	return &databasev1.SeriesServiceLookupResponse{}, nil
+}
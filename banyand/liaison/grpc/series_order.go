@@ -0,0 +1,104 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/pkg/convert"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+)
+
+var errOutOfOrder = errors.New("the element is out of order")
+
+// seriesOrder remembers the latest timestamps of the series of the streams enforcing the element order.
+// The least recently written series are forgotten once the capacity is exceeded,
+// and their next elements are accepted as they are.
+type seriesOrder struct {
+	latest *lru.Cache
+	mu     sync.Mutex
+}
+
+func newSeriesOrder(maxSeries int) (*seriesOrder, error) {
+	latest, err := lru.New(maxSeries)
+	if err != nil {
+		return nil, err
+	}
+	return &seriesOrder{latest: latest}, nil
+}
+
+// check applies the out-of-order policy to the element. The entity values include the subject at first.
+// If the element is older than the latest element of its series, it returns errOutOfOrder with the reason under the reject policy,
+// or moves the timestamp of the element to the latest one and returns the reason under the adjust policy.
+// The elements with the same timestamps are in order.
+func (o *seriesOrder) check(policy databasev1.OutOfOrderPolicy, md *commonv1.Metadata, entityValues pbv1.EntityValues,
+	element *streamv1.ElementValue,
+) (string, error) {
+	if policy == databasev1.OutOfOrderPolicy_OUT_OF_ORDER_POLICY_UNSPECIFIED || len(entityValues) < 1 {
+		return "", nil
+	}
+	series := pbv1.Series{Subject: md.GetGroup() + "/" + md.GetName(), EntityValues: entityValues[1:].Encode()}
+	if series.Marshal() != nil {
+		return "", nil
+	}
+	key := convert.Hash(series.Buffer)
+	ts := element.GetTimestamp().AsTime().UnixNano()
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	v, ok := o.latest.Get(key)
+	if !ok || ts >= v.(int64) {
+		o.latest.Add(key, ts)
+		return "", nil
+	}
+	latest := time.Unix(0, v.(int64)).UTC()
+	if policy == databasev1.OutOfOrderPolicy_OUT_OF_ORDER_POLICY_REJECT {
+		return fmt.Sprintf("the timestamp %s is before the latest timestamp %s of series %s",
+			element.GetTimestamp().AsTime().Format(time.RFC3339Nano), latest.Format(time.RFC3339Nano), entityValues), errOutOfOrder
+	}
+	reason := fmt.Sprintf("the timestamp %s is adjusted to the latest timestamp %s of series %s",
+		element.GetTimestamp().AsTime().Format(time.RFC3339Nano), latest.Format(time.RFC3339Nano), entityValues)
+	element.Timestamp = timestamppb.New(latest)
+	return reason, nil
+}
+
+// checkOrder applies the out-of-order policy of the stream to the element, and counts the out-of-order elements.
+func (s *streamService) checkOrder(writeEntity *streamv1.WriteRequest, entityValues pbv1.EntityValues) (string, error) {
+	if s.seriesOrder == nil {
+		return "", nil
+	}
+	stm, ok := s.entityRepo.loadStream(writeEntity.GetMetadata())
+	if !ok || stm.GetOutOfOrderPolicy() == databasev1.OutOfOrderPolicy_OUT_OF_ORDER_POLICY_UNSPECIFIED {
+		return "", nil
+	}
+	reason, err := s.seriesOrder.check(stm.GetOutOfOrderPolicy(), writeEntity.GetMetadata(), entityValues, writeEntity.GetElement())
+	if reason != "" {
+		policy := strings.ToLower(strings.TrimPrefix(stm.GetOutOfOrderPolicy().String(), "OUT_OF_ORDER_POLICY_"))
+		s.metrics.totalOutOfOrder.Inc(1, writeEntity.GetMetadata().GetGroup(), policy)
+	}
+	return reason, err
+}
@@ -0,0 +1,79 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+)
+
+func TestSeriesOrder(t *testing.T) {
+	o, err := newSeriesOrder(2)
+	require.NoError(t, err)
+	md := &commonv1.Metadata{Group: "sw", Name: "log"}
+	series := func(v string) pbv1.EntityValues {
+		return pbv1.EntityValues{pbv1.EntityStrValue("log"), pbv1.EntityStrValue(v)}
+	}
+	base := time.Unix(1700000000, 0).UTC()
+	element := func(offset time.Duration) *streamv1.ElementValue {
+		return &streamv1.ElementValue{Timestamp: timestamppb.New(base.Add(offset))}
+	}
+	reject := databasev1.OutOfOrderPolicy_OUT_OF_ORDER_POLICY_REJECT
+	adjust := databasev1.OutOfOrderPolicy_OUT_OF_ORDER_POLICY_ADJUST
+
+	reason, err := o.check(reject, md, series("svc1"), element(time.Second))
+	assert.NoError(t, err)
+	assert.Empty(t, reason)
+	// the elements with the same timestamps are in order.
+	_, err = o.check(reject, md, series("svc1"), element(time.Second))
+	assert.NoError(t, err)
+	reason, err = o.check(reject, md, series("svc1"), element(0))
+	assert.ErrorIs(t, err, errOutOfOrder)
+	assert.Contains(t, reason, "is before the latest timestamp 2023-11-14T22:13:21Z")
+	// the series are ordered independently.
+	_, err = o.check(reject, md, series("svc2"), element(0))
+	assert.NoError(t, err)
+
+	e := element(0)
+	reason, err = o.check(adjust, md, series("svc1"), e)
+	assert.NoError(t, err)
+	assert.Contains(t, reason, "is adjusted to the latest timestamp")
+	assert.Equal(t, base.Add(time.Second), e.GetTimestamp().AsTime())
+
+	// the policy is off.
+	e = element(0)
+	reason, err = o.check(databasev1.OutOfOrderPolicy_OUT_OF_ORDER_POLICY_UNSPECIFIED, md, series("svc1"), e)
+	assert.NoError(t, err)
+	assert.Empty(t, reason)
+	assert.Equal(t, base, e.GetTimestamp().AsTime())
+
+	// svc2 is the least recently written series, which is forgotten.
+	_, err = o.check(reject, md, series("svc3"), element(0))
+	assert.NoError(t, err)
+	_, err = o.check(reject, md, series("svc2"), element(-time.Second))
+	assert.NoError(t, err)
+}
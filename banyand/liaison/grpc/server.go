@@ -20,6 +20,7 @@ package grpc
 
 import (
 	"context"
+	"crypto/tls"
 	"net"
 	"runtime/debug"
 	"strconv"
@@ -28,36 +29,48 @@ import (
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/recovery"
 	grpc_validator "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/validator"
 	"github.com/pkg/errors"
+	coltracev1 "go.opentelemetry.io/proto/otlp/collector/trace/v1"
 	grpclib "google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/health"
 	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 
+	"github.com/apache/skywalking-banyandb/api/common"
 	"github.com/apache/skywalking-banyandb/api/data"
 	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
 	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
 	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
 	propertyv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/property/v1"
 	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/banyand/liaison/sampling"
 	"github.com/apache/skywalking-banyandb/banyand/measure"
 	"github.com/apache/skywalking-banyandb/banyand/metadata"
 	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
 	"github.com/apache/skywalking-banyandb/banyand/observability"
 	"github.com/apache/skywalking-banyandb/banyand/queue"
+	"github.com/apache/skywalking-banyandb/pkg/convert"
+	"github.com/apache/skywalking-banyandb/pkg/grpchelper"
+	"github.com/apache/skywalking-banyandb/pkg/listener"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
-	"github.com/apache/skywalking-banyandb/pkg/partition"
+	"github.com/apache/skywalking-banyandb/pkg/node"
 	"github.com/apache/skywalking-banyandb/pkg/run"
 	pkgtls "github.com/apache/skywalking-banyandb/pkg/tls"
+	"github.com/apache/skywalking-banyandb/pkg/tracecontext"
 )
 
-const defaultRecvSize = 10 << 20
+const (
+	defaultRecvSize       = 10 << 20
+	defaultMaxElementSize = 64 << 20
+	defaultChunkSize      = 4 << 20
+)
 
 var (
 	errServerCert        = errors.New("invalid server cert file")
 	errServerKey         = errors.New("invalid server key file")
 	errNoAddr            = errors.New("no address")
+	errListenerCert      = errors.New("a TLS listener without its own certificate requires the server TLS")
 	errQueryMsg          = errors.New("invalid query message")
 	errAccessLogRootPath = errors.New("access log root path is required")
 
@@ -93,24 +106,40 @@ type server struct {
 	*indexRuleRegistryServer
 	*measureRegistryServer
 	streamSVC      *streamService
+	otlpTraceSVC   *otlpTraceService
 	streamCallback *streamRedirectWriteCallback
 	*streamRegistryServer
 	measureSVC *measureService
 	log        *logger.Logger
 	*propertyRegistryServer
-	ser         *grpclib.Server
+	conns       *connManager
 	tlsReloader *pkgtls.Reloader
 	*propertyServer
 	*indexRuleBindingRegistryServer
 	groupRepo                *groupRepo
 	metrics                  *metrics
+	writeRate                *writeRateDetector
+	shardLoad                *shardLoadTracker
+	entityRegistrar          *entityRegistrar
 	certFile                 string
 	keyFile                  string
 	host                     string
 	addr                     string
 	accessLogRootPath        string
+	sampleDir                string
 	accessLogRecorders       []accessLogRecorder
+	listenAddrs              []string
+	listeners                []listener.Config
+	connSettings             connectionSettings
+	writeRateOpts            writeRateOptions
+	shardLoadOpts            shardLoadOptions
+	orderedSeries            int
+	entityRegistrationOpts   entityRegistrationOptions
 	maxRecvMsgSize           run.Bytes
+	elementIDWorker          int
+	coldQueryWorkers         int
+	maxListSize              run.DynamicInt
+	maxPatternWildcards      run.DynamicInt
 	port                     uint32
 	enableIngestionAccessLog bool
 	tls                      bool
@@ -122,7 +151,7 @@ func NewServer(_ context.Context, tir1Client, tir2Client, broadcaster queue.Clie
 	tire2Server queue.Server,
 ) Server {
 	gr := &groupRepo{resourceOpts: make(map[string]*commonv1.ResourceOpts)}
-	er := &entityRepo{entitiesMap: make(map[identity]partition.Locator), measureMap: make(map[identity]*databasev1.Measure)}
+	er := newEntityRepo()
 	streamSVC := &streamService{
 		discoveryService: newDiscoveryService(schema.KindStream, schemaRegistry, nr.StreamLiaisonNodeRegistry, gr),
 		pipeline:         tir1Client,
@@ -179,6 +208,10 @@ func NewServer(_ context.Context, tir1Client, tir2Client, broadcaster queue.Clie
 		propertyRegistryServer: &propertyRegistryServer{
 			schemaRegistry: schemaRegistry,
 		},
+		otlpTraceSVC: &otlpTraceService{
+			schemaRegistry: schemaRegistry,
+			streamSVC:      streamSVC,
+		},
 		topNPipeline: topNPipeline,
 		schemaRepo:   schemaRegistry,
 	}
@@ -187,10 +220,35 @@ func NewServer(_ context.Context, tir1Client, tir2Client, broadcaster queue.Clie
 	return s
 }
 
-func (s *server) PreRun(_ context.Context) error {
+func (s *server) PreRun(ctx context.Context) error {
 	s.log = logger.GetLogger("liaison-grpc")
+	var nodeID string
+	if val := ctx.Value(common.ContextNodeKey); val != nil {
+		nodeID = val.(common.Node).NodeID
+	}
+	s.streamSVC.nodeID = nodeID
+	s.measureSVC.nodeID = nodeID
+	worker := s.elementIDWorker
+	if worker < 0 {
+		// the worker is derived from the node ID if it's not specified.
+		worker = 0
+		if nodeID != "" {
+			worker = int(convert.HashStr(nodeID) % (maxElementIDWorker + 1))
+		}
+	}
+	s.log.Info().Int("worker", worker).Msg("the worker of the server-generated stream element IDs")
+	s.streamSVC.elementIDs = newElementIDGenerator(uint64(worker))
+	sampling.SetDir(s.sampleDir)
+	s.streamSVC.maxListSize = &s.maxListSize
+	s.measureSVC.maxListSize = &s.maxListSize
+	s.streamSVC.maxPatternWildcards = &s.maxPatternWildcards
+	s.measureSVC.maxPatternWildcards = &s.maxPatternWildcards
+	coldQueries := newColdQueryPool(s.coldQueryWorkers)
+	s.streamSVC.coldQueries = coldQueries
+	s.measureSVC.coldQueries = coldQueries
 	s.streamSVC.setLogger(s.log.Named("stream-t1"))
 	s.streamCallback.l = s.log.Named("stream-t2")
+	s.otlpTraceSVC.l = s.log.Named("otlp-trace")
 	s.measureSVC.setLogger(s.log)
 	s.propertyServer.SetLogger(s.log)
 	s.measureCallback.l = s.log.Named("measure-t2")
@@ -232,6 +290,40 @@ func (s *server) PreRun(_ context.Context) error {
 	s.groupRegistryServer.metrics = metrics
 	s.topNAggregationRegistryServer.metrics = metrics
 	s.propertyRegistryServer.metrics = metrics
+	if s.writeRateOpts.interval > 0 {
+		s.writeRate = newWriteRateDetector(s.writeRateOpts, s.log.Named("write-rate"), metrics.totalWriteRateAnomaly)
+		s.streamSVC.writeRate = s.writeRate
+		s.measureSVC.writeRate = s.writeRate
+	}
+	if s.shardLoadOpts.interval > 0 {
+		splitter := &shardSplitter{
+			l:            s.log.Named("shard-split"),
+			groups:       s.schemaRepo.GroupRegistry(),
+			streamNodes:  s.streamCallback.nodeRegistry,
+			measureNodes: s.measureCallback.nodeRegistry,
+		}
+		s.shardLoad = newShardLoadTracker(s.shardLoadOpts, s.log.Named("shard-load"), s.groupRepo, splitter, metrics.totalHotShard)
+		s.streamSVC.shardLoad = s.shardLoad
+		s.measureSVC.shardLoad = s.shardLoad
+	}
+	seriesOrder, err := newSeriesOrder(s.orderedSeries)
+	if err != nil {
+		return err
+	}
+	s.streamSVC.seriesOrder = seriesOrder
+	s.entityRegistrar = newEntityRegistrar(s.entityRegistrationOpts, s.log.Named("entity-registration"), s.groupRepo,
+		s.schemaRepo, s.propertyServer.Apply, metrics.totalEntityRegistration)
+	s.streamSVC.entityRegistrar = s.entityRegistrar
+	s.measureSVC.entityRegistrar = s.entityRegistrar
+	placement := &shardPlacement{
+		groups: s.schemaRepo.GroupRegistry(),
+		registries: map[commonv1.Catalog]NodeRegistry{
+			commonv1.Catalog_CATALOG_STREAM:   s.streamCallback.nodeRegistry,
+			commonv1.Catalog_CATALOG_MEASURE:  s.measureCallback.nodeRegistry,
+			commonv1.Catalog_CATALOG_PROPERTY: s.propertyServer.nodeRegistry,
+		},
+	}
+	node.SetPlacement(placement.place)
 
 	if s.tls {
 		var err error
@@ -278,6 +370,9 @@ func (s *server) FlagSet() *run.FlagSet {
 	fs.StringVar(&s.keyFile, "key-file", "", "the TLS key file")
 	fs.StringVar(&s.host, "grpc-host", "", "the host of banyand listens")
 	fs.Uint32Var(&s.port, "grpc-port", 17912, "the port of banyand listens")
+	fs.StringSliceVar(&s.listenAddrs, "grpc-listen-addrs", nil,
+		"the additional addresses the gRPC server listens on, e.g. \"[::1]:17922\", \"unix:///var/run/banyandb.sock\", "+
+			"\"tcp6://[::]:17932?tls=true&cert-file=a.crt&key-file=a.key\"")
 	fs.BoolVar(&s.enableIngestionAccessLog, "enable-ingestion-access-log", false, "enable ingestion access log")
 	fs.StringVar(&s.accessLogRootPath, "access-log-root-path", "", "access log root path")
 	fs.DurationVar(&s.streamSVC.writeTimeout, "stream-write-timeout", 15*time.Second, "timeout for writing stream among liaison nodes")
@@ -288,8 +383,90 @@ func (s *server) FlagSet() *run.FlagSet {
 		"the maximum duration to wait for metadata cache to load (for testing purposes)")
 	fs.DurationVar(&s.streamSVC.maxWaitDuration, "stream-metadata-cache-wait-duration", 0,
 		"the maximum duration to wait for metadata cache to load (for testing purposes)")
+	s.streamSVC.maxElementSize = defaultMaxElementSize
+	fs.VarP(&s.streamSVC.maxElementSize, "stream-max-element-size", "",
+		"the maximum size of a stream element, the larger elements are rejected with STATUS_ELEMENT_TOO_LARGE. 0 means no limit")
+	fs.VarP(&s.streamSVC.maxTagValueSize, "stream-max-tag-value-size", "",
+		"the maximum size of a stream tag value, the elements carrying larger ones are rejected with STATUS_ELEMENT_TOO_LARGE. 0 means no limit")
+	fs.VarP(&s.measureSVC.maxDataPointSize, "measure-max-data-point-size", "",
+		"the maximum size of a measure data point, the larger data points are rejected with STATUS_ELEMENT_TOO_LARGE. 0 means no limit")
+	fs.VarP(&s.measureSVC.maxTagValueSize, "measure-max-tag-value-size", "",
+		"the maximum size of a measure tag value, the data points carrying larger ones are rejected with STATUS_ELEMENT_TOO_LARGE. 0 means no limit")
+	s.streamSVC.chunkSize = defaultChunkSize
+	fs.VarP(&s.streamSVC.chunkSize, "stream-element-chunk-size", "",
+		"the size of the chunks which the elements larger than it are split into before being sent to the data nodes, "+
+			"which should be less than the max receiving message size of the data nodes. 0 disables the chunking")
+	fs.StringVar((*string)(&s.streamSVC.tagTypeMismatch), "stream-tag-type-mismatch", string(tagTypeMismatchNull),
+		"the policy of the stream tag values whose types don't match the schema: \"reject\" rejects the elements with STATUS_INVALID_DATA, "+
+			"\"coerce\" converts the values to the tag types and rejects the inconvertible ones, and \"null\" writes null instead")
+	fs.IntVar(&s.orderedSeries, "stream-ordered-series", 100000,
+		"the maximum number of the series whose latest timestamps are remembered for the streams enforcing the element order, "+
+			"the least recently written ones are forgotten once it's exceeded")
+	fs.DurationVar(&s.streamSVC.shardBatchInterval, "stream-write-shard-batch-interval", 0,
+		"the interval to send the stream elements grouped by the target shards, "+
+			"each of which is sent to a data node in one message. 0 sends the elements one by one")
 	fs.IntVar(&s.measureCallback.maxDiskUsagePercent, "liaison-measure-max-disk-usage-percent", 95, "the maximum disk usage percentage allowed")
 	fs.IntVar(&s.propertyServer.repairQueueCount, "property-repair-queue-count", 128, "the number of queues for property repair")
+	fs.StringVar(&s.sampleDir, "ingestion-sample-dir", "",
+		"the directory where the ingestion samplers started with file=true write the sampled requests. The file output is disabled if it's empty")
+	fs.StringVar(&s.otlpTraceSVC.group, "otlp-trace-group", "",
+		"the stream group which OTLP spans are written into. The OTLP trace receiver is disabled if it's empty")
+	fs.StringVar(&s.otlpTraceSVC.name, "otlp-trace-stream", "otlp_span", "the stream which OTLP spans are written into")
+	fs.IntVar(&s.elementIDWorker, "stream-element-id-worker", -1,
+		"the worker of the server-generated stream element IDs, which should be unique among the liaison nodes, ranging from 0 to 1023. "+
+			"It's derived from the node ID if it's negative")
+	fs.DynamicIntVar(&s.maxListSize, "query-max-list-size", 65536,
+		"the maximum number of the values in the list of a query condition, e.g. IN and NOT IN, 0 means no limit")
+	fs.DynamicIntVar(&s.maxPatternWildcards, "query-max-pattern-wildcards", 4,
+		"the maximum number of the wildcards in the pattern of a WILDCARD query condition, 0 means no limit")
+	fs.IntVar(&s.coldQueryWorkers, "cold-query-workers", 4,
+		"the number of the concurrent queries reading the cold tiers, i.e. the lifecycle stages other than hot and the archive groups, 0 means unbounded")
+	fs.DurationVar(&s.connSettings.keepaliveTime, "grpc-keepalive-time", 0,
+		"the idle duration after which the server pings the client, 0 means the default of gRPC(2h)")
+	fs.DurationVar(&s.connSettings.keepaliveTimeout, "grpc-keepalive-timeout", 0,
+		"the duration the server waits for the ping ack before closing the connection, 0 means the default of gRPC(20s)")
+	fs.DurationVar(&s.connSettings.keepaliveMinTime, "grpc-keepalive-min-time", 0,
+		"the minimum interval a client is allowed to ping the server, 0 means the default of gRPC(5m)")
+	fs.BoolVar(&s.connSettings.keepalivePermitWithoutStream, "grpc-keepalive-permit-without-stream", false,
+		"allow the client to ping the server when there is no active stream")
+	fs.DurationVar(&s.connSettings.maxConnectionIdle, "grpc-max-connection-idle", 0,
+		"the duration after which an idle connection is closed, 0 means infinity")
+	fs.DurationVar(&s.connSettings.maxConnectionAge, "grpc-max-connection-age", 0,
+		"the maximum duration a connection may exist before it's gracefully closed, 0 means infinity")
+	fs.DurationVar(&s.connSettings.maxConnectionAgeGrace, "grpc-max-connection-age-grace", 0,
+		"the additional duration for the pending RPCs to complete after the max connection age, 0 means infinity")
+	fs.Uint32Var(&s.connSettings.maxConcurrentStreams, "grpc-max-concurrent-streams", 0,
+		"the maximum number of concurrent streams of every connection, 0 means unlimited")
+	fs.VarP(&s.connSettings.writeBufferSize, "grpc-write-buffer-size", "",
+		"the size of the write buffer of every connection, 0 means the default of gRPC(32KiB)")
+	fs.VarP(&s.connSettings.connWindowSize, "grpc-conn-window-size", "",
+		"the flow control window of every connection, 0 means the default of gRPC(64KiB)")
+	fs.DurationVar(&s.writeRateOpts.interval, "write-rate-anomaly-interval", 0,
+		"the interval to evaluate the write rates of the groups and the series, 0 disables the anomaly detection")
+	fs.Float64Var(&s.writeRateOpts.threshold, "write-rate-anomaly-threshold", 5,
+		"the times by which a write rate exceeds or falls below its baseline to be flagged as an anomaly")
+	fs.Float64Var(&s.writeRateOpts.alpha, "write-rate-anomaly-alpha", 0.3,
+		"the smoothing factor of the exponentially weighted moving average of the write rates, in (0, 1]")
+	fs.Float64Var(&s.writeRateOpts.minRate, "write-rate-anomaly-min-rate", 1,
+		"the minimum baseline rate(writes per second) to detect the anomalies, which avoids flagging the sparse series")
+	fs.IntVar(&s.writeRateOpts.maxSeries, "write-rate-anomaly-max-series", 100000,
+		"the maximum number of the series to track the write rates")
+	fs.DurationVar(&s.shardLoadOpts.interval, "hot-shard-interval", 0,
+		"the interval to evaluate the write and the query rates of the shards, 0 disables the hot shard detection")
+	fs.Float64Var(&s.shardLoadOpts.threshold, "hot-shard-threshold", 3,
+		"the times by which the rate of a shard exceeds the average of the other shards in its group to be hot")
+	fs.Float64Var(&s.shardLoadOpts.alpha, "hot-shard-alpha", 0.3,
+		"the smoothing factor of the exponentially weighted moving average of the shard rates, in (0, 1]")
+	fs.Float64Var(&s.shardLoadOpts.minRate, "hot-shard-min-rate", 100,
+		"the minimum rate(writes or queries per second) of a hot shard, which avoids flagging the shards of the idle groups")
+	fs.IntVar(&s.shardLoadOpts.persistence, "hot-shard-persistence", 5,
+		"the number of the intervals in a row which a shard is hot for before it's reported and split")
+	fs.BoolVar(&s.shardLoadOpts.autoSplit, "hot-shard-auto-split", false,
+		"split the hot shards by moving half of their series to the least loaded data nodes")
+	fs.IntVar(&s.entityRegistrationOpts.queueSize, "entity-registration-queue-size", 1024,
+		"the size of the queue of the entities waiting to be registered into the property groups, the overflowed ones are dropped")
+	fs.IntVar(&s.entityRegistrationOpts.maxEntities, "entity-registration-max-entities", 100000,
+		"the maximum number of the registered entities to remember, which are registered again once it's exceeded")
 	return fs
 }
 
@@ -301,6 +478,51 @@ func (s *server) Validate() error {
 	if s.enableIngestionAccessLog && s.accessLogRootPath == "" {
 		return errAccessLogRootPath
 	}
+	if err := s.connSettings.validate(); err != nil {
+		return err
+	}
+	if err := s.writeRateOpts.validate(); err != nil {
+		return err
+	}
+	if err := s.shardLoadOpts.validate(); err != nil {
+		return err
+	}
+	if err := s.entityRegistrationOpts.validate(); err != nil {
+		return err
+	}
+	if s.streamSVC.maxElementSize < 0 || s.streamSVC.chunkSize < 0 {
+		return errors.Errorf("stream-max-element-size %s and stream-element-chunk-size %s must not be negative",
+			s.streamSVC.maxElementSize.String(), s.streamSVC.chunkSize.String())
+	}
+	if s.streamSVC.maxTagValueSize < 0 || s.measureSVC.maxDataPointSize < 0 || s.measureSVC.maxTagValueSize < 0 {
+		return errors.Errorf("stream-max-tag-value-size %s, measure-max-data-point-size %s and measure-max-tag-value-size %s must not be negative",
+			s.streamSVC.maxTagValueSize.String(), s.measureSVC.maxDataPointSize.String(), s.measureSVC.maxTagValueSize.String())
+	}
+	if err := s.streamSVC.tagTypeMismatch.validate(); err != nil {
+		return err
+	}
+	if s.orderedSeries <= 0 {
+		return errors.Errorf("stream-ordered-series %d must be positive", s.orderedSeries)
+	}
+	if s.streamSVC.shardBatchInterval < 0 {
+		return errors.Errorf("stream-write-shard-batch-interval %s must not be negative", s.streamSVC.shardBatchInterval)
+	}
+	if s.elementIDWorker > maxElementIDWorker {
+		return errors.Errorf("stream-element-id-worker %d exceeds %d", s.elementIDWorker, maxElementIDWorker)
+	}
+	if s.coldQueryWorkers < 0 {
+		return errors.Errorf("cold-query-workers %d must not be negative", s.coldQueryWorkers)
+	}
+	extra, err := listener.ParseAll(s.listenAddrs)
+	if err != nil {
+		return err
+	}
+	s.listeners = append([]listener.Config{{Network: "tcp", Address: s.addr, TLS: s.tls}}, extra...)
+	for _, l := range extra {
+		if l.TLS && l.CertFile == "" && !s.tls {
+			return errors.Wrap(errListenerCert, l.String())
+		}
+	}
 	if !s.tls {
 		return nil
 	}
@@ -314,7 +536,8 @@ func (s *server) Validate() error {
 }
 
 func (s *server) Serve() run.StopNotify {
-	var opts []grpclib.ServerOption
+	// TLS is terminated by the listeners, so that every listener has its own TLS settings.
+	var serverTLS *tls.Config
 	if s.tls {
 		if err := s.tlsReloader.Start(); err != nil {
 			s.log.Error().Err(err).Msg("Failed to start TLSReloader for gRPC")
@@ -322,9 +545,7 @@ func (s *server) Serve() run.StopNotify {
 			return s.stopCh
 		}
 		s.log.Info().Str("certFile", s.certFile).Str("keyFile", s.keyFile).Msg("Starting TLS file monitoring")
-		tlsConfig := s.tlsReloader.GetTLSConfig()
-		creds := credentials.NewTLS(tlsConfig)
-		opts = append(opts, grpclib.Creds(creds))
+		serverTLS = s.tlsReloader.GetTLSConfig()
 	}
 	grpcPanicRecoveryHandler := func(p any) (err error) {
 		s.log.Error().Interface("panic", p).Str("stack", string(debug.Stack())).Msg("recovered from panic")
@@ -333,47 +554,54 @@ func (s *server) Serve() run.StopNotify {
 	}
 
 	streamChain := []grpclib.StreamServerInterceptor{
+		grpchelper.StreamServerInterceptor(),
 		grpc_validator.StreamServerInterceptor(),
 		recovery.StreamServerInterceptor(recovery.WithRecoveryHandler(grpcPanicRecoveryHandler)),
+		tracecontext.StreamServerInterceptor(),
 	}
 	unaryChain := []grpclib.UnaryServerInterceptor{
+		grpchelper.UnaryServerInterceptor(errorResource),
 		grpc_validator.UnaryServerInterceptor(),
 		recovery.UnaryServerInterceptor(recovery.WithRecoveryHandler(grpcPanicRecoveryHandler)),
+		tracecontext.UnaryServerInterceptor(),
 	}
 
-	opts = append(opts, grpclib.MaxRecvMsgSize(int(s.maxRecvMsgSize)),
+	opts := []grpclib.ServerOption{
+		grpclib.MaxRecvMsgSize(int(s.maxRecvMsgSize)),
 		grpclib.ChainUnaryInterceptor(unaryChain...),
 		grpclib.ChainStreamInterceptor(streamChain...),
-	)
-	s.ser = grpclib.NewServer(opts...)
-
-	commonv1.RegisterServiceServer(s.ser, &apiVersionService{})
-	streamv1.RegisterStreamServiceServer(s.ser, s.streamSVC)
-	measurev1.RegisterMeasureServiceServer(s.ser, s.measureSVC)
-	databasev1.RegisterGroupRegistryServiceServer(s.ser, s.groupRegistryServer)
-	databasev1.RegisterIndexRuleBindingRegistryServiceServer(s.ser, s.indexRuleBindingRegistryServer)
-	databasev1.RegisterIndexRuleRegistryServiceServer(s.ser, s.indexRuleRegistryServer)
-	databasev1.RegisterStreamRegistryServiceServer(s.ser, s.streamRegistryServer)
-	databasev1.RegisterMeasureRegistryServiceServer(s.ser, s.measureRegistryServer)
-	propertyv1.RegisterPropertyServiceServer(s.ser, s.propertyServer)
-	databasev1.RegisterTopNAggregationRegistryServiceServer(s.ser, s.topNAggregationRegistryServer)
-	databasev1.RegisterSnapshotServiceServer(s.ser, s)
-	databasev1.RegisterPropertyRegistryServiceServer(s.ser, s.propertyRegistryServer)
-	grpc_health_v1.RegisterHealthServer(s.ser, health.NewServer())
+	}
+	s.conns = newConnManager(s.log, s.connSettings, func(settings connectionSettings) *grpclib.Server {
+		return s.newGRPCServer(append(settings.serverOptions(), opts...))
+	})
 
 	s.stopCh = make(chan struct{})
 	s.propertyServer.startRepairQueue(s.stopCh)
+	if s.writeRate != nil {
+		s.writeRate.start(s.stopCh)
+	}
+	if s.shardLoad != nil {
+		s.shardLoad.start(s.stopCh)
+	}
+	s.entityRegistrar.start(s.stopCh)
 	s.log.Info().Str("addr", s.addr).Msg("Starting gRPC server")
 	go func() {
-		lis, err := net.Listen("tcp", s.addr)
-		if err != nil {
-			s.log.Error().Err(err).Msg("Failed to listen")
-			close(s.stopCh)
-			return
+		listeners := make([]net.Listener, 0, len(s.listeners))
+		for _, lc := range s.listeners {
+			lis, err := listener.Listen(lc, serverTLS, s.log)
+			if err != nil {
+				s.log.Error().Err(err).Stringer("listener", lc).Msg("Failed to listen")
+				for _, l := range listeners {
+					_ = l.Close()
+				}
+				close(s.stopCh)
+				return
+			}
+			s.log.Info().Stringer("listener", lc).Bool("tls", lc.TLS).Msg("Listening to")
+			listeners = append(listeners, lis)
 		}
-		s.log.Info().Str("addr", s.addr).Msg("Listening to")
-		err = s.ser.Serve(lis)
-		if err != nil {
+		err := s.conns.serve(listeners...)
+		if err != nil && !errors.Is(err, net.ErrClosed) {
 			s.log.Error().Err(err).Msg("server is interrupted")
 		}
 		close(s.stopCh)
@@ -381,14 +609,45 @@ func (s *server) Serve() run.StopNotify {
 	return s.stopCh
 }
 
+func (s *server) newGRPCServer(opts []grpclib.ServerOption) *grpclib.Server {
+	ser := grpclib.NewServer(opts...)
+	commonv1.RegisterServiceServer(ser, &apiVersionService{})
+	streamv1.RegisterStreamServiceServer(ser, s.streamSVC)
+	measurev1.RegisterMeasureServiceServer(ser, s.measureSVC)
+	databasev1.RegisterGroupRegistryServiceServer(ser, s.groupRegistryServer)
+	databasev1.RegisterIndexRuleBindingRegistryServiceServer(ser, s.indexRuleBindingRegistryServer)
+	databasev1.RegisterIndexRuleRegistryServiceServer(ser, s.indexRuleRegistryServer)
+	databasev1.RegisterStreamRegistryServiceServer(ser, s.streamRegistryServer)
+	databasev1.RegisterMeasureRegistryServiceServer(ser, s.measureRegistryServer)
+	propertyv1.RegisterPropertyServiceServer(ser, s.propertyServer)
+	databasev1.RegisterTopNAggregationRegistryServiceServer(ser, s.topNAggregationRegistryServer)
+	databasev1.RegisterSnapshotServiceServer(ser, s)
+	databasev1.RegisterWarmupServiceServer(ser, &warmupServer{pipeline: s.streamSVC.broadcaster})
+	databasev1.RegisterRetentionServiceServer(ser, &retentionServer{pipeline: s.streamSVC.broadcaster})
+	databasev1.RegisterStorageUsageServiceServer(ser, &usageServer{pipeline: s.streamSVC.broadcaster})
+	databasev1.RegisterSeriesServiceServer(ser, &seriesServer{schemaRegistry: s.schemaRepo, pipeline: s.streamSVC.broadcaster})
+	databasev1.RegisterDeletionServiceServer(ser, &deletionServer{
+		schemaRegistry: s.schemaRepo, groupRepo: s.groupRepo, pipeline: s.streamSVC.broadcaster, l: s.log.Named("deletion"),
+	})
+	databasev1.RegisterPropertyRegistryServiceServer(ser, s.propertyRegistryServer)
+	databasev1.RegisterConnectionSettingsServiceServer(ser, &connectionSettingsServer{conns: s.conns})
+	databasev1.RegisterDynamicFlagServiceServer(ser, &dynamicFlagServer{schemaRegistry: s.schemaRepo})
+	if s.otlpTraceSVC.group != "" {
+		coltracev1.RegisterTraceServiceServer(ser, s.otlpTraceSVC)
+	}
+	grpc_health_v1.RegisterHealthServer(ser, health.NewServer())
+	return ser
+}
+
 func (s *server) GracefulStop() {
 	s.log.Info().Msg("stopping")
+	node.SetPlacement(nil)
 	if s.tls && s.tlsReloader != nil {
 		s.tlsReloader.Stop()
 	}
 	stopped := make(chan struct{})
 	go func() {
-		s.ser.GracefulStop()
+		s.conns.gracefulStop()
 		if s.enableIngestionAccessLog {
 			for _, alr := range s.accessLogRecorders {
 				_ = alr.Close()
@@ -397,10 +656,10 @@ func (s *server) GracefulStop() {
 		close(stopped)
 	}()
 
-	t := time.NewTimer(10 * time.Second)
+	t := time.NewTimer(forceStopTimeout)
 	select {
 	case <-t.C:
-		s.ser.Stop()
+		s.conns.stop()
 		s.log.Info().Msg("force stopped")
 	case <-stopped:
 		t.Stop()
@@ -408,6 +667,17 @@ func (s *server) GracefulStop() {
 	}
 }
 
+// ShutdownPhase implements run.PhasedService.
+func (s *server) ShutdownPhase() run.ShutdownPhase {
+	return run.ShutdownPhaseIngress
+}
+
+// isAdmin reports whether the request comes through an admin listener.
+func isAdmin(ctx context.Context) bool {
+	p, ok := peer.FromContext(ctx)
+	return ok && listener.IsAdmin(p.LocalAddr)
+}
+
 type accessLogRecorder interface {
 	activeIngestionAccessLog(root string) error
 	Close() error
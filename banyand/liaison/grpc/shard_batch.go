@@ -0,0 +1,192 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/multierr"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/apache/skywalking-banyandb/api/data"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/banyand/queue"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+)
+
+type shardBatchKey struct {
+	node  string
+	group string
+	shard uint32
+}
+
+// shardBatch is a batch of the elements of a shard sent to a node.
+// The elements added to it keep it to learn whether they reached the node, so its requests are dropped once it's sent.
+type shardBatch struct {
+	batch  *streamv1.InternalWriteBatch
+	size   int
+	failed bool
+}
+
+// shardBatcher groups the elements by the target nodes and shards,
+// and sends the elements of a shard to a node in one message every flush interval.
+// A batch is sent ahead of the interval if it would exceed maxSize bytes. 0 maxSize means no limit.
+// It's safe to add the elements while the batches are flushed by the loop started by start.
+type shardBatcher struct {
+	lastFlush time.Time
+	err       error
+	batches   map[shardBatchKey]*shardBatch
+	interval  time.Duration
+	maxSize   int
+	mu        sync.Mutex
+}
+
+func newShardBatcher(interval time.Duration, maxSize int) *shardBatcher {
+	return &shardBatcher{
+		lastFlush: time.Now(),
+		batches:   make(map[shardBatchKey]*shardBatch),
+		interval:  interval,
+		maxSize:   maxSize,
+	}
+}
+
+// add puts iwr into the batch of its shard to the node, and returns the batch.
+// The error of the batch sent ahead of the interval is returned by the next flush.
+func (b *shardBatcher) add(ctx context.Context, publisher queue.BatchPublisher, node string, iwr *streamv1.InternalWriteRequest) *shardBatch {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	k := shardBatchKey{node: node, group: iwr.GetRequest().GetMetadata().GetGroup(), shard: iwr.GetShardId()}
+	size := proto.Size(iwr)
+	sb, ok := b.batches[k]
+	if ok && b.maxSize > 0 && sb.size+size > b.maxSize {
+		b.err = multierr.Append(b.err, b.publish(ctx, publisher, k, sb))
+		delete(b.batches, k)
+		ok = false
+	}
+	if !ok {
+		sb = &shardBatch{batch: &streamv1.InternalWriteBatch{Group: k.group, ShardId: k.shard}}
+		b.batches[k] = sb
+	}
+	sb.batch.Requests = append(sb.batch.Requests, iwr)
+	sb.size += size
+	return sb
+}
+
+// due returns true if the batches should be sent.
+func (b *shardBatcher) due(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.batches) > 0 && now.Sub(b.lastFlush) >= b.interval
+}
+
+// wait returns the duration until the next flush.
+func (b *shardBatcher) wait(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if d := b.interval - now.Sub(b.lastFlush); d > 0 {
+		return d
+	}
+	return b.interval
+}
+
+// start flushes the due batches until the returned stop is called or ctx is done,
+// so that the elements of an idle stream are sent. tick is called with the error of the flush, if any,
+// every time the batches are checked. stop waits for the running tick to finish.
+func (b *shardBatcher) start(ctx context.Context, publisher queue.BatchPublisher, tick func(error)) (stop func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		timer := time.NewTimer(b.interval)
+		defer timer.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case now := <-timer.C:
+				var err error
+				if b.due(now) {
+					err = b.flush(ctx, publisher)
+				}
+				tick(err)
+				timer.Reset(b.wait(time.Now()))
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// flush sends all the batches.
+func (b *shardBatcher) flush(ctx context.Context, publisher queue.BatchPublisher) (err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	err, b.err = b.err, nil
+	for k, sb := range b.batches {
+		err = multierr.Append(err, b.publish(ctx, publisher, k, sb))
+		delete(b.batches, k)
+	}
+	b.lastFlush = time.Now()
+	return err
+}
+
+func (b *shardBatcher) publish(ctx context.Context, publisher queue.BatchPublisher, k shardBatchKey, sb *shardBatch) error {
+	message := bus.NewBatchMessageWithNode(bus.MessageID(time.Now().UnixNano()), k.node, &streamv1.InternalWriteRequest{
+		ShardId: k.shard,
+		Batch:   sb.batch,
+	})
+	requests := len(sb.batch.GetRequests())
+	_, err := publisher.Publish(ctx, data.TopicStreamWrite, message)
+	sb.batch = nil
+	if err != nil {
+		sb.failed = true
+		return fmt.Errorf("failed to publish %d elements of the shard %d of the group %s to the node %s: %w",
+			requests, k.shard, k.group, k.node, err)
+	}
+	return nil
+}
+
+// settled returns true if all the batches are sent, and whether any of them failed.
+func (b *shardBatcher) settled(batches []*shardBatch) (sent, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, sb := range batches {
+		if sb.batch != nil {
+			return false, false
+		}
+	}
+	return true, anyFailed(batches)
+}
+
+// anyFailed returns true if any of the batches failed to be sent.
+// The batches must not be sent concurrently.
+func anyFailed(batches []*shardBatch) bool {
+	for _, sb := range batches {
+		if sb.failed {
+			return true
+		}
+	}
+	return false
+}
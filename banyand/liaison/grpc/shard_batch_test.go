@@ -0,0 +1,175 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+)
+
+type recordingPublisher struct {
+	err      error
+	messages []bus.Message
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, _ bus.Topic, messages ...bus.Message) (bus.Future, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	p.messages = append(p.messages, messages...)
+	return nil, nil
+}
+
+func (p *recordingPublisher) Close() (map[string]*common.Error, error) {
+	return nil, nil
+}
+
+func (p *recordingPublisher) batches() map[string][]*streamv1.InternalWriteBatch {
+	result := make(map[string][]*streamv1.InternalWriteBatch)
+	for _, m := range p.messages {
+		result[m.Node()] = append(result[m.Node()], m.Data().(*streamv1.InternalWriteRequest).GetBatch())
+	}
+	return result
+}
+
+func newShardWriteRequest(shard uint32, size int) *streamv1.InternalWriteRequest {
+	return &streamv1.InternalWriteRequest{ShardId: shard, Request: newBinaryWriteRequest(size)}
+}
+
+func TestShardBatcher(t *testing.T) {
+	ctx := context.Background()
+	p := &recordingPublisher{}
+	b := newShardBatcher(time.Hour, 0)
+	require.False(t, b.due(time.Now().Add(2*time.Hour)), "no batch to send")
+
+	b.add(ctx, p, "n1", newShardWriteRequest(0, 10))
+	b.add(ctx, p, "n1", newShardWriteRequest(0, 10))
+	b.add(ctx, p, "n1", newShardWriteRequest(1, 10))
+	b.add(ctx, p, "n2", newShardWriteRequest(0, 10))
+	require.Empty(t, p.messages)
+	require.False(t, b.due(time.Now()))
+	require.True(t, b.due(time.Now().Add(2*time.Hour)))
+
+	require.NoError(t, b.flush(ctx, p))
+	batches := p.batches()
+	require.Len(t, batches["n1"], 2)
+	require.Len(t, batches["n2"], 1)
+	for _, batch := range batches["n1"] {
+		assert.Equal(t, "g", batch.GetGroup())
+		if batch.GetShardId() == 0 {
+			assert.Len(t, batch.GetRequests(), 2)
+		} else {
+			assert.Len(t, batch.GetRequests(), 1)
+		}
+	}
+	require.False(t, b.due(time.Now().Add(2*time.Hour)))
+}
+
+func TestShardBatcherMaxSize(t *testing.T) {
+	ctx := context.Background()
+	p := &recordingPublisher{}
+	iwr := newShardWriteRequest(0, 100)
+	b := newShardBatcher(time.Hour, proto.Size(iwr)*2)
+	for range 3 {
+		b.add(ctx, p, "n1", newShardWriteRequest(0, 100))
+	}
+	require.Len(t, p.messages, 1, "the full batch is sent ahead of the interval")
+	require.Len(t, p.batches()["n1"][0].GetRequests(), 2)
+	require.NoError(t, b.flush(ctx, p))
+	require.Len(t, p.messages, 2)
+	require.Len(t, p.batches()["n1"][1].GetRequests(), 1)
+}
+
+type failingPublisher struct {
+	recordingPublisher
+	failedNode string
+}
+
+func (p *failingPublisher) Publish(ctx context.Context, topic bus.Topic, messages ...bus.Message) (bus.Future, error) {
+	for _, m := range messages {
+		if m.Node() == p.failedNode {
+			return nil, errors.New("unavailable")
+		}
+	}
+	return p.recordingPublisher.Publish(ctx, topic, messages...)
+}
+
+func TestShardBatcherFailure(t *testing.T) {
+	ctx := context.Background()
+	p := &failingPublisher{failedNode: "n1"}
+	iwr := newShardWriteRequest(0, 100)
+	b := newShardBatcher(time.Hour, proto.Size(iwr)*2)
+	failed := b.add(ctx, p, "n1", newShardWriteRequest(0, 100))
+	delivered := b.add(ctx, p, "n2", newShardWriteRequest(0, 100))
+	otherShard := b.add(ctx, p, "n2", newShardWriteRequest(1, 100))
+	b.add(ctx, p, "n1", newShardWriteRequest(0, 100))
+	assert.False(t, failed.failed, "the batch isn't sent yet")
+	require.Error(t, b.flush(ctx, p))
+	assert.True(t, anyFailed([]*shardBatch{delivered, failed}))
+	assert.False(t, anyFailed([]*shardBatch{delivered, otherShard}), "the delivered batches of the same node aren't failed")
+
+	// the batch sent ahead of the interval reports its error by the next flush.
+	p.failedNode = "n2"
+	sentAhead := b.add(ctx, p, "n2", newShardWriteRequest(0, 100))
+	b.add(ctx, p, "n2", newShardWriteRequest(0, 100))
+	b.add(ctx, p, "n2", newShardWriteRequest(0, 100))
+	assert.True(t, sentAhead.failed)
+	p.failedNode = ""
+	assert.Error(t, b.flush(ctx, p))
+	assert.NoError(t, b.flush(ctx, p))
+}
+
+func TestShardBatcherStart(t *testing.T) {
+	ctx := context.Background()
+	p := &recordingPublisher{}
+	b := newShardBatcher(50*time.Millisecond, 0)
+	var batches []*shardBatch
+	for range 3 {
+		batches = append(batches, b.add(ctx, p, "n1", newShardWriteRequest(0, 10)))
+	}
+	settled := make(chan struct{})
+	stop := b.start(ctx, p, func(err error) {
+		assert.NoError(t, err)
+		if sent, failed := b.settled(batches); sent && !failed {
+			select {
+			case <-settled:
+			default:
+				close(settled)
+			}
+		}
+	})
+	// no more elements are added, the idle batch is sent by the loop.
+	select {
+	case <-settled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the batch isn't sent after the interval")
+	}
+	stop()
+	require.Len(t, p.messages, 1)
+	require.Len(t, p.batches()["n1"][0].GetRequests(), 3)
+}
@@ -0,0 +1,260 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/meter"
+	"github.com/apache/skywalking-banyandb/pkg/partition"
+)
+
+const (
+	shardLoadWrite = "write"
+	shardLoadQuery = "query"
+)
+
+type shardLoadOptions struct {
+	interval    time.Duration
+	alpha       float64
+	threshold   float64
+	minRate     float64
+	persistence int
+	autoSplit   bool
+}
+
+func (o shardLoadOptions) validate() error {
+	if o.interval < 0 {
+		return errors.New("hot-shard-interval must not be negative")
+	}
+	if o.interval == 0 {
+		return nil
+	}
+	if o.threshold <= 1 {
+		return errors.New("hot-shard-threshold must be greater than 1")
+	}
+	if o.alpha <= 0 || o.alpha > 1 {
+		return errors.New("hot-shard-alpha must be in (0, 1]")
+	}
+	if o.minRate < 0 {
+		return errors.New("hot-shard-min-rate must not be negative")
+	}
+	if o.persistence <= 0 {
+		return errors.New("hot-shard-persistence must be positive")
+	}
+	return nil
+}
+
+type shardRef struct {
+	group string
+	id    uint32
+}
+
+type shardLoad struct {
+	writes     uint64
+	queries    uint64
+	writeRate  float64
+	queryRate  float64
+	hotPeriods int
+	samples    int
+	hot        bool
+}
+
+// shardLoadTracker tracks the write and the query rates of the shards, and flags a shard as hot
+// if either rate exceeds the average of the other shards in its group by the threshold times for several intervals in a row,
+// e.g. the entities of a mega service are hashed to the same shard.
+// The hot shards are split if the auto split is enabled.
+type shardLoadTracker struct {
+	l         *logger.Logger
+	groupRepo *groupRepo
+	splitter  *shardSplitter
+	hotShards meter.Counter
+	shards    map[shardRef]*shardLoad
+	opts      shardLoadOptions
+	mu        sync.Mutex
+}
+
+func newShardLoadTracker(opts shardLoadOptions, l *logger.Logger, gr *groupRepo, splitter *shardSplitter, hotShards meter.Counter) *shardLoadTracker {
+	return &shardLoadTracker{
+		l:         l,
+		groupRepo: gr,
+		splitter:  splitter,
+		hotShards: hotShards,
+		opts:      opts,
+		shards:    make(map[shardRef]*shardLoad),
+	}
+}
+
+// observeWrite counts a write to the shard.
+func (t *shardLoadTracker) observeWrite(group string, id common.ShardID) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.load(group, uint32(id)).writes++
+}
+
+// observeQuery counts a query against the shard if the criteria pin the sharding key or the entity of the schema.
+// The queries scanning all the shards load every shard evenly, which are ignored.
+func (t *shardLoadTracker) observeQuery(group, name string, entity *databasev1.Entity, shardingKey *databasev1.ShardingKey,
+	criteria *modelv1.Criteria,
+) {
+	if t == nil {
+		return
+	}
+	shardNum, ok := t.groupRepo.shardNum(group)
+	if !ok {
+		return
+	}
+	if len(shardingKey.GetTagNames()) == 0 {
+		shardingKey = &databasev1.ShardingKey{TagNames: entity.GetTagNames()}
+	}
+	id, pinned, err := partition.PinShard(name, shardingKey, criteria, shardNum)
+	if err != nil || !pinned {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.load(group, uint32(id)).queries++
+}
+
+func (t *shardLoadTracker) load(group string, id uint32) *shardLoad {
+	k := shardRef{group: group, id: id}
+	l, ok := t.shards[k]
+	if !ok {
+		l = &shardLoad{}
+		t.shards[k] = l
+	}
+	return l
+}
+
+func (t *shardLoadTracker) start(stopCh <-chan struct{}) {
+	go func() {
+		ticker := time.NewTicker(t.opts.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				t.evaluate()
+			case <-stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// evaluate closes the current interval, then splits the shards turning hot in it if the auto split is enabled.
+func (t *shardLoadTracker) evaluate() {
+	hot, loads := t.update()
+	if !t.opts.autoSplit || t.splitter == nil {
+		return
+	}
+	for _, s := range hot {
+		if t.groupRepo.split(s.group, s.id) {
+			continue
+		}
+		if err := t.splitter.split(s.group, s.id, loads); err != nil {
+			t.l.Error().Err(err).Str("group", s.group).Uint32("shard_id", s.id).Msg("fail to split the hot shard")
+		}
+	}
+}
+
+// update merges the rates of the interval into the EWMAs and returns the shards turning hot in the interval,
+// together with the loads of all the shards.
+func (t *shardLoadTracker) update() ([]shardRef, map[shardRef]float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	type total struct {
+		writeRate float64
+		queryRate float64
+	}
+	totals := make(map[string]*total)
+	for k, l := range t.shards {
+		writeRate := float64(l.writes) / t.opts.interval.Seconds()
+		queryRate := float64(l.queries) / t.opts.interval.Seconds()
+		l.writes, l.queries = 0, 0
+		if l.samples == 0 {
+			l.writeRate, l.queryRate = writeRate, queryRate
+		} else {
+			l.writeRate = t.opts.alpha*writeRate + (1-t.opts.alpha)*l.writeRate
+			l.queryRate = t.opts.alpha*queryRate + (1-t.opts.alpha)*l.queryRate
+		}
+		l.samples++
+		tt, ok := totals[k.group]
+		if !ok {
+			tt = &total{}
+			totals[k.group] = tt
+		}
+		tt.writeRate += l.writeRate
+		tt.queryRate += l.queryRate
+	}
+	var hot []shardRef
+	loads := make(map[shardRef]float64, len(t.shards))
+	for k, l := range t.shards {
+		shardNum, ok := t.groupRepo.shardNum(k.group)
+		if !ok || k.id >= shardNum {
+			delete(t.shards, k)
+			continue
+		}
+		loads[k] = l.writeRate + l.queryRate
+		var kind string
+		// a group of a single shard can't be unbalanced.
+		if shardNum > 1 {
+			switch tt := totals[k.group]; {
+			case t.hotter(l.writeRate, tt.writeRate, shardNum):
+				kind = shardLoadWrite
+			case t.hotter(l.queryRate, tt.queryRate, shardNum):
+				kind = shardLoadQuery
+			}
+		}
+		if kind == "" {
+			l.hotPeriods, l.hot = 0, false
+			if l.writeRate < t.opts.minRate/t.opts.threshold && l.queryRate < t.opts.minRate/t.opts.threshold {
+				delete(t.shards, k)
+			}
+			continue
+		}
+		l.hotPeriods++
+		// a shard lasting hot is reported once.
+		if l.hotPeriods < t.opts.persistence || l.hot {
+			continue
+		}
+		l.hot = true
+		hot = append(hot, k)
+		t.l.Warn().Str("group", k.group).Uint32("shard_id", k.id).Str("kind", kind).
+			Float64("write_rate", l.writeRate).Float64("query_rate", l.queryRate).
+			Float64("group_write_rate", totals[k.group].writeRate).Float64("group_query_rate", totals[k.group].queryRate).
+			Msg("the shard is persistently hot")
+		t.hotShards.Inc(1, k.group, strconv.FormatUint(uint64(k.id), 10), kind)
+	}
+	return hot, loads
+}
+
+// hotter reports whether the rate of a shard exceeds the average of the other shards in its group by the threshold times.
+func (t *shardLoadTracker) hotter(rate, groupRate float64, shardNum uint32) bool {
+	return rate >= t.opts.minRate && rate > (groupRate-rate)/float64(shardNum-1)*t.opts.threshold
+}
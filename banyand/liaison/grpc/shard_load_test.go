@@ -0,0 +1,114 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/partition"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+)
+
+func TestShardLoadOptionsValidate(t *testing.T) {
+	assert.NoError(t, shardLoadOptions{}.validate())
+	assert.NoError(t, shardLoadOptions{interval: time.Minute, threshold: 3, alpha: 0.3, minRate: 100, persistence: 5}.validate())
+	assert.Error(t, shardLoadOptions{interval: -time.Second}.validate())
+	assert.Error(t, shardLoadOptions{interval: time.Minute, threshold: 1, alpha: 0.3, persistence: 5}.validate())
+	assert.Error(t, shardLoadOptions{interval: time.Minute, threshold: 3, alpha: 0, persistence: 5}.validate())
+	assert.Error(t, shardLoadOptions{interval: time.Minute, threshold: 3, alpha: 0.3, persistence: 0}.validate())
+}
+
+func TestShardLoadTrackerWrites(t *testing.T) {
+	gr := &groupRepo{resourceOpts: map[string]*commonv1.ResourceOpts{
+		"sw": {ShardNum: 4},
+	}}
+	counter := &anomalyCounter{}
+	tracker := newShardLoadTracker(shardLoadOptions{
+		interval:    time.Second,
+		threshold:   3,
+		alpha:       0.5,
+		minRate:     100,
+		persistence: 2,
+	}, logger.GetLogger("test"), gr, nil, counter)
+	interval := func(hotWrites int) []shardRef {
+		for id := range uint32(4) {
+			writes := 50
+			if id == 1 {
+				writes = hotWrites
+			}
+			for range writes {
+				tracker.observeWrite("sw", common.ShardID(id))
+			}
+		}
+		hot, loads := tracker.update()
+		assert.Len(t, loads, 4)
+		return hot
+	}
+
+	assert.Empty(t, interval(60))
+	assert.Empty(t, counter.events)
+	// the shard is hot for an interval, which isn't persistent yet.
+	assert.Empty(t, interval(1000))
+	assert.Equal(t, []shardRef{{group: "sw", id: 1}}, interval(1000))
+	assert.Equal(t, []string{"sw/1/write"}, counter.events)
+	// a shard lasting hot is reported once.
+	assert.Empty(t, interval(1000))
+	assert.Len(t, counter.events, 1)
+}
+
+func TestShardLoadTrackerQueries(t *testing.T) {
+	gr := &groupRepo{resourceOpts: map[string]*commonv1.ResourceOpts{
+		"sw": {ShardNum: 4},
+	}}
+	tracker := newShardLoadTracker(shardLoadOptions{
+		interval:    time.Second,
+		threshold:   3,
+		alpha:       1,
+		minRate:     1,
+		persistence: 1,
+	}, logger.GetLogger("test"), gr, nil, &anomalyCounter{})
+	entity := &databasev1.Entity{TagNames: []string{"service_id"}}
+	eq := &modelv1.Criteria{Exp: &modelv1.Criteria_Condition{Condition: &modelv1.Condition{
+		Name:  "service_id",
+		Op:    modelv1.Condition_BINARY_OP_EQ,
+		Value: &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: "svc"}}},
+	}}}
+	for range 10 {
+		tracker.observeQuery("sw", "service_cpm", entity, nil, eq)
+		// the queries scanning all the shards aren't counted.
+		tracker.observeQuery("sw", "service_cpm", entity, nil, nil)
+		// the unknown groups are ignored.
+		tracker.observeQuery("unknown", "service_cpm", entity, nil, eq)
+	}
+
+	key, err := pbv1.EntityValues{pbv1.EntityStrValue("service_cpm"), pbv1.EntityStrValue("svc")}.ToEntity()
+	assert.NoError(t, err)
+	id, err := partition.ShardID(key.Marshal(), 4)
+	assert.NoError(t, err)
+	hot, loads := tracker.update()
+	assert.Equal(t, []shardRef{{group: "sw", id: uint32(id)}}, hot)
+	assert.Equal(t, map[shardRef]float64{{group: "sw", id: uint32(id)}: 10}, loads)
+}
@@ -0,0 +1,183 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
+	"github.com/apache/skywalking-banyandb/pkg/convert"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+)
+
+const shardSplitTimeout = 10 * time.Second
+
+var errNoSplitTarget = errors.New("no data node is available to receive the split shard")
+
+// splitNode returns the node receiving the upper half of the series of the shard if the shard is split,
+// and the node is known by the registry.
+func (s *groupRepo) splitNode(nr NodeRegistry, groupName string, shardID uint32) (string, bool) {
+	s.RWMutex.RLock()
+	r, ok := s.resourceOpts[groupName]
+	s.RWMutex.RUnlock()
+	if !ok {
+		return "", false
+	}
+	for _, ss := range r.GetShardSplits() {
+		if ss.GetShardId() != shardID {
+			continue
+		}
+		if _, known := nr.Address(ss.GetNode()); !known {
+			return "", false
+		}
+		return ss.GetNode(), true
+	}
+	return "", false
+}
+
+// split reports whether the shard of the group is split.
+func (s *groupRepo) split(groupName string, shardID uint32) bool {
+	s.RWMutex.RLock()
+	defer s.RWMutex.RUnlock()
+	return isSplit(s.resourceOpts[groupName], shardID)
+}
+
+func isSplit(opts *commonv1.ResourceOpts, shardID uint32) bool {
+	for _, ss := range opts.GetShardSplits() {
+		if ss.GetShardId() == shardID {
+			return true
+		}
+	}
+	return false
+}
+
+// upperHalf reports whether the series falls into the upper half of its shard.
+// The series ID is hashed again to be independent of the shard routing, which hashes the same entity,
+// so that the series of a shard are divided evenly.
+func upperHalf(name string, entityValues []*modelv1.TagValue) bool {
+	series := pbv1.Series{Subject: name, EntityValues: entityValues}
+	if err := series.Marshal(); err != nil {
+		return false
+	}
+	return convert.Hash(convert.Uint64ToBytes(uint64(series.ID)))&1 == 1
+}
+
+// splitStreamWrite divides the write to a split shard into the lower and the upper halves of the series.
+// Either half is nil if it's empty.
+func splitStreamWrite(writeEvent *streamv1.InternalWriteRequest) (lower, upper *streamv1.InternalWriteRequest) {
+	batch := writeEvent.GetBatch()
+	if batch == nil {
+		if upperHalf(writeEvent.GetRequest().GetMetadata().GetName(), writeEvent.GetEntityValues()) {
+			return nil, writeEvent
+		}
+		return writeEvent, nil
+	}
+	var lowerRequests, upperRequests []*streamv1.InternalWriteRequest
+	for _, req := range batch.GetRequests() {
+		if upperHalf(req.GetRequest().GetMetadata().GetName(), req.GetEntityValues()) {
+			upperRequests = append(upperRequests, req)
+			continue
+		}
+		lowerRequests = append(lowerRequests, req)
+	}
+	half := func(requests []*streamv1.InternalWriteRequest) *streamv1.InternalWriteRequest {
+		if len(requests) == 0 {
+			return nil
+		}
+		return &streamv1.InternalWriteRequest{Batch: &streamv1.InternalWriteBatch{
+			Group:    batch.GetGroup(),
+			ShardId:  batch.GetShardId(),
+			Requests: requests,
+		}}
+	}
+	return half(lowerRequests), half(upperRequests)
+}
+
+// shardSplitter splits the hot shards by appending the splits to their groups.
+// The other liaisons route the writes by the splits once they receive the updated groups.
+type shardSplitter struct {
+	l            *logger.Logger
+	groups       schema.Group
+	streamNodes  NodeRegistry
+	measureNodes NodeRegistry
+}
+
+// split moves the upper half of the series of the shard to the least loaded node which doesn't hold the shard.
+// The load of a node is the sum of the loads of the shards whose first copies it holds.
+// It does nothing if the shard is already split.
+func (s *shardSplitter) split(group string, shardID uint32, loads map[shardRef]float64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), shardSplitTimeout)
+	defer cancel()
+	g, err := s.groups.GetGroup(ctx, group)
+	if err != nil {
+		return err
+	}
+	if g.GetResourceOpts() == nil || isSplit(g.GetResourceOpts(), shardID) {
+		return nil
+	}
+	nr := s.measureNodes
+	if g.GetCatalog() == commonv1.Catalog_CATALOG_STREAM {
+		nr = s.streamNodes
+	}
+	owners := make(map[string]struct{})
+	for i := range g.GetResourceOpts().GetReplicas() + 1 {
+		nodeID, errLocate := nr.Locate(group, "", shardID, i)
+		if errLocate != nil {
+			return errLocate
+		}
+		owners[nodeID] = struct{}{}
+	}
+	nodeLoads := make(map[string]float64)
+	for k, load := range loads {
+		if nodeID, errLocate := nr.Locate(k.group, "", k.id, 0); errLocate == nil {
+			nodeLoads[nodeID] += load
+		}
+	}
+	var target string
+	for _, nodeID := range nr.Nodes() {
+		if _, ok := owners[nodeID]; ok {
+			continue
+		}
+		if target == "" || nodeLoads[nodeID] < nodeLoads[target] {
+			target = nodeID
+		}
+	}
+	if target == "" {
+		return errNoSplitTarget
+	}
+	updated := proto.Clone(g).(*commonv1.Group)
+	updated.ResourceOpts.ShardSplits = append(updated.ResourceOpts.ShardSplits, &commonv1.ShardSplit{
+		ShardId:   shardID,
+		Node:      target,
+		CreatedAt: timestamppb.Now(),
+	})
+	if err = s.groups.UpdateGroup(ctx, updated); err != nil {
+		return err
+	}
+	s.l.Info().Str("group", group).Uint32("shard_id", shardID).Str("node", target).Msg("the hot shard is split")
+	return nil
+}
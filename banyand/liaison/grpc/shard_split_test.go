@@ -0,0 +1,174 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+)
+
+// fakeNodeRegistry places the shard i of every group on the node i.
+type fakeNodeRegistry struct {
+	nodes []string
+}
+
+func (f fakeNodeRegistry) Locate(_, _ string, shardID, replicaID uint32) (string, error) {
+	return f.nodes[int(shardID+replicaID)%len(f.nodes)], nil
+}
+
+func (f fakeNodeRegistry) Address(nodeID string) (string, bool) {
+	for _, n := range f.nodes {
+		if n == nodeID {
+			return nodeID + ":17912", true
+		}
+	}
+	return "", false
+}
+
+func (f fakeNodeRegistry) Nodes() []string {
+	return f.nodes
+}
+
+func (fakeNodeRegistry) Epoch() uint64 {
+	return 0
+}
+
+func (fakeNodeRegistry) String() string {
+	return "fake"
+}
+
+func entityValues(i int) []*modelv1.TagValue {
+	return []*modelv1.TagValue{{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: fmt.Sprintf("svc-%d", i)}}}}
+}
+
+func TestUpperHalf(t *testing.T) {
+	var upper int
+	for i := range 1000 {
+		if upperHalf("service_cpm", entityValues(i)) {
+			upper++
+		}
+		assert.Equal(t, upperHalf("service_cpm", entityValues(i)), upperHalf("service_cpm", entityValues(i)))
+	}
+	assert.InDelta(t, 500, upper, 100)
+}
+
+func TestGroupRepoSplitNode(t *testing.T) {
+	gr := &groupRepo{resourceOpts: map[string]*commonv1.ResourceOpts{
+		"sw": {ShardNum: 2, ShardSplits: []*commonv1.ShardSplit{{ShardId: 1, Node: "n2"}, {ShardId: 0, Node: "gone"}}},
+	}}
+	nr := fakeNodeRegistry{nodes: []string{"n0", "n1", "n2"}}
+	nodeID, ok := gr.splitNode(nr, "sw", 1)
+	assert.True(t, ok)
+	assert.Equal(t, "n2", nodeID)
+	// the writes stay on the original nodes if the split node leaves.
+	_, ok = gr.splitNode(nr, "sw", 0)
+	assert.False(t, ok)
+	_, ok = gr.splitNode(nr, "unknown", 1)
+	assert.False(t, ok)
+	assert.True(t, gr.split("sw", 1))
+	assert.False(t, gr.split("sw", 3))
+}
+
+func TestSplitStreamWrite(t *testing.T) {
+	single := &streamv1.InternalWriteRequest{
+		EntityValues: entityValues(0),
+		Request:      &streamv1.WriteRequest{Metadata: &commonv1.Metadata{Group: "sw", Name: "segment"}},
+	}
+	lower, upper := splitStreamWrite(single)
+	if upperHalf("segment", entityValues(0)) {
+		assert.Nil(t, lower)
+		assert.Equal(t, single, upper)
+	} else {
+		assert.Equal(t, single, lower)
+		assert.Nil(t, upper)
+	}
+
+	batch := &streamv1.InternalWriteBatch{Group: "sw", ShardId: 1}
+	var upperCount int
+	for i := range 100 {
+		batch.Requests = append(batch.Requests, &streamv1.InternalWriteRequest{
+			EntityValues: entityValues(i),
+			Request:      &streamv1.WriteRequest{Metadata: &commonv1.Metadata{Group: "sw", Name: "segment"}},
+		})
+		if upperHalf("segment", entityValues(i)) {
+			upperCount++
+		}
+	}
+	lower, upper = splitStreamWrite(&streamv1.InternalWriteRequest{Batch: batch})
+	assert.Len(t, upper.GetBatch().GetRequests(), upperCount)
+	assert.Len(t, lower.GetBatch().GetRequests(), 100-upperCount)
+	assert.Equal(t, uint32(1), upper.GetBatch().GetShardId())
+	assert.Equal(t, "sw", lower.GetBatch().GetGroup())
+}
+
+func TestShardSplitter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	groups := schema.NewMockGroup(ctrl)
+	nr := fakeNodeRegistry{nodes: []string{"n0", "n1", "n2", "n3"}}
+	splitter := &shardSplitter{
+		l:            logger.GetLogger("test"),
+		groups:       groups,
+		streamNodes:  nr,
+		measureNodes: nr,
+	}
+	group := &commonv1.Group{
+		Metadata:     &commonv1.Metadata{Name: "sw"},
+		Catalog:      commonv1.Catalog_CATALOG_MEASURE,
+		ResourceOpts: &commonv1.ResourceOpts{ShardNum: 2, Replicas: 1},
+	}
+	loads := map[shardRef]float64{
+		{group: "sw", id: 1}:    1000,
+		{group: "other", id: 0}: 10,
+		{group: "other", id: 3}: 20,
+	}
+	// n1 and n2 hold the copies of shard 1, and n3 is more loaded than n0.
+	groups.EXPECT().GetGroup(gomock.Any(), "sw").Return(group, nil)
+	groups.EXPECT().UpdateGroup(gomock.Any(), gomock.Any()).DoAndReturn(func(_ any, updated *commonv1.Group) error {
+		assert.Len(t, updated.GetResourceOpts().GetShardSplits(), 1)
+		ss := updated.GetResourceOpts().GetShardSplits()[0]
+		assert.Equal(t, uint32(1), ss.GetShardId())
+		assert.Equal(t, "n0", ss.GetNode())
+		assert.NotNil(t, ss.GetCreatedAt())
+		return nil
+	})
+	assert.NoError(t, splitter.split("sw", 1, loads))
+	assert.Empty(t, group.GetResourceOpts().GetShardSplits())
+
+	// a split shard isn't split again.
+	split := &commonv1.Group{
+		Metadata:     group.GetMetadata(),
+		Catalog:      group.GetCatalog(),
+		ResourceOpts: &commonv1.ResourceOpts{ShardNum: 2, ShardSplits: []*commonv1.ShardSplit{{ShardId: 1, Node: "n3"}}},
+	}
+	groups.EXPECT().GetGroup(gomock.Any(), "sw").Return(split, nil)
+	assert.NoError(t, splitter.split("sw", 1, loads))
+
+	// no node is available if all the nodes hold the shard.
+	splitter.measureNodes = fakeNodeRegistry{nodes: []string{"n0", "n1"}}
+	groups.EXPECT().GetGroup(gomock.Any(), "sw").Return(group, nil)
+	assert.ErrorIs(t, splitter.split("sw", 1, loads), errNoSplitTarget)
+}
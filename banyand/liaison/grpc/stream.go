@@ -20,6 +20,7 @@ package grpc
 import (
 	"context"
 	"io"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -39,6 +40,8 @@ import (
 	"github.com/apache/skywalking-banyandb/pkg/logger"
 	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
 	"github.com/apache/skywalking-banyandb/pkg/query"
+	"github.com/apache/skywalking-banyandb/pkg/query/logical"
+	"github.com/apache/skywalking-banyandb/pkg/run"
 	"github.com/apache/skywalking-banyandb/pkg/timestamp"
 )
 
@@ -48,10 +51,25 @@ type streamService struct {
 	pipeline           queue.Client
 	broadcaster        queue.Client
 	*discoveryService
-	l               *logger.Logger
-	metrics         *metrics
-	writeTimeout    time.Duration
-	maxWaitDuration time.Duration
+	l                   *logger.Logger
+	metrics             *metrics
+	elementIDs          *elementIDGenerator
+	nodeID              string
+	writeRate           *writeRateDetector
+	shardLoad           *shardLoadTracker
+	seriesOrder         *seriesOrder
+	entityRegistrar     *entityRegistrar
+	writeTimeout        time.Duration
+	maxWaitDuration     time.Duration
+	maxListSize         *run.DynamicInt
+	maxPatternWildcards *run.DynamicInt
+	coldQueries         *coldQueryPool
+	maxElementSize      run.Bytes
+	maxTagValueSize     run.Bytes
+	chunkSize           run.Bytes
+	tagTypeMismatch     tagTypeMismatchPolicy
+	// shardBatchInterval is the interval to send the elements grouped by the shards. 0 disables the grouping.
+	shardBatchInterval time.Duration
 }
 
 func (s *streamService) setLogger(log *logger.Logger) {
@@ -106,64 +124,170 @@ func (s *streamService) navigateWithRetry(writeEntity *streamv1.WriteRequest) (t
 	return s.navigate(writeEntity.GetMetadata(), writeEntity.GetElement().GetTagFamilies())
 }
 
+// assignElementID replaces the element_id of the request with a generated one if the group requires.
+// It returns the generated ID, or 0 if the element_id of the request is kept.
+func (s *streamService) assignElementID(writeEntity *streamv1.WriteRequest) uint64 {
+	if s.groupRepo.elementIDSource(writeEntity.GetMetadata().GetGroup()) != commonv1.ElementIDSource_ELEMENT_ID_SOURCE_SERVER {
+		return 0
+	}
+	id := s.elementIDs.next()
+	writeEntity.Element.ElementId = formatElementID(id)
+	return id
+}
+
 func (s *streamService) publishMessages(
 	ctx context.Context,
 	publisher queue.BatchPublisher,
 	writeEntity *streamv1.WriteRequest,
 	shardID common.ShardID,
 	tagValues pbv1.EntityValues,
-) ([]string, error) {
+	elementID uint64,
+	batcher *shardBatcher,
+) (nodes []string, batches []*shardBatch, err error) {
+	s.writeRate.observe(writeEntity.Metadata.GetGroup(), tagValues)
+	s.shardLoad.observeWrite(writeEntity.Metadata.GetGroup(), shardID)
+	s.entityRegistrar.observe(commonv1.Catalog_CATALOG_STREAM, writeEntity.GetMetadata(), tagValues)
 	iwr := &streamv1.InternalWriteRequest{
 		Request:      writeEntity,
 		ShardId:      uint32(shardID),
 		EntityValues: tagValues[1:].Encode(),
+		ElementId:    elementID,
+	}
+	var chunkID uint64
+	if s.chunkSize > 0 && proto.Size(iwr) > int(s.chunkSize) {
+		chunkID = s.elementIDs.next()
+	}
+	iwrs, err := chunkElement(iwr, int(s.chunkSize), chunkID)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	copies, ok := s.groupRepo.copies(writeEntity.Metadata.GetGroup())
 	if !ok {
-		return nil, errors.New("failed to get group copies")
+		return nil, nil, errors.New("failed to get group copies")
 	}
 
-	nodes := make([]string, 0, copies)
+	nodes = make([]string, 0, copies)
 	for i := range copies {
 		nodeID, err := s.nodeRegistry.Locate(writeEntity.GetMetadata().GetGroup(), writeEntity.GetMetadata().GetName(), uint32(shardID), i)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
-		message := bus.NewBatchMessageWithNode(bus.MessageID(time.Now().UnixNano()), nodeID, iwr)
-		if _, err := publisher.Publish(ctx, data.TopicStreamWrite, message); err != nil {
-			return nil, err
+		if batcher != nil && len(iwrs) == 1 {
+			// the element learns whether it reached the node from its own batch.
+			batches = append(batches, batcher.add(ctx, publisher, nodeID, iwrs[0]))
+			nodes = append(nodes, nodeID)
+			continue
+		}
+		for _, m := range iwrs {
+			message := bus.NewBatchMessageWithNode(bus.MessageID(time.Now().UnixNano()), nodeID, m)
+			if _, err := publisher.Publish(ctx, data.TopicStreamWrite, message); err != nil {
+				return nil, nil, err
+			}
 		}
 		nodes = append(nodes, nodeID)
 	}
-	return nodes, nil
+	return nodes, batches, nil
+}
+
+// newShardBatcher returns nil if the elements are sent one by one.
+func (s *streamService) newShardBatcher() *shardBatcher {
+	if s.shardBatchInterval <= 0 {
+		return nil
+	}
+	return newShardBatcher(s.shardBatchInterval, int(s.chunkSize))
 }
 
 func (s *streamService) Write(stream streamv1.StreamService_WriteServer) error {
-	reply := func(metadata *commonv1.Metadata, status modelv1.Status, messageId uint64, stream streamv1.StreamService_WriteServer, logger *logger.Logger) {
-		if status != modelv1.Status_STATUS_SUCCEED {
-			s.metrics.totalStreamMsgReceivedErr.Inc(1, metadata.Group, "stream", "write")
+	// the replies of the flushed shard batches are sent by the flush loop of the batcher.
+	var sendMu sync.Mutex
+	send := func(resp *streamv1.WriteResponse, stream streamv1.StreamService_WriteServer, logger *logger.Logger) {
+		sendMu.Lock()
+		defer sendMu.Unlock()
+		if resp.Status != modelv1.Status_STATUS_SUCCEED.String() {
+			s.metrics.totalStreamMsgReceivedErr.Inc(1, resp.Metadata.Group, "stream", "write")
 		}
-		s.metrics.totalStreamMsgSent.Inc(1, metadata.Group, "stream", "write")
-		if errResp := stream.Send(&streamv1.WriteResponse{Metadata: metadata, Status: status.String(), MessageId: messageId}); errResp != nil {
+		s.metrics.totalStreamMsgSent.Inc(1, resp.Metadata.Group, "stream", "write")
+		if errResp := stream.Send(resp); errResp != nil {
 			if dl := logger.Debug(); dl.Enabled() {
 				dl.Err(errResp).Msg("failed to send stream write response")
 			}
-			s.metrics.totalStreamMsgSentErr.Inc(1, metadata.Group, "stream", "write")
+			s.metrics.totalStreamMsgSentErr.Inc(1, resp.Metadata.Group, "stream", "write")
+		}
+	}
+	reply := func(metadata *commonv1.Metadata, status modelv1.Status, messageId uint64, elementID string, hint *modelv1.RoutingHint,
+		stream streamv1.StreamService_WriteServer, logger *logger.Logger,
+	) {
+		if status != modelv1.Status_STATUS_SUCCEED {
+			elementID = ""
+			hint = nil
+		}
+		send(&streamv1.WriteResponse{Metadata: metadata, Status: status.String(), MessageId: messageId, ElementId: elementID, RoutingHint: hint}, stream, logger)
+	}
+	replySent := func(ssm succeedSentMessage, status modelv1.Status) {
+		if status != modelv1.Status_STATUS_SUCCEED {
+			reply(ssm.metadata, status, ssm.messageID, "", nil, stream, s.l)
+			return
 		}
+		send(&streamv1.WriteResponse{
+			Metadata:    ssm.metadata,
+			Status:      status.String(),
+			MessageId:   ssm.messageID,
+			ElementId:   ssm.elementID,
+			RoutingHint: ssm.routingHint,
+			Reason:      ssm.reason,
+		}, stream, s.l)
 	}
 
 	s.metrics.totalStreamStarted.Inc(1, "stream", "write")
 	publisher := s.pipeline.NewBatchPublisher(s.writeTimeout)
+	batcher := s.newShardBatcher()
 	start := time.Now()
+	var succeedSentMu sync.Mutex
 	var succeedSent []succeedSentMessage
 	requestCount := 0
+	stopBatcher := func() {}
+	if batcher != nil {
+		stopBatcher = batcher.start(stream.Context(), publisher, func(errFlush error) {
+			if errFlush != nil {
+				s.l.Error().Err(errFlush).Msg("failed to send the shard batches")
+			}
+			succeedSentMu.Lock()
+			defer succeedSentMu.Unlock()
+			// reply to the elements whose batches are sent, the others wait for the next tick or the end of the stream.
+			pending := succeedSent[:0]
+			for _, ssm := range succeedSent {
+				sent, failed := false, false
+				if len(ssm.batches) > 0 {
+					sent, failed = batcher.settled(ssm.batches)
+				}
+				switch {
+				case !sent:
+					pending = append(pending, ssm)
+				case failed:
+					replySent(ssm, modelv1.Status_STATUS_INTERNAL_ERROR)
+				default:
+					replySent(ssm, modelv1.Status_STATUS_SUCCEED)
+				}
+			}
+			clear(succeedSent[len(pending):])
+			succeedSent = pending
+		})
+	}
 	defer func() {
+		stopBatcher()
+		if batcher != nil {
+			if errFlush := batcher.flush(stream.Context(), publisher); errFlush != nil {
+				s.l.Error().Err(errFlush).Msg("failed to send the shard batches")
+			}
+		}
 		cee, err := publisher.Close()
 		for _, ssm := range succeedSent {
 			code := modelv1.Status_STATUS_SUCCEED
-			if cee != nil {
+			if anyFailed(ssm.batches) {
+				code = modelv1.Status_STATUS_INTERNAL_ERROR
+			} else if cee != nil {
 				for _, node := range ssm.nodes {
 					if ce, ok := cee[node]; ok {
 						code = ce.Status()
@@ -171,7 +295,7 @@ func (s *streamService) Write(stream streamv1.StreamService_WriteServer) error {
 					}
 				}
 			}
-			reply(ssm.metadata, code, ssm.messageID, stream, s.l)
+			replySent(ssm, code)
 		}
 		if err != nil {
 			s.l.Error().Err(err).Msg("failed to close the publisher")
@@ -204,9 +328,15 @@ func (s *streamService) Write(stream streamv1.StreamService_WriteServer) error {
 
 		requestCount++
 		s.metrics.totalStreamMsgReceived.Inc(1, writeEntity.Metadata.Group, "stream", "write")
+		s.sampleStreamWrite(writeEntity)
 
 		if err = s.validateTimestamp(writeEntity); err != nil {
-			reply(writeEntity.GetMetadata(), modelv1.Status_STATUS_INVALID_TIMESTAMP, writeEntity.GetMessageId(), stream, s.l)
+			reply(writeEntity.GetMetadata(), modelv1.Status_STATUS_INVALID_TIMESTAMP, writeEntity.GetMessageId(), "", nil, stream, s.l)
+			continue
+		}
+
+		if s.groupRepo.archived(writeEntity.GetMetadata().GetGroup()) {
+			reply(writeEntity.GetMetadata(), modelv1.Status_STATUS_READ_ONLY, writeEntity.GetMessageId(), "", nil, stream, s.l)
 			continue
 		}
 
@@ -218,14 +348,51 @@ func (s *streamService) Write(stream streamv1.StreamService_WriteServer) error {
 				status = modelv1.Status_STATUS_EXPIRED_SCHEMA
 			}
 			s.l.Error().Err(err).Stringer("written", writeEntity).Msg("metadata validation failed")
-			reply(writeEntity.GetMetadata(), status, writeEntity.GetMessageId(), stream, s.l)
+			reply(writeEntity.GetMetadata(), status, writeEntity.GetMessageId(), "", nil, stream, s.l)
+			continue
+		}
+
+		s.applyTagDefaults(stream.Context(), writeEntity)
+
+		if err = s.checkElementBudget(writeEntity); err != nil {
+			s.l.Warn().Err(err).Stringer("metadata", writeEntity.GetMetadata()).Msg("reject the oversized element")
+			send(&streamv1.WriteResponse{
+				Metadata:  writeEntity.GetMetadata(),
+				Status:    modelv1.Status_STATUS_ELEMENT_TOO_LARGE.String(),
+				MessageId: writeEntity.GetMessageId(),
+				Reason:    err.Error(),
+			}, stream, s.l)
+			continue
+		}
+
+		if err = s.checkTagTypes(writeEntity); err != nil {
+			s.l.Warn().Err(err).Stringer("metadata", writeEntity.GetMetadata()).Msg("reject the element with the mismatched tag types")
+			send(&streamv1.WriteResponse{
+				Metadata:  writeEntity.GetMetadata(),
+				Status:    modelv1.Status_STATUS_INVALID_DATA.String(),
+				MessageId: writeEntity.GetMessageId(),
+				Reason:    err.Error(),
+			}, stream, s.l)
 			continue
 		}
 
+		elementID := s.assignElementID(writeEntity)
 		tagValues, shardID, err := s.navigateWithRetry(writeEntity)
 		if err != nil {
 			s.l.Error().Err(err).RawJSON("written", logger.Proto(writeEntity)).Msg("navigation failed")
-			reply(writeEntity.GetMetadata(), modelv1.Status_STATUS_INTERNAL_ERROR, writeEntity.GetMessageId(), stream, s.l)
+			reply(writeEntity.GetMetadata(), modelv1.Status_STATUS_INTERNAL_ERROR, writeEntity.GetMessageId(), "", nil, stream, s.l)
+			continue
+		}
+
+		reason, err := s.checkOrder(writeEntity, tagValues)
+		if err != nil {
+			s.l.Warn().Str("reason", reason).Stringer("metadata", writeEntity.GetMetadata()).Msg("reject the out-of-order element")
+			send(&streamv1.WriteResponse{
+				Metadata:  writeEntity.GetMetadata(),
+				Status:    modelv1.Status_STATUS_OUT_OF_ORDER.String(),
+				MessageId: writeEntity.GetMessageId(),
+				Reason:    reason,
+			}, stream, s.l)
 			continue
 		}
 
@@ -235,19 +402,107 @@ func (s *streamService) Write(stream streamv1.StreamService_WriteServer) error {
 			}
 		}
 
-		nodes, err := s.publishMessages(ctx, publisher, writeEntity, shardID, tagValues)
+		nodes, batches, err := s.publishMessages(ctx, publisher, writeEntity, shardID, tagValues, elementID, batcher)
 		if err != nil {
 			s.l.Error().Err(err).RawJSON("written", logger.Proto(writeEntity)).Msg("publishing failed")
-			reply(writeEntity.GetMetadata(), modelv1.Status_STATUS_INTERNAL_ERROR, writeEntity.GetMessageId(), stream, s.l)
+			reply(writeEntity.GetMetadata(), modelv1.Status_STATUS_INTERNAL_ERROR, writeEntity.GetMessageId(), "", nil, stream, s.l)
 			continue
 		}
+		succeed := succeedSentMessage{
+			metadata:  writeEntity.GetMetadata(),
+			messageID: writeEntity.GetMessageId(),
+			nodes:     nodes,
+			batches:   batches,
+			reason:    reason,
+		}
+		if elementID > 0 {
+			succeed.elementID = writeEntity.GetElement().GetElementId()
+		}
+		// the clients can't write a split shard directly, whose series are spread over two nodes.
+		if writeEntity.GetRoutingHint() && !s.groupRepo.split(writeEntity.GetMetadata().GetGroup(), uint32(shardID)) {
+			succeed.routingHint = routingHint(s.nodeRegistry, uint32(shardID), nodes)
+		}
+		succeedSentMu.Lock()
+		succeedSent = append(succeedSent, succeed)
+		succeedSentMu.Unlock()
+	}
+}
 
+// writeElements publishes the requests through the same path as Write, and returns
+// the number of elements which are rejected or failed to reach the data nodes.
+// It's used by the receivers which convert other protocols into stream elements.
+func (s *streamService) writeElements(ctx context.Context, requests []*streamv1.WriteRequest) (rejected int, err error) {
+	s.metrics.totalStreamStarted.Inc(1, "stream", "write")
+	publisher := s.pipeline.NewBatchPublisher(s.writeTimeout)
+	batcher := s.newShardBatcher()
+	start := time.Now()
+	var succeedSent []succeedSentMessage
+	for _, writeEntity := range requests {
+		s.metrics.totalStreamMsgReceived.Inc(1, writeEntity.Metadata.Group, "stream", "write")
+		if s.validateTimestamp(writeEntity) != nil {
+			rejected++
+			continue
+		}
+		s.applyTagDefaults(ctx, writeEntity)
+		if errSize := s.checkElementBudget(writeEntity); errSize != nil {
+			s.l.Warn().Err(errSize).Stringer("metadata", writeEntity.GetMetadata()).Msg("reject the oversized element")
+			rejected++
+			continue
+		}
+		if errType := s.checkTagTypes(writeEntity); errType != nil {
+			s.l.Warn().Err(errType).Stringer("metadata", writeEntity.GetMetadata()).Msg("reject the element with the mismatched tag types")
+			rejected++
+			continue
+		}
+		elementID := s.assignElementID(writeEntity)
+		tagValues, shardID, errNav := s.navigateWithRetry(writeEntity)
+		if errNav != nil {
+			s.l.Error().Err(errNav).RawJSON("written", logger.Proto(writeEntity)).Msg("navigation failed")
+			rejected++
+			continue
+		}
+		if reason, errOrder := s.checkOrder(writeEntity, tagValues); errOrder != nil {
+			s.l.Warn().Str("reason", reason).Stringer("metadata", writeEntity.GetMetadata()).Msg("reject the out-of-order element")
+			rejected++
+			continue
+		}
+		nodes, batches, errPub := s.publishMessages(ctx, publisher, writeEntity, shardID, tagValues, elementID, batcher)
+		if errPub != nil {
+			s.l.Error().Err(errPub).RawJSON("written", logger.Proto(writeEntity)).Msg("publishing failed")
+			rejected++
+			continue
+		}
 		succeedSent = append(succeedSent, succeedSentMessage{
 			metadata:  writeEntity.GetMetadata(),
 			messageID: writeEntity.GetMessageId(),
 			nodes:     nodes,
+			batches:   batches,
 		})
 	}
+	if batcher != nil {
+		if errFlush := batcher.flush(ctx, publisher); errFlush != nil {
+			s.l.Error().Err(errFlush).Msg("failed to send the shard batches")
+		}
+	}
+	cee, err := publisher.Close()
+	for _, ssm := range succeedSent {
+		if anyFailed(ssm.batches) {
+			rejected++
+			continue
+		}
+		for _, node := range ssm.nodes {
+			if ce, ok := cee[node]; ok && ce.Status() != modelv1.Status_STATUS_SUCCEED {
+				rejected++
+				break
+			}
+		}
+	}
+	if rejected > 0 {
+		s.metrics.totalStreamMsgReceivedErr.Inc(float64(rejected), requests[0].Metadata.Group, "stream", "write")
+	}
+	s.metrics.totalStreamFinished.Inc(1, "stream", "write")
+	s.metrics.totalStreamLatency.Inc(time.Since(start).Seconds(), "stream", "write")
+	return rejected, err
 }
 
 var emptyStreamQueryResponse = &streamv1.QueryResponse{Elements: make([]*streamv1.Element, 0)}
@@ -266,13 +521,53 @@ func (s *streamService) Query(ctx context.Context, req *streamv1.QueryRequest) (
 			s.metrics.totalLatency.Inc(time.Since(start).Seconds(), g, "stream", "query")
 		}
 	}()
+	if req.GetWithElementMetadata() && !isAdmin(ctx) {
+		return nil, status.Error(codes.PermissionDenied, "the element metadata is only available through the admin listeners")
+	}
 	timeRange := req.GetTimeRange()
 	if timeRange == nil {
-		req.TimeRange = timestamp.DefaultTimeRange
+		if req.TimeRange = s.groupRepo.defaultTimeRange(req.Groups); req.TimeRange == nil {
+			req.TimeRange = timestamp.DefaultTimeRange
+		}
 	}
 	if err = timestamp.CheckTimeRange(req.GetTimeRange()); err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, "%v is invalid :%s", req.GetTimeRange(), err)
 	}
+	if err = s.groupRepo.checkQueryLimits(req.Groups, req.GetTimeRange(), req.GetOffset(), req.GetLimit()); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	if err = logical.CheckListSize(req.GetCriteria(), s.maxListSize.Load()); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	if err = logical.CheckPatterns(req.GetCriteria(), s.maxPatternWildcards.Load()); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	if s.shardLoad != nil {
+		for _, g := range req.Groups {
+			if stm, ok := s.entityRepo.loadStream(&commonv1.Metadata{Group: g, Name: req.Name}); ok {
+				s.shardLoad.observeQuery(g, req.Name, stm.GetEntity(), stm.GetShardingKey(), req.GetCriteria())
+			}
+		}
+	}
+	coldRead, release, err := s.coldQueries.acquire(ctx, s.groupRepo.coldTiers(req.Groups, req.Stages))
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	if coldRead != nil {
+		for _, g := range req.Groups {
+			s.metrics.totalColdQuery.Inc(1, g, "stream")
+		}
+		defer func() {
+			if err != nil || resp == nil {
+				return
+			}
+			if resp == emptyStreamQueryResponse {
+				resp = &streamv1.QueryResponse{Elements: make([]*streamv1.Element, 0)}
+			}
+			resp.ColdRead = coldRead
+		}()
+	}
 	now := time.Now()
 	if req.Trace {
 		tracer, _ := query.NewTracer(ctx, now.Format(time.RFC3339Nano))
@@ -310,6 +605,44 @@ func (s *streamService) Query(ctx context.Context, req *streamv1.QueryRequest) (
 	return nil, nil
 }
 
+// FetchElements fetches the elements by the IDs returned by a previous query, which is the second phase of a two-phase query.
+func (s *streamService) FetchElements(ctx context.Context, req *streamv1.FetchElementsRequest) (*streamv1.FetchElementsResponse, error) {
+	resp, err := s.Query(ctx, &streamv1.QueryRequest{
+		Groups:     req.GetGroups(),
+		Name:       req.GetName(),
+		TimeRange:  req.GetTimeRange(),
+		Limit:      uint32(len(req.GetElementIds())),
+		Projection: req.GetProjection(),
+		Trace:      req.GetTrace(),
+		Stages:     req.GetStages(),
+		ElementIds: req.GetElementIds(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &streamv1.FetchElementsResponse{Elements: resp.GetElements(), Trace: resp.GetTrace()}, nil
+}
+
+// GetByTraceId looks up the elements by the trace ID in the trace index, instead of matching the tags.
+func (s *streamService) GetByTraceId(ctx context.Context, req *streamv1.GetByTraceIdRequest) (*streamv1.GetByTraceIdResponse, error) {
+	resp, err := s.Query(ctx, &streamv1.QueryRequest{
+		Groups:     req.GetGroups(),
+		Name:       req.GetName(),
+		TimeRange:  req.GetTimeRange(),
+		Limit:      req.GetLimit(),
+		OrderBy:    &modelv1.QueryOrder{Sort: modelv1.Sort_SORT_ASC},
+		Projection: req.GetProjection(),
+		Trace:      req.GetTrace(),
+		Stages:     req.GetStages(),
+		TraceId:    req.GetTraceId(),
+		SpanId:     req.GetSpanId(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &streamv1.GetByTraceIdResponse{Elements: resp.GetElements(), Trace: resp.GetTrace()}, nil
+}
+
 func (s *streamService) Close() error {
 	if s.ingestionAccessLog != nil {
 		return s.ingestionAccessLog.Close()
@@ -361,15 +694,25 @@ func (r *streamRedirectWriteCallback) Rev(ctx context.Context, message bus.Messa
 			continue
 		}
 
-		metadata := writeEvent.Request.GetMetadata()
-		if metadata == nil {
-			r.l.Warn().Msg("metadata is nil in InternalWriteRequest")
-			continue
-		}
-
-		group := metadata.GetGroup()
-		streamName := metadata.GetName()
+		var group, streamName string
 		shardID := writeEvent.GetShardId()
+		if batch := writeEvent.GetBatch(); batch != nil {
+			// the nodes are located by the group and the shard, so the batch is redirected as a whole.
+			if len(batch.GetRequests()) < 1 {
+				continue
+			}
+			group = batch.GetGroup()
+			streamName = batch.GetRequests()[0].GetRequest().GetMetadata().GetName()
+			shardID = batch.GetShardId()
+		} else {
+			metadata := writeEvent.Request.GetMetadata()
+			if metadata == nil {
+				r.l.Warn().Msg("metadata is nil in InternalWriteRequest")
+				continue
+			}
+			group = metadata.GetGroup()
+			streamName = metadata.GetName()
+		}
 
 		copies, ok := r.groupRepo.copies(group)
 		if !ok {
@@ -383,13 +726,29 @@ func (r *streamRedirectWriteCallback) Rev(ctx context.Context, message bus.Messa
 				r.l.Error().Err(err).Str("group", group).Str("stream", streamName).Uint32("shard", shardID).Uint32("copy", copyIdx).Msg("failed to locate node")
 				continue
 			}
-
-			msg := bus.NewBatchMessageWithNode(bus.MessageID(time.Now().UnixNano()), nodeID, writeEvent)
-			if _, err := publisher.Publish(ctx, data.TopicStreamWrite, msg); err != nil {
-				r.l.Error().Err(err).Str("node", nodeID).Msg("failed to publish message")
+			event := writeEvent
+			if copyIdx == 0 {
+				// the first copies of the upper half of the series of a split shard go to the split node.
+				if splitNodeID, split := r.groupRepo.splitNode(r.nodeRegistry, group, shardID); split {
+					var upper *streamv1.InternalWriteRequest
+					if event, upper = splitStreamWrite(writeEvent); upper != nil {
+						r.publish(ctx, publisher, splitNodeID, upper)
+					}
+					if event == nil {
+						continue
+					}
+				}
 			}
+			r.publish(ctx, publisher, nodeID, event)
 		}
 	}
 
 	return
 }
+
+func (r *streamRedirectWriteCallback) publish(ctx context.Context, publisher queue.BatchPublisher, nodeID string, writeEvent *streamv1.InternalWriteRequest) {
+	msg := bus.NewBatchMessageWithNode(bus.MessageID(time.Now().UnixNano()), nodeID, writeEvent)
+	if _, err := publisher.Publish(ctx, data.TopicStreamWrite, msg); err != nil {
+		r.l.Error().Err(err).Str("node", nodeID).Msg("failed to publish message")
+	}
+}
@@ -0,0 +1,167 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"fmt"
+	"strconv"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+)
+
+// tagTypeMismatchPolicy decides what happens to the stream tag values whose types don't match the schema.
+type tagTypeMismatchPolicy string
+
+const (
+	// tagTypeMismatchReject rejects the element with STATUS_INVALID_DATA.
+	tagTypeMismatchReject tagTypeMismatchPolicy = "reject"
+	// tagTypeMismatchCoerce converts the value to the type of the tag, and rejects the element if it can't be converted.
+	tagTypeMismatchCoerce tagTypeMismatchPolicy = "coerce"
+	// tagTypeMismatchNull writes null instead of the value, which is the default.
+	tagTypeMismatchNull tagTypeMismatchPolicy = "null"
+)
+
+func (p tagTypeMismatchPolicy) validate() error {
+	switch p {
+	case tagTypeMismatchReject, tagTypeMismatchCoerce, tagTypeMismatchNull:
+		return nil
+	default:
+		return fmt.Errorf("stream-tag-type-mismatch %q should be one of reject, coerce and null", string(p))
+	}
+}
+
+// checkTagTypes applies the tag type mismatch policy to the element, and counts the mismatched tags.
+// The element is left to the navigation if its stream isn't cached yet.
+func (s *streamService) checkTagTypes(writeEntity *streamv1.WriteRequest) error {
+	stm, ok := s.entityRepo.loadStream(writeEntity.GetMetadata())
+	if !ok {
+		return nil
+	}
+	mismatched, err := applyTagTypePolicy(stm, writeEntity.GetElement(), s.tagTypeMismatch)
+	if mismatched > 0 {
+		s.metrics.totalTagTypeMismatch.Inc(float64(mismatched), writeEntity.GetMetadata().GetGroup(), string(s.tagTypeMismatch))
+	}
+	return err
+}
+
+// applyTagTypePolicy replaces the mismatched tag values of the element in place according to the policy.
+// It returns the number of the mismatched tags, and an error locating the tag by the indexes of its family and itself
+// if the element should be rejected.
+func applyTagTypePolicy(stm *databasev1.Stream, element *streamv1.ElementValue, policy tagTypeMismatchPolicy) (mismatched int, err error) {
+	entities := make(map[string]struct{}, len(stm.GetEntity().GetTagNames()))
+	for _, name := range stm.GetEntity().GetTagNames() {
+		entities[name] = struct{}{}
+	}
+	families := element.GetTagFamilies()
+	for i, spec := range stm.GetTagFamilies() {
+		if i >= len(families) {
+			break
+		}
+		tags := families[i].GetTags()
+		for j, tagSpec := range spec.GetTags() {
+			if j >= len(tags) {
+				break
+			}
+			v := tags[j]
+			if _, isNull := v.GetValue().(*modelv1.TagValue_Null); isNull || v.GetValue() == nil || tagValueMatches(tagSpec.GetType(), v) {
+				continue
+			}
+			mismatched++
+			switch policy {
+			case tagTypeMismatchCoerce:
+				if cv, ok := coerceTagValue(tagSpec.GetType(), v); ok {
+					tags[j] = cv
+					continue
+				}
+				return mismatched, fmt.Errorf("tag family %d (%s) tag %d (%s) is %T, which can't be coerced to the type %s",
+					i, spec.GetName(), j, tagSpec.GetName(), v.GetValue(), tagSpec.GetType())
+			case tagTypeMismatchReject:
+				return mismatched, fmt.Errorf("tag family %d (%s) tag %d (%s) is %T, which doesn't match the type %s",
+					i, spec.GetName(), j, tagSpec.GetName(), v.GetValue(), tagSpec.GetType())
+			default:
+				if _, ok := entities[tagSpec.GetName()]; ok {
+					return mismatched, fmt.Errorf("tag family %d (%s) tag %d (%s) is %T, which doesn't match the type %s and can't be null as an entity tag",
+						i, spec.GetName(), j, tagSpec.GetName(), v.GetValue(), tagSpec.GetType())
+				}
+				tags[j] = pbv1.NullTagValue
+			}
+		}
+	}
+	return mismatched, nil
+}
+
+// coerceTagValue converts the value to the tag type. The scalars are wrapped into the arrays,
+// and the strings are parsed as the integers.
+func coerceTagValue(t databasev1.TagType, v *modelv1.TagValue) (*modelv1.TagValue, bool) {
+	switch t {
+	case databasev1.TagType_TAG_TYPE_STRING:
+		if iv := v.GetInt(); iv != nil {
+			return strTagValue(strconv.FormatInt(iv.GetValue(), 10)), true
+		}
+	case databasev1.TagType_TAG_TYPE_INT:
+		if sv := v.GetStr(); sv != nil {
+			if n, err := strconv.ParseInt(sv.GetValue(), 10, 64); err == nil {
+				return &modelv1.TagValue{Value: &modelv1.TagValue_Int{Int: &modelv1.Int{Value: n}}}, true
+			}
+		}
+	case databasev1.TagType_TAG_TYPE_STRING_ARRAY:
+		switch {
+		case v.GetStr() != nil:
+			return &modelv1.TagValue{Value: &modelv1.TagValue_StrArray{StrArray: &modelv1.StrArray{Value: []string{v.GetStr().GetValue()}}}}, true
+		case v.GetInt() != nil:
+			return &modelv1.TagValue{Value: &modelv1.TagValue_StrArray{StrArray: &modelv1.StrArray{
+				Value: []string{strconv.FormatInt(v.GetInt().GetValue(), 10)},
+			}}}, true
+		case v.GetIntArray() != nil:
+			arr := make([]string, len(v.GetIntArray().GetValue()))
+			for i, n := range v.GetIntArray().GetValue() {
+				arr[i] = strconv.FormatInt(n, 10)
+			}
+			return &modelv1.TagValue{Value: &modelv1.TagValue_StrArray{StrArray: &modelv1.StrArray{Value: arr}}}, true
+		}
+	case databasev1.TagType_TAG_TYPE_INT_ARRAY:
+		var strs []string
+		switch {
+		case v.GetInt() != nil:
+			return &modelv1.TagValue{Value: &modelv1.TagValue_IntArray{IntArray: &modelv1.IntArray{Value: []int64{v.GetInt().GetValue()}}}}, true
+		case v.GetStr() != nil:
+			strs = []string{v.GetStr().GetValue()}
+		case v.GetStrArray() != nil:
+			strs = v.GetStrArray().GetValue()
+		default:
+			return nil, false
+		}
+		arr := make([]int64, len(strs))
+		for i, str := range strs {
+			n, err := strconv.ParseInt(str, 10, 64)
+			if err != nil {
+				return nil, false
+			}
+			arr[i] = n
+		}
+		return &modelv1.TagValue{Value: &modelv1.TagValue_IntArray{IntArray: &modelv1.IntArray{Value: arr}}}, true
+	case databasev1.TagType_TAG_TYPE_DATA_BINARY:
+		if sv := v.GetStr(); sv != nil {
+			return &modelv1.TagValue{Value: &modelv1.TagValue_BinaryData{BinaryData: []byte(sv.GetValue())}}, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,90 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+)
+
+func TestApplyTagTypePolicy(t *testing.T) {
+	stm := &databasev1.Stream{
+		TagFamilies: []*databasev1.TagFamilySpec{{
+			Name: "searchable",
+			Tags: []*databasev1.TagSpec{
+				{Name: "service", Type: databasev1.TagType_TAG_TYPE_STRING},
+				{Name: "status", Type: databasev1.TagType_TAG_TYPE_INT},
+				{Name: "codes", Type: databasev1.TagType_TAG_TYPE_INT_ARRAY},
+			},
+		}},
+		Entity: &databasev1.Entity{TagNames: []string{"service"}},
+	}
+	str := func(v string) *modelv1.TagValue {
+		return &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: v}}}
+	}
+	num := func(v int64) *modelv1.TagValue {
+		return &modelv1.TagValue{Value: &modelv1.TagValue_Int{Int: &modelv1.Int{Value: v}}}
+	}
+	element := func(tags ...*modelv1.TagValue) *streamv1.ElementValue {
+		return &streamv1.ElementValue{TagFamilies: []*modelv1.TagFamilyForWrite{{Tags: tags}}}
+	}
+
+	for _, policy := range []tagTypeMismatchPolicy{tagTypeMismatchReject, tagTypeMismatchCoerce, tagTypeMismatchNull} {
+		n, err := applyTagTypePolicy(stm, element(str("svc"), num(200), pbv1.NullTagValue), policy)
+		require.NoError(t, err, policy)
+		assert.Zero(t, n, policy)
+	}
+
+	n, err := applyTagTypePolicy(stm, element(str("svc"), str("200")), tagTypeMismatchReject)
+	assert.Equal(t, 1, n)
+	assert.ErrorContains(t, err, "tag family 0 (searchable) tag 1 (status) is *v1.TagValue_Str, which doesn't match the type TAG_TYPE_INT")
+
+	e := element(num(1), str("200"), str("404"))
+	n, err = applyTagTypePolicy(stm, e, tagTypeMismatchCoerce)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, "1", e.TagFamilies[0].Tags[0].GetStr().GetValue())
+	assert.Equal(t, int64(200), e.TagFamilies[0].Tags[1].GetInt().GetValue())
+	assert.Equal(t, []int64{404}, e.TagFamilies[0].Tags[2].GetIntArray().GetValue())
+
+	_, err = applyTagTypePolicy(stm, element(str("svc"), str("ok")), tagTypeMismatchCoerce)
+	assert.ErrorContains(t, err, "tag family 0 (searchable) tag 1 (status) is *v1.TagValue_Str, which can't be coerced to the type TAG_TYPE_INT")
+
+	e = element(str("svc"), str("ok"))
+	n, err = applyTagTypePolicy(stm, e, tagTypeMismatchNull)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, pbv1.NullTagValue, e.TagFamilies[0].Tags[1])
+
+	_, err = applyTagTypePolicy(stm, element(num(1)), tagTypeMismatchNull)
+	assert.ErrorContains(t, err, "can't be null as an entity tag")
+}
+
+func TestTagTypeMismatchPolicyValidate(t *testing.T) {
+	for _, policy := range []tagTypeMismatchPolicy{tagTypeMismatchReject, tagTypeMismatchCoerce, tagTypeMismatchNull} {
+		assert.NoError(t, policy.validate())
+	}
+	assert.Error(t, tagTypeMismatchPolicy("drop").validate())
+}
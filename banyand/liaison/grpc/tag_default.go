@@ -0,0 +1,130 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/peer"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+)
+
+// tagDefaultSource resolves the values of the server-populated tags of a write request.
+type tagDefaultSource struct {
+	receivedAt time.Time
+	nodeID     string
+	peerAddr   string
+}
+
+func newTagDefaultSource(ctx context.Context, nodeID string) tagDefaultSource {
+	src := tagDefaultSource{
+		receivedAt: time.Now(),
+		nodeID:     nodeID,
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		src.peerAddr = p.Addr.String()
+	}
+	return src
+}
+
+func (src tagDefaultSource) value(spec *databasev1.TagSpec) *modelv1.TagValue {
+	d := spec.GetDefaultValue()
+	switch d.GetSource() {
+	case databasev1.TagDefault_SOURCE_NODE_ID:
+		if src.nodeID == "" {
+			return nil
+		}
+		return &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: src.nodeID}}}
+	case databasev1.TagDefault_SOURCE_PEER_ADDRESS:
+		if src.peerAddr == "" {
+			return nil
+		}
+		return &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: src.peerAddr}}}
+	case databasev1.TagDefault_SOURCE_RECEIVE_TIME:
+		if spec.GetType() == databasev1.TagType_TAG_TYPE_TIMESTAMP {
+			return &modelv1.TagValue{Value: &modelv1.TagValue_Timestamp{Timestamp: timestamppb.New(src.receivedAt)}}
+		}
+		return &modelv1.TagValue{Value: &modelv1.TagValue_Int{Int: &modelv1.Int{Value: src.receivedAt.UnixMilli()}}}
+	default:
+		return d.GetValue()
+	}
+}
+
+// applyTagDefaults populates the absent tags by the defaults of their specs. A tag is absent if its family or itself
+// isn't written, or its value is null. The families and the tags are extended by the null values to reach the
+// populated tags.
+func applyTagDefaults(families []*modelv1.TagFamilyForWrite, specs []*databasev1.TagFamilySpec, src tagDefaultSource) []*modelv1.TagFamilyForWrite {
+	for i, spec := range specs {
+		for j, tagSpec := range spec.GetTags() {
+			if tagSpec.GetDefaultValue() == nil {
+				continue
+			}
+			if i < len(families) && j < len(families[i].GetTags()) && !isNullTagValue(families[i].Tags[j]) {
+				continue
+			}
+			v := src.value(tagSpec)
+			if v == nil {
+				continue
+			}
+			for len(families) <= i {
+				families = append(families, &modelv1.TagFamilyForWrite{})
+			}
+			if families[i] == nil {
+				families[i] = &modelv1.TagFamilyForWrite{}
+			}
+			for len(families[i].Tags) <= j {
+				families[i].Tags = append(families[i].Tags, pbv1.NullTagValue)
+			}
+			families[i].Tags[j] = v
+		}
+	}
+	return families
+}
+
+func isNullTagValue(v *modelv1.TagValue) bool {
+	if v.GetValue() == nil {
+		return true
+	}
+	_, isNull := v.GetValue().(*modelv1.TagValue_Null)
+	return isNull
+}
+
+// applyTagDefaults populates the absent tags of the element if the stream is cached.
+func (s *streamService) applyTagDefaults(ctx context.Context, writeEntity *streamv1.WriteRequest) {
+	stm, ok := s.entityRepo.loadStream(writeEntity.GetMetadata())
+	if !ok || writeEntity.GetElement() == nil {
+		return
+	}
+	writeEntity.Element.TagFamilies = applyTagDefaults(writeEntity.Element.TagFamilies, stm.GetTagFamilies(), newTagDefaultSource(ctx, s.nodeID))
+}
+
+// applyTagDefaults populates the absent tags of the data point if the measure is cached.
+func (ms *measureService) applyTagDefaults(ctx context.Context, writeRequest *measurev1.WriteRequest) {
+	m, ok := ms.entityRepo.loadMeasure(writeRequest.GetMetadata())
+	if !ok || writeRequest.GetDataPoint() == nil {
+		return
+	}
+	writeRequest.DataPoint.TagFamilies = applyTagDefaults(writeRequest.DataPoint.TagFamilies, m.GetTagFamilies(), newTagDefaultSource(ctx, ms.nodeID))
+}
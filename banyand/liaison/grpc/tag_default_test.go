@@ -0,0 +1,102 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/peer"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+)
+
+func TestApplyTagDefaults(t *testing.T) {
+	specs := []*databasev1.TagFamilySpec{
+		{
+			Name: "default",
+			Tags: []*databasev1.TagSpec{
+				{Name: "service", Type: databasev1.TagType_TAG_TYPE_STRING},
+				{Name: "env", Type: databasev1.TagType_TAG_TYPE_STRING, DefaultValue: &databasev1.TagDefault{
+					Value: &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: "prod"}}},
+				}},
+			},
+		},
+		{
+			Name: "server",
+			Tags: []*databasev1.TagSpec{
+				{Name: "node", Type: databasev1.TagType_TAG_TYPE_STRING, DefaultValue: &databasev1.TagDefault{
+					Source: databasev1.TagDefault_SOURCE_NODE_ID,
+				}},
+				{Name: "received_ms", Type: databasev1.TagType_TAG_TYPE_INT, DefaultValue: &databasev1.TagDefault{
+					Source: databasev1.TagDefault_SOURCE_RECEIVE_TIME,
+				}},
+				{Name: "received_at", Type: databasev1.TagType_TAG_TYPE_TIMESTAMP, DefaultValue: &databasev1.TagDefault{
+					Source: databasev1.TagDefault_SOURCE_RECEIVE_TIME,
+				}},
+				{Name: "peer", Type: databasev1.TagType_TAG_TYPE_STRING, DefaultValue: &databasev1.TagDefault{
+					Source: databasev1.TagDefault_SOURCE_PEER_ADDRESS,
+				}},
+			},
+		},
+	}
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 11800}})
+	src := newTagDefaultSource(ctx, "liaison-0")
+	src.receivedAt = time.UnixMilli(1700000000000)
+
+	families := applyTagDefaults([]*modelv1.TagFamilyForWrite{{Tags: []*modelv1.TagValue{
+		{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: "svc"}}},
+	}}}, specs, src)
+	require.Len(t, families, 2)
+	require.Len(t, families[0].Tags, 2)
+	assert.Equal(t, "svc", families[0].Tags[0].GetStr().GetValue())
+	assert.Equal(t, "prod", families[0].Tags[1].GetStr().GetValue())
+	require.Len(t, families[1].Tags, 4)
+	assert.Equal(t, "liaison-0", families[1].Tags[0].GetStr().GetValue())
+	assert.Equal(t, int64(1700000000000), families[1].Tags[1].GetInt().GetValue())
+	assert.Equal(t, int64(1700000000000), families[1].Tags[2].GetTimestamp().AsTime().UnixMilli())
+	assert.Equal(t, "10.0.0.1:11800", families[1].Tags[3].GetStr().GetValue())
+}
+
+func TestApplyTagDefaultsKeepsWrittenTags(t *testing.T) {
+	specs := []*databasev1.TagFamilySpec{{
+		Name: "default",
+		Tags: []*databasev1.TagSpec{
+			{Name: "env", Type: databasev1.TagType_TAG_TYPE_STRING, DefaultValue: &databasev1.TagDefault{
+				Value: &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: "prod"}}},
+			}},
+			{Name: "peer", Type: databasev1.TagType_TAG_TYPE_STRING, DefaultValue: &databasev1.TagDefault{
+				Source: databasev1.TagDefault_SOURCE_PEER_ADDRESS,
+			}},
+		},
+	}}
+	families := applyTagDefaults([]*modelv1.TagFamilyForWrite{{Tags: []*modelv1.TagValue{
+		{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: "test"}}},
+		pbv1.NullTagValue,
+	}}}, specs, newTagDefaultSource(context.Background(), ""))
+	require.Len(t, families[0].Tags, 2)
+	assert.Equal(t, "test", families[0].Tags[0].GetStr().GetValue())
+	// the peer address is unknown without the peer in the context, so the tag stays null.
+	assert.True(t, isNullTagValue(families[0].Tags[1]))
+}
@@ -0,0 +1,63 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/apache/skywalking-banyandb/api/data"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	"github.com/apache/skywalking-banyandb/banyand/queue"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+)
+
+type usageServer struct {
+	databasev1.UnimplementedStorageUsageServiceServer
+	pipeline queue.Client
+}
+
+func (u *usageServer) Report(ctx context.Context, req *databasev1.StorageUsageServiceReportRequest) (*databasev1.StorageUsageServiceReportResponse, error) {
+	fs, err := u.pipeline.Publish(ctx, data.TopicStorageUsage, bus.NewMessage(bus.MessageID(0), req.GetGroups()))
+	if errors.Is(err, bus.ErrTopicNotExist) {
+		return nil, fmt.Errorf("this server does not support the storage usage, which is served by the data nodes")
+	}
+	if err != nil {
+		return nil, err
+	}
+	mm, err := fs.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	var result []*databasev1.GroupUsage
+	for _, m := range mm {
+		data := m.Data()
+		if data == nil {
+			continue
+		}
+		uu, ok := data.([]*databasev1.GroupUsage)
+		if !ok {
+			logger.Panicf("invalid data type %T", data)
+		}
+		result = append(result, uu...)
+	}
+	return &databasev1.StorageUsageServiceReportResponse{Groups: storage.CompleteUsage(req.GetGroups(), result)}, nil
+}
@@ -0,0 +1,63 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/apache/skywalking-banyandb/api/data"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/banyand/queue"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/schema"
+)
+
+type warmupServer struct {
+	databasev1.UnimplementedWarmupServiceServer
+	pipeline queue.Client
+}
+
+func (w *warmupServer) Warmup(ctx context.Context, req *databasev1.WarmupRequest) (*databasev1.WarmupResponse, error) {
+	fs, err := w.pipeline.Publish(ctx, data.TopicWarmup, bus.NewMessage(bus.MessageID(0), req.GetGroups()))
+	if errors.Is(err, bus.ErrTopicNotExist) {
+		return nil, fmt.Errorf("this server does not support warming up, which is served by the data nodes")
+	}
+	if err != nil {
+		return nil, err
+	}
+	mm, err := fs.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	var result []*databasev1.GroupReadiness
+	for _, m := range mm {
+		data := m.Data()
+		if data == nil {
+			continue
+		}
+		rr, ok := data.([]*databasev1.GroupReadiness)
+		if !ok {
+			logger.Panicf("invalid data type %T", data)
+		}
+		result = append(result, rr...)
+	}
+	return &databasev1.WarmupResponse{Groups: schema.CompleteReadiness(req.GetGroups(), result)}, nil
+}
@@ -0,0 +1,93 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+)
+
+// checkElementBudget rejects the element exceeding the max element size or carrying a tag value larger than
+// the max tag value size. The error locates the offending tag, whose name is resolved if the stream is cached.
+func (s *streamService) checkElementBudget(writeEntity *streamv1.WriteRequest) error {
+	if err := checkElementSize(writeEntity, int(s.maxElementSize)); err != nil {
+		return err
+	}
+	if s.maxTagValueSize <= 0 {
+		return nil
+	}
+	stm, _ := s.entityRepo.loadStream(writeEntity.GetMetadata())
+	return checkTagValueSizes(writeEntity.GetElement().GetTagFamilies(), stm.GetTagFamilies(), int(s.maxTagValueSize))
+}
+
+// checkDataPointBudget rejects the data point exceeding the max data point size or carrying a tag value larger than
+// the max tag value size. The error locates the offending tag, whose name is resolved if the measure is cached.
+func (ms *measureService) checkDataPointBudget(writeRequest *measurev1.WriteRequest) error {
+	if err := checkDataPointSize(writeRequest, int(ms.maxDataPointSize)); err != nil {
+		return err
+	}
+	if ms.maxTagValueSize <= 0 {
+		return nil
+	}
+	m, _ := ms.entityRepo.loadMeasure(writeRequest.GetMetadata())
+	return checkTagValueSizes(writeRequest.GetDataPoint().GetTagFamilies(), m.GetTagFamilies(), int(ms.maxTagValueSize))
+}
+
+// checkDataPointSize rejects the data point whose encoded size exceeds maxSize. 0 means no limit.
+func checkDataPointSize(writeRequest *measurev1.WriteRequest, maxSize int) error {
+	if maxSize <= 0 {
+		return nil
+	}
+	if size := proto.Size(writeRequest.GetDataPoint()); size > maxSize {
+		return fmt.Errorf("the data point has %d bytes, which exceeds the max data point size %d", size, maxSize)
+	}
+	return nil
+}
+
+// checkTagValueSizes rejects the first tag value whose encoded size exceeds maxSize, locating it by the indexes of
+// its family and itself. The names come from specs, which are empty if the schema is unknown. 0 means no limit.
+func checkTagValueSizes(families []*modelv1.TagFamilyForWrite, specs []*databasev1.TagFamilySpec, maxSize int) error {
+	if maxSize <= 0 {
+		return nil
+	}
+	for i, family := range families {
+		var spec *databasev1.TagFamilySpec
+		if i < len(specs) {
+			spec = specs[i]
+		}
+		for j, v := range family.GetTags() {
+			size := proto.Size(v)
+			if size <= maxSize {
+				continue
+			}
+			var tagName string
+			if tags := spec.GetTags(); j < len(tags) {
+				tagName = tags[j].GetName()
+			}
+			return fmt.Errorf("tag family %d (%s) tag %d (%s) has %d bytes, which exceeds the max tag value size %d",
+				i, spec.GetName(), j, tagName, size, maxSize)
+		}
+	}
+	return nil
+}
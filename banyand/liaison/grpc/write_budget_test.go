@@ -0,0 +1,67 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+)
+
+func TestCheckDataPointSize(t *testing.T) {
+	req := &measurev1.WriteRequest{
+		DataPoint: &measurev1.DataPointValue{
+			Timestamp: timestamppb.Now(),
+			TagFamilies: []*modelv1.TagFamilyForWrite{{Tags: []*modelv1.TagValue{
+				{Value: &modelv1.TagValue_BinaryData{BinaryData: bytes.Repeat([]byte{'a'}, 1024)}},
+			}}},
+		},
+	}
+	require.NoError(t, checkDataPointSize(req, 0))
+	require.NoError(t, checkDataPointSize(req, 2048))
+	require.ErrorContains(t, checkDataPointSize(req, 1024), "exceeds the max data point size 1024")
+}
+
+func TestCheckTagValueSizes(t *testing.T) {
+	families := newBinaryWriteRequest(1024).GetElement().GetTagFamilies()
+	specs := []*databasev1.TagFamilySpec{{
+		Name: "default",
+		Tags: []*databasev1.TagSpec{
+			{Name: "service", Type: databasev1.TagType_TAG_TYPE_STRING},
+			{Name: "payload", Type: databasev1.TagType_TAG_TYPE_DATA_BINARY},
+		},
+	}}
+	require.NoError(t, checkTagValueSizes(families, specs, 0))
+	require.NoError(t, checkTagValueSizes(families, specs, 2048))
+
+	err := checkTagValueSizes(families, specs, 1024)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "tag family 0 (default) tag 1 (payload)")
+	assert.Contains(t, err.Error(), "exceeds the max tag value size 1024")
+
+	err = checkTagValueSizes(families, nil, 1024)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "tag family 0 () tag 1 ()", "the names are unknown without the schema")
+}
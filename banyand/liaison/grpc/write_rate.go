@@ -0,0 +1,210 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/apache/skywalking-banyandb/pkg/convert"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/meter"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+)
+
+const (
+	writeRateSpike = "spike"
+	writeRateDrop  = "drop"
+
+	// writeRateWarmupSamples is the number of the intervals to build the baseline before the detection.
+	writeRateWarmupSamples = 3
+)
+
+type writeRateOptions struct {
+	interval  time.Duration
+	alpha     float64
+	threshold float64
+	minRate   float64
+	maxSeries int
+}
+
+func (o writeRateOptions) validate() error {
+	if o.interval < 0 {
+		return errors.New("write-rate-anomaly-interval must not be negative")
+	}
+	if o.interval == 0 {
+		return nil
+	}
+	if o.threshold <= 1 {
+		return errors.New("write-rate-anomaly-threshold must be greater than 1")
+	}
+	if o.alpha <= 0 || o.alpha > 1 {
+		return errors.New("write-rate-anomaly-alpha must be in (0, 1]")
+	}
+	if o.minRate < 0 || o.maxSeries < 0 {
+		return errors.New("write-rate-anomaly-min-rate and write-rate-anomaly-max-series must not be negative")
+	}
+	return nil
+}
+
+type rateState struct {
+	count     uint64
+	ewma      float64
+	samples   int
+	anomalous bool
+}
+
+type seriesRate struct {
+	group  string
+	entity string
+	rateState
+}
+
+// writeRateDetector flags the groups and the series whose write rates change drastically,
+// e.g. an agent is misconfigured to flood a series or stops reporting.
+// The rate of every interval is compared with the EWMA of the previous rates. It's anomalous
+// if it exceeds the EWMA by the threshold times, or falls below the EWMA divided by the threshold.
+type writeRateDetector struct {
+	l         *logger.Logger
+	anomalies meter.Counter
+	groups    map[string]*rateState
+	series    map[uint64]*seriesRate
+	opts      writeRateOptions
+	mu        sync.Mutex
+}
+
+func newWriteRateDetector(opts writeRateOptions, l *logger.Logger, anomalies meter.Counter) *writeRateDetector {
+	return &writeRateDetector{
+		l:         l,
+		anomalies: anomalies,
+		opts:      opts,
+		groups:    make(map[string]*rateState),
+		series:    make(map[uint64]*seriesRate),
+	}
+}
+
+// observe counts a write of the series. The entity values include the subject at first.
+func (d *writeRateDetector) observe(group string, entityValues pbv1.EntityValues) {
+	if d == nil || len(entityValues) < 1 {
+		return
+	}
+	series := pbv1.Series{Subject: entityValues[0].GetStr().GetValue(), EntityValues: entityValues[1:].Encode()}
+	var key uint64
+	trackSeries := series.Marshal() == nil
+	if trackSeries {
+		key = convert.Hash(series.Buffer)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	g, ok := d.groups[group]
+	if !ok {
+		g = &rateState{}
+		d.groups[group] = g
+	}
+	g.count++
+	if !trackSeries {
+		return
+	}
+	s, ok := d.series[key]
+	if !ok {
+		if len(d.series) >= d.opts.maxSeries {
+			return
+		}
+		s = &seriesRate{group: group, entity: entityValues.String()}
+		d.series[key] = s
+	}
+	s.count++
+}
+
+func (d *writeRateDetector) start(stopCh <-chan struct{}) {
+	go func() {
+		ticker := time.NewTicker(d.opts.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				d.evaluate()
+			case <-stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// evaluate closes the current interval and emits the anomalies.
+func (d *writeRateDetector) evaluate() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for group, g := range d.groups {
+		if rate, kind := d.update(g); kind != "" {
+			d.emit("group", group, group, rate, g.ewma, kind)
+		}
+		if d.idle(g) {
+			delete(d.groups, group)
+		}
+	}
+	for key, s := range d.series {
+		if rate, kind := d.update(&s.rateState); kind != "" {
+			d.emit("series", s.group, s.entity, rate, s.ewma, kind)
+		}
+		if d.idle(&s.rateState) {
+			delete(d.series, key)
+		}
+	}
+}
+
+// update returns the rate of the interval and the kind of the anomaly which starts in it,
+// then merges the rate into the EWMA.
+func (d *writeRateDetector) update(st *rateState) (rate float64, kind string) {
+	rate = float64(st.count) / d.opts.interval.Seconds()
+	st.count = 0
+	var current string
+	if st.samples >= writeRateWarmupSamples && st.ewma >= d.opts.minRate {
+		switch {
+		case rate > st.ewma*d.opts.threshold:
+			current = writeRateSpike
+		case rate < st.ewma/d.opts.threshold:
+			current = writeRateDrop
+		}
+	}
+	// an anomaly lasting for several intervals is reported once.
+	if current != "" && !st.anomalous {
+		kind = current
+	}
+	st.anomalous = current != ""
+	if st.samples == 0 {
+		st.ewma = rate
+	} else {
+		st.ewma = d.opts.alpha*rate + (1-d.opts.alpha)*st.ewma
+	}
+	st.samples++
+	return rate, kind
+}
+
+// idle reports whether the state stops receiving writes, which is released to bound the memory.
+func (d *writeRateDetector) idle(st *rateState) bool {
+	return st.samples > writeRateWarmupSamples && st.ewma < d.opts.minRate/d.opts.threshold
+}
+
+func (d *writeRateDetector) emit(scope, group, target string, rate, baseline float64, kind string) {
+	d.l.Warn().Str("scope", scope).Str("group", group).Str("target", target).Str("kind", kind).
+		Float64("rate", rate).Float64("baseline", baseline).Msg("the write rate changes drastically")
+	d.anomalies.Inc(1, group, scope, kind)
+}
@@ -0,0 +1,94 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+)
+
+type anomalyCounter struct {
+	events []string
+}
+
+func (c *anomalyCounter) Inc(_ float64, labelValues ...string) {
+	c.events = append(c.events, strings.Join(labelValues, "/"))
+}
+
+func (c *anomalyCounter) Delete(_ ...string) bool {
+	return false
+}
+
+func TestWriteRateOptionsValidate(t *testing.T) {
+	assert.NoError(t, writeRateOptions{}.validate())
+	assert.NoError(t, writeRateOptions{interval: time.Minute, threshold: 5, alpha: 0.3, minRate: 1, maxSeries: 10}.validate())
+	assert.Error(t, writeRateOptions{interval: -time.Second}.validate())
+	assert.Error(t, writeRateOptions{interval: time.Minute, threshold: 1, alpha: 0.3}.validate())
+	assert.Error(t, writeRateOptions{interval: time.Minute, threshold: 5, alpha: 0}.validate())
+}
+
+func TestWriteRateDetector(t *testing.T) {
+	counter := &anomalyCounter{}
+	d := newWriteRateDetector(writeRateOptions{
+		interval:  time.Second,
+		threshold: 5,
+		alpha:     0.5,
+		minRate:   1,
+		maxSeries: 2,
+	}, logger.GetLogger("test"), counter)
+	s1 := pbv1.EntityValues{pbv1.EntityStrValue("sw"), pbv1.EntityStrValue("svc1")}
+	s2 := pbv1.EntityValues{pbv1.EntityStrValue("sw"), pbv1.EntityStrValue("svc2")}
+	s3 := pbv1.EntityValues{pbv1.EntityStrValue("sw"), pbv1.EntityStrValue("svc3")}
+	write := func(entity pbv1.EntityValues, n int) {
+		for i := 0; i < n; i++ {
+			d.observe("g", entity)
+		}
+	}
+	for i := 0; i < writeRateWarmupSamples; i++ {
+		write(s1, 10)
+		write(s2, 10)
+		d.evaluate()
+	}
+	assert.Empty(t, counter.events)
+	assert.Len(t, d.series, 2)
+	write(s3, 10)
+	assert.Len(t, d.series, 2, "the series beyond the limit are not tracked")
+
+	// svc1 floods, svc2 keeps steady.
+	write(s1, 100)
+	write(s2, 10)
+	d.evaluate()
+	assert.ElementsMatch(t, []string{"g/group/spike", "g/series/spike"}, counter.events)
+
+	// the lasting spike is reported once.
+	write(s1, 100)
+	write(s2, 10)
+	d.evaluate()
+	assert.Len(t, counter.events, 2)
+
+	// both stop reporting.
+	counter.events = nil
+	d.evaluate()
+	assert.ElementsMatch(t, []string{"g/group/drop", "g/series/drop", "g/series/drop"}, counter.events)
+}
@@ -0,0 +1,155 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/pkg/bydbql"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+)
+
+const (
+	bydbqlPath    = "/api/v1/bydbql/query"
+	bydbqlMaxBody = 1 << 20
+)
+
+// bydbqlRequest is the body of a BanyanQL query. Group is used if the statement has no IN clause.
+type bydbqlRequest struct {
+	Query string `json:"query"`
+	Group string `json:"group"`
+}
+
+// bydbqlHandler compiles BanyanQL statements into stream or measure queries.
+// The schema is fetched on every query to resolve tag families.
+type bydbqlHandler struct {
+	streamClient          streamv1.StreamServiceClient
+	measureClient         measurev1.MeasureServiceClient
+	streamRegistryClient  databasev1.StreamRegistryServiceClient
+	measureRegistryClient databasev1.MeasureRegistryServiceClient
+	l                     *logger.Logger
+}
+
+func newBydbqlHandler(ctx context.Context, l *logger.Logger, addr string, opts []grpc.DialOption) (*bydbqlHandler, error) {
+	conn, err := newGRPCConn(ctx, l, addr, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &bydbqlHandler{
+		streamClient:          streamv1.NewStreamServiceClient(conn),
+		measureClient:         measurev1.NewMeasureServiceClient(conn),
+		streamRegistryClient:  databasev1.NewStreamRegistryServiceClient(conn),
+		measureRegistryClient: databasev1.NewMeasureRegistryServiceClient(conn),
+		l:                     l,
+	}, nil
+}
+
+func (h *bydbqlHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, req.Body, bydbqlMaxBody))
+	if err != nil {
+		h.writeStatus(w, status.New(codes.InvalidArgument, err.Error()))
+		return
+	}
+	var r bydbqlRequest
+	if err = json.Unmarshal(body, &r); err != nil {
+		h.writeStatus(w, status.New(codes.InvalidArgument, err.Error()))
+		return
+	}
+	q, err := bydbql.Parse(r.Query)
+	if err != nil {
+		h.writeStatus(w, status.New(codes.InvalidArgument, err.Error()))
+		return
+	}
+	if len(q.Groups) == 0 {
+		if r.Group == "" {
+			h.writeStatus(w, status.New(codes.InvalidArgument, "the group is absent in both the statement and the request"))
+			return
+		}
+		q.Groups = []string{r.Group}
+	}
+	resp, err := h.query(req.Context(), q)
+	if errors.Is(err, bydbql.ErrInvalidQuery) {
+		h.writeStatus(w, status.New(codes.InvalidArgument, err.Error()))
+		return
+	}
+	if err != nil {
+		h.writeStatus(w, status.Convert(err))
+		return
+	}
+	h.write(w, http.StatusOK, resp)
+}
+
+// writeStatus responds the error in the same form as the gRPC gateway.
+func (h *bydbqlHandler) writeStatus(w http.ResponseWriter, st *status.Status) {
+	h.write(w, runtime.HTTPStatusFromCode(st.Code()), st.Proto())
+}
+
+func (h *bydbqlHandler) write(w http.ResponseWriter, code int, msg proto.Message) {
+	data, err := protojson.Marshal(msg)
+	if err != nil {
+		h.l.Error().Err(err).Msg("failed to marshal bydbql response")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if _, err = w.Write(data); err != nil {
+		h.l.Debug().Err(err).Msg("failed to write bydbql response")
+	}
+}
+
+// query resolves the schema from the first group and sends the compiled request.
+func (h *bydbqlHandler) query(ctx context.Context, q *bydbql.Query) (proto.Message, error) {
+	md := &commonv1.Metadata{Group: q.Groups[0], Name: q.Name}
+	if q.Catalog == commonv1.Catalog_CATALOG_MEASURE {
+		resp, err := h.measureRegistryClient.Get(ctx, &databasev1.MeasureRegistryServiceGetRequest{Metadata: md})
+		if err != nil {
+			return nil, err
+		}
+		req, err := q.MeasureQuery(resp.GetMeasure(), time.Now())
+		if err != nil {
+			return nil, err
+		}
+		return h.measureClient.Query(ctx, req)
+	}
+	resp, err := h.streamRegistryClient.Get(ctx, &databasev1.StreamRegistryServiceGetRequest{Metadata: md})
+	if err != nil {
+		return nil, err
+	}
+	req, err := q.StreamQuery(resp.GetStream(), time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return h.streamClient.Query(ctx, req)
+}
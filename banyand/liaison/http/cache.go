@@ -0,0 +1,135 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// queryCachePaths are the query endpoints of the gateway, which read the data in the time ranges of the requests.
+var queryCachePaths = map[string]struct{}{
+	"/v1/measure/data":         {},
+	"/v1/measure/topn":         {},
+	"/v1/stream/data":          {},
+	"/v1/stream/data/elements": {},
+	"/v1/stream/data/trace":    {},
+}
+
+// queryCache adds the ETag and the Cache-Control headers to the responses of the query endpoints.
+// A request with a matched If-None-Match gets 304 without the body. The responses of the time ranges ending
+// before immutableAfter ago are cacheable for maxAge, and the others have to be revalidated.
+type queryCache struct {
+	maxAge         time.Duration
+	immutableAfter time.Duration
+}
+
+type queryTimeRange struct {
+	TimeRange      *struct{ End *time.Time } `json:"timeRange"`
+	TimeRangeProto *struct{ End *time.Time } `json:"time_range"`
+}
+
+func (qc queryCache) wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := queryCachePaths[r.URL.Path]; !ok || r.Method != http.MethodPost {
+			next.ServeHTTP(w, r)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		rec := &responseRecorder{header: w.Header(), status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		if rec.status != http.StatusOK {
+			w.WriteHeader(rec.status)
+			_, _ = w.Write(rec.body.Bytes())
+			return
+		}
+		etag := `"` + strconv.FormatUint(xxhash.Sum64(rec.body.Bytes()), 16) + `"`
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Cache-Control", qc.cacheControl(body, time.Now()))
+		if etagMatches(r.Header.Get("If-None-Match"), etag) {
+			w.Header().Del("Content-Length")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = w.Write(rec.body.Bytes())
+	})
+}
+
+// cacheControl allows the caches to reuse the response for maxAge if the time range of the request
+// ends before immutableAfter ago, whose data are not supposed to change.
+func (qc queryCache) cacheControl(body []byte, now time.Time) string {
+	if qc.maxAge <= 0 {
+		return "no-cache"
+	}
+	var req queryTimeRange
+	if err := json.Unmarshal(body, &req); err != nil {
+		return "no-cache"
+	}
+	tr := req.TimeRange
+	if tr == nil {
+		tr = req.TimeRangeProto
+	}
+	if tr == nil || tr.End == nil || tr.End.After(now.Add(-qc.immutableAfter)) {
+		return "no-cache"
+	}
+	return "public, max-age=" + strconv.FormatInt(int64(qc.maxAge/time.Second), 10) + ", immutable"
+}
+
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, t := range strings.Split(ifNoneMatch, ",") {
+		t = strings.TrimPrefix(strings.TrimSpace(t), "W/")
+		if t == "*" || t == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// responseRecorder buffers the response to compute its ETag before sending it.
+type responseRecorder struct {
+	header http.Header
+	body   bytes.Buffer
+	status int
+}
+
+func (rr *responseRecorder) Header() http.Header {
+	return rr.header
+}
+
+func (rr *responseRecorder) Write(p []byte) (int, error) {
+	return rr.body.Write(p)
+}
+
+func (rr *responseRecorder) WriteHeader(status int) {
+	rr.status = status
+}
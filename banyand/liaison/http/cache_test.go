@@ -0,0 +1,74 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package http
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryCacheControl(t *testing.T) {
+	now := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	qc := queryCache{maxAge: 10 * time.Minute, immutableAfter: time.Hour}
+	assert.Equal(t, "public, max-age=600, immutable",
+		qc.cacheControl([]byte(`{"timeRange":{"begin":"2026-01-01T00:00:00Z","end":"2026-01-02T10:00:00Z"}}`), now))
+	assert.Equal(t, "public, max-age=600, immutable",
+		qc.cacheControl([]byte(`{"time_range":{"end":"2026-01-02T10:00:00Z"}}`), now))
+	assert.Equal(t, "no-cache",
+		qc.cacheControl([]byte(`{"timeRange":{"end":"2026-01-02T11:30:00Z"}}`), now), "the recent data might change")
+	assert.Equal(t, "no-cache", qc.cacheControl([]byte(`{"name":"m"}`), now), "no time range")
+	assert.Equal(t, "no-cache", queryCache{immutableAfter: time.Hour}.
+		cacheControl([]byte(`{"timeRange":{"end":"2026-01-02T10:00:00Z"}}`), now), "the caching is disabled")
+}
+
+func TestQueryCacheETag(t *testing.T) {
+	var served int
+	h := queryCache{}.wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served++
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write([]byte(`{"dataPoints":[]}` + string(body)))
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/measure/data", strings.NewReader(`{}`)))
+	require.Equal(t, http.StatusOK, rec.Code)
+	etag := rec.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+	assert.Equal(t, "no-cache", rec.Header().Get("Cache-Control"))
+	assert.Equal(t, `{"dataPoints":[]}{}`, rec.Body.String(), "the handler reads the request body")
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/measure/data", strings.NewReader(`{}`))
+	req.Header.Set("If-None-Match", `"other", `+etag)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Empty(t, rec.Body.String())
+	assert.Equal(t, etag, rec.Header().Get("ETag"))
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/measure/schema", strings.NewReader(`{}`)))
+	assert.Empty(t, rec.Header().Get("ETag"), "only the query endpoints are tagged")
+	assert.Equal(t, 3, served)
+}
@@ -0,0 +1,620 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package http
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/pkg/errors"
+	otlpcommonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	otlptracev1 "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+)
+
+const (
+	jaegerAPIPath = "/jaeger/api"
+	// jaegerScanLimit caps the elements scanned to collect services, operations or trace IDs.
+	jaegerScanLimit      = 5000
+	jaegerDefaultLimit   = 20
+	jaegerSearchableTF   = "searchable"
+	jaegerStorageOnlyTF  = "storage_only"
+	jaegerPayloadTag     = "data_binary"
+	jaegerTraceIDTag     = "trace_id"
+	jaegerServiceTag     = "service_name"
+	jaegerSpanNameTag    = "span_name"
+	jaegerSpanKindTag    = "span_kind"
+	jaegerLatencyTag     = "latency"
+	jaegerTagsTag        = "tags"
+	jaegerServiceNameKey = "service.name"
+)
+
+var errJaegerTraceNotFound = errors.New("trace not found")
+
+// jaegerQuery serves Jaeger's query HTTP API on top of the stream written by the OTLP trace receiver.
+type jaegerQuery struct {
+	client   streamv1.StreamServiceClient
+	l        *logger.Logger
+	group    string
+	name     string
+	lookback time.Duration
+}
+
+func newJaegerQuery(ctx context.Context, l *logger.Logger, addr, group, name string, lookback time.Duration,
+	opts []grpc.DialOption,
+) (*jaegerQuery, error) {
+	conn, err := newGRPCConn(ctx, l, addr, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &jaegerQuery{
+		client:   streamv1.NewStreamServiceClient(conn),
+		l:        l,
+		group:    group,
+		name:     name,
+		lookback: lookback,
+	}, nil
+}
+
+func (j *jaegerQuery) register(r chi.Router) {
+	r.Route(jaegerAPIPath, func(r chi.Router) {
+		r.Get("/services", j.services)
+		r.Get("/services/{service}/operations", j.serviceOperations)
+		r.Get("/operations", j.operations)
+		r.Get("/traces", j.searchTraces)
+		r.Get("/traces/{traceID}", j.getTrace)
+	})
+}
+
+type jaegerResponse struct {
+	Data   any           `json:"data"`
+	Errors []jaegerError `json:"errors"`
+	Total  int           `json:"total"`
+	Limit  int           `json:"limit"`
+	Offset int           `json:"offset"`
+}
+
+type jaegerError struct {
+	Msg  string `json:"msg"`
+	Code int    `json:"code"`
+}
+
+type jaegerOperation struct {
+	Name     string `json:"name"`
+	SpanKind string `json:"spanKind"`
+}
+
+type jaegerTrace struct {
+	Processes map[string]jaegerProcess `json:"processes"`
+	TraceID   string                   `json:"traceID"`
+	Spans     []jaegerSpan             `json:"spans"`
+	Warnings  []string                 `json:"warnings"`
+}
+
+type jaegerSpan struct {
+	TraceID       string            `json:"traceID"`
+	SpanID        string            `json:"spanID"`
+	OperationName string            `json:"operationName"`
+	ProcessID     string            `json:"processID"`
+	References    []jaegerReference `json:"references"`
+	Tags          []jaegerKeyValue  `json:"tags"`
+	Logs          []jaegerLog       `json:"logs"`
+	Warnings      []string          `json:"warnings"`
+	StartTime     uint64            `json:"startTime"`
+	Duration      uint64            `json:"duration"`
+	Flags         uint32            `json:"flags"`
+}
+
+type jaegerReference struct {
+	RefType string `json:"refType"`
+	TraceID string `json:"traceID"`
+	SpanID  string `json:"spanID"`
+}
+
+type jaegerKeyValue struct {
+	Value any    `json:"value"`
+	Key   string `json:"key"`
+	Type  string `json:"type"`
+}
+
+type jaegerLog struct {
+	Fields    []jaegerKeyValue `json:"fields"`
+	Timestamp uint64           `json:"timestamp"`
+}
+
+type jaegerProcess struct {
+	ServiceName string           `json:"serviceName"`
+	Tags        []jaegerKeyValue `json:"tags"`
+}
+
+func (j *jaegerQuery) services(w http.ResponseWriter, r *http.Request) {
+	tr, err := parseJaegerTimeRange(r.URL.Query(), time.Now(), j.lookback)
+	if err != nil {
+		j.writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	values, err := j.distinct(r.Context(), nil, tr, jaegerServiceTag)
+	if err != nil {
+		j.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	j.writeData(w, values)
+}
+
+func (j *jaegerQuery) serviceOperations(w http.ResponseWriter, r *http.Request) {
+	tr, err := parseJaegerTimeRange(r.URL.Query(), time.Now(), j.lookback)
+	if err != nil {
+		j.writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	values, err := j.distinct(r.Context(), strCondition(jaegerServiceTag, chi.URLParam(r, "service")), tr, jaegerSpanNameTag)
+	if err != nil {
+		j.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	j.writeData(w, values)
+}
+
+func (j *jaegerQuery) operations(w http.ResponseWriter, r *http.Request) {
+	tr, err := parseJaegerTimeRange(r.URL.Query(), time.Now(), j.lookback)
+	if err != nil {
+		j.writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	criteria := strCondition(jaegerServiceTag, r.URL.Query().Get("service"))
+	resp, err := j.query(r.Context(), criteria, tr, jaegerScanLimit, false, jaegerSpanNameTag, jaegerSpanKindTag)
+	if err != nil {
+		j.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	wantKind := r.URL.Query().Get("spanKind")
+	seen := make(map[jaegerOperation]struct{})
+	ops := make([]jaegerOperation, 0)
+	for _, e := range resp.GetElements() {
+		op := jaegerOperation{
+			Name:     tagString(e, jaegerSpanNameTag),
+			SpanKind: jaegerSpanKind(tagString(e, jaegerSpanKindTag)),
+		}
+		if wantKind != "" && wantKind != op.SpanKind {
+			continue
+		}
+		if _, ok := seen[op]; ok {
+			continue
+		}
+		seen[op] = struct{}{}
+		ops = append(ops, op)
+	}
+	j.writeData(w, ops)
+}
+
+func (j *jaegerQuery) searchTraces(w http.ResponseWriter, r *http.Request) {
+	params, err := parseJaegerSearch(r, time.Now(), j.lookback)
+	if err != nil {
+		j.writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	resp, err := j.query(r.Context(), params.criteria(), params.timeRange, jaegerScanLimit, false, jaegerTraceIDTag)
+	if err != nil {
+		j.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	var traceIDs []string
+	seen := make(map[string]struct{})
+	for _, e := range resp.GetElements() {
+		id := tagString(e, jaegerTraceIDTag)
+		if _, ok := seen[id]; ok || id == "" {
+			continue
+		}
+		seen[id] = struct{}{}
+		traceIDs = append(traceIDs, id)
+		if len(traceIDs) >= params.limit {
+			break
+		}
+	}
+	traces := make([]jaegerTrace, 0, len(traceIDs))
+	for _, id := range traceIDs {
+		t, errTrace := j.fetchTrace(r.Context(), id, params.timeRange)
+		if errTrace != nil {
+			if errors.Is(errTrace, errJaegerTraceNotFound) {
+				continue
+			}
+			j.writeError(w, http.StatusInternalServerError, errTrace)
+			return
+		}
+		traces = append(traces, t)
+	}
+	j.writeData(w, traces)
+}
+
+func (j *jaegerQuery) getTrace(w http.ResponseWriter, r *http.Request) {
+	// a trace looked up by its ID only is searched in the default time range of the group, or in all the data,
+	// since it might be found by a search with a longer lookback than the default one of the API.
+	var tr *modelv1.TimeRange
+	if q := r.URL.Query(); q.Get("start") != "" || q.Get("end") != "" {
+		var err error
+		if tr, err = parseJaegerTimeRange(q, time.Now(), j.lookback); err != nil {
+			j.writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	t, err := j.fetchTrace(r.Context(), chi.URLParam(r, "traceID"), tr)
+	if errors.Is(err, errJaegerTraceNotFound) {
+		j.writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		j.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	j.writeData(w, []jaegerTrace{t})
+}
+
+func (j *jaegerQuery) fetchTrace(ctx context.Context, traceID string, tr *modelv1.TimeRange) (jaegerTrace, error) {
+	resp, err := j.query(ctx, strCondition(jaegerTraceIDTag, traceID), tr, jaegerScanLimit, true, jaegerTraceIDTag)
+	if err != nil {
+		return jaegerTrace{}, err
+	}
+	payloads := make([][]byte, 0, len(resp.GetElements()))
+	for _, e := range resp.GetElements() {
+		for _, tf := range e.GetTagFamilies() {
+			for _, t := range tf.GetTags() {
+				if t.GetKey() == jaegerPayloadTag {
+					payloads = append(payloads, t.GetValue().GetBinaryData())
+				}
+			}
+		}
+	}
+	if len(payloads) == 0 {
+		return jaegerTrace{}, errors.WithMessage(errJaegerTraceNotFound, traceID)
+	}
+	return otlpToJaegerTrace(traceID, payloads)
+}
+
+func (j *jaegerQuery) distinct(ctx context.Context, criteria *modelv1.Criteria, tr *modelv1.TimeRange, tag string) ([]string, error) {
+	resp, err := j.query(ctx, criteria, tr, jaegerScanLimit, false, tag)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]struct{})
+	values := make([]string, 0)
+	for _, e := range resp.GetElements() {
+		v := tagString(e, tag)
+		if _, ok := seen[v]; ok || v == "" {
+			continue
+		}
+		seen[v] = struct{}{}
+		values = append(values, v)
+	}
+	sort.Strings(values)
+	return values, nil
+}
+
+func (j *jaegerQuery) query(ctx context.Context, criteria *modelv1.Criteria, tr *modelv1.TimeRange,
+	limit uint32, withPayload bool, searchableTags ...string,
+) (*streamv1.QueryResponse, error) {
+	projection := &modelv1.TagProjection{TagFamilies: []*modelv1.TagProjection_TagFamily{
+		{Name: jaegerSearchableTF, Tags: searchableTags},
+	}}
+	if withPayload {
+		projection.TagFamilies = append(projection.TagFamilies,
+			&modelv1.TagProjection_TagFamily{Name: jaegerStorageOnlyTF, Tags: []string{jaegerPayloadTag}})
+	}
+	return j.client.Query(ctx, &streamv1.QueryRequest{
+		Groups:     []string{j.group},
+		Name:       j.name,
+		TimeRange:  tr,
+		Limit:      limit,
+		Criteria:   criteria,
+		Projection: projection,
+		OrderBy:    &modelv1.QueryOrder{Sort: modelv1.Sort_SORT_DESC},
+	})
+}
+
+func (j *jaegerQuery) writeData(w http.ResponseWriter, data any) {
+	total := 0
+	switch d := data.(type) {
+	case []string:
+		total = len(d)
+	case []jaegerOperation:
+		total = len(d)
+	case []jaegerTrace:
+		total = len(d)
+	}
+	j.write(w, http.StatusOK, jaegerResponse{Data: data, Total: total})
+}
+
+func (j *jaegerQuery) writeError(w http.ResponseWriter, code int, err error) {
+	if code >= http.StatusInternalServerError {
+		j.l.Error().Err(err).Msg("failed to serve jaeger query")
+	}
+	j.write(w, code, jaegerResponse{Errors: []jaegerError{{Code: code, Msg: err.Error()}}})
+}
+
+func (j *jaegerQuery) write(w http.ResponseWriter, code int, resp jaegerResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		j.l.Debug().Err(err).Msg("failed to write jaeger response")
+	}
+}
+
+type jaegerSearchParams struct {
+	timeRange   *modelv1.TimeRange
+	tags        map[string]string
+	service     string
+	operation   string
+	minDuration time.Duration
+	maxDuration time.Duration
+	limit       int
+}
+
+func parseJaegerSearch(r *http.Request, now time.Time, defaultLookback time.Duration) (*jaegerSearchParams, error) {
+	q := r.URL.Query()
+	p := &jaegerSearchParams{
+		service:   q.Get("service"),
+		operation: q.Get("operation"),
+		limit:     jaegerDefaultLimit,
+	}
+	if p.service == "" {
+		return nil, errors.New("parameter 'service' is required")
+	}
+	var err error
+	if v := q.Get("limit"); v != "" {
+		if p.limit, err = strconv.Atoi(v); err != nil || p.limit <= 0 {
+			return nil, errors.Errorf("invalid parameter 'limit': %s", v)
+		}
+	}
+	if p.timeRange, err = parseJaegerTimeRange(q, now, defaultLookback); err != nil {
+		return nil, err
+	}
+	for name, d := range map[string]*time.Duration{"minDuration": &p.minDuration, "maxDuration": &p.maxDuration} {
+		if v := q.Get(name); v != "" {
+			if *d, err = time.ParseDuration(v); err != nil {
+				return nil, errors.Errorf("invalid parameter '%s': %s", name, v)
+			}
+		}
+	}
+	if v := q.Get("tags"); v != "" {
+		if err = json.Unmarshal([]byte(v), &p.tags); err != nil {
+			return nil, errors.Errorf("invalid parameter 'tags': %s", v)
+		}
+	}
+	return p, nil
+}
+
+// parseJaegerTimeRange parses the "start" and "end" in microseconds and the "lookback" of a request.
+// The range ends at now and goes back by defaultLookback if they are absent.
+func parseJaegerTimeRange(q url.Values, now time.Time, defaultLookback time.Duration) (*modelv1.TimeRange, error) {
+	end := now
+	if v := q.Get("end"); v != "" {
+		us, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, errors.Errorf("invalid parameter 'end': %s", v)
+		}
+		end = time.UnixMicro(us)
+	}
+	lookback := defaultLookback
+	if v := q.Get("lookback"); v != "" && v != "custom" {
+		var err error
+		if lookback, err = parseJaegerLookback(v); err != nil {
+			return nil, errors.Errorf("invalid parameter 'lookback': %s", v)
+		}
+	}
+	start := end.Add(-lookback)
+	if v := q.Get("start"); v != "" {
+		us, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, errors.Errorf("invalid parameter 'start': %s", v)
+		}
+		start = time.UnixMicro(us)
+	}
+	return &modelv1.TimeRange{Begin: timestamppb.New(start), End: timestamppb.New(end)}, nil
+}
+
+// parseJaegerLookback parses a duration, which might be in days like "2d" as the Jaeger UI sends.
+func parseJaegerLookback(v string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(v, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(v)
+}
+
+func (p *jaegerSearchParams) criteria() *modelv1.Criteria {
+	conditions := []*modelv1.Criteria{strCondition(jaegerServiceTag, p.service)}
+	if p.operation != "" {
+		conditions = append(conditions, strCondition(jaegerSpanNameTag, p.operation))
+	}
+	if p.minDuration > 0 {
+		conditions = append(conditions, intCondition(jaegerLatencyTag, modelv1.Condition_BINARY_OP_GE, p.minDuration.Milliseconds()))
+	}
+	if p.maxDuration > 0 {
+		conditions = append(conditions, intCondition(jaegerLatencyTag, modelv1.Condition_BINARY_OP_LE, p.maxDuration.Milliseconds()))
+	}
+	if len(p.tags) > 0 {
+		tags := make([]string, 0, len(p.tags))
+		for k, v := range p.tags {
+			tags = append(tags, k+"="+v)
+		}
+		sort.Strings(tags)
+		conditions = append(conditions, &modelv1.Criteria{Exp: &modelv1.Criteria_Condition{Condition: &modelv1.Condition{
+			Name:  jaegerTagsTag,
+			Op:    modelv1.Condition_BINARY_OP_HAVING,
+			Value: &modelv1.TagValue{Value: &modelv1.TagValue_StrArray{StrArray: &modelv1.StrArray{Value: tags}}},
+		}}})
+	}
+	c := conditions[0]
+	for _, right := range conditions[1:] {
+		c = &modelv1.Criteria{Exp: &modelv1.Criteria_Le{Le: &modelv1.LogicalExpression{
+			Op:    modelv1.LogicalExpression_LOGICAL_OP_AND,
+			Left:  c,
+			Right: right,
+		}}}
+	}
+	return c
+}
+
+func strCondition(name, value string) *modelv1.Criteria {
+	return &modelv1.Criteria{Exp: &modelv1.Criteria_Condition{Condition: &modelv1.Condition{
+		Name:  name,
+		Op:    modelv1.Condition_BINARY_OP_EQ,
+		Value: &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: value}}},
+	}}}
+}
+
+func intCondition(name string, op modelv1.Condition_BinaryOp, value int64) *modelv1.Criteria {
+	return &modelv1.Criteria{Exp: &modelv1.Criteria_Condition{Condition: &modelv1.Condition{
+		Name:  name,
+		Op:    op,
+		Value: &modelv1.TagValue{Value: &modelv1.TagValue_Int{Int: &modelv1.Int{Value: value}}},
+	}}}
+}
+
+func tagString(e *streamv1.Element, name string) string {
+	for _, tf := range e.GetTagFamilies() {
+		for _, t := range tf.GetTags() {
+			if t.GetKey() == name {
+				return t.GetValue().GetStr().GetValue()
+			}
+		}
+	}
+	return ""
+}
+
+// jaegerSpanKind converts "SPAN_KIND_SERVER" to "server".
+func jaegerSpanKind(kind string) string {
+	kind = strings.ToLower(strings.TrimPrefix(kind, "SPAN_KIND_"))
+	if kind == "unspecified" {
+		return ""
+	}
+	return kind
+}
+
+// otlpToJaegerTrace converts the span payloads stored by the OTLP receiver into a Jaeger trace.
+func otlpToJaegerTrace(traceID string, payloads [][]byte) (jaegerTrace, error) {
+	t := jaegerTrace{TraceID: traceID, Processes: make(map[string]jaegerProcess)}
+	processIDs := make(map[string]string)
+	for _, p := range payloads {
+		rs := &otlptracev1.ResourceSpans{}
+		if err := proto.Unmarshal(p, rs); err != nil {
+			return jaegerTrace{}, errors.Wrap(err, "failed to unmarshal span payload")
+		}
+		process := jaegerProcess{Tags: make([]jaegerKeyValue, 0)}
+		for _, kv := range rs.GetResource().GetAttributes() {
+			if kv.GetKey() == jaegerServiceNameKey {
+				process.ServiceName = kv.GetValue().GetStringValue()
+				continue
+			}
+			process.Tags = append(process.Tags, jaegerKV(kv.GetKey(), kv.GetValue()))
+		}
+		pid, ok := processIDs[process.ServiceName]
+		if !ok {
+			pid = "p" + strconv.Itoa(len(processIDs)+1)
+			processIDs[process.ServiceName] = pid
+			t.Processes[pid] = process
+		}
+		for _, ss := range rs.GetScopeSpans() {
+			for _, s := range ss.GetSpans() {
+				t.Spans = append(t.Spans, otlpToJaegerSpan(s, pid))
+			}
+		}
+	}
+	sort.Slice(t.Spans, func(i, j int) bool {
+		return t.Spans[i].StartTime < t.Spans[j].StartTime
+	})
+	return t, nil
+}
+
+func otlpToJaegerSpan(s *otlptracev1.Span, processID string) jaegerSpan {
+	traceID := hex.EncodeToString(s.GetTraceId())
+	js := jaegerSpan{
+		TraceID:       traceID,
+		SpanID:        hex.EncodeToString(s.GetSpanId()),
+		OperationName: s.GetName(),
+		ProcessID:     processID,
+		Flags:         s.GetFlags(),
+		StartTime:     s.GetStartTimeUnixNano() / 1e3,
+		References:    make([]jaegerReference, 0),
+		Tags:          make([]jaegerKeyValue, 0, len(s.GetAttributes())+2),
+		Logs:          make([]jaegerLog, 0, len(s.GetEvents())),
+	}
+	if s.GetEndTimeUnixNano() > s.GetStartTimeUnixNano() {
+		js.Duration = (s.GetEndTimeUnixNano() - s.GetStartTimeUnixNano()) / 1e3
+	}
+	if len(s.GetParentSpanId()) > 0 {
+		js.References = append(js.References, jaegerReference{RefType: "CHILD_OF", TraceID: traceID, SpanID: hex.EncodeToString(s.GetParentSpanId())})
+	}
+	for _, l := range s.GetLinks() {
+		js.References = append(js.References, jaegerReference{
+			RefType: "FOLLOWS_FROM",
+			TraceID: hex.EncodeToString(l.GetTraceId()),
+			SpanID:  hex.EncodeToString(l.GetSpanId()),
+		})
+	}
+	for _, kv := range s.GetAttributes() {
+		js.Tags = append(js.Tags, jaegerKV(kv.GetKey(), kv.GetValue()))
+	}
+	if kind := jaegerSpanKind(s.GetKind().String()); kind != "" {
+		js.Tags = append(js.Tags, jaegerKeyValue{Key: "span.kind", Type: "string", Value: kind})
+	}
+	if s.GetStatus().GetCode() == otlptracev1.Status_STATUS_CODE_ERROR {
+		js.Tags = append(js.Tags, jaegerKeyValue{Key: "error", Type: "bool", Value: true})
+	}
+	for _, e := range s.GetEvents() {
+		fields := []jaegerKeyValue{{Key: "event", Type: "string", Value: e.GetName()}}
+		for _, kv := range e.GetAttributes() {
+			fields = append(fields, jaegerKV(kv.GetKey(), kv.GetValue()))
+		}
+		js.Logs = append(js.Logs, jaegerLog{Timestamp: e.GetTimeUnixNano() / 1e3, Fields: fields})
+	}
+	return js
+}
+
+func jaegerKV(key string, v *otlpcommonv1.AnyValue) jaegerKeyValue {
+	switch x := v.GetValue().(type) {
+	case *otlpcommonv1.AnyValue_BoolValue:
+		return jaegerKeyValue{Key: key, Type: "bool", Value: x.BoolValue}
+	case *otlpcommonv1.AnyValue_IntValue:
+		return jaegerKeyValue{Key: key, Type: "int64", Value: x.IntValue}
+	case *otlpcommonv1.AnyValue_DoubleValue:
+		return jaegerKeyValue{Key: key, Type: "float64", Value: x.DoubleValue}
+	case *otlpcommonv1.AnyValue_BytesValue:
+		return jaegerKeyValue{Key: key, Type: "binary", Value: x.BytesValue}
+	case *otlpcommonv1.AnyValue_StringValue:
+		return jaegerKeyValue{Key: key, Type: "string", Value: x.StringValue}
+	default:
+		return jaegerKeyValue{Key: key, Type: "string", Value: v.String()}
+	}
+}
@@ -0,0 +1,226 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	otlpcommonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	otlpresourcev1 "go.opentelemetry.io/proto/otlp/resource/v1"
+	otlptracev1 "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+)
+
+func strAttr(k, v string) *otlpcommonv1.KeyValue {
+	return &otlpcommonv1.KeyValue{Key: k, Value: &otlpcommonv1.AnyValue{Value: &otlpcommonv1.AnyValue_StringValue{StringValue: v}}}
+}
+
+func spanPayload(t *testing.T, service string, span *otlptracev1.Span) []byte {
+	data, err := proto.Marshal(&otlptracev1.ResourceSpans{
+		Resource:   &otlpresourcev1.Resource{Attributes: []*otlpcommonv1.KeyValue{strAttr("service.name", service), strAttr("host", "h1")}},
+		ScopeSpans: []*otlptracev1.ScopeSpans{{Spans: []*otlptracev1.Span{span}}},
+	})
+	require.NoError(t, err)
+	return data
+}
+
+func TestOTLPToJaegerTrace(t *testing.T) {
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	root := &otlptracev1.Span{
+		TraceId:           []byte{0x01},
+		SpanId:            []byte{0x0a},
+		Name:              "GET /users",
+		Kind:              otlptracev1.Span_SPAN_KIND_SERVER,
+		StartTimeUnixNano: uint64(start.UnixNano()),
+		EndTimeUnixNano:   uint64(start.Add(150 * time.Millisecond).UnixNano()),
+		Status:            &otlptracev1.Status{Code: otlptracev1.Status_STATUS_CODE_ERROR},
+		Events: []*otlptracev1.Span_Event{{
+			Name:         "exception",
+			TimeUnixNano: uint64(start.Add(time.Millisecond).UnixNano()),
+		}},
+	}
+	child := &otlptracev1.Span{
+		TraceId:           []byte{0x01},
+		SpanId:            []byte{0x0b},
+		ParentSpanId:      []byte{0x0a},
+		Name:              "SELECT",
+		Kind:              otlptracev1.Span_SPAN_KIND_CLIENT,
+		StartTimeUnixNano: uint64(start.Add(10 * time.Millisecond).UnixNano()),
+		EndTimeUnixNano:   uint64(start.Add(20 * time.Millisecond).UnixNano()),
+		Attributes: []*otlpcommonv1.KeyValue{
+			{Key: "db.rows", Value: &otlpcommonv1.AnyValue{Value: &otlpcommonv1.AnyValue_IntValue{IntValue: 3}}},
+		},
+	}
+
+	trace, err := otlpToJaegerTrace("01", [][]byte{spanPayload(t, "db", child), spanPayload(t, "user-svc", root)})
+	require.NoError(t, err)
+	require.Len(t, trace.Spans, 2)
+	require.Len(t, trace.Processes, 2)
+
+	s := trace.Spans[0]
+	assert.Equal(t, "0a", s.SpanID)
+	assert.Equal(t, uint64(start.UnixMicro()), s.StartTime)
+	assert.Equal(t, uint64(150000), s.Duration)
+	assert.Empty(t, s.References)
+	assert.Equal(t, []jaegerKeyValue{
+		{Key: "span.kind", Type: "string", Value: "server"},
+		{Key: "error", Type: "bool", Value: true},
+	}, s.Tags)
+	require.Len(t, s.Logs, 1)
+	assert.Equal(t, "exception", s.Logs[0].Fields[0].Value)
+	assert.Equal(t, "user-svc", trace.Processes[s.ProcessID].ServiceName)
+	assert.Equal(t, []jaegerKeyValue{{Key: "host", Type: "string", Value: "h1"}}, trace.Processes[s.ProcessID].Tags)
+
+	s = trace.Spans[1]
+	assert.Equal(t, []jaegerReference{{RefType: "CHILD_OF", TraceID: "01", SpanID: "0a"}}, s.References)
+	assert.Equal(t, jaegerKeyValue{Key: "db.rows", Type: "int64", Value: int64(3)}, s.Tags[0])
+	assert.Equal(t, "db", trace.Processes[s.ProcessID].ServiceName)
+}
+
+func TestParseJaegerSearch(t *testing.T) {
+	now := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	q := url.Values{}
+	q.Set("service", "user-svc")
+	q.Set("operation", "GET /users")
+	q.Set("minDuration", "100ms")
+	q.Set("tags", `{"http.method":"GET"}`)
+	q.Set("limit", "5")
+	q.Set("lookback", "2h")
+	p, err := parseJaegerSearch(httptest.NewRequest("GET", "/jaeger/api/traces?"+q.Encode(), nil), now, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 5, p.limit)
+	assert.True(t, now.Add(-2*time.Hour).Equal(p.timeRange.Begin.AsTime()))
+	assert.True(t, now.Equal(p.timeRange.End.AsTime()))
+
+	var conditions []*modelv1.Condition
+	var walk func(c *modelv1.Criteria)
+	walk = func(c *modelv1.Criteria) {
+		if le := c.GetLe(); le != nil {
+			assert.Equal(t, modelv1.LogicalExpression_LOGICAL_OP_AND, le.Op)
+			walk(le.Left)
+			walk(le.Right)
+			return
+		}
+		conditions = append(conditions, c.GetCondition())
+	}
+	walk(p.criteria())
+	require.Len(t, conditions, 4)
+	assert.Equal(t, "user-svc", conditions[0].Value.GetStr().GetValue())
+	assert.Equal(t, "GET /users", conditions[1].Value.GetStr().GetValue())
+	assert.Equal(t, modelv1.Condition_BINARY_OP_GE, conditions[2].Op)
+	assert.Equal(t, int64(100), conditions[2].Value.GetInt().GetValue())
+	assert.Equal(t, modelv1.Condition_BINARY_OP_HAVING, conditions[3].Op)
+	assert.Equal(t, []string{"http.method=GET"}, conditions[3].Value.GetStrArray().GetValue())
+}
+
+func TestParseJaegerSearchInvalid(t *testing.T) {
+	for _, query := range []string{"", "service=a&limit=x", "service=a&minDuration=1", "service=a&tags=x", "service=a&lookback=xd"} {
+		_, err := parseJaegerSearch(httptest.NewRequest("GET", "/jaeger/api/traces?"+query, nil), time.Now(), time.Hour)
+		assert.Error(t, err, query)
+	}
+}
+
+// fakeJaegerStream records the time ranges of the queries and returns a span of every query.
+type fakeJaegerStream struct {
+	streamv1.StreamServiceClient
+	payload    []byte
+	timeRanges []*modelv1.TimeRange
+	mu         sync.Mutex
+}
+
+func (f *fakeJaegerStream) Query(_ context.Context, in *streamv1.QueryRequest, _ ...grpc.CallOption) (*streamv1.QueryResponse, error) {
+	f.mu.Lock()
+	f.timeRanges = append(f.timeRanges, in.GetTimeRange())
+	f.mu.Unlock()
+	strValue := func(v string) *modelv1.TagValue {
+		return &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: v}}}
+	}
+	return &streamv1.QueryResponse{Elements: []*streamv1.Element{{
+		TagFamilies: []*modelv1.TagFamily{
+			{Name: jaegerSearchableTF, Tags: []*modelv1.Tag{
+				{Key: jaegerTraceIDTag, Value: strValue("01")},
+				{Key: jaegerServiceTag, Value: strValue("user-svc")},
+				{Key: jaegerSpanNameTag, Value: strValue("GET /users")},
+			}},
+			{Name: jaegerStorageOnlyTF, Tags: []*modelv1.Tag{
+				{Key: jaegerPayloadTag, Value: &modelv1.TagValue{Value: &modelv1.TagValue_BinaryData{BinaryData: f.payload}}},
+			}},
+		},
+	}}}, nil
+}
+
+func (f *fakeJaegerStream) lastTimeRange() *modelv1.TimeRange {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.timeRanges[len(f.timeRanges)-1]
+}
+
+func TestJaegerQueryTimeRange(t *testing.T) {
+	stream := &fakeJaegerStream{payload: spanPayload(t, "user-svc", &otlptracev1.Span{TraceId: []byte{0x01}, SpanId: []byte{0x0a}, Name: "GET /users"})}
+	j := &jaegerQuery{client: stream, l: logger.GetLogger("test"), group: "g", name: "s", lookback: time.Hour}
+	r := chi.NewRouter()
+	j.register(r)
+	get := func(path string) int {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, jaegerAPIPath+path, nil))
+		return w.Code
+	}
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(48 * time.Hour)
+	custom := "start=" + strconv.FormatInt(start.UnixMicro(), 10) + "&end=" + strconv.FormatInt(end.UnixMicro(), 10)
+	assertRange := func(path string) {
+		require.Equal(t, http.StatusOK, get(path), path)
+		tr := stream.lastTimeRange()
+		assert.True(t, start.Equal(tr.GetBegin().AsTime()), path)
+		assert.True(t, end.Equal(tr.GetEnd().AsTime()), path)
+	}
+
+	// the trace found by a search with a long lookback is looked up by its ID only
+	require.Equal(t, http.StatusOK, get("/traces/01"))
+	assert.Nil(t, stream.lastTimeRange())
+	assertRange("/traces/01?" + custom)
+	assertRange("/services?" + custom)
+	assertRange("/services/user-svc/operations?" + custom)
+	assertRange("/operations?service=user-svc&" + custom)
+
+	require.Equal(t, http.StatusOK, get("/services?lookback=2d"))
+	tr := stream.lastTimeRange()
+	assert.Equal(t, 48*time.Hour, tr.GetEnd().AsTime().Sub(tr.GetBegin().AsTime()))
+	require.Equal(t, http.StatusOK, get("/operations?service=user-svc"))
+	tr = stream.lastTimeRange()
+	assert.Equal(t, time.Hour, tr.GetEnd().AsTime().Sub(tr.GetBegin().AsTime()))
+
+	for _, path := range []string{"/traces/01?start=x", "/services?lookback=x", "/services/user-svc/operations?end=x", "/operations?start=x"} {
+		assert.Equal(t, http.StatusBadRequest, get(path), path)
+	}
+}
@@ -0,0 +1,289 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package http
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/prompb"
+)
+
+const (
+	promRemoteWritePath = "/api/prometheus/write"
+	// promTagFamily holds the series key and all the labels of a Prometheus series.
+	promTagFamily = "labels"
+	// promSeriesTag is the entity tag. The double underscore prefix is reserved by Prometheus,
+	// so it never collides with a user label.
+	promSeriesTag  = "__series__"
+	promValueField = "value"
+	promMaxBody    = 32 << 20
+)
+
+var nullTagValue = &modelv1.TagValue{Value: &modelv1.TagValue_Null{Null: structpb.NullValue_NULL_VALUE}}
+
+// promReceiver receives Prometheus remote-write requests and writes samples into measures.
+// Every metric name maps to a measure in the configured group. The measure is created
+// on the first sample, and new label names are appended to its tag family.
+type promReceiver struct {
+	measureClient  measurev1.MeasureServiceClient
+	registryClient databasev1.MeasureRegistryServiceClient
+	l              *logger.Logger
+	measures       map[string]*promMeasure
+	group          string
+	mu             sync.Mutex
+}
+
+// promMeasure caches the measure of a metric. Its lock serializes the registry calls
+// of the same metric, so that the other metrics aren't blocked by them.
+type promMeasure struct {
+	m  *databasev1.Measure
+	mu sync.RWMutex
+}
+
+func newPromReceiver(ctx context.Context, l *logger.Logger, addr, group string, opts []grpc.DialOption) (*promReceiver, error) {
+	conn, err := newGRPCConn(ctx, l, addr, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &promReceiver{
+		measureClient:  measurev1.NewMeasureServiceClient(conn),
+		registryClient: databasev1.NewMeasureRegistryServiceClient(conn),
+		l:              l,
+		group:          group,
+		measures:       make(map[string]*promMeasure),
+	}, nil
+}
+
+func (r *promReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, req.Body, promMaxBody))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	wr, err := prompb.DecodeSnappy(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err = r.write(req.Context(), wr); err != nil {
+		r.l.Error().Err(err).Msg("failed to write prometheus samples")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (r *promReceiver) write(ctx context.Context, wr *prompb.WriteRequest) error {
+	requests := make([]*measurev1.WriteRequest, 0, len(wr.Timeseries))
+	messageID := uint64(time.Now().UnixNano())
+	for i := range wr.Timeseries {
+		ts := &wr.Timeseries[i]
+		name := promMeasureName(ts.MetricName())
+		if name == "" || len(ts.Samples) == 0 {
+			continue
+		}
+		labels := ts.SeriesLabels()
+		m, err := r.ensureMeasure(ctx, name, labels)
+		if err != nil {
+			return err
+		}
+		tags := promTagValues(m, ts.SeriesKey(), labels)
+		for _, s := range ts.Samples {
+			messageID++
+			requests = append(requests, &measurev1.WriteRequest{
+				// the mod revision is omitted since the measure might be appended with new labels at any time
+				Metadata: &commonv1.Metadata{Group: r.group, Name: name},
+				DataPoint: &measurev1.DataPointValue{
+					Timestamp:   timestamppb.New(time.UnixMilli(s.Timestamp)),
+					TagFamilies: []*modelv1.TagFamilyForWrite{{Tags: tags}},
+					Fields: []*modelv1.FieldValue{
+						{Value: &modelv1.FieldValue_Float{Float: &modelv1.Float{Value: s.Value}}},
+					},
+				},
+				MessageId: messageID,
+			})
+		}
+	}
+	if len(requests) == 0 {
+		return nil
+	}
+	wc, err := r.measureClient.Write(ctx)
+	if err != nil {
+		return err
+	}
+	for _, wreq := range requests {
+		if err = wc.Send(wreq); err != nil {
+			return err
+		}
+	}
+	if err = wc.CloseSend(); err != nil {
+		return err
+	}
+	var failed int
+	for {
+		resp, errRecv := wc.Recv()
+		if errors.Is(errRecv, io.EOF) {
+			break
+		}
+		if errRecv != nil {
+			return errRecv
+		}
+		if resp.GetStatus() != modelv1.Status_STATUS_SUCCEED.String() {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d samples failed to be written", failed, len(requests))
+	}
+	return nil
+}
+
+// ensureMeasure returns the measure of the metric, creating it or appending missing label tags.
+func (r *promReceiver) ensureMeasure(ctx context.Context, name string, labels []prompb.Label) (*databasev1.Measure, error) {
+	r.mu.Lock()
+	pm, ok := r.measures[name]
+	if !ok {
+		pm = &promMeasure{}
+		r.measures[name] = pm
+	}
+	r.mu.Unlock()
+	pm.mu.RLock()
+	m := pm.m
+	pm.mu.RUnlock()
+	if m != nil && len(promMissingTags(m, labels)) == 0 {
+		return m, nil
+	}
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	// another writer of the metric might have synced the measure while waiting for the lock
+	if pm.m != nil && len(promMissingTags(pm.m, labels)) == 0 {
+		return pm.m, nil
+	}
+	m, err := r.syncMeasure(ctx, name, labels)
+	if err != nil {
+		return nil, err
+	}
+	pm.m = m
+	return m, nil
+}
+
+// syncMeasure fetches the measure from the registry, creating it or appending missing label tags.
+func (r *promReceiver) syncMeasure(ctx context.Context, name string, labels []prompb.Label) (*databasev1.Measure, error) {
+	md := &commonv1.Metadata{Group: r.group, Name: name}
+	resp, err := r.registryClient.Get(ctx, &databasev1.MeasureRegistryServiceGetRequest{Metadata: md})
+	if status.Code(err) == codes.NotFound {
+		if _, err = r.registryClient.Create(ctx, &databasev1.MeasureRegistryServiceCreateRequest{Measure: newPromMeasure(md, labels)}); err != nil &&
+			status.Code(err) != codes.AlreadyExists {
+			return nil, errors.WithMessagef(err, "failed to create measure %s", name)
+		}
+		resp, err = r.registryClient.Get(ctx, &databasev1.MeasureRegistryServiceGetRequest{Metadata: md})
+	}
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to get measure %s", name)
+	}
+	m := resp.GetMeasure()
+	if missing := promMissingTags(m, labels); len(missing) > 0 {
+		tf := m.TagFamilies[0]
+		for _, n := range missing {
+			tf.Tags = append(tf.Tags, &databasev1.TagSpec{Name: n, Type: databasev1.TagType_TAG_TYPE_STRING})
+		}
+		if _, err = r.registryClient.Update(ctx, &databasev1.MeasureRegistryServiceUpdateRequest{Measure: m}); err != nil {
+			return nil, errors.WithMessagef(err, "failed to append labels to measure %s", name)
+		}
+	}
+	return m, nil
+}
+
+func newPromMeasure(md *commonv1.Metadata, labels []prompb.Label) *databasev1.Measure {
+	tags := make([]*databasev1.TagSpec, 0, len(labels)+1)
+	tags = append(tags, &databasev1.TagSpec{Name: promSeriesTag, Type: databasev1.TagType_TAG_TYPE_STRING})
+	for _, l := range labels {
+		tags = append(tags, &databasev1.TagSpec{Name: l.Name, Type: databasev1.TagType_TAG_TYPE_STRING})
+	}
+	return &databasev1.Measure{
+		Metadata:    md,
+		TagFamilies: []*databasev1.TagFamilySpec{{Name: promTagFamily, Tags: tags}},
+		Fields: []*databasev1.FieldSpec{{
+			Name:              promValueField,
+			FieldType:         databasev1.FieldType_FIELD_TYPE_FLOAT,
+			EncodingMethod:    databasev1.EncodingMethod_ENCODING_METHOD_GORILLA,
+			CompressionMethod: databasev1.CompressionMethod_COMPRESSION_METHOD_ZSTD,
+		}},
+		Entity: &databasev1.Entity{TagNames: []string{promSeriesTag}},
+	}
+}
+
+func promMissingTags(m *databasev1.Measure, labels []prompb.Label) []string {
+	if len(m.GetTagFamilies()) == 0 {
+		return nil
+	}
+	known := make(map[string]struct{}, len(m.TagFamilies[0].Tags))
+	for _, t := range m.TagFamilies[0].Tags {
+		known[t.Name] = struct{}{}
+	}
+	var missing []string
+	for _, l := range labels {
+		if _, ok := known[l.Name]; !ok {
+			missing = append(missing, l.Name)
+		}
+	}
+	return missing
+}
+
+// promTagValues orders the series key and label values as the tag family of the measure.
+func promTagValues(m *databasev1.Measure, seriesKey string, labels []prompb.Label) []*modelv1.TagValue {
+	values := make(map[string]string, len(labels)+1)
+	values[promSeriesTag] = seriesKey
+	for _, l := range labels {
+		values[l.Name] = l.Value
+	}
+	specs := m.TagFamilies[0].Tags
+	tags := make([]*modelv1.TagValue, len(specs))
+	for i, t := range specs {
+		v, ok := values[t.Name]
+		if !ok {
+			tags[i] = nullTagValue
+			continue
+		}
+		tags[i] = &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: v}}}
+	}
+	return tags
+}
+
+// promMeasureName replaces the characters which are valid in metric names but not in measure names.
+func promMeasureName(metric string) string {
+	return strings.ReplaceAll(metric, ":", "_")
+}
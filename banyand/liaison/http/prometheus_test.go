@@ -0,0 +1,306 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package http
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/prompb"
+)
+
+type fakeMeasureRegistry struct {
+	databasev1.MeasureRegistryServiceClient
+	measures map[string]*databasev1.Measure
+	blocked  map[string]chan struct{}
+	calls    map[string]int
+	mu       sync.Mutex
+}
+
+func newFakeMeasureRegistry() *fakeMeasureRegistry {
+	return &fakeMeasureRegistry{
+		measures: make(map[string]*databasev1.Measure),
+		blocked:  make(map[string]chan struct{}),
+		calls:    make(map[string]int),
+	}
+}
+
+func (f *fakeMeasureRegistry) Get(ctx context.Context, in *databasev1.MeasureRegistryServiceGetRequest,
+	_ ...grpc.CallOption,
+) (*databasev1.MeasureRegistryServiceGetResponse, error) {
+	f.mu.Lock()
+	f.calls["get"]++
+	ch := f.blocked[in.Metadata.Name]
+	f.mu.Unlock()
+	if ch != nil {
+		select {
+		case <-ch:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	m, ok := f.measures[in.Metadata.Name]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "measure not found")
+	}
+	return &databasev1.MeasureRegistryServiceGetResponse{Measure: proto.Clone(m).(*databasev1.Measure)}, nil
+}
+
+func (f *fakeMeasureRegistry) Create(_ context.Context, in *databasev1.MeasureRegistryServiceCreateRequest,
+	_ ...grpc.CallOption,
+) (*databasev1.MeasureRegistryServiceCreateResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls["create"]++
+	if _, ok := f.measures[in.Measure.Metadata.Name]; ok {
+		return nil, status.Error(codes.AlreadyExists, "measure exists")
+	}
+	f.measures[in.Measure.Metadata.Name] = proto.Clone(in.Measure).(*databasev1.Measure)
+	return &databasev1.MeasureRegistryServiceCreateResponse{}, nil
+}
+
+func (f *fakeMeasureRegistry) Update(_ context.Context, in *databasev1.MeasureRegistryServiceUpdateRequest,
+	_ ...grpc.CallOption,
+) (*databasev1.MeasureRegistryServiceUpdateResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls["update"]++
+	f.measures[in.Measure.Metadata.Name] = proto.Clone(in.Measure).(*databasev1.Measure)
+	return &databasev1.MeasureRegistryServiceUpdateResponse{}, nil
+}
+
+func (f *fakeMeasureRegistry) count(call string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[call]
+}
+
+func (f *fakeMeasureRegistry) tagNames(name string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var names []string
+	for _, t := range f.measures[name].TagFamilies[0].Tags {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+type fakeMeasureService struct {
+	measurev1.MeasureServiceClient
+	status string
+	sent   []*measurev1.WriteRequest
+}
+
+func (f *fakeMeasureService) Write(_ context.Context, _ ...grpc.CallOption) (grpc.BidiStreamingClient[measurev1.WriteRequest, measurev1.WriteResponse], error) {
+	return &fakeMeasureWriteClient{s: f}, nil
+}
+
+type fakeMeasureWriteClient struct {
+	grpc.ClientStream
+	s    *fakeMeasureService
+	next int
+}
+
+func (c *fakeMeasureWriteClient) Send(req *measurev1.WriteRequest) error {
+	c.s.sent = append(c.s.sent, req)
+	return nil
+}
+
+func (c *fakeMeasureWriteClient) CloseSend() error {
+	return nil
+}
+
+func (c *fakeMeasureWriteClient) Recv() (*measurev1.WriteResponse, error) {
+	if c.next >= len(c.s.sent) {
+		return nil, io.EOF
+	}
+	req := c.s.sent[c.next]
+	c.next++
+	return &measurev1.WriteResponse{MessageId: req.MessageId, Status: c.s.status}, nil
+}
+
+func newTestPromReceiver(registry *fakeMeasureRegistry, ms *fakeMeasureService) *promReceiver {
+	return &promReceiver{
+		measureClient:  ms,
+		registryClient: registry,
+		l:              logger.GetLogger("test"),
+		group:          "prom",
+		measures:       make(map[string]*promMeasure),
+	}
+}
+
+func strValues(tags []*modelv1.TagValue) []string {
+	values := make([]string, len(tags))
+	for i, t := range tags {
+		if t.GetNull() != 0 || t.GetStr() == nil {
+			values[i] = "<null>"
+			continue
+		}
+		values[i] = t.GetStr().GetValue()
+	}
+	return values
+}
+
+func TestPromLabelsToTags(t *testing.T) {
+	labels := []prompb.Label{{Name: "instance", Value: "h1:9090"}, {Name: "job", Value: "node"}}
+	m := newPromMeasure(nil, labels)
+	require.Len(t, m.TagFamilies, 1)
+	assert.Equal(t, promTagFamily, m.TagFamilies[0].Name)
+	assert.Equal(t, []string{promSeriesTag}, m.Entity.TagNames)
+	require.Len(t, m.Fields, 1)
+	assert.Equal(t, databasev1.FieldType_FIELD_TYPE_FLOAT, m.Fields[0].FieldType)
+	assert.Empty(t, promMissingTags(m, labels))
+	assert.Equal(t, []string{"zone"}, promMissingTags(m, append(labels, prompb.Label{Name: "zone", Value: "z1"})))
+
+	// the values follow the order of the tag specs, and the absent labels are null
+	m.TagFamilies[0].Tags = append(m.TagFamilies[0].Tags, &databasev1.TagSpec{Name: "zone", Type: databasev1.TagType_TAG_TYPE_STRING})
+	key := `{instance="h1:9090",job="node"}`
+	assert.Equal(t, []string{key, "h1:9090", "node", "<null>"}, strValues(promTagValues(m, key, labels)))
+
+	assert.Equal(t, "node_cpu_seconds_total", promMeasureName("node_cpu_seconds_total"))
+	assert.Equal(t, "job_rate5m", promMeasureName("job:rate5m"))
+}
+
+func TestPromEnsureMeasure(t *testing.T) {
+	registry := newFakeMeasureRegistry()
+	r := newTestPromReceiver(registry, &fakeMeasureService{})
+	ctx := context.Background()
+
+	m, err := r.ensureMeasure(ctx, "up", []prompb.Label{{Name: "job", Value: "node"}})
+	require.NoError(t, err)
+	assert.Equal(t, "prom", m.Metadata.Group)
+	assert.Equal(t, 1, registry.count("create"))
+	assert.Equal(t, []string{promSeriesTag, "job"}, registry.tagNames("up"))
+
+	// the cached measure serves the known labels without calling the registry
+	gets := registry.count("get")
+	_, err = r.ensureMeasure(ctx, "up", []prompb.Label{{Name: "job", Value: "api"}})
+	require.NoError(t, err)
+	assert.Equal(t, gets, registry.count("get"))
+
+	// a new label is appended to the tag family
+	m, err = r.ensureMeasure(ctx, "up", []prompb.Label{{Name: "job", Value: "node"}, {Name: "zone", Value: "z1"}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, registry.count("create"))
+	assert.Equal(t, 1, registry.count("update"))
+	assert.Equal(t, []string{promSeriesTag, "job", "zone"}, registry.tagNames("up"))
+	assert.Len(t, m.TagFamilies[0].Tags, 3)
+}
+
+func TestPromEnsureMeasureConcurrently(t *testing.T) {
+	registry := newFakeMeasureRegistry()
+	release := make(chan struct{})
+	registry.blocked["slow"] = release
+	r := newTestPromReceiver(registry, &fakeMeasureService{})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	labels := []prompb.Label{{Name: "job", Value: "node"}}
+	var wg sync.WaitGroup
+	errs := make(chan error, 4)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := r.ensureMeasure(ctx, "slow", labels)
+			errs <- err
+		}()
+	}
+
+	// the registry calls of a metric don't block the other metrics
+	require.Eventually(t, func() bool { return registry.count("get") > 0 }, 5*time.Second, 10*time.Millisecond)
+	done := make(chan error, 1)
+	go func() {
+		_, err := r.ensureMeasure(ctx, "fast", labels)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the measure of a metric was blocked by the registry calls of another metric")
+	}
+
+	close(release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+	// the writers of the same metric wait for the first one instead of creating the measure again
+	assert.Equal(t, 2, registry.count("create"))
+	assert.Equal(t, 0, registry.count("update"))
+}
+
+func TestPromWrite(t *testing.T) {
+	registry := newFakeMeasureRegistry()
+	ms := &fakeMeasureService{status: modelv1.Status_STATUS_SUCCEED.String()}
+	r := newTestPromReceiver(registry, ms)
+
+	wr := &prompb.WriteRequest{Timeseries: []prompb.TimeSeries{
+		{
+			Labels: []prompb.Label{
+				{Name: prompb.MetricNameLabel, Value: "job:requests:rate5m"},
+				{Name: "job", Value: "api"},
+				{Name: "instance", Value: "h1"},
+			},
+			Samples: []prompb.Sample{{Value: 1.5, Timestamp: 1000}, {Value: 2.5, Timestamp: 2000}},
+		},
+		// the series without a name or samples are skipped
+		{Labels: []prompb.Label{{Name: "job", Value: "api"}}, Samples: []prompb.Sample{{Value: 1, Timestamp: 1000}}},
+		{Labels: []prompb.Label{{Name: prompb.MetricNameLabel, Value: "up"}}},
+	}}
+	require.NoError(t, r.write(context.Background(), wr))
+
+	require.Len(t, ms.sent, 2)
+	assert.Equal(t, []string{promSeriesTag, "instance", "job"}, registry.tagNames("job_requests_rate5m"))
+	assert.NotEqual(t, ms.sent[0].MessageId, ms.sent[1].MessageId)
+	for i, want := range []struct {
+		value float64
+		ts    int64
+	}{{1.5, 1000}, {2.5, 2000}} {
+		req := ms.sent[i]
+		assert.Equal(t, "prom", req.Metadata.Group)
+		assert.Equal(t, "job_requests_rate5m", req.Metadata.Name)
+		assert.Equal(t, want.ts, req.DataPoint.Timestamp.AsTime().UnixMilli())
+		require.Len(t, req.DataPoint.TagFamilies, 1)
+		assert.Equal(t, []string{`{instance="h1",job="api"}`, "h1", "api"}, strValues(req.DataPoint.TagFamilies[0].Tags))
+		require.Len(t, req.DataPoint.Fields, 1)
+		assert.Equal(t, want.value, req.DataPoint.Fields[0].GetFloat().GetValue())
+	}
+
+	ms.status = modelv1.Status_STATUS_INTERNAL_ERROR.String()
+	ms.sent = nil
+	require.ErrorContains(t, r.write(context.Background(), wr), "2 of 2 samples failed")
+}
@@ -20,6 +20,7 @@ package http
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net"
 	"net/http"
@@ -42,10 +43,17 @@ import (
 	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
 	propertyv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/property/v1"
 	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	"github.com/apache/skywalking-banyandb/banyand/liaison/sampling"
+	"github.com/apache/skywalking-banyandb/banyand/queue"
 	"github.com/apache/skywalking-banyandb/pkg/healthcheck"
+	"github.com/apache/skywalking-banyandb/pkg/listener"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/node"
+	"github.com/apache/skywalking-banyandb/pkg/query"
 	"github.com/apache/skywalking-banyandb/pkg/run"
 	pkgtls "github.com/apache/skywalking-banyandb/pkg/tls"
+	"github.com/apache/skywalking-banyandb/pkg/tracecontext"
 )
 
 var (
@@ -55,6 +63,9 @@ var (
 	errServerCert = errors.New("http: invalid server cert file")
 	errServerKey  = errors.New("http: invalid server key file")
 	errNoAddr     = errors.New("http: no address")
+
+	errListenerCert  = errors.New("http: a TLS listener without its own certificate requires the server TLS")
+	errListenerAdmin = errors.New("http: the admin capability only applies to the gRPC listeners")
 )
 
 // NewServer return a http service.
@@ -85,10 +96,17 @@ type server struct {
 	host            string
 	listenAddr      string
 	grpcAddr        string
+	promGroup       string
+	jaegerGroup     string
+	jaegerStream    string
 	keyFile         string
 	certFile        string
 	grpcCert        string
+	listenAddrs     []string
+	listeners       []listener.Config
 	grpcMu          sync.Mutex
+	jaegerLookback  time.Duration
+	queryCache      queryCache
 	port            uint32
 	tls             bool
 }
@@ -97,11 +115,25 @@ func (p *server) FlagSet() *run.FlagSet {
 	flagSet := run.NewFlagSet("http")
 	flagSet.StringVar(&p.host, "http-host", "", "listen host for http")
 	flagSet.Uint32Var(&p.port, "http-port", 17913, "listen port for http")
+	flagSet.StringSliceVar(&p.listenAddrs, "http-listen-addrs", nil,
+		"the additional addresses the http server listens on, e.g. \"[::1]:17923\", \"unix:///var/run/banyandb-http.sock\", "+
+			"\"tcp6://[::]:17933?tls=true&cert-file=a.crt&key-file=a.key\"")
 	flagSet.StringVar(&p.grpcAddr, "http-grpc-addr", "localhost:17912", "http server redirect grpc requests to this address")
 	flagSet.StringVar(&p.certFile, "http-cert-file", "", "the TLS cert file of http server")
 	flagSet.StringVar(&p.keyFile, "http-key-file", "", "the TLS key file of http server")
 	flagSet.StringVar(&p.grpcCert, "http-grpc-cert-file", "", "the grpc TLS cert file if grpc server enables tls")
 	flagSet.BoolVar(&p.tls, "http-tls", false, "connection uses TLS if true, else plain HTTP")
+	flagSet.StringVar(&p.promGroup, "prometheus-remote-write-group", "",
+		"the measure group which Prometheus remote-write samples are written into. The receiver is disabled if it's empty")
+	flagSet.StringVar(&p.jaegerGroup, "jaeger-query-group", "",
+		"the stream group which the Jaeger query API reads spans from. The API is disabled if it's empty")
+	flagSet.StringVar(&p.jaegerStream, "jaeger-query-stream", "otlp_span", "the stream which the Jaeger query API reads spans from")
+	flagSet.DurationVar(&p.jaegerLookback, "jaeger-query-lookback", time.Hour,
+		"the default time range of the Jaeger query API if the request doesn't specify one")
+	flagSet.DurationVar(&p.queryCache.maxAge, "http-query-cache-max-age", 0,
+		"the max age of the cached responses of the queries on the historical time ranges, 0 makes the clients revalidate them by the ETags")
+	flagSet.DurationVar(&p.queryCache.immutableAfter, "http-query-immutable-after", time.Hour,
+		"the time ranges ending before this duration ago are historical, whose query responses are cacheable")
 	return flagSet
 }
 
@@ -110,6 +142,22 @@ func (p *server) Validate() error {
 	if p.listenAddr == ":" {
 		return errNoAddr
 	}
+	extra, err := listener.ParseAll(p.listenAddrs)
+	if err != nil {
+		return err
+	}
+	p.listeners = append([]listener.Config{{Network: "tcp", Address: p.listenAddr, TLS: p.tls}}, extra...)
+	for _, l := range extra {
+		if l.TLS && l.CertFile == "" && !p.tls {
+			return errors.Wrap(errListenerCert, l.String())
+		}
+		if l.Admin {
+			return errors.Wrap(errListenerAdmin, l.String())
+		}
+	}
+	if p.queryCache.maxAge < 0 || p.queryCache.immutableAfter < 0 {
+		return errors.New("http-query-cache-max-age and http-query-immutable-after must not be negative")
+	}
 	if !p.tls {
 		return nil
 	}
@@ -232,7 +280,7 @@ func (p *server) PreRun(_ context.Context) error {
 
 	p.handlerWrapper = &atomicHandler{}
 
-	// Configure the HTTP server with dynamic TLS if enabled
+	// TLS is terminated by the listeners, so that every listener has its own TLS settings.
 	p.srv = &http.Server{
 		Addr:              p.listenAddr,
 		Handler:           p.handlerWrapper,
@@ -240,9 +288,6 @@ func (p *server) PreRun(_ context.Context) error {
 		WriteTimeout:      30 * time.Second,
 		IdleTimeout:       60 * time.Second,
 	}
-	if p.tls {
-		p.srv.TLSConfig = p.tlsReloader.GetTLSConfig()
-	}
 
 	return nil
 }
@@ -268,20 +313,40 @@ func (p *server) Serve() run.StopNotify {
 		return p.stopCh
 	}
 
-	go func() {
-		defer close(p.stopCh)
-		p.l.Info().Str("listenAddr", p.listenAddr).Msg("Start liaison http server")
-		var err error
-		if p.tls {
-			// Start the TLSReloader file monitoring already done above
-			// Use TLS with dynamic certificate loading
-			err = p.srv.ListenAndServeTLS("", "") // Empty strings because TLSConfig is set
-		} else {
-			err = p.srv.ListenAndServe()
-		}
-		if err != http.ErrServerClosed {
-			p.l.Error().Err(err).Msg("HTTP server failed")
+	var serverTLS *tls.Config
+	if p.tls {
+		serverTLS = p.tlsReloader.GetTLSConfig()
+	}
+	listeners := make([]net.Listener, 0, len(p.listeners))
+	for _, lc := range p.listeners {
+		lis, err := listener.Listen(lc, serverTLS, p.l, "h2", "http/1.1")
+		if err != nil {
+			p.l.Error().Err(err).Stringer("listener", lc).Msg("Failed to listen")
+			for _, l := range listeners {
+				_ = l.Close()
+			}
+			close(p.stopCh)
+			return p.stopCh
 		}
+		listeners = append(listeners, lis)
+	}
+
+	var wg sync.WaitGroup
+	for i, lis := range listeners {
+		wg.Add(1)
+		go func(lc listener.Config, lis net.Listener) {
+			defer wg.Done()
+			p.l.Info().Stringer("listener", lc).Bool("tls", lc.TLS).Msg("Start liaison http server")
+			if err := p.srv.Serve(lis); !errors.Is(err, http.ErrServerClosed) {
+				p.l.Error().Err(err).Stringer("listener", lc).Msg("HTTP server failed")
+				// a failed listener stops the whole server.
+				_ = p.srv.Close()
+			}
+		}(p.listeners[i], lis)
+	}
+	go func() {
+		wg.Wait()
+		close(p.stopCh)
 	}()
 	return p.stopCh
 }
@@ -299,14 +364,18 @@ func (p *server) initGRPCClient() error {
 	// Use switch statement instead of if-else chain
 	switch {
 	case p.grpcTLSReloader != nil:
-		// Extract hostname from grpcAddr
-		host, _, err := net.SplitHostPort(p.grpcAddr)
-		if err != nil {
-			p.l.Error().Err(err).Msg("Failed to split gRPC address")
-			return errors.Wrap(err, "failed to split gRPC address")
-		}
-		if host == "" || host == "0.0.0.0" || host == "[::]" {
-			host = "localhost"
+		// Extract hostname from grpcAddr. A Unix domain socket has no host, so it's served as localhost.
+		host := "localhost"
+		if !strings.HasPrefix(p.grpcAddr, "unix:") {
+			var err error
+			host, _, err = net.SplitHostPort(p.grpcAddr)
+			if err != nil {
+				p.l.Error().Err(err).Msg("Failed to split gRPC address")
+				return errors.Wrap(err, "failed to split gRPC address")
+			}
+			if host == "" || host == "0.0.0.0" || host == "[::]" {
+				host = "localhost"
+			}
 		}
 
 		// Get fresh TLS config from the reloader
@@ -338,7 +407,8 @@ func (p *server) initGRPCClient() error {
 	p.grpcClient.Store(client)
 
 	// Create gateway mux with health endpoint
-	p.gwMux = runtime.NewServeMux(runtime.WithHealthzEndpoint(p.grpcClient.Load()))
+	p.gwMux = runtime.NewServeMux(runtime.WithHealthzEndpoint(p.grpcClient.Load()),
+		runtime.WithIncomingHeaderMatcher(incomingHeaderMatcher))
 
 	// Register all service handlers
 	err = multierr.Combine(
@@ -350,7 +420,14 @@ func (p *server) initGRPCClient() error {
 		databasev1.RegisterGroupRegistryServiceHandlerFromEndpoint(p.grpcCtx, p.gwMux, p.grpcAddr, opts),
 		databasev1.RegisterTopNAggregationRegistryServiceHandlerFromEndpoint(p.grpcCtx, p.gwMux, p.grpcAddr, opts),
 		databasev1.RegisterSnapshotServiceHandlerFromEndpoint(p.grpcCtx, p.gwMux, p.grpcAddr, opts),
+		databasev1.RegisterWarmupServiceHandlerFromEndpoint(p.grpcCtx, p.gwMux, p.grpcAddr, opts),
+		databasev1.RegisterRetentionServiceHandlerFromEndpoint(p.grpcCtx, p.gwMux, p.grpcAddr, opts),
+		databasev1.RegisterStorageUsageServiceHandlerFromEndpoint(p.grpcCtx, p.gwMux, p.grpcAddr, opts),
+		databasev1.RegisterSeriesServiceHandlerFromEndpoint(p.grpcCtx, p.gwMux, p.grpcAddr, opts),
+		databasev1.RegisterDeletionServiceHandlerFromEndpoint(p.grpcCtx, p.gwMux, p.grpcAddr, opts),
 		databasev1.RegisterPropertyRegistryServiceHandlerFromEndpoint(p.grpcCtx, p.gwMux, p.grpcAddr, opts),
+		databasev1.RegisterConnectionSettingsServiceHandlerFromEndpoint(p.grpcCtx, p.gwMux, p.grpcAddr, opts),
+		databasev1.RegisterDynamicFlagServiceHandlerFromEndpoint(p.grpcCtx, p.gwMux, p.grpcAddr, opts),
 		streamv1.RegisterStreamServiceHandlerFromEndpoint(p.grpcCtx, p.gwMux, p.grpcAddr, opts),
 		measurev1.RegisterMeasureServiceHandlerFromEndpoint(p.grpcCtx, p.gwMux, p.grpcAddr, opts),
 		propertyv1.RegisterPropertyServiceHandlerFromEndpoint(p.grpcCtx, p.gwMux, p.grpcAddr, opts),
@@ -364,7 +441,37 @@ func (p *server) initGRPCClient() error {
 	newMux := chi.NewRouter()
 
 	// Mount the gateway mux to the HTTP server
-	newMux.Mount("/api", http.StripPrefix("/api", p.gwMux))
+	newMux.Mount("/api", http.StripPrefix("/api", p.queryCache.wrap(p.gwMux)))
+	newMux.Get(storage.RepairPath, storage.ServeRepairs)
+	newMux.Get(queue.DeadMessagePath, queue.ServeDeadMessages)
+	newMux.Get(queue.CatchUpPath, queue.ServeCatchUps)
+	newMux.Get(queue.NodeHealthPath, queue.ServeNodeHealths)
+	newMux.Get(node.PlacementPath, node.ServePlacement)
+	newMux.Get(query.SlowQueryPath, query.ServeSlowQueries)
+	newMux.Get(sampling.Path, sampling.Serve)
+	newMux.Post(sampling.Path, sampling.Serve)
+	newMux.Delete(sampling.Path, sampling.Serve)
+
+	qh, err := newBydbqlHandler(p.grpcCtx, p.l, p.grpcAddr, opts)
+	if err != nil {
+		return errors.Wrap(err, "failed to create bydbql handler")
+	}
+	newMux.Post(bydbqlPath, qh.ServeHTTP)
+
+	if p.promGroup != "" {
+		pr, errProm := newPromReceiver(p.grpcCtx, p.l, p.grpcAddr, p.promGroup, opts)
+		if errProm != nil {
+			return errors.Wrap(errProm, "failed to create prometheus remote-write receiver")
+		}
+		newMux.Post(promRemoteWritePath, pr.ServeHTTP)
+	}
+	if p.jaegerGroup != "" {
+		jq, errJaeger := newJaegerQuery(p.grpcCtx, p.l, p.grpcAddr, p.jaegerGroup, p.jaegerStream, p.jaegerLookback, opts)
+		if errJaeger != nil {
+			return errors.Wrap(errJaeger, "failed to create jaeger query api")
+		}
+		jq.register(newMux)
+	}
 
 	// Replace the old mux with the new one
 	if err := p.setRootPath(newMux); err != nil {
@@ -375,6 +482,21 @@ func (p *server) initGRPCClient() error {
 	return nil
 }
 
+// newGRPCConn creates a connection to the gRPC server, which is closed when the ctx is done.
+func newGRPCConn(ctx context.Context, l *logger.Logger, addr string, opts []grpc.DialOption) (*grpc.ClientConn, error) {
+	conn, err := grpc.NewClient(addr, opts...)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		<-ctx.Done()
+		if cerr := conn.Close(); cerr != nil {
+			l.Info().Str("addr", addr).Err(cerr).Msg("Failed to close conn")
+		}
+	}()
+	return conn, nil
+}
+
 func (p *server) GracefulStop() {
 	if p.tlsReloader != nil {
 		p.tlsReloader.Stop()
@@ -383,6 +505,16 @@ func (p *server) GracefulStop() {
 		p.grpcTLSReloader.Stop()
 	}
 
+	// finish the in-flight requests before closing the connections.
+	ctx, cancelShutdown := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelShutdown()
+	if err := p.srv.Shutdown(ctx); err != nil {
+		p.l.Warn().Err(err).Msg("failed to shutdown gracefully, force closing")
+		if err = p.srv.Close(); err != nil {
+			p.l.Error().Err(err).Msg("failed to close the server")
+		}
+	}
+
 	p.grpcMu.Lock()
 	var cancel context.CancelFunc
 	if p.grpcCancel != nil {
@@ -392,10 +524,11 @@ func (p *server) GracefulStop() {
 	if cancel != nil {
 		cancel()
 	}
+}
 
-	if err := p.srv.Close(); err != nil {
-		p.l.Error().Err(err)
-	}
+// ShutdownPhase implements run.PhasedService.
+func (p *server) ShutdownPhase() run.ShutdownPhase {
+	return run.ShutdownPhaseIngress
 }
 
 func intercept404(handler, on404 http.Handler) http.HandlerFunc {
@@ -473,3 +606,11 @@ func (h *atomicHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 func (h *atomicHandler) Store(handler http.Handler) {
 	h.value.Store(handler)
 }
+
+// incomingHeaderMatcher forwards the trace context headers to the gRPC server besides the default ones.
+func incomingHeaderMatcher(key string) (string, bool) {
+	if tracecontext.IsHeader(key) {
+		return strings.ToLower(key), true
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
@@ -0,0 +1,379 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package sampling captures a fraction of the raw write requests of a group for diagnostics,
+// e.g. reproducing the schema mismatches reported by the users without the packet captures.
+package sampling
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Path is the HTTP path to start, stop and inspect the samplers.
+const Path = "/api/debug/ingestion/samples"
+
+const (
+	defaultCapacity = 100
+	maxCapacity     = 10000
+	defaultDuration = 10 * time.Minute
+	maxDuration     = 24 * time.Hour
+)
+
+// Options configures the sampler of a group.
+type Options struct {
+	Group string `json:"group"`
+	// RedactTags are the names of the tags whose values are masked in the samples.
+	RedactTags []string `json:"redact_tags,omitempty"`
+	// Rate is the fraction of the requests to capture, in (0, 1].
+	Rate float64 `json:"rate"`
+	// Capacity is the number of the latest samples kept in memory.
+	Capacity int `json:"capacity"`
+	// Duration is how long the sampler captures the requests.
+	Duration time.Duration `json:"duration"`
+	// File appends the samples to a JSON lines file in the sample directory as well.
+	File bool `json:"file,omitempty"`
+}
+
+func (o *Options) validate() error {
+	if o.Group == "" || strings.ContainsAny(o.Group, `/\`) {
+		return fmt.Errorf("invalid group %q", o.Group)
+	}
+	if o.Rate <= 0 || o.Rate > 1 {
+		return fmt.Errorf("the rate %v should be in (0, 1]", o.Rate)
+	}
+	if o.Capacity == 0 {
+		o.Capacity = defaultCapacity
+	}
+	if o.Capacity < 0 || o.Capacity > maxCapacity {
+		return fmt.Errorf("the capacity %d should be in [1, %d]", o.Capacity, maxCapacity)
+	}
+	if o.Duration == 0 {
+		o.Duration = defaultDuration
+	}
+	if o.Duration < 0 || o.Duration > maxDuration {
+		return fmt.Errorf("the duration %s should be in (0, %s]", o.Duration, maxDuration)
+	}
+	return nil
+}
+
+// Sample is a captured write request.
+type Sample struct {
+	Time    time.Time       `json:"time"`
+	Kind    string          `json:"kind"`
+	Request json.RawMessage `json:"request"`
+}
+
+// Summary describes a sampler and its latest samples from the oldest to the latest.
+type Summary struct {
+	Until    time.Time `json:"until"`
+	File     string    `json:"file,omitempty"`
+	Samples  []Sample  `json:"samples"`
+	Options  Options   `json:"options"`
+	Seen     uint64    `json:"seen"`
+	Captured uint64    `json:"captured"`
+	Active   bool      `json:"active"`
+}
+
+// Redactor masks the sensitive data of the cloned request before it's captured.
+type Redactor func(kind string, req proto.Message)
+
+// Sampler captures the write requests of a group until it expires.
+type Sampler struct {
+	until    time.Time
+	file     *os.File
+	redact   map[string]struct{}
+	opts     Options
+	samples  []Sample
+	next     int
+	seen     atomic.Uint64
+	captured atomic.Uint64
+	mu       sync.Mutex
+}
+
+var registry = struct {
+	samplers  map[string]*Sampler
+	redactors []Redactor
+	dir       string
+	active    atomic.Int32
+	mu        sync.RWMutex
+}{samplers: make(map[string]*Sampler)}
+
+// SetDir sets the directory of the sample files. The file output is disabled if it's empty.
+func SetDir(dir string) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	registry.dir = dir
+}
+
+// RegisterRedactor adds a hook masking the requests of all the samplers.
+func RegisterRedactor(r Redactor) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	registry.redactors = append(registry.redactors, r)
+}
+
+// Start starts sampling the group, which replaces the running sampler of the group.
+func Start(opts Options) (*Sampler, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	s := &Sampler{
+		opts:    opts,
+		until:   time.Now().Add(opts.Duration),
+		samples: make([]Sample, 0, opts.Capacity),
+		redact:  make(map[string]struct{}, len(opts.RedactTags)),
+	}
+	for _, t := range opts.RedactTags {
+		s.redact[t] = struct{}{}
+	}
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if opts.File {
+		if registry.dir == "" {
+			return nil, fmt.Errorf("the sample directory isn't configured")
+		}
+		f, err := os.OpenFile(filepath.Join(registry.dir, fmt.Sprintf("%s-%d.jsonl", opts.Group, time.Now().UnixNano())),
+			os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("cannot create the sample file: %w", err)
+		}
+		s.file = f
+	}
+	if pre, ok := registry.samplers[opts.Group]; ok {
+		pre.close()
+	} else {
+		registry.active.Add(1)
+	}
+	registry.samplers[opts.Group] = s
+	return s, nil
+}
+
+// Stop stops and removes the sampler of the group. It returns false if the group isn't sampled.
+func Stop(group string) bool {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	s, ok := registry.samplers[group]
+	if !ok {
+		return false
+	}
+	s.close()
+	delete(registry.samplers, group)
+	registry.active.Add(-1)
+	return true
+}
+
+// Lookup returns the sampler of the group if the request should be captured.
+// It's cheap when no group is sampled.
+func Lookup(group string) *Sampler {
+	if registry.active.Load() == 0 {
+		return nil
+	}
+	registry.mu.RLock()
+	s := registry.samplers[group]
+	registry.mu.RUnlock()
+	if s == nil || !s.hit(time.Now()) {
+		return nil
+	}
+	return s
+}
+
+// Summaries returns the summaries of the samplers by their groups.
+func Summaries() map[string]Summary {
+	registry.mu.RLock()
+	samplers := make([]*Sampler, 0, len(registry.samplers))
+	for _, s := range registry.samplers {
+		samplers = append(samplers, s)
+	}
+	registry.mu.RUnlock()
+	result := make(map[string]Summary, len(samplers))
+	for _, s := range samplers {
+		result[s.opts.Group] = s.Summary()
+	}
+	return result
+}
+
+// Redacted reports whether the values of the tag are masked.
+func (s *Sampler) Redacted(tag string) bool {
+	_, ok := s.redact[tag]
+	return ok
+}
+
+// Redacting reports whether any tag is masked.
+func (s *Sampler) Redacting() bool {
+	return len(s.redact) > 0
+}
+
+// Capture records the request, which should be a clone redacted by the caller.
+// It overwrites the oldest sample if the sampler is full.
+func (s *Sampler) Capture(kind string, req proto.Message) {
+	registry.mu.RLock()
+	redactors := registry.redactors
+	registry.mu.RUnlock()
+	for _, r := range redactors {
+		r(kind, req)
+	}
+	b, err := protojson.Marshal(req)
+	if err != nil {
+		return
+	}
+	sample := Sample{Time: time.Now(), Kind: kind, Request: b}
+	s.captured.Add(1)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file != nil {
+		if line, errMarshal := json.Marshal(sample); errMarshal == nil {
+			_, _ = s.file.Write(append(line, '\n'))
+		}
+	}
+	if len(s.samples) < cap(s.samples) {
+		s.samples = append(s.samples, sample)
+		return
+	}
+	s.samples[s.next] = sample
+	s.next = (s.next + 1) % len(s.samples)
+}
+
+// Summary returns the state and the samples of the sampler.
+func (s *Sampler) Summary() Summary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sum := Summary{
+		Options:  s.opts,
+		Until:    s.until,
+		Active:   time.Now().Before(s.until),
+		Seen:     s.seen.Load(),
+		Captured: s.captured.Load(),
+		Samples:  make([]Sample, 0, len(s.samples)),
+	}
+	if s.file != nil {
+		sum.File = s.file.Name()
+	}
+	sum.Samples = append(sum.Samples, s.samples[s.next:]...)
+	sum.Samples = append(sum.Samples, s.samples[:s.next]...)
+	return sum
+}
+
+func (s *Sampler) hit(now time.Time) bool {
+	if !now.Before(s.until) {
+		// the file is closed once the sampler expires, and the samples in memory are kept until it's stopped.
+		s.mu.Lock()
+		s.closeFile()
+		s.mu.Unlock()
+		return false
+	}
+	s.seen.Add(1)
+	return s.opts.Rate >= 1 || rand.Float64() < s.opts.Rate
+}
+
+func (s *Sampler) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.until = time.Now()
+	s.closeFile()
+}
+
+func (s *Sampler) closeFile() {
+	if s.file == nil {
+		return
+	}
+	_ = s.file.Close()
+	s.file = nil
+}
+
+// Serve starts a sampler by POST, stops it by DELETE, and returns the summaries by GET.
+// POST takes the query parameters group, rate, capacity, duration, redact as the comma-separated tag names, and file.
+func Serve(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		opts, err := parseOptions(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s, err := Start(opts)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, s.Summary())
+	case http.MethodDelete:
+		if !Stop(r.URL.Query().Get("group")) {
+			http.Error(w, "the group isn't sampled", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		summaries := Summaries()
+		if g := r.URL.Query().Get("group"); g != "" {
+			sum, ok := summaries[g]
+			if !ok {
+				http.Error(w, "the group isn't sampled", http.StatusNotFound)
+				return
+			}
+			writeJSON(w, sum)
+			return
+		}
+		writeJSON(w, summaries)
+	}
+}
+
+func parseOptions(r *http.Request) (opts Options, err error) {
+	q := r.URL.Query()
+	opts.Group = q.Get("group")
+	if v := q.Get("rate"); v != "" {
+		if opts.Rate, err = strconv.ParseFloat(v, 64); err != nil {
+			return opts, fmt.Errorf("invalid rate %q: %w", v, err)
+		}
+	}
+	if v := q.Get("capacity"); v != "" {
+		if opts.Capacity, err = strconv.Atoi(v); err != nil {
+			return opts, fmt.Errorf("invalid capacity %q: %w", v, err)
+		}
+	}
+	if v := q.Get("duration"); v != "" {
+		if opts.Duration, err = time.ParseDuration(v); err != nil {
+			return opts, fmt.Errorf("invalid duration %q: %w", v, err)
+		}
+	}
+	if v := q.Get("redact"); v != "" {
+		opts.RedactTags = strings.Split(v, ",")
+	}
+	if v := q.Get("file"); v != "" {
+		if opts.File, err = strconv.ParseBool(v); err != nil {
+			return opts, fmt.Errorf("invalid file %q: %w", v, err)
+		}
+	}
+	return opts, nil
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,147 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package sampling
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+)
+
+func request(name string) *streamv1.WriteRequest {
+	return &streamv1.WriteRequest{Metadata: &commonv1.Metadata{Group: "sw", Name: name}, MessageId: 1}
+}
+
+func TestSamplerRing(t *testing.T) {
+	s, err := Start(Options{Group: "sw", Rate: 1, Capacity: 2})
+	require.NoError(t, err)
+	defer Stop("sw")
+	assert.Nil(t, Lookup("other"))
+	for _, name := range []string{"a", "b", "c"} {
+		sp := Lookup("sw")
+		require.Same(t, s, sp)
+		sp.Capture("stream", request(name))
+	}
+	sum := s.Summary()
+	assert.True(t, sum.Active)
+	assert.Equal(t, uint64(3), sum.Seen)
+	assert.Equal(t, uint64(3), sum.Captured)
+	require.Len(t, sum.Samples, 2)
+	assert.Contains(t, string(sum.Samples[0].Request), `"name":"b"`)
+	assert.Contains(t, string(sum.Samples[1].Request), `"name":"c"`)
+
+	assert.True(t, Stop("sw"))
+	assert.False(t, Stop("sw"))
+	assert.Nil(t, Lookup("sw"))
+}
+
+func TestSamplerExpire(t *testing.T) {
+	s, err := Start(Options{Group: "sw", Rate: 1, Duration: time.Millisecond})
+	require.NoError(t, err)
+	defer Stop("sw")
+	time.Sleep(5 * time.Millisecond)
+	assert.Nil(t, Lookup("sw"))
+	sum := s.Summary()
+	assert.False(t, sum.Active)
+	assert.Zero(t, sum.Seen)
+}
+
+func TestSamplerOptions(t *testing.T) {
+	for _, opts := range []Options{
+		{Rate: 1},
+		{Group: "../sw", Rate: 1},
+		{Group: "sw"},
+		{Group: "sw", Rate: 1.5},
+		{Group: "sw", Rate: 1, Capacity: maxCapacity + 1},
+		{Group: "sw", Rate: 1, Duration: maxDuration + time.Second},
+		{Group: "sw", Rate: 1, File: true},
+	} {
+		_, err := Start(opts)
+		assert.Error(t, err, opts)
+	}
+	assert.Empty(t, Summaries())
+}
+
+func TestSamplerFileAndRedactor(t *testing.T) {
+	dir := t.TempDir()
+	SetDir(dir)
+	defer SetDir("")
+	RegisterRedactor(func(_ string, req proto.Message) {
+		if r, ok := req.(*streamv1.WriteRequest); ok {
+			r.IdempotencyKey = ""
+		}
+	})
+	defer func() { registry.redactors = nil }()
+	s, err := Start(Options{Group: "sw", Rate: 1, File: true})
+	require.NoError(t, err)
+	req := request("a")
+	req.IdempotencyKey = "secret"
+	Lookup("sw").Capture("stream", req)
+	sum := s.Summary()
+	require.NotEmpty(t, sum.File)
+	require.True(t, Stop("sw"))
+
+	f, err := os.Open(sum.File)
+	require.NoError(t, err)
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	require.True(t, scanner.Scan())
+	var sample Sample
+	require.NoError(t, json.Unmarshal(scanner.Bytes(), &sample))
+	assert.Equal(t, "stream", sample.Kind)
+	assert.NotContains(t, string(sample.Request), "secret")
+	assert.False(t, scanner.Scan())
+}
+
+func TestServe(t *testing.T) {
+	do := func(method, query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		Serve(w, httptest.NewRequest(method, Path+query, nil))
+		return w
+	}
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "?group=sw&rate=x").Code)
+	w := do(http.MethodPost, "?group=sw&rate=1&capacity=5&duration=1m&redact=trace_id,user")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var sum Summary
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &sum))
+	assert.Equal(t, 5, sum.Options.Capacity)
+	assert.Equal(t, []string{"trace_id", "user"}, sum.Options.RedactTags)
+	assert.True(t, Lookup("sw").Redacted("user"))
+
+	w = do(http.MethodGet, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var all map[string]Summary
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &all))
+	assert.Contains(t, all, "sw")
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "?group=sw").Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "?group=other").Code)
+
+	assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, "?group=sw").Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodDelete, "?group=sw").Code)
+}
@@ -24,6 +24,7 @@ import (
 
 	"github.com/apache/skywalking-banyandb/api/common"
 	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
 	"github.com/apache/skywalking-banyandb/pkg/bytes"
 	"github.com/apache/skywalking-banyandb/pkg/encoding"
 	"github.com/apache/skywalking-banyandb/pkg/fs"
@@ -131,6 +132,24 @@ func (b *block) resizeTagFamilies(tagFamiliesLen int) []columnFamily {
 	return tff
 }
 
+// dropDeleted drops the data points ingested at ingestedAt in the deleted time ranges. It returns false if no data point remains.
+func (b *block) dropDeleted(rt *storage.RangeTombstones, ingestedAt int64) bool {
+	kept, dropped := rt.FilterTimestamps(b.timestamps, ingestedAt)
+	if !dropped {
+		return len(b.timestamps) > 0
+	}
+	n := len(b.timestamps)
+	b.timestamps = storage.KeepRows(b.timestamps, kept)
+	if len(b.versions) == n {
+		b.versions = storage.KeepRows(b.versions, kept)
+	}
+	for i := range b.tagFamilies {
+		b.tagFamilies[i].keepRows(kept, n)
+	}
+	b.field.keepRows(kept, n)
+	return len(kept) > 0
+}
+
 func (b *block) Len() int {
 	return len(b.timestamps)
 }
@@ -205,7 +224,7 @@ func (b *block) marshalTagFamily(tf columnFamily, bm *blockMetadata, ww *writers
 }
 
 func (b *block) unmarshalTagFamily(decoder *encoding.BytesBlockDecoder, tfIndex int, name string,
-	columnFamilyMetadataBlock *dataBlock, tagProjection []string, metaReader, valueReader fs.Reader, count int,
+	columnFamilyMetadataBlock *dataBlock, tagProjection []string, metaReader, valueReader fs.Reader, count int, formatVersion uint32,
 ) {
 	if len(tagProjection) < 1 {
 		return
@@ -215,7 +234,7 @@ func (b *block) unmarshalTagFamily(decoder *encoding.BytesBlockDecoder, tfIndex
 	fs.MustReadData(metaReader, int64(columnFamilyMetadataBlock.offset), bb.Buf)
 	cfm := generateColumnFamilyMetadata()
 	defer releaseColumnFamilyMetadata(cfm)
-	_, err := cfm.unmarshal(bb.Buf)
+	_, err := cfm.unmarshal(bb.Buf, formatVersion)
 	if err != nil {
 		logger.Panicf("%s: cannot unmarshal columnFamilyMetadata: %v", metaReader.Path(), err)
 	}
@@ -240,7 +259,7 @@ NEXT:
 }
 
 func (b *block) unmarshalTagFamilyFromSeqReaders(decoder *encoding.BytesBlockDecoder, tfIndex int, name string,
-	columnFamilyMetadataBlock *dataBlock, metaReader, valueReader *seqReader,
+	columnFamilyMetadataBlock *dataBlock, metaReader, valueReader *seqReader, formatVersion uint32,
 ) {
 	if columnFamilyMetadataBlock.offset != metaReader.bytesRead {
 		logger.Panicf("offset %d must be equal to bytesRead %d", columnFamilyMetadataBlock.offset, metaReader.bytesRead)
@@ -250,7 +269,7 @@ func (b *block) unmarshalTagFamilyFromSeqReaders(decoder *encoding.BytesBlockDec
 	metaReader.mustReadFull(bb.Buf)
 	cfm := generateColumnFamilyMetadata()
 	defer releaseColumnFamilyMetadata(cfm)
-	_, err := cfm.unmarshal(bb.Buf)
+	_, err := cfm.unmarshal(bb.Buf, formatVersion)
 	if err != nil {
 		logger.Panicf("%s: cannot unmarshal columnFamilyMetadata: %v", metaReader.Path(), err)
 	}
@@ -324,11 +343,11 @@ func (b *block) mustReadFrom(decoder *encoding.BytesBlockDecoder, p *part, bm bl
 		}
 		b.unmarshalTagFamily(decoder, i, name, block,
 			bm.tagProjection[i].Names, p.tagFamilyMetadata[name],
-			p.tagFamilies[name], int(bm.count))
+			p.tagFamilies[name], int(bm.count), p.partMetadata.formatVersion())
 	}
 }
 
-func (b *block) mustSeqReadFrom(decoder *encoding.BytesBlockDecoder, seqReaders *seqReaders, bm blockMetadata) {
+func (b *block) mustSeqReadFrom(decoder *encoding.BytesBlockDecoder, seqReaders *seqReaders, bm blockMetadata, formatVersion uint32) {
 	b.reset()
 
 	b.timestamps, b.versions = mustSeqReadTimestampsFrom(b.timestamps, b.versions, &bm.timestamps, int(bm.count), &seqReaders.timestamps)
@@ -346,7 +365,7 @@ func (b *block) mustSeqReadFrom(decoder *encoding.BytesBlockDecoder, seqReaders
 	for i, name := range keys {
 		block := bm.tagFamilies[name]
 		b.unmarshalTagFamilyFromSeqReaders(decoder, i, name, block,
-			seqReaders.tagFamilyMetadata[name], seqReaders.tagFamilies[name])
+			seqReaders.tagFamilyMetadata[name], seqReaders.tagFamilies[name], formatVersion)
 	}
 }
 
@@ -442,6 +461,7 @@ type blockCursor struct {
 	fieldProjection     []string
 	bm                  blockMetadata
 	idx                 int
+	rangeTombstones     *storage.RangeTombstones
 	minTimestamp        int64
 	maxTimestamp        int64
 }
@@ -450,6 +470,7 @@ func (bc *blockCursor) reset() {
 	bc.idx = 0
 	bc.p = nil
 	bc.bm.reset()
+	bc.rangeTombstones = nil
 	bc.minTimestamp = 0
 	bc.maxTimestamp = 0
 	bc.tagProjection = bc.tagProjection[:0]
@@ -471,7 +492,11 @@ func (bc *blockCursor) init(p *part, bm *blockMetadata, queryOpts queryOptions)
 	bc.p = p
 	bc.bm.copyFrom(bm)
 	bc.minTimestamp = queryOpts.minTimestamp
+	if deletedAt, ok := queryOpts.tombstones.deletedAt(bm.seriesID); ok && deletedAt >= bc.minTimestamp {
+		bc.minTimestamp = deletedAt + 1
+	}
 	bc.maxTimestamp = queryOpts.maxTimestamp
+	bc.rangeTombstones = queryOpts.rangeTombstones
 	bc.tagProjection = queryOpts.TagProjection
 	bc.fieldProjection = queryOpts.FieldProjection
 }
@@ -690,6 +715,9 @@ func (bc *blockCursor) replace(r *model.MeasureResult, storedIndexValue map[comm
 
 func (bc *blockCursor) loadData(tmpBlock *block) bool {
 	tmpBlock.reset()
+	if bc.rangeTombstones.Covers(bc.bm.timestamps.min, bc.bm.timestamps.max, bc.p.partMetadata.IngestedAt) {
+		return false
+	}
 	cfm := make([]columnMetadata, 0, len(bc.fieldProjection))
 NEXT_FIELD:
 	for _, fp := range bc.fieldProjection {
@@ -719,6 +747,9 @@ NEXT_FIELD:
 	}
 	bc.bm.tagFamilies = tf
 	tmpBlock.mustReadFrom(&bc.columnValuesDecoder, bc.p, bc.bm)
+	if !tmpBlock.dropDeleted(bc.rangeTombstones, bc.p.partMetadata.IngestedAt) {
+		return false
+	}
 
 	start, end, ok := timestamp.FindRange(tmpBlock.timestamps, bc.minTimestamp, bc.maxTimestamp)
 	if !ok {
@@ -798,6 +829,28 @@ func (bi *blockPointer) updateMetadata() {
 	bi.bm.timestamps.max = bi.block.timestamps[len(bi.timestamps)-1]
 }
 
+// skipUntil skips the data points not after ts. It returns false if no data point remains.
+func (bi *blockPointer) skipUntil(ts int64) bool {
+	for bi.idx < len(bi.timestamps) && bi.timestamps[bi.idx] <= ts {
+		bi.idx++
+	}
+	if bi.idx >= len(bi.timestamps) {
+		return false
+	}
+	bi.bm.timestamps.min = bi.timestamps[bi.idx]
+	return true
+}
+
+// dropDeleted drops the data points in the deleted time ranges before the pointer moves.
+// It returns false if no data point remains.
+func (bi *blockPointer) dropDeleted(rt *storage.RangeTombstones, ingestedAt int64) bool {
+	if !bi.block.dropDeleted(rt, ingestedAt) {
+		return false
+	}
+	bi.updateMetadata()
+	return true
+}
+
 func (bi *blockPointer) copyFrom(src *blockPointer) {
 	bi.reset()
 	bi.bm.copyFrom(&src.bm)
@@ -129,7 +129,7 @@ func (bm *blockMetadata) marshal(dst []byte) []byte {
 	return bm.field.marshal(dst)
 }
 
-func (bm *blockMetadata) unmarshal(src []byte) ([]byte, error) {
+func (bm *blockMetadata) unmarshal(src []byte, formatVersion uint32) ([]byte, error) {
 	if len(src) < 8 {
 		return nil, errors.New("cannot unmarshal blockMetadata from less than 8 bytes")
 	}
@@ -159,7 +159,7 @@ func (bm *blockMetadata) unmarshal(src []byte) ([]byte, error) {
 		}
 	}
 	var err error
-	src, err = bm.field.unmarshal(src)
+	src, err = bm.field.unmarshal(src, formatVersion)
 	if err != nil {
 		return nil, fmt.Errorf("cannot unmarshal columnFamilyMetadata: %w", err)
 	}
@@ -271,7 +271,7 @@ func (tm *timestampsMetadata) unmarshal(src []byte) []byte {
 	return src[1:]
 }
 
-func unmarshalBlockMetadata(dst []blockMetadata, src []byte) ([]blockMetadata, error) {
+func unmarshalBlockMetadata(dst []blockMetadata, src []byte, formatVersion uint32) ([]blockMetadata, error) {
 	dstOrig := dst
 	var pre *blockMetadata
 	for len(src) > 0 {
@@ -282,7 +282,7 @@ func unmarshalBlockMetadata(dst []blockMetadata, src []byte) ([]blockMetadata, e
 		}
 		bm := &dst[len(dst)-1]
 		bm.reset()
-		tail, err := bm.unmarshal(src)
+		tail, err := bm.unmarshal(src, formatVersion)
 		if err != nil {
 			return dstOrig, fmt.Errorf("cannot unmarshal blockMetadata entries: %w", err)
 		}
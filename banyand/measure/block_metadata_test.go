@@ -317,7 +317,7 @@ func Test_blockMetadata_marshal_unmarshal(t *testing.T) {
 				tagFamilies: make(map[string]*dataBlock),
 			}
 
-			_, err := unmarshaled.unmarshal(marshaled)
+			_, err := unmarshaled.unmarshal(marshaled, currentPartFormatVersion)
 			require.NoError(t, err)
 
 			assert.Equal(t, tc.original.seriesID, unmarshaled.seriesID)
@@ -364,7 +364,7 @@ func Test_unmarshalBlockMetadata(t *testing.T) {
 			marshaled = bm.marshal(marshaled)
 		}
 
-		unmarshaled, err := unmarshalBlockMetadata(nil, marshaled)
+		unmarshaled, err := unmarshalBlockMetadata(nil, marshaled, currentPartFormatVersion)
 		require.NoError(t, err)
 		require.Equal(t, original, unmarshaled)
 	})
@@ -402,7 +402,7 @@ func Test_unmarshalBlockMetadata(t *testing.T) {
 			marshaled = bm.marshal(marshaled)
 		}
 
-		_, err := unmarshalBlockMetadata(nil, marshaled)
+		_, err := unmarshalBlockMetadata(nil, marshaled, currentPartFormatVersion)
 		require.Error(t, err)
 	})
 }
@@ -212,6 +212,11 @@ func (br *blockReader) loadBlockData(decoder *encoding.BytesBlockDecoder) {
 	br.pih[0].mustLoadBlockData(decoder, br.block)
 }
 
+// ingestedAt returns the ingestion time of the part holding the current block.
+func (br *blockReader) ingestedAt() int64 {
+	return br.pih[0].ingestedAt
+}
+
 func (br *blockReader) error() error {
 	if errors.Is(br.err, io.EOF) {
 		return nil
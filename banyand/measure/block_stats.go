@@ -0,0 +1,161 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package measure
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/blugelabs/bluge/numeric"
+
+	pkgbytes "github.com/apache/skywalking-banyandb/pkg/bytes"
+	"github.com/apache/skywalking-banyandb/pkg/convert"
+	"github.com/apache/skywalking-banyandb/pkg/fs"
+	"github.com/apache/skywalking-banyandb/pkg/index"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+	"github.com/apache/skywalking-banyandb/pkg/pool"
+)
+
+var _ index.FilterOp = (*blockStats)(nil)
+
+// blockStats exposes the statistics of the tags and fields in a block to the skipping filter.
+// The field metadata is held by the block metadata, while the tag family metadata is loaded on demand.
+type blockStats struct {
+	p           *part
+	bm          *blockMetadata
+	tagFamilies []*columnFamilyMetadata
+	loaded      bool
+}
+
+func (bs *blockStats) reset() {
+	for _, cfm := range bs.tagFamilies {
+		releaseColumnFamilyMetadata(cfm)
+	}
+	bs.tagFamilies = bs.tagFamilies[:0]
+	bs.p = nil
+	bs.bm = nil
+	bs.loaded = false
+}
+
+func (bs *blockStats) init(p *part, bm *blockMetadata) {
+	bs.p = p
+	bs.bm = bm
+}
+
+func (bs *blockStats) loadTagFamilies() {
+	if bs.loaded {
+		return
+	}
+	bs.loaded = true
+	bb := bigValuePool.Generate()
+	defer bigValuePool.Release(bb)
+	for name, block := range bs.bm.tagFamilies {
+		metaReader := bs.p.tagFamilyMetadata[name]
+		if metaReader == nil {
+			continue
+		}
+		bb.Buf = pkgbytes.ResizeExact(bb.Buf, int(block.size))
+		fs.MustReadData(metaReader, int64(block.offset), bb.Buf)
+		cfm := generateColumnFamilyMetadata()
+		if _, err := cfm.unmarshal(bb.Buf, bs.p.partMetadata.formatVersion()); err != nil {
+			logger.Panicf("%s: cannot unmarshal columnFamilyMetadata: %v", metaReader.Path(), err)
+		}
+		bs.tagFamilies = append(bs.tagFamilies, cfm)
+	}
+}
+
+func (bs *blockStats) find(name string) *columnMetadata {
+	for i := range bs.bm.field.columnMetadata {
+		if bs.bm.field.columnMetadata[i].name == name {
+			return &bs.bm.field.columnMetadata[i]
+		}
+	}
+	bs.loadTagFamilies()
+	for _, cfm := range bs.tagFamilies {
+		for i := range cfm.columnMetadata {
+			if cfm.columnMetadata[i].name == name {
+				return &cfm.columnMetadata[i]
+			}
+		}
+	}
+	return nil
+}
+
+// Eq never rules out a block since the measures don't build the bloom filters.
+func (bs *blockStats) Eq(_ string, _ string) bool {
+	return true
+}
+
+// Stats returns the statistics of the tag or field in the block.
+func (bs *blockStats) Stats(name string) (index.BlockStats, bool) {
+	cm := bs.find(name)
+	if cm == nil {
+		return index.BlockStats{}, false
+	}
+	return index.BlockStats{
+		Min:            cm.min,
+		Max:            cm.max,
+		Count:          bs.bm.count,
+		NullCount:      cm.nullCount,
+		NullCountKnown: bs.p.partMetadata.formatVersion() >= partFormatV2,
+	}, true
+}
+
+func (bs *blockStats) Range(name string, rangeOpts index.RangeOpts) (bool, error) {
+	cm := bs.find(name)
+	if cm == nil || cm.valueType != pbv1.ValueTypeInt64 || len(cm.min) != 8 || len(cm.max) != 8 {
+		return true, nil
+	}
+	if rangeOpts.Lower != nil {
+		lower, ok := rangeOpts.Lower.(*index.FloatTermValue)
+		if !ok {
+			return false, fmt.Errorf("lower is not a float value: %v", rangeOpts.Lower)
+		}
+		value := convert.Int64ToBytes(numeric.Float64ToInt64(lower.Value))
+		if bytes.Compare(cm.max, value) == -1 || !rangeOpts.IncludesLower && bytes.Equal(cm.max, value) {
+			return false, nil
+		}
+	}
+	if rangeOpts.Upper != nil {
+		upper, ok := rangeOpts.Upper.(*index.FloatTermValue)
+		if !ok {
+			return false, fmt.Errorf("upper is not a float value: %v", rangeOpts.Upper)
+		}
+		value := convert.Int64ToBytes(numeric.Float64ToInt64(upper.Value))
+		if bytes.Compare(cm.min, value) == 1 || !rangeOpts.IncludesUpper && bytes.Equal(cm.min, value) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func generateBlockStats() *blockStats {
+	v := blockStatsPool.Get()
+	if v == nil {
+		return &blockStats{}
+	}
+	return v
+}
+
+func releaseBlockStats(bs *blockStats) {
+	bs.reset()
+	blockStatsPool.Put(bs)
+}
+
+var blockStatsPool = pool.Register[*blockStats]("measure-blockStats")
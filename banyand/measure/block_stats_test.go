@@ -0,0 +1,154 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package measure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	"github.com/apache/skywalking-banyandb/pkg/convert"
+	"github.com/apache/skywalking-banyandb/pkg/index"
+	"github.com/apache/skywalking-banyandb/pkg/index/posting"
+)
+
+func Test_blockStats(t *testing.T) {
+	mp := generateMemPart()
+	defer releaseMemPart(mp)
+	mp.mustInitFromDataPoints(dps)
+	p := openMemPart(mp)
+	defer p.close()
+	p.cache = storage.NewShardCache("test-group", 0, 0)
+
+	bma := generateBlockMetadataArray()
+	defer releaseBlockMetadataArray(bma)
+	pi := partIter{}
+	pi.init(bma, p, []common.SeriesID{1, 2, 3}, 1, 220, nil)
+	got := make(map[common.SeriesID]*blockStats)
+	for pi.nextBlock() {
+		bs := &blockStats{}
+		bs.init(p, pi.curBlock)
+		got[pi.curBlock.seriesID] = bs
+	}
+	require.NoError(t, pi.error())
+	require.Len(t, got, 3)
+
+	stats, ok := got[1].Stats("intTag")
+	require.True(t, ok)
+	assert.Equal(t, index.BlockStats{
+		Min:            convert.Int64ToBytes(10),
+		Max:            convert.Int64ToBytes(20),
+		Count:          2,
+		NullCountKnown: true,
+	}, stats)
+	stats, ok = got[1].Stats("intField")
+	require.True(t, ok)
+	assert.Equal(t, convert.Int64ToBytes(1110), stats.Min)
+	assert.Equal(t, convert.Int64ToBytes(2220), stats.Max)
+	stats, ok = got[1].Stats("floatField")
+	require.True(t, ok)
+	assert.Equal(t, convert.Float64ToBytes(1221233.343), stats.Min)
+	assert.Equal(t, convert.Float64ToBytes(2442466.686), stats.Max)
+	stats, ok = got[1].Stats("strTag")
+	require.True(t, ok)
+	assert.Empty(t, stats.Min)
+	assert.Empty(t, stats.Max)
+
+	_, ok = got[2].Stats("intTag")
+	assert.False(t, ok)
+	_, ok = got[2].Stats("intField")
+	assert.False(t, ok)
+	stats, ok = got[3].Stats("intField")
+	require.True(t, ok)
+	assert.Equal(t, convert.Int64ToBytes(1110), stats.Min)
+	assert.Equal(t, convert.Int64ToBytes(2220), stats.Max)
+
+	mightContain, err := got[1].Range("intTag", index.NewIntRangeOpts(15, 30, true, true))
+	require.NoError(t, err)
+	assert.True(t, mightContain)
+	mightContain, err = got[1].Range("intTag", index.NewIntRangeOpts(21, 30, true, true))
+	require.NoError(t, err)
+	assert.False(t, mightContain)
+	mightContain, err = got[1].Range("intTag", index.NewIntRangeOpts(20, 30, false, true))
+	require.NoError(t, err)
+	assert.False(t, mightContain)
+	for _, bs := range got {
+		bs.reset()
+	}
+}
+
+func Test_partIter_blockFilter(t *testing.T) {
+	tests := []struct {
+		name string
+		opts index.RangeOpts
+		want []common.SeriesID
+	}{
+		{
+			name: "overlapping range",
+			opts: index.NewIntRangeOpts(15, 30, true, true),
+			want: []common.SeriesID{1, 2, 3},
+		},
+		{
+			name: "disjoint range",
+			opts: index.NewIntRangeOpts(25, 30, true, true),
+			// the blocks without the tag are kept
+			want: []common.SeriesID{2, 3},
+		},
+	}
+	mp := generateMemPart()
+	defer releaseMemPart(mp)
+	mp.mustInitFromDataPoints(dps)
+	p := openMemPart(mp)
+	defer p.close()
+	p.cache = storage.NewShardCache("test-group", 0, 0)
+	bma := generateBlockMetadataArray()
+	defer releaseBlockMetadataArray(bma)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pi := partIter{}
+			pi.init(bma, p, []common.SeriesID{1, 2, 3}, 1, 220, &rangeFilter{tag: "intTag", opts: tt.opts})
+			var got []common.SeriesID
+			for pi.nextBlock() {
+				got = append(got, pi.curBlock.seriesID)
+			}
+			require.NoError(t, pi.error())
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+type rangeFilter struct {
+	tag  string
+	opts index.RangeOpts
+}
+
+func (rf *rangeFilter) Execute(_ index.GetSearcher, _ common.SeriesID, _ *index.RangeOpts) (posting.List, posting.List, error) {
+	return nil, nil, nil
+}
+
+func (rf *rangeFilter) ShouldSkip(op index.FilterOp) (bool, error) {
+	mightContain, err := op.Range(rf.tag, rf.opts)
+	return !mightContain, err
+}
+
+func (rf *rangeFilter) String() string {
+	return rf.tag
+}
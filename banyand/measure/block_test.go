@@ -328,7 +328,7 @@ func Test_marshalAndUnmarshalTagFamily(t *testing.T) {
 	unmarshaled.timestamps = make([]int64, len(b.timestamps))
 	unmarshaled.resizeTagFamilies(1)
 
-	unmarshaled.unmarshalTagFamily(decoder, tfIndex, name, bm.getTagFamilyMetadata(name), tagProjection[name], metaBuffer, dataBuffer, 1)
+	unmarshaled.unmarshalTagFamily(decoder, tfIndex, name, bm.getTagFamilyMetadata(name), tagProjection[name], metaBuffer, dataBuffer, 1, currentPartFormatVersion)
 
 	if diff := cmp.Diff(unmarshaled.tagFamilies[0], b.tagFamilies[0],
 		cmp.AllowUnexported(columnFamily{}, column{}),
@@ -348,7 +348,7 @@ func Test_marshalAndUnmarshalTagFamily(t *testing.T) {
 	defer releaseSeqReader(valueReader)
 	valueReader.init(dataBuffer)
 
-	unmarshaled2.unmarshalTagFamilyFromSeqReaders(decoder, tfIndex, name, bm.getTagFamilyMetadata(name), metaReader, valueReader)
+	unmarshaled2.unmarshalTagFamilyFromSeqReaders(decoder, tfIndex, name, bm.getTagFamilyMetadata(name), metaReader, valueReader, currentPartFormatVersion)
 
 	if diff := cmp.Diff(unmarshaled2.tagFamilies[0], b.tagFamilies[0],
 		cmp.AllowUnexported(columnFamily{}, column{}),
@@ -372,9 +372,10 @@ func Test_marshalAndUnmarshalBlock(t *testing.T) {
 		fieldValuesWriter:        *fieldWriter,
 	}
 	p := &part{
-		primary:     &bytes.Buffer{},
-		timestamps:  timestampBuffer,
-		fieldValues: fieldBuffer,
+		primary:      &bytes.Buffer{},
+		timestamps:   timestampBuffer,
+		fieldValues:  fieldBuffer,
+		partMetadata: partMetadata{FormatVersion: currentPartFormatVersion},
 	}
 	b := &conventionalBlock
 	tagProjection := toTagProjection(*b)
@@ -419,7 +420,7 @@ func Test_marshalAndUnmarshalBlock(t *testing.T) {
 	sr.init(p)
 	defer sr.reset()
 
-	unmarshaled2.mustSeqReadFrom(decoder, &sr, bm)
+	unmarshaled2.mustSeqReadFrom(decoder, &sr, bm, currentPartFormatVersion)
 	if !reflect.DeepEqual(b, unmarshaled2) {
 		t.Errorf("block.mustSeqReadFrom() = %+v, want %+v", unmarshaled, b)
 	}
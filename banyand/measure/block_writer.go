@@ -265,6 +265,7 @@ func (bw *blockWriter) Flush(pm *partMetadata) {
 	pm.BlocksCount = bw.totalBlocksCount
 	pm.MinTimestamp = bw.totalMinTimestamp
 	pm.MaxTimestamp = bw.totalMaxTimestamp
+	pm.FormatVersion = currentPartFormatVersion
 
 	bw.mustFlushPrimaryBlock(bw.primaryBlockData)
 
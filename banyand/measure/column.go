@@ -18,6 +18,7 @@
 package measure
 
 import (
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
 	"github.com/apache/skywalking-banyandb/pkg/bytes"
 	"github.com/apache/skywalking-banyandb/pkg/convert"
 	"github.com/apache/skywalking-banyandb/pkg/encoding"
@@ -65,9 +66,23 @@ func releaseFloat64Slice(float64Slice *[]float64) {
 	float64SlicePool.Put(float64Slice)
 }
 
+func generateDictionary() *encoding.Dictionary {
+	v := dictionaryPool.Get()
+	if v == nil {
+		return encoding.NewDictionary()
+	}
+	return v
+}
+
+func releaseDictionary(d *encoding.Dictionary) {
+	d.Reset()
+	dictionaryPool.Put(d)
+}
+
 var (
 	int64SlicePool   = pool.Register[*[]int64]("measure-int64Slice")
 	float64SlicePool = pool.Register[*[]float64]("measure-float64Slice")
+	dictionaryPool   = pool.Register[*encoding.Dictionary]("measure-dictionary")
 )
 
 type column struct {
@@ -111,6 +126,8 @@ func (c *column) mustWriteTo(cm *columnMetadata, columnWriter *writer) {
 		c.encodeInt64Column(bb)
 	case pbv1.ValueTypeFloat64:
 		c.encodeFloat64Column(bb)
+	case pbv1.ValueTypeStr:
+		c.encodeStrColumn(bb)
 	default:
 		c.encodeDefault(bb)
 	}
@@ -120,6 +137,47 @@ func (c *column) mustWriteTo(cm *columnMetadata, columnWriter *writer) {
 	}
 	cm.offset = columnWriter.bytesWritten
 	columnWriter.MustWrite(bb.Buf)
+	c.collectStats(cm)
+}
+
+// collectStats collects the statistics of the values, which prune the blocks even if the tag isn't indexed.
+// The bounds are copied since the field metadata outlives the values of the block.
+func (c *column) collectStats(cm *columnMetadata) {
+	minIdx, maxIdx := -1, -1
+	for i, v := range c.values {
+		if len(v) == 0 || string(v) == "null" {
+			cm.nullCount++
+			continue
+		}
+		if c.valueType != pbv1.ValueTypeInt64 && c.valueType != pbv1.ValueTypeFloat64 {
+			continue
+		}
+		if len(v) != 8 {
+			cm.nullCount++
+			continue
+		}
+		if minIdx < 0 {
+			minIdx, maxIdx = i, i
+			continue
+		}
+		if c.less(v, c.values[minIdx]) {
+			minIdx = i
+		}
+		if c.less(c.values[maxIdx], v) {
+			maxIdx = i
+		}
+	}
+	if minIdx >= 0 {
+		cm.min = append(cm.min[:0], c.values[minIdx]...)
+		cm.max = append(cm.max[:0], c.values[maxIdx]...)
+	}
+}
+
+func (c *column) less(a, b []byte) bool {
+	if c.valueType == pbv1.ValueTypeFloat64 {
+		return convert.BytesToFloat64(a) < convert.BytesToFloat64(b)
+	}
+	return convert.BytesToInt64(a) < convert.BytesToInt64(b)
 }
 
 func (c *column) encodeInt64Column(bb *bytes.Buffer) {
@@ -205,6 +263,22 @@ func (c *column) encodeFloat64Column(bb *bytes.Buffer) {
 	)
 }
 
+func (c *column) encodeStrColumn(bb *bytes.Buffer) {
+	// use dictionary encoding if the block has a few unique values
+	dict := generateDictionary()
+	defer releaseDictionary(dict)
+	for _, v := range c.values {
+		if !dict.Add(v) {
+			c.encodeDefault(bb)
+			// Prepend encodeType (1 byte) to the beginning
+			bb.Buf = append([]byte{byte(encoding.EncodeTypePlain)}, bb.Buf...)
+			return
+		}
+	}
+	bb.Buf = append(bb.Buf[:0], byte(encoding.EncodeTypeDictionary))
+	bb.Buf = dict.Encode(bb.Buf, nil)
+}
+
 func (c *column) encodeDefault(bb *bytes.Buffer) {
 	bb.Buf = encoding.EncodeBytesBlock(bb.Buf[:0], c.values)
 }
@@ -255,6 +329,8 @@ func (c *column) decodeColumnValues(decoder *encoding.BytesBlockDecoder, path st
 		c.decodeInt64Column(decoder, path, count, bb)
 	case pbv1.ValueTypeFloat64:
 		c.decodeFloat64Column(decoder, path, count, bb)
+	case pbv1.ValueTypeStr:
+		c.decodeStrColumn(decoder, path, count, bb)
 	default:
 		c.decodeDefault(decoder, bb, count, path)
 	}
@@ -339,6 +415,30 @@ func (c *column) decodeFloat64Column(decoder *encoding.BytesBlockDecoder, path s
 	}
 }
 
+func (c *column) decodeStrColumn(decoder *encoding.BytesBlockDecoder, path string, count uint64, bb *bytes.Buffer) {
+	if len(bb.Buf) < 1 {
+		logger.Panicf("bb.Buf length too short: expect at least %d bytes, but got %d bytes", 1, len(bb.Buf))
+	}
+	switch encoding.EncodeType(bb.Buf[0]) {
+	case encoding.EncodeTypeDictionary:
+		dict := generateDictionary()
+		defer releaseDictionary(dict)
+		if err := dict.Decode(bb.Buf[1:], nil); err != nil {
+			logger.Panicf("%s: cannot decode dictionary: %v", path, err)
+		}
+		c.values = dict.Values(c.values[:0])
+		if uint64(len(c.values)) != count {
+			logger.Panicf("%s: unexpected values length: got %d, expected %d", path, len(c.values), count)
+		}
+	case encoding.EncodeTypePlain:
+		bb.Buf = bb.Buf[1:]
+		c.decodeDefault(decoder, bb, count, path)
+	default:
+		// the legacy blocks aren't prefixed by the encode type, and start with the compress type instead.
+		c.decodeDefault(decoder, bb, count, path)
+	}
+}
+
 func (c *column) decodeDefault(decoder *encoding.BytesBlockDecoder, bb *bytes.Buffer, count uint64, path string) {
 	var err error
 	c.values, err = decoder.Decode(c.values[:0], bb.Buf, count)
@@ -364,6 +464,15 @@ func (cf *columnFamily) reset() {
 	cf.columns = columns[:0]
 }
 
+// keepRows compacts the columns holding all the n rows to the kept rows.
+func (cf *columnFamily) keepRows(kept []int, n int) {
+	for i := range cf.columns {
+		if len(cf.columns[i].values) == n {
+			cf.columns[i].values = storage.KeepRows(cf.columns[i].values, kept)
+		}
+	}
+}
+
 func (cf *columnFamily) resizeColumns(columnsLen int) []column {
 	columns := cf.columns
 	if n := columnsLen - cap(columns); n > 0 {
@@ -28,30 +28,46 @@ import (
 
 type columnMetadata struct {
 	name string
+	// min and max are the bounds of the int64 or float64 values in the block.
+	min []byte
+	max []byte
 	dataBlock
 	valueType pbv1.ValueType
+	// nullCount is the number of the null values in the block.
+	nullCount uint64
 }
 
 func (cm *columnMetadata) reset() {
 	cm.name = ""
 	cm.valueType = 0
 	cm.dataBlock.reset()
+	cm.min = nil
+	cm.max = nil
+	cm.nullCount = 0
 }
 
 func (cm *columnMetadata) copyFrom(src *columnMetadata) {
 	cm.name = src.name
 	cm.valueType = src.valueType
 	cm.dataBlock.copyFrom(&src.dataBlock)
+	cm.min = append(cm.min[:0], src.min...)
+	cm.max = append(cm.max[:0], src.max...)
+	cm.nullCount = src.nullCount
 }
 
 func (cm *columnMetadata) marshal(dst []byte) []byte {
 	dst = encoding.EncodeBytes(dst, convert.StringToBytes(cm.name))
 	dst = append(dst, byte(cm.valueType))
 	dst = cm.dataBlock.marshal(dst)
+	dst = encoding.EncodeBytes(dst, cm.min)
+	dst = encoding.EncodeBytes(dst, cm.max)
+	dst = encoding.VarUint64ToBytes(dst, cm.nullCount)
 	return dst
 }
 
-func (cm *columnMetadata) unmarshal(src []byte) ([]byte, error) {
+// unmarshal decodes the column metadata written in the given format version.
+// The statistics are absent in the parts written before partFormatV2.
+func (cm *columnMetadata) unmarshal(src []byte, formatVersion uint32) ([]byte, error) {
 	src, nameBytes, err := encoding.DecodeBytes(src)
 	if err != nil {
 		return nil, fmt.Errorf("cannot unmarshal columnMetadata.name: %w", err)
@@ -63,6 +79,25 @@ func (cm *columnMetadata) unmarshal(src []byte) ([]byte, error) {
 	cm.valueType = pbv1.ValueType(src[0])
 	src = src[1:]
 	src = cm.dataBlock.unmarshal(src)
+	if formatVersion < partFormatV2 {
+		return src, nil
+	}
+	// the bounds are copied since the metadata outlives the buffer, e.g. in the block metadata cache.
+	var bound []byte
+	src, bound, err = encoding.DecodeBytes(src)
+	if err != nil {
+		return nil, fmt.Errorf("cannot unmarshal columnMetadata.min: %w", err)
+	}
+	cm.min = append(cm.min[:0], bound...)
+	src, bound, err = encoding.DecodeBytes(src)
+	if err != nil {
+		return nil, fmt.Errorf("cannot unmarshal columnMetadata.max: %w", err)
+	}
+	cm.max = append(cm.max[:0], bound...)
+	if len(src) == 0 {
+		return nil, fmt.Errorf("cannot unmarshal columnMetadata.nullCount: src is too short")
+	}
+	src, cm.nullCount = encoding.BytesToVarUint64(src)
 	return src, nil
 }
 
@@ -105,7 +140,7 @@ func (cfm *columnFamilyMetadata) marshal(dst []byte) []byte {
 	return dst
 }
 
-func (cfm *columnFamilyMetadata) unmarshal(src []byte) ([]byte, error) {
+func (cfm *columnFamilyMetadata) unmarshal(src []byte, formatVersion uint32) ([]byte, error) {
 	src, columnMetadataLen := encoding.BytesToVarUint64(src)
 	if columnMetadataLen < 1 {
 		return src, nil
@@ -113,7 +148,7 @@ func (cfm *columnFamilyMetadata) unmarshal(src []byte) ([]byte, error) {
 	cms := cfm.resizeColumnMetadata(int(columnMetadataLen))
 	var err error
 	for i := range cms {
-		src, err = cms[i].unmarshal(src)
+		src, err = cms[i].unmarshal(src, formatVersion)
 		if err != nil {
 			return nil, fmt.Errorf("cannot unmarshal columnMetadata %d: %w", i, err)
 		}
@@ -22,6 +22,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/apache/skywalking-banyandb/pkg/convert"
+	"github.com/apache/skywalking-banyandb/pkg/encoding"
 	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
 )
 
@@ -64,12 +66,48 @@ func Test_columnMetadata_marshal(t *testing.T) {
 	assert.NotNil(t, marshaled)
 
 	unmarshaled := &columnMetadata{}
-	_, err := unmarshaled.unmarshal(marshaled)
+	_, err := unmarshaled.unmarshal(marshaled, currentPartFormatVersion)
 	assert.Nil(t, err)
 
 	assert.Equal(t, original, unmarshaled)
 }
 
+func Test_columnMetadata_marshalStats(t *testing.T) {
+	original := &columnMetadata{
+		name:      "test",
+		valueType: pbv1.ValueTypeInt64,
+		dataBlock: dataBlock{offset: 1, size: 10},
+		min:       convert.Int64ToBytes(-5),
+		max:       convert.Int64ToBytes(42),
+		nullCount: 3,
+	}
+
+	marshaled := original.marshal(nil)
+	unmarshaled := &columnMetadata{}
+	tail, err := unmarshaled.unmarshal(marshaled, currentPartFormatVersion)
+	assert.Nil(t, err)
+	assert.Empty(t, tail)
+	assert.Equal(t, original, unmarshaled)
+}
+
+func Test_columnMetadata_unmarshalV1(t *testing.T) {
+	// the parts of partFormatV1 end the column metadata with the data block
+	marshaled := encoding.EncodeBytes(nil, []byte("test"))
+	marshaled = append(marshaled, byte(pbv1.ValueTypeInt64))
+	marshaled = (&dataBlock{offset: 1, size: 10}).marshal(marshaled)
+	marshaled = append(marshaled, 0xff)
+
+	unmarshaled := &columnMetadata{}
+	tail, err := unmarshaled.unmarshal(marshaled, partFormatV1)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0xff}, tail)
+	assert.Equal(t, &columnMetadata{
+		name:      "test",
+		valueType: pbv1.ValueTypeInt64,
+		dataBlock: dataBlock{offset: 1, size: 10},
+	}, unmarshaled)
+}
+
 func Test_columnFamilyMetadata_reset(t *testing.T) {
 	cfm := &columnFamilyMetadata{
 		columnMetadata: []columnMetadata{
@@ -163,7 +201,7 @@ func Test_columnFamilyMetadata_marshalUnmarshal(t *testing.T) {
 			assert.NotNil(t, marshaled)
 
 			unmarshaled := &columnFamilyMetadata{}
-			_, err := unmarshaled.unmarshal(marshaled)
+			_, err := unmarshaled.unmarshal(marshaled, currentPartFormatVersion)
 			assert.Nil(t, err)
 
 			assert.Equal(t, tt.original, unmarshaled)
@@ -18,6 +18,7 @@
 package measure
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -56,15 +57,33 @@ func TestColumn_resizeValues(t *testing.T) {
 }
 
 func TestColumn_mustWriteTo_mustReadValues(t *testing.T) {
+	var uniqueStrs [][]byte
+	for i := 0; i < 300; i++ {
+		uniqueStrs = append(uniqueStrs, []byte(fmt.Sprintf("value%d", i)))
+	}
 	tests := []struct {
-		name      string
-		values    [][]byte
-		valueType pbv1.ValueType
+		name       string
+		values     [][]byte
+		valueType  pbv1.ValueType
+		encodeType encoding.EncodeType
 	}{
 		{
-			name:      "string values with nils",
-			valueType: pbv1.ValueTypeStr,
-			values:    [][]byte{[]byte("value1"), nil, []byte("value2"), nil},
+			name:       "string values with nils",
+			valueType:  pbv1.ValueTypeStr,
+			values:     [][]byte{[]byte("value1"), nil, []byte("value2"), nil},
+			encodeType: encoding.EncodeTypeDictionary,
+		},
+		{
+			name:       "repeated string values",
+			valueType:  pbv1.ValueTypeStr,
+			values:     [][]byte{[]byte("GET"), []byte("GET"), []byte("POST"), []byte("GET")},
+			encodeType: encoding.EncodeTypeDictionary,
+		},
+		{
+			name:       "too many unique string values",
+			valueType:  pbv1.ValueTypeStr,
+			values:     uniqueStrs,
+			encodeType: encoding.EncodeTypePlain,
 		},
 		{
 			name:      "int64 values as 'null'",
@@ -102,6 +121,9 @@ func TestColumn_mustWriteTo_mustReadValues(t *testing.T) {
 			assert.Equal(t, uint64(0), cm.offset)
 			assert.Equal(t, original.name, cm.name)
 			assert.Equal(t, original.valueType, cm.valueType)
+			if tt.encodeType != encoding.EncodeTypeUnknown {
+				assert.Equal(t, tt.encodeType, encoding.EncodeType(buf.Buf[0]))
+			}
 
 			decoder := &encoding.BytesBlockDecoder{}
 			unmarshaled := &column{}
@@ -114,6 +136,20 @@ func TestColumn_mustWriteTo_mustReadValues(t *testing.T) {
 	}
 }
 
+func TestColumn_mustReadValues_legacyStr(t *testing.T) {
+	values := [][]byte{[]byte("value1"), nil, []byte("value2")}
+	buf := &bytes.Buffer{}
+	// the string values were written without the encode type.
+	buf.Buf = encoding.EncodeBytesBlock(buf.Buf, values)
+	cm := columnMetadata{name: "test", valueType: pbv1.ValueTypeStr}
+	cm.size = uint64(len(buf.Buf))
+
+	decoder := &encoding.BytesBlockDecoder{}
+	c := &column{}
+	c.mustReadValues(decoder, buf, cm, uint64(len(values)))
+	assert.Equal(t, values, c.values)
+}
+
 func TestColumnFamily_reset(t *testing.T) {
 	cf := &columnFamily{
 		name: "test",
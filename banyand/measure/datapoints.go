@@ -23,6 +23,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/apache/skywalking-banyandb/api/common"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
 	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
 	"github.com/apache/skywalking-banyandb/pkg/index"
 	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
@@ -223,10 +224,13 @@ type dataPointsInTable struct {
 	tsTable    *tsTable
 	dataPoints *dataPoints
 	timeRange  timestamp.TimeRange
+	// flush holds the data points acknowledged after they're flushed to the disk.
+	flush bool
 }
 
 type dataPointsInGroup struct {
 	tsdb            storage.TSDB[*tsTable, option]
+	group           *commonv1.Group
 	metadataDocs    index.Documents
 	indexModeDocs   index.Documents
 	metadataDocMap  map[uint64]int
@@ -0,0 +1,285 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package measure
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"time"
+
+	"go.uber.org/multierr"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	"github.com/apache/skywalking-banyandb/banyand/protector"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/partition"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+const (
+	defaultImportBatchSize = 100_000
+	maxImportLineSize      = 16 * 1024 * 1024
+)
+
+// ErrInvalidDataPoint marks a data point of the input which can't be decoded or written, Import skips it and goes on.
+var ErrInvalidDataPoint = errors.New("invalid data point")
+
+// DataPointReader reads the data points to import one by one.
+type DataPointReader interface {
+	// Next returns the next data point, or io.EOF at the end of the input.
+	Next() (*measurev1.DataPointValue, error)
+}
+
+type jsonDataPointReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// NewJSONDataPointReader returns a DataPointReader decoding one JSON-encoded DataPointValue per line of r.
+func NewJSONDataPointReader(r io.Reader) DataPointReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxImportLineSize)
+	return &jsonDataPointReader{scanner: scanner}
+}
+
+func (jr *jsonDataPointReader) Next() (*measurev1.DataPointValue, error) {
+	for jr.scanner.Scan() {
+		jr.line++
+		data := jr.scanner.Bytes()
+		if len(bytes.TrimSpace(data)) == 0 {
+			continue
+		}
+		dp := &measurev1.DataPointValue{}
+		if err := protojson.Unmarshal(data, dp); err != nil {
+			return nil, fmt.Errorf("%w at line %d: %w", ErrInvalidDataPoint, jr.line, err)
+		}
+		return dp, nil
+	}
+	if err := jr.scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read line %d: %w", jr.line+1, err)
+	}
+	return nil, io.EOF
+}
+
+// ImportOptions describes the measure to import and the place its data live in.
+type ImportOptions struct {
+	Group   *commonv1.Group
+	Measure *databasev1.Measure
+	// Root is the root path of the measure data, the same as the "measure-root-path" of the data node.
+	Root       string
+	IndexRules []*databasev1.IndexRule
+	// BatchSize is the number of data points built into the parts of a table at once.
+	BatchSize int
+}
+
+// ImportStats summarizes an import.
+type ImportStats struct {
+	DataPoints int
+	Skipped    int
+	Parts      int
+}
+
+// Import reads the data points of a measure from r, builds the sealed parts and the series index from them,
+// and installs the parts into the segments under the root path. The data points of an index-mode measure only go to the series index.
+// It bypasses the memory parts, so the data node owning the root path must be stopped while importing.
+func Import(ctx context.Context, opts ImportOptions, r DataPointReader) (stats ImportStats, err error) {
+	group := opts.Group.GetMetadata().GetName()
+	ro := opts.Group.GetResourceOpts()
+	if ro == nil {
+		return stats, fmt.Errorf("no resource opts in group %s", group)
+	}
+	if opts.Measure.GetMetadata().GetGroup() != group {
+		return stats, fmt.Errorf("measure %s doesn't belong to group %s", opts.Measure.GetMetadata(), group)
+	}
+	batchSize := opts.BatchSize
+	if batchSize < 1 {
+		batchSize = defaultImportBatchSize
+	}
+	l := logger.GetLogger("measure", "import")
+	m, err := openMeasure(measureSpec{schema: opts.Measure}, l, nil, protector.Nop{}, nil)
+	if err != nil {
+		return stats, fmt.Errorf("cannot parse measure %s: %w", opts.Measure.GetMetadata(), err)
+	}
+	m.OnIndexUpdate(opts.IndexRules)
+
+	tsdb, err := storage.OpenTSDB(
+		common.SetPosition(context.Background(), func(p common.Position) common.Position {
+			p.Module = "measure"
+			p.Database = group
+			return p
+		}),
+		storage.TSDBOpts[*tsTable, option]{
+			ShardNum:        ro.ShardNum,
+			Location:        filepath.Join(opts.Root, "measure", storage.DataDir, group),
+			TSTableCreator:  newTSTable,
+			SegmentInterval: storage.MustToIntervalRule(ro.SegmentInterval),
+			TTL:             storage.MustToIntervalRule(ro.Ttl),
+			Option: option{
+				mergePolicy:  newDefaultMergePolicy(),
+				protector:    protector.Nop{},
+				flushTimeout: defaultFlushTimeout,
+			},
+			SeriesIndexFlushTimeoutSeconds: defaultFlushTimeout.Nanoseconds() / int64(time.Second),
+			// the history might be older than the TTL, leave it to the data node.
+			DisableRetention: true,
+		}, nil, group)
+	if err != nil {
+		return stats, fmt.Errorf("cannot open the database of group %s: %w", group, err)
+	}
+	defer func() {
+		err = multierr.Append(err, tsdb.Close())
+	}()
+
+	im := &importer{
+		tsdb:     tsdb,
+		is:       m.loadIndexSchema(),
+		l:        l,
+		group:    opts.Group,
+		md:       opts.Measure.GetMetadata(),
+		locator:  partition.NewEntityLocator(opts.Measure.GetTagFamilies(), opts.Measure.GetEntity(), 0),
+		shardNum: ro.ShardNum,
+	}
+	if shardingKey := opts.Measure.GetShardingKey(); len(shardingKey.GetTagNames()) > 0 {
+		l := partition.NewShardingKeyLocator(opts.Measure.GetTagFamilies(), shardingKey)
+		im.shardingKeyLocator = &l
+	}
+	im.reset()
+	var pending int
+	for n := 1; ; n++ {
+		if err = ctx.Err(); err != nil {
+			return stats, err
+		}
+		dp, errNext := r.Next()
+		if errors.Is(errNext, io.EOF) {
+			break
+		}
+		if errNext == nil {
+			errNext = im.add(dp)
+		}
+		if errNext != nil {
+			if !errors.Is(errNext, ErrInvalidDataPoint) {
+				stats.Parts += im.flush()
+				return stats, errNext
+			}
+			l.Warn().Err(errNext).Int("data_point", n).Msg("skip the data point")
+			stats.Skipped++
+			continue
+		}
+		stats.DataPoints++
+		if pending++; pending >= batchSize {
+			stats.Parts += im.flush()
+			pending = 0
+		}
+	}
+	stats.Parts += im.flush()
+	return stats, nil
+}
+
+type importer struct {
+	tsdb               storage.TSDB[*tsTable, option]
+	is                 *indexSchema
+	l                  *logger.Logger
+	group              *commonv1.Group
+	md                 *commonv1.Metadata
+	dpg                *dataPointsInGroup
+	shardingKeyLocator *partition.Locator
+	locator            partition.Locator
+	shardNum           uint32
+}
+
+func (im *importer) reset() {
+	im.dpg = &dataPointsInGroup{
+		tsdb:            im.tsdb,
+		group:           im.group,
+		metadataDocMap:  make(map[uint64]int),
+		indexModeDocMap: make(map[uint64]int),
+	}
+}
+
+func (im *importer) add(dp *measurev1.DataPointValue) error {
+	if dp.GetTimestamp() == nil {
+		return fmt.Errorf("%w: the timestamp is absent", ErrInvalidDataPoint)
+	}
+	t := dp.GetTimestamp().AsTime().Local()
+	if err := timestamp.Check(t); err != nil {
+		return fmt.Errorf("%w: invalid timestamp: %w", ErrInvalidDataPoint, err)
+	}
+	tagValues, shardID, err := im.locator.Locate(im.md.GetName(), dp.GetTagFamilies(), im.shardNum)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidDataPoint, err)
+	}
+	if im.shardingKeyLocator != nil {
+		if _, shardID, err = im.shardingKeyLocator.Locate(im.md.GetName(), dp.GetTagFamilies(), im.shardNum); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidDataPoint, err)
+		}
+	}
+	if ts := t.UnixNano(); im.dpg.latestTS < ts {
+		im.dpg.latestTS = ts
+	}
+	// the imported data points are always flushed to the disk.
+	if err = appendDataPoint(im.dpg, im.is, &measurev1.InternalWriteRequest{
+		Request: &measurev1.WriteRequest{
+			Metadata:  im.md,
+			DataPoint: dp,
+		},
+		ShardId:      uint32(shardID),
+		EntityValues: tagValues[1:].Encode(),
+	}, t, true); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidDataPoint, err)
+	}
+	return nil
+}
+
+// flush installs the pending data points into their tables, and returns the number of the built parts.
+func (im *importer) flush() (parts int) {
+	for _, dpt := range im.dpg.tables {
+		if dpt.dataPoints == nil {
+			continue
+		}
+		if len(dpt.dataPoints.timestamps) > 0 {
+			dpt.tsTable.mustFlushDataPoints(dpt.dataPoints)
+			parts++
+		}
+		releaseDataPoints(dpt.dataPoints)
+	}
+	for _, segment := range im.dpg.segments {
+		if len(im.dpg.metadataDocs) > 0 {
+			if err := segment.IndexDB().Insert(im.dpg.metadataDocs); err != nil {
+				im.l.Error().Err(err).Msg("cannot write metadata")
+			}
+		}
+		if len(im.dpg.indexModeDocs) > 0 {
+			if err := segment.IndexDB().Update(im.dpg.indexModeDocs); err != nil {
+				im.l.Error().Err(err).Msg("cannot write index")
+			}
+		}
+		segment.DecRef()
+	}
+	im.reset()
+	return parts
+}
@@ -0,0 +1,127 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package measure
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	"github.com/apache/skywalking-banyandb/banyand/protector"
+	"github.com/apache/skywalking-banyandb/pkg/test"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+func TestImport(t *testing.T) {
+	tmpPath, defFn := test.Space(require.New(t))
+	defer defFn()
+
+	opts := ImportOptions{
+		Group: &commonv1.Group{
+			Metadata: &commonv1.Metadata{Name: "default"},
+			Catalog:  commonv1.Catalog_CATALOG_MEASURE,
+			ResourceOpts: &commonv1.ResourceOpts{
+				ShardNum:        2,
+				SegmentInterval: &commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_DAY, Num: 1},
+				Ttl:             &commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_DAY, Num: 7},
+			},
+		},
+		Measure: &databasev1.Measure{
+			Metadata: &commonv1.Metadata{Name: "service_cpm", Group: "default"},
+			Entity:   &databasev1.Entity{TagNames: []string{"service"}},
+			TagFamilies: []*databasev1.TagFamilySpec{{
+				Name: "default",
+				Tags: []*databasev1.TagSpec{
+					{Name: "service", Type: databasev1.TagType_TAG_TYPE_STRING},
+					{Name: "layer", Type: databasev1.TagType_TAG_TYPE_STRING},
+				},
+			}},
+			Fields: []*databasev1.FieldSpec{
+				{
+					Name: "total", FieldType: databasev1.FieldType_FIELD_TYPE_INT,
+					EncodingMethod: databasev1.EncodingMethod_ENCODING_METHOD_GORILLA, CompressionMethod: databasev1.CompressionMethod_COMPRESSION_METHOD_ZSTD,
+				},
+			},
+		},
+		Root:      tmpPath,
+		BatchSize: 3,
+	}
+	// the data points span two days.
+	begin := time.Date(2024, 1, 1, 12, 0, 0, 0, time.Local)
+	var input strings.Builder
+	for i := 0; i < 8; i++ {
+		ts := begin.Add(time.Duration(i) * 4 * time.Hour)
+		fmt.Fprintf(&input, `{"timestamp":%q,"version":"1","tagFamilies":[{"tags":[{"str":{"value":"svc-%d"}},{"str":{"value":"general"}}]}],"fields":[{"int":{"value":%d}}]}`+"\n",
+			ts.UTC().Format(time.RFC3339Nano), i%2, i*10)
+	}
+	input.WriteString("\n")
+	input.WriteString(`{"version":"1","tagFamilies":[]}` + "\n")
+	input.WriteString("not json\n")
+
+	stats, err := Import(context.Background(), opts, NewJSONDataPointReader(strings.NewReader(input.String())))
+	require.NoError(t, err)
+	assert.Equal(t, 8, stats.DataPoints)
+	assert.Equal(t, 2, stats.Skipped)
+	assert.Positive(t, stats.Parts)
+
+	db, err := storage.OpenTSDB(common.SetPosition(context.Background(), func(p common.Position) common.Position {
+		p.Module = "measure"
+		p.Database = "default"
+		return p
+	}), storage.TSDBOpts[*tsTable, option]{
+		ShardNum:         2,
+		Location:         filepath.Join(tmpPath, "measure", storage.DataDir, "default"),
+		TSTableCreator:   newTSTable,
+		SegmentInterval:  storage.IntervalRule{Unit: storage.DAY, Num: 1},
+		TTL:              storage.IntervalRule{Unit: storage.DAY, Num: 7},
+		Option:           option{flushTimeout: time.Hour, mergePolicy: newDefaultMergePolicyForTesting(), protector: protector.Nop{}},
+		DisableRetention: true,
+	}, nil, "default")
+	require.NoError(t, err)
+	defer db.Close()
+	segments, err := db.SelectSegments(timestamp.NewInclusiveTimeRange(begin.Add(-time.Hour), begin.Add(48*time.Hour)))
+	require.NoError(t, err)
+	assert.Len(t, segments, 2)
+	var total uint64
+	for _, s := range segments {
+		tables, _ := s.Tables()
+		for _, tst := range tables {
+			snp := tst.currentSnapshot()
+			if snp == nil {
+				continue
+			}
+			for _, pw := range snp.parts {
+				assert.Nil(t, pw.mp, "the imported parts should be on the disk")
+				total += pw.p.partMetadata.TotalCount
+			}
+			snp.decRef()
+		}
+		s.DecRef()
+	}
+	assert.Equal(t, uint64(8), total)
+}
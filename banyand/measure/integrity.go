@@ -0,0 +1,105 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package measure
+
+import (
+	"context"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+	"github.com/apache/skywalking-banyandb/pkg/bytes"
+	"github.com/apache/skywalking-banyandb/pkg/compress/zstd"
+	"github.com/apache/skywalking-banyandb/pkg/fs"
+)
+
+type digestListener struct {
+	*bus.UnImplementedHealthyListener
+	s *service
+}
+
+// Rev digests the shard of a measure group. The requests of the other groups are ignored.
+func (l *digestListener) Rev(_ context.Context, message bus.Message) bus.Message {
+	var result any
+	if req, ok := message.Data().(*databasev1.IntegrityServiceDigestRequest); ok {
+		resp, err := storage.ShardDigest[*tsTable, option](commonv1.Catalog_CATALOG_MEASURE, l.s.schemaRepo, req)
+		switch {
+		case err != nil:
+			result = err
+		case resp != nil:
+			result = resp
+		}
+	}
+	return bus.NewMessage(bus.MessageID(time.Now().UnixNano()), result)
+}
+
+// Digest adds the parts in the snapshot to td. The checksum of a part hashes its block metadata,
+// which holds the series, the counts, the timestamp ranges and the layout of its blocks.
+func (tst *tsTable) Digest(td *storage.TableDigest) {
+	snp := tst.currentSnapshot()
+	if snp == nil {
+		return
+	}
+	defer snp.decRef()
+	for _, pw := range snp.parts {
+		pd, err := digestPart(pw.p, td)
+		if err != nil {
+			tst.l.Warn().Err(err).Stringer("part", pw.p).Msg("failed to digest the part")
+			continue
+		}
+		pd.InMemory = pw.mp != nil
+		td.AddPart(pd)
+	}
+}
+
+func digestPart(p *part, td *storage.TableDigest) (*databasev1.PartDigest, error) {
+	h := xxhash.New()
+	var compressed, primary []byte
+	var bms []blockMetadata
+	var timestamps []int64
+	var versions []int64
+	var err error
+	for i := range p.primaryBlockMetadata {
+		pbm := &p.primaryBlockMetadata[i]
+		compressed = bytes.ResizeOver(compressed, int(pbm.size))
+		fs.MustReadData(p.primary, int64(pbm.offset), compressed)
+		if primary, err = zstd.Decompress(primary[:0], compressed); err != nil {
+			return nil, err
+		}
+		_, _ = h.Write(primary)
+		if bms, err = unmarshalBlockMetadata(bms[:0], primary, p.partMetadata.formatVersion()); err != nil {
+			return nil, err
+		}
+		for j := range bms {
+			timestamps, versions = mustReadTimestampsFrom(timestamps[:0], versions[:0], &bms[j].timestamps, int(bms[j].count), p.timestamps)
+			td.AddBlock(bms[j].seriesID, timestamps)
+		}
+	}
+	return &databasev1.PartDigest{
+		Id:           p.partMetadata.ID,
+		Checksum:     h.Sum64(),
+		TotalCount:   p.partMetadata.TotalCount,
+		BlocksCount:  p.partMetadata.BlocksCount,
+		MinTimestamp: p.partMetadata.MinTimestamp,
+		MaxTimestamp: p.partMetadata.MaxTimestamp,
+	}, nil
+}
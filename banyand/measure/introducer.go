@@ -24,12 +24,17 @@ import (
 
 type introduction struct {
 	memPart *partWrapper
+	removed map[uint64]struct{}
 	applied chan struct{}
+	// imported are the parts built on the disk, which are introduced without a memory part.
+	imported []*partWrapper
 }
 
 func (i *introduction) reset() {
 	i.memPart = nil
+	i.removed = nil
 	i.applied = nil
+	i.imported = nil
 }
 
 var introductionPool = pool.Register[*introduction]("measure-introduction")
@@ -118,6 +123,14 @@ func (tst *tsTable) introducerLoop(flushCh chan *flusherIntroduction, mergeCh ch
 		case <-tst.loopCloser.CloseNotify():
 			return
 		case next := <-tst.introductions:
+			if next.memPart == nil {
+				tst.incTotalIntroduceLoopStarted(1, "import")
+				tst.introduceImported(next, epoch)
+				tst.incTotalIntroduceLoopFinished(1, "import")
+				tst.gc.clean()
+				epoch++
+				break
+			}
 			tst.incTotalIntroduceLoopStarted(1, "mem")
 			tst.introduceMemPart(next, epoch)
 			tst.incTotalIntroduceLoopFinished(1, "mem")
@@ -160,6 +173,25 @@ func (tst *tsTable) introduceMemPart(nextIntroduction *introduction, epoch uint6
 	}
 }
 
+// introduceImported installs the parts built on the disk, removes the replaced ones,
+// and persists the manifest listing them.
+func (tst *tsTable) introduceImported(nextIntroduction *introduction, epoch uint64) {
+	cur := tst.currentSnapshot()
+	if cur != nil {
+		defer cur.decRef()
+	} else {
+		cur = new(snapshot)
+	}
+
+	nextSnp := cur.remove(epoch, nextIntroduction.removed)
+	nextSnp.parts = append(nextSnp.parts, nextIntroduction.imported...)
+	nextSnp.creator = snapshotCreatorFlusher
+	tst.replaceSnapshot(&nextSnp, true)
+	if nextIntroduction.applied != nil {
+		close(nextIntroduction.applied)
+	}
+}
+
 func (tst *tsTable) introduceFlushed(nextIntroduction *flusherIntroduction, epoch uint64) {
 	cur := tst.currentSnapshot()
 	if cur == nil {
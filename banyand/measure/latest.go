@@ -0,0 +1,109 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package measure
+
+import (
+	"math"
+	"sort"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	"github.com/apache/skywalking-banyandb/pkg/query/model"
+)
+
+// pruneLatestBlocks drops the blocks which can't contain the latest data point of their series.
+//
+// The max timestamp of a block is a data point in the time range if it isn't greater than the end of the range,
+// which gives a floor of the latest timestamp of the series. The blocks whose max timestamps in the range
+// are less than the floor are released, and the remaining ones only load the data points not less than the floor.
+func pruneLatestBlocks(bcs []*blockCursor) []*blockCursor {
+	floors := make(map[common.SeriesID]int64)
+	for _, bc := range bcs {
+		var floor int64
+		switch {
+		case bc.bm.timestamps.max <= bc.maxTimestamp:
+			floor = bc.bm.timestamps.max
+		case bc.bm.timestamps.min >= bc.minTimestamp && bc.bm.timestamps.min <= bc.maxTimestamp:
+			floor = bc.bm.timestamps.min
+		default:
+			floor = math.MinInt64
+		}
+		if f, ok := floors[bc.bm.seriesID]; !ok || floor > f {
+			floors[bc.bm.seriesID] = floor
+		}
+	}
+	result := bcs[:0]
+	for _, bc := range bcs {
+		floor := floors[bc.bm.seriesID]
+		if min(bc.bm.timestamps.max, bc.maxTimestamp) < floor {
+			releaseBlockCursor(bc)
+			continue
+		}
+		if floor > bc.minTimestamp {
+			bc.minTimestamp = floor
+		}
+		result = append(result, bc)
+	}
+	for i := len(result); i < len(bcs); i++ {
+		bcs[i] = nil
+	}
+	return result
+}
+
+// pullLatest returns the latest data point of a series on every call.
+func (qr *queryResult) pullLatest() *model.MeasureResult {
+	if qr.latestResults == nil && qr.Len() > 0 {
+		for qr.Len() > 0 {
+			r := qr.merge(qr.storedIndexValue, qr.tagProjection)
+			if len(r.Timestamps) < 1 {
+				continue
+			}
+			keepLatest(r)
+			qr.latestResults = append(qr.latestResults, r)
+		}
+		if qr.latestOrderByTS {
+			sort.SliceStable(qr.latestResults, func(i, j int) bool {
+				if qr.ascTS {
+					return qr.latestResults[i].Timestamps[0] < qr.latestResults[j].Timestamps[0]
+				}
+				return qr.latestResults[i].Timestamps[0] > qr.latestResults[j].Timestamps[0]
+			})
+		}
+	}
+	if len(qr.latestResults) == 0 {
+		return nil
+	}
+	r := qr.latestResults[0]
+	qr.latestResults[0] = nil
+	qr.latestResults = qr.latestResults[1:]
+	return r
+}
+
+// keepLatest truncates the data points of a series which are sorted by timestamps in ascending order to the last one.
+func keepLatest(r *model.MeasureResult) {
+	last := len(r.Timestamps) - 1
+	r.Timestamps = r.Timestamps[last:]
+	r.Versions = r.Versions[last:]
+	for i := range r.TagFamilies {
+		for j := range r.TagFamilies[i].Tags {
+			r.TagFamilies[i].Tags[j].Values = r.TagFamilies[i].Tags[j].Values[last:]
+		}
+	}
+	for i := range r.Fields {
+		r.Fields[i].Values = r.Fields[i].Values[last:]
+	}
+}
@@ -0,0 +1,162 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package measure
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	itest "github.com/apache/skywalking-banyandb/banyand/internal/test"
+	"github.com/apache/skywalking-banyandb/pkg/fs"
+	"github.com/apache/skywalking-banyandb/pkg/run"
+	"github.com/apache/skywalking-banyandb/pkg/test"
+	"github.com/apache/skywalking-banyandb/pkg/watcher"
+)
+
+func TestPruneLatestBlocks(t *testing.T) {
+	newCursor := func(sid common.SeriesID, blockMin, blockMax int64) *blockCursor {
+		bc := generateBlockCursor()
+		bc.bm.seriesID = sid
+		bc.bm.timestamps.min = blockMin
+		bc.bm.timestamps.max = blockMax
+		bc.minTimestamp = 0
+		bc.maxTimestamp = 15
+		return bc
+	}
+	bcs := []*blockCursor{
+		newCursor(1, 1, 5),
+		newCursor(1, 6, 10),
+		newCursor(1, 8, 20),
+		newCursor(2, 12, 30),
+		newCursor(3, -5, 30),
+	}
+	got := pruneLatestBlocks(bcs)
+	defer func() {
+		for _, bc := range got {
+			releaseBlockCursor(bc)
+		}
+	}()
+	type block struct {
+		sid          common.SeriesID
+		blockMax     int64
+		minTimestamp int64
+	}
+	var blocks []block
+	for _, bc := range got {
+		blocks = append(blocks, block{sid: bc.bm.seriesID, blockMax: bc.bm.timestamps.max, minTimestamp: bc.minTimestamp})
+	}
+	assert.Equal(t, []block{
+		{sid: 1, blockMax: 10, minTimestamp: 10},
+		{sid: 1, blockMax: 20, minTimestamp: 10},
+		{sid: 2, blockMax: 30, minTimestamp: 12},
+		{sid: 3, blockMax: 30, minTimestamp: 0},
+	}, blocks)
+}
+
+func TestQueryResult_Latest(t *testing.T) {
+	tests := []struct {
+		name     string
+		wantTS   []int64
+		wantVers []int64
+		ascTS    bool
+	}{
+		{
+			name:     "order by TS asc",
+			ascTS:    true,
+			wantTS:   []int64{2, 2, 2},
+			wantVers: []int64{4, 5, 6},
+		},
+		{
+			name:     "order by TS desc",
+			wantTS:   []int64{2, 2, 2},
+			wantVers: []int64{4, 5, 6},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpPath, defFn := test.Space(require.New(t))
+			defer defFn()
+			tst := &tsTable{
+				loopCloser:    run.NewCloser(2),
+				introductions: make(chan *introduction),
+				fileSystem:    fs.NewLocalFileSystem(),
+				root:          tmpPath,
+			}
+			tst.gc.init(tst)
+			flushCh := make(chan *flusherIntroduction)
+			mergeCh := make(chan *mergerIntroduction)
+			introducerWatcher := make(watcher.Channel, 1)
+			go tst.introducerLoop(flushCh, mergeCh, introducerWatcher, 1)
+			defer tst.Close()
+			for _, dps := range []*dataPoints{dpsTS1, dpsTS2, dpsTS11} {
+				tst.mustAddDataPoints(dps)
+				time.Sleep(100 * time.Millisecond)
+			}
+
+			m := &measure{pm: &itest.MockMemoryProtector{}}
+			queryOpts := queryOptions{
+				minTimestamp: 1,
+				maxTimestamp: 2,
+			}
+			queryOpts.Latest = true
+			queryOpts.TagProjection = tagProjections[1]
+			queryOpts.FieldProjection = fieldProjections[1]
+			s := tst.currentSnapshot()
+			require.NotNil(t, s)
+			defer s.decRef()
+			shardCache := storage.NewShardCache("test-group", 0, 0)
+			pp, _ := s.getParts(nil, shardCache, queryOpts.minTimestamp, queryOpts.maxTimestamp)
+			result := queryResult{
+				ctx:             context.TODO(),
+				tagProjection:   allTagProjections,
+				latest:          true,
+				latestOrderByTS: true,
+				ascTS:           tt.ascTS,
+			}
+			require.NoError(t, m.searchBlocks(context.TODO(), &result, []common.SeriesID{1, 2, 3}, pp, queryOpts))
+			defer result.Release()
+			// the blocks of the first and the third parts can't contain the latest data points
+			require.Len(t, result.data, 3)
+
+			var sids []common.SeriesID
+			var timestamps, versions []int64
+			for {
+				r := result.Pull()
+				if r == nil {
+					break
+				}
+				require.NoError(t, r.Error)
+				sids = append(sids, r.SID)
+				timestamps = append(timestamps, r.Timestamps...)
+				versions = append(versions, r.Versions...)
+				for _, f := range r.Fields {
+					require.Len(t, f.Values, 1)
+				}
+			}
+			assert.Equal(t, []common.SeriesID{1, 2, 3}, sids)
+			assert.Equal(t, tt.wantTS, timestamps)
+			assert.Equal(t, tt.wantVers, versions)
+		})
+	}
+}
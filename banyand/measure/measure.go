@@ -45,20 +45,30 @@ const (
 )
 
 type option struct {
-	mergePolicy        *mergePolicy
+	mergePolicy        mergePolicy
 	protector          protector.Memory
+	tombstones         *tombstones
+	rangeTombstones    *storage.RangeTombstones
 	seriesCacheMaxSize run.Bytes
+	packPartMaxSize    run.Bytes
 	flushTimeout       time.Duration
+	flushWorkers       int
 }
 
+// indexSchema is an immutable snapshot of the measure schema and its index rules.
+// Writes and queries load it once and use it for their whole duration,
+// so the tag families and the rule locators always come from the same epoch.
 type indexSchema struct {
+	schema             *databasev1.Measure
 	indexTagMap        map[string]struct{}
 	fieldIndexLocation partition.FieldIndexLocation
 	indexRuleLocators  partition.IndexRuleLocator
 	indexRules         []*databasev1.IndexRule
+	epoch              uint64
 }
 
 func (i *indexSchema) parse(schema *databasev1.Measure) {
+	i.schema = schema
 	i.indexRuleLocators, i.fieldIndexLocation = partition.ParseIndexRuleLocators(schema.GetEntity(), schema.GetTagFamilies(), i.indexRules, schema.IndexMode)
 	i.indexTagMap = make(map[string]struct{})
 	for j := range i.indexRules {
@@ -69,7 +79,7 @@ func (i *indexSchema) parse(schema *databasev1.Measure) {
 }
 
 type measure struct {
-	indexSchema atomic.Value
+	indexSchema atomic.Pointer[indexSchema]
 	tsdb        atomic.Value
 	c           storage.Cache
 	pm          protector.Memory
@@ -79,6 +89,7 @@ type measure struct {
 	name        string
 	group       string
 	interval    time.Duration
+	epoch       atomic.Uint64
 }
 
 func (m *measure) GetSchema() *databasev1.Measure {
@@ -90,14 +101,32 @@ func (m *measure) GetIndexRules() []*databasev1.IndexRule {
 	if is == nil {
 		return nil
 	}
-	return is.(indexSchema).indexRules
+	return is.indexRules
+}
+
+// loadIndexSchema pins the current schema epoch.
+func (m *measure) loadIndexSchema() *indexSchema {
+	return m.indexSchema.Load()
+}
+
+// storeIndexSchema publishes a new epoch unless a later one has been published by a concurrent update.
+func (m *measure) storeIndexSchema(is *indexSchema) {
+	for {
+		cur := m.indexSchema.Load()
+		if cur != nil && cur.epoch > is.epoch {
+			return
+		}
+		if m.indexSchema.CompareAndSwap(cur, is) {
+			return
+		}
+	}
 }
 
 func (m *measure) OnIndexUpdate(index []*databasev1.IndexRule) {
-	var is indexSchema
-	is.indexRules = index
+	is := &indexSchema{indexRules: index}
 	is.parse(m.schema)
-	m.indexSchema.Store(is)
+	is.epoch = m.epoch.Add(1)
+	m.storeIndexSchema(is)
 }
 
 func (m *measure) parseSpec() (err error) {
@@ -105,9 +134,10 @@ func (m *measure) parseSpec() (err error) {
 	if m.schema.Interval != "" {
 		m.interval, err = timestamp.ParseDuration(m.schema.Interval)
 	}
-	var is indexSchema
+	is := &indexSchema{}
 	is.parse(m.schema)
-	m.indexSchema.Store(is)
+	is.epoch = m.epoch.Add(1)
+	m.storeIndexSchema(is)
 	return err
 }
 
@@ -25,6 +25,7 @@ import (
 
 	"github.com/dustin/go-humanize"
 
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
 	"github.com/apache/skywalking-banyandb/pkg/cgroups"
 	"github.com/apache/skywalking-banyandb/pkg/encoding"
 	"github.com/apache/skywalking-banyandb/pkg/fs"
@@ -91,7 +92,7 @@ func (tst *tsTable) mergeSnapshot(curSnapshot *snapshot, merges chan *mergerIntr
 	freeDiskSize := tst.freeDiskSpace(tst.root)
 	var toBeMerged map[uint64]struct{}
 	dst, toBeMerged = tst.getPartsToMerge(curSnapshot, freeDiskSize, dst)
-	if len(dst) < 2 {
+	if len(dst) == 0 {
 		return nil, nil
 	}
 	if _, err := tst.mergePartsThenSendIntroduction(snapshotCreatorMerger, dst,
@@ -203,13 +204,19 @@ func (tst *tsTable) getPartsToMerge(snapshot *snapshot, freeDiskSize uint64, dst
 	var parts []*partWrapper
 
 	for _, pw := range snapshot.parts {
-		if pw.mp != nil || pw.p.partMetadata.TotalCount < 1 {
+		// the replicated parts mirror the primary, which merges them instead.
+		if pw.mp != nil || pw.p.partMetadata.TotalCount < 1 || pw.p.partMetadata.ReplicaOf > 0 {
 			continue
 		}
 		parts = append(parts, pw)
 	}
 
 	dst = tst.option.mergePolicy.getPartsToMerge(dst, parts, freeDiskSize)
+	if len(dst) == 0 {
+		// upgrade the parts in older formats when there is nothing worth merging,
+		// a single part is rewritten alone.
+		dst = tst.option.mergePolicy.getOutdatedParts(dst, parts, freeDiskSize)
+	}
 	if len(dst) == 0 {
 		return nil, nil
 	}
@@ -253,7 +260,7 @@ func (tst *tsTable) mergeParts(fileSystem fs.FileSystem, closeCh <-chan struct{}
 	bw := generateBlockWriter()
 	bw.mustInitForFilePart(fileSystem, dstPath, shouldCache)
 
-	pm, err := mergeBlocks(closeCh, bw, br)
+	pm, err := mergeBlocks(closeCh, bw, br, tst.option.tombstones, tst.option.rangeTombstones)
 	releaseBlockWriter(bw)
 	releaseBlockReader(br)
 	for i := range pii {
@@ -262,6 +269,9 @@ func (tst *tsTable) mergeParts(fileSystem fs.FileSystem, closeCh <-chan struct{}
 	if err != nil {
 		return nil, err
 	}
+	for i := range parts {
+		pm.IngestedAt = max(pm.IngestedAt, parts[i].p.partMetadata.IngestedAt)
+	}
 	pm.mustWriteMetadata(fileSystem, dstPath)
 	fileSystem.SyncPath(dstPath)
 	p := mustOpenFilePart(partID, root, fileSystem)
@@ -270,7 +280,7 @@ func (tst *tsTable) mergeParts(fileSystem fs.FileSystem, closeCh <-chan struct{}
 
 var errClosed = fmt.Errorf("the merger is closed")
 
-func mergeBlocks(closeCh <-chan struct{}, bw *blockWriter, br *blockReader) (*partMetadata, error) {
+func mergeBlocks(closeCh <-chan struct{}, bw *blockWriter, br *blockReader, ts *tombstones, rt *storage.RangeTombstones) (*partMetadata, error) {
 	pendingBlockIsEmpty := true
 	pendingBlock := generateBlockPointer()
 	defer releaseBlockPointer(pendingBlock)
@@ -296,8 +306,30 @@ func mergeBlocks(closeCh <-chan struct{}, bw *blockWriter, br *blockReader) (*pa
 		}
 		b := br.block
 
-		if pendingBlockIsEmpty {
+		// the block data is always read to keep the readers in sequence,
+		// then the deleted data points are skipped.
+		loaded := false
+		if ingestedAt := br.ingestedAt(); rt.Overlaps(b.bm.timestamps.min, b.bm.timestamps.max, ingestedAt) {
 			br.loadBlockData(getDecoder())
+			loaded = true
+			if !b.dropDeleted(rt, ingestedAt) {
+				continue
+			}
+		}
+		if deletedAt, ok := ts.deletedAt(b.bm.seriesID); ok && deletedAt >= b.bm.timestamps.min {
+			if !loaded {
+				br.loadBlockData(getDecoder())
+				loaded = true
+			}
+			if !b.skipUntil(deletedAt) {
+				continue
+			}
+		}
+
+		if pendingBlockIsEmpty {
+			if !loaded {
+				br.loadBlockData(getDecoder())
+			}
 			pendingBlock.copyFrom(b)
 			pendingBlockIsEmpty = false
 			continue
@@ -306,8 +338,11 @@ func mergeBlocks(closeCh <-chan struct{}, bw *blockWriter, br *blockReader) (*pa
 		if pendingBlock.bm.seriesID != b.bm.seriesID ||
 			(pendingBlock.isFull() && pendingBlock.bm.timestamps.max <= b.bm.timestamps.min) {
 			bw.mustWriteBlock(pendingBlock.bm.seriesID, &pendingBlock.block)
-			releaseDecoder()
-			br.loadBlockData(getDecoder())
+			if !loaded {
+				// the decoder is kept if it holds the data of the loaded block
+				releaseDecoder()
+				br.loadBlockData(getDecoder())
+			}
 			pendingBlock.copyFrom(b)
 			continue
 		}
@@ -318,7 +353,9 @@ func mergeBlocks(closeCh <-chan struct{}, bw *blockWriter, br *blockReader) (*pa
 		}
 		tmpBlock.reset()
 		tmpBlock.bm.seriesID = b.bm.seriesID
-		br.loadBlockData(getDecoder())
+		if !loaded {
+			br.loadBlockData(getDecoder())
+		}
 		mergeTwoBlocks(tmpBlock, pendingBlock, b)
 		if len(tmpBlock.timestamps) <= maxBlockLength && tmpBlock.uncompressedSizeBytes() <= maxUncompressedBlockSize {
 			if len(tmpBlock.timestamps) == 0 {
@@ -383,6 +383,29 @@ func Test_mergeParts(t *testing.T) {
 				}
 				verify(t, fpp, fileSystem, tmpPath, uint64(len(tt.dpsList)))
 			})
+
+			t.Run("packed file parts", func(t *testing.T) {
+				var fpp []*partWrapper
+				tmpPath, defFn := test.Space(require.New(t))
+				defer func() {
+					for _, pw := range fpp {
+						pw.decRef()
+					}
+					defFn()
+				}()
+				fileSystem := fs.NewLocalFileSystem()
+				for i, dps := range tt.dpsList {
+					mp := generateMemPart()
+					mp.mustInitFromDataPoints(dps)
+					mp.mustFlushPacked(fileSystem, partPath(tmpPath, uint64(i)))
+					require.NoError(t, validatePart(fileSystem, partPath(tmpPath, uint64(i))))
+					filePW := newPartWrapper(nil, mustOpenFilePart(uint64(i), tmpPath, fileSystem))
+					filePW.p.partMetadata.ID = uint64(i)
+					fpp = append(fpp, filePW)
+					releaseMemPart(mp)
+				}
+				verify(t, fpp, fileSystem, tmpPath, uint64(len(tt.dpsList)))
+			})
 		})
 	}
 }
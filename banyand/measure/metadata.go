@@ -40,6 +40,7 @@ import (
 	"github.com/apache/skywalking-banyandb/banyand/protector"
 	"github.com/apache/skywalking-banyandb/banyand/queue"
 	"github.com/apache/skywalking-banyandb/banyand/queue/pub"
+	"github.com/apache/skywalking-banyandb/pkg/fs"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
 	resourceSchema "github.com/apache/skywalking-banyandb/pkg/schema"
 	"github.com/apache/skywalking-banyandb/pkg/timestamp"
@@ -79,6 +80,8 @@ type schemaRepo struct {
 	pipeline         queue.Client
 	l                *logger.Logger
 	topNProcessorMap sync.Map
+	tombstones       sync.Map
+	rangeTombstones  sync.Map
 	path             string
 }
 
@@ -143,6 +146,18 @@ func (sr *schemaRepo) GetRemovalSegmentsTimeRange(group string) *timestamp.TimeR
 	return db.(storage.TSDB[*tsTable, option]).GetExpiredSegmentsTimeRange()
 }
 
+func (sr *schemaRepo) GetSegmentsTimeRanges(group string) []timestamp.TimeRange {
+	g, ok := sr.LoadGroup(group)
+	if !ok {
+		return nil
+	}
+	db := g.SupplyTSDB()
+	if db == nil {
+		return nil
+	}
+	return db.(storage.TSDB[*tsTable, option]).GetSegmentsTimeRanges()
+}
+
 func (sr *schemaRepo) OnInit(kinds []schema.Kind) (bool, []int64) {
 	if len(kinds) != 5 {
 		logger.Panicf("unexpected kinds: %v", kinds)
@@ -341,15 +356,20 @@ func (sr *schemaRepo) createTopNResultMeasure(ctx context.Context, measureSchema
 var _ resourceSchema.ResourceSupplier = (*supplier)(nil)
 
 type supplier struct {
-	metadata   metadata.Repo
-	omr        observability.MetricsRegistry
-	l          *logger.Logger
-	c          storage.Cache
-	pm         protector.Memory
-	schemaRepo *schemaRepo
-	nodeLabels map[string]string
-	path       string
-	option     option
+	metadata      metadata.Repo
+	omr           observability.MetricsRegistry
+	l             *logger.Logger
+	c             storage.Cache
+	pm            protector.Memory
+	replayCache   *storage.ReplayCache
+	fdp           protector.FD
+	iop           protector.IO
+	lfs           fs.FileSystem
+	schemaRepo    *schemaRepo
+	nodeLabels    map[string]string
+	path          string
+	option        option
+	segmentWarmup bool
 }
 
 func newSupplier(path string, svc *service, sr *schemaRepo, nodeLabels map[string]string) *supplier {
@@ -364,15 +384,20 @@ func newSupplier(path string, svc *service, sr *schemaRepo, nodeLabels map[strin
 	}
 
 	return &supplier{
-		path:       path,
-		metadata:   svc.metadata,
-		l:          svc.l,
-		c:          svc.c,
-		option:     opt,
-		omr:        svc.omr,
-		pm:         svc.pm,
-		schemaRepo: sr,
-		nodeLabels: nodeLabels,
+		path:          path,
+		metadata:      svc.metadata,
+		l:             svc.l,
+		c:             svc.c,
+		replayCache:   svc.replayCache,
+		option:        opt,
+		omr:           svc.omr,
+		pm:            svc.pm,
+		fdp:           svc.fdp,
+		iop:           svc.iop,
+		lfs:           svc.lfs,
+		schemaRepo:    sr,
+		nodeLabels:    nodeLabels,
+		segmentWarmup: svc.segmentWarmup,
 	}
 }
 
@@ -428,19 +453,33 @@ func (s *supplier) OpenDB(groupSchema *commonv1.Group) (resourceSchema.DB, error
 		}
 	}
 	group := groupSchema.Metadata.Name
+	opt := s.option
+	opt.mergePolicy = newGroupMergePolicy(ro.GetCompaction(), opt.mergePolicy)
+	location := path.Join(s.path, group)
+	var err error
+	if opt.tombstones, err = s.schemaRepo.openTombstones(s.lfs, group, location); err != nil {
+		return nil, err
+	}
+	if opt.rangeTombstones, err = s.schemaRepo.openRangeTombstones(s.lfs, group, location); err != nil {
+		return nil, err
+	}
 	opts := storage.TSDBOpts[*tsTable, option]{
 		ShardNum:                       shardNum,
-		Location:                       path.Join(s.path, group),
+		Location:                       location,
 		TSTableCreator:                 newTSTable,
 		TableMetrics:                   metrics,
 		SegmentInterval:                storage.MustToIntervalRule(segInterval),
 		TTL:                            storage.MustToIntervalRule(ttl),
-		Option:                         s.option,
+		Option:                         opt,
 		SeriesIndexFlushTimeoutSeconds: s.option.flushTimeout.Nanoseconds() / int64(time.Second),
 		SeriesIndexCacheMaxBytes:       int(s.option.seriesCacheMaxSize),
 		StorageMetricsFactory:          factory,
 		SegmentIdleTimeout:             segmentIdleTimeout,
+		SegmentWarmup:                  s.segmentWarmup,
 		MemoryLimit:                    s.pm.GetLimit(),
+		FDProtector:                    s.fdp,
+		IOScheduler:                    s.iop,
+		ReplayCache:                    s.replayCache,
 	}
 	return storage.OpenTSDB(
 		common.SetPosition(context.Background(), func(_ common.Position) common.Position {
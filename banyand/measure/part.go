@@ -21,8 +21,10 @@ import (
 	"fmt"
 	"path"
 	"path/filepath"
+	"slices"
 	"sort"
 	"sync/atomic"
+	"time"
 
 	"github.com/apache/skywalking-banyandb/api/common"
 	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
@@ -47,6 +49,7 @@ type part struct {
 	timestamps           fs.Reader
 	fieldValues          fs.Reader
 	fileSystem           fs.FileSystem
+	pack                 *storage.Pack
 	tagFamilyMetadata    map[string]fs.Reader
 	tagFamilies          map[string]fs.Reader
 	cache                storage.Cache
@@ -65,6 +68,9 @@ func (p *part) close() {
 	for _, tfh := range p.tagFamilyMetadata {
 		fs.MustClose(tfh)
 	}
+	if p.pack != nil {
+		fs.MustClose(p.pack)
+	}
 }
 
 func (p *part) String() string {
@@ -179,6 +185,7 @@ func (mp *memPart) mustInitFromDataPoints(dps *dataPoints) {
 	}
 	bsw.MustWriteDataPoints(sidPrev, dps.timestamps[indexPrev:], dps.versions[indexPrev:], dps.tagFamilies[indexPrev:], dps.fields[indexPrev:])
 	bsw.Flush(&mp.partMetadata)
+	mp.partMetadata.IngestedAt = time.Now().UnixNano()
 	releaseBlockWriter(bsw)
 }
 
@@ -201,6 +208,29 @@ func (mp *memPart) mustFlush(fileSystem fs.FileSystem, path string) {
 	fileSystem.SyncPath(path)
 }
 
+// mustFlushPacked writes the files of the part into a container file, except the metadata.
+func (mp *memPart) mustFlushPacked(fileSystem fs.FileSystem, path string) {
+	fileSystem.MkdirPanicIfExist(path, storage.DirPerm)
+
+	entries := []storage.PackEntry{
+		{Name: metaFilename, Data: mp.meta.Buf},
+		{Name: primaryFilename, Data: mp.primary.Buf},
+		{Name: timestampsFilename, Data: mp.timestamps.Buf},
+		{Name: fieldValuesFilename, Data: mp.fieldValues.Buf},
+	}
+	for name, tf := range mp.tagFamilies {
+		entries = append(entries, storage.PackEntry{Name: name + tagFamiliesFilenameExt, Data: tf.Buf})
+	}
+	for name, tfh := range mp.tagFamilyMetadata {
+		entries = append(entries, storage.PackEntry{Name: name + tagFamiliesMetadataFilenameExt, Data: tfh.Buf})
+	}
+	storage.MustWritePack(fileSystem, path, entries)
+
+	mp.partMetadata.mustWriteMetadata(fileSystem, path)
+
+	fileSystem.SyncPath(path)
+}
+
 func uncompressedDataPointSizeBytes(index int, dps *dataPoints) uint64 {
 	// 8 bytes for timestamp
 	// 8 bytes for version
@@ -286,30 +316,40 @@ func mustOpenFilePart(id uint64, root string, fileSystem fs.FileSystem) *part {
 	p.partMetadata.mustReadMetadata(fileSystem, partPath)
 	p.partMetadata.ID = id
 
-	metaPath := path.Join(partPath, metaFilename)
-	pr := mustOpenReader(metaPath, fileSystem)
+	var names []string
+	for _, e := range fileSystem.ReadDir(partPath) {
+		if !e.IsDir() {
+			names = append(names, e.Name())
+		}
+	}
+	open := func(name string) fs.Reader {
+		return mustOpenReader(path.Join(partPath, name), fileSystem)
+	}
+	if slices.Contains(names, storage.PackFilename) {
+		p.pack = mustOpenPack(fileSystem, partPath)
+		names = p.pack.Names()
+		open = p.pack.MustReader
+	}
+
+	pr := open(metaFilename)
 	p.primaryBlockMetadata = mustReadPrimaryBlockMetadata(p.primaryBlockMetadata[:0], pr)
 	fs.MustClose(pr)
 
-	p.primary = mustOpenReader(path.Join(partPath, primaryFilename), fileSystem)
-	p.timestamps = mustOpenReader(path.Join(partPath, timestampsFilename), fileSystem)
-	p.fieldValues = mustOpenReader(path.Join(partPath, fieldValuesFilename), fileSystem)
-	ee := fileSystem.ReadDir(partPath)
-	for _, e := range ee {
-		if e.IsDir() {
-			continue
-		}
-		if filepath.Ext(e.Name()) == tagFamiliesMetadataFilenameExt {
+	p.primary = open(primaryFilename)
+	p.timestamps = open(timestampsFilename)
+	p.fieldValues = open(fieldValuesFilename)
+	for _, name := range names {
+		if filepath.Ext(name) == tagFamiliesMetadataFilenameExt {
 			if p.tagFamilyMetadata == nil {
 				p.tagFamilyMetadata = make(map[string]fs.Reader)
 			}
-			p.tagFamilyMetadata[removeExt(e.Name(), tagFamiliesMetadataFilenameExt)] = mustOpenReader(path.Join(partPath, e.Name()), fileSystem)
+			p.tagFamilyMetadata[removeExt(name, tagFamiliesMetadataFilenameExt)] = open(name)
 		}
-		if filepath.Ext(e.Name()) == tagFamiliesFilenameExt {
+		if filepath.Ext(name) == tagFamiliesFilenameExt {
 			if p.tagFamilies == nil {
 				p.tagFamilies = make(map[string]fs.Reader)
 			}
-			p.tagFamilies[removeExt(e.Name(), tagFamiliesFilenameExt)] = mustOpenReader(path.Join(partPath, e.Name()), fileSystem)
+			p.tagFamilies[removeExt(name, tagFamiliesFilenameExt)] = open(name)
 		}
 	}
 	return &p
@@ -323,6 +363,14 @@ func mustOpenReader(name string, fileSystem fs.FileSystem) fs.Reader {
 	return f
 }
 
+func mustOpenPack(fileSystem fs.FileSystem, partPath string) *storage.Pack {
+	p, err := storage.OpenPack(fileSystem, partPath)
+	if err != nil {
+		logger.Panicf("cannot open the pack of %q: %s", partPath, err)
+	}
+	return p
+}
+
 func removeExt(nameWithExt, ext string) string {
 	return nameWithExt[:len(nameWithExt)-len(ext)]
 }
@@ -29,12 +29,14 @@ import (
 	"github.com/apache/skywalking-banyandb/pkg/compress/zstd"
 	"github.com/apache/skywalking-banyandb/pkg/encoding"
 	"github.com/apache/skywalking-banyandb/pkg/fs"
+	"github.com/apache/skywalking-banyandb/pkg/index"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
 	"github.com/apache/skywalking-banyandb/pkg/pool"
 )
 
 type partIter struct {
 	err                  error
+	blockFilter          index.Filter
 	p                    *part
 	c                    storage.Cache
 	curBlock             *blockMetadata
@@ -50,6 +52,7 @@ type partIter struct {
 
 func (pi *partIter) reset() {
 	pi.curBlock = nil
+	pi.blockFilter = nil
 	pi.p = nil
 	pi.c = nil
 	pi.sids = nil
@@ -61,10 +64,11 @@ func (pi *partIter) reset() {
 	pi.err = nil
 }
 
-func (pi *partIter) init(bma *blockMetadataArray, p *part, sids []common.SeriesID, minTimestamp, maxTimestamp int64) {
+func (pi *partIter) init(bma *blockMetadataArray, p *part, sids []common.SeriesID, minTimestamp, maxTimestamp int64, blockFilter index.Filter) {
 	pi.reset()
 	pi.curBlock = &blockMetadata{}
 	pi.p = p
+	pi.blockFilter = blockFilter
 	pi.c = p.cache
 
 	pi.bms = bma.arr
@@ -201,7 +205,7 @@ func (pi *partIter) readPrimaryBlock(bms []blockMetadata, mr *primaryBlockMetada
 	if err != nil {
 		return nil, fmt.Errorf("cannot decompress index block: %w", err)
 	}
-	bms, err = unmarshalBlockMetadata(bms, pi.primaryBuf)
+	bms, err = unmarshalBlockMetadata(bms, pi.primaryBuf, pi.p.partMetadata.formatVersion())
 	if err != nil {
 		return nil, fmt.Errorf("cannot unmarshal index block: %w", err)
 	}
@@ -246,6 +250,23 @@ func (pi *partIter) findBlock() bool {
 			continue
 		}
 
+		if pi.blockFilter != nil {
+			shouldSkip, err := func() (bool, error) {
+				bs := generateBlockStats()
+				defer releaseBlockStats(bs)
+				bs.init(pi.p, bm)
+				return pi.blockFilter.ShouldSkip(bs)
+			}()
+			if err != nil {
+				pi.err = err
+				return false
+			}
+			if shouldSkip {
+				bhs = bhs[1:]
+				continue
+			}
+		}
+
 		pi.curBlock = bm
 
 		pi.bms = bhs[1:]
@@ -262,8 +283,10 @@ type partMergeIter struct {
 	compressedPrimaryBuf []byte
 	primaryBuf           []byte
 	block                blockPointer
+	ingestedAt           int64
 	partID               uint64
 	primaryMetadataIdx   int
+	formatVersion        uint32
 }
 
 func (pmi *partMergeIter) reset() {
@@ -271,7 +294,9 @@ func (pmi *partMergeIter) reset() {
 	pmi.seqReaders.reset()
 	pmi.primaryBlockMetadata = nil
 	pmi.primaryMetadataIdx = 0
+	pmi.ingestedAt = 0
 	pmi.partID = 0
+	pmi.formatVersion = 0
 	pmi.primaryBuf = pmi.primaryBuf[:0]
 	pmi.compressedPrimaryBuf = pmi.compressedPrimaryBuf[:0]
 	pmi.block.reset()
@@ -281,7 +306,9 @@ func (pmi *partMergeIter) mustInitFromPart(p *part) {
 	pmi.reset()
 	pmi.seqReaders.init(p)
 	pmi.primaryBlockMetadata = p.primaryBlockMetadata
+	pmi.ingestedAt = p.partMetadata.IngestedAt
 	pmi.partID = p.partMetadata.ID
+	pmi.formatVersion = p.partMetadata.formatVersion()
 }
 
 func (pmi *partMergeIter) error() error {
@@ -328,7 +355,7 @@ func (pmi *partMergeIter) loadPrimaryBuf() error {
 func (pmi *partMergeIter) loadBlockMetadata() error {
 	pmi.block.reset()
 	var err error
-	pmi.primaryBuf, err = pmi.block.bm.unmarshal(pmi.primaryBuf)
+	pmi.primaryBuf, err = pmi.block.bm.unmarshal(pmi.primaryBuf, pmi.formatVersion)
 	if err != nil {
 		pm := pmi.primaryBlockMetadata[pmi.primaryMetadataIdx-1]
 		return fmt.Errorf("can't read block metadata from primary at %d: %w", pm.offset, err)
@@ -337,7 +364,7 @@ func (pmi *partMergeIter) loadBlockMetadata() error {
 }
 
 func (pmi *partMergeIter) mustLoadBlockData(decoder *encoding.BytesBlockDecoder, block *blockPointer) {
-	block.block.mustSeqReadFrom(decoder, &pmi.seqReaders, pmi.block.bm)
+	block.block.mustSeqReadFrom(decoder, &pmi.seqReaders, pmi.block.bm, pmi.formatVersion)
 }
 
 func generatePartMergeIter() *partMergeIter {
@@ -31,11 +31,13 @@ import (
 	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
 	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
 	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	"github.com/apache/skywalking-banyandb/banyand/protector"
 	"github.com/apache/skywalking-banyandb/pkg/convert"
 	"github.com/apache/skywalking-banyandb/pkg/index"
 	"github.com/apache/skywalking-banyandb/pkg/index/posting/roaring"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
 	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query"
 	"github.com/apache/skywalking-banyandb/pkg/query/model"
 	resourceSchema "github.com/apache/skywalking-banyandb/pkg/schema"
 	"github.com/apache/skywalking-banyandb/pkg/timestamp"
@@ -53,6 +55,7 @@ type Query interface {
 	LoadGroup(name string) (resourceSchema.Group, bool)
 	Measure(measure *commonv1.Metadata) (Measure, error)
 	GetRemovalSegmentsTimeRange(group string) *timestamp.TimeRange
+	GetSegmentsTimeRanges(group string) []timestamp.TimeRange
 }
 
 // Measure allows inspecting measure data points' details.
@@ -65,6 +68,8 @@ type Measure interface {
 var _ Measure = (*measure)(nil)
 
 type queryOptions struct {
+	tombstones      *tombstones
+	rangeTombstones *storage.RangeTombstones
 	model.MeasureQueryOptions
 	minTimestamp int64
 	maxTimestamp int64
@@ -117,6 +122,12 @@ func (m *measure) Query(ctx context.Context, mqo model.MeasureQueryOptions) (mqr
 	if err != nil {
 		return nil, err
 	}
+	if sids, err = query.SampleSeries(ctx, sids, storedIndexValue); err != nil {
+		for i := range segments {
+			segments[i].DecRef()
+		}
+		return nil, err
+	}
 	if len(sids) < 1 {
 		for i := range segments {
 			segments[i].DecRef()
@@ -140,6 +151,8 @@ func (m *measure) Query(ctx context.Context, mqo model.MeasureQueryOptions) (mqr
 		MeasureQueryOptions: mqo,
 		minTimestamp:        mqo.TimeRange.Start.UnixNano(),
 		maxTimestamp:        mqo.TimeRange.End.UnixNano(),
+		tombstones:          m.schemaRepo.loadTombstones(m.group),
+		rangeTombstones:     m.schemaRepo.loadRangeTombstones(m.group),
 	}
 	var n int
 	for i := range tables {
@@ -175,6 +188,13 @@ func (m *measure) Query(ctx context.Context, mqo model.MeasureQueryOptions) (mqr
 			result.orderByTS = false
 		}
 	}
+	if mqo.Latest {
+		// the data points are merged by series to pick the latest one of every series,
+		// and then sorted by the original order.
+		result.latest = true
+		result.latestOrderByTS = result.orderByTS
+		result.orderByTS = false
+	}
 
 	return &result, nil
 }
@@ -193,13 +213,13 @@ func (m *measure) searchSeriesList(ctx context.Context, series []*pbv1.Series, m
 	fieldToValueType := make(map[string]tagNameWithType)
 	var projectedEntityOffsets map[string]int
 	newTagProjection = make([]model.TagProjection, 0)
-	is := m.indexSchema.Load().(indexSchema)
+	is := m.loadIndexSchema()
 	for _, tp := range mqo.TagProjection {
 		var tagProjection model.TagProjection
 	TAG:
 		for _, n := range tp.Names {
-			for i := range m.schema.GetEntity().GetTagNames() {
-				if n == m.schema.GetEntity().GetTagNames()[i] {
+			for i := range is.schema.GetEntity().GetTagNames() {
+				if n == is.schema.GetEntity().GetTagNames()[i] {
 					if projectedEntityOffsets == nil {
 						projectedEntityOffsets = make(map[string]int)
 					}
@@ -287,7 +307,7 @@ func (m *measure) buildIndexQueryResult(ctx context.Context, mqo model.MeasureQu
 			segments[i].DecRef()
 		}
 	}()
-	is := m.indexSchema.Load().(indexSchema)
+	is := m.loadIndexSchema()
 	r := &indexSortResult{}
 	var indexProjection []index.FieldKey
 	for _, tp := range mqo.TagProjection {
@@ -299,8 +319,8 @@ func (m *measure) buildIndexQueryResult(ctx context.Context, mqo model.MeasureQu
 	TAG:
 		for _, n := range tp.Names {
 			tagFamilyLocation.tagNames = append(tagFamilyLocation.tagNames, n)
-			for i := range m.schema.GetEntity().GetTagNames() {
-				if n == m.schema.GetEntity().GetTagNames()[i] {
+			for i := range is.schema.GetEntity().GetTagNames() {
+				if n == is.schema.GetEntity().GetTagNames()[i] {
 					tagFamilyLocation.projectedEntityOffsets[n] = i
 					continue TAG
 				}
@@ -370,7 +390,7 @@ func (m *measure) searchBlocks(ctx context.Context, result *queryResult, sids []
 	originalSids := make([]common.SeriesID, len(sids))
 	copy(originalSids, sids)
 	sort.Slice(sids, func(i, j int) bool { return sids[i] < sids[j] })
-	tstIter.init(bma, parts, sids, qo.minTimestamp, qo.maxTimestamp)
+	tstIter.init(bma, parts, sids, qo.minTimestamp, qo.maxTimestamp, qo.SkippingFilter)
 	if tstIter.Error() != nil {
 		return fmt.Errorf("cannot init tstIter: %w", tstIter.Error())
 	}
@@ -389,7 +409,16 @@ func (m *measure) searchBlocks(ctx context.Context, result *queryResult, sids []
 		bc := generateBlockCursor()
 		p := tstIter.piHeap[0]
 		bc.init(p.p, p.curBlock, qo)
+		if bc.minTimestamp > bc.bm.timestamps.max {
+			// all the data points of the block are deleted
+			releaseBlockCursor(bc)
+			continue
+		}
 		result.data = append(result.data, bc)
+		if qo.Latest {
+			// the quota is checked after the blocks are pruned
+			continue
+		}
 		totalBlockBytes += bc.bm.uncompressedSizeBytes
 		if quota >= 0 && totalBlockBytes > uint64(quota) {
 			return fmt.Errorf("block scan quota exceeded: used %d bytes, quota is %d bytes", totalBlockBytes, quota)
@@ -398,9 +427,21 @@ func (m *measure) searchBlocks(ctx context.Context, result *queryResult, sids []
 	if tstIter.Error() != nil {
 		return fmt.Errorf("cannot iterate tstIter: %w", tstIter.Error())
 	}
+	if qo.Latest {
+		result.data = pruneLatestBlocks(result.data)
+		for _, bc := range result.data {
+			totalBlockBytes += bc.bm.uncompressedSizeBytes
+		}
+		if quota >= 0 && totalBlockBytes > uint64(quota) {
+			return fmt.Errorf("block scan quota exceeded: used %d bytes, quota is %d bytes", totalBlockBytes, quota)
+		}
+	}
 	if err := m.pm.AcquireResource(ctx, totalBlockBytes); err != nil {
 		return err
 	}
+	if err := protector.Account(ctx, totalBlockBytes); err != nil {
+		return err
+	}
 	result.sidToIndex = make(map[common.SeriesID]int)
 	for i, si := range originalSids {
 		result.sidToIndex[si] = i
@@ -567,10 +608,13 @@ type queryResult struct {
 	data             []*blockCursor
 	snapshots        []*snapshot
 	segments         []storage.Segment[*tsTable, option]
+	latestResults    []*model.MeasureResult
 	hit              int
 	loaded           bool
 	orderByTS        bool
 	ascTS            bool
+	latest           bool
+	latestOrderByTS  bool
 }
 
 func (qr *queryResult) Pull() *model.MeasureResult {
@@ -631,6 +675,9 @@ func (qr *queryResult) Pull() *model.MeasureResult {
 		qr.loaded = true
 		heap.Init(qr)
 	}
+	if qr.latest {
+		return qr.pullLatest()
+	}
 	if len(qr.data) == 0 {
 		return nil
 	}
@@ -1253,7 +1253,7 @@ func TestQueryResult(t *testing.T) {
 					return sids[i] < tt.sids[j]
 				})
 				ti := &tstIter{}
-				ti.init(bma, pp, sids, tt.minTimestamp, tt.maxTimestamp)
+				ti.init(bma, pp, sids, tt.minTimestamp, tt.maxTimestamp, nil)
 
 				var result queryResult
 				result.ctx = context.TODO()
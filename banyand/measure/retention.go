@@ -0,0 +1,40 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package measure
+
+import (
+	"context"
+	"time"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+)
+
+type retentionListener struct {
+	*bus.UnImplementedHealthyListener
+	s       *service
+	trigger bool
+}
+
+// Rev previews the retention of the measure groups, or runs it if the listener is the trigger.
+func (r *retentionListener) Rev(_ context.Context, message bus.Message) bus.Message {
+	groups, _ := message.Data().([]string)
+	result := storage.Retention[*tsTable, option](commonv1.Catalog_CATALOG_MEASURE, r.s.schemaRepo, groups, r.trigger, time.Now())
+	return bus.NewMessage(bus.MessageID(time.Now().UnixNano()), result)
+}
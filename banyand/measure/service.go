@@ -22,6 +22,7 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -57,6 +58,7 @@ type Service interface {
 	run.Config
 	run.Service
 	Query
+	observability.BundleContributor
 	TopNService
 }
 
@@ -71,17 +73,27 @@ type service struct {
 	omr                 observability.MetricsRegistry
 	metadata            metadata.Repo
 	pm                  protector.Memory
+	fdp                 protector.FD
+	iop                 protector.IO
 	schemaRepo          *schemaRepo
 	l                   *logger.Logger
 	c                   storage.Cache
 	cm                  *cacheMetrics
+	replayCache         *storage.ReplayCache
 	root                string
 	snapshotDir         string
+	replicaDir          string
 	dataPath            string
 	option              option
+	sizeTiered          *sizeTieredPolicy
 	cc                  storage.CacheConfig
 	maxDiskUsagePercent int
 	maxFileSnapshotNum  int
+	replayCacheSize     int
+	replayCacheTTL      time.Duration
+	replicaMu           sync.Mutex
+	validateRouting     bool
+	segmentWarmup       bool
 }
 
 func (s *service) Measure(metadata *commonv1.Metadata) (Measure, error) {
@@ -100,17 +112,35 @@ func (s *service) GetRemovalSegmentsTimeRange(group string) *timestamp.TimeRange
 	return s.schemaRepo.GetRemovalSegmentsTimeRange(group)
 }
 
+func (s *service) GetSegmentsTimeRanges(group string) []timestamp.TimeRange {
+	return s.schemaRepo.GetSegmentsTimeRanges(group)
+}
+
 func (s *service) FlagSet() *run.FlagSet {
 	flagS := run.NewFlagSet("storage")
 	flagS.StringVar(&s.root, "measure-root-path", "/tmp", "the root path of measure")
 	flagS.StringVar(&s.dataPath, "measure-data-path", "", "the data directory path of measure. If not set, <measure-root-path>/measure/data will be used")
 	flagS.DurationVar(&s.option.flushTimeout, "measure-flush-timeout", defaultFlushTimeout, "the memory data timeout of measure")
-	s.option.mergePolicy = newDefaultMergePolicy()
-	flagS.VarP(&s.option.mergePolicy.maxFanOutSize, "measure-max-fan-out-size", "", "the upper bound of a single file size after merge of measure")
+	s.sizeTiered = newDefaultMergePolicy()
+	s.option.mergePolicy = s.sizeTiered
+	flagS.VarP(&s.sizeTiered.maxFanOutSize, "measure-max-fan-out-size", "", "the upper bound of a single file size after merge of measure")
+	flagS.IntVar(&s.sizeTiered.maxParts, "measure-merge-fan-in", defaultMergeFanIn(),
+		"the max number of the parts consumed by a single merge of measure, which defaults to the number of the CPUs between 8 and 16")
+	flagS.IntVar(&s.option.flushWorkers, "measure-flush-workers", defaultFlushWorkers(),
+		"the number of the goroutines flushing the in-memory parts of a shard of measure, which defaults to a quarter of the CPUs between 1 and 8")
 	s.option.seriesCacheMaxSize = run.Bytes(32 << 20)
 	flagS.VarP(&s.option.seriesCacheMaxSize, "measure-series-cache-max-size", "", "the max size of series cache in each group")
+	flagS.VarP(&s.option.packPartMaxSize, "measure-pack-part-max-size", "",
+		"the max compressed size of the flushed parts which are packed into a single file to save the inodes and the file descriptors, 0 disables the packing")
+	flagS.BoolVar(&s.segmentWarmup, "measure-segment-warmup", true,
+		"pre-read the index term dictionaries and the block metadata of the segments opened from the disk in the background")
 	flagS.IntVar(&s.maxDiskUsagePercent, "measure-max-disk-usage-percent", 95, "the maximum disk usage percentage allowed")
 	flagS.IntVar(&s.maxFileSnapshotNum, "measure-max-file-snapshot-num", 10, "the maximum number of file snapshots allowed")
+	flagS.IntVar(&s.replayCacheSize, "measure-idempotency-cache-size", 10000,
+		"the maximum number of the idempotency keys remembered in each shard, 0 disables the duplicate write detection")
+	flagS.DurationVar(&s.replayCacheTTL, "measure-idempotency-cache-ttl", 5*time.Minute, "the period in which the writes with the same idempotency key are dropped")
+	flagS.BoolVar(&s.validateRouting, "measure-validate-routing", false,
+		"validate the shards of the writes, which is required if the clients write to the data nodes directly by the routing hints")
 	s.cc.MaxCacheSize = run.Bytes(100 * 1024 * 1024)
 	flagS.VarP(&s.cc.MaxCacheSize, "service-cache-max-size", "", "maximum service cache size (e.g., 100M)")
 	flagS.DurationVar(&s.cc.CleanupInterval, "service-cache-cleanup-interval", 30*time.Second, "service cache cleanup interval")
@@ -128,6 +158,12 @@ func (s *service) Validate() error {
 	if s.maxDiskUsagePercent > 100 {
 		return errors.New("measure-max-disk-usage-percen must be less than or equal to 100")
 	}
+	if s.sizeTiered.maxParts < 2 {
+		return errors.New("measure-merge-fan-in must be greater than or equal to 2")
+	}
+	if s.option.flushWorkers < 1 {
+		return errors.New("measure-flush-workers must be greater than 0")
+	}
 	if s.cc.MaxCacheSize < 0 {
 		return errors.New("service-cache-max-size must be greater than or equal to 0")
 	}
@@ -154,6 +190,7 @@ func (s *service) PreRun(ctx context.Context) error {
 	s.lfs = fs.NewLocalFileSystemWithLoggerAndLimit(s.l, s.pm.GetLimit())
 	path := path.Join(s.root, s.Name())
 	s.snapshotDir = filepath.Join(path, storage.SnapshotsDir)
+	s.replicaDir = filepath.Join(path, storage.ReplicasDir)
 	observability.UpdatePath(path)
 	if s.dataPath == "" {
 		s.dataPath = filepath.Join(path, storage.DataDir)
@@ -167,6 +204,7 @@ func (s *service) PreRun(ctx context.Context) error {
 		return errors.New("node id is empty")
 	}
 	s.c = storage.NewServiceCacheWithConfig(s.cc)
+	s.replayCache = storage.NewReplayCache(s.replayCacheSize, s.replayCacheTTL)
 	node := val.(common.Node)
 	s.schemaRepo = newSchemaRepo(s.dataPath, s, node.Labels)
 
@@ -184,12 +222,46 @@ func (s *service) PreRun(ctx context.Context) error {
 	if err := s.pipeline.Subscribe(data.TopicSnapshot, &snapshotListener{s: s}); err != nil {
 		return err
 	}
+	if err := s.pipeline.Subscribe(data.TopicSnapshotDir, &snapshotDirListener{s: s}); err != nil {
+		return err
+	}
+	if err := s.pipeline.Subscribe(data.TopicWarmup, &warmupListener{s: s}); err != nil {
+		return err
+	}
+	if err := s.pipeline.Subscribe(data.TopicRetentionPreview, &retentionListener{s: s}); err != nil {
+		return err
+	}
+	if err := s.pipeline.Subscribe(data.TopicRetentionTrigger, &retentionListener{s: s, trigger: true}); err != nil {
+		return err
+	}
+	if err := s.pipeline.Subscribe(data.TopicStorageUsage, &usageListener{s: s}); err != nil {
+		return err
+	}
+	if err := s.pipeline.Subscribe(data.TopicShardDigest, &digestListener{s: s}); err != nil {
+		return err
+	}
+	if err := s.pipeline.Subscribe(data.TopicMeasureSeriesLookup, &seriesLookupListener{s: s}); err != nil {
+		return err
+	}
+	if err := s.pipeline.Subscribe(data.TopicMeasureDeleteSeries, &deleteSeriesListener{s: s}); err != nil {
+		return err
+	}
+	if err := s.pipeline.Subscribe(data.TopicMeasureDeleteRange, &deleteRangeListener{s: s}); err != nil {
+		return err
+	}
+
+	replica := &replicaListener{s: s}
+	for _, topic := range []bus.Topic{data.TopicReplicaDir, data.TopicReplicaStatus, data.TopicReplicaSeries, data.TopicReplicaCommit} {
+		if err := s.pipeline.Subscribe(topic, replica); err != nil {
+			return err
+		}
+	}
 
 	if err := s.pipeline.Subscribe(data.TopicMeasureDeleteExpiredSegments, &deleteStreamSegmentsListener{s: s}); err != nil {
 		return err
 	}
 
-	s.writeListener = setUpWriteCallback(s.l, s.schemaRepo, s.maxDiskUsagePercent)
+	s.writeListener = setUpWriteCallback(s.l, s.schemaRepo, s.maxDiskUsagePercent, s.replayCache, s.validateRouting)
 	// only subscribe metricPipeline for data node
 	if s.metricPipeline != nil {
 		err := s.metricPipeline.Subscribe(data.TopicMeasureWrite, s.writeListener)
@@ -210,11 +282,17 @@ func (s *service) Serve() run.StopNotify {
 
 func (s *service) GracefulStop() {
 	observability.MetricsCollector.Unregister("measure_cache")
-	s.schemaRepo.Close()
-	s.c.Close()
+	// stop the local pipeline first to drain the in-flight writes before closing the tables.
 	if s.localPipeline != nil {
 		s.localPipeline.GracefulStop()
 	}
+	s.schemaRepo.Close()
+	s.c.Close()
+}
+
+// ShutdownPhase implements run.PhasedService.
+func (s *service) ShutdownPhase() run.ShutdownPhase {
+	return run.ShutdownPhaseStorage
 }
 
 func (s *service) InFlow(stm *databasev1.Measure, seriesID uint64, shardID uint32, entityValues []*modelv1.TagValue, dp *measurev1.DataPointValue) {
@@ -247,13 +325,17 @@ func (s *service) collectCacheMetrics() {
 }
 
 // NewService returns a new service.
-func NewService(metadata metadata.Repo, pipeline queue.Server, metricPipeline queue.Server, omr observability.MetricsRegistry, pm protector.Memory) (Service, error) {
+func NewService(metadata metadata.Repo, pipeline queue.Server, metricPipeline queue.Server, omr observability.MetricsRegistry,
+	pm protector.Memory, fdp protector.FD, iop protector.IO,
+) (Service, error) {
 	return &service{
 		metadata:       metadata,
 		pipeline:       pipeline,
 		metricPipeline: metricPipeline,
 		omr:            omr,
 		pm:             pm,
+		fdp:            fdp,
+		iop:            iop,
 	}, nil
 }
 
@@ -282,6 +364,10 @@ func (d *deleteStreamSegmentsListener) Rev(_ context.Context, message bus.Messag
 		d.s.l.Error().Err(err).Str("group", req.Group).Msg("failed to load tsdb")
 		return bus.NewMessage(bus.MessageID(time.Now().UnixNano()), int64(0))
 	}
-	deleted := db.DeleteExpiredSegments(timestamp.NewSectionTimeRange(req.TimeRange.Begin.AsTime(), req.TimeRange.End.AsTime()))
+	timeRange := timestamp.NewSectionTimeRange(req.TimeRange.Begin.AsTime(), req.TimeRange.End.AsTime())
+	if req.Archived {
+		return bus.NewMessage(bus.MessageID(time.Now().UnixNano()), db.DeleteSegments(timeRange))
+	}
+	deleted := db.DeleteExpiredSegments(timeRange)
 	return bus.NewMessage(bus.MessageID(time.Now().UnixNano()), deleted)
 }
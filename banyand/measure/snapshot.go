@@ -273,3 +273,17 @@ func (s *snapshotListener) snapshotName() string {
 	s.snapshotSeq++
 	return fmt.Sprintf("%s-%08X", time.Now().UTC().Format("20060102150405"), s.snapshotSeq)
 }
+
+type snapshotDirListener struct {
+	*bus.UnImplementedHealthyListener
+	s *service
+}
+
+// Rev returns the directory of a snapshot taken by the snapshotListener.
+func (s *snapshotDirListener) Rev(_ context.Context, message bus.Message) bus.Message {
+	req := message.Data().(*databasev1.PullSnapshotRequest)
+	if req.GetCatalog() != commonv1.Catalog_CATALOG_MEASURE {
+		return bus.NewMessage(bus.MessageID(time.Now().UnixNano()), nil)
+	}
+	return bus.NewMessage(bus.MessageID(time.Now().UnixNano()), filepath.Join(s.s.snapshotDir, req.GetName()))
+}
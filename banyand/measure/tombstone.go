@@ -0,0 +1,230 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package measure
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+	"github.com/apache/skywalking-banyandb/pkg/fs"
+)
+
+const tombstonesFilename = "tombstones.json"
+
+// tombstones records the deletion time of the deleted series of a group.
+// The data points of a series whose timestamps are not after its deletion time
+// are dropped by the queries and the merges.
+type tombstones struct {
+	fileSystem fs.FileSystem
+	entries    atomic.Pointer[map[common.SeriesID]int64]
+	path       string
+	mu         sync.Mutex
+}
+
+func openTombstones(fileSystem fs.FileSystem, root string) (*tombstones, error) {
+	t := &tombstones{
+		fileSystem: fileSystem,
+		path:       filepath.Join(root, tombstonesFilename),
+	}
+	entries := make(map[common.SeriesID]int64)
+	data, err := fileSystem.Read(t.path)
+	if err != nil {
+		var fsErr *fs.FileSystemError
+		if !errors.As(err, &fsErr) || fsErr.Code != fs.IsNotExistError {
+			return nil, errors.WithMessagef(err, "cannot read %s", t.path)
+		}
+	} else if err = json.Unmarshal(data, &entries); err != nil {
+		return nil, errors.WithMessagef(err, "cannot parse %s", t.path)
+	}
+	t.entries.Store(&entries)
+	return t, nil
+}
+
+// deletedAt returns the deletion time of the series.
+func (t *tombstones) deletedAt(sid common.SeriesID) (int64, bool) {
+	if t == nil {
+		return 0, false
+	}
+	entries := t.entries.Load()
+	if entries == nil || len(*entries) == 0 {
+		return 0, false
+	}
+	ts, ok := (*entries)[sid]
+	return ts, ok
+}
+
+// add tombstones the series at deletedAt and persists the tombstones.
+// The tombstones older than since are pruned because the segments holding their data points have expired.
+func (t *tombstones) add(sids []common.SeriesID, deletedAt, since int64) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entries := make(map[common.SeriesID]int64, len(*t.entries.Load())+len(sids))
+	for sid, ts := range *t.entries.Load() {
+		if ts >= since {
+			entries[sid] = ts
+		}
+	}
+	for _, sid := range sids {
+		if ts, ok := entries[sid]; !ok || ts < deletedAt {
+			entries[sid] = deletedAt
+		}
+	}
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return errors.WithMessage(err, "cannot marshal the tombstones")
+	}
+	tmpPath := t.path + ".tmp"
+	if _, err = t.fileSystem.Write(data, tmpPath, storage.FilePerm); err != nil {
+		return errors.WithMessagef(err, "cannot write %s", tmpPath)
+	}
+	if err = t.fileSystem.Rename(tmpPath, t.path); err != nil {
+		return errors.WithMessagef(err, "cannot rename %s", tmpPath)
+	}
+	t.entries.Store(&entries)
+	return nil
+}
+
+func (sr *schemaRepo) loadTombstones(group string) *tombstones {
+	if sr == nil {
+		return nil
+	}
+	if t, ok := sr.tombstones.Load(group); ok {
+		return t.(*tombstones)
+	}
+	return nil
+}
+
+func (sr *schemaRepo) openTombstones(fileSystem fs.FileSystem, group, root string) (*tombstones, error) {
+	if t := sr.loadTombstones(group); t != nil {
+		return t, nil
+	}
+	t, err := openTombstones(fileSystem, root)
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := sr.tombstones.LoadOrStore(group, t)
+	return actual.(*tombstones), nil
+}
+
+type deleteSeriesListener struct {
+	*bus.UnImplementedHealthyListener
+	s *service
+}
+
+// Rev tombstones the series of a group hosted by this node.
+func (l *deleteSeriesListener) Rev(_ context.Context, message bus.Message) bus.Message {
+	now := time.Now().UnixNano()
+	req, ok := message.Data().(*measurev1.InternalDeleteSeriesRequest)
+	if !ok {
+		return bus.NewMessage(bus.MessageID(now), common.NewError("invalid event data type %T", message.Data()))
+	}
+	resp := &measurev1.DeleteSeriesResponse{DeletedAt: req.GetDeletedAt()}
+	if _, err := l.s.schemaRepo.loadTSDB(req.GetGroup()); err != nil {
+		// the group is not hosted by this node
+		return bus.NewMessage(bus.MessageID(now), resp)
+	}
+	t := l.s.schemaRepo.loadTombstones(req.GetGroup())
+	if t == nil {
+		return bus.NewMessage(bus.MessageID(now), common.NewError("the tombstones of group %s are not open", req.GetGroup()))
+	}
+	sids := make([]common.SeriesID, len(req.GetSeriesIds()))
+	for i, id := range req.GetSeriesIds() {
+		sids[i] = common.SeriesID(id)
+	}
+	since := now
+	for _, tr := range l.s.schemaRepo.GetSegmentsTimeRanges(req.GetGroup()) {
+		if start := tr.Start.UnixNano(); start < since {
+			since = start
+		}
+	}
+	if err := t.add(sids, req.GetDeletedAt().AsTime().UnixNano(), since); err != nil {
+		return bus.NewMessage(bus.MessageID(now), common.NewError("failed to delete the series of %s: %v", req.GetGroup(), err))
+	}
+	l.s.l.Info().Str("group", req.GetGroup()).Int("series", len(sids)).Msg("tombstoned the series")
+	return bus.NewMessage(bus.MessageID(now), resp)
+}
+
+func (sr *schemaRepo) loadRangeTombstones(group string) *storage.RangeTombstones {
+	if sr == nil {
+		return nil
+	}
+	if t, ok := sr.rangeTombstones.Load(group); ok {
+		return t.(*storage.RangeTombstones)
+	}
+	return nil
+}
+
+func (sr *schemaRepo) openRangeTombstones(fileSystem fs.FileSystem, group, root string) (*storage.RangeTombstones, error) {
+	if t := sr.loadRangeTombstones(group); t != nil {
+		return t, nil
+	}
+	t, err := storage.OpenRangeTombstones(fileSystem, root)
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := sr.rangeTombstones.LoadOrStore(group, t)
+	return actual.(*storage.RangeTombstones), nil
+}
+
+type deleteRangeListener struct {
+	*bus.UnImplementedHealthyListener
+	s *service
+}
+
+// Rev tombstones a time range of a group hosted by this node.
+func (l *deleteRangeListener) Rev(_ context.Context, message bus.Message) bus.Message {
+	now := time.Now().UnixNano()
+	req, ok := message.Data().(*databasev1.InternalDeleteRangeRequest)
+	if !ok {
+		return bus.NewMessage(bus.MessageID(now), common.NewError("invalid event data type %T", message.Data()))
+	}
+	resp := &databasev1.DeletionServiceDeleteRangeResponse{DeletedAt: req.GetDeletedAt()}
+	if _, err := l.s.schemaRepo.loadTSDB(req.GetGroup()); err != nil {
+		// the group is not hosted by this node
+		return bus.NewMessage(bus.MessageID(now), resp)
+	}
+	t := l.s.schemaRepo.loadRangeTombstones(req.GetGroup())
+	if t == nil {
+		return bus.NewMessage(bus.MessageID(now), common.NewError("the range tombstones of group %s are not open", req.GetGroup()))
+	}
+	since := now
+	for _, tr := range l.s.schemaRepo.GetSegmentsTimeRanges(req.GetGroup()) {
+		if start := tr.Start.UnixNano(); start < since {
+			since = start
+		}
+	}
+	tr := req.GetTimeRange()
+	// the range is stamped by the local clock, which also stamps the ingestion time of the parts,
+	// so that the data written after the deletion are kept whatever the clock skew to the liaison is.
+	if err := t.Add(tr.GetBegin().AsTime().UnixNano(), tr.GetEnd().AsTime().UnixNano(), now, since); err != nil {
+		return bus.NewMessage(bus.MessageID(now), common.NewError("failed to delete the range of %s: %v", req.GetGroup(), err))
+	}
+	l.s.l.Info().Str("group", req.GetGroup()).Time("begin", tr.GetBegin().AsTime()).Time("end", tr.GetEnd().AsTime()).Msg("tombstoned the range")
+	return bus.NewMessage(bus.MessageID(now), resp)
+}
@@ -0,0 +1,171 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package measure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	"github.com/apache/skywalking-banyandb/banyand/protector"
+	"github.com/apache/skywalking-banyandb/pkg/fs"
+	"github.com/apache/skywalking-banyandb/pkg/test"
+)
+
+func TestTombstones(t *testing.T) {
+	tmpPath, defFn := test.Space(require.New(t))
+	defer defFn()
+	fileSystem := fs.NewLocalFileSystem()
+
+	ts, err := openTombstones(fileSystem, tmpPath)
+	require.NoError(t, err)
+	_, ok := ts.deletedAt(1)
+	require.False(t, ok)
+
+	require.NoError(t, ts.add([]common.SeriesID{1, 2}, 100, 0))
+	require.NoError(t, ts.add([]common.SeriesID{2}, 50, 0))
+	deletedAt, ok := ts.deletedAt(2)
+	require.True(t, ok)
+	require.Equal(t, int64(100), deletedAt, "an earlier deletion doesn't move the tombstone back")
+
+	reopened, err := openTombstones(fileSystem, tmpPath)
+	require.NoError(t, err)
+	deletedAt, ok = reopened.deletedAt(1)
+	require.True(t, ok)
+	require.Equal(t, int64(100), deletedAt)
+
+	require.NoError(t, reopened.add([]common.SeriesID{3}, 300, 200))
+	_, ok = reopened.deletedAt(1)
+	require.False(t, ok, "the tombstones before the oldest segment are pruned")
+	deletedAt, ok = reopened.deletedAt(3)
+	require.True(t, ok)
+	require.Equal(t, int64(300), deletedAt)
+
+	var nilTombstones *tombstones
+	_, ok = nilTombstones.deletedAt(1)
+	require.False(t, ok)
+}
+
+func Test_mergePartsWithTombstones(t *testing.T) {
+	tmpPath, defFn := test.Space(require.New(t))
+	defer defFn()
+	fileSystem := fs.NewLocalFileSystem()
+	ts, err := openTombstones(fileSystem, tmpPath)
+	require.NoError(t, err)
+	// series 1 loses the data points at 1, series 2 loses all the data points
+	require.NoError(t, ts.add([]common.SeriesID{1}, 1, 0))
+	require.NoError(t, ts.add([]common.SeriesID{2}, 2, 0))
+
+	var pp []*partWrapper
+	defer func() {
+		for _, pw := range pp {
+			pw.decRef()
+		}
+	}()
+	for i, dps := range []*dataPoints{dpsTS1, dpsTS2} {
+		mp := generateMemPart()
+		mp.mustInitFromDataPoints(dps)
+		mp.mustFlush(fileSystem, partPath(tmpPath, uint64(i)))
+		pw := newPartWrapper(nil, mustOpenFilePart(uint64(i), tmpPath, fileSystem))
+		pw.p.partMetadata.ID = uint64(i)
+		pp = append(pp, pw)
+		releaseMemPart(mp)
+	}
+	closeCh := make(chan struct{})
+	defer close(closeCh)
+	tst := &tsTable{pm: protector.Nop{}, option: option{tombstones: ts}}
+	p, err := tst.mergeParts(fileSystem, closeCh, pp, 2, tmpPath)
+	require.NoError(t, err)
+	defer p.decRef()
+
+	pmi := &partMergeIter{}
+	pmi.mustInitFromPart(p.p)
+	reader := &blockReader{}
+	reader.init([]*partMergeIter{pmi})
+	got := make(map[common.SeriesID][]int64)
+	decoder := generateColumnValuesDecoder()
+	defer releaseColumnValuesDecoder(decoder)
+	for reader.nextBlockMetadata() {
+		reader.loadBlockData(decoder)
+		got[reader.block.bm.seriesID] = append(got[reader.block.bm.seriesID], reader.block.timestamps...)
+	}
+	require.NoError(t, reader.error())
+	require.Equal(t, map[common.SeriesID][]int64{
+		1: {2},
+		3: {1, 2},
+	}, got)
+}
+
+func Test_mergePartsWithRangeTombstones(t *testing.T) {
+	tmpPath, defFn := test.Space(require.New(t))
+	defer defFn()
+	fileSystem := fs.NewLocalFileSystem()
+	rt, err := storage.OpenRangeTombstones(fileSystem, tmpPath)
+	require.NoError(t, err)
+	// the data points at 2 of all the series ingested at or before 2 are deleted
+	require.NoError(t, rt.Add(2, 3, 2, 0))
+
+	var pp []*partWrapper
+	defer func() {
+		for _, pw := range pp {
+			pw.decRef()
+		}
+	}()
+	// the data points at 2 are ingested again after the deletion by the last part
+	for i, ingestedAt := range []int64{1, 2, 3} {
+		dps := dpsTS2
+		if i == 0 {
+			dps = dpsTS1
+		}
+		mp := generateMemPart()
+		mp.mustInitFromDataPoints(dps)
+		mp.partMetadata.IngestedAt = ingestedAt
+		mp.mustFlush(fileSystem, partPath(tmpPath, uint64(i)))
+		pw := newPartWrapper(nil, mustOpenFilePart(uint64(i), tmpPath, fileSystem))
+		pw.p.partMetadata.ID = uint64(i)
+		pp = append(pp, pw)
+		releaseMemPart(mp)
+	}
+	closeCh := make(chan struct{})
+	defer close(closeCh)
+	tst := &tsTable{pm: protector.Nop{}, option: option{rangeTombstones: rt}}
+	p, err := tst.mergeParts(fileSystem, closeCh, pp, 3, tmpPath)
+	require.NoError(t, err)
+	defer p.decRef()
+
+	pmi := &partMergeIter{}
+	pmi.mustInitFromPart(p.p)
+	reader := &blockReader{}
+	reader.init([]*partMergeIter{pmi})
+	got := make(map[common.SeriesID][]int64)
+	decoder := generateColumnValuesDecoder()
+	defer releaseColumnValuesDecoder(decoder)
+	for reader.nextBlockMetadata() {
+		reader.loadBlockData(decoder)
+		got[reader.block.bm.seriesID] = append(got[reader.block.bm.seriesID], reader.block.timestamps...)
+	}
+	require.NoError(t, reader.error())
+	require.Equal(t, map[common.SeriesID][]int64{
+		1: {1, 2},
+		2: {1, 2},
+		3: {1, 2},
+	}, got)
+	require.Equal(t, int64(3), p.p.partMetadata.IngestedAt)
+}
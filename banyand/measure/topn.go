@@ -149,6 +149,8 @@ type topNStreamingProcessor struct {
 	stopCh        chan struct{}
 	flow.ComponentState
 	interval      time.Duration
+	lateness      time.Duration
+	offset        time.Duration
 	sortDirection modelv1.Sort
 }
 
@@ -292,7 +294,9 @@ func (t *topNStreamingProcessor) writeStreamRecord(record flow.StreamRecord, buf
 }
 
 func (t *topNStreamingProcessor) downSampleTimeBucket(eventTimeMillis int64) time.Time {
-	return time.UnixMilli(eventTimeMillis - eventTimeMillis%t.interval.Milliseconds())
+	interval := t.interval.Milliseconds()
+	offset := t.offset.Milliseconds() % interval
+	return time.UnixMilli(eventTimeMillis - (eventTimeMillis-offset)%interval)
 }
 
 func (t *topNStreamingProcessor) start() *topNStreamingProcessor {
@@ -302,6 +306,8 @@ func (t *topNStreamingProcessor) start() *topNStreamingProcessor {
 	}
 	t.errCh = t.streamingFlow.Window(streaming.NewTumblingTimeWindows(t.interval, flushInterval)).
 		AllowedMaxWindows(int(t.topNSchema.GetLruSize())).
+		AllowedLateness(t.lateness).
+		WindowOffset(t.offset).
 		TopN(int(t.topNSchema.GetCountersNumber()),
 			streaming.WithKeyExtractor(func(record flow.StreamRecord) uint64 {
 				return record.Data().(flow.Data)[4].(uint64)
@@ -449,6 +455,14 @@ func (manager *topNProcessorManager) start(topNSchema *databasev1.TopNAggregatio
 	if err != nil {
 		return errors.Wrapf(err, "invalid interval %s for measure %s", manager.m.Interval, manager.m.GetMetadata().GetName())
 	}
+	lateness, err := parseOptionalDuration(topNSchema.GetAllowedLateness())
+	if err != nil {
+		return errors.Wrapf(err, "invalid allowed lateness %s for topN %s", topNSchema.GetAllowedLateness(), topNSchema.GetMetadata().GetName())
+	}
+	offset, err := parseOptionalDuration(topNSchema.GetWindowOffset())
+	if err != nil {
+		return errors.Wrapf(err, "invalid window offset %s for topN %s", topNSchema.GetWindowOffset(), topNSchema.GetMetadata().GetName())
+	}
 	sortDirections := make([]modelv1.Sort, 0, 2)
 	if topNSchema.GetFieldValueSort() == modelv1.Sort_SORT_UNSPECIFIED {
 		sortDirections = append(sortDirections, modelv1.Sort_SORT_ASC, modelv1.Sort_SORT_DESC)
@@ -469,7 +483,18 @@ func (manager *topNProcessorManager) start(topNSchema *databasev1.TopNAggregatio
 		}
 		streamingFlow = streamingFlow.Filter(filters)
 
-		mapper, innerErr := manager.buildMapper(topNSchema.GetFieldName(), topNSchema.GetGroupByTagNames()...)
+		fieldValue, innerErr := manager.buildFieldEvaluator(topNSchema)
+		if innerErr != nil {
+			return innerErr
+		}
+		if topNSchema.GetFieldExpression() != nil {
+			// drop the data points whose expression can't be computed
+			streamingFlow = streamingFlow.Filter(flow.UnaryFunc[bool](func(_ context.Context, request any) bool {
+				_, ok := fieldValue(request.(*dataPointWithEntityValues).GetFields())
+				return ok
+			}))
+		}
+		mapper, innerErr := manager.buildMapper(fieldValue, topNSchema.GetGroupByTagNames()...)
 		if innerErr != nil {
 			return innerErr
 		}
@@ -478,6 +503,8 @@ func (manager *topNProcessorManager) start(topNSchema *databasev1.TopNAggregatio
 			m:             manager.m,
 			l:             manager.l,
 			interval:      interval,
+			lateness:      lateness,
+			offset:        offset,
 			topNSchema:    topNSchema,
 			sortDirection: sortDirection,
 			src:           srcCh,
@@ -492,6 +519,13 @@ func (manager *topNProcessorManager) start(topNSchema *databasev1.TopNAggregatio
 	return nil
 }
 
+func parseOptionalDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return timestamp.ParseDuration(s)
+}
+
 func (manager *topNProcessorManager) removeProcessors(topNSchema *databasev1.TopNAggregation) []*topNStreamingProcessor {
 	var processors []*topNStreamingProcessor
 	var newList []*topNStreamingProcessor
@@ -530,30 +564,41 @@ func (manager *topNProcessorManager) buildFilter(criteria *modelv1.Criteria) (fl
 	}, nil
 }
 
-func (manager *topNProcessorManager) buildMapper(fieldName string, groupByNames ...string) (flow.UnaryFunc[any], error) {
+func (manager *topNProcessorManager) buildFieldEvaluator(topNSchema *databasev1.TopNAggregation) (fieldEvaluator, error) {
+	if expr := topNSchema.GetFieldExpression(); expr != nil {
+		return compileFieldExpression(manager.m, expr)
+	}
+	fieldName := topNSchema.GetFieldName()
 	fieldIdx := slices.IndexFunc(manager.m.GetFields(), func(spec *databasev1.FieldSpec) bool {
 		return spec.GetName() == fieldName
 	})
 	if fieldIdx == -1 {
 		return nil, fmt.Errorf("field %s is not found in %s schema", fieldName, manager.m.Metadata.GetName())
 	}
+	return func(fields []*modelv1.FieldValue) (int64, bool) {
+		if len(fields) <= fieldIdx {
+			manager.l.Warn().
+				Str("fieldName", fieldName).
+				Int("len", len(fields)).
+				Int("fieldIdx", fieldIdx).
+				Msg("out of range")
+		}
+		return fields[fieldIdx].GetInt().GetValue(), true
+	}, nil
+}
+
+func (manager *topNProcessorManager) buildMapper(fieldValue fieldEvaluator, groupByNames ...string) (flow.UnaryFunc[any], error) {
 	if len(groupByNames) == 0 {
 		return func(_ context.Context, request any) any {
 			dpWithEvs := request.(*dataPointWithEntityValues)
-			if len(dpWithEvs.GetFields()) <= fieldIdx {
-				manager.l.Warn().Interface("point", dpWithEvs.DataPointValue).
-					Str("fieldName", fieldName).
-					Int("len", len(dpWithEvs.GetFields())).
-					Int("fieldIdx", fieldIdx).
-					Msg("out of range")
-			}
+			v, _ := fieldValue(dpWithEvs.GetFields())
 			return flow.Data{
 				// EntityValues as identity
 				dpWithEvs.entityValues,
 				// save string representation of group values as the key, i.e. v1
 				"",
 				// field value as v2
-				dpWithEvs.GetFields()[fieldIdx].GetInt().GetValue(),
+				v,
 				// shardID values as v3
 				dpWithEvs.shardID,
 				// seriesID values as v4
@@ -567,6 +612,7 @@ func (manager *topNProcessorManager) buildMapper(fieldName string, groupByNames
 	}
 	return func(_ context.Context, request any) any {
 		dpWithEvs := request.(*dataPointWithEntityValues)
+		v, _ := fieldValue(dpWithEvs.GetFields())
 		return flow.Data{
 			// EntityValues as identity
 			dpWithEvs.entityValues,
@@ -575,7 +621,7 @@ func (manager *topNProcessorManager) buildMapper(fieldName string, groupByNames
 				return Stringify(extractTagValue(dpWithEvs.DataPointValue, locator))
 			})),
 			// field value as v2
-			dpWithEvs.GetFields()[fieldIdx].GetInt().GetValue(),
+			v,
 			// shardID values as v3
 			dpWithEvs.shardID,
 			// seriesID values as v4
@@ -0,0 +1,100 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package measure
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"golang.org/x/exp/slices"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+)
+
+// fieldEvaluator computes the value which ranks a data point.
+// It returns false if the value can't be computed, for example, the divisor is zero.
+type fieldEvaluator func(fields []*modelv1.FieldValue) (int64, bool)
+
+// compileFieldExpression compiles the expression over the int fields of the measure into a fieldEvaluator.
+func compileFieldExpression(m *databasev1.Measure, expr *databasev1.FieldExpression) (fieldEvaluator, error) {
+	switch e := expr.GetExpr().(type) {
+	case *databasev1.FieldExpression_FieldName:
+		fieldIdx := slices.IndexFunc(m.GetFields(), func(spec *databasev1.FieldSpec) bool {
+			return spec.GetName() == e.FieldName
+		})
+		if fieldIdx == -1 {
+			return nil, fmt.Errorf("field %s is not found in %s schema", e.FieldName, m.GetMetadata().GetName())
+		}
+		if m.GetFields()[fieldIdx].GetFieldType() != databasev1.FieldType_FIELD_TYPE_INT {
+			return nil, fmt.Errorf("field %s of the expression is not an int field", e.FieldName)
+		}
+		return func(fields []*modelv1.FieldValue) (int64, bool) {
+			if len(fields) <= fieldIdx {
+				return 0, false
+			}
+			v, ok := fields[fieldIdx].GetValue().(*modelv1.FieldValue_Int)
+			if !ok {
+				return 0, false
+			}
+			return v.Int.GetValue(), true
+		}, nil
+	case *databasev1.FieldExpression_Constant:
+		return func(_ []*modelv1.FieldValue) (int64, bool) {
+			return e.Constant, true
+		}, nil
+	case *databasev1.FieldExpression_Binary:
+		left, err := compileFieldExpression(m, e.Binary.GetLeft())
+		if err != nil {
+			return nil, err
+		}
+		right, err := compileFieldExpression(m, e.Binary.GetRight())
+		if err != nil {
+			return nil, err
+		}
+		op := e.Binary.GetOp()
+		if op == databasev1.FieldExpression_OP_UNSPECIFIED {
+			return nil, errors.New("the op of the field expression is unspecified")
+		}
+		return func(fields []*modelv1.FieldValue) (int64, bool) {
+			l, ok := left(fields)
+			if !ok {
+				return 0, false
+			}
+			r, ok := right(fields)
+			if !ok {
+				return 0, false
+			}
+			switch op {
+			case databasev1.FieldExpression_OP_ADD:
+				return l + r, true
+			case databasev1.FieldExpression_OP_SUB:
+				return l - r, true
+			case databasev1.FieldExpression_OP_MUL:
+				return l * r, true
+			case databasev1.FieldExpression_OP_DIV:
+				if r == 0 {
+					return 0, false
+				}
+				return l / r, true
+			}
+			return 0, false
+		}, nil
+	}
+	return nil, errors.New("the field expression is empty")
+}
@@ -0,0 +1,104 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package measure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+)
+
+func fieldRef(name string) *databasev1.FieldExpression {
+	return &databasev1.FieldExpression{Expr: &databasev1.FieldExpression_FieldName{FieldName: name}}
+}
+
+func constant(v int64) *databasev1.FieldExpression {
+	return &databasev1.FieldExpression{Expr: &databasev1.FieldExpression_Constant{Constant: v}}
+}
+
+func binary(op databasev1.FieldExpression_Op, left, right *databasev1.FieldExpression) *databasev1.FieldExpression {
+	return &databasev1.FieldExpression{Expr: &databasev1.FieldExpression_Binary{
+		Binary: &databasev1.FieldExpression_BinaryExpression{Op: op, Left: left, Right: right},
+	}}
+}
+
+func intField(v int64) *modelv1.FieldValue {
+	return &modelv1.FieldValue{Value: &modelv1.FieldValue_Int{Int: &modelv1.Int{Value: v}}}
+}
+
+func TestCompileFieldExpression(t *testing.T) {
+	m := &databasev1.Measure{
+		Metadata: &commonv1.Metadata{Name: "service_cpm_minute"},
+		Fields: []*databasev1.FieldSpec{
+			{Name: "errors", FieldType: databasev1.FieldType_FIELD_TYPE_INT},
+			{Name: "requests", FieldType: databasev1.FieldType_FIELD_TYPE_INT},
+			{Name: "latency", FieldType: databasev1.FieldType_FIELD_TYPE_FLOAT},
+		},
+	}
+	ratio := binary(databasev1.FieldExpression_OP_DIV,
+		binary(databasev1.FieldExpression_OP_MUL, fieldRef("errors"), constant(10000)),
+		fieldRef("requests"))
+	tests := []struct {
+		expr    *databasev1.FieldExpression
+		name    string
+		fields  []*modelv1.FieldValue
+		want    int64
+		wantOK  bool
+		wantErr bool
+	}{
+		{name: "field", expr: fieldRef("requests"), fields: []*modelv1.FieldValue{intField(3), intField(40)}, want: 40, wantOK: true},
+		{name: "ratio", expr: ratio, fields: []*modelv1.FieldValue{intField(3), intField(40)}, want: 750, wantOK: true},
+		{name: "zero divisor", expr: ratio, fields: []*modelv1.FieldValue{intField(3), intField(0)}},
+		{name: "missing field value", expr: ratio, fields: []*modelv1.FieldValue{intField(3)}},
+		{
+			name: "null field value", expr: ratio,
+			fields: []*modelv1.FieldValue{intField(3), {Value: &modelv1.FieldValue_Null{}}},
+		},
+		{
+			name:   "sum and difference",
+			expr:   binary(databasev1.FieldExpression_OP_SUB, binary(databasev1.FieldExpression_OP_ADD, fieldRef("errors"), fieldRef("requests")), constant(1)),
+			fields: []*modelv1.FieldValue{intField(3), intField(40)},
+			want:   42,
+			wantOK: true,
+		},
+		{name: "unknown field", expr: fieldRef("absent"), wantErr: true},
+		{name: "float field", expr: fieldRef("latency"), wantErr: true},
+		{name: "unspecified op", expr: binary(databasev1.FieldExpression_OP_UNSPECIFIED, constant(1), constant(2)), wantErr: true},
+		{name: "empty expression", expr: &databasev1.FieldExpression{}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eval, err := compileFieldExpression(m, tt.expr)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			got, ok := eval(tt.fields)
+			assert.Equal(t, tt.wantOK, ok)
+			if tt.wantOK {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
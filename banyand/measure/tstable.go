@@ -34,6 +34,7 @@ import (
 	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
 	"github.com/apache/skywalking-banyandb/banyand/protector"
 	"github.com/apache/skywalking-banyandb/pkg/fs"
+	"github.com/apache/skywalking-banyandb/pkg/index"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
 	"github.com/apache/skywalking-banyandb/pkg/pool"
 	"github.com/apache/skywalking-banyandb/pkg/run"
@@ -45,6 +46,13 @@ const (
 	snapshotSuffix = ".snp"
 )
 
+var (
+	errNoManifest = errors.New("there isn't any readable manifest")
+	errPartAbsent = errors.New("the part is absent on the disk")
+	errPartMerged = errors.New("the part isn't in the manifest, and is older than the newest part in it")
+	errPartOrphan = errors.New("the part isn't in the manifest, and is newer than all the parts in it")
+)
+
 func newTSTable(fileSystem fs.FileSystem, rootPath string, p common.Position,
 	l *logger.Logger, _ timestamp.TimeRange, option option, m any,
 ) (*tsTable, error) {
@@ -73,19 +81,19 @@ func newTSTable(fileSystem fs.FileSystem, rootPath string, p common.Position,
 	}
 	var loadedParts []uint64
 	var loadedSnapshots []uint64
-	var needToDelete []string
 	for i := range ee {
 		if ee[i].IsDir() {
+			if ee[i].Name() == storage.QuarantineDirname {
+				continue
+			}
 			p, err := parseEpoch(ee[i].Name())
 			if err != nil {
-				l.Info().Err(err).Msg("cannot parse part file name. skip and delete it")
-				needToDelete = append(needToDelete, ee[i].Name())
+				storage.Quarantine(fileSystem, rootPath, ee[i].Name(), err, l)
 				continue
 			}
-			err = validatePartMetadata(fileSystem, filepath.Join(rootPath, ee[i].Name()))
+			err = validatePart(fileSystem, filepath.Join(rootPath, ee[i].Name()))
 			if err != nil {
-				l.Info().Err(err).Msg("cannot validate part metadata. skip and delete it")
-				needToDelete = append(needToDelete, ee[i].Name())
+				storage.Quarantine(fileSystem, rootPath, ee[i].Name(), err, l)
 				continue
 			}
 
@@ -97,17 +105,12 @@ func newTSTable(fileSystem fs.FileSystem, rootPath string, p common.Position,
 		}
 		snapshot, err := parseSnapshot(ee[i].Name())
 		if err != nil {
-			l.Info().Err(err).Msg("cannot parse snapshot file name. skip and delete it")
-			needToDelete = append(needToDelete, ee[i].Name())
+			storage.Quarantine(fileSystem, rootPath, ee[i].Name(), err, l)
 			continue
 		}
 		loadedSnapshots = append(loadedSnapshots, snapshot)
 	}
-	for i := range needToDelete {
-		l.Info().Str("path", filepath.Join(rootPath, needToDelete[i])).Msg("delete invalid directory or file")
-		fileSystem.MustRMAll(filepath.Join(rootPath, needToDelete[i]))
-	}
-	if len(loadedParts) == 0 || len(loadedSnapshots) == 0 {
+	if len(loadedParts) == 0 && len(loadedSnapshots) == 0 {
 		t := &tst
 		t.startLoop(uint64(time.Now().UnixNano()))
 		return t, nil
@@ -115,9 +118,11 @@ func newTSTable(fileSystem fs.FileSystem, rootPath string, p common.Position,
 	sort.Slice(loadedSnapshots, func(i, j int) bool {
 		return loadedSnapshots[i] > loadedSnapshots[j]
 	})
-	epoch := loadedSnapshots[0]
 	t := &tst
-	t.loadSnapshot(epoch, loadedParts)
+	epoch := t.loadSnapshot(loadedSnapshots, loadedParts)
+	if epoch == 0 {
+		epoch = uint64(time.Now().UnixNano())
+	}
 	t.startLoop(epoch)
 	return t, nil
 }
@@ -138,48 +143,79 @@ type tsTable struct {
 	sync.RWMutex
 }
 
-func (tst *tsTable) loadSnapshot(epoch uint64, loadedParts []uint64) {
-	parts := tst.mustReadSnapshot(epoch)
+// loadSnapshot loads the latest readable manifest, and reconciles it with the parts on the disk:
+//   - The parts absent on the disk are dropped from the manifest.
+//   - The parts not in the manifest and older than its newest part are left by the merger, so they are removed.
+//   - The other parts not in the manifest might keep the data which aren't merged, so they are quarantined.
+//
+// It returns the epoch of the loaded manifest, or 0 if there isn't any readable manifest.
+func (tst *tsTable) loadSnapshot(epochs []uint64, loadedParts []uint64) uint64 {
+	var epoch uint64
+	var parts []uint64
+	for _, e := range epochs {
+		var err error
+		if parts, err = tst.readSnapshot(e); err == nil {
+			epoch = e
+			break
+		}
+		storage.Quarantine(tst.fileSystem, tst.root, snapshotName(e), err, tst.l)
+	}
+	if epoch == 0 {
+		for _, id := range loadedParts {
+			storage.Quarantine(tst.fileSystem, tst.root, partName(id), errNoManifest, tst.l)
+		}
+		return 0
+	}
+	onDisk := make(map[uint64]struct{}, len(loadedParts))
+	for _, id := range loadedParts {
+		onDisk[id] = struct{}{}
+		// the new parts shouldn't reuse the ID of any part on the disk.
+		if tst.curPartID < id {
+			tst.curPartID = id
+		}
+	}
 	snp := snapshot{
 		epoch: epoch,
 	}
 	needToPersist := false
-	for _, id := range loadedParts {
-		var find bool
-		for j := range parts {
-			if id == parts[j] {
-				find = true
-				break
-			}
-		}
-		if !find {
-			tst.gc.removePart(id)
-			continue
-		}
-		err := validatePartMetadata(tst.fileSystem, partPath(tst.root, id))
-		if err != nil {
-			tst.l.Info().Err(err).Uint64("id", id).Msg("cannot validate part metadata. skip and delete it")
-			tst.gc.removePart(id)
+	var newest uint64
+	for _, id := range parts {
+		if _, ok := onDisk[id]; !ok {
+			path := partPath(tst.root, id)
+			tst.l.Warn().Str("path", path).Msg("the part in the manifest is absent, drop it")
+			storage.ReportRepair(path, storage.RepairActionDropped, errPartAbsent)
 			needToPersist = true
 			continue
 		}
+		delete(onDisk, id)
 		p := mustOpenFilePart(id, tst.root, tst.fileSystem)
 		p.partMetadata.ID = id
 		snp.parts = append(snp.parts, newPartWrapper(nil, p))
-		if tst.curPartID < id {
-			tst.curPartID = id
+		if newest < id {
+			newest = id
+		}
+	}
+	for id := range onDisk {
+		if id < newest {
+			tst.gc.removePart(id)
+			storage.ReportRepair(partPath(tst.root, id), storage.RepairActionRemoved, errPartMerged)
+			continue
 		}
+		storage.Quarantine(tst.fileSystem, tst.root, partName(id), errPartOrphan, tst.l)
 	}
 	tst.gc.registerSnapshot(&snp)
+	if needToPersist {
+		// the repaired manifest replaces the loaded one.
+		snp.epoch++
+		tst.persistSnapshot(&snp)
+	}
 	tst.gc.clean()
 	if len(snp.parts) < 1 {
-		return
+		return snp.epoch
 	}
 	snp.incRef()
 	tst.snapshot = &snp
-	if needToPersist {
-		tst.persistSnapshot(&snp)
-	}
+	return snp.epoch
 }
 
 func (tst *tsTable) startLoop(cur uint64) {
@@ -221,25 +257,25 @@ func (tst *tsTable) mustWriteSnapshot(snapshot uint64, partNames []string) {
 	}
 }
 
-func (tst *tsTable) mustReadSnapshot(snapshot uint64) []uint64 {
+func (tst *tsTable) readSnapshot(snapshot uint64) ([]uint64, error) {
 	snapshotPath := filepath.Join(tst.root, snapshotName(snapshot))
 	data, err := tst.fileSystem.Read(snapshotPath)
 	if err != nil {
-		logger.Panicf("cannot read %s: %s", snapshotPath, err)
+		return nil, fmt.Errorf("cannot read %s: %w", snapshotPath, err)
 	}
 	var partNames []string
 	if err := json.Unmarshal(data, &partNames); err != nil {
-		logger.Panicf("cannot parse %s: %s", snapshotPath, err)
+		return nil, fmt.Errorf("cannot parse %s: %w", snapshotPath, err)
 	}
 	var result []uint64
 	for i := range partNames {
 		e, err := parseEpoch(partNames[i])
 		if err != nil {
-			logger.Panicf("cannot parse %s: %s", partNames[i], err)
+			return nil, err
 		}
 		result = append(result, e)
 	}
-	return result
+	return result, nil
 }
 
 func (tst *tsTable) Close() error {
@@ -247,6 +283,7 @@ func (tst *tsTable) Close() error {
 		tst.loopCloser.Done()
 		tst.loopCloser.CloseThenWait()
 	}
+	tst.flushOnClose()
 	tst.Lock()
 	defer tst.Unlock()
 	tst.deleteMetrics()
@@ -288,6 +325,45 @@ func (tst *tsTable) mustAddDataPoints(dps *dataPoints) {
 	tst.incTotalBatchIntroLatency(time.Since(startTime).Seconds())
 }
 
+// mustFlushDataPoints builds a part from dps on the disk and installs it into the table, bypassing the memory parts,
+// so the data points are durable once it returns. It serves the writes acknowledged after the flush.
+func (tst *tsTable) mustFlushDataPoints(dps *dataPoints) {
+	if len(dps.seriesIDs) == 0 {
+		return
+	}
+	if tst.fileSystem == nil {
+		// the table only lives in memory.
+		tst.mustAddDataPoints(dps)
+		return
+	}
+
+	mp := generateMemPart()
+	defer releaseMemPart(mp)
+	mp.mustInitFromDataPoints(dps)
+	partID := atomic.AddUint64(&tst.curPartID, 1)
+	tst.mustFlushMemPart(mp, partPath(tst.root, partID))
+	p := mustOpenFilePart(partID, tst.root, tst.fileSystem)
+	p.partMetadata.ID = partID
+
+	ind := generateIntroduction()
+	defer releaseIntroduction(ind)
+	ind.applied = make(chan struct{})
+	ind.imported = []*partWrapper{newPartWrapper(nil, p)}
+	startTime := time.Now()
+	select {
+	case tst.introductions <- ind:
+	case <-tst.loopCloser.CloseNotify():
+		return
+	}
+	select {
+	case <-ind.applied:
+	case <-tst.loopCloser.CloseNotify():
+	}
+	tst.incTotalWritten(len(dps.timestamps))
+	tst.incTotalBatch(1)
+	tst.incTotalBatchIntroLatency(time.Since(startTime).Seconds())
+}
+
 type tstIter struct {
 	err           error
 	parts         []*part
@@ -316,7 +392,7 @@ func (ti *tstIter) reset() {
 	ti.nextBlockNoop = false
 }
 
-func (ti *tstIter) init(bma *blockMetadataArray, parts []*part, sids []common.SeriesID, minTimestamp, maxTimestamp int64) {
+func (ti *tstIter) init(bma *blockMetadataArray, parts []*part, sids []common.SeriesID, minTimestamp, maxTimestamp int64, blockFilter index.Filter) {
 	ti.reset()
 	ti.parts = parts
 
@@ -325,7 +401,7 @@ func (ti *tstIter) init(bma *blockMetadataArray, parts []*part, sids []common.Se
 	}
 	ti.piPool = ti.piPool[:len(ti.parts)]
 	for i, p := range ti.parts {
-		ti.piPool[i].init(bma, p, sids, minTimestamp, maxTimestamp)
+		ti.piPool[i].init(bma, p, sids, minTimestamp, maxTimestamp, blockFilter)
 	}
 
 	ti.piHeap = ti.piHeap[:0]
@@ -19,6 +19,10 @@ package measure
 
 import (
 	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -29,12 +33,15 @@ import (
 
 	"github.com/apache/skywalking-banyandb/api/common"
 	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	"github.com/apache/skywalking-banyandb/banyand/protector"
 	"github.com/apache/skywalking-banyandb/pkg/convert"
 	"github.com/apache/skywalking-banyandb/pkg/fs"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
 	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
 	"github.com/apache/skywalking-banyandb/pkg/query/model"
 	"github.com/apache/skywalking-banyandb/pkg/run"
 	"github.com/apache/skywalking-banyandb/pkg/test"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
 	"github.com/apache/skywalking-banyandb/pkg/watcher"
 )
 
@@ -128,6 +135,127 @@ func Test_tsTable_mustAddDataPoints(t *testing.T) {
 	}
 }
 
+func Test_tsTable_CloseFlushesMemParts(t *testing.T) {
+	for _, workers := range []int{1, 4} {
+		t.Run(fmt.Sprintf("%d flush workers", workers), func(t *testing.T) {
+			tmpPath, defFn := test.Space(require.New(t))
+			defer defFn()
+			fileSystem := fs.NewLocalFileSystem()
+			opt := option{flushTimeout: time.Hour, mergePolicy: newDefaultMergePolicyForTesting(), protector: protector.Nop{}, flushWorkers: workers}
+			tst, err := newTSTable(fileSystem, tmpPath, common.Position{}, logger.GetLogger("test"), timestamp.TimeRange{}, opt, nil)
+			require.NoError(t, err)
+			tst.mustAddDataPoints(dpsTS1)
+			tst.mustAddDataPoints(dpsTS2)
+			// the flusher is paused to pile up the in-memory parts.
+			require.NoError(t, tst.Close())
+
+			tst, err = newTSTable(fileSystem, tmpPath, common.Position{}, logger.GetLogger("test"), timestamp.TimeRange{}, opt, nil)
+			require.NoError(t, err)
+			defer tst.Close()
+			s := tst.currentSnapshot()
+			require.NotNil(t, s)
+			defer s.decRef()
+			var total uint64
+			for _, pw := range s.parts {
+				require.Nil(t, pw.mp)
+				total += pw.p.partMetadata.TotalCount
+			}
+			assert.Equal(t, uint64(len(dpsTS1.timestamps)+len(dpsTS2.timestamps)), total)
+		})
+	}
+}
+
+func Test_tsTable_mustFlushDataPoints(t *testing.T) {
+	tmpPath, defFn := test.Space(require.New(t))
+	defer defFn()
+	opt := option{flushTimeout: time.Hour, mergePolicy: newDefaultMergePolicyForTesting(), protector: protector.Nop{}}
+	tst, err := newTSTable(fs.NewLocalFileSystem(), tmpPath, common.Position{}, logger.GetLogger("test"), timestamp.TimeRange{}, opt, nil)
+	require.NoError(t, err)
+	defer tst.Close()
+	tst.mustFlushDataPoints(dpsTS2)
+
+	s := tst.currentSnapshot()
+	require.NotNil(t, s)
+	defer s.decRef()
+	require.Len(t, s.parts, 1)
+	// the data points are on the disk without waiting for the flusher.
+	require.Nil(t, s.parts[0].mp)
+	assert.Equal(t, uint64(len(dpsTS2.timestamps)), s.parts[0].p.partMetadata.TotalCount)
+	persisted, err := tst.readSnapshot(s.epoch)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{s.parts[0].ID()}, persisted)
+}
+
+func Test_tsTable_RepairOnOpen(t *testing.T) {
+	tmpPath, defFn := test.Space(require.New(t))
+	defer defFn()
+	fileSystem := fs.NewLocalFileSystem()
+	opt := option{flushTimeout: time.Hour, mergePolicy: newDefaultMergePolicyForTesting(), protector: protector.Nop{}}
+	open := func() *tsTable {
+		tst, err := newTSTable(fileSystem, tmpPath, common.Position{}, logger.GetLogger("test"), timestamp.TimeRange{}, opt, nil)
+		require.NoError(t, err)
+		return tst
+	}
+	tst := open()
+	tst.mustAddDataPoints(dpsTS1)
+	require.NoError(t, tst.Close())
+	tst = open()
+	tst.mustAddDataPoints(dpsTS2)
+	epoch := tst.currentEpoch()
+	require.NoError(t, tst.Close())
+	tst = open()
+	parts, err := tst.readSnapshot(tst.currentEpoch())
+	require.NoError(t, err)
+	require.NoError(t, tst.Close())
+	require.Len(t, parts, 2)
+	damaged, kept := parts[0], parts[1]
+	require.Less(t, damaged, kept)
+
+	// a crash leaves a damaged part, the orphan parts and a torn manifest.
+	require.NoError(t, os.Remove(filepath.Join(partPath(tmpPath, damaged), timestampsFilename)))
+	require.NoError(t, os.CopyFS(partPath(tmpPath, 0), os.DirFS(partPath(tmpPath, kept))))
+	require.NoError(t, os.CopyFS(partPath(tmpPath, kept+10), os.DirFS(partPath(tmpPath, kept))))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpPath, snapshotName(epoch+10)), []byte("[\"torn"), 0o600))
+
+	tst = open()
+	s := tst.currentSnapshot()
+	require.NotNil(t, s)
+	var ids []uint64
+	for _, pw := range s.parts {
+		ids = append(ids, pw.ID())
+	}
+	s.decRef()
+	assert.Equal(t, []uint64{kept}, ids)
+	parts, err = tst.readSnapshot(tst.currentEpoch())
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{kept}, parts)
+	require.NoError(t, tst.Close())
+	assert.NoDirExists(t, partPath(tmpPath, 0))
+	assert.NoDirExists(t, partPath(tmpPath, kept+10))
+	quarantined, err := os.ReadDir(filepath.Join(tmpPath, storage.QuarantineDirname))
+	require.NoError(t, err)
+	assert.Len(t, quarantined, 3)
+
+	counts := func() map[storage.RepairAction]int {
+		result := make(map[storage.RepairAction]int)
+		for _, e := range storage.Repairs().Events {
+			if strings.HasPrefix(e.Path, tmpPath) {
+				result[e.Action]++
+			}
+		}
+		return result
+	}
+	want := map[storage.RepairAction]int{
+		storage.RepairActionQuarantined: 3,
+		storage.RepairActionDropped:     1,
+		storage.RepairActionRemoved:     1,
+	}
+	assert.Equal(t, want, counts())
+	// the repaired table is consistent.
+	require.NoError(t, open().Close())
+	assert.Equal(t, want, counts())
+}
+
 func Test_tstIter(t *testing.T) {
 	type testCtx struct {
 		wantErr      error
@@ -152,7 +280,7 @@ func Test_tstIter(t *testing.T) {
 		pp, n := s.getParts(nil, shardCache, tt.minTimestamp, tt.maxTimestamp)
 		require.Equal(t, len(s.parts), n)
 		ti := &tstIter{}
-		ti.init(bma, pp, tt.sids, tt.minTimestamp, tt.maxTimestamp)
+		ti.init(bma, pp, tt.sids, tt.minTimestamp, tt.maxTimestamp, nil)
 		var got []blockMetadata
 		for ti.nextBlock() {
 			if ti.piHeap[0].curBlock.seriesID == 0 {
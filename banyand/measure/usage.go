@@ -0,0 +1,72 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package measure
+
+import (
+	"context"
+	"time"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	"github.com/apache/skywalking-banyandb/banyand/observability"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+)
+
+type usageListener struct {
+	*bus.UnImplementedHealthyListener
+	s *service
+}
+
+// Rev reports the storage usage of the measure groups.
+func (l *usageListener) Rev(_ context.Context, message bus.Message) bus.Message {
+	groups, _ := message.Data().([]string)
+	result := storage.StorageUsage[*tsTable, option](commonv1.Catalog_CATALOG_MEASURE, l.s.schemaRepo, groups, time.Now())
+	return bus.NewMessage(bus.MessageID(time.Now().UnixNano()), result)
+}
+
+// Bundle adds the storage usage and the metadata files of the measure groups to the support bundle.
+func (s *service) Bundle(_ context.Context, req observability.BundleRequest, w observability.BundleWriter) error {
+	if s.schemaRepo == nil {
+		return nil
+	}
+	return storage.Bundle[*tsTable, option](commonv1.Catalog_CATALOG_MEASURE, s.schemaRepo, s.dataPath, req.Groups, w)
+}
+
+// Usage adds the usage of the parts on the disk to u. The parts in memory are skipped.
+func (tst *tsTable) Usage(u *storage.TableUsage) {
+	snp := tst.currentSnapshot()
+	if snp == nil {
+		return
+	}
+	defer snp.decRef()
+	for _, pw := range snp.parts {
+		if pw.mp != nil {
+			continue
+		}
+		p := pw.p
+		u.PartsCount++
+		u.PartsSize += int64(p.partMetadata.CompressedSizeBytes)
+		u.FieldsSize += storage.ReaderSize(p.fieldValues)
+		for name, r := range p.tagFamilies {
+			u.TagFamilySizes[name] += storage.ReaderSize(r)
+		}
+		for name, r := range p.tagFamilyMetadata {
+			u.TagFamilySizes[name] += storage.ReaderSize(r)
+		}
+	}
+}
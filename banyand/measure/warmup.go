@@ -0,0 +1,66 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package measure
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+)
+
+type warmupListener struct {
+	*bus.UnImplementedHealthyListener
+	s *service
+}
+
+// Rev loads the measure groups with their schemas into the caches and reports their readiness.
+func (w *warmupListener) Rev(ctx context.Context, message bus.Message) bus.Message {
+	groups, _ := message.Data().([]string)
+	return bus.NewMessage(bus.MessageID(time.Now().UnixNano()), w.s.schemaRepo.Warmup(ctx, commonv1.Catalog_CATALOG_MEASURE, groups))
+}
+
+// Warmup loads the block metadata of the parts on the disk into the cache c.
+func (tst *tsTable) Warmup(ctx context.Context, c storage.Cache) error {
+	snp := tst.currentSnapshot()
+	if snp == nil {
+		return nil
+	}
+	defer snp.decRef()
+	pi := &partIter{c: c}
+	for _, pw := range snp.parts {
+		if pw.mp != nil {
+			continue
+		}
+		pi.p = pw.p
+		for i := range pw.p.primaryBlockMetadata {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			// the cached block metadata share their buffers with the returned ones,
+			// so the returned ones are not reused across the blocks.
+			if _, err := pi.readPrimaryBlock(nil, &pw.p.primaryBlockMetadata[i]); err != nil {
+				return fmt.Errorf("cannot warm up the part %d: %w", pw.ID(), err)
+			}
+		}
+	}
+	return nil
+}
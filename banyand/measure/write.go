@@ -45,17 +45,23 @@ var subjectField = index.FieldKey{TagName: index.IndexModeName}
 type writeCallback struct {
 	l                   *logger.Logger
 	schemaRepo          *schemaRepo
+	replayCache         *storage.ReplayCache
 	maxDiskUsagePercent int
+	validateRouting     bool
 }
 
-func setUpWriteCallback(l *logger.Logger, schemaRepo *schemaRepo, maxDiskUsagePercent int) bus.MessageListener {
+func setUpWriteCallback(l *logger.Logger, schemaRepo *schemaRepo, maxDiskUsagePercent int, replayCache *storage.ReplayCache,
+	validateRouting bool,
+) bus.MessageListener {
 	if maxDiskUsagePercent > 100 {
 		maxDiskUsagePercent = 100
 	}
 	return &writeCallback{
 		l:                   l,
 		schemaRepo:          schemaRepo,
+		replayCache:         replayCache,
 		maxDiskUsagePercent: maxDiskUsagePercent,
+		validateRouting:     validateRouting,
 	}
 }
 
@@ -93,40 +99,50 @@ func (w *writeCallback) handle(dst map[string]*dataPointsInGroup, writeEvent *me
 			metadataDocMap:  make(map[uint64]int),
 			indexModeDocMap: make(map[uint64]int),
 		}
+		if g, ok := w.schemaRepo.LoadGroup(gn); ok {
+			dpg.group = g.GetSchema()
+		}
 		dst[gn] = dpg
 	}
 	if dpg.latestTS < ts {
 		dpg.latestTS = ts
 	}
 
+	stm, ok := w.schemaRepo.loadMeasure(req.GetMetadata())
+	if !ok {
+		return nil, fmt.Errorf("cannot find measure definition: %s", req.GetMetadata())
+	}
+	// the data points acknowledged after the flush are held apart from the others, since they're flushed to the disk immediately.
+	if err := appendDataPoint(dpg, stm.loadIndexSchema(), writeEvent, t, storage.AckOnFlush(req.GetAckLevel(), dpg.group)); err != nil {
+		return nil, err
+	}
+	return dst, nil
+}
+
+// appendDataPoint adds the data point of writeEvent to the table of dpg covering t, and collects its series index document.
+func appendDataPoint(dpg *dataPointsInGroup, is *indexSchema, writeEvent *measurev1.InternalWriteRequest, t time.Time, flush bool) error {
+	req := writeEvent.Request
+	ts := t.UnixNano()
 	var dpt *dataPointsInTable
 	for i := range dpg.tables {
-		if dpg.tables[i].timeRange.Contains(ts) {
+		if dpg.tables[i].flush == flush && dpg.tables[i].timeRange.Contains(ts) {
 			dpt = dpg.tables[i]
 			break
 		}
 	}
-	stm, ok := w.schemaRepo.loadMeasure(req.GetMetadata())
-	if !ok {
-		return nil, fmt.Errorf("cannot find measure definition: %s", req.GetMetadata())
-	}
 	fLen := len(req.DataPoint.GetTagFamilies())
 	if fLen < 1 {
-		return nil, fmt.Errorf("%s has no tag family", req.Metadata)
+		return fmt.Errorf("%s has no tag family", req.Metadata)
 	}
-	if fLen > len(stm.schema.GetTagFamilies()) {
-		return nil, fmt.Errorf("%s has more tag families than %s", req.Metadata, stm.schema)
-	}
-	is := stm.indexSchema.Load().(indexSchema)
-	if len(is.indexRuleLocators.TagFamilyTRule) != len(stm.GetSchema().GetTagFamilies()) {
-		logger.Panicf("metadata crashed, tag family rule length %d, tag family length %d",
-			len(is.indexRuleLocators.TagFamilyTRule), len(stm.GetSchema().GetTagFamilies()))
+	if fLen > len(is.schema.GetTagFamilies()) {
+		return fmt.Errorf("%s has more tag families than %s", req.Metadata, is.schema)
 	}
 
 	shardID := common.ShardID(writeEvent.ShardId)
 	if dpt == nil {
-		if dpt, err = w.newDpt(tsdb, dpg, t, ts, shardID, stm.schema.IndexMode); err != nil {
-			return nil, fmt.Errorf("cannot create data points in table: %w", err)
+		var err error
+		if dpt, err = newDpt(dpg, t, ts, shardID, is.schema.IndexMode, flush); err != nil {
+			return fmt.Errorf("cannot create data points in table: %w", err)
 		}
 	}
 
@@ -135,44 +151,52 @@ func (w *writeCallback) handle(dst map[string]*dataPointsInGroup, writeEvent *me
 		EntityValues: writeEvent.EntityValues,
 	}
 	if err := series.Marshal(); err != nil {
-		return nil, fmt.Errorf("cannot marshal series: %w", err)
+		return fmt.Errorf("cannot marshal series: %w", err)
 	}
 
-	if stm.schema.IndexMode {
-		fields := handleIndexMode(stm.schema, req, is.indexRuleLocators)
-		fields = w.appendEntityTagsToIndexFields(fields, stm, series)
+	if is.schema.IndexMode {
+		fields := handleIndexMode(is.schema, req, is.indexRuleLocators)
+		fields = appendEntityTagsToIndexFields(fields, is, series)
 		doc := index.Document{
 			DocID:        uint64(series.ID),
 			EntityValues: series.Buffer,
 			Fields:       fields,
 			Version:      req.DataPoint.Version,
 			Timestamp:    ts,
+			FirstSeen:    ts,
+			LastSeen:     ts,
 		}
 
 		if pos, exists := dpg.indexModeDocMap[doc.DocID]; exists {
+			doc.Seen(dpg.indexModeDocs[pos].FirstSeen)
+			doc.Seen(dpg.indexModeDocs[pos].LastSeen)
 			dpg.indexModeDocs[pos] = doc
 		} else {
 			dpg.indexModeDocMap[doc.DocID] = len(dpg.indexModeDocs)
 			dpg.indexModeDocs = append(dpg.indexModeDocs, doc)
 		}
-		return dst, nil
+		return nil
 	}
 
-	fields := appendDataPoints(dpt, ts, series.ID, stm.GetSchema(), req, is.indexRuleLocators)
+	fields := appendDataPoints(dpt, ts, series.ID, is.schema, req, is.indexRuleLocators)
 
 	doc := index.Document{
 		DocID:        uint64(series.ID),
 		EntityValues: series.Buffer,
 		Fields:       fields,
+		FirstSeen:    ts,
+		LastSeen:     ts,
 	}
 
 	if pos, exists := dpg.metadataDocMap[doc.DocID]; exists {
+		doc.Seen(dpg.metadataDocs[pos].FirstSeen)
+		doc.Seen(dpg.metadataDocs[pos].LastSeen)
 		dpg.metadataDocs[pos] = doc
 	} else {
 		dpg.metadataDocMap[doc.DocID] = len(dpg.metadataDocs)
 		dpg.metadataDocs = append(dpg.metadataDocs, doc)
 	}
-	return dst, nil
+	return nil
 }
 
 func appendDataPoints(dest *dataPointsInTable, ts int64, sid common.SeriesID, schema *databasev1.Measure,
@@ -209,8 +233,7 @@ func appendDataPoints(dest *dataPointsInTable, ts int64, sid common.SeriesID, sc
 	return fields
 }
 
-func (w *writeCallback) newDpt(tsdb storage.TSDB[*tsTable, option], dpg *dataPointsInGroup,
-	t time.Time, ts int64, shardID common.ShardID, indexMode bool,
+func newDpt(dpg *dataPointsInGroup, t time.Time, ts int64, shardID common.ShardID, indexMode, flush bool,
 ) (*dataPointsInTable, error) {
 	var segment storage.Segment[*tsTable, option]
 	for _, seg := range dpg.segments {
@@ -220,7 +243,7 @@ func (w *writeCallback) newDpt(tsdb storage.TSDB[*tsTable, option], dpg *dataPoi
 	}
 	if segment == nil {
 		var err error
-		segment, err = tsdb.CreateSegmentIfNotExist(t)
+		segment, err = dpg.tsdb.CreateSegmentIfNotExist(t)
 		if err != nil {
 			return nil, fmt.Errorf("cannot create segment: %w", err)
 		}
@@ -239,6 +262,7 @@ func (w *writeCallback) newDpt(tsdb storage.TSDB[*tsTable, option], dpg *dataPoi
 	dpt := &dataPointsInTable{
 		timeRange: segment.GetTimeRange(),
 		tsTable:   tstb,
+		flush:     flush,
 	}
 	dpg.tables = append(dpg.tables, dpt)
 	return dpt, nil
@@ -278,11 +302,13 @@ func handleTagFamily(schema *databasev1.Measure, req *measurev1.WriteRequest, lo
 				fieldKey := index.FieldKey{}
 				fieldKey.IndexRuleID = r.GetMetadata().GetId()
 				fieldKey.Analyzer = r.Analyzer
+				caseInsensitive := isCaseInsensitive(r, encodeTagValue.valueType)
 				if encodeTagValue.value != nil {
 					f := index.NewBytesField(fieldKey, encodeTagValue.value)
 					f.Store = true
 					f.Index = true
 					f.NoSort = r.GetNoSort()
+					f.CaseInsensitive = caseInsensitive
 					fields = append(fields, f)
 				} else {
 					for _, val := range encodeTagValue.valueArr {
@@ -290,6 +316,7 @@ func handleTagFamily(schema *databasev1.Measure, req *measurev1.WriteRequest, lo
 						f.Store = true
 						f.Index = true
 						f.NoSort = r.GetNoSort()
+						f.CaseInsensitive = caseInsensitive
 						fields = append(fields, f)
 					}
 				}
@@ -342,11 +369,13 @@ func handleIndexMode(schema *databasev1.Measure, req *measurev1.WriteRequest, lo
 			} else {
 				fieldKey.TagName = t.Name
 			}
+			caseInsensitive := toIndex && isCaseInsensitive(r, encodeTagValue.valueType)
 			if encodeTagValue.value != nil {
 				f := index.NewBytesField(fieldKey, encodeTagValue.value)
 				f.Store = true
 				f.Index = toIndex
 				f.NoSort = r.GetNoSort()
+				f.CaseInsensitive = caseInsensitive
 				fields = append(fields, f)
 			} else {
 				for _, val := range encodeTagValue.valueArr {
@@ -354,6 +383,7 @@ func handleIndexMode(schema *databasev1.Measure, req *measurev1.WriteRequest, lo
 					f.Store = true
 					f.Index = toIndex
 					f.NoSort = r.GetNoSort()
+					f.CaseInsensitive = caseInsensitive
 					fields = append(fields, f)
 				}
 			}
@@ -363,22 +393,26 @@ func handleIndexMode(schema *databasev1.Measure, req *measurev1.WriteRequest, lo
 	return fields
 }
 
-func (w *writeCallback) appendEntityTagsToIndexFields(fields []index.Field, stm *measure, series *pbv1.Series) []index.Field {
+// isCaseInsensitive returns whether the index holds the lowercase terms of the string tag.
+func isCaseInsensitive(r *databasev1.IndexRule, valueType pbv1.ValueType) bool {
+	return r.GetCaseInsensitive() && (valueType == pbv1.ValueTypeStr || valueType == pbv1.ValueTypeStrArr)
+}
+
+func appendEntityTagsToIndexFields(fields []index.Field, is *indexSchema, series *pbv1.Series) []index.Field {
 	f := index.NewStringField(subjectField, series.Subject)
 	f.Index = true
 	f.NoSort = true
 	fields = append(fields, f)
-	is := stm.indexSchema.Load().(indexSchema)
-	for i := range stm.schema.Entity.TagNames {
-		if _, exists := is.indexTagMap[stm.schema.Entity.TagNames[i]]; exists {
+	for i := range is.schema.Entity.TagNames {
+		if _, exists := is.indexTagMap[is.schema.Entity.TagNames[i]]; exists {
 			continue
 		}
-		tagName := stm.schema.Entity.TagNames[i]
+		tagName := is.schema.Entity.TagNames[i]
 		var t *databasev1.TagSpec
-		for j := range stm.schema.TagFamilies {
-			for k := range stm.schema.TagFamilies[j].Tags {
-				if stm.schema.TagFamilies[j].Tags[k].Name == tagName {
-					t = stm.schema.TagFamilies[j].Tags[k]
+		for j := range is.schema.TagFamilies {
+			for k := range is.schema.TagFamilies[j].Tags {
+				if is.schema.TagFamilies[j].Tags[k].Name == tagName {
+					t = is.schema.TagFamilies[j].Tags[k]
 				}
 			}
 		}
@@ -409,6 +443,8 @@ func (w *writeCallback) Rev(_ context.Context, message bus.Message) (resp bus.Me
 		return
 	}
 	groups := make(map[string]*dataPointsInGroup)
+	replay := w.replayCache.NewBatch()
+	var misrouted int
 	for i := range events {
 		var writeEvent *measurev1.InternalWriteRequest
 		switch e := events[i].(type) {
@@ -424,10 +460,23 @@ func (w *writeCallback) Rev(_ context.Context, message bus.Message) (resp bus.Me
 			w.l.Warn().Msg("invalid event data type")
 			continue
 		}
+		req := writeEvent.GetRequest()
+		if w.validateRouting {
+			if err := w.checkRouting(writeEvent); err != nil {
+				w.l.Error().Err(err).RawJSON("written", logger.Proto(req)).Msg("reject the write")
+				misrouted++
+				continue
+			}
+		}
+		if replay.Seen(req.GetMetadata().GetGroup(), common.ShardID(writeEvent.ShardId), req.GetIdempotencyKey()) {
+			w.l.Debug().Str("group", req.GetMetadata().GetGroup()).Str("key", req.GetIdempotencyKey()).Msg("drop the duplicate write")
+			continue
+		}
 		var err error
 		if groups, err = w.handle(groups, writeEvent); err != nil {
 			w.l.Error().Err(err).RawJSON("written", logger.Proto(writeEvent)).Msg("cannot handle write event")
 			groups = make(map[string]*dataPointsInGroup)
+			replay.Reset()
 			continue
 		}
 	}
@@ -436,7 +485,11 @@ func (w *writeCallback) Rev(_ context.Context, message bus.Message) (resp bus.Me
 		for j := range g.tables {
 			dps := g.tables[j]
 			if dps.tsTable != nil {
-				dps.tsTable.mustAddDataPoints(dps.dataPoints)
+				if dps.flush {
+					dps.tsTable.mustFlushDataPoints(dps.dataPoints)
+				} else {
+					dps.tsTable.mustAddDataPoints(dps.dataPoints)
+				}
 			}
 			if dps.dataPoints != nil {
 				releaseDataPoints(dps.dataPoints)
@@ -457,9 +510,40 @@ func (w *writeCallback) Rev(_ context.Context, message bus.Message) (resp bus.Me
 		}
 		g.tsdb.Tick(g.latestTS)
 	}
+	replay.Commit()
+	if misrouted > 0 {
+		resp = bus.NewMessage(message.ID(), common.NewErrorWithStatus(modelv1.Status_STATUS_MISROUTED,
+			fmt.Sprintf("%d data points are written to the wrong shards", misrouted)))
+	}
 	return
 }
 
+// checkRouting verifies the shard of the data point against the measure schema,
+// since the smart clients could write to the data node directly.
+func (w *writeCallback) checkRouting(writeEvent *measurev1.InternalWriteRequest) error {
+	req := writeEvent.GetRequest()
+	// the top-n results are written by the data node itself to the shards of their source series.
+	if req.GetMetadata().GetName() == TopNSchemaName {
+		return nil
+	}
+	m, ok := w.schemaRepo.loadMeasure(req.GetMetadata())
+	if !ok {
+		return fmt.Errorf("cannot find measure definition: %s", req.GetMetadata())
+	}
+	g, ok := w.schemaRepo.LoadGroup(req.GetMetadata().GetGroup())
+	if !ok {
+		return fmt.Errorf("group %s not found", req.GetMetadata().GetGroup())
+	}
+	var locator partition.Locator
+	if shardingKey := m.schema.GetShardingKey(); len(shardingKey.GetTagNames()) > 0 {
+		locator = partition.NewShardingKeyLocator(m.schema.GetTagFamilies(), shardingKey)
+	} else {
+		locator = partition.NewEntityLocator(m.schema.GetTagFamilies(), m.schema.GetEntity(), 0)
+	}
+	return locator.CheckShard(req.GetMetadata().GetName(), req.GetDataPoint().GetTagFamilies(),
+		g.GetSchema().GetResourceOpts().GetShardNum(), writeEvent.GetShardId())
+}
+
 func encodeFieldValue(name string, fieldType databasev1.FieldType, fieldValue *modelv1.FieldValue) *nameValue {
 	nv := &nameValue{name: name}
 	switch fieldType {
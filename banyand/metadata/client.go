@@ -151,6 +151,13 @@ func (s *clientService) PreRun(ctx context.Context) error {
 		}
 		return err
 	}
+	if flags, ok := ctx.Value(common.ContextDynamicFlagsKey).(*run.DynamicFlags); ok {
+		var nodeID string
+		if node, ok := ctx.Value(common.ContextNodeKey).(common.Node); ok {
+			nodeID = node.NodeID
+		}
+		s.schemaRegistry.RegisterHandler("dynamic-flag", schema.KindDynamicFlag, newDynamicFlagApplier(nodeID, flags, l))
+	}
 	if !s.toRegisterNode {
 		return nil
 	}
@@ -259,6 +266,10 @@ func (s *clientService) PropertyRegistry() schema.Property {
 	return s.schemaRegistry
 }
 
+func (s *clientService) DynamicFlagRegistry() schema.DynamicFlag {
+	return s.schemaRegistry
+}
+
 func (s *clientService) Name() string {
 	return "metadata"
 }
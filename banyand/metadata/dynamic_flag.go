@@ -0,0 +1,110 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package metadata
+
+import (
+	"sync"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/run"
+)
+
+// dynamicFlagApplier applies the dynamic flags stored in the registry to the local flags.
+// A flag overridden on this node takes precedence over the cluster-wide one.
+type dynamicFlagApplier struct {
+	schema.UnimplementedOnInitHandler
+	flags   *run.DynamicFlags
+	l       *logger.Logger
+	cluster map[string]string
+	node    map[string]string
+	nodeID  string
+	mu      sync.Mutex
+}
+
+func newDynamicFlagApplier(nodeID string, flags *run.DynamicFlags, l *logger.Logger) *dynamicFlagApplier {
+	return &dynamicFlagApplier{
+		nodeID:  nodeID,
+		flags:   flags,
+		l:       l,
+		cluster: make(map[string]string),
+		node:    make(map[string]string),
+	}
+}
+
+func (a *dynamicFlagApplier) OnAddOrUpdate(m schema.Metadata) {
+	f, ok := m.Spec.(*databasev1.DynamicFlag)
+	if !ok {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	values := a.values(f.GetNode())
+	if values == nil {
+		return
+	}
+	values[f.GetName()] = f.GetValue()
+	a.apply(f.GetName())
+}
+
+func (a *dynamicFlagApplier) OnDelete(m schema.Metadata) {
+	f, ok := m.Spec.(*databasev1.DynamicFlag)
+	if !ok {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	values := a.values(f.GetNode())
+	if values == nil {
+		return
+	}
+	delete(values, f.GetName())
+	a.apply(f.GetName())
+}
+
+func (a *dynamicFlagApplier) values(node string) map[string]string {
+	switch node {
+	case "":
+		return a.cluster
+	case a.nodeID:
+		return a.node
+	default:
+		return nil
+	}
+}
+
+func (a *dynamicFlagApplier) apply(name string) {
+	if !a.flags.Has(name) {
+		a.l.Debug().Str("flag", name).Msg("ignore the flag which can't be changed at runtime")
+		return
+	}
+	var err error
+	if v, ok := a.node[name]; ok {
+		err = a.flags.Set(name, v)
+	} else if v, ok = a.cluster[name]; ok {
+		err = a.flags.Set(name, v)
+	} else {
+		err = a.flags.Reset(name)
+	}
+	if err != nil {
+		a.l.Warn().Err(err).Str("flag", name).Msg("fail to apply the dynamic flag")
+		return
+	}
+	a.l.Info().Str("flag", name).Msg("apply the dynamic flag")
+}
@@ -50,6 +50,7 @@ type Repo interface {
 	RegisterHandler(string, schema.Kind, schema.EventHandler)
 	NodeRegistry() schema.Node
 	PropertyRegistry() schema.Property
+	DynamicFlagRegistry() schema.DynamicFlag
 }
 
 // Service is the metadata repository.
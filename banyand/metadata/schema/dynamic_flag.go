@@ -0,0 +1,82 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package schema
+
+import (
+	"context"
+	"errors"
+	"path"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+)
+
+const dynamicFlagKeyPrefix = "/dynamic-flags/"
+
+func (e *etcdSchemaRegistry) ListDynamicFlag(ctx context.Context) ([]*databasev1.DynamicFlag, error) {
+	messages, err := e.listWithPrefix(ctx, dynamicFlagKeyPrefix, KindDynamicFlag)
+	if err != nil {
+		return nil, err
+	}
+	entities := make([]*databasev1.DynamicFlag, 0, len(messages))
+	for _, message := range messages {
+		entities = append(entities, message.(*databasev1.DynamicFlag))
+	}
+	return entities, nil
+}
+
+// SetDynamicFlag creates the flag, or overwrites it if it exists.
+func (e *etcdSchemaRegistry) SetDynamicFlag(ctx context.Context, flag *databasev1.DynamicFlag) error {
+	if flag.GetName() == "" {
+		return BadRequest("name", "name should not be empty")
+	}
+	flag.UpdatedAt = timestamppb.Now()
+	md := Metadata{
+		TypeMeta: TypeMeta{
+			Kind:  KindDynamicFlag,
+			Group: flag.GetNode(),
+			Name:  flag.GetName(),
+		},
+		Spec: flag,
+	}
+	_, err := e.create(ctx, md)
+	if errors.Is(err, ErrGRPCAlreadyExists) {
+		_, err = e.update(ctx, md)
+	}
+	return err
+}
+
+func (e *etcdSchemaRegistry) DeleteDynamicFlag(ctx context.Context, node, name string) (bool, error) {
+	return e.delete(ctx, Metadata{
+		TypeMeta: TypeMeta{
+			Kind:  KindDynamicFlag,
+			Group: node,
+			Name:  name,
+		},
+	})
+}
+
+// formatDynamicFlagKey returns the key of the cluster-wide flag if the node is empty,
+// otherwise the key of the flag overridden on the node.
+func formatDynamicFlagKey(node, name string) string {
+	if node == "" {
+		return path.Join(dynamicFlagKeyPrefix, "cluster", name)
+	}
+	return path.Join(dynamicFlagKeyPrefix, "nodes", node, name)
+}
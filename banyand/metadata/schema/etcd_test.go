@@ -421,3 +421,33 @@ func Test_Etcd_Entity_Update(t *testing.T) {
 		})
 	}
 }
+
+func Test_Etcd_DynamicFlag(t *testing.T) {
+	req := require.New(t)
+	registry, closer := initServerAndRegister(t)
+	defer closer()
+	ctx := context.TODO()
+
+	req.NoError(registry.SetDynamicFlag(ctx, &databasev1.DynamicFlag{Name: "slow-query", Value: "1s"}))
+	req.NoError(registry.SetDynamicFlag(ctx, &databasev1.DynamicFlag{Name: "slow-query", Value: "500ms", Node: "data-0"}))
+	// the flag is overwritten if it exists
+	req.NoError(registry.SetDynamicFlag(ctx, &databasev1.DynamicFlag{Name: "slow-query", Value: "2s"}))
+
+	flags, err := registry.ListDynamicFlag(ctx)
+	req.NoError(err)
+	req.Len(flags, 2)
+	values := make(map[string]string, len(flags))
+	for _, f := range flags {
+		req.NotNil(f.UpdatedAt)
+		values[f.Node] = f.Value
+	}
+	req.Equal(map[string]string{"": "2s", "data-0": "500ms"}, values)
+
+	ok, err := registry.DeleteDynamicFlag(ctx, "data-0", "slow-query")
+	req.NoError(err)
+	req.True(ok)
+	flags, err = registry.ListDynamicFlag(ctx)
+	req.NoError(err)
+	req.Len(flags, 1)
+	req.Empty(flags[0].Node)
+}
@@ -105,11 +105,6 @@ func (e *etcdSchemaRegistry) UpdateGroup(ctx context.Context, group *commonv1.Gr
 	if proto.Equal(g.ResourceOpts, group.ResourceOpts) {
 		return nil
 	}
-	if group.Catalog == commonv1.Catalog_CATALOG_STREAM || group.Catalog == commonv1.Catalog_CATALOG_MEASURE {
-		if g.GetResourceOpts().SegmentInterval.Unit != group.GetResourceOpts().SegmentInterval.Unit {
-			return errors.New("segment interval unit cannot be changed")
-		}
-	}
 	_, err = e.update(ctx, Metadata{
 		TypeMeta: TypeMeta{
 			Kind: KindGroup,
@@ -41,10 +41,11 @@ const (
 	KindTopNAggregation
 	KindNode
 	KindProperty
+	KindDynamicFlag
 	KindMask = KindGroup | KindStream | KindMeasure |
 		KindIndexRuleBinding | KindIndexRule |
-		KindTopNAggregation | KindNode | KindProperty
-	KindSize = 8
+		KindTopNAggregation | KindNode | KindProperty | KindDynamicFlag
+	KindSize = 9
 )
 
 func (k Kind) key() string {
@@ -63,6 +64,8 @@ func (k Kind) key() string {
 		return topNAggregationKeyPrefix
 	case KindNode:
 		return nodeKeyPrefix
+	case KindDynamicFlag:
+		return dynamicFlagKeyPrefix
 	default:
 		return "unknown"
 	}
@@ -91,6 +94,8 @@ func (k Kind) Unmarshal(kv *mvccpb.KeyValue) (Metadata, error) {
 		m = &databasev1.Node{}
 	case KindProperty:
 		m = &databasev1.Property{}
+	case KindDynamicFlag:
+		m = &databasev1.DynamicFlag{}
 	default:
 		return Metadata{}, errUnsupportedEntityType
 	}
@@ -133,6 +138,8 @@ func (k Kind) String() string {
 		return "topNAggregation"
 	case KindNode:
 		return "node"
+	case KindDynamicFlag:
+		return "dynamicFlag"
 	default:
 		return "unknown"
 	}
@@ -142,7 +149,8 @@ func allKeys() []string {
 	var keys []string
 	for i := 0; i < KindSize; i++ {
 		ki := Kind(1 << i)
-		if KindMask&ki > 0 {
+		// the dynamic flags aren't grouped
+		if KindMask&ki > 0 && ki != KindDynamicFlag {
 			keys = append(keys, ki.key())
 		}
 	}
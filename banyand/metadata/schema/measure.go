@@ -153,8 +153,8 @@ func validateEqualExceptAppendTagsAndFields(prevMeasure, newMeasure *databasev1.
 			return fmt.Errorf("number of tags in tag family %s is less in the new measure", tagFamily.Name)
 		}
 		for j, tag := range tagFamily.Tags {
-			if tag.String() != newMeasure.GetTagFamilies()[i].Tags[j].String() {
-				return fmt.Errorf("tag %s in tag family %s is different: %s != %s", tag.Name, tagFamily.Name, tag.String(), newMeasure.GetTagFamilies()[i].Tags[j].String())
+			if prevTag, newTag := tagSpecString(tag), tagSpecString(newMeasure.GetTagFamilies()[i].Tags[j]); prevTag != newTag {
+				return fmt.Errorf("tag %s in tag family %s is different: %s != %s", tag.Name, tagFamily.Name, prevTag, newTag)
 			}
 		}
 	}
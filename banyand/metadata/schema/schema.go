@@ -65,6 +65,7 @@ type Registry interface {
 	TopNAggregation
 	Node
 	Property
+	DynamicFlag
 	RegisterHandler(string, Kind, EventHandler)
 	NewWatcher(string, Kind, int64, ...WatcherOption) *watcher
 	Register(context.Context, Metadata, bool) error
@@ -126,6 +127,8 @@ func (m Metadata) key() (string, error) {
 			Group: m.Group,
 			Name:  m.Name,
 		}), nil
+	case KindDynamicFlag:
+		return formatDynamicFlagKey(m.Group, m.Name), nil
 	default:
 		return "", errUnsupportedEntityType
 	}
@@ -217,3 +220,10 @@ type Property interface {
 	UpdateProperty(ctx context.Context, property *databasev1.Property) error
 	DeleteProperty(ctx context.Context, metadata *commonv1.Metadata) (bool, error)
 }
+
+// DynamicFlag allows setting the flags changed at runtime.
+type DynamicFlag interface {
+	ListDynamicFlag(ctx context.Context) ([]*databasev1.DynamicFlag, error)
+	SetDynamicFlag(ctx context.Context, flag *databasev1.DynamicFlag) error
+	DeleteDynamicFlag(ctx context.Context, node, name string) (bool, error)
+}
@@ -22,6 +22,7 @@ import (
 	"fmt"
 
 	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
@@ -94,6 +95,9 @@ func validateEqualExceptAppendTags(prevStream, newStream *databasev1.Stream) err
 	if prevStream.GetEntity().String() != newStream.GetEntity().String() {
 		return fmt.Errorf("entity is different: %s != %s", prevStream.GetEntity().String(), newStream.GetEntity().String())
 	}
+	if prevStream.GetShardingKey().String() != newStream.GetShardingKey().String() {
+		return fmt.Errorf("sharding key is different: %s != %s", prevStream.GetShardingKey().String(), newStream.GetShardingKey().String())
+	}
 	if len(prevStream.GetTagFamilies()) > len(newStream.GetTagFamilies()) {
 		return fmt.Errorf("number of tag families is less in the new stream")
 	}
@@ -101,18 +105,32 @@ func validateEqualExceptAppendTags(prevStream, newStream *databasev1.Stream) err
 		if tagFamily.Name != newStream.GetTagFamilies()[i].Name {
 			return fmt.Errorf("tag family name is different: %s != %s", tagFamily.Name, newStream.GetTagFamilies()[i].Name)
 		}
+		if (tagFamily.GetLayout() == databasev1.TagFamilyLayout_TAG_FAMILY_LAYOUT_PAYLOAD) !=
+			(newStream.GetTagFamilies()[i].GetLayout() == databasev1.TagFamilyLayout_TAG_FAMILY_LAYOUT_PAYLOAD) {
+			return fmt.Errorf("layout of tag family %s is different: %s != %s", tagFamily.Name, tagFamily.GetLayout(), newStream.GetTagFamilies()[i].GetLayout())
+		}
 		if len(tagFamily.Tags) > len(newStream.GetTagFamilies()[i].Tags) {
 			return fmt.Errorf("number of tags in tag family %s is less in the new stream", tagFamily.Name)
 		}
 		for j, tag := range tagFamily.Tags {
-			if tag.String() != newStream.GetTagFamilies()[i].Tags[j].String() {
-				return fmt.Errorf("tag %s in tag family %s is different: %s != %s", tag.Name, tagFamily.Name, tag.String(), newStream.GetTagFamilies()[i].Tags[j].String())
+			if prevTag, newTag := tagSpecString(tag), tagSpecString(newStream.GetTagFamilies()[i].Tags[j]); prevTag != newTag {
+				return fmt.Errorf("tag %s in tag family %s is different: %s != %s", tag.Name, tagFamily.Name, prevTag, newTag)
 			}
 		}
 	}
 	return nil
 }
 
+// tagSpecString renders the tag spec without its default value, which is allowed to change.
+func tagSpecString(tag *databasev1.TagSpec) string {
+	if tag.GetDefaultValue() == nil {
+		return tag.String()
+	}
+	t := proto.Clone(tag).(*databasev1.TagSpec)
+	t.DefaultValue = nil
+	return t.String()
+}
+
 func (e *etcdSchemaRegistry) CreateStream(ctx context.Context, stream *databasev1.Stream) (int64, error) {
 	if stream.UpdatedAt != nil {
 		stream.UpdatedAt = timestamppb.Now()
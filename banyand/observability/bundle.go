@@ -0,0 +1,267 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package observability
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/multierr"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/banyand/metadata"
+	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/version"
+)
+
+// BundlePath is the HTTP path to download the support bundles.
+const BundlePath = "/debug/bundle"
+
+const maxBundleCPUSeconds = 60
+
+// The profiles attached to the support bundles, the goroutines are dumped in the text form to be read without the tools.
+var bundleProfiles = []struct {
+	name  string
+	file  string
+	debug int
+}{
+	{name: "goroutine", file: "goroutine.txt", debug: 2},
+	{name: "heap", file: "heap.pb.gz"},
+	{name: "allocs", file: "allocs.pb.gz"},
+	{name: "block", file: "block.pb.gz"},
+	{name: "mutex", file: "mutex.pb.gz"},
+	{name: "threadcreate", file: "threadcreate.pb.gz"},
+}
+
+// BundleRequest selects the content of a support bundle.
+type BundleRequest struct {
+	// Groups select the groups whose part metadata is attached. The storage usage covers all the groups if it's empty.
+	Groups []string
+	// CPUProfile is the duration of the CPU profile, which is skipped if it's zero.
+	CPUProfile time.Duration
+}
+
+// BundleWriter adds the files to a support bundle.
+type BundleWriter interface {
+	WriteFile(name string, data []byte) error
+}
+
+// BundleContributor adds its diagnostics to the support bundles, e.g. the schemas or the storage state of a node.
+type BundleContributor interface {
+	Bundle(ctx context.Context, req BundleRequest, w BundleWriter) error
+}
+
+// BundleContributorFunc adapts a function to a BundleContributor.
+type BundleContributorFunc func(ctx context.Context, req BundleRequest, w BundleWriter) error
+
+// Bundle calls f.
+func (f BundleContributorFunc) Bundle(ctx context.Context, req BundleRequest, w BundleWriter) error {
+	return f(ctx, req, w)
+}
+
+type tarBundleWriter struct {
+	tw     *tar.Writer
+	prefix string
+	now    time.Time
+}
+
+func (w *tarBundleWriter) WriteFile(name string, data []byte) error {
+	if err := w.tw.WriteHeader(&tar.Header{
+		Name:    w.prefix + name,
+		Mode:    0o644,
+		Size:    int64(len(data)),
+		ModTime: w.now,
+	}); err != nil {
+		return err
+	}
+	_, err := w.tw.Write(data)
+	return err
+}
+
+// bundleHandler packages the profiles, the recent logs and the diagnostics of the contributors
+// into a gzipped tarball. The failures of the contributors are listed in errors.txt instead of failing the download,
+// since a partial bundle is still useful for the bug reports.
+type bundleHandler struct {
+	l            *logger.Logger
+	contributors []BundleContributor
+}
+
+func (h *bundleHandler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(rw, "only GET is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	req, err := parseBundleRequest(r)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+	now := time.Now()
+	hostname, _ := os.Hostname()
+	name := fmt.Sprintf("banyandb-bundle-%s-%s", hostname, now.UTC().Format("20060102T150405Z"))
+	rw.Header().Set("Content-Type", "application/gzip")
+	rw.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+".tar.gz"))
+	gw := gzip.NewWriter(rw)
+	tw := tar.NewWriter(gw)
+	w := &tarBundleWriter{tw: tw, prefix: name + "/", now: now}
+	var failures []string
+	record := func(what string, err error) {
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", what, err))
+		}
+	}
+	record("info", writeBundleJSON(w, "info.json", map[string]any{
+		"version":    version.Build(),
+		"go_version": runtime.Version(),
+		"hostname":   hostname,
+		"time":       now.Format(time.RFC3339Nano),
+		"goroutines": runtime.NumGoroutine(),
+		"groups":     req.Groups,
+	}))
+	record("logs", w.WriteFile("logs/recent.log", logger.RecentLogs()))
+	for _, p := range bundleProfiles {
+		record("profile "+p.name, writeProfile(w, p.name, p.file, p.debug))
+	}
+	if req.CPUProfile > 0 {
+		record("profile cpu", writeCPUProfile(r.Context(), w, req.CPUProfile))
+	}
+	for _, c := range h.contributors {
+		record("contributor", c.Bundle(r.Context(), req, w))
+	}
+	if len(failures) > 0 {
+		record("errors", w.WriteFile("errors.txt", []byte(strings.Join(failures, "\n")+"\n")))
+		h.l.Warn().Strs("errors", failures).Msg("the support bundle is incomplete")
+	}
+	if err = multierr.Combine(tw.Close(), gw.Close()); err != nil {
+		h.l.Error().Err(err).Msg("failed to write the support bundle")
+	}
+}
+
+func parseBundleRequest(r *http.Request) (BundleRequest, error) {
+	q := r.URL.Query()
+	req := BundleRequest{Groups: q["group"]}
+	if s := q.Get("cpu_seconds"); s != "" {
+		seconds, err := strconv.Atoi(s)
+		if err != nil || seconds < 0 || seconds > maxBundleCPUSeconds {
+			return req, fmt.Errorf("cpu_seconds should be an integer in [0, %d]", maxBundleCPUSeconds)
+		}
+		req.CPUProfile = time.Duration(seconds) * time.Second
+	}
+	return req, nil
+}
+
+func writeProfile(w BundleWriter, name, file string, debug int) error {
+	p := pprof.Lookup(name)
+	if p == nil {
+		return fmt.Errorf("unknown profile %s", name)
+	}
+	var buf bytes.Buffer
+	if err := p.WriteTo(&buf, debug); err != nil {
+		return err
+	}
+	return w.WriteFile("profiles/"+file, buf.Bytes())
+}
+
+func writeCPUProfile(ctx context.Context, w BundleWriter, d time.Duration) error {
+	var buf bytes.Buffer
+	if err := pprof.StartCPUProfile(&buf); err != nil {
+		return err
+	}
+	select {
+	case <-time.After(d):
+	case <-ctx.Done():
+	}
+	pprof.StopCPUProfile()
+	return w.WriteFile("profiles/cpu.pb.gz", buf.Bytes())
+}
+
+func writeBundleJSON(w BundleWriter, name string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return w.WriteFile(name, data)
+}
+
+// WriteBundleProtos writes the messages as a JSON array to the bundle.
+func WriteBundleProtos[T proto.Message](w BundleWriter, name string, messages []T) error {
+	raw := make([]json.RawMessage, 0, len(messages))
+	for _, m := range messages {
+		data, err := protojson.Marshal(m)
+		if err != nil {
+			return err
+		}
+		raw = append(raw, data)
+	}
+	return writeBundleJSON(w, name, raw)
+}
+
+// NewSchemaBundleContributor snapshots the schemas of all the groups into the support bundles.
+func NewSchemaBundleContributor(repo metadata.Repo) BundleContributor {
+	return BundleContributorFunc(func(ctx context.Context, _ BundleRequest, w BundleWriter) error {
+		groups, err := repo.GroupRegistry().ListGroup(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list the groups: %w", err)
+		}
+		err = WriteBundleProtos(w, "schema/groups.json", groups)
+		for _, g := range groups {
+			opt := schema.ListOpt{Group: g.GetMetadata().GetName()}
+			prefix := "schema/" + opt.Group + "/"
+			err = multierr.Combine(err,
+				writeBundleList(w, prefix+"streams.json", func() ([]*databasev1.Stream, error) {
+					return repo.StreamRegistry().ListStream(ctx, opt)
+				}),
+				writeBundleList(w, prefix+"measures.json", func() ([]*databasev1.Measure, error) {
+					return repo.MeasureRegistry().ListMeasure(ctx, opt)
+				}),
+				writeBundleList(w, prefix+"index_rules.json", func() ([]*databasev1.IndexRule, error) {
+					return repo.IndexRuleRegistry().ListIndexRule(ctx, opt)
+				}),
+				writeBundleList(w, prefix+"index_rule_bindings.json", func() ([]*databasev1.IndexRuleBinding, error) {
+					return repo.IndexRuleBindingRegistry().ListIndexRuleBinding(ctx, opt)
+				}),
+				writeBundleList(w, prefix+"topn_aggregations.json", func() ([]*databasev1.TopNAggregation, error) {
+					return repo.TopNAggregationRegistry().ListTopNAggregation(ctx, opt)
+				}),
+			)
+		}
+		return err
+	})
+}
+
+func writeBundleList[T proto.Message](w BundleWriter, name string, list func() ([]T, error)) error {
+	messages, err := list()
+	if err != nil {
+		return fmt.Errorf("failed to list %s: %w", name, err)
+	}
+	return WriteBundleProtos(w, name, messages)
+}
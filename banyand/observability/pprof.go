@@ -23,6 +23,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/apache/skywalking-banyandb/pkg/fault"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
 	"github.com/apache/skywalking-banyandb/pkg/run"
 )
@@ -32,19 +33,21 @@ var (
 	_ run.Config  = (*pprofService)(nil)
 )
 
-// NewProfService returns a pprof service.
-func NewProfService() run.Service {
+// NewProfService returns a pprof service, which also serves the support bundles with the diagnostics of the contributors.
+func NewProfService(contributors ...BundleContributor) run.Service {
 	return &pprofService{
-		closer: run.NewCloser(1),
+		closer:       run.NewCloser(1),
+		contributors: contributors,
 	}
 }
 
 type pprofService struct {
-	l          *logger.Logger
-	svr        *http.Server
-	closer     *run.Closer
-	listenAddr string
-	svrMux     sync.Mutex
+	l            *logger.Logger
+	svr          *http.Server
+	closer       *run.Closer
+	listenAddr   string
+	contributors []BundleContributor
+	svrMux       sync.Mutex
 }
 
 func (p *pprofService) FlagSet() *run.FlagSet {
@@ -72,6 +75,9 @@ func (p *pprofService) Serve() run.StopNotify {
 	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
 	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
 	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc(fault.Path, fault.Serve)
+	mux.HandleFunc(fault.Path+"/clock", fault.Serve)
+	mux.Handle(BundlePath, &bundleHandler{l: p.l, contributors: p.contributors})
 	p.svrMux.Lock()
 	defer p.svrMux.Unlock()
 	p.svr = &http.Server{
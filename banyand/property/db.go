@@ -20,6 +20,7 @@ package property
 import (
 	"context"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -28,6 +29,7 @@ import (
 
 	"github.com/pkg/errors"
 	"go.uber.org/multierr"
+	"google.golang.org/protobuf/encoding/protojson"
 
 	"github.com/apache/skywalking-banyandb/api/common"
 	propertyv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/property/v1"
@@ -157,14 +159,57 @@ func (db *database) query(ctx context.Context, req *propertyv1.QueryRequest) ([]
 	if sLst == nil {
 		return nil, nil
 	}
+	// the paged queries sort all the matched properties, then return the ones up to the page
+	// and the next one, which tells the liaison whether there are more.
+	// The others fetch the next one from every shard for the same reason.
+	// A zero limit returns all the matched properties.
+	paged := req.Offset > 0 || req.OrderBy != nil
+	var limit int
+	if req.Limit > 0 && !paged {
+		limit = int(req.Limit) + 1
+	}
 	var res []*queryProperty
 	for _, s := range *sLst {
-		r, err := s.search(ctx, iq, int(req.Limit))
+		r, err := s.search(ctx, iq, limit)
 		if err != nil {
 			return nil, err
 		}
 		res = append(res, r...)
 	}
+	if !paged {
+		return res, nil
+	}
+	n := len(res)
+	if req.Limit > 0 {
+		n = int(req.Offset) + int(req.Limit) + 1
+	}
+	return sortQueryProperties(res, req.OrderBy, n)
+}
+
+// sortQueryProperties sorts the properties by the order, and keeps the first n of them.
+func sortQueryProperties(qp []*queryProperty, order *propertyv1.QueryOrder, n int) ([]*queryProperty, error) {
+	type decoded struct {
+		qp *queryProperty
+		p  *propertyv1.Property
+	}
+	dd := make([]decoded, 0, len(qp))
+	for _, q := range qp {
+		var p propertyv1.Property
+		if err := protojson.Unmarshal(q.source, &p); err != nil {
+			return nil, errors.WithMessagef(err, "cannot decode the property %s", q.id)
+		}
+		dd = append(dd, decoded{qp: q, p: &p})
+	}
+	slices.SortStableFunc(dd, func(a, b decoded) int {
+		return CompareProperties(a.p, b.p, order)
+	})
+	if len(dd) > n {
+		dd = dd[:n]
+	}
+	res := make([]*queryProperty, 0, len(dd))
+	for _, d := range dd {
+		res = append(res, d.qp)
+	}
 	return res, nil
 }
 
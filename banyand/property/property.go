@@ -19,9 +19,13 @@
 package property
 
 import (
+	"bytes"
+	"cmp"
+	"slices"
 	"strconv"
 	"strings"
 
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
 	propertyv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/property/v1"
 	"github.com/apache/skywalking-banyandb/pkg/convert"
 	"github.com/apache/skywalking-banyandb/pkg/run"
@@ -43,3 +47,83 @@ func GetPropertyID(prop *propertyv1.Property) []byte {
 func GetEntity(prop *propertyv1.Property) string {
 	return strings.Join([]string{prop.Metadata.Group, prop.Metadata.Name, prop.Id}, "/")
 }
+
+// CompareProperties compares the properties by the tag of the order, then by their entities.
+// The properties without the tag are placed at the end whatever the direction is.
+func CompareProperties(a, b *propertyv1.Property, order *propertyv1.QueryOrder) int {
+	if order.GetTagName() != "" {
+		va, vb := findTagValue(a, order.TagName), findTagValue(b, order.TagName)
+		switch {
+		case va == nil && vb != nil:
+			return 1
+		case va != nil && vb == nil:
+			return -1
+		case va != nil && vb != nil:
+			c := compareTagValue(va, vb)
+			if order.Sort == modelv1.Sort_SORT_DESC {
+				c = -c
+			}
+			if c != 0 {
+				return c
+			}
+		}
+	}
+	return strings.Compare(GetEntity(a), GetEntity(b))
+}
+
+func findTagValue(p *propertyv1.Property, name string) *modelv1.TagValue {
+	for _, t := range p.Tags {
+		if t.Key != name {
+			continue
+		}
+		switch t.Value.GetValue().(type) {
+		case nil, *modelv1.TagValue_Null:
+			return nil
+		default:
+			return t.Value
+		}
+	}
+	return nil
+}
+
+// compareTagValue compares the values of the same type, and the values of different types are ordered by their types.
+func compareTagValue(a, b *modelv1.TagValue) int {
+	if c := cmp.Compare(tagValueRank(a), tagValueRank(b)); c != 0 {
+		return c
+	}
+	switch v := a.Value.(type) {
+	case *modelv1.TagValue_Int:
+		return cmp.Compare(v.Int.GetValue(), b.GetInt().GetValue())
+	case *modelv1.TagValue_Str:
+		return strings.Compare(v.Str.GetValue(), b.GetStr().GetValue())
+	case *modelv1.TagValue_BinaryData:
+		return bytes.Compare(v.BinaryData, b.GetBinaryData())
+	case *modelv1.TagValue_IntArray:
+		return slices.Compare(v.IntArray.GetValue(), b.GetIntArray().GetValue())
+	case *modelv1.TagValue_StrArray:
+		return slices.Compare(v.StrArray.GetValue(), b.GetStrArray().GetValue())
+	case *modelv1.TagValue_Timestamp:
+		return v.Timestamp.AsTime().Compare(b.GetTimestamp().AsTime())
+	default:
+		return 0
+	}
+}
+
+func tagValueRank(v *modelv1.TagValue) int {
+	switch v.Value.(type) {
+	case *modelv1.TagValue_Int:
+		return 0
+	case *modelv1.TagValue_Str:
+		return 1
+	case *modelv1.TagValue_BinaryData:
+		return 2
+	case *modelv1.TagValue_IntArray:
+		return 3
+	case *modelv1.TagValue_StrArray:
+		return 4
+	case *modelv1.TagValue_Timestamp:
+		return 5
+	default:
+		return 6
+	}
+}
@@ -161,6 +161,11 @@ func (s *service) GracefulStop() {
 	}
 }
 
+// ShutdownPhase implements run.PhasedService.
+func (s *service) ShutdownPhase() run.ShutdownPhase {
+	return run.ShutdownPhaseStorage
+}
+
 // NewService returns a new service.
 func NewService(metadata metadata.Repo, pipeline queue.Server, omr observability.MetricsRegistry, pm protector.Memory) (Service, error) {
 	return &service{
@@ -0,0 +1,321 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package protector
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/apache/skywalking-banyandb/banyand/observability"
+	"github.com/apache/skywalking-banyandb/pkg/fs"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/meter"
+	"github.com/apache/skywalking-banyandb/pkg/run"
+)
+
+var (
+	fdScope = observability.RootScope.SubScope("fd_protector")
+
+	errFileClosed = errors.New("the file is closed")
+)
+
+// FD is a protector that bounds the file descriptors held by the part readers on the node.
+type FD interface {
+	// Wrap returns the file system whose opened files are counted in the budget under the group and the segment.
+	Wrap(fileSystem fs.FileSystem, group, segment string) fs.FileSystem
+	run.PreRunner
+	run.Config
+}
+
+var _ FD = (*fdBudget)(nil)
+
+type fdLabels struct {
+	group   string
+	segment string
+}
+
+// fdBudget closes the idle files in the least recently used order once the open files exceed the limit,
+// and the closed files are reopened by their next reads. The files being read are never closed.
+type fdBudget struct {
+	l         *logger.Logger
+	openGauge meter.Gauge
+	evicted   meter.Counter
+	limitG    meter.Gauge
+	lru       *list.List
+	open      map[fdLabels]int
+	limit     int
+	mu        sync.Mutex
+}
+
+// NewFD creates a new FD protector.
+func NewFD(omr observability.MetricsRegistry) FD {
+	factory := omr.With(fdScope)
+	return &fdBudget{
+		openGauge: factory.NewGauge("open_files", "group", "segment"),
+		evicted:   factory.NewCounter("evicted_files", "group"),
+		limitG:    factory.NewGauge("limit"),
+		lru:       list.New(),
+		open:      make(map[fdLabels]int),
+	}
+}
+
+// Name returns the name of the protector.
+func (b *fdBudget) Name() string {
+	return "fd-protector"
+}
+
+// FlagSet returns the flag set for the protector.
+func (b *fdBudget) FlagSet() *run.FlagSet {
+	flagS := run.NewFlagSet(b.Name())
+	flagS.IntVarP(&b.limit, "max-open-part-files", "", 0,
+		"the maximum number of the files held open by the parts of the streams and the measures, "+
+			"the idle ones are closed in the least recently used order once it's exceeded, 0 means unlimited")
+	return flagS
+}
+
+// Validate validates the protector's flags.
+func (b *fdBudget) Validate() error {
+	if b.limit < 0 {
+		return errors.New("max-open-part-files must not be negative")
+	}
+	return nil
+}
+
+// PreRun initializes the protector.
+func (b *fdBudget) PreRun(context.Context) error {
+	b.l = logger.GetLogger(b.Name())
+	b.limitG.Set(float64(b.limit))
+	if b.limit > 0 {
+		b.l.Info().Int("limit", b.limit).Msg("fd protector enabled")
+	}
+	return nil
+}
+
+func (b *fdBudget) Wrap(fileSystem fs.FileSystem, group, segment string) fs.FileSystem {
+	return &budgetedFileSystem{FileSystem: fileSystem, b: b, labels: fdLabels{group: group, segment: segment}}
+}
+
+// acquire pins the file open until it's released.
+func (b *fdBudget) acquire(f *budgetedFile) (fs.File, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if f.closed {
+		return nil, fmt.Errorf("%s: %w", f.name, errFileClosed)
+	}
+	if f.f == nil {
+		file, err := f.fileSystem.OpenFile(f.name)
+		if err != nil {
+			return nil, err
+		}
+		b.track(f, file)
+	} else {
+		b.lru.MoveToFront(f.elem)
+	}
+	f.refs++
+	b.evict()
+	return f.f, nil
+}
+
+func (b *fdBudget) release(f *budgetedFile) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	f.refs--
+	if f.closed && f.refs == 0 && f.f != nil {
+		// the file is closed while it's being read.
+		if err := b.untrack(f); err != nil && b.l != nil {
+			b.l.Warn().Err(err).Str("path", f.name).Msg("failed to close the file")
+		}
+		return
+	}
+	b.evict()
+}
+
+func (b *fdBudget) track(f *budgetedFile, file fs.File) {
+	f.f = file
+	f.elem = b.lru.PushFront(f)
+	b.open[f.labels]++
+	b.openGauge.Set(float64(b.open[f.labels]), f.labels.group, f.labels.segment)
+}
+
+func (b *fdBudget) untrack(f *budgetedFile) error {
+	err := f.f.Close()
+	f.f = nil
+	b.lru.Remove(f.elem)
+	f.elem = nil
+	b.open[f.labels]--
+	if b.open[f.labels] > 0 {
+		b.openGauge.Set(float64(b.open[f.labels]), f.labels.group, f.labels.segment)
+		return err
+	}
+	delete(b.open, f.labels)
+	b.openGauge.Delete(f.labels.group, f.labels.segment)
+	return err
+}
+
+// evict closes the least recently used idle files until the open files fit the limit.
+func (b *fdBudget) evict() {
+	if b.limit <= 0 {
+		return
+	}
+	for e := b.lru.Back(); e != nil && b.lru.Len() > b.limit; {
+		f := e.Value.(*budgetedFile)
+		e = e.Prev()
+		if f.refs > 0 {
+			continue
+		}
+		if err := b.untrack(f); err != nil && b.l != nil {
+			b.l.Warn().Err(err).Str("path", f.name).Msg("failed to close the idle file")
+		}
+		b.evicted.Inc(1, f.labels.group)
+	}
+}
+
+type budgetedFileSystem struct {
+	fs.FileSystem
+	b      *fdBudget
+	labels fdLabels
+}
+
+// OpenFile opens the file at once to report the errors, and the file might be closed by the budget later.
+func (bfs *budgetedFileSystem) OpenFile(name string) (fs.File, error) {
+	file, err := bfs.FileSystem.OpenFile(name)
+	if err != nil {
+		return nil, err
+	}
+	f := &budgetedFile{fileSystem: bfs.FileSystem, name: name, labels: bfs.labels, b: bfs.b}
+	bfs.b.mu.Lock()
+	bfs.b.track(f, file)
+	bfs.b.evict()
+	bfs.b.mu.Unlock()
+	return f, nil
+}
+
+type budgetedFile struct {
+	fileSystem fs.FileSystem
+	f          fs.File
+	b          *fdBudget
+	elem       *list.Element
+	labels     fdLabels
+	name       string
+	refs       int
+	closed     bool
+}
+
+func (f *budgetedFile) Write(buffer []byte) (int, error) {
+	file, err := f.b.acquire(f)
+	if err != nil {
+		return 0, err
+	}
+	defer f.b.release(f)
+	return file.Write(buffer)
+}
+
+func (f *budgetedFile) Writev(iov *[][]byte) (int, error) {
+	file, err := f.b.acquire(f)
+	if err != nil {
+		return 0, err
+	}
+	defer f.b.release(f)
+	return file.Writev(iov)
+}
+
+func (f *budgetedFile) SequentialWrite() fs.SeqWriter {
+	file, err := f.b.acquire(f)
+	if err != nil {
+		logger.Panicf("cannot write %s: %s", f.name, err)
+	}
+	return &budgetedSeqWriter{SeqWriter: file.SequentialWrite(), f: f}
+}
+
+func (f *budgetedFile) Read(offset int64, buffer []byte) (int, error) {
+	file, err := f.b.acquire(f)
+	if err != nil {
+		return 0, err
+	}
+	defer f.b.release(f)
+	return file.Read(offset, buffer)
+}
+
+func (f *budgetedFile) Readv(offset int64, iov *[][]byte) (int, error) {
+	file, err := f.b.acquire(f)
+	if err != nil {
+		return 0, err
+	}
+	defer f.b.release(f)
+	return file.Readv(offset, iov)
+}
+
+// SequentialRead pins the file open until the returned reader is closed.
+func (f *budgetedFile) SequentialRead() fs.SeqReader {
+	file, err := f.b.acquire(f)
+	if err != nil {
+		logger.Panicf("cannot read %s: %s", f.name, err)
+	}
+	return &budgetedSeqReader{SeqReader: file.SequentialRead(), f: f}
+}
+
+func (f *budgetedFile) Size() (int64, error) {
+	file, err := f.b.acquire(f)
+	if err != nil {
+		return 0, err
+	}
+	defer f.b.release(f)
+	return file.Size()
+}
+
+func (f *budgetedFile) Path() string {
+	return f.name
+}
+
+func (f *budgetedFile) Close() error {
+	f.b.mu.Lock()
+	defer f.b.mu.Unlock()
+	if f.closed {
+		return nil
+	}
+	f.closed = true
+	if f.f == nil || f.refs > 0 {
+		return nil
+	}
+	return f.b.untrack(f)
+}
+
+type budgetedSeqReader struct {
+	fs.SeqReader
+	f *budgetedFile
+}
+
+func (r *budgetedSeqReader) Close() error {
+	err := r.SeqReader.Close()
+	r.f.b.release(r.f)
+	return err
+}
+
+type budgetedSeqWriter struct {
+	fs.SeqWriter
+	f *budgetedFile
+}
+
+func (w *budgetedSeqWriter) Close() error {
+	err := w.SeqWriter.Close()
+	w.f.b.release(w.f)
+	return err
+}
@@ -0,0 +1,128 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package protector
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/skywalking-banyandb/banyand/observability"
+	"github.com/apache/skywalking-banyandb/pkg/fs"
+	"github.com/apache/skywalking-banyandb/pkg/test"
+)
+
+func newTestFD(t *testing.T, limit int) *fdBudget {
+	b := NewFD(observability.BypassRegistry).(*fdBudget)
+	b.limit = limit
+	require.NoError(t, b.Validate())
+	require.NoError(t, b.PreRun(context.Background()))
+	return b
+}
+
+func writeFiles(t *testing.T, fileSystem fs.FileSystem, dir string, names ...string) {
+	for _, name := range names {
+		fs.MustFlush(fileSystem, []byte(name), filepath.Join(dir, name), 0o600)
+	}
+}
+
+func TestFDEvictIdleFiles(t *testing.T) {
+	dir, defFn := test.Space(require.New(t))
+	defer defFn()
+	lfs := fs.NewLocalFileSystem()
+	writeFiles(t, lfs, dir, "a", "b", "c")
+	b := newTestFD(t, 2)
+	fileSystem := b.Wrap(lfs, "sw_metric", "seg-1")
+
+	files := make([]fs.File, 0, 3)
+	for _, name := range []string{"a", "b", "c"} {
+		f, err := fileSystem.OpenFile(filepath.Join(dir, name))
+		require.NoError(t, err)
+		files = append(files, f)
+	}
+	assert.Equal(t, 2, b.lru.Len())
+	assert.Nil(t, files[0].(*budgetedFile).f, "the least recently used file is closed")
+	assert.Equal(t, 2, b.open[fdLabels{group: "sw_metric", segment: "seg-1"}])
+
+	buf := make([]byte, 1)
+	_, err := files[0].Read(0, buf)
+	require.NoError(t, err, "the closed file is reopened by the read")
+	assert.Equal(t, "a", string(buf))
+	assert.Equal(t, 2, b.lru.Len())
+	assert.Nil(t, files[1].(*budgetedFile).f)
+
+	for _, f := range files {
+		require.NoError(t, f.Close())
+	}
+	assert.Zero(t, b.lru.Len())
+	assert.Empty(t, b.open)
+	_, err = files[0].Read(0, buf)
+	assert.ErrorIs(t, err, errFileClosed)
+}
+
+func TestFDKeepFilesInUse(t *testing.T) {
+	dir, defFn := test.Space(require.New(t))
+	defer defFn()
+	lfs := fs.NewLocalFileSystem()
+	writeFiles(t, lfs, dir, "a", "b")
+	b := newTestFD(t, 1)
+	fileSystem := b.Wrap(lfs, "sw_record", "seg-1")
+
+	a, err := fileSystem.OpenFile(filepath.Join(dir, "a"))
+	require.NoError(t, err)
+	seq := a.SequentialRead()
+	bf, err := fileSystem.OpenFile(filepath.Join(dir, "b"))
+	require.NoError(t, err)
+	assert.NotNil(t, a.(*budgetedFile).f, "the file being read is kept open")
+	assert.Nil(t, bf.(*budgetedFile).f, "the idle file is closed over the limit")
+
+	require.NoError(t, a.Close())
+	data, err := io.ReadAll(seq)
+	require.NoError(t, err, "the file closed while it's being read is closed by the reader")
+	assert.Equal(t, "a", string(data))
+	require.NoError(t, seq.Close())
+	assert.Nil(t, a.(*budgetedFile).f)
+	assert.Zero(t, b.lru.Len())
+	require.NoError(t, bf.Close())
+}
+
+func TestFDUnlimited(t *testing.T) {
+	dir, defFn := test.Space(require.New(t))
+	defer defFn()
+	lfs := fs.NewLocalFileSystem()
+	writeFiles(t, lfs, dir, "a", "b")
+	b := newTestFD(t, 0)
+	fileSystem := b.Wrap(lfs, "sw_metric", "seg-1")
+	files := make([]fs.File, 0, 2)
+	for _, name := range []string{"a", "b"} {
+		f, err := fileSystem.OpenFile(filepath.Join(dir, name))
+		require.NoError(t, err)
+		files = append(files, f)
+	}
+	assert.Equal(t, 2, b.lru.Len())
+	for _, f := range files {
+		require.NoError(t, f.Close())
+	}
+
+	b.limit = -1
+	assert.Error(t, b.Validate())
+}
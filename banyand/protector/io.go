@@ -0,0 +1,230 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package protector
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/apache/skywalking-banyandb/banyand/observability"
+	"github.com/apache/skywalking-banyandb/pkg/fs"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/meter"
+	"github.com/apache/skywalking-banyandb/pkg/run"
+)
+
+var ioScope = observability.RootScope.SubScope("io_scheduler")
+
+// IOPriority is the priority of the reads in the IO scheduler.
+type IOPriority int
+
+// The priorities of the reads.
+const (
+	// IOPriorityInteractive denotes the random reads of the queries.
+	IOPriorityInteractive IOPriority = iota
+	// IOPriorityBackground denotes the sequential reads of the merges and the part loading.
+	IOPriorityBackground
+)
+
+func (p IOPriority) String() string {
+	if p == IOPriorityInteractive {
+		return "interactive"
+	}
+	return "background"
+}
+
+// IO is a protector that schedules the reads of the parts, which serves the interactive query reads
+// ahead of the background ones to keep the query latency steady during the merges.
+type IO interface {
+	// Wrap returns the file system whose reads are scheduled.
+	Wrap(fileSystem fs.FileSystem) fs.FileSystem
+	run.PreRunner
+	run.Config
+}
+
+var _ IO = (*ioScheduler)(nil)
+
+type ioWaiter struct {
+	ready    chan struct{}
+	deadline time.Time
+}
+
+// ioScheduler grants a limited number of tokens to the concurrent reads.
+// The waiting interactive reads take the released tokens first, unless a background read has waited past its deadline,
+// which keeps the merges from starving.
+type ioScheduler struct {
+	l                 *logger.Logger
+	reads             meter.Counter
+	waiting           meter.Gauge
+	waitSeconds       meter.Histogram
+	interactive       *list.List
+	background        *list.List
+	backgroundMaxWait time.Duration
+	tokens            int
+	inUse             int
+	mu                sync.Mutex
+}
+
+// NewIO creates a new IO protector.
+func NewIO(omr observability.MetricsRegistry) IO {
+	factory := omr.With(ioScope)
+	return &ioScheduler{
+		reads:       factory.NewCounter("reads", "priority"),
+		waiting:     factory.NewGauge("waiting_reads", "priority"),
+		waitSeconds: factory.NewHistogram("wait_seconds", meter.DefBuckets, "priority"),
+		interactive: list.New(),
+		background:  list.New(),
+	}
+}
+
+// Name returns the name of the protector.
+func (s *ioScheduler) Name() string {
+	return "io-protector"
+}
+
+// FlagSet returns the flag set for the protector.
+func (s *ioScheduler) FlagSet() *run.FlagSet {
+	flagS := run.NewFlagSet(s.Name())
+	flagS.IntVarP(&s.tokens, "io-max-concurrent-reads", "", 0,
+		"the maximum number of the concurrent reads of the parts, the query reads are served ahead of the merge reads once it's reached, "+
+			"0 means unlimited")
+	flagS.DurationVarP(&s.backgroundMaxWait, "io-background-max-wait", "", time.Second,
+		"the deadline of the merge reads, they are served ahead of the query reads once they have waited longer than it")
+	return flagS
+}
+
+// Validate validates the protector's flags.
+func (s *ioScheduler) Validate() error {
+	if s.tokens < 0 {
+		return errors.New("io-max-concurrent-reads must not be negative")
+	}
+	if s.backgroundMaxWait <= 0 {
+		return errors.New("io-background-max-wait must be positive")
+	}
+	return nil
+}
+
+// PreRun initializes the protector.
+func (s *ioScheduler) PreRun(context.Context) error {
+	s.l = logger.GetLogger(s.Name())
+	if s.tokens > 0 {
+		s.l.Info().Int("tokens", s.tokens).Dur("background_max_wait", s.backgroundMaxWait).Msg("io scheduler enabled")
+	}
+	return nil
+}
+
+func (s *ioScheduler) Wrap(fileSystem fs.FileSystem) fs.FileSystem {
+	if s.tokens <= 0 {
+		return fileSystem
+	}
+	return &scheduledFileSystem{FileSystem: fileSystem, s: s}
+}
+
+// acquire blocks until the read takes a token.
+func (s *ioScheduler) acquire(priority IOPriority) {
+	s.reads.Inc(1, priority.String())
+	s.mu.Lock()
+	if s.inUse < s.tokens && s.interactive.Len() == 0 && (priority == IOPriorityInteractive || s.background.Len() == 0) {
+		s.inUse++
+		s.mu.Unlock()
+		return
+	}
+	start := time.Now()
+	w := &ioWaiter{ready: make(chan struct{}), deadline: start.Add(s.backgroundMaxWait)}
+	queue := s.queue(priority)
+	queue.PushBack(w)
+	s.waiting.Set(float64(queue.Len()), priority.String())
+	s.mu.Unlock()
+	<-w.ready
+	s.waitSeconds.Observe(time.Since(start).Seconds(), priority.String())
+}
+
+// release hands the token over to the next waiting read.
+func (s *ioScheduler) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	priority := IOPriorityInteractive
+	if e := s.background.Front(); e != nil && (s.interactive.Len() == 0 || !time.Now().Before(e.Value.(*ioWaiter).deadline)) {
+		priority = IOPriorityBackground
+	}
+	queue := s.queue(priority)
+	e := queue.Front()
+	if e == nil {
+		s.inUse--
+		return
+	}
+	queue.Remove(e)
+	s.waiting.Set(float64(queue.Len()), priority.String())
+	close(e.Value.(*ioWaiter).ready)
+}
+
+func (s *ioScheduler) queue(priority IOPriority) *list.List {
+	if priority == IOPriorityInteractive {
+		return s.interactive
+	}
+	return s.background
+}
+
+type scheduledFileSystem struct {
+	fs.FileSystem
+	s *ioScheduler
+}
+
+func (sfs *scheduledFileSystem) OpenFile(name string) (fs.File, error) {
+	f, err := sfs.FileSystem.OpenFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return &scheduledFile{File: f, s: sfs.s}, nil
+}
+
+// scheduledFile schedules the random reads as the interactive ones and the sequential reads as the background ones.
+type scheduledFile struct {
+	fs.File
+	s *ioScheduler
+}
+
+func (f *scheduledFile) Read(offset int64, buffer []byte) (int, error) {
+	f.s.acquire(IOPriorityInteractive)
+	defer f.s.release()
+	return f.File.Read(offset, buffer)
+}
+
+func (f *scheduledFile) Readv(offset int64, iov *[][]byte) (int, error) {
+	f.s.acquire(IOPriorityInteractive)
+	defer f.s.release()
+	return f.File.Readv(offset, iov)
+}
+
+func (f *scheduledFile) SequentialRead() fs.SeqReader {
+	return &scheduledSeqReader{SeqReader: f.File.SequentialRead(), s: f.s}
+}
+
+type scheduledSeqReader struct {
+	fs.SeqReader
+	s *ioScheduler
+}
+
+func (r *scheduledSeqReader) Read(p []byte) (int, error) {
+	r.s.acquire(IOPriorityBackground)
+	defer r.s.release()
+	return r.SeqReader.Read(p)
+}
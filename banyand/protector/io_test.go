@@ -0,0 +1,136 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package protector
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/skywalking-banyandb/banyand/observability"
+	"github.com/apache/skywalking-banyandb/pkg/fs"
+	"github.com/apache/skywalking-banyandb/pkg/test"
+)
+
+func newTestIO(t *testing.T, tokens int, backgroundMaxWait time.Duration) *ioScheduler {
+	s := NewIO(observability.BypassRegistry).(*ioScheduler)
+	s.tokens = tokens
+	s.backgroundMaxWait = backgroundMaxWait
+	require.NoError(t, s.Validate())
+	require.NoError(t, s.PreRun(context.Background()))
+	return s
+}
+
+func waitQueued(t *testing.T, s *ioScheduler, interactive, background int) {
+	require.Eventually(t, func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.interactive.Len() == interactive && s.background.Len() == background
+	}, 5*time.Second, time.Millisecond)
+}
+
+func TestIOSchedulerPrioritizesInteractiveReads(t *testing.T) {
+	s := newTestIO(t, 1, time.Hour)
+	s.acquire(IOPriorityInteractive)
+
+	var mu sync.Mutex
+	var order []IOPriority
+	var wg sync.WaitGroup
+	read := func(priority IOPriority) {
+		defer wg.Done()
+		s.acquire(priority)
+		mu.Lock()
+		order = append(order, priority)
+		mu.Unlock()
+		s.release()
+	}
+	wg.Add(3)
+	go read(IOPriorityBackground)
+	waitQueued(t, s, 0, 1)
+	go read(IOPriorityInteractive)
+	waitQueued(t, s, 1, 1)
+	go read(IOPriorityInteractive)
+	waitQueued(t, s, 2, 1)
+
+	s.release()
+	wg.Wait()
+	assert.Equal(t, []IOPriority{IOPriorityInteractive, IOPriorityInteractive, IOPriorityBackground}, order)
+	assert.Zero(t, s.inUse)
+}
+
+func TestIOSchedulerBackgroundDeadline(t *testing.T) {
+	s := newTestIO(t, 1, time.Millisecond)
+	s.acquire(IOPriorityInteractive)
+
+	var mu sync.Mutex
+	var order []IOPriority
+	var wg sync.WaitGroup
+	read := func(priority IOPriority) {
+		defer wg.Done()
+		s.acquire(priority)
+		mu.Lock()
+		order = append(order, priority)
+		mu.Unlock()
+		s.release()
+	}
+	wg.Add(2)
+	go read(IOPriorityBackground)
+	waitQueued(t, s, 0, 1)
+	go read(IOPriorityInteractive)
+	waitQueued(t, s, 1, 1)
+	time.Sleep(5 * time.Millisecond)
+
+	s.release()
+	wg.Wait()
+	assert.Equal(t, []IOPriority{IOPriorityBackground, IOPriorityInteractive}, order, "the overdue background read goes first")
+}
+
+func TestIOSchedulerWrap(t *testing.T) {
+	dir, defFn := test.Space(require.New(t))
+	defer defFn()
+	lfs := fs.NewLocalFileSystem()
+	fs.MustFlush(lfs, []byte("data"), filepath.Join(dir, "a"), 0o600)
+
+	assert.Equal(t, lfs, newTestIO(t, 0, time.Second).Wrap(lfs), "the unlimited scheduler doesn't wrap the file system")
+
+	s := newTestIO(t, 1, time.Second)
+	f, err := s.Wrap(lfs).OpenFile(filepath.Join(dir, "a"))
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, f.Close())
+	}()
+	buf := make([]byte, 2)
+	_, err = f.Read(2, buf)
+	require.NoError(t, err)
+	assert.Equal(t, "ta", string(buf))
+	seq := f.SequentialRead()
+	data, err := io.ReadAll(seq)
+	require.NoError(t, err)
+	assert.Equal(t, "data", string(data))
+	require.NoError(t, seq.Close())
+	assert.Zero(t, s.inUse, "the tokens are released")
+
+	s.tokens = -1
+	assert.Error(t, s.Validate())
+}
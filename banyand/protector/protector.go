@@ -80,11 +80,31 @@ func NewMemory(omr observability.MetricsRegistry) Memory {
 	}
 }
 
+type budgetKey struct{}
+
+// WithBudget returns a context whose resource acquisitions are limited to the `percent` of the memory limit.
+// It lets the queries of lower classes back off earlier than the others under the memory pressure.
+func WithBudget(ctx context.Context, percent int) context.Context {
+	if percent <= 0 || percent >= 100 {
+		return ctx
+	}
+	return context.WithValue(ctx, budgetKey{}, percent)
+}
+
+func (m *memory) budget(ctx context.Context) uint64 {
+	limit := m.limit.Load()
+	if percent, ok := ctx.Value(budgetKey{}).(int); ok {
+		return limit * uint64(percent) / 100
+	}
+	return limit
+}
+
 // AcquireResource attempts to acquire a `size` amount of memory.
 func (m *memory) AcquireResource(ctx context.Context, size uint64) error {
 	if m.limit.Load() == 0 {
 		return nil
 	}
+	limit := m.budget(ctx)
 	start := time.Now()
 
 	select {
@@ -96,7 +116,7 @@ func (m *memory) AcquireResource(ctx context.Context, size uint64) error {
 
 	for {
 		currentUsage := atomic.LoadUint64(&m.usage)
-		if currentUsage+size <= m.limit.Load() {
+		if currentUsage+size <= limit {
 			return nil
 		}
 
@@ -106,7 +126,7 @@ func (m *memory) AcquireResource(ctx context.Context, size uint64) error {
 		case <-ctx.Done():
 			return fmt.Errorf(
 				"context canceled: memory acquisition failed (currentUsage: %d, limit: %d, size: %d, blockedDuration: %v): %w",
-				currentUsage, m.limit.Load(), size, time.Since(start), ctx.Err(),
+				currentUsage, limit, size, time.Since(start), ctx.Err(),
 			)
 		}
 	}
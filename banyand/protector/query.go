@@ -0,0 +1,139 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package protector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+
+	"github.com/dustin/go-humanize"
+)
+
+// ErrQueryMemoryExceeded is returned and set as the cause of the canceled query once it allocates more memory than allowed.
+var ErrQueryMemoryExceeded = errors.New("the query exceeds the memory limit")
+
+// ErrQueryNotAdmitted is returned if the memory estimated for a query exceeds what the node affords before the query runs.
+var ErrQueryNotAdmitted = errors.New("the query isn't admitted")
+
+// QueryAccountant accounts the memory allocated by the running queries of a node, e.g. the decoded blocks,
+// against the per-query and the per-node limits.
+type QueryAccountant struct {
+	queryLimit uint64
+	nodeLimit  uint64
+	usage      atomic.Uint64
+}
+
+// NewQueryAccountant creates a new QueryAccountant. The zero limits are unlimited.
+func NewQueryAccountant(queryLimit, nodeLimit uint64) *QueryAccountant {
+	return &QueryAccountant{queryLimit: queryLimit, nodeLimit: nodeLimit}
+}
+
+// Usage returns the memory accounted to the running queries.
+func (a *QueryAccountant) Usage() uint64 {
+	return a.usage.Load()
+}
+
+type queryTrackerKey struct{}
+
+type queryTracker struct {
+	a        *QueryAccountant
+	cancel   context.CancelCauseFunc
+	usage    uint64
+	mu       sync.Mutex
+	released bool
+}
+
+// Track returns the context whose allocations are accounted to a query. The context is canceled with ErrQueryMemoryExceeded
+// once the query exceeds a limit. The returned function gives the memory of the query back when it finishes.
+func (a *QueryAccountant) Track(ctx context.Context) (context.Context, func()) {
+	if a == nil || (a.queryLimit == 0 && a.nodeLimit == 0) {
+		return ctx, func() {}
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	t := &queryTracker{a: a, cancel: cancel}
+	return context.WithValue(ctx, queryTrackerKey{}, t), func() {
+		t.mu.Lock()
+		t.released = true
+		a.usage.Add(-t.usage)
+		t.mu.Unlock()
+		cancel(nil)
+	}
+}
+
+// Account accounts the `size` bytes allocated by the query of the context.
+// It cancels the query and returns the error if the query or the node exceeds the limit.
+func Account(ctx context.Context, size uint64) error {
+	t, ok := ctx.Value(queryTrackerKey{}).(*queryTracker)
+	if !ok || size == 0 {
+		return nil
+	}
+	t.mu.Lock()
+	if t.released {
+		// the allocations of the finished query are not accounted any more.
+		t.mu.Unlock()
+		return nil
+	}
+	t.usage += size
+	usage := t.usage
+	nodeUsage := t.a.usage.Add(size)
+	t.mu.Unlock()
+	var err error
+	switch {
+	case t.a.queryLimit > 0 && usage > t.a.queryLimit:
+		err = fmt.Errorf("%w: the query allocated %s, the limit per query is %s",
+			ErrQueryMemoryExceeded, humanize.IBytes(usage), humanize.IBytes(t.a.queryLimit))
+	case t.a.nodeLimit > 0 && nodeUsage > t.a.nodeLimit:
+		err = fmt.Errorf("%w: the running queries allocated %s, the limit of the node is %s",
+			ErrQueryMemoryExceeded, humanize.IBytes(nodeUsage), humanize.IBytes(t.a.nodeLimit))
+	default:
+		return nil
+	}
+	t.cancel(err)
+	return err
+}
+
+// Available returns the memory the query of the context can allocate before it exceeds a limit.
+// It returns false if the query is unlimited.
+func Available(ctx context.Context) (uint64, bool) {
+	t, ok := ctx.Value(queryTrackerKey{}).(*queryTracker)
+	if !ok {
+		return 0, false
+	}
+	t.mu.Lock()
+	usage := t.usage
+	t.mu.Unlock()
+	available := uint64(math.MaxUint64)
+	if t.a.queryLimit > 0 {
+		available = remaining(t.a.queryLimit, usage)
+	}
+	if t.a.nodeLimit > 0 {
+		available = min(available, remaining(t.a.nodeLimit, t.a.usage.Load()))
+	}
+	return available, true
+}
+
+func remaining(limit, usage uint64) uint64 {
+	if usage >= limit {
+		return 0
+	}
+	return limit - usage
+}
@@ -0,0 +1,92 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package protector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryAccountantQueryLimit(t *testing.T) {
+	a := NewQueryAccountant(100, 0)
+	ctx, release := a.Track(context.Background())
+	require.NoError(t, Account(ctx, 60))
+	require.NoError(t, Account(ctx, 40))
+	assert.Equal(t, uint64(100), a.Usage())
+	err := Account(ctx, 1)
+	require.ErrorIs(t, err, ErrQueryMemoryExceeded)
+	assert.ErrorContains(t, err, "per query")
+	assert.ErrorIs(t, ctx.Err(), context.Canceled, "the query is canceled")
+	assert.ErrorIs(t, context.Cause(ctx), ErrQueryMemoryExceeded)
+
+	release()
+	assert.Zero(t, a.Usage())
+	require.NoError(t, Account(ctx, 1), "the finished query isn't accounted")
+	assert.Zero(t, a.Usage())
+}
+
+func TestQueryAccountantNodeLimit(t *testing.T) {
+	a := NewQueryAccountant(0, 100)
+	ctx1, release1 := a.Track(context.Background())
+	ctx2, release2 := a.Track(context.Background())
+	defer release2()
+	require.NoError(t, Account(ctx1, 80))
+	err := Account(ctx2, 30)
+	require.ErrorIs(t, err, ErrQueryMemoryExceeded)
+	assert.ErrorContains(t, err, "of the node")
+	assert.NoError(t, ctx1.Err(), "only the query exceeding the limit is canceled")
+	assert.Error(t, ctx2.Err())
+
+	release1()
+	assert.Equal(t, uint64(30), a.Usage())
+}
+
+func TestQueryAccountantUnlimited(t *testing.T) {
+	ctx := context.Background()
+	tracked, release := NewQueryAccountant(0, 0).Track(ctx)
+	defer release()
+	assert.Equal(t, ctx, tracked)
+	var a *QueryAccountant
+	tracked, release = a.Track(ctx)
+	defer release()
+	assert.Equal(t, ctx, tracked)
+	assert.NoError(t, Account(ctx, 1<<40), "the untracked query isn't limited")
+}
+
+func TestQueryAccountantAvailable(t *testing.T) {
+	_, limited := Available(context.Background())
+	assert.False(t, limited, "the untracked query is unlimited")
+
+	a := NewQueryAccountant(100, 150)
+	ctx1, release1 := a.Track(context.Background())
+	defer release1()
+	ctx2, release2 := a.Track(context.Background())
+	defer release2()
+	require.NoError(t, Account(ctx1, 30))
+	available, limited := Available(ctx1)
+	require.True(t, limited)
+	assert.Equal(t, uint64(70), available, "the limit per query applies")
+	require.NoError(t, Account(ctx2, 90))
+	available, _ = Available(ctx1)
+	assert.Equal(t, uint64(30), available, "the limit of the node applies")
+	available, _ = Available(ctx2)
+	assert.Equal(t, uint64(10), available)
+}
@@ -0,0 +1,82 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/dustin/go-humanize"
+
+	"github.com/apache/skywalking-banyandb/banyand/protector"
+	"github.com/apache/skywalking-banyandb/pkg/query"
+	"github.com/apache/skywalking-banyandb/pkg/run"
+)
+
+const (
+	admissionPolicyReject  = "reject"
+	admissionPolicyDegrade = "degrade"
+)
+
+// admission estimates the memory held by the group-by and the TopN queries from the cardinality of their group-by keys
+// in the series index, and rejects or degrades the queries the node can't afford before they run.
+// A degraded query reads the series of a deterministic sample of its groups, it neither spills nor approximates the aggregation.
+type admission struct {
+	policy     string
+	groupBytes run.Bytes
+}
+
+func (a *admission) flags(fs *run.FlagSet) {
+	fs.StringVar(&a.policy, "query-admission-policy", admissionPolicyReject,
+		"the policy of the group-by and TopN queries whose estimated memory exceeds the memory limits of the queries, "+
+			"\"reject\" rejects them, \"degrade\" reads the series of a deterministic sample of their groups")
+	a.groupBytes = 4 * 1024
+	fs.Var(&a.groupBytes, "query-admission-group-bytes",
+		"the memory estimated for a group of the group-by and TopN queries, 0 means the queries are always admitted")
+}
+
+func (a *admission) validate() error {
+	if a.policy != admissionPolicyReject && a.policy != admissionPolicyDegrade {
+		return fmt.Errorf("the admission policy %q is neither %q nor %q", a.policy, admissionPolicyReject, admissionPolicyDegrade)
+	}
+	if a.groupBytes < 0 {
+		return errors.New("the memory estimated for a group must not be negative")
+	}
+	return nil
+}
+
+// admit returns the admission of the groups read by the query of ctx, which compares their estimated memory
+// with the memory the query can allocate.
+func (a *admission) admit(ctx context.Context) query.SeriesAdmission {
+	return func(series, groups int) (int, error) {
+		available, limited := protector.Available(ctx)
+		if !limited || a.groupBytes == 0 {
+			return groups, nil
+		}
+		estimated := uint64(groups) * uint64(a.groupBytes)
+		if estimated <= available {
+			return groups, nil
+		}
+		if affordable := int(available / uint64(a.groupBytes)); a.policy == admissionPolicyDegrade && affordable > 0 {
+			return affordable, nil
+		}
+		return 0, fmt.Errorf("%w: %d groups of %d series need about %s, %s is available", protector.ErrQueryNotAdmitted,
+			groups, series, humanize.IBytes(estimated), humanize.IBytes(available))
+	}
+}
@@ -0,0 +1,67 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package query
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/skywalking-banyandb/banyand/protector"
+)
+
+func TestAdmission(t *testing.T) {
+	ctx, release := protector.NewQueryAccountant(1000, 0).Track(context.Background())
+	defer release()
+	require.NoError(t, protector.Account(ctx, 400))
+
+	a := admission{policy: admissionPolicyReject, groupBytes: 100}
+	n, err := a.admit(ctx)(100, 6)
+	require.NoError(t, err)
+	assert.Equal(t, 6, n, "the affordable groups are admitted regardless of the series")
+	_, err = a.admit(ctx)(7, 7)
+	require.ErrorIs(t, err, protector.ErrQueryNotAdmitted)
+	assert.ErrorContains(t, err, "7 groups")
+
+	a.policy = admissionPolicyDegrade
+	n, err = a.admit(ctx)(20, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 6, n, "the query is degraded to the affordable groups")
+
+	n, err = a.admit(context.Background())(20, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 10, n, "the unlimited query is always admitted")
+	a.groupBytes = 0
+	n, err = a.admit(ctx)(20, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 10, n, "the admission is disabled without the estimate of a group")
+
+	require.NoError(t, protector.Account(ctx, 600))
+	a.groupBytes = 100
+	_, err = a.admit(ctx)(1, 1)
+	assert.ErrorIs(t, err, protector.ErrQueryNotAdmitted, "the query affording no group is rejected even if it's degraded")
+}
+
+func TestAdmissionValidate(t *testing.T) {
+	assert.NoError(t, (&admission{policy: admissionPolicyReject}).validate())
+	assert.NoError(t, (&admission{policy: admissionPolicyDegrade, groupBytes: 1}).validate())
+	assert.Error(t, (&admission{policy: "spill"}).validate())
+	assert.Error(t, (&admission{policy: admissionPolicyReject, groupBytes: -1}).validate())
+}
@@ -24,7 +24,7 @@ import (
 	"runtime/debug"
 	"time"
 
-	"golang.org/x/exp/slices"
+	"google.golang.org/protobuf/types/known/durationpb"
 
 	"github.com/apache/skywalking-banyandb/api/common"
 	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
@@ -32,6 +32,7 @@ import (
 	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
 	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
 	"github.com/apache/skywalking-banyandb/banyand/measure"
+	"github.com/apache/skywalking-banyandb/banyand/protector"
 	"github.com/apache/skywalking-banyandb/banyand/stream"
 	"github.com/apache/skywalking-banyandb/pkg/bus"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
@@ -41,6 +42,7 @@ import (
 	logical_measure "github.com/apache/skywalking-banyandb/pkg/query/logical/measure"
 	logical_stream "github.com/apache/skywalking-banyandb/pkg/query/logical/stream"
 	"github.com/apache/skywalking-banyandb/pkg/run"
+	"github.com/apache/skywalking-banyandb/pkg/tracecontext"
 )
 
 const (
@@ -61,6 +63,7 @@ type streamQueryProcessor struct {
 }
 
 func (p *streamQueryProcessor) Rev(ctx context.Context, message bus.Message) (resp bus.Message) {
+	ql := tracecontext.Logger(ctx, p.log)
 	n := time.Now()
 	now := n.UnixNano()
 	queryCriteria, ok := message.Data().(*streamv1.QueryRequest)
@@ -68,12 +71,12 @@ func (p *streamQueryProcessor) Rev(ctx context.Context, message bus.Message) (re
 		resp = bus.NewMessage(bus.MessageID(now), common.NewError("invalid event data type"))
 		return
 	}
-	if p.log.Debug().Enabled() {
-		p.log.Debug().RawJSON("criteria", logger.Proto(queryCriteria)).Msg("received a query request")
+	if ql.Debug().Enabled() {
+		ql.Debug().RawJSON("criteria", logger.Proto(queryCriteria)).Msg("received a query request")
 	}
 	defer func() {
 		if err := recover(); err != nil {
-			p.log.Error().Interface("err", err).RawJSON("req", logger.Proto(queryCriteria)).Str("stack", string(debug.Stack())).Msg("panic")
+			ql.Error().Interface("err", err).RawJSON("req", logger.Proto(queryCriteria)).Str("stack", string(debug.Stack())).Msg("panic")
 			resp = bus.NewMessage(bus.MessageID(time.Now().UnixNano()), common.NewError("panic"))
 		}
 	}()
@@ -106,8 +109,8 @@ func (p *streamQueryProcessor) Rev(ctx context.Context, message bus.Message) (re
 		return
 	}
 
-	if p.log.Debug().Enabled() {
-		p.log.Debug().Str("plan", plan.String()).Msg("query plan")
+	if ql.Debug().Enabled() {
+		ql.Debug().Str("plan", plan.String()).Msg("query plan")
 	}
 	var tracer *query.Tracer
 	var span *query.Span
@@ -129,21 +132,40 @@ func (p *streamQueryProcessor) Rev(ctx context.Context, message bus.Message) (re
 			span.Stop()
 		}()
 	}
+	ctx, fc := logical_stream.WithFacetCollector(ctx, queryCriteria.GetFacet())
+	ctx, cancel := withDeadline(ctx, queryCriteria.GetTimeout())
+	defer cancel()
+	ctx, release, err := p.qos.pool(queryCriteria.Groups, p.streamService.LoadGroup).acquire(ctx)
+	if err != nil {
+		ql.Error().Err(err).RawJSON("req", logger.Proto(queryCriteria)).Msg("fail to acquire a query worker")
+		resp = bus.NewMessage(bus.MessageID(now), common.NewError("fail to execute the query plan for stream %s: %v", queryCriteria.GetName(), err))
+		return
+	}
+	defer release()
 	se := plan.(executor.StreamExecutable)
 	defer se.Close()
 	entities, err := se.Execute(ctx)
 	if err != nil {
-		p.log.Error().Err(err).RawJSON("req", logger.Proto(queryCriteria)).Msg("fail to execute the query plan")
+		if reason, truncated := p.truncatedReason(ctx); truncated {
+			ql.Warn().Err(err).RawJSON("req", logger.Proto(queryCriteria)).Msg("the stream query is truncated")
+			resp = bus.NewMessage(bus.MessageID(now), &streamv1.QueryResponse{Truncated: true, TruncatedReason: reason})
+			return
+		}
+		if cause := memoryExceeded(ctx); cause != nil {
+			err = cause
+		}
+		ql.Error().Err(err).RawJSON("req", logger.Proto(queryCriteria)).Msg("fail to execute the query plan")
 		resp = bus.NewMessage(bus.MessageID(now), common.NewError("execute the query plan for stream %s: %v", queryCriteria.GetName(), err))
 		return
 	}
 
-	resp = bus.NewMessage(bus.MessageID(now), &streamv1.QueryResponse{Elements: entities})
+	resp = bus.NewMessage(bus.MessageID(now), &streamv1.QueryResponse{Elements: entities, Facets: fc.Result()})
 
-	if !queryCriteria.Trace && p.slowQuery > 0 {
+	if !queryCriteria.Trace && p.slowQuery.Load() > 0 {
 		latency := time.Since(n)
-		if latency > p.slowQuery {
-			p.log.Warn().Dur("latency", latency).RawJSON("req", logger.Proto(queryCriteria)).Int("resp_count", len(entities)).Msg("stream slow query")
+		if latency > p.slowQuery.Load() {
+			ql.Warn().Dur("latency", latency).RawJSON("req", logger.Proto(queryCriteria)).Int("resp_count", len(entities)).Msg("stream slow query")
+			p.slowQueries.Capture("stream", latency, queryCriteria, len(entities))
 		}
 	}
 	return
@@ -156,6 +178,7 @@ type measureQueryProcessor struct {
 }
 
 func (p *measureQueryProcessor) Rev(ctx context.Context, message bus.Message) (resp bus.Message) {
+	ql := tracecontext.Logger(ctx, p.log)
 	queryCriteria, ok := message.Data().(*measurev1.QueryRequest)
 	n := time.Now()
 	now := n.UnixNano()
@@ -163,65 +186,52 @@ func (p *measureQueryProcessor) Rev(ctx context.Context, message bus.Message) (r
 		resp = bus.NewMessage(bus.MessageID(now), common.NewError("invalid event data type"))
 		return
 	}
-	if queryCriteria.RewriteAggTopNResult {
+	rewrite := queryCriteria.RewriteAggTopNResult && queryCriteria.Top != nil
+	if rewrite {
 		queryCriteria.Top.Number *= 2
 	}
 	resp = p.executeQuery(ctx, queryCriteria)
 
-	if queryCriteria.RewriteAggTopNResult {
-		result, handleErr := handleResponse(resp)
+	if rewrite {
+		aggResp, handleErr := handleResponse(resp)
 		if handleErr != nil {
 			return
 		}
+		result := aggResp.DataPoints
 		if len(result) == 0 {
 			return
 		}
-		groupByTags := make([]string, 0)
-		if queryCriteria.GetGroupBy() != nil {
-			for _, tagFamily := range queryCriteria.GetGroupBy().GetTagProjection().GetTagFamilies() {
-				groupByTags = append(groupByTags, tagFamily.GetTags()...)
-			}
-		}
-		tagValueMap := make(map[string][]*modelv1.TagValue)
-		for _, dp := range result {
-			for _, tagFamily := range dp.GetTagFamilies() {
-				for _, tag := range tagFamily.GetTags() {
-					tagName := tag.GetKey()
-					if len(groupByTags) == 0 || slices.Contains(groupByTags, tagName) {
-						tagValueMap[tagName] = append(tagValueMap[tagName], tag.GetValue())
-					}
-				}
-			}
-		}
-		rewriteCriteria, err := rewriteCriteria(tagValueMap)
+		rewriteQueryCriteria, err := logical_measure.RewriteAggTopNRequest(queryCriteria, result)
 		if err != nil {
-			p.log.Error().Err(err).RawJSON("req", logger.Proto(queryCriteria)).Msg("fail to rewrite the query criteria")
+			ql.Error().Err(err).RawJSON("req", logger.Proto(queryCriteria)).Msg("fail to rewrite the query criteria")
 			return
 		}
-		rewriteQueryCriteria := &measurev1.QueryRequest{
-			Groups:          queryCriteria.Groups,
-			Name:            queryCriteria.Name,
-			TimeRange:       queryCriteria.TimeRange,
-			Criteria:        rewriteCriteria,
-			TagProjection:   queryCriteria.TagProjection,
-			FieldProjection: queryCriteria.FieldProjection,
-		}
 		resp = p.executeQuery(ctx, rewriteQueryCriteria)
-		dataPoints, handleErr := handleResponse(resp)
+		rawResp, handleErr := handleResponse(resp)
 		if handleErr != nil {
 			return
 		}
-		resp = bus.NewMessage(bus.MessageID(now), &measurev1.QueryResponse{DataPoints: dataPoints})
+		qr := &measurev1.QueryResponse{DataPoints: rawResp.DataPoints}
+		for _, r := range []*measurev1.QueryResponse{aggResp, rawResp} {
+			if r.Truncated {
+				qr.Truncated, qr.TruncatedReason = true, r.TruncatedReason
+			}
+			if r.SeriesOverflow != nil {
+				qr.SeriesOverflow = r.SeriesOverflow
+			}
+		}
+		resp = bus.NewMessage(bus.MessageID(now), qr)
 	}
 	return
 }
 
 func (p *measureQueryProcessor) executeQuery(ctx context.Context, queryCriteria *measurev1.QueryRequest) (resp bus.Message) {
+	ql := tracecontext.Logger(ctx, p.log)
 	n := time.Now()
 	now := n.UnixNano()
 	defer func() {
 		if err := recover(); err != nil {
-			p.log.Error().Interface("err", err).RawJSON("req", logger.Proto(queryCriteria)).Str("stack", string(debug.Stack())).Msg("panic")
+			ql.Error().Interface("err", err).RawJSON("req", logger.Proto(queryCriteria)).Str("stack", string(debug.Stack())).Msg("panic")
 			resp = bus.NewMessage(bus.MessageID(time.Now().UnixNano()), common.NewError("panic"))
 		}
 	}()
@@ -247,7 +257,7 @@ func (p *measureQueryProcessor) executeQuery(ctx context.Context, queryCriteria
 		schemas = append(schemas, s)
 		metadata = append(metadata, meta)
 	}
-	ml := p.log.Named("measure", queryCriteria.Groups[0], queryCriteria.Name)
+	ml := tracecontext.Logger(ctx, p.log.Named("measure", queryCriteria.Groups[0], queryCriteria.Name))
 	if e := ml.Debug(); e.Enabled() {
 		e.RawJSON("req", logger.Proto(queryCriteria)).Msg("received a query event")
 	}
@@ -282,8 +292,41 @@ func (p *measureQueryProcessor) executeQuery(ctx context.Context, queryCriteria
 		e.Str("plan", plan.String()).Msg("query plan")
 	}
 
+	ctx, cancel := withDeadline(ctx, queryCriteria.GetTimeout())
+	defer cancel()
+	ctx, release, err := p.qos.pool(queryCriteria.Groups, p.measureService.LoadGroup).acquire(ctx)
+	if err != nil {
+		ml.Error().Err(err).RawJSON("req", logger.Proto(queryCriteria)).Msg("fail to acquire a query worker")
+		resp = bus.NewMessage(bus.MessageID(now), common.NewError("fail to execute the query plan for measure %s: %v", queryCriteria.GetName(), err))
+		return
+	}
+	defer release()
+	ctx = query.WithSeriesLimit(ctx, p.maxSeries)
+	if groupBy := queryCriteria.GetGroupBy(); groupBy != nil {
+		// the groups are held in memory, so the query is admitted by the cardinality of its group-by key.
+		// The aggregation and the top without a group-by hold a single group.
+		var keyTags []string
+		for _, tp := range groupBy.GetTagProjection().GetTagFamilies() {
+			keyTags = append(keyTags, tp.GetTags()...)
+		}
+		ctx = query.WithSeriesAdmission(ctx, p.admission.admit(ctx), keyTags)
+	}
 	mIterator, err := plan.(executor.MeasureExecutable).Execute(ctx)
 	if err != nil {
+		if errors.Is(err, protector.ErrQueryNotAdmitted) {
+			ml.Warn().Err(err).RawJSON("req", logger.Proto(queryCriteria)).Msg("the measure query isn't admitted")
+			resp = bus.NewMessage(bus.MessageID(now), common.NewErrorWithStatus(modelv1.Status_STATUS_QUERY_NOT_ADMITTED,
+				fmt.Sprintf("fail to execute the query plan for measure %s: %v", queryCriteria.GetName(), err)))
+			return
+		}
+		if reason, truncated := p.truncatedReason(ctx); truncated {
+			ml.Warn().Err(err).RawJSON("req", logger.Proto(queryCriteria)).Msg("the measure query is truncated")
+			resp = bus.NewMessage(bus.MessageID(now), &measurev1.QueryResponse{Truncated: true, TruncatedReason: reason})
+			return
+		}
+		if cause := memoryExceeded(ctx); cause != nil {
+			err = cause
+		}
 		ml.Error().Err(err).RawJSON("req", logger.Proto(queryCriteria)).Msg("fail to query")
 		resp = bus.NewMessage(bus.MessageID(now), common.NewError("fail to execute the query plan for measure %s: %v", queryCriteria.GetName(), err))
 		return
@@ -314,109 +357,67 @@ func (p *measureQueryProcessor) executeQuery(ctx context.Context, queryCriteria
 			if len(current) > 0 {
 				result = append(result, current[0])
 			}
+			if ctx.Err() != nil {
+				break
+			}
 		}
 	}()
-	qr := &measurev1.QueryResponse{DataPoints: result}
+	if err = memoryExceeded(ctx); err != nil {
+		ml.Error().Err(err).RawJSON("req", logger.Proto(queryCriteria)).Msg("fail to query")
+		resp = bus.NewMessage(bus.MessageID(now), common.NewError("fail to execute the query plan for measure %s: %v", queryCriteria.GetName(), err))
+		return
+	}
+	qr := &measurev1.QueryResponse{DataPoints: result, SeriesOverflow: query.GetSeriesOverflow(ctx)}
+	if reason, truncated := p.truncatedReason(ctx); truncated {
+		ml.Warn().Int("resp_count", len(result)).RawJSON("req", logger.Proto(queryCriteria)).Msg("the measure query is truncated")
+		qr.Truncated, qr.TruncatedReason = true, reason
+	}
 	if e := ml.Debug(); e.Enabled() {
 		e.RawJSON("ret", logger.Proto(qr)).Msg("got a measure")
 	}
 	resp = bus.NewMessage(bus.MessageID(now), qr)
-	if !queryCriteria.Trace && p.slowQuery > 0 {
+	if !queryCriteria.Trace && p.slowQuery.Load() > 0 {
 		latency := time.Since(n)
-		if latency > p.slowQuery {
-			p.log.Warn().Dur("latency", latency).RawJSON("req", logger.Proto(queryCriteria)).Int("resp_count", len(result)).Msg("measure slow query")
+		if latency > p.slowQuery.Load() {
+			ql.Warn().Dur("latency", latency).RawJSON("req", logger.Proto(queryCriteria)).Int("resp_count", len(result)).Msg("measure slow query")
+			p.slowQueries.Capture("measure", latency, queryCriteria, len(result))
 		}
 	}
 	return
 }
 
-func handleResponse(resp bus.Message) ([]*measurev1.DataPoint, *common.Error) {
+func handleResponse(resp bus.Message) (*measurev1.QueryResponse, *common.Error) {
 	data := resp.Data()
 	switch d := data.(type) {
 	case *common.Error:
 		return nil, d
 	case *measurev1.QueryResponse:
-		return d.DataPoints, nil
+		return d, nil
 	default:
 		return nil, common.NewError("unexpected response data type: %T", d)
 	}
 }
 
-func rewriteCriteria(tagValueMap map[string][]*modelv1.TagValue) (*modelv1.Criteria, error) {
-	var tagConditions []*modelv1.Condition
-	for tagName, tagValues := range tagValueMap {
-		if len(tagValues) == 0 {
-			continue
-		}
-		switch tagValues[0].GetValue().(type) {
-		case *modelv1.TagValue_Str:
-			valueSet := make(map[string]bool)
-			for _, value := range tagValues {
-				if strVal, ok := value.GetValue().(*modelv1.TagValue_Str); ok {
-					valueSet[strVal.Str.GetValue()] = true
-				}
-			}
-			values := make([]string, 0, len(valueSet))
-			for value := range valueSet {
-				values = append(values, value)
-			}
-			condition := &modelv1.Condition{
-				Name: tagName,
-				Op:   modelv1.Condition_BINARY_OP_IN,
-				Value: &modelv1.TagValue{
-					Value: &modelv1.TagValue_StrArray{
-						StrArray: &modelv1.StrArray{
-							Value: values,
-						},
-					},
-				},
-			}
-			tagConditions = append(tagConditions, condition)
-		case *modelv1.TagValue_Int:
-			valueSet := make(map[int64]bool)
-			for _, value := range tagValues {
-				if intVal, ok := value.GetValue().(*modelv1.TagValue_Int); ok {
-					valueSet[intVal.Int.GetValue()] = true
-				}
-			}
-			values := make([]int64, 0, len(valueSet))
-			for value := range valueSet {
-				values = append(values, value)
-			}
-			condition := &modelv1.Condition{
-				Name: tagName,
-				Op:   modelv1.Condition_BINARY_OP_IN,
-				Value: &modelv1.TagValue{
-					Value: &modelv1.TagValue_IntArray{
-						IntArray: &modelv1.IntArray{
-							Value: values,
-						},
-					},
-				},
-			}
-			tagConditions = append(tagConditions, condition)
-		default:
-			return nil, fmt.Errorf("unsupported tag value type: %T", tagValues[0].GetValue())
-		}
+// withDeadline bounds the query by the timeout of the request.
+func withDeadline(ctx context.Context, timeout *durationpb.Duration) (context.Context, context.CancelFunc) {
+	if timeout.AsDuration() <= 0 {
+		return ctx, func() {}
 	}
-	return buildCriteriaTree(tagConditions), nil
+	return context.WithTimeout(ctx, timeout.AsDuration())
 }
 
-func buildCriteriaTree(conditions []*modelv1.Condition) *modelv1.Criteria {
-	if len(conditions) == 0 {
-		return nil
+// memoryExceeded returns the cause of the query canceled by exceeding the memory limit.
+func memoryExceeded(ctx context.Context) error {
+	if cause := context.Cause(ctx); errors.Is(cause, protector.ErrQueryMemoryExceeded) {
+		return cause
 	}
-	return &modelv1.Criteria{
-		Exp: &modelv1.Criteria_Le{
-			Le: &modelv1.LogicalExpression{
-				Op: modelv1.LogicalExpression_LOGICAL_OP_AND,
-				Left: &modelv1.Criteria{
-					Exp: &modelv1.Criteria_Condition{
-						Condition: conditions[0],
-					},
-				},
-				Right: buildCriteriaTree(conditions[1:]),
-			},
-		},
+	return nil
+}
+
+// truncatedReason returns why the results are partial if the query reaches its deadline.
+func (q *queryService) truncatedReason(ctx context.Context) (string, bool) {
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return "", false
 	}
+	return fmt.Sprintf("node %s reaches the deadline of the query", q.nodeID), true
 }
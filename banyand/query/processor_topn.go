@@ -34,6 +34,7 @@ import (
 	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
 	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
 	"github.com/apache/skywalking-banyandb/banyand/measure"
+	"github.com/apache/skywalking-banyandb/banyand/protector"
 	"github.com/apache/skywalking-banyandb/pkg/bus"
 	"github.com/apache/skywalking-banyandb/pkg/flow"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
@@ -42,6 +43,7 @@ import (
 	"github.com/apache/skywalking-banyandb/pkg/query/aggregation"
 	"github.com/apache/skywalking-banyandb/pkg/query/executor"
 	logical_measure "github.com/apache/skywalking-banyandb/pkg/query/logical/measure"
+	"github.com/apache/skywalking-banyandb/pkg/tracecontext"
 )
 
 type topNQueryProcessor struct {
@@ -51,22 +53,23 @@ type topNQueryProcessor struct {
 }
 
 func (t *topNQueryProcessor) Rev(ctx context.Context, message bus.Message) (resp bus.Message) {
+	ql := tracecontext.Logger(ctx, t.log)
 	request, ok := message.Data().(*measurev1.TopNRequest)
 	n := time.Now()
 	now := n.UnixNano()
 	if !ok {
-		t.log.Warn().Msg("invalid event data type")
+		ql.Warn().Msg("invalid event data type")
 		return
 	}
-	ml := t.log.Named("topn", strings.Join(request.Groups, ","), request.Name)
+	ml := tracecontext.Logger(ctx, t.log.Named("topn", strings.Join(request.Groups, ","), request.Name))
 	if e := ml.Debug(); e.Enabled() {
 		e.RawJSON("req", logger.Proto(request)).Msg("received a topn event for groups: " + strings.Join(request.Groups, ","))
 	}
 	if request.GetFieldValueSort() == modelv1.Sort_SORT_UNSPECIFIED {
-		t.log.Warn().Msg("invalid requested sort direction")
+		ql.Warn().Msg("invalid requested sort direction")
 		return
 	}
-	if e := t.log.Debug(); e.Enabled() {
+	if e := ql.Debug(); e.Enabled() {
 		e.Stringer("req", request).Msg("received a topN query event")
 	}
 	// Process all groups
@@ -81,19 +84,19 @@ func (t *topNQueryProcessor) Rev(ctx context.Context, message bus.Message) (resp
 		}
 		topNSchema, err := t.metaService.TopNAggregationRegistry().GetTopNAggregation(ctx, topNMetadata)
 		if err != nil {
-			t.log.Error().Err(err).
+			ql.Error().Err(err).
 				Str("group", group).
 				Msg("fail to get execution context")
 			return
 		}
 		if topNSchema.GetFieldValueSort() != modelv1.Sort_SORT_UNSPECIFIED &&
 			topNSchema.GetFieldValueSort() != request.GetFieldValueSort() {
-			t.log.Warn().Str("group", group).Msg("unmatched sort direction")
+			ql.Warn().Str("group", group).Msg("unmatched sort direction")
 			return
 		}
 		sourceMeasure, err := t.measureService.Measure(topNSchema.GetSourceMeasure())
 		if err != nil {
-			t.log.Error().Err(err).
+			ql.Error().Err(err).
 				Str("group", group).
 				Msg("fail to find source measure")
 			return
@@ -139,8 +142,26 @@ func (t *topNQueryProcessor) Rev(ctx context.Context, message bus.Message) (resp
 			span.Stop()
 		}()
 	}
+	ctx, release, err := t.qos.pool(request.Groups, t.measureService.LoadGroup).acquire(ctx)
+	if err != nil {
+		ml.Error().Err(err).RawJSON("req", logger.Proto(request)).Msg("fail to acquire a query worker")
+		resp = bus.NewMessage(bus.MessageID(now), common.NewError("fail to execute the topn plan for measure %s: %v", request.Name, err))
+		return
+	}
+	defer release()
+	// the TopN lists are aggregated in memory, so they're admitted by the cardinality of their keys.
+	ctx = query.WithSeriesAdmission(query.WithSeriesLimit(ctx, 0), t.admission.admit(ctx), measure.TopNTagNames)
 	mIterator, err := plan.(executor.MeasureExecutable).Execute(ctx)
 	if err != nil {
+		if errors.Is(err, protector.ErrQueryNotAdmitted) {
+			ml.Warn().Err(err).RawJSON("req", logger.Proto(request)).Msg("the topn query isn't admitted")
+			resp = bus.NewMessage(bus.MessageID(now), common.NewErrorWithStatus(modelv1.Status_STATUS_QUERY_NOT_ADMITTED,
+				fmt.Sprintf("fail to execute the topn plan for measure %s: %v", request.Name, err)))
+			return
+		}
+		if cause := memoryExceeded(ctx); cause != nil {
+			err = cause
+		}
 		ml.Error().Err(err).RawJSON("req", logger.Proto(request)).Msg("fail to close the topn plan")
 		resp = bus.NewMessage(bus.MessageID(now), common.NewError("fail to execute the topn plan for measure %s: %v", request.Name, err))
 		return
@@ -170,12 +191,20 @@ func (t *topNQueryProcessor) Rev(ctx context.Context, message bus.Message) (resp
 			}
 		}
 	}()
+	if err = memoryExceeded(ctx); err != nil {
+		ml.Error().Err(err).RawJSON("req", logger.Proto(request)).Msg("fail to execute the topn plan")
+		resp = bus.NewMessage(bus.MessageID(now), common.NewError("fail to execute the topn plan for measure %s: %v", request.Name, err))
+		return
+	}
 
-	resp = bus.NewMessage(bus.MessageID(now), toTopNResponse(result))
-	if !request.Trace && t.slowQuery > 0 {
+	topNResp := toTopNResponse(result)
+	topNResp.SeriesOverflow = query.GetSeriesOverflow(ctx)
+	resp = bus.NewMessage(bus.MessageID(now), topNResp)
+	if !request.Trace && t.slowQuery.Load() > 0 {
 		latency := time.Since(n)
-		if latency > t.slowQuery {
-			t.log.Warn().Dur("latency", latency).RawJSON("req", logger.Proto(request)).Int("resp_count", len(result)).Msg("top_n slow query")
+		if latency > t.slowQuery.Load() {
+			ql.Warn().Dur("latency", latency).RawJSON("req", logger.Proto(request)).Int("resp_count", len(result)).Msg("top_n slow query")
+			t.slowQueries.Capture("top_n", latency, request, len(result))
 		}
 	}
 	return
@@ -0,0 +1,135 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package query
+
+import (
+	"context"
+	"fmt"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	"github.com/apache/skywalking-banyandb/banyand/protector"
+	"github.com/apache/skywalking-banyandb/pkg/run"
+	"github.com/apache/skywalking-banyandb/pkg/schema"
+)
+
+// qosPool holds the query workers and the memory budget of a QoS class.
+type qosPool struct {
+	workers       chan struct{}
+	memory        *protector.QueryAccountant
+	class         commonv1.QoSClass
+	workerNum     int
+	memoryPercent int
+}
+
+func (p *qosPool) init(memory *protector.QueryAccountant) {
+	p.memory = memory
+	if p.workerNum > 0 {
+		p.workers = make(chan struct{}, p.workerNum)
+	}
+}
+
+// acquire waits for a worker of the class. The returned context carries the memory budget of the class,
+// and accounts the memory allocated by the query.
+func (p *qosPool) acquire(ctx context.Context) (context.Context, func(), error) {
+	ctx = protector.WithBudget(ctx, p.memoryPercent)
+	ctx, untrack := p.memory.Track(ctx)
+	if p.workers == nil {
+		return ctx, untrack, nil
+	}
+	select {
+	case p.workers <- struct{}{}:
+		return ctx, func() {
+			untrack()
+			<-p.workers
+		}, nil
+	case <-ctx.Done():
+		untrack()
+		return ctx, nil, fmt.Errorf("no query worker of the QoS class %s is available: %w", p.class, ctx.Err())
+	}
+}
+
+// qosPools holds the pools of the QoS classes.
+type qosPools struct {
+	gold   qosPool
+	silver qosPool
+	bronze qosPool
+}
+
+func newQoSPools() *qosPools {
+	return &qosPools{
+		gold:   qosPool{class: commonv1.QoSClass_QOS_CLASS_GOLD},
+		silver: qosPool{class: commonv1.QoSClass_QOS_CLASS_SILVER},
+		bronze: qosPool{class: commonv1.QoSClass_QOS_CLASS_BRONZE},
+	}
+}
+
+func (q *qosPools) flags(fs *run.FlagSet, defaultBronzeWorkers int) {
+	fs.IntVar(&q.gold.workerNum, "qos-gold-workers", 0, "the number of the concurrent queries of the gold QoS class, 0 means unbounded")
+	fs.IntVar(&q.silver.workerNum, "qos-silver-workers", 0, "the number of the concurrent queries of the silver QoS class, 0 means unbounded")
+	fs.IntVar(&q.bronze.workerNum, "qos-bronze-workers", defaultBronzeWorkers,
+		"the number of the concurrent queries of the bronze QoS class, 0 means unbounded")
+	fs.IntVar(&q.gold.memoryPercent, "qos-gold-memory-percent", 100,
+		"the percentage of the memory limit of the protector available to the queries of the gold QoS class")
+	fs.IntVar(&q.silver.memoryPercent, "qos-silver-memory-percent", 100,
+		"the percentage of the memory limit of the protector available to the queries of the silver QoS class")
+	fs.IntVar(&q.bronze.memoryPercent, "qos-bronze-memory-percent", 80,
+		"the percentage of the memory limit of the protector available to the queries of the bronze QoS class")
+}
+
+func (q *qosPools) validate() error {
+	for _, p := range []*qosPool{&q.gold, &q.silver, &q.bronze} {
+		if p.workerNum < 0 {
+			return fmt.Errorf("the workers of the QoS class %s must not be negative", p.class)
+		}
+		if p.memoryPercent <= 0 || p.memoryPercent > 100 {
+			return fmt.Errorf("the memory percent of the QoS class %s must be in the range (0, 100]", p.class)
+		}
+	}
+	return nil
+}
+
+func (q *qosPools) init(memory *protector.QueryAccountant) {
+	q.gold.init(memory)
+	q.silver.init(memory)
+	q.bronze.init(memory)
+}
+
+// pool returns the pool of the lowest class of the groups.
+func (q *qosPools) pool(groups []string, load func(group string) (schema.Group, bool)) *qosPool {
+	if len(groups) == 0 {
+		return &q.silver
+	}
+	class := commonv1.QoSClass_QOS_CLASS_GOLD
+	for _, g := range groups {
+		c := commonv1.QoSClass_QOS_CLASS_SILVER
+		if gs, ok := load(g); ok && gs.GetSchema().GetResourceOpts().GetQosClass() != commonv1.QoSClass_QOS_CLASS_UNSPECIFIED {
+			c = gs.GetSchema().GetResourceOpts().GetQosClass()
+		}
+		if c > class {
+			class = c
+		}
+	}
+	switch class {
+	case commonv1.QoSClass_QOS_CLASS_GOLD:
+		return &q.gold
+	case commonv1.QoSClass_QOS_CLASS_BRONZE:
+		return &q.bronze
+	default:
+		return &q.silver
+	}
+}
@@ -0,0 +1,78 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package query
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	"github.com/apache/skywalking-banyandb/pkg/schema"
+)
+
+type fakeGroup struct {
+	class commonv1.QoSClass
+}
+
+func (g fakeGroup) GetSchema() *commonv1.Group {
+	return &commonv1.Group{ResourceOpts: &commonv1.ResourceOpts{QosClass: g.class}}
+}
+
+func (g fakeGroup) SupplyTSDB() io.Closer {
+	return nil
+}
+
+func TestQoSPoolsPool(t *testing.T) {
+	groups := map[string]commonv1.QoSClass{
+		"metrics":     commonv1.QoSClass_QOS_CLASS_GOLD,
+		"logs":        commonv1.QoSClass_QOS_CLASS_BRONZE,
+		"unspecified": commonv1.QoSClass_QOS_CLASS_UNSPECIFIED,
+	}
+	load := func(group string) (schema.Group, bool) {
+		c, ok := groups[group]
+		return fakeGroup{class: c}, ok
+	}
+	q := newQoSPools()
+	assert.Equal(t, commonv1.QoSClass_QOS_CLASS_GOLD, q.pool([]string{"metrics"}, load).class)
+	assert.Equal(t, commonv1.QoSClass_QOS_CLASS_SILVER, q.pool([]string{"unspecified"}, load).class)
+	assert.Equal(t, commonv1.QoSClass_QOS_CLASS_SILVER, q.pool([]string{"absent"}, load).class)
+	assert.Equal(t, commonv1.QoSClass_QOS_CLASS_SILVER, q.pool([]string{"metrics", "unspecified"}, load).class)
+	assert.Equal(t, commonv1.QoSClass_QOS_CLASS_BRONZE, q.pool([]string{"metrics", "logs"}, load).class)
+}
+
+func TestQoSPoolAcquire(t *testing.T) {
+	p := &qosPool{class: commonv1.QoSClass_QOS_CLASS_BRONZE, workerNum: 1, memoryPercent: 80}
+	p.init(nil)
+	_, release, err := p.acquire(context.Background())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, _, err = p.acquire(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	release()
+	_, release, err = p.acquire(context.Background())
+	require.NoError(t, err)
+	release()
+}
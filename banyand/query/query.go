@@ -21,7 +21,6 @@ package query
 import (
 	"context"
 	"errors"
-	"time"
 
 	"go.uber.org/multierr"
 
@@ -29,9 +28,12 @@ import (
 	"github.com/apache/skywalking-banyandb/api/data"
 	"github.com/apache/skywalking-banyandb/banyand/measure"
 	"github.com/apache/skywalking-banyandb/banyand/metadata"
+	"github.com/apache/skywalking-banyandb/banyand/protector"
 	"github.com/apache/skywalking-banyandb/banyand/queue"
 	"github.com/apache/skywalking-banyandb/banyand/stream"
+	"github.com/apache/skywalking-banyandb/pkg/cgroups"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/query"
 	"github.com/apache/skywalking-banyandb/pkg/run"
 )
 
@@ -42,8 +44,15 @@ type queryService struct {
 	sqp         *streamQueryProcessor
 	mqp         *measureQueryProcessor
 	tqp         *topNQueryProcessor
+	qos         *qosPools
+	slowQueries *query.SlowQueryRecorder
 	nodeID      string
-	slowQuery   time.Duration
+	admission   admission
+	slowQuery   run.DynamicDuration
+	queryMemory run.Bytes
+	nodeMemory  run.Bytes
+	maxSeries   int
+	slowRecords int
 }
 
 // NewService return a new query service.
@@ -53,6 +62,7 @@ func NewService(_ context.Context, streamService stream.Service, measureService
 	svc := &queryService{
 		metaService: metaService,
 		pipeline:    pipeline,
+		qos:         newQoSPools(),
 	}
 	// measure query processor
 	svc.mqp = &measureQueryProcessor{
@@ -84,6 +94,8 @@ func (q *queryService) PreRun(ctx context.Context) error {
 	node := val.(common.Node)
 	q.nodeID = node.NodeID
 	q.log = logger.GetLogger(moduleName)
+	q.qos.init(protector.NewQueryAccountant(uint64(q.queryMemory), uint64(q.nodeMemory)))
+	q.slowQueries = query.NewSlowQueryRecorder(moduleName, q.slowRecords)
 	return multierr.Combine(
 		q.pipeline.Subscribe(data.TopicStreamQuery, q.sqp),
 		q.pipeline.Subscribe(data.TopicMeasureQuery, q.mqp),
@@ -93,10 +105,31 @@ func (q *queryService) PreRun(ctx context.Context) error {
 
 func (q *queryService) FlagSet() *run.FlagSet {
 	fs := run.NewFlagSet("query")
-	fs.DurationVar(&q.slowQuery, "slow-query", 0, "slow query threshold, 0 means no slow query log")
+	fs.DynamicDurationVar(&q.slowQuery, "slow-query", 0, "slow query threshold, 0 means no slow query log")
+	fs.IntVar(&q.slowRecords, "slow-query-records", 100, "the number of the last slow queries kept for the web console, 0 means none")
+	fs.Var(&q.queryMemory, "query-max-memory", "the maximum memory allocated by a query, e.g. the decoded blocks, "+
+		"the query exceeding it is canceled, 0 means unlimited")
+	fs.Var(&q.nodeMemory, "query-max-node-memory", "the maximum memory allocated by all the running queries of the node, "+
+		"the query making them exceed it is canceled, 0 means unlimited")
+	fs.IntVar(&q.maxSeries, "query-max-measure-series", 0, "the maximum series read by a measure query of a group, "+
+		"the query matching more series reads a deterministic sample of them, 0 means unlimited")
+	q.admission.flags(fs)
+	q.qos.flags(fs, cgroups.CPUs())
 	return fs
 }
 
 func (q *queryService) Validate() error {
-	return nil
+	if q.queryMemory < 0 || q.nodeMemory < 0 {
+		return errors.New("the memory limits of the queries must not be negative")
+	}
+	if q.slowRecords < 0 {
+		return errors.New("the slow query records must not be negative")
+	}
+	if q.maxSeries < 0 {
+		return errors.New("the maximum series of the measure queries must not be negative")
+	}
+	if err := q.admission.validate(); err != nil {
+		return err
+	}
+	return q.qos.validate()
 }
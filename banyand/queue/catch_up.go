@@ -0,0 +1,94 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package queue
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// CatchUpPath is the HTTP path of the catch-up progress of the queue clients.
+const CatchUpPath = "/api/healthz/catch-up"
+
+// CatchUpState is the state of the writes spooled for a node.
+type CatchUpState string
+
+// The catch-up states.
+const (
+	// CatchUpStateSpooling spools the writes failing to reach the node until it's active again.
+	CatchUpStateSpooling CatchUpState = "spooling"
+	// CatchUpStateReplaying replays the spooled writes to the node in the background.
+	CatchUpStateReplaying CatchUpState = "replaying"
+	// CatchUpStateDone has replayed all the spooled writes.
+	CatchUpStateDone CatchUpState = "done"
+)
+
+// CatchUpProgress is the progress of replaying the writes spooled for a node.
+// Replayed, Failed and Dropped count the writes since the client started.
+type CatchUpProgress struct {
+	StartedAt  *time.Time   `json:"started_at,omitempty"`
+	FinishedAt *time.Time   `json:"finished_at,omitempty"`
+	State      CatchUpState `json:"state"`
+	Pending    int          `json:"pending"`
+	Replayed   int          `json:"replayed"`
+	Failed     int          `json:"failed"`
+	Dropped    int          `json:"dropped"`
+}
+
+var catchUps = struct {
+	progress map[string]func() map[string]CatchUpProgress
+	mu       sync.RWMutex
+}{progress: make(map[string]func() map[string]CatchUpProgress)}
+
+// RegisterCatchUp serves the catch-up progress of the queue client name by the nodes through ServeCatchUps.
+// The returned function stops serving it.
+func RegisterCatchUp(name string, progress func() map[string]CatchUpProgress) func() {
+	catchUps.mu.Lock()
+	defer catchUps.mu.Unlock()
+	catchUps.progress[name] = progress
+	return func() {
+		catchUps.mu.Lock()
+		defer catchUps.mu.Unlock()
+		delete(catchUps.progress, name)
+	}
+}
+
+// CatchUps returns the catch-up progress of the queue clients by their names and the nodes.
+func CatchUps() map[string]map[string]CatchUpProgress {
+	catchUps.mu.RLock()
+	names := make([]string, 0, len(catchUps.progress))
+	progress := make([]func() map[string]CatchUpProgress, 0, len(catchUps.progress))
+	for name, p := range catchUps.progress {
+		names = append(names, name)
+		progress = append(progress, p)
+	}
+	catchUps.mu.RUnlock()
+	result := make(map[string]map[string]CatchUpProgress, len(names))
+	for i := range names {
+		result[names[i]] = progress[i]()
+	}
+	return result
+}
+
+// ServeCatchUps writes the catch-up progress in JSON.
+func ServeCatchUps(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(CatchUps())
+}
@@ -0,0 +1,183 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package queue
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// DeadMessagePath is the HTTP path of the captured dead messages.
+const DeadMessagePath = "/api/debug/queue/dead-messages"
+
+const maxDeadMessagePayload = 1024
+
+// DeadMessage is a message which the queue failed to deliver or to handle.
+type DeadMessage struct {
+	Time      time.Time `json:"time"`
+	Topic     string    `json:"topic"`
+	Node      string    `json:"node,omitempty"`
+	Reason    string    `json:"reason"`
+	Payload   string    `json:"payload,omitempty"`
+	MessageID uint64    `json:"message_id"`
+}
+
+// DeadMessageSummary summarizes the dead messages of a queue endpoint.
+// Counts has all the dead messages by the topic since the server started, and Messages only keeps the latest ones.
+type DeadMessageSummary struct {
+	Counts   map[string]int `json:"counts"`
+	Messages []DeadMessage  `json:"messages"`
+}
+
+var deadMessageRecorders = struct {
+	recorders map[string]*DeadMessageRecorder
+	mu        sync.RWMutex
+}{recorders: make(map[string]*DeadMessageRecorder)}
+
+// DeadMessageRecorder keeps the last dead messages of a queue client or server.
+// The methods of a nil recorder do nothing, so the capture is disabled by a nil recorder.
+type DeadMessageRecorder struct {
+	counts   map[string]int
+	name     string
+	messages []DeadMessage
+	next     int
+	mu       sync.Mutex
+}
+
+// NewDeadMessageRecorder returns a recorder keeping the last capacity dead messages.
+// It returns nil if the capacity isn't positive. The recorder is served by ServeDeadMessages under name until it's closed.
+func NewDeadMessageRecorder(name string, capacity int) *DeadMessageRecorder {
+	if capacity <= 0 {
+		return nil
+	}
+	r := &DeadMessageRecorder{
+		name:     name,
+		counts:   make(map[string]int),
+		messages: make([]DeadMessage, 0, capacity),
+	}
+	deadMessageRecorders.mu.Lock()
+	defer deadMessageRecorders.mu.Unlock()
+	deadMessageRecorders.recorders[name] = r
+	return r
+}
+
+// Capture records a dead message. It overwrites the oldest message if the recorder is full.
+func (r *DeadMessageRecorder) Capture(m DeadMessage) {
+	if r == nil {
+		return
+	}
+	if m.Time.IsZero() {
+		m.Time = time.Now()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.counts[m.Topic]++
+	if len(r.messages) < cap(r.messages) {
+		r.messages = append(r.messages, m)
+		return
+	}
+	r.messages[r.next] = m
+	r.next = (r.next + 1) % len(r.messages)
+}
+
+// Summary returns the dead messages from the oldest to the latest.
+func (r *DeadMessageRecorder) Summary() DeadMessageSummary {
+	if r == nil {
+		return DeadMessageSummary{}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := DeadMessageSummary{
+		Counts:   make(map[string]int, len(r.counts)),
+		Messages: make([]DeadMessage, 0, len(r.messages)),
+	}
+	for k, v := range r.counts {
+		s.Counts[k] = v
+	}
+	s.Messages = append(s.Messages, r.messages[r.next:]...)
+	s.Messages = append(s.Messages, r.messages[:r.next]...)
+	return s
+}
+
+// Close stops serving the recorder.
+func (r *DeadMessageRecorder) Close() {
+	if r == nil {
+		return
+	}
+	deadMessageRecorders.mu.Lock()
+	defer deadMessageRecorders.mu.Unlock()
+	if deadMessageRecorders.recorders[r.name] == r {
+		delete(deadMessageRecorders.recorders, r.name)
+	}
+}
+
+// DeadMessages returns the summaries of the running recorders by their names.
+func DeadMessages() map[string]DeadMessageSummary {
+	deadMessageRecorders.mu.RLock()
+	names := make([]string, 0, len(deadMessageRecorders.recorders))
+	recorders := make([]*DeadMessageRecorder, 0, len(deadMessageRecorders.recorders))
+	for name, r := range deadMessageRecorders.recorders {
+		names = append(names, name)
+		recorders = append(recorders, r)
+	}
+	deadMessageRecorders.mu.RUnlock()
+	result := make(map[string]DeadMessageSummary, len(names))
+	for i := range names {
+		result[names[i]] = recorders[i].Summary()
+	}
+	return result
+}
+
+// ServeDeadMessages writes the captured dead messages in JSON.
+func ServeDeadMessages(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(DeadMessages())
+}
+
+// DescribePayload describes the payload of a dead message. The proto messages are printed in JSON,
+// and the description is truncated to keep the recorder small.
+func DescribePayload(payload any) string {
+	var desc string
+	switch p := payload.(type) {
+	case nil:
+		return ""
+	case proto.Message:
+		b, err := protojson.Marshal(p)
+		if err != nil {
+			desc = fmt.Sprintf("%T", p)
+			break
+		}
+		desc = string(b)
+	case []byte:
+		desc = fmt.Sprintf("%d bytes", len(p))
+	case []any:
+		desc = fmt.Sprintf("%d messages", len(p))
+	default:
+		desc = fmt.Sprintf("%T", p)
+	}
+	if len(desc) > maxDeadMessagePayload {
+		desc = desc[:maxDeadMessagePayload] + "..."
+	}
+	return desc
+}
@@ -0,0 +1,85 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package queue
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+)
+
+func TestDeadMessageRecorder(t *testing.T) {
+	assert.Nil(t, NewDeadMessageRecorder("disabled", 0))
+	// the nil recorder disables the capture.
+	var disabled *DeadMessageRecorder
+	disabled.Capture(DeadMessage{Topic: "stream-write"})
+	disabled.Close()
+
+	r := NewDeadMessageRecorder("test", 3)
+	defer r.Close()
+	for i := 0; i < 5; i++ {
+		r.Capture(DeadMessage{Topic: "stream-write", MessageID: uint64(i), Reason: fmt.Sprintf("reason %d", i)})
+	}
+	r.Capture(DeadMessage{Topic: "measure-write", MessageID: 5})
+
+	s := r.Summary()
+	assert.Equal(t, map[string]int{"stream-write": 5, "measure-write": 1}, s.Counts)
+	require.Len(t, s.Messages, 3)
+	for i, m := range s.Messages {
+		assert.Equal(t, uint64(i+3), m.MessageID)
+		assert.False(t, m.Time.IsZero())
+	}
+}
+
+func TestServeDeadMessages(t *testing.T) {
+	r := NewDeadMessageRecorder("served", 1)
+	r.Capture(DeadMessage{Topic: "stream-write", Node: "node1", Reason: "unavailable"})
+
+	rec := httptest.NewRecorder()
+	ServeDeadMessages(rec, httptest.NewRequest(http.MethodGet, DeadMessagePath, nil))
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var got map[string]DeadMessageSummary
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	require.Contains(t, got, "served")
+	assert.Equal(t, "node1", got["served"].Messages[0].Node)
+
+	// the closed recorders aren't served.
+	r.Close()
+	assert.NotContains(t, DeadMessages(), "served")
+}
+
+func TestDescribePayload(t *testing.T) {
+	assert.Empty(t, DescribePayload(nil))
+	assert.Equal(t, "3 bytes", DescribePayload([]byte("abc")))
+	assert.Equal(t, "2 messages", DescribePayload([]any{1, 2}))
+	assert.Equal(t, "int", DescribePayload(1))
+	assert.Contains(t, DescribePayload(&streamv1.InternalWriteRequest{ShardId: 1}), "shardId")
+
+	long := DescribePayload(&streamv1.InternalWriteRequest{Request: &streamv1.WriteRequest{
+		Element: &streamv1.ElementValue{ElementId: strings.Repeat("a", 2*maxDeadMessagePayload)},
+	}})
+	assert.Len(t, long, maxDeadMessagePayload+len("..."))
+}
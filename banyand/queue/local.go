@@ -55,6 +55,11 @@ func (l *local) GracefulStop() {
 	}
 }
 
+// ShutdownPhase implements run.PhasedService.
+func (l *local) ShutdownPhase() run.ShutdownPhase {
+	return run.ShutdownPhaseQueue
+}
+
 // Serve implements Queue.
 func (l *local) Serve() run.StopNotify {
 	return l.stopCh
@@ -0,0 +1,118 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package queue
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+)
+
+// NodeHealthPath is the HTTP path of the health of the nodes which the queue clients publish to.
+const NodeHealthPath = "/api/healthz/nodes"
+
+// NodeState is the state of a node in a queue client.
+type NodeState string
+
+// The node states.
+const (
+	// NodeStateActive receives the messages published by the client.
+	NodeStateActive NodeState = "active"
+	// NodeStateUnhealthy fails the health checks, which are retried until the node is active again or removed.
+	NodeStateUnhealthy NodeState = "unhealthy"
+	// NodeStateInactive is registered but not connected by the client.
+	NodeStateInactive NodeState = "inactive"
+)
+
+// NodeHealth is the health of a node seen by a queue client.
+type NodeHealth struct {
+	CreatedAt   *time.Time        `json:"created_at,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Name        string            `json:"name"`
+	GrpcAddress string            `json:"grpc_address"`
+	HTTPAddress string            `json:"http_address,omitempty"`
+	State       NodeState         `json:"state"`
+	Roles       []string          `json:"roles"`
+}
+
+// NewNodeHealth returns the health of the node in the state.
+func NewNodeHealth(node *databasev1.Node, state NodeState) NodeHealth {
+	h := NodeHealth{
+		Name:        node.GetMetadata().GetName(),
+		GrpcAddress: node.GetGrpcAddress(),
+		HTTPAddress: node.GetHttpAddress(),
+		Labels:      node.GetLabels(),
+		State:       state,
+		Roles:       make([]string, 0, len(node.GetRoles())),
+	}
+	for _, r := range node.GetRoles() {
+		h.Roles = append(h.Roles, r.String())
+	}
+	if node.GetCreatedAt() != nil {
+		t := node.GetCreatedAt().AsTime()
+		h.CreatedAt = &t
+	}
+	return h
+}
+
+var nodeHealths = struct {
+	health map[string]func() []NodeHealth
+	mu     sync.RWMutex
+}{health: make(map[string]func() []NodeHealth)}
+
+// RegisterNodeHealth serves the health of the nodes of the queue client name through ServeNodeHealths.
+// It returns the function to stop serving them.
+func RegisterNodeHealth(name string, health func() []NodeHealth) func() {
+	nodeHealths.mu.Lock()
+	defer nodeHealths.mu.Unlock()
+	nodeHealths.health[name] = health
+	return func() {
+		nodeHealths.mu.Lock()
+		defer nodeHealths.mu.Unlock()
+		delete(nodeHealths.health, name)
+	}
+}
+
+// NodeHealths returns the health of the nodes sorted by their names, keyed by the names of the queue clients.
+func NodeHealths() map[string][]NodeHealth {
+	nodeHealths.mu.RLock()
+	names := make([]string, 0, len(nodeHealths.health))
+	health := make([]func() []NodeHealth, 0, len(nodeHealths.health))
+	for name, h := range nodeHealths.health {
+		names = append(names, name)
+		health = append(health, h)
+	}
+	nodeHealths.mu.RUnlock()
+	result := make(map[string][]NodeHealth, len(names))
+	for i := range names {
+		nn := health[i]()
+		sort.Slice(nn, func(a, b int) bool { return nn[a].Name < nn[b].Name })
+		result[names[i]] = nn
+	}
+	return result
+}
+
+// ServeNodeHealths writes the health of the nodes of the queue clients in JSON.
+func ServeNodeHealths(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(NodeHealths())
+}
@@ -0,0 +1,63 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package queue
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+)
+
+func TestServeNodeHealths(t *testing.T) {
+	stop := RegisterNodeHealth("served", func() []NodeHealth {
+		return []NodeHealth{
+			NewNodeHealth(&databasev1.Node{
+				Metadata:    &commonv1.Metadata{Name: "node2"},
+				Roles:       []databasev1.Role{databasev1.Role_ROLE_DATA},
+				GrpcAddress: "node2:17912",
+			}, NodeStateUnhealthy),
+			NewNodeHealth(&databasev1.Node{Metadata: &commonv1.Metadata{Name: "node1"}}, NodeStateActive),
+		}
+	})
+
+	rec := httptest.NewRecorder()
+	ServeNodeHealths(rec, httptest.NewRequest(http.MethodGet, NodeHealthPath, nil))
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var got map[string][]NodeHealth
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	require.Len(t, got["served"], 2)
+	assert.Equal(t, "node1", got["served"][0].Name)
+	assert.Equal(t, NodeStateActive, got["served"][0].State)
+	assert.Equal(t, NodeHealth{
+		Name:        "node2",
+		GrpcAddress: "node2:17912",
+		State:       NodeStateUnhealthy,
+		Roles:       []string{"ROLE_DATA"},
+	}, got["served"][1])
+
+	// the stopped clients aren't served.
+	stop()
+	assert.NotContains(t, NodeHealths(), "served")
+}
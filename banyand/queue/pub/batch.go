@@ -19,6 +19,7 @@ package pub
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -31,12 +32,14 @@ import (
 	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
 	"github.com/apache/skywalking-banyandb/banyand/queue"
 	"github.com/apache/skywalking-banyandb/pkg/bus"
+	"github.com/apache/skywalking-banyandb/pkg/fault"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
 )
 
 type writeStream struct {
 	client    clusterv1.Service_SendClient
 	ctxDoneCh <-chan struct{}
+	version   uint32
 }
 
 type batchPublisher struct {
@@ -46,6 +49,8 @@ type batchPublisher struct {
 	failedNodes map[string]*common.Error
 	f           batchFuture
 	timeout     time.Duration
+	// replay is true if the publisher replays the spooled messages, whose failures aren't spooled again.
+	replay bool
 }
 
 // NewBatchPublisher returns a new batch publisher.
@@ -64,12 +69,11 @@ func (bp *batchPublisher) Publish(ctx context.Context, topic bus.Topic, messages
 	}
 	var err error
 	for _, m := range messages {
-		r, errM2R := messageToRequest(topic, m)
-		if errM2R != nil {
-			err = multierr.Append(err, fmt.Errorf("failed to marshal message %T: %w", m, errM2R))
+		node := m.Node()
+		if fault.Enabled && fault.Drop(fault.PointQueueSend, topic.String()+"@"+node) {
+			// the message is lost as if the network dropped it.
 			continue
 		}
-		node := m.Node()
 		sendData := func() (success bool) {
 			if stream, ok := bp.streams[node]; ok {
 				defer func() {
@@ -84,12 +88,20 @@ func (bp *batchPublisher) Publish(ctx context.Context, topic bus.Topic, messages
 					return false
 				default:
 				}
-				errSend := stream.client.Send(r)
-				if errSend != nil {
-					err = multierr.Append(err, fmt.Errorf("failed to send message to node %s: %w", node, errSend))
-					return false
+				rr, errM2R := messageToRequests(topic, m, stream.version)
+				if errM2R != nil {
+					// the stream is still usable, and the message is dropped.
+					bp.fail(topic, m, fmt.Errorf("failed to marshal message %T: %w", m, errM2R), &err)
+					return true
 				}
-				return errSend == nil
+				for _, r := range rr {
+					if errSend := stream.client.Send(r); errSend != nil {
+						bp.fail(topic, m, fmt.Errorf("failed to send message to node %s: %w", node, errSend), &err)
+						return false
+					}
+				}
+				bp.pub.metrics.totalMsgSent.Inc(1, topic.String())
+				return true
 			}
 			return false
 		}
@@ -99,12 +111,13 @@ func (bp *batchPublisher) Publish(ctx context.Context, topic bus.Topic, messages
 
 		select {
 		case <-ctx.Done():
+			bp.pub.deadMessage(topic, node, m, ctx.Err())
 			return nil, ctx.Err()
 		default:
 		}
 		if bp.failedNodes != nil {
 			if ce := bp.failedNodes[node]; ce != nil {
-				err = multierr.Append(err, ce)
+				bp.fail(topic, m, ce, &err)
 			}
 			continue
 		}
@@ -116,7 +129,7 @@ func (bp *batchPublisher) Publish(ctx context.Context, topic bus.Topic, messages
 			var ok bool
 			client, ok = bp.pub.active[node]
 			if !ok {
-				err = multierr.Append(err, fmt.Errorf("failed to get client for node %s", node))
+				bp.lose(topic, m, fmt.Errorf("failed to get client for node %s", node), &err)
 				return true
 			}
 			succeed, ce := bp.pub.checkWritable(node, topic)
@@ -127,7 +140,9 @@ func (bp *batchPublisher) Publish(ctx context.Context, topic bus.Topic, messages
 				bp.failedNodes = make(map[string]*common.Error)
 			}
 			bp.failedNodes[node] = ce
-			err = multierr.Append(err, ce)
+			if ce != nil {
+				bp.fail(topic, m, ce, &err)
+			}
 			return true
 		}() {
 			continue
@@ -136,14 +151,15 @@ func (bp *batchPublisher) Publish(ctx context.Context, topic bus.Topic, messages
 		streamCtx, cancel := context.WithTimeout(ctx, bp.timeout)
 		// this assignment is for getting around the go vet lint
 		deferFn := cancel
-		stream, errCreateStream := client.client.Send(streamCtx)
+		stream, errCreateStream := client.conn.Send(streamCtx)
 		if errCreateStream != nil {
-			err = multierr.Append(err, fmt.Errorf("failed to get stream for node %s: %w", node, errCreateStream))
+			bp.lose(topic, m, fmt.Errorf("failed to get stream for node %s: %w", node, errCreateStream), &err)
 			continue
 		}
 		bp.streams[node] = writeStream{
 			client:    stream,
 			ctxDoneCh: streamCtx.Done(),
+			version:   client.version,
 		}
 		bp.f.events = append(bp.f.events, make(chan batchEvent))
 		_ = sendData()
@@ -159,6 +175,7 @@ func (bp *batchPublisher) Publish(ctx context.Context, topic bus.Topic, messages
 			}
 			resp, errRecv := s.Recv()
 			if errRecv != nil {
+				bp.pub.deadResponse(topic, node, 0, errRecv)
 				if isFailoverError(errRecv) {
 					bc <- batchEvent{n: node, e: common.NewErrorWithStatus(modelv1.Status_STATUS_INTERNAL_ERROR, errRecv.Error())}
 				}
@@ -170,6 +187,7 @@ func (bp *batchPublisher) Publish(ctx context.Context, topic bus.Topic, messages
 			if resp.Error == "" {
 				return
 			}
+			bp.pub.deadResponse(topic, node, resp.MessageId, errors.New(resp.Error))
 			if isFailoverStatus(resp.Status) {
 				ce := common.NewErrorWithStatus(resp.Status, resp.Error)
 				bc <- batchEvent{n: node, e: ce}
@@ -179,6 +197,22 @@ func (bp *batchPublisher) Publish(ctx context.Context, topic bus.Topic, messages
 	return nil, err
 }
 
+// fail counts and captures the message m failing to reach its node, and appends the reason to err.
+func (bp *batchPublisher) fail(topic bus.Topic, m bus.Message, reason error, err *error) {
+	bp.pub.deadMessage(topic, m.Node(), m, reason)
+	*err = multierr.Append(*err, reason)
+}
+
+// lose spools the message m failing to reach its node, which is replayed once the node is active again.
+// The message fails if the spool is disabled.
+func (bp *batchPublisher) lose(topic bus.Topic, m bus.Message, reason error, err *error) {
+	if !bp.replay && bp.pub.hints.spool(topic, m) {
+		bp.pub.metrics.totalMsgSpooled.Inc(1, topic.String())
+		return
+	}
+	bp.fail(topic, m, reason, err)
+}
+
 func (bp *batchPublisher) Close() (cee map[string]*common.Error, err error) {
 	for i := range bp.streams {
 		err = multierr.Append(err, bp.streams[i].client.CloseSend())
@@ -22,14 +22,14 @@ import (
 	"fmt"
 	"time"
 
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/health/grpc_health_v1"
-
 	"github.com/apache/skywalking-banyandb/api/common"
+	"github.com/apache/skywalking-banyandb/api/data"
 	clusterv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/cluster/v1"
 	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
 	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
 	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
+	"github.com/apache/skywalking-banyandb/banyand/queue"
+	"github.com/apache/skywalking-banyandb/banyand/queue/transport"
 	"github.com/apache/skywalking-banyandb/pkg/bus"
 	"github.com/apache/skywalking-banyandb/pkg/grpchelper"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
@@ -61,9 +61,10 @@ var (
 )
 
 type client struct {
-	client clusterv1.ServiceClient
-	conn   *grpc.ClientConn
-	md     schema.Metadata
+	conn transport.Conn
+	md   schema.Metadata
+	// version is the protocol version of the messages negotiated with the node.
+	version uint32
 }
 
 func (p *pub) OnAddOrUpdate(md schema.Metadata) {
@@ -112,14 +113,9 @@ func (p *pub) OnAddOrUpdate(md schema.Metadata) {
 	if _, ok := p.evictable[name]; ok {
 		return
 	}
-	credOpts, err := p.getClientTransportCredentials()
+	conn, err := p.dialer.Dial(node)
 	if err != nil {
-		p.log.Error().Err(err).Msg("failed to load client TLS credentials")
-		return
-	}
-	conn, err := grpc.NewClient(address, append(credOpts, grpc.WithDefaultServiceConfig(retryPolicy))...)
-	if err != nil {
-		p.log.Error().Err(err).Msg("failed to connect to grpc server")
+		p.log.Error().Err(err).Str("address", address).Msg("failed to connect to the node")
 		return
 	}
 
@@ -128,9 +124,9 @@ func (p *pub) OnAddOrUpdate(md schema.Metadata) {
 		return
 	}
 
-	c := clusterv1.NewServiceClient(conn)
-	p.active[name] = &client{conn: conn, client: c, md: md}
+	p.active[name] = &client{conn: conn, md: md, version: p.negotiate(name, conn)}
 	p.addClient(md)
+	p.catchUp(name)
 	p.log.Info().Str("status", p.dump()).Stringer("node", node).Msg("new node is healthy, add it to active queue")
 }
 
@@ -239,7 +235,7 @@ func (p *pub) removeNodeIfUnhealthy(md schema.Metadata, node *databasev1.Node, c
 	return true
 }
 
-func (p *pub) checkClientHealthAndReconnect(conn *grpc.ClientConn, md schema.Metadata) bool {
+func (p *pub) checkClientHealthAndReconnect(conn transport.Conn, md schema.Metadata) bool {
 	node, ok := md.Spec.(*databasev1.Node)
 	if !ok {
 		logger.Panicf("failed to cast node spec")
@@ -261,13 +257,9 @@ func (p *pub) checkClientHealthAndReconnect(conn *grpc.ClientConn, md schema.Met
 		for {
 			select {
 			case <-time.After(backoff):
-				credOpts, errEvict := p.getClientTransportCredentials()
-				if errEvict != nil {
-					p.log.Error().Err(errEvict).Msg("failed to load client TLS credentials (evict)")
-					return
-				}
-				connEvict, errEvict := grpc.NewClient(node.GrpcAddress, append(credOpts, grpc.WithDefaultServiceConfig(retryPolicy))...)
+				connEvict, errEvict := p.dialer.Dial(en.n)
 				if errEvict == nil && p.healthCheck(en.n.String(), connEvict) {
+					version := p.negotiate(name, connEvict)
 					func() {
 						p.mu.Lock()
 						defer p.mu.Unlock()
@@ -275,21 +267,21 @@ func (p *pub) checkClientHealthAndReconnect(conn *grpc.ClientConn, md schema.Met
 							// The client has been removed from evict clients map, just return
 							return
 						}
-						c := clusterv1.NewServiceClient(connEvict)
-						p.active[name] = &client{conn: connEvict, client: c, md: md}
+						p.active[name] = &client{conn: connEvict, md: md, version: version}
 						p.addClient(md)
 						delete(p.evictable, name)
+						p.catchUp(name)
 						p.log.Info().Str("status", p.dump()).Stringer("node", en.n).Msg("node is healthy, move it back to active queue")
 					}()
 					return
 				}
-				if errEvict != nil {
+				if errEvict == nil {
 					_ = connEvict.Close()
 				}
 				if _, ok := p.registered[name]; !ok {
 					return
 				}
-				p.log.Error().Err(errEvict).Msgf("failed to re-connect to grpc server after waiting for %s", backoff)
+				p.log.Error().Err(errEvict).Msgf("failed to re-connect to the node after waiting for %s", backoff)
 			case <-en.c:
 				return
 			case <-p.closer.CloseNotify():
@@ -305,31 +297,37 @@ func (p *pub) checkClientHealthAndReconnect(conn *grpc.ClientConn, md schema.Met
 	return false
 }
 
-func (p *pub) healthCheck(node string, conn *grpc.ClientConn) bool {
-	var resp *grpc_health_v1.HealthCheckResponse
-	if err := grpchelper.Request(context.Background(), rpcTimeout, func(rpcCtx context.Context) (err error) {
-		resp, err = grpc_health_v1.NewHealthClient(conn).Check(rpcCtx,
-			&grpc_health_v1.HealthCheckRequest{
-				Service: "",
-			})
-		return err
-	}); err != nil {
+func (p *pub) healthCheck(node string, conn transport.Conn) bool {
+	if err := grpchelper.Request(context.Background(), rpcTimeout, conn.CheckHealth); err != nil {
 		if e := p.log.Debug(); e.Enabled() {
 			e.Err(err).Str("node", node).Msg("service unhealthy")
 		}
 		return false
 	}
-	if resp.GetStatus() == grpc_health_v1.HealthCheckResponse_SERVING {
-		return true
+	return true
+}
+
+// negotiate returns the protocol version of the messages supported by both the client and the node.
+// The node is treated as the base version if it fails to answer, which keeps the messages readable by it.
+func (p *pub) negotiate(node string, conn transport.Conn) uint32 {
+	ctx, cancel := context.WithTimeout(context.Background(), rpcTimeout)
+	defer cancel()
+	resp, err := conn.HealthCheck(ctx, &clusterv1.HealthCheckRequest{Version: data.ProtocolVersion})
+	if err != nil {
+		p.log.Warn().Err(err).Str("node", node).Msg("failed to negotiate the protocol version, fall back to the base version")
+		return data.ProtocolVersionBase
 	}
-	return false
+	version := data.NegotiateVersion(resp.GetVersion())
+	if version < data.ProtocolVersion {
+		p.log.Info().Str("node", node).Uint32("version", version).Msg("the node is older than the client, send the messages in its version")
+	}
+	return version
 }
 
-func (p *pub) checkServiceHealth(svc string, conn *grpc.ClientConn) *common.Error {
-	client := clusterv1.NewServiceClient(conn)
+func (p *pub) checkServiceHealth(svc string, conn transport.Conn) *common.Error {
 	ctx, cancel := context.WithTimeout(context.Background(), rpcTimeout)
 	defer cancel()
-	resp, err := client.HealthCheck(ctx, &clusterv1.HealthCheckRequest{
+	resp, err := conn.HealthCheck(ctx, &clusterv1.HealthCheckRequest{
 		ServiceName: svc,
 	})
 	if err != nil {
@@ -446,6 +444,23 @@ func (p *pub) dump() string {
 	return fmt.Sprintf("registered: %v, active :%v, evictable :%v", keysRegistered, keysActive, keysEvictable)
 }
 
+// nodeHealth returns the health of the registered nodes.
+func (p *pub) nodeHealth() []queue.NodeHealth {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	result := make([]queue.NodeHealth, 0, len(p.registered))
+	for name, node := range p.registered {
+		state := queue.NodeStateInactive
+		if _, ok := p.active[name]; ok {
+			state = queue.NodeStateActive
+		} else if _, ok := p.evictable[name]; ok {
+			state = queue.NodeStateUnhealthy
+		}
+		result = append(result, queue.NewNodeHealth(node, state))
+	}
+	return result
+}
+
 type evictNode struct {
 	n *databasev1.Node
 	c chan struct{}
@@ -32,6 +32,7 @@ import (
 	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
 	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
 	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/banyand/queue"
 	"github.com/apache/skywalking-banyandb/pkg/bus"
 	"github.com/apache/skywalking-banyandb/pkg/test/flags"
 )
@@ -71,6 +72,26 @@ var _ = ginkgo.Describe("publish clients register/unregister", func() {
 		verifyClients(p, 0, 0, 1, 2)
 	})
 
+	ginkgo.It("should report the health of the nodes", func() {
+		addr1 := getAddress()
+		closeFn := setup(addr1, codes.OK, 200*time.Millisecond)
+		p := newPub()
+		defer func() {
+			p.GracefulStop()
+			closeFn()
+		}()
+		p.OnAddOrUpdate(getDataNode("node1", addr1))
+		p.OnAddOrUpdate(getDataNode("node2", getAddress()))
+		states := make(map[string]queue.NodeState)
+		for _, h := range p.nodeHealth() {
+			states[h.Name] = h.State
+		}
+		gomega.Expect(states).To(gomega.Equal(map[string]queue.NodeState{
+			"node1": queue.NodeStateActive,
+			"node2": queue.NodeStateUnhealthy,
+		}))
+	})
+
 	ginkgo.It("should move back to active queue", func() {
 		addr1 := getAddress()
 		node1 := getDataNode("node1", addr1)
@@ -0,0 +1,213 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package pub
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/apache/skywalking-banyandb/banyand/queue"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+)
+
+const (
+	catchUpBatchSize = 100
+	catchUpTimeout   = 30 * time.Second
+)
+
+type hint struct {
+	m     bus.Message
+	topic bus.Topic
+}
+
+type nodeHints struct {
+	progress queue.CatchUpProgress
+	hints    []hint
+}
+
+// hintedHandoff spools the writes failing to reach the nodes, and replays them once the nodes are active again.
+type hintedHandoff struct {
+	nodes    map[string]*nodeHints
+	capacity int
+	rate     int
+	mu       sync.Mutex
+}
+
+func newHintedHandoff() *hintedHandoff {
+	return &hintedHandoff{nodes: make(map[string]*nodeHints)}
+}
+
+func (h *hintedHandoff) enabled() bool {
+	return h.capacity > 0
+}
+
+// spool keeps the message m for its node. The oldest message of the node is dropped if the spool is full.
+// It returns false if the spool is disabled.
+func (h *hintedHandoff) spool(topic bus.Topic, m bus.Message) bool {
+	if !h.enabled() {
+		return false
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	n := h.nodes[m.Node()]
+	if n == nil {
+		n = &nodeHints{}
+		h.nodes[m.Node()] = n
+	}
+	if len(n.hints) >= h.capacity {
+		n.hints[0] = hint{}
+		n.hints = n.hints[1:]
+		n.progress.Dropped++
+	}
+	n.hints = append(n.hints, hint{topic: topic, m: m})
+	n.progress.Pending = len(n.hints)
+	if n.progress.State != queue.CatchUpStateReplaying {
+		n.progress.State = queue.CatchUpStateSpooling
+	}
+	return true
+}
+
+// start marks the node replaying. It returns false if nothing is spooled for the node or it's being replayed.
+func (h *hintedHandoff) start(node string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	n := h.nodes[node]
+	if n == nil || len(n.hints) == 0 || n.progress.State == queue.CatchUpStateReplaying {
+		return false
+	}
+	now := time.Now()
+	n.progress.State = queue.CatchUpStateReplaying
+	n.progress.StartedAt, n.progress.FinishedAt = &now, nil
+	return true
+}
+
+// next takes up to size messages spooled for the node. It marks the node done if nothing is left.
+func (h *hintedHandoff) next(node string, size int) []hint {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	n := h.nodes[node]
+	if len(n.hints) == 0 {
+		now := time.Now()
+		n.progress.State = queue.CatchUpStateDone
+		n.progress.FinishedAt = &now
+		return nil
+	}
+	size = min(size, len(n.hints))
+	batch := make([]hint, size)
+	copy(batch, n.hints[:size])
+	clear(n.hints[:size])
+	n.hints = n.hints[size:]
+	n.progress.Pending = len(n.hints)
+	return batch
+}
+
+// pause puts the messages not replayed back to the front of the spool of the node, which is unavailable again.
+// They're replayed once the node is active again.
+func (h *hintedHandoff) pause(node string, rest []hint) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	n := h.nodes[node]
+	hints := make([]hint, 0, len(rest)+len(n.hints))
+	hints = append(hints, rest...)
+	n.hints = append(hints, n.hints...)
+	if over := len(n.hints) - h.capacity; over > 0 {
+		n.hints = n.hints[over:]
+		n.progress.Dropped += over
+	}
+	n.progress.Pending = len(n.hints)
+	n.progress.State = queue.CatchUpStateSpooling
+}
+
+func (h *hintedHandoff) report(node string, replayed, failed int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	n := h.nodes[node]
+	n.progress.Replayed += replayed
+	n.progress.Failed += failed
+}
+
+// progress returns the catch-up progress by the nodes.
+func (h *hintedHandoff) progress() map[string]queue.CatchUpProgress {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	result := make(map[string]queue.CatchUpProgress, len(h.nodes))
+	for node, n := range h.nodes {
+		result[node] = n.progress
+	}
+	return result
+}
+
+// catchUp replays the messages spooled for the node in the background.
+// The replay is throttled by the catch-up rate and sent through its own streams,
+// so that the replay burst after the node restarts doesn't slow down the live writes.
+func (p *pub) catchUp(node string) {
+	if !p.hints.enabled() || !p.hints.start(node) {
+		return
+	}
+	if !p.closer.AddRunning() {
+		return
+	}
+	go func() {
+		defer p.closer.Done()
+		ticker := time.NewTicker(time.Second / time.Duration(p.hints.rate))
+		defer ticker.Stop()
+		p.log.Info().Str("node", node).Msg("start replaying the spooled messages")
+		for {
+			batch := p.hints.next(node, catchUpBatchSize)
+			if len(batch) == 0 {
+				p.log.Info().Str("node", node).Msg("the spooled messages are replayed")
+				return
+			}
+			bp := p.NewBatchPublisher(catchUpTimeout).(*batchPublisher)
+			bp.replay = true
+			var failed int
+			for i := range batch {
+				select {
+				case <-ticker.C:
+				case <-p.closer.CloseNotify():
+					_, _ = bp.Close()
+					return
+				}
+				if !p.isActive(node) {
+					_, _ = bp.Close()
+					p.hints.report(node, i-failed, failed)
+					p.hints.pause(node, batch[i:])
+					p.log.Warn().Str("node", node).Int("rest", len(batch)-i).Msg("the node is unavailable again, pause replaying the spooled messages")
+					return
+				}
+				if _, err := bp.Publish(context.Background(), batch[i].topic, batch[i].m); err != nil {
+					failed++
+				}
+			}
+			cee, _ := bp.Close()
+			if cee[node] != nil {
+				// the node fails to handle the batch, which is captured as the dead messages
+				failed = len(batch)
+			}
+			p.hints.report(node, len(batch)-failed, failed)
+		}
+	}()
+}
+
+func (p *pub) isActive(node string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	_, ok := p.active[node]
+	return ok
+}
@@ -0,0 +1,72 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package pub
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/skywalking-banyandb/api/data"
+	"github.com/apache/skywalking-banyandb/banyand/queue"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+)
+
+func TestHintedHandoff(t *testing.T) {
+	h := newHintedHandoff()
+	m := func(id int) bus.Message {
+		return bus.NewBatchMessageWithNode(bus.MessageID(id), "node1", nil)
+	}
+	assert.False(t, h.spool(data.TopicStreamWrite, m(0)), "the spool is disabled by default")
+
+	h.capacity = 3
+	for i := 0; i < 5; i++ {
+		require.True(t, h.spool(data.TopicStreamWrite, m(i)))
+	}
+	p := h.progress()["node1"]
+	assert.Equal(t, queue.CatchUpStateSpooling, p.State)
+	assert.Equal(t, 3, p.Pending)
+	assert.Equal(t, 2, p.Dropped)
+
+	assert.False(t, h.start("node2"))
+	require.True(t, h.start("node1"))
+	assert.False(t, h.start("node1"), "the node is being replayed")
+	batch := h.next("node1", 2)
+	require.Len(t, batch, 2)
+	assert.Equal(t, bus.MessageID(2), batch[0].m.ID())
+	assert.Equal(t, 1, h.progress()["node1"].Pending)
+
+	// the node is unavailable again after replaying the first message
+	h.report("node1", 1, 0)
+	h.pause("node1", batch[1:])
+	p = h.progress()["node1"]
+	assert.Equal(t, queue.CatchUpStateSpooling, p.State)
+	assert.Equal(t, 2, p.Pending)
+
+	require.True(t, h.start("node1"))
+	batch = h.next("node1", 10)
+	require.Len(t, batch, 2)
+	assert.Equal(t, bus.MessageID(3), batch[0].m.ID())
+	h.report("node1", 2, 0)
+	assert.Empty(t, h.next("node1", 10))
+	p = h.progress()["node1"]
+	assert.Equal(t, queue.CatchUpStateDone, p.State)
+	assert.Equal(t, 3, p.Replayed)
+	assert.NotNil(t, p.FinishedAt)
+}
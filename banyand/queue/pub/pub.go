@@ -26,6 +26,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/nats-io/nats.go"
 	"github.com/pkg/errors"
 	"go.uber.org/multierr"
 	"google.golang.org/grpc"
@@ -40,17 +41,23 @@ import (
 	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
 	"github.com/apache/skywalking-banyandb/banyand/metadata"
 	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
+	"github.com/apache/skywalking-banyandb/banyand/observability"
 	"github.com/apache/skywalking-banyandb/banyand/queue"
+	"github.com/apache/skywalking-banyandb/banyand/queue/transport"
 	"github.com/apache/skywalking-banyandb/pkg/bus"
 	"github.com/apache/skywalking-banyandb/pkg/grpchelper"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/meter"
 	"github.com/apache/skywalking-banyandb/pkg/run"
+	"github.com/apache/skywalking-banyandb/pkg/tracecontext"
 )
 
 var (
 	_ run.PreRunner = (*pub)(nil)
 	_ run.Service   = (*pub)(nil)
 	_ run.Config    = (*pub)(nil)
+
+	queuePubScope = observability.RootScope.SubScope("queue_pub")
 )
 
 type pub struct {
@@ -62,9 +69,21 @@ type pub struct {
 	active       map[string]*client
 	handlers     map[bus.Topic]schema.EventHandler
 	closer       *run.Closer
+	dialer       transport.Dialer
+	nc           *nats.Conn
+	omr          observability.MetricsRegistry
+	metrics      *metrics
+	dead         *queue.DeadMessageRecorder
+	hints        *hintedHandoff
+	stopCatchUp  func()
+	stopHealth   func()
 	caCertPath   string
 	prefix       string
+	transport    string
+	natsURL      string
+	natsPrefix   string
 	allowedRoles []databasev1.Role
+	deadCapacity int
 	mu           sync.RWMutex
 	tlsEnabled   bool
 }
@@ -79,6 +98,17 @@ func (p *pub) FlagSet() *run.FlagSet {
 	fs := run.NewFlagSet("queue-client")
 	fs.BoolVar(&p.tlsEnabled, prefixFlag("client-tls"), false, fmt.Sprintf("enable client TLS for %s", p.prefix))
 	fs.StringVar(&p.caCertPath, prefixFlag("client-ca-cert"), "", fmt.Sprintf("CA certificate file to verify the %s server", p.prefix))
+	fs.StringVar(&p.transport, prefixFlag("client-transport"), transport.GRPC,
+		fmt.Sprintf("the transport to reach the %s nodes, %q or %q", p.prefix, transport.GRPC, transport.NATS))
+	fs.StringVar(&p.natsURL, prefixFlag("client-nats-url"), "", "the comma-separated URLs of the NATS servers shared by the nodes")
+	fs.StringVar(&p.natsPrefix, prefixFlag("client-nats-subject-prefix"), transport.DefaultSubjectPrefix,
+		fmt.Sprintf("the prefix of the NATS subjects of the %s nodes", p.prefix))
+	fs.IntVar(&p.deadCapacity, prefixFlag("client-dead-message-capacity"), 0,
+		fmt.Sprintf("the number of the latest messages failing to reach the %s nodes to keep for debugging, 0 disables the capture", p.prefix))
+	fs.IntVar(&p.hints.capacity, prefixFlag("client-hint-capacity"), 0,
+		fmt.Sprintf("the number of the writes failing to reach a %s node to spool for the catch-up after it restarts, 0 disables the spool", p.prefix))
+	fs.IntVar(&p.hints.rate, prefixFlag("client-catch-up-rate"), 1000,
+		fmt.Sprintf("the number of the spooled writes replayed to a %s node per second", p.prefix))
 	return fs
 }
 
@@ -87,7 +117,10 @@ func (p *pub) Validate() error {
 	if p.tlsEnabled && p.caCertPath == "" {
 		return fmt.Errorf("TLS is enabled (--internal-tls), but no CA certificate file was provided (--internal-ca-cert is required)")
 	}
-	return nil
+	if p.hints.enabled() && p.hints.rate <= 0 {
+		return fmt.Errorf("the catch-up rate should be positive, but got %d", p.hints.rate)
+	}
+	return transport.Validate(p.transport, p.natsURL)
 }
 
 func (p *pub) Register(topic bus.Topic, handler schema.EventHandler) {
@@ -107,6 +140,22 @@ func (p *pub) GracefulStop() {
 		_ = c.conn.Close()
 	}
 	p.active = nil
+	p.dead.Close()
+	if p.stopHealth != nil {
+		p.stopHealth()
+	}
+	if p.stopCatchUp != nil {
+		p.stopCatchUp()
+	}
+	_ = p.dialer.Close()
+	if p.nc != nil {
+		p.nc.Close()
+	}
+}
+
+// ShutdownPhase implements run.PhasedService.
+func (p *pub) ShutdownPhase() run.ShutdownPhase {
+	return run.ShutdownPhaseQueue
 }
 
 // Serve implements run.Service.
@@ -174,7 +223,7 @@ func (p *pub) Broadcast(timeout time.Duration, topic bus.Topic, messages bus.Mes
 		wg.Add(1)
 		go func(n string) {
 			defer wg.Done()
-			f, err := p.publish(timeout, topic, bus.NewMessageWithNode(messages.ID(), n, messages.Data()))
+			f, err := p.publish(timeout, topic, bus.NewMessageWithNode(messages.ID(), n, messages.Data()).WithTraceContext(messages.TraceContext()))
 			futureCh <- publishResult{n: n, f: f, e: err}
 		}(n)
 	}
@@ -214,36 +263,48 @@ type publishResult struct {
 
 func (p *pub) publish(timeout time.Duration, topic bus.Topic, messages ...bus.Message) (bus.Future, error) {
 	var err error
-	f := &future{}
-	handleMessage := func(m bus.Message, err error) error {
-		r, errSend := messageToRequest(topic, m)
-		if errSend != nil {
-			return multierr.Append(err, fmt.Errorf("failed to marshal message[%d]: %w", m.ID(), errSend))
-		}
+	f := &future{pub: p}
+	handleMessage := func(m bus.Message) error {
 		node := m.Node()
 		p.mu.RLock()
 		client, ok := p.active[node]
 		p.mu.RUnlock()
 		if !ok {
-			return multierr.Append(err, fmt.Errorf("failed to get client for node %s", node))
+			return fmt.Errorf("failed to get client for node %s", node)
+		}
+		rr, errSend := messageToRequests(topic, m, client.version)
+		if errSend != nil {
+			return fmt.Errorf("failed to marshal message[%d]: %w", m.ID(), errSend)
 		}
+		// the future expects one response for every message.
+		if len(rr) != 1 {
+			return fmt.Errorf("message[%d] can't be sent to node %s of protocol version %d in one request: %w",
+				m.ID(), node, client.version, data.ErrUnsupportedVersion)
+		}
+		r := rr[0]
 		ctx, cancel := context.WithTimeout(context.Background(), timeout)
 		f.cancelFn = append(f.cancelFn, cancel)
-		stream, errCreateStream := client.client.Send(ctx)
+		ctx = tracecontext.AppendToOutgoingContext(ctx, m.TraceContext())
+		stream, errCreateStream := client.conn.Send(ctx)
 		if errCreateStream != nil {
-			return multierr.Append(err, fmt.Errorf("failed to get stream for node %s: %w", node, errCreateStream))
+			return fmt.Errorf("failed to get stream for node %s: %w", node, errCreateStream)
 		}
 		errSend = stream.Send(r)
 		if errSend != nil {
-			return multierr.Append(err, fmt.Errorf("failed to send message to node %s: %w", node, errSend))
+			return fmt.Errorf("failed to send message to node %s: %w", node, errSend)
 		}
 		f.clients = append(f.clients, stream)
 		f.topics = append(f.topics, topic)
 		f.nodes = append(f.nodes, node)
-		return err
+		return nil
 	}
 	for _, m := range messages {
-		err = handleMessage(m, err)
+		if errMsg := handleMessage(m); errMsg != nil {
+			p.deadMessage(topic, m.Node(), m, errMsg)
+			err = multierr.Append(err, errMsg)
+			continue
+		}
+		p.metrics.totalMsgSent.Inc(1, topic.String())
 	}
 	return f, err
 }
@@ -279,10 +340,22 @@ func New(metadata metadata.Repo, roles ...databasev1.Role) queue.Client {
 		closer:       run.NewCloser(1),
 		allowedRoles: roles,
 		prefix:       strBuilder.String(),
+		transport:    transport.GRPC,
+		metrics:      newMetrics(observability.BypassRegistry.With(queuePubScope)),
+		hints:        newHintedHandoff(),
 	}
+	p.dialer = transport.NewGRPCDialer(p.dialOptions)
 	return p
 }
 
+// SetMetricsRegistry makes the client report its metrics to omr.
+// The metrics service publishes the metrics through the client, so the registry is set after both are created.
+func SetMetricsRegistry(c queue.Client, omr observability.MetricsRegistry) {
+	if p, ok := c.(*pub); ok {
+		p.omr = omr
+	}
+}
+
 // NewWithoutMetadata returns a new queue client without metadata, defaulting to data nodes.
 func NewWithoutMetadata() queue.Client {
 	p := New(nil, databasev1.Role_ROLE_DATA)
@@ -300,28 +373,63 @@ func (p *pub) PreRun(context.Context) error {
 	}
 
 	p.log = logger.GetLogger("server-queue-pub-" + p.prefix)
+	if p.omr != nil {
+		p.metrics = newMetrics(p.omr.With(queuePubScope.ConstLabels(meter.LabelPairs{"client": p.prefix})))
+	}
+	p.dead = queue.NewDeadMessageRecorder(p.Name(), p.deadCapacity)
+	p.stopHealth = queue.RegisterNodeHealth(p.Name(), p.nodeHealth)
+	if p.hints.enabled() {
+		p.stopCatchUp = queue.RegisterCatchUp(p.Name(), p.hints.progress)
+	}
+	if p.transport != transport.NATS {
+		return nil
+	}
+	nc, err := transport.ConnectNATS(p.natsURL, p.Name())
+	if err != nil {
+		return errors.WithMessagef(err, "failed to connect to the NATS servers %s", p.natsURL)
+	}
+	dialer, err := transport.NewNATSDialer(nc, p.natsPrefix)
+	if err != nil {
+		nc.Close()
+		return err
+	}
+	p.nc, p.dialer = nc, dialer
 	return nil
 }
 
-func messageToRequest(topic bus.Topic, m bus.Message) (*clusterv1.SendRequest, error) {
-	r := &clusterv1.SendRequest{
-		Topic:     topic.String(),
-		MessageId: uint64(m.ID()),
-		BatchMod:  m.BatchModeEnabled(),
-	}
+// messageToRequests encodes the message in the protocol version of the node.
+// The message is converted into the older variants if the node doesn't support the version of the client.
+func messageToRequests(topic bus.Topic, m bus.Message, version uint32) ([]*clusterv1.SendRequest, error) {
 	message, ok := m.Data().(proto.Message)
 	if !ok {
 		return nil, fmt.Errorf("invalid message type %T", m.Data())
 	}
-	data, err := proto.Marshal(message)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal message %T: %w", m, err)
+	messages := []proto.Message{message}
+	if downgrade, ok := data.TopicDowngradeMap[topic]; ok && version < data.ProtocolVersion {
+		var err error
+		if messages, err = downgrade(message, version); err != nil {
+			return nil, err
+		}
+	}
+	rr := make([]*clusterv1.SendRequest, 0, len(messages))
+	for _, msg := range messages {
+		body, err := proto.Marshal(msg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal message %T: %w", m, err)
+		}
+		rr = append(rr, &clusterv1.SendRequest{
+			Topic:     topic.String(),
+			MessageId: uint64(m.ID()),
+			BatchMod:  m.BatchModeEnabled(),
+			Body:      body,
+			Version:   version,
+		})
 	}
-	r.Body = data
-	return r, nil
+	return rr, nil
 }
 
 type future struct {
+	pub      *pub
 	clients  []clusterv1.Service_SendClient
 	cancelFn []func()
 	topics   []bus.Topic
@@ -342,12 +450,20 @@ func (l *future) Get() (bus.Message, error) {
 		l.cancelFn = l.cancelFn[1:]
 		l.nodes = l.nodes[1:]
 	}()
+	// the failed messages carry the node as well, which tells the caller the node failing to answer.
 	resp, err := c.Recv()
 	if err != nil {
-		return bus.Message{}, err
+		l.pub.deadResponse(t, n, 0, err)
+		return bus.NewMessageWithNode(0, n, nil), err
 	}
 	if resp.Error != "" {
-		return bus.Message{}, errors.New(resp.Error)
+		err = errors.New(resp.Error)
+		if resp.Status == modelv1.Status_STATUS_QUERY_NOT_ADMITTED {
+			// the callers tell the rejected queries from the failed ones.
+			err = common.NewErrorWithStatus(resp.Status, resp.Error)
+		}
+		l.pub.deadResponse(t, n, resp.MessageId, err)
+		return bus.NewMessageWithNode(bus.MessageID(resp.MessageId), n, nil), err
 	}
 	if resp.Body == nil {
 		return bus.NewMessageWithNode(bus.MessageID(resp.MessageId), n, nil), nil
@@ -391,10 +507,49 @@ func isFailoverError(err error) bool {
 	return s.Code() == codes.Unavailable || s.Code() == codes.DeadlineExceeded
 }
 
-func (p *pub) getClientTransportCredentials() ([]grpc.DialOption, error) {
+func (p *pub) dialOptions() ([]grpc.DialOption, error) {
 	opts, err := grpchelper.SecureOptions(nil, p.tlsEnabled, false, p.caCertPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load TLS config: %w", err)
 	}
-	return opts, nil
+	return append(opts, grpc.WithDefaultServiceConfig(retryPolicy)), nil
+}
+
+// deadMessage counts and captures the message m failing to reach the node.
+func (p *pub) deadMessage(topic bus.Topic, node string, m bus.Message, reason error) {
+	p.metrics.totalMsgSentErr.Inc(1, topic.String())
+	p.dead.Capture(queue.DeadMessage{
+		Topic:     topic.String(),
+		Node:      node,
+		MessageID: uint64(m.ID()),
+		Reason:    reason.Error(),
+		Payload:   queue.DescribePayload(m.Data()),
+	})
+}
+
+// deadResponse counts and captures the message which the node failed to handle.
+func (p *pub) deadResponse(topic bus.Topic, node string, messageID uint64, reason error) {
+	p.metrics.totalMsgReceivedErr.Inc(1, topic.String())
+	p.dead.Capture(queue.DeadMessage{
+		Topic:     topic.String(),
+		Node:      node,
+		MessageID: messageID,
+		Reason:    reason.Error(),
+	})
+}
+
+type metrics struct {
+	totalMsgSent        meter.Counter
+	totalMsgSentErr     meter.Counter
+	totalMsgReceivedErr meter.Counter
+	totalMsgSpooled     meter.Counter
+}
+
+func newMetrics(factory *observability.Factory) *metrics {
+	return &metrics{
+		totalMsgSent:        factory.NewCounter("total_msg_sent", "topic"),
+		totalMsgSentErr:     factory.NewCounter("total_msg_sent_err", "topic"),
+		totalMsgReceivedErr: factory.NewCounter("total_msg_received_err", "topic"),
+		totalMsgSpooled:     factory.NewCounter("total_msg_spooled", "topic"),
+	}
 }
@@ -0,0 +1,117 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package pub
+
+import (
+	"context"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+	"github.com/onsi/gomega/gleak"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+
+	"github.com/apache/skywalking-banyandb/api/data"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/banyand/queue/transport"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/test/flags"
+)
+
+var _ = ginkgo.Describe("Publish through NATS", func() {
+	var goods []gleak.Goroutine
+	var ns *server.Server
+	var p *pub
+	var stopNodes []func()
+
+	ginkgo.BeforeEach(func() {
+		goods = gleak.Goroutines()
+		var err error
+		ns, err = server.NewServer(&server.Options{Host: "127.0.0.1", Port: -1, NoSigs: true})
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		go ns.Start()
+		gomega.Expect(ns.ReadyForConnections(10 * time.Second)).Should(gomega.BeTrue())
+		p = newPub()
+		p.transport = transport.NATS
+		p.natsURL = ns.ClientURL()
+		p.natsPrefix = transport.DefaultSubjectPrefix
+		gomega.Expect(p.PreRun(context.Background())).Should(gomega.Succeed())
+	})
+
+	ginkgo.AfterEach(func() {
+		p.GracefulStop()
+		for _, stop := range stopNodes {
+			stop()
+		}
+		stopNodes = nil
+		ns.Shutdown()
+		ns.WaitForShutdown()
+		gomega.Eventually(gleak.Goroutines, flags.EventuallyTimeout).ShouldNot(gleak.HaveLeaked(goods))
+	})
+
+	serveNode := func(name string) {
+		nc, err := transport.ConnectNATS(ns.ClientURL(), name)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		srv, err := transport.ServeNATS(nc, transport.DefaultSubjectPrefix, name, &mockServer{
+			code:         codes.OK,
+			statusCode:   modelv1.Status_STATUS_SUCCEED,
+			healthServer: health.NewServer(),
+			version:      data.ProtocolVersion,
+		}, logger.GetLogger("test"))
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		stopNodes = append(stopNodes, func() {
+			srv.Stop()
+			nc.Close()
+		})
+	}
+
+	ginkgo.It("should publish messages", func() {
+		serveNode("node1")
+		p.OnAddOrUpdate(getDataNode("node1", "node1:17912"))
+		verifyClients(p, 1, 0, 1, 0)
+
+		f, err := p.Publish(context.TODO(), data.TopicStreamWrite,
+			bus.NewMessageWithNode(bus.MessageID(1), "node1", &streamv1.InternalWriteRequest{}))
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		_, err = f.Get()
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+
+		bp := p.NewBatchPublisher(3 * time.Second)
+		for i := 0; i < 10; i++ {
+			_, err = bp.Publish(context.TODO(), data.TopicStreamWrite,
+				bus.NewBatchMessageWithNode(bus.MessageID(i), "node1", &streamv1.InternalWriteRequest{}))
+			gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		}
+		cee, err := bp.Close()
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(cee).Should(gomega.BeEmpty())
+	})
+
+	ginkgo.It("should go to evict queue when node doesn't serve", func() {
+		p.OnAddOrUpdate(getDataNode("node1", "node1:17912"))
+		verifyClients(p, 0, 1, 0, 1)
+		serveNode("node1")
+		gomega.Eventually(func(g gomega.Gomega) {
+			verifyClientsWithGomega(g, p, data.TopicCommon, 1, 0, 1, 1)
+		}, flags.EventuallyTimeout).Should(gomega.Succeed())
+	})
+})
@@ -66,6 +66,7 @@ type mockServer struct {
 	latency      time.Duration
 	code         codes.Code
 	statusCode   modelv1.Status
+	version      uint32
 }
 
 func (s *mockServer) Send(stream clusterv1.Service_SendServer) (err error) {
@@ -136,8 +137,9 @@ func (s *mockServer) Send(stream clusterv1.Service_SendServer) (err error) {
 
 func (s *mockServer) HealthCheck(context.Context, *clusterv1.HealthCheckRequest) (*clusterv1.HealthCheckResponse, error) {
 	return &clusterv1.HealthCheckResponse{
-		Status: s.statusCode,
-		Error:  s.errMsg,
+		Status:  s.statusCode,
+		Error:   s.errMsg,
+		Version: s.version,
 	}, nil
 }
 
@@ -149,6 +151,7 @@ func setup(address string, code codes.Code, latency time.Duration) func() {
 		statusCode:   modelv1.Status_STATUS_SUCCEED,
 		latency:      latency,
 		healthServer: hs,
+		version:      data.ProtocolVersion,
 	})
 	grpc_health_v1.RegisterHealthServer(s, hs)
 	lis, err := net.Listen("tcp", address)
@@ -173,6 +176,7 @@ func setupWithStatus(address string, statusCode modelv1.Status) (*health.Server,
 		statusCode:   statusCode,
 		errMsg:       modelv1.Status_name[int32(statusCode)],
 		healthServer: hs,
+		version:      data.ProtocolVersion,
 	})
 	grpc_health_v1.RegisterHealthServer(s, hs)
 	lis, err := net.Listen("tcp", address)
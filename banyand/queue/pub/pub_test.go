@@ -34,6 +34,7 @@ import (
 	"github.com/apache/skywalking-banyandb/api/data"
 	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
 	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/banyand/queue"
 	"github.com/apache/skywalking-banyandb/pkg/bus"
 	"github.com/apache/skywalking-banyandb/pkg/test/flags"
 )
@@ -78,6 +79,38 @@ var _ = ginkgo.Describe("Publish and Broadcast", func() {
 			gomega.Expect(cee).Should(gomega.HaveLen(0))
 		})
 
+		ginkgo.It("should replay the spooled messages once the node is active", func() {
+			addr1 := getAddress()
+			closeFn1 := setup(addr1, codes.OK, 0)
+			p := newPub()
+			p.hints.capacity = 100
+			p.hints.rate = 1000
+			defer func() {
+				p.GracefulStop()
+				closeFn1()
+			}()
+
+			// node1 isn't active yet, and the messages are spooled
+			bp := p.NewBatchPublisher(3 * time.Second)
+			for i := 0; i < 10; i++ {
+				_, err := bp.Publish(context.TODO(), data.TopicStreamWrite,
+					bus.NewBatchMessageWithNode(bus.MessageID(i), "node1", &streamv1.InternalWriteRequest{}))
+				gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+			}
+			_, err := bp.Close()
+			gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+			gomega.Expect(p.hints.progress()["node1"].Pending).Should(gomega.Equal(10))
+
+			p.OnAddOrUpdate(getDataNode("node1", addr1))
+			gomega.Eventually(func() queue.CatchUpProgress {
+				return p.hints.progress()["node1"]
+			}, flags.EventuallyTimeout).Should(gomega.And(
+				gomega.HaveField("State", queue.CatchUpStateDone),
+				gomega.HaveField("Replayed", 10),
+				gomega.HaveField("Pending", 0),
+			))
+		})
+
 		ginkgo.It("should go to evict queue when node is unavailable", func() {
 			addr1 := getAddress()
 			addr2 := getAddress()
@@ -197,6 +230,42 @@ var _ = ginkgo.Describe("Publish and Broadcast", func() {
 				return len(p.active)
 			}, "1s").Should(gomega.Equal(2))
 		})
+
+		ginkgo.It("should capture the messages failing to reach the nodes", func() {
+			addr1 := getAddress()
+			_, closeFn1 := setupWithStatus(addr1, modelv1.Status_STATUS_DISK_FULL)
+			p := newPub()
+			p.dead = queue.NewDeadMessageRecorder("test-pub", 5)
+			defer func() {
+				p.GracefulStop()
+				closeFn1()
+			}()
+			p.OnAddOrUpdate(getDataNode("node1", addr1))
+
+			bp := p.NewBatchPublisher(3 * time.Second)
+			for i := 0; i < 10; i++ {
+				_, err := bp.Publish(context.TODO(), data.TopicStreamWrite,
+					bus.NewBatchMessageWithNode(bus.MessageID(i), "node1", &streamv1.InternalWriteRequest{ShardId: uint32(i)}))
+				gomega.Expect(err).Should(gomega.HaveOccurred())
+			}
+			_, err := bp.Close()
+			gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+			_, err = p.Publish(context.TODO(), data.TopicMeasureWrite,
+				bus.NewMessageWithNode(bus.MessageID(10), "absent", &streamv1.InternalWriteRequest{}))
+			gomega.Expect(err).Should(gomega.HaveOccurred())
+
+			s := p.dead.Summary()
+			gomega.Expect(s.Counts).Should(gomega.Equal(map[string]int{
+				data.TopicStreamWrite.String():  10,
+				data.TopicMeasureWrite.String(): 1,
+			}))
+			gomega.Expect(s.Messages).Should(gomega.HaveLen(5))
+			last := s.Messages[4]
+			gomega.Expect(last.Node).Should(gomega.Equal("absent"))
+			gomega.Expect(last.MessageID).Should(gomega.Equal(uint64(10)))
+			gomega.Expect(s.Messages[3].Node).Should(gomega.Equal("node1"))
+			gomega.Expect(s.Messages[3].Payload).Should(gomega.MatchRegexp(`"shardId":\s*9`))
+		})
 	})
 
 	ginkgo.Context("Broadcast", func() {
@@ -0,0 +1,190 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package pub
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+	"github.com/onsi/gomega/gleak"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/apache/skywalking-banyandb/api/data"
+	clusterv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/cluster/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+	"github.com/apache/skywalking-banyandb/pkg/test/flags"
+)
+
+// versionServer records the requests, and answers the health checks with its protocol version.
+type versionServer struct {
+	clusterv1.UnimplementedServiceServer
+	requests []*clusterv1.SendRequest
+	version  uint32
+	mu       sync.Mutex
+}
+
+func (s *versionServer) Send(stream clusterv1.Service_SendServer) error {
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return stream.Send(&clusterv1.SendResponse{Status: modelv1.Status_STATUS_SUCCEED})
+		}
+		if err != nil {
+			return err
+		}
+		s.mu.Lock()
+		s.requests = append(s.requests, req)
+		s.mu.Unlock()
+	}
+}
+
+func (s *versionServer) HealthCheck(context.Context, *clusterv1.HealthCheckRequest) (*clusterv1.HealthCheckResponse, error) {
+	return &clusterv1.HealthCheckResponse{Status: modelv1.Status_STATUS_SUCCEED, Version: s.version}, nil
+}
+
+func (s *versionServer) received() []*clusterv1.SendRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+func setupVersion(address string, version uint32) (*versionServer, func()) {
+	s := grpc.NewServer()
+	vs := &versionServer{version: version}
+	clusterv1.RegisterServiceServer(s, vs)
+	grpc_health_v1.RegisterHealthServer(s, health.NewServer())
+	lis, err := net.Listen("tcp", address)
+	gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+	go func() {
+		_ = s.Serve(lis)
+	}()
+	return vs, s.GracefulStop
+}
+
+var _ = ginkgo.Describe("Protocol version", func() {
+	var goods []gleak.Goroutine
+	ginkgo.BeforeEach(func() {
+		goods = gleak.Goroutines()
+	})
+	ginkgo.AfterEach(func() {
+		gomega.Eventually(gleak.Goroutines, flags.EventuallyTimeout).ShouldNot(gleak.HaveLeaked(goods))
+	})
+
+	batch := func() *streamv1.InternalWriteRequest {
+		return &streamv1.InternalWriteRequest{Batch: &streamv1.InternalWriteBatch{
+			Group: "default",
+			Requests: []*streamv1.InternalWriteRequest{
+				{ShardId: 1, ElementId: 1},
+				{ShardId: 1, ElementId: 2},
+			},
+		}}
+	}
+
+	publish := func(p *pub, node string, messages ...*streamv1.InternalWriteRequest) error {
+		bp := p.NewBatchPublisher(3 * time.Second)
+		var errPublish error
+		for i, m := range messages {
+			if _, err := bp.Publish(context.TODO(), data.TopicStreamWrite, bus.NewBatchMessageWithNode(bus.MessageID(i), node, m)); err != nil {
+				errPublish = err
+			}
+		}
+		_, err := bp.Close()
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		return errPublish
+	}
+
+	ginkgo.DescribeTable("negotiates the version with the node",
+		func(serverVersion, negotiated uint32) {
+			addr := getAddress()
+			_, closeFn := setupVersion(addr, serverVersion)
+			p := newPub()
+			defer func() {
+				p.GracefulStop()
+				closeFn()
+			}()
+			p.OnAddOrUpdate(getDataNode("node1", addr))
+			verifyClients(p, 1, 0, 1, 0)
+			gomega.Expect(p.active["node1"].version).Should(gomega.Equal(negotiated))
+		},
+		ginkgo.Entry("the node older than the versioning", uint32(0), data.ProtocolVersionBase),
+		ginkgo.Entry("the node of an older version", data.ProtocolVersionStreamElementChunk, data.ProtocolVersionStreamElementChunk),
+		ginkgo.Entry("the node of the same version", data.ProtocolVersion, data.ProtocolVersion),
+		ginkgo.Entry("the node of a newer version", data.ProtocolVersion+1, data.ProtocolVersion),
+	)
+
+	ginkgo.It("should send the batch as a whole to the node supporting it", func() {
+		addr := getAddress()
+		vs, closeFn := setupVersion(addr, data.ProtocolVersion)
+		p := newPub()
+		defer func() {
+			p.GracefulStop()
+			closeFn()
+		}()
+		p.OnAddOrUpdate(getDataNode("node1", addr))
+		gomega.Expect(publish(p, "node1", batch())).Should(gomega.Succeed())
+		reqs := vs.received()
+		gomega.Expect(reqs).Should(gomega.HaveLen(1))
+		gomega.Expect(reqs[0].Version).Should(gomega.Equal(data.ProtocolVersion))
+	})
+
+	ginkgo.It("should split the batch for the node older than the batches", func() {
+		addr := getAddress()
+		vs, closeFn := setupVersion(addr, 0)
+		p := newPub()
+		defer func() {
+			p.GracefulStop()
+			closeFn()
+		}()
+		p.OnAddOrUpdate(getDataNode("node1", addr))
+		gomega.Expect(publish(p, "node1", batch())).Should(gomega.Succeed())
+		reqs := vs.received()
+		gomega.Expect(reqs).Should(gomega.HaveLen(2))
+		for i, r := range reqs {
+			gomega.Expect(r.Version).Should(gomega.Equal(data.ProtocolVersionBase))
+			iwr := &streamv1.InternalWriteRequest{}
+			gomega.Expect(proto.Unmarshal(r.Body, iwr)).Should(gomega.Succeed())
+			gomega.Expect(iwr.ElementId).Should(gomega.Equal(uint64(i + 1)))
+		}
+	})
+
+	ginkgo.It("should reject the chunks for the node older than the chunks", func() {
+		addr := getAddress()
+		vs, closeFn := setupVersion(addr, 0)
+		p := newPub()
+		defer func() {
+			p.GracefulStop()
+			closeFn()
+		}()
+		p.OnAddOrUpdate(getDataNode("node1", addr))
+		chunk := &streamv1.InternalWriteRequest{ShardId: 1, Chunk: &streamv1.ElementChunk{Id: 1, Total: 2}}
+		err := publish(p, "node1", chunk, &streamv1.InternalWriteRequest{ShardId: 1})
+		gomega.Expect(errors.Is(err, data.ErrUnsupportedVersion)).Should(gomega.BeTrue())
+		gomega.Expect(vs.received()).Should(gomega.HaveLen(1))
+	})
+})
@@ -20,11 +20,19 @@ package sub
 import (
 	"context"
 
+	"github.com/apache/skywalking-banyandb/api/data"
 	clusterv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/cluster/v1"
 	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
 )
 
 func (s *server) HealthCheck(_ context.Context, req *clusterv1.HealthCheckRequest) (*clusterv1.HealthCheckResponse, error) {
+	// The empty service name checks the node itself, which tells the clients the protocol version as well.
+	if req.ServiceName == "" {
+		return &clusterv1.HealthCheckResponse{
+			Status:  modelv1.Status_STATUS_SUCCEED,
+			Version: data.ProtocolVersion,
+		}, nil
+	}
 	if t, ok := s.topicMap[req.ServiceName]; ok {
 		ll := s.listeners[t]
 		for _, l := range ll {
@@ -33,16 +41,19 @@ func (s *server) HealthCheck(_ context.Context, req *clusterv1.HealthCheckReques
 					ServiceName: req.ServiceName,
 					Status:      err.Status(),
 					Error:       err.Error(),
+					Version:     data.ProtocolVersion,
 				}, nil
 			}
 		}
 		return &clusterv1.HealthCheckResponse{
 			ServiceName: req.ServiceName,
 			Status:      modelv1.Status_STATUS_SUCCEED,
+			Version:     data.ProtocolVersion,
 		}, nil
 	}
 	return &clusterv1.HealthCheckResponse{
 		ServiceName: req.ServiceName,
 		Status:      modelv1.Status_STATUS_NOT_FOUND,
+		Version:     data.ProtocolVersion,
 	}, nil
 }
@@ -25,6 +25,7 @@ import (
 
 	"github.com/apache/skywalking-banyandb/api/common"
 	clusterv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/cluster/v1"
+	"github.com/apache/skywalking-banyandb/banyand/queue"
 	"github.com/apache/skywalking-banyandb/pkg/bus"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
 )
@@ -46,7 +47,9 @@ func (s *server) handleEOF(stream clusterv1.Service_SendServer, topic *bus.Topic
 		s.reply(stream, writeEntity, le, "")
 		return
 	}
+	handleStart := time.Now()
 	message := listener.Rev(stream.Context(), bus.NewMessage(bus.MessageID(0), dataCollection))
+	s.metrics.handlerLatency.Observe(time.Since(handleStart).Seconds(), topic.String())
 	var resp *clusterv1.SendResponse
 	data := message.Data()
 	if data != nil {
@@ -57,6 +60,11 @@ func (s *server) handleEOF(stream clusterv1.Service_SendServer, topic *bus.Topic
 				Error:     d.Error(),
 				Status:    d.Status(),
 			}
+			s.dead.Capture(queue.DeadMessage{
+				Topic:   topic.String(),
+				Reason:  d.Error(),
+				Payload: queue.DescribePayload(dataCollection),
+			})
 		default:
 			resp = &clusterv1.SendResponse{
 				MessageId: writeEntity.MessageId,
@@ -0,0 +1,60 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package sub
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/apache/skywalking-banyandb/api/data"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+)
+
+type integrityService struct {
+	databasev1.UnimplementedIntegrityServiceServer
+	ser *server
+}
+
+// Digest sends the request to the listeners of the catalogs, and returns the digest of the one holding the group.
+func (s *integrityService) Digest(ctx context.Context, req *databasev1.IntegrityServiceDigestRequest) (*databasev1.IntegrityServiceDigestResponse, error) {
+	if req.GetGroup() == "" {
+		return nil, status.Error(codes.InvalidArgument, "group is required")
+	}
+	if req.GetBucket() != nil && req.GetBucket().AsDuration() <= 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "bucket %s should be positive", req.GetBucket().AsDuration())
+	}
+	s.ser.listenersLock.RLock()
+	defer s.ser.listenersLock.RUnlock()
+	for _, l := range s.ser.getListeners(data.TopicShardDigest) {
+		switch d := l.Rev(ctx, bus.NewMessage(bus.MessageID(0), req)).Data().(type) {
+		case nil:
+			continue
+		case error:
+			return nil, status.Error(codes.Internal, d.Error())
+		case *databasev1.IntegrityServiceDigestResponse:
+			return d, nil
+		default:
+			logger.Panicf("invalid data type %T", d)
+		}
+	}
+	return nil, status.Errorf(codes.NotFound, "group %s isn't found on the node", req.GetGroup())
+}
@@ -0,0 +1,183 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package sub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"go.uber.org/multierr"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/apache/skywalking-banyandb/api/data"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+)
+
+type replicationService struct {
+	databasev1.UnimplementedReplicationServiceServer
+	ser *server
+}
+
+// ReplicatePart writes the files of a part into the staging directory. A file is resumed from the offset of its first chunk,
+// so the sender could stop at any chunk and continue with the received size reported by ReplicaStatus.
+func (r *replicationService) ReplicatePart(stream databasev1.ReplicationService_ReplicatePartServer) (err error) {
+	var dir, path string
+	var file *os.File
+	var received uint64
+	defer func() {
+		if file != nil {
+			err = multierr.Append(err, file.Close())
+		}
+	}()
+	for {
+		req, errRecv := stream.Recv()
+		if errors.Is(errRecv, io.EOF) {
+			return stream.SendAndClose(&databasev1.ReplicatePartResponse{ReceivedBytes: received})
+		}
+		if errRecv != nil {
+			return errRecv
+		}
+		if dir == "" {
+			if req.GetPart() == nil {
+				return status.Error(codes.InvalidArgument, "the part is absent in the first request")
+			}
+			result, errDir := r.dispatch(stream.Context(), data.TopicReplicaDir, req.GetPart(), req.GetPart())
+			if errDir != nil {
+				return errDir
+			}
+			dir = result.(string)
+			if err = os.MkdirAll(dir, storage.DirPerm); err != nil {
+				return status.Errorf(codes.Internal, "failed to create %s: %v", dir, err)
+			}
+		}
+		if req.GetPath() == "" {
+			continue
+		}
+		if file == nil || path != req.GetPath() {
+			if file != nil {
+				if err = file.Close(); err != nil {
+					return status.Errorf(codes.Internal, "failed to close %s: %v", path, err)
+				}
+				file = nil
+			}
+			path = req.GetPath()
+			if path != filepath.Base(path) || path == "." || path == ".." {
+				return status.Errorf(codes.InvalidArgument, "invalid file name %q", path)
+			}
+			if file, err = openStagedFile(filepath.Join(dir, path), req.GetOffset()); err != nil {
+				return err
+			}
+		}
+		if _, err = file.Write(req.GetChunk()); err != nil {
+			return status.Errorf(codes.Internal, "failed to write %s: %v", path, err)
+		}
+		received += uint64(len(req.GetChunk()))
+		if req.GetLast() {
+			if err = file.Close(); err != nil {
+				return status.Errorf(codes.Internal, "failed to close %s: %v", path, err)
+			}
+			file = nil
+		}
+	}
+}
+
+// openStagedFile opens a staged file to append the chunks from offset, which can't exceed the size of the file.
+func openStagedFile(name string, offset uint64) (*os.File, error) {
+	file, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY, storage.FilePerm)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to open %s: %v", name, err)
+	}
+	info, err := file.Stat()
+	if err == nil && uint64(info.Size()) < offset {
+		err = fmt.Errorf("the offset %d exceeds the received size %d", offset, info.Size())
+		_ = file.Close()
+		return nil, status.Errorf(codes.OutOfRange, "failed to resume %s: %v", name, err)
+	}
+	if err == nil {
+		err = file.Truncate(int64(offset))
+	}
+	if err == nil {
+		_, err = file.Seek(int64(offset), io.SeekStart)
+	}
+	if err != nil {
+		_ = file.Close()
+		return nil, status.Errorf(codes.Internal, "failed to resume %s: %v", name, err)
+	}
+	return file, nil
+}
+
+func (r *replicationService) ReplicaStatus(ctx context.Context, req *databasev1.ReplicaStatusRequest) (*databasev1.ReplicaStatusResponse, error) {
+	result, err := r.dispatch(ctx, data.TopicReplicaStatus, req.GetShard(), req)
+	if err != nil {
+		return nil, err
+	}
+	resp, ok := result.(*databasev1.ReplicaStatusResponse)
+	if !ok {
+		logger.Panicf("invalid data type %T", result)
+	}
+	return resp, nil
+}
+
+func (r *replicationService) ReplicateSeries(ctx context.Context, req *databasev1.ReplicateSeriesRequest) (*databasev1.ReplicateSeriesResponse, error) {
+	result, err := r.dispatch(ctx, data.TopicReplicaSeries, req.GetShard(), req)
+	if err != nil {
+		return nil, err
+	}
+	resp, ok := result.(*databasev1.ReplicateSeriesResponse)
+	if !ok {
+		logger.Panicf("invalid data type %T", result)
+	}
+	return resp, nil
+}
+
+func (r *replicationService) CommitReplica(ctx context.Context, req *databasev1.CommitReplicaRequest) (*databasev1.CommitReplicaResponse, error) {
+	result, err := r.dispatch(ctx, data.TopicReplicaCommit, req.GetShard(), req)
+	if err != nil {
+		return nil, err
+	}
+	resp, ok := result.(*databasev1.CommitReplicaResponse)
+	if !ok {
+		logger.Panicf("invalid data type %T", result)
+	}
+	return resp, nil
+}
+
+// dispatch sends the request to the listener of the catalog of the shard.
+func (r *replicationService) dispatch(ctx context.Context, topic bus.Topic, shard *databasev1.ReplicaPart, req any) (any, error) {
+	r.ser.listenersLock.RLock()
+	defer r.ser.listenersLock.RUnlock()
+	for _, l := range r.ser.getListeners(topic) {
+		switch d := l.Rev(ctx, bus.NewMessage(bus.MessageID(0), req)).Data().(type) {
+		case nil:
+			continue
+		case error:
+			return nil, status.Error(codes.FailedPrecondition, d.Error())
+		default:
+			return d, nil
+		}
+	}
+	return nil, status.Errorf(codes.InvalidArgument, "catalog %s isn't replicated on the node", shard.GetCatalog())
+}
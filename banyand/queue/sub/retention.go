@@ -0,0 +1,69 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package sub
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/apache/skywalking-banyandb/api/data"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+)
+
+type retentionService struct {
+	databasev1.UnimplementedRetentionServiceServer
+	ser *server
+}
+
+func (s *retentionService) Preview(ctx context.Context, req *databasev1.RetentionServicePreviewRequest) (*databasev1.RetentionServicePreviewResponse, error) {
+	return &databasev1.RetentionServicePreviewResponse{Groups: s.retention(ctx, data.TopicRetentionPreview, req.GetGroups())}, nil
+}
+
+func (s *retentionService) Trigger(ctx context.Context, req *databasev1.RetentionServiceTriggerRequest) (*databasev1.RetentionServiceTriggerResponse, error) {
+	if len(req.GetGroups()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "groups are required")
+	}
+	if !req.GetConfirm() {
+		return nil, status.Error(codes.FailedPrecondition, "confirm is required to delete the segments, preview them first")
+	}
+	return &databasev1.RetentionServiceTriggerResponse{Groups: s.retention(ctx, data.TopicRetentionTrigger, req.GetGroups())}, nil
+}
+
+func (s *retentionService) retention(ctx context.Context, topic bus.Topic, groups []string) []*databasev1.GroupRetention {
+	s.ser.listenersLock.RLock()
+	defer s.ser.listenersLock.RUnlock()
+	var result []*databasev1.GroupRetention
+	for _, l := range s.ser.getListeners(topic) {
+		message := l.Rev(ctx, bus.NewMessage(bus.MessageID(0), groups))
+		data := message.Data()
+		if data == nil {
+			continue
+		}
+		rr, ok := data.([]*databasev1.GroupRetention)
+		if !ok {
+			logger.Panicf("invalid data type %T", data)
+		}
+		result = append(result, rr...)
+	}
+	return storage.CompleteRetention(groups, result)
+}
@@ -19,6 +19,7 @@ package sub
 
 import (
 	"context"
+	"fmt"
 	"net"
 	"net/http"
 	"runtime/debug"
@@ -30,6 +31,7 @@ import (
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/recovery"
 	grpc_validator "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/validator"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/nats-io/nats.go"
 	"github.com/pkg/errors"
 	grpclib "google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -39,16 +41,20 @@ import (
 	"google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/status"
 
+	"github.com/apache/skywalking-banyandb/api/common"
 	clusterv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/cluster/v1"
 	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
 	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
 	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
 	"github.com/apache/skywalking-banyandb/banyand/observability"
 	"github.com/apache/skywalking-banyandb/banyand/queue"
+	"github.com/apache/skywalking-banyandb/banyand/queue/transport"
 	"github.com/apache/skywalking-banyandb/pkg/bus"
 	"github.com/apache/skywalking-banyandb/pkg/healthcheck"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
 	"github.com/apache/skywalking-banyandb/pkg/meter"
+	"github.com/apache/skywalking-banyandb/pkg/query"
 	"github.com/apache/skywalking-banyandb/pkg/run"
 )
 
@@ -77,6 +83,9 @@ type server struct {
 	topicMap       map[string]bus.Topic
 	log            *logger.Logger
 	httpSrv        *http.Server
+	nc             *nats.Conn
+	natsSrv        *transport.NATSServer
+	dead           *queue.DeadMessageRecorder
 	clientCloser   context.CancelFunc
 	httpAddr       string
 	addr           string
@@ -84,7 +93,12 @@ type server struct {
 	certFile       string
 	keyFile        string
 	flagNamePrefix string
+	transport      string
+	natsURL        string
+	natsPrefix     string
+	nodeID         string
 	maxRecvMsgSize run.Bytes
+	deadCapacity   int
 	listenersLock  sync.RWMutex
 	port           uint32
 	httpPort       uint32
@@ -109,9 +123,27 @@ func NewServerWithPorts(omr observability.MetricsRegistry, flagNamePrefix string
 	}
 }
 
-func (s *server) PreRun(_ context.Context) error {
+func (s *server) PreRun(ctx context.Context) error {
 	s.log = logger.GetLogger("server-queue-sub")
 	s.metrics = newMetrics(s.omr.With(queueSubScope))
+	name := s.Name()
+	if s.flagNamePrefix != "" {
+		name += "-" + s.flagNamePrefix
+	}
+	s.dead = queue.NewDeadMessageRecorder(name, s.deadCapacity)
+	if s.transport != transport.NATS {
+		return nil
+	}
+	val := ctx.Value(common.ContextNodeKey)
+	if val == nil {
+		return errors.New("the NATS transport requires the node id")
+	}
+	s.nodeID = val.(common.Node).NodeID
+	nc, err := transport.ConnectNATS(s.natsURL, s.Name()+"-"+s.nodeID)
+	if err != nil {
+		return errors.WithMessagef(err, "failed to connect to the NATS servers %s", s.natsURL)
+	}
+	s.nc = nc
 	return nil
 }
 
@@ -142,6 +174,12 @@ func (s *server) FlagSet() *run.FlagSet {
 	fs.StringVar(&s.host, prefixFlag("grpc-host"), "", "the host of banyand listens")
 	fs.Uint32Var(&s.port, prefixFlag("grpc-port"), s.port, "the port of banyand listens")
 	fs.Uint32Var(&s.httpPort, prefixFlag("http-port"), s.httpPort, "the port of banyand http api listens")
+	fs.StringVar(&s.transport, prefixFlag("queue-transport"), transport.GRPC,
+		fmt.Sprintf("the transport receiving the messages from the other nodes, %q or %q", transport.GRPC, transport.NATS))
+	fs.StringVar(&s.natsURL, prefixFlag("queue-nats-url"), "", "the comma-separated URLs of the NATS servers shared by the nodes")
+	fs.StringVar(&s.natsPrefix, prefixFlag("queue-nats-subject-prefix"), transport.DefaultSubjectPrefix, "the prefix of the NATS subjects of the node")
+	fs.IntVar(&s.deadCapacity, prefixFlag("queue-dead-message-capacity"), 0,
+		"the number of the latest messages failing to be handled to keep for debugging, 0 disables the capture")
 	return fs
 }
 
@@ -160,6 +198,12 @@ func (s *server) Validate() error {
 	if s.httpAddr == ":" {
 		return errNoAddr
 	}
+	if s.transport == "" {
+		s.transport = transport.GRPC
+	}
+	if err := transport.Validate(s.transport, s.natsURL); err != nil {
+		return err
+	}
 	if !s.tls {
 		return nil
 	}
@@ -204,6 +248,11 @@ func (s *server) Serve() run.StopNotify {
 	clusterv1.RegisterServiceServer(s.ser, s)
 	grpc_health_v1.RegisterHealthServer(s.ser, health.NewServer())
 	databasev1.RegisterSnapshotServiceServer(s.ser, s)
+	databasev1.RegisterWarmupServiceServer(s.ser, &warmupService{ser: s})
+	databasev1.RegisterRetentionServiceServer(s.ser, &retentionService{ser: s})
+	databasev1.RegisterStorageUsageServiceServer(s.ser, &usageService{ser: s})
+	databasev1.RegisterReplicationServiceServer(s.ser, &replicationService{ser: s})
+	databasev1.RegisterIntegrityServiceServer(s.ser, &integrityService{ser: s})
 	streamv1.RegisterStreamServiceServer(s.ser, &streamService{ser: s})
 	measurev1.RegisterMeasureServiceServer(s.ser, &measureService{ser: s})
 
@@ -228,13 +277,39 @@ func (s *server) Serve() run.StopNotify {
 		close(stopCh)
 		return stopCh
 	}
+	if err := databasev1.RegisterWarmupServiceHandlerFromEndpoint(ctx, gwMux, s.addr, clientOpts); err != nil {
+		s.log.Error().Err(err).Msg("Failed to register warmup service")
+		close(stopCh)
+		return stopCh
+	}
+	if err := databasev1.RegisterRetentionServiceHandlerFromEndpoint(ctx, gwMux, s.addr, clientOpts); err != nil {
+		s.log.Error().Err(err).Msg("Failed to register retention service")
+		close(stopCh)
+		return stopCh
+	}
+	if err := databasev1.RegisterStorageUsageServiceHandlerFromEndpoint(ctx, gwMux, s.addr, clientOpts); err != nil {
+		s.log.Error().Err(err).Msg("Failed to register storage usage service")
+		close(stopCh)
+		return stopCh
+	}
 	mux := chi.NewRouter()
 	mux.Mount("/api", http.StripPrefix("/api", gwMux))
+	mux.Get(storage.RepairPath, storage.ServeRepairs)
+	mux.Get(queue.DeadMessagePath, queue.ServeDeadMessages)
+	mux.Get(query.SlowQueryPath, query.ServeSlowQueries)
 	s.httpSrv = &http.Server{
 		Addr:              s.httpAddr,
 		Handler:           mux,
 		ReadHeaderTimeout: 3 * time.Second,
 	}
+	if s.nc != nil {
+		if s.natsSrv, err = transport.ServeNATS(s.nc, s.natsPrefix, s.nodeID, s, s.log); err != nil {
+			s.log.Error().Err(err).Msg("Failed to serve through NATS")
+			close(stopCh)
+			return stopCh
+		}
+		s.log.Info().Str("url", s.natsURL).Str("node", s.nodeID).Msg("Serving through NATS")
+	}
 	var wg sync.WaitGroup
 	wg.Add(2)
 	go func() {
@@ -269,6 +344,13 @@ func (s *server) Serve() run.StopNotify {
 
 func (s *server) GracefulStop() {
 	s.log.Info().Msg("stopping")
+	s.dead.Close()
+	if s.natsSrv != nil {
+		s.natsSrv.Stop()
+	}
+	if s.nc != nil {
+		s.nc.Close()
+	}
 	stopped := make(chan struct{})
 	s.clientCloser()
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -290,12 +372,19 @@ func (s *server) GracefulStop() {
 	}
 }
 
+// ShutdownPhase implements run.PhasedService.
+func (s *server) ShutdownPhase() run.ShutdownPhase {
+	return run.ShutdownPhaseQueue
+}
+
 type metrics struct {
 	totalStarted  meter.Counter
 	totalFinished meter.Counter
 	totalErr      meter.Counter
 	totalLatency  meter.Counter
 
+	handlerLatency meter.Histogram
+
 	totalMsgReceived    meter.Counter
 	totalMsgReceivedErr meter.Counter
 	totalMsgSent        meter.Counter
@@ -308,6 +397,7 @@ func newMetrics(factory *observability.Factory) *metrics {
 		totalFinished:       factory.NewCounter("total_finished", "topic"),
 		totalErr:            factory.NewCounter("total_err", "topic"),
 		totalLatency:        factory.NewCounter("total_latency", "topic"),
+		handlerLatency:      factory.NewHistogram("handler_latency", meter.DefBuckets, "topic"),
 		totalMsgReceived:    factory.NewCounter("total_msg_received", "topic"),
 		totalMsgReceivedErr: factory.NewCounter("total_msg_received_err", "topic"),
 		totalMsgSent:        factory.NewCounter("total_msg_sent", "topic"),
@@ -19,6 +19,13 @@ package sub
 
 import (
 	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/apache/skywalking-banyandb/api/data"
 	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
@@ -49,3 +56,92 @@ func (s *server) Snapshot(ctx context.Context, req *databasev1.SnapshotRequest)
 	}
 	return &databasev1.SnapshotResponse{Snapshots: result}, nil
 }
+
+const snapshotChunkSize = 1 << 20
+
+// PullSnapshot streams the files of a snapshot which the follower doesn't have.
+func (s *server) PullSnapshot(req *databasev1.PullSnapshotRequest, stream databasev1.SnapshotService_PullSnapshotServer) error {
+	name := req.GetName()
+	if name == "" || name != filepath.Base(name) || name == "." || name == ".." {
+		return status.Errorf(codes.InvalidArgument, "invalid snapshot name %q", name)
+	}
+	dir := s.snapshotDir(stream.Context(), req)
+	if dir == "" {
+		return status.Errorf(codes.NotFound, "no snapshot directory for %s", req.GetCatalog())
+	}
+	files, err := listSnapshotFiles(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return status.Errorf(codes.NotFound, "snapshot %s is not found", name)
+		}
+		return status.Errorf(codes.Internal, "failed to list snapshot %s: %v", name, err)
+	}
+	if err = stream.Send(&databasev1.PullSnapshotResponse{Files: files}); err != nil {
+		return err
+	}
+	existing := make(map[string]struct{}, len(req.GetExistingFiles()))
+	for _, f := range req.GetExistingFiles() {
+		existing[f] = struct{}{}
+	}
+	for _, f := range files {
+		if _, ok := existing[f.Path]; ok {
+			continue
+		}
+		if err = sendSnapshotFile(stream, dir, f.Path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *server) snapshotDir(ctx context.Context, req *databasev1.PullSnapshotRequest) string {
+	for _, l := range s.getListeners(data.TopicSnapshotDir) {
+		message := l.Rev(ctx, bus.NewMessage(bus.MessageID(0), req))
+		if dir, ok := message.Data().(string); ok && dir != "" {
+			return dir
+		}
+	}
+	return ""
+}
+
+func listSnapshotFiles(root string) ([]*databasev1.SnapshotFile, error) {
+	var files []*databasev1.SnapshotFile
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		relPath, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, &databasev1.SnapshotFile{Path: filepath.ToSlash(relPath), Size: uint64(info.Size())})
+		return nil
+	})
+	return files, err
+}
+
+func sendSnapshotFile(stream databasev1.SnapshotService_PullSnapshotServer, root, relPath string) error {
+	file, err := os.Open(filepath.Join(root, filepath.FromSlash(relPath)))
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to open %s: %v", relPath, err)
+	}
+	defer file.Close()
+	for {
+		// the chunk is not reused since the message might be marshaled lazily after sending.
+		buf := make([]byte, snapshotChunkSize)
+		n, errRead := io.ReadFull(file, buf)
+		last := errors.Is(errRead, io.EOF) || errors.Is(errRead, io.ErrUnexpectedEOF)
+		if errRead != nil && !last {
+			return status.Errorf(codes.Internal, "failed to read %s: %v", relPath, errRead)
+		}
+		if err = stream.Send(&databasev1.PullSnapshotResponse{Path: relPath, Chunk: buf[:n], Last: last}); err != nil {
+			return err
+		}
+		if last {
+			return nil
+		}
+	}
+}
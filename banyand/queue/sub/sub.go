@@ -19,22 +19,27 @@
 package sub
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"time"
 
 	"github.com/pkg/errors"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/protobuf/proto"
 
 	"github.com/apache/skywalking-banyandb/api/common"
 	"github.com/apache/skywalking-banyandb/api/data"
 	clusterv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/cluster/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/banyand/queue"
 	"github.com/apache/skywalking-banyandb/pkg/bus"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/tracecontext"
 )
 
 func (s *server) Send(stream clusterv1.Service_SendServer) error {
-	ctx := stream.Context()
+	ctx := tracecontext.FromIncomingContext(stream.Context())
 	var topic *bus.Topic
 	var m bus.Message
 	var dataCollection []any
@@ -72,6 +77,12 @@ func (s *server) Send(stream clusterv1.Service_SendServer) error {
 			s.reply(stream, writeEntity, err, "topic is empty")
 			continue
 		}
+		// The negotiated clients never send the newer messages, which might be unmarshaled without an error but misread.
+		if writeEntity.Version > data.ProtocolVersion {
+			s.reply(stream, writeEntity, common.NewErrorWithStatus(modelv1.Status_STATUS_UNSUPPORTED_VERSION,
+				fmt.Sprintf("protocol version %d is newer than %d", writeEntity.Version, data.ProtocolVersion)), "unsupported protocol version")
+			continue
+		}
 
 		if reqSupplier, ok := data.TopicRequestMap[*topic]; ok {
 			req := reqSupplier()
@@ -99,13 +110,16 @@ func (s *server) Send(stream clusterv1.Service_SendServer) error {
 		}
 		listener := listeners[0]
 
+		handleStart := time.Now()
 		m = listener.Rev(ctx, m)
+		s.metrics.handlerLatency.Observe(time.Since(handleStart).Seconds(), writeEntity.Topic)
 		if m.Data() == nil {
 			if errSend := stream.Send(&clusterv1.SendResponse{
 				MessageId: writeEntity.MessageId,
 			}); errSend != nil {
 				s.log.Error().Stringer("request", writeEntity).Err(errSend).Msg("failed to send empty response")
 				s.metrics.totalMsgSentErr.Inc(1, writeEntity.Topic)
+				s.deadMessage(ctx, writeEntity, errSend.Error())
 				continue
 			}
 			s.metrics.totalMsgSent.Inc(1, writeEntity.Topic)
@@ -122,7 +136,7 @@ func (s *server) Send(stream clusterv1.Service_SendServer) error {
 				return ctx.Err()
 			default:
 			}
-			s.reply(stream, writeEntity, nil, d.Error())
+			s.reply(stream, writeEntity, d, d.Error())
 			continue
 		default:
 			s.reply(stream, writeEntity, nil, fmt.Sprintf("invalid response: %T", d))
@@ -139,6 +153,7 @@ func (s *server) Send(stream clusterv1.Service_SendServer) error {
 		}); err != nil {
 			s.log.Error().Stringer("request", writeEntity).Dur("latency", time.Since(start)).Err(err).Msg("failed to send query response")
 			s.metrics.totalMsgSentErr.Inc(1, writeEntity.Topic)
+			s.deadMessage(ctx, writeEntity, err.Error())
 			continue
 		}
 		s.metrics.totalMsgSent.Inc(1, writeEntity.Topic)
@@ -181,11 +196,32 @@ func (s *server) reply(stream clusterv1.Service_SendServer, writeEntity *cluster
 	} else {
 		resp.Error = message
 	}
-	if errResp := stream.Send(&clusterv1.SendResponse{
-		MessageId: writeEntity.MessageId,
-		Error:     message,
-	}); errResp != nil {
+	s.deadMessage(stream.Context(), writeEntity, resp.Error)
+	if errResp := stream.Send(resp); errResp != nil {
 		s.log.Error().Err(errResp).AnErr("original", err).Stringer("request", writeEntity).Msg("failed to send error response")
 		s.metrics.totalMsgSentErr.Inc(1, writeEntity.Topic)
 	}
 }
+
+// deadMessage captures the request failing to be handled. The payload is decoded if possible.
+func (s *server) deadMessage(ctx context.Context, writeEntity *clusterv1.SendRequest, reason string) {
+	if s.dead == nil || writeEntity == nil {
+		return
+	}
+	dm := queue.DeadMessage{
+		Topic:     writeEntity.Topic,
+		MessageID: writeEntity.MessageId,
+		Reason:    reason,
+		Payload:   queue.DescribePayload(writeEntity.Body),
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		dm.Node = p.Addr.String()
+	}
+	if reqSupplier, ok := data.TopicRequestMap[data.TopicMap[writeEntity.Topic]]; ok {
+		req := reqSupplier()
+		if proto.Unmarshal(writeEntity.Body, req) == nil {
+			dm.Payload = queue.DescribePayload(req)
+		}
+	}
+	s.dead.Capture(dm)
+}
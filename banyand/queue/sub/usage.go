@@ -0,0 +1,52 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package sub
+
+import (
+	"context"
+
+	"github.com/apache/skywalking-banyandb/api/data"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+)
+
+type usageService struct {
+	databasev1.UnimplementedStorageUsageServiceServer
+	ser *server
+}
+
+func (s *usageService) Report(ctx context.Context, req *databasev1.StorageUsageServiceReportRequest) (*databasev1.StorageUsageServiceReportResponse, error) {
+	s.ser.listenersLock.RLock()
+	defer s.ser.listenersLock.RUnlock()
+	var result []*databasev1.GroupUsage
+	for _, l := range s.ser.getListeners(data.TopicStorageUsage) {
+		message := l.Rev(ctx, bus.NewMessage(bus.MessageID(0), req.GetGroups()))
+		data := message.Data()
+		if data == nil {
+			continue
+		}
+		uu, ok := data.([]*databasev1.GroupUsage)
+		if !ok {
+			logger.Panicf("invalid data type %T", data)
+		}
+		result = append(result, uu...)
+	}
+	return &databasev1.StorageUsageServiceReportResponse{Groups: storage.CompleteUsage(req.GetGroups(), result)}, nil
+}
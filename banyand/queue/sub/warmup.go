@@ -0,0 +1,52 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package sub
+
+import (
+	"context"
+
+	"github.com/apache/skywalking-banyandb/api/data"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/schema"
+)
+
+type warmupService struct {
+	databasev1.UnimplementedWarmupServiceServer
+	ser *server
+}
+
+func (s *warmupService) Warmup(ctx context.Context, req *databasev1.WarmupRequest) (*databasev1.WarmupResponse, error) {
+	s.ser.listenersLock.RLock()
+	defer s.ser.listenersLock.RUnlock()
+	var result []*databasev1.GroupReadiness
+	for _, l := range s.ser.getListeners(data.TopicWarmup) {
+		message := l.Rev(ctx, bus.NewMessage(bus.MessageID(0), req.GetGroups()))
+		data := message.Data()
+		if data == nil {
+			continue
+		}
+		rr, ok := data.([]*databasev1.GroupReadiness)
+		if !ok {
+			logger.Panicf("invalid data type %T", data)
+		}
+		result = append(result, rr...)
+	}
+	return &databasev1.WarmupResponse{Groups: schema.CompleteReadiness(req.GetGroups(), result)}, nil
+}
@@ -0,0 +1,75 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package transport
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
+
+	clusterv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/cluster/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+)
+
+type grpcDialer struct {
+	opts func() ([]grpc.DialOption, error)
+}
+
+// NewGRPCDialer returns a Dialer connecting to the gRPC addresses of the nodes.
+// The dial options are loaded on every dial, which picks up the renewed credentials.
+func NewGRPCDialer(opts func() ([]grpc.DialOption, error)) Dialer {
+	return &grpcDialer{opts: opts}
+}
+
+func (d *grpcDialer) Dial(node *databasev1.Node) (Conn, error) {
+	opts, err := d.opts()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := grpc.NewClient(node.GrpcAddress, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &grpcConn{ServiceClient: clusterv1.NewServiceClient(conn), conn: conn}, nil
+}
+
+func (d *grpcDialer) Close() error {
+	return nil
+}
+
+type grpcConn struct {
+	clusterv1.ServiceClient
+	conn *grpc.ClientConn
+}
+
+func (c *grpcConn) CheckHealth(ctx context.Context) error {
+	resp, err := grpc_health_v1.NewHealthClient(c.conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+	if err != nil {
+		return err
+	}
+	if resp.GetStatus() != grpc_health_v1.HealthCheckResponse_SERVING {
+		return errors.Errorf("the node is %s", resp.GetStatus())
+	}
+	return nil
+}
+
+func (c *grpcConn) Close() error {
+	return c.conn.Close()
+}
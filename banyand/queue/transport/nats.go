@@ -0,0 +1,660 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package transport
+
+import (
+	"context"
+	"io"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nuid"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	clusterv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/cluster/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+)
+
+// The messages of a stream carry the stream ID in the header. A message with the EOF header
+// closes the stream from its sender, and the one sent by the server carries the status of the handler.
+const (
+	headerStream   = "Bydb-Stream"
+	headerDeadline = "Bydb-Deadline"
+	headerMore     = "Bydb-More"
+	headerEOF      = "Bydb-Eof"
+	headerCancel   = "Bydb-Cancel"
+	headerCode     = "Bydb-Code"
+	headerMessage  = "Bydb-Message"
+
+	sendSubject   = "send"
+	healthSubject = "health"
+
+	// frameHeadroom is reserved in every frame for the headers, which count toward the max payload.
+	frameHeadroom = 4 << 10
+	minFrameSize  = 1 << 10
+)
+
+var subjectReplacer = strings.NewReplacer(".", "_", "*", "_", ">", "_", " ", "_", "\t", "_")
+
+func subject(prefix, node, kind string) string {
+	return prefix + "." + subjectReplacer.Replace(node) + "." + kind
+}
+
+// ConnectNATS connects to the NATS servers of the URL, which is a comma-separated list.
+// The connection reconnects to the servers endlessly.
+func ConnectNATS(url, name string) (*nats.Conn, error) {
+	return nats.Connect(url, nats.Name(name), nats.MaxReconnects(-1))
+}
+
+type natsDialer struct {
+	nc       *nats.Conn
+	sub      *nats.Subscription
+	streams  map[string]*natsSendClient
+	prefix   string
+	respBase string
+	mu       sync.RWMutex
+}
+
+// NewNATSDialer returns a Dialer reaching the nodes through the NATS connection.
+// The subjects of a node are prefixed by the subject prefix, which must match the one of the node.
+// The dialer doesn't own the connection, so closing it leaves the connection open.
+func NewNATSDialer(nc *nats.Conn, subjectPrefix string) (Dialer, error) {
+	d := &natsDialer{
+		nc:       nc,
+		prefix:   subjectPrefix,
+		respBase: nc.NewInbox(),
+		streams:  make(map[string]*natsSendClient),
+	}
+	sub, err := nc.Subscribe(d.respBase+".*", d.dispatch)
+	if err != nil {
+		return nil, err
+	}
+	if err = sub.SetPendingLimits(-1, -1); err != nil {
+		_ = sub.Unsubscribe()
+		return nil, err
+	}
+	d.sub = sub
+	return d, nil
+}
+
+func (d *natsDialer) Dial(node *databasev1.Node) (Conn, error) {
+	if d.nc.IsClosed() {
+		return nil, nats.ErrConnectionClosed
+	}
+	return &natsConn{dialer: d, node: node.Metadata.GetName()}, nil
+}
+
+func (d *natsDialer) Close() error {
+	return d.sub.Unsubscribe()
+}
+
+func (d *natsDialer) dispatch(m *nats.Msg) {
+	id := strings.TrimPrefix(m.Subject, d.respBase+".")
+	d.mu.RLock()
+	s, ok := d.streams[id]
+	d.mu.RUnlock()
+	if ok {
+		s.queue.push(m)
+	}
+}
+
+func (d *natsDialer) register(s *natsSendClient) {
+	d.mu.Lock()
+	d.streams[s.id] = s
+	d.mu.Unlock()
+}
+
+func (d *natsDialer) unregister(id string) {
+	d.mu.Lock()
+	delete(d.streams, id)
+	d.mu.Unlock()
+}
+
+type natsConn struct {
+	dialer *natsDialer
+	node   string
+}
+
+func (c *natsConn) Send(ctx context.Context, _ ...grpc.CallOption) (grpc.BidiStreamingClient[clusterv1.SendRequest, clusterv1.SendResponse], error) {
+	d := c.dialer
+	if d.nc.IsClosed() {
+		return nil, toStatus(nats.ErrConnectionClosed)
+	}
+	id := nuid.Next()
+	s := &natsSendClient{
+		ctx:     ctx,
+		nc:      d.nc,
+		id:      id,
+		subject: subject(d.prefix, c.node, sendSubject),
+		reply:   d.respBase + "." + id,
+		queue:   newMsgQueue(),
+		done:    make(chan struct{}),
+	}
+	d.register(s)
+	go func() {
+		select {
+		case <-ctx.Done():
+			// The server is told to stop the handler which might still wait for the requests.
+			_ = d.nc.PublishMsg(s.newMsg(headerCancel))
+		case <-s.done:
+		}
+		d.unregister(id)
+	}()
+	return s, nil
+}
+
+func (c *natsConn) HealthCheck(ctx context.Context, in *clusterv1.HealthCheckRequest, _ ...grpc.CallOption) (*clusterv1.HealthCheckResponse, error) {
+	data, err := proto.Marshal(in)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	m, err := c.dialer.nc.RequestWithContext(ctx, subject(c.dialer.prefix, c.node, healthSubject), data)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	if err = headerStatus(m.Header); err != nil {
+		return nil, err
+	}
+	resp := &clusterv1.HealthCheckResponse{}
+	if err = proto.Unmarshal(m.Data, resp); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return resp, nil
+}
+
+// CheckHealth checks the node by the empty service name, which the node answers by itself.
+func (c *natsConn) CheckHealth(ctx context.Context) error {
+	resp, err := c.HealthCheck(ctx, &clusterv1.HealthCheckRequest{})
+	if err != nil {
+		return err
+	}
+	if resp.GetStatus() != modelv1.Status_STATUS_SUCCEED {
+		return errors.Errorf("the node is %s: %s", resp.GetStatus(), resp.GetError())
+	}
+	return nil
+}
+
+func (c *natsConn) Close() error {
+	return nil
+}
+
+type natsSendClient struct {
+	ctx     context.Context
+	err     error
+	nc      *nats.Conn
+	queue   *msgQueue
+	done    chan struct{}
+	id      string
+	subject string
+	reply   string
+	frames  frameAssembler
+	once    sync.Once
+}
+
+func (s *natsSendClient) newMsg(marker string) *nats.Msg {
+	m := nats.NewMsg(s.subject)
+	m.Reply = s.reply
+	m.Header.Set(headerStream, s.id)
+	if deadline, ok := s.ctx.Deadline(); ok {
+		m.Header.Set(headerDeadline, strconv.FormatInt(deadline.UnixNano(), 10))
+	}
+	if marker != "" {
+		m.Header.Set(marker, "1")
+	}
+	return m
+}
+
+func (s *natsSendClient) Send(req *clusterv1.SendRequest) error {
+	if err := s.ctx.Err(); err != nil {
+		return toStatus(err)
+	}
+	data, err := proto.Marshal(req)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	return toStatus(publishFrames(s.nc, s.newMsg(""), data))
+}
+
+func (s *natsSendClient) Recv() (*clusterv1.SendResponse, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	for {
+		m, err := s.queue.pop(s.ctx)
+		if err != nil {
+			return nil, toStatus(err)
+		}
+		if m.Header.Get(headerEOF) != "" {
+			s.err = headerStatus(m.Header)
+			if s.err == nil {
+				s.err = io.EOF
+			}
+			s.finish()
+			return nil, s.err
+		}
+		data, ok := s.frames.add(m)
+		if !ok {
+			continue
+		}
+		resp := &clusterv1.SendResponse{}
+		if err = proto.Unmarshal(data, resp); err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		return resp, nil
+	}
+}
+
+func (s *natsSendClient) finish() {
+	s.once.Do(func() { close(s.done) })
+}
+
+func (s *natsSendClient) CloseSend() error {
+	return toStatus(s.nc.PublishMsg(s.newMsg(headerEOF)))
+}
+
+func (s *natsSendClient) Header() (metadata.MD, error) {
+	return metadata.MD{}, nil
+}
+
+func (s *natsSendClient) Trailer() metadata.MD {
+	return metadata.MD{}
+}
+
+func (s *natsSendClient) Context() context.Context {
+	return s.ctx
+}
+
+func (s *natsSendClient) SendMsg(m any) error {
+	req, ok := m.(*clusterv1.SendRequest)
+	if !ok {
+		return status.Errorf(codes.Internal, "unexpected message %T", m)
+	}
+	return s.Send(req)
+}
+
+func (s *natsSendClient) RecvMsg(m any) error {
+	resp, err := s.Recv()
+	if err != nil {
+		return err
+	}
+	dst, ok := m.(*clusterv1.SendResponse)
+	if !ok {
+		return status.Errorf(codes.Internal, "unexpected message %T", m)
+	}
+	proto.Merge(dst, resp)
+	return nil
+}
+
+// NATSServer serves the cluster service of a node through NATS.
+type NATSServer struct {
+	nc      *nats.Conn
+	srv     clusterv1.ServiceServer
+	l       *logger.Logger
+	streams map[string]*natsSendServer
+	subs    []*nats.Subscription
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	stopped bool
+}
+
+// ServeNATS subscribes the subjects of the node, and delivers the messages to the cluster service.
+func ServeNATS(nc *nats.Conn, subjectPrefix, node string, srv clusterv1.ServiceServer, l *logger.Logger) (*NATSServer, error) {
+	s := &NATSServer{
+		nc:      nc,
+		srv:     srv,
+		l:       l,
+		streams: make(map[string]*natsSendServer),
+	}
+	sendSub, err := nc.Subscribe(subject(subjectPrefix, node, sendSubject), s.dispatch)
+	if err != nil {
+		return nil, err
+	}
+	s.subs = append(s.subs, sendSub)
+	if err = sendSub.SetPendingLimits(-1, -1); err != nil {
+		s.Stop()
+		return nil, err
+	}
+	healthSub, err := nc.Subscribe(subject(subjectPrefix, node, healthSubject), s.health)
+	if err != nil {
+		s.Stop()
+		return nil, err
+	}
+	s.subs = append(s.subs, healthSub)
+	return s, nil
+}
+
+// Stop unsubscribes the subjects, and waits for the handlers of the streams.
+func (s *NATSServer) Stop() {
+	for _, sub := range s.subs {
+		_ = sub.Unsubscribe()
+	}
+	s.mu.Lock()
+	s.stopped = true
+	for _, st := range s.streams {
+		st.cancel()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+func (s *NATSServer) dispatch(m *nats.Msg) {
+	id := m.Header.Get(headerStream)
+	if id == "" || m.Reply == "" {
+		s.l.Warn().Str("subject", m.Subject).Msg("drop the message without a stream")
+		return
+	}
+	cancel := m.Header.Get(headerCancel) != ""
+	s.mu.Lock()
+	st, ok := s.streams[id]
+	if !ok {
+		// The stream is done, or is canceled before any request.
+		if cancel || s.stopped {
+			s.mu.Unlock()
+			return
+		}
+		st = s.newStream(id, m)
+	}
+	s.mu.Unlock()
+	if cancel {
+		st.cancel()
+		return
+	}
+	st.queue.push(m)
+}
+
+func (s *NATSServer) newStream(id string, m *nats.Msg) *natsSendServer {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if nanos, err := strconv.ParseInt(m.Header.Get(headerDeadline), 10, 64); err == nil {
+		ctx, cancel = context.WithDeadline(context.Background(), time.Unix(0, nanos))
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+	st := &natsSendServer{
+		ctx:    ctx,
+		cancel: cancel,
+		nc:     s.nc,
+		id:     id,
+		reply:  m.Reply,
+		queue:  newMsgQueue(),
+	}
+	s.streams[id] = st
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		err := s.handle(st)
+		eof := st.newMsg()
+		eof.Header.Set(headerEOF, "1")
+		if err != nil {
+			sts := status.Convert(err)
+			eof.Header.Set(headerCode, strconv.Itoa(int(sts.Code())))
+			eof.Header.Set(headerMessage, sts.Message())
+		}
+		if errPub := s.nc.PublishMsg(eof); errPub != nil {
+			s.l.Warn().Err(errPub).Str("stream", id).Msg("failed to close the stream")
+		}
+		s.mu.Lock()
+		delete(s.streams, id)
+		s.mu.Unlock()
+		st.cancel()
+	}()
+	return st
+}
+
+// handle recovers from the panic of the handler, as the recovery interceptor of the gRPC server does.
+func (s *NATSServer) handle(st *natsSendServer) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			s.l.Error().Interface("panic", p).Str("stack", string(debug.Stack())).Msg("recovered from panic")
+			err = status.Errorf(codes.Internal, "%s", p)
+		}
+	}()
+	return s.srv.Send(st)
+}
+
+func (s *NATSServer) health(m *nats.Msg) {
+	reply := func(resp *clusterv1.HealthCheckResponse, err error) {
+		msg := nats.NewMsg(m.Reply)
+		if err != nil {
+			sts := status.Convert(err)
+			msg.Header.Set(headerCode, strconv.Itoa(int(sts.Code())))
+			msg.Header.Set(headerMessage, sts.Message())
+		} else if msg.Data, err = proto.Marshal(resp); err != nil {
+			s.l.Warn().Err(err).Msg("failed to marshal the health check response")
+			return
+		}
+		if err = m.RespondMsg(msg); err != nil {
+			s.l.Warn().Err(err).Msg("failed to respond the health check")
+		}
+	}
+	req := &clusterv1.HealthCheckRequest{}
+	if err := proto.Unmarshal(m.Data, req); err != nil {
+		reply(nil, status.Error(codes.InvalidArgument, err.Error()))
+		return
+	}
+	reply(s.srv.HealthCheck(context.Background(), req))
+}
+
+type natsSendServer struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	nc     *nats.Conn
+	queue  *msgQueue
+	id     string
+	reply  string
+	frames frameAssembler
+}
+
+func (s *natsSendServer) newMsg() *nats.Msg {
+	m := nats.NewMsg(s.reply)
+	m.Header.Set(headerStream, s.id)
+	return m
+}
+
+func (s *natsSendServer) Recv() (*clusterv1.SendRequest, error) {
+	for {
+		m, err := s.queue.pop(s.ctx)
+		if err != nil {
+			return nil, toStatus(err)
+		}
+		if m.Header.Get(headerEOF) != "" {
+			return nil, io.EOF
+		}
+		data, ok := s.frames.add(m)
+		if !ok {
+			continue
+		}
+		req := &clusterv1.SendRequest{}
+		if err = proto.Unmarshal(data, req); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return req, nil
+	}
+}
+
+func (s *natsSendServer) Send(resp *clusterv1.SendResponse) error {
+	if err := s.ctx.Err(); err != nil {
+		return toStatus(err)
+	}
+	data, err := proto.Marshal(resp)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	return toStatus(publishFrames(s.nc, s.newMsg(), data))
+}
+
+func (s *natsSendServer) SetHeader(metadata.MD) error {
+	return nil
+}
+
+func (s *natsSendServer) SendHeader(metadata.MD) error {
+	return nil
+}
+
+func (s *natsSendServer) SetTrailer(metadata.MD) {}
+
+func (s *natsSendServer) Context() context.Context {
+	return s.ctx
+}
+
+func (s *natsSendServer) SendMsg(m any) error {
+	resp, ok := m.(*clusterv1.SendResponse)
+	if !ok {
+		return status.Errorf(codes.Internal, "unexpected message %T", m)
+	}
+	return s.Send(resp)
+}
+
+func (s *natsSendServer) RecvMsg(m any) error {
+	req, err := s.Recv()
+	if err != nil {
+		return err
+	}
+	dst, ok := m.(*clusterv1.SendRequest)
+	if !ok {
+		return status.Errorf(codes.Internal, "unexpected message %T", m)
+	}
+	proto.Merge(dst, req)
+	return nil
+}
+
+// publishFrames publishes the data in the frames fitting the max payload of the server.
+// Every frame but the last one carries the more header.
+func publishFrames(nc *nats.Conn, m *nats.Msg, data []byte) error {
+	frameSize := int(nc.MaxPayload()) - frameHeadroom
+	if frameSize < minFrameSize {
+		frameSize = minFrameSize
+	}
+	for len(data) > frameSize {
+		frame := nats.NewMsg(m.Subject)
+		frame.Reply = m.Reply
+		for k, v := range m.Header {
+			frame.Header[k] = v
+		}
+		frame.Header.Set(headerMore, "1")
+		frame.Data = data[:frameSize]
+		if err := nc.PublishMsg(frame); err != nil {
+			return err
+		}
+		data = data[frameSize:]
+	}
+	m.Data = data
+	return nc.PublishMsg(m)
+}
+
+type frameAssembler struct {
+	buf []byte
+}
+
+// add returns the whole data once the last frame arrives.
+func (f *frameAssembler) add(m *nats.Msg) ([]byte, bool) {
+	if m.Header.Get(headerMore) != "" {
+		f.buf = append(f.buf, m.Data...)
+		return nil, false
+	}
+	if len(f.buf) == 0 {
+		return m.Data, true
+	}
+	data := append(f.buf, m.Data...)
+	f.buf = nil
+	return data, true
+}
+
+// msgQueue is an unbounded queue, which keeps the subscription from blocking on a slow stream.
+type msgQueue struct {
+	notify chan struct{}
+	msgs   []*nats.Msg
+	mu     sync.Mutex
+}
+
+func newMsgQueue() *msgQueue {
+	return &msgQueue{notify: make(chan struct{}, 1)}
+}
+
+func (q *msgQueue) push(m *nats.Msg) {
+	q.mu.Lock()
+	q.msgs = append(q.msgs, m)
+	q.mu.Unlock()
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (q *msgQueue) pop(ctx context.Context) (*nats.Msg, error) {
+	for {
+		q.mu.Lock()
+		if len(q.msgs) > 0 {
+			m := q.msgs[0]
+			q.msgs[0] = nil
+			q.msgs = q.msgs[1:]
+			q.mu.Unlock()
+			return m, nil
+		}
+		q.mu.Unlock()
+		select {
+		case <-q.notify:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func headerStatus(h nats.Header) error {
+	code := h.Get(headerCode)
+	if code == "" {
+		return nil
+	}
+	c, err := strconv.Atoi(code)
+	if err != nil {
+		return status.Errorf(codes.Unknown, "invalid status code %q: %s", code, h.Get(headerMessage))
+	}
+	return status.Error(codes.Code(c), h.Get(headerMessage))
+}
+
+// toStatus converts the errors of NATS to the gRPC status, on which the queue clients decide to fail over.
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	case errors.Is(err, nats.ErrTimeout):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, nats.ErrNoResponders), errors.Is(err, nats.ErrConnectionClosed),
+		errors.Is(err, nats.ErrConnectionDraining), errors.Is(err, nats.ErrConnectionReconnecting),
+		errors.Is(err, nats.ErrDisconnected):
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	return status.Error(codes.Unknown, err.Error())
+}
@@ -0,0 +1,224 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package transport
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	clusterv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/cluster/v1"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+)
+
+const (
+	testNode       = "10.0.0.1:17912"
+	testMaxPayload = 16 << 10
+)
+
+type echoServer struct {
+	clusterv1.UnimplementedServiceServer
+	done chan error
+}
+
+// Send echoes the requests. The batch requests are answered by their count once the client closes the stream.
+func (s *echoServer) Send(stream clusterv1.Service_SendServer) error {
+	err := s.send(stream)
+	if s.done != nil {
+		s.done <- err
+	}
+	return err
+}
+
+func (s *echoServer) send(stream clusterv1.Service_SendServer) error {
+	var batch uint64
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return stream.Send(&clusterv1.SendResponse{MessageId: batch})
+		}
+		if err != nil {
+			return err
+		}
+		switch req.Topic {
+		case "error":
+			return status.Error(codes.ResourceExhausted, "too many requests")
+		case "panic":
+			panic("boom")
+		}
+		if req.BatchMod {
+			batch++
+			continue
+		}
+		if err = stream.Send(&clusterv1.SendResponse{MessageId: req.MessageId, Body: req.Body}); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *echoServer) HealthCheck(_ context.Context, req *clusterv1.HealthCheckRequest) (*clusterv1.HealthCheckResponse, error) {
+	if req.ServiceName == "" {
+		return &clusterv1.HealthCheckResponse{Status: modelv1.Status_STATUS_SUCCEED}, nil
+	}
+	return &clusterv1.HealthCheckResponse{ServiceName: req.ServiceName, Status: modelv1.Status_STATUS_DISK_FULL}, nil
+}
+
+func setupNATS(t *testing.T, srv clusterv1.ServiceServer) Dialer {
+	ns, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: -1, MaxPayload: testMaxPayload, NoSigs: true})
+	require.NoError(t, err)
+	go ns.Start()
+	t.Cleanup(ns.Shutdown)
+	require.True(t, ns.ReadyForConnections(10*time.Second))
+
+	serverConn, err := ConnectNATS(ns.ClientURL(), "server")
+	require.NoError(t, err)
+	t.Cleanup(serverConn.Close)
+	ss, err := ServeNATS(serverConn, DefaultSubjectPrefix, testNode, srv, logger.GetLogger("test"))
+	require.NoError(t, err)
+	t.Cleanup(ss.Stop)
+
+	clientConn, err := ConnectNATS(ns.ClientURL(), "client")
+	require.NoError(t, err)
+	t.Cleanup(clientConn.Close)
+	d, err := NewNATSDialer(clientConn, DefaultSubjectPrefix)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = d.Close() })
+	return d
+}
+
+func dial(t *testing.T, d Dialer, name string) Conn {
+	conn, err := d.Dial(&databasev1.Node{Metadata: &commonv1.Metadata{Name: name}})
+	require.NoError(t, err)
+	return conn
+}
+
+func TestNATSSend(t *testing.T) {
+	conn := dial(t, setupNATS(t, &echoServer{}), testNode)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	stream, err := conn.Send(ctx)
+	require.NoError(t, err)
+	// the large body is split into the frames fitting the max payload.
+	for i, body := range [][]byte{[]byte("small"), bytes.Repeat([]byte("large"), 10*testMaxPayload)} {
+		require.NoError(t, stream.Send(&clusterv1.SendRequest{Topic: "echo", MessageId: uint64(i), Body: body}))
+		resp, errRecv := stream.Recv()
+		require.NoError(t, errRecv)
+		assert.Equal(t, uint64(i), resp.MessageId)
+		assert.Equal(t, body, resp.Body)
+	}
+}
+
+func TestNATSSendBatch(t *testing.T) {
+	conn := dial(t, setupNATS(t, &echoServer{}), testNode)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	stream, err := conn.Send(ctx)
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		require.NoError(t, stream.Send(&clusterv1.SendRequest{Topic: "echo", BatchMod: true}))
+	}
+	require.NoError(t, stream.CloseSend())
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(10), resp.MessageId)
+	_, err = stream.Recv()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestNATSSendError(t *testing.T) {
+	tests := []struct {
+		topic string
+		code  codes.Code
+	}{
+		{topic: "error", code: codes.ResourceExhausted},
+		{topic: "panic", code: codes.Internal},
+	}
+	conn := dial(t, setupNATS(t, &echoServer{}), testNode)
+	for _, tt := range tests {
+		t.Run(tt.topic, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			stream, err := conn.Send(ctx)
+			require.NoError(t, err)
+			require.NoError(t, stream.Send(&clusterv1.SendRequest{Topic: tt.topic}))
+			_, err = stream.Recv()
+			assert.Equal(t, tt.code, status.Code(err))
+		})
+	}
+}
+
+func TestNATSSendCancel(t *testing.T) {
+	srv := &echoServer{done: make(chan error, 1)}
+	conn := dial(t, setupNATS(t, srv), testNode)
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := conn.Send(ctx)
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&clusterv1.SendRequest{Topic: "echo", BatchMod: true}))
+	cancel()
+	select {
+	case err = <-srv.done:
+		assert.Equal(t, codes.Canceled, status.Code(err))
+	case <-time.After(10 * time.Second):
+		t.Fatal("the handler isn't canceled")
+	}
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Canceled, status.Code(err))
+}
+
+func TestNATSHealthCheck(t *testing.T) {
+	d := setupNATS(t, &echoServer{})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	conn := dial(t, d, testNode)
+	require.NoError(t, conn.CheckHealth(ctx))
+	resp, err := conn.HealthCheck(ctx, &clusterv1.HealthCheckRequest{ServiceName: "measure-write"})
+	require.NoError(t, err)
+	assert.Equal(t, modelv1.Status_STATUS_DISK_FULL, resp.Status)
+
+	// the queue clients fail over the nodes which nobody serves.
+	absent := dial(t, d, "10.0.0.2:17912")
+	assert.Equal(t, codes.Unavailable, status.Code(absent.CheckHealth(ctx)))
+}
+
+func TestToStatus(t *testing.T) {
+	assert.NoError(t, toStatus(nil))
+	assert.Equal(t, codes.Unavailable, status.Code(toStatus(nats.ErrConnectionClosed)))
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(toStatus(context.DeadlineExceeded)))
+	assert.Equal(t, codes.NotFound, status.Code(toStatus(status.Error(codes.NotFound, "missing"))))
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate(GRPC, ""))
+	assert.NoError(t, Validate(NATS, "nats://127.0.0.1:4222"))
+	assert.ErrorIs(t, Validate(NATS, ""), ErrNoNATSURL)
+	assert.ErrorIs(t, Validate("kafka", ""), ErrUnknownTransport)
+}
@@ -0,0 +1,77 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package transport implements the transports carrying the messages of the cluster service between the nodes.
+//
+// The queue clients dial the nodes through a Dialer, and the queue servers receive the messages
+// from the gRPC server or from a NATS server shared by the nodes.
+package transport
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	clusterv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/cluster/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+)
+
+const (
+	// GRPC connects to the gRPC addresses of the nodes, which is the default transport.
+	GRPC = "grpc"
+	// NATS exchanges the messages through a NATS server shared by the nodes.
+	NATS = "nats"
+
+	// DefaultSubjectPrefix is the default prefix of the NATS subjects of the nodes.
+	DefaultSubjectPrefix = "banyandb.queue"
+)
+
+var (
+	// ErrUnknownTransport indicates the transport isn't supported.
+	ErrUnknownTransport = errors.New("unknown transport")
+	// ErrNoNATSURL indicates the NATS transport is selected without the URL of the NATS server.
+	ErrNoNATSURL = errors.New("the NATS transport requires the URL of the NATS server")
+)
+
+// Conn is a connection to the cluster service of a node.
+type Conn interface {
+	clusterv1.ServiceClient
+	// CheckHealth returns nil if the node is serving.
+	CheckHealth(ctx context.Context) error
+	Close() error
+}
+
+// Dialer connects to the cluster service of the nodes.
+type Dialer interface {
+	Dial(node *databasev1.Node) (Conn, error)
+	Close() error
+}
+
+// Validate checks the name of the transport and its settings.
+func Validate(name, natsURL string) error {
+	switch name {
+	case GRPC:
+		return nil
+	case NATS:
+		if natsURL == "" {
+			return ErrNoNATSURL
+		}
+		return nil
+	default:
+		return errors.Wrapf(ErrUnknownTransport, "%q, it should be %q or %q", name, GRPC, NATS)
+	}
+}
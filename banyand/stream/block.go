@@ -18,7 +18,6 @@
 package stream
 
 import (
-	"bytes"
 	"fmt"
 	"sort"
 
@@ -26,6 +25,7 @@ import (
 
 	"github.com/apache/skywalking-banyandb/api/common"
 	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
 	pkgbytes "github.com/apache/skywalking-banyandb/pkg/bytes"
 	"github.com/apache/skywalking-banyandb/pkg/encoding"
 	"github.com/apache/skywalking-banyandb/pkg/filter"
@@ -115,18 +115,6 @@ func (b *block) processTags(tf tagValues, tagFamilyIdx, i int, elementsLen int)
 		tags[j].filter.SetN(elementsLen)
 		tags[j].filter.ResizeBits((elementsLen*filter.B + 63) / 64)
 		tags[j].filter.Add(t.value)
-		if t.valueType == pbv1.ValueTypeInt64 {
-			if len(tags[j].min) == 0 {
-				tags[j].min = t.value
-			} else if bytes.Compare(t.value, tags[j].min) == -1 {
-				tags[j].min = t.value
-			}
-			if len(tags[j].max) == 0 {
-				tags[j].max = t.value
-			} else if bytes.Compare(t.value, tags[j].max) == 1 {
-				tags[j].max = t.value
-			}
-		}
 	}
 }
 
@@ -140,6 +128,23 @@ func (b *block) resizeTagFamilies(tagFamiliesLen int) []tagFamily {
 	return tff
 }
 
+// dropDeleted drops the elements ingested at ingestedAt in the deleted time ranges. It returns false if no element remains.
+func (b *block) dropDeleted(rt *storage.RangeTombstones, ingestedAt int64) bool {
+	kept, dropped := rt.FilterTimestamps(b.timestamps, ingestedAt)
+	if !dropped {
+		return len(b.timestamps) > 0
+	}
+	n := len(b.timestamps)
+	b.timestamps = storage.KeepRows(b.timestamps, kept)
+	if len(b.elementIDs) == n {
+		b.elementIDs = storage.KeepRows(b.elementIDs, kept)
+	}
+	for i := range b.tagFamilies {
+		b.tagFamilies[i].keepRows(kept, n)
+	}
+	return len(kept) > 0
+}
+
 func (b *block) Len() int {
 	return len(b.timestamps)
 }
@@ -188,7 +193,7 @@ func (b *block) marshalTagFamily(tf tagFamily, bm *blockMetadata, ww *writers) {
 	cfm := generateTagFamilyMetadata()
 	cmm := cfm.resizeTagMetadata(len(cc))
 	for i := range cc {
-		cc[i].mustWriteTo(&cmm[i], w, fw)
+		cc[i].mustWriteTo(&cmm[i], w, fw, ww.compressionLevel, ww.tagFamilyDicts[tf.name])
 	}
 	bb := bigValuePool.Generate()
 	defer bigValuePool.Release(bb)
@@ -221,6 +226,8 @@ func (b *block) unmarshalTagFamily(decoder *encoding.BytesBlockDecoder, tfIndex
 	bigValuePool.Release(bb)
 	b.tagFamilies[tfIndex].name = name
 	cc := b.tagFamilies[tfIndex].resizeTags(len(tagProjection))
+	// the tags of a family in the payload layout are stored in a single column.
+	var payload *tag
 NEXT:
 	for j := range tagProjection {
 		for i := range tfm.tagMetadata {
@@ -228,13 +235,26 @@ NEXT:
 				cc[j].mustReadValues(decoder, valueReader, tfm.tagMetadata[i], uint64(b.Len()))
 				continue NEXT
 			}
-			cc[j].name = tagProjection[j]
-			cc[j].valueType = pbv1.ValueTypeUnknown
-			cc[j].resizeValues(count)
-			for k := range cc[j].values {
-				cc[j].values[k] = nil
+		}
+		if payload == nil {
+			for i := range tfm.tagMetadata {
+				if tfm.tagMetadata[i].name == payloadTagName {
+					payload = &tag{}
+					payload.mustReadValues(decoder, valueReader, tfm.tagMetadata[i], uint64(b.Len()))
+					break
+				}
 			}
 		}
+		if payload != nil {
+			cc[j].mustDecodePayload(payload, tagProjection[j])
+			continue
+		}
+		cc[j].name = tagProjection[j]
+		cc[j].valueType = pbv1.ValueTypeUnknown
+		cc[j].resizeValues(count)
+		for k := range cc[j].values {
+			cc[j].values[k] = nil
+		}
 	}
 }
 
@@ -418,19 +438,25 @@ type blockCursor struct {
 	tagFamilies      []tagFamily
 	tagValuesDecoder encoding.BytesBlockDecoder
 	tagProjection    []model.TagProjection
+	loc              partLocation
 	bm               blockMetadata
 	idx              int
+	rangeTombstones  *storage.RangeTombstones
 	minTimestamp     int64
 	maxTimestamp     int64
+	withMetadata     bool
 }
 
 func (bc *blockCursor) reset() {
 	bc.idx = 0
 	bc.p = nil
 	bc.bm.reset()
+	bc.rangeTombstones = nil
 	bc.minTimestamp = 0
 	bc.maxTimestamp = 0
 	bc.tagProjection = bc.tagProjection[:0]
+	bc.loc = partLocation{}
+	bc.withMetadata = false
 
 	bc.timestamps = bc.timestamps[:0]
 	bc.elementIDs = bc.elementIDs[:0]
@@ -448,8 +474,25 @@ func (bc *blockCursor) init(p *part, bm *blockMetadata, opts queryOptions) {
 	bc.bm.copyFrom(bm)
 	bc.minTimestamp = opts.minTimestamp
 	bc.maxTimestamp = opts.maxTimestamp
+	bc.rangeTombstones = opts.rangeTombstones
 	bc.tagProjection = opts.TagProjection
 	bc.elementFilter = opts.elementFilter
+	bc.withMetadata = opts.WithMetadata
+}
+
+// metadata returns the storage metadata shared by the elements of the block.
+func (bc *blockCursor) metadata() model.ElementMetadata {
+	m := model.ElementMetadata{
+		Segment: bc.loc.segment,
+		ShardID: bc.loc.shardID,
+		PartID:  bc.p.partMetadata.ID,
+	}
+	// the size of an element is its share of the block, scaled by the compression ratio of the part.
+	if pm := &bc.p.partMetadata; bc.bm.count > 0 && pm.UncompressedSizeBytes > 0 {
+		m.Size = uint64(float64(bc.bm.uncompressedSizeBytes) / float64(bc.bm.count) *
+			float64(pm.CompressedSizeBytes) / float64(pm.UncompressedSizeBytes))
+	}
+	return m
 }
 
 func (bc *blockCursor) copyAllTo(r *model.StreamResult, desc bool) {
@@ -473,6 +516,12 @@ func (bc *blockCursor) copyAllTo(r *model.StreamResult, desc bool) {
 		slices.Reverse(r.Timestamps)
 		slices.Reverse(r.ElementIDs)
 	}
+	if bc.withMetadata {
+		m := bc.metadata()
+		for i := start; i < end; i++ {
+			r.Metadata = append(r.Metadata, m)
+		}
+	}
 
 	if len(r.TagFamilies) != len(bc.tagProjection) {
 		r.TagFamilies = make([]model.TagFamily, len(bc.tagProjection))
@@ -506,6 +555,9 @@ func (bc *blockCursor) copyTo(r *model.StreamResult) {
 	r.Timestamps = append(r.Timestamps, bc.timestamps[bc.idx])
 	r.ElementIDs = append(r.ElementIDs, bc.elementIDs[bc.idx])
 	r.SIDs = append(r.SIDs, bc.bm.seriesID)
+	if bc.withMetadata {
+		r.Metadata = append(r.Metadata, bc.metadata())
+	}
 	if len(r.TagFamilies) != len(bc.tagProjection) {
 		for _, tp := range bc.tagProjection {
 			tf := model.TagFamily{
@@ -539,6 +591,9 @@ func (bc *blockCursor) copyTo(r *model.StreamResult) {
 
 func (bc *blockCursor) loadData(tmpBlock *block) bool {
 	tmpBlock.reset()
+	if bc.rangeTombstones.Covers(bc.bm.timestamps.min, bc.bm.timestamps.max, bc.p.partMetadata.IngestedAt) {
+		return false
+	}
 	bc.bm.tagProjection = bc.tagProjection
 	var tf map[string]*dataBlock
 	for _, tp := range bc.tagProjection {
@@ -557,7 +612,7 @@ func (bc *blockCursor) loadData(tmpBlock *block) bool {
 
 	bc.bm.tagFamilies = tf
 	tmpBlock.mustReadFrom(&bc.tagValuesDecoder, bc.p, bc.bm)
-	if len(tmpBlock.timestamps) == 0 {
+	if !tmpBlock.dropDeleted(bc.rangeTombstones, bc.p.partMetadata.IngestedAt) {
 		return false
 	}
 
@@ -642,6 +697,16 @@ func (bi *blockPointer) updateMetadata() {
 	bi.bm.timestamps.max = bi.block.timestamps[len(bi.timestamps)-1]
 }
 
+// dropDeleted drops the elements in the deleted time ranges before the pointer moves.
+// It returns false if no element remains.
+func (bi *blockPointer) dropDeleted(rt *storage.RangeTombstones, ingestedAt int64) bool {
+	if !bi.block.dropDeleted(rt, ingestedAt) {
+		return false
+	}
+	bi.updateMetadata()
+	return true
+}
+
 func (bi *blockPointer) copyFrom(src *blockPointer) {
 	bi.idx = 0
 	bi.bm.copyFrom(&src.bm)
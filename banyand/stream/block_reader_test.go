@@ -26,6 +26,7 @@ import (
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/stretchr/testify/require"
 
+	"github.com/apache/skywalking-banyandb/pkg/encoding"
 	"github.com/apache/skywalking-banyandb/pkg/fs"
 	"github.com/apache/skywalking-banyandb/pkg/test"
 )
@@ -127,7 +128,7 @@ func Test_blockReader_nextBlock(t *testing.T) {
 				for _, es := range tt.esList {
 					mp := generateMemPart()
 					mpp = append(mpp, mp)
-					mp.mustInitFromElements(es)
+					mp.mustInitFromElements(es, encoding.DefaultCompressionLevel)
 					pp = append(pp, openMemPart(mp))
 				}
 				verify(t, pp)
@@ -151,7 +152,7 @@ func Test_blockReader_nextBlock(t *testing.T) {
 				for i, es := range tt.esList {
 					mp := generateMemPart()
 					mpp = append(mpp, mp)
-					mp.mustInitFromElements(es)
+					mp.mustInitFromElements(es, encoding.DefaultCompressionLevel)
 					mp.mustFlush(fileSystem, partPath(tmpPath, uint64(i)))
 					filePW := newPartWrapper(nil, mustOpenFilePart(uint64(i), tmpPath, fileSystem))
 					filePW.p.partMetadata.ID = uint64(i)
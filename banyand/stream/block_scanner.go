@@ -21,6 +21,7 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"strconv"
 
 	"github.com/apache/skywalking-banyandb/api/common"
 	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
@@ -39,13 +40,15 @@ import (
 const blockScannerBatchSize = 32
 
 type blockScanResult struct {
-	p  *part
-	qo queryOptions
-	bm blockMetadata
+	p   *part
+	loc partLocation
+	qo  queryOptions
+	bm  blockMetadata
 }
 
 func (bs *blockScanResult) reset() {
 	bs.p = nil
+	bs.loc = partLocation{}
 	bs.qo.reset()
 	bs.bm.reset()
 }
@@ -80,6 +83,32 @@ func releaseBlockScanResultBatch(bsb *blockScanResultBatch) {
 
 var blockScanResultBatchPool = pool.Register[*blockScanResultBatch]("stream-blockScannerBatch")
 
+// partLocation is the shard and the segment storing a part, which are reported in the element metadata.
+type partLocation struct {
+	segment string
+	shardID common.ShardID
+}
+
+func (tst *tsTable) location() partLocation {
+	shardID, _ := strconv.ParseUint(tst.p.Shard, 10, 32)
+	return partLocation{segment: tst.p.Segment, shardID: common.ShardID(shardID)}
+}
+
+// locateParts records the location of the parts[offset:] if the query asks for the element metadata.
+func locateParts(locations map[*part]partLocation, qo queryOptions, tst *tsTable, parts []*part, offset int) map[*part]partLocation {
+	if !qo.WithMetadata {
+		return locations
+	}
+	if locations == nil {
+		locations = make(map[*part]partLocation)
+	}
+	loc := tst.location()
+	for _, p := range parts[offset:] {
+		locations[p] = loc
+	}
+	return locations
+}
+
 func searchSeries(ctx context.Context, qo queryOptions, segment storage.Segment[*tsTable, *option], series []*pbv1.Series) (queryOptions, error) {
 	seriesFilter := roaring.NewPostingList()
 	sl, err := segment.Lookup(ctx, series)
@@ -120,16 +149,26 @@ func getBlockScanner(ctx context.Context, segment storage.Segment[*tsTable, *opt
 	var parts []*part
 	var size, offset int
 	filterIndex := make(map[uint64]posting.List)
+	var locations map[*part]partLocation
 	for i := range tabs {
 		filter, filterTS, err := search(ctx, qo, qo.sortedSids, tabs[i], tr)
 		if err != nil {
 			return nil, err
 		}
+		if filter, filterTS, err = filterTrace(qo.StreamQueryOptions, tabs[i:i+1], tr, filter, filterTS); err != nil {
+			return nil, err
+		}
+		if filter, err = filterElements(qo.StreamQueryOptions, filter); err != nil {
+			return nil, err
+		}
 		if filter != nil && filter.IsEmpty() {
 			continue
 		}
 		minTimestamp, maxTimestamp := updateTimeRange(filterTS, qo.minTimestamp, qo.maxTimestamp)
 		snp := tabs[i].currentSnapshot()
+		if snp == nil {
+			continue
+		}
 		parts, size = snp.getParts(parts, minTimestamp, maxTimestamp)
 		if size < 1 {
 			snp.decRef()
@@ -139,6 +178,7 @@ func getBlockScanner(ctx context.Context, segment storage.Segment[*tsTable, *opt
 		for j := offset; j < offset+size; j++ {
 			filterIndex[parts[j].partMetadata.ID] = filter
 		}
+		locations = locateParts(locations, qo, tabs[i], parts, offset)
 		offset += size
 	}
 	if len(parts) < 1 {
@@ -153,6 +193,7 @@ func getBlockScanner(ctx context.Context, segment storage.Segment[*tsTable, *opt
 	return &blockScanner{
 		parts:       getDisjointParts(parts, asc),
 		filterIndex: filterIndex,
+		locations:   locations,
 		qo:          qo,
 		asc:         asc,
 		l:           l,
@@ -198,6 +239,7 @@ type scanFinalizer func()
 
 type blockScanner struct {
 	filterIndex map[uint64]posting.List
+	locations   map[*part]partLocation
 	l           *logger.Logger
 	pm          protector.Memory
 	parts       [][]*part
@@ -238,12 +280,23 @@ func (bsn *blockScanner) scan(ctx context.Context, blockCh chan *blockScanResult
 	for ti.nextBlock() {
 		p := ti.piHeap[0]
 		batch.bss = append(batch.bss, blockScanResult{
-			p: p.p,
+			p:   p.p,
+			loc: bsn.locations[p.p],
 		})
 		bs := &batch.bss[len(batch.bss)-1]
 		bs.qo.copyFrom(&bsn.qo)
 		bs.qo.elementFilter = bsn.filterIndex[p.p.partMetadata.ID]
 		bs.bm.copyFrom(p.curBlock)
+		if err := protector.Account(ctx, bs.bm.uncompressedSizeBytes); err != nil {
+			batch.err = err
+			select {
+			case blockCh <- batch:
+			case <-ctx.Done():
+				releaseBlockScanResultBatch(batch)
+				bsn.l.Warn().Err(err).Msg("the query exceeds the memory limit")
+			}
+			return
+		}
 		quota := bsn.pm.AvailableBytes()
 		for i := range batch.bss {
 			totalBlockBytes += batch.bss[i].bm.uncompressedSizeBytes
@@ -899,3 +899,32 @@ func Test_blockPointer_copyFrom(t *testing.T) {
 		})
 	}
 }
+
+func Test_blockCursor_copyMetadata(t *testing.T) {
+	p := &part{partMetadata: partMetadata{ID: 7, CompressedSizeBytes: 100, UncompressedSizeBytes: 400}}
+	bm := &blockMetadata{seriesID: 1, count: 4, uncompressedSizeBytes: 80}
+	want := model.ElementMetadata{Segment: "seg-20241017", Size: 5, PartID: 7, ShardID: 2}
+
+	bc := &blockCursor{}
+	bc.init(p, bm, queryOptions{StreamQueryOptions: model.StreamQueryOptions{WithMetadata: true}})
+	bc.loc = partLocation{segment: "seg-20241017", shardID: 2}
+	bc.timestamps = []int64{1, 2, 3}
+	bc.elementIDs = []uint64{11, 12, 13}
+
+	r := &model.StreamResult{}
+	bc.copyTo(r)
+	bc.idx = 1
+	bc.copyAllTo(r, false)
+	if diff := cmp.Diff([]model.ElementMetadata{want, want, want}, r.Metadata); diff != "" {
+		t.Errorf("unexpected metadata (-want +got):\n%s", diff)
+	}
+
+	bc.init(p, bm, queryOptions{})
+	bc.timestamps = []int64{1}
+	bc.elementIDs = []uint64{11}
+	r.Reset()
+	bc.copyTo(r)
+	if len(r.Metadata) != 0 {
+		t.Errorf("unexpected metadata %v without asking for it", r.Metadata)
+	}
+}
@@ -66,11 +66,17 @@ type writers struct {
 	tagFamilyMetadataWriters   map[string]*writer
 	tagFamilyWriters           map[string]*writer
 	tagFamilyFilterWriters     map[string]*writer
-	timestampsWriter           writer
+	// tagFamilyDicts are the zstd dictionaries compressing the values of the tag families.
+	tagFamilyDicts   map[string]*zstd.Dict
+	timestampsWriter writer
+	// compressionLevel is the zstd level compressing the blocks.
+	compressionLevel int
 }
 
 func (sw *writers) reset() {
 	sw.mustCreateTagFamilyWriters = nil
+	sw.compressionLevel = 0
+	sw.tagFamilyDicts = nil
 	sw.metaWriter.reset()
 	sw.primaryWriter.reset()
 	sw.timestampsWriter.reset()
@@ -176,16 +182,18 @@ func (bw *blockWriter) reset() {
 	bw.primaryBlockMetadata.reset()
 }
 
-func (bw *blockWriter) MustInitForMemPart(mp *memPart) {
+func (bw *blockWriter) MustInitForMemPart(mp *memPart, compressionLevel int) {
 	bw.reset()
+	bw.writers.compressionLevel = compressionLevel
 	bw.writers.mustCreateTagFamilyWriters = mp.mustCreateMemTagFamilyWriters
 	bw.writers.metaWriter.init(&mp.meta)
 	bw.writers.primaryWriter.init(&mp.primary)
 	bw.writers.timestampsWriter.init(&mp.timestamps)
 }
 
-func (bw *blockWriter) mustInitForFilePart(fileSystem fs.FileSystem, path string, shouldCache bool) {
+func (bw *blockWriter) mustInitForFilePart(fileSystem fs.FileSystem, path string, shouldCache bool, compressionLevel int) {
 	bw.reset()
+	bw.writers.compressionLevel = compressionLevel
 	fileSystem.MkdirPanicIfExist(path, storage.DirPerm)
 	bw.writers.mustCreateTagFamilyWriters = func(name string) (fs.Writer, fs.Writer, fs.Writer) {
 		metaPath := filepath.Join(path, name+tagFamiliesMetadataFilenameExt)
@@ -201,6 +209,11 @@ func (bw *blockWriter) mustInitForFilePart(fileSystem fs.FileSystem, path string
 	bw.writers.timestampsWriter.init(fs.MustCreateFile(fileSystem, filepath.Join(path, timestampsFilename), storage.FilePerm, shouldCache))
 }
 
+// useTagFamilyDicts compresses the values of the tag families with the dictionaries, which are owned by the caller.
+func (bw *blockWriter) useTagFamilyDicts(dicts map[string]*zstd.Dict) {
+	bw.writers.tagFamilyDicts = dicts
+}
+
 func (bw *blockWriter) MustWriteElements(sid common.SeriesID, timestamps []int64, elementIDs []uint64, tagFamilies [][]tagValues) {
 	if len(timestamps) == 0 {
 		return
@@ -276,11 +289,12 @@ func (bw *blockWriter) Flush(pm *partMetadata) {
 	pm.BlocksCount = bw.totalBlocksCount
 	pm.MinTimestamp = bw.totalMinTimestamp
 	pm.MaxTimestamp = bw.totalMaxTimestamp
+	pm.FormatVersion = currentPartFormatVersion
 
 	bw.mustFlushPrimaryBlock(bw.primaryBlockData)
 
 	bb := bigValuePool.Generate()
-	bb.Buf = zstd.Compress(bb.Buf[:0], bw.metaData, 1)
+	bb.Buf = zstd.Compress(bb.Buf[:0], bw.metaData, bw.writers.compressionLevel)
 	bw.writers.metaWriter.MustWrite(bb.Buf)
 	bigValuePool.Release(bb)
 
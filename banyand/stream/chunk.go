@@ -0,0 +1,113 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+)
+
+// chunkTimeout is how long the chunks of an element are kept before all of them arrive.
+const chunkTimeout = time.Minute
+
+type pendingElement struct {
+	updated  time.Time
+	chunks   [][]byte
+	received int
+}
+
+// chunkAssembler reassembles the elements which the liaison splits into chunks.
+type chunkAssembler struct {
+	pending map[uint64]*pendingElement
+	mu      sync.Mutex
+}
+
+func newChunkAssembler() *chunkAssembler {
+	return &chunkAssembler{pending: make(map[uint64]*pendingElement)}
+}
+
+// add keeps the chunk of writeEvent. It returns the write event with the whole element once all the chunks arrive,
+// or nil if some chunks are missing.
+func (a *chunkAssembler) add(writeEvent *streamv1.InternalWriteRequest, now time.Time) (*streamv1.InternalWriteRequest, error) {
+	c := writeEvent.GetChunk()
+	if c.GetTotal() == 0 || c.GetIndex() >= c.GetTotal() {
+		return nil, fmt.Errorf("invalid chunk %d of %d", c.GetIndex(), c.GetTotal())
+	}
+	a.mu.Lock()
+	pe, ok := a.pending[c.GetId()]
+	if !ok {
+		pe = &pendingElement{chunks: make([][]byte, c.GetTotal())}
+		a.pending[c.GetId()] = pe
+	}
+	if len(pe.chunks) != int(c.GetTotal()) {
+		a.mu.Unlock()
+		return nil, fmt.Errorf("the chunk %d has %d chunks in total, but %d chunks are expected", c.GetId(), c.GetTotal(), len(pe.chunks))
+	}
+	pe.updated = now
+	// the chunks resent by the retries are dropped.
+	if pe.chunks[c.GetIndex()] == nil {
+		pe.chunks[c.GetIndex()] = c.GetData()
+		pe.received++
+	}
+	if pe.received < len(pe.chunks) {
+		a.mu.Unlock()
+		return nil, nil
+	}
+	delete(a.pending, c.GetId())
+	a.mu.Unlock()
+
+	var size int
+	for _, d := range pe.chunks {
+		size += len(d)
+	}
+	buf := make([]byte, 0, size)
+	for _, d := range pe.chunks {
+		buf = append(buf, d...)
+	}
+	element := &streamv1.ElementValue{}
+	if err := proto.Unmarshal(buf, element); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the element of the chunk %d: %w", c.GetId(), err)
+	}
+	req := proto.Clone(writeEvent.GetRequest()).(*streamv1.WriteRequest)
+	req.Element = element
+	return &streamv1.InternalWriteRequest{
+		ShardId:      writeEvent.GetShardId(),
+		EntityValues: writeEvent.GetEntityValues(),
+		Request:      req,
+		ElementId:    writeEvent.GetElementId(),
+	}, nil
+}
+
+// expire drops the elements whose chunks haven't arrived for chunkTimeout, and returns the number of them.
+func (a *chunkAssembler) expire(now time.Time) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var n int
+	for id, pe := range a.pending {
+		if now.Sub(pe.updated) > chunkTimeout {
+			delete(a.pending, id)
+			n++
+		}
+	}
+	return n
+}
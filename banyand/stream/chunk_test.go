@@ -0,0 +1,101 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+)
+
+func chunkEvents(t *testing.T, id uint64, element *streamv1.ElementValue, size int) []*streamv1.InternalWriteRequest {
+	data, err := proto.Marshal(element)
+	require.NoError(t, err)
+	total := (len(data) + size - 1) / size
+	var ee []*streamv1.InternalWriteRequest
+	for i := 0; i < total; i++ {
+		ee = append(ee, &streamv1.InternalWriteRequest{
+			ShardId:   1,
+			ElementId: 2,
+			Request:   &streamv1.WriteRequest{Metadata: &commonv1.Metadata{Group: "g", Name: "s"}, MessageId: 1},
+			Chunk: &streamv1.ElementChunk{
+				Id:    id,
+				Index: uint32(i),
+				Total: uint32(total),
+				Data:  data[i*size : min((i+1)*size, len(data))],
+			},
+		})
+	}
+	return ee
+}
+
+func TestChunkAssembler(t *testing.T) {
+	element := &streamv1.ElementValue{
+		ElementId: "e",
+		TagFamilies: []*modelv1.TagFamilyForWrite{{Tags: []*modelv1.TagValue{
+			{Value: &modelv1.TagValue_BinaryData{BinaryData: make([]byte, 100)}},
+		}}},
+	}
+	now := time.Now()
+	a := newChunkAssembler()
+	ee := chunkEvents(t, 1, element, 30)
+	require.Len(t, ee, 4)
+
+	// the chunks arrive out of order, and a chunk is resent.
+	for _, i := range []int{2, 0, 2, 3} {
+		got, err := a.add(ee[i], now)
+		require.NoError(t, err)
+		require.Nil(t, got)
+	}
+	got, err := a.add(ee[1], now)
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Nil(t, got.GetChunk())
+	assert.Equal(t, uint32(1), got.GetShardId())
+	assert.Equal(t, uint64(2), got.GetElementId())
+	assert.Equal(t, "g", got.GetRequest().GetMetadata().GetGroup())
+	assert.True(t, proto.Equal(element, got.GetRequest().GetElement()))
+	assert.Nil(t, ee[1].GetRequest().GetElement(), "the request of the chunk isn't changed")
+	assert.Empty(t, a.pending)
+
+	// the incomplete elements expire.
+	got, err = a.add(chunkEvents(t, 2, element, 30)[0], now)
+	require.NoError(t, err)
+	require.Nil(t, got)
+	assert.Zero(t, a.expire(now.Add(chunkTimeout)))
+	assert.Equal(t, 1, a.expire(now.Add(chunkTimeout+time.Second)))
+	assert.Empty(t, a.pending)
+}
+
+func TestChunkAssemblerInvalidChunk(t *testing.T) {
+	a := newChunkAssembler()
+	now := time.Now()
+	_, err := a.add(&streamv1.InternalWriteRequest{Chunk: &streamv1.ElementChunk{Id: 1, Index: 2, Total: 2}}, now)
+	require.Error(t, err)
+	_, err = a.add(&streamv1.InternalWriteRequest{Chunk: &streamv1.ElementChunk{Id: 1, Index: 0, Total: 2}}, now)
+	require.NoError(t, err)
+	_, err = a.add(&streamv1.InternalWriteRequest{Chunk: &streamv1.ElementChunk{Id: 1, Index: 0, Total: 3}}, now)
+	require.Error(t, err, "the total of the chunks is inconsistent")
+}
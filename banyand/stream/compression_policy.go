@@ -0,0 +1,149 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/apache/skywalking-banyandb/pkg/encoding"
+)
+
+const (
+	maxCompressionLevel = 22
+	maxDictSize         = 1 << 20
+)
+
+// compressionPolicy adapts the zstd compression level of the ingested parts to the write pressure.
+// The memory parts waiting to be flushed are the feedback: once they pile up to the high watermark,
+// the ingestion drops to the pressure level to keep the write latency bounded,
+// and it goes back to the ingest level after they're drained to the low watermark.
+// The merges recompress the parts at the merge level, so the storage efficiency is restored in the background.
+// They also train a dictionary of at most dictSize bytes per tag family, which primes the compression of
+// the small and repetitive values, e.g. the endpoint names and the SQL templates.
+// The archive groups are written in batches by the lifecycle service and rarely queried,
+// so they're compressed at the archive level all the time.
+type compressionPolicy struct {
+	ingestLevel   int
+	pressureLevel int
+	mergeLevel    int
+	archiveLevel  int
+	highWatermark int
+	lowWatermark  int
+	dictSize      int
+}
+
+func newDefaultCompressionPolicy() *compressionPolicy {
+	return &compressionPolicy{
+		ingestLevel:   3,
+		pressureLevel: 1,
+		mergeLevel:    6,
+		archiveLevel:  15,
+		highWatermark: 16,
+		lowWatermark:  4,
+		dictSize:      16 << 10,
+	}
+}
+
+func (cp *compressionPolicy) validate() error {
+	for _, l := range []int{cp.ingestLevel, cp.pressureLevel, cp.mergeLevel, cp.archiveLevel} {
+		if l < 1 || l > maxCompressionLevel {
+			return fmt.Errorf("the compression level %d should be in [1, %d]", l, maxCompressionLevel)
+		}
+	}
+	if cp.pressureLevel > cp.ingestLevel {
+		return errors.New("the pressure compression level should be less than or equal to the ingest compression level")
+	}
+	if cp.lowWatermark < 0 || cp.lowWatermark >= cp.highWatermark {
+		return errors.New("the low watermark of the compression should be in [0, the high watermark)")
+	}
+	if cp.dictSize < 0 || cp.dictSize > maxDictSize {
+		return fmt.Errorf("the dictionary size %d should be in [0, %d]", cp.dictSize, maxDictSize)
+	}
+	return nil
+}
+
+// archived returns the policy of the archive groups, which compresses the ingested and the merged data at the archive level.
+func (cp *compressionPolicy) archived() *compressionPolicy {
+	a := *cp
+	a.ingestLevel, a.pressureLevel, a.mergeLevel = cp.archiveLevel, cp.archiveLevel, cp.archiveLevel
+	return &a
+}
+
+// next returns the ingest level after observing the pending memory parts.
+// The level stays between the watermarks to avoid flapping.
+func (cp *compressionPolicy) next(cur, pending int) int {
+	switch {
+	case pending >= cp.highWatermark:
+		return cp.pressureLevel
+	case pending <= cp.lowWatermark, cur == 0:
+		return cp.ingestLevel
+	default:
+		return cur
+	}
+}
+
+// ingestCompressionLevel returns the level compressing the memory parts.
+func (tst *tsTable) ingestCompressionLevel() int {
+	if l := tst.compressionLevel.Load(); l > 0 {
+		return int(l)
+	}
+	if cp := tst.option.compressionPolicy; cp != nil {
+		return cp.ingestLevel
+	}
+	return encoding.DefaultCompressionLevel
+}
+
+// mergeCompressionLevel returns the level recompressing the parts during the merges.
+func (tst *tsTable) mergeCompressionLevel() int {
+	if cp := tst.option.compressionPolicy; cp != nil {
+		return cp.mergeLevel
+	}
+	return encoding.DefaultCompressionLevel
+}
+
+// mergeDictSize returns the size limit of the dictionaries trained during the merges, and 0 disables them.
+func (tst *tsTable) mergeDictSize() int {
+	if cp := tst.option.compressionPolicy; cp != nil {
+		return cp.dictSize
+	}
+	return 0
+}
+
+// adaptCompressionLevel is the feedback loop adjusting the ingest level by the memory parts of the snapshot.
+func (tst *tsTable) adaptCompressionLevel(snp *snapshot) {
+	cp := tst.option.compressionPolicy
+	if cp == nil {
+		return
+	}
+	var pending int
+	for _, pw := range snp.parts {
+		if pw.mp != nil {
+			pending++
+		}
+	}
+	cur := int(tst.compressionLevel.Load())
+	level := cp.next(cur, pending)
+	if level == cur {
+		return
+	}
+	tst.compressionLevel.Store(int32(level))
+	if cur > 0 {
+		tst.l.Info().Int("from", cur).Int("to", level).Int("pendingMemParts", pending).Msg("the compression level of the ingestion is changed")
+	}
+}
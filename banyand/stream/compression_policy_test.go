@@ -0,0 +1,87 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompressionPolicyNext(t *testing.T) {
+	cp := newDefaultCompressionPolicy()
+	tests := []struct {
+		name    string
+		cur     int
+		pending int
+		want    int
+	}{
+		{name: "initial", cur: 0, pending: 8, want: cp.ingestLevel},
+		{name: "idle", cur: cp.ingestLevel, pending: 0, want: cp.ingestLevel},
+		{name: "reach the high watermark", cur: cp.ingestLevel, pending: cp.highWatermark, want: cp.pressureLevel},
+		{name: "stay under the pressure between the watermarks", cur: cp.pressureLevel, pending: cp.lowWatermark + 1, want: cp.pressureLevel},
+		{name: "stay without the pressure between the watermarks", cur: cp.ingestLevel, pending: cp.highWatermark - 1, want: cp.ingestLevel},
+		{name: "drain to the low watermark", cur: cp.pressureLevel, pending: cp.lowWatermark, want: cp.ingestLevel},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, cp.next(tt.cur, tt.pending))
+		})
+	}
+}
+
+func TestCompressionPolicyArchived(t *testing.T) {
+	cp := newDefaultCompressionPolicy()
+	a := cp.archived()
+	assert.Equal(t, cp.archiveLevel, a.ingestLevel)
+	assert.Equal(t, cp.archiveLevel, a.pressureLevel)
+	assert.Equal(t, cp.archiveLevel, a.mergeLevel)
+	assert.Equal(t, cp.dictSize, a.dictSize)
+	// the policy of the other groups is intact.
+	assert.Equal(t, 3, cp.ingestLevel)
+	require.NoError(t, a.validate())
+}
+
+func TestCompressionPolicyValidate(t *testing.T) {
+	require.NoError(t, newDefaultCompressionPolicy().validate())
+
+	cp := newDefaultCompressionPolicy()
+	cp.mergeLevel = 23
+	assert.Error(t, cp.validate())
+
+	cp = newDefaultCompressionPolicy()
+	cp.pressureLevel = cp.ingestLevel + 1
+	assert.Error(t, cp.validate())
+
+	cp = newDefaultCompressionPolicy()
+	cp.lowWatermark = cp.highWatermark
+	assert.Error(t, cp.validate())
+
+	cp = newDefaultCompressionPolicy()
+	cp.archiveLevel = 0
+	assert.Error(t, cp.validate())
+
+	cp = newDefaultCompressionPolicy()
+	cp.dictSize = 0
+	require.NoError(t, cp.validate())
+	cp.dictSize = -1
+	assert.Error(t, cp.validate())
+	cp.dictSize = maxDictSize + 1
+	assert.Error(t, cp.validate())
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/apache/skywalking-banyandb/api/common"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
 	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
 	"github.com/apache/skywalking-banyandb/pkg/index"
 	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
@@ -206,11 +207,14 @@ type elementsInTable struct {
 	elements *elements
 
 	docs index.Documents
+	// flush holds the elements acknowledged after they're flushed to the disk.
+	flush bool
 }
 
 type elementsInGroup struct {
 	tsdb        storage.TSDB[*tsTable, option]
-	docIDsAdded map[uint64]struct{}
+	group       *commonv1.Group
+	docIDsAdded map[uint64]int
 	docs        index.Documents
 	tables      []*elementsInTable
 	segments    []storage.Segment[*tsTable, option]
@@ -0,0 +1,90 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	"github.com/apache/skywalking-banyandb/pkg/index"
+	"github.com/apache/skywalking-banyandb/pkg/index/posting"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query/model"
+)
+
+func (s *stream) Facets(ctx context.Context, sfo model.StreamFacetOptions) ([]index.Facet, error) {
+	if sfo.TimeRange == nil || len(sfo.Entities) < 1 {
+		return nil, errors.New("invalid facet options: timeRange and series are required")
+	}
+	facets := make([]index.Facet, len(sfo.Fields))
+	for i := range facets {
+		facets[i] = make(index.Facet)
+	}
+	tsdb, err := s.getTSDB()
+	if err != nil {
+		return nil, err
+	}
+	segments, err := tsdb.SelectSegments(*sfo.TimeRange)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		for i := range segments {
+			segments[i].DecRef()
+		}
+	}()
+	series := prepareSeriesData(model.StreamQueryOptions{Name: sfo.Name, Entities: sfo.Entities})
+	sqo := model.StreamQueryOptions{InvertedFilter: sfo.InvertedFilter}
+	tr := index.NewIntRangeOpts(sfo.TimeRange.Start.UnixNano(), sfo.TimeRange.End.UnixNano(), true, true)
+	var sl pbv1.SeriesList
+	var ff []index.Facet
+	for _, segment := range segments {
+		if sl, err = segment.Lookup(ctx, series); err != nil {
+			return nil, err
+		}
+		if len(sl) == 0 {
+			continue
+		}
+		sids := make([]common.SeriesID, len(sl))
+		for i := range sl {
+			sids[i] = sl[i].ID
+		}
+		tables, _ := segment.Tables()
+		// the element IDs matching the inverted filter, nil means all the elements of the series.
+		var filter posting.List
+		if filter, _, err = indexSearch(ctx, sqo, tables, sl.ToList().ToSlice(), &tr); err != nil {
+			return nil, err
+		}
+		if filter != nil && filter.IsEmpty() {
+			continue
+		}
+		for _, t := range tables {
+			if ff, err = t.Index().Facets(ctx, sids, filter, sfo.Fields, sfo.TimeRange); err != nil {
+				return nil, err
+			}
+			for i := range ff {
+				for k, v := range ff[i] {
+					facets[i][k] += v
+				}
+			}
+		}
+	}
+	return facets, nil
+}
@@ -20,6 +20,8 @@ package stream
 import (
 	"errors"
 	"math"
+	"runtime"
+	"sync"
 	"time"
 
 	"github.com/apache/skywalking-banyandb/pkg/watcher"
@@ -40,6 +42,12 @@ func (tst *tsTable) flusherLoop(flushCh chan *flusherIntroduction, mergeCh chan
 		case e := <-flusherWatcher:
 			flusherWatchers.Add(e)
 		case <-epochWatcher.Watch():
+			select {
+			case <-tst.loopCloser.CloseNotify():
+				// the parts flushed by the last round might not be introduced, which mustn't be flushed again.
+				return
+			default:
+			}
 			if func() bool {
 				tst.incTotalFlushLoopStarted(1)
 				start := time.Now()
@@ -145,24 +153,13 @@ func (tst *tsTable) flush(snapshot *snapshot, flushCh chan *flusherIntroduction)
 	ind := generateFlusherIntroduction()
 	defer releaseFlusherIntroduction(ind)
 	start := time.Now()
-	partsCount := 0
-	for _, pw := range snapshot.parts {
-		if pw.mp == nil || pw.mp.partMetadata.TotalCount < 1 {
-			continue
-		}
-		partsCount++
-		partPath := partPath(tst.root, pw.ID())
-		pw.mp.mustFlush(tst.fileSystem, partPath)
-		newPW := newPartWrapper(nil, mustOpenFilePart(pw.ID(), tst.root, tst.fileSystem))
-		newPW.p.partMetadata.ID = pw.ID()
-		ind.flushed[newPW.ID()] = newPW
-	}
+	tst.flushMemParts(snapshot, ind.flushed, nil)
 	if len(ind.flushed) < 1 {
 		return
 	}
 	end := time.Now()
 	tst.incTotalFlushed(1)
-	tst.incTotalFlushedMemParts(partsCount)
+	tst.incTotalFlushedMemParts(len(ind.flushed))
 	tst.incTotalFlushLatency(end.Sub(start).Seconds())
 	ind.applied = make(chan struct{})
 	select {
@@ -177,6 +174,86 @@ func (tst *tsTable) flush(snapshot *snapshot, flushCh chan *flusherIntroduction)
 	tst.incTotalFlushIntroLatency(time.Since(end).Seconds())
 }
 
+// flushMemParts writes the in-memory parts of the snapshot to disk, and puts the opened file parts into flushed.
+// The parts in onDisk have been written by the flusher, but not introduced yet.
+// The parts are written to their own directories by up to flushWorkers goroutines.
+func (tst *tsTable) flushMemParts(snapshot *snapshot, flushed map[uint64]*partWrapper, onDisk map[string]struct{}) {
+	var pending []*partWrapper
+	for _, pw := range snapshot.parts {
+		if pw.mp == nil || pw.mp.partMetadata.TotalCount < 1 {
+			continue
+		}
+		pending = append(pending, pw)
+	}
+	workers := make(chan struct{}, max(tst.option.flushWorkers, 1))
+	var wg sync.WaitGroup
+	for _, pw := range pending {
+		if _, ok := onDisk[partName(pw.ID())]; ok {
+			continue
+		}
+		workers <- struct{}{}
+		wg.Add(1)
+		go func(mp *memPart, path string) {
+			defer func() {
+				<-workers
+				wg.Done()
+			}()
+			tst.mustFlushMemPart(mp, path)
+		}(pw.mp, partPath(tst.root, pw.ID()))
+	}
+	wg.Wait()
+	for _, pw := range pending {
+		newPW := newPartWrapper(nil, mustOpenFilePart(pw.ID(), tst.root, tst.fileSystem))
+		newPW.p.partMetadata.ID = pw.ID()
+		flushed[newPW.ID()] = newPW
+	}
+}
+
+// defaultFlushWorkers gives a flush worker to every 4 CPUs, up to 8 workers per shard.
+func defaultFlushWorkers() int {
+	return min(max(runtime.GOMAXPROCS(0)/4, 1), 8)
+}
+
+// mustFlushMemPart writes the memory part to path, which is packed into a single file if it's small enough.
+func (tst *tsTable) mustFlushMemPart(mp *memPart, path string) {
+	if tst.option.packPartMaxSize > 0 && mp.partMetadata.CompressedSizeBytes <= uint64(tst.option.packPartMaxSize) {
+		mp.mustFlushPacked(tst.fileSystem, path)
+		return
+	}
+	mp.mustFlush(tst.fileSystem, path)
+}
+
+// flushOnClose persists the in-memory parts left by the stopped loops,
+// so that the acknowledged data survive the shutdown.
+func (tst *tsTable) flushOnClose() {
+	if tst.fileSystem == nil {
+		// the table only lives in memory.
+		return
+	}
+	cur := tst.currentSnapshot()
+	if cur == nil {
+		return
+	}
+	defer cur.decRef()
+	// the flusher might stop before its introduction is applied.
+	onDisk := make(map[string]struct{})
+	for _, e := range tst.fileSystem.ReadDir(tst.root) {
+		if e.IsDir() {
+			onDisk[e.Name()] = struct{}{}
+		}
+	}
+	flushed := make(map[uint64]*partWrapper)
+	tst.flushMemParts(cur, flushed, onDisk)
+	if len(flushed) < 1 {
+		return
+	}
+	next := cur.merge(cur.epoch+1, flushed)
+	next.creator = snapshotCreatorFlusher
+	tst.replaceSnapshot(&next)
+	tst.persistSnapshot(&next)
+	tst.gc.clean()
+}
+
 func (tst *tsTable) persistSnapshot(snapshot *snapshot) {
 	var partNames []string
 	for i := range snapshot.parts {
@@ -0,0 +1,284 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.uber.org/multierr"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	"github.com/apache/skywalking-banyandb/banyand/protector"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/partition"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+const (
+	defaultImportBatchSize = 100_000
+	maxImportLineSize      = 16 * 1024 * 1024
+)
+
+// ImportOptions describes the stream to import and the place its data live in.
+type ImportOptions struct {
+	Group  *commonv1.Group
+	Stream *databasev1.Stream
+	// Root is the root path of the stream data, the same as the "stream-root-path" of the data node.
+	Root       string
+	IndexRules []*databasev1.IndexRule
+	// BatchSize is the number of elements built into the parts of a table at once.
+	BatchSize int
+}
+
+// ErrInvalidElement marks an element of the input which can't be decoded or written, Import skips it and goes on.
+var ErrInvalidElement = errors.New("invalid element")
+
+// ElementReader reads the elements to import one by one.
+type ElementReader interface {
+	// Next returns the next element, or io.EOF at the end of the input.
+	Next() (*streamv1.ElementValue, error)
+}
+
+type jsonElementReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// NewJSONElementReader returns an ElementReader decoding one JSON-encoded ElementValue per line of r.
+func NewJSONElementReader(r io.Reader) ElementReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxImportLineSize)
+	return &jsonElementReader{scanner: scanner}
+}
+
+func (jr *jsonElementReader) Next() (*streamv1.ElementValue, error) {
+	for jr.scanner.Scan() {
+		jr.line++
+		data := jr.scanner.Bytes()
+		if len(bytes.TrimSpace(data)) == 0 {
+			continue
+		}
+		ev := &streamv1.ElementValue{}
+		if err := protojson.Unmarshal(data, ev); err != nil {
+			return nil, fmt.Errorf("%w at line %d: %w", ErrInvalidElement, jr.line, err)
+		}
+		return ev, nil
+	}
+	if err := jr.scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read line %d: %w", jr.line+1, err)
+	}
+	return nil, io.EOF
+}
+
+// ImportStats summarizes an import.
+type ImportStats struct {
+	Elements int
+	Skipped  int
+	Parts    int
+}
+
+// Import reads the elements of a stream from r, builds the sealed parts and the indexes from them, and installs the parts into the segments under the root path.
+// It bypasses the memory parts, so the data node owning the root path must be stopped while importing.
+func Import(ctx context.Context, opts ImportOptions, r ElementReader) (stats ImportStats, err error) {
+	group := opts.Group.GetMetadata().GetName()
+	ro := opts.Group.GetResourceOpts()
+	if ro == nil {
+		return stats, fmt.Errorf("no resource opts in group %s", group)
+	}
+	if opts.Stream.GetMetadata().GetGroup() != group {
+		return stats, fmt.Errorf("stream %s doesn't belong to group %s", opts.Stream.GetMetadata(), group)
+	}
+	batchSize := opts.BatchSize
+	if batchSize < 1 {
+		batchSize = defaultImportBatchSize
+	}
+	l := logger.GetLogger("stream", "import")
+	stm := openStream(streamSpec{schema: opts.Stream}, l, protector.Nop{}, nil)
+	stm.OnIndexUpdate(opts.IndexRules)
+
+	tsdb, err := storage.OpenTSDB(
+		common.SetPosition(context.Background(), func(p common.Position) common.Position {
+			p.Module = "stream"
+			p.Database = group
+			return p
+		}),
+		storage.TSDBOpts[*tsTable, option]{
+			ShardNum:        ro.ShardNum,
+			Location:        filepath.Join(opts.Root, "stream", storage.DataDir, group),
+			TSTableCreator:  newTSTable,
+			SegmentInterval: storage.MustToIntervalRule(ro.SegmentInterval),
+			TTL:             storage.MustToIntervalRule(ro.Ttl),
+			Option: option{
+				mergePolicy:              newDefaultMergePolicy(),
+				protector:                protector.Nop{},
+				flushTimeout:             defaultFlushTimeout,
+				elementIndexFlushTimeout: defaultFlushTimeout,
+			},
+			SeriesIndexFlushTimeoutSeconds: defaultFlushTimeout.Nanoseconds() / int64(time.Second),
+			// the history might be older than the TTL, leave it to the data node.
+			DisableRetention: true,
+		}, nil, group)
+	if err != nil {
+		return stats, fmt.Errorf("cannot open the database of group %s: %w", group, err)
+	}
+	defer func() {
+		err = multierr.Append(err, tsdb.Close())
+	}()
+
+	im := &importer{
+		stm:      stm,
+		tsdb:     tsdb,
+		l:        l,
+		locator:  partition.NewEntityLocator(opts.Stream.GetTagFamilies(), opts.Stream.GetEntity(), 0),
+		md:       opts.Stream.GetMetadata(),
+		shardNum: ro.ShardNum,
+	}
+	if shardingKey := opts.Stream.GetShardingKey(); len(shardingKey.GetTagNames()) > 0 {
+		l := partition.NewShardingKeyLocator(opts.Stream.GetTagFamilies(), shardingKey)
+		im.shardingKeyLocator = &l
+	}
+	im.reset()
+	var pending int
+	for n := 1; ; n++ {
+		if err = ctx.Err(); err != nil {
+			return stats, err
+		}
+		ev, errNext := r.Next()
+		if errors.Is(errNext, io.EOF) {
+			break
+		}
+		if errNext == nil {
+			errNext = im.add(ev)
+		}
+		if errNext != nil {
+			if !errors.Is(errNext, ErrInvalidElement) {
+				stats.Parts += im.flush()
+				return stats, errNext
+			}
+			l.Warn().Err(errNext).Int("element", n).Msg("skip the element")
+			stats.Skipped++
+			continue
+		}
+		stats.Elements++
+		if pending++; pending >= batchSize {
+			stats.Parts += im.flush()
+			pending = 0
+		}
+	}
+	stats.Parts += im.flush()
+	return stats, nil
+}
+
+type importer struct {
+	tsdb               storage.TSDB[*tsTable, option]
+	stm                *stream
+	l                  *logger.Logger
+	md                 *commonv1.Metadata
+	eg                 *elementsInGroup
+	shardingKeyLocator *partition.Locator
+	docIDBuilder       strings.Builder
+	locator            partition.Locator
+	shardNum           uint32
+}
+
+func (im *importer) reset() {
+	im.eg = &elementsInGroup{
+		tsdb:        im.tsdb,
+		docIDsAdded: make(map[uint64]int),
+	}
+}
+
+func (im *importer) add(ev *streamv1.ElementValue) error {
+	if ev.GetTimestamp() == nil {
+		return fmt.Errorf("%w: the timestamp is absent", ErrInvalidElement)
+	}
+	t := ev.GetTimestamp().AsTime().Local()
+	if err := timestamp.Check(t); err != nil {
+		return fmt.Errorf("%w: invalid timestamp: %w", ErrInvalidElement, err)
+	}
+	if n := len(ev.GetTagFamilies()); n < 1 || n > len(im.stm.schema.GetTagFamilies()) {
+		return fmt.Errorf("%w: the element has %d tag families, but %s has %d", ErrInvalidElement, n, im.md, len(im.stm.schema.GetTagFamilies()))
+	}
+	tagValues, shardID, err := im.locator.Locate(im.md.GetName(), ev.GetTagFamilies(), im.shardNum)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidElement, err)
+	}
+	if im.shardingKeyLocator != nil {
+		if _, shardID, err = im.shardingKeyLocator.Locate(im.md.GetName(), ev.GetTagFamilies(), im.shardNum); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidElement, err)
+		}
+	}
+	ts := t.UnixNano()
+	et, err := prepareElementsInTable(im.eg, shardID, ts, false)
+	if err != nil {
+		return err
+	}
+	if im.eg.latestTS < ts {
+		im.eg.latestTS = ts
+	}
+	if err = processElements(im.stm, et, im.eg, &streamv1.InternalWriteRequest{
+		Request: &streamv1.WriteRequest{
+			Metadata: im.md,
+			Element:  ev,
+		},
+		ShardId:      uint32(shardID),
+		EntityValues: tagValues[1:].Encode(),
+	}, &im.docIDBuilder, ts, indexTermLimit{}); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidElement, err)
+	}
+	return nil
+}
+
+// flush installs the pending elements into their tables, and returns the number of the built parts.
+func (im *importer) flush() (parts int) {
+	for _, et := range im.eg.tables {
+		if len(et.elements.timestamps) > 0 {
+			et.tsTable.mustImportElements(et.elements)
+			parts++
+		}
+		releaseElements(et.elements)
+		if len(et.docs) > 0 {
+			if err := et.tsTable.Index().Write(et.docs); err != nil {
+				im.l.Error().Err(err).Msg("cannot write element index")
+			}
+		}
+	}
+	for _, segment := range im.eg.segments {
+		if len(im.eg.docs) > 0 {
+			if err := segment.IndexDB().Insert(im.eg.docs); err != nil {
+				im.l.Error().Err(err).Msg("cannot write index")
+			}
+		}
+		segment.DecRef()
+	}
+	im.reset()
+	return parts
+}
@@ -33,23 +33,32 @@ import (
 	"github.com/apache/skywalking-banyandb/pkg/timestamp"
 )
 
+const (
+	// traceIDField indexes the trace IDs of the elements across all the series.
+	traceIDField = "_trace_id"
+	spanIDField  = "_span_id"
+)
+
 type elementIndex struct {
 	store    index.Store
 	l        *logger.Logger
 	location string
 }
 
-func newElementIndex(ctx context.Context, root string, flushTimeoutSeconds int64, metrics *inverted.Metrics) (*elementIndex, error) {
+func newElementIndex(ctx context.Context, root string, flushTimeoutSeconds int64, metrics *inverted.Metrics,
+	mergeScheduler *inverted.MergeScheduler,
+) (*elementIndex, error) {
 	ei := &elementIndex{
 		l:        logger.Fetch(ctx, "element_index"),
 		location: path.Join(root, elementIndexFilename),
 	}
 	var err error
 	if ei.store, err = inverted.NewStore(inverted.StoreOpts{
-		Path:         ei.location,
-		Logger:       ei.l,
-		BatchWaitSec: flushTimeoutSeconds,
-		Metrics:      metrics,
+		Path:           ei.location,
+		Logger:         ei.l,
+		BatchWaitSec:   flushTimeoutSeconds,
+		Metrics:        metrics,
+		MergeScheduler: mergeScheduler,
 	}); err != nil {
 		return nil, err
 	}
@@ -107,6 +116,35 @@ func (e *elementIndex) Search(ctx context.Context, seriesList []uint64, filter i
 	return result, resultTS, nil
 }
 
+// LookupTrace returns the elements written with the trace ID, which are narrowed down to the span if spanID isn't empty.
+func (e *elementIndex) LookupTrace(traceID, spanID string, tr *index.RangeOpts) (posting.List, posting.List, error) {
+	pl, plTS, err := e.store.LookupTerm(index.NewStringField(index.FieldKey{TagName: traceIDField, TimeRange: tr}, traceID))
+	if err != nil || spanID == "" || pl.IsEmpty() {
+		return pl, plTS, err
+	}
+	spanPL, spanTS, err := e.store.LookupTerm(index.NewStringField(index.FieldKey{TagName: spanIDField, TimeRange: tr}, spanID))
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = pl.Intersect(spanPL); err != nil {
+		return nil, nil, err
+	}
+	if err = plTS.Intersect(spanTS); err != nil {
+		return nil, nil, err
+	}
+	return pl, plTS, nil
+}
+
+func (e *elementIndex) Facets(ctx context.Context, sids []common.SeriesID, docIDs posting.List, fields []index.FacetField,
+	timeRange *timestamp.TimeRange,
+) ([]index.Facet, error) {
+	return e.store.Facets(ctx, sids, docIDs, fields, timeRange)
+}
+
+func (e *elementIndex) Values(ctx context.Context, elementIDs []uint64, fields []index.FieldKey) (map[uint64]index.StoredFields, error) {
+	return e.store.Values(ctx, elementIDs, fields)
+}
+
 func (e *elementIndex) Close() error {
 	return e.store.Close()
 }
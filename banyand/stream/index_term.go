@@ -0,0 +1,126 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+)
+
+const (
+	indexTermPolicyTruncate = "truncate"
+	indexTermPolicyReject   = "reject"
+)
+
+var errIndexTermTooLong = errors.New("the indexed term is too long")
+
+// indexTermLimit bounds the length of the string and the binary terms indexed by the inverted rules,
+// e.g. the SQL statements tokenized by the analyzers, which bloat the index memory and slow the writes down.
+// A term longer than maxLength bytes is cut at the UTF-8 boundary under the truncate policy,
+// or the element is rejected under the reject policy.
+// The rules storing the values can't be truncated without corrupting the stored values,
+// so their elements are rejected under both policies.
+// A non-positive maxLength lifts the limit.
+type indexTermLimit struct {
+	policy    string
+	maxLength int
+}
+
+func (l indexTermLimit) validate() error {
+	if l.policy != indexTermPolicyTruncate && l.policy != indexTermPolicyReject {
+		return fmt.Errorf("the index term policy %q should be either %q or %q", l.policy, indexTermPolicyTruncate, indexTermPolicyReject)
+	}
+	return nil
+}
+
+func (l indexTermLimit) enabled() bool {
+	return l.maxLength > 0
+}
+
+// check scans the values of the inverted index rules in the tag families,
+// and reports whether any of them exceeds the limit and whether the element should be rejected.
+func (l indexTermLimit) check(is *indexSchema, tagFamilies []*modelv1.TagFamilyForWrite) (exceeded, reject bool) {
+	if !l.enabled() {
+		return false, false
+	}
+	for i, tagFamilySpec := range is.schema.GetTagFamilies() {
+		if i >= len(tagFamilies) {
+			break
+		}
+		tfr := is.indexRuleLocators.TagFamilyTRule[i]
+		for j, t := range tagFamilySpec.GetTags() {
+			if j >= len(tagFamilies[i].GetTags()) {
+				break
+			}
+			r, ok := tfr[t.GetName()]
+			if !ok || r.GetType() != databasev1.IndexRule_TYPE_INVERTED {
+				continue
+			}
+			tagValue := tagFamilies[i].GetTags()[j]
+			if tagValue == pbv1.NullTagValue || !l.exceeds(tagValue) {
+				continue
+			}
+			exceeded = true
+			if l.policy == indexTermPolicyReject || r.GetStoreValue() {
+				return true, true
+			}
+		}
+	}
+	return exceeded, false
+}
+
+func (l indexTermLimit) exceeds(tagValue *modelv1.TagValue) bool {
+	switch v := tagValue.GetValue().(type) {
+	case *modelv1.TagValue_Str:
+		return len(v.Str.GetValue()) > l.maxLength
+	case *modelv1.TagValue_StrArray:
+		for _, s := range v.StrArray.GetValue() {
+			if len(s) > l.maxLength {
+				return true
+			}
+		}
+	case *modelv1.TagValue_BinaryData:
+		return len(v.BinaryData) > l.maxLength
+	}
+	return false
+}
+
+// truncateTerm cuts s to at most maxLength bytes without splitting a rune.
+func truncateTerm(s string, maxLength int) string {
+	if maxLength < 1 || len(s) <= maxLength {
+		return s
+	}
+	n := maxLength
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// truncateBinaryTerm cuts b to at most maxLength bytes.
+func truncateBinaryTerm(b []byte, maxLength int) []byte {
+	if maxLength < 1 || len(b) <= maxLength {
+		return b
+	}
+	return b[:maxLength]
+}
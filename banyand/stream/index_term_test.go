@@ -0,0 +1,114 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+)
+
+func TestTruncateTerm(t *testing.T) {
+	assert.Equal(t, "select", truncateTerm("select", 0))
+	assert.Equal(t, "select", truncateTerm("select", 6))
+	assert.Equal(t, "sel", truncateTerm("select", 3))
+	// "数据" has two runes of three bytes each, the second one can't be split.
+	assert.Equal(t, "数", truncateTerm("数据", 5))
+	assert.Equal(t, []byte{1, 2}, truncateBinaryTerm([]byte{1, 2, 3}, 2))
+}
+
+func TestIndexTermLimit(t *testing.T) {
+	s := &stream{schema: &databasev1.Stream{
+		Metadata: &commonv1.Metadata{Name: "sw", Group: "default"},
+		Entity:   &databasev1.Entity{TagNames: []string{"service"}},
+		TagFamilies: []*databasev1.TagFamilySpec{{
+			Name: "searchable",
+			Tags: []*databasev1.TagSpec{
+				{Name: "service", Type: databasev1.TagType_TAG_TYPE_STRING},
+				{Name: "statement", Type: databasev1.TagType_TAG_TYPE_STRING},
+				{Name: "peer", Type: databasev1.TagType_TAG_TYPE_STRING, IndexedOnly: true},
+			},
+		}},
+	}}
+	s.OnIndexUpdate([]*databasev1.IndexRule{
+		{Metadata: &commonv1.Metadata{Id: 1}, Tags: []string{"statement"}, Type: databasev1.IndexRule_TYPE_INVERTED, Analyzer: "standard"},
+		{Metadata: &commonv1.Metadata{Id: 2}, Tags: []string{"peer"}, Type: databasev1.IndexRule_TYPE_INVERTED, StoreValue: true},
+	})
+	strValue := func(v string) *modelv1.TagValue {
+		return &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: v}}}
+	}
+	statement := strings.Repeat("select * from t where id = 1 ", 10)
+	write := func(limit indexTermLimit, peer string) (*elementsInTable, error) {
+		et := &elementsInTable{elements: generateElements()}
+		et.elements.reset()
+		eg := &elementsInGroup{docIDsAdded: make(map[uint64]int)}
+		var builder strings.Builder
+		err := processElements(s, et, eg, &streamv1.InternalWriteRequest{
+			Request: &streamv1.WriteRequest{
+				Metadata: s.schema.GetMetadata(),
+				Element: &streamv1.ElementValue{
+					ElementId: "1",
+					TagFamilies: []*modelv1.TagFamilyForWrite{{
+						Tags: []*modelv1.TagValue{strValue("svc"), strValue(statement), strValue(peer)},
+					}},
+				},
+			},
+		}, &builder, 1, limit)
+		return et, err
+	}
+	termOf := func(et *elementsInTable, ruleID uint32) string {
+		for _, f := range et.docs[0].Fields {
+			if f.Key.IndexRuleID == ruleID {
+				return string(f.GetBytes())
+			}
+		}
+		return ""
+	}
+
+	et, err := write(indexTermLimit{policy: indexTermPolicyTruncate}, "db")
+	require.NoError(t, err)
+	assert.Equal(t, statement, termOf(et, 1))
+
+	et, err = write(indexTermLimit{policy: indexTermPolicyTruncate, maxLength: 64}, "db")
+	require.NoError(t, err)
+	assert.Equal(t, statement[:64], termOf(et, 1))
+	assert.Equal(t, "db", termOf(et, 2))
+	// the tag value stored in the part is intact, the entity and the indexed-only tags aren't there.
+	require.Len(t, et.elements.tagFamilies, 1)
+	assert.Equal(t, []byte(statement), et.elements.tagFamilies[0][0].values[0].value)
+
+	et, err = write(indexTermLimit{policy: indexTermPolicyReject, maxLength: 64}, "db")
+	require.ErrorIs(t, err, errIndexTermTooLong)
+	assert.Empty(t, et.elements.timestamps)
+	assert.Empty(t, et.docs)
+
+	// the stored values can't be truncated.
+	et, err = write(indexTermLimit{policy: indexTermPolicyTruncate, maxLength: 512}, strings.Repeat("p", 513))
+	require.ErrorIs(t, err, errIndexTermTooLong)
+	assert.Empty(t, et.elements.timestamps)
+
+	require.NoError(t, indexTermLimit{policy: indexTermPolicyReject}.validate())
+	require.Error(t, indexTermLimit{policy: "drop"}.validate())
+}
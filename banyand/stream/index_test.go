@@ -0,0 +1,90 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/banyand/protector"
+	"github.com/apache/skywalking-banyandb/pkg/fs"
+	"github.com/apache/skywalking-banyandb/pkg/index"
+	"github.com/apache/skywalking-banyandb/pkg/index/posting"
+	"github.com/apache/skywalking-banyandb/pkg/index/posting/roaring"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/query/model"
+	"github.com/apache/skywalking-banyandb/pkg/test"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+func TestFilterTrace(t *testing.T) {
+	tmpPath, defFn := test.Space(require.New(t))
+	defer defFn()
+	opt := option{flushTimeout: time.Hour, mergePolicy: newDefaultMergePolicyForTesting(), protector: protector.Nop{}}
+	tst, err := newTSTable(fs.NewLocalFileSystem(), tmpPath, common.Position{}, logger.GetLogger("test"), timestamp.TimeRange{}, opt, nil)
+	require.NoError(t, err)
+	defer tst.Close()
+
+	now := time.Now().UnixNano()
+	elements := []struct {
+		element  *streamv1.ElementValue
+		seriesID common.SeriesID
+	}{
+		{element: &streamv1.ElementValue{TraceId: "t1", SpanId: "s1"}, seriesID: 1},
+		{element: &streamv1.ElementValue{TraceId: "t1", SpanId: "s2"}, seriesID: 2},
+		{element: &streamv1.ElementValue{TraceId: "t2", SpanId: "s1"}, seriesID: 1},
+		{element: &streamv1.ElementValue{}, seriesID: 2},
+	}
+	docs := make(index.Documents, 0, len(elements))
+	for i, e := range elements {
+		docs = append(docs, index.Document{
+			DocID:     uint64(i + 1),
+			Fields:    appendTraceFields(nil, e.element, e.seriesID),
+			Timestamp: now + int64(i),
+		})
+	}
+	require.NoError(t, tst.Index().Write(docs))
+
+	tr := index.NewIntRangeOpts(now, now+int64(len(elements)), true, true)
+	tests := []struct {
+		filter  posting.List
+		want    posting.List
+		name    string
+		traceID string
+		spanID  string
+	}{
+		{name: "trace across series", traceID: "t1", want: roaring.NewPostingListWithInitialData(1, 2)},
+		{name: "span of trace", traceID: "t1", spanID: "s2", want: roaring.NewPostingListWithInitialData(2)},
+		{name: "intersect filter", traceID: "t1", filter: roaring.NewPostingListWithInitialData(1, 3), want: roaring.NewPostingListWithInitialData(1)},
+		{name: "unknown trace", traceID: "t3", want: roaring.NewPostingList()},
+		{name: "no trace", filter: roaring.NewPostingListWithInitialData(4), want: roaring.NewPostingListWithInitialData(4)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqo := model.StreamQueryOptions{TraceID: tt.traceID, SpanID: tt.spanID}
+			got, _, err := filterTrace(sqo, []*tsTable{tst}, &tr, tt.filter, nil)
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(got), "got %v", got.ToSlice())
+		})
+	}
+}
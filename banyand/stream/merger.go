@@ -25,6 +25,7 @@ import (
 
 	"github.com/dustin/go-humanize"
 
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
 	"github.com/apache/skywalking-banyandb/pkg/cgroups"
 	"github.com/apache/skywalking-banyandb/pkg/encoding"
 	"github.com/apache/skywalking-banyandb/pkg/fs"
@@ -91,7 +92,7 @@ func (tst *tsTable) mergeSnapshot(curSnapshot *snapshot, merges chan *mergerIntr
 	freeDiskSize := tst.freeDiskSpace(tst.root)
 	var toBeMerged map[uint64]struct{}
 	dst, toBeMerged = tst.getPartsToMerge(curSnapshot, freeDiskSize, dst)
-	if len(dst) < 2 {
+	if len(dst) == 0 {
 		return nil, nil
 	}
 	if _, err := tst.mergePartsThenSendIntroduction(snapshotCreatorMerger, dst,
@@ -107,7 +108,13 @@ func (tst *tsTable) mergePartsThenSendIntroduction(creator snapshotCreator, part
 	reservedSpace := tst.reserveSpace(parts)
 	defer releaseDiskSpace(reservedSpace)
 	start := time.Now()
-	newPart, err := tst.mergeParts(tst.fileSystem, closeCh, parts, atomic.AddUint64(&tst.curPartID, 1), tst.root)
+	// the memory parts merged by the flusher are still under the write pressure,
+	// they're neither recompressed at the merge level nor compressed with the dictionaries.
+	compressionLevel, dictSize := tst.mergeCompressionLevel(), tst.mergeDictSize()
+	if creator == snapshotCreatorMergedFlusher {
+		compressionLevel, dictSize = tst.ingestCompressionLevel(), 0
+	}
+	newPart, err := tst.mergeParts(tst.fileSystem, closeCh, parts, atomic.AddUint64(&tst.curPartID, 1), tst.root, compressionLevel, dictSize)
 	if err != nil {
 		return nil, err
 	}
@@ -203,13 +210,19 @@ func (tst *tsTable) getPartsToMerge(snapshot *snapshot, freeDiskSize uint64, dst
 	var parts []*partWrapper
 
 	for _, pw := range snapshot.parts {
-		if pw.mp != nil || pw.p.partMetadata.TotalCount < 1 {
+		// the replicated parts mirror the primary, which merges them instead.
+		if pw.mp != nil || pw.p.partMetadata.TotalCount < 1 || pw.p.partMetadata.ReplicaOf > 0 {
 			continue
 		}
 		parts = append(parts, pw)
 	}
 
 	dst = tst.option.mergePolicy.getPartsToMerge(dst, parts, freeDiskSize)
+	if len(dst) == 0 {
+		// upgrade the parts in older formats when there is nothing worth merging,
+		// a single part is rewritten alone.
+		dst = tst.option.mergePolicy.getOutdatedParts(dst, parts, freeDiskSize)
+	}
 	if len(dst) == 0 {
 		return nil, nil
 	}
@@ -234,10 +247,19 @@ func (tst *tsTable) reserveSpace(parts []*partWrapper) uint64 {
 
 var errNoPartToMerge = fmt.Errorf("no part to merge")
 
-func (tst *tsTable) mergeParts(fileSystem fs.FileSystem, closeCh <-chan struct{}, parts []*partWrapper, partID uint64, root string) (*partWrapper, error) {
+func (tst *tsTable) mergeParts(fileSystem fs.FileSystem, closeCh <-chan struct{}, parts []*partWrapper, partID uint64, root string,
+	compressionLevel, dictSize int,
+) (*partWrapper, error) {
 	if len(parts) == 0 {
 		return nil, errNoPartToMerge
 	}
+	var samples map[string][][]byte
+	if dictSize > 0 {
+		var err error
+		if samples, err = sampleTagFamilies(parts); err != nil {
+			return nil, err
+		}
+	}
 	dstPath := partPath(root, partID)
 	var totalSize int64
 	pii := make([]*partMergeIter, 0, len(parts))
@@ -251,9 +273,12 @@ func (tst *tsTable) mergeParts(fileSystem fs.FileSystem, closeCh <-chan struct{}
 	br := generateBlockReader()
 	br.init(pii)
 	bw := generateBlockWriter()
-	bw.mustInitForFilePart(fileSystem, dstPath, shouldCache)
+	bw.mustInitForFilePart(fileSystem, dstPath, shouldCache, compressionLevel)
+	dicts := mustWriteTagFamilyDicts(fileSystem, dstPath, samples, dictSize)
+	defer releaseTagFamilyDicts(dicts)
+	bw.useTagFamilyDicts(dicts)
 
-	pm, err := mergeBlocks(closeCh, bw, br)
+	pm, err := mergeBlocks(closeCh, bw, br, tst.option.rangeTombstones)
 	releaseBlockWriter(bw)
 	releaseBlockReader(br)
 	for i := range pii {
@@ -262,6 +287,12 @@ func (tst *tsTable) mergeParts(fileSystem fs.FileSystem, closeCh <-chan struct{}
 	if err != nil {
 		return nil, err
 	}
+	for i := range parts {
+		pm.IngestedAt = max(pm.IngestedAt, parts[i].p.partMetadata.IngestedAt)
+	}
+	for _, d := range dicts {
+		pm.CompressedSizeBytes += uint64(len(d.Content()))
+	}
 	pm.mustWriteMetadata(fileSystem, dstPath)
 	fileSystem.SyncPath(dstPath)
 	p := mustOpenFilePart(partID, root, fileSystem)
@@ -271,7 +302,7 @@ func (tst *tsTable) mergeParts(fileSystem fs.FileSystem, closeCh <-chan struct{}
 
 var errClosed = fmt.Errorf("the merger is closed")
 
-func mergeBlocks(closeCh <-chan struct{}, bw *blockWriter, br *blockReader) (*partMetadata, error) {
+func mergeBlocks(closeCh <-chan struct{}, bw *blockWriter, br *blockReader, rt *storage.RangeTombstones) (*partMetadata, error) {
 	pendingBlockIsEmpty := true
 	pendingBlock := generateBlockPointer()
 	defer releaseBlockPointer(pendingBlock)
@@ -297,8 +328,21 @@ func mergeBlocks(closeCh <-chan struct{}, bw *blockWriter, br *blockReader) (*pa
 		}
 		b := br.block
 
-		if pendingBlockIsEmpty {
+		// the block data is always read to keep the readers in sequence,
+		// then the elements in the deleted time ranges are dropped.
+		loaded := false
+		if ingestedAt := br.ingestedAt(); rt.Overlaps(b.bm.timestamps.min, b.bm.timestamps.max, ingestedAt) {
 			br.loadBlockData(getDecoder())
+			loaded = true
+			if !b.dropDeleted(rt, ingestedAt) {
+				continue
+			}
+		}
+
+		if pendingBlockIsEmpty {
+			if !loaded {
+				br.loadBlockData(getDecoder())
+			}
 			pendingBlock.copyFrom(b)
 			pendingBlockIsEmpty = false
 			continue
@@ -307,9 +351,12 @@ func mergeBlocks(closeCh <-chan struct{}, bw *blockWriter, br *blockReader) (*pa
 		if pendingBlock.bm.seriesID != b.bm.seriesID ||
 			(pendingBlock.isFull() && pendingBlock.bm.timestamps.max <= b.bm.timestamps.min) {
 			bw.mustWriteBlock(pendingBlock.bm.seriesID, &pendingBlock.block)
-			releaseDecoder()
 			pendingBlock.reset()
-			br.loadBlockData(getDecoder())
+			if !loaded {
+				// the decoder is kept if it holds the data of the loaded block
+				releaseDecoder()
+				br.loadBlockData(getDecoder())
+			}
 			pendingBlock.copyFrom(b)
 			continue
 		}
@@ -320,7 +367,9 @@ func mergeBlocks(closeCh <-chan struct{}, bw *blockWriter, br *blockReader) (*pa
 		}
 		tmpBlock.reset()
 		tmpBlock.bm.seriesID = b.bm.seriesID
-		br.loadBlockData(getDecoder())
+		if !loaded {
+			br.loadBlockData(getDecoder())
+		}
 		mergeTwoBlocks(tmpBlock, pendingBlock, b)
 		if tmpBlock.uncompressedSizeBytes() <= maxUncompressedBlockSize {
 			if len(tmpBlock.timestamps) == 0 {
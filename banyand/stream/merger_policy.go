@@ -19,38 +19,55 @@ package stream
 
 import (
 	"math"
+	"runtime"
 	"sort"
+	"time"
 
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
 	"github.com/apache/skywalking-banyandb/pkg/run"
 )
 
-// MergePolicy aims to choose an optimal combination
+// mergePolicy chooses the parts to be merged together.
+type mergePolicy interface {
+	getPartsToMerge(dst, src []*partWrapper, freeDiskSize uint64) []*partWrapper
+	getOutdatedParts(dst, src []*partWrapper, freeDiskSize uint64) []*partWrapper
+}
+
+// sizeTieredPolicy aims to choose an optimal combination
 // that has the lowest write amplification.
-type mergePolicy struct {
+type sizeTieredPolicy struct {
 	maxParts           int
 	minMergeMultiplier float64
 	maxFanOutSize      run.Bytes
 }
 
+const defaultMaxParts = 15
+
 // NewDefaultMergePolicy create a MergePolicy with default parameters.
-func newDefaultMergePolicy() *mergePolicy {
-	return newMergePolicy(15, 1.7, run.Bytes(math.MaxInt64))
+func newDefaultMergePolicy() *sizeTieredPolicy {
+	return newMergePolicy(defaultMergeFanIn(), 1.7, run.Bytes(math.MaxInt64))
+}
+
+// defaultMergeFanIn grows the number of the parts consumed by a merge with the CPUs up to twice the default,
+// which saves the merge rounds on the large machines.
+func defaultMergeFanIn() int {
+	return min(max(defaultMaxParts, runtime.GOMAXPROCS(0)), 2*defaultMaxParts)
 }
 
-func newDefaultMergePolicyForTesting() *mergePolicy {
+func newDefaultMergePolicyForTesting() *sizeTieredPolicy {
 	return newMergePolicy(3, 1, run.Bytes(math.MaxInt64))
 }
 
 // NewMergePolicy creates a MergePolicy with given parameters.
-func newMergePolicy(maxParts int, minMergeMul float64, maxFanOutSize run.Bytes) *mergePolicy {
-	return &mergePolicy{
+func newMergePolicy(maxParts int, minMergeMul float64, maxFanOutSize run.Bytes) *sizeTieredPolicy {
+	return &sizeTieredPolicy{
 		maxParts:           maxParts,
 		minMergeMultiplier: minMergeMul,
 		maxFanOutSize:      maxFanOutSize,
 	}
 }
 
-func (l *mergePolicy) getPartsToMerge(dst, src []*partWrapper, freeDiskSize uint64) []*partWrapper {
+func (l *sizeTieredPolicy) getPartsToMerge(dst, src []*partWrapper, freeDiskSize uint64) []*partWrapper {
 	if len(src) < 2 {
 		return dst
 	}
@@ -116,6 +133,98 @@ func (l *mergePolicy) getPartsToMerge(dst, src []*partWrapper, freeDiskSize uint
 	return append(dst, pws...)
 }
 
+// getOutdatedParts chooses the parts written in older formats to be rewritten in the current format.
+// The smaller parts are rewritten first, and they are merged together as many as possible.
+func (l *sizeTieredPolicy) getOutdatedParts(dst, src []*partWrapper, freeDiskSize uint64) []*partWrapper {
+	var outdated []*partWrapper
+	for _, pw := range src {
+		if pw.p.partMetadata.outdated() {
+			outdated = append(outdated, pw)
+		}
+	}
+	sortPartsForOptimalMerge(outdated)
+	maxFanOut := min(freeDiskSize, uint64(l.maxFanOutSize))
+	var outSize uint64
+	for i, pw := range outdated {
+		outSize += pw.p.partMetadata.CompressedSizeBytes
+		if i >= l.maxParts || outSize > maxFanOut {
+			break
+		}
+		dst = append(dst, pw)
+	}
+	return dst
+}
+
+// timeWindowPolicy merges the parts in the same time window by the base policy,
+// and never merges the parts across the windows, so that the parts of a window are dropped together by the retention.
+// A part spanning several windows is only merged with the parts spanning the same windows.
+type timeWindowPolicy struct {
+	base   mergePolicy
+	window int64
+}
+
+// newGroupMergePolicy returns the merge policy selected by the compaction of a group.
+func newGroupMergePolicy(compaction *commonv1.Compaction, base mergePolicy) mergePolicy {
+	if compaction.GetStrategy() != commonv1.Compaction_STRATEGY_TIME_WINDOW {
+		return base
+	}
+	window := time.Hour
+	if ir := compaction.GetWindow(); ir != nil && ir.GetNum() > 0 {
+		window = time.Duration(ir.GetNum()) * time.Hour
+		if ir.GetUnit() == commonv1.IntervalRule_UNIT_DAY {
+			window *= 24
+		}
+	}
+	return &timeWindowPolicy{base: base, window: window.Nanoseconds()}
+}
+
+func (p *timeWindowPolicy) getPartsToMerge(dst, src []*partWrapper, freeDiskSize uint64) []*partWrapper {
+	for _, parts := range p.split(src) {
+		n := len(dst)
+		if dst = p.base.getPartsToMerge(dst, parts, freeDiskSize); len(dst) > n {
+			return dst
+		}
+	}
+	return dst
+}
+
+func (p *timeWindowPolicy) getOutdatedParts(dst, src []*partWrapper, freeDiskSize uint64) []*partWrapper {
+	for _, parts := range p.split(src) {
+		n := len(dst)
+		if dst = p.base.getOutdatedParts(dst, parts, freeDiskSize); len(dst) > n {
+			return dst
+		}
+	}
+	return dst
+}
+
+// split groups the parts by the windows they span, and the oldest windows come first.
+func (p *timeWindowPolicy) split(src []*partWrapper) [][]*partWrapper {
+	type span struct {
+		first, last int64
+	}
+	windows := make(map[span][]*partWrapper)
+	var spans []span
+	for _, pw := range src {
+		s := span{first: pw.p.partMetadata.MinTimestamp / p.window, last: pw.p.partMetadata.MaxTimestamp / p.window}
+		if _, ok := windows[s]; !ok {
+			spans = append(spans, s)
+		}
+		windows[s] = append(windows[s], pw)
+	}
+	sort.Slice(spans, func(i, j int) bool {
+		if spans[i].first == spans[j].first {
+			return spans[i].last < spans[j].last
+		}
+		return spans[i].first < spans[j].first
+	})
+	result := make([][]*partWrapper, 0, len(spans))
+	for _, s := range spans {
+		result = append(result, windows[s])
+	}
+	return result
+}
+
 func sortPartsForOptimalMerge(pws []*partWrapper) {
 	// Sort src parts by size and backwards timestamp.
 	// This should improve adjacent points' locality in the merged parts.
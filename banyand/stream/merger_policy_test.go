@@ -0,0 +1,72 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	"github.com/apache/skywalking-banyandb/pkg/run"
+)
+
+func TestTimeWindowPolicy(t *testing.T) {
+	base := newMergePolicy(3, 1, run.Bytes(math.MaxInt64))
+	assert.Equal(t, mergePolicy(base), newGroupMergePolicy(nil, base))
+	assert.Equal(t, mergePolicy(base), newGroupMergePolicy(&commonv1.Compaction{Strategy: commonv1.Compaction_STRATEGY_SIZE_TIERED}, base))
+	policy := newGroupMergePolicy(&commonv1.Compaction{Strategy: commonv1.Compaction_STRATEGY_TIME_WINDOW}, base)
+
+	hour := time.Hour.Nanoseconds()
+	newPart := func(id uint64, minTimestamp, maxTimestamp int64) *partWrapper {
+		return newPartWrapper(nil, &part{partMetadata: partMetadata{
+			ID: id, CompressedSizeBytes: 100, TotalCount: 10,
+			MinTimestamp: minTimestamp, MaxTimestamp: maxTimestamp, FormatVersion: currentPartFormatVersion,
+		}})
+	}
+	ids := func(pws []*partWrapper) []uint64 {
+		var result []uint64
+		for _, pw := range pws {
+			result = append(result, pw.ID())
+		}
+		return result
+	}
+
+	// the parts in the first window are merged, and the ones spanning the windows are left alone.
+	parts := []*partWrapper{
+		newPart(1, 2*hour+1, 2*hour+10),
+		newPart(2, hour+1, hour+10),
+		newPart(3, hour+20, hour+30),
+		newPart(4, hour+40, 2*hour+10),
+		newPart(5, hour+50, hour+60),
+	}
+	assert.ElementsMatch(t, []uint64{2, 3, 5}, ids(policy.getPartsToMerge(nil, parts, math.MaxUint64)))
+
+	// every window has a single part, while the size-tiered policy merges them.
+	parts = []*partWrapper{parts[0], parts[1], parts[3]}
+	assert.Empty(t, policy.getPartsToMerge(nil, parts, math.MaxUint64))
+	assert.Len(t, base.getPartsToMerge(nil, parts, math.MaxUint64), 3)
+
+	daily := newGroupMergePolicy(&commonv1.Compaction{
+		Strategy: commonv1.Compaction_STRATEGY_TIME_WINDOW,
+		Window:   &commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_DAY, Num: 1},
+	}, base)
+	assert.Len(t, daily.getPartsToMerge(nil, parts, math.MaxUint64), 3)
+}
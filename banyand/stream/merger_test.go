@@ -19,6 +19,8 @@ package stream
 
 import (
 	"errors"
+	"fmt"
+	"math"
 	"reflect"
 	"testing"
 
@@ -26,9 +28,13 @@ import (
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/stretchr/testify/require"
 
+	"github.com/apache/skywalking-banyandb/api/common"
 	"github.com/apache/skywalking-banyandb/banyand/protector"
+	"github.com/apache/skywalking-banyandb/pkg/convert"
+	"github.com/apache/skywalking-banyandb/pkg/encoding"
 	"github.com/apache/skywalking-banyandb/pkg/fs"
 	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+	"github.com/apache/skywalking-banyandb/pkg/run"
 	"github.com/apache/skywalking-banyandb/pkg/test"
 )
 
@@ -293,7 +299,7 @@ func Test_mergeParts(t *testing.T) {
 				closeCh := make(chan struct{})
 				defer close(closeCh)
 				tst := &tsTable{pm: protector.Nop{}}
-				p, err := tst.mergeParts(fileSystem, closeCh, pp, partID, root)
+				p, err := tst.mergeParts(fileSystem, closeCh, pp, partID, root, encoding.DefaultCompressionLevel, 0)
 				if tt.wantErr != nil {
 					if !errors.Is(err, tt.wantErr) {
 						t.Fatalf("Unexpected error: got %v, want %v", err, tt.wantErr)
@@ -332,7 +338,7 @@ func Test_mergeParts(t *testing.T) {
 				}()
 				for _, es := range tt.esList {
 					mp := generateMemPart()
-					mp.mustInitFromElements(es)
+					mp.mustInitFromElements(es, encoding.DefaultCompressionLevel)
 					pp = append(pp, newPartWrapper(mp, openMemPart(mp)))
 				}
 				verify(t, pp, fs.NewLocalFileSystem(), tmpPath, 1)
@@ -350,7 +356,7 @@ func Test_mergeParts(t *testing.T) {
 				fileSystem := fs.NewLocalFileSystem()
 				for i, es := range tt.esList {
 					mp := generateMemPart()
-					mp.mustInitFromElements(es)
+					mp.mustInitFromElements(es, encoding.DefaultCompressionLevel)
 					mp.mustFlush(fileSystem, partPath(tmpPath, uint64(i)))
 					filePW := newPartWrapper(nil, mustOpenFilePart(uint64(i), tmpPath, fileSystem))
 					filePW.p.partMetadata.ID = uint64(i)
@@ -359,6 +365,175 @@ func Test_mergeParts(t *testing.T) {
 				}
 				verify(t, fpp, fileSystem, tmpPath, uint64(len(tt.esList)))
 			})
+
+			t.Run("packed file parts", func(t *testing.T) {
+				var fpp []*partWrapper
+				tmpPath, defFn := test.Space(require.New(t))
+				defer func() {
+					for _, pw := range fpp {
+						pw.decRef()
+					}
+					defFn()
+				}()
+				fileSystem := fs.NewLocalFileSystem()
+				for i, es := range tt.esList {
+					mp := generateMemPart()
+					mp.mustInitFromElements(es, encoding.DefaultCompressionLevel)
+					mp.mustFlushPacked(fileSystem, partPath(tmpPath, uint64(i)))
+					require.NoError(t, validatePart(fileSystem, partPath(tmpPath, uint64(i))))
+					filePW := newPartWrapper(nil, mustOpenFilePart(uint64(i), tmpPath, fileSystem))
+					filePW.p.partMetadata.ID = uint64(i)
+					fpp = append(fpp, filePW)
+					releaseMemPart(mp)
+				}
+				verify(t, fpp, fileSystem, tmpPath, uint64(len(tt.esList)))
+			})
 		})
 	}
 }
+
+func Test_upgradeOutdatedParts(t *testing.T) {
+	tmpPath, defFn := test.Space(require.New(t))
+	defer defFn()
+	fileSystem := fs.NewLocalFileSystem()
+	var pp []*partWrapper
+	defer func() {
+		for _, pw := range pp {
+			pw.decRef()
+		}
+	}()
+	for i, es := range []*elements{esTS1, esTS2} {
+		mp := generateMemPart()
+		mp.mustInitFromElements(es, encoding.DefaultCompressionLevel)
+		path := partPath(tmpPath, uint64(i))
+		mp.mustFlush(fileSystem, path)
+		releaseMemPart(mp)
+		if i == 0 {
+			// the parts written before the format version is introduced have no formatVersion.
+			var pm partMetadata
+			pm.mustReadMetadata(fileSystem, path)
+			pm.FormatVersion = 0
+			pm.mustWriteMetadata(fileSystem, path)
+		}
+		pw := newPartWrapper(nil, mustOpenFilePart(uint64(i), tmpPath, fileSystem))
+		pw.p.partMetadata.ID = uint64(i)
+		pp = append(pp, pw)
+	}
+	require.True(t, pp[0].p.partMetadata.outdated())
+	require.False(t, pp[1].p.partMetadata.outdated())
+
+	policy := newMergePolicy(3, 10, run.Bytes(math.MaxInt64))
+	require.Empty(t, policy.getPartsToMerge(nil, pp, math.MaxUint64))
+	outdated := policy.getOutdatedParts(nil, pp, math.MaxUint64)
+	require.Len(t, outdated, 1)
+	require.Equal(t, uint64(0), outdated[0].ID())
+	require.Empty(t, policy.getOutdatedParts(nil, pp, 1), "the rewrite needs enough disk space")
+
+	closeCh := make(chan struct{})
+	defer close(closeCh)
+	tst := &tsTable{pm: protector.Nop{}}
+	p, err := tst.mergeParts(fileSystem, closeCh, outdated, 2, tmpPath, encoding.DefaultCompressionLevel, 0)
+	require.NoError(t, err)
+	defer p.decRef()
+	require.Equal(t, currentPartFormatVersion, p.p.partMetadata.FormatVersion)
+	require.Equal(t, pp[0].p.partMetadata.TotalCount, p.p.partMetadata.TotalCount)
+
+	// the parts written by a newer release are refused.
+	var pm partMetadata
+	pm.mustReadMetadata(fileSystem, partPath(tmpPath, 1))
+	pm.FormatVersion = currentPartFormatVersion + 1
+	pm.mustWriteMetadata(fileSystem, partPath(tmpPath, 1))
+	require.Error(t, validatePart(fileSystem, partPath(tmpPath, 1)))
+}
+
+func generateStatementEs(partIndex int) *elements {
+	templates := []string{
+		"SELECT id, name, email, created_at FROM users WHERE tenant_id = %d AND status = 'active' ORDER BY created_at DESC",
+		"UPDATE orders SET status = 'shipped', updated_at = now() WHERE order_id = %d AND warehouse = 'east'",
+		"INSERT INTO audit_log (actor, action, target, payload) VALUES ('service-account', 'write', 'inventory', '%d')",
+	}
+	es := &elements{}
+	for sid := 1; sid <= 200; sid++ {
+		for i := 0; i < 5; i++ {
+			n := partIndex*10000 + sid*10 + i
+			es.seriesIDs = append(es.seriesIDs, common.SeriesID(sid))
+			es.timestamps = append(es.timestamps, int64(n))
+			es.elementIDs = append(es.elementIDs, uint64(n))
+			es.tagFamilies = append(es.tagFamilies, []tagValues{
+				{
+					tag: "db", values: []*tagValue{
+						{tag: "statement", valueType: pbv1.ValueTypeStr, value: []byte(fmt.Sprintf(templates[n%len(templates)], n*7919))},
+						{tag: "rows", valueType: pbv1.ValueTypeInt64, value: convert.Int64ToBytes(int64(n))},
+					},
+				},
+			})
+		}
+	}
+	return es
+}
+
+func readPartTagValues(t *testing.T, p *part) []string {
+	pmi := &partMergeIter{}
+	pmi.mustInitFromPart(p)
+	br := &blockReader{}
+	br.init([]*partMergeIter{pmi})
+	decoder := &encoding.BytesBlockDecoder{}
+	var values []string
+	for br.nextBlockMetadata() {
+		decoder.Reset()
+		br.loadBlockData(decoder)
+		for _, tf := range br.block.tagFamilies {
+			for _, tg := range tf.tags {
+				for _, v := range tg.values {
+					values = append(values, string(v))
+				}
+			}
+		}
+	}
+	require.NoError(t, br.error())
+	return values
+}
+
+func Test_mergePartsWithTagFamilyDicts(t *testing.T) {
+	tmpPath, defFn := test.Space(require.New(t))
+	defer defFn()
+	fileSystem := fs.NewLocalFileSystem()
+	for i := 0; i < 2; i++ {
+		mp := generateMemPart()
+		mp.mustInitFromElements(generateStatementEs(i), encoding.DefaultCompressionLevel)
+		mp.mustFlush(fileSystem, partPath(tmpPath, uint64(i)))
+		releaseMemPart(mp)
+	}
+	// the sequential readers of a part can't be rewound, so every merge opens the parts again.
+	openParts := func() []*partWrapper {
+		var pp []*partWrapper
+		for i := 0; i < 2; i++ {
+			pw := newPartWrapper(nil, mustOpenFilePart(uint64(i), tmpPath, fileSystem))
+			t.Cleanup(pw.decRef)
+			pp = append(pp, pw)
+		}
+		return pp
+	}
+
+	closeCh := make(chan struct{})
+	defer close(closeCh)
+	tst := &tsTable{pm: protector.Nop{}}
+	plain, err := tst.mergeParts(fileSystem, closeCh, openParts(), 2, tmpPath, encoding.DefaultCompressionLevel, 0)
+	require.NoError(t, err)
+	defer plain.decRef()
+	compressed, err := tst.mergeParts(fileSystem, closeCh, openParts(), 3, tmpPath, encoding.DefaultCompressionLevel, 16<<10)
+	require.NoError(t, err)
+	defer compressed.decRef()
+
+	require.Empty(t, plain.p.tagFamilyDicts)
+	require.Contains(t, compressed.p.tagFamilyDicts, "db")
+	require.Less(t, compressed.p.partMetadata.CompressedSizeBytes, plain.p.partMetadata.CompressedSizeBytes)
+	want := readPartTagValues(t, plain.p)
+	require.Len(t, want, 2*2*200*5)
+	require.Equal(t, want, readPartTagValues(t, compressed.p))
+
+	// the dictionaries are loaded again once the part is reopened.
+	reopened := newPartWrapper(nil, mustOpenFilePart(3, tmpPath, fileSystem))
+	defer reopened.decRef()
+	require.Equal(t, want, readPartTagValues(t, reopened.p))
+}
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"io"
 	"path"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -37,6 +38,7 @@ import (
 	"github.com/apache/skywalking-banyandb/banyand/protector"
 	"github.com/apache/skywalking-banyandb/banyand/queue"
 	"github.com/apache/skywalking-banyandb/banyand/queue/pub"
+	"github.com/apache/skywalking-banyandb/pkg/fs"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
 	"github.com/apache/skywalking-banyandb/pkg/meter"
 	resourceSchema "github.com/apache/skywalking-banyandb/pkg/schema"
@@ -52,16 +54,18 @@ type SchemaService interface {
 }
 type schemaRepo struct {
 	resourceSchema.Repository
-	l        *logger.Logger
-	metadata metadata.Repo
-	path     string
+	l               *logger.Logger
+	metadata        metadata.Repo
+	rangeTombstones *sync.Map
+	path            string
 }
 
 func newSchemaRepo(path string, svc *service, nodeLabels map[string]string) schemaRepo {
 	sr := schemaRepo{
-		l:        svc.l,
-		path:     path,
-		metadata: svc.metadata,
+		l:               svc.l,
+		path:            path,
+		metadata:        svc.metadata,
+		rangeTombstones: &svc.rangeTombstones,
 		Repository: resourceSchema.NewRepository(
 			svc.metadata,
 			svc.l,
@@ -116,6 +120,18 @@ func (sr *schemaRepo) GetRemovalSegmentsTimeRange(group string) *timestamp.TimeR
 	return db.(storage.TSDB[*tsTable, option]).GetExpiredSegmentsTimeRange()
 }
 
+func (sr *schemaRepo) GetSegmentsTimeRanges(group string) []timestamp.TimeRange {
+	g, ok := sr.LoadGroup(group)
+	if !ok {
+		return nil
+	}
+	db := g.SupplyTSDB()
+	if db == nil {
+		return nil
+	}
+	return db.(storage.TSDB[*tsTable, option]).GetSegmentsTimeRanges()
+}
+
 func (sr *schemaRepo) OnInit(kinds []schema.Kind) (bool, []int64) {
 	if len(kinds) != 4 {
 		logger.Panicf("invalid kinds: %v", kinds)
@@ -245,15 +261,21 @@ func (sr *schemaRepo) loadTSDB(groupName string) (storage.TSDB[*tsTable, option]
 var _ resourceSchema.ResourceSupplier = (*supplier)(nil)
 
 type supplier struct {
-	metadata   metadata.Repo
-	pipeline   queue.Queue
-	omr        observability.MetricsRegistry
-	l          *logger.Logger
-	pm         protector.Memory
-	schemaRepo *schemaRepo
-	nodeLabels map[string]string
-	path       string
-	option     option
+	metadata      metadata.Repo
+	pipeline      queue.Queue
+	omr           observability.MetricsRegistry
+	l             *logger.Logger
+	pm            protector.Memory
+	fdp           protector.FD
+	iop           protector.IO
+	lfs           fs.FileSystem
+	replayCache   *storage.ReplayCache
+	schemaRepo    *schemaRepo
+	tombstones    *sync.Map
+	nodeLabels    map[string]string
+	path          string
+	option        option
+	segmentWarmup bool
 }
 
 func newSupplier(path string, svc *service, nodeLabels map[string]string) *supplier {
@@ -268,15 +290,21 @@ func newSupplier(path string, svc *service, nodeLabels map[string]string) *suppl
 	}
 
 	return &supplier{
-		metadata:   svc.metadata,
-		l:          svc.l,
-		pipeline:   svc.localPipeline,
-		option:     opt,
-		omr:        svc.omr,
-		pm:         svc.pm,
-		path:       path,
-		schemaRepo: &svc.schemaRepo,
-		nodeLabels: nodeLabels,
+		metadata:      svc.metadata,
+		l:             svc.l,
+		pipeline:      svc.localPipeline,
+		option:        opt,
+		omr:           svc.omr,
+		pm:            svc.pm,
+		fdp:           svc.fdp,
+		iop:           svc.iop,
+		lfs:           svc.lfs,
+		replayCache:   svc.replayCache,
+		path:          path,
+		schemaRepo:    &svc.schemaRepo,
+		tombstones:    &svc.rangeTombstones,
+		nodeLabels:    nodeLabels,
+		segmentWarmup: svc.segmentWarmup,
 	}
 }
 
@@ -331,19 +359,33 @@ func (s *supplier) OpenDB(groupSchema *commonv1.Group) (resourceSchema.DB, error
 		}
 	}
 	group := groupSchema.Metadata.Name
+	opt := s.option
+	if ro.Archived && opt.compressionPolicy != nil {
+		opt.compressionPolicy = opt.compressionPolicy.archived()
+	}
+	opt.mergePolicy = newGroupMergePolicy(ro.GetCompaction(), opt.mergePolicy)
+	location := path.Join(s.path, group)
+	var err error
+	if opt.rangeTombstones, err = openRangeTombstones(s.tombstones, s.lfs, group, location); err != nil {
+		return nil, err
+	}
 	opts := storage.TSDBOpts[*tsTable, option]{
 		ShardNum:                       shardNum,
-		Location:                       path.Join(s.path, group),
+		Location:                       location,
 		TSTableCreator:                 newTSTable,
 		TableMetrics:                   s.newMetrics(p),
 		SegmentInterval:                storage.MustToIntervalRule(segInterval),
 		TTL:                            storage.MustToIntervalRule(ttl),
-		Option:                         s.option,
+		Option:                         opt,
 		SeriesIndexFlushTimeoutSeconds: s.option.flushTimeout.Nanoseconds() / int64(time.Second),
 		SeriesIndexCacheMaxBytes:       int(s.option.seriesCacheMaxSize),
 		StorageMetricsFactory:          s.omr.With(storageScope.ConstLabels(meter.ToLabelPairs(common.DBLabelNames(), p.DBLabelValues()))),
 		SegmentIdleTimeout:             segmentIdleTimeout,
+		SegmentWarmup:                  s.segmentWarmup,
 		MemoryLimit:                    s.pm.GetLimit(),
+		FDProtector:                    s.fdp,
+		IOScheduler:                    s.iop,
+		ReplayCache:                    s.replayCache,
 	}
 	return storage.OpenTSDB(
 		common.SetPosition(context.Background(), func(_ common.Position) common.Position {
@@ -60,6 +60,8 @@ type metrics struct {
 	totalMergedParts  meter.Counter
 	totalMergeLatency meter.Counter
 	totalMerged       meter.Counter
+
+	totalIndexTermExceeded meter.Counter
 }
 
 func (tst *tsTable) incTotalWritten(delta int) {
@@ -209,6 +211,13 @@ func (tst *tsTable) incTotalMerged(delta int, typ string) {
 	tst.metrics.totalMerged.Inc(float64(delta), typ)
 }
 
+func (tst *tsTable) incTotalIndexTermExceeded(delta int, policy string) {
+	if tst == nil || tst.metrics == nil {
+		return
+	}
+	tst.metrics.totalIndexTermExceeded.Inc(float64(delta), policy)
+}
+
 func (m *metrics) DeleteAll() {
 	if m == nil {
 		return
@@ -245,6 +254,9 @@ func (m *metrics) DeleteAll() {
 	m.totalMergedParts.Delete("file")
 	m.totalMergeLatency.Delete("file")
 	m.totalMerged.Delete("file")
+
+	m.totalIndexTermExceeded.Delete(indexTermPolicyTruncate)
+	m.totalIndexTermExceeded.Delete(indexTermPolicyReject)
 }
 
 func (s *supplier) newMetrics(p common.Position) storage.Metrics {
@@ -271,6 +283,7 @@ func (s *supplier) newMetrics(p common.Position) storage.Metrics {
 		totalMergedParts:           factory.NewCounter("total_merged_parts", "type"),
 		totalMergeLatency:          factory.NewCounter("total_merge_latency", "type"),
 		totalMerged:                factory.NewCounter("total_merged", "type"),
+		totalIndexTermExceeded:     factory.NewCounter("total_index_term_exceeded", "policy"),
 		tbMetrics: tbMetrics{
 			totalMemParts:                  factory.NewGauge("total_mem_part", common.ShardLabelNames()...),
 			totalMemElements:               factory.NewGauge("total_mem_elements", common.ShardLabelNames()...),
@@ -282,6 +295,7 @@ func (s *supplier) newMetrics(p common.Position) storage.Metrics {
 			totalFileBlocks:                factory.NewGauge("total_file_blocks", common.ShardLabelNames()...),
 			totalFilePartBytes:             factory.NewGauge("total_file_part_bytes", common.ShardLabelNames()...),
 			totalFilePartUncompressedBytes: factory.NewGauge("total_file_part_uncompressed_bytes", common.ShardLabelNames()...),
+			compressionLevel:               factory.NewGauge("compression_level", common.ShardLabelNames()...),
 		},
 		indexMetrics: inverted.NewMetrics(factory, common.SegLabelNames()...),
 	}
@@ -326,6 +340,7 @@ func (tst *tsTable) Collect(m storage.Metrics) {
 	metrics.totalFileBlocks.Set(float64(totalFileBlocks), tst.p.ShardLabelValues()...)
 	metrics.totalFilePartBytes.Set(float64(totalFilePartBytes), tst.p.ShardLabelValues()...)
 	metrics.totalFilePartUncompressedBytes.Set(float64(totalFilePartUncompressedBytes), tst.p.ShardLabelValues()...)
+	metrics.compressionLevel.Set(float64(tst.ingestCompressionLevel()), tst.p.ShardLabelValues()...)
 	tst.index.collectMetrics(tst.p.SegLabelValues()...)
 }
 
@@ -343,6 +358,7 @@ func (tst *tsTable) deleteMetrics() {
 	tst.metrics.tbMetrics.totalFileBlocks.Delete(tst.p.ShardLabelValues()...)
 	tst.metrics.tbMetrics.totalFilePartBytes.Delete(tst.p.ShardLabelValues()...)
 	tst.metrics.tbMetrics.totalFilePartUncompressedBytes.Delete(tst.p.ShardLabelValues()...)
+	tst.metrics.tbMetrics.compressionLevel.Delete(tst.p.ShardLabelValues()...)
 	tst.metrics.indexMetrics.DeleteAll(tst.p.SegLabelValues()...)
 }
 
@@ -358,4 +374,6 @@ type tbMetrics struct {
 	totalFileBlocks                meter.Gauge
 	totalFilePartBytes             meter.Gauge
 	totalFilePartUncompressedBytes meter.Gauge
+
+	compressionLevel meter.Gauge
 }
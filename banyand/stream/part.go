@@ -21,12 +21,15 @@ import (
 	"fmt"
 	"path"
 	"path/filepath"
+	"slices"
 	"sort"
 	"sync/atomic"
+	"time"
 
 	"github.com/apache/skywalking-banyandb/api/common"
 	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
 	"github.com/apache/skywalking-banyandb/pkg/bytes"
+	"github.com/apache/skywalking-banyandb/pkg/compress/zstd"
 	"github.com/apache/skywalking-banyandb/pkg/fs"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
 	"github.com/apache/skywalking-banyandb/pkg/pool"
@@ -41,15 +44,18 @@ const (
 	tagFamiliesMetadataFilenameExt = ".tfm"
 	tagFamiliesFilenameExt         = ".tf"
 	tagFamiliesFilterFilenameExt   = ".tff"
+	tagFamiliesDictFilenameExt     = ".tfd"
 )
 
 type part struct {
 	primary              fs.Reader
 	timestamps           fs.Reader
 	fileSystem           fs.FileSystem
+	pack                 *storage.Pack
 	tagFamilyMetadata    map[string]fs.Reader
 	tagFamilies          map[string]fs.Reader
 	tagFamilyFilter      map[string]fs.Reader
+	tagFamilyDicts       map[string]*zstd.Dict
 	path                 string
 	primaryBlockMetadata []primaryBlockMetadata
 	partMetadata         partMetadata
@@ -67,6 +73,12 @@ func (p *part) close() {
 	for _, tff := range p.tagFamilyFilter {
 		fs.MustClose(tff)
 	}
+	for _, d := range p.tagFamilyDicts {
+		d.Release()
+	}
+	if p.pack != nil {
+		fs.MustClose(p.pack)
+	}
 }
 
 func (p *part) String() string {
@@ -148,7 +160,7 @@ func (mp *memPart) reset() {
 	}
 }
 
-func (mp *memPart) mustInitFromElements(es *elements) {
+func (mp *memPart) mustInitFromElements(es *elements, compressionLevel int) {
 	mp.reset()
 
 	if len(es.timestamps) == 0 {
@@ -158,7 +170,7 @@ func (mp *memPart) mustInitFromElements(es *elements) {
 	sort.Sort(es)
 
 	bsw := generateBlockWriter()
-	bsw.MustInitForMemPart(mp)
+	bsw.MustInitForMemPart(mp, compressionLevel)
 	var sidPrev common.SeriesID
 	uncompressedBlockSizeBytes := uint64(0)
 	var indexPrev int
@@ -178,6 +190,7 @@ func (mp *memPart) mustInitFromElements(es *elements) {
 	}
 	bsw.MustWriteElements(sidPrev, es.timestamps[indexPrev:], es.elementIDs[indexPrev:], es.tagFamilies[indexPrev:])
 	bsw.Flush(&mp.partMetadata)
+	mp.partMetadata.IngestedAt = time.Now().UnixNano()
 	releaseBlockWriter(bsw)
 }
 
@@ -202,6 +215,31 @@ func (mp *memPart) mustFlush(fileSystem fs.FileSystem, path string) {
 	fileSystem.SyncPath(path)
 }
 
+// mustFlushPacked writes the files of the part into a container file, except the metadata.
+func (mp *memPart) mustFlushPacked(fileSystem fs.FileSystem, path string) {
+	fileSystem.MkdirPanicIfExist(path, storage.DirPerm)
+
+	entries := []storage.PackEntry{
+		{Name: metaFilename, Data: mp.meta.Buf},
+		{Name: primaryFilename, Data: mp.primary.Buf},
+		{Name: timestampsFilename, Data: mp.timestamps.Buf},
+	}
+	for name, tf := range mp.tagFamilies {
+		entries = append(entries, storage.PackEntry{Name: name + tagFamiliesFilenameExt, Data: tf.Buf})
+	}
+	for name, tfh := range mp.tagFamilyMetadata {
+		entries = append(entries, storage.PackEntry{Name: name + tagFamiliesMetadataFilenameExt, Data: tfh.Buf})
+	}
+	for name, tff := range mp.tagFamilyFilter {
+		entries = append(entries, storage.PackEntry{Name: name + tagFamiliesFilterFilenameExt, Data: tff.Buf})
+	}
+	storage.MustWritePack(fileSystem, path, entries)
+
+	mp.partMetadata.mustWriteMetadata(fileSystem, path)
+
+	fileSystem.SyncPath(path)
+}
+
 func uncompressedElementSizeBytes(index int, es *elements) uint64 {
 	// 8 bytes for timestamp
 	// 8 bytes for elementID
@@ -283,35 +321,55 @@ func mustOpenFilePart(id uint64, root string, fileSystem fs.FileSystem) *part {
 	p.partMetadata.mustReadMetadata(fileSystem, partPath)
 	p.partMetadata.ID = id
 
-	metaPath := path.Join(partPath, metaFilename)
-	pr := mustOpenReader(metaPath, fileSystem)
+	var names []string
+	for _, e := range fileSystem.ReadDir(partPath) {
+		if !e.IsDir() {
+			names = append(names, e.Name())
+		}
+	}
+	open := func(name string) fs.Reader {
+		return mustOpenReader(path.Join(partPath, name), fileSystem)
+	}
+	read := func(name string) ([]byte, error) {
+		return fileSystem.Read(path.Join(partPath, name))
+	}
+	if slices.Contains(names, storage.PackFilename) {
+		p.pack = mustOpenPack(fileSystem, partPath)
+		names = p.pack.Names()
+		open = p.pack.MustReader
+		read = p.pack.Read
+	}
+
+	pr := open(metaFilename)
 	p.primaryBlockMetadata = mustReadPrimaryBlockMetadata(p.primaryBlockMetadata[:0], pr)
 	fs.MustClose(pr)
 
-	p.primary = mustOpenReader(path.Join(partPath, primaryFilename), fileSystem)
-	p.timestamps = mustOpenReader(path.Join(partPath, timestampsFilename), fileSystem)
-	ee := fileSystem.ReadDir(partPath)
-	for _, e := range ee {
-		if e.IsDir() {
-			continue
-		}
-		if filepath.Ext(e.Name()) == tagFamiliesMetadataFilenameExt {
+	p.primary = open(primaryFilename)
+	p.timestamps = open(timestampsFilename)
+	for _, name := range names {
+		if filepath.Ext(name) == tagFamiliesMetadataFilenameExt {
 			if p.tagFamilyMetadata == nil {
 				p.tagFamilyMetadata = make(map[string]fs.Reader)
 			}
-			p.tagFamilyMetadata[removeExt(e.Name(), tagFamiliesMetadataFilenameExt)] = mustOpenReader(path.Join(partPath, e.Name()), fileSystem)
+			p.tagFamilyMetadata[removeExt(name, tagFamiliesMetadataFilenameExt)] = open(name)
 		}
-		if filepath.Ext(e.Name()) == tagFamiliesFilenameExt {
+		if filepath.Ext(name) == tagFamiliesFilenameExt {
 			if p.tagFamilies == nil {
 				p.tagFamilies = make(map[string]fs.Reader)
 			}
-			p.tagFamilies[removeExt(e.Name(), tagFamiliesFilenameExt)] = mustOpenReader(path.Join(partPath, e.Name()), fileSystem)
+			p.tagFamilies[removeExt(name, tagFamiliesFilenameExt)] = open(name)
 		}
-		if filepath.Ext(e.Name()) == tagFamiliesFilterFilenameExt {
+		if filepath.Ext(name) == tagFamiliesFilterFilenameExt {
 			if p.tagFamilyFilter == nil {
 				p.tagFamilyFilter = make(map[string]fs.Reader)
 			}
-			p.tagFamilyFilter[removeExt(e.Name(), tagFamiliesFilterFilenameExt)] = mustOpenReader(path.Join(partPath, e.Name()), fileSystem)
+			p.tagFamilyFilter[removeExt(name, tagFamiliesFilterFilenameExt)] = open(name)
+		}
+		if filepath.Ext(name) == tagFamiliesDictFilenameExt {
+			if p.tagFamilyDicts == nil {
+				p.tagFamilyDicts = make(map[string]*zstd.Dict)
+			}
+			p.tagFamilyDicts[removeExt(name, tagFamiliesDictFilenameExt)] = mustLoadDict(name, read)
 		}
 	}
 	return &p
@@ -325,6 +383,26 @@ func mustOpenReader(name string, fileSystem fs.FileSystem) fs.Reader {
 	return f
 }
 
+func mustOpenPack(fileSystem fs.FileSystem, partPath string) *storage.Pack {
+	p, err := storage.OpenPack(fileSystem, partPath)
+	if err != nil {
+		logger.Panicf("cannot open the pack of %q: %s", partPath, err)
+	}
+	return p
+}
+
+func mustLoadDict(name string, read func(string) ([]byte, error)) *zstd.Dict {
+	content, err := read(name)
+	if err != nil {
+		logger.Panicf("cannot read %q: %s", name, err)
+	}
+	d, err := zstd.LoadDict(content)
+	if err != nil {
+		logger.Panicf("cannot load the dictionary %q: %s", name, err)
+	}
+	return d
+}
+
 func removeExt(nameWithExt, ext string) string {
 	return nameWithExt[:len(nameWithExt)-len(ext)]
 }
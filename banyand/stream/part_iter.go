@@ -238,7 +238,8 @@ func (pi *partIter) findBlock() bool {
 			shouldSkip, err := func() (bool, error) {
 				tfs := generateTagFamilyFilters()
 				defer releaseTagFamilyFilters(tfs)
-				tfs.unmarshal(bm.tagFamilies, pi.p.tagFamilyMetadata, pi.p.tagFamilyFilter)
+				tfs.nullCountKnown = pi.p.partMetadata.formatVersion() >= partFormatV2
+				tfs.unmarshal(bm.tagFamilies, bm.count, pi.p.tagFamilyMetadata, pi.p.tagFamilyFilter, pi.p.tagFamilies)
 				return pi.blockFilter.ShouldSkip(tfs)
 			}()
 			if err != nil {
@@ -246,9 +247,7 @@ func (pi *partIter) findBlock() bool {
 				return false
 			}
 			if shouldSkip {
-				if !pi.nextSeriesID() {
-					return false
-				}
+				bhs = bhs[1:]
 				continue
 			}
 		}
@@ -269,6 +268,7 @@ type partMergeIter struct {
 	compressedPrimaryBuf []byte
 	primaryBuf           []byte
 	block                blockPointer
+	ingestedAt           int64
 	primaryMetadataIdx   int
 }
 
@@ -277,6 +277,7 @@ func (pmi *partMergeIter) reset() {
 	pmi.seqReaders.reset()
 	pmi.primaryBlockMetadata = nil
 	pmi.primaryMetadataIdx = 0
+	pmi.ingestedAt = 0
 	pmi.primaryBuf = pmi.primaryBuf[:0]
 	pmi.compressedPrimaryBuf = pmi.compressedPrimaryBuf[:0]
 	pmi.block.reset()
@@ -286,6 +287,7 @@ func (pmi *partMergeIter) mustInitFromPart(p *part) {
 	pmi.reset()
 	pmi.seqReaders.init(p)
 	pmi.primaryBlockMetadata = p.primaryBlockMetadata
+	pmi.ingestedAt = p.partMetadata.IngestedAt
 }
 
 func (pmi *partMergeIter) error() error {
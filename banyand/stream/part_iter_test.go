@@ -28,6 +28,8 @@ import (
 	"github.com/apache/skywalking-banyandb/api/common"
 	"github.com/apache/skywalking-banyandb/pkg/encoding"
 	"github.com/apache/skywalking-banyandb/pkg/fs"
+	"github.com/apache/skywalking-banyandb/pkg/index"
+	"github.com/apache/skywalking-banyandb/pkg/index/posting"
 	"github.com/apache/skywalking-banyandb/pkg/test"
 )
 
@@ -143,7 +145,7 @@ func Test_partIter_nextBlock(t *testing.T) {
 			}
 			mp := generateMemPart()
 			releaseMemPart(mp)
-			mp.mustInitFromElements(es)
+			mp.mustInitFromElements(es, encoding.DefaultCompressionLevel)
 
 			p := openMemPart(mp)
 			verifyPart(p)
@@ -206,7 +208,7 @@ func Test_partMergeIter_nextBlock(t *testing.T) {
 			}
 			mp := generateMemPart()
 			releaseMemPart(mp)
-			mp.mustInitFromElements(tt.es)
+			mp.mustInitFromElements(tt.es, encoding.DefaultCompressionLevel)
 
 			decoder := generateColumnValuesDecoder()
 			defer releaseColumnValuesDecoder(decoder)
@@ -223,3 +225,48 @@ func Test_partMergeIter_nextBlock(t *testing.T) {
 		})
 	}
 }
+
+type skipFirstBlockFilter struct {
+	skipped bool
+}
+
+func (f *skipFirstBlockFilter) String() string {
+	return "skip-first-block"
+}
+
+func (f *skipFirstBlockFilter) Execute(_ index.GetSearcher, _ common.SeriesID, _ *index.RangeOpts) (posting.List, posting.List, error) {
+	return nil, nil, nil
+}
+
+func (f *skipFirstBlockFilter) ShouldSkip(_ index.FilterOp) (bool, error) {
+	if f.skipped {
+		return false, nil
+	}
+	f.skipped = true
+	return true, nil
+}
+
+func Test_partIter_findBlockAfterPrunedBlock(t *testing.T) {
+	pi := partIter{
+		p:            &part{},
+		curBlock:     &blockMetadata{seriesID: 1},
+		sids:         []common.SeriesID{1, 2},
+		sidIdx:       1,
+		minTimestamp: 1,
+		maxTimestamp: 100,
+		blockFilter:  &skipFirstBlockFilter{},
+		bms: []blockMetadata{
+			{seriesID: 1, count: 1, timestamps: timestampsMetadata{min: 1, max: 10}},
+			{seriesID: 1, count: 2, timestamps: timestampsMetadata{min: 11, max: 20}},
+			{seriesID: 2, count: 3, timestamps: timestampsMetadata{min: 1, max: 10}},
+		},
+	}
+	var got []blockMetadata
+	for pi.findBlock() {
+		got = append(got, *pi.curBlock)
+	}
+	require.Len(t, got, 2)
+	require.Equal(t, common.SeriesID(1), got[0].seriesID)
+	require.Equal(t, uint64(2), got[0].count)
+	require.Equal(t, common.SeriesID(2), got[1].seriesID)
+}
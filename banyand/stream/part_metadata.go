@@ -28,6 +28,17 @@ import (
 	"github.com/apache/skywalking-banyandb/pkg/logger"
 )
 
+const (
+	// partFormatV1 is the format of the parts written before the format version is introduced.
+	partFormatV1 uint32 = 1
+	// partFormatV2 tracks the null count of every tag in the tag family metadata.
+	partFormatV2 uint32 = 2
+	// partFormatV3 compresses the values of the tag families with the dictionaries trained during the merges.
+	partFormatV3 uint32 = 3
+
+	currentPartFormatVersion = partFormatV3
+)
+
 type partMetadata struct {
 	CompressedSizeBytes   uint64 `json:"compressedSizeBytes"`
 	UncompressedSizeBytes uint64 `json:"uncompressedSizeBytes"`
@@ -35,7 +46,15 @@ type partMetadata struct {
 	BlocksCount           uint64 `json:"blocksCount"`
 	MinTimestamp          int64  `json:"minTimestamp"`
 	MaxTimestamp          int64  `json:"maxTimestamp"`
-	ID                    uint64 `json:"-"`
+	// IngestedAt is the time in nanoseconds when the data of the part were written, which decides
+	// whether the range tombstones apply to them. A merged part takes the latest one of its sources.
+	// It's absent in the parts written before, which are taken as ingested before any deletion.
+	IngestedAt int64 `json:"ingestedAt,omitempty"`
+	// ReplicaOf is the ID of the part on the primary if the part is replicated from another cluster.
+	ReplicaOf uint64 `json:"replicaOf,omitempty"`
+	ID        uint64 `json:"-"`
+	// FormatVersion is the version of the on-disk format of the part. It's absent in the parts of partFormatV1.
+	FormatVersion uint32 `json:"formatVersion,omitempty"`
 }
 
 func (pm *partMetadata) reset() {
@@ -45,7 +64,22 @@ func (pm *partMetadata) reset() {
 	pm.BlocksCount = 0
 	pm.MinTimestamp = 0
 	pm.MaxTimestamp = 0
+	pm.IngestedAt = 0
+	pm.ReplicaOf = 0
 	pm.ID = 0
+	pm.FormatVersion = 0
+}
+
+func (pm *partMetadata) formatVersion() uint32 {
+	if pm.FormatVersion == 0 {
+		return partFormatV1
+	}
+	return pm.FormatVersion
+}
+
+// outdated reports whether the part is written in an older format, which is rewritten during the merges.
+func (pm *partMetadata) outdated() bool {
+	return pm.formatVersion() < currentPartFormatVersion
 }
 
 func validatePartMetadata(fileSystem fs.FileSystem, partPath string) error {
@@ -58,6 +92,47 @@ func validatePartMetadata(fileSystem fs.FileSystem, partPath string) error {
 	if err := json.Unmarshal(metadata, &pm); err != nil {
 		return errors.WithMessage(err, "cannot parse metadata.json")
 	}
+	return storage.CheckPartFormatVersion(pm.formatVersion(), currentPartFormatVersion)
+}
+
+// validatePart checks the metadata and the files of the part, which are required by mustOpenFilePart.
+func validatePart(fileSystem fs.FileSystem, partPath string) error {
+	if err := validatePartMetadata(fileSystem, partPath); err != nil {
+		return err
+	}
+	files := make(map[string]struct{})
+	for _, e := range fileSystem.ReadDir(partPath) {
+		if !e.IsDir() {
+			files[e.Name()] = struct{}{}
+		}
+	}
+	if _, ok := files[storage.PackFilename]; ok {
+		pack, err := storage.OpenPack(fileSystem, partPath)
+		if err != nil {
+			return err
+		}
+		files = make(map[string]struct{})
+		for _, name := range pack.Names() {
+			files[name] = struct{}{}
+		}
+		if err = pack.Close(); err != nil {
+			return err
+		}
+	}
+	for _, name := range []string{metaFilename, primaryFilename, timestampsFilename} {
+		if _, ok := files[name]; !ok {
+			return errors.Errorf("%s is absent", name)
+		}
+	}
+	for name := range files {
+		if filepath.Ext(name) != tagFamiliesMetadataFilenameExt {
+			continue
+		}
+		tf := removeExt(name, tagFamiliesMetadataFilenameExt) + tagFamiliesFilenameExt
+		if _, ok := files[tf]; !ok {
+			return errors.Errorf("%s is absent", tf)
+		}
+	}
 	return nil
 }
 
@@ -75,6 +150,9 @@ func (pm *partMetadata) mustReadMetadata(fileSystem fs.FileSystem, partPath stri
 		return
 	}
 
+	if err := storage.CheckPartFormatVersion(pm.formatVersion(), currentPartFormatVersion); err != nil {
+		logger.Panicf("cannot open %s: %s", partPath, err)
+	}
 	if pm.MinTimestamp > pm.MaxTimestamp {
 		logger.Panicf("MinTimestamp cannot exceed MaxTimestamp; got %d vs %d", pm.MinTimestamp, pm.MaxTimestamp)
 	}
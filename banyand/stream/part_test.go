@@ -25,6 +25,7 @@ import (
 
 	"github.com/apache/skywalking-banyandb/api/common"
 	"github.com/apache/skywalking-banyandb/pkg/convert"
+	"github.com/apache/skywalking-banyandb/pkg/encoding"
 	"github.com/apache/skywalking-banyandb/pkg/fs"
 	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
 	"github.com/apache/skywalking-banyandb/pkg/test"
@@ -84,7 +85,7 @@ func TestMustInitFromElements(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mp := &memPart{}
-			mp.mustInitFromElements(tt.es)
+			mp.mustInitFromElements(tt.es, encoding.DefaultCompressionLevel)
 			assert.Equal(t, tt.want.BlocksCount, mp.partMetadata.BlocksCount)
 			assert.Equal(t, tt.want.MinTimestamp, mp.partMetadata.MinTimestamp)
 			assert.Equal(t, tt.want.MaxTimestamp, mp.partMetadata.MaxTimestamp)
@@ -0,0 +1,75 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"github.com/apache/skywalking-banyandb/pkg/convert"
+	"github.com/apache/skywalking-banyandb/pkg/encoding"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+)
+
+// payloadTagName is the name of the column storing all the tags of a family in the payload layout.
+const payloadTagName = "_payload"
+
+// encodePayload marshals the values of a tag family in the payload layout into a single value.
+// The null values are omitted, so they're decoded as nulls as well.
+func encodePayload(values []*tagValue) *tagValue {
+	tv := generateTagValue()
+	tv.tag = payloadTagName
+	tv.valueType = pbv1.ValueTypeBinaryData
+	for _, v := range values {
+		m := v.marshal()
+		if len(m) == 0 {
+			continue
+		}
+		tv.value = encoding.EncodeBytes(tv.value, convert.StringToBytes(v.tag))
+		tv.value = append(tv.value, byte(v.valueType))
+		tv.value = encoding.EncodeBytes(tv.value, m)
+	}
+	return tv
+}
+
+// mustDecodePayload fills the values of the tag with the given name from the payload column.
+func (t *tag) mustDecodePayload(payload *tag, name string) {
+	t.name = name
+	t.valueType = pbv1.ValueTypeUnknown
+	values := t.resizeValues(len(payload.values))
+	for i, src := range payload.values {
+		values[i] = nil
+		var n, v []byte
+		var err error
+		for len(src) > 0 {
+			if src, n, err = encoding.DecodeBytes(src); err != nil {
+				logger.Panicf("cannot decode the tag name of the payload: %v", err)
+			}
+			if len(src) < 1 {
+				logger.Panicf("cannot decode the value type of the tag %q in the payload: src is too short", n)
+			}
+			vt := pbv1.ValueType(src[0])
+			if src, v, err = encoding.DecodeBytes(src[1:]); err != nil {
+				logger.Panicf("cannot decode the value of the tag %q in the payload: %v", n, err)
+			}
+			if string(n) == name {
+				values[i] = v
+				t.valueType = vt
+				break
+			}
+		}
+	}
+}
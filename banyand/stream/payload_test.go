@@ -0,0 +1,89 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/skywalking-banyandb/pkg/bytes"
+	"github.com/apache/skywalking-banyandb/pkg/convert"
+	"github.com/apache/skywalking-banyandb/pkg/encoding"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+)
+
+func TestPayload(t *testing.T) {
+	elements := [][]*tagValue{
+		{
+			{tag: "binary", valueType: pbv1.ValueTypeBinaryData, value: []byte("payload")},
+			{tag: "int", valueType: pbv1.ValueTypeInt64, value: convert.Int64ToBytes(10)},
+			{tag: "strArr", valueType: pbv1.ValueTypeStrArr, valueArr: [][]byte{[]byte("a"), []byte("b")}},
+		},
+		{
+			{tag: "binary", valueType: pbv1.ValueTypeBinaryData},
+			{tag: "int", valueType: pbv1.ValueTypeInt64, value: convert.Int64ToBytes(20)},
+			{tag: "strArr", valueType: pbv1.ValueTypeStrArr},
+		},
+		{
+			{tag: "binary", valueType: pbv1.ValueTypeBinaryData},
+		},
+	}
+	payload := &tag{name: payloadTagName, valueType: pbv1.ValueTypeBinaryData}
+	for _, tvs := range elements {
+		payload.values = append(payload.values, encodePayload(tvs).marshal())
+	}
+
+	// write the payload column, then read it back as the other columns.
+	tm := &tagMetadata{}
+	tagWriter, tagFilterWriter := &writer{}, &writer{}
+	buf, filterBuf := &bytes.Buffer{}, &bytes.Buffer{}
+	tagWriter.init(buf)
+	tagFilterWriter.init(filterBuf)
+	payload.mustWriteTo(tm, tagWriter, tagFilterWriter, encoding.DefaultCompressionLevel, nil)
+	read := &tag{}
+	decoder := &encoding.BytesBlockDecoder{}
+	read.mustReadValues(decoder, buf, *tm, uint64(len(elements)))
+
+	binary := &tag{}
+	binary.mustDecodePayload(read, "binary")
+	assert.Equal(t, "binary", binary.name)
+	assert.Equal(t, pbv1.ValueTypeBinaryData, binary.valueType)
+	assert.Equal(t, [][]byte{[]byte("payload"), nil, nil}, binary.values)
+
+	intTag := &tag{}
+	intTag.mustDecodePayload(read, "int")
+	assert.Equal(t, pbv1.ValueTypeInt64, intTag.valueType)
+	require.Len(t, intTag.values, 3)
+	assert.Equal(t, int64(10), convert.BytesToInt64(intTag.values[0]))
+	assert.Equal(t, int64(20), convert.BytesToInt64(intTag.values[1]))
+	assert.Nil(t, intTag.values[2])
+
+	strArr := &tag{}
+	strArr.mustDecodePayload(read, "strArr")
+	assert.Equal(t, pbv1.ValueTypeStrArr, strArr.valueType)
+	assert.Equal(t, "a|b|", string(strArr.values[0]))
+	assert.Nil(t, strArr.values[1])
+	assert.Nil(t, strArr.values[2])
+
+	absent := &tag{}
+	absent.mustDecodePayload(read, "absent")
+	assert.Equal(t, pbv1.ValueTypeUnknown, absent.valueType)
+	assert.Equal(t, [][]byte{nil, nil, nil}, absent.values)
+}
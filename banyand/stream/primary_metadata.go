@@ -50,7 +50,7 @@ func (ph *primaryBlockMetadata) mustWriteBlock(data []byte, sidFirst common.Seri
 	ph.maxTimestamp = maxTimestamp
 
 	bb := bigValuePool.Generate()
-	bb.Buf = zstd.Compress(bb.Buf[:0], data, 1)
+	bb.Buf = zstd.Compress(bb.Buf[:0], data, sw.compressionLevel)
 	ph.offset = sw.primaryWriter.bytesWritten
 	ph.size = uint64(len(bb.Buf))
 	sw.primaryWriter.MustWrite(bb.Buf)
@@ -68,6 +68,7 @@ func (s *stream) Query(ctx context.Context, sqo model.StreamQueryOptions) (sqr m
 
 	series := prepareSeriesData(sqo)
 	qo := prepareQueryOptions(sqo)
+	qo.rangeTombstones = s.schemaRepo.loadRangeTombstones(s.group)
 	tr := index.NewIntRangeOpts(qo.minTimestamp, qo.maxTimestamp, true, true)
 
 	if sqo.Order == nil || sqo.Order.Index == nil {
@@ -202,6 +203,7 @@ func (s *stream) processSegmentsAndBuildFilters(
 	result.qo = queryOptions{
 		StreamQueryOptions: sqo,
 		seriesToEntity:     make(map[common.SeriesID][]*modelv1.TagValue),
+		rangeTombstones:    s.schemaRepo.loadRangeTombstones(s.group),
 	}
 
 	seriesFilter := roaring.NewPostingList()
@@ -220,6 +222,12 @@ func (s *stream) processSegmentsAndBuildFilters(
 		if filter, filterTS, err = indexSearch(ctx, sqo, tables, sl.ToList().ToSlice(), tr); err != nil {
 			return result, nil, nil, err
 		}
+		if filter, filterTS, err = filterTrace(sqo, tables, tr, filter, filterTS); err != nil {
+			return result, nil, nil, err
+		}
+		if filter, err = filterElements(sqo, filter); err != nil {
+			return result, nil, nil, err
+		}
 
 		if filter != nil && filter.IsEmpty() {
 			continue
@@ -232,8 +240,12 @@ func (s *stream) processSegmentsAndBuildFilters(
 			if err = result.qo.elementFilter.Union(filter); err != nil {
 				return result, nil, nil, err
 			}
-			if err = resultTS.Union(filterTS); err != nil {
-				return result, nil, nil, err
+			if resultTS == nil {
+				resultTS = filterTS
+			} else if filterTS != nil {
+				if err = resultTS.Union(filterTS); err != nil {
+					return result, nil, nil, err
+				}
 			}
 		}
 
@@ -253,9 +265,10 @@ func (s *stream) processSegmentsAndBuildFilters(
 }
 
 type queryOptions struct {
-	elementFilter  posting.List
-	seriesToEntity map[common.SeriesID][]*modelv1.TagValue
-	sortedSids     []common.SeriesID
+	elementFilter   posting.List
+	seriesToEntity  map[common.SeriesID][]*modelv1.TagValue
+	rangeTombstones *storage.RangeTombstones
+	sortedSids      []common.SeriesID
 	model.StreamQueryOptions
 	minTimestamp int64
 	maxTimestamp int64
@@ -266,6 +279,7 @@ func (qo *queryOptions) reset() {
 	qo.elementFilter = nil
 	qo.seriesToEntity = nil
 	qo.sortedSids = nil
+	qo.rangeTombstones = nil
 	qo.minTimestamp = 0
 	qo.maxTimestamp = 0
 }
@@ -275,6 +289,7 @@ func (qo *queryOptions) copyFrom(other *queryOptions) {
 	qo.elementFilter = other.elementFilter
 	qo.seriesToEntity = other.seriesToEntity
 	qo.sortedSids = other.sortedSids
+	qo.rangeTombstones = other.rangeTombstones
 	qo.minTimestamp = other.minTimestamp
 	qo.maxTimestamp = other.maxTimestamp
 }
@@ -308,6 +323,53 @@ func indexSearch(ctx context.Context, sqo model.StreamQueryOptions,
 	return result, resultTS, nil
 }
 
+// filterElements narrows the filter down to the elements whose IDs are requested by the query.
+// A nil filter means all the elements, so the requested IDs become the filter.
+func filterElements(sqo model.StreamQueryOptions, filter posting.List) (posting.List, error) {
+	if len(sqo.ElementIDs) == 0 {
+		return filter, nil
+	}
+	result := roaring.NewPostingListWithInitialData(sqo.ElementIDs...)
+	if filter == nil {
+		return result, nil
+	}
+	if err := result.Intersect(filter); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// filterTrace narrows the filter down to the elements written with the trace ID of the query,
+// which are looked up in the trace index of the tables.
+func filterTrace(sqo model.StreamQueryOptions, tabs []*tsTable, tr *index.RangeOpts, filter, filterTS posting.List) (posting.List, posting.List, error) {
+	if sqo.TraceID == "" {
+		return filter, filterTS, nil
+	}
+	result, resultTS := roaring.NewPostingList(), roaring.NewPostingList()
+	for _, tw := range tabs {
+		pl, plTS, err := tw.Index().LookupTrace(sqo.TraceID, sqo.SpanID, tr)
+		if err != nil {
+			return nil, nil, err
+		}
+		if pl == nil || pl.IsEmpty() {
+			continue
+		}
+		if err = result.Union(pl); err != nil {
+			return nil, nil, err
+		}
+		if err = resultTS.Union(plTS); err != nil {
+			return nil, nil, err
+		}
+	}
+	if filter != nil {
+		if err := result.Intersect(filter); err != nil {
+			return nil, nil, err
+		}
+	}
+	// the timestamps of the trace cover the ones of the result, so they narrow the parts to scan.
+	return result, resultTS, nil
+}
+
 func (s *stream) indexSort(ctx context.Context, sqo model.StreamQueryOptions, tabs []*tsTable,
 	sids []uint64,
 ) (itersort.Iterator[*index.DocumentResult], error) {
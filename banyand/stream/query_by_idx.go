@@ -50,6 +50,17 @@ type idxResult struct {
 }
 
 func (qr *idxResult) Pull(ctx context.Context) *model.StreamResult {
+	r := qr.pull(ctx)
+	if r == nil || r.Error != nil {
+		return r
+	}
+	if err := qr.sm.loadStoredTags(ctx, qr.tabs, qr.qo.TagProjection, r); err != nil {
+		return &model.StreamResult{Error: err}
+	}
+	return r
+}
+
+func (qr *idxResult) pull(ctx context.Context) *model.StreamResult {
 	if !qr.loaded {
 		qr.elementIDsSorted = make([]uint64, 0, qr.qo.MaxElementSize)
 		return qr.loadSortingData(ctx)
@@ -64,6 +75,7 @@ func (qr *idxResult) Pull(ctx context.Context) *model.StreamResult {
 func (qr *idxResult) scanParts(ctx context.Context, qo queryOptions) error {
 	var parts []*part
 	var n int
+	var locations map[*part]partLocation
 	for i := range qr.tabs {
 		s := qr.tabs[i].currentSnapshot()
 		if s == nil {
@@ -75,6 +87,7 @@ func (qr *idxResult) scanParts(ctx context.Context, qo queryOptions) error {
 			continue
 		}
 		qr.snapshots = append(qr.snapshots, s)
+		locations = locateParts(locations, qo, qr.tabs[i], parts, len(parts)-n)
 	}
 	bma := generateBlockMetadataArray()
 	defer releaseBlockMetadataArray(bma)
@@ -102,6 +115,7 @@ func (qr *idxResult) scanParts(ctx context.Context, qo queryOptions) error {
 		bc := generateBlockCursor()
 		p := ti.piHeap[0]
 		bc.init(p.p, p.curBlock, qo)
+		bc.loc = locations[p.p]
 		qr.data = append(qr.data, bc)
 		totalBlockBytes += bc.bm.uncompressedSizeBytes
 		if quota >= 0 && totalBlockBytes > uint64(quota) {
@@ -114,6 +128,9 @@ func (qr *idxResult) scanParts(ctx context.Context, qo queryOptions) error {
 	if err := qr.pm.AcquireResource(ctx, totalBlockBytes); err != nil {
 		return fmt.Errorf("cannot acquire resource: %w", err)
 	}
+	if err := protector.Account(ctx, totalBlockBytes); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -186,16 +203,11 @@ func (qr *idxResult) nextValue() *model.StreamResult {
 }
 
 func (qr *idxResult) loadSortingData(ctx context.Context) *model.StreamResult {
-	var qo queryOptions
-	qo.StreamQueryOptions = qr.qo.StreamQueryOptions
-	qo.elementFilter = roaring.NewPostingList()
-	qo.seriesToEntity = qr.qo.seriesToEntity
-	qr.elementIDsSorted = qr.elementIDsSorted[:0]
 	count, searchedSize := 1, 0
 	tracer := query.GetTracer(ctx)
 	if tracer != nil {
 		span, _ := tracer.StartSpan(ctx, "load-sorting-data")
-		span.Tagf("max_element_size", "%d", qo.MaxElementSize)
+		span.Tagf("max_element_size", "%d", qr.qo.MaxElementSize)
 		if qr.qo.elementFilter != nil {
 			span.Tag("filter_size", fmt.Sprintf("%d", qr.qo.elementFilter.Len()))
 		}
@@ -205,50 +217,62 @@ func (qr *idxResult) loadSortingData(ctx context.Context) *model.StreamResult {
 			span.Stop()
 		}()
 	}
-	for ; qr.sortingIter.Next(); count++ {
-		searchedSize++
-		val := qr.sortingIter.Val()
-		if qr.qo.elementFilter != nil && !qr.qo.elementFilter.Contains(val.DocID) {
-			count--
-			continue
-		}
-		qo.elementFilter.Insert(val.DocID)
-		if val.Timestamp > qo.maxTimestamp {
-			qo.maxTimestamp = val.Timestamp
-		}
-		if val.Timestamp < qo.minTimestamp || qo.minTimestamp == 0 {
-			qo.minTimestamp = val.Timestamp
-		}
-		qr.elementIDsSorted = append(qr.elementIDsSorted, val.DocID)
+	for {
+		var qo queryOptions
+		qo.StreamQueryOptions = qr.qo.StreamQueryOptions
+		qo.elementFilter = roaring.NewPostingList()
+		qo.seriesToEntity = qr.qo.seriesToEntity
+		qo.rangeTombstones = qr.qo.rangeTombstones
+		qr.elementIDsSorted = qr.elementIDsSorted[:0]
+		count = 1
+		for ; qr.sortingIter.Next(); count++ {
+			searchedSize++
+			val := qr.sortingIter.Val()
+			if qr.qo.elementFilter != nil && !qr.qo.elementFilter.Contains(val.DocID) {
+				count--
+				continue
+			}
+			qo.elementFilter.Insert(val.DocID)
+			if val.Timestamp > qo.maxTimestamp {
+				qo.maxTimestamp = val.Timestamp
+			}
+			if val.Timestamp < qo.minTimestamp || qo.minTimestamp == 0 {
+				qo.minTimestamp = val.Timestamp
+			}
+			qr.elementIDsSorted = append(qr.elementIDsSorted, val.DocID)
 
-		// Insertion sort
-		insertPos, found := -1, false
-		for i, sid := range qo.sortedSids {
-			if val.SeriesID == sid {
-				found = true
-				break
+			// Insertion sort
+			insertPos, found := -1, false
+			for i, sid := range qo.sortedSids {
+				if val.SeriesID == sid {
+					found = true
+					break
+				}
+				if val.SeriesID < sid {
+					insertPos = i
+					break
+				}
+			}
+
+			if !found {
+				if insertPos == -1 {
+					qo.sortedSids = append(qo.sortedSids, val.SeriesID)
+				} else {
+					qo.sortedSids = append(qo.sortedSids[:insertPos], append([]common.SeriesID{val.SeriesID}, qo.sortedSids[insertPos:]...)...)
+				}
 			}
-			if val.SeriesID < sid {
-				insertPos = i
+			if count >= qo.MaxElementSize {
 				break
 			}
 		}
-
-		if !found {
-			if insertPos == -1 {
-				qo.sortedSids = append(qo.sortedSids, val.SeriesID)
-			} else {
-				qo.sortedSids = append(qo.sortedSids[:insertPos], append([]common.SeriesID{val.SeriesID}, qo.sortedSids[insertPos:]...)...)
-			}
+		if qo.elementFilter.IsEmpty() {
+			return nil
 		}
-		if count >= qo.MaxElementSize {
-			break
+		if r := qr.load(ctx, qo); r != nil {
+			return r
 		}
+		// the blocks of the batch are all pruned, so the next batch is loaded.
 	}
-	if qo.elementFilter.IsEmpty() {
-		return nil
-	}
-	return qr.load(ctx, qo)
 }
 
 func (qr *idxResult) releaseParts() {
@@ -312,6 +336,9 @@ func (qr *idxResult) mergeByTagValue() *model.StreamResult {
 		}
 		r.Timestamps = append(r.Timestamps, tmp.Timestamps[idx])
 		r.ElementIDs = append(r.ElementIDs, tmp.ElementIDs[idx])
+		if len(tmp.Metadata) > 0 {
+			r.Metadata = append(r.Metadata, tmp.Metadata[idx])
+		}
 		for i := 0; i < len(r.TagFamilies); i++ {
 			for j := 0; j < len(r.TagFamilies[i].Tags); j++ {
 				r.TagFamilies[i].Tags[j].Values = append(r.TagFamilies[i].Tags[j].Values, tmp.TagFamilies[i].Tags[j].Values[idx])
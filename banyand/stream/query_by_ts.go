@@ -43,6 +43,7 @@ type tsResult struct {
 	ts       *blockScanner
 	tr       *index.RangeOpts
 	segments []storage.Segment[*tsTable, option]
+	tabs     []*tsTable
 	series   []*pbv1.Series
 	shards   []*model.StreamResult
 	qo       queryOptions
@@ -85,6 +86,7 @@ func (t *tsResult) scan(ctx context.Context) (*model.StreamResult, error) {
 			continue
 		}
 		t.ts = ts
+		t.tabs, _ = segment.Tables()
 		return t.runTabScanner(ctx)
 	}
 	return nil, nil
@@ -121,6 +123,7 @@ func (t *tsResult) runTabScanner(ctx context.Context) (*model.StreamResult, erro
 				for _, bs := range batch.bss {
 					bc := generateBlockCursor()
 					bc.init(bs.p, &bs.bm, bs.qo)
+					bc.loc = bs.loc
 					if loadBlockCursor(bc, tmpBlock, bs.qo, t.sm) {
 						if !t.asc {
 							bc.idx = len(bc.timestamps) - 1
@@ -140,20 +143,27 @@ func (t *tsResult) runTabScanner(ctx context.Context) (*model.StreamResult, erro
 	t.ts.scan(ctx, batchCh)
 	close(batchCh)
 	workerWg.Wait()
-	if len(t.ts.parts) == 0 {
-		t.ts.close()
-		t.ts = nil
-	}
 	var err error
 	for i := range t.shards {
 		if t.shards[i].Error != nil {
 			err = multierr.Append(err, t.shards[i].Error)
 		}
 	}
+	var r *model.StreamResult
+	if err == nil {
+		r = model.MergeStreamResults(t.shards, t.qo.MaxElementSize, t.asc)
+		// the tables are released along with the scanner, so the stored tags are loaded before closing it.
+		err = t.sm.loadStoredTags(ctx, t.tabs, t.qo.TagProjection, r)
+	}
+	if len(t.ts.parts) == 0 {
+		t.ts.close()
+		t.ts = nil
+		t.tabs = nil
+	}
 	if err != nil {
 		return nil, err
 	}
-	return model.MergeStreamResults(t.shards, t.qo.MaxElementSize, t.asc), nil
+	return r, nil
 }
 
 func loadBlockCursor(bc *blockCursor, tmpBlock *block, qo queryOptions, sm *stream) bool {
@@ -168,7 +178,7 @@ func loadBlockCursor(bc *blockCursor, tmpBlock *block, qo queryOptions, sm *stre
 	for idx, tagFamily := range bc.tagFamilies {
 		tagFamilyMap[tagFamily.name] = idx + 1
 	}
-	is := sm.indexSchema.Load().(indexSchema)
+	is := sm.loadIndexSchema()
 	for _, tagFamilyProj := range bc.tagProjection {
 		for j, tagProj := range tagFamilyProj.Names {
 			tagSpec := is.tagMap[tagProj]
@@ -0,0 +1,275 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+	"github.com/apache/skywalking-banyandb/pkg/convert"
+	"github.com/apache/skywalking-banyandb/pkg/index"
+)
+
+// replicatedParts returns the IDs of the primary parts installed into the table.
+func (tst *tsTable) replicatedParts() map[uint64]uint64 {
+	result := make(map[uint64]uint64)
+	snp := tst.currentSnapshot()
+	if snp == nil {
+		return result
+	}
+	defer snp.decRef()
+	for _, pw := range snp.parts {
+		if pw.mp == nil && pw.p.partMetadata.ReplicaOf > 0 {
+			result[pw.p.partMetadata.ReplicaOf] = pw.ID()
+		}
+	}
+	return result
+}
+
+// applyReplicas installs the parts staged in dir whose primary parts are live, and removes the replicated parts
+// whose primary parts are absent from live. Both take effect in one manifest, so the table never holds
+// the merged part of the primary together with its sources.
+func (tst *tsTable) applyReplicas(dir string, live map[uint64]struct{}) (installed, removed int, err error) {
+	applied := tst.replicatedParts()
+	ind := generateIntroduction()
+	defer releaseIntroduction(ind)
+	// the introducer owns the installed parts once the introduction is sent.
+	owned := true
+	defer func() {
+		if err == nil || !owned {
+			return
+		}
+		// the installed parts aren't listed by any manifest.
+		for _, pw := range ind.imported {
+			pw.removable.Store(true)
+			pw.decRef()
+		}
+	}()
+	for primaryID, id := range applied {
+		if _, ok := live[primaryID]; ok {
+			continue
+		}
+		if ind.removed == nil {
+			ind.removed = make(map[uint64]struct{})
+		}
+		ind.removed[id] = struct{}{}
+	}
+	for _, e := range tst.fileSystem.ReadDir(dir) {
+		if !e.IsDir() {
+			continue
+		}
+		stagedPath := filepath.Join(dir, e.Name())
+		primaryID, errParse := parseEpoch(e.Name())
+		if errParse != nil {
+			tst.fileSystem.MustRMAll(stagedPath)
+			continue
+		}
+		_, isLive := live[primaryID]
+		if _, ok := applied[primaryID]; ok || !isLive {
+			tst.fileSystem.MustRMAll(stagedPath)
+			continue
+		}
+		if err = validatePart(tst.fileSystem, stagedPath); err != nil {
+			return 0, 0, fmt.Errorf("the staged part %s is incomplete: %w", stagedPath, err)
+		}
+		var pm partMetadata
+		pm.mustReadMetadata(tst.fileSystem, stagedPath)
+		pm.ReplicaOf = primaryID
+		pm.mustWriteMetadata(tst.fileSystem, stagedPath)
+		partID := atomic.AddUint64(&tst.curPartID, 1)
+		if err = tst.fileSystem.Rename(stagedPath, partPath(tst.root, partID)); err != nil {
+			return 0, 0, fmt.Errorf("cannot install the staged part %s: %w", stagedPath, err)
+		}
+		ind.imported = append(ind.imported, newPartWrapper(nil, mustOpenFilePart(partID, tst.root, tst.fileSystem)))
+	}
+	if len(ind.imported) == 0 && len(ind.removed) == 0 {
+		return 0, 0, nil
+	}
+	ind.applied = make(chan struct{})
+	select {
+	case tst.introductions <- ind:
+	case <-tst.loopCloser.CloseNotify():
+		return 0, 0, errClosed
+	}
+	owned = false
+	select {
+	case <-ind.applied:
+	case <-tst.loopCloser.CloseNotify():
+		return 0, 0, errClosed
+	}
+	return len(ind.imported), len(ind.removed), nil
+}
+
+func (s *service) replicaTable(shard *databasev1.ReplicaPart) (*tsTable, storage.Segment[*tsTable, option], error) {
+	if shard.GetCatalog() != commonv1.Catalog_CATALOG_STREAM {
+		return nil, nil, nil
+	}
+	g, ok := s.schemaRepo.LoadGroup(shard.GetGroup())
+	if !ok {
+		return nil, nil, fmt.Errorf("group %s not found", shard.GetGroup())
+	}
+	if shardNum := g.GetSchema().GetResourceOpts().GetShardNum(); shard.GetShardId() >= shardNum {
+		return nil, nil, fmt.Errorf("shard %d is out of the %d shards of group %s", shard.GetShardId(), shardNum, shard.GetGroup())
+	}
+	db, err := s.schemaRepo.loadTSDB(shard.GetGroup())
+	if err != nil {
+		return nil, nil, err
+	}
+	segment, err := db.CreateSegmentIfNotExist(time.Unix(0, shard.GetTimestamp()))
+	if err != nil {
+		return nil, nil, err
+	}
+	tst, err := segment.CreateTSTableIfNotExist(common.ShardID(shard.GetShardId()))
+	if err != nil {
+		segment.DecRef()
+		return nil, nil, err
+	}
+	return tst, segment, nil
+}
+
+// replicaShardDir returns the staging directory of a replicated shard.
+func (s *service) replicaShardDir(shard *databasev1.ReplicaPart) (string, error) {
+	if !filepath.IsLocal(shard.GetGroup()) || shard.GetSegment() == "" || filepath.Base(shard.GetSegment()) != shard.GetSegment() {
+		return "", fmt.Errorf("invalid shard %s/%s", shard.GetGroup(), shard.GetSegment())
+	}
+	return filepath.Join(s.replicaDir, shard.GetGroup(), shard.GetSegment(), "shard-"+strconv.Itoa(int(shard.GetShardId()))), nil
+}
+
+type replicaListener struct {
+	*bus.UnImplementedHealthyListener
+	s *service
+}
+
+// Rev handles the requests of the replication on the stream shards. The requests of the other catalogs are ignored.
+func (r *replicaListener) Rev(ctx context.Context, message bus.Message) bus.Message {
+	var result any
+	switch req := message.Data().(type) {
+	case *databasev1.ReplicaPart:
+		if req.GetCatalog() == commonv1.Catalog_CATALOG_STREAM {
+			result = r.dir(req)
+		}
+	case *databasev1.ReplicaStatusRequest:
+		if req.GetShard().GetCatalog() == commonv1.Catalog_CATALOG_STREAM {
+			result = r.status(req)
+		}
+	case *databasev1.ReplicateSeriesRequest:
+		if req.GetShard().GetCatalog() == commonv1.Catalog_CATALOG_STREAM {
+			result = r.series(ctx, req)
+		}
+	case *databasev1.CommitReplicaRequest:
+		if req.GetShard().GetCatalog() == commonv1.Catalog_CATALOG_STREAM {
+			result = r.commit(req)
+		}
+	}
+	return bus.NewMessage(bus.MessageID(time.Now().UnixNano()), result)
+}
+
+func (r *replicaListener) dir(part *databasev1.ReplicaPart) any {
+	if _, ok := r.s.schemaRepo.LoadGroup(part.GetGroup()); !ok {
+		return fmt.Errorf("group %s not found", part.GetGroup())
+	}
+	dir, err := r.s.replicaShardDir(part)
+	if err != nil {
+		return err
+	}
+	return filepath.Join(dir, partName(part.GetPartId()))
+}
+
+func (r *replicaListener) status(req *databasev1.ReplicaStatusRequest) any {
+	tst, segment, err := r.s.replicaTable(req.GetShard())
+	if err != nil {
+		return err
+	}
+	defer segment.DecRef()
+	dir, err := r.s.replicaShardDir(req.GetShard())
+	if err != nil {
+		return err
+	}
+	resp := &databasev1.ReplicaStatusResponse{}
+	for primaryID := range tst.replicatedParts() {
+		resp.Applied = append(resp.Applied, primaryID)
+	}
+	for _, pe := range r.s.lfs.ReadDir(dir) {
+		if !pe.IsDir() {
+			continue
+		}
+		for _, fe := range r.s.lfs.ReadDir(filepath.Join(dir, pe.Name())) {
+			if fe.IsDir() {
+				continue
+			}
+			info, errStat := os.Stat(filepath.Join(dir, pe.Name(), fe.Name()))
+			if errStat != nil {
+				continue
+			}
+			resp.Staged = append(resp.Staged, &databasev1.SnapshotFile{Path: pe.Name() + "/" + fe.Name(), Size: uint64(info.Size())})
+		}
+	}
+	return resp
+}
+
+func (r *replicaListener) series(_ context.Context, req *databasev1.ReplicateSeriesRequest) any {
+	_, segment, err := r.s.replicaTable(req.GetShard())
+	if err != nil {
+		return err
+	}
+	defer segment.DecRef()
+	docs := make(index.Documents, 0, len(req.GetSeries()))
+	for _, s := range req.GetSeries() {
+		docs = append(docs, index.Document{
+			DocID:        convert.Hash(s),
+			EntityValues: s,
+		})
+	}
+	if err = segment.IndexDB().Insert(docs); err != nil {
+		return err
+	}
+	return &databasev1.ReplicateSeriesResponse{}
+}
+
+func (r *replicaListener) commit(req *databasev1.CommitReplicaRequest) any {
+	r.s.replicaMu.Lock()
+	defer r.s.replicaMu.Unlock()
+	tst, segment, err := r.s.replicaTable(req.GetShard())
+	if err != nil {
+		return err
+	}
+	defer segment.DecRef()
+	dir, err := r.s.replicaShardDir(req.GetShard())
+	if err != nil {
+		return err
+	}
+	live := make(map[uint64]struct{}, len(req.GetLive()))
+	for _, id := range req.GetLive() {
+		live[id] = struct{}{}
+	}
+	installed, removed, err := tst.applyReplicas(dir, live)
+	if err != nil {
+		return err
+	}
+	return &databasev1.CommitReplicaResponse{Installed: uint32(installed), Removed: uint32(removed)}
+}
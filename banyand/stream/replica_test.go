@@ -0,0 +1,125 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	"github.com/apache/skywalking-banyandb/banyand/protector"
+	"github.com/apache/skywalking-banyandb/pkg/fs"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/test"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+func Test_tsTable_applyReplicas(t *testing.T) {
+	tmpPath, defFn := test.Space(require.New(t))
+	defer defFn()
+	fileSystem := fs.NewLocalFileSystem()
+	opt := option{flushTimeout: time.Hour, mergePolicy: newDefaultMergePolicyForTesting(), protector: protector.Nop{}}
+	open := func(root string) *tsTable {
+		tst, err := newTSTable(fileSystem, root, common.Position{}, logger.GetLogger("test"), timestamp.TimeRange{}, opt, nil)
+		require.NoError(t, err)
+		return tst
+	}
+
+	// the primary seals two parts.
+	primaryPath := filepath.Join(tmpPath, "primary")
+	primary := open(primaryPath)
+	primary.mustAddElements(esTS1)
+	require.NoError(t, primary.Close())
+	primary = open(primaryPath)
+	primary.mustAddElements(esTS2)
+	require.NoError(t, primary.Close())
+	primary = open(primaryPath)
+	primaryParts, err := primary.readSnapshot(primary.currentEpoch())
+	require.NoError(t, err)
+	require.NoError(t, primary.Close())
+	require.Len(t, primaryParts, 2)
+
+	stagingPath := filepath.Join(tmpPath, "staging")
+	stage := func(primaryID, sourceID uint64) {
+		require.NoError(t, os.CopyFS(filepath.Join(stagingPath, partName(primaryID)), os.DirFS(partPath(primaryPath, sourceID))))
+	}
+	totalCount := func(tst *tsTable) uint64 {
+		s := tst.currentSnapshot()
+		require.NotNil(t, s)
+		defer s.decRef()
+		var total uint64
+		for _, pw := range s.parts {
+			total += pw.p.partMetadata.TotalCount
+		}
+		return total
+	}
+	want := uint64(len(esTS1.timestamps) + len(esTS2.timestamps))
+
+	replicaPath := filepath.Join(tmpPath, "replica")
+	replica := open(replicaPath)
+	for _, id := range primaryParts {
+		stage(id, id)
+	}
+	live := map[uint64]struct{}{primaryParts[0]: {}, primaryParts[1]: {}}
+	installed, removed, err := replica.applyReplicas(stagingPath, live)
+	require.NoError(t, err)
+	assert.Equal(t, 2, installed)
+	assert.Equal(t, 0, removed)
+	assert.Empty(t, fileSystem.ReadDir(stagingPath))
+	assert.Len(t, replica.replicatedParts(), 2)
+	assert.Equal(t, want, totalCount(replica))
+
+	// committing the same parts again is a no-op, and the stale staged parts are dropped.
+	stage(primaryParts[0], primaryParts[0])
+	installed, removed, err = replica.applyReplicas(stagingPath, live)
+	require.NoError(t, err)
+	assert.Equal(t, 0, installed)
+	assert.Equal(t, 0, removed)
+	assert.Empty(t, fileSystem.ReadDir(stagingPath))
+
+	// the replicated parts aren't merged by the replica.
+	s := replica.currentSnapshot()
+	dst, _ := replica.getPartsToMerge(s, 1<<40, nil)
+	s.decRef()
+	assert.Empty(t, dst)
+
+	// the primary merges its parts, and the merged part replaces the sources in one manifest.
+	mergedID := primaryParts[1] + 1
+	stage(mergedID, primaryParts[1])
+	installed, removed, err = replica.applyReplicas(stagingPath, map[uint64]struct{}{mergedID: {}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, installed)
+	assert.Equal(t, 2, removed)
+	applied := replica.replicatedParts()
+	assert.Len(t, applied, 1)
+	assert.Contains(t, applied, mergedID)
+	require.NoError(t, replica.Close())
+
+	// the replicated parts survive the restart.
+	replica = open(replicaPath)
+	defer replica.Close()
+	applied = replica.replicatedParts()
+	assert.Len(t, applied, 1)
+	assert.Contains(t, applied, mergedID)
+	assert.Equal(t, uint64(len(esTS2.timestamps)), totalCount(replica))
+}
@@ -0,0 +1,48 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"context"
+	"time"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+)
+
+type seriesLookupListener struct {
+	*bus.UnImplementedHealthyListener
+	s *service
+}
+
+// Rev looks up the series of a stream in the series indexes of its groups.
+func (l *seriesLookupListener) Rev(ctx context.Context, message bus.Message) bus.Message {
+	now := time.Now().UnixNano()
+	req, ok := message.Data().(*databasev1.SeriesServiceLookupRequest)
+	if !ok {
+		return bus.NewMessage(bus.MessageID(now), common.NewError("invalid event data type %T", message.Data()))
+	}
+	ss, err := storage.LookupSeries[*tsTable, option](ctx, commonv1.Catalog_CATALOG_STREAM, l.s.schemaRepo, req)
+	if err != nil {
+		return bus.NewMessage(bus.MessageID(now), common.NewError("failed to look up the series: %v", err))
+	}
+	return bus.NewMessage(bus.MessageID(now), &databasev1.SeriesServiceLookupResponse{Series: ss})
+}
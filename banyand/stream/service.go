@@ -22,6 +22,7 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -38,6 +39,7 @@ import (
 	"github.com/apache/skywalking-banyandb/banyand/queue"
 	"github.com/apache/skywalking-banyandb/pkg/bus"
 	"github.com/apache/skywalking-banyandb/pkg/fs"
+	"github.com/apache/skywalking-banyandb/pkg/index/inverted"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
 	"github.com/apache/skywalking-banyandb/pkg/run"
 	resourceSchema "github.com/apache/skywalking-banyandb/pkg/schema"
@@ -56,6 +58,7 @@ type Service interface {
 	run.Config
 	run.Service
 	Query
+	observability.BundleContributor
 }
 
 var _ Service = (*service)(nil)
@@ -68,14 +71,28 @@ type service struct {
 	omr                 observability.MetricsRegistry
 	lfs                 fs.FileSystem
 	pm                  protector.Memory
+	fdp                 protector.FD
+	iop                 protector.IO
 	l                   *logger.Logger
+	replayCache         *storage.ReplayCache
 	schemaRepo          schemaRepo
+	rangeTombstones     sync.Map
 	root                string
 	snapshotDir         string
+	replicaDir          string
 	dataPath            string
 	option              option
+	sizeTiered          *sizeTieredPolicy
+	termLimit           indexTermLimit
 	maxDiskUsagePercent int
 	maxFileSnapshotNum  int
+	replayCacheSize     int
+	replayCacheTTL      time.Duration
+	indexMergeRate      run.Bytes
+	indexMergeWorkers   int
+	replicaMu           sync.Mutex
+	validateRouting     bool
+	segmentWarmup       bool
 }
 
 func (s *service) Stream(metadata *commonv1.Metadata) (Stream, error) {
@@ -94,18 +111,59 @@ func (s *service) GetRemovalSegmentsTimeRange(group string) *timestamp.TimeRange
 	return s.schemaRepo.GetRemovalSegmentsTimeRange(group)
 }
 
+func (s *service) GetSegmentsTimeRanges(group string) []timestamp.TimeRange {
+	return s.schemaRepo.GetSegmentsTimeRanges(group)
+}
+
 func (s *service) FlagSet() *run.FlagSet {
 	flagS := run.NewFlagSet("storage")
 	flagS.StringVar(&s.root, "stream-root-path", "/tmp", "the root path of stream")
 	flagS.StringVar(&s.dataPath, "stream-data-path", "", "the data directory path of stream. If not set, <stream-root-path>/stream/data will be used")
 	flagS.DurationVar(&s.option.flushTimeout, "stream-flush-timeout", defaultFlushTimeout, "the memory data timeout of stream")
 	flagS.DurationVar(&s.option.elementIndexFlushTimeout, "element-index-flush-timeout", defaultFlushTimeout, "the elementIndex timeout of stream")
-	s.option.mergePolicy = newDefaultMergePolicy()
-	flagS.VarP(&s.option.mergePolicy.maxFanOutSize, "stream-max-fan-out-size", "", "the upper bound of a single file size after merge of stream")
+	s.sizeTiered = newDefaultMergePolicy()
+	s.option.mergePolicy = s.sizeTiered
+	flagS.VarP(&s.sizeTiered.maxFanOutSize, "stream-max-fan-out-size", "", "the upper bound of a single file size after merge of stream")
+	flagS.IntVar(&s.sizeTiered.maxParts, "stream-merge-fan-in", defaultMergeFanIn(),
+		"the max number of the parts consumed by a single merge of stream, which defaults to the number of the CPUs between 15 and 30")
+	flagS.IntVar(&s.indexMergeWorkers, "stream-index-merge-concurrency", 0,
+		"the max number of the concurrent merges of the element index segments on a node, which run apart from the merges of the parts. 0 means no limit")
+	flagS.VarP(&s.indexMergeRate, "stream-index-merge-rate", "",
+		"the max bytes per second written by the merges of the element index segments on a node. 0 means no limit")
+	flagS.IntVar(&s.option.flushWorkers, "stream-flush-workers", defaultFlushWorkers(),
+		"the number of the goroutines flushing the in-memory parts of a shard of stream, which defaults to a quarter of the CPUs between 1 and 8")
+	s.option.compressionPolicy = newDefaultCompressionPolicy()
+	flagS.IntVar(&s.option.compressionPolicy.ingestLevel, "stream-compression-level", s.option.compressionPolicy.ingestLevel,
+		"the zstd compression level of the ingested data")
+	flagS.IntVar(&s.option.compressionPolicy.pressureLevel, "stream-pressure-compression-level", s.option.compressionPolicy.pressureLevel,
+		"the zstd compression level of the ingested data under the write pressure")
+	flagS.IntVar(&s.option.compressionPolicy.mergeLevel, "stream-merge-compression-level", s.option.compressionPolicy.mergeLevel,
+		"the zstd compression level of the merged data")
+	flagS.IntVar(&s.option.compressionPolicy.archiveLevel, "stream-archive-compression-level", s.option.compressionPolicy.archiveLevel,
+		"the zstd compression level of the data in the archive groups")
+	flagS.IntVar(&s.option.compressionPolicy.highWatermark, "stream-compression-high-watermark", s.option.compressionPolicy.highWatermark,
+		"the number of the memory parts waiting to be flushed, at which the ingestion switches to the pressure compression level")
+	flagS.IntVar(&s.option.compressionPolicy.lowWatermark, "stream-compression-low-watermark", s.option.compressionPolicy.lowWatermark,
+		"the number of the memory parts waiting to be flushed, at which the ingestion restores the compression level")
+	flagS.IntVar(&s.option.compressionPolicy.dictSize, "stream-merge-dict-size", s.option.compressionPolicy.dictSize,
+		"the size limit in bytes of the zstd dictionary trained per tag family during the merges, 0 disables the dictionaries")
 	s.option.seriesCacheMaxSize = run.Bytes(32 << 20)
 	flagS.VarP(&s.option.seriesCacheMaxSize, "stream-series-cache-max-size", "", "the max size of series cache in each group")
+	flagS.VarP(&s.option.packPartMaxSize, "stream-pack-part-max-size", "",
+		"the max compressed size of the flushed parts which are packed into a single file to save the inodes and the file descriptors, 0 disables the packing")
+	flagS.BoolVar(&s.segmentWarmup, "stream-segment-warmup", true,
+		"pre-read the index term dictionaries and the block metadata of the segments opened from the disk in the background")
 	flagS.IntVar(&s.maxDiskUsagePercent, "stream-max-disk-usage-percent", 95, "the maximum disk usage percentage allowed")
 	flagS.IntVar(&s.maxFileSnapshotNum, "stream-max-file-snapshot-num", 2, "the maximum number of file snapshots allowed")
+	flagS.IntVar(&s.replayCacheSize, "stream-idempotency-cache-size", 10000,
+		"the maximum number of the idempotency keys remembered in each shard, 0 disables the duplicate write detection")
+	flagS.DurationVar(&s.replayCacheTTL, "stream-idempotency-cache-ttl", 5*time.Minute, "the period in which the writes with the same idempotency key are dropped")
+	flagS.BoolVar(&s.validateRouting, "stream-validate-routing", false,
+		"validate the shards of the writes, which is required if the clients write to the data nodes directly by the routing hints")
+	flagS.IntVar(&s.termLimit.maxLength, "stream-index-term-max-length", 0,
+		"the maximum length in bytes of a string or binary term indexed by the inverted rules, 0 means no limit")
+	flagS.StringVar(&s.termLimit.policy, "stream-index-term-policy", indexTermPolicyTruncate,
+		"the policy applied to the terms longer than stream-index-term-max-length: truncate or reject the element")
 	return flagS
 }
 
@@ -119,7 +177,19 @@ func (s *service) Validate() error {
 	if s.maxDiskUsagePercent > 100 {
 		return errors.New("stream-max-disk-usage-percent must be less than or equal to 100")
 	}
-	return nil
+	if s.sizeTiered.maxParts < 2 {
+		return errors.New("stream-merge-fan-in must be greater than or equal to 2")
+	}
+	if s.option.flushWorkers < 1 {
+		return errors.New("stream-flush-workers must be greater than 0")
+	}
+	if s.indexMergeWorkers < 0 || s.indexMergeRate < 0 {
+		return errors.New("stream-index-merge-concurrency and stream-index-merge-rate must be greater than or equal to 0")
+	}
+	if err := s.termLimit.validate(); err != nil {
+		return err
+	}
+	return s.option.compressionPolicy.validate()
 }
 
 func (s *service) Name() string {
@@ -134,8 +204,10 @@ func (s *service) PreRun(ctx context.Context) error {
 	s.l = logger.GetLogger(s.Name())
 	s.l.Info().Msg("memory protector is initialized in PreRun")
 	s.lfs = fs.NewLocalFileSystemWithLoggerAndLimit(s.l, s.pm.GetLimit())
+	s.option.indexMergeScheduler = inverted.NewMergeScheduler(s.indexMergeWorkers, int64(s.indexMergeRate))
 	path := path.Join(s.root, s.Name())
 	s.snapshotDir = filepath.Join(path, storage.SnapshotsDir)
+	s.replicaDir = filepath.Join(path, storage.ReplicasDir)
 	observability.UpdatePath(path)
 	val := ctx.Value(common.ContextNodeKey)
 	if val == nil {
@@ -148,6 +220,7 @@ func (s *service) PreRun(ctx context.Context) error {
 	if !strings.HasPrefix(filepath.VolumeName(s.dataPath), filepath.VolumeName(path)) {
 		observability.UpdatePath(s.dataPath)
 	}
+	s.replayCache = storage.NewReplayCache(s.replayCacheSize, s.replayCacheTTL)
 	s.schemaRepo = newSchemaRepo(s.dataPath, s, node.Labels)
 	if s.pipeline == nil {
 		return nil
@@ -157,10 +230,40 @@ func (s *service) PreRun(ctx context.Context) error {
 	if err := s.pipeline.Subscribe(data.TopicSnapshot, &snapshotListener{s: s}); err != nil {
 		return err
 	}
+	if err := s.pipeline.Subscribe(data.TopicSnapshotDir, &snapshotDirListener{s: s}); err != nil {
+		return err
+	}
+	if err := s.pipeline.Subscribe(data.TopicWarmup, &warmupListener{s: s}); err != nil {
+		return err
+	}
+	if err := s.pipeline.Subscribe(data.TopicRetentionPreview, &retentionListener{s: s}); err != nil {
+		return err
+	}
+	if err := s.pipeline.Subscribe(data.TopicRetentionTrigger, &retentionListener{s: s, trigger: true}); err != nil {
+		return err
+	}
+	if err := s.pipeline.Subscribe(data.TopicStorageUsage, &usageListener{s: s}); err != nil {
+		return err
+	}
+	if err := s.pipeline.Subscribe(data.TopicShardDigest, &digestListener{s: s}); err != nil {
+		return err
+	}
+	if err := s.pipeline.Subscribe(data.TopicStreamSeriesLookup, &seriesLookupListener{s: s}); err != nil {
+		return err
+	}
+	if err := s.pipeline.Subscribe(data.TopicStreamDeleteRange, &deleteRangeListener{s: s}); err != nil {
+		return err
+	}
+	replica := &replicaListener{s: s}
+	for _, topic := range []bus.Topic{data.TopicReplicaDir, data.TopicReplicaStatus, data.TopicReplicaSeries, data.TopicReplicaCommit} {
+		if err := s.pipeline.Subscribe(topic, replica); err != nil {
+			return err
+		}
+	}
 	if err := s.pipeline.Subscribe(data.TopicDeleteExpiredStreamSegments, &deleteStreamSegmentsListener{s: s}); err != nil {
 		return err
 	}
-	s.writeListener = setUpWriteCallback(s.l, &s.schemaRepo, s.maxDiskUsagePercent)
+	s.writeListener = setUpWriteCallback(s.l, &s.schemaRepo, s.maxDiskUsagePercent, s.replayCache, s.validateRouting, s.termLimit)
 	err := s.pipeline.Subscribe(data.TopicStreamWrite, s.writeListener)
 	if err != nil {
 		return err
@@ -173,19 +276,29 @@ func (s *service) Serve() run.StopNotify {
 }
 
 func (s *service) GracefulStop() {
-	s.schemaRepo.Close()
+	// stop the local pipeline first to drain the in-flight writes before closing the tables.
 	if s.localPipeline != nil {
 		s.localPipeline.GracefulStop()
 	}
+	s.schemaRepo.Close()
+}
+
+// ShutdownPhase implements run.PhasedService.
+func (s *service) ShutdownPhase() run.ShutdownPhase {
+	return run.ShutdownPhaseStorage
 }
 
 // NewService returns a new service.
-func NewService(metadata metadata.Repo, pipeline queue.Server, omr observability.MetricsRegistry, pm protector.Memory) (Service, error) {
+func NewService(metadata metadata.Repo, pipeline queue.Server, omr observability.MetricsRegistry,
+	pm protector.Memory, fdp protector.FD, iop protector.IO,
+) (Service, error) {
 	return &service{
 		metadata: metadata,
 		pipeline: pipeline,
 		omr:      omr,
 		pm:       pm,
+		fdp:      fdp,
+		iop:      iop,
 	}, nil
 }
 
@@ -214,6 +327,10 @@ func (d *deleteStreamSegmentsListener) Rev(_ context.Context, message bus.Messag
 		d.s.l.Error().Err(err).Str("group", req.Group).Msg("failed to load tsdb")
 		return bus.NewMessage(bus.MessageID(time.Now().UnixNano()), int64(0))
 	}
-	deleted := db.DeleteExpiredSegments(timestamp.NewSectionTimeRange(req.TimeRange.Begin.AsTime(), req.TimeRange.End.AsTime()))
+	timeRange := timestamp.NewSectionTimeRange(req.TimeRange.Begin.AsTime(), req.TimeRange.End.AsTime())
+	if req.Archived {
+		return bus.NewMessage(bus.MessageID(time.Now().UnixNano()), db.DeleteSegments(timeRange))
+	}
+	deleted := db.DeleteExpiredSegments(timeRange)
 	return bus.NewMessage(bus.MessageID(time.Now().UnixNano()), deleted)
 }
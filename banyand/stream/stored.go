@@ -0,0 +1,164 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"context"
+
+	"github.com/blugelabs/bluge/numeric"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/index"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query/model"
+)
+
+// storedTag is a projected indexed-only tag whose values are stored by its index rule.
+type storedTag struct {
+	spec   *databasev1.TagSpec
+	family string
+	key    index.FieldKey
+}
+
+// storedTags returns the projected indexed-only tags indexed by the inverted index rules storing the values.
+func (s *stream) storedTags(projection []model.TagProjection) []storedTag {
+	is := s.loadIndexSchema()
+	var tags []storedTag
+	for _, tp := range projection {
+		for _, name := range tp.Names {
+			spec := is.tagMap[name]
+			if spec == nil || !spec.GetIndexedOnly() {
+				continue
+			}
+			for _, r := range is.indexRules {
+				if !r.GetStoreValue() || r.GetType() != databasev1.IndexRule_TYPE_INVERTED ||
+					len(r.GetTags()) != 1 || r.GetTags()[0] != name {
+					continue
+				}
+				tags = append(tags, storedTag{
+					spec:   spec,
+					family: tp.Family,
+					key: index.FieldKey{
+						IndexRuleID: r.GetMetadata().GetId(),
+						Analyzer:    r.GetAnalyzer(),
+					},
+				})
+				break
+			}
+		}
+	}
+	return tags
+}
+
+// loadStoredTags fills the values of the stored tags in r from the element indices of the tables.
+func (s *stream) loadStoredTags(ctx context.Context, tabs []*tsTable, projection []model.TagProjection, r *model.StreamResult) error {
+	if r == nil || len(r.ElementIDs) == 0 {
+		return nil
+	}
+	tags := s.storedTags(projection)
+	if len(tags) == 0 {
+		return nil
+	}
+	keys := make([]index.FieldKey, len(tags))
+	values := make([][]*modelv1.TagValue, len(tags))
+	for i := range tags {
+		keys[i] = tags[i].key
+		values[i] = resultTagValues(r, tags[i].family, tags[i].spec.GetName())
+	}
+	positions := make(map[uint64]int, len(r.ElementIDs))
+	for i, id := range r.ElementIDs {
+		positions[id] = i
+	}
+	for _, tab := range tabs {
+		if len(positions) == 0 {
+			return nil
+		}
+		ids := make([]uint64, 0, len(positions))
+		for id := range positions {
+			ids = append(ids, id)
+		}
+		stored, err := tab.Index().Values(ctx, ids, keys)
+		if err != nil {
+			return err
+		}
+		for id, sf := range stored {
+			pos, ok := positions[id]
+			if !ok {
+				continue
+			}
+			delete(positions, id)
+			for i := range tags {
+				if len(values[i]) > pos {
+					values[i][pos] = decodeStoredValue(tags[i].spec.GetType(), sf[i])
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func resultTagValues(r *model.StreamResult, family, name string) []*modelv1.TagValue {
+	for i := range r.TagFamilies {
+		if r.TagFamilies[i].Name != family {
+			continue
+		}
+		for j := range r.TagFamilies[i].Tags {
+			if r.TagFamilies[i].Tags[j].Name == name {
+				return r.TagFamilies[i].Tags[j].Values
+			}
+		}
+	}
+	return nil
+}
+
+func decodeStoredValue(tagType databasev1.TagType, values [][]byte) *modelv1.TagValue {
+	if len(values) == 0 {
+		return pbv1.NullTagValue
+	}
+	switch tagType {
+	case databasev1.TagType_TAG_TYPE_STRING:
+		return &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: string(values[0])}}}
+	case databasev1.TagType_TAG_TYPE_INT:
+		n, err := numeric.PrefixCoded(values[0]).Int64()
+		if err != nil {
+			return pbv1.NullTagValue
+		}
+		return &modelv1.TagValue{Value: &modelv1.TagValue_Int{Int: &modelv1.Int{Value: n}}}
+	case databasev1.TagType_TAG_TYPE_DATA_BINARY:
+		return &modelv1.TagValue{Value: &modelv1.TagValue_BinaryData{BinaryData: values[0]}}
+	case databasev1.TagType_TAG_TYPE_STRING_ARRAY:
+		arr := make([]string, 0, len(values))
+		for _, v := range values {
+			arr = append(arr, string(v))
+		}
+		return &modelv1.TagValue{Value: &modelv1.TagValue_StrArray{StrArray: &modelv1.StrArray{Value: arr}}}
+	case databasev1.TagType_TAG_TYPE_INT_ARRAY:
+		arr := make([]int64, 0, len(values))
+		for _, v := range values {
+			n, err := numeric.PrefixCoded(v).Int64()
+			if err != nil {
+				return pbv1.NullTagValue
+			}
+			arr = append(arr, n)
+		}
+		return &modelv1.TagValue{Value: &modelv1.TagValue_IntArray{IntArray: &modelv1.IntArray{Value: arr}}}
+	default:
+		return pbv1.NullTagValue
+	}
+}
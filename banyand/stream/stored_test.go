@@ -0,0 +1,100 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/banyand/protector"
+	"github.com/apache/skywalking-banyandb/pkg/fs"
+	"github.com/apache/skywalking-banyandb/pkg/index"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query/model"
+	"github.com/apache/skywalking-banyandb/pkg/test"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+func TestLoadStoredTags(t *testing.T) {
+	tmpPath, defFn := test.Space(require.New(t))
+	defer defFn()
+	opt := option{flushTimeout: time.Hour, mergePolicy: newDefaultMergePolicyForTesting(), protector: protector.Nop{}}
+	tst, err := newTSTable(fs.NewLocalFileSystem(), tmpPath, common.Position{}, logger.GetLogger("test"), timestamp.TimeRange{}, opt, nil)
+	require.NoError(t, err)
+	defer tst.Close()
+
+	s := &stream{schema: &databasev1.Stream{
+		Entity: &databasev1.Entity{TagNames: []string{"service"}},
+		TagFamilies: []*databasev1.TagFamilySpec{{
+			Name: "searchable",
+			Tags: []*databasev1.TagSpec{
+				{Name: "service", Type: databasev1.TagType_TAG_TYPE_STRING},
+				{Name: "endpoint", Type: databasev1.TagType_TAG_TYPE_STRING, IndexedOnly: true},
+				{Name: "codes", Type: databasev1.TagType_TAG_TYPE_INT_ARRAY, IndexedOnly: true},
+				{Name: "peer", Type: databasev1.TagType_TAG_TYPE_STRING, IndexedOnly: true},
+			},
+		}},
+	}}
+	rules := []*databasev1.IndexRule{
+		{Metadata: &commonv1.Metadata{Id: 1}, Tags: []string{"endpoint"}, Type: databasev1.IndexRule_TYPE_INVERTED, StoreValue: true},
+		{Metadata: &commonv1.Metadata{Id: 2}, Tags: []string{"codes"}, Type: databasev1.IndexRule_TYPE_INVERTED, StoreValue: true},
+		{Metadata: &commonv1.Metadata{Id: 3}, Tags: []string{"peer"}, Type: databasev1.IndexRule_TYPE_INVERTED},
+	}
+	s.OnIndexUpdate(rules)
+
+	strValue := func(v string) *modelv1.TagValue {
+		return &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: v}}}
+	}
+	codesValue := &modelv1.TagValue{Value: &modelv1.TagValue_IntArray{IntArray: &modelv1.IntArray{Value: []int64{-1, 200}}}}
+	var fields []index.Field
+	fields = appendField(fields, index.FieldKey{IndexRuleID: 1, SeriesID: 1}, databasev1.TagType_TAG_TYPE_STRING, strValue("/home"), rules[0], 0)
+	fields = appendField(fields, index.FieldKey{IndexRuleID: 2, SeriesID: 1}, databasev1.TagType_TAG_TYPE_INT_ARRAY, codesValue, rules[1], 0)
+	fields = appendField(fields, index.FieldKey{IndexRuleID: 3, SeriesID: 1}, databasev1.TagType_TAG_TYPE_STRING, strValue("db"), rules[2], 0)
+	require.NoError(t, tst.Index().Write(index.Documents{
+		{DocID: 10, Fields: fields, Timestamp: time.Now().UnixNano()},
+	}))
+
+	r := &model.StreamResult{
+		ElementIDs: []uint64{11, 10},
+		TagFamilies: []model.TagFamily{{
+			Name: "searchable",
+			Tags: []model.Tag{
+				{Name: "endpoint", Values: []*modelv1.TagValue{pbv1.NullTagValue, pbv1.NullTagValue}},
+				{Name: "codes", Values: []*modelv1.TagValue{pbv1.NullTagValue, pbv1.NullTagValue}},
+				{Name: "peer", Values: []*modelv1.TagValue{pbv1.NullTagValue, pbv1.NullTagValue}},
+			},
+		}},
+	}
+	projection := []model.TagProjection{{Family: "searchable", Names: []string{"endpoint", "codes", "peer"}}}
+	require.NoError(t, s.loadStoredTags(context.TODO(), []*tsTable{tst}, projection, r))
+	tags := r.TagFamilies[0].Tags
+	// the element 11 is absent from the index.
+	assert.Equal(t, []*modelv1.TagValue{pbv1.NullTagValue, strValue("/home")}, tags[0].Values)
+	assert.Equal(t, []*modelv1.TagValue{pbv1.NullTagValue, codesValue}, tags[1].Values)
+	// the rule of peer doesn't store the values.
+	assert.Equal(t, []*modelv1.TagValue{pbv1.NullTagValue, pbv1.NullTagValue}, tags[2].Values)
+}
@@ -26,7 +26,10 @@ import (
 
 	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
 	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
 	"github.com/apache/skywalking-banyandb/banyand/protector"
+	"github.com/apache/skywalking-banyandb/pkg/index"
+	"github.com/apache/skywalking-banyandb/pkg/index/inverted"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
 	"github.com/apache/skywalking-banyandb/pkg/partition"
 	"github.com/apache/skywalking-banyandb/pkg/query/model"
@@ -46,11 +49,17 @@ const (
 )
 
 type option struct {
-	mergePolicy              *mergePolicy
+	mergePolicy              mergePolicy
+	compressionPolicy        *compressionPolicy
+	rangeTombstones          *storage.RangeTombstones
 	protector                protector.Memory
 	seriesCacheMaxSize       run.Bytes
+	packPartMaxSize          run.Bytes
 	flushTimeout             time.Duration
 	elementIndexFlushTimeout time.Duration
+	flushWorkers             int
+	// indexMergeScheduler bounds the merges of the element indexes apart from the merges of the parts.
+	indexMergeScheduler *inverted.MergeScheduler
 }
 
 // Query allow to retrieve elements in a series of streams.
@@ -58,6 +67,7 @@ type Query interface {
 	LoadGroup(name string) (schema.Group, bool)
 	Stream(stream *commonv1.Metadata) (Stream, error)
 	GetRemovalSegmentsTimeRange(group string) *timestamp.TimeRange
+	GetSegmentsTimeRanges(group string) []timestamp.TimeRange
 }
 
 // Stream allows inspecting elements' details.
@@ -65,15 +75,22 @@ type Stream interface {
 	GetSchema() *databasev1.Stream
 	GetIndexRules() []*databasev1.IndexRule
 	Query(ctx context.Context, opts model.StreamQueryOptions) (model.StreamQueryResult, error)
+	Facets(ctx context.Context, opts model.StreamFacetOptions) ([]index.Facet, error)
 }
 
+// indexSchema is an immutable snapshot of the stream schema and its index rules.
+// Writes and queries load it once and use it for their whole duration,
+// so the tag families and the rule locators always come from the same epoch.
 type indexSchema struct {
+	schema            *databasev1.Stream
 	tagMap            map[string]*databasev1.TagSpec
 	indexRuleLocators partition.IndexRuleLocator
 	indexRules        []*databasev1.IndexRule
+	epoch             uint64
 }
 
 func (i *indexSchema) parse(schema *databasev1.Stream) {
+	i.schema = schema
 	i.indexRuleLocators, _ = partition.ParseIndexRuleLocators(schema.GetEntity(), schema.GetTagFamilies(), i.indexRules, false)
 	i.tagMap = make(map[string]*databasev1.TagSpec)
 	for _, tf := range schema.GetTagFamilies() {
@@ -86,7 +103,7 @@ func (i *indexSchema) parse(schema *databasev1.Stream) {
 var _ Stream = (*stream)(nil)
 
 type stream struct {
-	indexSchema atomic.Value
+	indexSchema atomic.Pointer[indexSchema]
 	tsdb        atomic.Value
 	l           *logger.Logger
 	schema      *databasev1.Stream
@@ -94,6 +111,7 @@ type stream struct {
 	schemaRepo  *schemaRepo
 	name        string
 	group       string
+	epoch       atomic.Uint64
 }
 
 func (s *stream) GetSchema() *databasev1.Stream {
@@ -105,21 +123,40 @@ func (s *stream) GetIndexRules() []*databasev1.IndexRule {
 	if is == nil {
 		return nil
 	}
-	return is.(indexSchema).indexRules
+	return is.indexRules
+}
+
+// loadIndexSchema pins the current schema epoch.
+func (s *stream) loadIndexSchema() *indexSchema {
+	return s.indexSchema.Load()
+}
+
+// storeIndexSchema publishes a new epoch unless a later one has been published by a concurrent update.
+func (s *stream) storeIndexSchema(is *indexSchema) {
+	for {
+		cur := s.indexSchema.Load()
+		if cur != nil && cur.epoch > is.epoch {
+			return
+		}
+		if s.indexSchema.CompareAndSwap(cur, is) {
+			return
+		}
+	}
 }
 
 func (s *stream) OnIndexUpdate(index []*databasev1.IndexRule) {
-	var is indexSchema
-	is.indexRules = index
+	is := &indexSchema{indexRules: index}
 	is.parse(s.schema)
-	s.indexSchema.Store(is)
+	is.epoch = s.epoch.Add(1)
+	s.storeIndexSchema(is)
 }
 
 func (s *stream) parseSpec() {
 	s.name, s.group = s.schema.GetMetadata().GetName(), s.schema.GetMetadata().GetGroup()
-	var is indexSchema
+	is := &indexSchema{}
 	is.parse(s.schema)
-	s.indexSchema.Store(is)
+	is.epoch = s.epoch.Add(1)
+	s.storeIndexSchema(is)
 }
 
 type streamSpec struct {
@@ -0,0 +1,74 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+)
+
+func TestIndexSchemaEpoch(t *testing.T) {
+	s := &stream{schema: &databasev1.Stream{
+		Metadata: &commonv1.Metadata{Name: "sw", Group: "default"},
+		Entity:   &databasev1.Entity{TagNames: []string{"service"}},
+		TagFamilies: []*databasev1.TagFamilySpec{
+			{Name: "searchable", Tags: []*databasev1.TagSpec{{Name: "service", Type: databasev1.TagType_TAG_TYPE_STRING}}},
+			{Name: "data", Tags: []*databasev1.TagSpec{{Name: "payload", Type: databasev1.TagType_TAG_TYPE_DATA_BINARY}}},
+		},
+	}}
+	s.parseSpec()
+	first := s.loadIndexSchema()
+	require.Equal(t, uint64(1), first.epoch)
+
+	rules := []*databasev1.IndexRule{{Metadata: &commonv1.Metadata{Id: 1}, Tags: []string{"service"}, Type: databasev1.IndexRule_TYPE_INVERTED}}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				s.OnIndexUpdate(rules)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			var last uint64
+			for j := 0; j < 100; j++ {
+				is := s.loadIndexSchema()
+				assert.Len(t, is.indexRuleLocators.TagFamilyTRule, len(is.schema.GetTagFamilies()))
+				assert.GreaterOrEqual(t, is.epoch, last)
+				last = is.epoch
+			}
+		}()
+	}
+	wg.Wait()
+
+	is := s.loadIndexSchema()
+	assert.Equal(t, uint64(801), is.epoch)
+	assert.Equal(t, rules, s.GetIndexRules())
+	assert.Empty(t, first.indexRules, "a pinned epoch must not change")
+
+	s.storeIndexSchema(&indexSchema{epoch: is.epoch - 1})
+	assert.Same(t, is, s.loadIndexSchema(), "an older epoch must not replace a newer one")
+}
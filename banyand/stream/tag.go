@@ -18,7 +18,9 @@
 package stream
 
 import (
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
 	"github.com/apache/skywalking-banyandb/pkg/bytes"
+	"github.com/apache/skywalking-banyandb/pkg/compress/zstd"
 	"github.com/apache/skywalking-banyandb/pkg/convert"
 	"github.com/apache/skywalking-banyandb/pkg/encoding"
 	"github.com/apache/skywalking-banyandb/pkg/fs"
@@ -65,15 +67,29 @@ func releaseFloat64Slice(float64Slice *[]float64) {
 	float64SlicePool.Put(float64Slice)
 }
 
+func generateDictionary() *encoding.Dictionary {
+	v := dictionaryPool.Get()
+	if v == nil {
+		return encoding.NewDictionary()
+	}
+	return v
+}
+
+func releaseDictionary(d *encoding.Dictionary) {
+	d.Reset()
+	dictionaryPool.Put(d)
+}
+
 var (
 	int64SlicePool   = pool.Register[*[]int64]("stream-int64Slice")
 	float64SlicePool = pool.Register[*[]float64]("stream-float64Slice")
+	dictionaryPool   = pool.Register[*encoding.Dictionary]("stream-dictionary")
 )
 
 type tag struct {
+	name   string
+	values [][]byte
 	tagFilter
-	name      string
-	values    [][]byte
 	valueType pbv1.ValueType
 }
 
@@ -99,7 +115,7 @@ func (t *tag) resizeValues(valuesLen int) [][]byte {
 	return values
 }
 
-func (t *tag) mustWriteTo(tm *tagMetadata, tagWriter *writer, tagFilterWriter *writer) {
+func (t *tag) mustWriteTo(tm *tagMetadata, tagWriter *writer, tagFilterWriter *writer, compressionLevel int, dict *zstd.Dict) {
 	tm.reset()
 
 	tm.name = t.name
@@ -111,11 +127,13 @@ func (t *tag) mustWriteTo(tm *tagMetadata, tagWriter *writer, tagFilterWriter *w
 	// select encoding based on data type
 	switch t.valueType {
 	case pbv1.ValueTypeInt64:
-		t.encodeInt64Tag(bb)
+		t.encodeInt64Tag(bb, compressionLevel)
 	case pbv1.ValueTypeFloat64:
-		t.encodeFloat64Tag(bb)
+		t.encodeFloat64Tag(bb, compressionLevel)
+	case pbv1.ValueTypeStr:
+		t.encodeStrTag(bb, compressionLevel, dict)
 	default:
-		t.encodeDefault(bb)
+		t.encodeDefault(bb, compressionLevel, dict)
 	}
 	tm.size = uint64(len(bb.Buf))
 	if tm.size > maxValuesBlockSize {
@@ -123,21 +141,45 @@ func (t *tag) mustWriteTo(tm *tagMetadata, tagWriter *writer, tagFilterWriter *w
 	}
 	tm.offset = tagWriter.bytesWritten
 	tagWriter.MustWrite(bb.Buf)
+	t.collectStats(tm)
 
 	if t.filter != nil {
 		bb.Reset()
 		bb.Buf = encodeBloomFilter(bb.Buf[:0], t.filter)
-		if tm.valueType == pbv1.ValueTypeInt64 {
-			tm.min = t.min
-			tm.max = t.max
-		}
 		tm.filterBlock.size = uint64(len(bb.Buf))
 		tm.filterBlock.offset = tagFilterWriter.bytesWritten
 		tagFilterWriter.MustWrite(bb.Buf)
 	}
 }
 
-func (t *tag) encodeInt64Tag(bb *bytes.Buffer) {
+// collectStats collects the statistics of the values, which prune the blocks even if the tag isn't indexed.
+func (t *tag) collectStats(tm *tagMetadata) {
+	var minValue, maxValue int64
+	for _, v := range t.values {
+		if v == nil {
+			tm.nullCount++
+			continue
+		}
+		if t.valueType != pbv1.ValueTypeInt64 {
+			continue
+		}
+		if len(v) != 8 {
+			tm.nullCount++
+			continue
+		}
+		n := convert.BytesToInt64(v)
+		if len(tm.min) == 0 || n < minValue {
+			minValue = n
+			tm.min = v
+		}
+		if len(tm.max) == 0 || n > maxValue {
+			maxValue = n
+			tm.max = v
+		}
+	}
+}
+
+func (t *tag) encodeInt64Tag(bb *bytes.Buffer, compressionLevel int) {
 	// convert byte array to int64 array
 	intValuesPtr := generateInt64Slice(len(t.values))
 	intValues := *intValuesPtr
@@ -146,7 +188,7 @@ func (t *tag) encodeInt64Tag(bb *bytes.Buffer) {
 
 	for i, v := range t.values {
 		if v == nil || string(v) == "null" {
-			t.encodeDefault(bb)
+			t.encodeDefault(bb, compressionLevel, nil)
 			encodeType = encoding.EncodeTypePlain
 			// Prepend encodeType (1 byte) to the beginning
 			bb.Buf = append([]byte{byte(encodeType)}, bb.Buf...)
@@ -171,7 +213,7 @@ func (t *tag) encodeInt64Tag(bb *bytes.Buffer) {
 	)
 }
 
-func (t *tag) encodeFloat64Tag(bb *bytes.Buffer) {
+func (t *tag) encodeFloat64Tag(bb *bytes.Buffer, compressionLevel int) {
 	// convert byte array to float64 array
 	intValuesPtr := generateInt64Slice(len(t.values))
 	intValues := *intValuesPtr
@@ -184,7 +226,7 @@ func (t *tag) encodeFloat64Tag(bb *bytes.Buffer) {
 	var encodeType encoding.EncodeType
 
 	doEncodeDefault := func() {
-		t.encodeDefault(bb)
+		t.encodeDefault(bb, compressionLevel, nil)
 		encodeType = encoding.EncodeTypePlain
 		// Prepend encodeType (1 byte) to the beginning
 		bb.Buf = append([]byte{byte(encodeType)}, bb.Buf...)
@@ -220,8 +262,24 @@ func (t *tag) encodeFloat64Tag(bb *bytes.Buffer) {
 	)
 }
 
-func (t *tag) encodeDefault(bb *bytes.Buffer) {
-	bb.Buf = encoding.EncodeBytesBlock(bb.Buf[:0], t.values)
+func (t *tag) encodeStrTag(bb *bytes.Buffer, compressionLevel int, zstdDict *zstd.Dict) {
+	// use dictionary encoding if the block has a few unique values
+	dict := generateDictionary()
+	defer releaseDictionary(dict)
+	for _, v := range t.values {
+		if !dict.Add(v) {
+			t.encodeDefault(bb, compressionLevel, zstdDict)
+			// Prepend encodeType (1 byte) to the beginning
+			bb.Buf = append([]byte{byte(encoding.EncodeTypePlain)}, bb.Buf...)
+			return
+		}
+	}
+	bb.Buf = append(bb.Buf[:0], byte(encoding.EncodeTypeDictionary))
+	bb.Buf = dict.EncodeWithDict(bb.Buf, nil, compressionLevel, zstdDict)
+}
+
+func (t *tag) encodeDefault(bb *bytes.Buffer, compressionLevel int, dict *zstd.Dict) {
+	bb.Buf = encoding.EncodeBytesBlockWithDict(bb.Buf[:0], t.values, compressionLevel, dict)
 }
 
 func (t *tag) mustReadValues(decoder *encoding.BytesBlockDecoder, reader fs.Reader, cm tagMetadata, count uint64) {
@@ -270,11 +328,37 @@ func (t *tag) decodeTagValues(decoder *encoding.BytesBlockDecoder, path string,
 		t.decodeInt64Tag(decoder, path, count, bb)
 	case pbv1.ValueTypeFloat64:
 		t.decodeFloat64Tag(decoder, path, count, bb)
+	case pbv1.ValueTypeStr:
+		t.decodeStrTag(decoder, path, count, bb)
 	default:
 		t.decodeDefault(decoder, bb, count, path)
 	}
 }
 
+func (t *tag) decodeStrTag(decoder *encoding.BytesBlockDecoder, path string, count uint64, bb *bytes.Buffer) {
+	if len(bb.Buf) < 1 {
+		logger.Panicf("bb.Buf length too short: expect at least %d bytes, but got %d bytes", 1, len(bb.Buf))
+	}
+	switch encoding.EncodeType(bb.Buf[0]) {
+	case encoding.EncodeTypeDictionary:
+		dict := generateDictionary()
+		defer releaseDictionary(dict)
+		if err := dict.Decode(bb.Buf[1:], nil); err != nil {
+			logger.Panicf("%s: cannot decode dictionary: %v", path, err)
+		}
+		t.values = dict.Values(t.values[:0])
+		if uint64(len(t.values)) != count {
+			logger.Panicf("%s: unexpected values length: got %d, expected %d", path, len(t.values), count)
+		}
+	case encoding.EncodeTypePlain:
+		bb.Buf = bb.Buf[1:]
+		t.decodeDefault(decoder, bb, count, path)
+	default:
+		// the legacy blocks aren't prefixed by the encode type, and start with the compress type instead.
+		t.decodeDefault(decoder, bb, count, path)
+	}
+}
+
 func (t *tag) decodeInt64Tag(decoder *encoding.BytesBlockDecoder, path string, count uint64, bb *bytes.Buffer) {
 	// decode integer type
 	intValuesPtr := generateInt64Slice(int(count))
@@ -379,6 +463,15 @@ func (tf *tagFamily) reset() {
 	tf.tags = tags[:0]
 }
 
+// keepRows compacts the tags holding all the n rows to the kept rows.
+func (tf *tagFamily) keepRows(kept []int, n int) {
+	for i := range tf.tags {
+		if len(tf.tags[i].values) == n {
+			tf.tags[i].values = storage.KeepRows(tf.tags[i].values, kept)
+		}
+	}
+}
+
 func (tf *tagFamily) resizeTags(tagsLen int) []tag {
 	tags := tf.tags
 	if n := tagsLen - cap(tags); n > 0 {
@@ -0,0 +1,151 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	pkgbytes "github.com/apache/skywalking-banyandb/pkg/bytes"
+	"github.com/apache/skywalking-banyandb/pkg/compress/zstd"
+	"github.com/apache/skywalking-banyandb/pkg/encoding"
+	"github.com/apache/skywalking-banyandb/pkg/fs"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+)
+
+const (
+	// maxTagFamilyDictSampleSize is the size limit of the values sampled to train the dictionary of a tag family.
+	maxTagFamilyDictSampleSize = 256 << 10
+	// dictSampledBlocksPerPart is the number of the blocks sampled from a part, which are spread over the part.
+	dictSampledBlocksPerPart = 16
+)
+
+// sampleTagFamilies samples the string and binary values of the tag families from the parts to be merged.
+// Every part contributes a share of the samples, so the dictionaries fit the data of all of them.
+func sampleTagFamilies(parts []*partWrapper) (map[string][][]byte, error) {
+	samples := make(map[string][][]byte)
+	budget := maxTagFamilyDictSampleSize / len(parts)
+	decoder := generateColumnValuesDecoder()
+	defer releaseColumnValuesDecoder(decoder)
+	pi := &partIter{}
+	var bms []blockMetadata
+	for _, pw := range parts {
+		p := pw.p
+		pi.p = p
+		stride := p.partMetadata.BlocksCount / dictSampledBlocksPerPart
+		if stride < 1 {
+			stride = 1
+		}
+		sizes := make(map[string]int)
+		var n uint64
+		for i := range p.primaryBlockMetadata {
+			var err error
+			if bms, err = pi.readPrimaryBlock(bms[:0], &p.primaryBlockMetadata[i]); err != nil {
+				return nil, fmt.Errorf("cannot sample the part %d: %w", pw.ID(), err)
+			}
+			for j := range bms {
+				n++
+				if (n-1)%stride != 0 {
+					continue
+				}
+				for name, db := range bms[j].tagFamilies {
+					if sizes[name] >= budget {
+						continue
+					}
+					var size int
+					samples[name], size = sampleTagFamily(samples[name], decoder, p, name, db, bms[j].count)
+					sizes[name] += size
+				}
+			}
+		}
+	}
+	return samples, nil
+}
+
+func sampleTagFamily(dst [][]byte, decoder *encoding.BytesBlockDecoder, p *part, name string, db *dataBlock, count uint64) ([][]byte, int) {
+	metaReader := p.tagFamilyMetadata[name]
+	bb := bigValuePool.Generate()
+	defer bigValuePool.Release(bb)
+	bb.Buf = pkgbytes.ResizeExact(bb.Buf, int(db.size))
+	fs.MustReadData(metaReader, int64(db.offset), bb.Buf)
+	tfm := generateTagFamilyMetadata()
+	defer releaseTagFamilyMetadata(tfm)
+	if err := tfm.unmarshal(bb.Buf); err != nil {
+		logger.Panicf("%s: cannot unmarshal tagFamilyMetadata: %v", metaReader.Path(), err)
+	}
+
+	var size int
+	var t tag
+	distinct := make(map[string]struct{})
+	for i := range tfm.tagMetadata {
+		switch tfm.tagMetadata[i].valueType {
+		case pbv1.ValueTypeStr, pbv1.ValueTypeBinaryData, pbv1.ValueTypeStrArr:
+		default:
+			continue
+		}
+		decoder.Reset()
+		t.reset()
+		t.mustReadValues(decoder, p.tagFamilies[name], tfm.tagMetadata[i], count)
+		// the values repeated in a block are compressed well without the dictionary,
+		// so a block contributes every distinct value once.
+		clear(distinct)
+		for _, v := range t.values {
+			if len(v) == 0 {
+				continue
+			}
+			if _, ok := distinct[string(v)]; ok {
+				continue
+			}
+			distinct[string(v)] = struct{}{}
+			dst = append(dst, bytes.Clone(v))
+			size += len(v)
+		}
+	}
+	return dst, size
+}
+
+// mustWriteTagFamilyDicts trains the dictionaries of the tag families from the samples, and writes them into the part at the path.
+// The tag families having too little in common are left without a dictionary.
+func mustWriteTagFamilyDicts(fileSystem fs.FileSystem, path string, samples map[string][][]byte, dictSize int) map[string]*zstd.Dict {
+	var dicts map[string]*zstd.Dict
+	for name, ss := range samples {
+		content := zstd.TrainDict(ss, dictSize)
+		if content == nil {
+			continue
+		}
+		fs.MustFlush(fileSystem, content, filepath.Join(path, name+tagFamiliesDictFilenameExt), storage.FilePerm)
+		d, err := zstd.LoadDict(content)
+		if err != nil {
+			logger.Panicf("cannot load the dictionary of the tag family %s: %s", name, err)
+		}
+		if dicts == nil {
+			dicts = make(map[string]*zstd.Dict)
+		}
+		dicts[name] = d
+	}
+	return dicts
+}
+
+func releaseTagFamilyDicts(dicts map[string]*zstd.Dict) {
+	for _, d := range dicts {
+		d.Release()
+	}
+}
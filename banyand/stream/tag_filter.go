@@ -21,7 +21,10 @@ import (
 	"bytes"
 	"fmt"
 
+	"github.com/blugelabs/bluge/numeric"
+
 	pkgbytes "github.com/apache/skywalking-banyandb/pkg/bytes"
+	"github.com/apache/skywalking-banyandb/pkg/convert"
 	"github.com/apache/skywalking-banyandb/pkg/encoding"
 	"github.com/apache/skywalking-banyandb/pkg/filter"
 	"github.com/apache/skywalking-banyandb/pkg/fs"
@@ -68,15 +71,69 @@ func releaseBloomFilter(bf *filter.BloomFilter) {
 var bloomFilterPool = pool.Register[*filter.BloomFilter]("stream-bloomFilter")
 
 type tagFilter struct {
-	filter *filter.BloomFilter
-	min    []byte
-	max    []byte
+	filter    *filter.BloomFilter
+	dict      *dictionaryFilter
+	min       []byte
+	max       []byte
+	nullCount uint64
 }
 
 func (tf *tagFilter) reset() {
 	tf.filter = nil
+	tf.dict = nil
 	tf.min = tf.min[:0]
 	tf.max = tf.max[:0]
+	tf.nullCount = 0
+}
+
+// dictionaryFilter evaluates the equality predicates of a string tag without any bloom filter.
+// The dictionary of the block is loaded lazily from the values.
+type dictionaryFilter struct {
+	valueReader fs.Reader
+	dict        *encoding.Dictionary
+	valueBlock  dataBlock
+	loaded      bool
+}
+
+// mightContain looks up the value in the dictionary of the block.
+// The value is absent if it has no code in the dictionary, so the block could be skipped.
+// It returns true if the values aren't dictionary-encoded.
+func (df *dictionaryFilter) mightContain(value string) bool {
+	if !df.loaded {
+		df.loaded = true
+		df.dict = loadDictionary(df.valueReader, &df.valueBlock)
+	}
+	if df.dict == nil {
+		return true
+	}
+	return df.dict.Contains(convert.StringToBytes(value))
+}
+
+func (df *dictionaryFilter) release() {
+	if df.dict != nil {
+		releaseDictionary(df.dict)
+	}
+}
+
+func loadDictionary(reader fs.Reader, valueBlock *dataBlock) *encoding.Dictionary {
+	if valueBlock.size < 1 {
+		return nil
+	}
+	bb := bigValuePool.Generate()
+	defer bigValuePool.Release(bb)
+	bb.Buf = pkgbytes.ResizeExact(bb.Buf, 1)
+	fs.MustReadData(reader, int64(valueBlock.offset), bb.Buf)
+	if encoding.EncodeType(bb.Buf[0]) != encoding.EncodeTypeDictionary {
+		return nil
+	}
+	// the values of the dictionary might refer to the buffer, so it isn't pooled.
+	buf := make([]byte, valueBlock.size)
+	fs.MustReadData(reader, int64(valueBlock.offset), buf)
+	dict := generateDictionary()
+	if err := dict.Decode(buf[1:], nil); err != nil {
+		logger.Panicf("%s: cannot decode dictionary: %v", reader.Path(), err)
+	}
+	return dict
 }
 
 func generateTagFilter() *tagFilter {
@@ -88,7 +145,12 @@ func generateTagFilter() *tagFilter {
 }
 
 func releaseTagFilter(tf *tagFilter) {
-	releaseBloomFilter(tf.filter)
+	if tf.filter != nil {
+		releaseBloomFilter(tf.filter)
+	}
+	if tf.dict != nil {
+		tf.dict.release()
+	}
 	tf.reset()
 	tagFilterPool.Put(tf)
 }
@@ -101,8 +163,9 @@ func (tff *tagFamilyFilter) reset() {
 	clear(*tff)
 }
 
-func (tff tagFamilyFilter) unmarshal(tagFamilyMetadataBlock *dataBlock, metaReader, filterReader fs.Reader) {
+func (tff tagFamilyFilter) unmarshal(tagFamilyMetadataBlock *dataBlock, metaReader, filterReader, valueReader fs.Reader) {
 	bb := bigValuePool.Generate()
+	defer bigValuePool.Release(bb)
 	bb.Buf = pkgbytes.ResizeExact(bb.Buf, int(tagFamilyMetadataBlock.size))
 	fs.MustReadData(metaReader, int64(tagFamilyMetadataBlock.offset), bb.Buf)
 	tfm := generateTagFamilyMetadata()
@@ -111,22 +174,25 @@ func (tff tagFamilyFilter) unmarshal(tagFamilyMetadataBlock *dataBlock, metaRead
 	if err != nil {
 		logger.Panicf("%s: cannot unmarshal tagFamilyMetadata: %v", metaReader.Path(), err)
 	}
-	bigValuePool.Release(bb)
 	for _, tm := range tfm.tagMetadata {
+		tf := generateTagFilter()
+		// the bounds refer to the buffer, which is overwritten by the bloom filters.
+		tf.min = append(tf.min[:0], tm.min...)
+		tf.max = append(tf.max[:0], tm.max...)
+		tf.nullCount = tm.nullCount
+		tff[tm.name] = tf
 		if tm.filterBlock.size == 0 {
+			if tm.valueType == pbv1.ValueTypeStr && valueReader != nil {
+				tf.dict = &dictionaryFilter{valueReader: valueReader}
+				tf.dict.valueBlock.offset = tm.offset
+				tf.dict.valueBlock.size = tm.size
+			}
 			continue
 		}
 		bb.Buf = pkgbytes.ResizeExact(bb.Buf, int(tm.filterBlock.size))
 		fs.MustReadData(filterReader, int64(tm.filterBlock.offset), bb.Buf)
 		bf := generateBloomFilter()
-		bf = decodeBloomFilter(bb.Buf, bf)
-		tf := generateTagFilter()
-		tf.filter = bf
-		if tm.valueType == pbv1.ValueTypeInt64 {
-			tf.min = tm.min
-			tf.max = tm.max
-		}
-		tff[tm.name] = tf
+		tf.filter = decodeBloomFilter(bb.Buf, bf)
 	}
 }
 
@@ -150,16 +216,23 @@ var tagFamilyFilterPool = pool.Register[*tagFamilyFilter]("stream-tagFamilyFilte
 
 type tagFamilyFilters struct {
 	tagFamilyFilters []*tagFamilyFilter
+	// count is the number of the elements in the block.
+	count uint64
+	// nullCountKnown is false if the part is written before the null counts are tracked.
+	nullCountKnown bool
 }
 
 func (tfs *tagFamilyFilters) reset() {
 	tfs.tagFamilyFilters = tfs.tagFamilyFilters[:0]
+	tfs.count = 0
+	tfs.nullCountKnown = false
 }
 
-func (tfs *tagFamilyFilters) unmarshal(tagFamilies map[string]*dataBlock, metaReader, filterReader map[string]fs.Reader) {
+func (tfs *tagFamilyFilters) unmarshal(tagFamilies map[string]*dataBlock, count uint64, metaReader, filterReader, valueReader map[string]fs.Reader) {
+	tfs.count = count
 	for tf := range tagFamilies {
 		tff := generateTagFamilyFilter()
-		tff.unmarshal(tagFamilies[tf], metaReader[tf], filterReader[tf])
+		tff.unmarshal(tagFamilies[tf], metaReader[tf], filterReader[tf], valueReader[tf])
 		tfs.tagFamilyFilters = append(tfs.tagFamilyFilters, tff)
 	}
 }
@@ -167,22 +240,43 @@ func (tfs *tagFamilyFilters) unmarshal(tagFamilies map[string]*dataBlock, metaRe
 func (tfs *tagFamilyFilters) Eq(tagName string, tagValue string) bool {
 	for _, tff := range tfs.tagFamilyFilters {
 		if tf, ok := (*tff)[tagName]; ok {
-			return tf.filter.MightContain([]byte(tagValue))
+			if tf.filter != nil {
+				return tf.filter.MightContain([]byte(tagValue))
+			}
+			if tf.dict != nil {
+				return tf.dict.mightContain(tagValue)
+			}
+			return true
 		}
 	}
 	return true
 }
 
-func (tfs *tagFamilyFilters) Range(tagName string, rangeOpts index.RangeOpts) (bool, error) {
+// Stats returns the statistics of the tag in the block.
+func (tfs *tagFamilyFilters) Stats(tagName string) (index.BlockStats, bool) {
 	for _, tff := range tfs.tagFamilyFilters {
 		if tf, ok := (*tff)[tagName]; ok {
+			return index.BlockStats{
+				Min:            tf.min,
+				Max:            tf.max,
+				Count:          tfs.count,
+				NullCount:      tf.nullCount,
+				NullCountKnown: tfs.nullCountKnown,
+			}, true
+		}
+	}
+	return index.BlockStats{}, false
+}
+
+func (tfs *tagFamilyFilters) Range(tagName string, rangeOpts index.RangeOpts) (bool, error) {
+	for _, tff := range tfs.tagFamilyFilters {
+		if tf, ok := (*tff)[tagName]; ok && len(tf.min) == 8 && len(tf.max) == 8 {
 			if rangeOpts.Lower != nil {
 				lower, ok := rangeOpts.Lower.(*index.FloatTermValue)
 				if !ok {
 					return false, fmt.Errorf("lower is not a float value: %v", rangeOpts.Lower)
 				}
-				value := make([]byte, 0)
-				value = encoding.Int64ToBytes(value, int64(lower.Value))
+				value := convert.Int64ToBytes(numeric.Float64ToInt64(lower.Value))
 				if bytes.Compare(tf.max, value) == -1 || !rangeOpts.IncludesLower && bytes.Equal(tf.max, value) {
 					return false, nil
 				}
@@ -192,8 +286,7 @@ func (tfs *tagFamilyFilters) Range(tagName string, rangeOpts index.RangeOpts) (b
 				if !ok {
 					return false, fmt.Errorf("upper is not a float value: %v", rangeOpts.Upper)
 				}
-				value := make([]byte, 0)
-				value = encoding.Int64ToBytes(value, int64(upper.Value))
+				value := convert.Int64ToBytes(numeric.Float64ToInt64(upper.Value))
 				if bytes.Compare(tf.min, value) == 1 || !rangeOpts.IncludesUpper && bytes.Equal(tf.min, value) {
 					return false, nil
 				}
@@ -21,12 +21,17 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 
+	pkgbytes "github.com/apache/skywalking-banyandb/pkg/bytes"
+	"github.com/apache/skywalking-banyandb/pkg/convert"
+	"github.com/apache/skywalking-banyandb/pkg/encoding"
 	"github.com/apache/skywalking-banyandb/pkg/filter"
 	"github.com/apache/skywalking-banyandb/pkg/fs"
+	"github.com/apache/skywalking-banyandb/pkg/index"
 	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
 )
 
@@ -63,6 +68,130 @@ func TestEncodeAndDecodeBloomFilter(t *testing.T) {
 	}
 }
 
+func TestTagFamilyFiltersEqWithDictionary(t *testing.T) {
+	var uniqueStrs [][]byte
+	for i := 0; i < 300; i++ {
+		uniqueStrs = append(uniqueStrs, []byte(fmt.Sprintf("value%d", i)))
+	}
+	tags := []*tag{
+		{name: "method", valueType: pbv1.ValueTypeStr, values: [][]byte{[]byte("GET"), []byte("POST"), []byte("GET")}},
+		{name: "url", valueType: pbv1.ValueTypeStr, values: uniqueStrs},
+	}
+	tfm := generateTagFamilyMetadata()
+	defer releaseTagFamilyMetadata(tfm)
+	tms := tfm.resizeTagMetadata(len(tags))
+	valueBuf, filterBuf := &pkgbytes.Buffer{}, &pkgbytes.Buffer{}
+	w, fw := &writer{}, &writer{}
+	w.init(valueBuf)
+	fw.init(filterBuf)
+	for i := range tags {
+		tags[i].mustWriteTo(&tms[i], w, fw, encoding.DefaultCompressionLevel, nil)
+	}
+	metaBuf := tfm.marshal(nil)
+
+	tfs := generateTagFamilyFilters()
+	defer releaseTagFamilyFilters(tfs)
+	tfs.unmarshal(map[string]*dataBlock{"default": {size: uint64(len(metaBuf))}}, uint64(len(uniqueStrs)),
+		map[string]fs.Reader{"default": &mockReader{data: metaBuf}},
+		map[string]fs.Reader{"default": filterBuf},
+		map[string]fs.Reader{"default": valueBuf})
+
+	assert := assert.New(t)
+	assert.True(tfs.Eq("method", "GET"))
+	assert.True(tfs.Eq("method", "POST"))
+	assert.False(tfs.Eq("method", "PUT"))
+	// the values of url aren't dictionary-encoded, so the block can't be skipped.
+	assert.True(tfs.Eq("url", "absent"))
+	assert.True(tfs.Eq("absent", "GET"))
+}
+
+func TestTagFamilyFiltersStats(t *testing.T) {
+	tags := []*tag{
+		{name: "duration", valueType: pbv1.ValueTypeInt64, values: [][]byte{
+			convert.Int64ToBytes(-10), nil, convert.Int64ToBytes(300), convert.Int64ToBytes(20),
+		}},
+		{name: "error", valueType: pbv1.ValueTypeStr, values: [][]byte{nil, nil, nil, nil}},
+	}
+	tfm := generateTagFamilyMetadata()
+	defer releaseTagFamilyMetadata(tfm)
+	tms := tfm.resizeTagMetadata(len(tags))
+	valueBuf, filterBuf := &pkgbytes.Buffer{}, &pkgbytes.Buffer{}
+	w, fw := &writer{}, &writer{}
+	w.init(valueBuf)
+	fw.init(filterBuf)
+	for i := range tags {
+		tags[i].mustWriteTo(&tms[i], w, fw, encoding.DefaultCompressionLevel, nil)
+	}
+	metaBuf := tfm.marshal(nil)
+
+	tfs := generateTagFamilyFilters()
+	defer releaseTagFamilyFilters(tfs)
+	tfs.unmarshal(map[string]*dataBlock{"default": {size: uint64(len(metaBuf))}}, 4,
+		map[string]fs.Reader{"default": &mockReader{data: metaBuf}},
+		map[string]fs.Reader{"default": filterBuf},
+		map[string]fs.Reader{"default": valueBuf})
+
+	assert := assert.New(t)
+	stats, ok := tfs.Stats("duration")
+	assert.True(ok)
+	assert.Equal(convert.Int64ToBytes(-10), stats.Min)
+	assert.Equal(convert.Int64ToBytes(300), stats.Max)
+	assert.Equal(uint64(4), stats.Count)
+	assert.Equal(uint64(1), stats.NullCount)
+	stats, ok = tfs.Stats("error")
+	assert.True(ok)
+	assert.Empty(stats.Min)
+	assert.Equal(uint64(4), stats.NullCount)
+	_, ok = tfs.Stats("absent")
+	assert.False(ok)
+
+}
+
+func TestTagFamilyFiltersRange(t *testing.T) {
+	tags := []*tag{
+		{name: "duration", valueType: pbv1.ValueTypeInt64, values: [][]byte{
+			convert.Int64ToBytes(-10), convert.Int64ToBytes(300), convert.Int64ToBytes(20),
+		}},
+	}
+	tfm := generateTagFamilyMetadata()
+	defer releaseTagFamilyMetadata(tfm)
+	tms := tfm.resizeTagMetadata(len(tags))
+	valueBuf, filterBuf := &pkgbytes.Buffer{}, &pkgbytes.Buffer{}
+	w, fw := &writer{}, &writer{}
+	w.init(valueBuf)
+	fw.init(filterBuf)
+	for i := range tags {
+		tags[i].mustWriteTo(&tms[i], w, fw, encoding.DefaultCompressionLevel, nil)
+	}
+	metaBuf := tfm.marshal(nil)
+
+	tfs := generateTagFamilyFilters()
+	defer releaseTagFamilyFilters(tfs)
+	tfs.unmarshal(map[string]*dataBlock{"default": {size: uint64(len(metaBuf))}}, 3,
+		map[string]fs.Reader{"default": &mockReader{data: metaBuf}},
+		map[string]fs.Reader{"default": filterBuf},
+		map[string]fs.Reader{"default": valueBuf})
+
+	tests := []struct {
+		name      string
+		opts      index.RangeOpts
+		wantMatch bool
+	}{
+		{name: "above the max", opts: index.NewIntRangeOpts(301, math.MaxInt64, true, false), wantMatch: false},
+		{name: "below the min", opts: index.NewIntRangeOpts(math.MinInt64, -10, false, false), wantMatch: false},
+		{name: "including the min", opts: index.NewIntRangeOpts(math.MinInt64, -10, false, true), wantMatch: true},
+		{name: "including the max", opts: index.NewIntRangeOpts(300, math.MaxInt64, true, false), wantMatch: true},
+		{name: "inside the bounds", opts: index.NewIntRangeOpts(100, 200, true, true), wantMatch: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mightMatch, err := tfs.Range("duration", tt.opts)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantMatch, mightMatch)
+		})
+	}
+}
+
 type mockReader struct {
 	data []byte
 }
@@ -153,7 +282,7 @@ func BenchmarkTagFamilyFiltersUnmarshal(b *testing.B) {
 			b.ReportAllocs()
 			for i := 0; i < b.N; i++ {
 				tfs := generateTagFamilyFilters()
-				tfs.unmarshal(tagFamilies, metaReaders, filterReaders)
+				tfs.unmarshal(tagFamilies, uint64(tc.itemsPerTag), metaReaders, filterReaders, nil)
 				releaseTagFamilyFilters(tfs)
 			}
 		})
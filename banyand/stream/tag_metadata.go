@@ -28,11 +28,14 @@ import (
 
 type tagMetadata struct {
 	name string
-	min  []byte
-	max  []byte
+	// min and max are the bounds of the int64 values in the block.
+	min []byte
+	max []byte
 	dataBlock
 	valueType   pbv1.ValueType
 	filterBlock dataBlock
+	// nullCount is the number of the null values in the block.
+	nullCount uint64
 }
 
 func (tm *tagMetadata) reset() {
@@ -42,6 +45,7 @@ func (tm *tagMetadata) reset() {
 	tm.min = nil
 	tm.max = nil
 	tm.filterBlock.reset()
+	tm.nullCount = 0
 }
 
 func (tm *tagMetadata) copyFrom(src *tagMetadata) {
@@ -51,6 +55,7 @@ func (tm *tagMetadata) copyFrom(src *tagMetadata) {
 	tm.min = append(tm.min[:0], src.min...)
 	tm.max = append(tm.max[:0], src.max...)
 	tm.filterBlock.copyFrom(&src.filterBlock)
+	tm.nullCount = src.nullCount
 }
 
 func (tm *tagMetadata) marshal(dst []byte) []byte {
@@ -117,12 +122,17 @@ func (tfm *tagFamilyMetadata) resizeTagMetadata(tagMetadataLen int) []tagMetadat
 	return tms
 }
 
+// marshal appends the null counts of the tags after their metadata.
+// The parts written before don't have them, so the tags are deemed to have no null values.
 func (tfm *tagFamilyMetadata) marshal(dst []byte) []byte {
 	tms := tfm.tagMetadata
 	dst = encoding.VarUint64ToBytes(dst, uint64(len(tms)))
 	for i := range tms {
 		dst = tms[i].marshal(dst)
 	}
+	for i := range tms {
+		dst = encoding.VarUint64ToBytes(dst, tms[i].nullCount)
+	}
 	return dst
 }
 
@@ -139,6 +149,15 @@ func (tfm *tagFamilyMetadata) unmarshal(src []byte) error {
 			return fmt.Errorf("cannot unmarshal tagMetadata %d: %w", i, err)
 		}
 	}
+	if len(src) == 0 {
+		return nil
+	}
+	for i := range tms {
+		if len(src) == 0 {
+			return fmt.Errorf("cannot unmarshal the null count of tagMetadata %d: src is too short", i)
+		}
+		src, tms[i].nullCount = encoding.BytesToVarUint64(src)
+	}
 	return nil
 }
 
@@ -22,6 +22,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/apache/skywalking-banyandb/pkg/encoding"
 	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
 )
 
@@ -177,6 +178,7 @@ func Test_tagFamilyMetadata_marshalUnmarshal(t *testing.T) {
 						filterBlock: dataBlock{offset: 4, size: 40},
 						min:         []byte{1, 2, 3},
 						max:         []byte{4, 5, 6},
+						nullCount:   7,
 					},
 				},
 			},
@@ -200,3 +202,28 @@ func Test_tagFamilyMetadata_marshalUnmarshal(t *testing.T) {
 		})
 	}
 }
+
+func Test_tagFamilyMetadata_unmarshalWithoutNullCount(t *testing.T) {
+	tms := []tagMetadata{
+		{name: "test1", valueType: pbv1.ValueTypeStr, dataBlock: dataBlock{offset: 1, size: 10}},
+		{name: "test2", valueType: pbv1.ValueTypeInt64, dataBlock: dataBlock{offset: 3, size: 30}},
+	}
+	// the parts written by the older versions don't have the null counts.
+	src := encoding.VarUint64ToBytes(nil, uint64(len(tms)))
+	for i := range tms {
+		src = tms[i].marshal(src)
+	}
+
+	unmarshaled := &tagFamilyMetadata{}
+	assert.NoError(t, unmarshaled.unmarshal(src))
+	assert.Len(t, unmarshaled.tagMetadata, len(tms))
+	for i := range tms {
+		assert.Equal(t, tms[i].name, unmarshaled.tagMetadata[i].name)
+		assert.Equal(t, tms[i].dataBlock, unmarshaled.tagMetadata[i].dataBlock)
+		assert.Zero(t, unmarshaled.tagMetadata[i].nullCount)
+	}
+
+	// a truncated section of the null counts is rejected.
+	src = encoding.VarUint64ToBytes(src, 1)
+	assert.Error(t, unmarshaled.unmarshal(src))
+}
@@ -18,6 +18,7 @@
 package stream
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -56,9 +57,14 @@ func TestTag_resizeValues(t *testing.T) {
 }
 
 func TestTag_mustWriteTo_mustReadValues(t *testing.T) {
+	var uniqueStrs [][]byte
+	for i := 0; i < 300; i++ {
+		uniqueStrs = append(uniqueStrs, []byte(fmt.Sprintf("value%d", i)))
+	}
 	tests := []struct {
-		tag  *tag
-		name string
+		tag        *tag
+		name       string
+		encodeType encoding.EncodeType
 	}{
 		{
 			name: "string with nils",
@@ -67,6 +73,25 @@ func TestTag_mustWriteTo_mustReadValues(t *testing.T) {
 				valueType: pbv1.ValueTypeStr,
 				values:    [][]byte{[]byte("value1"), nil, []byte("value2"), nil},
 			},
+			encodeType: encoding.EncodeTypeDictionary,
+		},
+		{
+			name: "repeated string values",
+			tag: &tag{
+				name:      "test",
+				valueType: pbv1.ValueTypeStr,
+				values:    [][]byte{[]byte("GET"), []byte("GET"), []byte("POST"), []byte("GET")},
+			},
+			encodeType: encoding.EncodeTypeDictionary,
+		},
+		{
+			name: "too many unique string values",
+			tag: &tag{
+				name:      "test",
+				valueType: pbv1.ValueTypeStr,
+				values:    uniqueStrs,
+			},
+			encodeType: encoding.EncodeTypePlain,
 		},
 		{
 			name: "int64 with null",
@@ -100,12 +125,15 @@ func TestTag_mustWriteTo_mustReadValues(t *testing.T) {
 			w.init(buf)
 			fw.init(filterBuf)
 
-			tt.tag.mustWriteTo(tm, w, fw)
+			tt.tag.mustWriteTo(tm, w, fw, encoding.DefaultCompressionLevel, nil)
 			assert.Equal(t, w.bytesWritten, tm.size)
 			assert.Equal(t, uint64(len(buf.Buf)), tm.size)
 			assert.Equal(t, uint64(0), tm.offset)
 			assert.Equal(t, tt.tag.name, tm.name)
 			assert.Equal(t, tt.tag.valueType, tm.valueType)
+			if tt.encodeType != encoding.EncodeTypeUnknown {
+				assert.Equal(t, tt.encodeType, encoding.EncodeType(buf.Buf[0]))
+			}
 
 			decoder := &encoding.BytesBlockDecoder{}
 			unmarshaled := &tag{}
@@ -118,6 +146,20 @@ func TestTag_mustWriteTo_mustReadValues(t *testing.T) {
 	}
 }
 
+func TestTag_mustReadValues_legacyStr(t *testing.T) {
+	values := [][]byte{[]byte("value1"), nil, []byte("value2")}
+	buf := &bytes.Buffer{}
+	// the string values were written without the encode type.
+	buf.Buf = encoding.EncodeBytesBlock(buf.Buf, values)
+	tm := tagMetadata{name: "test", valueType: pbv1.ValueTypeStr}
+	tm.size = uint64(len(buf.Buf))
+
+	decoder := &encoding.BytesBlockDecoder{}
+	unmarshaled := &tag{}
+	unmarshaled.mustReadValues(decoder, buf, tm, uint64(len(values)))
+	assert.Equal(t, values, unmarshaled.values)
+}
+
 func TestTagFamily_reset(t *testing.T) {
 	tf := &tagFamily{
 		name: "test",
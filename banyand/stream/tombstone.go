@@ -0,0 +1,97 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+	"github.com/apache/skywalking-banyandb/pkg/fs"
+)
+
+func (sr *schemaRepo) loadRangeTombstones(group string) *storage.RangeTombstones {
+	if sr == nil {
+		return nil
+	}
+	return loadRangeTombstones(sr.rangeTombstones, group)
+}
+
+func loadRangeTombstones(tombstones *sync.Map, group string) *storage.RangeTombstones {
+	if tombstones == nil {
+		return nil
+	}
+	if t, ok := tombstones.Load(group); ok {
+		return t.(*storage.RangeTombstones)
+	}
+	return nil
+}
+
+// openRangeTombstones opens the range tombstones of a group once, which are shared by the supplier and the schema repository.
+func openRangeTombstones(tombstones *sync.Map, fileSystem fs.FileSystem, group, root string) (*storage.RangeTombstones, error) {
+	if t := loadRangeTombstones(tombstones, group); t != nil {
+		return t, nil
+	}
+	t, err := storage.OpenRangeTombstones(fileSystem, root)
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := tombstones.LoadOrStore(group, t)
+	return actual.(*storage.RangeTombstones), nil
+}
+
+type deleteRangeListener struct {
+	*bus.UnImplementedHealthyListener
+	s *service
+}
+
+// Rev tombstones a time range of a group hosted by this node.
+func (l *deleteRangeListener) Rev(_ context.Context, message bus.Message) bus.Message {
+	now := time.Now().UnixNano()
+	req, ok := message.Data().(*databasev1.InternalDeleteRangeRequest)
+	if !ok {
+		return bus.NewMessage(bus.MessageID(now), common.NewError("invalid event data type %T", message.Data()))
+	}
+	resp := &databasev1.DeletionServiceDeleteRangeResponse{DeletedAt: req.GetDeletedAt()}
+	if _, err := l.s.schemaRepo.loadTSDB(req.GetGroup()); err != nil {
+		// the group is not hosted by this node
+		return bus.NewMessage(bus.MessageID(now), resp)
+	}
+	t := l.s.schemaRepo.loadRangeTombstones(req.GetGroup())
+	if t == nil {
+		return bus.NewMessage(bus.MessageID(now), common.NewError("the range tombstones of group %s are not open", req.GetGroup()))
+	}
+	since := now
+	for _, tr := range l.s.schemaRepo.GetSegmentsTimeRanges(req.GetGroup()) {
+		if start := tr.Start.UnixNano(); start < since {
+			since = start
+		}
+	}
+	tr := req.GetTimeRange()
+	// the range is stamped by the local clock, which also stamps the ingestion time of the parts,
+	// so that the data written after the deletion are kept whatever the clock skew to the liaison is.
+	if err := t.Add(tr.GetBegin().AsTime().UnixNano(), tr.GetEnd().AsTime().UnixNano(), now, since); err != nil {
+		return bus.NewMessage(bus.MessageID(now), common.NewError("failed to delete the range of %s: %v", req.GetGroup(), err))
+	}
+	l.s.l.Info().Str("group", req.GetGroup()).Time("begin", tr.GetBegin().AsTime()).Time("end", tr.GetEnd().AsTime()).Msg("tombstoned the range")
+	return bus.NewMessage(bus.MessageID(now), resp)
+}
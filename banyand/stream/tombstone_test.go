@@ -0,0 +1,87 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	"github.com/apache/skywalking-banyandb/banyand/protector"
+	"github.com/apache/skywalking-banyandb/pkg/encoding"
+	"github.com/apache/skywalking-banyandb/pkg/fs"
+	"github.com/apache/skywalking-banyandb/pkg/test"
+)
+
+func Test_mergePartsWithRangeTombstones(t *testing.T) {
+	tmpPath, defFn := test.Space(require.New(t))
+	defer defFn()
+	fileSystem := fs.NewLocalFileSystem()
+	rt, err := storage.OpenRangeTombstones(fileSystem, tmpPath)
+	require.NoError(t, err)
+	// the elements at 1 of all the series ingested at or before 2 are deleted
+	require.NoError(t, rt.Add(1, 2, 2, 0))
+
+	var pp []*partWrapper
+	defer func() {
+		for _, pw := range pp {
+			pw.decRef()
+		}
+	}()
+	// the elements at 1 are ingested again after the deletion by the last part
+	for i, ingestedAt := range []int64{1, 2, 3} {
+		es := esTS1
+		if i == 1 {
+			es = esTS2
+		}
+		mp := generateMemPart()
+		mp.mustInitFromElements(es, encoding.DefaultCompressionLevel)
+		mp.partMetadata.IngestedAt = ingestedAt
+		mp.mustFlush(fileSystem, partPath(tmpPath, uint64(i)))
+		pw := newPartWrapper(nil, mustOpenFilePart(uint64(i), tmpPath, fileSystem))
+		pp = append(pp, pw)
+		releaseMemPart(mp)
+	}
+	closeCh := make(chan struct{})
+	defer close(closeCh)
+	tst := &tsTable{pm: protector.Nop{}, option: option{rangeTombstones: rt}}
+	p, err := tst.mergeParts(fileSystem, closeCh, pp, 3, tmpPath, encoding.DefaultCompressionLevel, 0)
+	require.NoError(t, err)
+	defer p.decRef()
+
+	pmi := &partMergeIter{}
+	pmi.mustInitFromPart(p.p)
+	reader := &blockReader{}
+	reader.init([]*partMergeIter{pmi})
+	got := make(map[common.SeriesID][]uint64)
+	decoder := generateColumnValuesDecoder()
+	defer releaseColumnValuesDecoder(decoder)
+	for reader.nextBlockMetadata() {
+		reader.loadBlockData(decoder)
+		got[reader.block.bm.seriesID] = append(got[reader.block.bm.seriesID], reader.block.elementIDs...)
+	}
+	require.NoError(t, reader.error())
+	require.Equal(t, map[common.SeriesID][]uint64{
+		1: {11, 12},
+		2: {21, 22},
+		3: {31, 32},
+	}, got)
+	require.Equal(t, int64(3), p.p.partMetadata.IngestedAt)
+}
@@ -48,6 +48,13 @@ const (
 	snapshotSuffix = ".snp"
 )
 
+var (
+	errNoManifest = errors.New("there isn't any readable manifest")
+	errPartAbsent = errors.New("the part is absent on the disk")
+	errPartMerged = errors.New("the part isn't in the manifest, and is older than the newest part in it")
+	errPartOrphan = errors.New("the part isn't in the manifest, and is newer than all the parts in it")
+)
+
 type tsTable struct {
 	fileSystem    fs.FileSystem
 	l             *logger.Logger
@@ -63,50 +70,83 @@ type tsTable struct {
 	gc            garbageCleaner
 	curPartID     uint64
 	sync.RWMutex
+	// compressionLevel is the zstd level of the memory parts, adapted by the compressionPolicy.
+	compressionLevel atomic.Int32
 }
 
-func (tst *tsTable) loadSnapshot(epoch uint64, loadedParts []uint64) {
-	parts := tst.mustReadSnapshot(epoch)
+// loadSnapshot loads the latest readable manifest, and reconciles it with the parts on the disk:
+//   - The parts absent on the disk are dropped from the manifest.
+//   - The parts not in the manifest and older than its newest part are left by the merger, so they are removed.
+//   - The other parts not in the manifest might keep the data which aren't merged, so they are quarantined.
+//
+// It returns the epoch of the loaded manifest, or 0 if there isn't any readable manifest.
+func (tst *tsTable) loadSnapshot(epochs []uint64, loadedParts []uint64) uint64 {
+	var epoch uint64
+	var parts []uint64
+	for _, e := range epochs {
+		var err error
+		if parts, err = tst.readSnapshot(e); err == nil {
+			epoch = e
+			break
+		}
+		storage.Quarantine(tst.fileSystem, tst.root, snapshotName(e), err, tst.l)
+	}
+	if epoch == 0 {
+		for _, id := range loadedParts {
+			storage.Quarantine(tst.fileSystem, tst.root, partName(id), errNoManifest, tst.l)
+		}
+		return 0
+	}
+	onDisk := make(map[uint64]struct{}, len(loadedParts))
+	for _, id := range loadedParts {
+		onDisk[id] = struct{}{}
+		// the new parts shouldn't reuse the ID of any part on the disk.
+		if tst.curPartID < id {
+			tst.curPartID = id
+		}
+	}
 	snp := snapshot{
 		epoch: epoch,
 	}
 	needToPersist := false
-	for _, id := range loadedParts {
-		var find bool
-		for j := range parts {
-			if id == parts[j] {
-				find = true
-				break
-			}
-		}
-		if !find {
-			tst.gc.removePart(id)
-			continue
-		}
-		err := validatePartMetadata(tst.fileSystem, partPath(tst.root, id))
-		if err != nil {
-			tst.l.Info().Err(err).Uint64("id", id).Msg("cannot validate part metadata. skip and delete it")
-			tst.gc.removePart(id)
+	var newest uint64
+	for _, id := range parts {
+		if _, ok := onDisk[id]; !ok {
+			path := partPath(tst.root, id)
+			tst.l.Warn().Str("path", path).Msg("the part in the manifest is absent, drop it")
+			storage.ReportRepair(path, storage.RepairActionDropped, errPartAbsent)
 			needToPersist = true
 			continue
 		}
+		delete(onDisk, id)
 		p := mustOpenFilePart(id, tst.root, tst.fileSystem)
 		p.partMetadata.ID = id
 		snp.parts = append(snp.parts, newPartWrapper(nil, p))
-		if tst.curPartID < id {
-			tst.curPartID = id
+		if newest < id {
+			newest = id
 		}
 	}
+	for id := range onDisk {
+		if id < newest {
+			tst.gc.removePart(id)
+			storage.ReportRepair(partPath(tst.root, id), storage.RepairActionRemoved, errPartMerged)
+			continue
+		}
+		storage.Quarantine(tst.fileSystem, tst.root, partName(id), errPartOrphan, tst.l)
+	}
 	tst.gc.registerSnapshot(&snp)
+	if needToPersist {
+		// the repaired manifest replaces the loaded one.
+		snp.epoch++
+		tst.persistSnapshot(&snp)
+	}
 	tst.gc.clean()
 	if len(snp.parts) < 1 {
-		return
+		return snp.epoch
 	}
 	snp.incRef()
 	tst.snapshot = &snp
-	if needToPersist {
-		tst.persistSnapshot(&snp)
-	}
+	return snp.epoch
 }
 
 func (tst *tsTable) startLoop(cur uint64) {
@@ -148,25 +188,25 @@ func (tst *tsTable) mustWriteSnapshot(snapshot uint64, partNames []string) {
 	}
 }
 
-func (tst *tsTable) mustReadSnapshot(snapshot uint64) []uint64 {
+func (tst *tsTable) readSnapshot(snapshot uint64) ([]uint64, error) {
 	snapshotPath := filepath.Join(tst.root, snapshotName(snapshot))
 	data, err := tst.fileSystem.Read(snapshotPath)
 	if err != nil {
-		logger.Panicf("cannot read %s: %s", snapshotPath, err)
+		return nil, fmt.Errorf("cannot read %s: %w", snapshotPath, err)
 	}
 	var partNames []string
 	if err := json.Unmarshal(data, &partNames); err != nil {
-		logger.Panicf("cannot parse %s: %s", snapshotPath, err)
+		return nil, fmt.Errorf("cannot parse %s: %w", snapshotPath, err)
 	}
 	var result []uint64
 	for i := range partNames {
 		e, err := parseEpoch(partNames[i])
 		if err != nil {
-			logger.Panicf("cannot parse %s: %s", partNames[i], err)
+			return nil, err
 		}
 		result = append(result, e)
 	}
-	return result
+	return result, nil
 }
 
 func newTSTable(fileSystem fs.FileSystem, rootPath string, p common.Position,
@@ -190,7 +230,8 @@ func newTSTable(fileSystem fs.FileSystem, rootPath string, p common.Position,
 		tst.metrics = m.(*metrics)
 		indexMetrics = tst.metrics.indexMetrics
 	}
-	index, err := newElementIndex(context.TODO(), rootPath, option.elementIndexFlushTimeout.Nanoseconds()/int64(time.Second), indexMetrics)
+	index, err := newElementIndex(context.TODO(), rootPath, option.elementIndexFlushTimeout.Nanoseconds()/int64(time.Second), indexMetrics,
+		option.indexMergeScheduler)
 	if err != nil {
 		return nil, err
 	}
@@ -204,22 +245,22 @@ func newTSTable(fileSystem fs.FileSystem, rootPath string, p common.Position,
 	}
 	var loadedParts []uint64
 	var loadedSnapshots []uint64
-	var needToDelete []string
 	for i := range ee {
 		if ee[i].IsDir() {
+			if ee[i].Name() == storage.QuarantineDirname {
+				continue
+			}
 			if ee[i].Name() == elementIndexFilename {
 				continue
 			}
 			p, err := parseEpoch(ee[i].Name())
 			if err != nil {
-				l.Info().Err(err).Msg("cannot parse part file name. skip and delete it")
-				needToDelete = append(needToDelete, ee[i].Name())
+				storage.Quarantine(fileSystem, rootPath, ee[i].Name(), err, l)
 				continue
 			}
-			err = validatePartMetadata(fileSystem, filepath.Join(rootPath, ee[i].Name()))
+			err = validatePart(fileSystem, filepath.Join(rootPath, ee[i].Name()))
 			if err != nil {
-				l.Info().Err(err).Msg("cannot validate part metadata. skip and delete it")
-				needToDelete = append(needToDelete, ee[i].Name())
+				storage.Quarantine(fileSystem, rootPath, ee[i].Name(), err, l)
 				continue
 			}
 			loadedParts = append(loadedParts, p)
@@ -230,17 +271,12 @@ func newTSTable(fileSystem fs.FileSystem, rootPath string, p common.Position,
 		}
 		snapshot, err := parseSnapshot(ee[i].Name())
 		if err != nil {
-			l.Info().Err(err).Msg("cannot parse snapshot file name. skip and delete it")
-			needToDelete = append(needToDelete, ee[i].Name())
+			storage.Quarantine(fileSystem, rootPath, ee[i].Name(), err, l)
 			continue
 		}
 		loadedSnapshots = append(loadedSnapshots, snapshot)
 	}
-	for i := range needToDelete {
-		l.Info().Str("path", filepath.Join(rootPath, needToDelete[i])).Msg("delete invalid directory or file")
-		fileSystem.MustRMAll(filepath.Join(rootPath, needToDelete[i]))
-	}
-	if len(loadedParts) == 0 || len(loadedSnapshots) == 0 {
+	if len(loadedParts) == 0 && len(loadedSnapshots) == 0 {
 		t := &tst
 		t.startLoop(uint64(time.Now().UnixNano()))
 		return t, nil
@@ -248,9 +284,11 @@ func newTSTable(fileSystem fs.FileSystem, rootPath string, p common.Position,
 	sort.Slice(loadedSnapshots, func(i, j int) bool {
 		return loadedSnapshots[i] > loadedSnapshots[j]
 	})
-	epoch := loadedSnapshots[0]
 	t := &tst
-	t.loadSnapshot(epoch, loadedParts)
+	epoch := t.loadSnapshot(loadedSnapshots, loadedParts)
+	if epoch == 0 {
+		epoch = uint64(time.Now().UnixNano())
+	}
 	t.startLoop(epoch)
 	return t, nil
 }
@@ -264,6 +302,7 @@ func (tst *tsTable) Close() error {
 		tst.loopCloser.Done()
 		tst.loopCloser.CloseThenWait()
 	}
+	tst.flushOnClose()
 	tst.Lock()
 	defer tst.Unlock()
 	tst.deleteMetrics()
@@ -281,7 +320,7 @@ func (tst *tsTable) mustAddElements(es *elements) {
 	}
 
 	mp := generateMemPart()
-	mp.mustInitFromElements(es)
+	mp.mustInitFromElements(es, tst.ingestCompressionLevel())
 	p := openMemPart(mp)
 
 	ind := generateIntroduction()
@@ -304,6 +343,58 @@ func (tst *tsTable) mustAddElements(es *elements) {
 	tst.incTotalBatchIntroLatency(time.Since(startTime).Seconds())
 }
 
+// mustFlushElements builds a part from es on the disk and installs it into the table, bypassing the memory parts,
+// so the elements are durable once it returns. It serves the writes acknowledged after the flush.
+func (tst *tsTable) mustFlushElements(es *elements) {
+	if len(es.seriesIDs) == 0 {
+		return
+	}
+	if tst.fileSystem == nil {
+		// the table only lives in memory.
+		tst.mustAddElements(es)
+		return
+	}
+	startTime := time.Now()
+	tst.mustInstallElements(es, tst.ingestCompressionLevel())
+	tst.incTotalBatch(1)
+	tst.incTotalBatchIntroLatency(time.Since(startTime).Seconds())
+}
+
+// mustImportElements builds a sealed part from es and installs it into the table,
+// bypassing the memory parts. The part becomes visible with the manifest that lists it.
+func (tst *tsTable) mustImportElements(es *elements) {
+	if len(es.seriesIDs) == 0 {
+		return
+	}
+	// the imported parts bypass the flusher, so they're compressed as the merged ones.
+	tst.mustInstallElements(es, tst.mergeCompressionLevel())
+}
+
+func (tst *tsTable) mustInstallElements(es *elements, compressionLevel int) {
+	mp := generateMemPart()
+	defer releaseMemPart(mp)
+	mp.mustInitFromElements(es, compressionLevel)
+	partID := atomic.AddUint64(&tst.curPartID, 1)
+	tst.mustFlushMemPart(mp, partPath(tst.root, partID))
+	p := mustOpenFilePart(partID, tst.root, tst.fileSystem)
+	p.partMetadata.ID = partID
+
+	ind := generateIntroduction()
+	defer releaseIntroduction(ind)
+	ind.applied = make(chan struct{})
+	ind.imported = []*partWrapper{newPartWrapper(nil, p)}
+	select {
+	case tst.introductions <- ind:
+	case <-tst.loopCloser.CloseNotify():
+		return
+	}
+	select {
+	case <-ind.applied:
+	case <-tst.loopCloser.CloseNotify():
+	}
+	tst.incTotalWritten(len(es.timestamps))
+}
+
 type tstIter struct {
 	err           error
 	parts         []*part
@@ -20,6 +20,9 @@ package stream
 import (
 	"context"
 	"errors"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -29,11 +32,16 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/apache/skywalking-banyandb/api/common"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	"github.com/apache/skywalking-banyandb/banyand/protector"
 	"github.com/apache/skywalking-banyandb/pkg/convert"
 	"github.com/apache/skywalking-banyandb/pkg/fs"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
 	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
 	"github.com/apache/skywalking-banyandb/pkg/run"
 	"github.com/apache/skywalking-banyandb/pkg/test"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
 	"github.com/apache/skywalking-banyandb/pkg/watcher"
 )
 
@@ -88,7 +96,7 @@ func Test_tsTable_mustAddDataPoints(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tmpPath, _ := test.Space(require.New(t))
-			index, _ := newElementIndex(context.TODO(), tmpPath, 0, nil)
+			index, _ := newElementIndex(context.TODO(), tmpPath, 0, nil, nil)
 			tst := &tsTable{
 				index:         index,
 				loopCloser:    run.NewCloser(2),
@@ -122,6 +130,176 @@ func Test_tsTable_mustAddDataPoints(t *testing.T) {
 	}
 }
 
+func Test_tsTable_CloseFlushesMemParts(t *testing.T) {
+	tmpPath, defFn := test.Space(require.New(t))
+	defer defFn()
+	fileSystem := fs.NewLocalFileSystem()
+	opt := option{flushTimeout: time.Hour, mergePolicy: newDefaultMergePolicyForTesting(), protector: protector.Nop{}}
+	tst, err := newTSTable(fileSystem, tmpPath, common.Position{}, logger.GetLogger("test"), timestamp.TimeRange{}, opt, nil)
+	require.NoError(t, err)
+	tst.mustAddElements(esTS1)
+	tst.mustAddElements(esTS2)
+	// the flusher is paused to pile up the in-memory parts.
+	require.NoError(t, tst.Close())
+
+	tst, err = newTSTable(fileSystem, tmpPath, common.Position{}, logger.GetLogger("test"), timestamp.TimeRange{}, opt, nil)
+	require.NoError(t, err)
+	defer tst.Close()
+	s := tst.currentSnapshot()
+	require.NotNil(t, s)
+	defer s.decRef()
+	var total uint64
+	for _, pw := range s.parts {
+		require.Nil(t, pw.mp)
+		total += pw.p.partMetadata.TotalCount
+	}
+	assert.Equal(t, uint64(len(esTS1.timestamps)+len(esTS2.timestamps)), total)
+}
+
+func Test_tsTable_mustFlushElements(t *testing.T) {
+	tmpPath, defFn := test.Space(require.New(t))
+	defer defFn()
+	opt := option{flushTimeout: time.Hour, mergePolicy: newDefaultMergePolicyForTesting(), protector: protector.Nop{}}
+	tst, err := newTSTable(fs.NewLocalFileSystem(), tmpPath, common.Position{}, logger.GetLogger("test"), timestamp.TimeRange{}, opt, nil)
+	require.NoError(t, err)
+	defer tst.Close()
+	tst.mustFlushElements(esTS2)
+
+	s := tst.currentSnapshot()
+	require.NotNil(t, s)
+	defer s.decRef()
+	require.Len(t, s.parts, 1)
+	// the elements are on the disk without waiting for the flusher.
+	require.Nil(t, s.parts[0].mp)
+	assert.Equal(t, uint64(len(esTS2.timestamps)), s.parts[0].p.partMetadata.TotalCount)
+	persisted, err := tst.readSnapshot(s.epoch)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{s.parts[0].ID()}, persisted)
+}
+
+func Test_tsTable_Usage(t *testing.T) {
+	tmpPath, defFn := test.Space(require.New(t))
+	defer defFn()
+	fileSystem := fs.NewLocalFileSystem()
+	opt := option{flushTimeout: time.Hour, mergePolicy: newDefaultMergePolicyForTesting(), protector: protector.Nop{}}
+	tst, err := newTSTable(fileSystem, tmpPath, common.Position{}, logger.GetLogger("test"), timestamp.TimeRange{}, opt, nil)
+	require.NoError(t, err)
+	tst.mustAddElements(esTS1)
+	u := storage.TableUsage{TagFamilySizes: make(map[string]int64)}
+	tst.Usage(&u)
+	assert.Zero(t, u.PartsCount, "the parts in memory are skipped")
+	require.NoError(t, tst.Close())
+
+	tst, err = newTSTable(fileSystem, tmpPath, common.Position{}, logger.GetLogger("test"), timestamp.TimeRange{}, opt, nil)
+	require.NoError(t, err)
+	defer tst.Close()
+	tst.Usage(&u)
+	assert.Equal(t, int64(1), u.PartsCount)
+	assert.Positive(t, u.PartsSize)
+	for _, tf := range []string{"arrTag", "binaryTag", "singleTag"} {
+		assert.Positive(t, u.TagFamilySizes[tf], tf)
+	}
+}
+
+func Test_tsTable_Digest(t *testing.T) {
+	tmpPath, defFn := test.Space(require.New(t))
+	defer defFn()
+	fileSystem := fs.NewLocalFileSystem()
+	opt := option{flushTimeout: time.Hour, mergePolicy: newDefaultMergePolicyForTesting(), protector: protector.Nop{}}
+	tst, err := newTSTable(fileSystem, tmpPath, common.Position{}, logger.GetLogger("test"), timestamp.TimeRange{}, opt, nil)
+	require.NoError(t, err)
+	tst.mustAddElements(esTS1)
+	td := storage.NewTableDigest(time.Hour)
+	tst.Digest(td)
+	inMemory := td.Segment("seg")
+	require.Len(t, inMemory.GetParts(), 1)
+	assert.True(t, inMemory.GetParts()[0].GetInMemory())
+	assert.Equal(t, uint64(3), inMemory.GetSeriesCount())
+	assert.Equal(t, []*databasev1.BucketCount{{Begin: 0, Count: 3}}, inMemory.GetBuckets())
+	require.NoError(t, tst.Close())
+
+	tst, err = newTSTable(fileSystem, tmpPath, common.Position{}, logger.GetLogger("test"), timestamp.TimeRange{}, opt, nil)
+	require.NoError(t, err)
+	defer tst.Close()
+	td = storage.NewTableDigest(time.Hour)
+	tst.Digest(td)
+	flushed := td.Segment("seg")
+	require.Len(t, flushed.GetParts(), 1)
+	assert.False(t, flushed.GetParts()[0].GetInMemory())
+	assert.Equal(t, inMemory.GetParts()[0].GetChecksum(), flushed.GetParts()[0].GetChecksum(), "flushing keeps the blocks")
+	assert.Equal(t, inMemory.GetBuckets(), flushed.GetBuckets())
+}
+
+func Test_tsTable_RepairOnOpen(t *testing.T) {
+	tmpPath, defFn := test.Space(require.New(t))
+	defer defFn()
+	fileSystem := fs.NewLocalFileSystem()
+	opt := option{flushTimeout: time.Hour, mergePolicy: newDefaultMergePolicyForTesting(), protector: protector.Nop{}}
+	open := func() *tsTable {
+		tst, err := newTSTable(fileSystem, tmpPath, common.Position{}, logger.GetLogger("test"), timestamp.TimeRange{}, opt, nil)
+		require.NoError(t, err)
+		return tst
+	}
+	tst := open()
+	tst.mustAddElements(esTS1)
+	require.NoError(t, tst.Close())
+	tst = open()
+	tst.mustAddElements(esTS2)
+	epoch := tst.currentEpoch()
+	require.NoError(t, tst.Close())
+	tst = open()
+	parts, err := tst.readSnapshot(tst.currentEpoch())
+	require.NoError(t, err)
+	require.NoError(t, tst.Close())
+	require.Len(t, parts, 2)
+	damaged, kept := parts[0], parts[1]
+	require.Less(t, damaged, kept)
+
+	// a crash leaves a damaged part, the orphan parts and a torn manifest.
+	require.NoError(t, os.Remove(filepath.Join(partPath(tmpPath, damaged), timestampsFilename)))
+	require.NoError(t, os.CopyFS(partPath(tmpPath, 0), os.DirFS(partPath(tmpPath, kept))))
+	require.NoError(t, os.CopyFS(partPath(tmpPath, kept+10), os.DirFS(partPath(tmpPath, kept))))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpPath, snapshotName(epoch+10)), []byte("[\"torn"), 0o600))
+
+	tst = open()
+	s := tst.currentSnapshot()
+	require.NotNil(t, s)
+	var ids []uint64
+	for _, pw := range s.parts {
+		ids = append(ids, pw.ID())
+	}
+	s.decRef()
+	assert.Equal(t, []uint64{kept}, ids)
+	parts, err = tst.readSnapshot(tst.currentEpoch())
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{kept}, parts)
+	require.NoError(t, tst.Close())
+	assert.NoDirExists(t, partPath(tmpPath, 0))
+	assert.NoDirExists(t, partPath(tmpPath, kept+10))
+	quarantined, err := os.ReadDir(filepath.Join(tmpPath, storage.QuarantineDirname))
+	require.NoError(t, err)
+	assert.Len(t, quarantined, 3)
+
+	counts := func() map[storage.RepairAction]int {
+		result := make(map[storage.RepairAction]int)
+		for _, e := range storage.Repairs().Events {
+			if strings.HasPrefix(e.Path, tmpPath) {
+				result[e.Action]++
+			}
+		}
+		return result
+	}
+	want := map[storage.RepairAction]int{
+		storage.RepairActionQuarantined: 3,
+		storage.RepairActionDropped:     1,
+		storage.RepairActionRemoved:     1,
+	}
+	assert.Equal(t, want, counts())
+	// the repaired table is consistent.
+	require.NoError(t, open().Close())
+	assert.Equal(t, want, counts())
+}
+
 func Test_tstIter(t *testing.T) {
 	type testCtx struct {
 		wantErr      error
@@ -226,7 +404,7 @@ func Test_tstIter(t *testing.T) {
 		for _, tt := range tests {
 			t.Run(tt.name, func(t *testing.T) {
 				tmpPath, defFn := test.Space(require.New(t))
-				index, _ := newElementIndex(context.TODO(), tmpPath, 0, nil)
+				index, _ := newElementIndex(context.TODO(), tmpPath, 0, nil, nil)
 				defer defFn()
 				tst := &tsTable{
 					index:         index,
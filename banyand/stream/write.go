@@ -20,6 +20,7 @@ package stream
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -36,6 +37,7 @@ import (
 	"github.com/apache/skywalking-banyandb/pkg/convert"
 	"github.com/apache/skywalking-banyandb/pkg/index"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/partition"
 	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
 	"github.com/apache/skywalking-banyandb/pkg/timestamp"
 )
@@ -43,17 +45,27 @@ import (
 type writeCallback struct {
 	l                   *logger.Logger
 	schemaRepo          *schemaRepo
+	replayCache         *storage.ReplayCache
+	chunks              *chunkAssembler
+	termLimit           indexTermLimit
 	maxDiskUsagePercent int
+	validateRouting     bool
 }
 
-func setUpWriteCallback(l *logger.Logger, schemaRepo *schemaRepo, maxDiskUsagePercent int) bus.MessageListener {
+func setUpWriteCallback(l *logger.Logger, schemaRepo *schemaRepo, maxDiskUsagePercent int, replayCache *storage.ReplayCache,
+	validateRouting bool, termLimit indexTermLimit,
+) bus.MessageListener {
 	if maxDiskUsagePercent > 100 {
 		maxDiskUsagePercent = 100
 	}
 	return &writeCallback{
 		l:                   l,
 		schemaRepo:          schemaRepo,
+		replayCache:         replayCache,
+		chunks:              newChunkAssembler(),
+		termLimit:           termLimit,
 		maxDiskUsagePercent: maxDiskUsagePercent,
+		validateRouting:     validateRouting,
 	}
 }
 
@@ -77,23 +89,77 @@ func (w *writeCallback) handle(dst map[string]*elementsInGroup, writeEvent *stre
 		return nil, fmt.Errorf("invalid timestamp: %w", err)
 	}
 	ts := t.UnixNano()
-	eg, err := w.prepareElementsInGroup(dst, writeEvent, ts)
+	eg, err := w.prepareElementsInGroup(dst, writeEvent.Request.Metadata.Group)
 	if err != nil {
 		return nil, err
 	}
-	et, err := w.prepareElementsInTable(eg, writeEvent, ts)
+	if eg.latestTS < ts {
+		eg.latestTS = ts
+	}
+	et, err := prepareElementsInTable(eg, common.ShardID(writeEvent.ShardId), ts, storage.AckOnFlush(writeEvent.GetRequest().GetAckLevel(), eg.group))
 	if err != nil {
 		return nil, err
 	}
-	err = w.processElements(et, eg, writeEvent, docIDBuilder, ts)
+	stm, ok := w.schemaRepo.loadStream(writeEvent.GetRequest().GetMetadata())
+	if !ok {
+		return nil, fmt.Errorf("cannot find stream definition: %s", writeEvent.GetRequest().GetMetadata())
+	}
+	err = processElements(stm, et, eg, writeEvent, docIDBuilder, ts, w.termLimit)
 	if err != nil {
 		return nil, err
 	}
 	return dst, nil
 }
 
-func (w *writeCallback) prepareElementsInGroup(dst map[string]*elementsInGroup, writeEvent *streamv1.InternalWriteRequest, ts int64) (*elementsInGroup, error) {
-	gn := writeEvent.Request.Metadata.Group
+// handleBatch writes the elements of a shard grouped by the liaison.
+// The group is loaded once for the batch, and the stream definitions once for each stream.
+func (w *writeCallback) handleBatch(dst map[string]*elementsInGroup, batch *streamv1.InternalWriteBatch,
+	replay *storage.ReplayBatch, docIDBuilder *strings.Builder,
+) (map[string]*elementsInGroup, error) {
+	eg, err := w.prepareElementsInGroup(dst, batch.GetGroup())
+	if err != nil {
+		return nil, err
+	}
+	shardID := common.ShardID(batch.GetShardId())
+	streams := make(map[string]*stream)
+	for _, writeEvent := range batch.GetRequests() {
+		req := writeEvent.GetRequest()
+		if replay.Seen(batch.GetGroup(), shardID, req.GetIdempotencyKey()) {
+			w.l.Debug().Str("group", batch.GetGroup()).Str("key", req.GetIdempotencyKey()).Msg("drop the duplicate write")
+			continue
+		}
+		t := req.GetElement().GetTimestamp().AsTime().Local()
+		if err = timestamp.Check(t); err != nil {
+			return nil, fmt.Errorf("invalid timestamp: %w", err)
+		}
+		ts := t.UnixNano()
+		if eg.latestTS < ts {
+			eg.latestTS = ts
+		}
+		et, err := prepareElementsInTable(eg, shardID, ts, storage.AckOnFlush(req.GetAckLevel(), eg.group))
+		if err != nil {
+			return nil, err
+		}
+		stm, ok := streams[req.GetMetadata().GetName()]
+		if !ok {
+			if stm, ok = w.schemaRepo.loadStream(req.GetMetadata()); !ok {
+				return nil, fmt.Errorf("cannot find stream definition: %s", req.GetMetadata())
+			}
+			streams[req.GetMetadata().GetName()] = stm
+		}
+		if err = processElements(stm, et, eg, writeEvent, docIDBuilder, ts, w.termLimit); err != nil {
+			if errors.Is(err, errIndexTermTooLong) {
+				w.l.Warn().Err(err).Str("group", batch.GetGroup()).Str("stream", req.GetMetadata().GetName()).
+					Str("element", req.GetElement().GetElementId()).Msg("reject the write")
+				continue
+			}
+			return nil, err
+		}
+	}
+	return dst, nil
+}
+
+func (w *writeCallback) prepareElementsInGroup(dst map[string]*elementsInGroup, gn string) (*elementsInGroup, error) {
 	tsdb, err := w.schemaRepo.loadTSDB(gn)
 	if err != nil {
 		return nil, fmt.Errorf("cannot load tsdb for group %s: %w", gn, err)
@@ -105,20 +171,22 @@ func (w *writeCallback) prepareElementsInGroup(dst map[string]*elementsInGroup,
 			tsdb:        tsdb,
 			tables:      make([]*elementsInTable, 0),
 			segments:    make([]storage.Segment[*tsTable, option], 0),
-			docIDsAdded: make(map[uint64]struct{}), // Initialize the map
+			docIDsAdded: make(map[uint64]int), // Initialize the map
+		}
+		if g, ok := w.schemaRepo.LoadGroup(gn); ok {
+			eg.group = g.GetSchema()
 		}
 		dst[gn] = eg
 	}
-	if eg.latestTS < ts {
-		eg.latestTS = ts
-	}
 	return eg, nil
 }
 
-func (w *writeCallback) prepareElementsInTable(eg *elementsInGroup, writeEvent *streamv1.InternalWriteRequest, ts int64) (*elementsInTable, error) {
+// prepareElementsInTable returns the elements of the table containing ts.
+// The elements acknowledged after the flush are held apart from the others, since they're flushed to the disk immediately.
+func prepareElementsInTable(eg *elementsInGroup, shardID common.ShardID, ts int64, flush bool) (*elementsInTable, error) {
 	var et *elementsInTable
 	for i := range eg.tables {
-		if eg.tables[i].timeRange.Contains(ts) {
+		if eg.tables[i].flush == flush && eg.tables[i].timeRange.Contains(ts) {
 			et = eg.tables[i]
 			break
 		}
@@ -141,7 +209,6 @@ func (w *writeCallback) prepareElementsInTable(eg *elementsInGroup, writeEvent *
 			eg.segments = append(eg.segments, segment)
 		}
 
-		shardID := common.ShardID(writeEvent.ShardId)
 		tstb, err := segment.CreateTSTableIfNotExist(shardID)
 		if err != nil {
 			return nil, fmt.Errorf("cannot create ts table: %w", err)
@@ -151,6 +218,7 @@ func (w *writeCallback) prepareElementsInTable(eg *elementsInGroup, writeEvent *
 			timeRange: segment.GetTimeRange(),
 			tsTable:   tstb,
 			elements:  generateElements(),
+			flush:     flush,
 		}
 		et.elements.reset()
 		eg.tables = append(eg.tables, et)
@@ -158,30 +226,40 @@ func (w *writeCallback) prepareElementsInTable(eg *elementsInGroup, writeEvent *
 	return et, nil
 }
 
-func (w *writeCallback) processElements(et *elementsInTable, eg *elementsInGroup, writeEvent *streamv1.InternalWriteRequest,
-	docIDBuilder *strings.Builder, ts int64,
+func processElements(stm *stream, et *elementsInTable, eg *elementsInGroup, writeEvent *streamv1.InternalWriteRequest,
+	docIDBuilder *strings.Builder, ts int64, termLimit indexTermLimit,
 ) error {
 	req := writeEvent.Request
+	is := stm.loadIndexSchema()
 
-	et.elements.timestamps = append(et.elements.timestamps, ts)
-	docIDBuilder.Reset()
-	docIDBuilder.WriteString(req.Metadata.Name)
-	docIDBuilder.WriteByte('|')
-	docIDBuilder.WriteString(req.Element.ElementId)
-	eID := convert.HashStr(docIDBuilder.String())
-	et.elements.elementIDs = append(et.elements.elementIDs, eID)
+	// the terms are checked before anything is appended, so a rejected element leaves no trace in the table.
+	maxTermLength := 0
+	if exceeded, reject := termLimit.check(is, req.GetElement().GetTagFamilies()); reject {
+		et.tsTable.incTotalIndexTermExceeded(1, indexTermPolicyReject)
+		return fmt.Errorf("%w: the limit is %d bytes", errIndexTermTooLong, termLimit.maxLength)
+	} else if exceeded {
+		et.tsTable.incTotalIndexTermExceeded(1, indexTermPolicyTruncate)
+		maxTermLength = termLimit.maxLength
+	}
 
-	stm, ok := w.schemaRepo.loadStream(writeEvent.GetRequest().GetMetadata())
-	if !ok {
-		return fmt.Errorf("cannot find stream definition: %s", writeEvent.GetRequest().GetMetadata())
+	et.elements.timestamps = append(et.elements.timestamps, ts)
+	// the element ID generated by the liaison is used as is.
+	eID := writeEvent.ElementId
+	if eID == 0 {
+		docIDBuilder.Reset()
+		docIDBuilder.WriteString(req.Metadata.Name)
+		docIDBuilder.WriteByte('|')
+		docIDBuilder.WriteString(req.Element.ElementId)
+		eID = convert.HashStr(docIDBuilder.String())
 	}
+	et.elements.elementIDs = append(et.elements.elementIDs, eID)
 
 	fLen := len(req.Element.GetTagFamilies())
 	if fLen < 1 {
 		return fmt.Errorf("%s has no tag family", req)
 	}
-	if fLen > len(stm.schema.GetTagFamilies()) {
-		return fmt.Errorf("%s has more tag families than %s", req.Metadata, stm.schema)
+	if fLen > len(is.schema.GetTagFamilies()) {
+		return fmt.Errorf("%s has more tag families than %s", req.Metadata, is.schema)
 	}
 
 	series := &pbv1.Series{
@@ -193,17 +271,11 @@ func (w *writeCallback) processElements(et *elementsInTable, eg *elementsInGroup
 	}
 	et.elements.seriesIDs = append(et.elements.seriesIDs, series.ID)
 
-	is := stm.indexSchema.Load().(indexSchema)
-	tagFamilies := make([]tagValues, 0, len(stm.schema.TagFamilies))
+	tagFamilies := make([]tagValues, 0, len(is.schema.TagFamilies))
 	indexedTags := make(map[string]map[string]struct{})
 	var fields []index.Field
 
-	if len(is.indexRuleLocators.TagFamilyTRule) != len(stm.GetSchema().GetTagFamilies()) {
-		return fmt.Errorf("metadata crashed, tag family rule length %d, tag family length %d",
-			len(is.indexRuleLocators.TagFamilyTRule), len(stm.GetSchema().GetTagFamilies()))
-	}
-
-	for i := range stm.GetSchema().GetTagFamilies() {
+	for i := range is.schema.GetTagFamilies() {
 		var tagFamily *modelv1.TagFamilyForWrite
 		if len(req.Element.TagFamilies) <= i {
 			tagFamily = pbv1.NullTagFamily
@@ -211,7 +283,7 @@ func (w *writeCallback) processElements(et *elementsInTable, eg *elementsInGroup
 			tagFamily = req.Element.TagFamilies[i]
 		}
 		tfr := is.indexRuleLocators.TagFamilyTRule[i]
-		tagFamilySpec := stm.GetSchema().GetTagFamilies()[i]
+		tagFamilySpec := is.schema.GetTagFamilies()[i]
 		tf := tagValues{
 			tag: tagFamilySpec.Name,
 		}
@@ -233,7 +305,7 @@ func (w *writeCallback) processElements(et *elementsInTable, eg *elementsInGroup
 						IndexRuleID: r.GetMetadata().GetId(),
 						Analyzer:    r.Analyzer,
 						SeriesID:    series.ID,
-					}, t.Type, tagValue, r.GetNoSort())
+					}, t.Type, tagValue, r, maxTermLength)
 				} else if r.GetType() == databasev1.IndexRule_TYPE_SKIPPING {
 					indexed = true
 				}
@@ -246,11 +318,19 @@ func (w *writeCallback) processElements(et *elementsInTable, eg *elementsInGroup
 			tv.indexed = indexed
 			tf.values = append(tf.values, tv)
 		}
+		if len(tf.values) > 0 && tagFamilySpec.GetLayout() == databasev1.TagFamilyLayout_TAG_FAMILY_LAYOUT_PAYLOAD {
+			payload := encodePayload(tf.values)
+			for _, v := range tf.values {
+				releaseTagValue(v)
+			}
+			tf.values = append(tf.values[:0], payload)
+		}
 		if len(tf.values) > 0 {
 			tagFamilies = append(tagFamilies, tf)
 		}
 	}
 	et.elements.tagFamilies = append(et.elements.tagFamilies, tagFamilies)
+	fields = appendTraceFields(fields, req.Element, series.ID)
 
 	et.docs = append(et.docs, index.Document{
 		DocID:     eID,
@@ -259,12 +339,16 @@ func (w *writeCallback) processElements(et *elementsInTable, eg *elementsInGroup
 	})
 
 	docID := uint64(series.ID)
-	if _, exists := eg.docIDsAdded[docID]; !exists {
+	if pos, exists := eg.docIDsAdded[docID]; exists {
+		eg.docs[pos].Seen(ts)
+	} else {
+		eg.docIDsAdded[docID] = len(eg.docs)
 		eg.docs = append(eg.docs, index.Document{
 			DocID:        docID,
 			EntityValues: series.Buffer,
+			FirstSeen:    ts,
+			LastSeen:     ts,
 		})
-		eg.docIDsAdded[docID] = struct{}{}
 	}
 
 	return nil
@@ -280,8 +364,14 @@ func (w *writeCallback) Rev(_ context.Context, message bus.Message) (resp bus.Me
 		w.l.Warn().Msg("empty event")
 		return
 	}
+	now := time.Now()
+	if n := w.chunks.expire(now); n > 0 {
+		w.l.Warn().Int("count", n).Msg("drop the chunked elements whose chunks are missing")
+	}
 	groups := make(map[string]*elementsInGroup)
 	var builder strings.Builder
+	replay := w.replayCache.NewBatch()
+	var misrouted int
 	for i := range events {
 		var writeEvent *streamv1.InternalWriteRequest
 		switch e := events[i].(type) {
@@ -297,10 +387,54 @@ func (w *writeCallback) Rev(_ context.Context, message bus.Message) (resp bus.Me
 			w.l.Warn().Msg("invalid event data type")
 			continue
 		}
+		if batch := writeEvent.GetBatch(); batch != nil {
+			if w.validateRouting {
+				if err := w.checkBatchRouting(batch); err != nil {
+					w.l.Error().Err(err).Str("group", batch.GetGroup()).Uint32("shard", batch.GetShardId()).Msg("reject the write")
+					misrouted += len(batch.GetRequests())
+					continue
+				}
+			}
+			var err error
+			if groups, err = w.handleBatch(groups, batch, replay, &builder); err != nil {
+				w.l.Error().Err(err).Msg("cannot handle write batch")
+				groups = make(map[string]*elementsInGroup)
+				replay.Reset()
+			}
+			continue
+		}
+		if writeEvent.GetChunk() != nil {
+			var err error
+			if writeEvent, err = w.chunks.add(writeEvent, now); err != nil {
+				w.l.Error().Err(err).Msg("cannot reassemble the chunked element")
+				continue
+			}
+			if writeEvent == nil {
+				continue
+			}
+		}
+		req := writeEvent.GetRequest()
+		if w.validateRouting {
+			if err := w.checkRouting(writeEvent); err != nil {
+				w.l.Error().Err(err).RawJSON("written", logger.Proto(req)).Msg("reject the write")
+				misrouted++
+				continue
+			}
+		}
+		if replay.Seen(req.GetMetadata().GetGroup(), common.ShardID(writeEvent.ShardId), req.GetIdempotencyKey()) {
+			w.l.Debug().Str("group", req.GetMetadata().GetGroup()).Str("key", req.GetIdempotencyKey()).Msg("drop the duplicate write")
+			continue
+		}
 		var err error
 		if groups, err = w.handle(groups, writeEvent, &builder); err != nil {
+			if errors.Is(err, errIndexTermTooLong) {
+				w.l.Warn().Err(err).Str("group", req.GetMetadata().GetGroup()).Str("stream", req.GetMetadata().GetName()).
+					Str("element", req.GetElement().GetElementId()).Msg("reject the write")
+				continue
+			}
 			w.l.Error().Err(err).Msg("cannot handle write event")
 			groups = make(map[string]*elementsInGroup)
+			replay.Reset()
 			continue
 		}
 	}
@@ -308,7 +442,11 @@ func (w *writeCallback) Rev(_ context.Context, message bus.Message) (resp bus.Me
 		g := groups[i]
 		for j := range g.tables {
 			es := g.tables[j]
-			es.tsTable.mustAddElements(es.elements)
+			if es.flush {
+				es.tsTable.mustFlushElements(es.elements)
+			} else {
+				es.tsTable.mustAddElements(es.elements)
+			}
 			releaseElements(es.elements)
 			if len(es.docs) > 0 {
 				index := es.tsTable.Index()
@@ -317,19 +455,62 @@ func (w *writeCallback) Rev(_ context.Context, message bus.Message) (resp bus.Me
 				}
 			}
 		}
-		if len(g.docs) > 0 {
-			for _, segment := range g.segments {
+		for _, segment := range g.segments {
+			if len(g.docs) > 0 {
 				if err := segment.IndexDB().Insert(g.docs); err != nil {
 					w.l.Error().Err(err).Msg("cannot write index")
 				}
-				segment.DecRef()
 			}
+			segment.DecRef()
 		}
 		g.tsdb.Tick(g.latestTS)
 	}
+	replay.Commit()
+	if misrouted > 0 {
+		resp = bus.NewMessage(message.ID(), common.NewErrorWithStatus(modelv1.Status_STATUS_MISROUTED,
+			fmt.Sprintf("%d elements are written to the wrong shards", misrouted)))
+	}
 	return
 }
 
+// checkRouting verifies the shard of the element against the stream schema,
+// since the smart clients could write to the data node directly.
+func (w *writeCallback) checkRouting(writeEvent *streamv1.InternalWriteRequest) error {
+	req := writeEvent.GetRequest()
+	stm, ok := w.schemaRepo.loadStream(req.GetMetadata())
+	if !ok {
+		return fmt.Errorf("cannot find stream definition: %s", req.GetMetadata())
+	}
+	g, ok := w.schemaRepo.LoadGroup(req.GetMetadata().GetGroup())
+	if !ok {
+		return fmt.Errorf("group %s not found", req.GetMetadata().GetGroup())
+	}
+	var locator partition.Locator
+	if shardingKey := stm.schema.GetShardingKey(); len(shardingKey.GetTagNames()) > 0 {
+		locator = partition.NewShardingKeyLocator(stm.schema.GetTagFamilies(), shardingKey)
+	} else {
+		locator = partition.NewEntityLocator(stm.schema.GetTagFamilies(), stm.schema.GetEntity(), 0)
+	}
+	return locator.CheckShard(req.GetMetadata().GetName(), req.GetElement().GetTagFamilies(),
+		g.GetSchema().GetResourceOpts().GetShardNum(), writeEvent.GetShardId())
+}
+
+// checkBatchRouting verifies the shards of the elements of a batch.
+func (w *writeCallback) checkBatchRouting(batch *streamv1.InternalWriteBatch) error {
+	for _, writeEvent := range batch.GetRequests() {
+		if writeEvent.GetRequest().GetMetadata().GetGroup() != batch.GetGroup() {
+			return fmt.Errorf("%s doesn't belong to the group %s of the batch", writeEvent.GetRequest().GetMetadata(), batch.GetGroup())
+		}
+		if writeEvent.GetShardId() != batch.GetShardId() {
+			return fmt.Errorf("%s doesn't belong to the shard %d of the batch", writeEvent.GetRequest().GetMetadata(), batch.GetShardId())
+		}
+		if err := w.checkRouting(writeEvent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func encodeTagValue(name string, tagType databasev1.TagType, tagVal *modelv1.TagValue) *tagValue {
 	tv := generateTagValue()
 	tv.tag = name
@@ -373,7 +554,25 @@ func encodeTagValue(name string, tagType databasev1.TagType, tagVal *modelv1.Tag
 	return tv
 }
 
-func appendField(dest []index.Field, fieldKey index.FieldKey, tagType databasev1.TagType, tagVal *modelv1.TagValue, noSort bool) []index.Field {
+// appendTraceFields indexes the trace ID and the span ID of the element, which are looked up regardless of the series.
+func appendTraceFields(dest []index.Field, element *streamv1.ElementValue, seriesID common.SeriesID) []index.Field {
+	if traceID := element.GetTraceId(); traceID != "" {
+		f := index.NewStringField(index.FieldKey{TagName: traceIDField, SeriesID: seriesID}, traceID)
+		f.NoSort = true
+		dest = append(dest, f)
+	}
+	if spanID := element.GetSpanId(); spanID != "" {
+		f := index.NewStringField(index.FieldKey{TagName: spanIDField, SeriesID: seriesID}, spanID)
+		f.NoSort = true
+		dest = append(dest, f)
+	}
+	return dest
+}
+
+// appendField indexes the tag value with the rule, cutting the string and the binary terms to maxTermLength bytes if it's positive.
+func appendField(dest []index.Field, fieldKey index.FieldKey, tagType databasev1.TagType, tagVal *modelv1.TagValue,
+	r *databasev1.IndexRule, maxTermLength int,
+) []index.Field {
 	switch tagType {
 	case databasev1.TagType_TAG_TYPE_INT:
 		v := tagVal.GetInt()
@@ -381,23 +580,27 @@ func appendField(dest []index.Field, fieldKey index.FieldKey, tagType databasev1
 			return dest
 		}
 		f := index.NewIntField(fieldKey, v.Value)
-		f.NoSort = noSort
+		f.NoSort = r.GetNoSort()
+		f.Store = r.GetStoreValue()
 		dest = append(dest, f)
 	case databasev1.TagType_TAG_TYPE_STRING:
 		v := tagVal.GetStr()
 		if v == nil {
 			return dest
 		}
-		f := index.NewStringField(fieldKey, v.Value)
-		f.NoSort = noSort
+		f := index.NewStringField(fieldKey, truncateTerm(v.Value, maxTermLength))
+		f.NoSort = r.GetNoSort()
+		f.Store = r.GetStoreValue()
+		f.CaseInsensitive = r.GetCaseInsensitive()
 		dest = append(dest, f)
 	case databasev1.TagType_TAG_TYPE_DATA_BINARY:
 		v := tagVal.GetBinaryData()
 		if v == nil {
 			return dest
 		}
-		f := index.NewBytesField(fieldKey, v)
-		f.NoSort = noSort
+		f := index.NewBytesField(fieldKey, truncateBinaryTerm(v, maxTermLength))
+		f.NoSort = r.GetNoSort()
+		f.Store = r.GetStoreValue()
 		dest = append(dest, f)
 	case databasev1.TagType_TAG_TYPE_INT_ARRAY:
 		if tagVal.GetIntArray() == nil {
@@ -405,7 +608,8 @@ func appendField(dest []index.Field, fieldKey index.FieldKey, tagType databasev1
 		}
 		for i := range tagVal.GetIntArray().Value {
 			f := index.NewIntField(fieldKey, tagVal.GetIntArray().Value[i])
-			f.NoSort = noSort
+			f.NoSort = r.GetNoSort()
+			f.Store = r.GetStoreValue()
 			dest = append(dest, f)
 		}
 	case databasev1.TagType_TAG_TYPE_STRING_ARRAY:
@@ -413,8 +617,10 @@ func appendField(dest []index.Field, fieldKey index.FieldKey, tagType databasev1
 			return dest
 		}
 		for i := range tagVal.GetStrArray().Value {
-			f := index.NewStringField(fieldKey, tagVal.GetStrArray().Value[i])
-			f.NoSort = noSort
+			f := index.NewStringField(fieldKey, truncateTerm(tagVal.GetStrArray().Value[i], maxTermLength))
+			f.NoSort = r.GetNoSort()
+			f.Store = r.GetStoreValue()
+			f.CaseInsensitive = r.GetCaseInsensitive()
 			dest = append(dest, f)
 		}
 	default:
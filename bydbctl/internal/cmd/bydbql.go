@@ -0,0 +1,63 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/apache/skywalking-banyandb/pkg/version"
+)
+
+const bydbqlUsage = `The statement is in the form of:
+	SELECT projection FROM STREAM|MEASURE name [IN group [, group]...]
+	  [TIME BETWEEN 'begin' AND 'end' | TIME >|>=|<|<= 'time']
+	  [WHERE condition]
+	  [GROUP BY tag [, tag]...]
+	  [ORDER BY TIME|index_rule [ASC|DESC]]
+	  [LIMIT n] [OFFSET n]
+		The group from the flag or the config file is used if the IN clause is absent.
+		Times can be absolute time like "2006-01-02T15:04:05Z07:00", relative time like "-30m", or "now".
+		The time range is the past 30 minutes if the TIME clause is absent.`
+
+func newBydbQLCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "bydbql [-g group] statement",
+		Version: version.Build(),
+		Short:   "Query data with a BanyanQL statement",
+		Long:    bydbqlUsage,
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			body, err := json.Marshal(map[string]string{
+				"query": strings.Join(args, " "),
+				"group": viper.GetString("group"),
+			})
+			if err != nil {
+				return err
+			}
+			return rest(func() ([]reqBody, error) { return []reqBody{{data: body}}, nil },
+				func(request request) (*resty.Response, error) {
+					return request.req.SetBody(request.data).Post(getPath("/api/v1/bydbql/query"))
+				}, yamlPrinter, enableTLS, insecure, cert)
+		},
+	}
+}
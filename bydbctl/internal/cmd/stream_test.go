@@ -244,6 +244,32 @@ projection:
 		}, flags.EventuallyTimeout).Should(Equal(5))
 	})
 
+	It("query stream data with bydbql", func() {
+		conn, err := grpclib.NewClient(
+			grpcAddr,
+			grpclib.WithTransportCredentials(insecure.NewCredentials()),
+		)
+		Expect(err).NotTo(HaveOccurred())
+
+		cases_stream_data.Write(conn, "sw", now, interval)
+		rootCmd.SetArgs([]string{"bydbql", "-a", addr, "-g", "default",
+			fmt.Sprintf("SELECT trace_id FROM STREAM sw TIME BETWEEN '%s' AND '%s'", nowStr, endStr)})
+		issue := func() string {
+			return capturer.CaptureStdout(func() {
+				err := rootCmd.Execute()
+				Expect(err).NotTo(HaveOccurred())
+			})
+		}
+		Eventually(issue, flags.EventuallyTimeout).ShouldNot(ContainSubstring("code:"))
+		Eventually(func() int {
+			out := issue()
+			resp := new(streamv1.QueryResponse)
+			helpers.UnmarshalYAML([]byte(out), resp)
+			GinkgoWriter.Println(resp)
+			return len(resp.Elements)
+		}, flags.EventuallyTimeout).Should(Equal(5))
+	})
+
 	DescribeTable("query stream data with time range flags", func(timeArgs ...string) {
 		conn, err := grpclib.NewClient(
 			grpcAddr,
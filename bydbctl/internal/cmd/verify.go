@@ -0,0 +1,127 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"go.uber.org/multierr"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/pkg/grpchelper"
+	"github.com/apache/skywalking-banyandb/pkg/integrity"
+	"github.com/apache/skywalking-banyandb/pkg/version"
+)
+
+const verifyTimeout = time.Minute
+
+var errReplicasDiverged = errors.New("the replicas diverge")
+
+func newVerifyCmd() *cobra.Command {
+	var nodes []string
+	var shardID uint32
+	var bucket time.Duration
+	verifyCmd := &cobra.Command{
+		Use:     "verify -g group --shard id --nodes addr1,addr2",
+		Version: version.Build(),
+		Short:   "Compare the replicas of a shard on the data nodes",
+		Long: `Compare the part checksums, the series counts and the per-bucket element counts of the replicas of a shard.
+The nodes are the gRPC addresses of the data nodes holding the replicas.
+The divergent parts alone are reported as a note, since every replica flushes and merges its parts independently.`,
+		SilenceUsage: true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			group := viper.GetString("group")
+			if group == "" {
+				return errors.New("please specify a group through the flag or the config file")
+			}
+			if len(nodes) < 2 {
+				return errors.New("at least two nodes are required to compare the replicas")
+			}
+			opts, err := grpchelper.SecureOptions(nil, enableTLS, insecure, cert)
+			if err != nil {
+				return err
+			}
+			req := &databasev1.IntegrityServiceDigestRequest{Group: group, ShardId: shardID, Bucket: durationpb.New(bucket)}
+			replicas := make([]integrity.Replica, 0, len(nodes))
+			for _, node := range nodes {
+				digest, errDigest := digestShard(node, req, opts)
+				if errDigest != nil {
+					return fmt.Errorf("failed to digest the shard on %s: %w", node, errDigest)
+				}
+				replicas = append(replicas, integrity.Replica{Node: node, Digest: digest})
+			}
+			var diverged bool
+			for _, d := range integrity.Compare(replicas) {
+				fmt.Println(formatDivergence(d, replicas))
+				diverged = diverged || d.Diverged()
+			}
+			if diverged {
+				return errReplicasDiverged
+			}
+			fmt.Printf("the replicas of shard %d in group %s are consistent\n", shardID, group)
+			return nil
+		},
+	}
+	verifyCmd.Flags().StringSliceVarP(&nodes, "nodes", "", nil, "The gRPC addresses of the data nodes holding the replicas")
+	verifyCmd.Flags().Uint32VarP(&shardID, "shard", "", 0, "The ID of the shard")
+	verifyCmd.Flags().DurationVarP(&bucket, "bucket", "", time.Hour, "The width of the time buckets counting the elements")
+	bindTLSRelatedFlag(verifyCmd)
+	return verifyCmd
+}
+
+func digestShard(addr string, req *databasev1.IntegrityServiceDigestRequest, opts []grpc.DialOption) (
+	resp *databasev1.IntegrityServiceDigestResponse, err error,
+) {
+	conn, err := grpchelper.Conn(addr, 10*time.Second, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = multierr.Append(err, conn.Close())
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), verifyTimeout)
+	defer cancel()
+	return databasev1.NewIntegrityServiceClient(conn).Digest(ctx, req)
+}
+
+func formatDivergence(d integrity.Divergence, replicas []integrity.Replica) string {
+	var sb strings.Builder
+	sb.WriteString(d.Segment)
+	sb.WriteString(" ")
+	sb.WriteString(string(d.Kind))
+	if d.Kind == integrity.KindElements {
+		sb.WriteString(" ")
+		sb.WriteString(time.Unix(0, d.Bucket).UTC().Format(time.RFC3339))
+	}
+	sb.WriteString(":")
+	for _, r := range replicas {
+		fmt.Fprintf(&sb, " %s=%d", r.Node, d.Values[r.Node])
+	}
+	if !d.Diverged() {
+		sb.WriteString(" (note)")
+	}
+	return sb.String()
+}
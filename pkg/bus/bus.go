@@ -30,6 +30,9 @@ import (
 
 	"github.com/apache/skywalking-banyandb/api/common"
 	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/fault"
+	"github.com/apache/skywalking-banyandb/pkg/run"
+	"github.com/apache/skywalking-banyandb/pkg/tracecontext"
 )
 
 type (
@@ -50,6 +53,7 @@ type Message struct {
 	payload       payload
 	nodeSelectors map[string][]string
 	timeRange     *modelv1.TimeRange
+	traceContext  tracecontext.TraceContext
 	node          string
 	id            MessageID
 	batchMode     bool
@@ -85,6 +89,18 @@ func (m Message) BatchModeEnabled() bool {
 	return m.batchMode
 }
 
+// TraceContext returns the trace context of the request which the Message serves.
+func (m Message) TraceContext() tracecontext.TraceContext {
+	return m.traceContext
+}
+
+// WithTraceContext returns a copy of the Message carrying the trace context.
+// The trace context is forwarded to the remote nodes along with the Message.
+func (m Message) WithTraceContext(tc tracecontext.TraceContext) Message {
+	m.traceContext = tc
+	return m
+}
+
 // NewMessage returns a new Message with a MessageID and embed data.
 func NewMessage(id MessageID, data interface{}) Message {
 	return Message{id: id, node: "local", payload: data}
@@ -171,6 +187,7 @@ func (t Topic) String() string {
 // The Bus allows publish-subscribe-style communication between components.
 type Bus struct {
 	topics map[Topic][]MessageListener
+	closer *run.Closer
 	mutex  sync.RWMutex
 }
 
@@ -178,12 +195,15 @@ type Bus struct {
 func NewBus() *Bus {
 	b := new(Bus)
 	b.topics = make(map[Topic][]MessageListener)
+	b.closer = run.NewCloser(0)
 	return b
 }
 
 var (
 	// ErrTopicNotExist hints the topic published doesn't exist.
 	ErrTopicNotExist = errors.New("the topic does not exist")
+	// ErrClosed hints the bus is closed.
+	ErrClosed = errors.New("the bus is closed")
 
 	errTopicEmpty    = errors.New("the topic is empty")
 	errListenerEmpty = errors.New("the message listener is empty")
@@ -222,6 +242,10 @@ func (b *Bus) Publish(ctx context.Context, topic Topic, message ...Message) (Fut
 	if topic.id == "" {
 		return nil, errTopicEmpty
 	}
+	if !b.closer.AddRunning() {
+		return nil, ErrClosed
+	}
+	defer b.closer.Done()
 	b.mutex.RLock()
 	defer b.mutex.RUnlock()
 	mll, exit := b.topics[topic]
@@ -243,6 +267,9 @@ func (b *Bus) Publish(ctx context.Context, topic Topic, message ...Message) (Fut
 		}
 
 		for _, m := range message {
+			if fault.Enabled && fault.Drop(fault.PointBusPublish, topic.String()) {
+				continue
+			}
 			if f != nil {
 				f.messages = append(f.messages, ml.Rev(ctx, m))
 			} else {
@@ -276,5 +303,7 @@ func (b *Bus) Subscribe(topic Topic, listener MessageListener) error {
 }
 
 // Close a Bus until all Messages are sent to Subscribers.
+// The Messages published after closing are rejected with ErrClosed.
 func (b *Bus) Close() {
+	b.closer.CloseThenWait()
 }
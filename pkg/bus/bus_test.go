@@ -257,6 +257,59 @@ func waitTimeout(wg *sync.WaitGroup, timeout time.Duration) bool {
 
 var _ MessageListener = new(mockListener)
 
+func TestBus_CloseDrainsInFlightMessages(t *testing.T) {
+	b := NewBus()
+	topic := UniTopic("default")
+	l := &blockingListener{received: make(chan struct{}), release: make(chan struct{})}
+	if err := b.Subscribe(topic, l); err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	published := make(chan error, 1)
+	go func() {
+		_, err := b.Publish(context.Background(), topic, NewMessage(1, nil))
+		published <- err
+	}()
+	<-l.received
+
+	closed := make(chan struct{})
+	go func() {
+		b.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+		t.Fatal("Close() returns before the in-flight message is handled")
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(l.release)
+	if err := <-published; err != nil {
+		t.Errorf("Publish() error = %v", err)
+	}
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close() is blocked after the in-flight message is handled")
+	}
+	if _, err := b.Publish(context.Background(), topic, NewMessage(2, nil)); !errors.Is(err, ErrClosed) {
+		t.Errorf("Publish() after Close() error = %v, want %v", err, ErrClosed)
+	}
+}
+
+type blockingListener struct {
+	received chan struct{}
+	release  chan struct{}
+}
+
+func (l *blockingListener) CheckHealth() *common.Error {
+	return nil
+}
+
+func (l *blockingListener) Rev(_ context.Context, _ Message) Message {
+	close(l.received)
+	<-l.release
+	return Message{}
+}
+
 type mockListener struct {
 	wg        *sync.WaitGroup
 	closeWg   *sync.WaitGroup
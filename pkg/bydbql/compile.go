@@ -0,0 +1,325 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package bydbql
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	str2duration "github.com/xhit/go-str2duration/v2"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+)
+
+// DefaultTimeRange is the length of the time range when the TIME clause is absent or has a single bound.
+const DefaultTimeRange = 30 * time.Minute
+
+// ErrInvalidQuery indicates the statement doesn't match the schema.
+var ErrInvalidQuery = errors.New("invalid query")
+
+// StreamQuery compiles the query against the stream schema. Relative times are based on now.
+func (q *Query) StreamQuery(s *databasev1.Stream, now time.Time) (*streamv1.QueryRequest, error) {
+	if q.Catalog != commonv1.Catalog_CATALOG_STREAM {
+		return nil, errors.WithMessagef(ErrInvalidQuery, "%s is not a stream", q.Name)
+	}
+	if len(q.GroupBy) > 0 {
+		return nil, errors.WithMessage(ErrInvalidQuery, "GROUP BY is not supported by streams")
+	}
+	tags := tagSpecs(s.GetTagFamilies())
+	var names []string
+	for _, item := range q.Projection {
+		if item.Agg != modelv1.AggregationFunction_AGGREGATION_FUNCTION_UNSPECIFIED {
+			return nil, errors.WithMessage(ErrInvalidQuery, "aggregation is not supported by streams")
+		}
+		if _, ok := tags[item.Name]; !ok {
+			return nil, errors.WithMessagef(ErrInvalidQuery, "tag %s is not found", item.Name)
+		}
+		names = append(names, item.Name)
+	}
+	tr, err := q.timeRange(now)
+	if err != nil {
+		return nil, err
+	}
+	criteria, err := compileExpr(q.Criteria, tags)
+	if err != nil {
+		return nil, err
+	}
+	return &streamv1.QueryRequest{
+		Groups:     q.groups(s.GetMetadata()),
+		Name:       q.Name,
+		TimeRange:  tr,
+		Offset:     q.Offset,
+		Limit:      q.Limit,
+		OrderBy:    q.order(),
+		Criteria:   criteria,
+		Projection: tagProjection(s.GetTagFamilies(), names),
+	}, nil
+}
+
+// MeasureQuery compiles the query against the measure schema. Relative times are based on now.
+func (q *Query) MeasureQuery(m *databasev1.Measure, now time.Time) (*measurev1.QueryRequest, error) {
+	if q.Catalog != commonv1.Catalog_CATALOG_MEASURE {
+		return nil, errors.WithMessagef(ErrInvalidQuery, "%s is not a measure", q.Name)
+	}
+	tags := tagSpecs(m.GetTagFamilies())
+	fields := make(map[string]struct{}, len(m.GetFields()))
+	for _, f := range m.GetFields() {
+		fields[f.GetName()] = struct{}{}
+	}
+	req := &measurev1.QueryRequest{
+		Groups:  q.groups(m.GetMetadata()),
+		Name:    q.Name,
+		Offset:  q.Offset,
+		Limit:   q.Limit,
+		OrderBy: q.order(),
+	}
+	var tagNames, fieldNames []string
+	if q.Projection == nil {
+		for name := range tags {
+			tagNames = append(tagNames, name)
+		}
+		for _, f := range m.GetFields() {
+			fieldNames = append(fieldNames, f.GetName())
+		}
+	}
+	for _, item := range q.Projection {
+		if _, ok := fields[item.Name]; ok {
+			fieldNames = append(fieldNames, item.Name)
+			if item.Agg == modelv1.AggregationFunction_AGGREGATION_FUNCTION_UNSPECIFIED {
+				continue
+			}
+			if req.Agg != nil {
+				return nil, errors.WithMessage(ErrInvalidQuery, "only one aggregation is supported")
+			}
+			req.Agg = &measurev1.QueryRequest_Aggregation{Function: item.Agg, FieldName: item.Name}
+			continue
+		}
+		if _, ok := tags[item.Name]; !ok {
+			return nil, errors.WithMessagef(ErrInvalidQuery, "tag or field %s is not found", item.Name)
+		}
+		if item.Agg != modelv1.AggregationFunction_AGGREGATION_FUNCTION_UNSPECIFIED {
+			return nil, errors.WithMessagef(ErrInvalidQuery, "tag %s can't be aggregated", item.Name)
+		}
+		tagNames = append(tagNames, item.Name)
+	}
+	if len(q.GroupBy) > 0 {
+		if req.Agg == nil {
+			return nil, errors.WithMessage(ErrInvalidQuery, "GROUP BY requires an aggregation")
+		}
+		for _, name := range q.GroupBy {
+			if _, ok := tags[name]; !ok {
+				return nil, errors.WithMessagef(ErrInvalidQuery, "tag %s is not found", name)
+			}
+			if !contains(tagNames, name) {
+				tagNames = append(tagNames, name)
+			}
+		}
+		req.GroupBy = &measurev1.QueryRequest_GroupBy{
+			TagProjection: tagProjection(m.GetTagFamilies(), q.GroupBy),
+			FieldName:     req.Agg.FieldName,
+		}
+	}
+	req.TagProjection = tagProjection(m.GetTagFamilies(), tagNames)
+	if len(fieldNames) > 0 {
+		req.FieldProjection = &measurev1.QueryRequest_FieldProjection{Names: fieldNames}
+	}
+	var err error
+	if req.TimeRange, err = q.timeRange(now); err != nil {
+		return nil, err
+	}
+	if req.Criteria, err = compileExpr(q.Criteria, tags); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func (q *Query) groups(md *commonv1.Metadata) []string {
+	if len(q.Groups) > 0 {
+		return q.Groups
+	}
+	return []string{md.GetGroup()}
+}
+
+func (q *Query) order() *modelv1.QueryOrder {
+	if q.OrderBy == nil {
+		return nil
+	}
+	return &modelv1.QueryOrder{IndexRuleName: q.OrderBy.IndexRule, Sort: q.OrderBy.Sort}
+}
+
+func (q *Query) timeRange(now time.Time) (*modelv1.TimeRange, error) {
+	var begin, end time.Time
+	var err error
+	tr := q.TimeRange
+	if tr == nil {
+		tr = &TimeRange{}
+	}
+	switch {
+	case tr.Begin == "" && tr.End == "":
+		begin, end = now.Add(-DefaultTimeRange), now
+	case tr.Begin == "":
+		if end, err = parseTime(tr.End, now); err != nil {
+			return nil, err
+		}
+		begin = end.Add(-DefaultTimeRange)
+	case tr.End == "":
+		if begin, err = parseTime(tr.Begin, now); err != nil {
+			return nil, err
+		}
+		end = now
+	default:
+		if begin, err = parseTime(tr.Begin, now); err != nil {
+			return nil, err
+		}
+		if end, err = parseTime(tr.End, now); err != nil {
+			return nil, err
+		}
+	}
+	if end.Before(begin) {
+		return nil, errors.WithMessagef(ErrInvalidQuery, "the end time %s is before the begin time %s", end, begin)
+	}
+	return &modelv1.TimeRange{Begin: timestamppb.New(begin), End: timestamppb.New(end)}, nil
+}
+
+// parseTime parses an RFC3339 time, "now", or a duration relative to now such as "-30m".
+func parseTime(s string, now time.Time) (time.Time, error) {
+	if strings.EqualFold(s, "now") {
+		return now, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	d, err := str2duration.ParseDuration(s)
+	if err != nil {
+		return time.Time{}, errors.WithMessagef(ErrInvalidQuery, "time %s is neither absolute time nor relative time", s)
+	}
+	return now.Add(d), nil
+}
+
+func tagSpecs(families []*databasev1.TagFamilySpec) map[string]*databasev1.TagSpec {
+	tags := make(map[string]*databasev1.TagSpec)
+	for _, tf := range families {
+		for _, t := range tf.GetTags() {
+			tags[t.GetName()] = t
+		}
+	}
+	return tags
+}
+
+// tagProjection groups the tags by their families in the order of the schema.
+// All tags are projected if names is empty.
+func tagProjection(families []*databasev1.TagFamilySpec, names []string) *modelv1.TagProjection {
+	projection := &modelv1.TagProjection{}
+	for _, tf := range families {
+		var selected []string
+		for _, t := range tf.GetTags() {
+			if len(names) == 0 || contains(names, t.GetName()) {
+				selected = append(selected, t.GetName())
+			}
+		}
+		if len(selected) > 0 {
+			projection.TagFamilies = append(projection.TagFamilies, &modelv1.TagProjection_TagFamily{Name: tf.GetName(), Tags: selected})
+		}
+	}
+	return projection
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func compileExpr(e Expr, tags map[string]*databasev1.TagSpec) (*modelv1.Criteria, error) {
+	switch x := e.(type) {
+	case nil:
+		return nil, nil
+	case *Logical:
+		left, err := compileExpr(x.Left, tags)
+		if err != nil {
+			return nil, err
+		}
+		right, err := compileExpr(x.Right, tags)
+		if err != nil {
+			return nil, err
+		}
+		return &modelv1.Criteria{Exp: &modelv1.Criteria_Le{Le: &modelv1.LogicalExpression{Op: x.Op, Left: left, Right: right}}}, nil
+	case *Condition:
+		spec, ok := tags[x.Name]
+		if !ok {
+			return nil, errors.WithMessagef(ErrInvalidQuery, "tag %s is not found", x.Name)
+		}
+		if x.Op == modelv1.Condition_BINARY_OP_EXISTS || x.Op == modelv1.Condition_BINARY_OP_IS_NULL {
+			return &modelv1.Criteria{Exp: &modelv1.Criteria_Condition{Condition: &modelv1.Condition{Name: x.Name, Op: x.Op}}}, nil
+		}
+		v, err := tagValue(spec, x)
+		if err != nil {
+			return nil, err
+		}
+		return &modelv1.Criteria{Exp: &modelv1.Criteria_Condition{Condition: &modelv1.Condition{Name: x.Name, Op: x.Op, Value: v}}}, nil
+	}
+	return nil, errors.Errorf("unknown expression %T", e)
+}
+
+// tagValue converts the literals of the condition to a value in the type of the tag.
+// A list is converted to an array, and a single value is converted to a scalar.
+func tagValue(spec *databasev1.TagSpec, c *Condition) (*modelv1.TagValue, error) {
+	if !c.List && c.Values[0].IsNull {
+		return &modelv1.TagValue{Value: &modelv1.TagValue_Null{Null: structpb.NullValue_NULL_VALUE}}, nil
+	}
+	var isInt bool
+	switch spec.GetType() {
+	case databasev1.TagType_TAG_TYPE_INT, databasev1.TagType_TAG_TYPE_INT_ARRAY:
+		isInt = true
+	case databasev1.TagType_TAG_TYPE_STRING, databasev1.TagType_TAG_TYPE_STRING_ARRAY:
+	default:
+		return nil, errors.WithMessagef(ErrInvalidQuery, "tag %s in the type of %s can't be queried", spec.GetName(), spec.GetType())
+	}
+	for _, v := range c.Values {
+		if v.IsNull || v.IsInt != isInt {
+			return nil, errors.WithMessagef(ErrInvalidQuery, "the value of tag %s should be in the type of %s", spec.GetName(), spec.GetType())
+		}
+	}
+	switch {
+	case c.List && isInt:
+		arr := make([]int64, 0, len(c.Values))
+		for _, v := range c.Values {
+			arr = append(arr, v.Int)
+		}
+		return &modelv1.TagValue{Value: &modelv1.TagValue_IntArray{IntArray: &modelv1.IntArray{Value: arr}}}, nil
+	case c.List:
+		arr := make([]string, 0, len(c.Values))
+		for _, v := range c.Values {
+			arr = append(arr, v.Str)
+		}
+		return &modelv1.TagValue{Value: &modelv1.TagValue_StrArray{StrArray: &modelv1.StrArray{Value: arr}}}, nil
+	case isInt:
+		return &modelv1.TagValue{Value: &modelv1.TagValue_Int{Int: &modelv1.Int{Value: c.Values[0].Int}}}, nil
+	}
+	return &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: c.Values[0].Str}}}, nil
+}
@@ -0,0 +1,183 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package bydbql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+)
+
+var (
+	now    = time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	stream = &databasev1.Stream{
+		Metadata: &commonv1.Metadata{Group: "default", Name: "sw"},
+		TagFamilies: []*databasev1.TagFamilySpec{
+			{Name: "searchable", Tags: []*databasev1.TagSpec{
+				{Name: "trace_id", Type: databasev1.TagType_TAG_TYPE_STRING},
+				{Name: "duration", Type: databasev1.TagType_TAG_TYPE_INT},
+				{Name: "tags", Type: databasev1.TagType_TAG_TYPE_STRING_ARRAY},
+			}},
+			{Name: "data", Tags: []*databasev1.TagSpec{
+				{Name: "data_binary", Type: databasev1.TagType_TAG_TYPE_DATA_BINARY},
+			}},
+		},
+	}
+	measure = &databasev1.Measure{
+		Metadata: &commonv1.Metadata{Group: "sw_metric", Name: "service_cpm_minute"},
+		TagFamilies: []*databasev1.TagFamilySpec{
+			{Name: "default", Tags: []*databasev1.TagSpec{
+				{Name: "id", Type: databasev1.TagType_TAG_TYPE_STRING},
+				{Name: "entity_id", Type: databasev1.TagType_TAG_TYPE_STRING},
+			}},
+		},
+		Fields: []*databasev1.FieldSpec{{Name: "total"}, {Name: "value"}},
+	}
+)
+
+func TestStreamQuery(t *testing.T) {
+	q, err := Parse("SELECT duration, trace_id FROM STREAM sw TIME < '2024-05-01T00:00:00Z' " +
+		"WHERE duration > 100 AND tags HAVING ('a=b', 'c=d') ORDER BY TIME ASC LIMIT 5")
+	require.NoError(t, err)
+	req, err := q.StreamQuery(stream, now)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"default"}, req.Groups)
+	assert.True(t, now.Add(-DefaultTimeRange).Equal(req.TimeRange.Begin.AsTime()))
+	assert.True(t, now.Equal(req.TimeRange.End.AsTime()))
+	assert.Equal(t, uint32(5), req.Limit)
+	assert.True(t, proto.Equal(&modelv1.QueryOrder{Sort: modelv1.Sort_SORT_ASC}, req.OrderBy))
+	assert.True(t, proto.Equal(&modelv1.TagProjection{TagFamilies: []*modelv1.TagProjection_TagFamily{
+		{Name: "searchable", Tags: []string{"trace_id", "duration"}},
+	}}, req.Projection))
+	le := req.Criteria.GetLe()
+	require.NotNil(t, le)
+	assert.Equal(t, int64(100), le.Left.GetCondition().Value.GetInt().GetValue())
+	assert.Equal(t, []string{"a=b", "c=d"}, le.Right.GetCondition().Value.GetStrArray().GetValue())
+}
+
+func TestStreamQueryNullCheck(t *testing.T) {
+	q, err := Parse("SELECT trace_id FROM STREAM sw WHERE duration IS NULL OR data_binary IS NOT NULL")
+	require.NoError(t, err)
+	req, err := q.StreamQuery(stream, now)
+	require.NoError(t, err)
+	le := req.Criteria.GetLe()
+	require.NotNil(t, le)
+	assert.True(t, proto.Equal(&modelv1.Condition{Name: "duration", Op: modelv1.Condition_BINARY_OP_IS_NULL}, le.Left.GetCondition()))
+	assert.True(t, proto.Equal(&modelv1.Condition{Name: "data_binary", Op: modelv1.Condition_BINARY_OP_EXISTS}, le.Right.GetCondition()))
+}
+
+func TestStreamQueryPattern(t *testing.T) {
+	q, err := Parse("SELECT trace_id FROM STREAM sw WHERE trace_id STARTS_WITH 'trace-' AND tags LIKE 'http.method=G?T*'")
+	require.NoError(t, err)
+	req, err := q.StreamQuery(stream, now)
+	require.NoError(t, err)
+	le := req.Criteria.GetLe()
+	require.NotNil(t, le)
+	assert.Equal(t, modelv1.Condition_BINARY_OP_PREFIX, le.Left.GetCondition().Op)
+	assert.Equal(t, "trace-", le.Left.GetCondition().Value.GetStr().GetValue())
+	assert.Equal(t, modelv1.Condition_BINARY_OP_WILDCARD, le.Right.GetCondition().Op)
+	assert.Equal(t, "http.method=G?T*", le.Right.GetCondition().Value.GetStr().GetValue())
+}
+
+func TestStreamQueryNot(t *testing.T) {
+	q, err := Parse("SELECT trace_id FROM STREAM sw WHERE duration != 200 AND NOT (trace_id STARTS_WITH 'health-' OR NOT tags HAVING ('a=b'))")
+	require.NoError(t, err)
+	req, err := q.StreamQuery(stream, now)
+	require.NoError(t, err)
+	le := req.Criteria.GetLe()
+	require.NotNil(t, le)
+	assert.Equal(t, modelv1.LogicalExpression_LOGICAL_OP_AND, le.Op)
+	not := le.Right.GetLe()
+	require.NotNil(t, not)
+	assert.Equal(t, modelv1.LogicalExpression_LOGICAL_OP_NOT, not.Op)
+	assert.Nil(t, not.Right)
+	or := not.Left.GetLe()
+	assert.Equal(t, modelv1.LogicalExpression_LOGICAL_OP_OR, or.Op)
+	assert.Equal(t, modelv1.Condition_BINARY_OP_PREFIX, or.Left.GetCondition().Op)
+	assert.Equal(t, modelv1.LogicalExpression_LOGICAL_OP_NOT, or.Right.GetLe().Op)
+	assert.Equal(t, modelv1.Condition_BINARY_OP_HAVING, or.Right.GetLe().Left.GetCondition().Op)
+}
+
+func TestStreamQueryAllTags(t *testing.T) {
+	q, err := Parse("SELECT * FROM STREAM sw IN g1 TIME BETWEEN '-1h' AND 'now'")
+	require.NoError(t, err)
+	req, err := q.StreamQuery(stream, now)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"g1"}, req.Groups)
+	assert.True(t, now.Add(-time.Hour).Equal(req.TimeRange.Begin.AsTime()))
+	assert.Len(t, req.Projection.TagFamilies, 2)
+	assert.Nil(t, req.Criteria)
+}
+
+func TestMeasureQuery(t *testing.T) {
+	q, err := Parse("SELECT MEAN(value) FROM MEASURE service_cpm_minute WHERE id IN ('a', 'b') GROUP BY entity_id")
+	require.NoError(t, err)
+	req, err := q.MeasureQuery(measure, now)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sw_metric"}, req.Groups)
+	assert.True(t, proto.Equal(&measurev1.QueryRequest_Aggregation{
+		Function:  modelv1.AggregationFunction_AGGREGATION_FUNCTION_MEAN,
+		FieldName: "value",
+	}, req.Agg))
+	entity := &modelv1.TagProjection{TagFamilies: []*modelv1.TagProjection_TagFamily{{Name: "default", Tags: []string{"entity_id"}}}}
+	assert.True(t, proto.Equal(entity, req.GroupBy.TagProjection))
+	assert.True(t, proto.Equal(entity, req.TagProjection))
+	assert.Equal(t, "value", req.GroupBy.FieldName)
+	assert.Equal(t, []string{"value"}, req.FieldProjection.Names)
+	assert.Equal(t, modelv1.Condition_BINARY_OP_IN, req.Criteria.GetCondition().Op)
+	assert.Equal(t, []string{"a", "b"}, req.Criteria.GetCondition().Value.GetStrArray().GetValue())
+}
+
+func TestQueryInvalid(t *testing.T) {
+	for _, statement := range []string{
+		"SELECT unknown FROM STREAM sw",
+		"SELECT * FROM STREAM sw WHERE duration = 'x'",
+		"SELECT * FROM STREAM sw WHERE data_binary = 'x'",
+		"SELECT * FROM STREAM sw WHERE unknown = 'x'",
+		"SELECT * FROM STREAM sw TIME BETWEEN 'now' AND '-1h'",
+		"SELECT * FROM STREAM sw TIME > 'yesterday'",
+		"SELECT SUM(duration) FROM STREAM sw",
+		"SELECT * FROM STREAM sw GROUP BY trace_id",
+		"SELECT * FROM MEASURE sw",
+	} {
+		q, err := Parse(statement)
+		require.NoError(t, err, statement)
+		_, err = q.StreamQuery(stream, now)
+		assert.True(t, errors.Is(err, ErrInvalidQuery), "%q: %v", statement, err)
+	}
+	for _, statement := range []string{
+		"SELECT SUM(id) FROM MEASURE service_cpm_minute",
+		"SELECT SUM(value), MAX(total) FROM MEASURE service_cpm_minute",
+		"SELECT value FROM MEASURE service_cpm_minute GROUP BY id",
+		"SELECT SUM(value) FROM MEASURE service_cpm_minute GROUP BY value",
+	} {
+		q, err := Parse(statement)
+		require.NoError(t, err, statement)
+		_, err = q.MeasureQuery(measure, now)
+		assert.True(t, errors.Is(err, ErrInvalidQuery), "%q: %v", statement, err)
+	}
+}
@@ -0,0 +1,127 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package bydbql
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenKeyword
+	tokenString
+	tokenNumber
+	tokenSymbol
+)
+
+var keywords = map[string]struct{}{
+	"SELECT": {}, "FROM": {}, "STREAM": {}, "MEASURE": {}, "IN": {}, "TIME": {}, "BETWEEN": {},
+	"WHERE": {}, "AND": {}, "OR": {}, "NOT": {}, "HAVING": {}, "MATCH": {}, "NULL": {}, "IS": {}, "STARTS_WITH": {}, "LIKE": {},
+	"GROUP": {}, "ORDER": {}, "BY": {}, "ASC": {}, "DESC": {}, "LIMIT": {}, "OFFSET": {},
+}
+
+type token struct {
+	text string
+	kind tokenKind
+	pos  int
+}
+
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "end of input"
+	}
+	return "'" + t.text + "'"
+}
+
+// tokenize splits the statement into tokens. Keywords are upper-cased,
+// and quoted identifiers and strings are unquoted.
+func tokenize(input string) ([]token, error) {
+	var tokens []token
+	runes := []rune(input)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '\'' || r == '"' || r == '`':
+			var sb strings.Builder
+			j := i + 1
+			for ; j < len(runes); j++ {
+				if runes[j] == r {
+					// a doubled quote escapes itself
+					if j+1 < len(runes) && runes[j+1] == r {
+						sb.WriteRune(r)
+						j++
+						continue
+					}
+					break
+				}
+				sb.WriteRune(runes[j])
+			}
+			if j >= len(runes) {
+				return nil, errors.Errorf("unterminated quote at position %d", i)
+			}
+			kind := tokenIdent
+			if r == '\'' {
+				kind = tokenString
+			}
+			tokens = append(tokens, token{kind: kind, text: sb.String(), pos: i})
+			i = j + 1
+		case unicode.IsDigit(r) || (r == '-' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			j := i + 1
+			for j < len(runes) && unicode.IsDigit(runes[j]) {
+				j++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: string(runes[i:j]), pos: i})
+			i = j
+		case unicode.IsLetter(r) || r == '_':
+			j := i + 1
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_' || runes[j] == '.') {
+				j++
+			}
+			text := string(runes[i:j])
+			if _, ok := keywords[strings.ToUpper(text)]; ok {
+				tokens = append(tokens, token{kind: tokenKeyword, text: strings.ToUpper(text), pos: i})
+			} else {
+				tokens = append(tokens, token{kind: tokenIdent, text: text, pos: i})
+			}
+			i = j
+		default:
+			if i+1 < len(runes) {
+				switch two := string(runes[i : i+2]); two {
+				case "!=", "<>", "<=", ">=":
+					tokens = append(tokens, token{kind: tokenSymbol, text: two, pos: i})
+					i += 2
+					continue
+				}
+			}
+			if !strings.ContainsRune("(),*=<>", r) {
+				return nil, errors.Errorf("unexpected character '%c' at position %d", r, i)
+			}
+			tokens = append(tokens, token{kind: tokenSymbol, text: string(r), pos: i})
+			i++
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(runes)}), nil
+}
@@ -0,0 +1,528 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package bydbql implements BanyanQL, a SQL-like query language which compiles to the query requests of streams and measures.
+//
+//	SELECT projection FROM STREAM|MEASURE name [IN group [, group]...]
+//	  [TIME BETWEEN 'begin' AND 'end' | TIME >|>=|<|<= 'time']
+//	  [WHERE condition]
+//	  [GROUP BY tag [, tag]...]
+//	  [ORDER BY TIME|index_rule [ASC|DESC]]
+//	  [LIMIT n] [OFFSET n]
+package bydbql
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+)
+
+// ErrSyntax indicates the statement is not valid BanyanQL.
+var ErrSyntax = errors.New("syntax error")
+
+// Query is a parsed BanyanQL statement.
+type Query struct {
+	TimeRange  *TimeRange
+	Criteria   Expr
+	OrderBy    *OrderBy
+	Name       string
+	Groups     []string
+	Projection []Projection
+	GroupBy    []string
+	Catalog    commonv1.Catalog
+	Limit      uint32
+	Offset     uint32
+}
+
+// Projection is an item of the SELECT clause. Agg is unspecified if the item isn't aggregated.
+type Projection struct {
+	Name string
+	Agg  modelv1.AggregationFunction
+}
+
+// TimeRange holds the raw bounds of the TIME clause. An empty bound is computed when compiling.
+type TimeRange struct {
+	Begin string
+	End   string
+}
+
+// OrderBy sorts the result by the index rule. The result is sorted by time if IndexRule is empty.
+type OrderBy struct {
+	IndexRule string
+	Sort      modelv1.Sort
+}
+
+// Expr is a node of the WHERE clause, which is either a *Logical or a *Condition.
+type Expr interface {
+	expr()
+}
+
+// Logical combines two expressions with AND or OR, or negates the left one with NOT.
+type Logical struct {
+	Left  Expr
+	Right Expr
+	Op    modelv1.LogicalExpression_LogicalOp
+}
+
+// Condition compares a tag with values. List is true if the values are enclosed in parentheses.
+type Condition struct {
+	Name   string
+	Values []Value
+	Op     modelv1.Condition_BinaryOp
+	List   bool
+}
+
+// Value is a literal of a condition.
+type Value struct {
+	Str    string
+	Int    int64
+	IsInt  bool
+	IsNull bool
+}
+
+func (*Logical) expr()   {}
+func (*Condition) expr() {}
+
+var aggFunctions = map[string]modelv1.AggregationFunction{
+	"MEAN":  modelv1.AggregationFunction_AGGREGATION_FUNCTION_MEAN,
+	"AVG":   modelv1.AggregationFunction_AGGREGATION_FUNCTION_MEAN,
+	"MAX":   modelv1.AggregationFunction_AGGREGATION_FUNCTION_MAX,
+	"MIN":   modelv1.AggregationFunction_AGGREGATION_FUNCTION_MIN,
+	"COUNT": modelv1.AggregationFunction_AGGREGATION_FUNCTION_COUNT,
+	"SUM":   modelv1.AggregationFunction_AGGREGATION_FUNCTION_SUM,
+}
+
+var comparisons = map[string]modelv1.Condition_BinaryOp{
+	"=":  modelv1.Condition_BINARY_OP_EQ,
+	"!=": modelv1.Condition_BINARY_OP_NE,
+	"<>": modelv1.Condition_BINARY_OP_NE,
+	"<":  modelv1.Condition_BINARY_OP_LT,
+	"<=": modelv1.Condition_BINARY_OP_LE,
+	">":  modelv1.Condition_BINARY_OP_GT,
+	">=": modelv1.Condition_BINARY_OP_GE,
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// Parse parses a BanyanQL statement.
+func Parse(statement string) (*Query, error) {
+	tokens, err := tokenize(statement)
+	if err != nil {
+		return nil, errors.WithMessage(ErrSyntax, err.Error())
+	}
+	p := &parser{tokens: tokens}
+	q, err := p.parseQuery()
+	if err != nil {
+		return nil, errors.WithMessage(ErrSyntax, err.Error())
+	}
+	return q, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) isKeyword(kw string) bool {
+	t := p.peek()
+	return t.kind == tokenKeyword && t.text == kw
+}
+
+func (p *parser) acceptKeyword(kw string) bool {
+	if p.isKeyword(kw) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) acceptSymbol(s string) bool {
+	t := p.peek()
+	if t.kind == tokenSymbol && t.text == s {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectKeyword(kw string) error {
+	if !p.acceptKeyword(kw) {
+		return p.unexpected(kw)
+	}
+	return nil
+}
+
+func (p *parser) expectSymbol(s string) error {
+	if !p.acceptSymbol(s) {
+		return p.unexpected("'" + s + "'")
+	}
+	return nil
+}
+
+func (p *parser) unexpected(want string) error {
+	t := p.peek()
+	return errors.Errorf("expected %s but got %s at position %d", want, t, t.pos)
+}
+
+func (p *parser) ident() (string, error) {
+	t := p.peek()
+	if t.kind != tokenIdent {
+		return "", p.unexpected("identifier")
+	}
+	p.pos++
+	return t.text, nil
+}
+
+func (p *parser) identList() ([]string, error) {
+	var names []string
+	for {
+		n, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, n)
+		if !p.acceptSymbol(",") {
+			return names, nil
+		}
+	}
+}
+
+func (p *parser) uint() (uint32, error) {
+	t := p.peek()
+	if t.kind != tokenNumber {
+		return 0, p.unexpected("number")
+	}
+	p.pos++
+	n, err := strconv.ParseUint(t.text, 10, 32)
+	if err != nil {
+		return 0, errors.Errorf("invalid number %s at position %d", t, t.pos)
+	}
+	return uint32(n), nil
+}
+
+func (p *parser) str() (string, error) {
+	t := p.peek()
+	if t.kind != tokenString {
+		return "", p.unexpected("string")
+	}
+	p.pos++
+	return t.text, nil
+}
+
+func (p *parser) parseQuery() (*Query, error) {
+	q := &Query{}
+	var err error
+	if err = p.expectKeyword("SELECT"); err != nil {
+		return nil, err
+	}
+	if q.Projection, err = p.parseProjection(); err != nil {
+		return nil, err
+	}
+	if err = p.expectKeyword("FROM"); err != nil {
+		return nil, err
+	}
+	switch {
+	case p.acceptKeyword("STREAM"):
+		q.Catalog = commonv1.Catalog_CATALOG_STREAM
+	case p.acceptKeyword("MEASURE"):
+		q.Catalog = commonv1.Catalog_CATALOG_MEASURE
+	default:
+		return nil, p.unexpected("STREAM or MEASURE")
+	}
+	if q.Name, err = p.ident(); err != nil {
+		return nil, err
+	}
+	if p.acceptKeyword("IN") {
+		if q.Groups, err = p.identList(); err != nil {
+			return nil, err
+		}
+	}
+	if p.acceptKeyword("TIME") {
+		if q.TimeRange, err = p.parseTimeRange(); err != nil {
+			return nil, err
+		}
+	}
+	if p.acceptKeyword("WHERE") {
+		if q.Criteria, err = p.parseOr(); err != nil {
+			return nil, err
+		}
+	}
+	if p.acceptKeyword("GROUP") {
+		if err = p.expectKeyword("BY"); err != nil {
+			return nil, err
+		}
+		if q.GroupBy, err = p.identList(); err != nil {
+			return nil, err
+		}
+	}
+	if p.acceptKeyword("ORDER") {
+		if err = p.expectKeyword("BY"); err != nil {
+			return nil, err
+		}
+		q.OrderBy = &OrderBy{}
+		if !p.acceptKeyword("TIME") {
+			if q.OrderBy.IndexRule, err = p.ident(); err != nil {
+				return nil, err
+			}
+		}
+		switch {
+		case p.acceptKeyword("ASC"):
+			q.OrderBy.Sort = modelv1.Sort_SORT_ASC
+		case p.acceptKeyword("DESC"):
+			q.OrderBy.Sort = modelv1.Sort_SORT_DESC
+		}
+	}
+	if p.acceptKeyword("LIMIT") {
+		if q.Limit, err = p.uint(); err != nil {
+			return nil, err
+		}
+	}
+	if p.acceptKeyword("OFFSET") {
+		if q.Offset, err = p.uint(); err != nil {
+			return nil, err
+		}
+	}
+	if p.peek().kind != tokenEOF {
+		return nil, p.unexpected("end of input")
+	}
+	return q, nil
+}
+
+// parseProjection returns nil if all tags and fields are selected.
+func (p *parser) parseProjection() ([]Projection, error) {
+	if p.acceptSymbol("*") {
+		return nil, nil
+	}
+	var items []Projection
+	for {
+		t := p.peek()
+		if t.kind != tokenIdent {
+			return nil, p.unexpected("identifier")
+		}
+		p.pos++
+		item := Projection{Name: t.text}
+		if agg, ok := aggFunctions[strings.ToUpper(t.text)]; ok && p.acceptSymbol("(") {
+			var err error
+			if item.Name, err = p.ident(); err != nil {
+				return nil, err
+			}
+			if err = p.expectSymbol(")"); err != nil {
+				return nil, err
+			}
+			item.Agg = agg
+		}
+		items = append(items, item)
+		if !p.acceptSymbol(",") {
+			return items, nil
+		}
+	}
+}
+
+func (p *parser) parseTimeRange() (*TimeRange, error) {
+	tr := &TimeRange{}
+	var err error
+	if p.acceptKeyword("BETWEEN") {
+		if tr.Begin, err = p.str(); err != nil {
+			return nil, err
+		}
+		if err = p.expectKeyword("AND"); err != nil {
+			return nil, err
+		}
+		if tr.End, err = p.str(); err != nil {
+			return nil, err
+		}
+		return tr, nil
+	}
+	switch {
+	case p.acceptSymbol(">"), p.acceptSymbol(">="):
+		tr.Begin, err = p.str()
+	case p.acceptSymbol("<"), p.acceptSymbol("<="):
+		tr.End, err = p.str()
+	default:
+		return nil, p.unexpected("BETWEEN or a comparison")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tr, nil
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("OR") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &Logical{Op: modelv1.LogicalExpression_LOGICAL_OP_OR, Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("AND") {
+		right, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		left = &Logical{Op: modelv1.LogicalExpression_LOGICAL_OP_AND, Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parsePrimary() (Expr, error) {
+	if p.acceptKeyword("NOT") {
+		e, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		return &Logical{Op: modelv1.LogicalExpression_LOGICAL_OP_NOT, Left: e}, nil
+	}
+	if p.acceptSymbol("(") {
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err = p.expectSymbol(")"); err != nil {
+			return nil, err
+		}
+		return e, nil
+	}
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	c := &Condition{Name: name}
+	if t := p.peek(); t.kind == tokenSymbol {
+		op, ok := comparisons[t.text]
+		if !ok {
+			return nil, p.unexpected("an operator")
+		}
+		p.pos++
+		c.Op = op
+		v, parseErr := p.parseValue()
+		if parseErr != nil {
+			return nil, parseErr
+		}
+		c.Values = []Value{v}
+		return c, nil
+	}
+	if p.acceptKeyword("IS") {
+		c.Op = modelv1.Condition_BINARY_OP_IS_NULL
+		if p.acceptKeyword("NOT") {
+			c.Op = modelv1.Condition_BINARY_OP_EXISTS
+		}
+		if err = p.expectKeyword("NULL"); err != nil {
+			return nil, err
+		}
+		return c, nil
+	}
+	not := p.acceptKeyword("NOT")
+	switch {
+	case p.acceptKeyword("IN"):
+		c.Op = modelv1.Condition_BINARY_OP_IN
+		if not {
+			c.Op = modelv1.Condition_BINARY_OP_NOT_IN
+		}
+	case p.acceptKeyword("HAVING"):
+		c.Op = modelv1.Condition_BINARY_OP_HAVING
+		if not {
+			c.Op = modelv1.Condition_BINARY_OP_NOT_HAVING
+		}
+	case !not && p.acceptKeyword("MATCH"):
+		c.Op = modelv1.Condition_BINARY_OP_MATCH
+	case !not && p.acceptKeyword("STARTS_WITH"):
+		c.Op = modelv1.Condition_BINARY_OP_PREFIX
+	case !not && p.acceptKeyword("LIKE"):
+		c.Op = modelv1.Condition_BINARY_OP_WILDCARD
+	default:
+		return nil, p.unexpected("an operator")
+	}
+	if c.Values, c.List, err = p.parseValues(); err != nil {
+		return nil, err
+	}
+	if c.Op == modelv1.Condition_BINARY_OP_IN || c.Op == modelv1.Condition_BINARY_OP_NOT_IN {
+		c.List = true
+	}
+	return c, nil
+}
+
+// parseValues parses a single value or a list of values enclosed in parentheses.
+func (p *parser) parseValues() ([]Value, bool, error) {
+	if !p.acceptSymbol("(") {
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, false, err
+		}
+		return []Value{v}, false, nil
+	}
+	var values []Value
+	for {
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, false, err
+		}
+		values = append(values, v)
+		if p.acceptSymbol(",") {
+			continue
+		}
+		if err = p.expectSymbol(")"); err != nil {
+			return nil, false, err
+		}
+		return values, true, nil
+	}
+}
+
+func (p *parser) parseValue() (Value, error) {
+	t := p.peek()
+	switch {
+	case t.kind == tokenString:
+		p.pos++
+		return Value{Str: t.text}, nil
+	case t.kind == tokenNumber:
+		p.pos++
+		n, err := strconv.ParseInt(t.text, 10, 64)
+		if err != nil {
+			return Value{}, errors.Errorf("invalid number %s at position %d", t, t.pos)
+		}
+		return Value{Int: n, IsInt: true}, nil
+	case p.acceptKeyword("NULL"):
+		return Value{IsNull: true}, nil
+	}
+	return Value{}, p.unexpected("a value")
+}
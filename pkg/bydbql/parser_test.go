@@ -0,0 +1,100 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package bydbql
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+)
+
+func TestParse(t *testing.T) {
+	q, err := Parse(`select trace_id, "duration" from stream sw in default, g2
+		time between '2024-05-01T00:00:00Z' and 'now'
+		where service_id = 'svc''1' and (duration >= 100 or state in (0, 1)) and tags not having ('a=b')
+		order by duration desc limit 10 offset 20`)
+	require.NoError(t, err)
+	assert.Equal(t, commonv1.Catalog_CATALOG_STREAM, q.Catalog)
+	assert.Equal(t, "sw", q.Name)
+	assert.Equal(t, []string{"default", "g2"}, q.Groups)
+	assert.Equal(t, []Projection{{Name: "trace_id"}, {Name: "duration"}}, q.Projection)
+	assert.Equal(t, &TimeRange{Begin: "2024-05-01T00:00:00Z", End: "now"}, q.TimeRange)
+	assert.Equal(t, &OrderBy{IndexRule: "duration", Sort: modelv1.Sort_SORT_DESC}, q.OrderBy)
+	assert.Equal(t, uint32(10), q.Limit)
+	assert.Equal(t, uint32(20), q.Offset)
+
+	and := modelv1.LogicalExpression_LOGICAL_OP_AND
+	assert.Equal(t, &Logical{
+		Op: and,
+		Left: &Logical{
+			Op:   and,
+			Left: &Condition{Name: "service_id", Op: modelv1.Condition_BINARY_OP_EQ, Values: []Value{{Str: "svc'1"}}},
+			Right: &Logical{
+				Op:   modelv1.LogicalExpression_LOGICAL_OP_OR,
+				Left: &Condition{Name: "duration", Op: modelv1.Condition_BINARY_OP_GE, Values: []Value{{Int: 100, IsInt: true}}},
+				Right: &Condition{
+					Name: "state", Op: modelv1.Condition_BINARY_OP_IN, List: true,
+					Values: []Value{{Int: 0, IsInt: true}, {Int: 1, IsInt: true}},
+				},
+			},
+		},
+		Right: &Condition{Name: "tags", Op: modelv1.Condition_BINARY_OP_NOT_HAVING, List: true, Values: []Value{{Str: "a=b"}}},
+	}, q.Criteria)
+}
+
+func TestParseMeasure(t *testing.T) {
+	q, err := Parse("SELECT entity_id, SUM(value) FROM MEASURE service_cpm_minute TIME > '-1h' GROUP BY entity_id ORDER BY TIME")
+	require.NoError(t, err)
+	assert.Equal(t, commonv1.Catalog_CATALOG_MEASURE, q.Catalog)
+	assert.Empty(t, q.Groups)
+	assert.Equal(t, []Projection{
+		{Name: "entity_id"},
+		{Name: "value", Agg: modelv1.AggregationFunction_AGGREGATION_FUNCTION_SUM},
+	}, q.Projection)
+	assert.Equal(t, &TimeRange{Begin: "-1h"}, q.TimeRange)
+	assert.Equal(t, []string{"entity_id"}, q.GroupBy)
+	assert.Equal(t, &OrderBy{}, q.OrderBy)
+}
+
+func TestParseError(t *testing.T) {
+	for _, statement := range []string{
+		"",
+		"SELECT FROM STREAM sw",
+		"SELECT * FROM TABLE sw",
+		"SELECT * FROM STREAM sw WHERE a",
+		"SELECT * FROM STREAM sw WHERE a = ",
+		"SELECT * FROM STREAM sw WHERE a = 'b",
+		"SELECT * FROM STREAM sw WHERE a NOT MATCH 'b'",
+		"SELECT * FROM STREAM sw WHERE (a = 'b'",
+		"SELECT * FROM STREAM sw LIMIT -1",
+		"SELECT * FROM STREAM sw TIME = 'now'",
+		"SELECT * FROM STREAM sw extra",
+		"SELECT * FROM STREAM sw WHERE a ; b",
+		"SELECT * FROM STREAM sw WHERE a IS 'b'",
+		"SELECT * FROM STREAM sw WHERE a IS NOT",
+		"SELECT * FROM STREAM sw WHERE a NOT LIKE 'b*'",
+	} {
+		_, err := Parse(statement)
+		assert.True(t, errors.Is(err, ErrSyntax), "%q: %v", statement, err)
+	}
+}
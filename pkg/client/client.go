@@ -0,0 +1,191 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package client implements the Go client of BanyanDB.
+//
+// The client pools the connections to the liaison or standalone servers, batches the writes, retries the
+// retryable failures with jitter, caches the schemas and the routing hints, and builds the queries in Go types.
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/multierr"
+	"google.golang.org/grpc"
+
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/pkg/grpchelper"
+)
+
+// ErrNoAddr indicates no server address is given.
+var ErrNoAddr = errors.New("no server address")
+
+// Options configures the client.
+type Options struct {
+	// CACert is the PEM-encoded CA certificate file to verify the servers. The system pool is used if it's empty.
+	CACert string
+	// Addrs are the gRPC addresses of the liaison or standalone servers.
+	Addrs []string
+	// DialOptions are appended to the options dialing the servers.
+	DialOptions []grpc.DialOption
+	// Retry is the retry policy of the queries and the writes.
+	Retry RetryPolicy
+	// PoolSize is the number of the connections to every server, which spreads the streams over the connections.
+	PoolSize int
+	// SchemaTTL is how long the cached schemas are used before they're fetched again.
+	SchemaTTL time.Duration
+	// TLS enables TLS.
+	TLS bool
+	// InsecureSkipVerify skips verifying the certificates of the servers.
+	InsecureSkipVerify bool
+	// RoutingHints asks the servers for the routing hints of the writes, which are kept in the routing cache.
+	RoutingHints bool
+	// SkipValidation sends the queries without checking them against the cached schemas.
+	SkipValidation bool
+}
+
+// DefaultOptions returns the options connecting to addrs with the defaults.
+func DefaultOptions(addrs ...string) Options {
+	return Options{
+		Addrs:     addrs,
+		PoolSize:  2,
+		SchemaTTL: time.Minute,
+		Retry:     DefaultRetryPolicy,
+	}
+}
+
+// Client is the Go client of BanyanDB. It's safe for concurrent use.
+type Client struct {
+	schemas        *SchemaCache
+	routes         *RoutingCache
+	conns          []*grpc.ClientConn
+	retry          RetryPolicy
+	next           atomic.Uint64
+	routingHints   bool
+	skipValidation bool
+}
+
+// New returns a client of the servers in opts. The connections are established lazily.
+func New(opts Options) (*Client, error) {
+	if len(opts.Addrs) == 0 {
+		return nil, ErrNoAddr
+	}
+	dialOpts, err := grpchelper.SecureOptions(nil, opts.TLS, opts.InsecureSkipVerify, opts.CACert)
+	if err != nil {
+		return nil, err
+	}
+	dialOpts = append(dialOpts, opts.DialOptions...)
+	c := &Client{
+		retry:          opts.Retry,
+		routingHints:   opts.RoutingHints,
+		skipValidation: opts.SkipValidation,
+		routes:         newRoutingCache(),
+	}
+	for i := 0; i < max(opts.PoolSize, 1); i++ {
+		for _, addr := range opts.Addrs {
+			conn, errConn := grpc.NewClient(addr, dialOpts...)
+			if errConn != nil {
+				_ = c.Close()
+				return nil, fmt.Errorf("failed to dial %s: %w", addr, errConn)
+			}
+			c.conns = append(c.conns, conn)
+		}
+	}
+	c.schemas = newSchemaCache(c, opts.SchemaTTL)
+	return c, nil
+}
+
+// Close closes the connections.
+func (c *Client) Close() error {
+	var err error
+	for _, conn := range c.conns {
+		err = multierr.Append(err, conn.Close())
+	}
+	return err
+}
+
+// Schemas returns the schema cache of the client.
+func (c *Client) Schemas() *SchemaCache {
+	return c.schemas
+}
+
+// Routes returns the routing cache of the client.
+func (c *Client) Routes() *RoutingCache {
+	return c.routes
+}
+
+// conn picks the connections in turn, so the retries go to the other servers.
+func (c *Client) conn() *grpc.ClientConn {
+	return c.conns[(c.next.Add(1)-1)%uint64(len(c.conns))]
+}
+
+// QueryStream queries the elements of a stream.
+// The query is checked against the cached schemas of its groups unless the validation is skipped.
+func (c *Client) QueryStream(ctx context.Context, q *StreamQuery) (*streamv1.QueryResponse, error) {
+	req, err := q.Build()
+	if err != nil {
+		return nil, err
+	}
+	if !c.skipValidation {
+		for _, group := range req.Groups {
+			s, errSchema := c.schemas.Stream(ctx, group, req.Name)
+			if errSchema != nil {
+				return nil, errSchema
+			}
+			if err = q.Validate(s); err != nil {
+				return nil, fmt.Errorf("invalid query of %s/%s: %w", group, req.Name, err)
+			}
+		}
+	}
+	var resp *streamv1.QueryResponse
+	err = c.retry.do(ctx, func(ctx context.Context) (errQuery error) {
+		resp, errQuery = streamv1.NewStreamServiceClient(c.conn()).Query(ctx, req)
+		return errQuery
+	})
+	return resp, err
+}
+
+// QueryMeasure queries the data points of a measure.
+// The query is checked against the cached schemas of its groups unless the validation is skipped.
+func (c *Client) QueryMeasure(ctx context.Context, q *MeasureQuery) (*measurev1.QueryResponse, error) {
+	req, err := q.Build()
+	if err != nil {
+		return nil, err
+	}
+	if !c.skipValidation {
+		for _, group := range req.Groups {
+			m, errSchema := c.schemas.Measure(ctx, group, req.Name)
+			if errSchema != nil {
+				return nil, errSchema
+			}
+			if err = q.Validate(m); err != nil {
+				return nil, fmt.Errorf("invalid query of %s/%s: %w", group, req.Name, err)
+			}
+		}
+	}
+	var resp *measurev1.QueryResponse
+	err = c.retry.do(ctx, func(ctx context.Context) (errQuery error) {
+		resp, errQuery = measurev1.NewMeasureServiceClient(c.conn()).Query(ctx, req)
+		return errQuery
+	})
+	return resp, err
+}
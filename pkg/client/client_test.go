@@ -0,0 +1,282 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/pkg/partition"
+)
+
+const testShardNum = 4
+
+var testStream = &databasev1.Stream{
+	Metadata: &commonv1.Metadata{Group: "sw", Name: "trace"},
+	TagFamilies: []*databasev1.TagFamilySpec{{
+		Name: "default",
+		Tags: []*databasev1.TagSpec{{Name: "service", Type: databasev1.TagType_TAG_TYPE_STRING}},
+	}},
+	Entity: &databasev1.Entity{TagNames: []string{"service"}},
+}
+
+// fakeServer rejects the first writes of the messages in reject, and fails the calls in unavailable.
+type fakeServer struct {
+	streamv1.UnimplementedStreamServiceServer
+	reject      map[uint64]modelv1.Status
+	keys        map[uint64][]string
+	unavailable atomic.Int32
+	schemaGets  atomic.Int32
+	mu          sync.Mutex
+}
+
+func (s *fakeServer) Write(stream streamv1.StreamService_WriteServer) error {
+	if s.unavailable.Add(-1) >= 0 {
+		return status.Error(codes.Unavailable, "unavailable")
+	}
+	var succeeded []*streamv1.WriteResponse
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		s.mu.Lock()
+		s.keys[req.MessageId] = append(s.keys[req.MessageId], req.IdempotencyKey)
+		rejected, ok := s.reject[req.MessageId]
+		delete(s.reject, req.MessageId)
+		s.mu.Unlock()
+		if ok {
+			if err = stream.Send(&streamv1.WriteResponse{MessageId: req.MessageId, Status: rejected.String(), Metadata: req.Metadata}); err != nil {
+				return err
+			}
+			continue
+		}
+		resp := &streamv1.WriteResponse{MessageId: req.MessageId, Status: modelv1.Status_STATUS_SUCCEED.String(), Metadata: req.Metadata}
+		if req.RoutingHint {
+			_, shardID, errLocate := partition.NewEntityLocator(testStream.TagFamilies, testStream.Entity, 0).
+				Locate(req.Metadata.Name, req.Element.TagFamilies, testShardNum)
+			if errLocate != nil {
+				return errLocate
+			}
+			resp.RoutingHint = &modelv1.RoutingHint{ShardId: uint32(shardID), Epoch: 1, Nodes: []string{"data-0:17912"}}
+		}
+		succeeded = append(succeeded, resp)
+	}
+	for _, resp := range succeeded {
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *fakeServer) Query(_ context.Context, req *streamv1.QueryRequest) (*streamv1.QueryResponse, error) {
+	if s.unavailable.Add(-1) >= 0 {
+		return nil, status.Error(codes.Unavailable, "unavailable")
+	}
+	return &streamv1.QueryResponse{Elements: []*streamv1.Element{{ElementId: req.Name}}}, nil
+}
+
+type groupRegistry struct {
+	databasev1.UnimplementedGroupRegistryServiceServer
+	*fakeServer
+}
+
+func (s groupRegistry) Get(_ context.Context, req *databasev1.GroupRegistryServiceGetRequest) (*databasev1.GroupRegistryServiceGetResponse, error) {
+	s.schemaGets.Add(1)
+	return &databasev1.GroupRegistryServiceGetResponse{Group: &commonv1.Group{
+		Metadata:     &commonv1.Metadata{Name: req.Group},
+		ResourceOpts: &commonv1.ResourceOpts{ShardNum: testShardNum},
+	}}, nil
+}
+
+type streamRegistry struct {
+	databasev1.UnimplementedStreamRegistryServiceServer
+	*fakeServer
+}
+
+func (s streamRegistry) Get(_ context.Context, _ *databasev1.StreamRegistryServiceGetRequest) (*databasev1.StreamRegistryServiceGetResponse, error) {
+	s.schemaGets.Add(1)
+	return &databasev1.StreamRegistryServiceGetResponse{Stream: testStream}, nil
+}
+
+func setup(t *testing.T, opts Options) (*Client, *fakeServer) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := grpc.NewServer()
+	fs := &fakeServer{reject: make(map[uint64]modelv1.Status), keys: make(map[uint64][]string)}
+	streamv1.RegisterStreamServiceServer(srv, fs)
+	databasev1.RegisterGroupRegistryServiceServer(srv, groupRegistry{fakeServer: fs})
+	databasev1.RegisterStreamRegistryServiceServer(srv, streamRegistry{fakeServer: fs})
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+	t.Cleanup(srv.Stop)
+
+	opts.Addrs = []string{lis.Addr().String()}
+	opts.Retry.InitialBackoff = time.Millisecond
+	c, err := New(opts)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+	return c, fs
+}
+
+func writeRequest(service string) *streamv1.WriteRequest {
+	return &streamv1.WriteRequest{
+		Metadata: &commonv1.Metadata{Group: "sw", Name: "trace"},
+		Element: &streamv1.ElementValue{
+			TagFamilies: []*modelv1.TagFamilyForWrite{{Tags: []*modelv1.TagValue{Tag(service)}}},
+		},
+	}
+}
+
+func TestNewWithoutAddr(t *testing.T) {
+	_, err := New(DefaultOptions())
+	assert.ErrorIs(t, err, ErrNoAddr)
+}
+
+func TestQueryRetry(t *testing.T) {
+	c, fs := setup(t, DefaultOptions())
+	ctx := context.Background()
+	q := NewStreamQuery("trace", "sw").TimeRange(time.Now().Add(-time.Hour), time.Now()).Project("default", "service")
+
+	fs.unavailable.Store(2)
+	resp, err := c.QueryStream(ctx, q)
+	require.NoError(t, err)
+	assert.Equal(t, "trace", resp.Elements[0].ElementId)
+
+	// the attempts run out.
+	fs.unavailable.Store(3)
+	_, err = c.QueryStream(ctx, q)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+func TestQueryValidation(t *testing.T) {
+	ctx := context.Background()
+	q := NewStreamQuery("trace", "sw").TimeRange(time.Now().Add(-time.Hour), time.Now()).Project("default", "service").
+		Where(Eq("service", 1))
+
+	c, fs := setup(t, DefaultOptions())
+	// the invalid query never reaches the server.
+	fs.unavailable.Store(1)
+	_, err := c.QueryStream(ctx, q)
+	assert.ErrorIs(t, err, ErrTagTypeMismatch)
+	assert.Equal(t, int32(1), fs.unavailable.Load())
+
+	opts := DefaultOptions()
+	opts.SkipValidation = true
+	c, _ = setup(t, opts)
+	_, err = c.QueryStream(ctx, q)
+	assert.NoError(t, err)
+}
+
+func TestStreamWriter(t *testing.T) {
+	c, fs := setup(t, DefaultOptions())
+	ctx := context.Background()
+	fs.reject[2] = modelv1.Status_STATUS_INTERNAL_ERROR
+	fs.reject[3] = modelv1.Status_STATUS_INVALID_TIMESTAMP
+
+	var results []WriteResult
+	w := c.NewStreamWriter(BatchOptions{Size: 3, OnResult: func(r WriteResult) { results = append(results, r) }})
+	require.NoError(t, w.Write(ctx, writeRequest("a")))
+	require.NoError(t, w.Write(ctx, writeRequest("b")))
+	// the batch is flushed once it's full, and only the invalid write isn't retried.
+	err := w.Write(ctx, writeRequest("c"))
+	var we *WriteError
+	require.ErrorAs(t, err, &we)
+	require.Len(t, we.Failed, 1)
+	assert.Equal(t, uint64(3), we.Failed[0].MessageID)
+	assert.Equal(t, modelv1.Status_STATUS_INVALID_TIMESTAMP, we.Failed[0].Status)
+	assert.Len(t, results, 3)
+
+	// the retry carries the same idempotency key.
+	require.Len(t, fs.keys[2], 2)
+	assert.NotEmpty(t, fs.keys[2][0])
+	assert.Equal(t, fs.keys[2][0], fs.keys[2][1])
+
+	// the stream failing with a retryable error is written again.
+	fs.unavailable.Store(1)
+	require.NoError(t, w.Write(ctx, writeRequest("d")))
+	require.NoError(t, w.Close(ctx))
+	assert.Len(t, fs.keys[4], 1)
+	assert.ErrorIs(t, w.Write(ctx, writeRequest("e")), ErrWriterClosed)
+}
+
+func TestStreamWriterFlushInterval(t *testing.T) {
+	c, fs := setup(t, DefaultOptions())
+	w := c.NewStreamWriter(BatchOptions{Size: 100, FlushInterval: 10 * time.Millisecond})
+	defer func() {
+		require.NoError(t, w.Close(context.Background()))
+	}()
+	require.NoError(t, w.Write(context.Background(), writeRequest("a")))
+	assert.Eventually(t, func() bool {
+		fs.mu.Lock()
+		defer fs.mu.Unlock()
+		return len(fs.keys) == 1
+	}, 10*time.Second, 10*time.Millisecond)
+}
+
+func TestRouteStream(t *testing.T) {
+	opts := DefaultOptions()
+	opts.RoutingHints = true
+	c, fs := setup(t, opts)
+	ctx := context.Background()
+
+	w := c.NewStreamWriter(BatchOptions{Size: 1})
+	require.NoError(t, w.Write(ctx, writeRequest("a")))
+	require.NoError(t, w.Close(ctx))
+
+	hint, ok, err := c.RouteStream(ctx, writeRequest("a"))
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, []string{"data-0:17912"}, hint.Nodes)
+	assert.Equal(t, uint64(1), c.Routes().Epoch())
+	// the schemas are cached.
+	_, _, err = c.RouteStream(ctx, writeRequest("a"))
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), fs.schemaGets.Load())
+
+	// the hints of the other epochs are dropped.
+	c.Routes().update("sw", &modelv1.RoutingHint{ShardId: hint.ShardId + 1, Epoch: 2})
+	_, ok = c.Routes().Get("sw", hint.ShardId)
+	assert.False(t, ok)
+
+	c.Schemas().Invalidate("sw", "trace")
+	_, _, err = c.RouteStream(ctx, writeRequest("a"))
+	require.NoError(t, err)
+	assert.Equal(t, int32(4), fs.schemaGets.Load())
+}
@@ -0,0 +1,397 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package client
+
+import (
+	"errors"
+	"time"
+
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+)
+
+var (
+	// ErrNoGroup indicates the query has no group.
+	ErrNoGroup = errors.New("the query has no group")
+	// ErrNoTimeRange indicates the query has no time range.
+	ErrNoTimeRange = errors.New("the query has no time range")
+	// ErrNoProjection indicates the query projects no tag or field.
+	ErrNoProjection = errors.New("the query projects nothing")
+)
+
+// TagValueType is the Go types of the tag values.
+type TagValueType interface {
+	string | int64 | int | []string | []int64 | []byte | time.Time
+}
+
+// Tag converts v to a tag value.
+func Tag[T TagValueType](v T) *modelv1.TagValue {
+	switch x := any(v).(type) {
+	case string:
+		return &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: x}}}
+	case int64:
+		return &modelv1.TagValue{Value: &modelv1.TagValue_Int{Int: &modelv1.Int{Value: x}}}
+	case int:
+		return &modelv1.TagValue{Value: &modelv1.TagValue_Int{Int: &modelv1.Int{Value: int64(x)}}}
+	case []string:
+		return &modelv1.TagValue{Value: &modelv1.TagValue_StrArray{StrArray: &modelv1.StrArray{Value: x}}}
+	case []int64:
+		return &modelv1.TagValue{Value: &modelv1.TagValue_IntArray{IntArray: &modelv1.IntArray{Value: x}}}
+	case []byte:
+		return &modelv1.TagValue{Value: &modelv1.TagValue_BinaryData{BinaryData: x}}
+	case time.Time:
+		return &modelv1.TagValue{Value: &modelv1.TagValue_Timestamp{Timestamp: timestamppb.New(x)}}
+	}
+	panic("unreachable")
+}
+
+// NullTag returns the null tag value.
+func NullTag() *modelv1.TagValue {
+	return &modelv1.TagValue{Value: &modelv1.TagValue_Null{Null: structpb.NullValue_NULL_VALUE}}
+}
+
+func condition(tag string, op modelv1.Condition_BinaryOp, value *modelv1.TagValue) *modelv1.Criteria {
+	return &modelv1.Criteria{Exp: &modelv1.Criteria_Condition{Condition: &modelv1.Condition{Name: tag, Op: op, Value: value}}}
+}
+
+// Eq matches the tag equal to v.
+func Eq[T TagValueType](tag string, v T) *modelv1.Criteria {
+	return condition(tag, modelv1.Condition_BINARY_OP_EQ, Tag(v))
+}
+
+// Ne matches the tag not equal to v.
+func Ne[T TagValueType](tag string, v T) *modelv1.Criteria {
+	return condition(tag, modelv1.Condition_BINARY_OP_NE, Tag(v))
+}
+
+// Lt matches the tag less than v.
+func Lt[T TagValueType](tag string, v T) *modelv1.Criteria {
+	return condition(tag, modelv1.Condition_BINARY_OP_LT, Tag(v))
+}
+
+// Le matches the tag less than or equal to v.
+func Le[T TagValueType](tag string, v T) *modelv1.Criteria {
+	return condition(tag, modelv1.Condition_BINARY_OP_LE, Tag(v))
+}
+
+// Gt matches the tag greater than v.
+func Gt[T TagValueType](tag string, v T) *modelv1.Criteria {
+	return condition(tag, modelv1.Condition_BINARY_OP_GT, Tag(v))
+}
+
+// Ge matches the tag greater than or equal to v.
+func Ge[T TagValueType](tag string, v T) *modelv1.Criteria {
+	return condition(tag, modelv1.Condition_BINARY_OP_GE, Tag(v))
+}
+
+// In matches the tag equal to any of vv.
+func In[T string | int64](tag string, vv ...T) *modelv1.Criteria {
+	return condition(tag, modelv1.Condition_BINARY_OP_IN, arrayTag(vv))
+}
+
+// NotIn matches the tag equal to none of vv.
+func NotIn[T string | int64](tag string, vv ...T) *modelv1.Criteria {
+	return condition(tag, modelv1.Condition_BINARY_OP_NOT_IN, arrayTag(vv))
+}
+
+// Having matches the array tag containing all of vv.
+func Having[T string | int64](tag string, vv ...T) *modelv1.Criteria {
+	return condition(tag, modelv1.Condition_BINARY_OP_HAVING, arrayTag(vv))
+}
+
+// NotHaving matches the array tag not containing all of vv.
+func NotHaving[T string | int64](tag string, vv ...T) *modelv1.Criteria {
+	return condition(tag, modelv1.Condition_BINARY_OP_NOT_HAVING, arrayTag(vv))
+}
+
+// Match searches the analyzed tag by the query.
+func Match(tag, query string) *modelv1.Criteria {
+	return condition(tag, modelv1.Condition_BINARY_OP_MATCH, Tag(query))
+}
+
+// Prefix matches the non-analyzed string tag starting with prefix.
+func Prefix(tag, prefix string) *modelv1.Criteria {
+	return condition(tag, modelv1.Condition_BINARY_OP_PREFIX, Tag(prefix))
+}
+
+// Wildcard matches the non-analyzed string tag by pattern, in which "*" matches any characters and "?" matches one.
+func Wildcard(tag, pattern string) *modelv1.Criteria {
+	return condition(tag, modelv1.Condition_BINARY_OP_WILDCARD, Tag(pattern))
+}
+
+// Exists matches the tag having a value.
+func Exists(tag string) *modelv1.Criteria {
+	return condition(tag, modelv1.Condition_BINARY_OP_EXISTS, NullTag())
+}
+
+// IsNull matches the tag without a value.
+func IsNull(tag string) *modelv1.Criteria {
+	return condition(tag, modelv1.Condition_BINARY_OP_IS_NULL, NullTag())
+}
+
+// And matches all of cc.
+func And(cc ...*modelv1.Criteria) *modelv1.Criteria {
+	return logical(modelv1.LogicalExpression_LOGICAL_OP_AND, cc)
+}
+
+// Or matches any of cc.
+func Or(cc ...*modelv1.Criteria) *modelv1.Criteria {
+	return logical(modelv1.LogicalExpression_LOGICAL_OP_OR, cc)
+}
+
+// Not matches the items not matching c.
+func Not(c *modelv1.Criteria) *modelv1.Criteria {
+	return &modelv1.Criteria{Exp: &modelv1.Criteria_Le{Le: &modelv1.LogicalExpression{
+		Op:   modelv1.LogicalExpression_LOGICAL_OP_NOT,
+		Left: c,
+	}}}
+}
+
+func logical(op modelv1.LogicalExpression_LogicalOp, cc []*modelv1.Criteria) *modelv1.Criteria {
+	switch len(cc) {
+	case 0:
+		return nil
+	case 1:
+		return cc[0]
+	}
+	return &modelv1.Criteria{Exp: &modelv1.Criteria_Le{Le: &modelv1.LogicalExpression{
+		Op:    op,
+		Left:  cc[0],
+		Right: logical(op, cc[1:]),
+	}}}
+}
+
+func arrayTag[T string | int64](vv []T) *modelv1.TagValue {
+	switch x := any(vv).(type) {
+	case []string:
+		return Tag(x)
+	case []int64:
+		return Tag(x)
+	}
+	panic("unreachable")
+}
+
+func timeRange(begin, end time.Time) *modelv1.TimeRange {
+	return &modelv1.TimeRange{Begin: timestamppb.New(begin), End: timestamppb.New(end)}
+}
+
+func tagProjection(p *modelv1.TagProjection, family string, tags []string) *modelv1.TagProjection {
+	if p == nil {
+		p = &modelv1.TagProjection{}
+	}
+	for _, f := range p.TagFamilies {
+		if f.Name == family {
+			f.Tags = append(f.Tags, tags...)
+			return p
+		}
+	}
+	p.TagFamilies = append(p.TagFamilies, &modelv1.TagProjection_TagFamily{Name: family, Tags: tags})
+	return p
+}
+
+// StreamQuery builds the query of a stream.
+type StreamQuery struct {
+	req *streamv1.QueryRequest
+}
+
+// NewStreamQuery returns the query of the stream name in groups.
+func NewStreamQuery(name string, groups ...string) *StreamQuery {
+	return &StreamQuery{req: &streamv1.QueryRequest{Name: name, Groups: groups}}
+}
+
+// TimeRange limits the elements in [begin, end).
+func (q *StreamQuery) TimeRange(begin, end time.Time) *StreamQuery {
+	q.req.TimeRange = timeRange(begin, end)
+	return q
+}
+
+// Project returns the tags of the family.
+func (q *StreamQuery) Project(family string, tags ...string) *StreamQuery {
+	q.req.Projection = tagProjection(q.req.Projection, family, tags)
+	return q
+}
+
+// Where filters the elements by c. The criteria of the calls are joined by AND.
+func (q *StreamQuery) Where(c *modelv1.Criteria) *StreamQuery {
+	q.req.Criteria = And(append(nonNil(q.req.Criteria), c)...)
+	return q
+}
+
+// OrderBy sorts the elements by the index rule, or by the timestamps if indexRule is empty.
+func (q *StreamQuery) OrderBy(indexRule string, sort modelv1.Sort) *StreamQuery {
+	q.req.OrderBy = &modelv1.QueryOrder{IndexRuleName: indexRule, Sort: sort}
+	return q
+}
+
+// Limit returns at most n elements.
+func (q *StreamQuery) Limit(n uint32) *StreamQuery {
+	q.req.Limit = n
+	return q
+}
+
+// Offset skips the first n elements.
+func (q *StreamQuery) Offset(n uint32) *StreamQuery {
+	q.req.Offset = n
+	return q
+}
+
+// Timeout asks the server to stop the query after d.
+func (q *StreamQuery) Timeout(d time.Duration) *StreamQuery {
+	q.req.Timeout = durationpb.New(d)
+	return q
+}
+
+// Trace asks the server to trace the query.
+func (q *StreamQuery) Trace() *StreamQuery {
+	q.req.Trace = true
+	return q
+}
+
+// Build validates the query and returns the request.
+func (q *StreamQuery) Build() (*streamv1.QueryRequest, error) {
+	if len(q.req.Groups) == 0 {
+		return nil, ErrNoGroup
+	}
+	if q.req.TimeRange == nil {
+		return nil, ErrNoTimeRange
+	}
+	if len(q.req.Projection.GetTagFamilies()) == 0 {
+		return nil, ErrNoProjection
+	}
+	return q.req, nil
+}
+
+// MeasureQuery builds the query of a measure.
+type MeasureQuery struct {
+	req *measurev1.QueryRequest
+}
+
+// NewMeasureQuery returns the query of the measure name in groups.
+func NewMeasureQuery(name string, groups ...string) *MeasureQuery {
+	return &MeasureQuery{req: &measurev1.QueryRequest{Name: name, Groups: groups}}
+}
+
+// TimeRange limits the data points in [begin, end).
+func (q *MeasureQuery) TimeRange(begin, end time.Time) *MeasureQuery {
+	q.req.TimeRange = timeRange(begin, end)
+	return q
+}
+
+// Project returns the tags of the family.
+func (q *MeasureQuery) Project(family string, tags ...string) *MeasureQuery {
+	q.req.TagProjection = tagProjection(q.req.TagProjection, family, tags)
+	return q
+}
+
+// Fields returns the fields.
+func (q *MeasureQuery) Fields(names ...string) *MeasureQuery {
+	if q.req.FieldProjection == nil {
+		q.req.FieldProjection = &measurev1.QueryRequest_FieldProjection{}
+	}
+	q.req.FieldProjection.Names = append(q.req.FieldProjection.Names, names...)
+	return q
+}
+
+// Where filters the data points by c. The criteria of the calls are joined by AND.
+func (q *MeasureQuery) Where(c *modelv1.Criteria) *MeasureQuery {
+	q.req.Criteria = And(append(nonNil(q.req.Criteria), c)...)
+	return q
+}
+
+// GroupBy groups the data points by the tags of the family, and keeps the field.
+func (q *MeasureQuery) GroupBy(field, family string, tags ...string) *MeasureQuery {
+	q.req.GroupBy = &measurev1.QueryRequest_GroupBy{
+		TagProjection: tagProjection(nil, family, tags),
+		FieldName:     field,
+	}
+	return q
+}
+
+// Aggregate aggregates the field of the data points by fn.
+func (q *MeasureQuery) Aggregate(fn modelv1.AggregationFunction, field string) *MeasureQuery {
+	q.req.Agg = &measurev1.QueryRequest_Aggregation{Function: fn, FieldName: field}
+	return q
+}
+
+// Top returns the top n data points by the field, or the bottom ones if sort is ascending.
+func (q *MeasureQuery) Top(n int32, field string, sort modelv1.Sort) *MeasureQuery {
+	q.req.Top = &measurev1.QueryRequest_Top{Number: n, FieldName: field, FieldValueSort: sort}
+	return q
+}
+
+// OrderBy sorts the data points by the index rule, or by the timestamps if indexRule is empty.
+func (q *MeasureQuery) OrderBy(indexRule string, sort modelv1.Sort) *MeasureQuery {
+	q.req.OrderBy = &modelv1.QueryOrder{IndexRuleName: indexRule, Sort: sort}
+	return q
+}
+
+// Limit returns at most n data points.
+func (q *MeasureQuery) Limit(n uint32) *MeasureQuery {
+	q.req.Limit = n
+	return q
+}
+
+// Offset skips the first n data points.
+func (q *MeasureQuery) Offset(n uint32) *MeasureQuery {
+	q.req.Offset = n
+	return q
+}
+
+// Latest returns the latest version of every data point.
+func (q *MeasureQuery) Latest() *MeasureQuery {
+	q.req.Latest = true
+	return q
+}
+
+// Timeout asks the server to stop the query after d.
+func (q *MeasureQuery) Timeout(d time.Duration) *MeasureQuery {
+	q.req.Timeout = durationpb.New(d)
+	return q
+}
+
+// Trace asks the server to trace the query.
+func (q *MeasureQuery) Trace() *MeasureQuery {
+	q.req.Trace = true
+	return q
+}
+
+// Build validates the query and returns the request.
+func (q *MeasureQuery) Build() (*measurev1.QueryRequest, error) {
+	if len(q.req.Groups) == 0 {
+		return nil, ErrNoGroup
+	}
+	if q.req.TimeRange == nil {
+		return nil, ErrNoTimeRange
+	}
+	if len(q.req.TagProjection.GetTagFamilies()) == 0 && len(q.req.FieldProjection.GetNames()) == 0 {
+		return nil, ErrNoProjection
+	}
+	return q.req, nil
+}
+
+func nonNil(c *modelv1.Criteria) []*modelv1.Criteria {
+	if c == nil {
+		return nil
+	}
+	return []*modelv1.Criteria{c}
+}
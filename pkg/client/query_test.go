@@ -0,0 +1,115 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+)
+
+func TestStreamQuery(t *testing.T) {
+	begin, end := time.Unix(0, 0), time.Unix(3600, 0)
+	_, err := NewStreamQuery("trace").Build()
+	assert.ErrorIs(t, err, ErrNoGroup)
+	_, err = NewStreamQuery("trace", "sw").Build()
+	assert.ErrorIs(t, err, ErrNoTimeRange)
+	_, err = NewStreamQuery("trace", "sw").TimeRange(begin, end).Build()
+	assert.ErrorIs(t, err, ErrNoProjection)
+
+	req, err := NewStreamQuery("trace", "sw").
+		TimeRange(begin, end).
+		Project("searchable", "trace_id").
+		Project("searchable", "duration").
+		Where(Eq("service_id", "svc")).
+		Where(Or(Gt("duration", int64(100)), In("state", int64(0), int64(1)))).
+		OrderBy("duration", modelv1.Sort_SORT_DESC).
+		Limit(10).
+		Build()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sw"}, req.Groups)
+	assert.Equal(t, end.Unix(), req.TimeRange.End.Seconds)
+	require.Len(t, req.Projection.TagFamilies, 1)
+	assert.Equal(t, []string{"trace_id", "duration"}, req.Projection.TagFamilies[0].Tags)
+	assert.Equal(t, uint32(10), req.Limit)
+
+	le := req.Criteria.GetLe()
+	require.NotNil(t, le)
+	assert.Equal(t, modelv1.LogicalExpression_LOGICAL_OP_AND, le.Op)
+	assert.Equal(t, "svc", le.Left.GetCondition().Value.GetStr().Value)
+	or := le.Right.GetLe()
+	assert.Equal(t, modelv1.LogicalExpression_LOGICAL_OP_OR, or.Op)
+	assert.Equal(t, modelv1.Condition_BINARY_OP_GT, or.Left.GetCondition().Op)
+	assert.Equal(t, []int64{0, 1}, or.Right.GetCondition().Value.GetIntArray().Value)
+}
+
+func TestNot(t *testing.T) {
+	c := And(Ne("status_code", "200"), Not(Prefix("endpoint", "/health")))
+	le := c.GetLe()
+	require.NotNil(t, le)
+	not := le.Right.GetLe()
+	require.NotNil(t, not)
+	assert.Equal(t, modelv1.LogicalExpression_LOGICAL_OP_NOT, not.Op)
+	assert.Equal(t, modelv1.Condition_BINARY_OP_PREFIX, not.Left.GetCondition().Op)
+	assert.Nil(t, not.Right)
+}
+
+func TestMeasureQuery(t *testing.T) {
+	_, err := NewMeasureQuery("service_cpm", "sw").TimeRange(time.Now(), time.Now()).Build()
+	assert.ErrorIs(t, err, ErrNoProjection)
+
+	req, err := NewMeasureQuery("service_cpm", "sw").
+		TimeRange(time.Now().Add(-time.Hour), time.Now()).
+		Project("default", "entity_id").
+		Fields("total").
+		GroupBy("total", "default", "entity_id").
+		Aggregate(modelv1.AggregationFunction_AGGREGATION_FUNCTION_SUM, "total").
+		Top(5, "total", modelv1.Sort_SORT_DESC).
+		Build()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"total"}, req.FieldProjection.Names)
+	assert.Equal(t, "entity_id", req.GroupBy.TagProjection.TagFamilies[0].Tags[0])
+	assert.Equal(t, modelv1.AggregationFunction_AGGREGATION_FUNCTION_SUM, req.Agg.Function)
+	assert.Equal(t, int32(5), req.Top.Number)
+}
+
+func TestTag(t *testing.T) {
+	assert.Equal(t, "a", Tag("a").GetStr().Value)
+	assert.Equal(t, int64(1), Tag(1).GetInt().Value)
+	assert.Equal(t, []string{"a"}, Tag([]string{"a"}).GetStrArray().Value)
+	assert.Equal(t, []byte("a"), Tag([]byte("a")).GetBinaryData())
+	assert.Equal(t, int64(3600), Tag(time.Unix(3600, 0)).GetTimestamp().Seconds)
+	assert.NotNil(t, Exists("a").GetCondition().Value.GetNull())
+	assert.Nil(t, And())
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 2, Jitter: 0.5}
+	for i := 0; i < 100; i++ {
+		assert.InDelta(t, float64(100*time.Millisecond), float64(p.backoff(1)), float64(50*time.Millisecond))
+		assert.InDelta(t, float64(400*time.Millisecond), float64(p.backoff(3)), float64(200*time.Millisecond))
+		assert.InDelta(t, float64(time.Second), float64(p.backoff(10)), float64(500*time.Millisecond))
+	}
+	p.Jitter = 0
+	assert.Equal(t, 200*time.Millisecond, p.backoff(2))
+	assert.Equal(t, 1, RetryPolicy{}.attempts())
+}
@@ -0,0 +1,118 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package client
+
+import (
+	"context"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+)
+
+// RetryPolicy decides which failures are retried and how long the client waits between the attempts.
+// The backoff grows by Multiplier from InitialBackoff to MaxBackoff, and every wait is randomized by Jitter
+// to keep the clients failing together from retrying together.
+type RetryPolicy struct {
+	// RetryableCodes are the gRPC codes of the failed calls or write streams to retry.
+	RetryableCodes []codes.Code
+	// RetryableStatuses are the statuses of the rejected writes to retry.
+	RetryableStatuses []modelv1.Status
+	// MaxAttempts is the number of the attempts including the first one. 1 disables the retries.
+	MaxAttempts int
+	// InitialBackoff is the wait before the first retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait between the attempts.
+	MaxBackoff time.Duration
+	// Multiplier grows the wait after every retry.
+	Multiplier float64
+	// Jitter is the fraction of the wait which is randomized, from 0 to 1.
+	Jitter float64
+}
+
+// DefaultRetryPolicy retries the unavailable servers and the writes failing to reach the data nodes twice.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:       3,
+	InitialBackoff:    100 * time.Millisecond,
+	MaxBackoff:        5 * time.Second,
+	Multiplier:        2,
+	Jitter:            0.2,
+	RetryableCodes:    []codes.Code{codes.Unavailable, codes.ResourceExhausted, codes.Aborted},
+	RetryableStatuses: []modelv1.Status{modelv1.Status_STATUS_INTERNAL_ERROR},
+}
+
+func (p RetryPolicy) attempts() int {
+	if p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+func (p RetryPolicy) retryableError(err error) bool {
+	return slices.Contains(p.RetryableCodes, status.Code(err))
+}
+
+func (p RetryPolicy) retryableStatus(s modelv1.Status) bool {
+	return slices.Contains(p.RetryableStatuses, s)
+}
+
+// backoff returns the wait before the retry following the attempt, which starts from 1.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := float64(p.InitialBackoff)
+	for i := 1; i < attempt; i++ {
+		d *= max(p.Multiplier, 1)
+		if p.MaxBackoff > 0 && d >= float64(p.MaxBackoff) {
+			d = float64(p.MaxBackoff)
+			break
+		}
+	}
+	if jitter := min(max(p.Jitter, 0), 1); jitter > 0 {
+		// #nosec G404 -- the jitter doesn't need a secure random number.
+		d *= 1 - jitter + 2*jitter*rand.Float64()
+	}
+	return time.Duration(d)
+}
+
+// wait sleeps for the backoff of the attempt unless ctx is done.
+func (p RetryPolicy) wait(ctx context.Context, attempt int) error {
+	t := time.NewTimer(p.backoff(attempt))
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// do calls fn until it succeeds, fails with a non-retryable error, or runs out of the attempts.
+func (p RetryPolicy) do(ctx context.Context, fn func(ctx context.Context) error) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = fn(ctx); err == nil || !p.retryableError(err) || attempt >= p.attempts() {
+			return err
+		}
+		if errWait := p.wait(ctx, attempt); errWait != nil {
+			return err
+		}
+	}
+}
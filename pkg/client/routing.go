@@ -0,0 +1,118 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package client
+
+import (
+	"context"
+	"sync"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/pkg/partition"
+)
+
+type shardKey struct {
+	group   string
+	shardID uint32
+}
+
+// RoutingCache keeps the routing hints returned by the writes asking for them.
+// The hints tell the data nodes holding the copies of a shard, and they're dropped once
+// a hint of another epoch arrives, since the data nodes known by the liaison have changed.
+type RoutingCache struct {
+	hints map[shardKey]*modelv1.RoutingHint
+	epoch uint64
+	mu    sync.RWMutex
+}
+
+func newRoutingCache() *RoutingCache {
+	return &RoutingCache{hints: make(map[shardKey]*modelv1.RoutingHint)}
+}
+
+// Get returns the hint of the shard in the group.
+func (r *RoutingCache) Get(group string, shardID uint32) (*modelv1.RoutingHint, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	h, ok := r.hints[shardKey{group: group, shardID: shardID}]
+	return h, ok
+}
+
+// Epoch returns the epoch of the cached hints.
+func (r *RoutingCache) Epoch() uint64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.epoch
+}
+
+func (r *RoutingCache) update(group string, hint *modelv1.RoutingHint) {
+	if hint == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if hint.Epoch != r.epoch {
+		clear(r.hints)
+		r.epoch = hint.Epoch
+	}
+	r.hints[shardKey{group: group, shardID: hint.ShardId}] = hint
+}
+
+// RouteStream returns the cached hint of the shard which the element goes to.
+// The shard is located by the cached schema in the same way as the liaison does.
+func (c *Client) RouteStream(ctx context.Context, req *streamv1.WriteRequest) (*modelv1.RoutingHint, bool, error) {
+	md := req.GetMetadata()
+	s, err := c.schemas.Stream(ctx, md.GetGroup(), md.GetName())
+	if err != nil {
+		return nil, false, err
+	}
+	return c.route(ctx, md, s.GetTagFamilies(), s.GetEntity(), s.GetShardingKey(), req.GetElement().GetTagFamilies())
+}
+
+// RouteMeasure returns the cached hint of the shard which the data point goes to.
+// The shard is located by the cached schema in the same way as the liaison does.
+func (c *Client) RouteMeasure(ctx context.Context, req *measurev1.WriteRequest) (*modelv1.RoutingHint, bool, error) {
+	md := req.GetMetadata()
+	m, err := c.schemas.Measure(ctx, md.GetGroup(), md.GetName())
+	if err != nil {
+		return nil, false, err
+	}
+	return c.route(ctx, md, m.GetTagFamilies(), m.GetEntity(), m.GetShardingKey(), req.GetDataPoint().GetTagFamilies())
+}
+
+func (c *Client) route(ctx context.Context, md *commonv1.Metadata, families []*databasev1.TagFamilySpec, entity *databasev1.Entity,
+	shardingKey *databasev1.ShardingKey, values []*modelv1.TagFamilyForWrite,
+) (*modelv1.RoutingHint, bool, error) {
+	g, err := c.schemas.Group(ctx, md.GetGroup())
+	if err != nil {
+		return nil, false, err
+	}
+	shardNum := g.GetResourceOpts().GetShardNum()
+	locator := partition.NewEntityLocator(families, entity, 0)
+	if len(shardingKey.GetTagNames()) > 0 {
+		locator = partition.NewShardingKeyLocator(families, shardingKey)
+	}
+	_, shardID, err := locator.Locate(md.GetName(), values, shardNum)
+	if err != nil {
+		return nil, false, err
+	}
+	h, ok := c.routes.Get(md.GetGroup(), uint32(shardID))
+	return h, ok, nil
+}
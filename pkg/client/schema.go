@@ -0,0 +1,138 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+)
+
+type schemaKind int
+
+const (
+	schemaKindGroup schemaKind = iota
+	schemaKindStream
+	schemaKindMeasure
+)
+
+type schemaKey struct {
+	group string
+	name  string
+	kind  schemaKind
+}
+
+type schemaEntry struct {
+	expireAt time.Time
+	value    any
+}
+
+// SchemaCache caches the groups, the streams and the measures fetched from the servers.
+// An entry is fetched again once it's older than the TTL, or it's invalidated.
+type SchemaCache struct {
+	c       *Client
+	entries map[schemaKey]schemaEntry
+	ttl     time.Duration
+	mu      sync.RWMutex
+}
+
+func newSchemaCache(c *Client, ttl time.Duration) *SchemaCache {
+	return &SchemaCache{
+		c:       c,
+		ttl:     ttl,
+		entries: make(map[schemaKey]schemaEntry),
+	}
+}
+
+// Group returns the group.
+func (s *SchemaCache) Group(ctx context.Context, group string) (*commonv1.Group, error) {
+	v, err := s.get(ctx, schemaKey{kind: schemaKindGroup, group: group}, func(ctx context.Context) (any, error) {
+		resp, err := databasev1.NewGroupRegistryServiceClient(s.c.conn()).Get(ctx, &databasev1.GroupRegistryServiceGetRequest{Group: group})
+		return resp.GetGroup(), err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*commonv1.Group), nil
+}
+
+// Stream returns the stream.
+func (s *SchemaCache) Stream(ctx context.Context, group, name string) (*databasev1.Stream, error) {
+	v, err := s.get(ctx, schemaKey{kind: schemaKindStream, group: group, name: name}, func(ctx context.Context) (any, error) {
+		resp, err := databasev1.NewStreamRegistryServiceClient(s.c.conn()).Get(ctx, &databasev1.StreamRegistryServiceGetRequest{
+			Metadata: &commonv1.Metadata{Group: group, Name: name},
+		})
+		return resp.GetStream(), err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*databasev1.Stream), nil
+}
+
+// Measure returns the measure.
+func (s *SchemaCache) Measure(ctx context.Context, group, name string) (*databasev1.Measure, error) {
+	v, err := s.get(ctx, schemaKey{kind: schemaKindMeasure, group: group, name: name}, func(ctx context.Context) (any, error) {
+		resp, err := databasev1.NewMeasureRegistryServiceClient(s.c.conn()).Get(ctx, &databasev1.MeasureRegistryServiceGetRequest{
+			Metadata: &commonv1.Metadata{Group: group, Name: name},
+		})
+		return resp.GetMeasure(), err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*databasev1.Measure), nil
+}
+
+// Invalidate drops the cached group and the stream or measure of name in it.
+// All the streams and measures of the group are dropped if name is empty.
+func (s *SchemaCache) Invalidate(group, name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, schemaKey{kind: schemaKindGroup, group: group})
+	for k := range s.entries {
+		if k.group == group && (name == "" || k.name == name) {
+			delete(s.entries, k)
+		}
+	}
+}
+
+func (s *SchemaCache) get(ctx context.Context, key schemaKey, fetch func(ctx context.Context) (any, error)) (any, error) {
+	now := time.Now()
+	s.mu.RLock()
+	e, ok := s.entries[key]
+	s.mu.RUnlock()
+	if ok && now.Before(e.expireAt) {
+		return e.value, nil
+	}
+	var v any
+	err := s.c.retry.do(ctx, func(ctx context.Context) (errFetch error) {
+		v, errFetch = fetch(ctx)
+		return errFetch
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key] = schemaEntry{value: v, expireAt: now.Add(s.ttl)}
+	return v, nil
+}
@@ -0,0 +1,169 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package client
+
+import (
+	"errors"
+	"fmt"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+)
+
+var (
+	// ErrUnknownTag indicates the query refers to a tag absent from the schema.
+	ErrUnknownTag = errors.New("unknown tag")
+	// ErrUnknownField indicates the query refers to a field absent from the schema.
+	ErrUnknownField = errors.New("unknown field")
+	// ErrTagTypeMismatch indicates the value of a condition doesn't match the type of the tag.
+	ErrTagTypeMismatch = errors.New("tag type mismatch")
+)
+
+// Validate checks the tags of the query against the schema of the stream.
+func (q *StreamQuery) Validate(s *databasev1.Stream) error {
+	if err := validateProjection(s.GetTagFamilies(), q.req.GetProjection()); err != nil {
+		return err
+	}
+	return validateCriteria(s.GetTagFamilies(), q.req.GetCriteria())
+}
+
+// Validate checks the tags and the fields of the query against the schema of the measure.
+func (q *MeasureQuery) Validate(m *databasev1.Measure) error {
+	families := m.GetTagFamilies()
+	if err := validateProjection(families, q.req.GetTagProjection()); err != nil {
+		return err
+	}
+	if err := validateCriteria(families, q.req.GetCriteria()); err != nil {
+		return err
+	}
+	if err := validateProjection(families, q.req.GetGroupBy().GetTagProjection()); err != nil {
+		return err
+	}
+	fields := make(map[string]struct{}, len(m.GetFields()))
+	for _, f := range m.GetFields() {
+		fields[f.GetName()] = struct{}{}
+	}
+	names := append([]string{}, q.req.GetFieldProjection().GetNames()...)
+	names = append(names, q.req.GetGroupBy().GetFieldName(), q.req.GetAgg().GetFieldName(), q.req.GetTop().GetFieldName())
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		if _, ok := fields[name]; !ok {
+			return fmt.Errorf("%w: %s is not a field of %s", ErrUnknownField, name, m.GetMetadata().GetName())
+		}
+	}
+	return nil
+}
+
+func validateProjection(families []*databasev1.TagFamilySpec, p *modelv1.TagProjection) error {
+	for _, pf := range p.GetTagFamilies() {
+		var family *databasev1.TagFamilySpec
+		for _, f := range families {
+			if f.GetName() == pf.GetName() {
+				family = f
+				break
+			}
+		}
+		if family == nil {
+			return fmt.Errorf("%w: the tag family %s is absent", ErrUnknownTag, pf.GetName())
+		}
+		for _, tag := range pf.GetTags() {
+			if _, _, spec := pbv1.FindTagByName([]*databasev1.TagFamilySpec{family}, tag); spec == nil {
+				return fmt.Errorf("%w: %s is not in the tag family %s", ErrUnknownTag, tag, family.GetName())
+			}
+		}
+	}
+	return nil
+}
+
+func validateCriteria(families []*databasev1.TagFamilySpec, c *modelv1.Criteria) error {
+	switch exp := c.GetExp().(type) {
+	case *modelv1.Criteria_Le:
+		if err := validateCriteria(families, exp.Le.GetLeft()); err != nil {
+			return err
+		}
+		return validateCriteria(families, exp.Le.GetRight())
+	case *modelv1.Criteria_Condition:
+		return validateCondition(families, exp.Condition)
+	}
+	return nil
+}
+
+func validateCondition(families []*databasev1.TagFamilySpec, cond *modelv1.Condition) error {
+	_, _, spec := pbv1.FindTagByName(families, cond.GetName())
+	if spec == nil {
+		return fmt.Errorf("%w: %s", ErrUnknownTag, cond.GetName())
+	}
+	kind, isArray := valueKind(cond.GetValue())
+	if kind == databasev1.TagType_TAG_TYPE_UNSPECIFIED {
+		// the null value of EXISTS and IS_NULL fits any tag.
+		return nil
+	}
+	if kind != elementType(spec.GetType()) {
+		return fmt.Errorf("%w: %s is %s, but the value is %s",
+			ErrTagTypeMismatch, cond.GetName(), spec.GetType(), kind)
+	}
+	switch cond.GetOp() {
+	case modelv1.Condition_BINARY_OP_IN, modelv1.Condition_BINARY_OP_NOT_IN,
+		modelv1.Condition_BINARY_OP_HAVING, modelv1.Condition_BINARY_OP_NOT_HAVING:
+		if !isArray {
+			return fmt.Errorf("%w: %s takes an array", ErrTagTypeMismatch, cond.GetOp())
+		}
+	case modelv1.Condition_BINARY_OP_MATCH, modelv1.Condition_BINARY_OP_PREFIX, modelv1.Condition_BINARY_OP_WILDCARD:
+		if kind != databasev1.TagType_TAG_TYPE_STRING {
+			return fmt.Errorf("%w: %s only applies to the string tags", ErrTagTypeMismatch, cond.GetOp())
+		}
+	default:
+		if isArray && spec.GetType() != databasev1.TagType_TAG_TYPE_STRING_ARRAY && spec.GetType() != databasev1.TagType_TAG_TYPE_INT_ARRAY {
+			return fmt.Errorf("%w: %s takes a single value of %s", ErrTagTypeMismatch, cond.GetOp(), cond.GetName())
+		}
+	}
+	return nil
+}
+
+// elementType returns the type of the elements of the array tags, or the type of the others.
+func elementType(t databasev1.TagType) databasev1.TagType {
+	switch t {
+	case databasev1.TagType_TAG_TYPE_STRING_ARRAY:
+		return databasev1.TagType_TAG_TYPE_STRING
+	case databasev1.TagType_TAG_TYPE_INT_ARRAY:
+		return databasev1.TagType_TAG_TYPE_INT
+	}
+	return t
+}
+
+// valueKind returns the type of the value, or of its elements if it's an array.
+func valueKind(v *modelv1.TagValue) (databasev1.TagType, bool) {
+	switch v.GetValue().(type) {
+	case *modelv1.TagValue_Str:
+		return databasev1.TagType_TAG_TYPE_STRING, false
+	case *modelv1.TagValue_StrArray:
+		return databasev1.TagType_TAG_TYPE_STRING, true
+	case *modelv1.TagValue_Int:
+		return databasev1.TagType_TAG_TYPE_INT, false
+	case *modelv1.TagValue_IntArray:
+		return databasev1.TagType_TAG_TYPE_INT, true
+	case *modelv1.TagValue_BinaryData:
+		return databasev1.TagType_TAG_TYPE_DATA_BINARY, false
+	case *modelv1.TagValue_Timestamp:
+		return databasev1.TagType_TAG_TYPE_TIMESTAMP, false
+	}
+	return databasev1.TagType_TAG_TYPE_UNSPECIFIED, false
+}
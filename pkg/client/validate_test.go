@@ -0,0 +1,109 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+)
+
+var validateStream = &databasev1.Stream{
+	Metadata: &commonv1.Metadata{Group: "sw", Name: "segment"},
+	TagFamilies: []*databasev1.TagFamilySpec{
+		{
+			Name: "searchable",
+			Tags: []*databasev1.TagSpec{
+				{Name: "trace_id", Type: databasev1.TagType_TAG_TYPE_STRING},
+				{Name: "duration", Type: databasev1.TagType_TAG_TYPE_INT},
+				{Name: "tags", Type: databasev1.TagType_TAG_TYPE_STRING_ARRAY},
+			},
+		},
+		{
+			Name: "data",
+			Tags: []*databasev1.TagSpec{{Name: "data_binary", Type: databasev1.TagType_TAG_TYPE_DATA_BINARY}},
+		},
+	},
+}
+
+func TestStreamQueryValidate(t *testing.T) {
+	query := func() *StreamQuery {
+		return NewStreamQuery("segment", "sw").TimeRange(time.Unix(0, 0), time.Unix(3600, 0)).Project("searchable", "trace_id")
+	}
+	tests := []struct {
+		query *StreamQuery
+		err   error
+		name  string
+	}{
+		{name: "valid", query: query().
+			Project("data", "data_binary").
+			Where(Or(Eq("trace_id", "t1"), Gt("duration", 100))).
+			Where(Having("tags", "http.method=GET")).
+			Where(In("duration", int64(1), int64(2))).
+			Where(Exists("trace_id"))},
+		{name: "unknown family", query: query().Project("unknown", "trace_id"), err: ErrUnknownTag},
+		{name: "tag out of the family", query: query().Project("data", "trace_id"), err: ErrUnknownTag},
+		{name: "unknown condition tag", query: query().Where(Eq("unknown", "v")), err: ErrUnknownTag},
+		{name: "nested unknown tag", query: query().Where(And(Eq("trace_id", "t1"), Or(Eq("trace_id", "t2"), Lt("unknown", 1)))), err: ErrUnknownTag},
+		{name: "string to int", query: query().Where(Eq("duration", "100")), err: ErrTagTypeMismatch},
+		{name: "int to string", query: query().Where(Eq("trace_id", 1)), err: ErrTagTypeMismatch},
+		{name: "in to a scalar", query: query().Where(condition("duration", modelv1.Condition_BINARY_OP_IN, Tag(1))), err: ErrTagTypeMismatch},
+		{name: "array to a scalar", query: query().Where(condition("duration", modelv1.Condition_BINARY_OP_EQ, Tag([]int64{1}))), err: ErrTagTypeMismatch},
+		{name: "match an int", query: query().Where(condition("duration", modelv1.Condition_BINARY_OP_MATCH, Tag(1))), err: ErrTagTypeMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.query.Validate(validateStream)
+			if tt.err == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestMeasureQueryValidate(t *testing.T) {
+	m := &databasev1.Measure{
+		Metadata: &commonv1.Metadata{Group: "sw", Name: "service_cpm"},
+		TagFamilies: []*databasev1.TagFamilySpec{{
+			Name: "default",
+			Tags: []*databasev1.TagSpec{{Name: "entity_id", Type: databasev1.TagType_TAG_TYPE_STRING}},
+		}},
+		Fields: []*databasev1.FieldSpec{{Name: "total"}, {Name: "value"}},
+	}
+	query := func() *MeasureQuery {
+		return NewMeasureQuery("service_cpm", "sw").TimeRange(time.Unix(0, 0), time.Unix(3600, 0)).Project("default", "entity_id")
+	}
+	assert.NoError(t, query().Fields("total", "value").
+		Where(Eq("entity_id", "svc")).
+		GroupBy("total", "default", "entity_id").
+		Aggregate(modelv1.AggregationFunction_AGGREGATION_FUNCTION_SUM, "total").
+		Top(5, "value", modelv1.Sort_SORT_DESC).
+		Validate(m))
+	assert.ErrorIs(t, query().Fields("unknown").Validate(m), ErrUnknownField)
+	assert.ErrorIs(t, query().Aggregate(modelv1.AggregationFunction_AGGREGATION_FUNCTION_MAX, "unknown").Validate(m), ErrUnknownField)
+	assert.ErrorIs(t, query().GroupBy("total", "default", "unknown").Validate(m), ErrUnknownTag)
+	assert.ErrorIs(t, query().Where(Eq("entity_id", 1)).Validate(m), ErrTagTypeMismatch)
+}
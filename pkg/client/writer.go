@@ -0,0 +1,387 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+)
+
+var (
+	// ErrNoResponse indicates the server closes the write stream without answering the write.
+	ErrNoResponse = errors.New("no response to the write")
+	// ErrWriterClosed indicates the writer is closed.
+	ErrWriterClosed = errors.New("the writer is closed")
+)
+
+// WriteResult is the result of a write.
+type WriteResult struct {
+	// Err is nil if the write succeeds.
+	Err         error
+	Metadata    *commonv1.Metadata
+	RoutingHint *modelv1.RoutingHint
+	// ElementID is the ID generated by the server for the stream element.
+	ElementID string
+	MessageID uint64
+	Status    modelv1.Status
+}
+
+// WriteError carries the writes which failed after the retries.
+type WriteError struct {
+	Failed []WriteResult
+}
+
+func (e *WriteError) Error() string {
+	return fmt.Sprintf("%d writes failed, the first one: %v", len(e.Failed), e.Failed[0].Err)
+}
+
+// BatchOptions configures the batching of a writer.
+type BatchOptions struct {
+	// OnResult is called with the result of every write if it's set.
+	// It's the only way to learn the failures of the periodical flushes.
+	OnResult func(WriteResult)
+	// Size flushes the batch once it has Size writes.
+	Size int
+	// FlushInterval flushes the batch periodically. 0 disables the periodical flushes.
+	FlushInterval time.Duration
+}
+
+// DefaultBatchOptions flushes every 1000 writes or every second.
+var DefaultBatchOptions = BatchOptions{
+	Size:          1000,
+	FlushInterval: time.Second,
+}
+
+type writeStream[Req, Resp proto.Message] interface {
+	Send(Req) error
+	Recv() (Resp, error)
+	CloseSend() error
+}
+
+// writeProtocol adapts the write API of the streams or the measures to the batcher.
+type writeProtocol[Req, Resp proto.Message] struct {
+	open    func(ctx context.Context, conn *grpc.ClientConn) (writeStream[Req, Resp], error)
+	prepare func(req Req, messageID uint64, idempotencyKey string, routingHint bool)
+	request func(req Req) (*commonv1.Metadata, uint64)
+	result  func(resp Resp) WriteResult
+}
+
+// batcher sends the writes in batches through a write stream, and retries the failed ones.
+// The messages IDs and the idempotency keys are assigned to the writes without them,
+// so that the data nodes drop the duplicated writes of the retries.
+type batcher[Req, Resp proto.Message] struct {
+	c         *Client
+	protocol  writeProtocol[Req, Resp]
+	stopCh    chan struct{}
+	doneCh    chan struct{}
+	keyPrefix string
+	pending   []Req
+	opts      BatchOptions
+	nextID    uint64
+	mu        sync.Mutex
+	closed    bool
+}
+
+func newBatcher[Req, Resp proto.Message](c *Client, protocol writeProtocol[Req, Resp], opts BatchOptions) *batcher[Req, Resp] {
+	b := &batcher[Req, Resp]{
+		c:        c,
+		protocol: protocol,
+		opts:     opts,
+		// #nosec G404 -- the prefix only tells the writers apart.
+		keyPrefix: strconv.FormatUint(rand.Uint64(), 36),
+		stopCh:    make(chan struct{}),
+		doneCh:    make(chan struct{}),
+	}
+	if b.opts.Size < 1 {
+		b.opts.Size = 1
+	}
+	if b.opts.FlushInterval <= 0 {
+		close(b.doneCh)
+		return b
+	}
+	go func() {
+		defer close(b.doneCh)
+		t := time.NewTicker(b.opts.FlushInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-b.stopCh:
+				return
+			case <-t.C:
+				// the failures are reported to OnResult.
+				_ = b.flush(context.Background())
+			}
+		}
+	}()
+	return b
+}
+
+func (b *batcher[Req, Resp]) write(ctx context.Context, req Req) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return ErrWriterClosed
+	}
+	b.nextID++
+	b.protocol.prepare(req, b.nextID, b.keyPrefix+"-"+strconv.FormatUint(b.nextID, 10), b.c.routingHints)
+	b.pending = append(b.pending, req)
+	if len(b.pending) < b.opts.Size {
+		return nil
+	}
+	return b.flushLocked(ctx)
+}
+
+func (b *batcher[Req, Resp]) flush(ctx context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.flushLocked(ctx)
+}
+
+func (b *batcher[Req, Resp]) close(ctx context.Context) error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	b.mu.Unlock()
+	close(b.stopCh)
+	<-b.doneCh
+	return b.flush(ctx)
+}
+
+func (b *batcher[Req, Resp]) flushLocked(ctx context.Context) error {
+	if len(b.pending) == 0 {
+		return nil
+	}
+	batch := b.pending
+	b.pending = nil
+	var failed []WriteResult
+	for _, r := range b.send(ctx, batch) {
+		if r.Err != nil {
+			failed = append(failed, r)
+		}
+		if r.RoutingHint != nil {
+			b.c.routes.update(r.Metadata.GetGroup(), r.RoutingHint)
+		}
+		if b.opts.OnResult != nil {
+			b.opts.OnResult(r)
+		}
+	}
+	if len(failed) > 0 {
+		return &WriteError{Failed: failed}
+	}
+	return nil
+}
+
+// send writes the batch, and retries the writes failing with the retryable errors or statuses.
+func (b *batcher[Req, Resp]) send(ctx context.Context, batch []Req) []WriteResult {
+	policy := b.c.retry
+	results := make([]WriteResult, 0, len(batch))
+	for attempt := 1; ; attempt++ {
+		resps, err := b.sendOnce(ctx, batch)
+		last := attempt >= policy.attempts()
+		var retries []Req
+		for _, req := range batch {
+			md, id := b.protocol.request(req)
+			r, retryable := b.result(resps, id, err)
+			r.Metadata, r.MessageID = md, id
+			if r.Err != nil && retryable && !last {
+				retries = append(retries, req)
+				continue
+			}
+			results = append(results, r)
+		}
+		if len(retries) == 0 {
+			return results
+		}
+		if errWait := policy.wait(ctx, attempt); errWait != nil {
+			for _, req := range retries {
+				md, id := b.protocol.request(req)
+				results = append(results, WriteResult{Metadata: md, MessageID: id, Err: errWait})
+			}
+			return results
+		}
+		batch = retries
+	}
+}
+
+func (b *batcher[Req, Resp]) result(resps map[uint64]WriteResult, id uint64, streamErr error) (WriteResult, bool) {
+	r, ok := resps[id]
+	if !ok {
+		if streamErr != nil {
+			return WriteResult{Err: streamErr}, b.c.retry.retryableError(streamErr)
+		}
+		// the idempotency key keeps the retry from writing the data twice.
+		return WriteResult{Err: ErrNoResponse}, true
+	}
+	if r.Status == modelv1.Status_STATUS_SUCCEED {
+		return r, false
+	}
+	r.Err = fmt.Errorf("the write is rejected with %s", r.Status)
+	if r.Status == modelv1.Status_STATUS_NOT_FOUND || r.Status == modelv1.Status_STATUS_EXPIRED_SCHEMA {
+		b.c.schemas.Invalidate(r.Metadata.GetGroup(), r.Metadata.GetName())
+	}
+	return r, b.c.retry.retryableStatus(r.Status)
+}
+
+// sendOnce sends the batch through a write stream, and collects the responses by the message IDs.
+func (b *batcher[Req, Resp]) sendOnce(ctx context.Context, batch []Req) (map[uint64]WriteResult, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := b.protocol.open(ctx, b.c.conn())
+	if err != nil {
+		return nil, err
+	}
+	for _, req := range batch {
+		// the error of the stream is returned by Recv.
+		if stream.Send(req) != nil {
+			break
+		}
+	}
+	_ = stream.CloseSend()
+	resps := make(map[uint64]WriteResult, len(batch))
+	for {
+		resp, errRecv := stream.Recv()
+		if errors.Is(errRecv, io.EOF) {
+			return resps, nil
+		}
+		if errRecv != nil {
+			return resps, errRecv
+		}
+		r := b.protocol.result(resp)
+		resps[r.MessageID] = r
+	}
+}
+
+// StreamWriter writes the elements of the streams in batches.
+type StreamWriter struct {
+	b *batcher[*streamv1.WriteRequest, *streamv1.WriteResponse]
+}
+
+// NewStreamWriter returns a writer of the stream elements.
+func (c *Client) NewStreamWriter(opts BatchOptions) *StreamWriter {
+	return &StreamWriter{b: newBatcher(c, writeProtocol[*streamv1.WriteRequest, *streamv1.WriteResponse]{
+		open: func(ctx context.Context, conn *grpc.ClientConn) (writeStream[*streamv1.WriteRequest, *streamv1.WriteResponse], error) {
+			return streamv1.NewStreamServiceClient(conn).Write(ctx)
+		},
+		prepare: func(req *streamv1.WriteRequest, messageID uint64, idempotencyKey string, routingHint bool) {
+			req.MessageId = messageID
+			if req.IdempotencyKey == "" {
+				req.IdempotencyKey = idempotencyKey
+			}
+			req.RoutingHint = req.RoutingHint || routingHint
+		},
+		request: func(req *streamv1.WriteRequest) (*commonv1.Metadata, uint64) {
+			return req.GetMetadata(), req.GetMessageId()
+		},
+		result: func(resp *streamv1.WriteResponse) WriteResult {
+			return WriteResult{
+				Metadata:    resp.GetMetadata(),
+				MessageID:   resp.GetMessageId(),
+				Status:      parseStatus(resp.GetStatus()),
+				ElementID:   resp.GetElementId(),
+				RoutingHint: resp.GetRoutingHint(),
+			}
+		},
+	}, opts)}
+}
+
+// Write adds the element to the batch. It flushes the batch once the batch is full,
+// and returns a *WriteError if any write of the batch fails.
+// The message ID of req is assigned by the writer.
+func (w *StreamWriter) Write(ctx context.Context, req *streamv1.WriteRequest) error {
+	return w.b.write(ctx, req)
+}
+
+// Flush sends the batch, and returns a *WriteError if any write fails.
+func (w *StreamWriter) Flush(ctx context.Context) error {
+	return w.b.flush(ctx)
+}
+
+// Close flushes the batch and stops the periodical flushes.
+func (w *StreamWriter) Close(ctx context.Context) error {
+	return w.b.close(ctx)
+}
+
+// MeasureWriter writes the data points of the measures in batches.
+type MeasureWriter struct {
+	b *batcher[*measurev1.WriteRequest, *measurev1.WriteResponse]
+}
+
+// NewMeasureWriter returns a writer of the measure data points.
+func (c *Client) NewMeasureWriter(opts BatchOptions) *MeasureWriter {
+	return &MeasureWriter{b: newBatcher(c, writeProtocol[*measurev1.WriteRequest, *measurev1.WriteResponse]{
+		open: func(ctx context.Context, conn *grpc.ClientConn) (writeStream[*measurev1.WriteRequest, *measurev1.WriteResponse], error) {
+			return measurev1.NewMeasureServiceClient(conn).Write(ctx)
+		},
+		prepare: func(req *measurev1.WriteRequest, messageID uint64, idempotencyKey string, routingHint bool) {
+			req.MessageId = messageID
+			if req.IdempotencyKey == "" {
+				req.IdempotencyKey = idempotencyKey
+			}
+			req.RoutingHint = req.RoutingHint || routingHint
+		},
+		request: func(req *measurev1.WriteRequest) (*commonv1.Metadata, uint64) {
+			return req.GetMetadata(), req.GetMessageId()
+		},
+		result: func(resp *measurev1.WriteResponse) WriteResult {
+			return WriteResult{
+				Metadata:    resp.GetMetadata(),
+				MessageID:   resp.GetMessageId(),
+				Status:      parseStatus(resp.GetStatus()),
+				RoutingHint: resp.GetRoutingHint(),
+			}
+		},
+	}, opts)}
+}
+
+// Write adds the data point to the batch. It flushes the batch once the batch is full,
+// and returns a *WriteError if any write of the batch fails.
+// The message ID of req is assigned by the writer.
+func (w *MeasureWriter) Write(ctx context.Context, req *measurev1.WriteRequest) error {
+	return w.b.write(ctx, req)
+}
+
+// Flush sends the batch, and returns a *WriteError if any write fails.
+func (w *MeasureWriter) Flush(ctx context.Context) error {
+	return w.b.flush(ctx)
+}
+
+// Close flushes the batch and stops the periodical flushes.
+func (w *MeasureWriter) Close(ctx context.Context) error {
+	return w.b.close(ctx)
+}
+
+func parseStatus(s string) modelv1.Status {
+	return modelv1.Status(modelv1.Status_value[s])
+}
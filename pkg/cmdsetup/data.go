@@ -48,16 +48,18 @@ func newDataCmd(runners ...run.Unit) *cobra.Command {
 	localPipeline := queue.Local()
 	metricSvc := observability.NewMetricService(metaSvc, localPipeline, "data", nil)
 	pm := protector.NewMemory(metricSvc)
+	fdp := protector.NewFD(metricSvc)
+	iop := protector.NewIO(metricSvc)
 	pipeline := sub.NewServer(metricSvc)
 	propertySvc, err := property.NewService(metaSvc, pipeline, metricSvc, pm)
 	if err != nil {
 		l.Fatal().Err(err).Msg("failed to initiate property service")
 	}
-	streamSvc, err := stream.NewService(metaSvc, pipeline, metricSvc, pm)
+	streamSvc, err := stream.NewService(metaSvc, pipeline, metricSvc, pm, fdp, iop)
 	if err != nil {
 		l.Fatal().Err(err).Msg("failed to initiate stream service")
 	}
-	measureSvc, err := measure.NewService(metaSvc, pipeline, localPipeline, metricSvc, pm)
+	measureSvc, err := measure.NewService(metaSvc, pipeline, localPipeline, metricSvc, pm, fdp, iop)
 	if err != nil {
 		l.Fatal().Err(err).Msg("failed to initiate measure service")
 	}
@@ -65,7 +67,7 @@ func newDataCmd(runners ...run.Unit) *cobra.Command {
 	if err != nil {
 		l.Fatal().Err(err).Msg("failed to initiate query processor")
 	}
-	profSvc := observability.NewProfService()
+	profSvc := observability.NewProfService(observability.NewSchemaBundleContributor(metaSvc), streamSvc, measureSvc)
 
 	var units []run.Unit
 	units = append(units, runners...)
@@ -74,6 +76,8 @@ func newDataCmd(runners ...run.Unit) *cobra.Command {
 		localPipeline,
 		metricSvc,
 		pm,
+		fdp,
+		iop,
 		pipeline,
 		propertySvc,
 		measureSvc,
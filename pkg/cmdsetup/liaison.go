@@ -54,12 +54,14 @@ func newLiaisonCmd(runners ...run.Unit) *cobra.Command {
 	measureLiaisonNodeRegistry := grpc.NewClusterNodeRegistry(data.TopicMeasureWrite, tire1Client, measureLiaisonNodeSel)
 	measureDataNodeSel := node.NewRoundRobinSelector(data.TopicMeasureWrite.String(), metaSvc)
 	metricSvc := observability.NewMetricService(metaSvc, tire1Client, "liaison", measureLiaisonNodeRegistry)
+	pub.SetMetricsRegistry(tire1Client, metricSvc)
+	pub.SetMetricsRegistry(tire2Client, metricSvc)
 	internalPipeline := sub.NewServerWithPorts(metricSvc, "liaison-server", 18912, 18913)
 	streamLiaisonNodeSel := node.NewRoundRobinSelector(data.TopicStreamWrite.String(), metaSvc)
 	streamDataNodeSel := node.NewRoundRobinSelector(data.TopicStreamWrite.String(), metaSvc)
 	propertyNodeSel := node.NewRoundRobinSelector(data.TopicPropertyUpdate.String(), metaSvc)
 	topNPipeline := queue.Local()
-	dQuery, err := dquery.NewService(metaSvc, localPipeline, tire2Client, topNPipeline, metricSvc)
+	dQuery, err := dquery.NewService(metaSvc, localPipeline, tire2Client, topNPipeline, metricSvc, streamDataNodeSel)
 	if err != nil {
 		l.Fatal().Err(err).Msg("failed to initiate distributed query service")
 	}
@@ -70,7 +72,7 @@ func newLiaisonCmd(runners ...run.Unit) *cobra.Command {
 		StreamDataNodeRegistry:     grpc.NewClusterNodeRegistry(data.TopicStreamWrite, tire2Client, streamDataNodeSel),
 		PropertyNodeRegistry:       grpc.NewClusterNodeRegistry(data.TopicPropertyUpdate, tire2Client, propertyNodeSel),
 	}, metricSvc, dQuery, internalPipeline)
-	profSvc := observability.NewProfService()
+	profSvc := observability.NewProfService(observability.NewSchemaBundleContributor(metaSvc))
 	httpServer := http.NewServer()
 	var units []run.Unit
 	units = append(units, runners...)
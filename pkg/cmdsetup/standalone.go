@@ -51,18 +51,20 @@ func newStandaloneCmd(runners ...run.Unit) *cobra.Command {
 	}
 	metricSvc := observability.NewMetricService(metaSvc, liaisonPipeline, "standalone", nil)
 	pm := protector.NewMemory(metricSvc)
+	fdp := protector.NewFD(metricSvc)
+	iop := protector.NewIO(metricSvc)
 	propertySvc, err := property.NewService(metaSvc, dataPipeline, metricSvc, pm)
 	if err != nil {
 		l.Fatal().Err(err).Msg("failed to initiate property service")
 	}
-	streamSvc, err := stream.NewService(metaSvc, dataPipeline, metricSvc, pm)
+	streamSvc, err := stream.NewService(metaSvc, dataPipeline, metricSvc, pm, fdp, iop)
 	if err != nil {
 		l.Fatal().Err(err).Msg("failed to initiate stream service")
 	}
 	var srvMetrics *grpcprom.ServerMetrics
 	srvMetrics.UnaryServerInterceptor()
 	srvMetrics.UnaryServerInterceptor()
-	measureSvc, err := measure.NewService(metaSvc, dataPipeline, nil, metricSvc, pm)
+	measureSvc, err := measure.NewService(metaSvc, dataPipeline, nil, metricSvc, pm, fdp, iop)
 	if err != nil {
 		l.Fatal().Err(err).Msg("failed to initiate measure service")
 	}
@@ -78,7 +80,7 @@ func newStandaloneCmd(runners ...run.Unit) *cobra.Command {
 		StreamLiaisonNodeRegistry:  nr,
 		PropertyNodeRegistry:       nr,
 	}, metricSvc, measureSvc, liaisonPipeline)
-	profSvc := observability.NewProfService()
+	profSvc := observability.NewProfService(observability.NewSchemaBundleContributor(metaSvc), streamSvc, measureSvc)
 	httpServer := http.NewServer()
 
 	var units []run.Unit
@@ -89,6 +91,8 @@ func newStandaloneCmd(runners ...run.Unit) *cobra.Command {
 		metaSvc,
 		metricSvc,
 		pm,
+		fdp,
+		iop,
 		propertySvc,
 		measureSvc,
 		streamSvc,
@@ -0,0 +1,261 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package zstd
+
+import (
+	"container/heap"
+	"fmt"
+	"sync"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+)
+
+const (
+	// dictKmerLen is the length of the substrings counted by the trainer.
+	dictKmerLen = 8
+	// dictSegmentLen is the length of the segments of the samples the dictionary is made of.
+	dictSegmentLen = 128
+	// minDictSize is the size below which a dictionary isn't worth being used.
+	minDictSize = 64
+)
+
+var (
+	dictsMu sync.Mutex
+	dicts   = make(map[uint64]*Dict)
+)
+
+// Dict is a raw content dictionary priming the compression of small and repetitive values.
+//
+// The dictionaries are registered by their IDs once loaded, and the blocks compressed with a dictionary
+// are decompressed by looking it up with LookupDict until it's released.
+type Dict struct {
+	decoder  *zstd.Decoder
+	encoders map[int]*zstd.Encoder
+	content  []byte
+	id       uint64
+	refs     int
+	mu       sync.Mutex
+}
+
+// LoadDict registers the dictionary of the content, or references it if it's loaded.
+// The caller should call Release if the dictionary isn't used any more.
+func LoadDict(content []byte) (*Dict, error) {
+	if len(content) < dictKmerLen {
+		return nil, fmt.Errorf("the dictionary of %d bytes is too small", len(content))
+	}
+	id := xxhash.Sum64(content)
+	dictsMu.Lock()
+	defer dictsMu.Unlock()
+	if d, ok := dicts[id]; ok {
+		d.refs++
+		return d, nil
+	}
+	c := make([]byte, len(content))
+	copy(c, content)
+	decoder, err := zstd.NewReader(nil, zstd.WithDecoderDictRaw(frameDictID(id), c))
+	if err != nil {
+		return nil, fmt.Errorf("cannot create the ZSTD reader with the dictionary: %w", err)
+	}
+	d := &Dict{
+		id:       id,
+		content:  c,
+		decoder:  decoder,
+		encoders: make(map[int]*zstd.Encoder),
+		refs:     1,
+	}
+	dicts[id] = d
+	return d, nil
+}
+
+// LookupDict returns the loaded dictionary with the id, or nil if it's absent.
+func LookupDict(id uint64) *Dict {
+	dictsMu.Lock()
+	defer dictsMu.Unlock()
+	return dicts[id]
+}
+
+// ID returns the identity of the dictionary, which is the hash of its content.
+func (d *Dict) ID() uint64 {
+	return d.id
+}
+
+// Content returns the raw content of the dictionary.
+func (d *Dict) Content() []byte {
+	return d.content
+}
+
+// Release dereferences the dictionary, which is unregistered once no one references it.
+func (d *Dict) Release() {
+	dictsMu.Lock()
+	d.refs--
+	if d.refs > 0 {
+		dictsMu.Unlock()
+		return
+	}
+	delete(dicts, d.id)
+	dictsMu.Unlock()
+
+	d.decoder.Close()
+	d.mu.Lock()
+	for _, e := range d.encoders {
+		_ = e.Close()
+	}
+	d.encoders = nil
+	d.mu.Unlock()
+}
+
+// Compress compresses the src into dst with the dictionary.
+func (d *Dict) Compress(dst, src []byte, compressionLevel int) []byte {
+	d.mu.Lock()
+	e := d.encoders[compressionLevel]
+	if e == nil {
+		var err error
+		e, err = zstd.NewWriter(nil,
+			zstd.WithEncoderCRC(false),
+			zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(compressionLevel)),
+			zstd.WithEncoderDictRaw(frameDictID(d.id), d.content))
+		if err != nil {
+			d.mu.Unlock()
+			logger.Panicf("failed to create ZSTD writer with the dictionary: %v", err)
+		}
+		d.encoders[compressionLevel] = e
+	}
+	d.mu.Unlock()
+	return e.EncodeAll(src, dst)
+}
+
+// Decompress decompresses the src compressed with the dictionary into dst.
+func (d *Dict) Decompress(dst, src []byte) ([]byte, error) {
+	return d.decoder.DecodeAll(src, dst)
+}
+
+// frameDictID returns the dictionary ID in the frame header, which can't be zero.
+func frameDictID(id uint64) uint32 {
+	return uint32(id) | 1
+}
+
+// TrainDict builds a raw content dictionary of at most maxSize bytes from the samples.
+// It returns nil if the samples have too little in common to be worth a dictionary.
+//
+// The trainer counts the samples every k-mer appears in, then greedily picks the segments of the samples
+// that cover the most frequent k-mers which aren't covered yet. The best segments are placed at the end
+// of the dictionary, where they are referenced with the shortest offsets.
+func TrainDict(samples [][]byte, maxSize int) []byte {
+	freq := make(map[uint64]int)
+	seen := make(map[uint64]struct{})
+	for _, s := range samples {
+		for i := 0; i+dictKmerLen <= len(s); i++ {
+			h := xxhash.Sum64(s[i : i+dictKmerLen])
+			if _, ok := seen[h]; ok {
+				continue
+			}
+			seen[h] = struct{}{}
+			freq[h]++
+		}
+		clear(seen)
+	}
+
+	distinct := make(map[string]struct{})
+	var segments segmentHeap
+	for _, s := range samples {
+		if len(s) < dictKmerLen {
+			continue
+		}
+		if _, ok := distinct[string(s)]; ok {
+			continue
+		}
+		distinct[string(s)] = struct{}{}
+		for off := 0; off+dictKmerLen <= len(s); off += dictSegmentLen {
+			seg := &segment{data: s[off:min(off+dictSegmentLen, len(s))]}
+			if seg.rescore(freq) > 0 {
+				segments = append(segments, seg)
+			}
+		}
+	}
+	heap.Init(&segments)
+
+	var picked []*segment
+	size := 0
+	for len(segments) > 0 && size < maxSize {
+		seg := segments[0]
+		// the scores drop as the k-mers are covered, so the top one is picked only if it's still the best.
+		if seg.rescore(freq) <= 0 {
+			heap.Pop(&segments)
+			continue
+		}
+		heap.Fix(&segments, 0)
+		if segments[0] != seg {
+			continue
+		}
+		heap.Pop(&segments)
+		if size+len(seg.data) > maxSize {
+			continue
+		}
+		for i := 0; i+dictKmerLen <= len(seg.data); i++ {
+			delete(freq, xxhash.Sum64(seg.data[i:i+dictKmerLen]))
+		}
+		picked = append(picked, seg)
+		size += len(seg.data)
+	}
+	if size < minDictSize {
+		return nil
+	}
+	dict := make([]byte, 0, size)
+	for i := len(picked) - 1; i >= 0; i-- {
+		dict = append(dict, picked[i].data...)
+	}
+	return dict
+}
+
+type segment struct {
+	data  []byte
+	score int
+}
+
+func (s *segment) rescore(freq map[uint64]int) int {
+	s.score = 0
+	for i := 0; i+dictKmerLen <= len(s.data); i++ {
+		// the k-mers appearing in a single sample don't help the others.
+		if n := freq[xxhash.Sum64(s.data[i:i+dictKmerLen])]; n > 1 {
+			s.score += n - 1
+		}
+	}
+	return s.score
+}
+
+type segmentHeap []*segment
+
+func (h segmentHeap) Len() int { return len(h) }
+
+func (h segmentHeap) Less(i, j int) bool { return h[i].score > h[j].score }
+
+func (h segmentHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *segmentHeap) Push(x any) { *h = append(*h, x.(*segment)) }
+
+func (h *segmentHeap) Pop() any {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return x
+}
@@ -0,0 +1,89 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package zstd_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/skywalking-banyandb/pkg/compress/zstd"
+)
+
+func sqlSamples(n int) [][]byte {
+	templates := []string{
+		"SELECT id, name, email, created_at FROM users WHERE tenant_id = %d AND status = 'active' ORDER BY created_at DESC",
+		"UPDATE orders SET status = 'shipped', updated_at = now() WHERE order_id = %d AND warehouse = 'east'",
+		"INSERT INTO audit_log (actor, action, target, payload) VALUES ('service-account', 'write', 'inventory', '%d')",
+	}
+	samples := make([][]byte, 0, n)
+	for i := 0; i < n; i++ {
+		samples = append(samples, []byte(fmt.Sprintf(templates[i%len(templates)], i*7919)))
+	}
+	return samples
+}
+
+func TestTrainDict(t *testing.T) {
+	samples := sqlSamples(300)
+	content := zstd.TrainDict(samples, 4<<10)
+	require.NotEmpty(t, content)
+	require.LessOrEqual(t, len(content), 4<<10)
+
+	d, err := zstd.LoadDict(content)
+	require.NoError(t, err)
+	defer d.Release()
+	require.Same(t, d, zstd.LookupDict(d.ID()))
+
+	src := sqlSamples(1)[0]
+	plain := zstd.Compress(nil, src, 3)
+	compressed := d.Compress(nil, src, 3)
+	require.Less(t, len(compressed), len(plain)/2, "the dictionary should prime the compression of a single value")
+
+	decompressed, err := d.Decompress(nil, compressed)
+	require.NoError(t, err)
+	require.Equal(t, src, decompressed)
+}
+
+func TestTrainDictWithoutCommonContent(t *testing.T) {
+	samples := make([][]byte, 0, 100)
+	for i := 0; i < 100; i++ {
+		samples = append(samples, randString(100))
+	}
+	require.Nil(t, zstd.TrainDict(samples, 4<<10))
+	require.Nil(t, zstd.TrainDict(nil, 4<<10))
+}
+
+func TestLoadDictReferences(t *testing.T) {
+	content := zstd.TrainDict(sqlSamples(30), 4<<10)
+	require.NotEmpty(t, content)
+
+	d1, err := zstd.LoadDict(content)
+	require.NoError(t, err)
+	d2, err := zstd.LoadDict(content)
+	require.NoError(t, err)
+	require.Same(t, d1, d2)
+
+	d1.Release()
+	require.Same(t, d2, zstd.LookupDict(d2.ID()))
+	d2.Release()
+	require.Nil(t, zstd.LookupDict(d2.ID()))
+
+	_, err = zstd.LoadDict([]byte("short"))
+	require.Error(t, err)
+}
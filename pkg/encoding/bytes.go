@@ -41,15 +41,29 @@ func DecodeBytes(src []byte) ([]byte, []byte, error) {
 	return src[n:], src[:n], nil
 }
 
+// DefaultCompressionLevel is the zstd compression level of the blocks if it isn't specified.
+const DefaultCompressionLevel = 1
+
 // EncodeBytesBlock encodes a block of strings into dst.
 func EncodeBytesBlock(dst []byte, a [][]byte) []byte {
+	return EncodeBytesBlockWithLevel(dst, a, DefaultCompressionLevel)
+}
+
+// EncodeBytesBlockWithLevel encodes a block of strings into dst, compressing it at the zstd compressionLevel.
+func EncodeBytesBlockWithLevel(dst []byte, a [][]byte, compressionLevel int) []byte {
+	return EncodeBytesBlockWithDict(dst, a, compressionLevel, nil)
+}
+
+// EncodeBytesBlockWithDict encodes a block of strings into dst, compressing the strings with the zstd dictionary.
+// The dictionary should stay loaded while the block is decoded. It's identical to EncodeBytesBlockWithLevel if dict is nil.
+func EncodeBytesBlockWithDict(dst []byte, a [][]byte, compressionLevel int, dict *zstd.Dict) []byte {
 	u64s := GenerateUint64List(len(a))
 	aLens := u64s.L[:0]
 	for _, s := range a {
 		aLens = append(aLens, uint64(len(s)))
 	}
 	u64s.L = aLens
-	dst = EncodeUint64Block(dst, u64s.L)
+	dst = encodeUint64Block(dst, u64s.L, compressionLevel)
 	ReleaseUint64List(u64s)
 
 	bb := bbPool.Generate()
@@ -58,7 +72,11 @@ func EncodeBytesBlock(dst []byte, a [][]byte) []byte {
 		b = append(b, s...)
 	}
 	bb.Buf = b
-	dst = compressBlock(dst, bb.Buf)
+	if dict != nil {
+		dst = compressBlockWithDict(dst, bb.Buf, compressionLevel, dict)
+	} else {
+		dst = compressBlock(dst, bb.Buf, compressionLevel)
+	}
 	bbPool.Release(bb)
 
 	return dst
@@ -116,9 +134,13 @@ func (bbd *BytesBlockDecoder) Decode(dst [][]byte, src []byte, itemsCount uint64
 
 // EncodeUint64Block encodes a block of uint64 values into dst.
 func EncodeUint64Block(dst []byte, a []uint64) []byte {
+	return encodeUint64Block(dst, a, DefaultCompressionLevel)
+}
+
+func encodeUint64Block(dst []byte, a []uint64, compressionLevel int) []byte {
 	bb := bbPool.Generate()
 	bb.Buf = encodeUint64List(bb.Buf[:0], a)
-	dst = compressBlock(dst, bb.Buf)
+	dst = compressBlock(dst, bb.Buf, compressionLevel)
 	bbPool.Release(bb)
 	return dst
 }
@@ -231,9 +253,11 @@ func decodeUint64List(dst []uint64, src []byte, itemsCount uint64) ([]uint64, er
 const (
 	compressTypePlain = 0
 	compressTypeZSTD  = 1
+	// compressTypeZSTDDict is followed by the ID of the dictionary compressing the block.
+	compressTypeZSTDDict = 2
 )
 
-func compressBlock(dst, src []byte) []byte {
+func compressBlock(dst, src []byte, compressionLevel int) []byte {
 	if len(src) < 128 {
 		dst = append(dst, compressTypePlain, byte(len(src)))
 		return append(dst, src...)
@@ -241,7 +265,22 @@ func compressBlock(dst, src []byte) []byte {
 
 	dst = append(dst, compressTypeZSTD)
 	bb := bbPool.Generate()
-	bb.Buf = zstd.Compress(bb.Buf[:0], src, 1)
+	bb.Buf = zstd.Compress(bb.Buf[:0], src, compressionLevel)
+	dst = VarUint64ToBytes(dst, uint64(len(bb.Buf)))
+	dst = append(dst, bb.Buf...)
+	bbPool.Release(bb)
+	return dst
+}
+
+func compressBlockWithDict(dst, src []byte, compressionLevel int, dict *zstd.Dict) []byte {
+	if len(src) < 128 {
+		return compressBlock(dst, src, compressionLevel)
+	}
+
+	dst = append(dst, compressTypeZSTDDict)
+	dst = Uint64ToBytes(dst, dict.ID())
+	bb := bbPool.Generate()
+	bb.Buf = dict.Compress(bb.Buf[:0], src, compressionLevel)
 	dst = VarUint64ToBytes(dst, uint64(len(bb.Buf)))
 	dst = append(dst, bb.Buf...)
 	bbPool.Release(bb)
@@ -289,8 +328,34 @@ func decompressBlock(dst, src []byte) ([]byte, []byte, error) {
 		dst = append(dst, bb.Buf...)
 		bbPool.Release(bb)
 		return dst, src, nil
+	case compressTypeZSTDDict:
+		if len(src) < 8 {
+			return dst, src, fmt.Errorf("cannot decode the dictionary ID from %d bytes", len(src))
+		}
+		id := BytesToUint64(src)
+		dict := zstd.LookupDict(id)
+		if dict == nil {
+			return dst, src, fmt.Errorf("the dictionary %d isn't loaded", id)
+		}
+		tail, blockLen := BytesToVarUint64(src[8:])
+		src = tail
+		if uint64(len(src)) < blockLen {
+			return dst, src, fmt.Errorf("cannot read compressed block with the size %d bytes from %d bytes", blockLen, len(src))
+		}
+		compressedBlock := src[:blockLen]
+		src = src[blockLen:]
+
+		var err error
+		bb := bbPool.Generate()
+		bb.Buf, err = dict.Decompress(bb.Buf[:0], compressedBlock)
+		if err != nil {
+			return dst, src, fmt.Errorf("cannot decompress block with the dictionary %d: %w", id, err)
+		}
+		dst = append(dst, bb.Buf...)
+		bbPool.Release(bb)
+		return dst, src, nil
 	default:
-		return dst, src, fmt.Errorf("unexpected block type: %d; supported types: 0, 1", blockType)
+		return dst, src, fmt.Errorf("unexpected block type: %d; supported types: 0, 1, 2", blockType)
 	}
 }
 
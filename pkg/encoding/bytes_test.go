@@ -18,11 +18,13 @@
 package encoding_test
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/apache/skywalking-banyandb/pkg/compress/zstd"
 	"github.com/apache/skywalking-banyandb/pkg/encoding"
 )
 
@@ -60,3 +62,25 @@ func TestEncodeBlockAndDecode(t *testing.T) {
 		assert.Equal(t, slice, decoded[i])
 	}
 }
+
+func TestEncodeBlockWithDictAndDecode(t *testing.T) {
+	var samples [][]byte
+	for i := 0; i < 100; i++ {
+		samples = append(samples, []byte(fmt.Sprintf("GET /api/v1/products/%d/reviews?sort=recent&page=1 HTTP/1.1", i)))
+	}
+	d, err := zstd.LoadDict(zstd.TrainDict(samples, 4<<10))
+	require.NoError(t, err)
+
+	slices := samples[:10]
+	encoded := encoding.EncodeBytesBlockWithDict(nil, slices, encoding.DefaultCompressionLevel, d)
+	assert.Less(t, len(encoded), len(encoding.EncodeBytesBlock(nil, slices)))
+	blockDecoder := &encoding.BytesBlockDecoder{}
+	decoded, err := blockDecoder.Decode(nil, encoded, uint64(len(slices)))
+	require.NoError(t, err)
+	assert.Equal(t, slices, decoded)
+
+	d.Release()
+	blockDecoder.Reset()
+	_, err = blockDecoder.Decode(nil, encoded, uint64(len(slices)))
+	require.ErrorContains(t, err, "isn't loaded")
+}
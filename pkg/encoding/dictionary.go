@@ -21,6 +21,8 @@ import (
 	"bytes"
 	"fmt"
 	"math/bits"
+
+	"github.com/apache/skywalking-banyandb/pkg/compress/zstd"
 )
 
 const maxUniqueValues = 256
@@ -64,8 +66,18 @@ func (d *Dictionary) Add(value []byte) bool {
 
 // Encode encodes the dictionary.
 func (d *Dictionary) Encode(dst []byte, tmp []uint32) []byte {
+	return d.EncodeWithLevel(dst, tmp, DefaultCompressionLevel)
+}
+
+// EncodeWithLevel encodes the dictionary, compressing its values at the zstd compressionLevel.
+func (d *Dictionary) EncodeWithLevel(dst []byte, tmp []uint32, compressionLevel int) []byte {
+	return d.EncodeWithDict(dst, tmp, compressionLevel, nil)
+}
+
+// EncodeWithDict encodes the dictionary, compressing its values with the zstd dictionary.
+func (d *Dictionary) EncodeWithDict(dst []byte, tmp []uint32, compressionLevel int, dict *zstd.Dict) []byte {
 	dst = VarUint64ToBytes(dst, uint64(len(d.values)))
-	dst = EncodeBytesBlock(dst, d.values)
+	dst = EncodeBytesBlockWithDict(dst, d.values, compressionLevel, dict)
 	re := encodeRLE(tmp, d.indices)
 	be := encodeBitPacking(re)
 	dst = append(dst, be...)
@@ -90,9 +102,32 @@ func (d *Dictionary) Decode(src []byte, tmp []uint32) error {
 		return err
 	}
 	d.indices = decodeRLE(d.indices[:0], tmp)
+	for _, index := range d.indices {
+		if index >= uint32(len(d.values)) {
+			return fmt.Errorf("index %d is out of the %d unique values", index, len(d.values))
+		}
+	}
 	return nil
 }
 
+// Contains reports whether the value has a code in the dictionary.
+func (d *Dictionary) Contains(value []byte) bool {
+	for _, v := range d.values {
+		if bytes.Equal(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Values appends the values in the order they were added to dst.
+func (d *Dictionary) Values(dst [][]byte) [][]byte {
+	for _, index := range d.indices {
+		dst = append(dst, d.values[index])
+	}
+	return dst
+}
+
 func (d *Dictionary) decodeBytesBlockWithTail(src []byte, itemsCount uint64) ([][]byte, []byte, error) {
 	u64List := GenerateUint64List(0)
 	defer ReleaseUint64List(u64List)
@@ -66,6 +66,18 @@ func TestEncodeAndDecodeDictionary(t *testing.T) {
 	require.Equal(t, expectedValues, decoded.values)
 	expectedIndices := []uint32{0, 1, 2, 3, 2}
 	require.Equal(t, expectedIndices, decoded.indices)
+	require.Equal(t, values, decoded.Values(nil))
+	require.True(t, decoded.Contains([]byte("hello")))
+	require.False(t, decoded.Contains([]byte("absent")))
+}
+
+func TestDictionaryTooManyValues(t *testing.T) {
+	dict := NewDictionary()
+	for i := 0; i < maxUniqueValues; i++ {
+		require.True(t, dict.Add([]byte(fmt.Sprintf("value-%d", i))))
+	}
+	require.True(t, dict.Add([]byte("value-0")))
+	require.False(t, dict.Add([]byte("overflow")))
 }
 
 type parameter struct {
@@ -94,6 +94,7 @@ const (
 	EncodeTypeDeltaWithVersion
 	EncodeTypeDeltaOfDeltaWithVersion
 	EncodeTypePlain
+	EncodeTypeDictionary
 )
 
 // GetVersionType returns the version type of the given encoding type.
@@ -0,0 +1,196 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package fault injects the faults into the storage and queue layers for the crash-recovery and replication tests.
+// The injection is only built in with the "fault" build tag. Otherwise, the hooks are no-ops and the rules
+// can't be added.
+package fault
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Path is the HTTP path to add, remove and list the rules, and to jump the clock.
+const Path = "/debug/faults"
+
+// The points where the faults are injected. The target of a point is the file path for the file system,
+// the topic for the bus, and "<topic>@<node>" for the queue.
+const (
+	PointFileSync   = "fs-sync"
+	PointFileWrite  = "fs-write"
+	PointBusPublish = "bus-publish"
+	PointQueueSend  = "queue-send"
+)
+
+// The actions of the rules.
+const (
+	// ActionDelay sleeps before the operation.
+	ActionDelay = "delay"
+	// ActionError fails the file operation.
+	ActionError = "error"
+	// ActionDrop discards the message silently.
+	ActionDrop = "drop"
+)
+
+var (
+	// ErrInjected is the error of the failed operations.
+	ErrInjected = errors.New("injected fault")
+
+	errDisabled = errors.New("the fault injection isn't built in, rebuild with the \"fault\" build tag")
+
+	pointActions = map[string][]string{
+		PointFileSync:   {ActionDelay, ActionError},
+		PointFileWrite:  {ActionDelay, ActionError},
+		PointBusPublish: {ActionDelay, ActionDrop},
+		PointQueueSend:  {ActionDelay, ActionDrop},
+	}
+)
+
+// Rule injects a fault into the operations at a point.
+type Rule struct {
+	ID     string `json:"id"`
+	Point  string `json:"point"`
+	Action string `json:"action"`
+	// Target limits the rule to the operations whose target contains it. An empty one matches all.
+	Target string `json:"target,omitempty"`
+	// Delay is the time ActionDelay sleeps, e.g. "500ms".
+	Delay Duration `json:"delay,omitempty"`
+	// Probability is the chance the rule fires, in (0, 1]. 0 means 1.
+	Probability float64 `json:"probability,omitempty"`
+	// Count is the number of the times the rule fires before it's removed. 0 means unlimited.
+	Count int `json:"count,omitempty"`
+}
+
+func (r *Rule) validate() error {
+	actions, ok := pointActions[r.Point]
+	if !ok {
+		return fmt.Errorf("unknown point %q", r.Point)
+	}
+	valid := false
+	for _, a := range actions {
+		valid = valid || a == r.Action
+	}
+	if !valid {
+		return fmt.Errorf("the action of the point %s should be one of %s", r.Point, strings.Join(actions, ", "))
+	}
+	if r.Action == ActionDelay && r.Delay <= 0 {
+		return errors.New("the delay should be positive")
+	}
+	if r.Probability < 0 || r.Probability > 1 {
+		return fmt.Errorf("the probability %v should be in (0, 1]", r.Probability)
+	}
+	if r.Count < 0 {
+		return fmt.Errorf("the count %d should not be negative", r.Count)
+	}
+	return nil
+}
+
+func (r *Rule) matches(point, target string) bool {
+	if r.Point != point || !strings.Contains(target, r.Target) {
+		return false
+	}
+	return r.Probability == 0 || r.Probability >= 1 || rand.Float64() < r.Probability
+}
+
+// Duration is a time.Duration in the string format of JSON.
+type Duration time.Duration
+
+// MarshalJSON implements json.Marshaler.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// State is the rules and the clock offset.
+type State struct {
+	Rules       []Rule   `json:"rules"`
+	ClockOffset Duration `json:"clock_offset"`
+	Enabled     bool     `json:"enabled"`
+}
+
+// Serve handles the requests of the Path and its "/clock" sub path:
+//   - GET lists the rules and the clock offset.
+//   - POST adds the rule in the JSON body, and returns it with its ID.
+//   - DELETE removes the rule of the "id" parameter, or all the rules without it.
+//   - POST to "/clock" jumps the clock by the "jump" parameter, e.g. "1h" or "-10m".
+func Serve(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if strings.HasSuffix(r.URL.Path, "/clock") {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		d, err := time.ParseDuration(r.URL.Query().Get("jump"))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid jump: %v", err), http.StatusBadRequest)
+			return
+		}
+		if err = jumpClock(d); err != nil {
+			http.Error(w, err.Error(), http.StatusNotImplemented)
+			return
+		}
+		writeState(w)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		writeState(w)
+	case http.MethodPost:
+		var rule Rule
+		if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+			http.Error(w, fmt.Sprintf("invalid rule: %v", err), http.StatusBadRequest)
+			return
+		}
+		if err := rule.validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		added, err := addRule(rule)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotImplemented)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(added)
+	case http.MethodDelete:
+		_ = json.NewEncoder(w).Encode(map[string]int{"removed": removeRules(r.URL.Query().Get("id"))})
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func writeState(w http.ResponseWriter) {
+	_ = json.NewEncoder(w).Encode(State{Enabled: Enabled, Rules: listRules(), ClockOffset: Duration(ClockOffset())})
+}
@@ -0,0 +1,57 @@
+//go:build !fault
+// +build !fault
+
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package fault
+
+import "time"
+
+// Enabled reports whether the fault injection is built in.
+const Enabled = false
+
+// Inject is a no-op without the "fault" build tag.
+func Inject(_, _ string) error {
+	return nil
+}
+
+// Drop is a no-op without the "fault" build tag.
+func Drop(_, _ string) bool {
+	return false
+}
+
+// ClockOffset is always zero without the "fault" build tag.
+func ClockOffset() time.Duration {
+	return 0
+}
+
+func addRule(_ Rule) (Rule, error) {
+	return Rule{}, errDisabled
+}
+
+func removeRules(_ string) int {
+	return 0
+}
+
+func listRules() []Rule {
+	return nil
+}
+
+func jumpClock(_ time.Duration) error {
+	return errDisabled
+}
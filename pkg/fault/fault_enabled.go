@@ -0,0 +1,138 @@
+//go:build fault
+// +build fault
+
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package fault
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Enabled reports whether the fault injection is built in.
+const Enabled = true
+
+var registry = struct {
+	rules       []*Rule
+	nextID      int
+	active      atomic.Int32
+	clockOffset atomic.Int64
+	mu          sync.Mutex
+}{}
+
+// Inject applies the delay and the error rules of the point to the operation on the target.
+// It returns ErrInjected if the operation should fail.
+func Inject(point, target string) error {
+	r := fire(point, target)
+	if r == nil {
+		return nil
+	}
+	switch r.Action {
+	case ActionDelay:
+		time.Sleep(time.Duration(r.Delay))
+	case ActionError:
+		return ErrInjected
+	}
+	return nil
+}
+
+// Drop applies the delay and the drop rules of the point to the message to the target.
+// It returns true if the message should be discarded.
+func Drop(point, target string) bool {
+	r := fire(point, target)
+	if r == nil {
+		return false
+	}
+	if r.Action == ActionDelay {
+		time.Sleep(time.Duration(r.Delay))
+		return false
+	}
+	return r.Action == ActionDrop
+}
+
+// ClockOffset is the sum of the clock jumps.
+func ClockOffset() time.Duration {
+	return time.Duration(registry.clockOffset.Load())
+}
+
+// fire picks the first rule matching the operation, and removes it once its count is used up.
+func fire(point, target string) *Rule {
+	if registry.active.Load() == 0 {
+		return nil
+	}
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	for i, r := range registry.rules {
+		if !r.matches(point, target) {
+			continue
+		}
+		fired := *r
+		if r.Count > 0 {
+			r.Count--
+			if r.Count == 0 {
+				registry.rules = append(registry.rules[:i], registry.rules[i+1:]...)
+				registry.active.Add(-1)
+			}
+		}
+		return &fired
+	}
+	return nil
+}
+
+func addRule(r Rule) (Rule, error) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	registry.nextID++
+	r.ID = strconv.Itoa(registry.nextID)
+	registry.rules = append(registry.rules, &r)
+	registry.active.Add(1)
+	return r, nil
+}
+
+func removeRules(id string) int {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	kept := registry.rules[:0]
+	for _, r := range registry.rules {
+		if id != "" && r.ID != id {
+			kept = append(kept, r)
+		}
+	}
+	removed := len(registry.rules) - len(kept)
+	registry.rules = kept
+	registry.active.Store(int32(len(kept)))
+	return removed
+}
+
+func listRules() []Rule {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	rules := make([]Rule, 0, len(registry.rules))
+	for _, r := range registry.rules {
+		rules = append(rules, *r)
+	}
+	return rules
+}
+
+func jumpClock(d time.Duration) error {
+	registry.clockOffset.Add(int64(d))
+	return nil
+}
@@ -0,0 +1,100 @@
+//go:build fault
+// +build fault
+
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package fault
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInject(t *testing.T) {
+	t.Cleanup(func() { removeRules("") })
+	_, err := addRule(Rule{Point: PointFileSync, Action: ActionError, Target: "/data/seg-", Count: 2})
+	require.NoError(t, err)
+
+	assert.NoError(t, Inject(PointFileSync, "/data/idx/meta"))
+	assert.NoError(t, Inject(PointFileWrite, "/data/seg-1/part"))
+	assert.ErrorIs(t, Inject(PointFileSync, "/data/seg-1/part"), ErrInjected)
+	assert.ErrorIs(t, Inject(PointFileSync, "/data/seg-1/part"), ErrInjected)
+	// the rule is removed once its count is used up.
+	assert.NoError(t, Inject(PointFileSync, "/data/seg-1/part"))
+	assert.Empty(t, listRules())
+}
+
+func TestDrop(t *testing.T) {
+	t.Cleanup(func() { removeRules("") })
+	_, err := addRule(Rule{Point: PointQueueSend, Action: ActionDelay, Target: "@node-1", Delay: Duration(20 * time.Millisecond)})
+	require.NoError(t, err)
+	drop, err := addRule(Rule{Point: PointQueueSend, Action: ActionDrop, Target: "@node-2"})
+	require.NoError(t, err)
+
+	start := time.Now()
+	assert.False(t, Drop(PointQueueSend, "measure-write@node-1"))
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+	assert.True(t, Drop(PointQueueSend, "measure-write@node-2"))
+	assert.False(t, Drop(PointBusPublish, "measure-write@node-2"))
+
+	assert.Equal(t, 1, removeRules(drop.ID))
+	assert.False(t, Drop(PointQueueSend, "measure-write@node-2"))
+}
+
+func TestServe(t *testing.T) {
+	t.Cleanup(func() {
+		removeRules("")
+		registry.clockOffset.Store(0)
+	})
+	rec := httptest.NewRecorder()
+	Serve(rec, httptest.NewRequest(http.MethodPost, Path, strings.NewReader(`{"point":"fs-sync","action":"delay","delay":"1s"}`)))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var added Rule
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &added))
+	assert.NotEmpty(t, added.ID)
+	assert.Equal(t, Duration(time.Second), added.Delay)
+
+	rec = httptest.NewRecorder()
+	Serve(rec, httptest.NewRequest(http.MethodPost, Path, strings.NewReader(`{"point":"fs-sync","action":"drop"}`)))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "should be one of delay, error")
+
+	rec = httptest.NewRecorder()
+	Serve(rec, httptest.NewRequest(http.MethodPost, Path+"/clock?jump=-1h", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, -time.Hour, ClockOffset())
+
+	rec = httptest.NewRecorder()
+	Serve(rec, httptest.NewRequest(http.MethodGet, Path, nil))
+	var state State
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &state))
+	assert.True(t, state.Enabled)
+	assert.Len(t, state.Rules, 1)
+	assert.Equal(t, Duration(-time.Hour), state.ClockOffset)
+
+	rec = httptest.NewRecorder()
+	Serve(rec, httptest.NewRequest(http.MethodDelete, Path, nil))
+	assert.JSONEq(t, `{"removed":1}`, rec.Body.String())
+}
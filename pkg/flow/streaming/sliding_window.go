@@ -73,6 +73,26 @@ func (s *windowedFlow) AllowedMaxWindows(windowCnt int) flow.WindowedFlow {
 	return s
 }
 
+func (s *windowedFlow) AllowedLateness(lateness time.Duration) flow.WindowedFlow {
+	switch v := s.wa.(type) {
+	case *tumblingTimeWindows:
+		v.allowedLateness = lateness.Milliseconds()
+	default:
+		s.f.drainErr(errors.New("allowed lateness is not supported"))
+	}
+	return s
+}
+
+func (s *windowedFlow) WindowOffset(offset time.Duration) flow.WindowedFlow {
+	switch v := s.wa.(type) {
+	case *tumblingTimeWindows:
+		v.offset = normalizeWindowOffset(offset.Milliseconds(), v.windowSize)
+	default:
+		s.f.drainErr(errors.New("window offset is not supported"))
+	}
+	return s
+}
+
 type tumblingTimeWindows struct {
 	l                  *logger.Logger
 	snapshots          *lru.Cache
@@ -87,6 +107,8 @@ type tumblingTimeWindows struct {
 	lastFlushTime    int64
 	flushInterval    int64
 	windowSize       int64
+	allowedLateness  int64
+	offset           int64
 	timerMu          sync.Mutex
 }
 
@@ -103,6 +125,13 @@ func (s *tumblingTimeWindows) Setup(_ context.Context) (err error) {
 		if s.windowCount <= 0 {
 			s.windowCount = defaultCacheSize
 		}
+		// keep all the windows within the lateness in the cache,
+		// otherwise a late element could recreate an evicted window with partial data
+		if s.allowedLateness > 0 && s.windowSize > 0 {
+			if minCount := int(s.allowedLateness/s.windowSize) + defaultCacheSize; s.windowCount < minCount {
+				s.windowCount = minCount
+			}
+		}
 		s.snapshots, err = lru.NewWithEvict(s.windowCount, func(key interface{}, value interface{}) {
 			flushed := s.flushSnapshot(key.(timeWindow), value.(flow.AggregationOp))
 			if e := s.l.Debug(); e.Enabled() {
@@ -144,6 +173,11 @@ func (s *tumblingTimeWindows) flushDueWindows() {
 		if lookAhead, ok := s.timerHeap.Peek().(*internalTimer); ok {
 			if lookAhead.triggerTimeMillis <= s.currentWatermark {
 				oldestTimer := heap.Pop(s.timerHeap).(*internalTimer)
+				if oldestTimer.purge {
+					// the evict callback flushes the window
+					s.snapshots.Remove(oldestTimer.w)
+					continue
+				}
 				s.flushWindow(oldestTimer.w)
 				continue
 			}
@@ -173,6 +207,9 @@ func (s *tumblingTimeWindows) receive() {
 		for _, w := range assignedWindows {
 			// drop if the window is late
 			if s.isWindowLate(w) {
+				if e := s.l.Debug(); e.Enabled() {
+					e.Stringer("window", w.(timeWindow)).Int64("watermark", s.currentWatermark).Msg("drop late element")
+				}
 				continue
 			}
 			tw := w.(timeWindow)
@@ -184,6 +221,9 @@ func (s *tumblingTimeWindows) receive() {
 				newAggr := s.aggregationFactory()
 				newAggr.Add([]flow.StreamRecord{elem})
 				s.snapshots.Add(tw, newAggr)
+				if s.allowedLateness > 0 {
+					ctx.RegisterPurgeTimer(tw.MaxTimestamp() + s.allowedLateness)
+				}
 				if e := s.l.Debug(); e.Enabled() {
 					e.Stringer("window", tw).Msg("create new window")
 				}
@@ -230,12 +270,16 @@ func (s *tumblingTimeWindows) receive() {
 	close(s.out)
 }
 
-// isWindowLate checks whether this window is valid. The window is late if and only if
+// isWindowLate checks whether this window is valid. The window is late if
+// the max timestamp plus the allowed lateness is before the current watermark, or
 // it meets all the following conditions,
 // 1) the max timestamp is before the current watermark
 // 2) the LRU cache is full
 // 3) the LRU cache does not contain the window entry.
 func (s *tumblingTimeWindows) isWindowLate(w flow.Window) bool {
+	if s.allowedLateness > 0 && w.MaxTimestamp()+s.allowedLateness <= s.currentWatermark {
+		return true
+	}
 	return w.MaxTimestamp() <= s.currentWatermark && s.snapshots.Len() >= s.windowCount && !s.snapshots.Contains(w)
 }
 
@@ -287,7 +331,7 @@ func (t timeWindow) String() string {
 // AssignWindows assigns windows according to the given timestamp.
 func (s *tumblingTimeWindows) AssignWindows(timestamp int64) ([]flow.Window, error) {
 	if timestamp > math.MinInt64 {
-		start := getWindowStart(timestamp, s.windowSize)
+		start := getWindowStart(timestamp-s.offset, s.windowSize) + s.offset
 		return []flow.Window{
 			timeWindow{
 				start: start,
@@ -304,6 +348,14 @@ func getWindowStart(timestamp, windowSize int64) int64 {
 	return timestamp - remainder
 }
 
+// normalizeWindowOffset maps the offset into [0, windowSize).
+func normalizeWindowOffset(offset, windowSize int64) int64 {
+	if windowSize <= 0 {
+		return 0
+	}
+	return (offset%windowSize + windowSize) % windowSize
+}
+
 // eventTimeTriggerOnElement processes element(s) with EventTimeTrigger.
 func eventTimeTriggerOnElement(window timeWindow, ctx *triggerContext) triggerResult {
 	if window.MaxTimestamp() <= ctx.GetCurrentWatermark() {
@@ -332,6 +384,17 @@ func (ctx *triggerContext) RegisterEventTimeTimer(triggerTime int64) {
 	})
 }
 
+// RegisterPurgeTimer registers a timer to remove the window once the watermark passes the allowed lateness.
+func (ctx *triggerContext) RegisterPurgeTimer(triggerTime int64) {
+	ctx.delegation.timerMu.Lock()
+	defer ctx.delegation.timerMu.Unlock()
+	heap.Push(ctx.delegation.timerHeap, &internalTimer{
+		triggerTimeMillis: triggerTime,
+		w:                 ctx.window,
+		purge:             true,
+	})
+}
+
 func (ctx *triggerContext) OnElement(_ flow.StreamRecord) triggerResult {
 	return eventTimeTriggerOnElement(ctx.window, ctx)
 }
@@ -342,6 +405,7 @@ type internalTimer struct {
 	w                 timeWindow
 	triggerTimeMillis int64
 	index             int
+	purge             bool
 }
 
 func (t *internalTimer) GetIndex() int {
@@ -60,6 +60,8 @@ var _ = g.Describe("Sliding Window", func() {
 		snk            *slice
 		input          []flow.StreamRecord
 		slidingWindows *tumblingTimeWindows
+		lateness       time.Duration
+		offset         time.Duration
 
 		aggrFactory = func() flow.AggregationOp {
 			return &intSumAggregator{}
@@ -68,6 +70,8 @@ var _ = g.Describe("Sliding Window", func() {
 
 	g.BeforeEach(func() {
 		baseTS = time.Now()
+		lateness = 0
+		offset = 0
 	})
 
 	g.JustBeforeEach(func() {
@@ -77,6 +81,8 @@ var _ = g.Describe("Sliding Window", func() {
 		slidingWindows.aggregationFactory = aggrFactory
 		slidingWindows.windowCount = 2
 		slidingWindows.l = logger.GetLogger("tumblingTimeWindows")
+		slidingWindows.allowedLateness = lateness.Milliseconds()
+		slidingWindows.offset = normalizeWindowOffset(offset.Milliseconds(), slidingWindows.windowSize)
 
 		gomega.Expect(slidingWindows.Setup(context.TODO())).Should(gomega.Succeed())
 		gomega.Expect(snk.Setup(context.TODO())).Should(gomega.Succeed())
@@ -136,4 +142,63 @@ var _ = g.Describe("Sliding Window", func() {
 			}).WithTimeout(flags.EventuallyTimeout).Should(gomega.Succeed())
 		})
 	})
+	g.When("input two elements within the same bucket shifted by the offset", func() {
+		g.BeforeEach(func() {
+			offset = time.Second * 5
+			baseTS = time.Unix(baseTS.Unix()-baseTS.Unix()%15, 0)
+			input = []flow.StreamRecord{
+				flow.NewStreamRecord(1, baseTS.Add(time.Second*4).UnixMilli()),
+				flow.NewStreamRecord(2, baseTS.Add(time.Second*6).UnixMilli()),
+			}
+		})
+
+		g.It("Should trigger the window starting at the offset", func() {
+			gomega.Eventually(func(g gomega.Gomega) {
+				g.Expect(snk.Value()).Should(gomega.HaveLen(1))
+				r := snk.Value()[0].(flow.StreamRecord)
+				g.Expect(r.TimestampMillis()).Should(gomega.Equal(baseTS.Add(-time.Second * 10).UnixMilli()))
+				g.Expect(r.Data()).Should(gomega.Equal(1))
+			}).WithTimeout(flags.EventuallyTimeout).Should(gomega.Succeed())
+		})
+	})
+
+	g.When("input a late element within the allowed lateness", func() {
+		g.BeforeEach(func() {
+			lateness = time.Second * 30
+			baseTS = time.Unix(baseTS.Unix()-baseTS.Unix()%15, 0)
+			input = []flow.StreamRecord{
+				flow.NewStreamRecord(1, baseTS.UnixMilli()),
+				flow.NewStreamRecord(2, baseTS.Add(time.Second*20).UnixMilli()),
+				flow.NewStreamRecord(4, baseTS.Add(time.Second).UnixMilli()),
+			}
+		})
+
+		g.It("Should trigger the window again with the late element", func() {
+			gomega.Eventually(func(g gomega.Gomega) {
+				g.Expect(snk.Value()).Should(gomega.ContainElement(flow.NewStreamRecord(1, baseTS.UnixMilli())))
+				g.Expect(snk.Value()).Should(gomega.ContainElement(flow.NewStreamRecord(5, baseTS.UnixMilli())))
+			}).WithTimeout(flags.EventuallyTimeout).Should(gomega.Succeed())
+		})
+	})
+
+	g.When("input a late element beyond the allowed lateness", func() {
+		g.BeforeEach(func() {
+			lateness = time.Second * 5
+			baseTS = time.Unix(baseTS.Unix()-baseTS.Unix()%15, 0)
+			input = []flow.StreamRecord{
+				flow.NewStreamRecord(1, baseTS.UnixMilli()),
+				flow.NewStreamRecord(2, baseTS.Add(time.Second*25).UnixMilli()),
+				flow.NewStreamRecord(4, baseTS.Add(time.Second).UnixMilli()),
+			}
+		})
+
+		g.It("Should drop the late element", func() {
+			gomega.Eventually(func(g gomega.Gomega) {
+				g.Expect(snk.Value()).Should(gomega.ContainElement(flow.NewStreamRecord(1, baseTS.UnixMilli())))
+			}).WithTimeout(flags.EventuallyTimeout).Should(gomega.Succeed())
+			gomega.Consistently(func() []interface{} {
+				return snk.Value()
+			}).WithTimeout(time.Second).ShouldNot(gomega.ContainElement(flow.NewStreamRecord(5, baseTS.UnixMilli())))
+		})
+	})
 })
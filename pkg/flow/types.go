@@ -57,6 +57,11 @@ type Flow interface {
 // The WindowedFlow can be created with a WindowAssigner.
 type WindowedFlow interface {
 	AllowedMaxWindows(windowCnt int) WindowedFlow
+	// AllowedLateness keeps a Window open until the watermark passes its end by the lateness,
+	// so that the late elements update the fired Window instead of being dropped.
+	AllowedLateness(lateness time.Duration) WindowedFlow
+	// WindowOffset shifts the start of the Window(s) by the offset, e.g. aligning daily windows to a time zone.
+	WindowOffset(offset time.Duration) WindowedFlow
 	// TopN applies a TopNAggregation to each Window.
 	TopN(topNum int, opts ...any) Flow
 }
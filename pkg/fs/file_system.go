@@ -122,6 +122,8 @@ type FileSystem interface {
 	MustGetFreeSpace(path string) uint64
 	// CreateHardLink creates hard links in destPath for files in srcPath that pass the filter.
 	CreateHardLink(srcPath, destPath string, filter func(string) bool) error
+	// Rename moves the file or directory from oldPath to newPath.
+	Rename(oldPath, newPath string) error
 }
 
 // DirEntry is the interface that wraps the basic information about a file or directory.
@@ -29,6 +29,7 @@ import (
 
 	"github.com/shirou/gopsutil/v3/disk"
 
+	"github.com/apache/skywalking-banyandb/pkg/fault"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
 	"github.com/apache/skywalking-banyandb/pkg/pool"
 )
@@ -221,6 +222,9 @@ func (fs *localFileSystem) Write(buffer []byte, name string, permission Mode) (i
 	}
 	defer file.Close()
 
+	if err = injectWriteFault(name); err != nil {
+		return 0, err
+	}
 	size, err := file.Write(buffer)
 	if err != nil {
 		return size, &FileSystemError{
@@ -280,6 +284,30 @@ func (fs *localFileSystem) DeleteFile(name string) error {
 	}
 }
 
+// Rename is used to move the file or directory.
+func (fs *localFileSystem) Rename(oldPath, newPath string) error {
+	err := os.Rename(oldPath, newPath)
+	switch {
+	case err == nil:
+		return nil
+	case os.IsNotExist(err):
+		return &FileSystemError{
+			Code:    IsNotExistError,
+			Message: fmt.Sprintf("File is not exist, file name: %s, error message: %s", oldPath, err),
+		}
+	case os.IsPermission(err):
+		return &FileSystemError{
+			Code:    permissionError,
+			Message: fmt.Sprintf("There is not enough permission, file name: %s, error message: %s", oldPath, err),
+		}
+	default:
+		return &FileSystemError{
+			Code:    otherError,
+			Message: fmt.Sprintf("Rename file error, file name: %s, new name: %s, error message: %s", oldPath, newPath, err),
+		}
+	}
+}
+
 func (fs *localFileSystem) MustRMAll(path string) {
 	if err := os.RemoveAll(path); err == nil {
 		return
@@ -398,6 +426,9 @@ func (fs *localFileSystem) CreateHardLink(srcPath, destPath string, filter func(
 
 // Write adds new data to the end of a file.
 func (file *LocalFile) Write(buffer []byte) (int, error) {
+	if err := injectWriteFault(file.file.Name()); err != nil {
+		return 0, err
+	}
 	size, err := file.file.Write(buffer)
 	switch {
 	case err == nil:
@@ -423,6 +454,9 @@ func (file *LocalFile) Write(buffer []byte) (int, error) {
 
 // Writev supports appending consecutive buffers to the end of the file.
 func (file *LocalFile) Writev(iov *[][]byte) (int, error) {
+	if err := injectWriteFault(file.file.Name()); err != nil {
+		return 0, err
+	}
 	var size int
 	for _, buffer := range *iov {
 		wsize, err := file.file.Write(buffer)
@@ -522,6 +556,12 @@ func (file *LocalFile) Path() string {
 
 // Close is used to close File.
 func (file *LocalFile) Close() error {
+	if err := fault.Inject(fault.PointFileSync, file.file.Name()); err != nil {
+		return &FileSystemError{
+			Code:    flushError,
+			Message: fmt.Sprintf("Flush File error, directory name: %s, error message: %s", file.file.Name(), err),
+		}
+	}
 	if err := syncFile(file.file); err != nil {
 		return err
 	}
@@ -583,6 +623,9 @@ type seqWriter struct {
 }
 
 func (w *seqWriter) Write(p []byte) (n int, err error) {
+	if err = injectWriteFault(w.fileName); err != nil {
+		return 0, err
+	}
 	n, err = w.writer.Write(p)
 	if n > 0 && w.file != nil && !w.skipFadvise {
 		if flushErr := w.writer.Flush(); flushErr != nil {
@@ -628,6 +671,17 @@ func (w *seqWriter) Close() error {
 	return nil
 }
 
+// injectWriteFault fails the write to the file if a fault is injected.
+func injectWriteFault(name string) error {
+	if err := fault.Inject(fault.PointFileWrite, name); err != nil {
+		return &FileSystemError{
+			Code:    writeError,
+			Message: fmt.Sprintf("Write file error, file name: %s, error message: %s", name, err),
+		}
+	}
+	return nil
+}
+
 func generateReader(f *os.File, ioSize int) *bufio.Reader {
 	v := bufReaderPool.Get()
 	if v == nil {
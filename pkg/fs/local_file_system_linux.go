@@ -26,6 +26,8 @@ import (
 	"syscall"
 
 	"golang.org/x/sys/unix"
+
+	"github.com/apache/skywalking-banyandb/pkg/fault"
 )
 
 // localFileSystem is the implementation of FileSystem interface.
@@ -61,6 +63,9 @@ func (*localFileSystem) CreateLockFile(name string, permission Mode) (File, erro
 }
 
 func (fs *localFileSystem) SyncPath(name string) {
+	if err := fault.Inject(fault.PointFileSync, name); err != nil {
+		fs.logger.Panic().Str("name", name).Err(err).Msg("failed to sync file")
+	}
 	file, err := os.Open(name)
 	if err != nil {
 		fs.logger.Panic().Str("name", name).Err(err).Msg("failed to open file")
@@ -146,6 +146,20 @@ var _ = ginkgo.Describe("Local File System", func() {
 			_, err = os.Stat(fileName)
 			gomega.Expect(err).To(gomega.HaveOccurred())
 		})
+
+		ginkgo.It("Rename Test", func() {
+			newName := fileName + ".renamed"
+			err := fs.Rename(fileName, newName)
+			gomega.Expect(err).ToNot(gomega.HaveOccurred())
+			_, err = os.Stat(fileName)
+			gomega.Expect(err).To(gomega.HaveOccurred())
+			_, err = os.Stat(newName)
+			gomega.Expect(err).ToNot(gomega.HaveOccurred())
+			err = fs.Rename(fileName, newName)
+			var fsErr *FileSystemError
+			gomega.Expect(errors.As(err, &fsErr)).To(gomega.BeTrue())
+			gomega.Expect(fsErr.Code).To(gomega.Equal(IsNotExistError))
+		})
 	})
 
 	ginkgo.Context("Hard Link Operations", func() {
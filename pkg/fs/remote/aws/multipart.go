@@ -0,0 +1,224 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package aws
+
+import (
+	"context"
+	"crypto/md5" //nolint:gosec // S3 verifies the content of the parts by MD5.
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+const (
+	defaultPartSize        = 64 << 20
+	minPartSize            = 5 << 20
+	defaultPartConcurrency = 4
+	maxParts               = 10000
+)
+
+// multipartAPI is the part of the S3 client used by the multipart uploads.
+type multipartAPI interface {
+	CreateMultipartUpload(ctx context.Context, params *s3.CreateMultipartUploadInput,
+		optFns ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error)
+	UploadPart(ctx context.Context, params *s3.UploadPartInput, optFns ...func(*s3.Options)) (*s3.UploadPartOutput, error)
+	CompleteMultipartUpload(ctx context.Context, params *s3.CompleteMultipartUploadInput,
+		optFns ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error)
+	ListMultipartUploads(ctx context.Context, params *s3.ListMultipartUploadsInput,
+		optFns ...func(*s3.Options)) (*s3.ListMultipartUploadsOutput, error)
+	ListParts(ctx context.Context, params *s3.ListPartsInput, optFns ...func(*s3.Options)) (*s3.ListPartsOutput, error)
+}
+
+// uploadMultipart uploads the object in parts, up to partConcurrency of which are sent in parallel.
+// The parts are kept if the upload fails, so that the next upload of the key resumes it and skips
+// the parts whose MD5 match the local ones. S3 verifies every part by its MD5, and by the checksum algorithm if it's set.
+func (s *s3FS) uploadMultipart(ctx context.Context, key string, r io.ReaderAt, size int64) error {
+	partSize := s.partSize
+	for (size+partSize-1)/partSize > maxParts {
+		partSize *= 2
+	}
+	total := int((size + partSize - 1) / partSize)
+	uploadID, uploaded, err := s.resumableUpload(ctx, key)
+	if err != nil {
+		return err
+	}
+	if uploadID == "" {
+		out, errCreate := s.api.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+			Bucket:            aws.String(s.bucket),
+			Key:               aws.String(key),
+			ChecksumAlgorithm: s.checksumAlgorithm,
+			StorageClass:      s.storageClass,
+		})
+		if errCreate != nil {
+			return fmt.Errorf("create multipart upload of %s: %w", key, errCreate)
+		}
+		uploadID = aws.ToString(out.UploadId)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	parts := make([]types.CompletedPart, total)
+	sem := make(chan struct{}, s.partConcurrency)
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	for i := 0; i < total && ctx.Err() == nil; i++ {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			offset := int64(i) * partSize
+			p, errPart := s.uploadPart(ctx, key, uploadID, int32(i+1), io.NewSectionReader(r, offset, min(partSize, size-offset)), uploaded)
+			if errPart != nil {
+				once.Do(func() {
+					firstErr = errPart
+					cancel()
+				})
+				return
+			}
+			parts[i] = p
+		}(i)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+	if _, err = s.api.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(s.bucket),
+		Key:             aws.String(key),
+		UploadId:        aws.String(uploadID),
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+	}); err != nil {
+		return fmt.Errorf("complete multipart upload of %s: %w", key, err)
+	}
+	return nil
+}
+
+// uploadPart skips the part if it's uploaded with the same MD5, otherwise it uploads the part with its MD5.
+func (s *s3FS) uploadPart(ctx context.Context, key, uploadID string, num int32, section *io.SectionReader,
+	uploaded map[int32]types.Part,
+) (types.CompletedPart, error) {
+	sum, err := contentMD5(section)
+	if err != nil {
+		return types.CompletedPart{}, err
+	}
+	if p, ok := uploaded[num]; ok && aws.ToInt64(p.Size) == section.Size() &&
+		strings.Trim(aws.ToString(p.ETag), `"`) == hex.EncodeToString(sum) {
+		return types.CompletedPart{
+			ETag:              p.ETag,
+			PartNumber:        aws.Int32(num),
+			ChecksumCRC32:     p.ChecksumCRC32,
+			ChecksumCRC32C:    p.ChecksumCRC32C,
+			ChecksumCRC64NVME: p.ChecksumCRC64NVME,
+			ChecksumSHA1:      p.ChecksumSHA1,
+			ChecksumSHA256:    p.ChecksumSHA256,
+		}, nil
+	}
+	if err = s.throttle.Wait(ctx, section.Size()); err != nil {
+		return types.CompletedPart{}, err
+	}
+	out, err := s.api.UploadPart(ctx, &s3.UploadPartInput{
+		Bucket:            aws.String(s.bucket),
+		Key:               aws.String(key),
+		UploadId:          aws.String(uploadID),
+		PartNumber:        aws.Int32(num),
+		Body:              io.NewSectionReader(section, 0, section.Size()),
+		ContentLength:     aws.Int64(section.Size()),
+		ContentMD5:        aws.String(base64.StdEncoding.EncodeToString(sum)),
+		ChecksumAlgorithm: s.checksumAlgorithm,
+	})
+	if err != nil {
+		return types.CompletedPart{}, fmt.Errorf("upload part %d of %s: %w", num, key, err)
+	}
+	return types.CompletedPart{
+		ETag:              out.ETag,
+		PartNumber:        aws.Int32(num),
+		ChecksumCRC32:     out.ChecksumCRC32,
+		ChecksumCRC32C:    out.ChecksumCRC32C,
+		ChecksumCRC64NVME: out.ChecksumCRC64NVME,
+		ChecksumSHA1:      out.ChecksumSHA1,
+		ChecksumSHA256:    out.ChecksumSHA256,
+	}, nil
+}
+
+// resumableUpload finds the latest incomplete upload of the key with the same checksum algorithm,
+// and returns its ID and parts. The ID is empty if there is no such upload.
+func (s *s3FS) resumableUpload(ctx context.Context, key string) (string, map[int32]types.Part, error) {
+	out, err := s.api.ListMultipartUploads(ctx, &s3.ListMultipartUploadsInput{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(key),
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("list multipart uploads of %s: %w", key, err)
+	}
+	var latest *types.MultipartUpload
+	for i := range out.Uploads {
+		u := &out.Uploads[i]
+		if aws.ToString(u.Key) != key || u.ChecksumAlgorithm != s.checksumAlgorithm {
+			continue
+		}
+		if latest == nil || aws.ToTime(u.Initiated).After(aws.ToTime(latest.Initiated)) {
+			latest = u
+		}
+	}
+	if latest == nil {
+		return "", nil, nil
+	}
+	uploadID := aws.ToString(latest.UploadId)
+	parts := make(map[int32]types.Part)
+	var marker *string
+	for {
+		lp, errList := s.api.ListParts(ctx, &s3.ListPartsInput{
+			Bucket:           aws.String(s.bucket),
+			Key:              aws.String(key),
+			UploadId:         aws.String(uploadID),
+			PartNumberMarker: marker,
+		})
+		if errList != nil {
+			return "", nil, fmt.Errorf("list parts of %s: %w", key, errList)
+		}
+		for _, p := range lp.Parts {
+			parts[aws.ToInt32(p.PartNumber)] = p
+		}
+		if !aws.ToBool(lp.IsTruncated) {
+			return uploadID, parts, nil
+		}
+		marker = lp.NextPartNumberMarker
+	}
+}
+
+func contentMD5(r io.Reader) ([]byte, error) {
+	h := md5.New() //nolint:gosec // S3 verifies the content of the parts by MD5.
+	if _, err := io.Copy(h, r); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
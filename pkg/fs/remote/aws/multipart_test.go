@@ -0,0 +1,134 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package aws
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeMultipartAPI struct {
+	parts     map[int32][]byte
+	completed []types.CompletedPart
+	uploads   []types.MultipartUpload
+	sent      []int32
+	mu        sync.Mutex
+}
+
+func (f *fakeMultipartAPI) CreateMultipartUpload(_ context.Context, params *s3.CreateMultipartUploadInput,
+	_ ...func(*s3.Options),
+) (*s3.CreateMultipartUploadOutput, error) {
+	f.uploads = append(f.uploads, types.MultipartUpload{Key: params.Key, UploadId: aws.String("new")})
+	return &s3.CreateMultipartUploadOutput{UploadId: aws.String("new")}, nil
+}
+
+func (f *fakeMultipartAPI) UploadPart(_ context.Context, params *s3.UploadPartInput, _ ...func(*s3.Options)) (*s3.UploadPartOutput, error) {
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(params.Body); err != nil {
+		return nil, err
+	}
+	sum, _ := contentMD5(bytes.NewReader(buf.Bytes()))
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.parts[aws.ToInt32(params.PartNumber)] = buf.Bytes()
+	f.sent = append(f.sent, aws.ToInt32(params.PartNumber))
+	return &s3.UploadPartOutput{ETag: aws.String(`"` + hex.EncodeToString(sum) + `"`)}, nil
+}
+
+func (f *fakeMultipartAPI) CompleteMultipartUpload(_ context.Context, params *s3.CompleteMultipartUploadInput,
+	_ ...func(*s3.Options),
+) (*s3.CompleteMultipartUploadOutput, error) {
+	f.completed = params.MultipartUpload.Parts
+	return &s3.CompleteMultipartUploadOutput{}, nil
+}
+
+func (f *fakeMultipartAPI) ListMultipartUploads(_ context.Context, _ *s3.ListMultipartUploadsInput,
+	_ ...func(*s3.Options),
+) (*s3.ListMultipartUploadsOutput, error) {
+	return &s3.ListMultipartUploadsOutput{Uploads: f.uploads}, nil
+}
+
+func (f *fakeMultipartAPI) ListParts(_ context.Context, _ *s3.ListPartsInput, _ ...func(*s3.Options)) (*s3.ListPartsOutput, error) {
+	out := &s3.ListPartsOutput{}
+	for num, data := range f.parts {
+		sum, _ := contentMD5(bytes.NewReader(data))
+		out.Parts = append(out.Parts, types.Part{
+			PartNumber: aws.Int32(num),
+			ETag:       aws.String(`"` + hex.EncodeToString(sum) + `"`),
+			Size:       aws.Int64(int64(len(data))),
+		})
+	}
+	return out, nil
+}
+
+func (f *fakeMultipartAPI) object() []byte {
+	var buf bytes.Buffer
+	for _, p := range f.completed {
+		buf.Write(f.parts[aws.ToInt32(p.PartNumber)])
+	}
+	return buf.Bytes()
+}
+
+func newTestContent() []byte {
+	content := make([]byte, 2*minPartSize+100)
+	for i := range content {
+		content[i] = byte(i % 251)
+	}
+	return content
+}
+
+func TestUploadMultipart(t *testing.T) {
+	api := &fakeMultipartAPI{parts: make(map[int32][]byte)}
+	fs := &s3FS{api: api, bucket: "b", partSize: minPartSize, partConcurrency: 2}
+	content := newTestContent()
+
+	require.NoError(t, fs.uploadMultipart(context.Background(), "k", bytes.NewReader(content), int64(len(content))))
+	require.Len(t, api.completed, 3)
+	for i, p := range api.completed {
+		assert.Equal(t, int32(i+1), aws.ToInt32(p.PartNumber), "the parts are completed in order")
+	}
+	assert.Equal(t, content, api.object())
+}
+
+func TestUploadMultipartResumes(t *testing.T) {
+	content := newTestContent()
+	api := &fakeMultipartAPI{
+		parts: map[int32][]byte{
+			1: content[:minPartSize],
+			2: make([]byte, minPartSize),
+		},
+		uploads: []types.MultipartUpload{{Key: aws.String("k"), UploadId: aws.String("left")}},
+	}
+	fs := &s3FS{api: api, bucket: "b", partSize: minPartSize, partConcurrency: 2}
+
+	require.NoError(t, fs.uploadMultipart(context.Background(), "k", bytes.NewReader(content), int64(len(content))))
+	sort.Slice(api.sent, func(i, j int) bool { return api.sent[i] < api.sent[j] })
+	assert.Equal(t, []int32{2, 3}, api.sent, "the uploaded part 1 is skipped, and the corrupted part 2 is uploaded again")
+	assert.Len(t, api.uploads, 1, "the incomplete upload is resumed")
+	assert.Equal(t, content, api.object())
+}
@@ -20,8 +20,10 @@ package aws
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"io"
+	"os"
 	"path"
 	"strings"
 
@@ -36,10 +38,14 @@ import (
 // todo: Maybe we can bring in minio, oss
 type s3FS struct {
 	client            *s3.Client
+	api               multipartAPI
+	throttle          *remote.Throttle
 	bucket            string
 	basePath          string
 	checksumAlgorithm types.ChecksumAlgorithm
 	storageClass      types.StorageClass
+	partSize          int64
+	partConcurrency   int
 }
 
 // NewFS creates a new instance of the file system for accessing S3 storage.
@@ -62,9 +68,22 @@ func NewFS(path string, userConfig *remote.FsConfig) (remote.FS, error) {
 	client := s3.NewFromConfig(awsCfg)
 
 	fs := &s3FS{
-		client:   client,
-		bucket:   bucket,
-		basePath: basePath,
+		client:          client,
+		api:             client,
+		throttle:        remote.NewThrottle(userConfig.UploadRateLimit),
+		bucket:          bucket,
+		basePath:        basePath,
+		partSize:        defaultPartSize,
+		partConcurrency: defaultPartConcurrency,
+	}
+	if userConfig.S3PartSize > 0 {
+		if userConfig.S3PartSize < minPartSize {
+			return nil, fmt.Errorf("the part size %d is less than the minimum %d of S3", userConfig.S3PartSize, minPartSize)
+		}
+		fs.partSize = userConfig.S3PartSize
+	}
+	if userConfig.S3PartConcurrency > 0 {
+		fs.partConcurrency = userConfig.S3PartConcurrency
 	}
 
 	if userConfig.S3ChecksumAlgorithm != "" {
@@ -115,13 +134,40 @@ func (s *s3FS) getFullPath(p string) string {
 	return path.Join(s.basePath, p)
 }
 
+// sizedReaderAt is a local file, whose parts are uploaded in parallel and verified by their MD5.
+type sizedReaderAt interface {
+	io.ReaderAt
+	Stat() (os.FileInfo, error)
+}
+
 func (s *s3FS) Upload(ctx context.Context, path string, data io.Reader) error {
 	key := s.getFullPath(path)
 
+	var contentMD5Str *string
+	if f, ok := data.(sizedReaderAt); ok {
+		fi, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		if fi.Size() > s.partSize {
+			return s.uploadMultipart(ctx, key, f, fi.Size())
+		}
+		sum, err := contentMD5(io.NewSectionReader(f, 0, fi.Size()))
+		if err != nil {
+			return err
+		}
+		contentMD5Str = aws.String(base64.StdEncoding.EncodeToString(sum))
+		if err = s.throttle.Wait(ctx, fi.Size()); err != nil {
+			return err
+		}
+		data = io.NewSectionReader(f, 0, fi.Size())
+	}
+
 	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
 		Bucket:            aws.String(s.bucket),
 		Key:               aws.String(key),
 		Body:              data,
+		ContentMD5:        contentMD5Str,
 		ChecksumAlgorithm: s.checksumAlgorithm,
 		StorageClass:      s.storageClass,
 	})
@@ -24,4 +24,10 @@ type FsConfig struct {
 	S3ProfileName        string
 	S3StorageClass       string
 	S3ChecksumAlgorithm  string
+	// S3PartSize is the size of the parts of the multipart uploads. The files larger than it are uploaded in parts.
+	S3PartSize int64
+	// S3PartConcurrency is the number of the parts of a file uploaded in parallel.
+	S3PartConcurrency int
+	// UploadRateLimit caps the bytes uploaded per second, 0 means no limit.
+	UploadRateLimit int64
 }
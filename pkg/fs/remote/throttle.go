@@ -0,0 +1,67 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package remote
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Throttle caps the bandwidth shared by the transfers of a remote file system.
+// A nil Throttle doesn't limit them.
+type Throttle struct {
+	next           time.Time
+	bytesPerSecond int64
+	mu             sync.Mutex
+}
+
+// NewThrottle returns a throttle allowing bytesPerSecond, which is nil if bytesPerSecond isn't positive.
+func NewThrottle(bytesPerSecond int64) *Throttle {
+	if bytesPerSecond <= 0 {
+		return nil
+	}
+	return &Throttle{bytesPerSecond: bytesPerSecond}
+}
+
+// Wait blocks until n bytes are allowed to be sent, or the ctx is done.
+func (t *Throttle) Wait(ctx context.Context, n int64) error {
+	if t == nil || n <= 0 {
+		return nil
+	}
+	t.mu.Lock()
+	now := time.Now()
+	if t.next.Before(now) {
+		t.next = now
+	}
+	at := t.next
+	t.next = t.next.Add(time.Duration(n * int64(time.Second) / t.bytesPerSecond))
+	t.mu.Unlock()
+	d := time.Until(at)
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
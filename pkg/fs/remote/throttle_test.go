@@ -0,0 +1,46 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package remote
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestThrottle(t *testing.T) {
+	assert.Nil(t, NewThrottle(0))
+	var nilThrottle *Throttle
+	require.NoError(t, nilThrottle.Wait(context.Background(), 1<<30))
+
+	th := NewThrottle(1000)
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		require.NoError(t, th.Wait(context.Background(), 100))
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 300*time.Millisecond)
+
+	th = NewThrottle(1)
+	require.NoError(t, th.Wait(context.Background(), 1))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.ErrorIs(t, th.Wait(ctx, 1), context.Canceled, "the waiting is canceled with the ctx")
+}
@@ -0,0 +1,317 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpchelper
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+)
+
+// ErrorDomain is the domain of the ErrorInfo details attached to the errors of the BanyanDB services.
+const ErrorDomain = "banyandb.apache.org"
+
+// The machine-readable reasons of the errors. The reasons derived from the gRPC codes are named after them,
+// and the others narrow down the codes.
+const (
+	ReasonInvalidArgument    = "INVALID_ARGUMENT"
+	ReasonNotFound           = "NOT_FOUND"
+	ReasonAlreadyExists      = "ALREADY_EXISTS"
+	ReasonPermissionDenied   = "PERMISSION_DENIED"
+	ReasonFailedPrecondition = "FAILED_PRECONDITION"
+	ReasonResourceExhausted  = "RESOURCE_EXHAUSTED"
+	ReasonUnavailable        = "UNAVAILABLE"
+	ReasonDeadlineExceeded   = "DEADLINE_EXCEEDED"
+	ReasonAborted            = "ABORTED"
+	ReasonCanceled           = "CANCELED"
+	ReasonUnimplemented      = "UNIMPLEMENTED"
+	ReasonDataLoss           = "DATA_LOSS"
+	ReasonInternal           = "INTERNAL"
+	ReasonUnknown            = "UNKNOWN"
+	// ReasonGroupReadOnly indicates the group is archived, so it rejects the writes and the deletions.
+	ReasonGroupReadOnly = "GROUP_READ_ONLY"
+	// ReasonSchemaNotFound indicates the schema of the written or queried resource isn't registered.
+	ReasonSchemaNotFound = "SCHEMA_NOT_FOUND"
+	// ReasonQueryNotAdmitted indicates the data nodes can't afford the memory estimated for an aggregation or TopN query.
+	ReasonQueryNotAdmitted = "QUERY_NOT_ADMITTED"
+)
+
+// The types of the offending resources.
+const (
+	ResourceGroup     = "group"
+	ResourceStream    = "stream"
+	ResourceMeasure   = "measure"
+	ResourceProperty  = "property"
+	ResourceIndexRule = "index_rule"
+	ResourceTrace     = "trace"
+	// ResourceIndexRuleBinding is the type of the index rule bindings.
+	ResourceIndexRuleBinding = "index_rule_binding"
+	// ResourceTopNAggregation is the type of the TopN aggregations.
+	ResourceTopNAggregation = "topn_aggregation"
+)
+
+var codeReasons = map[codes.Code]string{
+	codes.InvalidArgument:    ReasonInvalidArgument,
+	codes.OutOfRange:         ReasonInvalidArgument,
+	codes.NotFound:           ReasonNotFound,
+	codes.AlreadyExists:      ReasonAlreadyExists,
+	codes.PermissionDenied:   ReasonPermissionDenied,
+	codes.Unauthenticated:    ReasonPermissionDenied,
+	codes.FailedPrecondition: ReasonFailedPrecondition,
+	codes.ResourceExhausted:  ReasonResourceExhausted,
+	codes.Unavailable:        ReasonUnavailable,
+	codes.DeadlineExceeded:   ReasonDeadlineExceeded,
+	codes.Aborted:            ReasonAborted,
+	codes.Canceled:           ReasonCanceled,
+	codes.Unimplemented:      ReasonUnimplemented,
+	codes.DataLoss:           ReasonDataLoss,
+	codes.Internal:           ReasonInternal,
+	codes.Unknown:            ReasonUnknown,
+}
+
+// Retriable reports whether the request failed with the code could succeed by retrying it later.
+func Retriable(code codes.Code) bool {
+	switch code {
+	case codes.Unavailable, codes.ResourceExhausted, codes.Aborted, codes.DeadlineExceeded:
+		return true
+	default:
+		return false
+	}
+}
+
+// Error is the structured error returned by the services. It's carried by the gRPC status along with
+// the ErrorInfo, the ResourceInfo and the RetryInfo details, so that the clients branch on the reason
+// instead of the message.
+type Error struct {
+	Message      string
+	Reason       string
+	ResourceType string
+	ResourceName string
+	Code         codes.Code
+	Retriable    bool
+}
+
+// NewError returns an error with the reason derived from the code.
+func NewError(code codes.Code, msg string) *Error {
+	return &Error{
+		Code:      code,
+		Message:   msg,
+		Reason:    reasonOf(code),
+		Retriable: Retriable(code),
+	}
+}
+
+// WithReason overrides the reason derived from the code.
+func (e *Error) WithReason(reason string) *Error {
+	e.Reason = reason
+	return e
+}
+
+// WithResource locates the offending resource.
+func (e *Error) WithResource(resourceType, resourceName string) *Error {
+	e.ResourceType = resourceType
+	e.ResourceName = resourceName
+	return e
+}
+
+func (e *Error) clone() *Error {
+	c := *e
+	return &c
+}
+
+func (e *Error) Error() string {
+	return e.GRPCStatus().Err().Error()
+}
+
+// GRPCStatus converts the error to the gRPC status with its details.
+func (e *Error) GRPCStatus() *status.Status {
+	return withDetails(status.New(e.Code, e.Message), e)
+}
+
+// FromError parses the structured error from the error returned by a service.
+// The reason and the retriability are derived from the code if the error doesn't carry the details.
+func FromError(err error) (*Error, bool) {
+	if err == nil {
+		return nil, false
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return e, true
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		return nil, false
+	}
+	e = NewError(st.Code(), st.Message())
+	for _, d := range st.Details() {
+		switch detail := d.(type) {
+		case *errdetails.ErrorInfo:
+			if detail.GetDomain() == ErrorDomain && detail.GetReason() != "" {
+				e.Reason = detail.GetReason()
+			}
+		case *errdetails.ResourceInfo:
+			e.ResourceType = detail.GetResourceType()
+			e.ResourceName = detail.GetResourceName()
+		case *errdetails.RetryInfo:
+			e.Retriable = true
+		}
+	}
+	return e, true
+}
+
+// ResourceResolver locates the resource a call operates on by its full method name and request.
+// It returns an empty type if the resource is unknown.
+type ResourceResolver func(fullMethod string, req any) (resourceType, resourceName string)
+
+// NormalizeError attaches the details of the structured error to the error if it doesn't carry them.
+// The details already attached, for example, the BadRequest, are kept.
+func NormalizeError(err error) error {
+	return normalizeError(err, "", "")
+}
+
+func normalizeError(err error, resourceType, resourceName string) error {
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		if e.ResourceType == "" && resourceType != "" {
+			e = e.clone().WithResource(resourceType, resourceName)
+		}
+		return e.GRPCStatus().Err()
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		// the context errors are mapped to their codes, and the others are unknown.
+		st = status.FromContextError(err)
+	}
+	for _, d := range st.Details() {
+		if info, isInfo := d.(*errdetails.ErrorInfo); isInfo && info.GetDomain() == ErrorDomain {
+			return st.Err()
+		}
+	}
+	return withDetails(st, NewError(st.Code(), st.Message()).WithResource(resourceType, resourceName)).Err()
+}
+
+// UnaryServerInterceptor normalizes the errors returned by the unary calls.
+// The resolver, if it's not nil, locates the offending resource of the errors without one.
+func UnaryServerInterceptor(resolver ResourceResolver) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		resp, err := handler(ctx, req)
+		if err == nil {
+			return resp, nil
+		}
+		var resourceType, resourceName string
+		if resolver != nil {
+			resourceType, resourceName = resolver(info.FullMethod, req)
+		}
+		return resp, normalizeError(err, resourceType, resourceName)
+	}
+}
+
+// StreamServerInterceptor normalizes the errors returned by the streaming calls.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return NormalizeError(handler(srv, ss))
+	}
+}
+
+func reasonOf(code codes.Code) string {
+	if r, ok := codeReasons[code]; ok {
+		return r
+	}
+	return ReasonUnknown
+}
+
+func withDetails(st *status.Status, e *Error) *status.Status {
+	if e.Code == codes.OK {
+		return st
+	}
+	withInfo, err := st.WithDetails(&errdetails.ErrorInfo{Reason: e.Reason, Domain: ErrorDomain})
+	if err != nil {
+		return st
+	}
+	st = withInfo
+	if e.ResourceType != "" {
+		if s, errRes := st.WithDetails(&errdetails.ResourceInfo{ResourceType: e.ResourceType, ResourceName: e.ResourceName}); errRes == nil {
+			st = s
+		}
+	}
+	if e.Retriable {
+		if s, errRetry := st.WithDetails(&errdetails.RetryInfo{}); errRetry == nil {
+			st = s
+		}
+	}
+	return st
+}
+
+type writeStatusError struct {
+	reason    string
+	code      codes.Code
+	retriable bool
+}
+
+var writeStatusErrors = map[modelv1.Status]writeStatusError{
+	modelv1.Status_STATUS_INVALID_TIMESTAMP:   {code: codes.InvalidArgument, reason: "INVALID_TIMESTAMP"},
+	modelv1.Status_STATUS_NOT_FOUND:           {code: codes.NotFound, reason: ReasonSchemaNotFound},
+	modelv1.Status_STATUS_EXPIRED_SCHEMA:      {code: codes.FailedPrecondition, reason: "EXPIRED_SCHEMA", retriable: true},
+	modelv1.Status_STATUS_INTERNAL_ERROR:      {code: codes.Internal, reason: ReasonInternal},
+	modelv1.Status_STATUS_DISK_FULL:           {code: codes.ResourceExhausted, reason: "DISK_FULL", retriable: true},
+	modelv1.Status_STATUS_MISROUTED:           {code: codes.FailedPrecondition, reason: "MISROUTED", retriable: true},
+	modelv1.Status_STATUS_ELEMENT_TOO_LARGE:   {code: codes.InvalidArgument, reason: "ELEMENT_TOO_LARGE"},
+	modelv1.Status_STATUS_INVALID_DATA:        {code: codes.InvalidArgument, reason: "INVALID_DATA"},
+	modelv1.Status_STATUS_UNSUPPORTED_VERSION: {code: codes.FailedPrecondition, reason: "UNSUPPORTED_VERSION"},
+	modelv1.Status_STATUS_READ_ONLY:           {code: codes.FailedPrecondition, reason: ReasonGroupReadOnly},
+	modelv1.Status_STATUS_OUT_OF_ORDER:        {code: codes.FailedPrecondition, reason: "OUT_OF_ORDER"},
+}
+
+// WriteResponse is the response of a stream or measure write.
+type WriteResponse interface {
+	GetMetadata() *commonv1.Metadata
+	GetStatus() string
+	GetReason() string
+}
+
+// WriteStatusError converts the status of a stream or measure write response to the structured error,
+// so that the clients handle the rejected writes as the failed calls. It returns nil if the write succeeds.
+// The retriable statuses succeed once the client refreshes the schema or the routes, or the server frees the disk.
+func WriteStatusError(resourceType string, resp WriteResponse) *Error {
+	s := modelv1.Status(modelv1.Status_value[resp.GetStatus()])
+	if s == modelv1.Status_STATUS_SUCCEED {
+		return nil
+	}
+	msg := resp.GetStatus()
+	if resp.GetReason() != "" {
+		msg += ": " + resp.GetReason()
+	}
+	e := NewError(codes.Unknown, msg)
+	if se, ok := writeStatusErrors[s]; ok {
+		e = &Error{Code: se.code, Message: msg, Reason: se.reason, Retriable: se.retriable}
+	}
+	if md := resp.GetMetadata(); md != nil {
+		e.WithResource(resourceType, md.GetGroup()+"/"+md.GetName())
+	}
+	return e
+}
@@ -0,0 +1,100 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpchelper
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+)
+
+func TestErrorRoundTrip(t *testing.T) {
+	err := NewError(codes.FailedPrecondition, "group sw is read-only").
+		WithReason(ReasonGroupReadOnly).WithResource(ResourceGroup, "sw")
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	assert.Equal(t, codes.FailedPrecondition, st.Code())
+	assert.Equal(t, "group sw is read-only", st.Message())
+
+	e, ok := FromError(st.Err())
+	require.True(t, ok)
+	assert.Equal(t, ReasonGroupReadOnly, e.Reason)
+	assert.Equal(t, ResourceGroup, e.ResourceType)
+	assert.Equal(t, "sw", e.ResourceName)
+	assert.False(t, e.Retriable)
+}
+
+func TestNormalizeError(t *testing.T) {
+	e, ok := FromError(NormalizeError(status.Error(codes.Unavailable, "no data node")))
+	require.True(t, ok)
+	assert.Equal(t, ReasonUnavailable, e.Reason)
+	assert.True(t, e.Retriable)
+
+	e, ok = FromError(NormalizeError(context.DeadlineExceeded))
+	require.True(t, ok)
+	assert.Equal(t, codes.DeadlineExceeded, e.Code)
+	assert.True(t, e.Retriable)
+
+	e, ok = FromError(normalizeError(errors.New("boom"), ResourceStream, "sw/segment"))
+	require.True(t, ok)
+	assert.Equal(t, codes.Unknown, e.Code)
+	assert.Equal(t, ReasonUnknown, e.Reason)
+	assert.Equal(t, "sw/segment", e.ResourceName)
+
+	// the details already attached are kept.
+	st, err := status.New(codes.InvalidArgument, "bad").WithDetails(&errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: "group", Description: "empty"}},
+	})
+	require.NoError(t, err)
+	normalized, _ := status.FromError(NormalizeError(st.Err()))
+	require.Len(t, normalized.Details(), 2)
+	// normalizing twice doesn't duplicate the details.
+	twice, _ := status.FromError(NormalizeError(normalized.Err()))
+	assert.Len(t, twice.Details(), 2)
+}
+
+func TestWriteStatusError(t *testing.T) {
+	md := &commonv1.Metadata{Group: "sw", Name: "segment"}
+	assert.Nil(t, WriteStatusError(ResourceStream, &streamv1.WriteResponse{Metadata: md, Status: modelv1.Status_STATUS_SUCCEED.String()}))
+
+	e := WriteStatusError(ResourceStream, &streamv1.WriteResponse{
+		Metadata: md,
+		Status:   modelv1.Status_STATUS_DISK_FULL.String(),
+		Reason:   "the disk usage is over 95%",
+	})
+	require.NotNil(t, e)
+	assert.Equal(t, codes.ResourceExhausted, e.Code)
+	assert.Equal(t, "DISK_FULL", e.Reason)
+	assert.True(t, e.Retriable)
+	assert.Equal(t, "sw/segment", e.ResourceName)
+	assert.Contains(t, e.Message, "the disk usage is over 95%")
+
+	e = WriteStatusError(ResourceStream, &streamv1.WriteResponse{Metadata: md, Status: modelv1.Status_STATUS_READ_ONLY.String()})
+	assert.Equal(t, ReasonGroupReadOnly, e.Reason)
+	assert.False(t, e.Retriable)
+}
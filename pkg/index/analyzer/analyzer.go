@@ -24,6 +24,7 @@ import (
 
 	"github.com/blugelabs/bluge/analysis"
 	"github.com/blugelabs/bluge/analysis/analyzer"
+	"github.com/blugelabs/bluge/analysis/token"
 	"github.com/blugelabs/bluge/analysis/tokenizer"
 
 	"github.com/apache/skywalking-banyandb/pkg/index"
@@ -32,6 +33,8 @@ import (
 // Analyzers is a map that associates each IndexRule_Analyzer type with a corresponding Analyzer.
 var Analyzers map[string]*analysis.Analyzer
 
+var caseInsensitiveAnalyzers map[string]*analysis.Analyzer
+
 func init() {
 	Analyzers = map[string]*analysis.Analyzer{
 		index.AnalyzerKeyword:  analyzer.NewKeywordAnalyzer(),
@@ -39,6 +42,28 @@ func init() {
 		index.AnalyzerStandard: analyzer.NewStandardAnalyzer(),
 		index.AnalyzerURL:      NewURLAnalyzer(),
 	}
+	caseInsensitiveAnalyzers = map[string]*analysis.Analyzer{
+		index.AnalyzerUnspecified: withLowerCase(analyzer.NewKeywordAnalyzer()),
+	}
+	for name, a := range Analyzers {
+		caseInsensitiveAnalyzers[name] = withLowerCase(a)
+	}
+}
+
+// CaseInsensitive returns the analyzer which lowercases the tokens of the named analyzer.
+// The unspecified analyzer indexes the whole lowercase value as a single token.
+func CaseInsensitive(name string) *analysis.Analyzer {
+	return caseInsensitiveAnalyzers[name]
+}
+
+func withLowerCase(a *analysis.Analyzer) *analysis.Analyzer {
+	filters := make([]analysis.TokenFilter, 0, len(a.TokenFilters)+1)
+	filters = append(filters, a.TokenFilters...)
+	return &analysis.Analyzer{
+		CharFilters:  a.CharFilters,
+		Tokenizer:    a.Tokenizer,
+		TokenFilters: append(filters, token.NewLowerCaseFilter()),
+	}
 }
 
 // NewURLAnalyzer creates a new URL analyzer.
@@ -182,3 +182,17 @@ func extractTerms(tokenStream analysis.TokenStream) [][]byte {
 	}
 	return terms
 }
+
+func TestCaseInsensitive(t *testing.T) {
+	terms := func(a *analysis.Analyzer, input string) []string {
+		var tt []string
+		for _, token := range a.Analyze([]byte(input)) {
+			tt = append(tt, string(token.Term))
+		}
+		return tt
+	}
+	assert.Equal(t, []string{"get /api/users"}, terms(CaseInsensitive(""), "GET /api/Users"))
+	assert.Equal(t, []string{"get /api/users"}, terms(CaseInsensitive("keyword"), "GET /api/Users"))
+	assert.Equal(t, []string{"api", "users"}, terms(CaseInsensitive("url"), "/API/Users"))
+	assert.Equal(t, []string{"API", "Users"}, terms(Analyzers["url"], "/API/Users"), "the original analyzer is unchanged")
+}
@@ -107,6 +107,8 @@ type Field struct {
 	NoSort bool
 	Store  bool
 	Index  bool
+	// CaseInsensitive indicates the index holds the lowercase terms of the string field, while the stored value is unchanged.
+	CaseInsensitive bool
 }
 
 // GetTerm returns the term value of the field.
@@ -300,6 +302,20 @@ type Document struct {
 	Timestamp    int64
 	DocID        uint64
 	Version      int64
+	// FirstSeen and LastSeen are the earliest and the latest timestamps written to a series,
+	// which the series index stores if they're positive.
+	FirstSeen int64
+	LastSeen  int64
+}
+
+// Seen widens the first and last seen timestamps of the document to include ts.
+func (d *Document) Seen(ts int64) {
+	if d.FirstSeen == 0 || ts < d.FirstSeen {
+		d.FirstSeen = ts
+	}
+	if ts > d.LastSeen {
+		d.LastSeen = ts
+	}
 }
 
 // Documents is a collection of documents.
@@ -328,12 +344,44 @@ type FieldIterable interface {
 		order modelv1.Sort, timeRange *timestamp.TimeRange, preLoadSize int) (FieldIterator[*DocumentResult], error)
 }
 
+// FacetField is a field whose values are counted by a Faceter.
+type FacetField struct {
+	Key     FieldKey
+	Numeric bool
+}
+
+// Facet is the number of the documents having every value of a field.
+// The key is the bytes of a string value, or the encoded int64 of a numeric value.
+type Facet map[string]int64
+
+// Faceter counts the values of the fields among the documents.
+type Faceter interface {
+	// Facets counts the values of the fields in the documents of the series in the time range.
+	// The documents whose ids are not in docIDs are skipped if docIDs is not nil.
+	Facets(ctx context.Context, sids []common.SeriesID, docIDs posting.List, fields []FacetField,
+		timeRange *timestamp.TimeRange) ([]Facet, error)
+}
+
+// StoredFields is the stored values of the fields in a document, in the order of the fetched fields.
+// A field has several values if it's indexed from an array.
+type StoredFields [][][]byte
+
+// ValueFetcher fetches the stored values of the fields.
+type ValueFetcher interface {
+	// Values returns the stored values of the fields in the documents whose ids are docIDs.
+	// The documents absent from the index are absent from the result.
+	Values(ctx context.Context, docIDs []uint64, fields []FieldKey) (map[uint64]StoredFields, error)
+}
+
 // Searcher allows searching a field either by its key or by its key and term.
 type Searcher interface {
 	FieldIterable
 	Match(fieldKey FieldKey, match []string, opts *modelv1.Condition_MatchOption) (list posting.List, timestamps posting.List, err error)
 	MatchField(fieldKey FieldKey) (list posting.List, timestamps posting.List, err error)
 	MatchTerms(field Field) (list posting.List, timestamps posting.List, err error)
+	MatchAnyTerms(fields []Field) (list posting.List, timestamps posting.List, err error)
+	LookupTerm(field Field) (list posting.List, timestamps posting.List, err error)
+	MatchPattern(fieldKey FieldKey, pattern TermPattern) (list posting.List, timestamps posting.List, err error)
 	Range(fieldKey FieldKey, opts RangeOpts) (list posting.List, timestamps posting.List, err error)
 }
 
@@ -347,9 +395,16 @@ type Store interface {
 	io.Closer
 	Writer
 	Searcher
+	Faceter
+	ValueFetcher
 	CollectMetrics(...string)
 	Reset()
 	TakeFileSnapshot(dst string) error
+	// DocCount returns the number of the documents in the store.
+	DocCount() (uint64, error)
+	// Warmup reads the term dictionaries of all the fields, which loads them into the page cache.
+	// It returns the number of the terms read.
+	Warmup(ctx context.Context) (int, error)
 }
 
 // Series represents a series in an index.
@@ -372,6 +427,8 @@ type SeriesDocument struct {
 	Key       Series
 	Timestamp int64
 	Version   int64
+	FirstSeen int64
+	LastSeen  int64
 }
 
 // OrderByType is the type of order by.
@@ -449,4 +506,16 @@ type Filter interface {
 type FilterOp interface {
 	Eq(tagName string, tagValue string) bool
 	Range(tagName string, rangeOpts RangeOpts) (bool, error)
+	Stats(tagName string) (BlockStats, bool)
+}
+
+// BlockStats is the statistics of a tag in a block.
+// Min and Max are the bounds of the int64 values, or the float64 values of a measure field, which are empty for the other types.
+// NullCountKnown is false if the block is written before the null counts are tracked, whose NullCount is always zero.
+type BlockStats struct {
+	Min            []byte
+	Max            []byte
+	Count          uint64
+	NullCount      uint64
+	NullCountKnown bool
 }
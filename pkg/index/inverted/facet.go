@@ -0,0 +1,115 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package inverted
+
+import (
+	"context"
+
+	"github.com/blugelabs/bluge"
+	"github.com/blugelabs/bluge/numeric"
+	"github.com/blugelabs/bluge/search"
+	"github.com/pkg/errors"
+	"go.uber.org/multierr"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	"github.com/apache/skywalking-banyandb/pkg/convert"
+	"github.com/apache/skywalking-banyandb/pkg/index"
+	"github.com/apache/skywalking-banyandb/pkg/index/posting"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+const checkDoneEvery = 128
+
+// Facets counts the values of the fields by their doc values, which only the sortable fields have.
+func (s *store) Facets(ctx context.Context, sids []common.SeriesID, docIDs posting.List, fields []index.FacetField,
+	timeRange *timestamp.TimeRange,
+) (facets []index.Facet, err error) {
+	facets = make([]index.Facet, len(fields))
+	for i := range facets {
+		facets[i] = make(index.Facet)
+	}
+	if len(sids) == 0 || len(fields) == 0 || (docIDs != nil && docIDs.IsEmpty()) {
+		return facets, nil
+	}
+	if !s.closer.AddRunning() {
+		return facets, nil
+	}
+	defer s.closer.Done()
+	reader, err := s.writer.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = multierr.Append(err, reader.Close())
+	}()
+	dmi, err := reader.Search(ctx, bluge.NewAllMatches(seriesInTimeRangeQuery(sids, timeRange)))
+	if err != nil {
+		return nil, err
+	}
+	loaded := make([]string, 0, len(fields)+1)
+	for i := range fields {
+		loaded = append(loaded, fields[i].Key.Marshal())
+	}
+	if docIDs != nil {
+		loaded = append(loaded, docIDField)
+	}
+	sc := search.NewSearchContext(1, 0)
+	for i := 0; ; i++ {
+		if i%checkDoneEvery == 0 {
+			select {
+			case <-ctx.Done():
+				return nil, errors.WithMessagef(ctx.Err(), "count facets, hit: %d", i)
+			default:
+			}
+		}
+		match, errNext := dmi.Next()
+		if errNext != nil {
+			return nil, errors.WithMessagef(errNext, "failed to get next document, hit: %d", i)
+		}
+		if match == nil {
+			return facets, nil
+		}
+		if err = match.LoadDocumentValues(sc, loaded); err != nil {
+			return nil, err
+		}
+		if docIDs != nil {
+			ids := match.DocValues(docIDField)
+			if len(ids) == 0 || !docIDs.Contains(convert.BytesToUint64(ids[0])) {
+				continue
+			}
+		}
+		for j := range fields {
+			for _, v := range match.DocValues(loaded[j]) {
+				if !fields[j].Numeric {
+					facets[j][string(v)]++
+					continue
+				}
+				// a numeric field has the terms of several precisions, and only the full one is counted.
+				pc := numeric.PrefixCoded(v)
+				if shift, errShift := pc.Shift(); errShift != nil || shift != 0 {
+					continue
+				}
+				n, errInt := pc.Int64()
+				if errInt != nil {
+					continue
+				}
+				facets[j][convert.BytesToString(convert.Int64ToBytes(n))]++
+			}
+		}
+	}
+}
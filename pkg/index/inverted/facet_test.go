@@ -0,0 +1,99 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package inverted
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	"github.com/apache/skywalking-banyandb/pkg/convert"
+	"github.com/apache/skywalking-banyandb/pkg/index"
+	"github.com/apache/skywalking-banyandb/pkg/index/posting/roaring"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+func TestStore_Facets(t *testing.T) {
+	tester := assert.New(t)
+	is := require.New(t)
+	path, fn := setUp(is)
+	s, err := NewStore(StoreOpts{
+		Path:   path,
+		Logger: logger.GetLogger("test"),
+	})
+	is.NoError(err)
+	defer func() {
+		tester.NoError(s.Close())
+		fn()
+	}()
+	endpoint := index.FieldKey{IndexRuleID: 11}
+	duration := index.FieldKey{IndexRuleID: 12}
+	now := time.Now()
+	var batch index.Batch
+	// series 1 has the endpoints e0, e1 and e2, and series 2 has the endpoint e0.
+	for i := 0; i < 30; i++ {
+		sid := common.SeriesID(1)
+		ep := fmt.Sprintf("e%d", i%3)
+		if i >= 20 {
+			sid, ep = 2, "e0"
+		}
+		endpoint.SeriesID, duration.SeriesID = sid, sid
+		batch.Documents = append(batch.Documents, index.Document{
+			Fields: []index.Field{
+				index.NewStringField(endpoint, ep),
+				index.NewIntField(duration, int64(i%2*100-50)),
+			},
+			DocID:     uint64(i + 1),
+			Timestamp: now.UnixNano(),
+		})
+	}
+	is.NoError(s.Batch(batch))
+
+	fields := []index.FacetField{{Key: index.FieldKey{IndexRuleID: 11}}, {Key: index.FieldKey{IndexRuleID: 12}, Numeric: true}}
+	tr := timestamp.NewInclusiveTimeRange(now.Add(-time.Minute), now.Add(time.Minute))
+	intKey := func(v int64) string {
+		return string(convert.Int64ToBytes(v))
+	}
+
+	facets, err := s.Facets(context.TODO(), []common.SeriesID{1, 2}, nil, fields, &tr)
+	is.NoError(err)
+	tester.Equal(index.Facet{"e0": 17, "e1": 7, "e2": 6}, facets[0])
+	tester.Equal(index.Facet{intKey(-50): 15, intKey(50): 15}, facets[1])
+
+	facets, err = s.Facets(context.TODO(), []common.SeriesID{2}, nil, fields, &tr)
+	is.NoError(err)
+	tester.Equal(index.Facet{"e0": 10}, facets[0])
+
+	// only the documents in the list are counted.
+	facets, err = s.Facets(context.TODO(), []common.SeriesID{1, 2}, roaring.NewPostingListWithInitialData(1, 2, 3, 4, 21), fields, &tr)
+	is.NoError(err)
+	tester.Equal(index.Facet{"e0": 3, "e1": 1, "e2": 1}, facets[0])
+	tester.Equal(index.Facet{intKey(-50): 3, intKey(50): 2}, facets[1])
+
+	tr = timestamp.NewInclusiveTimeRange(now.Add(time.Minute), now.Add(2*time.Minute))
+	facets, err = s.Facets(context.TODO(), []common.SeriesID{1, 2}, nil, fields, &tr)
+	is.NoError(err)
+	tester.Empty(facets[0])
+	tester.Empty(facets[1])
+}
@@ -55,6 +55,8 @@ const (
 	versionField   = "_version"
 	sourceField    = "_source"
 	deletedField   = "_deleted"
+	firstSeenField = "_first_seen"
+	lastSeenField  = "_last_seen"
 )
 
 var (
@@ -69,6 +71,8 @@ type StoreOpts struct {
 	Logger               *logger.Logger
 	Metrics              *Metrics
 	PrepareMergeCallback func(src []*roaringpkg.Bitmap, segments []segment.Segment, id uint64) (dest []*roaringpkg.Bitmap, err error)
+	// MergeScheduler bounds the file merges of the index segments, which aren't limited if it's nil.
+	MergeScheduler *MergeScheduler
 
 	Path          string
 	BatchWaitSec  int64
@@ -76,10 +80,11 @@ type StoreOpts struct {
 }
 
 type store struct {
-	writer  *bluge.Writer
-	closer  *run.Closer
-	l       *logger.Logger
-	metrics *Metrics
+	writer   *bluge.Writer
+	closer   *run.Closer
+	l        *logger.Logger
+	metrics  *Metrics
+	postings *postingsStats
 }
 
 var batchPool = pool.Register[*blugeIndex.Batch]("index-bluge-batch")
@@ -122,7 +127,9 @@ func (s *store) Batch(batch index.Batch) error {
 			if f.Store {
 				tf.StoreValue()
 			}
-			if f.Key.Analyzer != index.AnalyzerUnspecified {
+			if f.CaseInsensitive {
+				tf = tf.WithAnalyzer(analyzer.CaseInsensitive(f.Key.Analyzer))
+			} else if f.Key.Analyzer != index.AnalyzerUnspecified {
 				tf = tf.WithAnalyzer(analyzer.Analyzers[f.Key.Analyzer])
 			}
 			doc.AddField(tf)
@@ -136,7 +143,11 @@ func (s *store) Batch(batch index.Batch) error {
 		}
 		b.Insert(doc)
 	}
-	return s.writer.Batch(b)
+	if err := s.writer.Batch(b); err != nil {
+		return err
+	}
+	s.postings.observe(batch.Documents, false)
+	return nil
 }
 
 // NewStore create a new inverted index repository.
@@ -150,10 +161,11 @@ func NewStore(opts StoreOpts) (index.SeriesStore, error) {
 			WithPersisterNapTimeMSec(int(opts.BatchWaitSec * 1000))
 	}
 	indexConfig.CacheMaxBytes = opts.CacheMaxBytes
+	indexConfig, prepareMerge := withMergeScheduler(indexConfig, opts.MergeScheduler, opts.PrepareMergeCallback)
 	config := bluge.DefaultConfigWithIndexConfig(indexConfig)
 	config.DefaultSearchAnalyzer = analyzer.Analyzers[index.AnalyzerKeyword]
 	config.Logger = log.New(opts.Logger, opts.Logger.Module(), 0)
-	config = config.WithPrepareMergeCallback(opts.PrepareMergeCallback)
+	config = config.WithPrepareMergeCallback(prepareMerge)
 	w, err := bluge.OpenWriter(config)
 	if err != nil {
 		return nil, err
@@ -164,6 +176,9 @@ func NewStore(opts StoreOpts) (index.SeriesStore, error) {
 		closer:  run.NewCloser(1),
 		metrics: opts.Metrics,
 	}
+	if opts.Metrics != nil {
+		s.postings = newPostingsStats()
+	}
 	return s, nil
 }
 
@@ -285,29 +300,84 @@ func (s *store) MatchField(fieldKey index.FieldKey) (posting.List, posting.List,
 }
 
 func (s *store) MatchTerms(field index.Field) (list posting.List, timestamps posting.List, err error) {
-	reader, err := s.writer.Reader()
+	if field.GetTerm() == nil {
+		return roaring.DummyPostingList, roaring.DummyPostingList, nil
+	}
+	term, err := fieldTerm(field)
+	if err != nil {
+		return nil, nil, err
+	}
+	query := bluge.NewBooleanQuery()
+	query.AddMust(bluge.NewTermQuery(term).SetField(field.Key.Marshal()))
+	return s.matchWithSeries(query, field.Key)
+}
+
+// MatchAnyTerms returns the documents matching any of the fields, which share the same key.
+func (s *store) MatchAnyTerms(fields []index.Field) (list posting.List, timestamps posting.List, err error) {
+	terms := make([][]byte, 0, len(fields))
+	for i := range fields {
+		if fields[i].GetTerm() == nil {
+			continue
+		}
+		term, errTerm := fieldTerm(fields[i])
+		if errTerm != nil {
+			return nil, nil, errTerm
+		}
+		terms = append(terms, convert.StringToBytes(term))
+	}
+	if len(terms) == 0 {
+		return roaring.DummyPostingList, roaring.DummyPostingList, nil
+	}
+	query := bluge.NewBooleanQuery()
+	query.AddMust(newTermsQuery(fields[0].Key.Marshal(), terms))
+	return s.matchWithSeries(query, fields[0].Key)
+}
+
+// MatchPattern returns the documents whose terms match the prefix or the wildcard pattern.
+func (s *store) MatchPattern(fieldKey index.FieldKey, pattern index.TermPattern) (list posting.List, timestamps posting.List, err error) {
+	query := bluge.NewBooleanQuery()
+	query.AddMust(newPatternQuery(fieldKey.Marshal(), pattern))
+	return s.matchWithSeries(query, fieldKey)
+}
+
+// LookupTerm returns the documents having the term of the field regardless of their series,
+// which serves the point lookups of the fields indexed across all the series, e.g. the trace IDs.
+func (s *store) LookupTerm(field index.Field) (list posting.List, timestamps posting.List, err error) {
+	if field.GetTerm() == nil {
+		return roaring.DummyPostingList, roaring.DummyPostingList, nil
+	}
+	term, err := fieldTerm(field)
 	if err != nil {
 		return nil, nil, err
 	}
+	query := bluge.NewBooleanQuery()
+	query.AddMust(bluge.NewTermQuery(term).SetField(field.Key.Marshal()))
+	return s.match(query, field.Key)
+}
 
-	var query *bluge.BooleanQuery
+func fieldTerm(field index.Field) (string, error) {
 	switch field.GetTerm().(type) {
 	case *index.BytesTermValue:
-		query = bluge.NewBooleanQuery()
-		query.AddMust(bluge.NewTermQuery(string(field.GetBytes())).SetField(field.Key.Marshal()))
+		return string(field.GetBytes()), nil
 	case *index.FloatTermValue:
-		query = bluge.NewBooleanQuery()
-		query.AddMust(bluge.NewTermQuery(
-			strconv.FormatFloat(field.GetFloat(), 'f', -1, 64)).
-			SetField(field.Key.Marshal()))
-	case nil:
-		return roaring.DummyPostingList, roaring.DummyPostingList, nil
+		return strconv.FormatFloat(field.GetFloat(), 'f', -1, 64), nil
 	default:
-		return nil, nil, errors.Errorf("unexpected field type: %T", field.GetTerm())
+		return "", errors.Errorf("unexpected field type: %T", field.GetTerm())
 	}
-	query.AddMust(bluge.NewTermQuery(string(field.Key.SeriesID.Marshal())).
+}
+
+func (s *store) matchWithSeries(query *bluge.BooleanQuery, fieldKey index.FieldKey) (list posting.List, timestamps posting.List, err error) {
+	query.AddMust(bluge.NewTermQuery(string(fieldKey.SeriesID.Marshal())).
 		SetField(seriesIDField))
-	_ = appendTimeRangeToQuery(query, field.Key)
+	return s.match(query, fieldKey)
+}
+
+func (s *store) match(query *bluge.BooleanQuery, fieldKey index.FieldKey) (list posting.List, timestamps posting.List, err error) {
+	reader, err := s.writer.Reader()
+	if err != nil {
+		return nil, nil, err
+	}
+	_ = appendTimeRangeToQuery(query, fieldKey)
 
 	documentMatchIterator, err := reader.Search(context.Background(), bluge.NewAllMatches(query))
 	if err != nil {
@@ -397,6 +467,52 @@ func (s *store) TakeFileSnapshot(dst string) error {
 	return reader.Backup(dst, nil)
 }
 
+func (s *store) DocCount() (uint64, error) {
+	reader, err := s.writer.Reader()
+	if err != nil {
+		return 0, err
+	}
+	defer reader.Close()
+	return reader.Count()
+}
+
+func (s *store) Warmup(ctx context.Context) (terms int, err error) {
+	reader, err := s.writer.Reader()
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		err = multierr.Append(err, reader.Close())
+	}()
+	fields, err := reader.Fields()
+	if err != nil {
+		return 0, err
+	}
+	for _, f := range fields {
+		dict, dictErr := reader.DictionaryIterator(f, nil, nil, nil)
+		if dictErr != nil {
+			return terms, dictErr
+		}
+		for {
+			if terms%checkDoneEvery == 0 && ctx.Err() != nil {
+				return terms, multierr.Append(ctx.Err(), dict.Close())
+			}
+			de, nextErr := dict.Next()
+			if nextErr != nil {
+				return terms, multierr.Append(nextErr, dict.Close())
+			}
+			if de == nil {
+				break
+			}
+			terms++
+		}
+		if err = dict.Close(); err != nil {
+			return terms, err
+		}
+	}
+	return terms, nil
+}
+
 type blugeMatchIterator struct {
 	delegated     search.DocumentMatchIterator
 	err           error
@@ -56,7 +56,11 @@ func (s *store) InsertSeriesBatch(batch index.Batch) error {
 		doc, ff := toDoc(d, true)
 		b.InsertIfAbsent(doc.ID(), ff, doc)
 	}
-	return s.writer.Batch(b)
+	if err := s.writer.Batch(b); err != nil {
+		return err
+	}
+	s.postings.observe(batch.Documents, true)
+	return nil
 }
 
 func (s *store) UpdateSeriesBatch(batch index.Batch) error {
@@ -76,7 +80,11 @@ func (s *store) UpdateSeriesBatch(batch index.Batch) error {
 		doc, _ := toDoc(d, false)
 		b.Update(doc.ID(), doc)
 	}
-	return s.writer.Batch(b)
+	if err := s.writer.Batch(b); err != nil {
+		return err
+	}
+	s.postings.observe(batch.Documents, true)
+	return nil
 }
 
 func (s *store) Delete(docID [][]byte) error {
@@ -109,7 +117,9 @@ func toDoc(d index.Document, toParseFieldNames bool) (*bluge.Document, []string)
 			if !f.NoSort {
 				tf.Sortable()
 			}
-			if f.Key.Analyzer != index.AnalyzerUnspecified {
+			if f.CaseInsensitive {
+				tf = tf.WithAnalyzer(analyzer.CaseInsensitive(f.Key.Analyzer))
+			} else if f.Key.Analyzer != index.AnalyzerUnspecified {
 				tf = tf.WithAnalyzer(analyzer.Analyzers[f.Key.Analyzer])
 			}
 		} else {
@@ -128,6 +138,10 @@ func toDoc(d index.Document, toParseFieldNames bool) (*bluge.Document, []string)
 		vf := bluge.NewStoredOnlyField(versionField, convert.Int64ToBytes(d.Version))
 		doc.AddField(vf)
 	}
+	if d.LastSeen > 0 {
+		doc.AddField(bluge.NewStoredOnlyField(firstSeenField, convert.Int64ToBytes(d.FirstSeen)))
+		doc.AddField(bluge.NewStoredOnlyField(lastSeenField, convert.Int64ToBytes(d.LastSeen)))
+	}
 	return doc, fieldNames
 }
 
@@ -253,6 +267,10 @@ func parseResult(dmi search.DocumentMatchIterator, loadedFields []index.FieldKey
 				doc.Timestamp = ts.UnixNano()
 			case versionField:
 				doc.Version = convert.BytesToInt64(value)
+			case firstSeenField:
+				doc.FirstSeen = convert.BytesToInt64(value)
+			case lastSeenField:
+				doc.LastSeen = convert.BytesToInt64(value)
 			default:
 				if _, ok := doc.Fields[field]; ok {
 					doc.Fields[field] = bytes.Clone(value)
@@ -408,6 +426,29 @@ func (s *store) SeriesIterator(ctx context.Context) (index.FieldIterator[index.S
 	return &dictIterator{dict: dict, ctx: ctx}, nil
 }
 
+// IterateSeries calls fn with the entity values of every series in the series index at path.
+// The index is opened read-only, so that it could be a copy taken by a snapshot.
+func IterateSeries(ctx context.Context, path string, fn func(entityValues []byte) error) (err error) {
+	reader, err := bluge.OpenReader(bluge.DefaultConfig(path))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = multierr.Append(err, reader.Close())
+	}()
+	dict, err := reader.DictionaryIterator(docIDField, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	iter := &dictIterator{dict: dict, ctx: ctx}
+	for iter.Next() {
+		if err = fn(iter.Val().EntityValues); err != nil {
+			return multierr.Append(err, iter.Close())
+		}
+	}
+	return iter.Close()
+}
+
 type dictIterator struct {
 	dict   segment.DictionaryIterator
 	ctx    context.Context
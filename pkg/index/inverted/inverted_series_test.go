@@ -35,6 +35,7 @@ import (
 	"github.com/apache/skywalking-banyandb/pkg/convert"
 	"github.com/apache/skywalking-banyandb/pkg/index"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/query/logical"
 	"github.com/apache/skywalking-banyandb/pkg/timestamp"
 )
 
@@ -493,6 +494,54 @@ func TestStore_SearchWithSecondaryQuery(t *testing.T) {
 	}
 }
 
+func TestStore_SearchWithNegatedQuery(t *testing.T) {
+	tester := require.New(t)
+	path, fn := setUp(tester)
+	s, err := NewStore(StoreOpts{
+		Path:   path,
+		Logger: logger.GetLogger("test"),
+	})
+	tester.NoError(err)
+	defer func() {
+		tester.NoError(s.Close())
+		fn()
+	}()
+
+	insertData(tester, s)
+
+	// duration = 500 AND NOT start_time = 1000
+	query, node := bluge.NewBooleanQuery(), newMustNode()
+	appendMust(query, node, &queryNode{
+		query: bluge.NewTermQuery(string(convert.Int64ToBytes(500))).SetField(fieldKeyDuration.Marshal()),
+		node:  newTermNode("500", nil),
+	})
+	appendMust(query, node, newNotQuery(&queryNode{
+		query: bluge.NewTermQuery(string(convert.Int64ToBytes(1000))).SetField(fieldKeyStartTime.Marshal()),
+		node:  newTermNode("1000", nil),
+	}))
+	tester.Len(query.MustNots(), 1)
+	secondaryQuery := &queryNode{query, node}
+
+	q, err := s.BuildQuery([]index.SeriesMatcher{
+		{Type: index.SeriesMatcherTypeExact, Match: []byte("test3")},
+		{Type: index.SeriesMatcherTypeExact, Match: []byte("test4")},
+	}, secondaryQuery, nil)
+	tester.NoError(err)
+	got, err := s.Search(context.Background(), []index.FieldKey{fieldKeyDuration}, q, 0)
+	tester.NoError(err)
+	tester.Equal([]index.SeriesDocument{
+		{
+			Key: index.Series{
+				EntityValues: []byte("test4"),
+			},
+			Fields: map[string][]byte{
+				fieldKeyDuration.Marshal(): convert.Int64ToBytes(int64(500)),
+			},
+			Timestamp: int64(2001),
+		},
+	}, got)
+}
+
 func TestStore_SeriesSort(t *testing.T) {
 	tester := require.New(t)
 	path, fn := setUp(tester)
@@ -948,3 +997,110 @@ func updateData(tester *require.Assertions, s index.SeriesStore) {
 	tester.NoError(s.UpdateSeriesBatch(b1))
 	tester.NoError(s.UpdateSeriesBatch(b2))
 }
+
+func TestStore_SearchNullCheck(t *testing.T) {
+	tester := require.New(t)
+	path, fn := setUp(tester)
+	s, err := NewStore(StoreOpts{
+		Path:   path,
+		Logger: logger.GetLogger("test"),
+	})
+	tester.NoError(err)
+	defer func() {
+		tester.NoError(s.Close())
+		fn()
+	}()
+	insertData(tester, s)
+
+	tests := []struct {
+		name string
+		want []string
+		op   modelv1.Condition_BinaryOp
+	}{
+		{name: "exists", op: modelv1.Condition_BINARY_OP_EXISTS, want: []string{"test2"}},
+		{name: "is null", op: modelv1.Condition_BINARY_OP_IS_NULL, want: []string{"test1", "test3", "test4"}},
+	}
+	var matchers []index.SeriesMatcher
+	for _, term := range []string{"test1", "test2", "test3", "test4"} {
+		matchers = append(matchers, index.SeriesMatcher{
+			Type:  index.SeriesMatcherTypeExact,
+			Match: []byte(term),
+		})
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cond := &modelv1.Condition{Name: "service_name", Op: tt.op}
+			expr, err := logical.ParseExpr(cond)
+			require.NoError(t, err)
+			secondaryQuery, err := parseConditionToQuery(cond, nil, expr, fieldKeyServiceName.Marshal())
+			require.NoError(t, err)
+			query, err := s.BuildQuery(matchers, secondaryQuery, nil)
+			require.NoError(t, err)
+			got, err := s.Search(context.Background(), []index.FieldKey{fieldKeyServiceName}, query, 0)
+			require.NoError(t, err)
+			var entities []string
+			for _, d := range got {
+				entities = append(entities, string(d.Key.EntityValues))
+			}
+			assert.ElementsMatch(t, tt.want, entities)
+		})
+	}
+}
+
+func TestStore_SearchPattern(t *testing.T) {
+	tester := require.New(t)
+	path, fn := setUp(tester)
+	s, err := NewStore(StoreOpts{
+		Path:   path,
+		Logger: logger.GetLogger("test"),
+	})
+	tester.NoError(err)
+	defer func() {
+		tester.NoError(s.Close())
+		fn()
+	}()
+	insertData(tester, s)
+
+	tests := []struct {
+		name  string
+		value string
+		want  []string
+		op    modelv1.Condition_BinaryOp
+	}{
+		{name: "prefix", op: modelv1.Condition_BINARY_OP_PREFIX, value: "sv", want: []string{"test2"}},
+		{name: "absent prefix", op: modelv1.Condition_BINARY_OP_PREFIX, value: "svc3"},
+		{name: "wildcard", op: modelv1.Condition_BINARY_OP_WILDCARD, value: "s?c*", want: []string{"test2"}},
+		{name: "absent wildcard", op: modelv1.Condition_BINARY_OP_WILDCARD, value: "s*1"},
+	}
+	var matchers []index.SeriesMatcher
+	for _, term := range []string{"test1", "test2", "test3", "test4"} {
+		matchers = append(matchers, index.SeriesMatcher{
+			Type:  index.SeriesMatcherTypeExact,
+			Match: []byte(term),
+		})
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cond := &modelv1.Condition{Name: "service_name", Op: tt.op, Value: &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: tt.value}}}}
+			expr, err := logical.ParseExpr(cond)
+			require.NoError(t, err)
+			secondaryQuery, err := parseConditionToQuery(cond, nil, expr, fieldKeyServiceName.Marshal())
+			require.NoError(t, err)
+			query, err := s.BuildQuery(matchers, secondaryQuery, nil)
+			require.NoError(t, err)
+			got, err := s.Search(context.Background(), []index.FieldKey{fieldKeyServiceName}, query, 0)
+			require.NoError(t, err)
+			var entities []string
+			for _, d := range got {
+				entities = append(entities, string(d.Key.EntityValues))
+			}
+			assert.ElementsMatch(t, tt.want, entities)
+		})
+	}
+
+	cond := &modelv1.Condition{Name: "service_name", Op: modelv1.Condition_BINARY_OP_WILDCARD, Value: &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: "*svc"}}}}
+	expr, err := logical.ParseExpr(cond)
+	tester.NoError(err)
+	_, err = parseConditionToQuery(cond, nil, expr, fieldKeyServiceName.Marshal())
+	assert.ErrorIs(t, err, logical.ErrPatternTooExpensive, "a leading wildcard scans the whole dictionary")
+}
@@ -19,6 +19,7 @@ package inverted
 
 import (
 	"context"
+	"fmt"
 	"strings"
 	"testing"
 
@@ -354,6 +355,100 @@ func TestStore_NumericMatch(t *testing.T) {
 	tester.True(roaring.NewPostingListWithInitialData(1).Equal(l))
 }
 
+func TestStore_MatchAnyTerms(t *testing.T) {
+	tester := assert.New(t)
+	path, fn := setUp(require.New(t))
+	s, err := NewStore(StoreOpts{
+		Path:   path,
+		Logger: logger.GetLogger("test"),
+	})
+	tester.NoError(err)
+	defer func() {
+		tester.NoError(s.Close())
+		fn()
+	}()
+	traceID := index.FieldKey{
+		IndexRuleID: 10,
+	}
+	durationName := index.FieldKey{
+		IndexRuleID: 7,
+	}
+	const total = 5000
+	var batch index.Batch
+	for i := 0; i < total; i++ {
+		batch.Documents = append(batch.Documents, index.Document{
+			Fields: []index.Field{
+				index.NewStringField(traceID, fmt.Sprintf("trace-%d", i)),
+				index.NewIntField(durationName, int64(i%10)),
+			},
+			DocID: uint64(i),
+		})
+	}
+	tester.NoError(s.Batch(batch))
+
+	// the even trace IDs and the unknown ones.
+	var fields []index.Field
+	want := roaring.NewPostingList()
+	for i := 0; i < total+1000; i += 2 {
+		fields = append(fields, index.NewStringField(traceID, fmt.Sprintf("trace-%d", i)))
+		if i < total {
+			want.Insert(uint64(i))
+		}
+	}
+	l, _, err := s.MatchAnyTerms(fields)
+	tester.NoError(err)
+	tester.True(want.Equal(l))
+
+	l, _, err = s.MatchAnyTerms([]index.Field{index.NewIntField(durationName, 3), index.NewIntField(durationName, 100)})
+	tester.NoError(err)
+	tester.Equal(total/10, l.Len())
+
+	l, _, err = s.MatchAnyTerms(nil)
+	tester.NoError(err)
+	tester.True(roaring.DummyPostingList.Equal(l))
+}
+
+func TestStore_LookupTerm(t *testing.T) {
+	tester := assert.New(t)
+	path, fn := setUp(require.New(t))
+	s, err := NewStore(StoreOpts{
+		Path:   path,
+		Logger: logger.GetLogger("test"),
+	})
+	tester.NoError(err)
+	defer func() {
+		tester.NoError(s.Close())
+		fn()
+	}()
+	const total = 10
+	var batch index.Batch
+	for i := 0; i < total; i++ {
+		batch.Documents = append(batch.Documents, index.Document{
+			Fields: []index.Field{
+				index.NewStringField(index.FieldKey{TagName: "_trace_id", SeriesID: common.SeriesID(i)}, fmt.Sprintf("trace-%d", i%3)),
+			},
+			DocID:     uint64(i),
+			Timestamp: int64(i + 1),
+		})
+	}
+	tester.NoError(s.Batch(batch))
+
+	// the documents of all the series have the term.
+	l, ts, err := s.LookupTerm(index.NewStringField(index.FieldKey{TagName: "_trace_id"}, "trace-1"))
+	tester.NoError(err)
+	tester.True(roaring.NewPostingListWithInitialData(1, 4, 7).Equal(l))
+	tester.True(roaring.NewPostingListWithInitialData(2, 5, 8).Equal(ts))
+
+	tr := index.NewIntRangeOpts(1, 5, true, true)
+	l, _, err = s.LookupTerm(index.NewStringField(index.FieldKey{TagName: "_trace_id", TimeRange: &tr}, "trace-1"))
+	tester.NoError(err)
+	tester.True(roaring.NewPostingListWithInitialData(1, 4).Equal(l))
+
+	l, _, err = s.LookupTerm(index.NewStringField(index.FieldKey{TagName: "_trace_id"}, "trace-9"))
+	tester.NoError(err)
+	tester.True(l.IsEmpty())
+}
+
 func setUp(t *require.Assertions) (tempDir string, deferFunc func()) {
 	t.NoError(logger.Init(logger.Logging{
 		Env:   "dev",
@@ -403,6 +498,46 @@ func TestStore_TakeFileSnapshot(t *testing.T) {
 	tester.True(len(entries) > 0, "Expected snapshot to produce files")
 }
 
+func TestStore_Warmup(t *testing.T) {
+	tester := require.New(t)
+	path, fn := setUp(tester)
+	s, err := NewStore(StoreOpts{
+		Path:   path,
+		Logger: logger.GetLogger("test"),
+	})
+	tester.NoError(err)
+	defer func() {
+		tester.NoError(s.Close())
+		fn()
+	}()
+
+	terms, err := s.Warmup(context.Background())
+	tester.NoError(err)
+	tester.Zero(terms)
+
+	var batch index.Batch
+	sampleKey := index.FieldKey{
+		IndexRuleID: 10,
+		SeriesID:    common.SeriesID(99),
+	}
+	for i, v := range []string{"warmup-a", "warmup-b", "warmup-c"} {
+		batch.Documents = append(batch.Documents, index.Document{
+			Fields: []index.Field{index.NewStringField(sampleKey, v)},
+			DocID:  uint64(i + 1),
+		})
+	}
+	tester.NoError(s.Batch(batch))
+
+	terms, err = s.Warmup(context.Background())
+	tester.NoError(err)
+	tester.GreaterOrEqual(terms, 3)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = s.Warmup(ctx)
+	tester.ErrorIs(err, context.Canceled)
+}
+
 func TestStore_TimeRangeFiltering(t *testing.T) {
 	tester := require.New(t)
 	path, fn := setUp(tester)
@@ -555,3 +690,89 @@ func TestStore_TimeRangeFiltering(t *testing.T) {
 		tester.True(timestamps.IsEmpty(), "Timestamps should be empty for empty result set")
 	})
 }
+
+func TestStore_CaseInsensitive(t *testing.T) {
+	tester := assert.New(t)
+	is := require.New(t)
+	path, fn := setUp(is)
+	s, err := NewStore(StoreOpts{
+		Path:   path,
+		Logger: logger.GetLogger("test"),
+	})
+	is.NoError(err)
+	defer func() {
+		tester.NoError(s.Close())
+		fn()
+	}()
+	method := index.FieldKey{IndexRuleID: 8}
+	field := func(value string) index.Field {
+		f := index.NewStringField(method, value)
+		f.Store = true
+		f.CaseInsensitive = true
+		return f
+	}
+	is.NoError(s.Batch(index.Batch{
+		Documents: index.Documents{
+			{Fields: []index.Field{field("GET")}, DocID: 1},
+			{Fields: []index.Field{field("get")}, DocID: 2},
+			{Fields: []index.Field{field("Post")}, DocID: 3},
+		},
+	}))
+
+	l, _, err := s.MatchTerms(index.NewStringField(method, "get"))
+	is.NoError(err)
+	tester.True(roaring.NewPostingListWithInitialData(1, 2).Equal(l), "the index holds the lowercase terms")
+	l, _, err = s.MatchTerms(index.NewStringField(method, "GET"))
+	is.NoError(err)
+	tester.True(l.IsEmpty(), "the values of the queries are lowercased by the query planner")
+
+	values, err := s.Values(context.TODO(), []uint64{1, 3}, []index.FieldKey{method})
+	is.NoError(err)
+	tester.Equal([][]byte{[]byte("GET")}, values[1][0], "the original value is stored")
+	tester.Equal([][]byte{[]byte("Post")}, values[3][0])
+}
+
+func TestStore_MatchPattern(t *testing.T) {
+	tester := assert.New(t)
+	is := require.New(t)
+	path, fn := setUp(is)
+	s, err := NewStore(StoreOpts{
+		Path:   path,
+		Logger: logger.GetLogger("test"),
+	})
+	is.NoError(err)
+	defer func() {
+		tester.NoError(s.Close())
+		fn()
+	}()
+	endpoint := index.FieldKey{IndexRuleID: 9}
+	var docs index.Documents
+	for i, v := range []string{"/api/v1/users", "/api/v2/users", "/api/v2/orders", "/api/v10/users", "/home"} {
+		docs = append(docs, index.Document{Fields: []index.Field{index.NewStringField(endpoint, v)}, DocID: uint64(i + 1)})
+	}
+	is.NoError(s.Batch(index.Batch{Documents: docs}))
+
+	tests := []struct {
+		name    string
+		pattern index.TermPattern
+		want    []uint64
+	}{
+		{name: "prefix", pattern: index.TermPattern{Value: "/api/v2/"}, want: []uint64{2, 3}},
+		{name: "wildcard one", pattern: index.TermPattern{Value: "/api/v?/users", Wildcard: true}, want: []uint64{1, 2}},
+		{name: "wildcard any", pattern: index.TermPattern{Value: "/api/*/users", Wildcard: true}, want: []uint64{1, 2, 4}},
+		{name: "no wildcard", pattern: index.TermPattern{Value: "/home", Wildcard: true}, want: []uint64{5}},
+		{name: "absent", pattern: index.TermPattern{Value: "/api/v3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(_ *testing.T) {
+			l, _, err := s.MatchPattern(endpoint, tt.pattern)
+			is.NoError(err)
+			tester.True(roaring.NewPostingListWithInitialData(tt.want...).Equal(l), "got %v", l)
+		})
+	}
+
+	_, _, err = s.MatchPattern(endpoint, index.TermPattern{Value: "/api/", MaxExpansions: 3})
+	tester.ErrorIs(err, index.ErrTooManyTerms)
+	_, _, err = s.MatchPattern(endpoint, index.TermPattern{Value: "/api/v2/", MaxExpansions: 3})
+	tester.NoError(err, "the cap applies to the matched terms")
+}
@@ -0,0 +1,163 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package inverted
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	roaringpkg "github.com/RoaringBitmap/roaring"
+	blugeIndex "github.com/blugelabs/bluge/index"
+	segment "github.com/blugelabs/bluge_segment_api"
+)
+
+// MergeScheduler runs the file merges of the index segments on their own budget, which is shared by the stores
+// it's passed to. It bounds the number of the concurrent merges and the bytes written by them per second,
+// so that the merges of the large indexes don't compete with the merges of the data parts for the disk.
+type MergeScheduler struct {
+	slots          chan struct{}
+	next           time.Time
+	bytesPerSecond int64
+	mu             sync.Mutex
+}
+
+// NewMergeScheduler returns a scheduler running up to concurrency merges at bytesPerSecond.
+// 0 means no limit, and it returns nil if neither of them is limited.
+func NewMergeScheduler(concurrency int, bytesPerSecond int64) *MergeScheduler {
+	if concurrency <= 0 && bytesPerSecond <= 0 {
+		return nil
+	}
+	ms := &MergeScheduler{bytesPerSecond: bytesPerSecond}
+	if concurrency > 0 {
+		ms.slots = make(chan struct{}, concurrency)
+	}
+	return ms
+}
+
+func (ms *MergeScheduler) acquire(closeCh chan struct{}) bool {
+	if ms.slots == nil {
+		return true
+	}
+	select {
+	case ms.slots <- struct{}{}:
+		return true
+	case <-closeCh:
+		return false
+	}
+}
+
+func (ms *MergeScheduler) release() {
+	if ms.slots != nil {
+		<-ms.slots
+	}
+}
+
+// wait blocks until n bytes are allowed to be written by the rate.
+func (ms *MergeScheduler) wait(n int, closeCh chan struct{}) bool {
+	if ms.bytesPerSecond <= 0 {
+		return true
+	}
+	ms.mu.Lock()
+	now := time.Now()
+	if ms.next.Before(now) {
+		ms.next = now
+	}
+	at := ms.next
+	ms.next = ms.next.Add(time.Duration(int64(n) * int64(time.Second) / ms.bytesPerSecond))
+	ms.mu.Unlock()
+	d := time.Until(at)
+	if d <= 0 {
+		return true
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-closeCh:
+		return false
+	}
+}
+
+// scheduledDirectory persists the merged segments under the budget of the scheduler.
+// The segments flushed by the persister are written as they are.
+type scheduledDirectory struct {
+	blugeIndex.Directory
+	scheduler *MergeScheduler
+	merging   sync.Map
+}
+
+func (d *scheduledDirectory) Persist(kind string, id uint64, w blugeIndex.WriterTo, closeCh chan struct{}) error {
+	if _, ok := d.merging.LoadAndDelete(id); !ok || kind != blugeIndex.ItemKindSegment {
+		return d.Directory.Persist(kind, id, w, closeCh)
+	}
+	if !d.scheduler.acquire(closeCh) {
+		return segment.ErrClosed
+	}
+	defer d.scheduler.release()
+	return d.Directory.Persist(kind, id, &scheduledWriterTo{WriterTo: w, scheduler: d.scheduler}, closeCh)
+}
+
+type scheduledWriterTo struct {
+	blugeIndex.WriterTo
+	scheduler *MergeScheduler
+}
+
+func (w *scheduledWriterTo) WriteTo(dst io.Writer, closeCh chan struct{}) (int64, error) {
+	return w.WriterTo.WriteTo(&scheduledWriter{Writer: dst, scheduler: w.scheduler, closeCh: closeCh}, closeCh)
+}
+
+type scheduledWriter struct {
+	io.Writer
+	scheduler *MergeScheduler
+	closeCh   chan struct{}
+}
+
+func (w *scheduledWriter) Write(p []byte) (int, error) {
+	if !w.scheduler.wait(len(p), w.closeCh) {
+		return 0, segment.ErrClosed
+	}
+	return w.Writer.Write(p)
+}
+
+// withMergeScheduler makes the file merges of the index run under the budget of the scheduler.
+// It chains the prepare merge callback, which is called right before a merged segment is persisted.
+func withMergeScheduler(config blugeIndex.Config, scheduler *MergeScheduler,
+	prepare func(src []*roaringpkg.Bitmap, segments []segment.Segment, id uint64) ([]*roaringpkg.Bitmap, error),
+) (blugeIndex.Config, func(src []*roaringpkg.Bitmap, segments []segment.Segment, id uint64) ([]*roaringpkg.Bitmap, error)) {
+	if scheduler == nil {
+		return config, prepare
+	}
+	newDirectory := config.DirectoryFunc
+	d := &scheduledDirectory{scheduler: scheduler}
+	config.DirectoryFunc = func() blugeIndex.Directory {
+		d.Directory = newDirectory()
+		return d
+	}
+	return config, func(src []*roaringpkg.Bitmap, segments []segment.Segment, id uint64) ([]*roaringpkg.Bitmap, error) {
+		if prepare != nil {
+			var err error
+			if src, err = prepare(src, segments, id); err != nil {
+				return nil, err
+			}
+		}
+		d.merging.Store(id, struct{}{})
+		return src, nil
+	}
+}
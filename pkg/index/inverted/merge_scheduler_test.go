@@ -0,0 +1,74 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package inverted
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	blugeIndex "github.com/blugelabs/bluge/index"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type bytesWriterTo []byte
+
+func (b bytesWriterTo) WriteTo(w io.Writer, _ chan struct{}) (int64, error) {
+	var n int64
+	for i := 0; i < len(b); i += 100 {
+		m, err := w.Write(b[i:min(i+100, len(b))])
+		n += int64(m)
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+func TestNewMergeScheduler(t *testing.T) {
+	assert.Nil(t, NewMergeScheduler(0, 0))
+	assert.NotNil(t, NewMergeScheduler(1, 0))
+	assert.NotNil(t, NewMergeScheduler(0, 1024))
+}
+
+func TestMergeSchedulerPersist(t *testing.T) {
+	scheduler := NewMergeScheduler(1, 1000)
+	config, prepare := withMergeScheduler(blugeIndex.InMemoryOnlyConfig(), scheduler, nil)
+	d := config.DirectoryFunc()
+	require.NoError(t, d.Setup(false))
+	closeCh := make(chan struct{})
+
+	start := time.Now()
+	require.NoError(t, d.Persist(blugeIndex.ItemKindSegment, 1, bytesWriterTo(make([]byte, 500)), closeCh))
+	assert.Less(t, time.Since(start), 200*time.Millisecond, "the segments flushed by the persister aren't throttled")
+
+	_, err := prepare(nil, nil, 2)
+	require.NoError(t, err)
+	start = time.Now()
+	require.NoError(t, d.Persist(blugeIndex.ItemKindSegment, 2, bytesWriterTo(make([]byte, 500)), closeCh))
+	assert.GreaterOrEqual(t, time.Since(start), 300*time.Millisecond, "the merged segment is written at 1000 bytes per second")
+	assert.Empty(t, scheduler.slots, "the slot is released after the merge")
+
+	scheduler.slots <- struct{}{}
+	_, err = prepare(nil, nil, 3)
+	require.NoError(t, err)
+	close(closeCh)
+	require.Error(t, d.Persist(blugeIndex.ItemKindSegment, 3, bytesWriterTo(make([]byte, 500)), closeCh),
+		"the merge waiting for a slot is canceled once the index is closed")
+}
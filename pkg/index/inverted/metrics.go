@@ -18,6 +18,8 @@
 package inverted
 
 import (
+	"sync"
+
 	"github.com/apache/skywalking-banyandb/banyand/observability"
 	"github.com/apache/skywalking-banyandb/pkg/meter"
 )
@@ -53,6 +55,11 @@ type Metrics struct {
 	cacheEntriesCount meter.Gauge
 	cacheBytesSize    meter.Gauge
 	cacheMaxBytesSize meter.Gauge
+
+	totalPostingsRawBytes        meter.Gauge
+	totalPostingsCompressedBytes meter.Gauge
+	postingsFields               map[string]struct{}
+	postingsFieldsMu             sync.Mutex
 }
 
 // NewMetrics creates a new metrics for the inverted index.
@@ -87,6 +94,10 @@ func NewMetrics(factory *observability.Factory, labelNames ...string) *Metrics {
 		cacheEntriesCount: factory.NewGauge("inverted_index_cache_entries_count", labelNames...),
 		cacheBytesSize:    factory.NewGauge("inverted_index_cache_bytes_size", labelNames...),
 		cacheMaxBytesSize: factory.NewGauge("inverted_index_cache_max_bytes_size", labelNames...),
+
+		totalPostingsRawBytes:        factory.NewGauge("inverted_index_total_postings_raw_bytes", append(labelNames, "field")...),
+		totalPostingsCompressedBytes: factory.NewGauge("inverted_index_total_postings_compressed_bytes", append(labelNames, "field")...),
+		postingsFields:               make(map[string]struct{}),
 	}
 }
 
@@ -127,6 +138,13 @@ func (m *Metrics) DeleteAll(labelValues ...string) {
 	m.cacheEntriesCount.Delete(labelValues...)
 	m.cacheBytesSize.Delete(labelValues...)
 	m.cacheMaxBytesSize.Delete(labelValues...)
+
+	m.postingsFieldsMu.Lock()
+	defer m.postingsFieldsMu.Unlock()
+	for field := range m.postingsFields {
+		m.totalPostingsRawBytes.Delete(append(labelValues, field)...)
+		m.totalPostingsCompressedBytes.Delete(append(labelValues, field)...)
+	}
 }
 
 func (s *store) CollectMetrics(labelValues ...string) {
@@ -168,6 +186,16 @@ func (s *store) CollectMetrics(labelValues ...string) {
 	s.metrics.cacheBytesSize.Set(float64(status.CacheBytesSize), labelValues...)
 	s.metrics.cacheMaxBytesSize.Set(float64(status.CacheMaxBytesSize), labelValues...)
 
+	if s.postings != nil {
+		s.metrics.postingsFieldsMu.Lock()
+		s.postings.visit(func(field string, size postingsSize) {
+			s.metrics.postingsFields[field] = struct{}{}
+			s.metrics.totalPostingsRawBytes.Set(float64(size.raw), append(labelValues, field)...)
+			s.metrics.totalPostingsCompressedBytes.Set(float64(size.compressed), append(labelValues, field)...)
+		})
+		s.metrics.postingsFieldsMu.Unlock()
+	}
+
 	r, err := s.writer.Reader()
 	if err != nil {
 		return
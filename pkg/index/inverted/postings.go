@@ -0,0 +1,115 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package inverted
+
+import (
+	"math"
+	"strconv"
+	"sync"
+
+	"github.com/RoaringBitmap/roaring"
+
+	"github.com/apache/skywalking-banyandb/pkg/convert"
+	"github.com/apache/skywalking-banyandb/pkg/index"
+)
+
+// postingsSize is the accumulated size of the postings lists of a field.
+type postingsSize struct {
+	raw        uint64
+	compressed uint64
+}
+
+// postingsStats estimates the size of the postings lists written by the batches.
+// Each batch is flushed to a segment whose document numbers follow the order of the batch,
+// so the postings of a term are rebuilt from the positions of the documents.
+// "raw" is the size of the array and bitmap containers, and "compressed" is the size
+// after converting the dense containers to run-length ones as the segment does.
+type postingsStats struct {
+	fields map[string]*postingsSize
+	mu     sync.Mutex
+}
+
+func newPostingsStats() *postingsStats {
+	return &postingsStats{
+		fields: make(map[string]*postingsSize),
+	}
+}
+
+func (ps *postingsStats) observe(docs index.Documents, indexedOnly bool) {
+	if ps == nil || len(docs) == 0 {
+		return
+	}
+	postings := make(map[string]map[string]*roaring.Bitmap)
+	for i := range docs {
+		for _, f := range docs[i].Fields {
+			if indexedOnly && !f.Index {
+				continue
+			}
+			var term string
+			switch t := f.GetTerm().(type) {
+			case *index.BytesTermValue:
+				term = convert.BytesToString(t.Value)
+			case *index.FloatTermValue:
+				term = convert.BytesToString(convert.Uint64ToBytes(math.Float64bits(t.Value)))
+			default:
+				continue
+			}
+			label := fieldLabel(f.Key)
+			terms, ok := postings[label]
+			if !ok {
+				terms = make(map[string]*roaring.Bitmap)
+				postings[label] = terms
+			}
+			bm, ok := terms[term]
+			if !ok {
+				bm = roaring.New()
+				terms[term] = bm
+			}
+			bm.Add(uint32(i))
+		}
+	}
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	for label, terms := range postings {
+		size, ok := ps.fields[label]
+		if !ok {
+			size = &postingsSize{}
+			ps.fields[label] = size
+		}
+		for _, bm := range terms {
+			size.raw += bm.GetSerializedSizeInBytes()
+			bm.RunOptimize()
+			size.compressed += bm.GetSerializedSizeInBytes()
+		}
+	}
+}
+
+func (ps *postingsStats) visit(fn func(field string, size postingsSize)) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	for field, size := range ps.fields {
+		fn(field, *size)
+	}
+}
+
+func fieldLabel(key index.FieldKey) string {
+	if len(key.TagName) > 0 {
+		return key.TagName
+	}
+	return strconv.FormatUint(uint64(key.IndexRuleID), 10)
+}
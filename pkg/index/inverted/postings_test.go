@@ -0,0 +1,60 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package inverted
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apache/skywalking-banyandb/pkg/index"
+)
+
+func TestPostingsStats(t *testing.T) {
+	serviceName := index.FieldKey{
+		IndexRuleID: 6,
+	}
+	durationName := index.FieldKey{
+		IndexRuleID: 7,
+	}
+	var docs index.Documents
+	for i := 0; i < 10000; i++ {
+		docs = append(docs, index.Document{
+			Fields: []index.Field{
+				// a few services own the consecutive documents.
+				index.NewStringField(serviceName, []string{"svc1", "svc2"}[i/5000]),
+				index.NewIntField(durationName, int64(i%3)),
+			},
+			DocID: uint64(i),
+		})
+	}
+	ps := newPostingsStats()
+	ps.observe(docs, false)
+	got := make(map[string]postingsSize)
+	ps.visit(func(field string, size postingsSize) {
+		got[field] = size
+	})
+	assert.Len(t, got, 2)
+	// the dense postings are encoded in the run-length containers.
+	assert.Less(t, got["6"].compressed*10, got["6"].raw)
+	// the sparse postings stay in the array or bitmap containers.
+	assert.Equal(t, got["7"].raw, got["7"].compressed)
+
+	var nilStats *postingsStats
+	nilStats.observe(docs, false)
+}
@@ -24,7 +24,11 @@ import (
 	"strings"
 
 	"github.com/blugelabs/bluge"
+	"github.com/blugelabs/bluge/search"
+	"github.com/blugelabs/bluge/search/searcher"
+	"github.com/blugelabs/bluge/search/similarity"
 	"github.com/pkg/errors"
+	"go.uber.org/multierr"
 
 	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
 	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
@@ -88,7 +92,7 @@ func BuildQuery(criteria *modelv1.Criteria, schema logical.Schema, entityDict ma
 		}
 		if ok, indexRule := schema.IndexDefined(cond.Name); ok {
 			fk := index.FieldKey{IndexRuleID: indexRule.Metadata.Id}
-			q, err := parseConditionToQuery(cond, indexRule, expr, fk.Marshal())
+			q, err := parseConditionToQuery(cond, indexRule, logical.FoldCase(expr, indexRule), fk.Marshal())
 			if err != nil {
 				return nil, nil, false, err
 			}
@@ -97,6 +101,21 @@ func BuildQuery(criteria *modelv1.Criteria, schema logical.Schema, entityDict ma
 		return nil, nil, false, errors.Wrapf(logical.ErrUnsupportedConditionOp, "mandatory index rule conf:%s", cond)
 	case *modelv1.Criteria_Le:
 		le := criteria.GetLe()
+		if le.Op == modelv1.LogicalExpression_LOGICAL_OP_NOT {
+			negated, err := logical.NegatedCriteria(le)
+			if err != nil {
+				return nil, nil, false, err
+			}
+			// the series can't be excluded by their entities, so the entity tags are looked up in the index
+			inner, _, _, err := BuildQuery(negated, schema, nil, entity)
+			if err != nil {
+				return nil, nil, false, err
+			}
+			if inner == nil {
+				return nil, [][]*modelv1.TagValue{entity}, false, nil
+			}
+			return newNotQuery(inner.(*queryNode)), [][]*modelv1.TagValue{entity}, false, nil
+		}
 		if le.GetLeft() == nil && le.GetRight() == nil {
 			return nil, nil, false, errors.WithMessagef(logical.ErrInvalidLogicalExpression, "both sides(left and right) of [%v] are empty", criteria)
 		}
@@ -131,12 +150,10 @@ func BuildQuery(criteria *modelv1.Criteria, schema logical.Schema, entityDict ma
 		case modelv1.LogicalExpression_LOGICAL_OP_AND:
 			query, node := bluge.NewBooleanQuery(), newMustNode()
 			if left != nil {
-				query.AddMust(left.(*queryNode).query)
-				node.Append(left.(*queryNode).node)
+				appendMust(query, node, left.(*queryNode))
 			}
 			if right != nil {
-				query.AddMust(right.(*queryNode).query)
-				node.Append(right.(*queryNode).node)
+				appendMust(query, node, right.(*queryNode))
 			}
 			return &queryNode{query, node}, entities, false, nil
 		case modelv1.LogicalExpression_LOGICAL_OP_OR:
@@ -210,7 +227,7 @@ func buildIndexModeCriteria(criteria *modelv1.Criteria, schema logical.Schema, e
 		}
 		if ok, indexRule := schema.IndexDefined(cond.Name); ok {
 			fk := index.FieldKey{IndexRuleID: indexRule.Metadata.Id}
-			return parseConditionToQuery(cond, indexRule, expr, fk.Marshal())
+			return parseConditionToQuery(cond, indexRule, logical.FoldCase(expr, indexRule), fk.Marshal())
 		}
 		if _, ok := entityDict[cond.Name]; ok {
 			fk := index.FieldKey{TagName: index.IndexModeEntityTagPrefix + cond.Name}
@@ -219,6 +236,17 @@ func buildIndexModeCriteria(criteria *modelv1.Criteria, schema logical.Schema, e
 		return nil, errors.Wrapf(logical.ErrUnsupportedConditionOp, "mandatory index rule conf:%s", cond)
 	case *modelv1.Criteria_Le:
 		le := criteria.GetLe()
+		if le.Op == modelv1.LogicalExpression_LOGICAL_OP_NOT {
+			negated, err := logical.NegatedCriteria(le)
+			if err != nil {
+				return nil, err
+			}
+			inner, err := buildIndexModeCriteria(negated, schema, entityDict)
+			if err != nil {
+				return nil, err
+			}
+			return newNotQuery(inner.(*queryNode)), nil
+		}
 		if le.GetLeft() == nil && le.GetRight() == nil {
 			return nil, errors.WithMessagef(logical.ErrInvalidLogicalExpression, "both sides(left and right) of [%v] are empty", criteria)
 		}
@@ -240,12 +268,10 @@ func buildIndexModeCriteria(criteria *modelv1.Criteria, schema logical.Schema, e
 		case modelv1.LogicalExpression_LOGICAL_OP_AND:
 			query, node := bluge.NewBooleanQuery(), newMustNode()
 			if left != nil {
-				query.AddMust(left.(*queryNode).query)
-				node.Append(left.(*queryNode).node)
+				appendMust(query, node, left.(*queryNode))
 			}
 			if right != nil {
-				query.AddMust(right.(*queryNode).query)
-				node.Append(right.(*queryNode).node)
+				appendMust(query, node, right.(*queryNode))
 			}
 			return &queryNode{query, node}, nil
 		case modelv1.LogicalExpression_LOGICAL_OP_OR:
@@ -266,6 +292,26 @@ func buildIndexModeCriteria(criteria *modelv1.Criteria, schema logical.Schema, e
 	return nil, logical.ErrInvalidCriteriaType
 }
 
+// newNotQuery excludes the inner query from all the documents.
+func newNotQuery(inner *queryNode) *queryNode {
+	query, node := bluge.NewBooleanQuery(), newMustNotNode()
+	query.AddMustNot(inner.query)
+	node.SetSubNode(inner.node)
+	node.negated = inner.query
+	return &queryNode{query, node}
+}
+
+// appendMust adds q to the "and" query. A negated query turns into a must-not clause,
+// which subtracts the excluded documents from the matched ones instead of all the documents.
+func appendMust(query *bluge.BooleanQuery, node *mustNode, q *queryNode) {
+	if mn, ok := q.node.(*mustNotNode); ok && mn.negated != nil {
+		query.AddMustNot(mn.negated)
+	} else {
+		query.AddMust(q.query)
+	}
+	node.Append(q.node)
+}
+
 func parseConditionToQuery(cond *modelv1.Condition, indexRule *databasev1.IndexRule,
 	expr logical.LiteralExpr, fieldKey string,
 ) (*queryNode, error) {
@@ -357,33 +403,41 @@ func parseConditionToQuery(cond *modelv1.Condition, indexRule *databasev1.IndexR
 		return &queryNode{query, node}, nil
 	case modelv1.Condition_BINARY_OP_IN:
 		bb, elements := expr.Bytes(), expr.Elements()
-		query, node := bluge.NewBooleanQuery(), newShouldNode()
-		query.SetMinShould(1)
-		for _, b := range bb {
-			query.AddShould(bluge.NewTermQuery(string(b)).SetField(fieldKey))
-		}
-		for _, e := range elements {
-			node.Append(newTermNode(e, indexRule))
-		}
-		return &queryNode{query, node}, nil
+		return &queryNode{newTermsQuery(fieldKey, bb), newTermsNode(elements, indexRule)}, nil
 	case modelv1.Condition_BINARY_OP_NOT_IN:
 		bb, elements := expr.Bytes(), expr.Elements()
-		subQuery, subNode := bluge.NewBooleanQuery(), newShouldNode()
-		subQuery.SetMinShould(1)
-		for _, b := range bb {
-			subQuery.AddShould(bluge.NewTermQuery(string(b)).SetField(fieldKey))
+		query, node := bluge.NewBooleanQuery(), newMustNotNode()
+		query.AddMustNot(newTermsQuery(fieldKey, bb))
+		node.SetSubNode(newTermsNode(elements, indexRule))
+		return &queryNode{query, node}, nil
+	case modelv1.Condition_BINARY_OP_PREFIX, modelv1.Condition_BINARY_OP_WILDCARD:
+		p, err := logical.ParsePattern(cond, indexRule)
+		if err != nil {
+			return nil, err
 		}
-		for _, e := range elements {
-			subNode.Append(newTermNode(e, indexRule))
+		if p.Wildcard {
+			return &queryNode{newPatternQuery(fieldKey, p), newWildcardNode(p.Value)}, nil
 		}
+		return &queryNode{newPatternQuery(fieldKey, p), newPrefixNode(p.Value)}, nil
+	case modelv1.Condition_BINARY_OP_EXISTS:
+		return newExistsQuery(indexRule, fieldKey), nil
+	case modelv1.Condition_BINARY_OP_IS_NULL:
+		exists := newExistsQuery(indexRule, fieldKey)
 		query, node := bluge.NewBooleanQuery(), newMustNotNode()
-		query.AddMustNot(subQuery)
-		node.SetSubNode(subNode)
+		query.AddMustNot(exists.query)
+		node.SetSubNode(exists.node)
 		return &queryNode{query, node}, nil
 	}
 	return nil, errors.WithMessagef(logical.ErrUnsupportedConditionOp, "index filter parses %v", cond)
 }
 
+// newExistsQuery matches the documents having any term in the field, which means the tag isn't null.
+func newExistsQuery(indexRule *databasev1.IndexRule, fieldKey string) *queryNode {
+	query := bluge.NewTermRangeInclusiveQuery("", maxTerm, true, true).SetField(fieldKey)
+	node := newTermRangeInclusiveNode(minInf, maxInf, true, true, indexRule, false)
+	return &queryNode{query, node}
+}
+
 type node interface {
 	fmt.Stringer
 }
@@ -438,6 +492,8 @@ func (s *shouldNode) String() string {
 
 type mustNotNode struct {
 	subNode node
+	// negated is the inner query of a NOT expression
+	negated bluge.Query
 }
 
 func newMustNotNode() *mustNotNode {
@@ -548,6 +604,123 @@ func (t *termNode) String() string {
 	return convert.JSONToString(t)
 }
 
+var _ bluge.Query = (*termsQuery)(nil)
+
+// termsQuery matches the documents having any of the terms in the field.
+// It looks up the terms in a batch and unions their posting lists as bitmaps,
+// instead of scoring a disjunction of term queries one by one.
+type termsQuery struct {
+	field string
+	terms [][]byte
+}
+
+func newTermsQuery(field string, terms [][]byte) *termsQuery {
+	return &termsQuery{
+		field: field,
+		terms: terms,
+	}
+}
+
+func (q *termsQuery) Searcher(i search.Reader, options search.SearcherOptions) (search.Searcher, error) {
+	if len(q.terms) == 0 {
+		return searcher.NewMatchNoneSearcher(i, options)
+	}
+	// the score is never used, which enables the bitmap union of the posting lists.
+	options.Score = "none"
+	return searcher.NewMultiTermSearcherBytes(i, q.terms, q.field, 1.0,
+		similarity.ConstantScorer(1), similarity.NewCompositeSumScorer(), options, false)
+}
+
+// patternQuery expands a prefix or a wildcard pattern to the terms in the dictionary of the field,
+// and fails if they exceed the cap of the pattern.
+type patternQuery struct {
+	field   string
+	pattern index.TermPattern
+}
+
+func newPatternQuery(field string, pattern index.TermPattern) *patternQuery {
+	return &patternQuery{
+		field:   field,
+		pattern: pattern,
+	}
+}
+
+func (q *patternQuery) Searcher(i search.Reader, options search.SearcherOptions) (search.Searcher, error) {
+	terms, err := expandPattern(i, q.field, q.pattern)
+	if err != nil {
+		return nil, err
+	}
+	return newTermsQuery(q.field, terms).Searcher(i, options)
+}
+
+func expandPattern(i search.Reader, field string, pattern index.TermPattern) (terms [][]byte, err error) {
+	// the literal prefix bounds the scan of the dictionary
+	start := []byte(pattern.LiteralPrefix())
+	var end []byte
+	if len(start) > 0 {
+		end = prefixEnd(start)
+	}
+	dict, err := i.DictionaryIterator(field, nil, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = multierr.Append(err, dict.Close())
+	}()
+	entry, err := dict.Next()
+	for err == nil && entry != nil {
+		if pattern.Match(entry.Term()) {
+			if pattern.MaxExpansions > 0 && len(terms) >= pattern.MaxExpansions {
+				return nil, errors.WithMessagef(index.ErrTooManyTerms, "%s matches more than %d terms", pattern, pattern.MaxExpansions)
+			}
+			terms = append(terms, []byte(entry.Term()))
+		}
+		entry, err = dict.Next()
+	}
+	return terms, err
+}
+
+// prefixEnd returns the smallest term greater than all the terms with the prefix, or nil if there is none.
+func prefixEnd(prefix []byte) []byte {
+	end := make([]byte, len(prefix))
+	copy(end, prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		end[i]++
+		if end[i] != 0 {
+			return end[:i+1]
+		}
+	}
+	return nil
+}
+
+type termsNode struct {
+	indexRule *databasev1.IndexRule
+	terms     []string
+}
+
+func newTermsNode(terms []string, indexRule *databasev1.IndexRule) *termsNode {
+	return &termsNode{
+		indexRule: indexRule,
+		terms:     terms,
+	}
+}
+
+func (t *termsNode) MarshalJSON() ([]byte, error) {
+	inner := make(map[string]interface{}, 3)
+	if t.indexRule != nil {
+		inner["index"] = t.indexRule.Metadata.Name + ":" + t.indexRule.Metadata.Group
+	}
+	inner["size"] = len(t.terms)
+	inner["values"] = logical.FormatElements(t.terms)
+	data := make(map[string]interface{}, 1)
+	data["terms"] = inner
+	return json.Marshal(data)
+}
+
+func (t *termsNode) String() string {
+	return convert.JSONToString(t)
+}
+
 type matchNode struct {
 	indexRule *databasev1.IndexRule
 	match     string
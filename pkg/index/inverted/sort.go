@@ -42,28 +42,7 @@ func (s *store) Sort(ctx context.Context, sids []common.SeriesID, fieldKey index
 		return nil, err
 	}
 
-	tqs := make([]bluge.Query, len(sids))
-	for i := range sids {
-		tq := bluge.NewTermQuery(string(sids[i].Marshal()))
-		tq.SetField(seriesIDField)
-		tqs[i] = tq
-	}
-	drq := bluge.
-		NewDateRangeInclusiveQuery(timeRange.Start, timeRange.End, timeRange.IncludeStart, timeRange.IncludeEnd).
-		SetField(timestampField)
-	var query bluge.Query
-	if len(tqs) == 0 {
-		query = drq
-	} else {
-		ibq := bluge.NewBooleanQuery()
-		ibq.AddShould(tqs...)
-		ibq.SetMinShould(1)
-		obq := bluge.NewBooleanQuery()
-		obq.AddMust(ibq)
-		obq.AddMust(drq)
-		query = obq
-	}
-
+	query := seriesInTimeRangeQuery(sids, timeRange)
 	fk := fieldKey.Marshal()
 	sortedKey := fk
 	if order == modelv1.Sort_SORT_DESC {
@@ -80,6 +59,28 @@ func (s *store) Sort(ctx context.Context, sids []common.SeriesID, fieldKey index
 	return result, nil
 }
 
+func seriesInTimeRangeQuery(sids []common.SeriesID, timeRange *timestamp.TimeRange) bluge.Query {
+	tqs := make([]bluge.Query, len(sids))
+	for i := range sids {
+		tq := bluge.NewTermQuery(string(sids[i].Marshal()))
+		tq.SetField(seriesIDField)
+		tqs[i] = tq
+	}
+	drq := bluge.
+		NewDateRangeInclusiveQuery(timeRange.Start, timeRange.End, timeRange.IncludeStart, timeRange.IncludeEnd).
+		SetField(timestampField)
+	if len(tqs) == 0 {
+		return drq
+	}
+	ibq := bluge.NewBooleanQuery()
+	ibq.AddShould(tqs...)
+	ibq.SetMinShould(1)
+	obq := bluge.NewBooleanQuery()
+	obq.AddMust(ibq)
+	obq.AddMust(drq)
+	return obq
+}
+
 type blugeIterator interface {
 	Next() bool
 	Val() index.DocumentResult
@@ -0,0 +1,92 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package inverted
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/blugelabs/bluge"
+	"github.com/pkg/errors"
+	"go.uber.org/multierr"
+
+	"github.com/apache/skywalking-banyandb/pkg/convert"
+	"github.com/apache/skywalking-banyandb/pkg/index"
+)
+
+// Values fetches the stored values of the fields, which only the fields flagged by Store have.
+func (s *store) Values(ctx context.Context, docIDs []uint64, fields []index.FieldKey) (result map[uint64]index.StoredFields, err error) {
+	result = make(map[uint64]index.StoredFields, len(docIDs))
+	if len(docIDs) == 0 || len(fields) == 0 {
+		return result, nil
+	}
+	if !s.closer.AddRunning() {
+		return result, nil
+	}
+	defer s.closer.Done()
+	reader, err := s.writer.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = multierr.Append(err, reader.Close())
+	}()
+	query := bluge.NewBooleanQuery()
+	for _, id := range docIDs {
+		query.AddShould(bluge.NewTermQuery(convert.BytesToString(convert.Uint64ToBytes(id))).SetField(docIDField))
+	}
+	dmi, err := reader.Search(ctx, bluge.NewAllMatches(query))
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]int, len(fields))
+	for i := range fields {
+		names[fields[i].Marshal()] = i
+	}
+	for i := 0; ; i++ {
+		if i%checkDoneEvery == 0 {
+			select {
+			case <-ctx.Done():
+				return nil, errors.WithMessagef(ctx.Err(), "fetch stored values, hit: %d", i)
+			default:
+			}
+		}
+		match, errNext := dmi.Next()
+		if errNext != nil {
+			return nil, errors.WithMessagef(errNext, "failed to get next document, hit: %d", i)
+		}
+		if match == nil {
+			return result, nil
+		}
+		var docID uint64
+		sf := make(index.StoredFields, len(fields))
+		if err = match.VisitStoredFields(func(field string, value []byte) bool {
+			if field == docIDField {
+				docID = convert.BytesToUint64(value)
+				return true
+			}
+			if j, ok := names[field]; ok {
+				sf[j] = append(sf[j], bytes.Clone(value))
+			}
+			return true
+		}); err != nil {
+			return nil, errors.WithMessagef(err, "visit stored fields, hit: %d", i)
+		}
+		result[docID] = sf
+	}
+}
@@ -0,0 +1,92 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package inverted
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/blugelabs/bluge/numeric"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/skywalking-banyandb/pkg/index"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+)
+
+func TestStore_Values(t *testing.T) {
+	tester := assert.New(t)
+	is := require.New(t)
+	path, fn := setUp(is)
+	s, err := NewStore(StoreOpts{
+		Path:   path,
+		Logger: logger.GetLogger("test"),
+	})
+	is.NoError(err)
+	defer func() {
+		tester.NoError(s.Close())
+		fn()
+	}()
+	endpoint := index.FieldKey{IndexRuleID: 11, SeriesID: 1}
+	duration := index.FieldKey{IndexRuleID: 12, SeriesID: 1}
+	tags := index.FieldKey{IndexRuleID: 13, SeriesID: 1}
+	stored := func(f index.Field) index.Field {
+		f.Store = true
+		return f
+	}
+	now := time.Now()
+	is.NoError(s.Batch(index.Batch{
+		Documents: index.Documents{
+			{
+				Fields: []index.Field{
+					stored(index.NewStringField(endpoint, "/home")),
+					stored(index.NewIntField(duration, -50)),
+					stored(index.NewStringField(tags, "a")),
+					stored(index.NewStringField(tags, "b")),
+				},
+				DocID:     1,
+				Timestamp: now.UnixNano(),
+			},
+			{
+				Fields: []index.Field{
+					stored(index.NewStringField(endpoint, "/login")),
+					index.NewIntField(duration, 100),
+				},
+				DocID:     2,
+				Timestamp: now.UnixNano(),
+			},
+		},
+	}))
+
+	values, err := s.Values(context.TODO(), []uint64{1, 2, 3}, []index.FieldKey{endpoint, duration, tags})
+	is.NoError(err)
+	is.Len(values, 2)
+	tester.Equal([][]byte{[]byte("/home")}, values[1][0])
+	is.Len(values[1][1], 1)
+	v, err := numeric.PrefixCoded(values[1][1][0]).Int64()
+	is.NoError(err)
+	tester.Equal(int64(-50), v)
+	tester.Equal([][]byte{[]byte("a"), []byte("b")}, values[1][2])
+	// the values of the fields without Store aren't stored.
+	tester.Equal(index.StoredFields{{[]byte("/login")}, nil, nil}, values[2])
+
+	values, err = s.Values(context.TODO(), nil, []index.FieldKey{endpoint})
+	is.NoError(err)
+	tester.Empty(values)
+}
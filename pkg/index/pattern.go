@@ -0,0 +1,94 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package index
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// WildcardAny matches any sequence of characters in a wildcard pattern.
+	WildcardAny = '*'
+	// WildcardOne matches a single character in a wildcard pattern.
+	WildcardOne = '?'
+)
+
+// ErrTooManyTerms indicates a prefix or a wildcard pattern expands to more terms than its cap.
+var ErrTooManyTerms = errors.New("the pattern expands to too many terms")
+
+// TermPattern matches the terms of a field by a prefix or a wildcard pattern.
+type TermPattern struct {
+	Value    string
+	Wildcard bool
+	// MaxExpansions caps the terms the pattern expands to. Zero means no cap.
+	MaxExpansions int
+}
+
+// LiteralPrefix returns the prefix shared by all the terms the pattern matches.
+func (p TermPattern) LiteralPrefix() string {
+	if !p.Wildcard {
+		return p.Value
+	}
+	if i := strings.IndexAny(p.Value, string([]rune{WildcardAny, WildcardOne})); i >= 0 {
+		return p.Value[:i]
+	}
+	return p.Value
+}
+
+// Match returns whether the term matches the pattern.
+func (p TermPattern) Match(term string) bool {
+	if !p.Wildcard {
+		return strings.HasPrefix(term, p.Value)
+	}
+	return matchWildcard([]rune(p.Value), []rune(term))
+}
+
+func (p TermPattern) String() string {
+	if p.Wildcard {
+		return "wildcard:" + p.Value
+	}
+	return "prefix:" + p.Value
+}
+
+// matchWildcard backtracks to the last '*' on a mismatch, which runs in O(len(pattern)*len(term)).
+func matchWildcard(pattern, term []rune) bool {
+	pi, ti := 0, 0
+	star, mark := -1, 0
+	for ti < len(term) {
+		switch {
+		case pi < len(pattern) && (pattern[pi] == WildcardOne || pattern[pi] == term[ti]):
+			pi++
+			ti++
+		case pi < len(pattern) && pattern[pi] == WildcardAny:
+			star, mark = pi, ti
+			pi++
+		case star >= 0:
+			pi = star + 1
+			mark++
+			ti = mark
+		default:
+			return false
+		}
+	}
+	for pi < len(pattern) && pattern[pi] == WildcardAny {
+		pi++
+	}
+	return pi == len(pattern)
+}
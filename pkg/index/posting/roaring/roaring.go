@@ -41,7 +41,10 @@ type postingsList struct {
 	bitmap *roaring64.Bitmap
 }
 
+// Marshall converts the dense containers to the run-length ones before encoding the bitmap,
+// which shrinks the postings of the consecutive document ids.
 func (p *postingsList) Marshall() ([]byte, error) {
+	p.bitmap.RunOptimize()
 	return p.bitmap.MarshalBinary()
 }
 
@@ -113,6 +116,9 @@ func (p *postingsList) Clone() posting.List {
 }
 
 func (p *postingsList) Equal(other posting.List) bool {
+	if o, ok := other.(*postingsList); ok {
+		return p.bitmap.Equals(o.bitmap)
+	}
 	if p.Len() != other.Len() {
 		return false
 	}
@@ -164,12 +170,19 @@ func (p *postingsList) Union(other posting.List) error {
 }
 
 func (p *postingsList) UnionMany(others []posting.List) error {
+	if len(others) == 0 {
+		return nil
+	}
+	bitmaps := make([]*roaring64.Bitmap, 0, len(others)+1)
+	bitmaps = append(bitmaps, p.bitmap)
 	for _, other := range others {
-		err := p.Union(other)
-		if err != nil {
-			return err
+		o, ok := other.(*postingsList)
+		if !ok {
+			return errUnionRoaringOnly
 		}
+		bitmaps = append(bitmaps, o.bitmap)
 	}
+	p.bitmap = roaring64.FastOr(bitmaps...)
 	return nil
 }
 
@@ -181,15 +194,15 @@ func (p *postingsList) AddIterator(iter posting.Iterator) error {
 }
 
 func (p *postingsList) AddRange(minVal, maxVal uint64) error {
-	for i := minVal; i < maxVal; i++ {
-		p.bitmap.Add(i)
+	if minVal < maxVal {
+		p.bitmap.AddRange(minVal, maxVal)
 	}
 	return nil
 }
 
 func (p *postingsList) RemoveRange(minVal, maxVal uint64) error {
-	for i := minVal; i < maxVal; i++ {
-		p.bitmap.Remove(i)
+	if minVal < maxVal {
+		p.bitmap.RemoveRange(minVal, maxVal)
 	}
 	return nil
 }
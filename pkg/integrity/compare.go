@@ -0,0 +1,152 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package integrity compares the digests of the replicas of a shard.
+package integrity
+
+import (
+	"sort"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+)
+
+// Kind is the kind of a divergence.
+type Kind string
+
+const (
+	// KindSegment means the segment is absent on some replicas. The values are the elements of the segment.
+	KindSegment Kind = "segment"
+	// KindSeries means the replicas hold different numbers of series in the segment.
+	KindSeries Kind = "series"
+	// KindElements means the replicas hold different numbers of elements in a time bucket.
+	KindElements Kind = "elements"
+	// KindParts means the replicas hold different parts in the segment. The values are the parts absent on any other replica.
+	// The parts are flushed and merged independently on every replica, so it alone doesn't mean the data diverges.
+	KindParts Kind = "parts"
+)
+
+// Replica is the digest of a shard reported by a replica.
+type Replica struct {
+	Digest *databasev1.IntegrityServiceDigestResponse
+	Node   string
+}
+
+// Divergence is a difference between the replicas. Values are keyed by the nodes of the replicas.
+type Divergence struct {
+	Values  map[string]uint64
+	Kind    Kind
+	Segment string
+	// Bucket is the start of the time bucket of KindElements in nanoseconds since the epoch.
+	Bucket int64
+}
+
+// Diverged reports whether the replicas hold different data.
+func (d Divergence) Diverged() bool {
+	return d.Kind != KindParts
+}
+
+// Compare compares the replicas, and returns the divergences ordered by the segments and the buckets.
+func Compare(replicas []Replica) []Divergence {
+	segments := make(map[string]map[string]*databasev1.SegmentDigest)
+	for _, r := range replicas {
+		for _, sd := range r.Digest.GetSegments() {
+			if segments[sd.GetSegment()] == nil {
+				segments[sd.GetSegment()] = make(map[string]*databasev1.SegmentDigest)
+			}
+			segments[sd.GetSegment()][r.Node] = sd
+		}
+	}
+	names := make([]string, 0, len(segments))
+	for name := range segments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var result []Divergence
+	for _, name := range names {
+		result = append(result, compareSegment(name, replicas, segments[name])...)
+	}
+	return result
+}
+
+func compareSegment(name string, replicas []Replica, digests map[string]*databasev1.SegmentDigest) []Divergence {
+	if len(digests) < len(replicas) {
+		d := Divergence{Kind: KindSegment, Segment: name, Values: make(map[string]uint64, len(replicas))}
+		for _, r := range replicas {
+			var total uint64
+			for _, b := range digests[r.Node].GetBuckets() {
+				total += b.GetCount()
+			}
+			d.Values[r.Node] = total
+		}
+		return []Divergence{d}
+	}
+	var result []Divergence
+	series := Divergence{Kind: KindSeries, Segment: name, Values: make(map[string]uint64, len(replicas))}
+	buckets := make(map[int64]map[string]uint64)
+	parts := make(map[uint64]int)
+	for _, r := range replicas {
+		sd := digests[r.Node]
+		series.Values[r.Node] = sd.GetSeriesCount()
+		for _, b := range sd.GetBuckets() {
+			if buckets[b.GetBegin()] == nil {
+				buckets[b.GetBegin()] = make(map[string]uint64, len(replicas))
+			}
+			buckets[b.GetBegin()][r.Node] = b.GetCount()
+		}
+		for _, pd := range sd.GetParts() {
+			parts[pd.GetChecksum()]++
+		}
+	}
+	if !equal(replicas, series.Values) {
+		result = append(result, series)
+	}
+	begins := make([]int64, 0, len(buckets))
+	for begin := range buckets {
+		begins = append(begins, begin)
+	}
+	sort.Slice(begins, func(i, j int) bool { return begins[i] < begins[j] })
+	for _, begin := range begins {
+		if !equal(replicas, buckets[begin]) {
+			result = append(result, Divergence{Kind: KindElements, Segment: name, Bucket: begin, Values: buckets[begin]})
+		}
+	}
+	unshared := Divergence{Kind: KindParts, Segment: name, Values: make(map[string]uint64, len(replicas))}
+	var found bool
+	for _, r := range replicas {
+		unshared.Values[r.Node] = 0
+		for _, pd := range digests[r.Node].GetParts() {
+			if parts[pd.GetChecksum()] < len(replicas) {
+				unshared.Values[r.Node]++
+				found = true
+			}
+		}
+	}
+	if found {
+		result = append(result, unshared)
+	}
+	return result
+}
+
+// equal reports whether all the replicas have the same value. An absent value is zero.
+func equal(replicas []Replica, values map[string]uint64) bool {
+	for _, r := range replicas {
+		if values[r.Node] != values[replicas[0].Node] {
+			return false
+		}
+	}
+	return true
+}
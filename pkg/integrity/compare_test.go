@@ -0,0 +1,75 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package integrity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+)
+
+func replica(node string, segments ...*databasev1.SegmentDigest) Replica {
+	return Replica{Node: node, Digest: &databasev1.IntegrityServiceDigestResponse{Segments: segments}}
+}
+
+func segment(name string, series uint64, checksums []uint64, buckets ...uint64) *databasev1.SegmentDigest {
+	sd := &databasev1.SegmentDigest{Segment: name, SeriesCount: series}
+	for _, c := range checksums {
+		sd.Parts = append(sd.Parts, &databasev1.PartDigest{Checksum: c})
+	}
+	for i := 0; i+1 < len(buckets); i += 2 {
+		sd.Buckets = append(sd.Buckets, &databasev1.BucketCount{Begin: int64(buckets[i]), Count: buckets[i+1]})
+	}
+	return sd
+}
+
+func TestCompareConsistent(t *testing.T) {
+	dd := Compare([]Replica{
+		replica("a", segment("seg-1", 2, []uint64{1, 2}, 0, 10, 100, 5)),
+		replica("b", segment("seg-1", 2, []uint64{2, 1}, 0, 10, 100, 5)),
+	})
+	assert.Empty(t, dd)
+}
+
+func TestCompareDiverged(t *testing.T) {
+	dd := Compare([]Replica{
+		replica("a", segment("seg-2", 3, []uint64{1}, 0, 10, 100, 5), segment("seg-1", 1, []uint64{7}, 0, 4)),
+		replica("b", segment("seg-2", 2, []uint64{1, 9}, 0, 10, 100, 4, 200, 1)),
+	})
+	assert.Equal(t, []Divergence{
+		{Kind: KindSegment, Segment: "seg-1", Values: map[string]uint64{"a": 4, "b": 0}},
+		{Kind: KindSeries, Segment: "seg-2", Values: map[string]uint64{"a": 3, "b": 2}},
+		{Kind: KindElements, Segment: "seg-2", Bucket: 100, Values: map[string]uint64{"a": 5, "b": 4}},
+		{Kind: KindElements, Segment: "seg-2", Bucket: 200, Values: map[string]uint64{"b": 1}},
+		{Kind: KindParts, Segment: "seg-2", Values: map[string]uint64{"a": 0, "b": 1}},
+	}, dd)
+	assert.False(t, dd[len(dd)-1].Diverged())
+}
+
+func TestCompareLayoutOnly(t *testing.T) {
+	dd := Compare([]Replica{
+		replica("a", segment("seg-1", 2, []uint64{1, 2}, 0, 10)),
+		replica("b", segment("seg-1", 2, []uint64{3}, 0, 10)),
+	})
+	assert.Len(t, dd, 1)
+	assert.Equal(t, KindParts, dd[0].Kind)
+	assert.Equal(t, map[string]uint64{"a": 2, "b": 1}, dd[0].Values)
+	assert.False(t, dd[0].Diverged())
+}
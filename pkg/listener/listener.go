@@ -0,0 +1,235 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package listener parses the listening addresses of servers and opens them with their TLS settings.
+//
+// An address is one of:
+//
+//	host:port, [::]:port                          a TCP address, which is dual-stack if the host is "::" or empty
+//	tcp://host:port, tcp4://..., tcp6://...       a TCP address bound to the specified IP family
+//	unix:///path/to/file.sock, unix:relative.sock a Unix domain socket
+//
+// The TLS settings are given by the query of the address: "?tls=true&cert-file=/a.crt&key-file=/a.key".
+// A TLS listener without cert-file and key-file uses the certificate of the server.
+// "?admin=true" grants the admin capability to the connections accepted by the listener, see IsAdmin.
+package listener
+
+import (
+	"crypto/tls"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	pkgtls "github.com/apache/skywalking-banyandb/pkg/tls"
+)
+
+// ErrInvalidAddress indicates the listening address is malformed.
+var ErrInvalidAddress = errors.New("invalid listening address")
+
+// Config is a listening address and its TLS and admin settings.
+type Config struct {
+	Network  string
+	Address  string
+	CertFile string
+	KeyFile  string
+	TLS      bool
+	Admin    bool
+}
+
+// Parse parses a listening address.
+func Parse(addr string) (Config, error) {
+	raw := addr
+	if !strings.Contains(addr, "://") && !strings.HasPrefix(addr, "unix:") {
+		raw = "tcp://" + addr
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return Config{}, errors.Wrapf(ErrInvalidAddress, "%s: %v", addr, err)
+	}
+	c := Config{Network: u.Scheme}
+	switch u.Scheme {
+	case "tcp", "tcp4", "tcp6":
+		if _, _, err = net.SplitHostPort(u.Host); err != nil {
+			return Config{}, errors.Wrapf(ErrInvalidAddress, "%s: %v", addr, err)
+		}
+		c.Address = u.Host
+	case "unix":
+		c.Address = u.Path
+		if c.Address == "" {
+			c.Address = u.Opaque
+		}
+		if c.Address == "" {
+			return Config{}, errors.Wrapf(ErrInvalidAddress, "%s: the socket path is absent", addr)
+		}
+	default:
+		return Config{}, errors.Wrapf(ErrInvalidAddress, "%s: unsupported network %q", addr, u.Scheme)
+	}
+	q := u.Query()
+	for k := range q {
+		switch k {
+		case "tls", "cert-file", "key-file", "admin":
+		default:
+			return Config{}, errors.Wrapf(ErrInvalidAddress, "%s: unknown option %q", addr, k)
+		}
+	}
+	if v := q.Get("tls"); v != "" {
+		if c.TLS, err = strconv.ParseBool(v); err != nil {
+			return Config{}, errors.Wrapf(ErrInvalidAddress, "%s: %v", addr, err)
+		}
+	}
+	if v := q.Get("admin"); v != "" {
+		if c.Admin, err = strconv.ParseBool(v); err != nil {
+			return Config{}, errors.Wrapf(ErrInvalidAddress, "%s: %v", addr, err)
+		}
+	}
+	c.CertFile, c.KeyFile = q.Get("cert-file"), q.Get("key-file")
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return Config{}, errors.Wrapf(ErrInvalidAddress, "%s: cert-file and key-file must be provided together", addr)
+	}
+	if c.CertFile != "" && !c.TLS {
+		return Config{}, errors.Wrapf(ErrInvalidAddress, "%s: cert-file and key-file require tls=true", addr)
+	}
+	return c, nil
+}
+
+// ParseAll parses the listening addresses.
+func ParseAll(addrs []string) ([]Config, error) {
+	cc := make([]Config, 0, len(addrs))
+	for _, addr := range addrs {
+		c, err := Parse(addr)
+		if err != nil {
+			return nil, err
+		}
+		cc = append(cc, c)
+	}
+	return cc, nil
+}
+
+// String returns the address without the TLS options.
+func (c Config) String() string {
+	if c.Network == "unix" {
+		return "unix://" + c.Address
+	}
+	return c.Network + "://" + c.Address
+}
+
+// Listen opens the listener. The serverTLS is the TLS config of the server,
+// which is used by a TLS listener without its own certificate.
+// The nextProtos overrides the ALPN protocols of the TLS config if it's not empty.
+func Listen(c Config, serverTLS *tls.Config, l *logger.Logger, nextProtos ...string) (net.Listener, error) {
+	var tlsConfig *tls.Config
+	var reloader *pkgtls.Reloader
+	if c.TLS {
+		switch {
+		case c.CertFile != "":
+			var err error
+			if reloader, err = pkgtls.NewReloader(c.CertFile, c.KeyFile, l); err != nil {
+				return nil, errors.Wrapf(err, "failed to load the certificate of %s", c)
+			}
+			if err = reloader.Start(); err != nil {
+				reloader.Stop()
+				return nil, errors.Wrapf(err, "failed to watch the certificate of %s", c)
+			}
+			tlsConfig = reloader.GetTLSConfig()
+		case serverTLS != nil:
+			tlsConfig = serverTLS
+		default:
+			return nil, errors.Errorf("%s enables TLS without any certificate", c)
+		}
+	}
+	if c.Network == "unix" {
+		removeStaleSocket(c.Address, l)
+	}
+	lis, err := net.Listen(c.Network, c.Address)
+	if err != nil {
+		if reloader != nil {
+			reloader.Stop()
+		}
+		return nil, err
+	}
+	if c.Admin {
+		lis = &adminListener{Listener: lis}
+	}
+	if tlsConfig == nil {
+		return lis, nil
+	}
+	if len(nextProtos) > 0 {
+		tlsConfig = tlsConfig.Clone()
+		tlsConfig.NextProtos = nextProtos
+	}
+	return &tlsListener{Listener: tls.NewListener(lis, tlsConfig), reloader: reloader}, nil
+}
+
+// removeStaleSocket removes the socket file left by an unclean shutdown, which fails the new listener.
+func removeStaleSocket(path string, l *logger.Logger) {
+	fi, err := os.Stat(path)
+	if err != nil || fi.Mode()&os.ModeSocket == 0 {
+		return
+	}
+	if err = os.Remove(path); err != nil {
+		l.Warn().Err(err).Str("path", path).Msg("failed to remove the stale socket file")
+	}
+}
+
+type tlsListener struct {
+	net.Listener
+	reloader *pkgtls.Reloader
+}
+
+func (tl *tlsListener) Close() error {
+	if tl.reloader != nil {
+		tl.reloader.Stop()
+	}
+	return tl.Listener.Close()
+}
+
+// IsAdmin reports whether the local address of a connection belongs to an admin listener.
+// The servers get the address from the peer of a request.
+func IsAdmin(localAddr net.Addr) bool {
+	_, ok := localAddr.(adminAddr)
+	return ok
+}
+
+type adminListener struct {
+	net.Listener
+}
+
+func (al *adminListener) Accept() (net.Conn, error) {
+	conn, err := al.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &adminConn{Conn: conn}, nil
+}
+
+// adminConn marks its local address, which survives the TLS wrapping.
+type adminConn struct {
+	net.Conn
+}
+
+func (ac *adminConn) LocalAddr() net.Addr {
+	return adminAddr{Addr: ac.Conn.LocalAddr()}
+}
+
+type adminAddr struct {
+	net.Addr
+}
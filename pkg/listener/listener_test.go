@@ -0,0 +1,169 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package listener
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	pkgtls "github.com/apache/skywalking-banyandb/pkg/tls"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		addr    string
+		want    Config
+		wantErr bool
+	}{
+		{addr: ":17912", want: Config{Network: "tcp", Address: ":17912"}},
+		{addr: "[::1]:17912", want: Config{Network: "tcp", Address: "[::1]:17912"}},
+		{addr: "tcp6://[::]:17912", want: Config{Network: "tcp6", Address: "[::]:17912"}},
+		{addr: "tcp4://0.0.0.0:17912?tls=true", want: Config{Network: "tcp4", Address: "0.0.0.0:17912", TLS: true}},
+		{addr: "unix:///var/run/banyandb.sock", want: Config{Network: "unix", Address: "/var/run/banyandb.sock"}},
+		{addr: "unix:banyandb.sock", want: Config{Network: "unix", Address: "banyandb.sock"}},
+		{
+			addr: "unix:///tmp/a.sock?tls=true&cert-file=/a.crt&key-file=/a.key",
+			want: Config{Network: "unix", Address: "/tmp/a.sock", TLS: true, CertFile: "/a.crt", KeyFile: "/a.key"},
+		},
+		{addr: "unix:///tmp/admin.sock?admin=true", want: Config{Network: "unix", Address: "/tmp/admin.sock", Admin: true}},
+		{addr: "localhost", wantErr: true},
+		{addr: "udp://:17912", wantErr: true},
+		{addr: "unix://", wantErr: true},
+		{addr: ":17912?tls=yes", wantErr: true},
+		{addr: ":17912?mode=0600", wantErr: true},
+		{addr: ":17912?admin=1x", wantErr: true},
+		{addr: ":17912?tls=true&cert-file=/a.crt", wantErr: true},
+		{addr: ":17912?cert-file=/a.crt&key-file=/a.key", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			got, err := Parse(tt.addr)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidAddress)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestListenUnix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bydb.sock")
+	c, err := Parse("unix://" + path)
+	require.NoError(t, err)
+	lis, err := Listen(c, nil, logger.GetLogger("test"))
+	require.NoError(t, err)
+	// an unclean shutdown leaves the socket file behind.
+	lis.(*net.UnixListener).SetUnlinkOnClose(false)
+	require.NoError(t, lis.Close())
+	_, err = os.Stat(path)
+	require.NoError(t, err)
+
+	lis, err = Listen(c, nil, logger.GetLogger("test"))
+	require.NoError(t, err)
+	defer lis.Close()
+	go func() {
+		conn, acceptErr := lis.Accept()
+		if acceptErr != nil {
+			return
+		}
+		_, _ = conn.Write([]byte("pong"))
+		_ = conn.Close()
+	}()
+	conn, err := net.Dial("unix", path)
+	require.NoError(t, err)
+	defer conn.Close()
+	data, err := io.ReadAll(conn)
+	require.NoError(t, err)
+	assert.Equal(t, "pong", string(data))
+}
+
+func TestListenTLS(t *testing.T) {
+	certPEM, keyPEM, err := pkgtls.GenerateSelfSignedCert("localhost", nil)
+	require.NoError(t, err)
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, certPEM, 0o600))
+	require.NoError(t, os.WriteFile(keyFile, keyPEM, 0o600))
+
+	_, err = Listen(Config{Network: "tcp", Address: "127.0.0.1:0", TLS: true}, nil, logger.GetLogger("test"))
+	require.Error(t, err)
+
+	c := Config{Network: "tcp", Address: "127.0.0.1:0", TLS: true, CertFile: certFile, KeyFile: keyFile}
+	lis, err := Listen(c, nil, logger.GetLogger("test"), "h2", "http/1.1")
+	require.NoError(t, err)
+	defer lis.Close()
+	go func() {
+		conn, acceptErr := lis.Accept()
+		if acceptErr != nil {
+			return
+		}
+		_, _ = conn.Write([]byte("pong"))
+		_ = conn.Close()
+	}()
+	pool := x509.NewCertPool()
+	require.True(t, pool.AppendCertsFromPEM(certPEM))
+	conn, err := tls.Dial("tcp", lis.Addr().String(), &tls.Config{
+		RootCAs:    pool,
+		ServerName: "localhost",
+		NextProtos: []string{"http/1.1"},
+		MinVersion: tls.VersionTLS12,
+	})
+	require.NoError(t, err)
+	defer conn.Close()
+	assert.Equal(t, "http/1.1", conn.ConnectionState().NegotiatedProtocol)
+	data, err := io.ReadAll(conn)
+	require.NoError(t, err)
+	assert.Equal(t, "pong", string(data))
+}
+
+func TestListenAdmin(t *testing.T) {
+	for _, admin := range []bool{false, true} {
+		c := Config{Network: "tcp", Address: "127.0.0.1:0", Admin: admin}
+		lis, err := Listen(c, nil, logger.GetLogger("test"))
+		require.NoError(t, err)
+		accepted := make(chan net.Conn, 1)
+		go func() {
+			conn, acceptErr := lis.Accept()
+			if acceptErr != nil {
+				close(accepted)
+				return
+			}
+			accepted <- conn
+		}()
+		client, err := net.Dial("tcp", lis.Addr().String())
+		require.NoError(t, err)
+		conn := <-accepted
+		require.NotNil(t, conn)
+		assert.Equal(t, admin, IsAdmin(conn.LocalAddr()))
+		assert.Equal(t, client.RemoteAddr().String(), conn.LocalAddr().String())
+		_ = conn.Close()
+		_ = client.Close()
+		_ = lis.Close()
+	}
+}
@@ -85,6 +85,12 @@ func (l *Logger) Named(name ...string) *Logger {
 	return &Logger{module: module, modules: l.modules, development: l.development, Logger: &subLogger, isDefaultLevel: isDefaultLevel}
 }
 
+// WithStr creates a new Logger which attaches the key and the value to all its events.
+func (l *Logger) WithStr(key, value string) *Logger {
+	subLogger := l.Logger.With().Str(key, value).Logger()
+	return &Logger{module: l.module, modules: l.modules, development: l.development, Logger: &subLogger, isDefaultLevel: l.isDefaultLevel}
+}
+
 // ToZapConfig outputs the zap config is derived from l.
 func (l *Logger) ToZapConfig() zap.Config {
 	level, err := zap.ParseAtomicLevel(l.GetLevel().String())
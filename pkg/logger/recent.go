@@ -0,0 +1,65 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logger
+
+import (
+	"bytes"
+	"sync"
+)
+
+// recentLogsCapacity is the number of the latest log lines kept in memory.
+const recentLogsCapacity = 2000
+
+var recent = &recentWriter{lines: make([][]byte, recentLogsCapacity)}
+
+// recentWriter keeps the latest log lines in a ring, which are attached to the support bundles.
+type recentWriter struct {
+	lines [][]byte
+	next  int
+	mu    sync.Mutex
+	full  bool
+}
+
+func (w *recentWriter) Write(p []byte) (int, error) {
+	line := bytes.Clone(p)
+	w.mu.Lock()
+	w.lines[w.next] = line
+	w.next++
+	if w.next == len(w.lines) {
+		w.next = 0
+		w.full = true
+	}
+	w.mu.Unlock()
+	return len(p), nil
+}
+
+// RecentLogs returns the latest log lines in the order they're written.
+func RecentLogs() []byte {
+	recent.mu.Lock()
+	defer recent.mu.Unlock()
+	var buf bytes.Buffer
+	if recent.full {
+		for _, l := range recent.lines[recent.next:] {
+			buf.Write(l)
+		}
+	}
+	for _, l := range recent.lines[:recent.next] {
+		buf.Write(l)
+	}
+	return buf.Bytes()
+}
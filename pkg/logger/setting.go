@@ -136,7 +136,7 @@ func getLogger(cfg Logging) (*Logger, error) {
 	} else {
 		w = os.Stderr
 	}
-	ctx := zerolog.New(w).Level(lvl).With().Timestamp()
+	ctx := zerolog.New(io.MultiWriter(w, recent)).Level(lvl).With().Timestamp()
 	if development {
 		ctx = ctx.Stack().Caller()
 	}
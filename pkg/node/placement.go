@@ -0,0 +1,68 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package node
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+)
+
+// PlacementPath is the HTTP path of the placement of the shards on the nodes.
+const PlacementPath = "/api/debug/shard-placement"
+
+// ShardPlacement places the replicas of a shard of a group on the nodes.
+type ShardPlacement struct {
+	Group   string `json:"group"`
+	Catalog string `json:"catalog"`
+	// SplitNode is the node receiving the upper half of the series of the shard if it's split.
+	SplitNode string `json:"split_node,omitempty"`
+	Error     string `json:"error,omitempty"`
+	// Nodes are the nodes of the replicas ordered by the replica IDs, which are empty if they can't be placed.
+	Nodes   []string `json:"nodes"`
+	ShardID uint32   `json:"shard_id"`
+}
+
+var placement = struct {
+	place func() []ShardPlacement
+	mu    sync.RWMutex
+}{}
+
+// SetPlacement serves the shard placement returned by place through ServePlacement, nil stops serving it.
+func SetPlacement(place func() []ShardPlacement) {
+	placement.mu.Lock()
+	defer placement.mu.Unlock()
+	placement.place = place
+}
+
+// Placement returns the shard placement, which is empty if nothing serves it.
+func Placement() []ShardPlacement {
+	placement.mu.RLock()
+	place := placement.place
+	placement.mu.RUnlock()
+	if place == nil {
+		return []ShardPlacement{}
+	}
+	return place()
+}
+
+// ServePlacement writes the shard placement in JSON.
+func ServePlacement(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(Placement())
+}
@@ -28,8 +28,12 @@ import (
 	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
 )
 
-// ErrMalformedElement indicates the element is malformed.
-var ErrMalformedElement = errors.New("element is malformed")
+var (
+	// ErrMalformedElement indicates the element is malformed.
+	ErrMalformedElement = errors.New("element is malformed")
+	// ErrMisrouted indicates the data is written to a shard which doesn't own it.
+	ErrMisrouted = errors.New("data is misrouted")
+)
 
 // Locator combines several TagLocators that help find the entity or sharding key value.
 type Locator struct {
@@ -98,6 +102,18 @@ func (l Locator) Locate(subject string, value []*modelv1.TagFamilyForWrite, shar
 	return tagValues, common.ShardID(id), nil
 }
 
+// CheckShard verifies that the data located from a tag family belongs to the shard.
+func (l Locator) CheckShard(subject string, value []*modelv1.TagFamilyForWrite, shardNum, shardID uint32) error {
+	_, id, err := l.Locate(subject, value, shardNum)
+	if err != nil {
+		return err
+	}
+	if uint32(id) != shardID {
+		return errors.Wrapf(ErrMisrouted, "the data belongs to shard %d instead of shard %d", id, shardID)
+	}
+	return nil
+}
+
 // GetTagByOffset gets a tag value based of a tag family offset and a tag offset in this family.
 func GetTagByOffset(value []*modelv1.TagFamilyForWrite, fIndex, tIndex int) (*modelv1.TagValue, error) {
 	if fIndex >= len(value) {
@@ -109,3 +125,51 @@ func GetTagByOffset(value []*modelv1.TagFamilyForWrite, fIndex, tIndex int) (*mo
 	}
 	return family.GetTags()[tIndex], nil
 }
+
+// PinShard returns the shard which the criteria pin by the equality conditions on all the tags of the sharding key,
+// prepending a subject to the sharding key as Locate does.
+// Only the conditions joined by AND pin the shard. It returns false if any tag of the sharding key isn't pinned.
+func PinShard(subject string, shardingKey *databasev1.ShardingKey, criteria *modelv1.Criteria, shardNum uint32) (common.ShardID, bool, error) {
+	if len(shardingKey.GetTagNames()) == 0 || criteria == nil {
+		return 0, false, nil
+	}
+	pinned := make(map[string]*modelv1.TagValue)
+	collectEqualValues(criteria, pinned)
+	keyValues := make(pbv1.EntityValues, len(shardingKey.GetTagNames())+1)
+	keyValues[0] = pbv1.EntityStrValue(subject)
+	for i, name := range shardingKey.GetTagNames() {
+		v, ok := pinned[name]
+		if !ok {
+			return 0, false, nil
+		}
+		keyValues[i+1] = v
+	}
+	key, err := keyValues.ToEntity()
+	if err != nil {
+		return 0, false, err
+	}
+	id, err := ShardID(key.Marshal(), shardNum)
+	if err != nil {
+		return 0, false, err
+	}
+	return common.ShardID(id), true, nil
+}
+
+func collectEqualValues(criteria *modelv1.Criteria, pinned map[string]*modelv1.TagValue) {
+	switch exp := criteria.GetExp().(type) {
+	case *modelv1.Criteria_Condition:
+		if exp.Condition.GetOp() == modelv1.Condition_BINARY_OP_EQ {
+			pinned[exp.Condition.GetName()] = exp.Condition.GetValue()
+		}
+	case *modelv1.Criteria_Le:
+		if exp.Le.GetOp() != modelv1.LogicalExpression_LOGICAL_OP_AND {
+			return
+		}
+		if exp.Le.GetLeft() != nil {
+			collectEqualValues(exp.Le.GetLeft(), pinned)
+		}
+		if exp.Le.GetRight() != nil {
+			collectEqualValues(exp.Le.GetRight(), pinned)
+		}
+	}
+}
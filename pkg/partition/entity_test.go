@@ -0,0 +1,80 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package partition
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+)
+
+func strValue(v string) *modelv1.TagValue {
+	return &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: v}}}
+}
+
+func condition(name string, op modelv1.Condition_BinaryOp, v string) *modelv1.Criteria {
+	return &modelv1.Criteria{Exp: &modelv1.Criteria_Condition{Condition: &modelv1.Condition{Name: name, Op: op, Value: strValue(v)}}}
+}
+
+func logicalExpression(op modelv1.LogicalExpression_LogicalOp, left, right *modelv1.Criteria) *modelv1.Criteria {
+	return &modelv1.Criteria{Exp: &modelv1.Criteria_Le{Le: &modelv1.LogicalExpression{Op: op, Left: left, Right: right}}}
+}
+
+func TestPinShard(t *testing.T) {
+	const shardNum = 16
+	families := []*databasev1.TagFamilySpec{{
+		Name: "searchable",
+		Tags: []*databasev1.TagSpec{
+			{Name: "service_id", Type: databasev1.TagType_TAG_TYPE_STRING},
+			{Name: "trace_id", Type: databasev1.TagType_TAG_TYPE_STRING},
+		},
+	}}
+	shardingKey := &databasev1.ShardingKey{TagNames: []string{"trace_id"}}
+	locator := NewShardingKeyLocator(families, shardingKey)
+	_, written, err := locator.Locate("sw", []*modelv1.TagFamilyForWrite{{Tags: []*modelv1.TagValue{strValue("svc"), strValue("t1")}}}, shardNum)
+	require.NoError(t, err)
+
+	traceID := condition("trace_id", modelv1.Condition_BINARY_OP_EQ, "t1")
+	service := condition("service_id", modelv1.Condition_BINARY_OP_EQ, "svc")
+	tests := []struct {
+		criteria *modelv1.Criteria
+		name     string
+		pinned   bool
+	}{
+		{name: "equality condition", criteria: traceID, pinned: true},
+		{name: "joined by and", criteria: logicalExpression(modelv1.LogicalExpression_LOGICAL_OP_AND, service, traceID), pinned: true},
+		{name: "joined by or", criteria: logicalExpression(modelv1.LogicalExpression_LOGICAL_OP_OR, service, traceID)},
+		{name: "not equal", criteria: condition("trace_id", modelv1.Condition_BINARY_OP_NE, "t1")},
+		{name: "other tags", criteria: service},
+		{name: "no criteria"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shardID, pinned, err := PinShard("sw", shardingKey, tt.criteria, shardNum)
+			require.NoError(t, err)
+			assert.Equal(t, tt.pinned, pinned)
+			if tt.pinned {
+				assert.Equal(t, written, shardID)
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package prompb decodes the Prometheus remote-write protocol.
+//
+// Only the subset of the protocol carrying series labels and float samples is decoded.
+// Metadata, exemplars and native histograms are skipped.
+package prompb
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/klauspost/compress/s2"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// MetricNameLabel is the label holding the metric name.
+const MetricNameLabel = "__name__"
+
+var errMalformed = errors.New("malformed remote-write payload")
+
+// WriteRequest is the payload sent by a Prometheus remote-write client.
+type WriteRequest struct {
+	Timeseries []TimeSeries
+}
+
+// TimeSeries is a set of samples sharing the same labels.
+type TimeSeries struct {
+	Labels  []Label
+	Samples []Sample
+}
+
+// Label is a name/value pair identifying a series.
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Sample is a value observed at a timestamp in milliseconds.
+type Sample struct {
+	Value     float64
+	Timestamp int64
+}
+
+// MetricName returns the value of the "__name__" label.
+func (ts *TimeSeries) MetricName() string {
+	for _, l := range ts.Labels {
+		if l.Name == MetricNameLabel {
+			return l.Value
+		}
+	}
+	return ""
+}
+
+// SeriesLabels returns the labels except for the metric name, sorted by name.
+func (ts *TimeSeries) SeriesLabels() []Label {
+	ll := make([]Label, 0, len(ts.Labels))
+	for _, l := range ts.Labels {
+		if l.Name == MetricNameLabel {
+			continue
+		}
+		ll = append(ll, l)
+	}
+	sort.Slice(ll, func(i, j int) bool {
+		return ll[i].Name < ll[j].Name
+	})
+	return ll
+}
+
+// SeriesKey returns the canonical text form of the series labels, e.g. {a="1",b="2"}.
+func (ts *TimeSeries) SeriesKey() string {
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i, l := range ts.SeriesLabels() {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(l.Name)
+		sb.WriteString(`="`)
+		sb.WriteString(l.Value)
+		sb.WriteByte('"')
+	}
+	sb.WriteByte('}')
+	return sb.String()
+}
+
+// DecodeSnappy decompresses a snappy block and decodes the WriteRequest.
+func DecodeSnappy(src []byte) (*WriteRequest, error) {
+	raw, err := s2.Decode(nil, src)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decompress remote-write payload")
+	}
+	return Decode(raw)
+}
+
+// Decode decodes the protobuf-encoded WriteRequest.
+func Decode(b []byte) (*WriteRequest, error) {
+	wr := &WriteRequest{}
+	err := walk(b, func(num protowire.Number, typ protowire.Type, v []byte, _ uint64) error {
+		if num != 1 || typ != protowire.BytesType {
+			return nil
+		}
+		ts, err := decodeTimeSeries(v)
+		if err != nil {
+			return err
+		}
+		wr.Timeseries = append(wr.Timeseries, ts)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return wr, nil
+}
+
+func decodeTimeSeries(b []byte) (TimeSeries, error) {
+	var ts TimeSeries
+	err := walk(b, func(num protowire.Number, typ protowire.Type, v []byte, _ uint64) error {
+		if typ != protowire.BytesType {
+			return nil
+		}
+		switch num {
+		case 1:
+			l, err := decodeLabel(v)
+			if err != nil {
+				return err
+			}
+			ts.Labels = append(ts.Labels, l)
+		case 2:
+			s, err := decodeSample(v)
+			if err != nil {
+				return err
+			}
+			ts.Samples = append(ts.Samples, s)
+		}
+		return nil
+	})
+	return ts, err
+}
+
+func decodeLabel(b []byte) (Label, error) {
+	var l Label
+	err := walk(b, func(num protowire.Number, typ protowire.Type, v []byte, _ uint64) error {
+		if typ != protowire.BytesType {
+			return nil
+		}
+		switch num {
+		case 1:
+			l.Name = string(v)
+		case 2:
+			l.Value = string(v)
+		}
+		return nil
+	})
+	return l, err
+}
+
+func decodeSample(b []byte) (Sample, error) {
+	var s Sample
+	err := walk(b, func(num protowire.Number, typ protowire.Type, _ []byte, n uint64) error {
+		switch {
+		case num == 1 && typ == protowire.Fixed64Type:
+			s.Value = math.Float64frombits(n)
+		case num == 2 && typ == protowire.VarintType:
+			s.Timestamp = int64(n)
+		}
+		return nil
+	})
+	return s, err
+}
+
+// walk iterates the fields of a message. Length-delimited values are passed as v,
+// numeric values are passed as n.
+func walk(b []byte, fn func(num protowire.Number, typ protowire.Type, v []byte, n uint64) error) error {
+	for len(b) > 0 {
+		num, typ, l := protowire.ConsumeTag(b)
+		if l < 0 {
+			return errors.WithMessage(errMalformed, protowire.ParseError(l).Error())
+		}
+		b = b[l:]
+		var v []byte
+		var n uint64
+		switch typ {
+		case protowire.BytesType:
+			v, l = protowire.ConsumeBytes(b)
+		case protowire.VarintType:
+			n, l = protowire.ConsumeVarint(b)
+		case protowire.Fixed64Type:
+			n, l = protowire.ConsumeFixed64(b)
+		case protowire.Fixed32Type:
+			var n32 uint32
+			n32, l = protowire.ConsumeFixed32(b)
+			n = uint64(n32)
+		default:
+			l = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if l < 0 {
+			return errors.WithMessage(errMalformed, protowire.ParseError(l).Error())
+		}
+		b = b[l:]
+		if err := fn(num, typ, v, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package prompb_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/klauspost/compress/s2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/apache/skywalking-banyandb/pkg/prompb"
+)
+
+func encodeLabel(name, value string) []byte {
+	var b []byte
+	b = protowire.AppendTag(b, 1, protowire.BytesType)
+	b = protowire.AppendString(b, name)
+	b = protowire.AppendTag(b, 2, protowire.BytesType)
+	return protowire.AppendString(b, value)
+}
+
+func encodeSample(value float64, ts int64) []byte {
+	var b []byte
+	b = protowire.AppendTag(b, 1, protowire.Fixed64Type)
+	b = protowire.AppendFixed64(b, math.Float64bits(value))
+	b = protowire.AppendTag(b, 2, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(ts))
+}
+
+func encodeWriteRequest(series ...prompb.TimeSeries) []byte {
+	var b []byte
+	for _, s := range series {
+		var ts []byte
+		for _, l := range s.Labels {
+			ts = protowire.AppendTag(ts, 1, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, encodeLabel(l.Name, l.Value))
+		}
+		for _, sp := range s.Samples {
+			ts = protowire.AppendTag(ts, 2, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, encodeSample(sp.Value, sp.Timestamp))
+		}
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendBytes(b, ts)
+	}
+	// metadata should be skipped
+	b = protowire.AppendTag(b, 3, protowire.BytesType)
+	b = protowire.AppendBytes(b, encodeLabel("ignored", "ignored"))
+	return b
+}
+
+func TestDecodeSnappy(t *testing.T) {
+	want := []prompb.TimeSeries{
+		{
+			Labels: []prompb.Label{
+				{Name: prompb.MetricNameLabel, Value: "http_requests_total"},
+				{Name: "job", Value: "api"},
+				{Name: "instance", Value: "10.0.0.1:9090"},
+			},
+			Samples: []prompb.Sample{{Value: 1, Timestamp: 1000}, {Value: 2.5, Timestamp: 2000}},
+		},
+		{
+			Labels:  []prompb.Label{{Name: prompb.MetricNameLabel, Value: "up"}},
+			Samples: []prompb.Sample{{Value: 1, Timestamp: 3000}},
+		},
+	}
+	wr, err := prompb.DecodeSnappy(s2.EncodeSnappy(nil, encodeWriteRequest(want...)))
+	require.NoError(t, err)
+	assert.Equal(t, want, wr.Timeseries)
+
+	assert.Equal(t, "http_requests_total", wr.Timeseries[0].MetricName())
+	assert.Equal(t, `{instance="10.0.0.1:9090",job="api"}`, wr.Timeseries[0].SeriesKey())
+	assert.Equal(t, "{}", wr.Timeseries[1].SeriesKey())
+}
+
+func TestDecodeMalformed(t *testing.T) {
+	payload := encodeWriteRequest(prompb.TimeSeries{Labels: []prompb.Label{{Name: "a", Value: "b"}}})
+	_, err := prompb.Decode(payload[:len(payload)-3])
+	assert.Error(t, err)
+
+	_, err = prompb.DecodeSnappy([]byte("not snappy"))
+	assert.Error(t, err)
+}
@@ -38,6 +38,18 @@ type Func[N Number] interface {
 	Reset()
 }
 
+// Overflower is implemented by the functions whose results may exceed the range of the number type.
+// The result is saturated to the range if it overflows.
+type Overflower interface {
+	Overflowed() bool
+}
+
+// Overflowed reports whether the result of the function exceeds the range of the number type.
+func Overflowed[N Number](f Func[N]) bool {
+	o, ok := f.(Overflower)
+	return ok && o.Overflowed()
+}
+
 // Number denotes the supported number types.
 type Number interface {
 	~int64 | ~float64
@@ -48,6 +60,10 @@ func NewFunc[N Number](af modelv1.AggregationFunction) (Func[N], error) {
 	var result Func[N]
 	switch af {
 	case modelv1.AggregationFunction_AGGREGATION_FUNCTION_MEAN:
+		if f, ok := any(&int64MeanFunc{}).(Func[N]); ok {
+			result = f
+			break
+		}
 		result = &meanFunc[N]{zero: zero[N]()}
 	case modelv1.AggregationFunction_AGGREGATION_FUNCTION_COUNT:
 		result = &countFunc[N]{zero: zero[N]()}
@@ -56,6 +72,10 @@ func NewFunc[N Number](af modelv1.AggregationFunction) (Func[N], error) {
 	case modelv1.AggregationFunction_AGGREGATION_FUNCTION_MIN:
 		result = &minFunc[N]{max: maxOf[N]()}
 	case modelv1.AggregationFunction_AGGREGATION_FUNCTION_SUM:
+		if f, ok := any(&int64SumFunc{}).(Func[N]); ok {
+			result = f
+			break
+		}
 		result = &sumFunc[N]{zero: zero[N]()}
 	default:
 		return nil, errors.WithMessagef(errUnknownFunc, "unknown function:%s", modelv1.AggregationFunction_name[int32(af)])
@@ -44,6 +44,33 @@ func (m *meanFunc[N]) Reset() {
 	m.count = m.zero
 }
 
+// int64MeanFunc accumulates the sum in 128 bits, whose mean always fits in int64.
+type int64MeanFunc struct {
+	sum   int128
+	count uint64
+}
+
+func (m *int64MeanFunc) In(val int64) {
+	m.sum.add(val)
+	m.count++
+}
+
+func (m int64MeanFunc) Val() int64 {
+	if m.count == 0 {
+		return 0
+	}
+	v := m.sum.quo(m.count)
+	if v < 1 {
+		return 1
+	}
+	return v
+}
+
+func (m *int64MeanFunc) Reset() {
+	m.sum = int128{}
+	m.count = 0
+}
+
 type countFunc[N Number] struct {
 	count N
 	zero  N
@@ -78,6 +105,28 @@ func (s *sumFunc[N]) Reset() {
 	s.sum = s.zero
 }
 
+// int64SumFunc accumulates the sum in 128 bits instead of wrapping around,
+// and saturates the result to the range of int64.
+type int64SumFunc struct {
+	sum int128
+}
+
+func (s *int64SumFunc) In(val int64) {
+	s.sum.add(val)
+}
+
+func (s int64SumFunc) Val() int64 {
+	return s.sum.int64()
+}
+
+func (s int64SumFunc) Overflowed() bool {
+	return !s.sum.isInt64()
+}
+
+func (s *int64SumFunc) Reset() {
+	s.sum = int128{}
+}
+
 type maxFunc[N Number] struct {
 	val N
 	min N
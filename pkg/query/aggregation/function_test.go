@@ -0,0 +1,100 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package aggregation
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+)
+
+func TestInt64Sum(t *testing.T) {
+	tests := []struct {
+		name       string
+		values     []int64
+		want       int64
+		overflowed bool
+	}{
+		{name: "small", values: []int64{1, -3, 5}, want: 3},
+		{name: "max", values: []int64{math.MaxInt64 - 1, 1}, want: math.MaxInt64},
+		{name: "min", values: []int64{math.MinInt64 + 1, -1}, want: math.MinInt64},
+		{name: "overflow", values: []int64{math.MaxInt64, 1}, want: math.MaxInt64, overflowed: true},
+		{name: "underflow", values: []int64{math.MinInt64, -1}, want: math.MinInt64, overflowed: true},
+		{name: "back to range", values: []int64{math.MaxInt64, math.MaxInt64, math.MinInt64, math.MinInt64, 10}, want: 8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewFunc[int64](modelv1.AggregationFunction_AGGREGATION_FUNCTION_SUM)
+			require.NoError(t, err)
+			for _, v := range tt.values {
+				f.In(v)
+			}
+			assert.Equal(t, tt.want, f.Val())
+			assert.Equal(t, tt.overflowed, Overflowed(f))
+			f.Reset()
+			assert.Equal(t, int64(0), f.Val())
+			assert.False(t, Overflowed(f))
+		})
+	}
+}
+
+func TestInt64Mean(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []int64
+		want   int64
+	}{
+		{name: "small", values: []int64{2, 4, 7}, want: 4},
+		{name: "large", values: []int64{math.MaxInt64, math.MaxInt64, math.MaxInt64 - 3}, want: math.MaxInt64 - 1},
+		{name: "negative", values: []int64{math.MinInt64, math.MinInt64}, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewFunc[int64](modelv1.AggregationFunction_AGGREGATION_FUNCTION_MEAN)
+			require.NoError(t, err)
+			for _, v := range tt.values {
+				f.In(v)
+			}
+			assert.Equal(t, tt.want, f.Val())
+			assert.False(t, Overflowed(f))
+		})
+	}
+}
+
+func TestInt128Quo(t *testing.T) {
+	var i int128
+	i.add(math.MinInt64)
+	i.add(math.MinInt64)
+	assert.Equal(t, int64(math.MinInt64), i.quo(2))
+	i.add(-1)
+	assert.Equal(t, int64(-6148914691236517205), i.quo(3))
+	assert.Equal(t, int64(math.MinInt64), i.quo(1))
+}
+
+func TestFloatSum(t *testing.T) {
+	f, err := NewFunc[float64](modelv1.AggregationFunction_AGGREGATION_FUNCTION_SUM)
+	require.NoError(t, err)
+	f.In(1.5)
+	f.In(2)
+	assert.InDelta(t, 3.5, f.Val(), 1e-9)
+	assert.False(t, Overflowed(f))
+}
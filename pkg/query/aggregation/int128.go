@@ -0,0 +1,80 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package aggregation
+
+import (
+	"math"
+	"math/bits"
+)
+
+// int128 is a two's complement 128-bit integer accumulating int64 values,
+// which doesn't wrap around until 2^64 values are added.
+type int128 struct {
+	hi int64
+	lo uint64
+}
+
+func (i *int128) add(v int64) {
+	var carry uint64
+	i.lo, carry = bits.Add64(i.lo, uint64(v), 0)
+	i.hi += int64(carry)
+	if v < 0 {
+		i.hi--
+	}
+}
+
+func (i int128) isInt64() bool {
+	if i.hi == 0 {
+		return i.lo <= math.MaxInt64
+	}
+	return i.hi == -1 && i.lo > math.MaxInt64
+}
+
+// int64 returns the value saturated to the range of int64.
+func (i int128) int64() int64 {
+	if i.isInt64() {
+		return int64(i.lo)
+	}
+	if i.hi < 0 {
+		return math.MinInt64
+	}
+	return math.MaxInt64
+}
+
+// quo returns the quotient of i divided by a positive n, truncated toward zero and saturated to the range of int64.
+func (i int128) quo(n uint64) int64 {
+	neg := i.hi < 0
+	hi, lo := uint64(i.hi), i.lo
+	if neg {
+		var borrow uint64
+		lo, borrow = bits.Sub64(0, lo, 0)
+		hi, _ = bits.Sub64(0, hi, borrow)
+	}
+	qhi, r := hi/n, hi%n
+	qlo, _ := bits.Div64(r, lo, n)
+	if qhi != 0 || qlo > math.MaxInt64+1 || (!neg && qlo > math.MaxInt64) {
+		if neg {
+			return math.MinInt64
+		}
+		return math.MaxInt64
+	}
+	if neg {
+		return -int64(qlo)
+	}
+	return int64(qlo)
+}
@@ -20,17 +20,23 @@ package executor
 
 import (
 	"context"
+	"time"
 
+	"google.golang.org/grpc/metadata"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
 	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
 	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
 	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
 	"github.com/apache/skywalking-banyandb/pkg/bus"
+	"github.com/apache/skywalking-banyandb/pkg/index"
 	"github.com/apache/skywalking-banyandb/pkg/query/model"
 )
 
 // StreamExecutionContext allows retrieving data through the stream module.
 type StreamExecutionContext interface {
 	Query(ctx context.Context, opts model.StreamQueryOptions) (model.StreamQueryResult, error)
+	Facets(ctx context.Context, opts model.StreamFacetOptions) ([]index.Facet, error)
 }
 
 // StreamExecutable allows querying in the stream schema.
@@ -63,6 +69,29 @@ type DistributedExecutionContext interface {
 	bus.Broadcaster
 	TimeRange() *modelv1.TimeRange
 	NodeSelectors() map[string][]string
+	// Federation returns nil if the queried groups aren't federated with any remote cluster.
+	Federation() Federation
+	// Timeout returns the timeout of the query and the shorter one of the data nodes,
+	// which lets the slow nodes return their partial results before the query stops waiting for them.
+	Timeout() (query, node time.Duration)
+	// ReportNode records the result of a data node answering the query.
+	ReportNode(status *commonv1.NodeStatus)
+}
+
+// FederatedHeader marks the queries fanned out by a liaison, which aren't fanned out again by the remote clusters.
+const FederatedHeader = "x-banyandb-federated"
+
+// IsFederated reports whether the incoming query is fanned out by the liaison of another cluster.
+func IsFederated(ctx context.Context) bool {
+	md, ok := metadata.FromIncomingContext(ctx)
+	return ok && len(md.Get(FederatedHeader)) > 0
+}
+
+// Federation fans out the queries to the remote clusters federated with the queried groups.
+// The responses of the failed clusters are absent, which are reported in the statuses of the clusters instead.
+type Federation interface {
+	QueryStream(ctx context.Context, req *streamv1.QueryRequest) []*streamv1.QueryResponse
+	QueryMeasure(ctx context.Context, req *measurev1.QueryRequest) []*measurev1.QueryResponse
 }
 
 // DistributedExecutionContextKey is the key of distributed execution context in context.Context.
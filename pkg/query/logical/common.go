@@ -30,6 +30,8 @@ var (
 	ErrUnsupportedConditionValue = errors.New("unsupported condition value type")
 	// ErrInvalidCriteriaType indicates an invalid criteria type.
 	ErrInvalidCriteriaType = errors.New("invalid criteria type")
+	// ErrTooManyValues indicates the list of a condition has too many values.
+	ErrTooManyValues = errors.New("too many values in the list")
 	// ErrInvalidLogicalExpression indicates an invalid logical expression.
 	ErrInvalidLogicalExpression = errors.New("invalid logical expression")
 	errTagNotDefined            = errors.New("tag is not defined")
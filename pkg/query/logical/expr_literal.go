@@ -387,6 +387,30 @@ func (s *strArrLiteral) Elements() []string {
 	return s.arr
 }
 
+// IsCaseInsensitive returns whether the inverted index rule ignores the case of the string tags.
+func IsCaseInsensitive(indexRule *databasev1.IndexRule) bool {
+	return indexRule.GetCaseInsensitive() && indexRule.GetType() == databasev1.IndexRule_TYPE_INVERTED
+}
+
+// FoldCase lowercases the string literals if the index rule ignores the case,
+// since the index holds the lowercase terms of such a rule.
+func FoldCase(expr LiteralExpr, indexRule *databasev1.IndexRule) LiteralExpr {
+	if !IsCaseInsensitive(indexRule) {
+		return expr
+	}
+	switch e := expr.(type) {
+	case *strLiteral:
+		return &strLiteral{strings.ToLower(e.string)}
+	case *strArrLiteral:
+		arr := make([]string, len(e.arr))
+		for i := range e.arr {
+			arr[i] = strings.ToLower(e.arr[i])
+		}
+		return newStrArrLiteral(arr)
+	}
+	return expr
+}
+
 var (
 	_               LiteralExpr    = (*nullLiteral)(nil)
 	_               ComparableExpr = (*nullLiteral)(nil)
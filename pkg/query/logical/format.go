@@ -31,3 +31,14 @@ func FormatTagRefs(sep string, exprGroup ...[]*TagRef) string {
 	}
 	return strings.Join(exprsStr, sep)
 }
+
+// maxFormattedElements is the maximum number of the list elements shown in a plan.
+const maxFormattedElements = 10
+
+// FormatElements outputs the elements of a list in a plan, which omits the ones beyond maxFormattedElements.
+func FormatElements(elements []string) []string {
+	if len(elements) <= maxFormattedElements {
+		return elements
+	}
+	return append(elements[:maxFormattedElements:maxFormattedElements], "...")
+}
@@ -60,6 +60,10 @@ func Analyze(criteria *measurev1.QueryRequest, metadata []*commonv1.Metadata, ss
 	}
 	groupByEntity := false
 	var groupByTags [][]*logical.Tag
+	bucket, err := newTimeBucket(criteria.GetGroupBy().GetTimeBucket())
+	if err != nil {
+		return nil, err
+	}
 	if criteria.GetGroupBy() != nil {
 		groupByProjectionTags := criteria.GetGroupBy().GetTagProjection()
 		groupByTags = make([][]*logical.Tag, len(groupByProjectionTags.GetTagFamilies()))
@@ -79,7 +83,6 @@ func Analyze(criteria *measurev1.QueryRequest, metadata []*commonv1.Metadata, ss
 		plan = parseFields(criteria, metadata[0], ecc[0], groupByEntity, tagProjection)
 		s = ss[0]
 	} else {
-		var err error
 		if s, err = mergeSchema(ss); err != nil {
 			return nil, err
 		}
@@ -100,7 +103,8 @@ func Analyze(criteria *measurev1.QueryRequest, metadata []*commonv1.Metadata, ss
 	pushedLimit := int(limitParameter + criteria.GetOffset())
 
 	if criteria.GetGroupBy() != nil {
-		plan = newUnresolvedGroupBy(plan, groupByTags, groupByEntity)
+		// the series sorted by the entity span several buckets, so they are grouped by the hash.
+		plan = newUnresolvedGroupBy(plan, groupByTags, groupByEntity && bucket == nil, bucket)
 		pushedLimit = math.MaxInt
 	}
 
@@ -108,7 +112,7 @@ func Analyze(criteria *measurev1.QueryRequest, metadata []*commonv1.Metadata, ss
 		plan = newUnresolvedAggregation(plan,
 			logical.NewField(criteria.GetAgg().GetFieldName()),
 			criteria.GetAgg().GetFunction(),
-			criteria.GetGroupBy() != nil,
+			criteria.GetGroupBy() != nil, bucket != nil,
 		)
 		pushedLimit = math.MaxInt
 	}
@@ -135,6 +139,10 @@ func Analyze(criteria *measurev1.QueryRequest, metadata []*commonv1.Metadata, ss
 // DistributedAnalyze converts logical expressions to executable operation tree represented by Plan.
 func DistributedAnalyze(criteria *measurev1.QueryRequest, ss []logical.Schema) (logical.Plan, error) {
 	var groupByTags [][]*logical.Tag
+	bucket, err := newTimeBucket(criteria.GetGroupBy().GetTimeBucket())
+	if err != nil {
+		return nil, err
+	}
 	if criteria.GetGroupBy() != nil {
 		groupByProjectionTags := criteria.GetGroupBy().GetTagProjection()
 		groupByTags = make([][]*logical.Tag, len(groupByProjectionTags.GetTagFamilies()))
@@ -154,7 +162,7 @@ func DistributedAnalyze(criteria *measurev1.QueryRequest, ss []logical.Schema) (
 	pushedLimit := int(limitParameter + criteria.GetOffset())
 
 	if criteria.GetGroupBy() != nil {
-		plan = newUnresolvedGroupBy(plan, groupByTags, false)
+		plan = newUnresolvedGroupBy(plan, groupByTags, false, bucket)
 		pushedLimit = math.MaxInt
 	}
 
@@ -162,7 +170,7 @@ func DistributedAnalyze(criteria *measurev1.QueryRequest, ss []logical.Schema) (
 		plan = newUnresolvedAggregation(plan,
 			logical.NewField(criteria.GetAgg().GetFieldName()),
 			criteria.GetAgg().GetFunction(),
-			criteria.GetGroupBy() != nil,
+			criteria.GetGroupBy() != nil, bucket != nil,
 		)
 		pushedLimit = math.MaxInt
 	}
@@ -174,7 +182,6 @@ func DistributedAnalyze(criteria *measurev1.QueryRequest, ss []logical.Schema) (
 	plan = limit(plan, criteria.GetOffset(), limitParameter)
 
 	var s logical.Schema
-	var err error
 	if len(ss) == 1 {
 		s = ss[0]
 	} else {
@@ -206,5 +213,5 @@ func parseFields(criteria *measurev1.QueryRequest, metadata *commonv1.Metadata,
 	}
 	timeRange := criteria.GetTimeRange()
 	return indexScan(timeRange.GetBegin().AsTime(), timeRange.GetEnd().AsTime(), metadata,
-		tagProjection, projFields, groupByEntity, criteria.GetCriteria(), ec)
+		tagProjection, projFields, groupByEntity, criteria.GetLatest(), criteria.GetCriteria(), ec)
 }
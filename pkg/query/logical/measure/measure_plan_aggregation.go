@@ -43,14 +43,18 @@ type unresolvedAggregation struct {
 	aggregationField *logical.Field
 	aggrFunc         modelv1.AggregationFunction
 	isGroup          bool
+	bucketed         bool
 }
 
-func newUnresolvedAggregation(input logical.UnresolvedPlan, aggrField *logical.Field, aggrFunc modelv1.AggregationFunction, isGroup bool) logical.UnresolvedPlan {
+func newUnresolvedAggregation(input logical.UnresolvedPlan, aggrField *logical.Field, aggrFunc modelv1.AggregationFunction,
+	isGroup, bucketed bool,
+) logical.UnresolvedPlan {
 	return &unresolvedAggregation{
 		unresolvedInput:  input,
 		aggrFunc:         aggrFunc,
 		aggregationField: aggrField,
 		isGroup:          isGroup,
+		bucketed:         bucketed,
 	}
 }
 
@@ -86,6 +90,7 @@ type aggregationPlan[N aggregation.Number] struct {
 	aggrFunc            aggregation.Func[N]
 	aggrType            modelv1.AggregationFunction
 	isGroup             bool
+	bucketed            bool
 }
 
 func newAggregationPlan[N aggregation.Number](gba *unresolvedAggregation, prevPlan logical.Plan,
@@ -104,6 +109,7 @@ func newAggregationPlan[N aggregation.Number](gba *unresolvedAggregation, prevPl
 		aggrFunc:            aggrFunc,
 		aggregationFieldRef: fieldRef,
 		isGroup:             gba.isGroup,
+		bucketed:            gba.bucketed,
 	}, nil
 }
 
@@ -128,7 +134,7 @@ func (g *aggregationPlan[N]) Execute(ec context.Context) (executor.MIterator, er
 		return nil, err
 	}
 	if g.isGroup {
-		return newAggGroupMIterator(iter, g.aggregationFieldRef, g.aggrFunc), nil
+		return newAggGroupMIterator(iter, g.aggregationFieldRef, g.aggrFunc, g.bucketed), nil
 	}
 	return newAggAllIterator(iter, g.aggregationFieldRef, g.aggrFunc), nil
 }
@@ -138,18 +144,21 @@ type aggGroupIterator[N aggregation.Number] struct {
 	aggregationFieldRef *logical.FieldRef
 	aggrFunc            aggregation.Func[N]
 
-	err error
+	err      error
+	bucketed bool
 }
 
 func newAggGroupMIterator[N aggregation.Number](
 	prev executor.MIterator,
 	aggregationFieldRef *logical.FieldRef,
 	aggrFunc aggregation.Func[N],
+	bucketed bool,
 ) executor.MIterator {
 	return &aggGroupIterator[N]{
 		prev:                prev,
 		aggregationFieldRef: aggregationFieldRef,
 		aggrFunc:            aggrFunc,
+		bucketed:            bucketed,
 	}
 }
 
@@ -167,7 +176,10 @@ func (ami *aggGroupIterator[N]) Current() []*measurev1.DataPoint {
 	ami.aggrFunc.Reset()
 	group := ami.prev.Current()
 	var resultDp *measurev1.DataPoint
+	// the data points pre-aggregated by the data nodes may have overflowed.
+	var overflowed bool
 	for _, dp := range group {
+		overflowed = overflowed || dp.Overflowed
 		value := dp.GetFields()[ami.aggregationFieldRef.Spec.FieldIdx].
 			GetValue()
 		v, err := aggregation.FromFieldValue[N](value)
@@ -182,6 +194,10 @@ func (ami *aggGroupIterator[N]) Current() []*measurev1.DataPoint {
 		resultDp = &measurev1.DataPoint{
 			TagFamilies: dp.TagFamilies,
 		}
+		if ami.bucketed {
+			// the data points of a bucketed group share the aligned start of the bucket.
+			resultDp.Timestamp = dp.Timestamp
+		}
 	}
 	if resultDp == nil {
 		return nil
@@ -197,6 +213,7 @@ func (ami *aggGroupIterator[N]) Current() []*measurev1.DataPoint {
 			Value: val,
 		},
 	}
+	resultDp.Overflowed = overflowed || aggregation.Overflowed(ami.aggrFunc)
 	return []*measurev1.DataPoint{resultDp}
 }
 
@@ -230,9 +247,11 @@ func (ami *aggAllIterator[N]) Next() bool {
 		return false
 	}
 	var resultDp *measurev1.DataPoint
+	var overflowed bool
 	for ami.prev.Next() {
 		group := ami.prev.Current()
 		for _, dp := range group {
+			overflowed = overflowed || dp.Overflowed
 			value := dp.GetFields()[ami.aggregationFieldRef.Spec.FieldIdx].
 				GetValue()
 			v, err := aggregation.FromFieldValue[N](value)
@@ -263,6 +282,7 @@ func (ami *aggAllIterator[N]) Next() bool {
 			Value: val,
 		},
 	}
+	resultDp.Overflowed = overflowed || aggregation.Overflowed(ami.aggrFunc)
 	ami.result = resultDp
 	return true
 }
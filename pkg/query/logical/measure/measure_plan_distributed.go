@@ -22,12 +22,13 @@ import (
 	"container/list"
 	"context"
 	"fmt"
-	"time"
 
 	"go.uber.org/multierr"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
 
 	"github.com/apache/skywalking-banyandb/api/data"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
 	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
 	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
 	"github.com/apache/skywalking-banyandb/pkg/bus"
@@ -38,10 +39,9 @@ import (
 	"github.com/apache/skywalking-banyandb/pkg/query"
 	"github.com/apache/skywalking-banyandb/pkg/query/executor"
 	"github.com/apache/skywalking-banyandb/pkg/query/logical"
+	"github.com/apache/skywalking-banyandb/pkg/tracecontext"
 )
 
-const defaultQueryTimeout = 15 * time.Second
-
 var _ logical.UnresolvedPlan = (*unresolvedDistributed)(nil)
 
 type unresolvedDistributed struct {
@@ -89,6 +89,7 @@ func (ud *unresolvedDistributed) Analyze(s logical.Schema) (logical.Plan, error)
 		Criteria:        ud.originalQuery.Criteria,
 		Limit:           limit + ud.originalQuery.Offset,
 		OrderBy:         ud.originalQuery.OrderBy,
+		Latest:          ud.originalQuery.Latest,
 	}
 	// push down groupBy, agg and top to data node and rewrite agg result to raw data
 	if ud.originalQuery.Agg != nil && ud.originalQuery.Top != nil {
@@ -187,34 +188,60 @@ func (t *distributedPlan) Execute(ctx context.Context) (mi executor.MIterator, e
 			}
 		}()
 	}
-	ff, err := dctx.Broadcast(defaultQueryTimeout, data.TopicMeasureQuery,
-		bus.NewMessageWithNodeSelectors(bus.MessageID(dctx.TimeRange().Begin.Nanos), dctx.NodeSelectors(), dctx.TimeRange(), queryRequest))
+	queryTimeout, nodeTimeout := dctx.Timeout()
+	queryRequest.Timeout = durationpb.New(nodeTimeout)
+	tc, _ := tracecontext.FromContext(ctx)
+	ff, err := dctx.Broadcast(queryTimeout, data.TopicMeasureQuery,
+		bus.NewMessageWithNodeSelectors(bus.MessageID(dctx.TimeRange().Begin.Nanos), dctx.NodeSelectors(), dctx.TimeRange(), queryRequest).
+			WithTraceContext(tc))
 	if err != nil {
 		return nil, err
 	}
+	var allErr error
+	var answered int
 	var see []sort.Iterator[*comparableDataPoint]
 	for _, f := range ff {
-		if m, getErr := f.Get(); getErr != nil {
-			err = multierr.Append(err, getErr)
-		} else {
-			d := m.Data()
-			if d == nil {
-				continue
-			}
-			resp := d.(*measurev1.QueryResponse)
+		m, getErr := f.Get()
+		if getErr != nil {
+			allErr = multierr.Append(allErr, getErr)
+			dctx.ReportNode(&commonv1.NodeStatus{Name: m.Node(), Reason: getErr.Error()})
+			continue
+		}
+		answered++
+		d := m.Data()
+		if d == nil {
+			dctx.ReportNode(&commonv1.NodeStatus{Name: m.Node()})
+			continue
+		}
+		resp := d.(*measurev1.QueryResponse)
+		dctx.ReportNode(&commonv1.NodeStatus{Name: m.Node(), Truncated: resp.Truncated, Reason: resp.TruncatedReason})
+		query.ReportSeriesOverflow(ctx, resp.SeriesOverflow)
+		if span != nil {
+			span.AddSubTrace(resp.Trace)
+		}
+		see = append(see,
+			newSortableElements(resp.DataPoints,
+				t.sortByTime, t.sortTagSpec))
+	}
+	// the failed nodes are reported by the coverage of the query unless none of the nodes answers.
+	if answered == 0 && allErr != nil {
+		return nil, allErr
+	}
+	if fed := dctx.Federation(); fed != nil {
+		// the remote clusters get the same request as the data nodes, so the aggregation and TopN are pushed down
+		// to them and they return the raw data points of the ranked groups, which are aggregated together with the local ones.
+		for _, resp := range fed.QueryMeasure(ctx, queryRequest) {
 			if span != nil {
 				span.AddSubTrace(resp.Trace)
 			}
-			see = append(see,
-				newSortableElements(resp.DataPoints,
-					t.sortByTime, t.sortTagSpec))
+			see = append(see, newSortableElements(resp.DataPoints, t.sortByTime, t.sortTagSpec))
 		}
 	}
 	smi := &sortedMIterator{
 		Iterator: sort.NewItemIter(see, t.desc),
 	}
 	smi.init()
-	return smi, err
+	return smi, nil
 }
 
 func (t *distributedPlan) String() string {
@@ -18,14 +18,23 @@
 package measure
 
 import (
+	"context"
 	"slices"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"google.golang.org/protobuf/testing/protocmp"
 	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
 	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+	"github.com/apache/skywalking-banyandb/pkg/query/executor"
 )
 
 type mockIterator struct {
@@ -158,3 +167,92 @@ func TestSortedMIterator(t *testing.T) {
 		})
 	}
 }
+
+type fakeFuture struct {
+	msg bus.Message
+}
+
+func (f fakeFuture) Get() (bus.Message, error) {
+	return f.msg, nil
+}
+
+func (f fakeFuture) GetAll() ([]bus.Message, error) {
+	return []bus.Message{f.msg}, nil
+}
+
+type fakeFederation struct {
+	req    *measurev1.QueryRequest
+	remote []*measurev1.DataPoint
+}
+
+func (f *fakeFederation) QueryStream(context.Context, *streamv1.QueryRequest) []*streamv1.QueryResponse {
+	return nil
+}
+
+func (f *fakeFederation) QueryMeasure(_ context.Context, req *measurev1.QueryRequest) []*measurev1.QueryResponse {
+	f.req = req
+	return []*measurev1.QueryResponse{{DataPoints: f.remote}}
+}
+
+type fakeDistributedContext struct {
+	fed   *fakeFederation
+	req   *measurev1.QueryRequest
+	local []*measurev1.DataPoint
+}
+
+func (c *fakeDistributedContext) Broadcast(_ time.Duration, _ bus.Topic, message bus.Message) ([]bus.Future, error) {
+	c.req = message.Data().(*measurev1.QueryRequest)
+	return []bus.Future{fakeFuture{msg: bus.NewMessageWithNode(1, "data-node", &measurev1.QueryResponse{DataPoints: c.local})}}, nil
+}
+
+func (c *fakeDistributedContext) TimeRange() *modelv1.TimeRange {
+	return &modelv1.TimeRange{Begin: timestamppb.New(time.Unix(0, 0)), End: timestamppb.New(time.Unix(100, 0))}
+}
+
+func (c *fakeDistributedContext) NodeSelectors() map[string][]string {
+	return nil
+}
+
+func (c *fakeDistributedContext) Federation() executor.Federation {
+	return c.fed
+}
+
+func (c *fakeDistributedContext) Timeout() (time.Duration, time.Duration) {
+	return time.Second, time.Second
+}
+
+func (c *fakeDistributedContext) ReportNode(*commonv1.NodeStatus) {}
+
+func TestDistributedPlanMergesRemoteClusters(t *testing.T) {
+	dp := func(sid uint64, sec int64) *measurev1.DataPoint {
+		return &measurev1.DataPoint{Sid: sid, Timestamp: &timestamppb.Timestamp{Seconds: sec}}
+	}
+	dctx := &fakeDistributedContext{
+		local: []*measurev1.DataPoint{dp(1, 1), dp(1, 3)},
+		fed:   &fakeFederation{remote: []*measurev1.DataPoint{dp(2, 2), dp(2, 4)}},
+	}
+	plan := &distributedPlan{
+		queryTemplate: &measurev1.QueryRequest{
+			Name:                 "service_cpm",
+			Groups:               []string{"sw"},
+			Limit:                10,
+			Agg:                  &measurev1.QueryRequest_Aggregation{Function: modelv1.AggregationFunction_AGGREGATION_FUNCTION_SUM, FieldName: "value"},
+			Top:                  &measurev1.QueryRequest_Top{Number: 5, FieldName: "value"},
+			RewriteAggTopNResult: true,
+		},
+		sortByTime: true,
+	}
+	iter, err := plan.Execute(executor.WithDistributedExecutionContext(context.Background(), dctx))
+	require.NoError(t, err)
+	var got []int64
+	for iter.Next() {
+		got = append(got, iter.Current()[0].Timestamp.Seconds)
+	}
+	require.NoError(t, iter.Close())
+	assert.Equal(t, []int64{1, 2, 3, 4}, got)
+
+	require.NotNil(t, dctx.fed.req)
+	if diff := cmp.Diff(dctx.req, dctx.fed.req, protocmp.Transform()); diff != "" {
+		t.Errorf("the remote clusters should get the request pushed down to the data nodes (-local +remote):\n%s", diff)
+	}
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/cespare/xxhash/v2"
 	"github.com/pkg/errors"
 	"go.uber.org/multierr"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
 	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
@@ -42,14 +43,16 @@ type unresolvedGroup struct {
 	unresolvedInput logical.UnresolvedPlan
 	// groupBy should be a subset of tag projection
 	groupBy       [][]*logical.Tag
+	bucket        *timeBucket
 	groupByEntity bool
 }
 
-func newUnresolvedGroupBy(input logical.UnresolvedPlan, groupBy [][]*logical.Tag, groupByEntity bool) logical.UnresolvedPlan {
+func newUnresolvedGroupBy(input logical.UnresolvedPlan, groupBy [][]*logical.Tag, groupByEntity bool, bucket *timeBucket) logical.UnresolvedPlan {
 	return &unresolvedGroup{
 		unresolvedInput: input,
 		groupBy:         groupBy,
 		groupByEntity:   groupByEntity,
+		bucket:          bucket,
 	}
 }
 
@@ -72,6 +75,7 @@ func (gba *unresolvedGroup) Analyze(measureSchema logical.Schema) (logical.Plan,
 		schema:          schema,
 		groupByTagsRefs: groupByTagRefs,
 		groupByEntity:   gba.groupByEntity,
+		bucket:          gba.bucket,
 	}, nil
 }
 
@@ -79,6 +83,7 @@ type groupBy struct {
 	*logical.Parent
 	schema          logical.Schema
 	groupByTagsRefs [][]*logical.TagRef
+	bucket          *timeBucket
 	groupByEntity   bool
 }
 
@@ -89,6 +94,11 @@ func (g *groupBy) String() string {
 	} else {
 		method = "hash"
 	}
+	if g.bucket != nil {
+		return fmt.Sprintf("%s GroupBy: groupBy=%s, method=%s, bucket=%s",
+			g.Input,
+			logical.FormatTagRefs(", ", g.groupByTagsRefs...), method, g.bucket)
+	}
 	return fmt.Sprintf("%s GroupBy: groupBy=%s, method=%s",
 		g.Input,
 		logical.FormatTagRefs(", ", g.groupByTagsRefs...), method)
@@ -131,7 +141,10 @@ func (g *groupBy) hash(ec context.Context) (mit executor.MIterator, err error) {
 	for iter.Next() {
 		dataPoints := iter.Current()
 		for _, dp := range dataPoints {
-			key, innerErr := formatGroupByKey(dp, g.groupByTagsRefs)
+			if g.bucket != nil {
+				dp.Timestamp = timestamppb.New(g.bucket.align(dp.GetTimestamp().AsTime()))
+			}
+			key, innerErr := formatGroupByKey(dp, g.groupByTagsRefs, g.bucket != nil)
 			if innerErr != nil {
 				return nil, innerErr
 			}
@@ -150,8 +163,13 @@ func (g *groupBy) hash(ec context.Context) (mit executor.MIterator, err error) {
 	return newGroupIterator(groupMap, groupLst), nil
 }
 
-func formatGroupByKey(point *measurev1.DataPoint, groupByTagsRefs [][]*logical.TagRef) (uint64, error) {
+func formatGroupByKey(point *measurev1.DataPoint, groupByTagsRefs [][]*logical.TagRef, bucketed bool) (uint64, error) {
 	hash := xxhash.New()
+	if bucketed {
+		if _, err := hash.Write(convert.Int64ToBytes(point.GetTimestamp().AsTime().UnixNano())); err != nil {
+			return 0, err
+		}
+	}
 	for _, tagFamilyRef := range groupByTagsRefs {
 		for _, tagRef := range tagFamilyRef {
 			if tagRef.Spec.TagFamilyIdx >= len(point.GetTagFamilies()) {
@@ -247,7 +265,7 @@ func (gmi *groupSortIterator) Next() bool {
 			gmi.closed = true
 			return len(gmi.current) > 0
 		}
-		k, err := formatGroupByKey(dp, gmi.groupByTagsRefs)
+		k, err := formatGroupByKey(dp, gmi.groupByTagsRefs, false)
 		if err != nil {
 			gmi.closed = true
 			gmi.err = err
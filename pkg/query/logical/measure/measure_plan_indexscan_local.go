@@ -34,6 +34,7 @@ import (
 	"github.com/apache/skywalking-banyandb/pkg/query"
 	"github.com/apache/skywalking-banyandb/pkg/query/executor"
 	"github.com/apache/skywalking-banyandb/pkg/query/logical"
+	logicalstream "github.com/apache/skywalking-banyandb/pkg/query/logical/stream"
 	"github.com/apache/skywalking-banyandb/pkg/query/model"
 	"github.com/apache/skywalking-banyandb/pkg/timestamp"
 )
@@ -49,6 +50,7 @@ type unresolvedIndexScan struct {
 	projectionTags   [][]*logical.Tag
 	projectionFields []*logical.Field
 	groupByEntity    bool
+	latest           bool
 }
 
 func (uis *unresolvedIndexScan) Analyze(s logical.Schema) (logical.Plan, error) {
@@ -98,6 +100,7 @@ func (uis *unresolvedIndexScan) Analyze(s logical.Schema) (logical.Plan, error)
 			metadata:             uis.metadata,
 			query:                query,
 			groupByEntity:        uis.groupByEntity,
+			latest:               uis.latest,
 			uis:                  uis,
 			l:                    logger.GetLogger("query", "measure", uis.metadata.Group, uis.metadata.Name, "local-index"),
 			ec:                   uis.ec,
@@ -116,6 +119,11 @@ func (uis *unresolvedIndexScan) Analyze(s logical.Schema) (logical.Plan, error)
 	if err != nil {
 		return nil, err
 	}
+	// the blocks aren't pruned if the conditions can't be checked against the statistics
+	skippingFilter, err := logicalstream.BuildSkippingFilter(uis.criteria, s, entityMap, entity)
+	if err != nil {
+		skippingFilter = nil
+	}
 
 	return &localIndexScan{
 		timeRange:            tr,
@@ -126,8 +134,10 @@ func (uis *unresolvedIndexScan) Analyze(s logical.Schema) (logical.Plan, error)
 		projectionFieldsRefs: projFieldRefs,
 		metadata:             uis.metadata,
 		query:                query,
+		skippingFilter:       skippingFilter,
 		entities:             entities,
 		groupByEntity:        uis.groupByEntity,
+		latest:               uis.latest,
 		uis:                  uis,
 		l:                    logger.GetLogger("query", "measure", uis.metadata.Group, uis.metadata.Name, "local-index"),
 		ec:                   uis.ec,
@@ -143,6 +153,7 @@ type localIndexScan struct {
 	ec                   executor.MeasureExecutionContext
 	schema               logical.Schema
 	query                index.Query
+	skippingFilter       index.Filter
 	uis                  *unresolvedIndexScan
 	order                *logical.OrderBy
 	metadata             *commonv1.Metadata
@@ -154,6 +165,7 @@ type localIndexScan struct {
 	projectionFields     []string
 	projectionTags       []model.TagProjection
 	groupByEntity        bool
+	latest               bool
 }
 
 func (i *localIndexScan) Sort(order *logical.OrderBy) {
@@ -187,9 +199,11 @@ func (i *localIndexScan) Execute(ctx context.Context) (mit executor.MIterator, e
 		TimeRange:       &i.timeRange,
 		Entities:        i.entities,
 		Query:           i.query,
+		SkippingFilter:  i.skippingFilter,
 		Order:           orderBy,
 		TagProjection:   i.projectionTags,
 		FieldProjection: i.projectionFields,
+		Latest:          i.latest,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to query measure: %w", err)
@@ -200,9 +214,9 @@ func (i *localIndexScan) Execute(ctx context.Context) (mit executor.MIterator, e
 }
 
 func (i *localIndexScan) String() string {
-	return fmt.Sprintf("IndexScan: startTime=%d,endTime=%d,Metadata{group=%s,name=%s},conditions=%s; projection=%s; order=%s;",
+	return fmt.Sprintf("IndexScan: startTime=%d,endTime=%d,Metadata{group=%s,name=%s},conditions=%s; projection=%s; order=%s; latest=%t;",
 		i.timeRange.Start.Unix(), i.timeRange.End.Unix(), i.metadata.GetGroup(), i.metadata.GetName(),
-		i.query, logical.FormatTagRefs(", ", i.projectionTagsRefs...), i.order)
+		i.query, logical.FormatTagRefs(", ", i.projectionTagsRefs...), i.order, i.latest)
 }
 
 func (i *localIndexScan) Children() []logical.Plan {
@@ -217,7 +231,7 @@ func (i *localIndexScan) Schema() logical.Schema {
 }
 
 func indexScan(startTime, endTime time.Time, metadata *commonv1.Metadata, projectionTags [][]*logical.Tag,
-	projectionFields []*logical.Field, groupByEntity bool, criteria *modelv1.Criteria, ec executor.MeasureExecutionContext,
+	projectionFields []*logical.Field, groupByEntity, latest bool, criteria *modelv1.Criteria, ec executor.MeasureExecutionContext,
 ) logical.UnresolvedPlan {
 	return &unresolvedIndexScan{
 		startTime:        startTime,
@@ -226,6 +240,7 @@ func indexScan(startTime, endTime time.Time, metadata *commonv1.Metadata, projec
 		projectionTags:   projectionTags,
 		projectionFields: projectionFields,
 		groupByEntity:    groupByEntity,
+		latest:           latest,
 		criteria:         criteria,
 		ec:               ec,
 	}
@@ -0,0 +1,140 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package measure
+
+import (
+	"fmt"
+	"slices"
+
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+)
+
+// RewriteAggTopNRequest returns the request querying the raw data points of the groups ranked in the aggregated results,
+// which are aggregated again together with the raw data points of the other nodes or clusters.
+func RewriteAggTopNRequest(req *measurev1.QueryRequest, aggregated []*measurev1.DataPoint) (*measurev1.QueryRequest, error) {
+	groupByTags := make([]string, 0)
+	if req.GetGroupBy() != nil {
+		for _, tagFamily := range req.GetGroupBy().GetTagProjection().GetTagFamilies() {
+			groupByTags = append(groupByTags, tagFamily.GetTags()...)
+		}
+	}
+	tagValueMap := make(map[string][]*modelv1.TagValue)
+	for _, dp := range aggregated {
+		for _, tagFamily := range dp.GetTagFamilies() {
+			for _, tag := range tagFamily.GetTags() {
+				tagName := tag.GetKey()
+				if len(groupByTags) == 0 || slices.Contains(groupByTags, tagName) {
+					tagValueMap[tagName] = append(tagValueMap[tagName], tag.GetValue())
+				}
+			}
+		}
+	}
+	criteria, err := rewriteCriteria(tagValueMap)
+	if err != nil {
+		return nil, err
+	}
+	return &measurev1.QueryRequest{
+		Groups:          req.Groups,
+		Name:            req.Name,
+		TimeRange:       req.TimeRange,
+		Criteria:        criteria,
+		TagProjection:   req.TagProjection,
+		FieldProjection: req.FieldProjection,
+		Timeout:         req.Timeout,
+	}, nil
+}
+
+func rewriteCriteria(tagValueMap map[string][]*modelv1.TagValue) (*modelv1.Criteria, error) {
+	var tagConditions []*modelv1.Condition
+	for tagName, tagValues := range tagValueMap {
+		if len(tagValues) == 0 {
+			continue
+		}
+		switch tagValues[0].GetValue().(type) {
+		case *modelv1.TagValue_Str:
+			valueSet := make(map[string]bool)
+			for _, value := range tagValues {
+				if strVal, ok := value.GetValue().(*modelv1.TagValue_Str); ok {
+					valueSet[strVal.Str.GetValue()] = true
+				}
+			}
+			values := make([]string, 0, len(valueSet))
+			for value := range valueSet {
+				values = append(values, value)
+			}
+			condition := &modelv1.Condition{
+				Name: tagName,
+				Op:   modelv1.Condition_BINARY_OP_IN,
+				Value: &modelv1.TagValue{
+					Value: &modelv1.TagValue_StrArray{
+						StrArray: &modelv1.StrArray{
+							Value: values,
+						},
+					},
+				},
+			}
+			tagConditions = append(tagConditions, condition)
+		case *modelv1.TagValue_Int:
+			valueSet := make(map[int64]bool)
+			for _, value := range tagValues {
+				if intVal, ok := value.GetValue().(*modelv1.TagValue_Int); ok {
+					valueSet[intVal.Int.GetValue()] = true
+				}
+			}
+			values := make([]int64, 0, len(valueSet))
+			for value := range valueSet {
+				values = append(values, value)
+			}
+			condition := &modelv1.Condition{
+				Name: tagName,
+				Op:   modelv1.Condition_BINARY_OP_IN,
+				Value: &modelv1.TagValue{
+					Value: &modelv1.TagValue_IntArray{
+						IntArray: &modelv1.IntArray{
+							Value: values,
+						},
+					},
+				},
+			}
+			tagConditions = append(tagConditions, condition)
+		default:
+			return nil, fmt.Errorf("unsupported tag value type: %T", tagValues[0].GetValue())
+		}
+	}
+	return buildCriteriaTree(tagConditions), nil
+}
+
+func buildCriteriaTree(conditions []*modelv1.Condition) *modelv1.Criteria {
+	if len(conditions) == 0 {
+		return nil
+	}
+	return &modelv1.Criteria{
+		Exp: &modelv1.Criteria_Le{
+			Le: &modelv1.LogicalExpression{
+				Op: modelv1.LogicalExpression_LOGICAL_OP_AND,
+				Left: &modelv1.Criteria{
+					Exp: &modelv1.Criteria_Condition{
+						Condition: conditions[0],
+					},
+				},
+				Right: buildCriteriaTree(conditions[1:]),
+			},
+		},
+	}
+}
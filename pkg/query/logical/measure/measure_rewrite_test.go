@@ -0,0 +1,60 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package measure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+)
+
+func TestRewriteAggTopNRequest(t *testing.T) {
+	tag := func(key, value string) *modelv1.Tag {
+		return &modelv1.Tag{Key: key, Value: &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: value}}}}
+	}
+	req := &measurev1.QueryRequest{
+		Name:   "service_cpm",
+		Groups: []string{"sw"},
+		GroupBy: &measurev1.QueryRequest_GroupBy{
+			TagProjection: &modelv1.TagProjection{TagFamilies: []*modelv1.TagProjection_TagFamily{{Name: "default", Tags: []string{"svc"}}}},
+		},
+		Agg: &measurev1.QueryRequest_Aggregation{Function: modelv1.AggregationFunction_AGGREGATION_FUNCTION_SUM, FieldName: "value"},
+		Top: &measurev1.QueryRequest_Top{Number: 2, FieldName: "value"},
+	}
+	aggregated := []*measurev1.DataPoint{
+		{TagFamilies: []*modelv1.TagFamily{{Name: "default", Tags: []*modelv1.Tag{tag("svc", "a"), tag("instance", "i1")}}}},
+		{TagFamilies: []*modelv1.TagFamily{{Name: "default", Tags: []*modelv1.Tag{tag("svc", "b"), tag("instance", "i2")}}}},
+	}
+	raw, err := RewriteAggTopNRequest(req, aggregated)
+	require.NoError(t, err)
+	assert.Equal(t, req.Name, raw.Name)
+	assert.Equal(t, req.Groups, raw.Groups)
+	assert.Nil(t, raw.Agg)
+	assert.Nil(t, raw.Top)
+	assert.False(t, raw.RewriteAggTopNResult)
+	cond := raw.GetCriteria().GetLe().GetLeft().GetCondition()
+	require.NotNil(t, cond)
+	assert.Equal(t, "svc", cond.Name)
+	assert.Equal(t, modelv1.Condition_BINARY_OP_IN, cond.Op)
+	assert.ElementsMatch(t, []string{"a", "b"}, cond.GetValue().GetStrArray().GetValue())
+	assert.Nil(t, raw.GetCriteria().GetLe().GetRight(), "the tags out of the group by aren't filtered")
+}
@@ -0,0 +1,89 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package measure
+
+import (
+	"fmt"
+	"time"
+	// the time zones are embedded, since the hosts might not have the tz database.
+	_ "time/tzdata"
+
+	"github.com/pkg/errors"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+)
+
+var errInvalidTimeBucket = errors.New("invalid time bucket")
+
+// timeBucket aligns the timestamps to the starts of the buckets in a time zone.
+type timeBucket struct {
+	loc  *time.Location
+	unit commonv1.IntervalRule_Unit
+	num  int
+}
+
+func newTimeBucket(tb *measurev1.TimeBucket) (*timeBucket, error) {
+	if tb == nil {
+		return nil, nil
+	}
+	interval := tb.GetInterval()
+	if interval.GetNum() <= 0 {
+		return nil, errors.WithMessagef(errInvalidTimeBucket, "the interval %v should be positive", interval)
+	}
+	switch interval.GetUnit() {
+	case commonv1.IntervalRule_UNIT_HOUR, commonv1.IntervalRule_UNIT_DAY:
+	default:
+		return nil, errors.WithMessagef(errInvalidTimeBucket, "unsupported unit %s", interval.GetUnit())
+	}
+	loc := time.UTC
+	if tz := tb.GetTimeZone(); tz != "" {
+		var err error
+		if loc, err = time.LoadLocation(tz); err != nil {
+			return nil, errors.WithMessagef(errInvalidTimeBucket, "unknown time zone %q: %v", tz, err)
+		}
+	}
+	return &timeBucket{loc: loc, unit: interval.GetUnit(), num: int(interval.GetNum())}, nil
+}
+
+func (tb *timeBucket) String() string {
+	return fmt.Sprintf("%d %s in %s", tb.num, tb.unit, tb.loc)
+}
+
+// align returns the start of the bucket containing t.
+// The hourly buckets are aligned by the elapsed time from the start of the local hour,
+// so the repeated hour of a daylight saving time transition falls into two buckets.
+// The daily buckets start at the local midnights, whose lengths follow the transitions.
+func (tb *timeBucket) align(t time.Time) time.Time {
+	local := t.In(tb.loc)
+	if tb.unit == commonv1.IntervalRule_UNIT_HOUR {
+		elapsed := time.Duration(local.Hour()%tb.num)*time.Hour +
+			time.Duration(local.Minute())*time.Minute +
+			time.Duration(local.Second())*time.Second +
+			time.Duration(local.Nanosecond())
+		return local.Add(-elapsed)
+	}
+	year, month, day := local.Date()
+	if tb.num > 1 {
+		// the days are counted from the epoch in the local calendar, so the buckets don't drift across the years.
+		days := int(time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Unix() / 86400)
+		days -= ((days % tb.num) + tb.num) % tb.num
+		return time.Date(1970, time.January, 1+days, 0, 0, 0, 0, tb.loc)
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, tb.loc)
+}
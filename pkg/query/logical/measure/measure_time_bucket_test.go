@@ -0,0 +1,125 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package measure
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+)
+
+func TestTimeBucketAlign(t *testing.T) {
+	tests := []struct {
+		name     string
+		timeZone string
+		value    string
+		want     string
+		unit     commonv1.IntervalRule_Unit
+		num      uint32
+	}{
+		{
+			name:  "hourly in utc",
+			unit:  commonv1.IntervalRule_UNIT_HOUR,
+			num:   1,
+			value: "2024-03-10T07:42:13Z",
+			want:  "2024-03-10T07:00:00Z",
+		},
+		{
+			name:     "three hours in a half-hour zone",
+			timeZone: "Asia/Kolkata",
+			unit:     commonv1.IntervalRule_UNIT_HOUR,
+			num:      3,
+			value:    "2024-03-10T07:42:13Z",
+			want:     "2024-03-10T12:00:00+05:30",
+		},
+		{
+			name:     "daily in the local midnight",
+			timeZone: "Asia/Shanghai",
+			unit:     commonv1.IntervalRule_UNIT_DAY,
+			num:      1,
+			value:    "2024-03-10T17:00:00Z",
+			want:     "2024-03-11T00:00:00+08:00",
+		},
+		{
+			name:     "daily after the spring forward",
+			timeZone: "America/New_York",
+			unit:     commonv1.IntervalRule_UNIT_DAY,
+			num:      1,
+			value:    "2024-03-10T23:59:59-04:00",
+			want:     "2024-03-10T00:00:00-05:00",
+		},
+		{
+			name:     "daily after the fall back",
+			timeZone: "America/New_York",
+			unit:     commonv1.IntervalRule_UNIT_DAY,
+			num:      1,
+			value:    "2024-11-03T23:30:00-05:00",
+			want:     "2024-11-03T00:00:00-04:00",
+		},
+		{
+			name:     "the repeated hour of the fall back",
+			timeZone: "America/New_York",
+			unit:     commonv1.IntervalRule_UNIT_HOUR,
+			num:      1,
+			value:    "2024-11-03T01:30:00-05:00",
+			want:     "2024-11-03T01:00:00-05:00",
+		},
+		{
+			name:     "two days from the epoch",
+			timeZone: "Europe/Berlin",
+			unit:     commonv1.IntervalRule_UNIT_DAY,
+			num:      2,
+			value:    "1970-01-04T12:00:00+01:00",
+			want:     "1970-01-03T00:00:00+01:00",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tb, err := newTimeBucket(&measurev1.TimeBucket{
+				Interval: &commonv1.IntervalRule{Unit: tt.unit, Num: tt.num},
+				TimeZone: tt.timeZone,
+			})
+			require.NoError(t, err)
+			value, err := time.Parse(time.RFC3339, tt.value)
+			require.NoError(t, err)
+			want, err := time.Parse(time.RFC3339, tt.want)
+			require.NoError(t, err)
+			assert.True(t, want.Equal(tb.align(value)), "want %s, got %s", want, tb.align(value))
+		})
+	}
+}
+
+func TestNewTimeBucket(t *testing.T) {
+	tb, err := newTimeBucket(nil)
+	require.NoError(t, err)
+	assert.Nil(t, tb)
+	_, err = newTimeBucket(&measurev1.TimeBucket{
+		Interval: &commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_HOUR},
+	})
+	assert.ErrorIs(t, err, errInvalidTimeBucket)
+	_, err = newTimeBucket(&measurev1.TimeBucket{
+		Interval: &commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_HOUR, Num: 1},
+		TimeZone: "Mars/Olympus_Mons",
+	})
+	assert.ErrorIs(t, err, errInvalidTimeBucket)
+}
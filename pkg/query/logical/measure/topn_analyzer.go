@@ -110,11 +110,11 @@ func TopNAnalyze(criteria *measurev1.TopNRequest, sourceMeasureSchemaList []*dat
 	if criteria.GetAgg() != 0 {
 		groupByProjectionTags := sourceMeasureSchema.GetEntity().GetTagNames()
 		groupByTags := [][]*logical.Tag{logical.NewTags(measure.TopNTagFamily, groupByProjectionTags...)}
-		plan = newUnresolvedGroupBy(plan, groupByTags, false)
+		plan = newUnresolvedGroupBy(plan, groupByTags, false, nil)
 		plan = newUnresolvedAggregation(plan,
 			&logical.Field{Name: topNAggSchema.FieldName},
 			criteria.GetAgg(),
-			true)
+			true, false)
 	}
 
 	plan = top(plan, &measurev1.QueryRequest_Top{
@@ -154,6 +154,13 @@ func buildVirtualSchema(sourceMeasureSchema *databasev1.Measure, fieldName strin
 			break
 		}
 	}
+	if len(fields) == 0 {
+		// the field names the value of the field expression, which is always an int.
+		fields = append(fields, &databasev1.FieldSpec{
+			Name:      fieldName,
+			FieldType: databasev1.FieldType_FIELD_TYPE_INT,
+		})
+	}
 	md := &databasev1.Measure{
 		Metadata: sourceMeasureSchema.Metadata,
 		TagFamilies: []*databasev1.TagFamilySpec{
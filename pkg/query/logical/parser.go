@@ -27,6 +27,16 @@ import (
 // ParseExprOrEntity parses the condition and returns the literal expression or the entities.
 func ParseExprOrEntity(entityDict map[string]int, entity []*modelv1.TagValue, cond *modelv1.Condition) (LiteralExpr, [][]*modelv1.TagValue, error) {
 	entityIdx, ok := entityDict[cond.Name]
+	if IsNullCheck(cond.Op) {
+		if !ok {
+			return newNullLiteral(), nil, nil
+		}
+		// the entity tags always have values
+		if cond.Op == modelv1.Condition_BINARY_OP_EXISTS {
+			return nil, [][]*modelv1.TagValue{entity}, nil
+		}
+		return nil, nil, errors.WithMessagef(ErrUnsupportedConditionOp, "entity tag %s can't be null", cond.Name)
+	}
 	if ok && cond.Op != modelv1.Condition_BINARY_OP_EQ && cond.Op != modelv1.Condition_BINARY_OP_IN {
 		ok = false
 	}
@@ -91,6 +101,9 @@ func ParseExprOrEntity(entityDict map[string]int, entity []*modelv1.TagValue, co
 
 // ParseExpr parses the condition and returns the literal expression.
 func ParseExpr(cond *modelv1.Condition) (LiteralExpr, error) {
+	if IsNullCheck(cond.Op) {
+		return newNullLiteral(), nil
+	}
 	switch v := cond.Value.Value.(type) {
 	case *modelv1.TagValue_Str:
 		return str(v.Str.GetValue()), nil
@@ -106,6 +119,46 @@ func ParseExpr(cond *modelv1.Condition) (LiteralExpr, error) {
 	return nil, errors.WithMessagef(ErrUnsupportedConditionValue, "condition parses %v", cond)
 }
 
+// IsNullCheck reports whether the operation checks the existence of a tag instead of comparing its value.
+func IsNullCheck(op modelv1.Condition_BinaryOp) bool {
+	return op == modelv1.Condition_BINARY_OP_EXISTS || op == modelv1.Condition_BINARY_OP_IS_NULL
+}
+
+// CheckListSize checks the lists of the conditions in the criteria have no more than maxSize values.
+// A non-positive maxSize means no limit.
+func CheckListSize(criteria *modelv1.Criteria, maxSize int) error {
+	if criteria == nil || maxSize <= 0 {
+		return nil
+	}
+	switch exp := criteria.GetExp().(type) {
+	case *modelv1.Criteria_Condition:
+		var size int
+		switch v := exp.Condition.GetValue().GetValue().(type) {
+		case *modelv1.TagValue_StrArray:
+			size = len(v.StrArray.GetValue())
+		case *modelv1.TagValue_IntArray:
+			size = len(v.IntArray.GetValue())
+		}
+		if size > maxSize {
+			return errors.WithMessagef(ErrTooManyValues, "%s has %d values, which exceeds the limit %d", exp.Condition.GetName(), size, maxSize)
+		}
+	case *modelv1.Criteria_Le:
+		if err := CheckListSize(exp.Le.GetLeft(), maxSize); err != nil {
+			return err
+		}
+		return CheckListSize(exp.Le.GetRight(), maxSize)
+	}
+	return nil
+}
+
+// NegatedCriteria returns the criteria negated by a NOT expression, which takes the left criteria only.
+func NegatedCriteria(le *modelv1.LogicalExpression) (*modelv1.Criteria, error) {
+	if le.GetLeft() == nil || le.GetRight() != nil {
+		return nil, errors.WithMessagef(ErrInvalidLogicalExpression, "NOT takes the left criteria only: %v", le)
+	}
+	return le.GetLeft(), nil
+}
+
 // ParseEntities merges entities based on the logical operation.
 func ParseEntities(op modelv1.LogicalExpression_LogicalOp, input []*modelv1.TagValue, left, right [][]*modelv1.TagValue) [][]*modelv1.TagValue {
 	count := len(input)
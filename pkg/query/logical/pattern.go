@@ -0,0 +1,101 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logical
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/index"
+)
+
+// MaxPatternExpansions caps the terms a prefix or a wildcard pattern expands to in an index.
+const MaxPatternExpansions = 1024
+
+// ErrPatternTooExpensive indicates a prefix or a wildcard pattern is too expensive to be matched.
+var ErrPatternTooExpensive = errors.New("the pattern is too expensive")
+
+// IsPatternMatch reports whether the operation matches the terms by a prefix or a wildcard pattern.
+func IsPatternMatch(op modelv1.Condition_BinaryOp) bool {
+	return op == modelv1.Condition_BINARY_OP_PREFIX || op == modelv1.Condition_BINARY_OP_WILDCARD
+}
+
+// CheckPatterns checks the prefix and the wildcard patterns in the criteria are bounded.
+// A pattern should start with a literal character, which bounds the scan of the term dictionary,
+// and a wildcard pattern should have no more than maxWildcards wildcards.
+// A non-positive maxWildcards means no limit on the wildcards.
+func CheckPatterns(criteria *modelv1.Criteria, maxWildcards int) error {
+	if criteria == nil {
+		return nil
+	}
+	switch exp := criteria.GetExp().(type) {
+	case *modelv1.Criteria_Condition:
+		if !IsPatternMatch(exp.Condition.GetOp()) {
+			return nil
+		}
+		_, err := parsePattern(exp.Condition, maxWildcards)
+		return err
+	case *modelv1.Criteria_Le:
+		if err := CheckPatterns(exp.Le.GetLeft(), maxWildcards); err != nil {
+			return err
+		}
+		return CheckPatterns(exp.Le.GetRight(), maxWildcards)
+	}
+	return nil
+}
+
+// ParsePattern returns the term pattern of a prefix or a wildcard condition on the tag indexed by the rule.
+// The rule is nil if the tag isn't indexed. The patterns only apply to the non-analyzed tags.
+func ParsePattern(cond *modelv1.Condition, indexRule *databasev1.IndexRule) (index.TermPattern, error) {
+	if a := indexRule.GetAnalyzer(); a != index.AnalyzerUnspecified && a != index.AnalyzerKeyword {
+		return index.TermPattern{}, errors.WithMessagef(ErrUnsupportedConditionOp, "%s is analyzed by %s, which doesn't support %s", cond.Name, a, cond.Op)
+	}
+	p, err := parsePattern(cond, 0)
+	if err != nil {
+		return p, err
+	}
+	if IsCaseInsensitive(indexRule) {
+		p.Value = strings.ToLower(p.Value)
+	}
+	return p, nil
+}
+
+func parsePattern(cond *modelv1.Condition, maxWildcards int) (index.TermPattern, error) {
+	v, ok := cond.GetValue().GetValue().(*modelv1.TagValue_Str)
+	if !ok {
+		return index.TermPattern{}, errors.WithMessagef(ErrUnsupportedConditionValue, "%s of %s should be a string", cond.Op, cond.Name)
+	}
+	p := index.TermPattern{
+		Value:         v.Str.GetValue(),
+		Wildcard:      cond.Op == modelv1.Condition_BINARY_OP_WILDCARD,
+		MaxExpansions: MaxPatternExpansions,
+	}
+	if p.LiteralPrefix() == "" {
+		return p, errors.WithMessagef(ErrPatternTooExpensive, "%s of %s should start with a literal character", p, cond.Name)
+	}
+	if !p.Wildcard || maxWildcards <= 0 {
+		return p, nil
+	}
+	if n := strings.Count(p.Value, string(index.WildcardAny)) + strings.Count(p.Value, string(index.WildcardOne)); n > maxWildcards {
+		return p, errors.WithMessagef(ErrPatternTooExpensive, "%s of %s has %d wildcards, which exceeds the limit %d", p, cond.Name, n, maxWildcards)
+	}
+	return p, nil
+}
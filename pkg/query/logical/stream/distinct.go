@@ -0,0 +1,103 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"fmt"
+
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query/logical"
+)
+
+// checkDistinctTag verifies the tag which the elements are distinct by is projected.
+func checkDistinctTag(criteria *streamv1.QueryRequest, s logical.Schema) error {
+	name := criteria.GetDistinctByTag()
+	if name == "" {
+		return nil
+	}
+	if s.FindTagSpecByName(name) == nil {
+		return fmt.Errorf("distinct tag %s not found", name)
+	}
+	for _, tf := range criteria.GetProjection().GetTagFamilies() {
+		for _, n := range tf.GetTags() {
+			if n == name {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("distinct tag %s isn't in the projection", name)
+}
+
+// elementDistinct keeps the first element of every value of a tag.
+// The elements missing the tag are regarded as carrying the null value.
+type elementDistinct struct {
+	seen map[string]struct{}
+	tag  string
+	buf  []byte
+}
+
+// newElementDistinct returns nil if the tag is empty, which keeps all the elements.
+func newElementDistinct(tag string) *elementDistinct {
+	if tag == "" {
+		return nil
+	}
+	return &elementDistinct{
+		seen: make(map[string]struct{}),
+		tag:  tag,
+	}
+}
+
+// keep returns true if the element is the first one carrying its value of the tag.
+func (d *elementDistinct) keep(e *streamv1.Element) bool {
+	if d == nil {
+		return true
+	}
+	v := pbv1.NullTagValue
+	for _, tf := range e.GetTagFamilies() {
+		for _, t := range tf.GetTags() {
+			if t.GetKey() == d.tag {
+				v = t.GetValue()
+			}
+		}
+	}
+	var err error
+	if d.buf, err = pbv1.MarshalTagValues(d.buf[:0], []*modelv1.TagValue{v}); err != nil {
+		return true
+	}
+	if _, ok := d.seen[string(d.buf)]; ok {
+		return false
+	}
+	d.seen[string(d.buf)] = struct{}{}
+	return true
+}
+
+// filter removes the elements carrying the values kept before in place.
+func (d *elementDistinct) filter(elements []*streamv1.Element) []*streamv1.Element {
+	if d == nil {
+		return elements
+	}
+	result := elements[:0]
+	for _, e := range elements {
+		if d.keep(e) {
+			result = append(result, e)
+		}
+	}
+	return result
+}
@@ -0,0 +1,64 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+)
+
+func newDistinctElement(id, endpoint string) *streamv1.Element {
+	e := &streamv1.Element{ElementId: id}
+	if endpoint == "" {
+		return e
+	}
+	e.TagFamilies = []*modelv1.TagFamily{{
+		Name: "searchable",
+		Tags: []*modelv1.Tag{{
+			Key:   "endpoint_id",
+			Value: &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: endpoint}}},
+		}},
+	}}
+	return e
+}
+
+func TestElementDistinct(t *testing.T) {
+	elements := func() []*streamv1.Element {
+		return []*streamv1.Element{
+			newDistinctElement("0", "/home"),
+			newDistinctElement("1", "/product"),
+			newDistinctElement("2", "/home"),
+			newDistinctElement("3", ""),
+			newDistinctElement("4", ""),
+		}
+	}
+	assert.Len(t, newElementDistinct("").filter(elements()), 5, "all the elements are kept without the distinct tag")
+
+	d := newElementDistinct("endpoint_id")
+	var ids []string
+	for _, e := range d.filter(elements()) {
+		ids = append(ids, e.GetElementId())
+	}
+	assert.Equal(t, []string{"0", "1", "3"}, ids)
+	assert.False(t, d.keep(newDistinctElement("5", "/product")), "the values are kept across the batches")
+	assert.True(t, d.keep(newDistinctElement("6", "/price")))
+}
@@ -0,0 +1,231 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/pkg/convert"
+	"github.com/apache/skywalking-banyandb/pkg/index"
+	"github.com/apache/skywalking-banyandb/pkg/query/logical"
+)
+
+const defaultFacetSize = 10
+
+var errUnsupportedFacet = errors.New("unsupported facet")
+
+// buildFacetFields returns the index fields of the facet tags.
+// It fails if a tag or a condition of the criteria can't be served by the inverted index.
+func buildFacetFields(facet *streamv1.Facet, criteria *modelv1.Criteria, s logical.Schema) ([]index.FacetField, error) {
+	entities := make(map[string]struct{}, len(s.EntityList()))
+	for _, e := range s.EntityList() {
+		entities[e] = struct{}{}
+	}
+	if err := checkFacetCriteria(criteria, s, entities); err != nil {
+		return nil, err
+	}
+	fields := make([]index.FacetField, 0, len(facet.GetTagNames()))
+	for _, name := range facet.GetTagNames() {
+		spec := s.FindTagSpecByName(name)
+		if spec == nil {
+			return nil, errors.WithMessagef(errUnsupportedFacet, "tag %s not found", name)
+		}
+		ok, rule := s.IndexDefined(name)
+		if !ok || rule.GetType() != databasev1.IndexRule_TYPE_INVERTED || rule.GetNoSort() {
+			return nil, errors.WithMessagef(errUnsupportedFacet, "tag %s isn't indexed by a sortable inverted index rule", name)
+		}
+		f := index.FacetField{
+			Key: index.FieldKey{
+				IndexRuleID: rule.GetMetadata().GetId(),
+				Analyzer:    rule.GetAnalyzer(),
+			},
+		}
+		switch spec.Spec.GetType() {
+		case databasev1.TagType_TAG_TYPE_INT, databasev1.TagType_TAG_TYPE_INT_ARRAY:
+			f.Numeric = true
+		case databasev1.TagType_TAG_TYPE_STRING, databasev1.TagType_TAG_TYPE_STRING_ARRAY:
+		default:
+			return nil, errors.WithMessagef(errUnsupportedFacet, "tag %s is in the type %s", name, spec.Spec.GetType())
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+func checkFacetCriteria(criteria *modelv1.Criteria, s logical.Schema, entities map[string]struct{}) error {
+	if criteria == nil {
+		return nil
+	}
+	switch criteria.GetExp().(type) {
+	case *modelv1.Criteria_Condition:
+		name := criteria.GetCondition().GetName()
+		if _, ok := entities[name]; ok {
+			return nil
+		}
+		if ok, rule := s.IndexDefined(name); ok && rule.GetType() == databasev1.IndexRule_TYPE_INVERTED {
+			return nil
+		}
+		return errors.WithMessagef(errUnsupportedFacet, "the condition on %s isn't served by an inverted index rule", name)
+	case *modelv1.Criteria_Le:
+		if criteria.GetLe().GetOp() == modelv1.LogicalExpression_LOGICAL_OP_NOT {
+			// the negated entity tags are matched by the tag filter instead of the series
+			return checkFacetCriteria(criteria.GetLe().GetLeft(), s, nil)
+		}
+		if err := checkFacetCriteria(criteria.GetLe().GetLeft(), s, entities); err != nil {
+			return err
+		}
+		return checkFacetCriteria(criteria.GetLe().GetRight(), s, entities)
+	}
+	return nil
+}
+
+// facetShardSize is the number of the values requested from every data node,
+// which is larger than the size to reduce the error of the merged counts.
+func facetShardSize(size uint32) uint32 {
+	if size == 0 {
+		size = defaultFacetSize
+	}
+	return size*3/2 + 10
+}
+
+type facetCollectorKey struct{}
+
+type facetEntry struct {
+	value *modelv1.TagValue
+	count int64
+}
+
+// FacetCollector accumulates the counts of the tag values from the index scans of the groups, or from the data nodes.
+type FacetCollector struct {
+	facet  *streamv1.Facet
+	values []map[string]*facetEntry
+	mu     sync.Mutex
+}
+
+// WithFacetCollector returns a context carrying a collector of the facet.
+// It returns the context as is and a nil collector if the facet is nil.
+func WithFacetCollector(ctx context.Context, facet *streamv1.Facet) (context.Context, *FacetCollector) {
+	if facet == nil {
+		return ctx, nil
+	}
+	fc := &FacetCollector{
+		facet:  facet,
+		values: make([]map[string]*facetEntry, len(facet.GetTagNames())),
+	}
+	for i := range fc.values {
+		fc.values[i] = make(map[string]*facetEntry)
+	}
+	return context.WithValue(ctx, facetCollectorKey{}, fc), fc
+}
+
+func facetCollectorFrom(ctx context.Context) *FacetCollector {
+	fc, _ := ctx.Value(facetCollectorKey{}).(*FacetCollector)
+	return fc
+}
+
+func (fc *FacetCollector) add(tagIdx int, key string, value *modelv1.TagValue, count int64) {
+	if e, ok := fc.values[tagIdx][key]; ok {
+		e.count += count
+		return
+	}
+	fc.values[tagIdx][key] = &facetEntry{value: value, count: count}
+}
+
+func (fc *FacetCollector) addIndexFacets(fields []index.FacetField, facets []index.Facet) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	for i := range facets {
+		for k, c := range facets[i] {
+			var v *modelv1.TagValue
+			if fields[i].Numeric {
+				v = &modelv1.TagValue{Value: &modelv1.TagValue_Int{Int: &modelv1.Int{Value: convert.BytesToInt64([]byte(k))}}}
+			} else {
+				v = &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: k}}}
+			}
+			fc.add(i, k, v, c)
+		}
+	}
+}
+
+func (fc *FacetCollector) addTagFacets(facets []*streamv1.TagFacet) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	for _, f := range facets {
+		for i, name := range fc.facet.GetTagNames() {
+			if name != f.GetTagName() {
+				continue
+			}
+			for _, v := range f.GetValues() {
+				var k string
+				switch tv := v.GetValue().GetValue().(type) {
+				case *modelv1.TagValue_Int:
+					k = string(convert.Int64ToBytes(tv.Int.GetValue()))
+				case *modelv1.TagValue_Str:
+					k = tv.Str.GetValue()
+				default:
+					continue
+				}
+				fc.add(i, k, v.GetValue(), v.GetCount())
+			}
+		}
+	}
+}
+
+// Result returns the most frequent values of every tag.
+func (fc *FacetCollector) Result() []*streamv1.TagFacet {
+	if fc == nil {
+		return nil
+	}
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	size := int(fc.facet.GetSize())
+	if size == 0 {
+		size = defaultFacetSize
+	}
+	result := make([]*streamv1.TagFacet, 0, len(fc.values))
+	for i, name := range fc.facet.GetTagNames() {
+		keys := make([]string, 0, len(fc.values[i]))
+		for k := range fc.values[i] {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(a, b int) bool {
+			ea, eb := fc.values[i][keys[a]], fc.values[i][keys[b]]
+			if ea.count != eb.count {
+				return ea.count > eb.count
+			}
+			return keys[a] < keys[b]
+		})
+		if len(keys) > size {
+			keys = keys[:size]
+		}
+		tf := &streamv1.TagFacet{TagName: name}
+		for _, k := range keys {
+			e := fc.values[i][k]
+			tf.Values = append(tf.Values, &streamv1.TagFacetValue{Value: e.value, Count: e.count})
+		}
+		result = append(result, tf)
+	}
+	return result
+}
@@ -32,6 +32,16 @@ import (
 	"github.com/apache/skywalking-banyandb/pkg/query/logical"
 )
 
+// BuildSkippingFilter builds the filter which prunes the blocks of a measure by the statistics of the tags.
+// The conditions on the entity tags are left out since they're resolved to the series.
+func BuildSkippingFilter(criteria *modelv1.Criteria, schema logical.Schema, entityDict map[string]int, entity []*modelv1.TagValue) (index.Filter, error) {
+	filter, _, err := buildLocalFilter(criteria, schema, entityDict, entity, databasev1.IndexRule_TYPE_SKIPPING)
+	if err != nil || filter == ENode {
+		return nil, err
+	}
+	return filter, nil
+}
+
 func buildLocalFilter(criteria *modelv1.Criteria, schema logical.Schema,
 	entityDict map[string]int, entity []*modelv1.TagValue,
 	indexRuleType databasev1.IndexRule_Type,
@@ -49,12 +59,30 @@ func buildLocalFilter(criteria *modelv1.Criteria, schema logical.Schema,
 		if parsedEntity != nil {
 			return nil, parsedEntity, nil
 		}
-		if ok, indexRule := schema.IndexDefined(cond.Name); ok && indexRule.Type == indexRuleType {
-			return parseConditionToFilter(cond, indexRule, expr, entity)
+		// the null checks are left to the tag filter since the index doesn't hold the null values
+		ok, indexRule := schema.IndexDefined(cond.Name)
+		if ok && indexRule.Type == indexRuleType && !logical.IsNullCheck(cond.Op) {
+			return parseConditionToFilter(cond, indexRule, logical.FoldCase(expr, indexRule), entity)
+		}
+		// the block stats hold the original values, which can't prune the blocks for a case-insensitive tag
+		if indexRuleType == databasev1.IndexRule_TYPE_SKIPPING && !(ok && logical.IsCaseInsensitive(indexRule)) {
+			return newStatsFilter(cond, expr, schema.FindTagSpecByName(cond.Name)), [][]*modelv1.TagValue{entity}, nil
 		}
 		return ENode, [][]*modelv1.TagValue{entity}, nil
 	case *modelv1.Criteria_Le:
 		le := criteria.GetLe()
+		if le.Op == modelv1.LogicalExpression_LOGICAL_OP_NOT {
+			negated, err := logical.NegatedCriteria(le)
+			if err != nil {
+				return nil, nil, err
+			}
+			// the entity tags are left to the tag filter since the series can't be excluded by their entities
+			inner, _, err := buildLocalFilter(negated, schema, nil, entity, indexRuleType)
+			if err != nil {
+				return nil, nil, err
+			}
+			return newExclusion(inner), [][]*modelv1.TagValue{entity}, nil
+		}
 		if le.GetLeft() == nil && le.GetRight() == nil {
 			return nil, nil, errors.WithMessagef(logical.ErrInvalidLogicalExpression, "both sides(left and right) of [%v] are empty", criteria)
 		}
@@ -121,6 +149,16 @@ func parseConditionToFilter(cond *modelv1.Condition, indexRule *databasev1.Index
 			return newMatch(indexRule, expr, cond.MatchOption), [][]*modelv1.TagValue{entity}, nil
 		}
 		return nil, nil, errors.WithMessagef(logical.ErrUnsupportedConditionOp, "index filter parses %v for skipping index", cond)
+	case modelv1.Condition_BINARY_OP_PREFIX, modelv1.Condition_BINARY_OP_WILDCARD:
+		// the tag filter matches the pattern if the tag has no inverted index
+		if indexRule.Type != databasev1.IndexRule_TYPE_INVERTED {
+			return ENode, [][]*modelv1.TagValue{entity}, nil
+		}
+		p, err := logical.ParsePattern(cond, indexRule)
+		if err != nil {
+			return nil, nil, err
+		}
+		return newPattern(indexRule, expr, p), [][]*modelv1.TagValue{entity}, nil
 	case modelv1.Condition_BINARY_OP_NE:
 		return newNot(indexRule, newEq(indexRule, expr)), [][]*modelv1.TagValue{entity}, nil
 	case modelv1.Condition_BINARY_OP_HAVING:
@@ -146,27 +184,15 @@ func parseConditionToFilter(cond *modelv1.Condition, indexRule *databasev1.Index
 		}
 		return newNot(indexRule, and), [][]*modelv1.TagValue{entity}, nil
 	case modelv1.Condition_BINARY_OP_IN:
-		ee := expr.SubExprs()
-		l := len(ee)
-		if l < 1 {
+		if len(expr.SubExprs()) < 1 {
 			return ENode, [][]*modelv1.TagValue{entity}, nil
 		}
-		or := newOr(l)
-		for i := range ee {
-			or.append(newEq(indexRule, ee[i]))
-		}
-		return or, [][]*modelv1.TagValue{entity}, nil
+		return newIn(indexRule, expr), [][]*modelv1.TagValue{entity}, nil
 	case modelv1.Condition_BINARY_OP_NOT_IN:
-		ee := expr.SubExprs()
-		l := len(ee)
-		if l < 1 {
+		if len(expr.SubExprs()) < 1 {
 			return ENode, [][]*modelv1.TagValue{entity}, nil
 		}
-		or := newOr(l)
-		for i := range ee {
-			or.append(newEq(indexRule, ee[i]))
-		}
-		return newNot(indexRule, or), [][]*modelv1.TagValue{entity}, nil
+		return newNot(indexRule, newIn(indexRule, expr)), [][]*modelv1.TagValue{entity}, nil
 	}
 	return nil, nil, errors.WithMessagef(logical.ErrUnsupportedConditionOp, "index filter parses %v", cond)
 }
@@ -268,7 +294,32 @@ func (an *andNode) merge(list ...posting.List) (posting.List, error) {
 }
 
 func (an *andNode) Execute(searcher index.GetSearcher, seriesID common.SeriesID, tr *index.RangeOpts) (posting.List, posting.List, error) {
-	return execute(searcher, seriesID, an.node, an, tr)
+	result, resultTS, err := execute(searcher, seriesID, an.node, an, tr)
+	if err != nil {
+		return nil, nil, err
+	}
+	// nothing but the sub nodes without an index, such as the exclusions, is left
+	if _, ok := result.(*bypassList); ok || result == nil {
+		return bList, bList, nil
+	}
+	// the exclusions are subtracted from what the other sub nodes select, instead of scanning the series
+	for _, sn := range an.node.SubNodes {
+		e, ok := sn.(*exclusion)
+		if !ok || !isExact(e.Inner) {
+			continue
+		}
+		list, listTS, err := e.Inner.Execute(searcher, seriesID, tr)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err = result.Difference(list); err != nil {
+			return nil, nil, err
+		}
+		if err = resultTS.Difference(listTS); err != nil {
+			return nil, nil, err
+		}
+	}
+	return result, resultTS, nil
 }
 
 func (an *andNode) ShouldSkip(tagFamilyFilters index.FilterOp) (bool, error) {
@@ -402,6 +453,10 @@ func (n *not) Execute(searcher index.GetSearcher, seriesID common.SeriesID, tr *
 	return all, allTS, err
 }
 
+func (n *not) ShouldSkip(_ index.FilterOp) (bool, error) {
+	return false, nil
+}
+
 func (n *not) MarshalJSON() ([]byte, error) {
 	data := make(map[string]interface{}, 1)
 	data["not"] = n.Inner
@@ -412,6 +467,61 @@ func (n *not) String() string {
 	return convert.JSONToString(n)
 }
 
+// exclusion is a negated filter. It selects nothing by itself, and the tag filter takes up the job
+// unless an "and" node subtracts it from the items selected by the siblings.
+type exclusion struct {
+	Inner index.Filter
+}
+
+func newExclusion(inner index.Filter) *exclusion {
+	return &exclusion{
+		Inner: inner,
+	}
+}
+
+func (e *exclusion) Execute(_ index.GetSearcher, _ common.SeriesID, _ *index.RangeOpts) (posting.List, posting.List, error) {
+	return bList, bList, nil
+}
+
+func (e *exclusion) ShouldSkip(_ index.FilterOp) (bool, error) {
+	return false, nil
+}
+
+func (e *exclusion) MarshalJSON() ([]byte, error) {
+	data := make(map[string]interface{}, 1)
+	data["exclude"] = e.Inner
+	return json.Marshal(data)
+}
+
+func (e *exclusion) String() string {
+	return convert.JSONToString(e)
+}
+
+// isExact reports whether the filter selects no more than the matched items, so that they're safe to subtract.
+// An "and" node ignores the sub nodes without an index and selects more than the matched items.
+func isExact(f index.Filter) bool {
+	switch n := f.(type) {
+	case *eq, *in, *match, *pattern, *rangeOp:
+		return true
+	case *andNode:
+		return isExactNode(n.node)
+	case *orNode:
+		return isExactNode(n.node)
+	case *not:
+		return isExact(n.Inner)
+	}
+	return false
+}
+
+func isExactNode(n *node) bool {
+	for _, sn := range n.SubNodes {
+		if !isExact(sn) {
+			return false
+		}
+	}
+	return len(n.SubNodes) > 0
+}
+
 type eq struct {
 	*leaf
 }
@@ -447,6 +557,58 @@ func (eq *eq) String() string {
 	return convert.JSONToString(eq)
 }
 
+// in looks up all the values in a batch, instead of an "or" of "eq"s.
+type in struct {
+	*leaf
+}
+
+func newIn(indexRule *databasev1.IndexRule, values logical.LiteralExpr) *in {
+	return &in{
+		leaf: &leaf{
+			Key:  newFieldKeyWithIndexRule(indexRule),
+			Expr: values,
+		},
+	}
+}
+
+func (in *in) Execute(searcher index.GetSearcher, seriesID common.SeriesID, tr *index.RangeOpts) (posting.List, posting.List, error) {
+	s, err := searcher(in.Key.Type)
+	if err != nil {
+		return nil, nil, err
+	}
+	key := in.Key.toIndex(seriesID, tr)
+	ee := in.Expr.SubExprs()
+	fields := make([]index.Field, len(ee))
+	for i := range ee {
+		fields[i] = ee[i].Field(key)
+	}
+	return s.MatchAnyTerms(fields)
+}
+
+func (in *in) ShouldSkip(tagFamilyFilters index.FilterOp) (bool, error) {
+	for _, e := range in.Expr.SubExprs() {
+		if tagFamilyFilters.Eq(in.Key.Tags[0], e.String()) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (in *in) MarshalJSON() ([]byte, error) {
+	elements := in.Expr.Elements()
+	inner := make(map[string]interface{}, 3)
+	inner["index"] = in.Key.IndexRule.Metadata.Name + ":" + in.Key.IndexRule.Metadata.Group
+	inner["size"] = len(elements)
+	inner["values"] = logical.FormatElements(elements)
+	data := make(map[string]interface{}, 1)
+	data["in"] = inner
+	return json.Marshal(data)
+}
+
+func (in *in) String() string {
+	return convert.JSONToString(in)
+}
+
 type match struct {
 	*leaf
 	opts *modelv1.Condition_MatchOption
@@ -493,6 +655,47 @@ func (match *match) String() string {
 	return convert.JSONToString(match)
 }
 
+type pattern struct {
+	*leaf
+	pattern index.TermPattern
+}
+
+func newPattern(indexRule *databasev1.IndexRule, expr logical.LiteralExpr, p index.TermPattern) *pattern {
+	return &pattern{
+		leaf: &leaf{
+			Key:  newFieldKeyWithIndexRule(indexRule),
+			Expr: expr,
+		},
+		pattern: p,
+	}
+}
+
+func (p *pattern) Execute(searcher index.GetSearcher, seriesID common.SeriesID, tr *index.RangeOpts) (posting.List, posting.List, error) {
+	s, err := searcher(p.Key.Type)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.MatchPattern(p.Key.toIndex(seriesID, tr), p.pattern)
+}
+
+func (p *pattern) ShouldSkip(_ index.FilterOp) (bool, error) {
+	return false, nil
+}
+
+func (p *pattern) MarshalJSON() ([]byte, error) {
+	data := make(map[string]interface{}, 1)
+	if p.pattern.Wildcard {
+		data["wildcard"] = p.leaf
+	} else {
+		data["prefix"] = p.leaf
+	}
+	return json.Marshal(data)
+}
+
+func (p *pattern) String() string {
+	return convert.JSONToString(p)
+}
+
 type rangeOp struct {
 	*leaf
 	Opts index.RangeOpts
@@ -516,7 +719,11 @@ func (r *rangeOp) Execute(searcher index.GetSearcher, seriesID common.SeriesID,
 }
 
 func (r *rangeOp) ShouldSkip(tagFamilyFilters index.FilterOp) (bool, error) {
-	return tagFamilyFilters.Range(r.Key.Tags[0], r.Opts)
+	mightContain, err := tagFamilyFilters.Range(r.Key.Tags[0], r.Opts)
+	if err != nil {
+		return false, err
+	}
+	return !mightContain, nil
 }
 
 func (r *rangeOp) MarshalJSON() ([]byte, error) {
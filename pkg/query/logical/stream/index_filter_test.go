@@ -0,0 +1,98 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/index"
+	"github.com/apache/skywalking-banyandb/pkg/index/posting"
+	"github.com/apache/skywalking-banyandb/pkg/index/posting/roaring"
+	"github.com/apache/skywalking-banyandb/pkg/query/logical"
+)
+
+// fakeSearcher returns the items of an index rule whatever the terms are.
+type fakeSearcher struct {
+	index.Searcher
+	items map[uint32][]uint64
+}
+
+func (f *fakeSearcher) list(key index.FieldKey) (posting.List, posting.List, error) {
+	items := f.items[key.IndexRuleID]
+	return roaring.NewPostingListWithInitialData(items...), roaring.NewPostingListWithInitialData(items...), nil
+}
+
+func (f *fakeSearcher) MatchTerms(field index.Field) (posting.List, posting.List, error) {
+	return f.list(field.Key)
+}
+
+func (f *fakeSearcher) MatchPattern(key index.FieldKey, _ index.TermPattern) (posting.List, posting.List, error) {
+	return f.list(key)
+}
+
+func TestExclusion(t *testing.T) {
+	rule := func(id uint32) *databasev1.IndexRule {
+		return &databasev1.IndexRule{
+			Metadata: &commonv1.Metadata{Id: id, Name: "rule", Group: "default"},
+			Type:     databasev1.IndexRule_TYPE_INVERTED,
+		}
+	}
+	expr, err := logical.ParseExpr(&modelv1.Condition{Value: &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: "v"}}}})
+	require.NoError(t, err)
+	s := &fakeSearcher{items: map[uint32][]uint64{
+		1: {1, 2, 3, 4},
+		2: {2, 4, 6},
+	}}
+	getSearcher := func(databasev1.IndexRule_Type) (index.Searcher, error) {
+		return s, nil
+	}
+	execute := func(f index.Filter) []uint64 {
+		list, _, err := f.Execute(getSearcher, common.SeriesID(1), nil)
+		require.NoError(t, err)
+		if _, ok := list.(*bypassList); ok {
+			return nil
+		}
+		return list.ToSlice()
+	}
+
+	and := newAnd(2)
+	and.append(newEq(rule(1), expr)).append(newExclusion(newPattern(rule(2), expr, index.TermPattern{Value: "v"})))
+	assert.Equal(t, []uint64{1, 3}, execute(and))
+
+	// the items of a standalone exclusion are left to the tag filter
+	assert.Nil(t, execute(newExclusion(newEq(rule(2), expr))))
+
+	// an "and" node ignoring the sub nodes without an index selects more than the matched items, which can't be subtracted
+	inner := newAnd(2)
+	inner.append(newEq(rule(2), expr)).append(ENode)
+	and = newAnd(2)
+	and.append(newEq(rule(1), expr)).append(newExclusion(inner))
+	assert.Equal(t, []uint64{1, 2, 3, 4}, execute(and))
+
+	// nothing but the exclusions selects all the items
+	and = newAnd(2)
+	and.append(ENode).append(newExclusion(newEq(rule(2), expr)))
+	assert.Nil(t, execute(and))
+}
@@ -0,0 +1,149 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/convert"
+	"github.com/apache/skywalking-banyandb/pkg/index"
+	"github.com/apache/skywalking-banyandb/pkg/index/posting"
+	"github.com/apache/skywalking-banyandb/pkg/query/logical"
+)
+
+// statsFilter prunes the blocks by the statistics of a tag which isn't indexed by the skipping index.
+// It never filters the elements, which is left to the tag filter.
+type statsFilter struct {
+	Expr    logical.LiteralExpr
+	Tag     string
+	Op      modelv1.Condition_BinaryOp
+	TagType databasev1.TagType
+}
+
+func newStatsFilter(cond *modelv1.Condition, expr logical.LiteralExpr, tagSpec *logical.TagSpec) index.Filter {
+	if tagSpec == nil {
+		return ENode
+	}
+	tagType := tagSpec.Spec.GetType()
+	switch cond.Op {
+	case modelv1.Condition_BINARY_OP_EQ, modelv1.Condition_BINARY_OP_IN:
+		if tagType != databasev1.TagType_TAG_TYPE_INT && tagType != databasev1.TagType_TAG_TYPE_STRING {
+			return ENode
+		}
+	case modelv1.Condition_BINARY_OP_GT, modelv1.Condition_BINARY_OP_GE,
+		modelv1.Condition_BINARY_OP_LT, modelv1.Condition_BINARY_OP_LE:
+		if tagType != databasev1.TagType_TAG_TYPE_INT {
+			return ENode
+		}
+	case modelv1.Condition_BINARY_OP_EXISTS, modelv1.Condition_BINARY_OP_IS_NULL:
+		return &statsFilter{
+			Tag:     cond.Name,
+			Op:      cond.Op,
+			TagType: tagType,
+			Expr:    expr,
+		}
+	default:
+		return ENode
+	}
+	if len(expr.Bytes()) < 1 {
+		return ENode
+	}
+	return &statsFilter{
+		Tag:     cond.Name,
+		Op:      cond.Op,
+		TagType: tagType,
+		Expr:    expr,
+	}
+}
+
+func (sf *statsFilter) Execute(_ index.GetSearcher, _ common.SeriesID, _ *index.RangeOpts) (posting.List, posting.List, error) {
+	return bList, bList, nil
+}
+
+func (sf *statsFilter) ShouldSkip(tagFamilyFilters index.FilterOp) (bool, error) {
+	stats, ok := tagFamilyFilters.Stats(sf.Tag)
+	if !ok {
+		return false, nil
+	}
+	allNull := stats.Count > 0 && stats.NullCount >= stats.Count
+	switch sf.Op {
+	case modelv1.Condition_BINARY_OP_EQ, modelv1.Condition_BINARY_OP_IN:
+		for _, v := range sf.Expr.Bytes() {
+			if sf.mightContain(tagFamilyFilters, stats, allNull, v) {
+				return false, nil
+			}
+		}
+		return true, nil
+	case modelv1.Condition_BINARY_OP_GT:
+		return sf.skipRange(tagFamilyFilters, allNull, sf.Expr.RangeOpts(false, false, false))
+	case modelv1.Condition_BINARY_OP_GE:
+		return sf.skipRange(tagFamilyFilters, allNull, sf.Expr.RangeOpts(false, true, false))
+	case modelv1.Condition_BINARY_OP_LT:
+		return sf.skipRange(tagFamilyFilters, allNull, sf.Expr.RangeOpts(true, false, false))
+	case modelv1.Condition_BINARY_OP_LE:
+		return sf.skipRange(tagFamilyFilters, allNull, sf.Expr.RangeOpts(true, false, true))
+	case modelv1.Condition_BINARY_OP_EXISTS:
+		return allNull, nil
+	case modelv1.Condition_BINARY_OP_IS_NULL:
+		// the blocks written before the null counts are tracked might have null values.
+		return stats.NullCountKnown && stats.NullCount == 0, nil
+	}
+	return false, nil
+}
+
+func (sf *statsFilter) mightContain(tagFamilyFilters index.FilterOp, stats index.BlockStats, allNull bool, value []byte) bool {
+	// an empty string is stored as a null value.
+	if len(value) == 0 {
+		return !stats.NullCountKnown || stats.NullCount > 0
+	}
+	if allNull {
+		return false
+	}
+	if sf.TagType == databasev1.TagType_TAG_TYPE_INT {
+		if len(stats.Min) == 0 || len(stats.Max) == 0 {
+			return true
+		}
+		return bytes.Compare(value, stats.Min) >= 0 && bytes.Compare(value, stats.Max) <= 0
+	}
+	return tagFamilyFilters.Eq(sf.Tag, string(value))
+}
+
+func (sf *statsFilter) skipRange(tagFamilyFilters index.FilterOp, allNull bool, opts index.RangeOpts) (bool, error) {
+	if allNull {
+		return true, nil
+	}
+	mightContain, err := tagFamilyFilters.Range(sf.Tag, opts)
+	if err != nil {
+		return false, err
+	}
+	return !mightContain, nil
+}
+
+func (sf *statsFilter) MarshalJSON() ([]byte, error) {
+	data := make(map[string]interface{}, 1)
+	data["stats"] = sf.Tag + " " + sf.Op.String() + " " + sf.Expr.String()
+	return json.Marshal(data)
+}
+
+func (sf *statsFilter) String() string {
+	return convert.JSONToString(sf)
+}
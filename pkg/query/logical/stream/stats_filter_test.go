@@ -0,0 +1,145 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"testing"
+
+	"github.com/blugelabs/bluge/numeric"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/convert"
+	"github.com/apache/skywalking-banyandb/pkg/index"
+	"github.com/apache/skywalking-banyandb/pkg/query/logical"
+)
+
+type fakeFilterOp struct {
+	stats  map[string]index.BlockStats
+	values map[string][]string
+}
+
+func (f *fakeFilterOp) Eq(tagName string, tagValue string) bool {
+	vv, ok := f.values[tagName]
+	if !ok {
+		return true
+	}
+	for _, v := range vv {
+		if v == tagValue {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *fakeFilterOp) Range(tagName string, rangeOpts index.RangeOpts) (bool, error) {
+	stats, ok := f.stats[tagName]
+	if !ok || len(stats.Min) == 0 {
+		return true, nil
+	}
+	minValue, maxValue := convert.BytesToInt64(stats.Min), convert.BytesToInt64(stats.Max)
+	lower := numeric.Float64ToInt64(rangeOpts.Lower.(*index.FloatTermValue).Value)
+	upper := numeric.Float64ToInt64(rangeOpts.Upper.(*index.FloatTermValue).Value)
+	if maxValue < lower || !rangeOpts.IncludesLower && maxValue == lower {
+		return false, nil
+	}
+	if minValue > upper || !rangeOpts.IncludesUpper && minValue == upper {
+		return false, nil
+	}
+	return true, nil
+}
+
+func (f *fakeFilterOp) Stats(tagName string) (index.BlockStats, bool) {
+	stats, ok := f.stats[tagName]
+	return stats, ok
+}
+
+func TestStatsFilterShouldSkip(t *testing.T) {
+	tagSpecs := map[string]*logical.TagSpec{
+		"duration":   {Spec: &databasev1.TagSpec{Name: "duration", Type: databasev1.TagType_TAG_TYPE_INT}},
+		"method":     {Spec: &databasev1.TagSpec{Name: "method", Type: databasev1.TagType_TAG_TYPE_STRING}},
+		"error":      {Spec: &databasev1.TagSpec{Name: "error", Type: databasev1.TagType_TAG_TYPE_STRING}},
+		"extensions": {Spec: &databasev1.TagSpec{Name: "extensions", Type: databasev1.TagType_TAG_TYPE_STRING_ARRAY}},
+		"legacy":     {Spec: &databasev1.TagSpec{Name: "legacy", Type: databasev1.TagType_TAG_TYPE_STRING}},
+	}
+	op := &fakeFilterOp{
+		stats: map[string]index.BlockStats{
+			"duration": {Min: convert.Int64ToBytes(10), Max: convert.Int64ToBytes(100), Count: 10, NullCount: 2, NullCountKnown: true},
+			"method":   {Count: 10, NullCountKnown: true},
+			"error":    {Count: 10, NullCount: 10, NullCountKnown: true},
+			"legacy":   {Count: 10},
+		},
+		values: map[string][]string{"method": {"GET", "POST"}},
+	}
+	intValue := func(v int64) *modelv1.TagValue {
+		return &modelv1.TagValue{Value: &modelv1.TagValue_Int{Int: &modelv1.Int{Value: v}}}
+	}
+	strValue := func(v string) *modelv1.TagValue {
+		return &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: v}}}
+	}
+	tests := []struct {
+		cond *modelv1.Condition
+		name string
+		skip bool
+	}{
+		{name: "eq in the bounds", cond: &modelv1.Condition{Name: "duration", Op: modelv1.Condition_BINARY_OP_EQ, Value: intValue(50)}},
+		{name: "eq out of the bounds", cond: &modelv1.Condition{Name: "duration", Op: modelv1.Condition_BINARY_OP_EQ, Value: intValue(101)}, skip: true},
+		{name: "gt the max", cond: &modelv1.Condition{Name: "duration", Op: modelv1.Condition_BINARY_OP_GT, Value: intValue(100)}, skip: true},
+		{name: "ge the max", cond: &modelv1.Condition{Name: "duration", Op: modelv1.Condition_BINARY_OP_GE, Value: intValue(100)}},
+		{name: "lt the min", cond: &modelv1.Condition{Name: "duration", Op: modelv1.Condition_BINARY_OP_LT, Value: intValue(10)}, skip: true},
+		{name: "le the min", cond: &modelv1.Condition{Name: "duration", Op: modelv1.Condition_BINARY_OP_LE, Value: intValue(10)}},
+		{name: "ne is never skipped", cond: &modelv1.Condition{Name: "duration", Op: modelv1.Condition_BINARY_OP_NE, Value: intValue(101)}},
+		{name: "eq a present string", cond: &modelv1.Condition{Name: "method", Op: modelv1.Condition_BINARY_OP_EQ, Value: strValue("GET")}},
+		{name: "eq an absent string", cond: &modelv1.Condition{Name: "method", Op: modelv1.Condition_BINARY_OP_EQ, Value: strValue("PUT")}, skip: true},
+		{
+			name: "in absent strings",
+			cond: &modelv1.Condition{Name: "method", Op: modelv1.Condition_BINARY_OP_IN, Value: &modelv1.TagValue{
+				Value: &modelv1.TagValue_StrArray{StrArray: &modelv1.StrArray{Value: []string{"PUT", "DELETE"}}},
+			}},
+			skip: true,
+		},
+		{
+			name: "in strings",
+			cond: &modelv1.Condition{Name: "method", Op: modelv1.Condition_BINARY_OP_IN, Value: &modelv1.TagValue{
+				Value: &modelv1.TagValue_StrArray{StrArray: &modelv1.StrArray{Value: []string{"PUT", "POST"}}},
+			}},
+		},
+		{name: "eq a value of a null tag", cond: &modelv1.Condition{Name: "error", Op: modelv1.Condition_BINARY_OP_EQ, Value: strValue("timeout")}, skip: true},
+		{name: "eq an empty string of a null tag", cond: &modelv1.Condition{Name: "error", Op: modelv1.Condition_BINARY_OP_EQ, Value: strValue("")}},
+		{name: "unknown tag", cond: &modelv1.Condition{Name: "absent", Op: modelv1.Condition_BINARY_OP_EQ, Value: strValue("GET")}},
+		{name: "exists in a null tag", cond: &modelv1.Condition{Name: "error", Op: modelv1.Condition_BINARY_OP_EXISTS}, skip: true},
+		{name: "exists in a partially null tag", cond: &modelv1.Condition{Name: "duration", Op: modelv1.Condition_BINARY_OP_EXISTS}},
+		{name: "exists in an array tag", cond: &modelv1.Condition{Name: "extensions", Op: modelv1.Condition_BINARY_OP_EXISTS}},
+		{name: "is null in a tag without null values", cond: &modelv1.Condition{Name: "method", Op: modelv1.Condition_BINARY_OP_IS_NULL}, skip: true},
+		{name: "is null in a partially null tag", cond: &modelv1.Condition{Name: "duration", Op: modelv1.Condition_BINARY_OP_IS_NULL}},
+		{name: "is null in a legacy block", cond: &modelv1.Condition{Name: "legacy", Op: modelv1.Condition_BINARY_OP_IS_NULL}},
+		{name: "eq an empty string in a legacy block", cond: &modelv1.Condition{Name: "legacy", Op: modelv1.Condition_BINARY_OP_EQ, Value: strValue("")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, _, err := logical.ParseExprOrEntity(nil, nil, tt.cond)
+			require.NoError(t, err)
+			f := newStatsFilter(tt.cond, expr, tagSpecs[tt.cond.Name])
+			skip, err := f.ShouldSkip(op)
+			require.NoError(t, err)
+			assert.Equal(t, tt.skip, skip)
+		})
+	}
+}
@@ -76,7 +76,10 @@ func Analyze(criteria *streamv1.QueryRequest, metadata []*commonv1.Metadata, ss
 	if limitParameter == 0 {
 		limitParameter = defaultLimit
 	}
-	plan = newLimit(plan, criteria.GetOffset(), limitParameter)
+	if err := checkDistinctTag(criteria, s); err != nil {
+		return nil, err
+	}
+	plan = newLimit(plan, criteria.GetOffset(), limitParameter, criteria.GetDistinctByTag())
 
 	p, err := plan.Analyze(s)
 	if err != nil {
@@ -104,6 +107,9 @@ func DistributedAnalyze(criteria *streamv1.QueryRequest, ss []logical.Schema) (l
 			return nil, err
 		}
 	}
+	if err := checkDistinctTag(criteria, s); err != nil {
+		return nil, err
+	}
 	plan := newUnresolvedDistributed(criteria)
 
 	// parse limit
@@ -129,8 +135,9 @@ type Parent struct {
 
 type limit struct {
 	*Parent
-	limitNum  uint32
-	offsetNum uint32
+	distinctTag string
+	limitNum    uint32
+	offsetNum   uint32
 }
 
 func (l *limit) Close() {
@@ -141,6 +148,7 @@ func (l *limit) Execute(ec context.Context) ([]*streamv1.Element, error) {
 	var allEntities []*streamv1.Element
 	targetCount := int(l.limitNum)
 	offset := int(l.offsetNum)
+	distinct := newElementDistinct(l.distinctTag)
 
 	for len(allEntities) < targetCount+offset {
 		entities, err := l.Parent.Input.(executor.StreamExecutable).Execute(ec)
@@ -150,6 +158,7 @@ func (l *limit) Execute(ec context.Context) ([]*streamv1.Element, error) {
 		if len(entities) == 0 {
 			break
 		}
+		entities = distinct.filter(entities)
 
 		needed := targetCount + offset - len(allEntities)
 		if len(entities) > needed {
@@ -192,13 +201,14 @@ func (l *limit) Children() []logical.Plan {
 	return []logical.Plan{l.Input}
 }
 
-func newLimit(input logical.UnresolvedPlan, offset, num uint32) logical.UnresolvedPlan {
+func newLimit(input logical.UnresolvedPlan, offset, num uint32, distinctTag string) logical.UnresolvedPlan {
 	return &limit{
 		Parent: &Parent{
 			UnresolvedInput: input,
 		},
-		offsetNum: offset,
-		limitNum:  num,
+		offsetNum:   offset,
+		limitNum:    num,
+		distinctTag: distinctTag,
 	}
 }
 
@@ -206,6 +216,12 @@ func parseTags(criteria *streamv1.QueryRequest, metadata *commonv1.Metadata,
 	ec executor.StreamExecutionContext, tagProjection [][]*logical.Tag,
 ) logical.UnresolvedPlan {
 	timeRange := criteria.GetTimeRange()
-	return tagFilter(timeRange.GetBegin().AsTime(), timeRange.GetEnd().AsTime(), metadata,
-		criteria.Criteria, tagProjection, ec)
+	uis := tagFilter(timeRange.GetBegin().AsTime(), timeRange.GetEnd().AsTime(), metadata,
+		criteria.Criteria, criteria.GetFacet(), tagProjection, ec)
+	uis.elementIDs = criteria.GetElementIds()
+	if uis.traceID = criteria.GetTraceId(); uis.traceID != "" {
+		uis.spanID = criteria.GetSpanId()
+	}
+	uis.withElementMetadata = criteria.GetWithElementMetadata()
+	return uis
 }
@@ -20,12 +20,13 @@ package stream
 import (
 	"context"
 	"fmt"
-	"time"
 
 	"go.uber.org/multierr"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
 
 	"github.com/apache/skywalking-banyandb/api/data"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
 	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
 	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
 	"github.com/apache/skywalking-banyandb/pkg/bus"
@@ -36,10 +37,9 @@ import (
 	"github.com/apache/skywalking-banyandb/pkg/query"
 	"github.com/apache/skywalking-banyandb/pkg/query/executor"
 	"github.com/apache/skywalking-banyandb/pkg/query/logical"
+	"github.com/apache/skywalking-banyandb/pkg/tracecontext"
 )
 
-const defaultQueryTimeout = 30 * time.Second
-
 var _ logical.UnresolvedPlan = (*unresolvedDistributed)(nil)
 
 type unresolvedDistributed struct {
@@ -56,6 +56,13 @@ func (ud *unresolvedDistributed) Analyze(s logical.Schema) (logical.Plan, error)
 	if ud.originalQuery.Projection == nil {
 		return nil, fmt.Errorf("projection is required")
 	}
+	var facet *streamv1.Facet
+	if f := ud.originalQuery.GetFacet(); f != nil {
+		if _, err := buildFacetFields(f, ud.originalQuery.GetCriteria(), s); err != nil {
+			return nil, err
+		}
+		facet = &streamv1.Facet{TagNames: f.GetTagNames(), Size: facetShardSize(f.GetSize())}
+	}
 	projectionTags := logical.ToTags(ud.originalQuery.GetProjection())
 	if len(projectionTags) > 0 {
 		var err error
@@ -70,12 +77,16 @@ func (ud *unresolvedDistributed) Analyze(s logical.Schema) (logical.Plan, error)
 		limit = defaultLimit
 	}
 	temp := &streamv1.QueryRequest{
-		Projection: ud.originalQuery.Projection,
-		Name:       ud.originalQuery.Name,
-		Groups:     ud.originalQuery.Groups,
-		Criteria:   ud.originalQuery.Criteria,
-		Limit:      limit + ud.originalQuery.Offset,
-		OrderBy:    ud.originalQuery.OrderBy,
+		Projection:          ud.originalQuery.Projection,
+		Name:                ud.originalQuery.Name,
+		Groups:              ud.originalQuery.Groups,
+		Criteria:            ud.originalQuery.Criteria,
+		Limit:               limit + ud.originalQuery.Offset,
+		OrderBy:             ud.originalQuery.OrderBy,
+		Facet:               facet,
+		ElementIds:          ud.originalQuery.ElementIds,
+		DistinctByTag:       ud.originalQuery.DistinctByTag,
+		WithElementMetadata: ud.originalQuery.WithElementMetadata,
 	}
 	if ud.originalQuery.OrderBy == nil {
 		return &distributedPlan{
@@ -152,35 +163,68 @@ func (t *distributedPlan) Execute(ctx context.Context) (ee []*streamv1.Element,
 			}
 		}()
 	}
-	ff, err := dctx.Broadcast(defaultQueryTimeout, data.TopicStreamQuery,
-		bus.NewMessageWithNodeSelectors(bus.MessageID(dctx.TimeRange().Begin.Nanos), dctx.NodeSelectors(), dctx.TimeRange(), queryRequest))
+	queryTimeout, nodeTimeout := dctx.Timeout()
+	queryRequest.Timeout = durationpb.New(nodeTimeout)
+	tc, _ := tracecontext.FromContext(ctx)
+	ff, err := dctx.Broadcast(queryTimeout, data.TopicStreamQuery,
+		bus.NewMessageWithNodeSelectors(bus.MessageID(dctx.TimeRange().Begin.Nanos), dctx.NodeSelectors(), dctx.TimeRange(), queryRequest).
+			WithTraceContext(tc))
 	if err != nil {
 		return nil, err
 	}
 	var allErr error
+	var answered int
 	var see []sort.Iterator[*comparableElement]
+	fc := facetCollectorFrom(ctx)
 	for _, f := range ff {
-		if m, getErr := f.Get(); getErr != nil {
+		m, getErr := f.Get()
+		if getErr != nil {
 			allErr = multierr.Append(allErr, getErr)
-		} else {
-			d := m.Data()
-			if d == nil {
-				continue
-			}
-			resp := d.(*streamv1.QueryResponse)
+			dctx.ReportNode(&commonv1.NodeStatus{Name: m.Node(), Reason: getErr.Error()})
+			continue
+		}
+		answered++
+		d := m.Data()
+		if d == nil {
+			dctx.ReportNode(&commonv1.NodeStatus{Name: m.Node()})
+			continue
+		}
+		resp := d.(*streamv1.QueryResponse)
+		dctx.ReportNode(&commonv1.NodeStatus{Name: m.Node(), Truncated: resp.Truncated, Reason: resp.TruncatedReason})
+		if span != nil {
+			span.AddSubTrace(resp.Trace)
+		}
+		if fc != nil {
+			fc.addTagFacets(resp.Facets)
+		}
+		see = append(see,
+			newSortableElements(resp.Elements, t.sortByTime, t.sortTagSpec))
+	}
+	// the failed nodes are reported by the coverage of the query unless none of the nodes answers.
+	if answered == 0 && allErr != nil {
+		return nil, allErr
+	}
+	if fed := dctx.Federation(); fed != nil {
+		for _, resp := range fed.QueryStream(ctx, queryRequest) {
 			if span != nil {
 				span.AddSubTrace(resp.Trace)
 			}
-			see = append(see,
-				newSortableElements(resp.Elements, t.sortByTime, t.sortTagSpec))
+			if fc != nil {
+				fc.addTagFacets(resp.Facets)
+			}
+			see = append(see, newSortableElements(resp.Elements, t.sortByTime, t.sortTagSpec))
 		}
 	}
 	iter := sort.NewItemIter[*comparableElement](see, t.desc)
+	// every data node returns the first elements of its distinct values, which are merged to the first ones of the whole.
+	distinct := newElementDistinct(t.queryTemplate.GetDistinctByTag())
 	var result []*streamv1.Element
 	for iter.Next() {
-		result = append(result, iter.Val().Element)
+		if distinct.keep(iter.Val().Element) {
+			result = append(result, iter.Val().Element)
+		}
 	}
-	return result, allErr
+	return result, nil
 }
 
 func (t *distributedPlan) String() string {
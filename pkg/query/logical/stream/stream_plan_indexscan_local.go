@@ -57,7 +57,12 @@ type localIndexScan struct {
 	projectionTagRefs [][]*logical.TagRef
 	projectionTags    []model.TagProjection
 	entities          [][]*modelv1.TagValue
+	facetFields       []index.FacetField
+	traceID           string
+	spanID            string
+	elementIDs        []uint64
 	maxElementSize    int
+	withMetadata      bool
 }
 
 func (i *localIndexScan) Close() {
@@ -81,7 +86,7 @@ func (i *localIndexScan) Execute(ctx context.Context) ([]*streamv1.Element, erro
 	default:
 	}
 	if i.result != nil {
-		return BuildElementsFromStreamResult(ctx, i.result)
+		return i.buildElements(ctx)
 	}
 	var orderBy *index.OrderBy
 	if i.order != nil {
@@ -99,14 +104,52 @@ func (i *localIndexScan) Execute(ctx context.Context) ([]*streamv1.Element, erro
 		SkippingFilter: i.skippingFilter,
 		Order:          orderBy,
 		TagProjection:  i.projectionTags,
+		ElementIDs:     i.elementIDs,
+		TraceID:        i.traceID,
+		SpanID:         i.spanID,
 		MaxElementSize: i.maxElementSize,
+		WithMetadata:   i.withMetadata,
 	}); err != nil {
 		return nil, err
 	}
+	if err = i.collectFacets(ctx); err != nil {
+		return nil, err
+	}
 	if i.result == nil {
 		return nil, nil
 	}
-	return BuildElementsFromStreamResult(ctx, i.result)
+	return i.buildElements(ctx)
+}
+
+// buildElements attributes the elements to the group of the scan, which tells them apart in a multi-group query.
+func (i *localIndexScan) buildElements(ctx context.Context) ([]*streamv1.Element, error) {
+	elements, err := BuildElementsFromStreamResult(ctx, i.result)
+	for _, e := range elements {
+		e.Group = i.metadata.GetGroup()
+	}
+	return elements, err
+}
+
+func (i *localIndexScan) collectFacets(ctx context.Context) error {
+	if len(i.facetFields) == 0 {
+		return nil
+	}
+	fc := facetCollectorFrom(ctx)
+	if fc == nil {
+		return nil
+	}
+	facets, err := i.ec.Facets(ctx, model.StreamFacetOptions{
+		Name:           i.metadata.GetName(),
+		TimeRange:      &i.timeRange,
+		Entities:       i.entities,
+		InvertedFilter: i.invertedFilter,
+		Fields:         i.facetFields,
+	})
+	if err != nil {
+		return err
+	}
+	fc.addIndexFacets(i.facetFields, facets)
+	return nil
 }
 
 func (i *localIndexScan) String() string {
@@ -126,6 +169,22 @@ func (i *localIndexScan) Schema() logical.Schema {
 	return i.schema.ProjTags(i.projectionTagRefs...)
 }
 
+// parseElementIDs parses the IDs of the elements built by BuildElementsFromStreamResult.
+func parseElementIDs(ids []string) ([]uint64, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	result := make([]uint64, 0, len(ids))
+	for _, id := range ids {
+		b, err := hex.DecodeString(id)
+		if err != nil || len(b) != 8 {
+			return nil, fmt.Errorf("invalid element id %q", id)
+		}
+		result = append(result, convert.BytesToUint64(b))
+	}
+	return result, nil
+}
+
 // BuildElementsFromStreamResult builds a slice of elements from the given stream query result.
 func BuildElementsFromStreamResult(ctx context.Context, result model.StreamQueryResult) (elements []*streamv1.Element, err error) {
 	var r *model.StreamResult
@@ -146,6 +205,15 @@ func BuildElementsFromStreamResult(ctx context.Context, result model.StreamQuery
 			Timestamp: timestamppb.New(time.Unix(0, r.Timestamps[i])),
 			ElementId: hex.EncodeToString(convert.Uint64ToBytes(r.ElementIDs[i])),
 		}
+		if len(r.Metadata) > 0 {
+			m := r.Metadata[i]
+			e.Metadata = &streamv1.ElementMetadata{
+				Size:    m.Size,
+				ShardId: uint32(m.ShardID),
+				PartId:  m.PartID,
+				Segment: m.Segment,
+			}
+		}
 
 		for _, tf := range r.TagFamilies {
 			tagFamily := &modelv1.TagFamily{
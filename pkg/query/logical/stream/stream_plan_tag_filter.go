@@ -38,12 +38,17 @@ import (
 var _ logical.UnresolvedPlan = (*unresolvedTagFilter)(nil)
 
 type unresolvedTagFilter struct {
-	startTime      time.Time
-	endTime        time.Time
-	ec             executor.StreamExecutionContext
-	metadata       *commonv1.Metadata
-	criteria       *modelv1.Criteria
-	projectionTags [][]*logical.Tag
+	startTime           time.Time
+	endTime             time.Time
+	ec                  executor.StreamExecutionContext
+	metadata            *commonv1.Metadata
+	criteria            *modelv1.Criteria
+	facet               *streamv1.Facet
+	projectionTags      [][]*logical.Tag
+	traceID             string
+	spanID              string
+	elementIDs          []string
+	withElementMetadata bool
 }
 
 func (uis *unresolvedTagFilter) Analyze(s logical.Schema) (logical.Plan, error) {
@@ -66,6 +71,16 @@ func (uis *unresolvedTagFilter) Analyze(s logical.Schema) (logical.Plan, error)
 		return nil, err
 	}
 
+	if ctx.elementIDs, err = parseElementIDs(uis.elementIDs); err != nil {
+		return nil, err
+	}
+
+	if uis.facet != nil {
+		if ctx.facetFields, err = buildFacetFields(uis.facet, uis.criteria, s); err != nil {
+			return nil, err
+		}
+	}
+
 	projTags := make([]model.TagProjection, len(uis.projectionTags))
 	if len(uis.projectionTags) > 0 {
 		for i := range uis.projectionTags {
@@ -105,19 +120,25 @@ func (uis *unresolvedTagFilter) selectIndexScanner(ctx *analyzeContext, ec execu
 		invertedFilter:    ctx.invertedFilter,
 		skippingFilter:    ctx.skippingFilter,
 		entities:          ctx.entities,
+		facetFields:       ctx.facetFields,
+		elementIDs:        ctx.elementIDs,
+		traceID:           uis.traceID,
+		spanID:            uis.spanID,
+		withMetadata:      uis.withElementMetadata,
 		l:                 logger.GetLogger("query", "stream", "local-index"),
 		ec:                ec,
 	}
 }
 
-func tagFilter(startTime, endTime time.Time, metadata *commonv1.Metadata, criteria *modelv1.Criteria,
+func tagFilter(startTime, endTime time.Time, metadata *commonv1.Metadata, criteria *modelv1.Criteria, facet *streamv1.Facet,
 	projection [][]*logical.Tag, ec executor.StreamExecutionContext,
-) logical.UnresolvedPlan {
+) *unresolvedTagFilter {
 	return &unresolvedTagFilter{
 		startTime:      startTime,
 		endTime:        endTime,
 		metadata:       metadata,
 		criteria:       criteria,
+		facet:          facet,
 		projectionTags: projection,
 		ec:             ec,
 	}
@@ -129,8 +150,10 @@ type analyzeContext struct {
 	skippingFilter   index.Filter
 	entities         [][]*modelv1.TagValue
 	projectionTags   []model.TagProjection
+	facetFields      []index.FacetField
 	globalConditions []interface{}
 	projTagsRefs     [][]*logical.TagRef
+	elementIDs       []uint64
 }
 
 func newAnalyzerContext(s logical.Schema) *analyzeContext {
@@ -27,6 +27,7 @@ import (
 
 	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
 	"github.com/apache/skywalking-banyandb/pkg/convert"
+	"github.com/apache/skywalking-banyandb/pkg/index"
 	"github.com/apache/skywalking-banyandb/pkg/index/analyzer"
 )
 
@@ -87,6 +88,23 @@ func BuildTagFilter(criteria *modelv1.Criteria, entityDict map[string]int, index
 	switch criteria.GetExp().(type) {
 	case *modelv1.Criteria_Condition:
 		cond := criteria.GetCondition()
+		if IsNullCheck(cond.Op) {
+			if _, ok := entityDict[cond.Name]; ok {
+				return DummyFilter, nil
+			}
+			return parseNullCheckFilter(cond), nil
+		}
+		if IsPatternMatch(cond.Op) {
+			_, indexRule := indexChecker.IndexRuleDefined(cond.Name)
+			p, err := ParsePattern(cond, indexRule)
+			if err != nil {
+				return nil, err
+			}
+			if IsCaseInsensitive(indexRule) {
+				return newCaseInsensitiveTag(cond.Name, newPatternTag(cond.Name, p)), nil
+			}
+			return newPatternTag(cond.Name, p), nil
+		}
 		var expr ComparableExpr
 		var err error
 		_, indexRule := indexChecker.IndexRuleDefined(cond.Name)
@@ -97,9 +115,19 @@ func BuildTagFilter(criteria *modelv1.Criteria, entityDict map[string]int, index
 		if _, ok := entityDict[cond.Name]; ok {
 			return DummyFilter, nil
 		}
-		return parseFilter(cond, expr, indexChecker)
+		if !IsCaseInsensitive(indexRule) {
+			return parseFilter(cond, expr, indexChecker)
+		}
+		filter, err := parseFilter(cond, FoldCase(expr, indexRule).(ComparableExpr), indexChecker)
+		if err != nil {
+			return nil, err
+		}
+		return newCaseInsensitiveTag(cond.Name, filter), nil
 	case *modelv1.Criteria_Le:
 		le := criteria.GetLe()
+		if le.Op == modelv1.LogicalExpression_LOGICAL_OP_NOT {
+			return buildNotTagFilter(le, indexChecker, hasGlobalIndex)
+		}
 		left, err := BuildTagFilter(le.Left, entityDict, indexChecker, hasGlobalIndex)
 		if err != nil {
 			return nil, err
@@ -128,6 +156,23 @@ func BuildTagFilter(criteria *modelv1.Criteria, entityDict map[string]int, index
 	return nil, ErrInvalidCriteriaType
 }
 
+// buildNotTagFilter negates the inner filter. The entity tags are matched as the ordinary ones inside it,
+// because the series can't be excluded by their entities.
+func buildNotTagFilter(le *modelv1.LogicalExpression, indexChecker IndexChecker, hasGlobalIndex bool) (TagFilter, error) {
+	if hasGlobalIndex {
+		return nil, errors.WithMessage(errUnsupportedLogicalOperation, "global index doesn't support NOT")
+	}
+	negated, err := NegatedCriteria(le)
+	if err != nil {
+		return nil, err
+	}
+	inner, err := BuildTagFilter(negated, nil, indexChecker, hasGlobalIndex)
+	if err != nil {
+		return nil, err
+	}
+	return newNotTag(inner), nil
+}
+
 func parseFilter(cond *modelv1.Condition, expr ComparableExpr, indexChecker IndexChecker) (TagFilter, error) {
 	switch cond.Op {
 	case modelv1.Condition_BINARY_OP_GT:
@@ -167,6 +212,13 @@ func parseFilter(cond *modelv1.Condition, expr ComparableExpr, indexChecker Inde
 	}
 }
 
+func parseNullCheckFilter(cond *modelv1.Condition) TagFilter {
+	if cond.Op == modelv1.Condition_BINARY_OP_IS_NULL {
+		return newNullTag(cond.Name)
+	}
+	return newNotTag(newNullTag(cond.Name))
+}
+
 func parseExpr(value *modelv1.TagValue, analyzer *analysis.Analyzer) (ComparableExpr, error) {
 	if analyzer != nil {
 		if _, ok := value.Value.(*modelv1.TagValue_Str); ok {
@@ -362,15 +414,31 @@ func (n *notTag) String() string {
 
 type inTag struct {
 	*tagLeaf
+	strs map[string]struct{}
+	ints map[int64]struct{}
 }
 
 func newInTag(tagName string, values LiteralExpr) *inTag {
-	return &inTag{
+	t := &inTag{
 		tagLeaf: &tagLeaf{
 			Name: tagName,
 			Expr: values,
 		},
 	}
+	// a large list is looked up through a set instead of being scanned for every row.
+	switch v := values.(type) {
+	case *strArrLiteral:
+		t.strs = make(map[string]struct{}, len(v.arr))
+		for _, s := range v.arr {
+			t.strs[s] = struct{}{}
+		}
+	case *int64ArrLiteral:
+		t.ints = make(map[int64]struct{}, len(v.arr))
+		for _, i := range v.arr {
+			t.ints[i] = struct{}{}
+		}
+	}
+	return t
 }
 
 func (h *inTag) Match(accessor TagValueIndexAccessor, registry TagSpecRegistry) (bool, error) {
@@ -378,9 +446,86 @@ func (h *inTag) Match(accessor TagValueIndexAccessor, registry TagSpecRegistry)
 	if err != nil {
 		return false, err
 	}
+	switch v := expr.(type) {
+	case *strLiteral:
+		if h.strs != nil {
+			_, ok := h.strs[v.string]
+			return ok, nil
+		}
+	case *int64Literal:
+		if h.ints != nil {
+			_, ok := h.ints[v.int64]
+			return ok, nil
+		}
+	}
 	return expr.BelongTo(h.Expr), nil
 }
 
+func (h *inTag) MarshalJSON() ([]byte, error) {
+	elements := h.Expr.Elements()
+	inner := make(map[string]interface{}, 3)
+	inner["name"] = h.Name
+	inner["size"] = len(elements)
+	inner["values"] = FormatElements(elements)
+	data := make(map[string]interface{}, 1)
+	data["in"] = inner
+	return json.Marshal(data)
+}
+
+func (h *inTag) String() string {
+	return convert.JSONToString(h)
+}
+
+// caseInsensitiveTag matches the lowercase values of the tag against the lowercase literals of the inner filter.
+type caseInsensitiveTag struct {
+	TagFilter
+	name string
+}
+
+func newCaseInsensitiveTag(tagName string, inner TagFilter) *caseInsensitiveTag {
+	return &caseInsensitiveTag{
+		TagFilter: inner,
+		name:      tagName,
+	}
+}
+
+func (c *caseInsensitiveTag) Match(accessor TagValueIndexAccessor, registry TagSpecRegistry) (bool, error) {
+	return c.TagFilter.Match(&lowerCaseAccessor{TagValueIndexAccessor: accessor, spec: registry.FindTagSpecByName(c.name)}, registry)
+}
+
+func (c *caseInsensitiveTag) MarshalJSON() ([]byte, error) {
+	data := make(map[string]interface{}, 1)
+	data["case_insensitive"] = c.TagFilter
+	return json.Marshal(data)
+}
+
+func (c *caseInsensitiveTag) String() string {
+	return convert.JSONToString(c)
+}
+
+type lowerCaseAccessor struct {
+	TagValueIndexAccessor
+	spec *TagSpec
+}
+
+func (a *lowerCaseAccessor) GetTagValue(tagFamilyIdx, tagIdx int) *modelv1.TagValue {
+	tv := a.TagValueIndexAccessor.GetTagValue(tagFamilyIdx, tagIdx)
+	if a.spec == nil || a.spec.TagFamilyIdx != tagFamilyIdx || a.spec.TagIdx != tagIdx {
+		return tv
+	}
+	switch v := tv.GetValue().(type) {
+	case *modelv1.TagValue_Str:
+		return &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: strings.ToLower(v.Str.GetValue())}}}
+	case *modelv1.TagValue_StrArray:
+		arr := make([]string, len(v.StrArray.GetValue()))
+		for i := range v.StrArray.GetValue() {
+			arr[i] = strings.ToLower(v.StrArray.GetValue()[i])
+		}
+		return &modelv1.TagValue{Value: &modelv1.TagValue_StrArray{StrArray: &modelv1.StrArray{Value: arr}}}
+	}
+	return tv
+}
+
 type eqTag struct {
 	*tagLeaf
 }
@@ -575,3 +720,88 @@ func (h *havingTag) MarshalJSON() ([]byte, error) {
 func (h *havingTag) String() string {
 	return convert.JSONToString(h)
 }
+
+type nullTag struct {
+	*tagLeaf
+}
+
+func newNullTag(tagName string) *nullTag {
+	return &nullTag{
+		tagLeaf: &tagLeaf{
+			Name: tagName,
+			Expr: nullLiteralExpr,
+		},
+	}
+}
+
+func (n *nullTag) Match(accessor TagValueIndexAccessor, registry TagSpecRegistry) (bool, error) {
+	tagSpec := registry.FindTagSpecByName(n.Name)
+	if tagSpec == nil {
+		return false, errTagNotDefined
+	}
+	tagVal := accessor.GetTagValue(tagSpec.TagFamilyIdx, tagSpec.TagIdx)
+	if tagVal == nil || tagVal.GetValue() == nil {
+		return true, nil
+	}
+	_, isNull := tagVal.GetValue().(*modelv1.TagValue_Null)
+	return isNull, nil
+}
+
+func (n *nullTag) MarshalJSON() ([]byte, error) {
+	data := make(map[string]interface{}, 1)
+	data["is_null"] = n.Name
+	return json.Marshal(data)
+}
+
+func (n *nullTag) String() string {
+	return convert.JSONToString(n)
+}
+
+// patternTag matches the string values of the tag by a prefix or a wildcard pattern.
+// An array matches if any of its elements matches.
+type patternTag struct {
+	*tagLeaf
+	pattern index.TermPattern
+}
+
+func newPatternTag(tagName string, p index.TermPattern) *patternTag {
+	return &patternTag{
+		tagLeaf: &tagLeaf{
+			Name: tagName,
+			Expr: str(p.Value),
+		},
+		pattern: p,
+	}
+}
+
+func (p *patternTag) Match(accessor TagValueIndexAccessor, registry TagSpecRegistry) (bool, error) {
+	tagSpec := registry.FindTagSpecByName(p.Name)
+	if tagSpec == nil {
+		return false, errTagNotDefined
+	}
+	switch v := accessor.GetTagValue(tagSpec.TagFamilyIdx, tagSpec.TagIdx).GetValue().(type) {
+	case *modelv1.TagValue_Str:
+		return p.pattern.Match(v.Str.GetValue()), nil
+	case *modelv1.TagValue_StrArray:
+		for _, e := range v.StrArray.GetValue() {
+			if p.pattern.Match(e) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func (p *patternTag) MarshalJSON() ([]byte, error) {
+	data := make(map[string]interface{}, 1)
+	if p.pattern.Wildcard {
+		data["wildcard"] = p.tagLeaf
+	} else {
+		data["prefix"] = p.tagLeaf
+	}
+	return json.Marshal(data)
+}
+
+func (p *patternTag) String() string {
+	return convert.JSONToString(p)
+}
@@ -57,13 +57,16 @@ type TagProjection struct {
 
 // MeasureQueryOptions is the options of a measure query.
 type MeasureQueryOptions struct {
-	Query           index.Query
+	Query index.Query
+	// SkippingFilter prunes the blocks by the statistics of the tags.
+	SkippingFilter  index.Filter
 	TimeRange       *timestamp.TimeRange
 	Order           *index.OrderBy
 	Name            string
 	Entities        [][]*modelv1.TagValue
 	TagProjection   []TagProjection
 	FieldProjection []string
+	Latest          bool
 }
 
 // MeasureResult is the result of a query.
@@ -91,7 +94,24 @@ type StreamQueryOptions struct {
 	SkippingFilter index.Filter
 	Order          *index.OrderBy
 	TagProjection  []TagProjection
+	// ElementIDs restrict the query to the elements with the IDs if it's not empty.
+	ElementIDs []uint64
+	// TraceID restricts the query to the elements written with the trace ID if it's not empty,
+	// and SpanID narrows them down to the span.
+	TraceID        string
+	SpanID         string
 	MaxElementSize int
+	// WithMetadata attaches the storage metadata to the elements in the results.
+	WithMetadata bool
+}
+
+// StreamFacetOptions is the options of counting the tag values of a stream.
+type StreamFacetOptions struct {
+	TimeRange      *timestamp.TimeRange
+	InvertedFilter index.Filter
+	Name           string
+	Entities       [][]*modelv1.TagValue
+	Fields         []index.FacetField
 }
 
 // Reset resets the StreamQueryOptions.
@@ -103,7 +123,11 @@ func (s *StreamQueryOptions) Reset() {
 	s.SkippingFilter = nil
 	s.Order = nil
 	s.TagProjection = nil
+	s.ElementIDs = nil
+	s.TraceID = ""
+	s.SpanID = ""
 	s.MaxElementSize = 0
+	s.WithMetadata = false
 }
 
 // CopyFrom copies the StreamQueryOptions from other to s.
@@ -131,7 +155,19 @@ func (s *StreamQueryOptions) CopyFrom(other *StreamQueryOptions) {
 		s.TagProjection = nil
 	}
 
+	s.TraceID = other.TraceID
+	s.SpanID = other.SpanID
 	s.MaxElementSize = other.MaxElementSize
+	s.WithMetadata = other.WithMetadata
+}
+
+// ElementMetadata is the storage metadata of an element.
+type ElementMetadata struct {
+	Segment string
+	// Size is the approximate bytes of the element in the storage.
+	Size    uint64
+	PartID  uint64
+	ShardID common.ShardID
 }
 
 // StreamResult is the result of a query.
@@ -141,9 +177,11 @@ type StreamResult struct {
 	ElementIDs  []uint64
 	TagFamilies []TagFamily
 	SIDs        []common.SeriesID
-	topN        int
-	idx         int
-	asc         bool
+	// Metadata is aligned with the elements if the query asks for it, otherwise it's empty.
+	Metadata []ElementMetadata
+	topN     int
+	idx      int
+	asc      bool
 }
 
 // NewStreamResult creates a new StreamResult.
@@ -175,6 +213,7 @@ func (sr *StreamResult) Reset() {
 	sr.ElementIDs = sr.ElementIDs[:0]
 	sr.TagFamilies = sr.TagFamilies[:0]
 	sr.SIDs = sr.SIDs[:0]
+	sr.Metadata = sr.Metadata[:0]
 }
 
 // CopyFrom copies the topN results from other to sr using tmp as a temporary result.
@@ -214,6 +253,7 @@ func (sr *StreamResult) CopyFrom(tmp, other *StreamResult) bool {
 	sr.ElementIDs = append(sr.ElementIDs, tmp.ElementIDs...)
 	sr.SIDs = append(sr.SIDs, tmp.SIDs...)
 	sr.TagFamilies = append(sr.TagFamilies, tmp.TagFamilies...)
+	sr.Metadata = append(sr.Metadata, tmp.Metadata...)
 
 	return len(sr.Timestamps) >= sr.topN
 }
@@ -223,6 +263,9 @@ func (sr *StreamResult) CopySingleFrom(other *StreamResult) {
 	sr.SIDs = append(sr.SIDs, other.SIDs[other.idx])
 	sr.Timestamps = append(sr.Timestamps, other.Timestamps[other.idx])
 	sr.ElementIDs = append(sr.ElementIDs, other.ElementIDs[other.idx])
+	if len(other.Metadata) > 0 {
+		sr.Metadata = append(sr.Metadata, other.Metadata[other.idx])
+	}
 	if len(sr.TagFamilies) < len(other.TagFamilies) {
 		for i := range other.TagFamilies {
 			tf := TagFamily{
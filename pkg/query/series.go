@@ -0,0 +1,182 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package query
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/convert"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+)
+
+type (
+	seriesLimitKey     struct{}
+	seriesAdmissionKey struct{}
+)
+
+// SeriesAdmission returns how many of the groups read by a query are affordable, or the error rejecting the query.
+// groups is the cardinality of the group-by key among the series matched by the query, which is estimated from the series index.
+type SeriesAdmission func(series, groups int) (int, error)
+
+type seriesAdmission struct {
+	admit   SeriesAdmission
+	keyTags []string
+}
+
+type seriesLimit struct {
+	overflow *measurev1.SeriesOverflow
+	limit    int
+	mu       sync.Mutex
+}
+
+// WithSeriesLimit returns the context capping the series read by a measure query at limit, 0 means unlimited.
+// The series counted by the query are reported by GetSeriesOverflow.
+func WithSeriesLimit(ctx context.Context, limit int) context.Context {
+	return context.WithValue(ctx, seriesLimitKey{}, &seriesLimit{limit: max(limit, 0)})
+}
+
+// WithSeriesAdmission returns the context admitting the groups read by a query with admit.
+// keyTags are the tags of the group-by key, whose values of the series are stored in the series index.
+// Each series is a group if keyTags is empty or the index doesn't store their values.
+func WithSeriesAdmission(ctx context.Context, admit SeriesAdmission, keyTags []string) context.Context {
+	return context.WithValue(ctx, seriesAdmissionKey{}, &seriesAdmission{admit: admit, keyTags: keyTags})
+}
+
+// SampleSeries caps sids at the limit of the context, and keeps the series of the groups its admission affords.
+// indexedValues are the tag values of the series stored in the series index, from which the group-by keys are taken.
+// The groups and then the series with the smallest hashes are kept in their original order.
+// The IDs are the hashes of the series, so the sample is deterministic and spreads over the groups and the series evenly.
+// It returns the error of the admission rejecting the query.
+func SampleSeries(ctx context.Context, sids []common.SeriesID, indexedValues map[common.SeriesID]map[string]*modelv1.TagValue) ([]common.SeriesID, error) {
+	sl, _ := ctx.Value(seriesLimitKey{}).(*seriesLimit)
+	var limit int
+	if sl != nil {
+		limit = sl.limit
+	}
+	sa, admitted := ctx.Value(seriesAdmissionKey{}).(*seriesAdmission)
+	if limit == 0 && !admitted {
+		return sids, nil
+	}
+	sampled := sids
+	if admitted {
+		keys, groups := groupKeys(sids, indexedValues, sa.keyTags)
+		affordable, err := sa.admit(len(sids), groups)
+		if err != nil {
+			return nil, err
+		}
+		if affordable < groups {
+			sampled = sampleSmallest(sids, keys, max(affordable, 1))
+		}
+	}
+	if limit > 0 && len(sampled) > limit {
+		keys := make([]uint64, len(sampled))
+		for i := range sampled {
+			keys[i] = uint64(sampled[i])
+		}
+		sampled = sampleSmallest(sampled, keys, limit)
+	}
+	if sl != nil {
+		sl.report(&measurev1.SeriesOverflow{Total: uint64(len(sids)), Sampled: uint64(len(sampled))})
+	}
+	return sampled, nil
+}
+
+// groupKeys returns the hashes of the group-by keys of sids and their cardinality.
+func groupKeys(sids []common.SeriesID, indexedValues map[common.SeriesID]map[string]*modelv1.TagValue, keyTags []string) ([]uint64, int) {
+	keys := make([]uint64, len(sids))
+	distinct := make(map[uint64]struct{}, len(sids))
+	values := make([]*modelv1.TagValue, len(keyTags))
+	var buf []byte
+	for i, sid := range sids {
+		keys[i] = uint64(sid)
+		if len(keyTags) > 0 {
+			if key, ok := hashKey(buf[:0], indexedValues[sid], keyTags, values); ok {
+				keys[i] = key
+			}
+		}
+		distinct[keys[i]] = struct{}{}
+	}
+	return keys, len(distinct)
+}
+
+func hashKey(buf []byte, tagValues map[string]*modelv1.TagValue, keyTags []string, values []*modelv1.TagValue) (uint64, bool) {
+	for i, tag := range keyTags {
+		v, ok := tagValues[tag]
+		if !ok {
+			return 0, false
+		}
+		values[i] = v
+	}
+	buf, err := pbv1.MarshalTagValues(buf, values)
+	if err != nil {
+		return 0, false
+	}
+	return convert.Hash(buf), true
+}
+
+// sampleSmallest keeps the items of the n smallest distinct keys in their original order.
+func sampleSmallest(sids []common.SeriesID, keys []uint64, n int) []common.SeriesID {
+	sorted := slices.Compact(slices.Sorted(slices.Values(keys)))
+	if len(sorted) <= n {
+		return sids
+	}
+	threshold := sorted[n-1]
+	sampled := make([]common.SeriesID, 0, n)
+	for i, sid := range sids {
+		if keys[i] <= threshold {
+			sampled = append(sampled, sid)
+		}
+	}
+	return sampled
+}
+
+// ReportSeriesOverflow adds the series counted by a part of the query, e.g. a data node, to the query of the context.
+func ReportSeriesOverflow(ctx context.Context, overflow *measurev1.SeriesOverflow) {
+	sl, ok := ctx.Value(seriesLimitKey{}).(*seriesLimit)
+	if !ok || overflow == nil {
+		return
+	}
+	sl.report(overflow)
+}
+
+// GetSeriesOverflow returns the series counted by the query of the context, or nil if none is counted.
+// All the series are read if the sampled ones are as many as the total.
+func GetSeriesOverflow(ctx context.Context) *measurev1.SeriesOverflow {
+	sl, ok := ctx.Value(seriesLimitKey{}).(*seriesLimit)
+	if !ok {
+		return nil
+	}
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+	return sl.overflow
+}
+
+func (sl *seriesLimit) report(overflow *measurev1.SeriesOverflow) {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+	if sl.overflow == nil {
+		sl.overflow = &measurev1.SeriesOverflow{}
+	}
+	sl.overflow.Total += overflow.GetTotal()
+	sl.overflow.Sampled += overflow.GetSampled()
+}
@@ -0,0 +1,133 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package query
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+)
+
+func TestSampleSeries(t *testing.T) {
+	sids := []common.SeriesID{50, 10, 40, 20, 30}
+
+	assert.Equal(t, sids, mustSampleSeries(t, context.Background(), sids, nil), "the series are unlimited without a limit")
+	assert.Nil(t, GetSeriesOverflow(context.Background()))
+
+	ctx := WithSeriesLimit(context.Background(), 0)
+	assert.Equal(t, sids, mustSampleSeries(t, ctx, sids, nil))
+	assert.Nil(t, GetSeriesOverflow(ctx), "the series aren't counted if they're unlimited")
+
+	ctx = WithSeriesLimit(context.Background(), 3)
+	sampled := mustSampleSeries(t, ctx, sids, nil)
+	assert.Equal(t, []common.SeriesID{10, 20, 30}, sampled, "the smallest series are kept in their original order")
+	assert.ElementsMatch(t, sampled, mustSampleSeries(t, WithSeriesLimit(context.Background(), 3), []common.SeriesID{30, 40, 20, 50, 10}, nil),
+		"the sample doesn't depend on the order of the series")
+	require.NotNil(t, GetSeriesOverflow(ctx))
+	assert.Equal(t, uint64(5), GetSeriesOverflow(ctx).Total)
+	assert.Equal(t, uint64(3), GetSeriesOverflow(ctx).Sampled)
+
+	mustSampleSeries(t, ctx, []common.SeriesID{1, 2}, nil)
+	ReportSeriesOverflow(ctx, &measurev1.SeriesOverflow{Total: 10, Sampled: 3})
+	ReportSeriesOverflow(ctx, nil)
+	assert.Equal(t, uint64(17), GetSeriesOverflow(ctx).Total)
+	assert.Equal(t, uint64(8), GetSeriesOverflow(ctx).Sampled)
+}
+
+func TestSampleSeriesAdmission(t *testing.T) {
+	sids := []common.SeriesID{50, 10, 40, 20, 30}
+	errRejected := errors.New("rejected")
+	affordable := func(n int) SeriesAdmission {
+		return func(_, groups int) (int, error) {
+			if n == 0 {
+				return 0, errRejected
+			}
+			return min(groups, n), nil
+		}
+	}
+
+	ctx := WithSeriesAdmission(WithSeriesLimit(context.Background(), 0), affordable(0), nil)
+	_, err := SampleSeries(ctx, sids, nil)
+	assert.ErrorIs(t, err, errRejected)
+
+	ctx = WithSeriesAdmission(WithSeriesLimit(context.Background(), 0), affordable(2), nil)
+	assert.Equal(t, []common.SeriesID{10, 20}, mustSampleSeries(t, ctx, sids, nil), "the query is degraded to the affordable series")
+	assert.Equal(t, &measurev1.SeriesOverflow{Total: 5, Sampled: 2}, GetSeriesOverflow(ctx))
+
+	ctx = WithSeriesAdmission(WithSeriesLimit(context.Background(), 1), affordable(2), nil)
+	assert.Equal(t, []common.SeriesID{10}, mustSampleSeries(t, ctx, sids, nil), "the lower limit applies")
+
+	ctx = WithSeriesAdmission(WithSeriesLimit(context.Background(), 0), affordable(10), nil)
+	assert.Equal(t, sids, mustSampleSeries(t, ctx, sids, nil))
+	assert.Equal(t, &measurev1.SeriesOverflow{Total: 5, Sampled: 5}, GetSeriesOverflow(ctx), "the admitted series are counted")
+}
+
+func TestSampleSeriesGroups(t *testing.T) {
+	sids := []common.SeriesID{50, 10, 40, 20, 30}
+	svc := func(name string) map[string]*modelv1.TagValue {
+		return map[string]*modelv1.TagValue{"service": {Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: name}}}}
+	}
+	indexedValues := map[common.SeriesID]map[string]*modelv1.TagValue{
+		50: svc("a"), 10: svc("b"), 40: svc("a"), 20: svc("c"), 30: svc("b"),
+	}
+	var gotSeries, gotGroups int
+	affordable := func(n int) SeriesAdmission {
+		return func(series, groups int) (int, error) {
+			gotSeries, gotGroups = series, groups
+			return min(groups, n), nil
+		}
+	}
+
+	ctx := WithSeriesAdmission(WithSeriesLimit(context.Background(), 0), affordable(3), []string{"service"})
+	assert.Equal(t, sids, mustSampleSeries(t, ctx, sids, indexedValues))
+	assert.Equal(t, 5, gotSeries)
+	assert.Equal(t, 3, gotGroups, "the groups are the distinct keys in the series index")
+
+	ctx = WithSeriesAdmission(WithSeriesLimit(context.Background(), 0), affordable(2), []string{"service"})
+	sampled := mustSampleSeries(t, ctx, sids, indexedValues)
+	groups := make(map[string]int)
+	for _, sid := range sampled {
+		groups[indexedValues[sid]["service"].GetStr().GetValue()]++
+	}
+	assert.Len(t, groups, 2, "the series of the affordable groups are kept")
+	for name, n := range groups {
+		expected := 2
+		if name == "c" {
+			expected = 1
+		}
+		assert.Equal(t, expected, n, "all the series of a kept group are read")
+	}
+	assert.Equal(t, &measurev1.SeriesOverflow{Total: 5, Sampled: uint64(len(sampled))}, GetSeriesOverflow(ctx))
+
+	ctx = WithSeriesAdmission(WithSeriesLimit(context.Background(), 0), affordable(10), []string{"endpoint"})
+	mustSampleSeries(t, ctx, sids, indexedValues)
+	assert.Equal(t, 5, gotGroups, "each series is a group if the index doesn't store the key")
+}
+
+func mustSampleSeries(t *testing.T, ctx context.Context, sids []common.SeriesID, indexedValues map[common.SeriesID]map[string]*modelv1.TagValue) []common.SeriesID {
+	sampled, err := SampleSeries(ctx, sids, indexedValues)
+	require.NoError(t, err)
+	return sampled
+}
@@ -0,0 +1,156 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// SlowQueryPath is the HTTP path of the recorded slow queries.
+const SlowQueryPath = "/api/debug/query/slow-queries"
+
+const maxSlowQueryRequest = 4096
+
+// SlowQuery is a query slower than the slow query threshold of the query service.
+type SlowQuery struct {
+	Time    time.Time `json:"time"`
+	Kind    string    `json:"kind"`
+	Request string    `json:"request"`
+	// Latency is in nanoseconds.
+	Latency time.Duration `json:"latency"`
+	Results int           `json:"results"`
+}
+
+var slowQueryRecorders = struct {
+	recorders map[string]*SlowQueryRecorder
+	mu        sync.RWMutex
+}{recorders: make(map[string]*SlowQueryRecorder)}
+
+// SlowQueryRecorder keeps the last slow queries of a query service in a ring buffer.
+type SlowQueryRecorder struct {
+	name    string
+	queries []SlowQuery
+	next    int
+	mu      sync.Mutex
+}
+
+// NewSlowQueryRecorder returns a recorder keeping the last capacity slow queries.
+// The recorder is served by ServeSlowQueries with the name. It returns nil if capacity isn't positive, which records nothing.
+func NewSlowQueryRecorder(name string, capacity int) *SlowQueryRecorder {
+	if capacity <= 0 {
+		return nil
+	}
+	r := &SlowQueryRecorder{
+		name:    name,
+		queries: make([]SlowQuery, 0, capacity),
+	}
+	slowQueryRecorders.mu.Lock()
+	defer slowQueryRecorders.mu.Unlock()
+	slowQueryRecorders.recorders[name] = r
+	return r
+}
+
+// Capture records the slow query of the kind, e.g. "stream", "measure" or "top_n".
+func (r *SlowQueryRecorder) Capture(kind string, latency time.Duration, req proto.Message, results int) {
+	if r == nil {
+		return
+	}
+	q := SlowQuery{
+		Time:    time.Now(),
+		Kind:    kind,
+		Request: describeRequest(req),
+		Latency: latency,
+		Results: results,
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.queries) < cap(r.queries) {
+		r.queries = append(r.queries, q)
+		return
+	}
+	r.queries[r.next] = q
+	r.next = (r.next + 1) % len(r.queries)
+}
+
+// Queries returns the recorded slow queries from the oldest to the latest.
+func (r *SlowQueryRecorder) Queries() []SlowQuery {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	qq := make([]SlowQuery, 0, len(r.queries))
+	qq = append(qq, r.queries[r.next:]...)
+	return append(qq, r.queries[:r.next]...)
+}
+
+// Close stops serving the recorder.
+func (r *SlowQueryRecorder) Close() {
+	if r == nil {
+		return
+	}
+	slowQueryRecorders.mu.Lock()
+	defer slowQueryRecorders.mu.Unlock()
+	if slowQueryRecorders.recorders[r.name] == r {
+		delete(slowQueryRecorders.recorders, r.name)
+	}
+}
+
+// SlowQueries returns the slow queries of the recorders keyed by their names.
+func SlowQueries() map[string][]SlowQuery {
+	slowQueryRecorders.mu.RLock()
+	names := make([]string, 0, len(slowQueryRecorders.recorders))
+	recorders := make([]*SlowQueryRecorder, 0, len(slowQueryRecorders.recorders))
+	for name, r := range slowQueryRecorders.recorders {
+		names = append(names, name)
+		recorders = append(recorders, r)
+	}
+	slowQueryRecorders.mu.RUnlock()
+	result := make(map[string][]SlowQuery, len(names))
+	for i := range names {
+		result[names[i]] = recorders[i].Queries()
+	}
+	return result
+}
+
+// ServeSlowQueries writes the slow queries of the recorders in JSON.
+func ServeSlowQueries(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(SlowQueries())
+}
+
+func describeRequest(req proto.Message) string {
+	if req == nil {
+		return ""
+	}
+	b, err := protojson.Marshal(req)
+	if err != nil {
+		return fmt.Sprintf("%T", req)
+	}
+	if len(b) > maxSlowQueryRequest {
+		return string(b[:maxSlowQueryRequest]) + "..."
+	}
+	return string(b)
+}
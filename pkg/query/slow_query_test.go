@@ -0,0 +1,76 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package query
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+)
+
+func TestSlowQueryRecorder(t *testing.T) {
+	assert.Nil(t, NewSlowQueryRecorder("disabled", 0))
+	// the nil recorder disables the capture.
+	var disabled *SlowQueryRecorder
+	disabled.Capture("measure", time.Second, nil, 0)
+	assert.Empty(t, disabled.Queries())
+	disabled.Close()
+
+	r := NewSlowQueryRecorder("test", 2)
+	defer r.Close()
+	for i := 0; i < 3; i++ {
+		r.Capture("measure", time.Duration(i)*time.Second, &measurev1.QueryRequest{Name: "service_cpm"}, i)
+	}
+	qq := r.Queries()
+	require.Len(t, qq, 2)
+	for i, q := range qq {
+		assert.Equal(t, time.Duration(i+1)*time.Second, q.Latency)
+		assert.Equal(t, i+1, q.Results)
+		assert.Contains(t, q.Request, "service_cpm")
+		assert.False(t, q.Time.IsZero())
+	}
+
+	r.Capture("measure", time.Second, &measurev1.QueryRequest{Name: strings.Repeat("n", maxSlowQueryRequest)}, 0)
+	qq = r.Queries()
+	assert.True(t, strings.HasSuffix(qq[len(qq)-1].Request, "..."))
+}
+
+func TestServeSlowQueries(t *testing.T) {
+	r := NewSlowQueryRecorder("served", 1)
+	r.Capture("stream", time.Second, nil, 1)
+
+	rec := httptest.NewRecorder()
+	ServeSlowQueries(rec, httptest.NewRequest(http.MethodGet, SlowQueryPath, nil))
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var got map[string][]SlowQuery
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	require.Len(t, got["served"], 1)
+	assert.Equal(t, "stream", got["served"][0].Kind)
+
+	// the closed recorders aren't served.
+	r.Close()
+	assert.NotContains(t, SlowQueries(), "served")
+}
@@ -24,6 +24,7 @@ import (
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	"github.com/apache/skywalking-banyandb/pkg/tracecontext"
 )
 
 var (
@@ -42,11 +43,15 @@ type Tracer struct {
 }
 
 // NewTracer creates a new tracer.
+// The tracer takes the trace id of the request's trace context, if there is one, instead of id.
 func NewTracer(ctx context.Context, id string) (*Tracer, context.Context) {
 	tracer := GetTracer(ctx)
 	if tracer != nil {
 		return tracer, ctx
 	}
+	if tc, ok := tracecontext.FromContext(ctx); ok {
+		id = tc.TraceID
+	}
 	t := &Tracer{
 		data: &commonv1.Trace{
 			TraceId: id,
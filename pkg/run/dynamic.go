@@ -0,0 +1,170 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package run
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+const dynamicAnnotation = "dynamic"
+
+// DynamicDuration is a time.Duration flag which can be changed at runtime.
+type DynamicDuration struct {
+	v atomic.Int64
+}
+
+// Load returns the current value.
+func (d *DynamicDuration) Load() time.Duration {
+	return time.Duration(d.v.Load())
+}
+
+// String returns a string representation of the value.
+func (d *DynamicDuration) String() string {
+	return d.Load().String()
+}
+
+// Set sets the value from the input string.
+func (d *DynamicDuration) Set(s string) error {
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	d.v.Store(int64(v))
+	return nil
+}
+
+// Type returns the type name of the flag.
+func (d *DynamicDuration) Type() string {
+	return "duration"
+}
+
+// DynamicInt is an int flag which can be changed at runtime.
+type DynamicInt struct {
+	v atomic.Int64
+}
+
+// Load returns the current value.
+func (d *DynamicInt) Load() int {
+	return int(d.v.Load())
+}
+
+// String returns a string representation of the value.
+func (d *DynamicInt) String() string {
+	return strconv.Itoa(d.Load())
+}
+
+// Set sets the value from the input string.
+func (d *DynamicInt) Set(s string) error {
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return err
+	}
+	d.v.Store(int64(v))
+	return nil
+}
+
+// Type returns the type name of the flag.
+func (d *DynamicInt) Type() string {
+	return "int"
+}
+
+// DynamicDurationVar defines a time.Duration flag which can be changed at runtime by the dynamic flags.
+func (fs *FlagSet) DynamicDurationVar(p *DynamicDuration, name string, value time.Duration, usage string) {
+	p.v.Store(int64(value))
+	fs.Var(p, name, usage)
+	_ = fs.SetAnnotation(name, dynamicAnnotation, []string{"true"})
+}
+
+// DynamicIntVar defines an int flag which can be changed at runtime by the dynamic flags.
+func (fs *FlagSet) DynamicIntVar(p *DynamicInt, name string, value int, usage string) {
+	p.v.Store(int64(value))
+	fs.Var(p, name, usage)
+	_ = fs.SetAnnotation(name, dynamicAnnotation, []string{"true"})
+}
+
+// DynamicFlags holds the flags of a Group which can be changed at runtime.
+type DynamicFlags struct {
+	flags map[string]*dynamicFlag
+	mu    sync.Mutex
+}
+
+type dynamicFlag struct {
+	flag    *pflag.Flag
+	initial string
+}
+
+// NewDynamicFlags returns the dynamic flags of the FlagSet.
+// The current values of the flags are restored when their dynamic values are reset.
+func NewDynamicFlags(fs *pflag.FlagSet) *DynamicFlags {
+	d := &DynamicFlags{flags: make(map[string]*dynamicFlag)}
+	if fs == nil {
+		return d
+	}
+	fs.VisitAll(func(f *pflag.Flag) {
+		if _, ok := f.Annotations[dynamicAnnotation]; ok {
+			d.flags[f.Name] = &dynamicFlag{flag: f, initial: f.Value.String()}
+		}
+	})
+	return d
+}
+
+// Names returns the sorted names of the dynamic flags.
+func (d *DynamicFlags) Names() []string {
+	names := make([]string, 0, len(d.flags))
+	for name := range d.flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Has returns true if the flag can be changed at runtime.
+func (d *DynamicFlags) Has(name string) bool {
+	_, ok := d.flags[name]
+	return ok
+}
+
+// Set changes the value of the flag.
+func (d *DynamicFlags) Set(name, value string) error {
+	f, ok := d.flags[name]
+	if !ok {
+		return fmt.Errorf("flag %s can't be changed at runtime", name)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := f.flag.Value.Set(value); err != nil {
+		return fmt.Errorf("invalid value %q of flag %s: %w", value, name, err)
+	}
+	return nil
+}
+
+// Reset restores the flag to the value on the command line, the environment variables or the configuration file.
+func (d *DynamicFlags) Reset(name string) error {
+	f, ok := d.flags[name]
+	if !ok {
+		return fmt.Errorf("flag %s can't be changed at runtime", name)
+	}
+	return d.Set(name, f.initial)
+}
@@ -0,0 +1,56 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package run
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDynamicFlags(t *testing.T) {
+	fs := NewFlagSet("test")
+	var slowQuery DynamicDuration
+	var maxListSize DynamicInt
+	var static int
+	fs.DynamicDurationVar(&slowQuery, "slow-query", time.Second, "")
+	fs.DynamicIntVar(&maxListSize, "max-list-size", 10, "")
+	fs.IntVar(&static, "static", 1, "")
+	require.NoError(t, fs.Parse([]string{"--slow-query=2s"}))
+
+	flags := NewDynamicFlags(fs.FlagSet)
+	assert.Equal(t, []string{"max-list-size", "slow-query"}, flags.Names())
+	assert.False(t, flags.Has("static"))
+	assert.Error(t, flags.Set("static", "2"))
+
+	require.NoError(t, flags.Set("slow-query", "3s"))
+	require.NoError(t, flags.Set("max-list-size", "20"))
+	assert.Equal(t, 3*time.Second, slowQuery.Load())
+	assert.Equal(t, 20, maxListSize.Load())
+
+	assert.Error(t, flags.Set("max-list-size", "x"))
+	assert.Equal(t, 20, maxListSize.Load())
+
+	// the values on the command line are restored
+	require.NoError(t, flags.Reset("slow-query"))
+	require.NoError(t, flags.Reset("max-list-size"))
+	assert.Equal(t, 2*time.Second, slowQuery.Load())
+	assert.Equal(t, 10, maxListSize.Load())
+}
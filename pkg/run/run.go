@@ -108,28 +108,58 @@ type Service interface {
 	GracefulStop()
 }
 
+// ShutdownPhase decides when a Service is stopped in the ordered shutdown of a Group.
+// The phases are stopped in ascending order, so that the upstream Services stop
+// feeding the downstream ones before the latter are closed.
+type ShutdownPhase int
+
+// The shutdown phases.
+const (
+	// ShutdownPhaseIngress stops accepting requests from clients.
+	ShutdownPhaseIngress ShutdownPhase = iota
+	// ShutdownPhaseQuery finishes or cancels the in-flight queries.
+	ShutdownPhaseQuery
+	// ShutdownPhaseQueue drains the in-flight messages of the queues.
+	ShutdownPhaseQueue
+	// ShutdownPhaseStorage flushes the in-memory data and closes the storage.
+	ShutdownPhaseStorage
+	// ShutdownPhaseDefault stops the rest, e.g. the metadata and observability Services.
+	ShutdownPhaseDefault
+)
+
+// PhasedService is implemented by the Service Units which should be stopped in a specific phase.
+// A Service which doesn't implement it is stopped in ShutdownPhaseDefault.
+type PhasedService interface {
+	Service
+	ShutdownPhase() ShutdownPhase
+}
+
 // Group builds on https://github.com/oklog/run to provide a deterministic way
 // to manage service lifecycles. It allows for easy composition of elegant
 // monoliths as well as adding signal handlers, metrics services, etc.
 type Group struct {
-	f            *FlagSet
-	readyCh      chan struct{}
-	log          *logger.Logger
-	name         string
-	r            run.Group
-	c            []Config
-	p            []PreRunner
-	s            []Service
-	rr           []Role
-	showRunGroup bool
-	configured   bool
+	f                   *FlagSet
+	readyCh             chan struct{}
+	log                 *logger.Logger
+	name                string
+	r                   run.Group
+	c                   []Config
+	p                   []PreRunner
+	s                   []Service
+	rr                  []Role
+	shutdownGracePeriod time.Duration
+	showRunGroup        bool
+	configured          bool
 }
 
+const defaultShutdownGracePeriod = 30 * time.Second
+
 // NewGroup return a Group with input name.
 func NewGroup(name string) Group {
 	return Group{
-		name:    name,
-		readyCh: make(chan struct{}),
+		name:                name,
+		readyCh:             make(chan struct{}),
+		shutdownGracePeriod: defaultShutdownGracePeriod,
 	}
 }
 
@@ -231,6 +261,8 @@ func (g *Group) RegisterFlags() *FlagSet {
 	gFS.SortFlags = false
 	gFS.StringVarP(&g.name, "name", "n", g.name, `name of this service`)
 	gFS.BoolVar(&g.showRunGroup, "show-rungroup-units", false, "show rungroup units")
+	gFS.DurationVar(&g.shutdownGracePeriod, "shutdown-grace-period", g.shutdownGracePeriod,
+		"the maximum duration to drain the requests, queries and queued messages before flushing and closing the storage")
 	g.f.AddFlagSet(gFS.FlagSet)
 
 	// register flags from attached Config objects
@@ -337,7 +369,10 @@ func (g *Group) RunConfig() (interrupted bool, err error) {
 //	Service phase (concurrently)
 //	  - Serve()          Execute all Service Units in separate Go routines.
 //	  - Wait             Block until one of the Serve() methods returns
-//	  - GracefulStop()   Call interrupt handlers of all Service Units.
+//	  - GracefulStop()   Call interrupt handlers of all Service Units phase by phase,
+//	                     see ShutdownPhase. The Units of a phase are stopped in the
+//	                     reverse order of registration. The phases before
+//	                     ShutdownPhaseStorage are bounded by the shutdown grace period.
 //
 //	Run will return with the originating error on:
 //	- first Config.Validate()  returning an error
@@ -387,6 +422,11 @@ func (g *Group) Run(ctx context.Context) (err error) {
 	if ctx == nil {
 		ctx = context.Background()
 	}
+	var fs *pflag.FlagSet
+	if g.f != nil {
+		fs = g.f.FlagSet
+	}
+	ctx = context.WithValue(ctx, common.ContextDynamicFlagsKey, NewDynamicFlags(fs))
 	// execute pre run stage and exit on error
 	for idx := range g.p {
 		// a PreRunner might have been deregistered during Run
@@ -407,6 +447,7 @@ func (g *Group) Run(ctx context.Context) (err error) {
 		close(g.readyCh)
 	}()
 	// feed our registered services to our internal run.Group
+	served := 0
 	for idx := range g.s {
 		// a Service might have been deregistered during Run
 		s := g.s[idx]
@@ -421,10 +462,20 @@ func (g *Group) Run(ctx context.Context) (err error) {
 			<-notify
 			return nil
 		}, func(_ error) {
-			g.log.Debug().Uint32("total", uint32(len(g.s))).Uint32("ran", uint32(idx+1)).Str("name", s.Name()).Msg("stopping")
-			startTime := time.Now()
-			s.GracefulStop()
-			g.log.Info().Dur("elapsed", time.Since(startTime)).Str("name", s.Name()).Msg("stopped")
+			// the services are stopped by the shutdown actor.
+		})
+		served++
+	}
+	if served > 0 {
+		// run.Group calls the interrupt functions one by one, so the shutdown actor stops
+		// all the services in order before the others return.
+		shutdownCh := make(chan struct{})
+		g.r.Add(func() error {
+			<-shutdownCh
+			return nil
+		}, func(_ error) {
+			g.shutdown()
+			close(shutdownCh)
 		})
 	}
 
@@ -432,6 +483,52 @@ func (g *Group) Run(ctx context.Context) (err error) {
 	return g.r.Run()
 }
 
+func (g *Group) shutdown() {
+	deadline := time.Now().Add(g.shutdownGracePeriod)
+	for phase := ShutdownPhaseIngress; phase <= ShutdownPhaseDefault; phase++ {
+		for idx := len(g.s) - 1; idx >= 0; idx-- {
+			s := g.s[idx]
+			if s == nil || shutdownPhaseOf(s) != phase {
+				continue
+			}
+			g.stop(s, deadline)
+		}
+	}
+}
+
+func (g *Group) stop(s Service, deadline time.Time) {
+	phase := shutdownPhaseOf(s)
+	g.log.Debug().Str("name", s.Name()).Int("phase", int(phase)).Msg("stopping")
+	startTime := time.Now()
+	if phase >= ShutdownPhaseStorage {
+		// abandoning the storage loses the in-memory data, so it's not bounded by the grace period.
+		s.GracefulStop()
+		g.log.Info().Dur("elapsed", time.Since(startTime)).Str("name", s.Name()).Msg("stopped")
+		return
+	}
+	stopped := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(stopped)
+	}()
+	t := time.NewTimer(time.Until(deadline))
+	defer t.Stop()
+	select {
+	case <-stopped:
+		g.log.Info().Dur("elapsed", time.Since(startTime)).Str("name", s.Name()).Msg("stopped")
+	case <-t.C:
+		g.log.Warn().Dur("elapsed", time.Since(startTime)).Str("name", s.Name()).
+			Msg("the shutdown grace period elapsed, stop the next service without waiting")
+	}
+}
+
+func shutdownPhaseOf(s Service) ShutdownPhase {
+	if ps, ok := s.(PhasedService); ok {
+		return ps.ShutdownPhase()
+	}
+	return ShutdownPhaseDefault
+}
+
 // ListUnits returns a list of all Group phases and the Units registered to each
 // of them.
 func (g Group) ListUnits() string {
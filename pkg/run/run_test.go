@@ -0,0 +1,117 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package run
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+)
+
+type stopRecorder struct {
+	stopped []string
+	mu      sync.Mutex
+}
+
+func (r *stopRecorder) record(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stopped = append(r.stopped, name)
+}
+
+func (r *stopRecorder) list() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.stopped...)
+}
+
+type phasedService struct {
+	recorder *stopRecorder
+	stopCh   chan struct{}
+	name     string
+	delay    time.Duration
+	phase    ShutdownPhase
+}
+
+func (s *phasedService) Name() string { return s.name }
+
+func (s *phasedService) Serve() StopNotify { return s.stopCh }
+
+func (s *phasedService) GracefulStop() {
+	time.Sleep(s.delay)
+	s.recorder.record(s.name)
+	close(s.stopCh)
+}
+
+func (s *phasedService) ShutdownPhase() ShutdownPhase { return s.phase }
+
+// defaultService doesn't implement PhasedService.
+type defaultService struct {
+	s *phasedService
+}
+
+func (d defaultService) Name() string { return d.s.Name() }
+
+func (d defaultService) Serve() StopNotify { return d.s.Serve() }
+
+func (d defaultService) GracefulStop() { d.s.GracefulStop() }
+
+func newPhasedService(r *stopRecorder, name string, phase ShutdownPhase) *phasedService {
+	return &phasedService{recorder: r, stopCh: make(chan struct{}), name: name, phase: phase}
+}
+
+func TestGroupShutdownOrder(t *testing.T) {
+	r := &stopRecorder{}
+	g := NewGroup("test")
+	g.log = logger.GetLogger("test")
+	g.s = []Service{
+		defaultService{newPhasedService(r, "metadata", ShutdownPhaseDefault)},
+		newPhasedService(r, "queue", ShutdownPhaseQueue),
+		newPhasedService(r, "measure", ShutdownPhaseStorage),
+		newPhasedService(r, "stream", ShutdownPhaseStorage),
+		newPhasedService(r, "query", ShutdownPhaseQuery),
+		newPhasedService(r, "grpc", ShutdownPhaseIngress),
+		newPhasedService(r, "http", ShutdownPhaseIngress),
+		nil,
+	}
+	g.shutdown()
+	assert.Equal(t, []string{"http", "grpc", "query", "queue", "stream", "measure", "metadata"}, r.list())
+}
+
+func TestGroupShutdownGracePeriod(t *testing.T) {
+	r := &stopRecorder{}
+	g := NewGroup("test")
+	g.log = logger.GetLogger("test")
+	g.shutdownGracePeriod = 100 * time.Millisecond
+	slow := newPhasedService(r, "slow", ShutdownPhaseIngress)
+	slow.delay = time.Second
+	g.s = []Service{
+		slow,
+		newPhasedService(r, "storage", ShutdownPhaseStorage),
+	}
+	start := time.Now()
+	g.shutdown()
+	assert.Less(t, time.Since(start), slow.delay)
+	assert.Equal(t, []string{"storage"}, r.list())
+	<-slow.stopCh
+	assert.Equal(t, []string{"storage", "slow"}, r.list())
+}
@@ -414,6 +414,10 @@ func (sr *schemaRepo) storeIndexRuleBinding(indexRuleBinding *databasev1.IndexRu
 }
 
 func (sr *schemaRepo) updateIndex(binding *databasev1.IndexRuleBinding) {
+	// Holding the resource lock keeps a concurrent resource reload from publishing
+	// an older rule set after this one.
+	sr.resourceMutex.Lock()
+	defer sr.resourceMutex.Unlock()
 	if r, ok := sr.LoadResource(&commonv1.Metadata{
 		Name:  binding.Subject.GetName(),
 		Group: binding.GetMetadata().GetGroup(),
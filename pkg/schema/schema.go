@@ -19,6 +19,7 @@
 package schema
 
 import (
+	"context"
 	"io"
 
 	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
@@ -107,6 +108,7 @@ type Repository interface {
 	LoadGroup(name string) (Group, bool)
 	LoadAllGroups() []Group
 	LoadResource(metadata *commonv1.Metadata) (Resource, bool)
+	Warmup(ctx context.Context, catalog commonv1.Catalog, names []string) []*databasev1.GroupReadiness
 	Close()
 	StopCh() <-chan struct{}
 }
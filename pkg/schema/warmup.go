@@ -0,0 +1,140 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package schema
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
+)
+
+var errGroupNotFound = errors.New("group not found")
+
+// Warmup loads the groups in the catalog with their index rules, index rule bindings and resources synchronously,
+// rather than waiting for the metadata events. All the groups in the catalog are loaded if names is empty.
+// The groups in other catalogs or absent from the registry are skipped.
+func (sr *schemaRepo) Warmup(ctx context.Context, catalog commonv1.Catalog, names []string) []*databasev1.GroupReadiness {
+	var groups []*commonv1.Group
+	var result []*databasev1.GroupReadiness
+	if len(names) == 0 {
+		gg, err := sr.metadata.GroupRegistry().ListGroup(ctx)
+		if err != nil {
+			return []*databasev1.GroupReadiness{{Catalog: catalog, Error: err.Error()}}
+		}
+		groups = gg
+	} else {
+		for _, name := range names {
+			g, err := sr.metadata.GroupRegistry().GetGroup(ctx, name)
+			if errors.Is(err, schema.ErrGRPCResourceNotFound) {
+				continue
+			}
+			if err != nil {
+				result = append(result, &databasev1.GroupReadiness{Catalog: catalog, Group: name, Error: err.Error()})
+				continue
+			}
+			groups = append(groups, g)
+		}
+	}
+	for _, g := range groups {
+		if g.GetCatalog() != catalog {
+			continue
+		}
+		r := &databasev1.GroupReadiness{Catalog: catalog, Group: g.GetMetadata().GetName()}
+		n, err := sr.warmupGroup(ctx, g)
+		if err != nil {
+			sr.l.Warn().Err(err).Str("group", r.Group).Msg("fail to warm up the group")
+			r.Error = err.Error()
+		} else {
+			r.Ready = true
+			r.Resources = uint32(n)
+		}
+		result = append(result, r)
+	}
+	return result
+}
+
+func (sr *schemaRepo) warmupGroup(ctx context.Context, groupSchema *commonv1.Group) (int, error) {
+	name := groupSchema.GetMetadata().GetName()
+	if g, ok := sr.getGroup(name); !ok || !g.isInit() {
+		if _, err := sr.storeGroup(groupSchema.GetMetadata()); err != nil {
+			return 0, errors.WithMessage(err, "fail to load the group")
+		}
+	}
+	if _, ok := sr.LoadGroup(name); !ok {
+		return 0, errGroupNotFound
+	}
+	rr, err := sr.metadata.IndexRuleRegistry().ListIndexRule(ctx, schema.ListOpt{Group: name})
+	if err != nil {
+		return 0, errors.WithMessage(err, "fail to list the index rules")
+	}
+	for _, r := range rr {
+		sr.storeIndexRule(r)
+	}
+	ibb, err := sr.metadata.IndexRuleBindingRegistry().ListIndexRuleBinding(ctx, schema.ListOpt{Group: name})
+	if err != nil {
+		return 0, errors.WithMessage(err, "fail to list the index rule bindings")
+	}
+	for _, ib := range ibb {
+		sr.storeIndexRuleBinding(ib)
+	}
+	var resources []ResourceSchema
+	if groupSchema.GetCatalog() == commonv1.Catalog_CATALOG_MEASURE {
+		mm, errList := sr.metadata.MeasureRegistry().ListMeasure(ctx, schema.ListOpt{Group: name})
+		if errList != nil {
+			return 0, errors.WithMessage(errList, "fail to list the measures")
+		}
+		for _, m := range mm {
+			resources = append(resources, m)
+		}
+	} else {
+		ss, errList := sr.metadata.StreamRegistry().ListStream(ctx, schema.ListOpt{Group: name})
+		if errList != nil {
+			return 0, errors.WithMessage(errList, "fail to list the streams")
+		}
+		for _, s := range ss {
+			resources = append(resources, s)
+		}
+	}
+	for _, r := range resources {
+		if err = sr.storeResource(r); err != nil {
+			return 0, err
+		}
+	}
+	return len(resources), nil
+}
+
+// CompleteReadiness appends the readiness of the requested groups which no catalog reports,
+// since they're absent from the registry.
+func CompleteReadiness(names []string, rr []*databasev1.GroupReadiness) []*databasev1.GroupReadiness {
+	reported := make(map[string]struct{}, len(rr))
+	for _, r := range rr {
+		reported[r.GetGroup()] = struct{}{}
+	}
+	for _, name := range names {
+		if _, ok := reported[name]; ok {
+			continue
+		}
+		reported[name] = struct{}{}
+		rr = append(rr, &databasev1.GroupReadiness{Group: name, Error: errGroupNotFound.Error()})
+	}
+	return rr
+}
@@ -22,6 +22,8 @@ import (
 	"time"
 
 	"github.com/benbjohnson/clock"
+
+	"github.com/apache/skywalking-banyandb/pkg/fault"
 )
 
 // Clock represents an interface contains all functions in the standard library time.
@@ -41,10 +43,32 @@ type MockClock interface {
 }
 
 // NewClock returns an instance of a real-time clock.
+// With the "fault" build tag, its time jumps by the offset injected by the fault package.
 func NewClock() Clock {
+	if fault.Enabled {
+		return &jumpingClock{Clock: clock.New()}
+	}
 	return clock.New()
 }
 
+// jumpingClock shifts the current time by the clock offset of the fault package.
+// The timers and the tickers aren't affected by the jumps.
+type jumpingClock struct {
+	clock.Clock
+}
+
+func (c *jumpingClock) Now() time.Time {
+	return c.Clock.Now().Add(fault.ClockOffset())
+}
+
+func (c *jumpingClock) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+func (c *jumpingClock) Until(t time.Time) time.Duration {
+	return t.Sub(c.Now())
+}
+
 // NewMockClock returns an instance of a mock clock.
 func NewMockClock() MockClock {
 	return clock.NewMock()
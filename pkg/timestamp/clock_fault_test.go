@@ -0,0 +1,46 @@
+//go:build fault
+// +build fault
+
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package timestamp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/skywalking-banyandb/pkg/fault"
+)
+
+func TestJumpingClock(t *testing.T) {
+	jump := func(d string) {
+		rec := httptest.NewRecorder()
+		fault.Serve(rec, httptest.NewRequest(http.MethodPost, fault.Path+"/clock?jump="+d, nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+	}
+	t.Cleanup(func() { jump("2h") })
+	c := NewClock()
+	jump("-2h")
+	assert.InDelta(t, float64(time.Now().Add(-2*time.Hour).UnixMilli()), float64(c.Now().UnixMilli()), 1000)
+	assert.InDelta(t, float64(2*time.Hour), float64(c.Since(time.Now().Add(-4*time.Hour))), float64(time.Second))
+}
@@ -0,0 +1,197 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package tracecontext propagates the trace context of the requests, which is carried
+// by the W3C traceparent or the SkyWalking sw8 header, from the liaison to the data nodes.
+package tracecontext
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+
+	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+)
+
+const (
+	// TraceParentHeader is the header of the W3C trace context.
+	TraceParentHeader = "traceparent"
+	// SW8Header is the header of the SkyWalking cross process propagation.
+	SW8Header = "sw8"
+	// LogKey is the key of the trace id in the log events.
+	LogKey = "trace_id"
+)
+
+type contextKey struct{}
+
+// TraceContext is the trace context carried by a request.
+type TraceContext struct {
+	// Header is the name of the header which carries the trace context.
+	Header string
+	// Value is the raw value of the header, which is forwarded as is.
+	Value string
+	// TraceID is the trace id parsed from the value.
+	TraceID string
+}
+
+// Valid reports whether tc carries a trace.
+func (tc TraceContext) Valid() bool {
+	return tc.TraceID != ""
+}
+
+// Parse parses the value of a trace context header.
+func Parse(header, value string) (TraceContext, bool) {
+	var traceID string
+	switch strings.ToLower(header) {
+	case TraceParentHeader:
+		traceID = parseTraceParent(value)
+	case SW8Header:
+		traceID = parseSW8(value)
+	}
+	if traceID == "" {
+		return TraceContext{}, false
+	}
+	return TraceContext{Header: strings.ToLower(header), Value: value, TraceID: traceID}, true
+}
+
+// IsHeader reports whether key is the name of a trace context header.
+func IsHeader(key string) bool {
+	key = strings.ToLower(key)
+	return key == TraceParentHeader || key == SW8Header
+}
+
+// FromMetadata looks up the trace context in md. The traceparent takes precedence over the sw8.
+func FromMetadata(md metadata.MD) (TraceContext, bool) {
+	for _, h := range []string{TraceParentHeader, SW8Header} {
+		for _, v := range md.Get(h) {
+			if tc, ok := Parse(h, v); ok {
+				return tc, true
+			}
+		}
+	}
+	return TraceContext{}, false
+}
+
+// NewContext returns a new context carrying tc.
+func NewContext(ctx context.Context, tc TraceContext) context.Context {
+	if !tc.Valid() {
+		return ctx
+	}
+	return context.WithValue(ctx, contextKey{}, tc)
+}
+
+// FromContext returns the trace context stored in ctx.
+func FromContext(ctx context.Context) (TraceContext, bool) {
+	tc, ok := ctx.Value(contextKey{}).(TraceContext)
+	return tc, ok
+}
+
+// FromIncomingContext stores the trace context of the incoming gRPC metadata in ctx.
+func FromIncomingContext(ctx context.Context) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+	tc, ok := FromMetadata(md)
+	if !ok {
+		return ctx
+	}
+	return NewContext(ctx, tc)
+}
+
+// AppendToOutgoingContext forwards tc to the gRPC server through the outgoing metadata.
+func AppendToOutgoingContext(ctx context.Context, tc TraceContext) context.Context {
+	if !tc.Valid() {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, tc.Header, tc.Value)
+}
+
+// Logger attaches the trace id stored in ctx to the events of l.
+func Logger(ctx context.Context, l *logger.Logger) *logger.Logger {
+	tc, ok := FromContext(ctx)
+	if !ok {
+		return l
+	}
+	return l.WithStr(LogKey, tc.TraceID)
+}
+
+// UnaryServerInterceptor stores the trace context of the incoming unary calls in their contexts.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return handler(FromIncomingContext(ctx), req)
+	}
+}
+
+// StreamServerInterceptor stores the trace context of the incoming streams in their contexts.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := FromIncomingContext(stream.Context())
+		if ctx == stream.Context() {
+			return handler(srv, stream)
+		}
+		wrapped := grpc_middleware.WrapServerStream(stream)
+		wrapped.WrappedContext = ctx
+		return handler(srv, wrapped)
+	}
+}
+
+// parseTraceParent returns the trace id of "version-traceid-parentid-flags".
+func parseTraceParent(value string) string {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 {
+		return ""
+	}
+	version, traceID, parentID, flags := parts[0], parts[1], parts[2], parts[3]
+	if !isHex(version, 2) || version == "ff" || (version == "00" && len(parts) != 4) {
+		return ""
+	}
+	if !isHex(traceID, 32) || !isHex(parentID, 16) || !isHex(flags, 2) {
+		return ""
+	}
+	if strings.Trim(traceID, "0") == "" || strings.Trim(parentID, "0") == "" {
+		return ""
+	}
+	return traceID
+}
+
+// parseSW8 returns the trace id of "sample-traceid-segmentid-spanid-service-instance-endpoint-target",
+// whose trace id is encoded in BASE64.
+func parseSW8(value string) string {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) != 8 || (parts[0] != "0" && parts[0] != "1") {
+		return ""
+	}
+	traceID, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return ""
+	}
+	return string(traceID)
+}
+
+func isHex(s string, n int) bool {
+	if len(s) != n || strings.ToLower(s) != s {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
@@ -0,0 +1,84 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tracecontext
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+const traceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestParse(t *testing.T) {
+	sw8 := "1-" + base64.StdEncoding.EncodeToString([]byte("a.b.c")) + "-" +
+		base64.StdEncoding.EncodeToString([]byte("d.e.f")) + "-3-c2Vydmlj-aW5zdGFuY2U=-L2FwaQ==-bG9jYWxob3N0OjgwODA="
+	tests := []struct {
+		header  string
+		value   string
+		traceID string
+	}{
+		{header: TraceParentHeader, value: traceParent, traceID: "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{header: "Traceparent", value: traceParent, traceID: "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{header: TraceParentHeader, value: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-future", traceID: "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{header: TraceParentHeader, value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-future"},
+		{header: TraceParentHeader, value: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		{header: TraceParentHeader, value: "00-00000000000000000000000000000000-00f067aa0ba902b7-01"},
+		{header: TraceParentHeader, value: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01"},
+		{header: TraceParentHeader, value: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01"},
+		{header: TraceParentHeader, value: "00-4bf92f3577b34da6-00f067aa0ba902b7-01"},
+		{header: SW8Header, value: sw8, traceID: "a.b.c"},
+		{header: SW8Header, value: "1-YS5iLmM=-ZC5lLmY="},
+		{header: SW8Header, value: "2" + sw8[1:]},
+		{header: SW8Header, value: "1-!!!" + sw8[1:]},
+		{header: "x-trace-id", value: traceParent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header+":"+tt.value, func(t *testing.T) {
+			tc, ok := Parse(tt.header, tt.value)
+			if tt.traceID == "" {
+				assert.False(t, ok)
+				return
+			}
+			require.True(t, ok)
+			assert.Equal(t, tt.traceID, tc.TraceID)
+			assert.Equal(t, tt.value, tc.Value)
+		})
+	}
+}
+
+func TestFromIncomingContext(t *testing.T) {
+	ctx := FromIncomingContext(context.Background())
+	_, ok := FromContext(ctx)
+	assert.False(t, ok)
+
+	md := metadata.Pairs(SW8Header, "1-YS5iLmM=-ZC5lLmY=-3-c2Vydmlj-aW5zdGFuY2U=-L2FwaQ==-bG9jYWxob3N0OjgwODA=", TraceParentHeader, traceParent)
+	ctx = FromIncomingContext(metadata.NewIncomingContext(context.Background(), md))
+	tc, ok := FromContext(ctx)
+	require.True(t, ok)
+	assert.Equal(t, TraceParentHeader, tc.Header)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", tc.TraceID)
+
+	out, ok := metadata.FromOutgoingContext(AppendToOutgoingContext(context.Background(), tc))
+	require.True(t, ok)
+	assert.Equal(t, []string{traceParent}, out.Get(TraceParentHeader))
+}
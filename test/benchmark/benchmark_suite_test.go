@@ -0,0 +1,106 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package benchmark
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"testing"
+	"time"
+
+	g "github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/apache/skywalking-banyandb/pkg/grpchelper"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/test/flags"
+)
+
+var (
+	workloadPath = flag.String("benchmark.workload", "", "the workload file to replay, the benchmark is skipped if it's empty")
+	topology     = flag.String("benchmark.topology", "standalone", "the topology to start: standalone or cluster")
+	dataNodes    = flag.Int("benchmark.data-nodes", 2, "the number of the data nodes of the cluster")
+	concurrency  = flag.Int("benchmark.concurrency", 4, "the number of the workers")
+	iterations   = flag.Int("benchmark.iterations", 1, "the number of the times the workload is replayed")
+	paced        = flag.Bool("benchmark.paced", false, "keep the recorded intervals between the operations")
+	reportPath   = flag.String("benchmark.report", "", "the file to write the report to")
+	baselinePath = flag.String("benchmark.baseline", "", "the report to compare with, the benchmark fails on the regressions")
+	tolerance    = flag.Float64("benchmark.tolerance", 0.1, "the fraction the metrics could get worse than the baseline")
+)
+
+func TestBenchmark(t *testing.T) {
+	gomega.RegisterFailHandler(g.Fail)
+	g.RunSpecs(t, "Benchmark Suite", g.Label("benchmark", "slow"))
+}
+
+var _ = g.Describe("Benchmark", func() {
+	g.BeforeEach(func() {
+		if *workloadPath == "" {
+			g.Skip("no workload is specified by -benchmark.workload")
+		}
+		gomega.Expect(logger.Init(logger.Logging{
+			Env:   "dev",
+			Level: flags.LogLevel,
+		})).To(gomega.Succeed())
+	})
+
+	g.It("replays the workload", func() {
+		w, err := LoadWorkload(*workloadPath)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		var top *Topology
+		switch *topology {
+		case "standalone":
+			top = StartStandalone("--logging-level", "warn")
+		case "cluster":
+			top = StartCluster(*dataNodes)
+		default:
+			g.Fail(fmt.Sprintf("unknown topology %q", *topology))
+		}
+		g.DeferCleanup(top.Close)
+
+		conn, err := grpchelper.Conn(top.Addr, 10*time.Second, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		g.DeferCleanup(func() {
+			_ = conn.Close()
+		})
+
+		report, err := Replay(context.Background(), conn, w, Options{
+			Topology:    top.Name,
+			Concurrency: *concurrency,
+			Iterations:  *iterations,
+			Paced:       *paced,
+		})
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		for kind, stats := range report.Operations {
+			g.GinkgoWriter.Printf("%s: count=%d errors=%d throughput=%.1f/s p50=%.2fms p99=%.2fms\n",
+				kind, stats.Count, stats.Errors, stats.Throughput, stats.P50Ms, stats.P99Ms)
+		}
+		if *reportPath != "" {
+			gomega.Expect(report.WriteFile(*reportPath)).To(gomega.Succeed())
+		}
+		if *baselinePath != "" {
+			baseline, errBaseline := LoadReport(*baselinePath)
+			gomega.Expect(errBaseline).NotTo(gomega.HaveOccurred())
+			gomega.Expect(Compare(baseline, report, *tolerance)).To(gomega.BeEmpty())
+		}
+	})
+})
@@ -0,0 +1,157 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package benchmark
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// Report is the machine-readable result of a replay.
+type Report struct {
+	StartedAt   time.Time           `json:"started_at"`
+	Operations  map[string]*OpStats `json:"operations"`
+	Workload    string              `json:"workload"`
+	Topology    string              `json:"topology"`
+	Seconds     float64             `json:"seconds"`
+	Concurrency int                 `json:"concurrency"`
+	Iterations  int                 `json:"iterations"`
+}
+
+// OpStats is the statistics of the operations of a kind.
+type OpStats struct {
+	latencies  []time.Duration
+	Count      int     `json:"count"`
+	Errors     int     `json:"errors"`
+	Throughput float64 `json:"throughput"`
+	MeanMs     float64 `json:"mean_ms"`
+	P50Ms      float64 `json:"p50_ms"`
+	P90Ms      float64 `json:"p90_ms"`
+	P99Ms      float64 `json:"p99_ms"`
+	MaxMs      float64 `json:"max_ms"`
+}
+
+// observe records a unit of n operations, of which the failed ones don't count in the latencies.
+func (s *OpStats) observe(latency time.Duration, n, failed int) {
+	s.Count += n
+	s.Errors += failed
+	if failed < n {
+		s.latencies = append(s.latencies, latency)
+	}
+}
+
+// summarize computes the throughput and the latency percentiles of the succeeded operations.
+func (s *OpStats) summarize(elapsed time.Duration) {
+	if elapsed > 0 {
+		s.Throughput = float64(s.Count-s.Errors) / elapsed.Seconds()
+	}
+	if len(s.latencies) == 0 {
+		return
+	}
+	sort.Slice(s.latencies, func(i, j int) bool { return s.latencies[i] < s.latencies[j] })
+	var total time.Duration
+	for _, l := range s.latencies {
+		total += l
+	}
+	s.MeanMs = millis(total / time.Duration(len(s.latencies)))
+	s.P50Ms = millis(percentile(s.latencies, 0.5))
+	s.P90Ms = millis(percentile(s.latencies, 0.9))
+	s.P99Ms = millis(percentile(s.latencies, 0.99))
+	s.MaxMs = millis(s.latencies[len(s.latencies)-1])
+}
+
+// percentile picks the nearest rank of the sorted latencies.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	i := int(float64(len(sorted))*p+0.5) - 1
+	return sorted[min(max(i, 0), len(sorted)-1)]
+}
+
+func millis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// WriteFile writes the report in JSON.
+func (r *Report) WriteFile(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// LoadReport reads a report written by WriteFile.
+func LoadReport(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	r := &Report{}
+	if err = json.Unmarshal(data, r); err != nil {
+		return nil, fmt.Errorf("invalid report %s: %w", path, err)
+	}
+	return r, nil
+}
+
+// Regression is a metric of the current report getting worse than the baseline beyond the tolerance.
+type Regression struct {
+	Kind     string  `json:"kind"`
+	Metric   string  `json:"metric"`
+	Baseline float64 `json:"baseline"`
+	Current  float64 `json:"current"`
+}
+
+func (r Regression) String() string {
+	return fmt.Sprintf("%s %s: %.3f -> %.3f", r.Kind, r.Metric, r.Baseline, r.Current)
+}
+
+// Compare detects the regressions of the current report against the baseline. The tolerance is the fraction
+// the throughput could drop and the p99 latency could rise, e.g. 0.1 for 10%. Any new error is a regression.
+func Compare(baseline, current *Report, tolerance float64) []Regression {
+	var regressions []Regression
+	kinds := make([]string, 0, len(baseline.Operations))
+	for kind := range baseline.Operations {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		b, c := baseline.Operations[kind], current.Operations[kind]
+		if c == nil {
+			continue
+		}
+		if c.Throughput < b.Throughput*(1-tolerance) {
+			regressions = append(regressions, Regression{Kind: kind, Metric: "throughput", Baseline: b.Throughput, Current: c.Throughput})
+		}
+		if c.P99Ms > b.P99Ms*(1+tolerance) {
+			regressions = append(regressions, Regression{Kind: kind, Metric: "p99_ms", Baseline: b.P99Ms, Current: c.P99Ms})
+		}
+		if errorRate(c) > errorRate(b) {
+			regressions = append(regressions, Regression{Kind: kind, Metric: "error_rate", Baseline: errorRate(b), Current: errorRate(c)})
+		}
+	}
+	return regressions
+}
+
+func errorRate(s *OpStats) float64 {
+	if s.Count == 0 {
+		return 0
+	}
+	return float64(s.Errors) / float64(s.Count)
+}
@@ -0,0 +1,66 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package benchmark
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpStats(t *testing.T) {
+	s := &OpStats{}
+	for i := 1; i <= 100; i++ {
+		s.observe(time.Duration(i)*time.Millisecond, 1, 0)
+	}
+	s.observe(time.Second, 10, 10)
+	s.summarize(10 * time.Second)
+	assert.Equal(t, 110, s.Count)
+	assert.Equal(t, 10, s.Errors)
+	assert.InDelta(t, 10, s.Throughput, 1e-9)
+	assert.InDelta(t, 50.5, s.MeanMs, 1e-9)
+	assert.InDelta(t, 50, s.P50Ms, 1e-9)
+	assert.InDelta(t, 90, s.P90Ms, 1e-9)
+	assert.InDelta(t, 99, s.P99Ms, 1e-9)
+	assert.InDelta(t, 100, s.MaxMs, 1e-9)
+}
+
+func TestCompare(t *testing.T) {
+	baseline := &Report{Operations: map[string]*OpStats{
+		KindMeasureWrite: {Count: 100, Throughput: 1000, P99Ms: 10},
+		KindStreamQuery:  {Count: 10, Throughput: 10, P99Ms: 100},
+	}}
+	current := &Report{Operations: map[string]*OpStats{
+		KindMeasureWrite: {Count: 100, Throughput: 950, P99Ms: 10.5},
+		KindStreamQuery:  {Count: 10, Errors: 1, Throughput: 8, P99Ms: 150},
+	}}
+	assert.Equal(t, []Regression{
+		{Kind: KindStreamQuery, Metric: "throughput", Baseline: 10, Current: 8},
+		{Kind: KindStreamQuery, Metric: "p99_ms", Baseline: 100, Current: 150},
+		{Kind: KindStreamQuery, Metric: "error_rate", Baseline: 0, Current: 0.1},
+	}, Compare(baseline, current, 0.1))
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	require.NoError(t, current.WriteFile(path))
+	loaded, err := LoadReport(path)
+	require.NoError(t, err)
+	assert.Empty(t, Compare(current, loaded, 0))
+}
@@ -0,0 +1,213 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package benchmark
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+)
+
+const defaultBatchSize = 100
+
+// Options configures a replay.
+type Options struct {
+	// Topology is the name of the topology recorded in the report.
+	Topology string
+	// Concurrency is the number of the workers sending the operations.
+	Concurrency int
+	// Iterations is the number of the times the workload is replayed.
+	Iterations int
+	// BatchSize is the max number of the consecutive writes of the same kind sent in a write stream.
+	BatchSize int
+	// Paced keeps the recorded intervals between the operations. Otherwise, the operations are sent as fast as possible.
+	Paced bool
+}
+
+// unit is sent by a worker at once, which is a query or a batch of writes.
+type unit struct {
+	kind     string
+	requests []proto.Message
+}
+
+// Replay sends the operations of the workload in the recorded order, and reports their statistics.
+// The latency of a write is the one of its batch, which is acknowledged once the write stream is closed.
+func Replay(ctx context.Context, conn *grpc.ClientConn, w *Workload, opts Options) (*Report, error) {
+	opts.Concurrency = max(opts.Concurrency, 1)
+	opts.Iterations = max(opts.Iterations, 1)
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultBatchSize
+	}
+	report := &Report{
+		Workload:    w.Name,
+		Topology:    opts.Topology,
+		Concurrency: opts.Concurrency,
+		Iterations:  opts.Iterations,
+		Operations:  make(map[string]*OpStats),
+		StartedAt:   time.Now(),
+	}
+	var mu sync.Mutex
+	units := make(chan unit)
+	var wg sync.WaitGroup
+	streamClient, measureClient := streamv1.NewStreamServiceClient(conn), measurev1.NewMeasureServiceClient(conn)
+	for i := 0; i < opts.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for u := range units {
+				start := time.Now()
+				errs := send(ctx, streamClient, measureClient, u)
+				latency := time.Since(start)
+				mu.Lock()
+				stats, ok := report.Operations[u.kind]
+				if !ok {
+					stats = &OpStats{}
+					report.Operations[u.kind] = stats
+				}
+				stats.observe(latency, len(u.requests), errs)
+				mu.Unlock()
+			}
+		}()
+	}
+	err := dispatch(ctx, w, opts, units)
+	close(units)
+	wg.Wait()
+	elapsed := time.Since(report.StartedAt)
+	report.Seconds = elapsed.Seconds()
+	for _, stats := range report.Operations {
+		stats.summarize(elapsed)
+	}
+	return report, err
+}
+
+// dispatch rebases the operations of every iteration to its start, and groups the consecutive writes into batches.
+func dispatch(ctx context.Context, w *Workload, opts Options, units chan<- unit) error {
+	for i := 0; i < opts.Iterations; i++ {
+		start := time.Now()
+		var pending unit
+		flush := func() error {
+			if len(pending.requests) == 0 {
+				return nil
+			}
+			select {
+			case units <- pending:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			pending = unit{}
+			return nil
+		}
+		for _, op := range w.Operations {
+			if opts.Paced {
+				if wait := time.Until(start.Add(op.Offset)); wait > 0 {
+					if err := flush(); err != nil {
+						return err
+					}
+					select {
+					case <-time.After(wait):
+					case <-ctx.Done():
+						return ctx.Err()
+					}
+				}
+			}
+			if pending.kind != op.Kind || !isWrite(op.Kind) || len(pending.requests) >= opts.BatchSize {
+				if err := flush(); err != nil {
+					return err
+				}
+				pending.kind = op.Kind
+			}
+			pending.requests = append(pending.requests, w.rebase(op, start))
+		}
+		if err := flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isWrite(kind string) bool {
+	return kind == KindStreamWrite || kind == KindMeasureWrite
+}
+
+// send sends the unit and returns the number of the failed operations.
+func send(ctx context.Context, streamClient streamv1.StreamServiceClient, measureClient measurev1.MeasureServiceClient, u unit) int {
+	switch u.kind {
+	case KindStreamQuery:
+		if _, err := streamClient.Query(ctx, u.requests[0].(*streamv1.QueryRequest)); err != nil {
+			return 1
+		}
+		return 0
+	case KindMeasureQuery:
+		if _, err := measureClient.Query(ctx, u.requests[0].(*measurev1.QueryRequest)); err != nil {
+			return 1
+		}
+		return 0
+	case KindStreamWrite:
+		wc, err := streamClient.Write(ctx)
+		if err != nil {
+			return len(u.requests)
+		}
+		return writeBatch(wc, u.requests, func() (string, error) {
+			resp, errRecv := wc.Recv()
+			return resp.GetStatus(), errRecv
+		})
+	case KindMeasureWrite:
+		wc, err := measureClient.Write(ctx)
+		if err != nil {
+			return len(u.requests)
+		}
+		return writeBatch(wc, u.requests, func() (string, error) {
+			resp, errRecv := wc.Recv()
+			return resp.GetStatus(), errRecv
+		})
+	default:
+		panic(fmt.Sprintf("unknown kind %q", u.kind))
+	}
+}
+
+// writeBatch sends the writes on a write stream, and counts the writes not acknowledged as succeeded.
+func writeBatch(wc grpc.ClientStream, requests []proto.Message, recv func() (string, error)) int {
+	for _, req := range requests {
+		if err := wc.SendMsg(req); err != nil {
+			return len(requests)
+		}
+	}
+	if err := wc.CloseSend(); err != nil {
+		return len(requests)
+	}
+	succeeded := 0
+	for {
+		// the stream ends with io.EOF once all the writes are acknowledged.
+		status, err := recv()
+		if err != nil {
+			break
+		}
+		if status == modelv1.Status_STATUS_SUCCEED.String() {
+			succeeded++
+		}
+	}
+	return max(len(requests)-succeeded, 0)
+}
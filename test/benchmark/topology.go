@@ -0,0 +1,97 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package benchmark
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/onsi/gomega"
+
+	"github.com/apache/skywalking-banyandb/banyand/metadata"
+	"github.com/apache/skywalking-banyandb/banyand/metadata/embeddedetcd"
+	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
+	"github.com/apache/skywalking-banyandb/pkg/test"
+	test_measure "github.com/apache/skywalking-banyandb/pkg/test/measure"
+	"github.com/apache/skywalking-banyandb/pkg/test/setup"
+	test_stream "github.com/apache/skywalking-banyandb/pkg/test/stream"
+)
+
+// Topology is a running deployment of BanyanDB with the stream and measure schemas of the test cases.
+// It's started in the Ginkgo specs, which fail once a node doesn't start.
+type Topology struct {
+	closeFn func()
+	// Name is "standalone" or "cluster-<n>", where n is the number of the data nodes.
+	Name string
+	// Addr is the gRPC address to send the operations to.
+	Addr string
+}
+
+// StartStandalone starts a standalone server.
+func StartStandalone(flags ...string) *Topology {
+	addr, _, closeFn := setup.Standalone(flags...)
+	return &Topology{Name: "standalone", Addr: addr, closeFn: closeFn}
+}
+
+// StartCluster starts an embedded etcd, the data nodes and a liaison node.
+// The flags are applied to all the nodes.
+func StartCluster(dataNodes int, flags ...string) *Topology {
+	ports, err := test.AllocateFreePorts(2)
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	dir, spaceDef, err := test.NewSpace()
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	ep := fmt.Sprintf("http://127.0.0.1:%d", ports[0])
+	server, err := embeddedetcd.NewServer(
+		embeddedetcd.ConfigureListener([]string{ep}, []string{fmt.Sprintf("http://127.0.0.1:%d", ports[1])}),
+		embeddedetcd.RootDir(dir),
+	)
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	<-server.ReadyNotify()
+	schemaRegistry, err := schema.NewEtcdSchemaRegistry(
+		schema.Namespace(metadata.DefaultNamespace),
+		schema.ConfigureServerEndpoints([]string{ep}),
+	)
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	ctx := context.Background()
+	gomega.Expect(test_stream.PreloadSchema(ctx, schemaRegistry)).To(gomega.Succeed())
+	gomega.Expect(test_measure.PreloadSchema(ctx, schemaRegistry)).To(gomega.Succeed())
+	gomega.Expect(schemaRegistry.Close()).To(gomega.Succeed())
+	closers := make([]func(), 0, dataNodes+1)
+	for i := 0; i < dataNodes; i++ {
+		closers = append(closers, setup.DataNode(ep, flags...))
+	}
+	addr, closeLiaison := setup.LiaisonNode(ep, flags...)
+	closers = append(closers, closeLiaison)
+	return &Topology{
+		Name: fmt.Sprintf("cluster-%d", dataNodes),
+		Addr: addr,
+		closeFn: func() {
+			for i := len(closers) - 1; i >= 0; i-- {
+				closers[i]()
+			}
+			_ = server.Close()
+			<-server.StopNotify()
+			spaceDef()
+		},
+	}
+}
+
+// Close stops the nodes and removes their data.
+func (t *Topology) Close() {
+	t.closeFn()
+}
@@ -0,0 +1,192 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package benchmark replays the recorded workloads against the standalone or cluster topologies,
+// and reports the throughput and the latencies of the operations, so that the performance regressions
+// are detected by comparing the reports.
+package benchmark
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+)
+
+// The kinds of the operations. The kinds of the writes are the same as the ones of the liaison samples,
+// so that the sample files captured from a running cluster are replayable workloads.
+const (
+	KindStreamWrite  = "stream"
+	KindMeasureWrite = "measure"
+	KindStreamQuery  = "stream_query"
+	KindMeasureQuery = "measure_query"
+)
+
+const maxRecordSize = 16 << 20
+
+// Record is a line of a workload file.
+type Record struct {
+	Time    time.Time       `json:"time"`
+	Kind    string          `json:"kind"`
+	Request json.RawMessage `json:"request"`
+}
+
+// Operation is a request of a workload.
+type Operation struct {
+	Request proto.Message
+	Kind    string
+	// Offset is the time since the first operation was recorded.
+	Offset time.Duration
+}
+
+// Workload is a recorded sequence of the writes and the queries.
+type Workload struct {
+	// base is the time the first operation was recorded, which is zero if the records have no time.
+	base       time.Time
+	Name       string
+	Operations []Operation
+}
+
+// LoadWorkload reads the workload from a JSON lines file, whose name is the name of the workload.
+func LoadWorkload(path string) (*Workload, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseWorkload(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)), f)
+}
+
+// ParseWorkload reads the workload from the JSON lines, one record per line.
+// The records are sorted by their time.
+func ParseWorkload(name string, r io.Reader) (*Workload, error) {
+	w := &Workload{Name: name}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxRecordSize)
+	var times []time.Time
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var rec Record
+		if err := json.Unmarshal([]byte(text), &rec); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		req, err := newRequest(rec.Kind)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if err = protojson.Unmarshal(rec.Request, req); err != nil {
+			return nil, fmt.Errorf("line %d: invalid %s request: %w", line, rec.Kind, err)
+		}
+		if !rec.Time.IsZero() && (w.base.IsZero() || rec.Time.Before(w.base)) {
+			w.base = rec.Time
+		}
+		times = append(times, rec.Time)
+		w.Operations = append(w.Operations, Operation{Kind: rec.Kind, Request: req})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(w.Operations) == 0 {
+		return nil, fmt.Errorf("workload %s is empty", name)
+	}
+	for i, t := range times {
+		if !t.IsZero() {
+			w.Operations[i].Offset = t.Sub(w.base)
+		}
+	}
+	sort.SliceStable(w.Operations, func(i, j int) bool {
+		return w.Operations[i].Offset < w.Operations[j].Offset
+	})
+	return w, nil
+}
+
+// Span is the offset of the last operation.
+func (w *Workload) Span() time.Duration {
+	var span time.Duration
+	for _, op := range w.Operations {
+		span = max(span, op.Offset)
+	}
+	return span
+}
+
+// rebase returns a copy of the request whose timestamps are shifted by the time between the start of the replay
+// and the time the workload was recorded, so that the written data and the queried time ranges stay in the TTL.
+func (w *Workload) rebase(op Operation, start time.Time) proto.Message {
+	req := proto.Clone(op.Request)
+	if w.base.IsZero() {
+		return req
+	}
+	// the server accepts the timestamps in milliseconds.
+	shift := start.Sub(w.base).Truncate(time.Millisecond)
+	switch r := req.(type) {
+	case *streamv1.WriteRequest:
+		shiftTimestamp(r.GetElement().GetTimestamp(), shift)
+	case *measurev1.WriteRequest:
+		shiftTimestamp(r.GetDataPoint().GetTimestamp(), shift)
+	case *streamv1.QueryRequest:
+		shiftTimeRange(r.GetTimeRange(), shift)
+	case *measurev1.QueryRequest:
+		shiftTimeRange(r.GetTimeRange(), shift)
+	}
+	return req
+}
+
+func shiftTimeRange(tr *modelv1.TimeRange, shift time.Duration) {
+	shiftTimestamp(tr.GetBegin(), shift)
+	shiftTimestamp(tr.GetEnd(), shift)
+}
+
+func shiftTimestamp(ts *timestamppb.Timestamp, shift time.Duration) {
+	if ts == nil {
+		return
+	}
+	t := timestamppb.New(ts.AsTime().Add(shift))
+	ts.Seconds, ts.Nanos = t.Seconds, t.Nanos
+}
+
+func newRequest(kind string) (proto.Message, error) {
+	switch kind {
+	case KindStreamWrite:
+		return &streamv1.WriteRequest{}, nil
+	case KindMeasureWrite:
+		return &measurev1.WriteRequest{}, nil
+	case KindStreamQuery:
+		return &streamv1.QueryRequest{}, nil
+	case KindMeasureQuery:
+		return &measurev1.QueryRequest{}, nil
+	default:
+		return nil, fmt.Errorf("unknown kind %q", kind)
+	}
+}
@@ -0,0 +1,71 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package benchmark
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+)
+
+func TestLoadWorkload(t *testing.T) {
+	w, err := LoadWorkload("testdata/sw.jsonl")
+	require.NoError(t, err)
+	assert.Equal(t, "sw", w.Name)
+	require.Len(t, w.Operations, 400)
+	kinds := make(map[string]int)
+	for _, op := range w.Operations {
+		kinds[op.Kind]++
+	}
+	assert.Equal(t, map[string]int{KindMeasureWrite: 200, KindStreamWrite: 160, KindStreamQuery: 20, KindMeasureQuery: 20}, kinds)
+	assert.Equal(t, 3990*time.Millisecond, w.Span())
+}
+
+func TestRebase(t *testing.T) {
+	w, err := ParseWorkload("test", strings.NewReader(`
+{"time":"2024-01-01T00:00:01Z","kind":"stream_query","request":{"groups":["default"],"name":"sw",`+
+		`"timeRange":{"begin":"2024-01-01T00:00:00Z","end":"2024-01-01T00:00:01Z"}}}
+{"time":"2024-01-01T00:00:00Z","kind":"measure","request":{"metadata":{"group":"sw_metric","name":"service_cpm_minute"},`+
+		`"dataPoint":{"timestamp":"2024-01-01T00:00:00Z"}}}
+`))
+	require.NoError(t, err)
+	require.Len(t, w.Operations, 2)
+	// the operations are sorted by the recorded time.
+	assert.Equal(t, KindMeasureWrite, w.Operations[0].Kind)
+	assert.Equal(t, time.Second, w.Operations[1].Offset)
+
+	start := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	write := w.rebase(w.Operations[0], start).(*measurev1.WriteRequest)
+	assert.Equal(t, start, write.GetDataPoint().GetTimestamp().AsTime())
+	query := w.rebase(w.Operations[1], start).(*streamv1.QueryRequest)
+	assert.Equal(t, start, query.GetTimeRange().GetBegin().AsTime())
+	assert.Equal(t, start.Add(time.Second), query.GetTimeRange().GetEnd().AsTime())
+	// the recorded requests are intact.
+	assert.Equal(t, 2024, w.Operations[0].Request.(*measurev1.WriteRequest).GetDataPoint().GetTimestamp().AsTime().Year())
+}
+
+func TestParseWorkloadUnknownKind(t *testing.T) {
+	_, err := ParseWorkload("test", strings.NewReader(`{"kind":"trace","request":{}}`))
+	require.ErrorContains(t, err, `line 1: unknown kind "trace"`)
+}
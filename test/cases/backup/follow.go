@@ -0,0 +1,136 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package backup_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/banyand/backup"
+	"github.com/apache/skywalking-banyandb/pkg/test"
+	"github.com/apache/skywalking-banyandb/pkg/test/flags"
+)
+
+type followStatus struct {
+	Catalogs map[string]struct {
+		Snapshot string `json:"snapshot"`
+		Error    string `json:"error"`
+	} `json:"catalogs"`
+	Promoted bool `json:"promoted"`
+}
+
+var _ = ginkgo.Describe("Follow", func() {
+	ginkgo.It("should follow the primary and be promoted", func() {
+		client := databasev1.NewSnapshotServiceClient(SharedContext.Connection)
+		stream, err := client.PullSnapshot(context.Background(), &databasev1.PullSnapshotRequest{
+			Catalog: commonv1.Catalog_CATALOG_STREAM,
+			Name:    "not-exist",
+		})
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		_, err = stream.Recv()
+		if status.Code(err) == codes.Unimplemented {
+			ginkgo.Skip("only the data nodes serve the snapshot pulling")
+		}
+		gomega.Expect(status.Code(err)).To(gomega.Equal(codes.NotFound))
+
+		standbyDir, err := os.MkdirTemp("", "follow-standby")
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		defer os.RemoveAll(standbyDir)
+		defer clearSnapshotDirs()
+		ports, err := test.AllocateFreePorts(1)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		httpAddr := fmt.Sprintf("127.0.0.1:%d", ports[0])
+
+		ginkgo.By("Follow the primary")
+		followCmd := backup.NewRestoreCommand()
+		followCmd.SetArgs([]string{
+			"follow",
+			"--primary-addr", SharedContext.DataAddr,
+			"--stream-groups", "default",
+			"--measure-groups", "sw_metric",
+			"--stream-root-path", standbyDir,
+			"--measure-root-path", standbyDir,
+			"--http-addr", httpAddr,
+			"--interval", "1s",
+		})
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- followCmd.Execute()
+		}()
+
+		getStatus := func() (st followStatus, err error) {
+			resp, err := http.Get("http://" + httpAddr + "/status")
+			if err != nil {
+				return st, err
+			}
+			defer resp.Body.Close()
+			err = json.NewDecoder(resp.Body).Decode(&st)
+			return st, err
+		}
+		gomega.Eventually(func(g gomega.Gomega) {
+			st, errStatus := getStatus()
+			g.Expect(errStatus).NotTo(gomega.HaveOccurred())
+			g.Expect(st.Catalogs).To(gomega.HaveLen(2))
+			for _, c := range st.Catalogs {
+				g.Expect(c.Error).To(gomega.BeEmpty())
+				g.Expect(c.Snapshot).NotTo(gomega.BeEmpty())
+			}
+		}, flags.EventuallyTimeout).Should(gomega.Succeed())
+
+		resp, err := http.Get("http://" + httpAddr + "/metrics")
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		metrics, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(string(metrics)).To(gomega.ContainSubstring(`banyandb_follow_lag_seconds{catalog="stream"}`))
+
+		ginkgo.By("Promote the standby")
+		resp, err = http.Post("http://"+httpAddr+"/promote", "application/json", nil)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		var st followStatus
+		err = json.NewDecoder(resp.Body).Decode(&st)
+		resp.Body.Close()
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(resp.StatusCode).To(gomega.Equal(http.StatusOK))
+		gomega.Expect(st.Promoted).To(gomega.BeTrue())
+		gomega.Eventually(errCh, 10*time.Second).Should(gomega.Receive(gomega.BeNil()))
+
+		for catalog, group := range map[string]string{"stream": "default", "measure": "sw_metric"} {
+			snapshotGroupDir := filepath.Join(SharedContext.RootDir, catalog, "snapshots", st.Catalogs[catalog].Snapshot, group)
+			want, errCount := countFilesRecursive(snapshotGroupDir)
+			gomega.Expect(errCount).NotTo(gomega.HaveOccurred())
+			gomega.Expect(want).To(gomega.BeNumerically(">", 0))
+			got, errCount := countFilesRecursive(filepath.Join(standbyDir, catalog, "data", group))
+			gomega.Expect(errCount).NotTo(gomega.HaveOccurred())
+			gomega.Expect(got).To(gomega.Equal(want))
+		}
+	})
+})
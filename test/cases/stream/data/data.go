@@ -92,6 +92,13 @@ var VerifyFn = func(innerGm gm.Gomega, sharedContext helpers.SharedContext, args
 			want.Elements[i].ElementId = hex.EncodeToString(convert.Uint64ToBytes(convert.HashStr(query.Name + "|" + want.Elements[i].ElementId)))
 		}
 	}
+	if len(query.Groups) == 1 {
+		for i := range want.Elements {
+			if want.Elements[i].Group == "" {
+				want.Elements[i].Group = query.Groups[0]
+			}
+		}
+	}
 	if args.DisOrder {
 		slices.SortFunc(want.Elements, func(a, b *streamv1.Element) int {
 			return strings.Compare(a.ElementId, b.ElementId)
@@ -100,8 +107,12 @@ var VerifyFn = func(innerGm gm.Gomega, sharedContext helpers.SharedContext, args
 			return strings.Compare(a.ElementId, b.ElementId)
 		})
 	}
+	// the coverage depends on the nodes of the cluster, which is verified to be complete instead.
+	// the cold read depends on the stages the query reads.
+	innerGm.Expect(resp.GetCoverage().GetIncompleteNodes()).To(gm.BeEmpty())
 	var extra []cmp.Option
 	extra = append(extra, protocmp.IgnoreUnknown(),
+		protocmp.IgnoreFields(&streamv1.QueryResponse{}, "coverage", "cold_read"),
 		protocmp.IgnoreFields(&streamv1.Element{}, "timestamp"),
 		protocmp.Transform())
 	if args.IgnoreElementID {
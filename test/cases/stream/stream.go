@@ -50,6 +50,7 @@ var _ = g.DescribeTable("Scanning Streams", func(args helpers.Args) {
 	g.Entry("limit", helpers.Args{Input: "limit", Duration: 1 * time.Hour}),
 	g.Entry("max limit", helpers.Args{Input: "all_max_limit", Want: "all", Duration: 1 * time.Hour}),
 	g.Entry("offset", helpers.Args{Input: "offset", Duration: 1 * time.Hour}),
+	g.Entry("distinct by tag", helpers.Args{Input: "distinct_by_tag", Duration: 1 * time.Hour}),
 	g.Entry("order asc", helpers.Args{Input: "order_asc", Duration: 1 * time.Hour}),
 	g.Entry("order desc", helpers.Args{Input: "order_desc", Duration: 1 * time.Hour}),
 	g.Entry("nothing", helpers.Args{
@@ -72,8 +73,15 @@ var _ = g.DescribeTable("Scanning Streams", func(args helpers.Args) {
 	g.Entry("filter by non-indexed tag order by duration desc with limit 3", helpers.Args{Input: "sort_duration_no_index_limit", Duration: 1 * time.Hour}),
 	g.Entry("get empty result by non-indexed tag", helpers.Args{Input: "filter_tag_empty", Duration: 1 * time.Hour, WantEmpty: true}),
 	g.Entry("get results by no non-index tag", helpers.Args{Input: "filter_no_indexed", Duration: 1 * time.Hour}),
+	g.Entry("filter by a null tag", helpers.Args{Input: "filter_is_null", Duration: 1 * time.Hour, DisOrder: true}),
+	g.Entry("filter by an existing tag", helpers.Args{Input: "filter_exists", Duration: 1 * time.Hour, DisOrder: true}),
+	g.Entry("filter by a prefix", helpers.Args{Input: "filter_prefix", Duration: 1 * time.Hour, DisOrder: true}),
+	g.Entry("filter by a wildcard", helpers.Args{Input: "filter_wildcard", Duration: 1 * time.Hour, DisOrder: true}),
 	g.Entry("numeric local index: less", helpers.Args{Input: "less", Duration: 1 * time.Hour}),
 	g.Entry("numeric local index: less and eq", helpers.Args{Input: "less_eq", Duration: 1 * time.Hour}),
+	g.Entry("numeric local index: in", helpers.Args{Input: "in", Duration: 1 * time.Hour}),
+	g.Entry("facet", helpers.Args{Input: "facet", Duration: 1 * time.Hour}),
+	g.Entry("facet on a tag without the inverted index", helpers.Args{Input: "facet_not_indexed", Duration: 1 * time.Hour, WantErr: true}),
 	g.Entry("logical expression", helpers.Args{Input: "logical", Duration: 1 * time.Hour}),
 	g.Entry("having", helpers.Args{Input: "having", Duration: 1 * time.Hour}),
 	g.Entry("having non indexed", helpers.Args{Input: "having_non_indexed", Duration: 1 * time.Hour}),
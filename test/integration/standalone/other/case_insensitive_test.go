@@ -0,0 +1,156 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package integration_other_test
+
+import (
+	"context"
+	"io"
+	"time"
+
+	g "github.com/onsi/ginkgo/v2"
+	gm "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gleak"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/pkg/grpchelper"
+	"github.com/apache/skywalking-banyandb/pkg/pool"
+	"github.com/apache/skywalking-banyandb/pkg/test/flags"
+	"github.com/apache/skywalking-banyandb/pkg/test/gmatcher"
+	"github.com/apache/skywalking-banyandb/pkg/test/setup"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+var _ = g.Describe("Case-insensitive index", func() {
+	var deferFn func()
+	var conn *grpc.ClientConn
+	var goods []gleak.Goroutine
+	md := &commonv1.Metadata{Name: "http_log", Group: "case_insensitive"}
+
+	g.BeforeEach(func() {
+		var addr string
+		addr, _, deferFn = setup.Standalone()
+		var err error
+		conn, err = grpchelper.Conn(addr, 10*time.Second, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		gm.Expect(err).NotTo(gm.HaveOccurred())
+		ctx := context.Background()
+		_, err = databasev1.NewGroupRegistryServiceClient(conn).Create(ctx, &databasev1.GroupRegistryServiceCreateRequest{
+			Group: &commonv1.Group{
+				Metadata: &commonv1.Metadata{Name: md.Group},
+				Catalog:  commonv1.Catalog_CATALOG_STREAM,
+				ResourceOpts: &commonv1.ResourceOpts{
+					ShardNum:        1,
+					SegmentInterval: &commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_DAY, Num: 1},
+					Ttl:             &commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_DAY, Num: 3},
+				},
+			},
+		})
+		gm.Expect(err).NotTo(gm.HaveOccurred())
+		_, err = databasev1.NewStreamRegistryServiceClient(conn).Create(ctx, &databasev1.StreamRegistryServiceCreateRequest{
+			Stream: &databasev1.Stream{
+				Metadata: md,
+				TagFamilies: []*databasev1.TagFamilySpec{{
+					Name: "default",
+					Tags: []*databasev1.TagSpec{{Name: "svc", Type: databasev1.TagType_TAG_TYPE_STRING}, {Name: "method", Type: databasev1.TagType_TAG_TYPE_STRING}},
+				}},
+				Entity: &databasev1.Entity{TagNames: []string{"svc"}},
+			},
+		})
+		gm.Expect(err).NotTo(gm.HaveOccurred())
+		_, err = databasev1.NewIndexRuleRegistryServiceClient(conn).Create(ctx, &databasev1.IndexRuleRegistryServiceCreateRequest{
+			IndexRule: &databasev1.IndexRule{
+				Metadata:        &commonv1.Metadata{Name: "method", Group: md.Group},
+				Tags:            []string{"method"},
+				Type:            databasev1.IndexRule_TYPE_INVERTED,
+				CaseInsensitive: true,
+			},
+		})
+		gm.Expect(err).NotTo(gm.HaveOccurred())
+		_, err = databasev1.NewIndexRuleBindingRegistryServiceClient(conn).Create(ctx, &databasev1.IndexRuleBindingRegistryServiceCreateRequest{
+			IndexRuleBinding: &databasev1.IndexRuleBinding{
+				Metadata: &commonv1.Metadata{Name: "http_log", Group: md.Group},
+				Rules:    []string{"method"},
+				Subject:  &databasev1.Subject{Catalog: commonv1.Catalog_CATALOG_STREAM, Name: md.Name},
+				BeginAt:  timestamppb.New(time.Now().Add(-time.Hour)),
+				ExpireAt: timestamppb.New(time.Now().Add(24 * time.Hour)),
+			},
+		})
+		gm.Expect(err).NotTo(gm.HaveOccurred())
+		goods = gleak.Goroutines()
+	})
+	g.AfterEach(func() {
+		gm.Expect(conn.Close()).To(gm.Succeed())
+		deferFn()
+		gm.Eventually(gleak.Goroutines, flags.EventuallyTimeout).ShouldNot(gleak.HaveLeaked(goods))
+		gm.Eventually(pool.AllRefsCount, flags.EventuallyTimeout).Should(gmatcher.HaveZeroRef())
+	})
+	g.It("matches the tag values ignoring the case", func() {
+		client := streamv1.NewStreamServiceClient(conn)
+		writeClient, err := client.Write(context.Background())
+		gm.Expect(err).NotTo(gm.HaveOccurred())
+		now := timestamp.NowMilli()
+		for i, method := range []string{"GET", "get", "Post"} {
+			gm.Expect(writeClient.Send(&streamv1.WriteRequest{
+				Metadata: md,
+				Element: &streamv1.ElementValue{
+					ElementId: method,
+					Timestamp: timestamppb.New(now.Add(time.Duration(i) * time.Millisecond)),
+					TagFamilies: []*modelv1.TagFamilyForWrite{{
+						Tags: []*modelv1.TagValue{strTag("svc1"), strTag(method)},
+					}},
+				},
+				MessageId: uint64(time.Now().UnixNano()),
+			})).To(gm.Succeed())
+		}
+		gm.Expect(writeClient.CloseSend()).To(gm.Succeed())
+		for {
+			if _, errRecv := writeClient.Recv(); errRecv != nil {
+				gm.Expect(errRecv).To(gm.Equal(io.EOF))
+				break
+			}
+		}
+
+		gm.Eventually(func(innerGm gm.Gomega) {
+			resp, errQuery := client.Query(context.Background(), &streamv1.QueryRequest{
+				Groups: []string{md.Group},
+				Name:   md.Name,
+				TimeRange: &modelv1.TimeRange{
+					Begin: timestamppb.New(now.Add(-time.Minute)),
+					End:   timestamppb.New(now.Add(time.Minute)),
+				},
+				Criteria: &modelv1.Criteria{Exp: &modelv1.Criteria_Condition{Condition: &modelv1.Condition{
+					Name:  "method",
+					Op:    modelv1.Condition_BINARY_OP_EQ,
+					Value: strTag("Get"),
+				}}},
+				Projection: &modelv1.TagProjection{TagFamilies: []*modelv1.TagProjection_TagFamily{{Name: "default", Tags: []string{"method"}}}},
+			})
+			innerGm.Expect(errQuery).NotTo(gm.HaveOccurred())
+			methods := make([]string, 0, len(resp.GetElements()))
+			for _, e := range resp.GetElements() {
+				methods = append(methods, e.GetTagFamilies()[0].GetTags()[0].GetValue().GetStr().GetValue())
+			}
+			innerGm.Expect(methods).To(gm.ConsistOf("GET", "get"), "the original values are returned")
+		}, flags.EventuallyTimeout).Should(gm.Succeed())
+	})
+})
@@ -0,0 +1,144 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package integration_other_test
+
+import (
+	"context"
+	"io"
+	"time"
+
+	g "github.com/onsi/ginkgo/v2"
+	gm "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gleak"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/pkg/grpchelper"
+	"github.com/apache/skywalking-banyandb/pkg/pool"
+	"github.com/apache/skywalking-banyandb/pkg/test/flags"
+	"github.com/apache/skywalking-banyandb/pkg/test/gmatcher"
+	"github.com/apache/skywalking-banyandb/pkg/test/setup"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+var _ = g.Describe("Range deletion", func() {
+	var deferFn func()
+	var conn *grpc.ClientConn
+	var goods []gleak.Goroutine
+	md := &commonv1.Metadata{Name: "backfill_log", Group: "range_deletion"}
+
+	g.BeforeEach(func() {
+		var addr string
+		addr, _, deferFn = setup.Standalone()
+		var err error
+		conn, err = grpchelper.Conn(addr, 10*time.Second, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		gm.Expect(err).NotTo(gm.HaveOccurred())
+		_, err = databasev1.NewGroupRegistryServiceClient(conn).Create(context.Background(), &databasev1.GroupRegistryServiceCreateRequest{
+			Group: &commonv1.Group{
+				Metadata: &commonv1.Metadata{Name: md.Group},
+				Catalog:  commonv1.Catalog_CATALOG_STREAM,
+				ResourceOpts: &commonv1.ResourceOpts{
+					ShardNum:        2,
+					SegmentInterval: &commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_DAY, Num: 1},
+					Ttl:             &commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_DAY, Num: 3},
+				},
+			},
+		})
+		gm.Expect(err).NotTo(gm.HaveOccurred())
+		_, err = databasev1.NewStreamRegistryServiceClient(conn).Create(context.Background(), &databasev1.StreamRegistryServiceCreateRequest{
+			Stream: &databasev1.Stream{
+				Metadata: md,
+				TagFamilies: []*databasev1.TagFamilySpec{{
+					Name: "default",
+					Tags: []*databasev1.TagSpec{{Name: "svc", Type: databasev1.TagType_TAG_TYPE_STRING}, {Name: "id", Type: databasev1.TagType_TAG_TYPE_STRING}},
+				}},
+				Entity: &databasev1.Entity{TagNames: []string{"svc"}},
+			},
+		})
+		gm.Expect(err).NotTo(gm.HaveOccurred())
+		goods = gleak.Goroutines()
+	})
+	g.AfterEach(func() {
+		gm.Expect(conn.Close()).To(gm.Succeed())
+		deferFn()
+		gm.Eventually(gleak.Goroutines, flags.EventuallyTimeout).ShouldNot(gleak.HaveLeaked(goods))
+		gm.Eventually(pool.AllRefsCount, flags.EventuallyTimeout).Should(gmatcher.HaveZeroRef())
+	})
+	g.It("keeps the data ingested into the range after the deletion", func() {
+		now := timestamp.NowMilli()
+		write := func(ids ...string) {
+			writeClient, err := streamv1.NewStreamServiceClient(conn).Write(context.Background())
+			gm.Expect(err).NotTo(gm.HaveOccurred())
+			for i, id := range ids {
+				gm.Expect(writeClient.Send(&streamv1.WriteRequest{
+					Metadata: md,
+					Element: &streamv1.ElementValue{
+						ElementId:   id,
+						Timestamp:   timestamppb.New(now.Add(time.Duration(i) * time.Millisecond)),
+						TagFamilies: []*modelv1.TagFamilyForWrite{{Tags: []*modelv1.TagValue{strTag("svc1"), strTag(id)}}},
+					},
+					MessageId: uint64(time.Now().UnixNano()),
+				})).To(gm.Succeed())
+			}
+			gm.Expect(writeClient.CloseSend()).To(gm.Succeed())
+			for {
+				resp, errRecv := writeClient.Recv()
+				if errRecv != nil {
+					gm.Expect(errRecv).To(gm.Equal(io.EOF))
+					break
+				}
+				gm.Expect(resp.GetStatus()).To(gm.Equal(modelv1.Status_STATUS_SUCCEED.String()))
+			}
+		}
+		client := streamv1.NewStreamServiceClient(conn)
+		query := func() ([]string, error) {
+			resp, err := client.Query(context.Background(), &streamv1.QueryRequest{
+				Groups:     []string{md.Group},
+				Name:       md.Name,
+				TimeRange:  &modelv1.TimeRange{Begin: timestamppb.New(now.Add(-time.Hour)), End: timestamppb.New(now.Add(time.Hour))},
+				Projection: &modelv1.TagProjection{TagFamilies: []*modelv1.TagProjection_TagFamily{{Name: "default", Tags: []string{"id"}}}},
+			})
+			if err != nil {
+				return nil, err
+			}
+			ids := make([]string, 0, len(resp.GetElements()))
+			for _, e := range resp.GetElements() {
+				ids = append(ids, e.GetTagFamilies()[0].GetTags()[0].GetValue().GetStr().GetValue())
+			}
+			return ids, nil
+		}
+
+		write("bad0", "bad1", "good2")
+		gm.Eventually(query, flags.EventuallyTimeout).Should(gm.ConsistOf("bad0", "bad1", "good2"))
+		_, err := databasev1.NewDeletionServiceClient(conn).DeleteRange(context.Background(), &databasev1.DeletionServiceDeleteRangeRequest{
+			Group:     md.Group,
+			TimeRange: &modelv1.TimeRange{Begin: timestamppb.New(now), End: timestamppb.New(now.Add(2 * time.Millisecond))},
+			Confirm:   true,
+		})
+		gm.Expect(err).NotTo(gm.HaveOccurred())
+		gm.Expect(query()).To(gm.ConsistOf("good2"))
+
+		write("good0", "good1")
+		gm.Eventually(query, flags.EventuallyTimeout).Should(gm.ConsistOf("good0", "good1", "good2"))
+	})
+})
@@ -0,0 +1,162 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package integration_other_test
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"time"
+
+	g "github.com/onsi/ginkgo/v2"
+	gm "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gleak"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/pkg/grpchelper"
+	"github.com/apache/skywalking-banyandb/pkg/pool"
+	"github.com/apache/skywalking-banyandb/pkg/test"
+	"github.com/apache/skywalking-banyandb/pkg/test/flags"
+	"github.com/apache/skywalking-banyandb/pkg/test/gmatcher"
+	"github.com/apache/skywalking-banyandb/pkg/test/setup"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+var _ = g.Describe("Element metadata", func() {
+	var deferFn, spaceDeferFn func()
+	var conn, adminConn *grpc.ClientConn
+	var goods []gleak.Goroutine
+	md := &commonv1.Metadata{Name: "access_log", Group: "element_metadata"}
+
+	g.BeforeEach(func() {
+		var path string
+		var err error
+		path, spaceDeferFn, err = test.NewSpace()
+		gm.Expect(err).NotTo(gm.HaveOccurred())
+		adminAddr := "unix://" + filepath.Join(path, "admin.sock")
+		var addr string
+		addr, _, deferFn = setup.Standalone("--grpc-listen-addrs=" + adminAddr + "?admin=true")
+		conn, err = grpchelper.Conn(addr, 10*time.Second, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		gm.Expect(err).NotTo(gm.HaveOccurred())
+		adminConn, err = grpchelper.Conn(adminAddr, 10*time.Second, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		gm.Expect(err).NotTo(gm.HaveOccurred())
+		ctx := context.Background()
+		_, err = databasev1.NewGroupRegistryServiceClient(conn).Create(ctx, &databasev1.GroupRegistryServiceCreateRequest{
+			Group: &commonv1.Group{
+				Metadata: &commonv1.Metadata{Name: md.Group},
+				Catalog:  commonv1.Catalog_CATALOG_STREAM,
+				ResourceOpts: &commonv1.ResourceOpts{
+					ShardNum:        1,
+					SegmentInterval: &commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_DAY, Num: 1},
+					Ttl:             &commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_DAY, Num: 3},
+				},
+			},
+		})
+		gm.Expect(err).NotTo(gm.HaveOccurred())
+		_, err = databasev1.NewStreamRegistryServiceClient(conn).Create(ctx, &databasev1.StreamRegistryServiceCreateRequest{
+			Stream: &databasev1.Stream{
+				Metadata: md,
+				TagFamilies: []*databasev1.TagFamilySpec{{
+					Name: "default",
+					Tags: []*databasev1.TagSpec{{Name: "svc", Type: databasev1.TagType_TAG_TYPE_STRING}, {Name: "path", Type: databasev1.TagType_TAG_TYPE_STRING}},
+				}},
+				Entity: &databasev1.Entity{TagNames: []string{"svc"}},
+			},
+		})
+		gm.Expect(err).NotTo(gm.HaveOccurred())
+		goods = gleak.Goroutines()
+	})
+	g.AfterEach(func() {
+		gm.Expect(conn.Close()).To(gm.Succeed())
+		gm.Expect(adminConn.Close()).To(gm.Succeed())
+		deferFn()
+		spaceDeferFn()
+		gm.Eventually(gleak.Goroutines, flags.EventuallyTimeout).ShouldNot(gleak.HaveLeaked(goods))
+		gm.Eventually(pool.AllRefsCount, flags.EventuallyTimeout).Should(gmatcher.HaveZeroRef())
+	})
+	g.It("is only available through the admin listeners", func() {
+		client := streamv1.NewStreamServiceClient(conn)
+		writeClient, err := client.Write(context.Background())
+		gm.Expect(err).NotTo(gm.HaveOccurred())
+		now := timestamp.NowMilli()
+		for i, path := range []string{"/a", "/b", "/c"} {
+			gm.Expect(writeClient.Send(&streamv1.WriteRequest{
+				Metadata: md,
+				Element: &streamv1.ElementValue{
+					ElementId: path,
+					Timestamp: timestamppb.New(now.Add(time.Duration(i) * time.Millisecond)),
+					TagFamilies: []*modelv1.TagFamilyForWrite{{
+						Tags: []*modelv1.TagValue{strTag("svc1"), strTag(path)},
+					}},
+				},
+				MessageId: uint64(time.Now().UnixNano()),
+			})).To(gm.Succeed())
+		}
+		gm.Expect(writeClient.CloseSend()).To(gm.Succeed())
+		for {
+			if _, errRecv := writeClient.Recv(); errRecv != nil {
+				gm.Expect(errRecv).To(gm.Equal(io.EOF))
+				break
+			}
+		}
+
+		req := &streamv1.QueryRequest{
+			Groups: []string{md.Group},
+			Name:   md.Name,
+			TimeRange: &modelv1.TimeRange{
+				Begin: timestamppb.New(now.Add(-time.Minute)),
+				End:   timestamppb.New(now.Add(time.Minute)),
+			},
+			Projection:          &modelv1.TagProjection{TagFamilies: []*modelv1.TagProjection_TagFamily{{Name: "default", Tags: []string{"path"}}}},
+			WithElementMetadata: true,
+		}
+		_, err = client.Query(context.Background(), req)
+		gm.Expect(status.Code(err)).To(gm.Equal(codes.PermissionDenied))
+
+		adminClient := streamv1.NewStreamServiceClient(adminConn)
+		gm.Eventually(func(innerGm gm.Gomega) {
+			resp, errQuery := adminClient.Query(context.Background(), req)
+			innerGm.Expect(errQuery).NotTo(gm.HaveOccurred())
+			innerGm.Expect(resp.GetElements()).To(gm.HaveLen(3))
+			for _, e := range resp.GetElements() {
+				m := e.GetMetadata()
+				innerGm.Expect(m).NotTo(gm.BeNil())
+				innerGm.Expect(m.GetShardId()).To(gm.BeZero())
+				innerGm.Expect(m.GetSegment()).NotTo(gm.BeEmpty())
+				innerGm.Expect(m.GetPartId()).NotTo(gm.BeZero())
+				innerGm.Expect(m.GetSize()).NotTo(gm.BeZero())
+			}
+		}, flags.EventuallyTimeout).Should(gm.Succeed())
+
+		req.WithElementMetadata = false
+		resp, err := client.Query(context.Background(), req)
+		gm.Expect(err).NotTo(gm.HaveOccurred())
+		gm.Expect(resp.GetElements()).To(gm.HaveLen(3))
+		for _, e := range resp.GetElements() {
+			gm.Expect(e.GetMetadata()).To(gm.BeNil())
+		}
+	})
+})
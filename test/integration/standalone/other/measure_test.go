@@ -18,14 +18,22 @@
 package integration_other_test
 
 import (
+	"context"
 	"time"
 
 	g "github.com/onsi/ginkgo/v2"
 	gm "github.com/onsi/gomega"
 	"github.com/onsi/gomega/gleak"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
 	"github.com/apache/skywalking-banyandb/pkg/grpchelper"
 	"github.com/apache/skywalking-banyandb/pkg/pool"
 	"github.com/apache/skywalking-banyandb/pkg/test/flags"
@@ -33,6 +41,7 @@ import (
 	"github.com/apache/skywalking-banyandb/pkg/test/helpers"
 	"github.com/apache/skywalking-banyandb/pkg/test/setup"
 	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+	"github.com/apache/skywalking-banyandb/pkg/tracecontext"
 	casesMeasureData "github.com/apache/skywalking-banyandb/test/cases/measure/data"
 )
 
@@ -70,4 +79,103 @@ var _ = g.Describe("Query service_cpm_minute", func() {
 			}, helpers.Args{Input: "all", Want: "update", Duration: 25 * time.Minute, Offset: -20 * time.Minute})
 		}, flags.EventuallyTimeout).Should(gm.Succeed())
 	})
+	g.It("truncates the query reaching its deadline", func() {
+		client := measurev1.NewMeasureServiceClient(conn)
+		req := &measurev1.QueryRequest{
+			Groups: []string{"sw_metric"},
+			Name:   "service_cpm_minute",
+			TimeRange: &modelv1.TimeRange{
+				Begin: timestamppb.New(baseTime.Add(-time.Hour)),
+				End:   timestamppb.New(baseTime.Add(time.Hour)),
+			},
+			TagProjection: &modelv1.TagProjection{
+				TagFamilies: []*modelv1.TagProjection_TagFamily{{Name: "default", Tags: []string{"id", "entity_id"}}},
+			},
+			FieldProjection: &measurev1.QueryRequest_FieldProjection{Names: []string{"total", "value"}},
+		}
+		gm.Eventually(func(innerGm gm.Gomega) {
+			resp, err := client.Query(context.Background(), req)
+			innerGm.Expect(err).NotTo(gm.HaveOccurred())
+			innerGm.Expect(resp.GetDataPoints()).NotTo(gm.BeEmpty())
+			innerGm.Expect(resp.GetTruncated()).To(gm.BeFalse())
+		}, flags.EventuallyTimeout).Should(gm.Succeed())
+		req.Timeout = durationpb.New(time.Nanosecond)
+		resp, err := client.Query(context.Background(), req)
+		gm.Expect(err).NotTo(gm.HaveOccurred())
+		gm.Expect(resp.GetTruncated()).To(gm.BeTrue())
+		gm.Expect(resp.GetTruncatedReason()).To(gm.ContainSubstring("deadline"))
+	})
+	g.It("traces the query with the trace id of the traceparent", func() {
+		client := measurev1.NewMeasureServiceClient(conn)
+		req := &measurev1.QueryRequest{
+			Groups: []string{"sw_metric"},
+			Name:   "service_cpm_minute",
+			TimeRange: &modelv1.TimeRange{
+				Begin: timestamppb.New(baseTime.Add(-time.Hour)),
+				End:   timestamppb.New(baseTime.Add(time.Hour)),
+			},
+			FieldProjection: &measurev1.QueryRequest_FieldProjection{Names: []string{"total"}},
+			Trace:           true,
+		}
+		ctx := metadata.AppendToOutgoingContext(context.Background(),
+			tracecontext.TraceParentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		gm.Eventually(func(innerGm gm.Gomega) {
+			resp, err := client.Query(ctx, req)
+			innerGm.Expect(err).NotTo(gm.HaveOccurred())
+			innerGm.Expect(resp.GetDataPoints()).NotTo(gm.BeEmpty())
+			innerGm.Expect(resp.GetTrace().GetTraceId()).To(gm.Equal("4bf92f3577b34da6a3ce929d0e0e4736"))
+		}, flags.EventuallyTimeout).Should(gm.Succeed())
+	})
+	g.It("drops the data points of the deleted series", func() {
+		client := measurev1.NewMeasureServiceClient(conn)
+		req := &measurev1.QueryRequest{
+			Groups: []string{"sw_metric"},
+			Name:   "service_cpm_minute",
+			TimeRange: &modelv1.TimeRange{
+				Begin: timestamppb.New(baseTime.Add(-time.Hour)),
+				End:   timestamppb.New(baseTime.Add(time.Hour)),
+			},
+			TagProjection: &modelv1.TagProjection{
+				TagFamilies: []*modelv1.TagProjection_TagFamily{{Name: "default", Tags: []string{"entity_id"}}},
+			},
+			FieldProjection: &measurev1.QueryRequest_FieldProjection{Names: []string{"total"}},
+		}
+		entities := func(innerGm gm.Gomega) map[string]int {
+			resp, err := client.Query(context.Background(), req)
+			innerGm.Expect(err).NotTo(gm.HaveOccurred())
+			result := make(map[string]int)
+			for _, dp := range resp.GetDataPoints() {
+				result[dp.GetTagFamilies()[0].GetTags()[0].GetValue().GetStr().GetValue()]++
+			}
+			return result
+		}
+		gm.Eventually(func(innerGm gm.Gomega) {
+			innerGm.Expect(entities(innerGm)).To(gm.HaveKey("entity_1"))
+		}, flags.EventuallyTimeout).Should(gm.Succeed())
+		resp, err := client.DeleteSeries(context.Background(), &measurev1.DeleteSeriesRequest{
+			Group: "sw_metric",
+			Name:  "service_cpm_minute",
+			Entities: []*measurev1.DeleteSeriesRequest_Entity{{Tags: []*modelv1.Tag{{
+				Key:   "entity_id",
+				Value: &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: "entity_1"}}},
+			}}}},
+		})
+		gm.Expect(err).NotTo(gm.HaveOccurred())
+		gm.Expect(resp.GetDeletedAt()).NotTo(gm.BeNil())
+		gm.Eventually(func(innerGm gm.Gomega) {
+			result := entities(innerGm)
+			innerGm.Expect(result).NotTo(gm.HaveKey("entity_1"))
+			innerGm.Expect(result).NotTo(gm.BeEmpty())
+		}, flags.EventuallyTimeout).Should(gm.Succeed())
+
+		_, err = client.DeleteSeries(context.Background(), &measurev1.DeleteSeriesRequest{
+			Group: "sw_metric",
+			Name:  "service_cpm_minute",
+			Entities: []*measurev1.DeleteSeriesRequest_Entity{{Tags: []*modelv1.Tag{{
+				Key:   "id",
+				Value: &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: "svc1"}}},
+			}}}},
+		})
+		gm.Expect(status.Code(err)).To(gm.Equal(codes.InvalidArgument))
+	})
 })
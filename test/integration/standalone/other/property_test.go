@@ -364,6 +364,56 @@ var _ = Describe("Property application", func() {
 		Expect(err).NotTo(HaveOccurred())
 		Expect(got.Properties).To(HaveLen(1))
 	})
+	It("sorts and pages properties", func() {
+		resp, err := client.Apply(context.Background(), &propertyv1.ApplyRequest{Property: &propertyv1.Property{
+			Metadata: md,
+			Id:       "3",
+			Tags: []*modelv1.Tag{
+				{Key: "t1", Value: &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: "v3"}}}},
+			},
+		}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.Created).To(BeTrue())
+		ids := func(properties []*propertyv1.Property) []string {
+			result := make([]string, 0, len(properties))
+			for _, p := range properties {
+				result = append(result, p.Id)
+			}
+			return result
+		}
+		orderBy := &propertyv1.QueryOrder{TagName: "t1", Sort: modelv1.Sort_SORT_DESC}
+		got, err := client.Query(context.Background(), &propertyv1.QueryRequest{
+			Groups:  []string{"g"},
+			Name:    "p",
+			Limit:   2,
+			OrderBy: orderBy,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ids(got.Properties)).To(Equal([]string{"3", "2"}))
+		Expect(got.HasMore).To(BeTrue())
+		got, err = client.Query(context.Background(), &propertyv1.QueryRequest{
+			Groups:  []string{"g"},
+			Name:    "p",
+			Limit:   2,
+			Offset:  2,
+			OrderBy: orderBy,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ids(got.Properties)).To(Equal([]string{"1"}))
+		Expect(got.HasMore).To(BeFalse())
+		got, err = client.Query(context.Background(), &propertyv1.QueryRequest{
+			Groups:        []string{"g"},
+			Name:          "p",
+			Limit:         1,
+			Offset:        1,
+			TagProjection: []string{"t1"},
+			OrderBy:       &propertyv1.QueryOrder{TagName: "t1", Sort: modelv1.Sort_SORT_ASC},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ids(got.Properties)).To(Equal([]string{"2"}))
+		Expect(got.Properties[0].Tags).To(HaveLen(1))
+		Expect(got.HasMore).To(BeTrue())
+	})
 	It("traces the query", func() {
 		got, err := client.Query(context.Background(), &propertyv1.QueryRequest{
 			Groups: []string{"g"},
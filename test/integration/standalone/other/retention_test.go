@@ -0,0 +1,91 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package integration_other_test
+
+import (
+	"context"
+	"time"
+
+	g "github.com/onsi/ginkgo/v2"
+	gm "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gleak"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/pkg/grpchelper"
+	"github.com/apache/skywalking-banyandb/pkg/pool"
+	"github.com/apache/skywalking-banyandb/pkg/test/flags"
+	"github.com/apache/skywalking-banyandb/pkg/test/gmatcher"
+	"github.com/apache/skywalking-banyandb/pkg/test/setup"
+)
+
+var _ = g.Describe("Retention", func() {
+	var deferFn func()
+	var conn *grpc.ClientConn
+	var goods []gleak.Goroutine
+
+	g.BeforeEach(func() {
+		var addr string
+		addr, _, deferFn = setup.Standalone()
+		var err error
+		conn, err = grpchelper.Conn(addr, 10*time.Second, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		gm.Expect(err).NotTo(gm.HaveOccurred())
+		goods = gleak.Goroutines()
+	})
+	g.AfterEach(func() {
+		gm.Expect(conn.Close()).To(gm.Succeed())
+		deferFn()
+		gm.Eventually(gleak.Goroutines, flags.EventuallyTimeout).ShouldNot(gleak.HaveLeaked(goods))
+		gm.Eventually(pool.AllRefsCount, flags.EventuallyTimeout).Should(gmatcher.HaveZeroRef())
+	})
+	g.It("previews and triggers the retention of the groups", func() {
+		// the groups are loaded with their storage by the warmup.
+		_, err := databasev1.NewWarmupServiceClient(conn).Warmup(context.Background(), &databasev1.WarmupRequest{Groups: []string{"default", "sw_metric"}})
+		gm.Expect(err).NotTo(gm.HaveOccurred())
+		client := databasev1.NewRetentionServiceClient(conn)
+		groups := []string{"default", "sw_metric", "not-exist"}
+		resp, err := client.Preview(context.Background(), &databasev1.RetentionServicePreviewRequest{Groups: groups})
+		gm.Expect(err).NotTo(gm.HaveOccurred())
+		retention := make(map[string]*databasev1.GroupRetention)
+		for _, r := range resp.GetGroups() {
+			retention[r.GetGroup()] = r
+		}
+		gm.Expect(retention).To(gm.HaveLen(3))
+		gm.Expect(retention["default"].GetCatalog()).To(gm.Equal(commonv1.Catalog_CATALOG_STREAM))
+		gm.Expect(retention["default"].GetError()).To(gm.BeEmpty())
+		gm.Expect(retention["default"].GetDeadline().AsTime()).To(gm.BeTemporally("<", time.Now()))
+		gm.Expect(retention["default"].GetSegments()).To(gm.BeEmpty())
+		gm.Expect(retention["sw_metric"].GetCatalog()).To(gm.Equal(commonv1.Catalog_CATALOG_MEASURE))
+		gm.Expect(retention["sw_metric"].GetError()).To(gm.BeEmpty())
+		gm.Expect(retention["not-exist"].GetError()).NotTo(gm.BeEmpty())
+
+		_, err = client.Trigger(context.Background(), &databasev1.RetentionServiceTriggerRequest{Groups: groups})
+		gm.Expect(status.Code(err)).To(gm.Equal(codes.FailedPrecondition))
+		_, err = client.Trigger(context.Background(), &databasev1.RetentionServiceTriggerRequest{Confirm: true})
+		gm.Expect(status.Code(err)).To(gm.Equal(codes.InvalidArgument))
+		triggered, err := client.Trigger(context.Background(), &databasev1.RetentionServiceTriggerRequest{Groups: []string{"default"}, Confirm: true})
+		gm.Expect(err).NotTo(gm.HaveOccurred())
+		gm.Expect(triggered.GetGroups()).To(gm.HaveLen(1))
+		gm.Expect(triggered.GetGroups()[0].GetError()).To(gm.BeEmpty())
+		gm.Expect(triggered.GetGroups()[0].GetSegments()).To(gm.BeEmpty())
+	})
+})
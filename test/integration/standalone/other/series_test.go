@@ -0,0 +1,158 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package integration_other_test
+
+import (
+	"context"
+	"io"
+	"time"
+
+	g "github.com/onsi/ginkgo/v2"
+	gm "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gleak"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/pkg/grpchelper"
+	"github.com/apache/skywalking-banyandb/pkg/pool"
+	"github.com/apache/skywalking-banyandb/pkg/test/flags"
+	"github.com/apache/skywalking-banyandb/pkg/test/gmatcher"
+	"github.com/apache/skywalking-banyandb/pkg/test/setup"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+func strTag(v string) *modelv1.TagValue {
+	return &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: v}}}
+}
+
+var _ = g.Describe("Series lookup", func() {
+	var deferFn func()
+	var conn *grpc.ClientConn
+	var goods []gleak.Goroutine
+	md := &commonv1.Metadata{Name: "instance_log", Group: "series_lookup"}
+
+	g.BeforeEach(func() {
+		var addr string
+		addr, _, deferFn = setup.Standalone()
+		var err error
+		conn, err = grpchelper.Conn(addr, 10*time.Second, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		gm.Expect(err).NotTo(gm.HaveOccurred())
+		_, err = databasev1.NewGroupRegistryServiceClient(conn).Create(context.Background(), &databasev1.GroupRegistryServiceCreateRequest{
+			Group: &commonv1.Group{
+				Metadata: &commonv1.Metadata{Name: md.Group},
+				Catalog:  commonv1.Catalog_CATALOG_STREAM,
+				ResourceOpts: &commonv1.ResourceOpts{
+					ShardNum:        2,
+					SegmentInterval: &commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_DAY, Num: 1},
+					Ttl:             &commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_DAY, Num: 3},
+				},
+			},
+		})
+		gm.Expect(err).NotTo(gm.HaveOccurred())
+		_, err = databasev1.NewStreamRegistryServiceClient(conn).Create(context.Background(), &databasev1.StreamRegistryServiceCreateRequest{
+			Stream: &databasev1.Stream{
+				Metadata: md,
+				TagFamilies: []*databasev1.TagFamilySpec{{
+					Name: "default",
+					Tags: []*databasev1.TagSpec{{Name: "svc", Type: databasev1.TagType_TAG_TYPE_STRING}, {Name: "inst", Type: databasev1.TagType_TAG_TYPE_STRING}},
+				}},
+				Entity: &databasev1.Entity{TagNames: []string{"svc", "inst"}},
+			},
+		})
+		gm.Expect(err).NotTo(gm.HaveOccurred())
+		goods = gleak.Goroutines()
+	})
+	g.AfterEach(func() {
+		gm.Expect(conn.Close()).To(gm.Succeed())
+		deferFn()
+		gm.Eventually(gleak.Goroutines, flags.EventuallyTimeout).ShouldNot(gleak.HaveLeaked(goods))
+		gm.Eventually(pool.AllRefsCount, flags.EventuallyTimeout).Should(gmatcher.HaveZeroRef())
+	})
+	g.It("looks up the series by the entity", func() {
+		writeClient, err := streamv1.NewStreamServiceClient(conn).Write(context.Background())
+		gm.Expect(err).NotTo(gm.HaveOccurred())
+		now := timestamp.NowMilli()
+		for i, e := range [][2]string{{"svc1", "inst1"}, {"svc1", "inst2"}, {"svc2", "inst1"}} {
+			gm.Expect(writeClient.Send(&streamv1.WriteRequest{
+				Metadata: md,
+				Element: &streamv1.ElementValue{
+					ElementId: e[0] + e[1],
+					Timestamp: timestamppb.New(now.Add(time.Duration(i) * time.Millisecond)),
+					TagFamilies: []*modelv1.TagFamilyForWrite{{
+						Tags: []*modelv1.TagValue{strTag(e[0]), strTag(e[1])},
+					}},
+				},
+				MessageId: uint64(time.Now().UnixNano()),
+			})).To(gm.Succeed())
+		}
+		gm.Expect(writeClient.CloseSend()).To(gm.Succeed())
+		for {
+			if _, errRecv := writeClient.Recv(); errRecv != nil {
+				gm.Expect(errRecv).To(gm.Equal(io.EOF))
+				break
+			}
+		}
+
+		client := databasev1.NewSeriesServiceClient(conn)
+		req := &databasev1.SeriesServiceLookupRequest{
+			Groups: []string{md.Group},
+			Name:   md.Name,
+			Entity: []*modelv1.Tag{{Key: "svc", Value: strTag("svc1")}},
+		}
+		gm.Eventually(func(innerGm gm.Gomega) {
+			resp, errLookup := client.Lookup(context.Background(), req)
+			innerGm.Expect(errLookup).NotTo(gm.HaveOccurred())
+			innerGm.Expect(resp.GetSeries()).To(gm.HaveLen(2))
+			seen := make(map[string]time.Time, 2)
+			for _, s := range resp.GetSeries() {
+				innerGm.Expect(s.GetGroup()).To(gm.Equal(md.Group))
+				innerGm.Expect(s.GetEntity()).To(gm.HaveLen(2))
+				innerGm.Expect(s.GetEntity()[0].GetKey()).To(gm.Equal("svc"))
+				innerGm.Expect(s.GetEntity()[0].GetValue().GetStr().GetValue()).To(gm.Equal("svc1"))
+				innerGm.Expect(s.GetFirstSeen().AsTime()).To(gm.Equal(s.GetLastSeen().AsTime()))
+				seen[s.GetEntity()[1].GetValue().GetStr().GetValue()] = s.GetLastSeen().AsTime()
+			}
+			innerGm.Expect(seen).To(gm.HaveLen(2))
+			innerGm.Expect(seen["inst1"]).To(gm.BeTemporally("==", now))
+			innerGm.Expect(seen["inst2"]).To(gm.BeTemporally("==", now.Add(time.Millisecond)))
+		}, flags.EventuallyTimeout).Should(gm.Succeed())
+
+		req.Limit = 1
+		resp, err := client.Lookup(context.Background(), req)
+		gm.Expect(err).NotTo(gm.HaveOccurred())
+		gm.Expect(resp.GetSeries()).To(gm.HaveLen(1))
+
+		req.Entity = []*modelv1.Tag{{Key: "svc", Value: strTag("svc3")}}
+		resp, err = client.Lookup(context.Background(), req)
+		gm.Expect(err).NotTo(gm.HaveOccurred())
+		gm.Expect(resp.GetSeries()).To(gm.BeEmpty())
+
+		req.Entity = []*modelv1.Tag{{Key: "endpoint", Value: strTag("/")}}
+		_, err = client.Lookup(context.Background(), req)
+		gm.Expect(err).To(gm.HaveOccurred())
+		_, err = client.Lookup(context.Background(), &databasev1.SeriesServiceLookupRequest{Name: md.Name})
+		gm.Expect(status.Code(err)).To(gm.Equal(codes.InvalidArgument))
+	})
+})
@@ -0,0 +1,145 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package integration_other_test
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"time"
+
+	g "github.com/onsi/ginkgo/v2"
+	gm "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gleak"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/pkg/grpchelper"
+	"github.com/apache/skywalking-banyandb/pkg/pool"
+	"github.com/apache/skywalking-banyandb/pkg/test/flags"
+	"github.com/apache/skywalking-banyandb/pkg/test/gmatcher"
+	"github.com/apache/skywalking-banyandb/pkg/test/setup"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+var _ = g.Describe("Stream shard batches", func() {
+	const interval = 500 * time.Millisecond
+	var deferFn func()
+	var conn *grpc.ClientConn
+	var goods []gleak.Goroutine
+	md := &commonv1.Metadata{Name: "access_log", Group: "shard_batch"}
+
+	g.BeforeEach(func() {
+		var addr string
+		addr, _, deferFn = setup.Standalone("--stream-write-shard-batch-interval=" + interval.String())
+		var err error
+		conn, err = grpchelper.Conn(addr, 10*time.Second, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		gm.Expect(err).NotTo(gm.HaveOccurred())
+		ctx := context.Background()
+		_, err = databasev1.NewGroupRegistryServiceClient(conn).Create(ctx, &databasev1.GroupRegistryServiceCreateRequest{
+			Group: &commonv1.Group{
+				Metadata: &commonv1.Metadata{Name: md.Group},
+				Catalog:  commonv1.Catalog_CATALOG_STREAM,
+				ResourceOpts: &commonv1.ResourceOpts{
+					ShardNum:        2,
+					SegmentInterval: &commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_DAY, Num: 1},
+					Ttl:             &commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_DAY, Num: 3},
+				},
+			},
+		})
+		gm.Expect(err).NotTo(gm.HaveOccurred())
+		_, err = databasev1.NewStreamRegistryServiceClient(conn).Create(ctx, &databasev1.StreamRegistryServiceCreateRequest{
+			Stream: &databasev1.Stream{
+				Metadata: md,
+				TagFamilies: []*databasev1.TagFamilySpec{{
+					Name: "default",
+					Tags: []*databasev1.TagSpec{{Name: "svc", Type: databasev1.TagType_TAG_TYPE_STRING}, {Name: "path", Type: databasev1.TagType_TAG_TYPE_STRING}},
+				}},
+				Entity: &databasev1.Entity{TagNames: []string{"svc"}},
+			},
+		})
+		gm.Expect(err).NotTo(gm.HaveOccurred())
+		goods = gleak.Goroutines()
+	})
+	g.AfterEach(func() {
+		gm.Expect(conn.Close()).To(gm.Succeed())
+		deferFn()
+		gm.Eventually(gleak.Goroutines, flags.EventuallyTimeout).ShouldNot(gleak.HaveLeaked(goods))
+		gm.Eventually(pool.AllRefsCount, flags.EventuallyTimeout).Should(gmatcher.HaveZeroRef())
+	})
+	g.It("replies to the elements of an idle stream after the interval", func() {
+		const count = 5
+		client := streamv1.NewStreamServiceClient(conn)
+		writeClient, err := client.Write(context.Background())
+		gm.Expect(err).NotTo(gm.HaveOccurred())
+		replies := make(chan *streamv1.WriteResponse, count)
+		recvErr := make(chan error, 1)
+		go func() {
+			for {
+				resp, errRecv := writeClient.Recv()
+				if errRecv != nil {
+					recvErr <- errRecv
+					return
+				}
+				replies <- resp
+			}
+		}()
+		now := timestamp.NowMilli()
+		for i := 0; i < count; i++ {
+			gm.Expect(writeClient.Send(&streamv1.WriteRequest{
+				Metadata: md,
+				Element: &streamv1.ElementValue{
+					ElementId: "e" + strconv.Itoa(i),
+					Timestamp: timestamppb.New(now.Add(time.Duration(i) * time.Millisecond)),
+					TagFamilies: []*modelv1.TagFamilyForWrite{{
+						Tags: []*modelv1.TagValue{strTag("svc" + strconv.Itoa(i)), strTag("/")},
+					}},
+				},
+				MessageId: uint64(i + 1),
+			})).To(gm.Succeed())
+		}
+
+		// the stream stays open without more elements, the batches are still sent and acknowledged.
+		acked := make(map[uint64]struct{}, count)
+		for range count {
+			var resp *streamv1.WriteResponse
+			gm.Eventually(replies, 10*interval).Should(gm.Receive(&resp))
+			gm.Expect(resp.Status).To(gm.Equal(modelv1.Status_STATUS_SUCCEED.String()))
+			acked[resp.MessageId] = struct{}{}
+		}
+		gm.Expect(acked).To(gm.HaveLen(count))
+		gm.Expect(writeClient.CloseSend()).To(gm.Succeed())
+		gm.Eventually(recvErr, flags.EventuallyTimeout).Should(gm.Receive(gm.Equal(io.EOF)))
+		gm.Expect(replies).To(gm.BeEmpty())
+		gm.Eventually(func(innerGm gm.Gomega) {
+			resp, errQuery := client.Query(context.Background(), &streamv1.QueryRequest{
+				Groups:     []string{md.Group},
+				Name:       md.Name,
+				TimeRange:  &modelv1.TimeRange{Begin: timestamppb.New(now.Add(-time.Hour)), End: timestamppb.New(now.Add(time.Hour))},
+				Projection: &modelv1.TagProjection{TagFamilies: []*modelv1.TagProjection_TagFamily{{Name: "default", Tags: []string{"svc"}}}},
+			})
+			innerGm.Expect(errQuery).NotTo(gm.HaveOccurred())
+			innerGm.Expect(resp.GetElements()).To(gm.HaveLen(count))
+		}, flags.EventuallyTimeout).Should(gm.Succeed())
+	})
+})
@@ -0,0 +1,433 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package integration_other_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gleak"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/pkg/grpchelper"
+	"github.com/apache/skywalking-banyandb/pkg/pool"
+	"github.com/apache/skywalking-banyandb/pkg/test/flags"
+	"github.com/apache/skywalking-banyandb/pkg/test/gmatcher"
+	"github.com/apache/skywalking-banyandb/pkg/test/setup"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+var _ = Describe("Stream with server-generated element IDs", func() {
+	var deferFn func()
+	var conn *grpc.ClientConn
+	var goods []gleak.Goroutine
+	var md *commonv1.Metadata
+
+	BeforeEach(func() {
+		var addr string
+		addr, _, deferFn = setup.Standalone()
+		var err error
+		conn, err = grpchelper.Conn(addr, 10*time.Second, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		Expect(err).NotTo(HaveOccurred())
+		md = &commonv1.Metadata{
+			Name:  "s",
+			Group: "server_id",
+		}
+		_, err = databasev1.NewGroupRegistryServiceClient(conn).Create(context.Background(), &databasev1.GroupRegistryServiceCreateRequest{
+			Group: &commonv1.Group{
+				Metadata: &commonv1.Metadata{Name: md.Group},
+				Catalog:  commonv1.Catalog_CATALOG_STREAM,
+				ResourceOpts: &commonv1.ResourceOpts{
+					ShardNum:        2,
+					SegmentInterval: &commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_DAY, Num: 1},
+					Ttl:             &commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_DAY, Num: 3},
+					ElementIdSource: commonv1.ElementIDSource_ELEMENT_ID_SOURCE_SERVER,
+				},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		streamClient := databasev1.NewStreamRegistryServiceClient(conn)
+		_, err = streamClient.Create(context.Background(), &databasev1.StreamRegistryServiceCreateRequest{
+			Stream: &databasev1.Stream{
+				Metadata: md,
+				TagFamilies: []*databasev1.TagFamilySpec{{
+					Name: "default",
+					Tags: []*databasev1.TagSpec{{Name: "svc", Type: databasev1.TagType_TAG_TYPE_STRING}, {Name: "msg", Type: databasev1.TagType_TAG_TYPE_STRING}},
+				}},
+				Entity: &databasev1.Entity{TagNames: []string{"svc"}},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		resp, err := streamClient.Get(context.Background(), &databasev1.StreamRegistryServiceGetRequest{Metadata: md})
+		Expect(err).NotTo(HaveOccurred())
+		md = resp.GetStream().GetMetadata()
+		goods = gleak.Goroutines()
+	})
+	AfterEach(func() {
+		Expect(conn.Close()).To(Succeed())
+		deferFn()
+		Eventually(gleak.Goroutines, flags.EventuallyTimeout).ShouldNot(gleak.HaveLeaked(goods))
+		Eventually(pool.AllRefsCount, flags.EventuallyTimeout).Should(gmatcher.HaveZeroRef())
+	})
+	It("returns the generated element IDs", func() {
+		const count = 5
+		writeClient, err := streamv1.NewStreamServiceClient(conn).Write(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		now := timestamp.NowMilli()
+		for i := 0; i < count; i++ {
+			// the duplicated element IDs provided by the client are ignored.
+			Expect(writeClient.Send(&streamv1.WriteRequest{
+				Metadata: md,
+				Element: &streamv1.ElementValue{
+					ElementId: "dup",
+					Timestamp: timestamppb.New(now.Add(time.Duration(i) * time.Millisecond)),
+					TagFamilies: []*modelv1.TagFamilyForWrite{{
+						Tags: []*modelv1.TagValue{{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: "svc1"}}}, {Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: "m"}}}},
+					}},
+				},
+				MessageId: uint64(time.Now().UnixNano()),
+			})).To(Succeed())
+		}
+		Expect(writeClient.CloseSend()).To(Succeed())
+		written := make(map[string]struct{})
+		for {
+			resp, errRecv := writeClient.Recv()
+			if errRecv == io.EOF {
+				break
+			}
+			Expect(errRecv).NotTo(HaveOccurred())
+			Expect(resp.Status).To(Equal(modelv1.Status_STATUS_SUCCEED.String()))
+			Expect(resp.ElementId).NotTo(BeEmpty())
+			written[resp.ElementId] = struct{}{}
+		}
+		Expect(written).To(HaveLen(count))
+
+		Eventually(func(g Gomega) {
+			resp, errQuery := streamv1.NewStreamServiceClient(conn).Query(context.Background(), &streamv1.QueryRequest{
+				Groups:     []string{md.Group},
+				Name:       md.Name,
+				TimeRange:  &modelv1.TimeRange{Begin: timestamppb.New(now.Add(-time.Hour)), End: timestamppb.New(now.Add(time.Hour))},
+				Projection: &modelv1.TagProjection{TagFamilies: []*modelv1.TagProjection_TagFamily{{Name: "default", Tags: []string{"svc", "msg"}}}},
+			})
+			g.Expect(errQuery).NotTo(HaveOccurred())
+			queried := make(map[string]struct{})
+			for _, e := range resp.Elements {
+				queried[e.ElementId] = struct{}{}
+			}
+			g.Expect(queried).To(Equal(written))
+		}, flags.EventuallyTimeout).Should(Succeed())
+	})
+	It("fetches the elements by the IDs returned by a previous query", func() {
+		writeClient, err := streamv1.NewStreamServiceClient(conn).Write(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		now := timestamp.NowMilli()
+		msgs := []string{"m0", "m1", "m2", "m3", "m4"}
+		for i, m := range msgs {
+			Expect(writeClient.Send(&streamv1.WriteRequest{
+				Metadata: md,
+				Element: &streamv1.ElementValue{
+					Timestamp: timestamppb.New(now.Add(time.Duration(i) * time.Millisecond)),
+					TagFamilies: []*modelv1.TagFamilyForWrite{{
+						Tags: []*modelv1.TagValue{{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: "svc1"}}}, {Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: m}}}},
+					}},
+				},
+				MessageId: uint64(time.Now().UnixNano()),
+			})).To(Succeed())
+		}
+		Expect(writeClient.CloseSend()).To(Succeed())
+		for {
+			_, errRecv := writeClient.Recv()
+			if errRecv == io.EOF {
+				break
+			}
+			Expect(errRecv).NotTo(HaveOccurred())
+		}
+		timeRange := &modelv1.TimeRange{Begin: timestamppb.New(now.Add(-time.Hour)), End: timestamppb.New(now.Add(time.Hour))}
+
+		// the first phase only projects the lightweight tags.
+		var ids []string
+		Eventually(func(g Gomega) {
+			resp, errQuery := streamv1.NewStreamServiceClient(conn).Query(context.Background(), &streamv1.QueryRequest{
+				Groups:     []string{md.Group},
+				Name:       md.Name,
+				TimeRange:  timeRange,
+				Projection: &modelv1.TagProjection{TagFamilies: []*modelv1.TagProjection_TagFamily{{Name: "default", Tags: []string{"svc"}}}},
+			})
+			g.Expect(errQuery).NotTo(HaveOccurred())
+			g.Expect(resp.Elements).To(HaveLen(len(msgs)))
+			ids = ids[:0]
+			for _, e := range resp.Elements {
+				ids = append(ids, e.ElementId)
+			}
+		}, flags.EventuallyTimeout).Should(Succeed())
+
+		resp, err := streamv1.NewStreamServiceClient(conn).FetchElements(context.Background(), &streamv1.FetchElementsRequest{
+			Groups:     []string{md.Group},
+			Name:       md.Name,
+			TimeRange:  timeRange,
+			ElementIds: []string{ids[1], ids[3]},
+			Projection: &modelv1.TagProjection{TagFamilies: []*modelv1.TagProjection_TagFamily{{Name: "default", Tags: []string{"msg"}}}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		fetched := make(map[string]string, len(resp.Elements))
+		for _, e := range resp.Elements {
+			fetched[e.ElementId] = e.TagFamilies[0].Tags[0].Value.GetStr().GetValue()
+		}
+		Expect(fetched).To(Equal(map[string]string{ids[1]: msgs[1], ids[3]: msgs[3]}))
+
+		_, err = streamv1.NewStreamServiceClient(conn).FetchElements(context.Background(), &streamv1.FetchElementsRequest{
+			Groups:     []string{md.Group},
+			Name:       md.Name,
+			TimeRange:  timeRange,
+			ElementIds: []string{"not-an-id"},
+			Projection: &modelv1.TagProjection{TagFamilies: []*modelv1.TagProjection_TagFamily{{Name: "default", Tags: []string{"msg"}}}},
+		})
+		Expect(err).To(HaveOccurred())
+	})
+	It("looks up the elements by the trace ID", func() {
+		writeClient, err := streamv1.NewStreamServiceClient(conn).Write(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		now := timestamp.NowMilli()
+		traceID := fmt.Sprintf("trace-%d", now.UnixNano())
+		spans := []struct{ traceID, spanID, msg string }{
+			{traceID, "span-0", "t0"},
+			{"other-" + traceID, "span-0", "o0"},
+			{traceID, "span-1", "t1"},
+		}
+		for i, sp := range spans {
+			Expect(writeClient.Send(&streamv1.WriteRequest{
+				Metadata: md,
+				Element: &streamv1.ElementValue{
+					Timestamp: timestamppb.New(now.Add(time.Duration(i) * time.Millisecond)),
+					TagFamilies: []*modelv1.TagFamilyForWrite{{
+						Tags: []*modelv1.TagValue{{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: "svc1"}}}, {Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: sp.msg}}}},
+					}},
+					TraceId: sp.traceID,
+					SpanId:  sp.spanID,
+				},
+				MessageId: uint64(time.Now().UnixNano()),
+			})).To(Succeed())
+		}
+		Expect(writeClient.CloseSend()).To(Succeed())
+		for {
+			_, errRecv := writeClient.Recv()
+			if errRecv == io.EOF {
+				break
+			}
+			Expect(errRecv).NotTo(HaveOccurred())
+		}
+		timeRange := &modelv1.TimeRange{Begin: timestamppb.New(now.Add(-time.Hour)), End: timestamppb.New(now.Add(time.Hour))}
+		projection := &modelv1.TagProjection{TagFamilies: []*modelv1.TagProjection_TagFamily{{Name: "default", Tags: []string{"msg"}}}}
+		msgs := func(elements []*streamv1.Element) []string {
+			result := make([]string, 0, len(elements))
+			for _, e := range elements {
+				result = append(result, e.TagFamilies[0].Tags[0].Value.GetStr().GetValue())
+			}
+			return result
+		}
+		Eventually(func(g Gomega) {
+			resp, errGet := streamv1.NewStreamServiceClient(conn).GetByTraceId(context.Background(), &streamv1.GetByTraceIdRequest{
+				Groups:     []string{md.Group},
+				Name:       md.Name,
+				TimeRange:  timeRange,
+				TraceId:    traceID,
+				Projection: projection,
+			})
+			g.Expect(errGet).NotTo(HaveOccurred())
+			g.Expect(msgs(resp.Elements)).To(Equal([]string{"t0", "t1"}))
+		}, flags.EventuallyTimeout).Should(Succeed())
+
+		resp, err := streamv1.NewStreamServiceClient(conn).GetByTraceId(context.Background(), &streamv1.GetByTraceIdRequest{
+			Groups:     []string{md.Group},
+			Name:       md.Name,
+			TimeRange:  timeRange,
+			TraceId:    traceID,
+			SpanId:     "span-1",
+			Projection: projection,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(msgs(resp.Elements)).To(Equal([]string{"t1"}))
+	})
+	It("drops the writes with the same idempotency key", func() {
+		writeClient, err := streamv1.NewStreamServiceClient(conn).Write(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		now := timestamp.NowMilli()
+		keys := []string{"k1", "k1", "k2", "k1"}
+		for i, k := range keys {
+			Expect(writeClient.Send(&streamv1.WriteRequest{
+				Metadata: md,
+				Element: &streamv1.ElementValue{
+					Timestamp: timestamppb.New(now.Add(time.Duration(i) * time.Millisecond)),
+					TagFamilies: []*modelv1.TagFamilyForWrite{{
+						Tags: []*modelv1.TagValue{{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: "svc1"}}}, {Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: k}}}},
+					}},
+				},
+				MessageId:      uint64(time.Now().UnixNano()),
+				IdempotencyKey: k,
+			})).To(Succeed())
+		}
+		Expect(writeClient.CloseSend()).To(Succeed())
+		for {
+			resp, errRecv := writeClient.Recv()
+			if errRecv == io.EOF {
+				break
+			}
+			Expect(errRecv).NotTo(HaveOccurred())
+			// the duplicates are acked as well.
+			Expect(resp.Status).To(Equal(modelv1.Status_STATUS_SUCCEED.String()))
+		}
+
+		Eventually(func(g Gomega) {
+			resp, errQuery := streamv1.NewStreamServiceClient(conn).Query(context.Background(), &streamv1.QueryRequest{
+				Groups:     []string{md.Group},
+				Name:       md.Name,
+				TimeRange:  &modelv1.TimeRange{Begin: timestamppb.New(now.Add(-time.Hour)), End: timestamppb.New(now.Add(time.Hour))},
+				Projection: &modelv1.TagProjection{TagFamilies: []*modelv1.TagProjection_TagFamily{{Name: "default", Tags: []string{"svc", "msg"}}}},
+			})
+			g.Expect(errQuery).NotTo(HaveOccurred())
+			msgs := make([]string, 0, len(resp.Elements))
+			for _, e := range resp.Elements {
+				msgs = append(msgs, e.TagFamilies[0].Tags[1].Value.GetStr().GetValue())
+			}
+			g.Expect(msgs).To(ConsistOf("k1", "k2"))
+		}, flags.EventuallyTimeout).Should(Succeed())
+	})
+})
+
+var _ = Describe("Stream with oversized elements", func() {
+	var deferFn func()
+	var conn *grpc.ClientConn
+	var goods []gleak.Goroutine
+	md := &commonv1.Metadata{
+		Name:  "s",
+		Group: "oversized",
+	}
+
+	BeforeEach(func() {
+		var addr string
+		addr, _, deferFn = setup.Standalone("--stream-element-chunk-size", "1024", "--stream-max-element-size", "65536")
+		var err error
+		conn, err = grpchelper.Conn(addr, 10*time.Second, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		Expect(err).NotTo(HaveOccurred())
+		_, err = databasev1.NewGroupRegistryServiceClient(conn).Create(context.Background(), &databasev1.GroupRegistryServiceCreateRequest{
+			Group: &commonv1.Group{
+				Metadata: &commonv1.Metadata{Name: md.Group},
+				Catalog:  commonv1.Catalog_CATALOG_STREAM,
+				ResourceOpts: &commonv1.ResourceOpts{
+					ShardNum:        2,
+					SegmentInterval: &commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_DAY, Num: 1},
+					Ttl:             &commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_DAY, Num: 3},
+				},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = databasev1.NewStreamRegistryServiceClient(conn).Create(context.Background(), &databasev1.StreamRegistryServiceCreateRequest{
+			Stream: &databasev1.Stream{
+				Metadata: md,
+				TagFamilies: []*databasev1.TagFamilySpec{{
+					Name: "default",
+					Tags: []*databasev1.TagSpec{{Name: "svc", Type: databasev1.TagType_TAG_TYPE_STRING}, {Name: "data", Type: databasev1.TagType_TAG_TYPE_DATA_BINARY}},
+				}},
+				Entity: &databasev1.Entity{TagNames: []string{"svc"}},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		goods = gleak.Goroutines()
+	})
+	AfterEach(func() {
+		Expect(conn.Close()).To(Succeed())
+		deferFn()
+		Eventually(gleak.Goroutines, flags.EventuallyTimeout).ShouldNot(gleak.HaveLeaked(goods))
+		Eventually(pool.AllRefsCount, flags.EventuallyTimeout).Should(gmatcher.HaveZeroRef())
+	})
+	It("chunks the large elements and rejects the ones exceeding the max element size", func() {
+		writeClient, err := streamv1.NewStreamServiceClient(conn).Write(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		now := timestamp.NowMilli()
+		payloads := map[string][]byte{
+			"small": []byte("small"),
+			"large": make([]byte, 10<<10),
+			"huge":  make([]byte, 100<<10),
+		}
+		for i := range payloads["large"] {
+			payloads["large"][i] = byte(i)
+		}
+		statuses := make(map[uint64]string, len(payloads))
+		ids := make(map[uint64]string, len(payloads))
+		var i int
+		for id, p := range payloads {
+			i++
+			messageID := uint64(i)
+			ids[messageID] = id
+			Expect(writeClient.Send(&streamv1.WriteRequest{
+				Metadata: md,
+				Element: &streamv1.ElementValue{
+					ElementId: id,
+					Timestamp: timestamppb.New(now.Add(time.Duration(i) * time.Millisecond)),
+					TagFamilies: []*modelv1.TagFamilyForWrite{{
+						Tags: []*modelv1.TagValue{{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: id}}}, {Value: &modelv1.TagValue_BinaryData{BinaryData: p}}},
+					}},
+				},
+				MessageId: messageID,
+			})).To(Succeed())
+		}
+		Expect(writeClient.CloseSend()).To(Succeed())
+		for {
+			resp, errRecv := writeClient.Recv()
+			if errRecv == io.EOF {
+				break
+			}
+			Expect(errRecv).NotTo(HaveOccurred())
+			statuses[resp.MessageId] = resp.Status
+		}
+		for messageID, id := range ids {
+			expected := modelv1.Status_STATUS_SUCCEED
+			if id == "huge" {
+				expected = modelv1.Status_STATUS_ELEMENT_TOO_LARGE
+			}
+			Expect(statuses[messageID]).To(Equal(expected.String()), id)
+		}
+
+		Eventually(func(g Gomega) {
+			resp, errQuery := streamv1.NewStreamServiceClient(conn).Query(context.Background(), &streamv1.QueryRequest{
+				Groups:     []string{md.Group},
+				Name:       md.Name,
+				TimeRange:  &modelv1.TimeRange{Begin: timestamppb.New(now.Add(-time.Hour)), End: timestamppb.New(now.Add(time.Hour))},
+				Projection: &modelv1.TagProjection{TagFamilies: []*modelv1.TagProjection_TagFamily{{Name: "default", Tags: []string{"svc", "data"}}}},
+			})
+			g.Expect(errQuery).NotTo(HaveOccurred())
+			written := make(map[string]bool, len(resp.Elements))
+			for _, e := range resp.Elements {
+				id := e.TagFamilies[0].Tags[0].Value.GetStr().GetValue()
+				written[id] = bytes.Equal(payloads[id], e.TagFamilies[0].Tags[1].Value.GetBinaryData())
+			}
+			g.Expect(written).To(Equal(map[string]bool{"small": true, "large": true}))
+		}, flags.EventuallyTimeout).Should(Succeed())
+	})
+})
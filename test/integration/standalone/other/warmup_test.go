@@ -0,0 +1,84 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package integration_other_test
+
+import (
+	"context"
+	"time"
+
+	g "github.com/onsi/ginkgo/v2"
+	gm "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gleak"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/pkg/grpchelper"
+	"github.com/apache/skywalking-banyandb/pkg/pool"
+	"github.com/apache/skywalking-banyandb/pkg/test/flags"
+	"github.com/apache/skywalking-banyandb/pkg/test/gmatcher"
+	"github.com/apache/skywalking-banyandb/pkg/test/setup"
+)
+
+var _ = g.Describe("Warmup", func() {
+	var deferFn func()
+	var conn *grpc.ClientConn
+	var goods []gleak.Goroutine
+
+	g.BeforeEach(func() {
+		var addr string
+		addr, _, deferFn = setup.Standalone()
+		var err error
+		conn, err = grpchelper.Conn(addr, 10*time.Second, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		gm.Expect(err).NotTo(gm.HaveOccurred())
+		goods = gleak.Goroutines()
+	})
+	g.AfterEach(func() {
+		gm.Expect(conn.Close()).To(gm.Succeed())
+		deferFn()
+		gm.Eventually(gleak.Goroutines, flags.EventuallyTimeout).ShouldNot(gleak.HaveLeaked(goods))
+		gm.Eventually(pool.AllRefsCount, flags.EventuallyTimeout).Should(gmatcher.HaveZeroRef())
+	})
+	g.It("reports the readiness of the groups", func() {
+		client := databasev1.NewWarmupServiceClient(conn)
+		resp, err := client.Warmup(context.Background(), &databasev1.WarmupRequest{Groups: []string{"default", "sw_metric", "not-exist"}})
+		gm.Expect(err).NotTo(gm.HaveOccurred())
+		readiness := make(map[string]*databasev1.GroupReadiness)
+		for _, r := range resp.GetGroups() {
+			readiness[r.GetGroup()] = r
+		}
+		gm.Expect(readiness).To(gm.HaveLen(3))
+		gm.Expect(readiness["default"].GetCatalog()).To(gm.Equal(commonv1.Catalog_CATALOG_STREAM))
+		gm.Expect(readiness["default"].GetReady()).To(gm.BeTrue())
+		gm.Expect(readiness["default"].GetResources()).To(gm.BeNumerically(">", 0))
+		gm.Expect(readiness["sw_metric"].GetCatalog()).To(gm.Equal(commonv1.Catalog_CATALOG_MEASURE))
+		gm.Expect(readiness["sw_metric"].GetReady()).To(gm.BeTrue())
+		gm.Expect(readiness["sw_metric"].GetResources()).To(gm.BeNumerically(">", 0))
+		gm.Expect(readiness["not-exist"].GetReady()).To(gm.BeFalse())
+		gm.Expect(readiness["not-exist"].GetError()).NotTo(gm.BeEmpty())
+
+		resp, err = client.Warmup(context.Background(), &databasev1.WarmupRequest{})
+		gm.Expect(err).NotTo(gm.HaveOccurred())
+		gm.Expect(len(resp.GetGroups())).To(gm.BeNumerically(">", 2))
+		for _, r := range resp.GetGroups() {
+			gm.Expect(r.GetError()).To(gm.BeEmpty())
+			gm.Expect(r.GetReady()).To(gm.BeTrue())
+		}
+	})
+})
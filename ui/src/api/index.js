@@ -194,3 +194,40 @@ export function applyProperty(group, name, id, data) {
     data: data,
   });
 }
+
+export function getNodeHealth() {
+  return request({
+    url: `/api/healthz/nodes`,
+    method: 'get',
+  });
+}
+
+export function getShardPlacement() {
+  return request({
+    url: `/api/debug/shard-placement`,
+    method: 'get',
+  });
+}
+
+export function getSlowQueries() {
+  return request({
+    url: `/api/debug/query/slow-queries`,
+    method: 'get',
+  });
+}
+
+export function getStorageUsage(data) {
+  return request({
+    url: `/api/v1/storage/usage`,
+    method: 'post',
+    data: data,
+  });
+}
+
+export function queryBydbql(data) {
+  return request({
+    url: `/api/v1/bydbql/query`,
+    method: 'post',
+    data: data,
+  });
+}
@@ -41,6 +41,11 @@ const router = createRouter({
           name: 'dashboard',
           component: () => import('@/views/Dashboard/index.vue'),
         },
+        {
+          path: '/banyandb/console',
+          name: 'console',
+          component: () => import('@/views/Console/index.vue'),
+        },
         {
           path: '/banyandb/stream',
           name: 'streamHome',